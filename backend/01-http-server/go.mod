@@ -3,9 +3,23 @@ module github.com/e6a5/learning/backend/01-http-server
 go 1.23.4
 
 require (
+	github.com/e6a5/learning/backend/19-feature-flags v0.0.0
+	github.com/e6a5/learning/backend/22-oauth2-oidc v0.0.0
+	github.com/e6a5/learning/backend/pkg v0.0.0
 	github.com/gorilla/mux v1.8.1
 	github.com/joho/godotenv v1.5.1
 	github.com/sirupsen/logrus v1.9.3
 )
 
-require golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+require (
+	github.com/golang-jwt/jwt/v5 v5.0.0 // indirect
+	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+)
+
+// The feature flag SDK, the token verifier and the shared helpers come
+// from the modules next door rather than published versions
+replace (
+	github.com/e6a5/learning/backend/19-feature-flags => ../19-feature-flags
+	github.com/e6a5/learning/backend/22-oauth2-oidc => ../22-oauth2-oidc
+	github.com/e6a5/learning/backend/pkg => ../pkg
+)
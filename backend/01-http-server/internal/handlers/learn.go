@@ -7,8 +7,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/e6a5/learning/backend/01-http-server/basics"
-	"github.com/e6a5/learning/backend/01-http-server/internal/models"
-	"github.com/e6a5/learning/backend/01-http-server/internal/utils"
+	"github.com/e6a5/learning/backend/pkg/response"
 )
 
 // LearnHandler handles learning-related HTTP requests
@@ -21,8 +20,7 @@ func NewLearnHandler() *LearnHandler {
 
 // Home handles GET / - welcome page
 func (h *LearnHandler) Home(w http.ResponseWriter, r *http.Request) {
-	response := models.Response{
-		Success: true,
+	response.JSON(w, http.StatusOK, response.APIResponse{
 		Message: "Welcome to Go HTTP Server Learning Lab!",
 		Data: map[string]interface{}{
 			"server_time": time.Now().Format("2006-01-02 15:04:05"),
@@ -39,30 +37,24 @@ func (h *LearnHandler) Home(w http.ResponseWriter, r *http.Request) {
 				"GET /learn/examples",
 			},
 		},
-	}
-
-	utils.SendJSONResponse(w, http.StatusOK, response)
+	})
 }
 
 // Health handles GET /health - health check
 func (h *LearnHandler) Health(w http.ResponseWriter, r *http.Request) {
-	response := models.Response{
-		Success: true,
+	response.JSON(w, http.StatusOK, response.APIResponse{
 		Message: "Server is healthy",
 		Data: map[string]interface{}{
 			"status":    "UP",
 			"timestamp": time.Now().Format("2006-01-02 15:04:05"),
 			"uptime":    "running",
 		},
-	}
-
-	utils.SendJSONResponse(w, http.StatusOK, response)
+	})
 }
 
 // Basics handles GET /learn/basics - Go basics tutorial
 func (h *LearnHandler) Basics(w http.ResponseWriter, r *http.Request) {
-	response := models.Response{
-		Success: true,
+	response.JSON(w, http.StatusOK, response.APIResponse{
 		Message: "Go Basics Tutorial",
 		Data: map[string]interface{}{
 			"variables": map[string]string{
@@ -86,15 +78,12 @@ func (h *LearnHandler) Basics(w http.ResponseWriter, r *http.Request) {
 				"Variadic functions":     "func printf(format string, args ...interface{})",
 			},
 		},
-	}
-
-	utils.SendJSONResponse(w, http.StatusOK, response)
+	})
 }
 
 // Packages handles GET /learn/packages - Go packages tutorial
 func (h *LearnHandler) Packages(w http.ResponseWriter, r *http.Request) {
-	response := models.Response{
-		Success: true,
+	response.JSON(w, http.StatusOK, response.APIResponse{
 		Message: "Go Packages & Third-party Libraries",
 		Data: map[string]interface{}{
 			"standard_library": map[string]string{
@@ -118,15 +107,12 @@ func (h *LearnHandler) Packages(w http.ResponseWriter, r *http.Request) {
 				"github.com/spf13/cobra":      "CLI application framework",
 			},
 		},
-	}
-
-	utils.SendJSONResponse(w, http.StatusOK, response)
+	})
 }
 
 // Modules handles GET /learn/modules - Go modules tutorial
 func (h *LearnHandler) Modules(w http.ResponseWriter, r *http.Request) {
-	response := models.Response{
-		Success: true,
+	response.JSON(w, http.StatusOK, response.APIResponse{
 		Message: "Go Modules Tutorial",
 		Data: map[string]interface{}{
 			"what_are_modules": "Go modules are collections of Go packages stored in a file tree with a go.mod file at its root",
@@ -160,15 +146,12 @@ func (h *LearnHandler) Modules(w http.ResponseWriter, r *http.Request) {
 				"Commit go.mod and go.sum files",
 			},
 		},
-	}
-
-	utils.SendJSONResponse(w, http.StatusOK, response)
+	})
 }
 
 // Examples handles GET /learn/examples - runs Go examples
 func (h *LearnHandler) Examples(w http.ResponseWriter, r *http.Request) {
-	response := models.Response{
-		Success: true,
+	body := response.APIResponse{
 		Message: "Running Go Fundamentals Examples from local 'basics' package",
 		Data: map[string]interface{}{
 			"note": "This endpoint demonstrates importing and using a local Go package",
@@ -201,5 +184,5 @@ func (h *LearnHandler) Examples(w http.ResponseWriter, r *http.Request) {
 	basics.RunAllExamples()
 	logrus.Info("Go fundamentals examples completed")
 
-	utils.SendJSONResponse(w, http.StatusOK, response)
+	response.JSON(w, http.StatusOK, body)
 }
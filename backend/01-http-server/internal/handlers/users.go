@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/gorilla/mux"
@@ -10,30 +11,40 @@ import (
 
 	"github.com/e6a5/learning/backend/01-http-server/internal/models"
 	"github.com/e6a5/learning/backend/01-http-server/internal/repository"
-	"github.com/e6a5/learning/backend/01-http-server/internal/utils"
+	"github.com/e6a5/learning/backend/pkg/response"
 )
 
+// FeatureFlags answers whether a feature is on for a user. The SDK from
+// 19-feature-flags satisfies it; any type with this method would do.
+type FeatureFlags interface {
+	Enabled(key, userID string, fallback bool) bool
+}
+
 // UserHandler handles user-related HTTP requests
 type UserHandler struct {
-	repo *repository.UserRepository
+	repo  *repository.UserRepository
+	flags FeatureFlags
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(repo *repository.UserRepository) *UserHandler {
-	return &UserHandler{repo: repo}
+func NewUserHandler(repo *repository.UserRepository, flags FeatureFlags) *UserHandler {
+	return &UserHandler{repo: repo, flags: flags}
 }
 
-// GetUsers handles GET /users - returns all users
+// GetUsers handles GET /users - returns all users, newest first for
+// callers who have the users-newest-first feature
 func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	users := h.repo.GetAll()
 
-	response := models.Response{
-		Success: true,
-		Message: "Found " + strconv.Itoa(len(users)) + " users",
-		Data:    users,
+	// The caller says who they are in X-User-ID; the flag decides
+	if h.flags.Enabled("users-newest-first", r.Header.Get("X-User-ID"), false) {
+		slices.Reverse(users)
 	}
 
-	utils.SendJSONResponse(w, http.StatusOK, response)
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: "Found " + strconv.Itoa(len(users)) + " users",
+		Data:    users,
+	})
 }
 
 // CreateUser handles POST /users - creates a new user
@@ -42,21 +53,13 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 
 	// Parse JSON from request body
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response := models.Response{
-			Success: false,
-			Message: "Invalid JSON format",
-		}
-		utils.SendJSONResponse(w, http.StatusBadRequest, response)
+		response.Error(w, http.StatusBadRequest, "Invalid JSON format")
 		return
 	}
 
 	// Validate request
 	if err := req.Validate(); err != nil {
-		response := models.Response{
-			Success: false,
-			Message: err.Error(),
-		}
-		utils.SendJSONResponse(w, http.StatusBadRequest, response)
+		response.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -69,13 +72,10 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		"email":   user.Email,
 	}).Info("New user created")
 
-	response := models.Response{
-		Success: true,
+	response.JSON(w, http.StatusCreated, response.APIResponse{
 		Message: "User created successfully",
 		Data:    user,
-	}
-
-	utils.SendJSONResponse(w, http.StatusCreated, response)
+	})
 }
 
 // GetUser handles GET /users/{id} - returns a specific user
@@ -87,30 +87,19 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	// Convert string to int
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		response := models.Response{
-			Success: false,
-			Message: "Invalid user ID",
-		}
-		utils.SendJSONResponse(w, http.StatusBadRequest, response)
+		response.Error(w, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	// Get user from repository
 	user, err := h.repo.GetByID(id)
 	if err != nil {
-		response := models.Response{
-			Success: false,
-			Message: "User not found",
-		}
-		utils.SendJSONResponse(w, http.StatusNotFound, response)
+		response.Error(w, http.StatusNotFound, "User not found")
 		return
 	}
 
-	response := models.Response{
-		Success: true,
+	response.JSON(w, http.StatusOK, response.APIResponse{
 		Message: "User found",
 		Data:    user,
-	}
-
-	utils.SendJSONResponse(w, http.StatusOK, response)
+	})
 }
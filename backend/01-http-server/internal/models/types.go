@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
 
 // User represents a user in our system
 type User struct {
@@ -10,13 +14,6 @@ type User struct {
 	JoinedAt string `json:"joined_at"`
 }
 
-// Response represents a standard API response
-type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
 // CreateUserRequest represents the request payload for creating a user
 type CreateUserRequest struct {
 	Name  string `json:"name"`
@@ -26,24 +23,14 @@ type CreateUserRequest struct {
 // Validate validates the create user request
 func (r CreateUserRequest) Validate() error {
 	if r.Name == "" {
-		return &ValidationError{Field: "name", Message: "Name is required"}
+		return &validation.Error{Field: "name", Message: "Name is required"}
 	}
 	if r.Email == "" {
-		return &ValidationError{Field: "email", Message: "Email is required"}
+		return &validation.Error{Field: "email", Message: "Email is required"}
 	}
 	return nil
 }
 
-// ValidationError represents a validation error
-type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
-}
-
-func (e *ValidationError) Error() string {
-	return e.Message
-}
-
 // NewUser creates a new user with generated ID and timestamp
 func NewUser(name, email string, nextID int) *User {
 	return &User{
@@ -1,27 +0,0 @@
-package utils
-
-import (
-	"encoding/json"
-	"net/http"
-	"os"
-
-	"github.com/sirupsen/logrus"
-)
-
-// SendJSONResponse sends a JSON response with the given status code and data
-func SendJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		logrus.WithError(err).Error("Failed to encode JSON response")
-	}
-}
-
-// GetEnv gets an environment variable with a default value
-func GetEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
@@ -1,16 +1,22 @@
 package main
 
 import (
+	"context"
+	"log/slog"
 	"net/http"
+	"os"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 
+	"github.com/e6a5/learning/backend/19-feature-flags/sdk"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/resource"
+
 	"github.com/e6a5/learning/backend/01-http-server/internal/handlers"
-	"github.com/e6a5/learning/backend/01-http-server/internal/middleware"
 	"github.com/e6a5/learning/backend/01-http-server/internal/repository"
-	"github.com/e6a5/learning/backend/01-http-server/internal/utils"
+	"github.com/e6a5/learning/backend/pkg/env"
+	"github.com/e6a5/learning/backend/pkg/middleware"
 )
 
 func main() {
@@ -19,12 +25,13 @@ func main() {
 
 	// Initialize dependencies
 	userRepo := repository.NewUserRepository()
-	userHandler := handlers.NewUserHandler(userRepo)
+	flags := setupFeatureFlags()
+	userHandler := handlers.NewUserHandler(userRepo, flags)
 	learnHandler := handlers.NewLearnHandler()
 
 	// Setup HTTP server
-	router := setupRoutes(userHandler, learnHandler)
-	port := utils.GetEnv("PORT", "8080")
+	router := setupRoutes(userHandler, learnHandler, setupAuth())
+	port := env.Get("PORT", "8080")
 
 	logrus.WithFields(logrus.Fields{
 		"port":    port,
@@ -44,12 +51,42 @@ func setupLogging() {
 	logrus.SetLevel(logrus.InfoLevel)
 }
 
-func setupRoutes(userHandler *handlers.UserHandler, learnHandler *handlers.LearnHandler) *mux.Router {
+// setupFeatureFlags connects to the flag service from 19-feature-flags if
+// FLAGS_URL is set. Without it, or while the service is down, every flag
+// answers with the fallback given in the code.
+func setupFeatureFlags() *sdk.Client {
+	url := env.Get("FLAGS_URL", "")
+	flags := sdk.New(url, sdk.WithLogger(logrus.Infof))
+	if url == "" {
+		logrus.Info("FLAGS_URL not set, feature flags use their defaults")
+		return flags
+	}
+	go flags.Run(context.Background())
+	return flags
+}
+
+// setupAuth checks access tokens from the provider in 22-oauth2-oidc if
+// OIDC_ISSUER is set. Without it, routes that ask for a scope are open to
+// everyone, as they always were.
+func setupAuth() func(scopes ...string) func(http.Handler) http.Handler {
+	issuer := env.Get("OIDC_ISSUER", "")
+	if issuer == "" {
+		logrus.Info("OIDC_ISSUER not set, POST /users needs no token")
+		return func(...string) func(http.Handler) http.Handler {
+			return func(next http.Handler) http.Handler { return next }
+		}
+	}
+	return resource.NewVerifier(issuer, env.Get("OIDC_AUDIENCE", "learning-api")).Require
+}
+
+func setupRoutes(userHandler *handlers.UserHandler, learnHandler *handlers.LearnHandler, requireScope func(...string) func(http.Handler) http.Handler) *mux.Router {
 	router := mux.NewRouter()
 
 	// Apply middleware
-	router.Use(middleware.LoggingMiddleware)
-	router.Use(middleware.CORSMiddleware)
+	// Apply middleware: request logs in JSON, like logrus writes, and CORS
+	// for pages that send the X-User-ID header
+	router.Use(middleware.Logging(slog.New(slog.NewJSONHandler(os.Stderr, nil)), slog.LevelInfo))
+	router.Use(middleware.CORS("X-User-ID"))
 
 	// System routes
 	router.HandleFunc("/", learnHandler.Home).Methods("GET")
@@ -57,7 +94,7 @@ func setupRoutes(userHandler *handlers.UserHandler, learnHandler *handlers.Learn
 
 	// User routes
 	router.HandleFunc("/users", userHandler.GetUsers).Methods("GET")
-	router.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
+	router.Handle("/users", requireScope("api:write")(http.HandlerFunc(userHandler.CreateUser))).Methods("POST")
 	router.HandleFunc("/users/{id:[0-9]+}", userHandler.GetUser).Methods("GET")
 
 	// Learning routes
@@ -3,8 +3,13 @@ module github.com/e6a5/learning/backend/02-mysql-crud
 go 1.23.4
 
 require (
+	github.com/e6a5/learning/backend/pkg v0.0.0
 	github.com/go-sql-driver/mysql v1.9.2
 	github.com/gorilla/mux v1.8.1
 )
 
 require filippo.io/edwards25519 v1.1.0 // indirect
+
+// The shared response, validation and middleware packages come from
+// backend/pkg rather than a published version
+replace github.com/e6a5/learning/backend/pkg => ../pkg
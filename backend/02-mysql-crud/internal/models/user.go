@@ -1,5 +1,7 @@
 package models
 
+import "github.com/e6a5/learning/backend/pkg/validation"
+
 // User represents a user in the database
 type User struct {
 	ID    int    `json:"id"`
@@ -22,10 +24,10 @@ type UpdateUserRequest struct {
 // Validate validates the create user request
 func (r CreateUserRequest) Validate() error {
 	if r.Name == "" {
-		return &ValidationError{Field: "name", Message: "Name is required"}
+		return &validation.Error{Field: "name", Message: "Name is required"}
 	}
 	if r.Email == "" {
-		return &ValidationError{Field: "email", Message: "Email is required"}
+		return &validation.Error{Field: "email", Message: "Email is required"}
 	}
 	return nil
 }
@@ -33,20 +35,10 @@ func (r CreateUserRequest) Validate() error {
 // Validate validates the update user request
 func (r UpdateUserRequest) Validate() error {
 	if r.Name == "" {
-		return &ValidationError{Field: "name", Message: "Name is required"}
+		return &validation.Error{Field: "name", Message: "Name is required"}
 	}
 	if r.Email == "" {
-		return &ValidationError{Field: "email", Message: "Email is required"}
+		return &validation.Error{Field: "email", Message: "Email is required"}
 	}
 	return nil
 }
-
-// ValidationError represents a validation error
-type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
-}
-
-func (e *ValidationError) Error() string {
-	return e.Message
-}
@@ -3,6 +3,7 @@ module github.com/e6a5/learning/backend/03-redis-intro
 go 1.23.4
 
 require (
+	github.com/e6a5/learning/backend/pkg v0.0.0
 	github.com/go-redis/redis/v8 v8.11.5
 	github.com/gorilla/mux v1.8.0
 )
@@ -11,3 +12,7 @@ require (
 	github.com/cespare/xxhash/v2 v2.1.2 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 )
+
+// The shared helpers in backend/pkg come from the directory next door
+// rather than a published version
+replace github.com/e6a5/learning/backend/pkg => ../pkg
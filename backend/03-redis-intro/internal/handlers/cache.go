@@ -9,7 +9,7 @@ import (
 
 	"github.com/e6a5/learning/backend/03-redis-intro/internal/models"
 	"github.com/e6a5/learning/backend/03-redis-intro/internal/repository"
-	"github.com/e6a5/learning/backend/03-redis-intro/internal/utils"
+	"github.com/e6a5/learning/backend/pkg/response"
 )
 
 // CacheHandler handles cache-related HTTP requests
@@ -30,14 +30,14 @@ func (h *CacheHandler) GetValue(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Printf("Error getting key %s: %v", key, err)
 		if err.Error() == "key not found: "+key {
-			utils.RespondJSON(w, http.StatusNotFound, models.APIResponse{Error: "Key not found"})
+			response.Error(w, http.StatusNotFound, "Key not found")
 		} else {
-			utils.RespondJSON(w, http.StatusInternalServerError, models.APIResponse{Error: "Internal server error"})
+			response.Error(w, http.StatusInternalServerError, "Internal server error")
 		}
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusOK, models.APIResponse{Data: kv})
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: kv})
 }
 
 // SetValue handles POST /cache - sets a cached value
@@ -45,23 +45,23 @@ func (h *CacheHandler) SetValue(w http.ResponseWriter, r *http.Request) {
 	var req models.SetCacheRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.RespondJSON(w, http.StatusBadRequest, models.APIResponse{Error: "Invalid JSON"})
+		response.Error(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
 	if err := req.Validate(); err != nil {
-		utils.RespondJSON(w, http.StatusBadRequest, models.APIResponse{Error: err.Error()})
+		response.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if err := h.repo.Set(req.Key, req.Value, req.TTL); err != nil {
 		log.Printf("Error setting key %s: %v", req.Key, err)
-		utils.RespondJSON(w, http.StatusInternalServerError, models.APIResponse{Error: "Internal server error"})
+		response.Error(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
 	kv := models.NewKeyValue(req.Key, req.Value, req.TTL)
-	utils.RespondJSON(w, http.StatusCreated, models.APIResponse{
+	response.JSON(w, http.StatusCreated, response.APIResponse{
 		Message: "Key set successfully",
 		Data:    kv,
 	})
@@ -74,14 +74,14 @@ func (h *CacheHandler) DeleteValue(w http.ResponseWriter, r *http.Request) {
 	if err := h.repo.Delete(key); err != nil {
 		log.Printf("Error deleting key %s: %v", key, err)
 		if err.Error() == "key not found: "+key {
-			utils.RespondJSON(w, http.StatusNotFound, models.APIResponse{Error: "Key not found"})
+			response.Error(w, http.StatusNotFound, "Key not found")
 		} else {
-			utils.RespondJSON(w, http.StatusInternalServerError, models.APIResponse{Error: "Internal server error"})
+			response.Error(w, http.StatusInternalServerError, "Internal server error")
 		}
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusOK, models.APIResponse{Message: "Key deleted successfully"})
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Key deleted successfully"})
 }
 
 // GetAllKeys handles GET /cache - retrieves all keys
@@ -91,11 +91,11 @@ func (h *CacheHandler) GetAllKeys(w http.ResponseWriter, r *http.Request) {
 	keys, err := h.repo.GetAllKeys(pattern)
 	if err != nil {
 		log.Printf("Error getting all keys: %v", err)
-		utils.RespondJSON(w, http.StatusInternalServerError, models.APIResponse{Error: "Internal server error"})
+		response.Error(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusOK, models.APIResponse{
+	response.JSON(w, http.StatusOK, response.APIResponse{
 		Data: map[string]interface{}{
 			"keys":  keys,
 			"count": len(keys),
@@ -110,11 +110,11 @@ func (h *CacheHandler) GetTTL(w http.ResponseWriter, r *http.Request) {
 	ttl, err := h.repo.GetTTL(key)
 	if err != nil {
 		log.Printf("Error getting TTL for key %s: %v", key, err)
-		utils.RespondJSON(w, http.StatusInternalServerError, models.APIResponse{Error: "Internal server error"})
+		response.Error(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusOK, models.APIResponse{
+	response.JSON(w, http.StatusOK, response.APIResponse{
 		Data: map[string]interface{}{
 			"key": key,
 			"ttl": ttl.Seconds(),
@@ -128,37 +128,37 @@ func (h *CacheHandler) SetExpire(w http.ResponseWriter, r *http.Request) {
 	var req models.SetExpireRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.RespondJSON(w, http.StatusBadRequest, models.APIResponse{Error: "Invalid JSON"})
+		response.Error(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
 	if err := req.Validate(); err != nil {
-		utils.RespondJSON(w, http.StatusBadRequest, models.APIResponse{Error: err.Error()})
+		response.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if err := h.repo.SetExpire(key, req.TTL); err != nil {
 		log.Printf("Error setting expire for key %s: %v", key, err)
 		if err.Error() == "key not found: "+key {
-			utils.RespondJSON(w, http.StatusNotFound, models.APIResponse{Error: "Key not found"})
+			response.Error(w, http.StatusNotFound, "Key not found")
 		} else {
-			utils.RespondJSON(w, http.StatusInternalServerError, models.APIResponse{Error: "Internal server error"})
+			response.Error(w, http.StatusInternalServerError, "Internal server error")
 		}
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusOK, models.APIResponse{Message: "Expiration set successfully"})
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Expiration set successfully"})
 }
 
 // HealthCheck handles GET /health - checks Redis connectivity
 func (h *CacheHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	if err := h.repo.Ping(); err != nil {
 		log.Printf("Health check failed: %v", err)
-		utils.RespondJSON(w, http.StatusServiceUnavailable, models.APIResponse{Error: "Redis unavailable"})
+		response.Error(w, http.StatusServiceUnavailable, "Redis unavailable")
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusOK, models.APIResponse{
+	response.JSON(w, http.StatusOK, response.APIResponse{
 		Message: "Service healthy",
 		Data:    map[string]string{"redis": "connected"},
 	})
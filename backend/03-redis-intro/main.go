@@ -9,7 +9,7 @@ import (
 
 	"github.com/e6a5/learning/backend/03-redis-intro/internal/handlers"
 	"github.com/e6a5/learning/backend/03-redis-intro/internal/repository"
-	"github.com/e6a5/learning/backend/03-redis-intro/internal/utils"
+	"github.com/e6a5/learning/backend/pkg/env"
 )
 
 func main() {
@@ -25,15 +25,15 @@ func main() {
 
 	// Setup HTTP server
 	router := setupRoutes(cacheHandler)
-	port := utils.GetEnv("PORT", "8080")
+	port := env.Get("PORT", "8080")
 
 	log.Println("🚀 Redis Server running at http://localhost:" + port)
 	log.Fatal(http.ListenAndServe(":"+port, router))
 }
 
 func initializeRedis() (*redis.Client, error) {
-	addr := utils.GetEnv("REDIS_ADDR", "redis:6379")
-	password := utils.GetEnv("REDIS_PASSWORD", "")
+	addr := env.Get("REDIS_ADDR", "redis:6379")
+	password := env.Get("REDIS_PASSWORD", "")
 	db := 0 // Default database
 
 	client := redis.NewClient(&redis.Options{
@@ -3,6 +3,7 @@ module github.com/e6a5/learning/backend/04-grpc-basics
 go 1.23.4
 
 require (
+	github.com/e6a5/learning/backend/pkg v0.0.0
 	google.golang.org/grpc v1.65.0
 	google.golang.org/protobuf v1.34.2
 )
@@ -13,3 +14,7 @@ require (
 	golang.org/x/text v0.15.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240528184218-531527333157 // indirect
 )
+
+// The shared response, validation and middleware packages come from
+// backend/pkg rather than a published version
+replace github.com/e6a5/learning/backend/pkg => ../pkg
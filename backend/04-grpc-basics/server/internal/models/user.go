@@ -1,11 +1,11 @@
 package models
 
 import (
-	"fmt"
 	"strings"
 	"time"
 
 	pb "github.com/e6a5/learning/backend/04-grpc-basics/proto"
+	"github.com/e6a5/learning/backend/pkg/validation"
 )
 
 // CreateUserRequest represents the validated user creation request
@@ -20,33 +20,23 @@ type ListUsersRequest struct {
 	Limit int32
 }
 
-// ValidationError represents a validation error
-type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
-}
-
-func (e *ValidationError) Error() string {
-	return fmt.Sprintf("%s: %s", e.Field, e.Message)
-}
-
 // Validate validates user creation input
 func (r CreateUserRequest) Validate() error {
 	if r.Name == "" {
-		return &ValidationError{Field: "name", Message: "Name is required"}
+		return &validation.Error{Field: "name", Message: "Name is required"}
 	}
 	if len(r.Name) < 2 {
-		return &ValidationError{Field: "name", Message: "Name must be at least 2 characters"}
+		return &validation.Error{Field: "name", Message: "Name must be at least 2 characters"}
 	}
 	if len(r.Name) > 100 {
-		return &ValidationError{Field: "name", Message: "Name must be less than 100 characters"}
+		return &validation.Error{Field: "name", Message: "Name must be less than 100 characters"}
 	}
 
 	if r.Email == "" {
-		return &ValidationError{Field: "email", Message: "Email is required"}
+		return &validation.Error{Field: "email", Message: "Email is required"}
 	}
 	if !isValidEmail(r.Email) {
-		return &ValidationError{Field: "email", Message: "Email format is invalid"}
+		return &validation.Error{Field: "email", Message: "Email format is invalid"}
 	}
 
 	return nil
@@ -55,13 +45,13 @@ func (r CreateUserRequest) Validate() error {
 // Validate validates list users request
 func (r ListUsersRequest) Validate() error {
 	if r.Page < 0 {
-		return &ValidationError{Field: "page", Message: "Page must be non-negative"}
+		return &validation.Error{Field: "page", Message: "Page must be non-negative"}
 	}
 	if r.Limit < 0 {
-		return &ValidationError{Field: "limit", Message: "Limit must be non-negative"}
+		return &validation.Error{Field: "limit", Message: "Limit must be non-negative"}
 	}
 	if r.Limit > 1000 {
-		return &ValidationError{Field: "limit", Message: "Limit must be less than 1000"}
+		return &validation.Error{Field: "limit", Message: "Limit must be less than 1000"}
 	}
 
 	return nil
@@ -0,0 +1,146 @@
+// Package client is a consumer of the users API. It declares its own request
+// and response shapes instead of importing models: what it expects of the
+// server is written down in Contract, and checked against the real handlers
+// by the provider's tests.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// User is a user as the client reads it
+type User struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	JoinedAt string `json:"joined_at"`
+}
+
+// CreateUserRequest is the body of POST /users
+type CreateUserRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// ErrNotFound and ErrConflict match an *APIError with status 404 and 409
+// through errors.Is
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+)
+
+// APIError is a non-2xx answer from the server
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"error"`
+	Field      string `json:"field,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("users API: %d: %s (%s)", e.StatusCode, e.Message, e.Field)
+	}
+	return fmt.Sprintf("users API: %d: %s", e.StatusCode, e.Message)
+}
+
+// Is lets callers test for ErrNotFound and ErrConflict
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	}
+	return false
+}
+
+// Client calls the users API at a base URL
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New creates a client for the API at baseURL. A nil httpClient means
+// http.DefaultClient.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: httpClient}
+}
+
+// CreateUser registers a user and returns it as the server stored it
+func (c *Client) CreateUser(ctx context.Context, req CreateUserRequest) (User, error) {
+	var user User
+	err := c.do(ctx, http.MethodPost, "/users", req, http.StatusCreated, &user)
+	return user, err
+}
+
+// GetUser returns the user with the given ID
+func (c *Client) GetUser(ctx context.Context, id int) (User, error) {
+	var user User
+	err := c.do(ctx, http.MethodGet, "/users/"+strconv.Itoa(id), nil, http.StatusOK, &user)
+	return user, err
+}
+
+// ListUsers returns every user ordered by ID
+func (c *Client) ListUsers(ctx context.Context) ([]User, error) {
+	var users []User
+	err := c.do(ctx, http.MethodGet, "/users", nil, http.StatusOK, &users)
+	return users, err
+}
+
+// DeleteUser removes the user with the given ID
+func (c *Client) DeleteUser(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, "/users/"+strconv.Itoa(id), nil, http.StatusNoContent, nil)
+}
+
+// do sends body as JSON when non-nil and decodes a wantStatus answer into
+// out when non-nil. Any other status becomes an *APIError.
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, wantStatus int, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("building request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != wantStatus {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(apiErr); err != nil {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding %s %s: %w", method, path, err)
+	}
+	return nil
+}
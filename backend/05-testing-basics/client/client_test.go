@@ -0,0 +1,88 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/client"
+	"github.com/e6a5/learning/backend/05-testing-basics/client/contracttest"
+)
+
+// The fake honours the same contract as the real handlers...
+func TestFakeServer_Contract(t *testing.T) {
+	contracttest.Verify(t, client.Contract, func(t *testing.T, given []client.User) http.Handler {
+		return client.NewFakeServer(given...)
+	})
+}
+
+// ...so the client can be tested against it, over real HTTP, without
+// starting the real server or its storage
+func newClient(t *testing.T, users ...client.User) *client.Client {
+	t.Helper()
+
+	server := httptest.NewServer(client.NewFakeServer(users...))
+	t.Cleanup(server.Close)
+	return client.New(server.URL, server.Client())
+}
+
+var alice = client.User{ID: 1, Name: "Alice", Email: "alice@example.com", JoinedAt: "2024-01-15 09:30:00"}
+
+func TestClient_CreateUser(t *testing.T) {
+	c := newClient(t, alice)
+
+	user, err := c.CreateUser(context.Background(), client.CreateUserRequest{Name: "Bob", Email: "Bob@Example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, user.ID)
+	assert.Equal(t, "bob@example.com", user.Email)
+	assert.NotEmpty(t, user.JoinedAt)
+
+	_, err = c.CreateUser(context.Background(), client.CreateUserRequest{Name: "Alice", Email: "alice@example.com"})
+	assert.ErrorIs(t, err, client.ErrConflict)
+
+	_, err = c.CreateUser(context.Background(), client.CreateUserRequest{Name: "Carol", Email: "carol"})
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, client.APIError{StatusCode: http.StatusBadRequest, Message: "email format is invalid", Field: "email"}, *apiErr)
+}
+
+func TestClient_GetListDelete(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, alice)
+
+	user, err := c.GetUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, alice, user)
+
+	users, err := c.ListUsers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []client.User{alice}, users)
+
+	require.NoError(t, c.DeleteUser(ctx, 1))
+	assert.ErrorIs(t, c.DeleteUser(ctx, 1), client.ErrNotFound)
+
+	_, err = c.GetUser(ctx, 1)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+
+	users, err = c.ListUsers(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestClient_NonJSONError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := client.New(server.URL, nil).ListUsers(context.Background())
+
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Equal(t, "Bad Gateway", apiErr.Message)
+}
@@ -0,0 +1,189 @@
+package client
+
+import "net/http"
+
+// Interaction is one request the client sends and the answer it relies on.
+// Given is the provider state: the users that exist beforehand, with IDs 1
+// to len(Given), so the next user created gets ID len(Given)+1.
+type Interaction struct {
+	Name     string
+	Given    []User
+	Request  ContractRequest
+	Response ContractResponse
+}
+
+// ContractRequest is sent as is; a non-nil Body is encoded as JSON
+type ContractRequest struct {
+	Method string
+	Path   string
+	Body   interface{}
+}
+
+// ContractResponse is what the client needs of the answer. Headers and the
+// fields of Body must be present with these values; the provider may send
+// more, which the client ignores. Fields named in AnyValue, such as a
+// timestamp the provider picks, only need to be present with the same JSON
+// type. A nil Body means no body is expected.
+type ContractResponse struct {
+	Status   int
+	Headers  map[string]string
+	Body     interface{}
+	AnyValue []string
+}
+
+var (
+	contractAlice = User{ID: 1, Name: "Alice", Email: "alice@example.com", JoinedAt: "2024-01-15 09:30:00"}
+	contractBob   = User{ID: 2, Name: "Bob", Email: "bob@example.com", JoinedAt: "2024-01-16 10:00:00"}
+)
+
+// Contract lists every interaction the client depends on. The fake server
+// and the real handlers are both verified against it, which is what lets
+// the client's own tests run on the fake.
+var Contract = []Interaction{
+	{
+		Name: "create a user",
+		Request: ContractRequest{
+			Method: http.MethodPost,
+			Path:   "/users",
+			Body:   CreateUserRequest{Name: " Alice ", Email: "Alice@Example.com"},
+		},
+		Response: ContractResponse{
+			Status:   http.StatusCreated,
+			Headers:  map[string]string{"Content-Type": "application/json", "Location": "/users/1"},
+			Body:     User{ID: 1, Name: "Alice", Email: "alice@example.com", JoinedAt: "2024-01-15 09:30:00"},
+			AnyValue: []string{"joined_at"},
+		},
+	},
+	{
+		Name:  "create after existing users takes the next ID",
+		Given: []User{contractAlice},
+		Request: ContractRequest{
+			Method: http.MethodPost,
+			Path:   "/users",
+			Body:   CreateUserRequest{Name: "Bob", Email: "bob@example.com"},
+		},
+		Response: ContractResponse{
+			Status:   http.StatusCreated,
+			Headers:  map[string]string{"Location": "/users/2"},
+			Body:     User{ID: 2, Name: "Bob", Email: "bob@example.com", JoinedAt: "2024-01-15 09:30:00"},
+			AnyValue: []string{"joined_at"},
+		},
+	},
+	{
+		Name: "create with an invalid email",
+		Request: ContractRequest{
+			Method: http.MethodPost,
+			Path:   "/users",
+			Body:   CreateUserRequest{Name: "Alice", Email: "alice"},
+		},
+		Response: ContractResponse{
+			Status: http.StatusBadRequest,
+			Body:   APIError{Message: "email format is invalid", Field: "email"},
+		},
+	},
+	{
+		Name: "create without a name",
+		Request: ContractRequest{
+			Method: http.MethodPost,
+			Path:   "/users",
+			Body:   CreateUserRequest{Email: "alice@example.com"},
+		},
+		Response: ContractResponse{
+			Status: http.StatusBadRequest,
+			Body:   APIError{Message: "name is required", Field: "name"},
+		},
+	},
+	{
+		Name:  "create with a taken email",
+		Given: []User{contractAlice},
+		Request: ContractRequest{
+			Method: http.MethodPost,
+			Path:   "/users",
+			Body:   CreateUserRequest{Name: "Another Alice", Email: "ALICE@example.com"},
+		},
+		Response: ContractResponse{
+			Status: http.StatusConflict,
+			Body:   APIError{Message: "Email already registered"},
+		},
+	},
+	{
+		Name:  "get a user",
+		Given: []User{contractAlice, contractBob},
+		Request: ContractRequest{
+			Method: http.MethodGet,
+			Path:   "/users/2",
+		},
+		Response: ContractResponse{
+			Status:  http.StatusOK,
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Body:    contractBob,
+		},
+	},
+	{
+		Name: "get a missing user",
+		Request: ContractRequest{
+			Method: http.MethodGet,
+			Path:   "/users/1",
+		},
+		Response: ContractResponse{
+			Status: http.StatusNotFound,
+			Body:   APIError{Message: "User not found"},
+		},
+	},
+	{
+		Name: "get with an invalid ID",
+		Request: ContractRequest{
+			Method: http.MethodGet,
+			Path:   "/users/abc",
+		},
+		Response: ContractResponse{
+			Status: http.StatusBadRequest,
+			Body:   APIError{Message: "User ID must be a positive integer"},
+		},
+	},
+	{
+		Name:  "list users",
+		Given: []User{contractAlice, contractBob},
+		Request: ContractRequest{
+			Method: http.MethodGet,
+			Path:   "/users",
+		},
+		Response: ContractResponse{
+			Status: http.StatusOK,
+			Body:   []User{contractAlice, contractBob},
+		},
+	},
+	{
+		Name: "list with no users is an empty array",
+		Request: ContractRequest{
+			Method: http.MethodGet,
+			Path:   "/users",
+		},
+		Response: ContractResponse{
+			Status: http.StatusOK,
+			Body:   []User{},
+		},
+	},
+	{
+		Name:  "delete a user",
+		Given: []User{contractAlice},
+		Request: ContractRequest{
+			Method: http.MethodDelete,
+			Path:   "/users/1",
+		},
+		Response: ContractResponse{
+			Status: http.StatusNoContent,
+		},
+	},
+	{
+		Name: "delete a missing user",
+		Request: ContractRequest{
+			Method: http.MethodDelete,
+			Path:   "/users/1",
+		},
+		Response: ContractResponse{
+			Status: http.StatusNotFound,
+			Body:   APIError{Message: "User not found"},
+		},
+	},
+}
@@ -0,0 +1,175 @@
+// Package contracttest replays client.Contract against any implementation of
+// the users API. The provider's tests run it on the real handlers, the
+// client's tests on client.FakeServer; passing both is what makes the fake
+// a safe stand-in.
+package contracttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/client"
+)
+
+// Provider returns a fresh server holding the given users, with their IDs.
+// It is called once per interaction, so no interaction sees another's state.
+type Provider func(t *testing.T, given []client.User) http.Handler
+
+// Verify runs every interaction as a subtest against a server from newProvider
+func Verify(t *testing.T, contract []client.Interaction, newProvider Provider) {
+	t.Helper()
+
+	for _, interaction := range contract {
+		t.Run(interaction.Name, func(t *testing.T) {
+			handler := newProvider(t, interaction.Given)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, newRequest(t, interaction.Request))
+
+			want := interaction.Response
+			if rec.Code != want.Status {
+				t.Errorf("status = %d, contract wants %d (body %q)", rec.Code, want.Status, rec.Body.String())
+			}
+			for name, value := range want.Headers {
+				if got := rec.Header().Get(name); got != value {
+					t.Errorf("header %s = %q, contract wants %q", name, got, value)
+				}
+			}
+
+			if want.Body == nil {
+				if rec.Body.Len() > 0 {
+					t.Errorf("contract wants no body, got %q", rec.Body.String())
+				}
+				return
+			}
+			mismatches, err := diff(want.Body, rec.Body.Bytes(), want.AnyValue)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range mismatches {
+				t.Errorf("body %s", m)
+			}
+		})
+	}
+}
+
+func newRequest(t *testing.T, r client.ContractRequest) *http.Request {
+	t.Helper()
+
+	var body io.Reader
+	if r.Body != nil {
+		encoded, err := json.Marshal(r.Body)
+		if err != nil {
+			t.Fatalf("encoding contract request: %v", err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(r.Method, r.Path, body)
+	if r.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+// diff compares a response body with the contract's expected value and
+// describes every difference the client would notice: missing fields,
+// changed values and changed JSON types. Extra fields are not differences.
+func diff(want interface{}, body []byte, anyValue []string) ([]string, error) {
+	encoded, err := json.Marshal(want)
+	if err != nil {
+		return nil, fmt.Errorf("encoding contract body: %w", err)
+	}
+	var wantJSON, gotJSON interface{}
+	if err := json.Unmarshal(encoded, &wantJSON); err != nil {
+		return nil, fmt.Errorf("decoding contract body: %w", err)
+	}
+	if err := json.Unmarshal(body, &gotJSON); err != nil {
+		return []string{fmt.Sprintf("is not JSON: %q", body)}, nil
+	}
+
+	loose := make(map[string]bool, len(anyValue))
+	for _, field := range anyValue {
+		loose[field] = true
+	}
+	return compare("$", wantJSON, gotJSON, loose), nil
+}
+
+func compare(path string, want, got interface{}, loose map[string]bool) []string {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: got %s, contract wants an object", path, jsonType(got))}
+		}
+		keys := make([]string, 0, len(w))
+		for key := range w {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var mismatches []string
+		for _, key := range keys {
+			fieldPath := path + "." + key
+			gv, present := g[key]
+			switch {
+			case !present:
+				mismatches = append(mismatches, fieldPath+": missing")
+			case loose[key]:
+				if jsonType(gv) != jsonType(w[key]) {
+					mismatches = append(mismatches, fmt.Sprintf("%s: got %s, contract wants %s", fieldPath, jsonType(gv), jsonType(w[key])))
+				}
+			default:
+				mismatches = append(mismatches, compare(fieldPath, w[key], gv, loose)...)
+			}
+		}
+		return mismatches
+
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: got %s, contract wants an array", path, jsonType(got))}
+		}
+		if len(g) != len(w) {
+			return []string{fmt.Sprintf("%s: got %d elements, contract wants %d", path, len(g), len(w))}
+		}
+		var mismatches []string
+		for i := range w {
+			mismatches = append(mismatches, compare(fmt.Sprintf("%s[%d]", path, i), w[i], g[i], loose)...)
+		}
+		return mismatches
+
+	default:
+		if jsonType(got) != jsonType(want) {
+			return []string{fmt.Sprintf("%s: got %s, contract wants %s", path, jsonType(got), jsonType(want))}
+		}
+		if !reflect.DeepEqual(want, got) {
+			return []string{fmt.Sprintf("%s: got %v, contract wants %v", path, got, want)}
+		}
+		return nil
+	}
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	case string:
+		return "a string"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", v)
+}
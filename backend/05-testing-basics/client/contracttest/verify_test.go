@@ -0,0 +1,92 @@
+package contracttest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/client"
+)
+
+// A verifier that never fails proves nothing, so diff is tested on the
+// breaking changes it must catch and the compatible ones it must allow
+func TestDiff(t *testing.T) {
+	alice := client.User{ID: 1, Name: "Alice", Email: "alice@example.com", JoinedAt: "2024-01-15 09:30:00"}
+
+	tests := []struct {
+		name     string
+		want     interface{}
+		body     string
+		anyValue []string
+		wantDiff []string
+	}{
+		{
+			name: "identical",
+			want: alice,
+			body: `{"id": 1, "name": "Alice", "email": "alice@example.com", "joined_at": "2024-01-15 09:30:00"}`,
+		},
+		{
+			name: "extra fields are compatible",
+			want: alice,
+			body: `{"id": 1, "name": "Alice", "email": "alice@example.com", "joined_at": "2024-01-15 09:30:00", "admin": false}`,
+		},
+		{
+			name:     "renamed field",
+			want:     alice,
+			body:     `{"id": 1, "name": "Alice", "mail": "alice@example.com", "joined_at": "2024-01-15 09:30:00"}`,
+			wantDiff: []string{"$.email: missing"},
+		},
+		{
+			name:     "changed type",
+			want:     alice,
+			body:     `{"id": "1", "name": "Alice", "email": "alice@example.com", "joined_at": "2024-01-15 09:30:00"}`,
+			wantDiff: []string{"$.id: got a string, contract wants a number"},
+		},
+		{
+			name:     "any value accepts another timestamp",
+			want:     alice,
+			body:     `{"id": 1, "name": "Alice", "email": "alice@example.com", "joined_at": "2030-06-01 00:00:00"}`,
+			anyValue: []string{"joined_at"},
+		},
+		{
+			name:     "any value still checks the type",
+			want:     alice,
+			body:     `{"id": 1, "name": "Alice", "email": "alice@example.com", "joined_at": 1705311000}`,
+			anyValue: []string{"joined_at"},
+			wantDiff: []string{"$.joined_at: got a number, contract wants a string"},
+		},
+		{
+			name:     "null instead of an empty array",
+			want:     []client.User{},
+			body:     `null`,
+			wantDiff: []string{"$: got null, contract wants an array"},
+		},
+		{
+			name:     "missing element",
+			want:     []client.User{alice, alice},
+			body:     `[{"id": 1, "name": "Alice", "email": "alice@example.com", "joined_at": "2024-01-15 09:30:00"}]`,
+			wantDiff: []string{"$: got 1 elements, contract wants 2"},
+		},
+		{
+			name:     "omitted optional field is not expected",
+			want:     client.APIError{Message: "User not found"},
+			body:     `{"error": "user not found"}`,
+			wantDiff: []string{"$.error: got user not found, contract wants User not found"},
+		},
+		{
+			name:     "not JSON",
+			want:     alice,
+			body:     `<html>`,
+			wantDiff: []string{`is not JSON: "<html>"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diff(tt.want, []byte(tt.body), tt.anyValue)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDiff, got)
+		})
+	}
+}
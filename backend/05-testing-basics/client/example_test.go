@@ -0,0 +1,52 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/client"
+)
+
+// The fake server stands in for the real API, so this runs with no server
+// started; against the real one only the URL changes
+func ExampleClient() {
+	server := httptest.NewServer(client.NewFakeServer())
+	defer server.Close()
+
+	ctx := context.Background()
+	c := client.New(server.URL, nil)
+
+	user, err := c.CreateUser(ctx, client.CreateUserRequest{Name: "Alice", Email: "Alice@Example.com"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(user.ID, user.Email)
+
+	_, err = c.CreateUser(ctx, client.CreateUserRequest{Name: "Alice", Email: "alice@example.com"})
+	fmt.Println(err)
+	fmt.Println(errors.Is(err, client.ErrConflict))
+
+	_, err = c.GetUser(ctx, 42)
+	fmt.Println(errors.Is(err, client.ErrNotFound))
+	// Output:
+	// 1 alice@example.com
+	// users API: 409: Email already registered
+	// true
+	// true
+}
+
+func ExampleAPIError() {
+	err := error(&client.APIError{StatusCode: 400, Message: "email format is invalid", Field: "email"})
+
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		fmt.Println(apiErr.Field)
+	}
+	fmt.Println(err)
+	// Output:
+	// email
+	// users API: 400: email format is invalid (email)
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeServer is an in-memory users API for testing code that uses Client,
+// without the real server's packages. It keeps only the behaviour Contract
+// describes, and is verified against it like the real handlers are.
+type FakeServer struct {
+	mux *http.ServeMux
+
+	mu     sync.Mutex
+	users  map[int]User
+	nextID int
+}
+
+// NewFakeServer creates a fake already holding users, which keep their IDs
+func NewFakeServer(users ...User) *FakeServer {
+	f := &FakeServer{mux: http.NewServeMux(), users: make(map[int]User), nextID: 1}
+	for _, user := range users {
+		f.users[user.ID] = user
+		if user.ID >= f.nextID {
+			f.nextID = user.ID + 1
+		}
+	}
+
+	f.mux.HandleFunc("GET /users", f.list)
+	f.mux.HandleFunc("POST /users", f.create)
+	f.mux.HandleFunc("GET /users/{id}", f.get)
+	f.mux.HandleFunc("DELETE /users/{id}", f.delete)
+	return f
+}
+
+// ServeHTTP serves the users API
+func (f *FakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mux.ServeHTTP(w, r)
+}
+
+func (f *FakeServer) list(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	users := make([]User, 0, len(f.users))
+	for _, user := range f.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	fakeJSON(w, http.StatusOK, users)
+}
+
+func (f *FakeServer) create(w http.ResponseWriter, r *http.Request) {
+	var req CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		fakeJSON(w, http.StatusBadRequest, APIError{Message: "Invalid JSON format"})
+		return
+	}
+
+	// A rough stand-in for the server's validation: enough for the cases
+	// the contract names, not a copy of its rules
+	name := strings.TrimSpace(req.Name)
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	switch {
+	case name == "":
+		fakeJSON(w, http.StatusBadRequest, APIError{Message: "name is required", Field: "name"})
+		return
+	case !strings.Contains(email, "@") || !strings.Contains(email, "."):
+		fakeJSON(w, http.StatusBadRequest, APIError{Message: "email format is invalid", Field: "email"})
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, existing := range f.users {
+		if existing.Email == email {
+			fakeJSON(w, http.StatusConflict, APIError{Message: "Email already registered"})
+			return
+		}
+	}
+
+	user := User{
+		ID:       f.nextID,
+		Name:     name,
+		Email:    email,
+		JoinedAt: time.Now().UTC().Format("2006-01-02 15:04:05"),
+	}
+	f.users[user.ID] = user
+	f.nextID++
+
+	w.Header().Set("Location", "/users/"+strconv.Itoa(user.ID))
+	fakeJSON(w, http.StatusCreated, user)
+}
+
+func (f *FakeServer) get(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakeUserID(w, r)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, found := f.users[id]
+	if !found {
+		fakeJSON(w, http.StatusNotFound, APIError{Message: "User not found"})
+		return
+	}
+	fakeJSON(w, http.StatusOK, user)
+}
+
+func (f *FakeServer) delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakeUserID(w, r)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, found := f.users[id]; !found {
+		fakeJSON(w, http.StatusNotFound, APIError{Message: "User not found"})
+		return
+	}
+	delete(f.users, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func fakeUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		fakeJSON(w, http.StatusBadRequest, APIError{Message: "User ID must be a positive integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+func fakeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
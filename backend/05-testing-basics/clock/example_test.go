@@ -0,0 +1,23 @@
+package clock_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/clock"
+)
+
+func ExampleFake() {
+	clk := clock.NewFake(time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC))
+	fmt.Println(clk.Now())
+
+	clk.Advance(36 * time.Hour)
+	fmt.Println(clk.Now())
+
+	clk.Set(time.Date(2023, 12, 31, 23, 59, 59, 0, time.UTC))
+	fmt.Println(clk.Now())
+	// Output:
+	// 2024-01-15 09:30:00 +0000 UTC
+	// 2024-01-16 21:30:00 +0000 UTC
+	// 2023-12-31 23:59:59 +0000 UTC
+}
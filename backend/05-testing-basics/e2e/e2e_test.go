@@ -0,0 +1,81 @@
+package e2e
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/client"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	removeBuild()
+	os.Exit(code)
+}
+
+// Every script gets its own server, so scripts can assert on exact IDs and
+// run in parallel
+func TestScenarios(t *testing.T) {
+	scripts, err := filepath.Glob("testdata/*.txt")
+	require.NoError(t, err)
+	require.NotEmpty(t, scripts)
+
+	for _, script := range scripts {
+		t.Run(strings.TrimSuffix(filepath.Base(script), ".txt"), func(t *testing.T) {
+			t.Parallel()
+
+			RunScript(t, Start(t), script)
+		})
+	}
+}
+
+// Flows that loops or concurrency express better than a script go through
+// the client package, which sees the server just as a caller would
+func TestClientFlow(t *testing.T) {
+	ctx := context.Background()
+	s := Start(t)
+	c := client.New(s.URL, s.Client)
+
+	const n = 20
+	var wg sync.WaitGroup
+	ids := make(chan int, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := c.CreateUser(ctx, client.CreateUserRequest{Name: "User", Email: "user" + string(rune('a'+i)) + "@example.com"})
+			if assert.NoError(t, err) {
+				ids <- user.ID
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int]bool)
+	for id := range ids {
+		seen[id] = true
+	}
+	assert.Len(t, seen, n, "concurrent registrations must get distinct IDs")
+
+	users, err := c.ListUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, n)
+	for i, user := range users {
+		assert.Equal(t, i+1, user.ID, "IDs 1 to n, listed in order")
+	}
+
+	for _, user := range users {
+		require.NoError(t, c.DeleteUser(ctx, user.ID))
+	}
+	users, err = c.ListUsers(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
@@ -0,0 +1,227 @@
+// Package e2e tests the lab's server from the outside: it starts a real
+// server, talks to it only over HTTP, and knows nothing of its packages
+// beyond how to start it.
+package e2e
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/server"
+)
+
+// readyTimeout bounds both waiting for the listen line and for /health
+const readyTimeout = 10 * time.Second
+
+// stopTimeout is how long a subprocess gets to exit after an interrupt
+const stopTimeout = 5 * time.Second
+
+// listenLine is what main logs once it listens, followed by the URL
+const listenLine = "Server running at "
+
+// Server is a running server under test
+type Server struct {
+	URL string
+	// Client talks only to this server. Its idle connections are closed
+	// before the server stops, which would otherwise wait out the ones
+	// the client opened but never used.
+	Client *http.Client
+}
+
+// Start runs a fresh server and stops it when the test ends. E2E_MODE picks
+// how: "inprocess" (the default) serves server.NewInMemory on a random port
+// in this process; "subprocess" builds main once and runs it with
+// ADDR=127.0.0.1:0. Either way Start returns only once GET /health answers.
+func Start(t *testing.T) *Server {
+	t.Helper()
+
+	var s *Server
+	switch mode := os.Getenv("E2E_MODE"); mode {
+	case "", "inprocess":
+		s = startInProcess(t)
+	case "subprocess":
+		s = startSubprocess(t, buildServer(t))
+	default:
+		t.Fatalf("E2E_MODE=%q: want inprocess or subprocess", mode)
+	}
+
+	s.Client = &http.Client{Transport: &http.Transport{}}
+	t.Cleanup(s.Client.CloseIdleConnections) // runs before the stop registered above
+	waitReady(t, s)
+	return s
+}
+
+func startInProcess(t *testing.T) *Server {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, ln, server.NewInMemory()) }()
+
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("server did not shut down cleanly: %v", err)
+		}
+	})
+	return &Server{URL: "http://" + ln.Addr().String()}
+}
+
+var build struct {
+	once sync.Once
+	dir  string
+	path string
+	err  error
+}
+
+// buildServer compiles main once per test binary; removeBuild deletes it
+func buildServer(t *testing.T) string {
+	t.Helper()
+
+	build.once.Do(func() {
+		build.dir, build.err = os.MkdirTemp("", "e2e-server-")
+		if build.err != nil {
+			return
+		}
+		build.path = filepath.Join(build.dir, "server")
+		out, err := exec.Command("go", "build", "-o", build.path, "github.com/e6a5/learning/backend/05-testing-basics").CombinedOutput()
+		if err != nil {
+			build.err = fmt.Errorf("go build: %v\n%s", err, out)
+		}
+	})
+	if build.err != nil {
+		t.Fatalf("building server: %v", build.err)
+	}
+	return build.path
+}
+
+func removeBuild() {
+	if build.dir != "" {
+		os.RemoveAll(build.dir)
+	}
+}
+
+// process is a server subprocess and everything it has logged
+type process struct {
+	cmd    *exec.Cmd
+	exited chan struct{}
+	err    error // from Wait, once exited is closed
+
+	mu  sync.Mutex
+	out strings.Builder
+}
+
+func (p *process) output() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.out.String()
+}
+
+func startSubprocess(t *testing.T, binary string) *Server {
+	t.Helper()
+
+	p := &process{cmd: exec.Command(binary), exited: make(chan struct{})}
+	p.cmd.Env = append(os.Environ(), "ADDR=127.0.0.1:0")
+	stderr, err := p.cmd.StderrPipe()
+	if err != nil {
+		t.Fatalf("piping server output: %v", err)
+	}
+	if err := p.cmd.Start(); err != nil {
+		t.Fatalf("starting server: %v", err)
+	}
+
+	// Wait must not run before the pipe is drained, so the scanner
+	// goroutine calls it once the server closes its end
+	urls := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			line := scanner.Text()
+			p.mu.Lock()
+			p.out.WriteString(line + "\n")
+			p.mu.Unlock()
+			if _, url, found := strings.Cut(line, listenLine); found {
+				select {
+				case urls <- strings.TrimSpace(url):
+				default:
+				}
+			}
+		}
+		p.err = p.cmd.Wait()
+		close(p.exited)
+	}()
+
+	t.Cleanup(func() { stopSubprocess(t, p) })
+
+	select {
+	case url := <-urls:
+		return &Server{URL: url}
+	case <-p.exited:
+		t.Fatalf("server exited before listening: %v\n%s", p.err, p.output())
+	case <-time.After(readyTimeout):
+		t.Fatalf("server did not log %q within %v\n%s", listenLine, readyTimeout, p.output())
+	}
+	return nil
+}
+
+// stopSubprocess interrupts the server as a user's Ctrl-C would, and fails
+// the test unless it shuts down cleanly in time
+func stopSubprocess(t *testing.T, p *process) {
+	select {
+	case <-p.exited:
+	default:
+		if err := p.cmd.Process.Signal(os.Interrupt); err != nil {
+			t.Errorf("interrupting server: %v", err)
+		}
+		select {
+		case <-p.exited:
+			if p.err != nil {
+				t.Errorf("server exited with %v after interrupt", p.err)
+			}
+		case <-time.After(stopTimeout):
+			p.cmd.Process.Kill()
+			<-p.exited
+			t.Errorf("server still running %v after interrupt; killed", stopTimeout)
+		}
+	}
+
+	if t.Failed() {
+		t.Logf("server output:\n%s", p.output())
+	}
+}
+
+// waitReady polls GET /health until it answers 200
+func waitReady(t *testing.T, s *Server) {
+	t.Helper()
+
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		resp, err := s.Client.Get(s.URL + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+			err = fmt.Errorf("status %d", resp.StatusCode)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s/health not ready within %v: %v", s.URL, readyTimeout, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,232 @@
+package e2e
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// RunScript runs the scenario in the file at path against s. A script
+// is a request flow written line by line; "#" starts a comment:
+//
+//	> POST /users {"name": "Alice", "email": "Alice@Example.com"}
+//	< 201
+//	< header Location /users/1
+//	< json {"id": 1, "email": "alice@example.com"}
+//	let alice = header Location
+//	> GET $alice
+//	< 200
+//
+// "> METHOD PATH [BODY]" sends a request, with BODY as JSON. Each "<" line
+// checks the latest response: its status, a header, or that its JSON body
+// contains the given fields and elements. "let NAME = header H" and
+// "let NAME = json FIELD" save a value that later lines use as $NAME. The
+// first failing line stops the script.
+func RunScript(t *testing.T, s *Server, path string) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening script: %v", err)
+	}
+	defer f.Close()
+
+	r := &scriptRun{server: s, vars: make(map[string]string)}
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := r.step(line); err != nil {
+			t.Fatalf("%s:%d: %s\n\t%v", path, lineNo, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("reading script: %v", err)
+	}
+}
+
+// scriptRun is the state a script carries from line to line
+type scriptRun struct {
+	server *Server
+	vars   map[string]string
+
+	resp *http.Response
+	body []byte
+}
+
+func (r *scriptRun) step(line string) error {
+	line, err := r.expand(line)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case strings.HasPrefix(line, ">"):
+		return r.send(strings.TrimSpace(line[1:]))
+	case strings.HasPrefix(line, "<"):
+		if r.resp == nil {
+			return fmt.Errorf("expectation before any request")
+		}
+		return r.expect(strings.TrimSpace(line[1:]))
+	case strings.HasPrefix(line, "let "):
+		if r.resp == nil {
+			return fmt.Errorf("let before any request")
+		}
+		return r.let(strings.TrimSpace(line[len("let "):]))
+	}
+	return fmt.Errorf("unknown step; want >, < or let")
+}
+
+// expand replaces $NAME with a saved value, failing on unknown names
+func (r *scriptRun) expand(line string) (string, error) {
+	var missing []string
+	expanded := os.Expand(line, func(name string) string {
+		value, ok := r.vars[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined variables %v", missing)
+	}
+	return expanded, nil
+}
+
+func (r *scriptRun) send(args string) error {
+	fields := strings.SplitN(args, " ", 3)
+	if len(fields) < 2 {
+		return fmt.Errorf("want > METHOD PATH [BODY]")
+	}
+
+	var body io.Reader
+	if len(fields) == 3 {
+		body = strings.NewReader(fields[2])
+	}
+	req, err := http.NewRequest(fields[0], r.server.URL+fields[1], body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.server.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	r.resp = resp
+	r.body, err = io.ReadAll(resp.Body)
+	return err
+}
+
+func (r *scriptRun) expect(args string) error {
+	kind, rest, _ := strings.Cut(args, " ")
+	switch kind {
+	case "header":
+		name, want, _ := strings.Cut(rest, " ")
+		if got := r.resp.Header.Get(name); got != want {
+			return fmt.Errorf("header %s = %q, want %q", name, got, want)
+		}
+		return nil
+
+	case "json":
+		var want, got interface{}
+		if err := json.Unmarshal([]byte(rest), &want); err != nil {
+			return fmt.Errorf("expected JSON: %v", err)
+		}
+		if err := json.Unmarshal(r.body, &got); err != nil {
+			return fmt.Errorf("response is not JSON: %q", r.body)
+		}
+		if path := mismatch("$", want, got); path != "" {
+			return fmt.Errorf("body differs at %s: got %s", path, r.body)
+		}
+		return nil
+	}
+
+	status, err := strconv.Atoi(kind)
+	if err != nil {
+		return fmt.Errorf("want < STATUS, < header or < json")
+	}
+	if r.resp.StatusCode != status {
+		return fmt.Errorf("status = %d, want %d: %s", r.resp.StatusCode, status, r.body)
+	}
+	return nil
+}
+
+func (r *scriptRun) let(args string) error {
+	name, source, ok := strings.Cut(args, " = ")
+	kind, key, ok2 := strings.Cut(source, " ")
+	if !ok || !ok2 {
+		return fmt.Errorf("want let NAME = header H or let NAME = json FIELD")
+	}
+
+	switch kind {
+	case "header":
+		r.vars[name] = r.resp.Header.Get(key)
+	case "json":
+		var body map[string]interface{}
+		if err := json.Unmarshal(r.body, &body); err != nil {
+			return fmt.Errorf("response is not a JSON object: %q", r.body)
+		}
+		value, found := body[key]
+		if !found {
+			return fmt.Errorf("no field %q in %s", key, r.body)
+		}
+		r.vars[name] = fmt.Sprint(value)
+	default:
+		return fmt.Errorf("unknown source %q; want header or json", kind)
+	}
+	return nil
+}
+
+// mismatch returns the path of the first place got does not contain want,
+// or "" when it does. Objects may have extra fields; arrays must match in
+// length and element by element.
+func mismatch(path string, want, got interface{}) string {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return path
+		}
+		keys := make([]string, 0, len(w))
+		for key := range w {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if p := mismatch(path+"."+key, w[key], g[key]); p != "" {
+				return p
+			}
+		}
+		return ""
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			return path
+		}
+		for i := range w {
+			if p := mismatch(fmt.Sprintf("%s[%d]", path, i), w[i], g[i]); p != "" {
+				return p
+			}
+		}
+		return ""
+	}
+	if !reflect.DeepEqual(want, got) {
+		return path
+	}
+	return ""
+}
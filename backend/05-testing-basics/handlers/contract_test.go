@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/client"
+	"github.com/e6a5/learning/backend/05-testing-basics/client/contracttest"
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+	"github.com/e6a5/learning/backend/05-testing-basics/service"
+	"github.com/e6a5/learning/backend/05-testing-basics/store"
+	"github.com/e6a5/learning/backend/05-testing-basics/testutil"
+)
+
+// TestUsersAPI_Contract is the provider side of the client's contract: a
+// change here that breaks what the client relies on fails this test, even
+// though the client's own tests only ever see its fake server
+func TestUsersAPI_Contract(t *testing.T) {
+	contracttest.Verify(t, client.Contract, func(t *testing.T, given []client.User) http.Handler {
+		s := store.NewMemoryStore()
+		ids := service.NewSequence()
+		for _, user := range given {
+			testutil.SeedUsers(t, s, models.User(user))
+			ids.NextID()
+		}
+		return NewUserHandler(service.NewUserService(s, ids, testutil.NewClock())).Routes()
+	})
+}
@@ -0,0 +1,36 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/clock"
+	"github.com/e6a5/learning/backend/05-testing-basics/handlers"
+	"github.com/e6a5/learning/backend/05-testing-basics/service"
+	"github.com/e6a5/learning/backend/05-testing-basics/store"
+)
+
+// Handlers can be called directly with httptest, no server needed. The
+// Output block pins the exact bytes a client receives.
+func ExampleUserHandler_Routes() {
+	clk := clock.NewFake(time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC))
+	users := service.NewUserService(store.NewMemoryStore(), service.NewSequence(), clk)
+	routes := handlers.NewUserHandler(users).Routes()
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Alice", "email": "alice@example.com"}`)),
+		httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Bob", "email": "bob"}`)),
+		httptest.NewRequest(http.MethodGet, "/users/2", nil),
+	} {
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, req)
+		fmt.Print(rec.Code, " ", rec.Body.String())
+	}
+	// Output:
+	// 201 {"id":1,"name":"Alice","email":"alice@example.com","joined_at":"2024-01-15 09:30:00"}
+	// 400 {"error":"email format is invalid","field":"email"}
+	// 404 {"error":"User not found"}
+}
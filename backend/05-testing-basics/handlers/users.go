@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+	"github.com/e6a5/learning/backend/05-testing-basics/service"
+	"github.com/e6a5/learning/backend/05-testing-basics/store"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// maxBodyBytes caps request bodies so a huge payload cannot exhaust memory
+const maxBodyBytes = 1 << 20
+
+// ErrorResponse is the JSON body of every error response. It matches
+// response.APIResponse's error field, plus the field a validation error is about.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Field string `json:"field,omitempty"` // set for validation errors
+}
+
+// UserHandler handles user-related HTTP requests
+type UserHandler struct {
+	users *service.UserService
+}
+
+// NewUserHandler creates a new user handler
+func NewUserHandler(users *service.UserService) *UserHandler {
+	return &UserHandler{users: users}
+}
+
+// Routes returns a mux serving the users API
+func (h *UserHandler) Routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", h.ListUsers)
+	mux.HandleFunc("POST /users", h.CreateUser)
+	mux.HandleFunc("GET /users/{id}", h.GetUser)
+	mux.HandleFunc("DELETE /users/{id}", h.DeleteUser)
+	return mux
+}
+
+// ListUsers handles GET /users - returns all users
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.users.List(r.Context())
+	if err != nil {
+		respondStoreError(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, users)
+}
+
+// CreateUser handles POST /users - creates a new user
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateUserRequest
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	user, err := h.users.Register(r.Context(), req)
+	if err != nil {
+		var validationErr models.UserValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			response.JSON(w, http.StatusBadRequest, ErrorResponse{Error: validationErr.Message, Field: validationErr.Field})
+		case errors.Is(err, store.ErrDuplicateEmail):
+			response.Error(w, http.StatusConflict, "Email already registered")
+		default:
+			respondStoreError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Location", "/users/"+strconv.Itoa(user.ID))
+	response.JSON(w, http.StatusCreated, user)
+}
+
+// GetUser handles GET /users/{id} - returns one user
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.users.Get(r.Context(), id)
+	if err != nil {
+		respondStoreError(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, user)
+}
+
+// DeleteUser handles DELETE /users/{id} - deletes a user
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.users.Delete(r.Context(), id); err != nil {
+		respondStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userID parses the {id} path value, answering 400 when it is not a
+// positive integer
+func userID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		response.Error(w, http.StatusBadRequest, "User ID must be a positive integer")
+		return 0, false
+	}
+	return id, true
+}
+
+// respondStoreError maps errors every store method can return
+func respondStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrUserNotFound):
+		response.Error(w, http.StatusNotFound, "User not found")
+	case errors.Is(err, context.DeadlineExceeded):
+		response.Error(w, http.StatusGatewayTimeout, "Storage timed out")
+	default:
+		log.Printf("Store error: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
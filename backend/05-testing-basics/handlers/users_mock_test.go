@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+	"github.com/e6a5/learning/backend/05-testing-basics/service"
+	"github.com/e6a5/learning/backend/05-testing-basics/store"
+	"github.com/e6a5/learning/backend/05-testing-basics/store/mocks"
+	"github.com/e6a5/learning/backend/05-testing-basics/testutil"
+)
+
+// newMockHandler returns routes backed by the real service on a mock store.
+// The controller fails the test on any call that was not expected, and at
+// the end on any expected call that never happened. IDs come from a fresh
+// sequence, so the first registered user gets ID 1.
+func newMockHandler(t *testing.T) (http.Handler, *mocks.MockUserStore) {
+	t.Helper()
+
+	mockStore := mocks.NewMockUserStore(gomock.NewController(t))
+	svc := service.NewUserService(mockStore, service.NewSequence(), testutil.NewClock())
+	return NewUserHandler(svc).Routes(), mockStore
+}
+
+var carol = testutil.NewUser(testutil.WithID(7), testutil.WithName("Carol"), testutil.WithEmail("carol@example.com"))
+
+func TestUserHandler_StoreErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       interface{}
+		expect     func(m *mocks.MockUserStoreMockRecorder)
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:   "list fails",
+			method: http.MethodGet,
+			path:   "/users",
+			expect: func(m *mocks.MockUserStoreMockRecorder) {
+				m.List(gomock.Any()).Return(nil, errors.New("connection refused"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "Internal server error",
+		},
+		{
+			name:   "get fails",
+			method: http.MethodGet,
+			path:   "/users/7",
+			expect: func(m *mocks.MockUserStoreMockRecorder) {
+				m.Get(gomock.Any(), 7).Return(models.User{}, errors.New("connection refused"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "Internal server error",
+		},
+		{
+			name:   "wrapped not found is still a 404",
+			method: http.MethodGet,
+			path:   "/users/7",
+			expect: func(m *mocks.MockUserStoreMockRecorder) {
+				m.Get(gomock.Any(), 7).Return(models.User{}, fmt.Errorf("query users: %w", store.ErrUserNotFound))
+			},
+			wantStatus: http.StatusNotFound,
+			wantError:  "User not found",
+		},
+		{
+			name:   "create hits a duplicate",
+			method: http.MethodPost,
+			path:   "/users",
+			body:   testutil.NewCreateUserRequest(),
+			expect: func(m *mocks.MockUserStoreMockRecorder) {
+				m.Create(gomock.Any(), gomock.Any()).Return(store.ErrDuplicateEmail)
+			},
+			wantStatus: http.StatusConflict,
+			wantError:  "Email already registered",
+		},
+		{
+			name:   "create fails",
+			method: http.MethodPost,
+			path:   "/users",
+			body:   testutil.NewCreateUserRequest(),
+			expect: func(m *mocks.MockUserStoreMockRecorder) {
+				m.Create(gomock.Any(), gomock.Any()).Return(errors.New("disk full"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "Internal server error",
+		},
+		{
+			name:   "delete times out",
+			method: http.MethodDelete,
+			path:   "/users/7",
+			expect: func(m *mocks.MockUserStoreMockRecorder) {
+				m.Delete(gomock.Any(), 7).Return(context.DeadlineExceeded)
+			},
+			wantStatus: http.StatusGatewayTimeout,
+			wantError:  "Storage timed out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockStore := newMockHandler(t)
+			tt.expect(mockStore.EXPECT())
+
+			rec := testutil.Do(t, handler, tt.method, tt.path, tt.body)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantError, testutil.DecodeJSON[ErrorResponse](t, rec).Error)
+		})
+	}
+}
+
+// Bad input is rejected before the store is called. No EXPECT() is set, so
+// any store call fails the test.
+func TestUserHandler_InvalidInputNeverReachesStore(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"malformed JSON", http.MethodPost, "/users", `{`},
+		{"invalid email", http.MethodPost, "/users", `{"name": "Carol", "email": "carol"}`},
+		{"bad ID on get", http.MethodGet, "/users/abc", nil},
+		{"bad ID on delete", http.MethodDelete, "/users/0", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := newMockHandler(t)
+
+			rec := testutil.Do(t, handler, tt.method, tt.path, tt.body)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestUserHandler_CreateStoresNormalizedUser(t *testing.T) {
+	handler, mockStore := newMockHandler(t)
+
+	// Eq matches the exact user the service built: normalized, numbered and
+	// stamped before it reaches the store
+	want := testutil.NewUser(testutil.WithID(1), testutil.WithName("Carol"), testutil.WithEmail("carol@example.com"))
+	mockStore.EXPECT().
+		Create(gomock.Any(), gomock.Eq(want)).
+		Return(nil).
+		Times(1)
+
+	rec := testutil.Do(t, handler, http.MethodPost, "/users", models.CreateUserRequest{Name: " Carol ", Email: "Carol@Example.com"})
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, want, testutil.DecodeJSON[models.User](t, rec))
+	assert.Equal(t, "/users/1", rec.Header().Get("Location"))
+}
+
+// The handler must pass the request context down, so a slow store gives up
+// when the request's deadline passes instead of hanging
+func TestUserHandler_StoreTimeout(t *testing.T) {
+	handler, mockStore := newMockHandler(t)
+
+	mockStore.EXPECT().
+		Get(gomock.Any(), 7).
+		DoAndReturn(func(ctx context.Context, id int) (models.User, error) {
+			select {
+			case <-ctx.Done():
+				return models.User{}, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return carol, nil
+			}
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	start := time.Now()
+	handler.ServeHTTP(rec, req)
+
+	assert.Less(t, time.Since(start), time.Second, "handler waited for the store instead of the deadline")
+	require.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	assert.Equal(t, "Storage timed out", testutil.DecodeJSON[ErrorResponse](t, rec).Error)
+}
+
+// InOrder fails the test if the calls happen in any other order, which pins
+// down a whole create-read-delete flow
+func TestUserHandler_CallOrder(t *testing.T) {
+	handler, mockStore := newMockHandler(t)
+	carol := testutil.NewUser(testutil.WithID(1))
+
+	gomock.InOrder(
+		mockStore.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil),
+		mockStore.EXPECT().Get(gomock.Any(), carol.ID).Return(carol, nil),
+		mockStore.EXPECT().Delete(gomock.Any(), carol.ID).Return(nil),
+		mockStore.EXPECT().Get(gomock.Any(), carol.ID).Return(models.User{}, store.ErrUserNotFound),
+	)
+
+	rec := testutil.Do(t, handler, http.MethodPost, "/users", testutil.NewCreateUserRequest())
+	require.Equal(t, http.StatusCreated, rec.Code)
+	location := rec.Header().Get("Location")
+
+	assert.Equal(t, http.StatusOK, testutil.Do(t, handler, http.MethodGet, location, nil).Code)
+	assert.Equal(t, http.StatusNoContent, testutil.Do(t, handler, http.MethodDelete, location, nil).Code)
+	assert.Equal(t, http.StatusNotFound, testutil.Do(t, handler, http.MethodGet, location, nil).Code)
+}
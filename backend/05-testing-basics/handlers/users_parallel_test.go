@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+	"github.com/e6a5/learning/backend/05-testing-basics/service"
+	"github.com/e6a5/learning/backend/05-testing-basics/store"
+	"github.com/e6a5/learning/backend/05-testing-basics/testutil"
+)
+
+// Each subtest builds its own handler, so parallel subtests share nothing
+// and may assert on anything, IDs and list lengths included
+func TestUsersAPI_ParallelIsolated(t *testing.T) {
+	t.Parallel()
+
+	for n := 1; n <= 5; n++ {
+		t.Run(fmt.Sprintf("%d users", n), func(t *testing.T) {
+			t.Parallel()
+
+			handler := newTestHandler(t)
+			for range n {
+				rec := testutil.Do(t, handler, http.MethodPost, "/users", testutil.NewCreateUserRequest())
+				require.Equal(t, http.StatusCreated, rec.Code)
+			}
+
+			rec := testutil.Do(t, handler, http.MethodGet, "/users", nil)
+			users := testutil.DecodeJSON[[]models.User](t, rec)
+			assert.Len(t, users, n)
+			assert.Equal(t, n, users[n-1].ID)
+		})
+	}
+}
+
+// Subtests here share one server and run in any order, so each may only
+// assert on what it alone wrote: its own user behind its own Location. The
+// ID it gets and the length of GET /users depend on the other subtests.
+func TestUsersAPI_ParallelShared(t *testing.T) {
+	users := service.NewUserService(store.NewShardedStore(0), service.NewSequence(), testutil.NewClock())
+	server := httptest.NewServer(NewUserHandler(users).Routes())
+	// Parallel subtests only start once this function has returned, so a
+	// defer would close the server before any of them ran. Cleanup waits
+	// for them.
+	t.Cleanup(server.Close)
+
+	for i := range 20 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+
+			email := fmt.Sprintf("parallel%d@example.com", i)
+			resp, err := http.Post(server.URL+"/users", "application/json",
+				strings.NewReader(fmt.Sprintf(`{"name": "User %d", "email": %q}`, i, email)))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+			var created models.User
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+			assert.Equal(t, email, created.Email)
+
+			resp, err = http.Get(server.URL + resp.Header.Get("Location"))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var got models.User
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, created, got)
+		})
+	}
+}
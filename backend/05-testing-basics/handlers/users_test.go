@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+	"github.com/e6a5/learning/backend/05-testing-basics/service"
+	"github.com/e6a5/learning/backend/05-testing-basics/store"
+	"github.com/e6a5/learning/backend/05-testing-basics/testutil"
+)
+
+// newTestHandler returns routes backed by the real service and an in-memory
+// store, with the given users registered in order
+func newTestHandler(t *testing.T, users ...models.CreateUserRequest) http.Handler {
+	t.Helper()
+
+	svc := service.NewUserService(store.NewMemoryStore(), service.NewSequence(), testutil.NewClock())
+	for _, req := range users {
+		if _, err := svc.Register(context.Background(), req); err != nil {
+			t.Fatalf("registering %+v: %v", req, err)
+		}
+	}
+	return NewUserHandler(svc).Routes()
+}
+
+var alice = testutil.NewCreateUserRequest(testutil.WithName("Alice"), testutil.WithEmail("alice@example.com"))
+var bob = testutil.NewCreateUserRequest(testutil.WithName("Bob"), testutil.WithEmail("bob@example.com"))
+
+func TestCreateUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{} // raw JSON as a string, or a value to encode
+		wantStatus int
+		wantError  ErrorResponse
+	}{
+		{
+			name:       "valid user",
+			body:       testutil.NewCreateUserRequest(testutil.WithName("Carol"), testutil.WithEmail("Carol@Example.com")),
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "malformed JSON",
+			body:       `{"name": "Carol",`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  ErrorResponse{Error: "Invalid JSON format"},
+		},
+		{
+			name:       "empty body",
+			body:       ``,
+			wantStatus: http.StatusBadRequest,
+			wantError:  ErrorResponse{Error: "Invalid JSON format"},
+		},
+		{
+			name:       "unknown field",
+			body:       `{"name": "Carol", "email": "carol@example.com", "admin": true}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  ErrorResponse{Error: "Invalid JSON format"},
+		},
+		{
+			name:       "missing name",
+			body:       `{"email": "carol@example.com"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  ErrorResponse{Error: "name is required", Field: "name"},
+		},
+		{
+			name:       "invalid email",
+			body:       `{"name": "Carol", "email": "carol"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  ErrorResponse{Error: "email format is invalid", Field: "email"},
+		},
+		{
+			name:       "duplicate email differing in case",
+			body:       testutil.NewCreateUserRequest(testutil.WithEmail("ALICE@example.com")),
+			wantStatus: http.StatusConflict,
+			wantError:  ErrorResponse{Error: "Email already registered"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestHandler(t, alice)
+
+			rec := testutil.Do(t, handler, http.MethodPost, "/users", tt.body)
+
+			require.Equal(t, tt.wantStatus, rec.Code, "body: %s", rec.Body.String())
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			if tt.wantStatus != http.StatusCreated {
+				assert.Equal(t, tt.wantError, testutil.DecodeJSON[ErrorResponse](t, rec))
+				return
+			}
+
+			// The store's clock is frozen, so the whole response is known
+			want := testutil.NewUser(testutil.WithID(2), testutil.WithName("Carol"), testutil.WithEmail("carol@example.com"))
+			assert.Equal(t, want, testutil.DecodeJSON[models.User](t, rec))
+			assert.Equal(t, "/users/2", rec.Header().Get("Location"))
+		})
+	}
+}
+
+// Any valid name and email must be accepted and normalized, not just the
+// handful in the table above
+func TestCreateUser_RandomValidUsers(t *testing.T) {
+	r := testutil.Rand(t)
+	handler := newTestHandler(t)
+
+	for i := 0; i < 50; i++ {
+		req := testutil.NewCreateUserRequest(testutil.WithRandomIdentity(r))
+
+		rec := testutil.Do(t, handler, http.MethodPost, "/users", req)
+
+		require.Equal(t, http.StatusCreated, rec.Code, "request %+v: %s", req, rec.Body.String())
+		user := testutil.DecodeJSON[models.User](t, rec)
+		assert.Equal(t, req.Name, user.Name)
+		assert.Equal(t, strings.ToLower(req.Email), user.Email)
+	}
+}
+
+func TestCreateUser_BodyTooLarge(t *testing.T) {
+	handler := newTestHandler(t)
+	body := `{"name": "` + strings.Repeat("a", maxBodyBytes) + `", "email": "a@example.com"}`
+
+	rec := testutil.Do(t, handler, http.MethodPost, "/users", body)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestListUsers(t *testing.T) {
+	t.Run("empty store returns an empty array, not null", func(t *testing.T) {
+		rec := testutil.Do(t, newTestHandler(t), http.MethodGet, "/users", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[]`, rec.Body.String())
+	})
+
+	t.Run("users ordered by ID", func(t *testing.T) {
+		rec := testutil.Do(t, newTestHandler(t, alice, bob), http.MethodGet, "/users", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		users := testutil.DecodeJSON[[]models.User](t, rec)
+		require.Len(t, users, 2)
+		assert.Equal(t, "Alice", users[0].Name)
+		assert.Equal(t, "Bob", users[1].Name)
+	})
+}
+
+func TestGetUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantName   string
+		wantError  string
+	}{
+		{"existing user", "/users/2", http.StatusOK, "Bob", ""},
+		{"unknown ID", "/users/99", http.StatusNotFound, "", "User not found"},
+		{"non-numeric ID", "/users/bob", http.StatusBadRequest, "", "User ID must be a positive integer"},
+		{"zero ID", "/users/0", http.StatusBadRequest, "", "User ID must be a positive integer"},
+		{"negative ID", "/users/-1", http.StatusBadRequest, "", "User ID must be a positive integer"},
+	}
+
+	handler := newTestHandler(t, alice, bob)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := testutil.Do(t, handler, http.MethodGet, tt.path, nil)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantError != "" {
+				assert.Equal(t, tt.wantError, testutil.DecodeJSON[ErrorResponse](t, rec).Error)
+				return
+			}
+			assert.Equal(t, tt.wantName, testutil.DecodeJSON[models.User](t, rec).Name)
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	handler := newTestHandler(t, alice, bob)
+
+	rec := testutil.Do(t, handler, http.MethodDelete, "/users/1", nil)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	// The user is gone, and deleting twice is a 404
+	assert.Equal(t, http.StatusNotFound, testutil.Do(t, handler, http.MethodGet, "/users/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, testutil.Do(t, handler, http.MethodDelete, "/users/1", nil).Code)
+	assert.Len(t, testutil.DecodeJSON[[]models.User](t, testutil.Do(t, handler, http.MethodGet, "/users", nil)), 1)
+}
+
+func TestRouting(t *testing.T) {
+	handler := newTestHandler(t, alice)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"unsupported method on collection", http.MethodPut, "/users", http.StatusMethodNotAllowed},
+		{"unsupported method on item", http.MethodPost, "/users/1", http.StatusMethodNotAllowed},
+		{"unknown path", http.MethodGet, "/accounts", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantStatus, testutil.Do(t, handler, tt.method, tt.path, nil).Code)
+		})
+	}
+}
+
+// TestUsersAPI_Server goes through a real listener and HTTP client, which
+// also exercises headers and encoding the way a caller sees them
+func TestUsersAPI_Server(t *testing.T) {
+	server := httptest.NewServer(newTestHandler(t))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/users", "application/json",
+		strings.NewReader(`{"name": "Dave", "email": "dave@example.com"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + resp.Header.Get("Location"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var user models.User
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&user))
+	assert.Equal(t, "dave@example.com", user.Email)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/server"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+func main() {
+	// ADDR=127.0.0.1:0 picks a free port; the log line below reports it
+	addr := env.Get("ADDR", ":8080")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal("Failed to listen:", err)
+	}
+
+	log.Printf("🛠️  Server running at http://%s", ln.Addr())
+	if err := server.Serve(ctx, ln, server.NewInMemory()); err != nil {
+		log.Fatal("Server failed:", err)
+	}
+	log.Println("👋 Server stopped")
+}
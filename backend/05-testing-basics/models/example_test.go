@@ -0,0 +1,75 @@
+package models_test
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+)
+
+// Examples are the fourth kind of Go test, after tests, benchmarks and fuzz
+// targets. go test runs each one and compares what it prints with its
+// Output comment, and go doc shows it next to the function it documents.
+
+func ExampleValidateCreateUserRequest() {
+	err := models.ValidateCreateUserRequest(models.CreateUserRequest{
+		Name:  "Alice",
+		Email: "alice@example.com",
+	})
+	fmt.Println(err)
+	// Output: <nil>
+}
+
+// Each request fails on its first invalid field, checked name then email
+func ExampleValidateCreateUserRequest_invalid() {
+	requests := []models.CreateUserRequest{
+		{Name: "   ", Email: "alice@example.com"},
+		{Name: "Alice", Email: ""},
+		{Name: "Alice", Email: "alice@example"},
+		{Name: "", Email: "not an email"},
+	}
+	for _, req := range requests {
+		fmt.Println(models.ValidateCreateUserRequest(req))
+	}
+	// Output:
+	// name: name is required
+	// email: email is required
+	// email: email format is invalid
+	// name: name is required
+}
+
+// The error is a UserValidationError, so callers can tell which field to
+// highlight
+func ExampleUserValidationError() {
+	err := models.ValidateCreateUserRequest(models.CreateUserRequest{Name: "Alice", Email: "alice"})
+
+	var validationErr models.UserValidationError
+	if errors.As(err, &validationErr) {
+		fmt.Printf("field=%s message=%q\n", validationErr.Field, validationErr.Message)
+	}
+	// Output: field=email message="email format is invalid"
+}
+
+// NewUser stamps the current time, which an Output block cannot match, so
+// this example prints everything else
+func ExampleNewUser() {
+	user := models.NewUser(models.CreateUserRequest{Name: "  Alice ", Email: "Alice@Example.COM"}, 1)
+	fmt.Printf("%d %q %q\n", user.ID, user.Name, user.Email)
+	// Output: 1 "Alice" "alice@example.com"
+}
+
+func ExampleNewUserAt() {
+	joined := time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+	user := models.NewUserAt(models.CreateUserRequest{Name: "Alice", Email: "Alice@Example.com"}, 1, joined)
+	fmt.Printf("%+v\n", user)
+	// Output: {ID:1 Name:Alice Email:alice@example.com JoinedAt:2024-01-15 09:30:00}
+}
+
+func ExampleUser_IsEmpty() {
+	fmt.Println(models.User{}.IsEmpty())
+	fmt.Println(models.User{ID: 1}.IsEmpty())
+	// Output:
+	// true
+	// false
+}
@@ -4,8 +4,22 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
+// JoinedAtFormat is the layout of User.JoinedAt
+const JoinedAtFormat = "2006-01-02 15:04:05"
+
+// maxNameLength is counted in characters, so non-Latin names get the same room
+const maxNameLength = 100
+
+// maxEmailLength is the longest address SMTP allows (RFC 5321)
+const maxEmailLength = 254
+
+// emailRegex is compiled once; compiling it per call dominated validation time
+var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
 // User represents a user in our system
 type User struct {
 	ID       int    `json:"id"`
@@ -30,30 +44,54 @@ func (e UserValidationError) Error() string {
 	return e.Field + ": " + e.Message
 }
 
-// ValidateCreateUserRequest validates a user creation request
+// ValidateCreateUserRequest validates a user creation request. Fields are
+// checked as NewUser will store them, with surrounding whitespace trimmed.
 func ValidateCreateUserRequest(req CreateUserRequest) error {
-	if strings.TrimSpace(req.Name) == "" {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
 		return UserValidationError{
 			Field:   "name",
 			Message: "name is required",
 		}
 	}
 
-	if len(req.Name) > 100 {
+	if !utf8.ValidString(name) {
+		return UserValidationError{
+			Field:   "name",
+			Message: "name must be valid UTF-8",
+		}
+	}
+
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return UserValidationError{
+			Field:   "name",
+			Message: "name must not contain control characters",
+		}
+	}
+
+	if utf8.RuneCountInString(name) > maxNameLength {
 		return UserValidationError{
 			Field:   "name",
 			Message: "name must be 100 characters or less",
 		}
 	}
 
-	if strings.TrimSpace(req.Email) == "" {
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
 		return UserValidationError{
 			Field:   "email",
 			Message: "email is required",
 		}
 	}
 
-	if !isValidEmail(req.Email) {
+	if len(email) > maxEmailLength {
+		return UserValidationError{
+			Field:   "email",
+			Message: "email must be 254 characters or less",
+		}
+	}
+
+	if !isValidEmail(email) {
 		return UserValidationError{
 			Field:   "email",
 			Message: "email format is invalid",
@@ -65,17 +103,22 @@ func ValidateCreateUserRequest(req CreateUserRequest) error {
 
 // NewUser creates a new user with generated ID and timestamp
 func NewUser(req CreateUserRequest, id int) User {
+	return NewUserAt(req, id, time.Now())
+}
+
+// NewUserAt creates a new user who joined at joinedAt. Code that needs
+// testable timestamps passes the time from a clock.Clock.
+func NewUserAt(req CreateUserRequest, id int, joinedAt time.Time) User {
 	return User{
 		ID:       id,
 		Name:     strings.TrimSpace(req.Name),
 		Email:    strings.TrimSpace(strings.ToLower(req.Email)),
-		JoinedAt: time.Now().Format("2006-01-02 15:04:05"),
+		JoinedAt: joinedAt.Format(JoinedAtFormat),
 	}
 }
 
 // isValidEmail validates email format using regex
 func isValidEmail(email string) bool {
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 	return emailRegex.MatchString(email)
 }
 
@@ -0,0 +1,102 @@
+package models_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+	"github.com/e6a5/learning/backend/05-testing-basics/testutil"
+)
+
+// sink keeps results alive so the compiler cannot drop the benchmarked call
+var sink interface{}
+
+// Validation cost should grow with input length, and no faster: names are
+// counted in runes, emails matched by a regex compiled once
+func BenchmarkValidate(b *testing.B) {
+	b.Run("name", func(b *testing.B) {
+		testutil.RunSizes(b, []int{1, 10, 100}, func(b *testing.B, n int) {
+			req := models.CreateUserRequest{Name: strings.Repeat("é", n), Email: "john@example.com"}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				sink = models.ValidateCreateUserRequest(req)
+			}
+		})
+	})
+
+	b.Run("email", func(b *testing.B) {
+		testutil.RunSizes(b, []int{1, 10, 100, 240}, func(b *testing.B, n int) {
+			req := models.CreateUserRequest{Name: "John Doe", Email: strings.Repeat("j", n) + "@example.com"}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				sink = models.ValidateCreateUserRequest(req)
+			}
+		})
+	})
+
+	b.Run("invalid", func(b *testing.B) {
+		req := models.CreateUserRequest{Name: "John Doe", Email: "john@"}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sink = models.ValidateCreateUserRequest(req)
+		}
+	})
+}
+
+// JSON is where a handler spends most of its time on large lists, so the
+// per-user cost is reported next to the per-op one
+func BenchmarkUserJSON(b *testing.B) {
+	b.Run("encode", func(b *testing.B) {
+		testutil.RunSizes(b, testutil.BenchSizes, func(b *testing.B, n int) {
+			users := testutil.Users(n)
+			var buf bytes.Buffer
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				if err := json.NewEncoder(&buf).Encode(users); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.SetBytes(int64(buf.Len()))
+			testutil.ReportPerItem(b, n, "user")
+		})
+	})
+
+	b.Run("decode", func(b *testing.B) {
+		testutil.RunSizes(b, testutil.BenchSizes, func(b *testing.B, n int) {
+			encoded, err := json.Marshal(testutil.Users(n))
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.SetBytes(int64(len(encoded)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var users []models.User
+				if err := json.Unmarshal(encoded, &users); err != nil {
+					b.Fatal(err)
+				}
+				sink = users
+			}
+			testutil.ReportPerItem(b, n, "user")
+		})
+	})
+
+	b.Run("decode request", func(b *testing.B) {
+		body := []byte(`{"name": "John Doe", "email": "john@example.com"}`)
+		b.SetBytes(int64(len(body)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var req models.CreateUserRequest
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(&req); err != nil {
+				b.Fatal(err)
+			}
+			sink = req
+		}
+	})
+}
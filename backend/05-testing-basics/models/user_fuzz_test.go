@@ -0,0 +1,125 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fuzz targets run their seeds as ordinary tests under `go test`. To search
+// for new failures, run one target at a time:
+//
+//	go test ./models -run='^$' -fuzz=FuzzValidateCreateUserRequest -fuzztime=30s
+//
+// Inputs that failed are saved under testdata/fuzz/ and replayed by every
+// later `go test`, so a fixed bug stays fixed. The files there are the
+// inputs that made the validators trim, count characters instead of bytes,
+// reject control characters and invalid UTF-8, and cap email length.
+
+func FuzzIsValidEmail(f *testing.F) {
+	for _, seed := range []string{
+		"john@example.com",
+		"john.doe+tag@mail.example.co.uk",
+		"JOHN@EXAMPLE.COM",
+		"",
+		"@",
+		"john@",
+		"@example.com",
+		"john@@example.com",
+		"john@example",
+		"john doe@example.com",
+		"john@exa mple.com",
+		"john@example.com\n",
+		"jöhn@example.com",
+		"john@example.c0m",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, email string) {
+		if !isValidEmail(email) {
+			return
+		}
+
+		local, domain, found := strings.Cut(email, "@")
+		if !found || local == "" || strings.Contains(domain, "@") {
+			t.Fatalf("%q accepted without exactly one @ after a non-empty local part", email)
+		}
+		if !strings.Contains(domain, ".") {
+			t.Fatalf("%q accepted with a domain without a dot", email)
+		}
+		for _, r := range email {
+			if r > unicode.MaxASCII || unicode.IsSpace(r) || unicode.IsControl(r) {
+				t.Fatalf("%q accepted with character %q", email, r)
+			}
+		}
+		if !isValidEmail(strings.ToLower(email)) {
+			t.Fatalf("%q accepted but its lowercase form is not", email)
+		}
+	})
+}
+
+func FuzzValidateCreateUserRequest(f *testing.F) {
+	for _, seed := range []CreateUserRequest{
+		{Name: "John Doe", Email: "john@example.com"},
+		{Name: "", Email: "john@example.com"},
+		{Name: "   ", Email: "john@example.com"},
+		{Name: strings.Repeat("a", 100), Email: "john@example.com"},
+		{Name: strings.Repeat("a", 101), Email: "john@example.com"},
+		{Name: "José Álvarez", Email: "jose@example.com"},
+		{Name: "John", Email: "not-an-email"},
+	} {
+		f.Add(seed.Name, seed.Email)
+	}
+
+	f.Fuzz(func(t *testing.T, name, email string) {
+		req := CreateUserRequest{Name: name, Email: email}
+		err := ValidateCreateUserRequest(req)
+
+		if err != nil {
+			var validationErr UserValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("%+v: error %v is not a UserValidationError", req, err)
+			}
+			if validationErr.Field != "name" && validationErr.Field != "email" {
+				t.Fatalf("%+v: error on unknown field %q", req, validationErr.Field)
+			}
+			if validationErr.Message == "" {
+				t.Fatalf("%+v: error without a message", req)
+			}
+		}
+
+		// Surrounding whitespace is trimmed by NewUser, so it must not change
+		// whether a request is accepted
+		padded := CreateUserRequest{Name: " " + name + "\t", Email: "\n" + email + " "}
+		if (ValidateCreateUserRequest(padded) == nil) != (err == nil) {
+			t.Fatalf("%+v and %+v validate differently", req, padded)
+		}
+
+		if err != nil {
+			return
+		}
+
+		// Whatever is accepted must be safe to store and display
+		if !utf8.ValidString(name) {
+			t.Fatalf("%+v: accepted a name that is not valid UTF-8", req)
+		}
+		for _, r := range name {
+			if unicode.IsControl(r) && !unicode.IsSpace(r) {
+				t.Fatalf("%+v: accepted a name with control character %q", req, r)
+			}
+		}
+		if n := len(strings.TrimSpace(email)); n > 254 {
+			t.Fatalf("%+v: accepted a %d byte email", req, n)
+		}
+
+		// The stored user must itself be valid, so a round trip through the
+		// store and back to the API never produces a rejected record
+		user := NewUser(req, 1)
+		if err := ValidateCreateUserRequest(CreateUserRequest{Name: user.Name, Email: user.Email}); err != nil {
+			t.Fatalf("%+v: normalized user %+v is invalid: %v", req, user, err)
+		}
+	})
+}
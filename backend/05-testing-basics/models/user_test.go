@@ -3,6 +3,7 @@ package models
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -70,6 +71,44 @@ func TestValidateCreateUserRequest(t *testing.T) {
 			errorField:  "email",
 			errorMsg:    "email format is invalid",
 		},
+		{
+			name:        "100 multibyte characters is not too long",
+			request:     CreateUserRequest{Name: strings.Repeat("é", 100), Email: "jose@example.com"},
+			expectError: false,
+		},
+		{
+			name:        "101 multibyte characters is too long",
+			request:     CreateUserRequest{Name: strings.Repeat("é", 101), Email: "jose@example.com"},
+			expectError: true,
+			errorField:  "name",
+			errorMsg:    "name must be 100 characters or less",
+		},
+		{
+			name:        "surrounding whitespace does not count",
+			request:     CreateUserRequest{Name: "  " + strings.Repeat("a", 100) + "  ", Email: "  john@example.com\n"},
+			expectError: false,
+		},
+		{
+			name:        "control character in name",
+			request:     CreateUserRequest{Name: "John\x00Doe", Email: "john@example.com"},
+			expectError: true,
+			errorField:  "name",
+			errorMsg:    "name must not contain control characters",
+		},
+		{
+			name:        "invalid UTF-8 name",
+			request:     CreateUserRequest{Name: "Jo\xffhn", Email: "john@example.com"},
+			expectError: true,
+			errorField:  "name",
+			errorMsg:    "name must be valid UTF-8",
+		},
+		{
+			name:        "email too long",
+			request:     CreateUserRequest{Name: "John Doe", Email: strings.Repeat("a", 243) + "@example.com"},
+			expectError: true,
+			errorField:  "email",
+			errorMsg:    "email must be 254 characters or less",
+		},
 		{
 			name:        "valid email with numbers",
 			request:     CreateUserRequest{Name: "John Doe", Email: "john123@example.com"},
@@ -154,6 +193,15 @@ func TestNewUser(t *testing.T) {
 	}
 }
 
+func TestNewUserAt(t *testing.T) {
+	joinedAt := time.Date(2024, 2, 29, 23, 59, 58, 0, time.UTC)
+
+	user := NewUserAt(CreateUserRequest{Name: " Jane ", Email: "Jane@Example.com"}, 5, joinedAt)
+
+	// With the time passed in, the whole user is known in advance
+	assert.Equal(t, User{ID: 5, Name: "Jane", Email: "jane@example.com", JoinedAt: "2024-02-29 23:59:58"}, user)
+}
+
 func TestUser_IsEmpty(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,70 @@
+// Package server assembles the lab's HTTP server. main runs it as a
+// process; the E2E tests run the same code either through that process or
+// in-process on a random port.
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/clock"
+	"github.com/e6a5/learning/backend/05-testing-basics/handlers"
+	"github.com/e6a5/learning/backend/05-testing-basics/service"
+	"github.com/e6a5/learning/backend/05-testing-basics/store"
+)
+
+// shutdownTimeout bounds how long in-flight requests get once Serve is told
+// to stop
+const shutdownTimeout = 5 * time.Second
+
+// New returns the API: the users routes plus GET /health, which answers 200
+// as soon as the server accepts requests
+func New(users *service.UserService) http.Handler {
+	routes := handlers.NewUserHandler(users).Routes()
+
+	mux := http.NewServeMux()
+	mux.Handle("/users", routes)
+	mux.Handle("/users/", routes)
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"ok"}` + "\n"))
+	})
+	return mux
+}
+
+// NewInMemory returns the server main runs: users kept in memory, so every
+// start is empty, and stamped with the real clock
+func NewInMemory() http.Handler {
+	return New(service.NewUserService(store.NewMemoryStore(), service.NewSequence(), clock.Real{}))
+}
+
+// Serve answers requests on ln until ctx is done, then shuts down
+// gracefully. It returns nil after a clean shutdown.
+func Serve(ctx context.Context, ln net.Listener, handler http.Handler) error {
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
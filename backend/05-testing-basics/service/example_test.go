@@ -0,0 +1,42 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/clock"
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+	"github.com/e6a5/learning/backend/05-testing-basics/service"
+	"github.com/e6a5/learning/backend/05-testing-basics/store"
+)
+
+// With a fake clock every field of a registered user is known in advance
+func ExampleUserService_Register() {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC))
+	users := service.NewUserService(store.NewMemoryStore(), service.NewSequence(), clk)
+
+	alice, _ := users.Register(ctx, models.CreateUserRequest{Name: "Alice", Email: "Alice@Example.com"})
+	fmt.Printf("%+v\n", alice)
+
+	clk.Advance(time.Hour)
+	bob, _ := users.Register(ctx, models.CreateUserRequest{Name: "Bob", Email: "bob@example.com"})
+	fmt.Printf("%+v\n", bob)
+
+	_, err := users.Register(ctx, models.CreateUserRequest{Name: "Alice", Email: "alice@example.com"})
+	fmt.Println(err)
+	fmt.Println(errors.Is(err, store.ErrDuplicateEmail))
+	// Output:
+	// {ID:1 Name:Alice Email:alice@example.com JoinedAt:2024-01-15 09:30:00}
+	// {ID:2 Name:Bob Email:bob@example.com JoinedAt:2024-01-15 10:30:00}
+	// registering alice@example.com: email already registered
+	// true
+}
+
+func ExampleSequence() {
+	ids := service.NewSequence()
+	fmt.Println(ids.NextID(), ids.NextID(), ids.NextID())
+	// Output: 1 2 3
+}
@@ -0,0 +1,26 @@
+package service
+
+import "sync/atomic"
+
+//go:generate mockgen -source=ids.go -destination=mocks/ids.go -package=mocks
+
+// IDGenerator hands out user IDs
+type IDGenerator interface {
+	NextID() int
+}
+
+// Sequence counts up from 1. IDs used by failed registrations are not
+// reused, so there can be gaps, as with MySQL's AUTO_INCREMENT.
+type Sequence struct {
+	last atomic.Int64
+}
+
+// NewSequence returns a sequence whose first ID is 1
+func NewSequence() *Sequence {
+	return &Sequence{}
+}
+
+// NextID returns the next ID; safe for concurrent use
+func (s *Sequence) NextID() int {
+	return int(s.last.Add(1))
+}
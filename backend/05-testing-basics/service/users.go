@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/clock"
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+	"github.com/e6a5/learning/backend/05-testing-basics/store"
+)
+
+// UserService is the use case layer between HTTP and storage. Everything it
+// depends on is passed in, so tests choose per collaborator between the
+// real thing, a fake and a mock.
+type UserService struct {
+	store store.UserStore
+	ids   IDGenerator
+	clock clock.Clock
+}
+
+// NewUserService creates a service persisting to s, numbering users with
+// ids and stamping them with the time clk tells
+func NewUserService(s store.UserStore, ids IDGenerator, clk clock.Clock) *UserService {
+	return &UserService{store: s, ids: ids, clock: clk}
+}
+
+// Register validates the request, builds the user and stores it. Invalid
+// requests fail with models.UserValidationError before an ID is taken;
+// store errors such as store.ErrDuplicateEmail are wrapped.
+func (s *UserService) Register(ctx context.Context, req models.CreateUserRequest) (models.User, error) {
+	if err := models.ValidateCreateUserRequest(req); err != nil {
+		return models.User{}, err
+	}
+
+	user := models.NewUserAt(req, s.ids.NextID(), s.clock.Now())
+	if err := s.store.Create(ctx, user); err != nil {
+		return models.User{}, fmt.Errorf("registering %s: %w", user.Email, err)
+	}
+	return user, nil
+}
+
+// Get returns the user with the given ID
+func (s *UserService) Get(ctx context.Context, id int) (models.User, error) {
+	return s.store.Get(ctx, id)
+}
+
+// List returns every user ordered by ID
+func (s *UserService) List(ctx context.Context) ([]models.User, error) {
+	return s.store.List(ctx)
+}
+
+// Delete removes the user with the given ID
+func (s *UserService) Delete(ctx context.Context, id int) error {
+	return s.store.Delete(ctx, id)
+}
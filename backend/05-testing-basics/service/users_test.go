@@ -0,0 +1,157 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/clock"
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+	"github.com/e6a5/learning/backend/05-testing-basics/service"
+	servicemocks "github.com/e6a5/learning/backend/05-testing-basics/service/mocks"
+	"github.com/e6a5/learning/backend/05-testing-basics/store"
+	storemocks "github.com/e6a5/learning/backend/05-testing-basics/store/mocks"
+	"github.com/e6a5/learning/backend/05-testing-basics/testutil"
+)
+
+// Collaborators are chosen one by one. The store and the ID generator are
+// boundaries, so they are mocked to assert exactly what crosses them;
+// validation is pure code, so it runs for real; the clock is a fake, since
+// a mock would only restate the time it returns.
+type fixture struct {
+	svc   *service.UserService
+	store *storemocks.MockUserStore
+	ids   *servicemocks.MockIDGenerator
+	clock *clock.Fake
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	f := fixture{
+		store: storemocks.NewMockUserStore(ctrl),
+		ids:   servicemocks.NewMockIDGenerator(ctrl),
+		clock: testutil.NewClock(),
+	}
+	f.svc = service.NewUserService(f.store, f.ids, f.clock)
+	return f
+}
+
+func TestUserService_Register(t *testing.T) {
+	f := newFixture(t)
+	f.clock.Advance(time.Hour)
+
+	want := testutil.NewUser(
+		testutil.WithID(42),
+		testutil.WithName("Carol"),
+		testutil.WithEmail("carol@example.com"),
+		testutil.WithJoinedAt(testutil.FrozenTime.Add(time.Hour).Format(models.JoinedAtFormat)),
+	)
+	gomock.InOrder(
+		f.ids.EXPECT().NextID().Return(42),
+		f.store.EXPECT().Create(gomock.Any(), want).Return(nil),
+	)
+
+	got, err := f.svc.Register(context.Background(), models.CreateUserRequest{Name: " Carol ", Email: "Carol@Example.com"})
+
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+// No EXPECT() is set: an invalid request must neither take an ID nor reach
+// the store
+func TestUserService_RegisterInvalid(t *testing.T) {
+	f := newFixture(t)
+
+	_, err := f.svc.Register(context.Background(), models.CreateUserRequest{Name: "Carol", Email: "carol"})
+
+	var validationErr models.UserValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "email", validationErr.Field)
+}
+
+func TestUserService_RegisterStoreErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		storeErr error
+	}{
+		{"duplicate email", store.ErrDuplicateEmail},
+		{"duplicate ID", store.ErrDuplicateID},
+		{"storage down", errors.New("connection refused")},
+		{"deadline", context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.ids.EXPECT().NextID().Return(1)
+			f.store.EXPECT().Create(gomock.Any(), gomock.Any()).Return(tt.storeErr)
+
+			user, err := f.svc.Register(context.Background(), testutil.NewCreateUserRequest(testutil.WithEmail("dave@example.com")))
+
+			assert.ErrorIs(t, err, tt.storeErr, "wrapping must keep the cause for errors.Is")
+			assert.Contains(t, err.Error(), "dave@example.com")
+			assert.Zero(t, user)
+		})
+	}
+}
+
+// Reads and deletes add nothing to the store's behaviour, so one call each
+// is enough to prove they pass the context, arguments and results through
+func TestUserService_PassThrough(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	carol := testutil.NewUser(testutil.WithID(7))
+
+	f.store.EXPECT().Get(ctx, 7).Return(carol, nil)
+	f.store.EXPECT().List(ctx).Return([]models.User{carol}, nil)
+	f.store.EXPECT().Delete(ctx, 8).Return(store.ErrUserNotFound)
+
+	got, err := f.svc.Get(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, carol, got)
+
+	users, err := f.svc.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.User{carol}, users)
+
+	assert.ErrorIs(t, f.svc.Delete(ctx, 8), store.ErrUserNotFound)
+}
+
+func TestSequence(t *testing.T) {
+	seq := service.NewSequence()
+	assert.Equal(t, 1, seq.NextID())
+	assert.Equal(t, 2, seq.NextID())
+}
+
+// Run with -race: every concurrent caller must get a distinct ID
+func TestSequence_Concurrent(t *testing.T) {
+	const goroutines, perGoroutine = 8, 100
+	seq := service.NewSequence()
+
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perGoroutine {
+				id := seq.NextID()
+				mu.Lock()
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, goroutines*perGoroutine)
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/testutil"
+)
+
+// benchStores are the in-memory stores compared side by side. MySQLStore is
+// left out: its numbers would measure Docker and the network, not the code.
+var benchStores = []struct {
+	name string
+	new  func() UserStore
+}{
+	{"store=memory", func() UserStore { return NewMemoryStore() }},
+	{"store=sharded", func() UserStore { return NewShardedStore(DefaultShards) }},
+}
+
+// newBenchStore returns a store of the given kind already holding n users
+func newBenchStore(b *testing.B, newStore func() UserStore, n int) UserStore {
+	b.Helper()
+
+	s := newStore()
+	testutil.SeedUsers(b, s, testutil.Users(n)...)
+	return s
+}
+
+// Each benchmark is named store=<kind>/size=<n>, so
+// "benchstat -col /store" compares the stores at every size
+func BenchmarkStore_Get(b *testing.B) {
+	ctx := context.Background()
+	for _, bs := range benchStores {
+		b.Run(bs.name, func(b *testing.B) {
+			testutil.RunSizes(b, testutil.BenchSizes, func(b *testing.B, n int) {
+				s := newBenchStore(b, bs.new, n)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := s.Get(ctx, i%n+1); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkStore_List(b *testing.B) {
+	ctx := context.Background()
+	for _, bs := range benchStores {
+		b.Run(bs.name, func(b *testing.B) {
+			testutil.RunSizes(b, testutil.BenchSizes, func(b *testing.B, n int) {
+				s := newBenchStore(b, bs.new, n)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := s.List(ctx); err != nil {
+						b.Fatal(err)
+					}
+				}
+				testutil.ReportPerItem(b, n, "user")
+			})
+		})
+	}
+}
+
+// Create checks every stored email in MemoryStore but only an index in
+// ShardedStore, which is what this benchmark is meant to show. Each op
+// creates a user and deletes it again, so the store stays at n users.
+func BenchmarkStore_CreateDelete(b *testing.B) {
+	ctx := context.Background()
+	for _, bs := range benchStores {
+		b.Run(bs.name, func(b *testing.B) {
+			testutil.RunSizes(b, testutil.BenchSizes, func(b *testing.B, n int) {
+				s := newBenchStore(b, bs.new, n)
+				user := testutil.NewUser(testutil.WithID(n+1), testutil.WithEmail("bench@example.com"))
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := s.Create(ctx, user); err != nil {
+						b.Fatal(err)
+					}
+					if err := s.Delete(ctx, user.ID); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+// Readers on every CPU at once: where per-shard locks should pay off.
+// Compare runs with -cpu=1,4,8.
+func BenchmarkStore_GetParallel(b *testing.B) {
+	ctx := context.Background()
+	const n = 1000
+	for _, bs := range benchStores {
+		b.Run(bs.name, func(b *testing.B) {
+			s := newBenchStore(b, bs.new, n)
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					id := int(next.Add(1))%n + 1
+					if _, err := s.Get(ctx, id); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+)
+
+var _ UserStore = (*MemoryStore)(nil)
+
+// MemoryStore keeps users in a map, standing in for a database in tests. It
+// honours cancellation only before doing any work, since nothing it does
+// blocks.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	users map[int]models.User
+}
+
+// NewMemoryStore creates an empty store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{users: make(map[int]models.User)}
+}
+
+// Create stores the user unless its ID or email is taken
+func (s *MemoryStore) Create(ctx context.Context, user models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[user.ID]; exists {
+		return ErrDuplicateID
+	}
+	for _, existing := range s.users {
+		if existing.Email == user.Email {
+			return ErrDuplicateEmail
+		}
+	}
+
+	s.users[user.ID] = user
+	return nil
+}
+
+// Get returns the user with the given ID
+func (s *MemoryStore) Get(ctx context.Context, id int) (models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return models.User{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// List returns every user ordered by ID
+func (s *MemoryStore) List(ctx context.Context) ([]models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]models.User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+// Delete removes the user with the given ID
+func (s *MemoryStore) Delete(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[id]; !ok {
+		return ErrUserNotFound
+	}
+	delete(s.users, id)
+	return nil
+}
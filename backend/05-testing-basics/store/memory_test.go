@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+	"github.com/e6a5/learning/backend/05-testing-basics/testutil"
+)
+
+func TestMemoryStore_Create(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	alice := testutil.NewUser(testutil.WithID(1), testutil.WithEmail("alice@example.com"))
+	require.NoError(t, s.Create(ctx, alice))
+
+	got, err := s.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, alice, got, "the store keeps the user exactly as given")
+
+	assert.ErrorIs(t, s.Create(ctx, testutil.NewUser(testutil.WithID(2), testutil.WithEmail("alice@example.com"))), ErrDuplicateEmail)
+	assert.ErrorIs(t, s.Create(ctx, testutil.NewUser(testutil.WithID(1))), ErrDuplicateID)
+
+	users, err := s.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, users, 1, "failed creates store nothing")
+}
+
+func TestMemoryStore_GetListDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	users, err := s.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	// Inserted out of order; List sorts by ID
+	bob := testutil.NewUser(testutil.WithID(2), testutil.WithName("Bob"))
+	alice := testutil.NewUser(testutil.WithID(1), testutil.WithName("Alice"))
+	testutil.SeedUsers(t, s, bob, alice)
+
+	users, err = s.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.User{alice, bob}, users)
+
+	_, err = s.Get(ctx, 42)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	require.NoError(t, s.Delete(ctx, alice.ID))
+	assert.ErrorIs(t, s.Delete(ctx, alice.ID), ErrUserNotFound)
+
+	users, err = s.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.User{bob}, users)
+}
+
+func TestMemoryStore_CanceledContext(t *testing.T) {
+	s := NewMemoryStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, s.Create(ctx, testutil.NewUser()), context.Canceled)
+	_, err := s.List(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	users, err := s.List(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, users, "nothing is stored after a canceled create")
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+)
+
+// mysqlDuplicateEntry is MySQL's ER_DUP_ENTRY, raised by the primary key and
+// the unique email key alike; the key name in the message tells them apart
+const mysqlDuplicateEntry = 1062
+
+var _ UserStore = (*MySQLStore)(nil)
+
+// MySQLStore keeps users in MySQL. Run Migrate first. The DSN must not set
+// parseTime, since joined_at is scanned as the string models.User holds.
+type MySQLStore struct {
+	db *sql.DB
+}
+
+// NewMySQLStore creates a store on an open database
+func NewMySQLStore(db *sql.DB) *MySQLStore {
+	return &MySQLStore{db: db}
+}
+
+// Create inserts the user with the ID and join time it already has
+func (s *MySQLStore) Create(ctx context.Context, user models.User) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO users (id, name, email, joined_at) VALUES (?, ?, ?, ?)",
+		user.ID, user.Name, user.Email, user.JoinedAt)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			if strings.Contains(mysqlErr.Message, "users_email_unique") {
+				return ErrDuplicateEmail
+			}
+			return ErrDuplicateID
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	return nil
+}
+
+// Get returns the user with the given ID
+func (s *MySQLStore) Get(ctx context.Context, id int) (models.User, error) {
+	var user models.User
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, name, email, joined_at FROM users WHERE id = ?", id).
+		Scan(&user.ID, &user.Name, &user.Email, &user.JoinedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, ErrUserNotFound
+	}
+	if err != nil {
+		return models.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// List returns every user ordered by ID
+func (s *MySQLStore) List(ctx context.Context) ([]models.User, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, email, joined_at FROM users ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.JoinedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return users, nil
+}
+
+// Delete removes the user with the given ID
+func (s *MySQLStore) Delete(ctx context.Context, id int) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+//go:build integration
+
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tcmysql "github.com/testcontainers/testcontainers-go/modules/mysql"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/clock"
+	"github.com/e6a5/learning/backend/05-testing-basics/handlers"
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+	"github.com/e6a5/learning/backend/05-testing-basics/service"
+	"github.com/e6a5/learning/backend/05-testing-basics/store"
+	"github.com/e6a5/learning/backend/05-testing-basics/testutil"
+)
+
+// db is shared by every test in this file: starting MySQL takes seconds,
+// emptying a table takes milliseconds
+var db *sql.DB
+
+func TestMain(m *testing.M) {
+	os.Exit(runWithMySQL(m))
+}
+
+func runWithMySQL(m *testing.M) int {
+	ctx := context.Background()
+
+	container, err := startMySQL(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "starting MySQL container (is Docker running?): %v\n", err)
+		return 1
+	}
+	defer func() {
+		if err := container.Terminate(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "stopping MySQL container: %v\n", err)
+		}
+	}()
+
+	dsn, err := container.ConnectionString(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "getting MySQL DSN: %v\n", err)
+		return 1
+	}
+	if db, err = sql.Open("mysql", dsn); err != nil {
+		fmt.Fprintf(os.Stderr, "opening MySQL: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	if err := store.Migrate(ctx, db); err != nil {
+		fmt.Fprintf(os.Stderr, "migrating: %v\n", err)
+		return 1
+	}
+	return m.Run()
+}
+
+// startMySQL starts a throwaway MySQL 8 container. testcontainers panics when
+// it finds no Docker at all; that becomes an error like any other.
+func startMySQL(ctx context.Context) (container *tcmysql.MySQLContainer, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return tcmysql.Run(ctx, "mysql:8.0",
+		tcmysql.WithDatabase("testing_lab"),
+		tcmysql.WithUsername("lab"),
+		tcmysql.WithPassword("lab"),
+	)
+}
+
+// newMySQLStore empties the users table, so each test starts from nothing
+// and IDs start at 1 again
+func newMySQLStore(t *testing.T) *store.MySQLStore {
+	t.Helper()
+
+	_, err := db.Exec("TRUNCATE TABLE users")
+	require.NoError(t, err)
+	return store.NewMySQLStore(db)
+}
+
+func TestMySQLStore_CRUD(t *testing.T) {
+	ctx := context.Background()
+	s := newMySQLStore(t)
+
+	alice := testutil.NewUser(testutil.WithID(1), testutil.WithName("Alice"))
+	bob := testutil.NewUser(testutil.WithID(2), testutil.WithName("Bob"))
+	require.NoError(t, s.Create(ctx, bob))
+	require.NoError(t, s.Create(ctx, alice))
+
+	// What comes back from the database must equal what went in, including
+	// the DATETIME round trip of joined_at
+	got, err := s.Get(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alice, got)
+
+	users, err := s.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.User{alice, bob}, users)
+
+	require.NoError(t, s.Delete(ctx, alice.ID))
+	_, err = s.Get(ctx, alice.ID)
+	assert.ErrorIs(t, err, store.ErrUserNotFound)
+	assert.ErrorIs(t, s.Delete(ctx, alice.ID), store.ErrUserNotFound)
+
+	users, err = s.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.User{bob}, users)
+}
+
+// Unique keys are constraints only the real database enforces across
+// connections; MemoryStore only imitates them. The email key also ignores
+// case, through MySQL's default collation.
+func TestMySQLStore_DuplicateKeys(t *testing.T) {
+	ctx := context.Background()
+	s := newMySQLStore(t)
+
+	testutil.SeedUsers(t, s, testutil.NewUser(testutil.WithID(1), testutil.WithEmail("alice@example.com")))
+
+	err := s.Create(ctx, testutil.NewUser(testutil.WithID(2), testutil.WithEmail("ALICE@example.com")))
+	assert.ErrorIs(t, err, store.ErrDuplicateEmail)
+
+	err = s.Create(ctx, testutil.NewUser(testutil.WithID(1)))
+	assert.ErrorIs(t, err, store.ErrDuplicateID)
+}
+
+func TestMySQLStore_EmptyList(t *testing.T) {
+	users, err := newMySQLStore(t).List(context.Background())
+
+	require.NoError(t, err)
+	assert.NotNil(t, users, "an empty table must encode as [], not null")
+	assert.Empty(t, users)
+}
+
+func TestMySQLStore_ContextDeadline(t *testing.T) {
+	s := newMySQLStore(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+
+	_, err := s.List(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMigrate_Idempotent(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, store.Migrate(ctx, db))
+
+	var applied int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&applied))
+	assert.Equal(t, 1, applied)
+}
+
+// TestUsersAPI_MySQL runs the real handlers and service on the real store:
+// the same requests the unit tests send, but nothing is faked
+func TestUsersAPI_MySQL(t *testing.T) {
+	users := service.NewUserService(newMySQLStore(t), service.NewSequence(), clock.Real{})
+	server := httptest.NewServer(handlers.NewUserHandler(users).Routes())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/users", "application/json",
+		strings.NewReader(`{"name": "Carol", "email": "carol@example.com"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	location := resp.Header.Get("Location")
+
+	resp, err = http.Post(server.URL+"/users", "application/json",
+		strings.NewReader(`{"name": "Carol", "email": "carol@example.com"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodDelete, server.URL+location, nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + location)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
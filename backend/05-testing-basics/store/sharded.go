@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+)
+
+// DefaultShards is the shard count NewShardedStore uses for n <= 0
+const DefaultShards = 16
+
+var _ UserStore = (*ShardedStore)(nil)
+
+// ShardedStore is a MemoryStore built for concurrency: users are spread over
+// shards by ID, each with its own lock, so reads of different shards never
+// wait on each other. Emails must be unique across all shards, so writes
+// also take the email index lock, always before a shard lock; that single
+// order is what keeps Create and Delete from deadlocking.
+//
+// Its locking is exactly what -race and the stress tests are there to check.
+type ShardedStore struct {
+	emailsMu sync.Mutex
+	emails   map[string]int // email -> ID
+
+	shards []shard
+}
+
+type shard struct {
+	mu    sync.RWMutex
+	users map[int]models.User
+}
+
+// NewShardedStore creates an empty store with n shards
+func NewShardedStore(n int) *ShardedStore {
+	if n <= 0 {
+		n = DefaultShards
+	}
+	s := &ShardedStore{
+		emails: make(map[string]int),
+		shards: make([]shard, n),
+	}
+	for i := range s.shards {
+		s.shards[i].users = make(map[int]models.User)
+	}
+	return s
+}
+
+func (s *ShardedStore) shardFor(id int) *shard {
+	i := id % len(s.shards)
+	if i < 0 {
+		i = -i
+	}
+	return &s.shards[i]
+}
+
+// Create stores the user unless its ID or email is taken
+func (s *ShardedStore) Create(ctx context.Context, user models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.emailsMu.Lock()
+	defer s.emailsMu.Unlock()
+
+	if _, taken := s.emails[user.Email]; taken {
+		return ErrDuplicateEmail
+	}
+
+	sh := s.shardFor(user.ID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, exists := sh.users[user.ID]; exists {
+		return ErrDuplicateID
+	}
+	sh.users[user.ID] = user
+	s.emails[user.Email] = user.ID
+	return nil
+}
+
+// Get returns the user with the given ID, locking only its shard
+func (s *ShardedStore) Get(ctx context.Context, id int) (models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return models.User{}, err
+	}
+
+	sh := s.shardFor(id)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	user, ok := sh.users[id]
+	if !ok {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// List returns every user ordered by ID. Shards are read one after another,
+// so under concurrent writes the result need not be a single point in time.
+func (s *ShardedStore) List(ctx context.Context) ([]models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	users := make([]models.User, 0)
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.RLock()
+		for _, user := range sh.users {
+			users = append(users, user)
+		}
+		sh.mu.RUnlock()
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+// Delete removes the user with the given ID
+func (s *ShardedStore) Delete(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.emailsMu.Lock()
+	defer s.emailsMu.Unlock()
+
+	sh := s.shardFor(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	user, ok := sh.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+	delete(sh.users, id)
+	delete(s.emails, user.Email)
+	return nil
+}
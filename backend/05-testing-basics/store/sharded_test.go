@@ -0,0 +1,173 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+	"github.com/e6a5/learning/backend/05-testing-basics/testutil"
+)
+
+// The tests below only mean something under the race detector: run them
+// with make test-race, or go test -race ./store
+
+func TestShardedStore_Create(t *testing.T) {
+	ctx := context.Background()
+	s := NewShardedStore(4)
+
+	// IDs 1 and 2 land on different shards, so only the shared email index
+	// can catch the duplicate
+	alice := testutil.NewUser(testutil.WithID(1), testutil.WithEmail("alice@example.com"))
+	require.NoError(t, s.Create(ctx, alice))
+	assert.ErrorIs(t, s.Create(ctx, testutil.NewUser(testutil.WithID(2), testutil.WithEmail("alice@example.com"))), ErrDuplicateEmail)
+	assert.ErrorIs(t, s.Create(ctx, testutil.NewUser(testutil.WithID(1))), ErrDuplicateID)
+	assert.NoError(t, s.Create(ctx, testutil.NewUser(testutil.WithID(5))), "ID 5 shares a shard with ID 1")
+
+	got, err := s.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, alice, got)
+
+	// Deleting frees the email for someone else
+	require.NoError(t, s.Delete(ctx, 1))
+	assert.NoError(t, s.Create(ctx, testutil.NewUser(testutil.WithID(2), testutil.WithEmail("alice@example.com"))))
+}
+
+// Parallel subtests may share a fixture only if none of them writes to it.
+// The store is seeded before t.Run, and each subtest reads its own user.
+func TestShardedStore_ParallelReads(t *testing.T) {
+	s := NewShardedStore(0)
+	users := make([]models.User, 20)
+	for i := range users {
+		users[i] = testutil.NewUser(testutil.WithID(i + 1))
+	}
+	testutil.SeedUsers(t, s, users...)
+
+	for _, user := range users {
+		t.Run(fmt.Sprint(user.ID), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := s.Get(context.Background(), user.ID)
+			require.NoError(t, err)
+			assert.Equal(t, user, got)
+		})
+	}
+}
+
+// Many goroutines race to register the same email under different IDs, all
+// released at once. Exactly one may win, however the scheduler interleaves
+// them.
+func TestShardedStore_ConcurrentCreateSameEmail(t *testing.T) {
+	const goroutines = 50
+	ctx := context.Background()
+	s := NewShardedStore(8)
+
+	var wins, duplicates atomic.Int32
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			err := s.Create(ctx, testutil.NewUser(testutil.WithID(i+1), testutil.WithEmail("race@example.com")))
+			switch {
+			case err == nil:
+				wins.Add(1)
+			case assert.ErrorIs(t, err, ErrDuplicateEmail):
+				duplicates.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, wins.Load())
+	assert.EqualValues(t, goroutines-1, duplicates.Load())
+
+	users, err := s.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+}
+
+// Writers create and delete while readers Get and List. A single test run
+// proves little on its own; its value is that -race watches every access,
+// and that a lock-order mistake between Create and Delete would hang it.
+func TestShardedStore_Stress(t *testing.T) {
+	writers, readers, opsPerWriter := 8, 8, 200
+	if testing.Short() {
+		opsPerWriter = 20
+	}
+	ctx := context.Background()
+	s := NewShardedStore(4)
+
+	var writersWG, readersWG sync.WaitGroup
+	done := make(chan struct{})
+
+	for r := range readers {
+		readersWG.Add(1)
+		go func() {
+			defer readersWG.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if _, err := s.Get(ctx, r+1); err != nil {
+					assert.ErrorIs(t, err, ErrUserNotFound)
+				}
+				users, err := s.List(ctx)
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.IsIncreasing(t, ids(users), "List must stay sorted by ID")
+			}
+		}()
+	}
+
+	// Every writer owns its own IDs and emails, and keeps the even ones, so
+	// the final contents are known exactly
+	for w := range writers {
+		writersWG.Add(1)
+		go func() {
+			defer writersWG.Done()
+			for i := range opsPerWriter {
+				id := w*opsPerWriter + i + 1
+				user := testutil.NewUser(testutil.WithID(id), testutil.WithEmail(fmt.Sprintf("w%d-%d@example.com", w, i)))
+				if !assert.NoError(t, s.Create(ctx, user)) {
+					return
+				}
+				if id%2 == 1 {
+					assert.NoError(t, s.Delete(ctx, id))
+				}
+			}
+		}()
+	}
+
+	writersWG.Wait()
+	close(done)
+	readersWG.Wait()
+
+	users, err := s.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, users, writers*opsPerWriter/2)
+	for _, user := range users {
+		assert.Zero(t, user.ID%2, "user %d should have been deleted", user.ID)
+	}
+}
+
+func ids(users []models.User) []int {
+	out := make([]int, len(users))
+	for i, user := range users {
+		out[i] = user.ID
+	}
+	return out
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+)
+
+//go:generate mockgen -source=store.go -destination=mocks/user_store.go -package=mocks
+
+// ErrUserNotFound is returned when no user has the requested ID
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrDuplicateEmail is returned when another user already has the email
+var ErrDuplicateEmail = errors.New("email already registered")
+
+// ErrDuplicateID is returned when another user already has the ID
+var ErrDuplicateID = errors.New("user ID already exists")
+
+// UserStore persists users; deciding what a valid user is, its ID and when
+// it joined is the service's job. MemoryStore implements it for tests and
+// the lab, mocks.MockUserStore for failures a real store rarely produces on
+// demand.
+type UserStore interface {
+	// Create stores a complete user, ID included
+	Create(ctx context.Context, user models.User) error
+	// Get returns ErrUserNotFound when no user has the ID
+	Get(ctx context.Context, id int) (models.User, error)
+	// List returns every user ordered by ID
+	List(ctx context.Context) ([]models.User, error)
+	// Delete returns ErrUserNotFound when no user has the ID
+	Delete(ctx context.Context, id int) error
+}
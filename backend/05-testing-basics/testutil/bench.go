@@ -0,0 +1,35 @@
+package testutil
+
+import (
+	"fmt"
+	"testing"
+)
+
+// BenchSizes are the input sizes benchmarks sweep by default: enough to show
+// whether cost grows linearly, without making a full run slow
+var BenchSizes = []int{1, 10, 100, 1000}
+
+// RunSizes runs fn as one sub-benchmark per size, named "size=<n>".
+// benchstat reads key=value name parts as a dimension, so
+// "benchstat -col /size" lays sizes out as columns.
+func RunSizes(b *testing.B, sizes []int, fn func(b *testing.B, n int)) {
+	b.Helper()
+
+	for _, n := range sizes {
+		b.Run(fmt.Sprintf("size=%d", n), func(b *testing.B) {
+			fn(b, n)
+		})
+	}
+}
+
+// ReportPerItem adds an "ns/<unit>" metric: the time per op divided by the
+// items each op handles, so sizes can be compared directly. Call it after
+// the timed loop.
+func ReportPerItem(b *testing.B, items int, unit string) {
+	b.Helper()
+
+	if b.N == 0 || items == 0 {
+		return
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(items), "ns/"+unit)
+}
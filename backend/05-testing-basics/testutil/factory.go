@@ -0,0 +1,105 @@
+// Package testutil holds fixtures shared by the module's tests: user
+// factories, random data and HTTP request helpers. Only _test.go files
+// import it.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/clock"
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+)
+
+// FrozenTime is when NewClock stands still
+var FrozenTime = time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+
+// DefaultJoinedAt is the JoinedAt of every factory user unless overridden,
+// and of every user registered through a service on NewClock
+var DefaultJoinedAt = FrozenTime.Format(models.JoinedAtFormat)
+
+// NewClock returns a fake clock frozen at FrozenTime, so registered users
+// compare equal to factory users
+func NewClock() *clock.Fake {
+	return clock.NewFake(FrozenTime)
+}
+
+// sequence makes default names and emails unique, so two factory users never
+// collide on the store's unique email rule
+var sequence atomic.Int64
+
+// UserOption overrides one field of a factory user
+type UserOption func(*models.User)
+
+// WithID sets the user's ID
+func WithID(id int) UserOption {
+	return func(u *models.User) { u.ID = id }
+}
+
+// WithName sets the user's name
+func WithName(name string) UserOption {
+	return func(u *models.User) { u.Name = name }
+}
+
+// WithEmail sets the user's email
+func WithEmail(email string) UserOption {
+	return func(u *models.User) { u.Email = email }
+}
+
+// WithJoinedAt sets the user's join timestamp
+func WithJoinedAt(joinedAt string) UserOption {
+	return func(u *models.User) { u.JoinedAt = joinedAt }
+}
+
+// NewUser returns a valid user such as the store would hold. Without options
+// it is "User <n>" <user<n>@example.com> with ID n, n unique per process;
+// tests should set explicitly any field they assert on.
+func NewUser(opts ...UserOption) models.User {
+	n := int(sequence.Add(1))
+	user := models.User{
+		ID:       n,
+		Name:     fmt.Sprintf("User %d", n),
+		Email:    fmt.Sprintf("user%d@example.com", n),
+		JoinedAt: DefaultJoinedAt,
+	}
+	for _, opt := range opts {
+		opt(&user)
+	}
+	return user
+}
+
+// NewCreateUserRequest returns a valid request, built from the same options
+// as NewUser so one option list can describe both the request and the user
+// it should produce
+func NewCreateUserRequest(opts ...UserOption) models.CreateUserRequest {
+	user := NewUser(opts...)
+	return models.CreateUserRequest{Name: user.Name, Email: user.Email}
+}
+
+// Users returns n factory users with IDs 1 to n
+func Users(n int) []models.User {
+	users := make([]models.User, n)
+	for i := range users {
+		users[i] = NewUser(WithID(i + 1))
+	}
+	return users
+}
+
+// UserCreator is the part of a store that SeedUsers needs
+type UserCreator interface {
+	Create(ctx context.Context, user models.User) error
+}
+
+// SeedUsers stores each user in order, failing the test on the first error
+func SeedUsers(t testing.TB, s UserCreator, users ...models.User) {
+	t.Helper()
+
+	for _, user := range users {
+		if err := s.Create(context.Background(), user); err != nil {
+			t.Fatalf("seeding %+v: %v", user, err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package testutil
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+)
+
+// Every test that uses a factory assumes its defaults are valid
+func TestFactoriesProduceValidData(t *testing.T) {
+	r := Rand(t)
+
+	for i := 0; i < 100; i++ {
+		for _, req := range []models.CreateUserRequest{
+			NewCreateUserRequest(),
+			NewCreateUserRequest(WithRandomIdentity(r)),
+		} {
+			assert.NoError(t, models.ValidateCreateUserRequest(req), "%+v", req)
+		}
+	}
+}
+
+func TestNewUser(t *testing.T) {
+	first, second := NewUser(), NewUser()
+	assert.NotEqual(t, first.Email, second.Email, "defaults must not collide on email")
+	assert.Equal(t, DefaultJoinedAt, first.JoinedAt)
+
+	user := NewUser(WithID(7), WithName("Carol"), WithEmail("carol@example.com"), WithJoinedAt("2020-02-02 02:02:02"))
+	assert.Equal(t, models.User{ID: 7, Name: "Carol", Email: "carol@example.com", JoinedAt: "2020-02-02 02:02:02"}, user)
+}
+
+func TestNewRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     interface{}
+		wantBody string
+		wantType string
+	}{
+		{"no body", nil, "", ""},
+		{"raw string", `{"name":`, `{"name":`, "application/json"},
+		{"encoded value", models.CreateUserRequest{Name: "Carol", Email: "carol@example.com"},
+			`{"name":"Carol","email":"carol@example.com"}`, "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := NewRequest(t, http.MethodPost, "/users", tt.body)
+
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBody, string(body))
+			assert.Equal(t, tt.wantType, req.Header.Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// NewRequest builds a request for handler tests. A string or []byte body is
+// sent as is, so tests can send malformed JSON; anything else is encoded as
+// JSON. A non-nil body also sets Content-Type.
+func NewRequest(t testing.TB, method, target string, body interface{}) *http.Request {
+	t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("encoding request body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+// Serve runs req through handler and returns the recorded response
+func Serve(handler http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// Do builds a request with NewRequest and serves it
+func Do(t testing.TB, handler http.Handler, method, target string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return Serve(handler, NewRequest(t, method, target, body))
+}
+
+// DecodeJSON unmarshals the recorded body into T, failing the test with the
+// body in the message when it is not valid JSON for T
+func DecodeJSON[T any](t testing.TB, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decoding %T from %q: %v", v, rec.Body.String(), err)
+	}
+	return v
+}
@@ -0,0 +1,77 @@
+package testutil
+
+import (
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/e6a5/learning/backend/05-testing-basics/models"
+)
+
+// SeedEnv replays a failing run: TESTUTIL_SEED=<seed from the log> go test ...
+const SeedEnv = "TESTUTIL_SEED"
+
+const (
+	lowercase = "abcdefghijklmnopqrstuvwxyz"
+	letters   = lowercase + "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// Rand returns a random source for one test. The seed is logged when the
+// test fails, and SeedEnv overrides it, so random data never makes a failure
+// impossible to reproduce.
+func Rand(t testing.TB) *rand.Rand {
+	t.Helper()
+
+	seed := time.Now().UnixNano()
+	if value := os.Getenv(SeedEnv); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			t.Fatalf("%s=%q is not an integer", SeedEnv, value)
+		}
+		seed = parsed
+	}
+
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Logf("random data seed: %s=%d", SeedEnv, seed)
+		}
+	})
+	return rand.New(rand.NewSource(seed))
+}
+
+// RandomString returns n characters drawn from alphabet
+func RandomString(r *rand.Rand, alphabet string, n int) string {
+	var b strings.Builder
+	b.Grow(n)
+	for i := 0; i < n; i++ {
+		b.WriteByte(alphabet[r.Intn(len(alphabet))])
+	}
+	return b.String()
+}
+
+// RandomName returns a valid two-word name such as "Qvrad Lemix"
+func RandomName(r *rand.Rand) string {
+	return capitalize(RandomString(r, lowercase, 3+r.Intn(8))) + " " +
+		capitalize(RandomString(r, lowercase, 3+r.Intn(8)))
+}
+
+// RandomEmail returns a valid, mixed-case email such as "qVraD@lemix.com"
+func RandomEmail(r *rand.Rand) string {
+	return RandomString(r, letters, 3+r.Intn(10)) + "@" + RandomString(r, lowercase, 3+r.Intn(10)) + ".com"
+}
+
+// WithRandomIdentity gives a factory user a random name and email
+func WithRandomIdentity(r *rand.Rand) UserOption {
+	name, email := RandomName(r), RandomEmail(r)
+	return func(u *models.User) {
+		u.Name = name
+		u.Email = email
+	}
+}
+
+func capitalize(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
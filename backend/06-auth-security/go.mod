@@ -3,6 +3,8 @@ module github.com/e6a5/learning/backend/06-auth-security
 go 1.23.4
 
 require (
+	github.com/e6a5/learning/backend/22-oauth2-oidc v0.0.0
+	github.com/e6a5/learning/backend/pkg v0.0.0
 	github.com/go-sql-driver/mysql v1.9.2
 	github.com/golang-jwt/jwt/v5 v5.0.0
 	github.com/gorilla/mux v1.8.1
@@ -11,3 +13,10 @@ require (
 )
 
 require filippo.io/edwards25519 v1.1.0 // indirect
+
+// The token verifier and the shared helpers come from the modules next
+// door rather than published versions
+replace (
+	github.com/e6a5/learning/backend/22-oauth2-oidc => ../22-oauth2-oidc
+	github.com/e6a5/learning/backend/pkg => ../pkg
+)
@@ -19,6 +19,9 @@ import (
 	"golang.org/x/time/rate"
 
 	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/resource"
+	"github.com/e6a5/learning/backend/pkg/middleware"
 )
 
 // 🔐 Configuration
@@ -132,16 +135,6 @@ func (s *AuthServer) securityHeaders(next http.Handler) http.Handler {
 		w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
 		w.Header().Set("Content-Security-Policy", "default-src 'self'")
 
-		// CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
 		next.ServeHTTP(w, r)
 	})
 }
@@ -373,6 +366,20 @@ func (s *AuthServer) profileHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user)
 }
 
+// oidcProfileHandler shows what an access token from the OAuth2 provider
+// in 22-oauth2-oidc says. There is no database lookup: the user belongs to
+// the provider, and the token is all this server knows about them.
+func (s *AuthServer) oidcProfileHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := resource.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Invalid token context", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(claims)
+}
+
 func (s *AuthServer) usersHandler(w http.ResponseWriter, r *http.Request) {
 	query := `
 		SELECT id, username, email, role, created_at, updated_at, is_active, last_login
@@ -416,6 +423,7 @@ func (s *AuthServer) statusHandler(w http.ResponseWriter, r *http.Request) {
 			"POST /auth/login":    "Authenticate user and get JWT",
 			"GET /auth/profile":   "Get current user profile (auth required)",
 			"GET /users":          "List all users (admin only)",
+			"GET /oidc/profile":   "Claims of an OAuth2 access token (needs OIDC_ISSUER)",
 		},
 	})
 }
@@ -436,6 +444,28 @@ func initDB() (*sql.DB, error) {
 	return db, nil
 }
 
+// newOIDCVerifier checks access tokens from the provider in
+// 22-oauth2-oidc, or returns nil if OIDC_ISSUER is not set. With
+// OIDC_CLIENT_SECRET it asks the provider about every token, so revoked
+// tokens fail at once; otherwise it checks signatures locally.
+func newOIDCVerifier() *resource.Verifier {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		log.Println("ℹ️ OIDC_ISSUER not set, /oidc/profile is disabled")
+		return nil
+	}
+
+	var opts []resource.Option
+	if baseURL := os.Getenv("OIDC_BASE_URL"); baseURL != "" {
+		opts = append(opts, resource.WithBaseURL(baseURL))
+	}
+	if secret := os.Getenv("OIDC_CLIENT_SECRET"); secret != "" {
+		opts = append(opts, resource.WithIntrospection("learning-api", secret))
+	}
+	log.Printf("🪪 Accepting access tokens from %s", issuer)
+	return resource.NewVerifier(issuer, "learning-api", opts...)
+}
+
 func main() {
 	log.Println("🔐 Starting Authentication & Security Server...")
 
@@ -457,6 +487,7 @@ func main() {
 
 	// Apply security middleware to all routes
 	r.Use(server.securityHeaders)
+	r.Use(middleware.CORS())
 	r.Use(server.rateLimiter)
 
 	// Public routes
@@ -475,6 +506,11 @@ func main() {
 	admin.Use(server.adminOnly)
 	admin.HandleFunc("", server.usersHandler).Methods("GET")
 
+	// OAuth2 routes: access tokens from 22-oauth2-oidc instead of our own JWTs
+	if verifier := newOIDCVerifier(); verifier != nil {
+		r.Handle("/oidc/profile", verifier.Require("api:read")(http.HandlerFunc(server.oidcProfileHandler))).Methods("GET")
+	}
+
 	log.Printf("🚀 Server starting on port %s", ServerPort)
 	log.Println("📚 Available endpoints:")
 	log.Println("  GET  /                - Server status")
@@ -482,6 +518,7 @@ func main() {
 	log.Println("  POST /auth/login      - Authenticate user")
 	log.Println("  GET  /auth/profile    - Get user profile (auth required)")
 	log.Println("  GET  /users           - List users (admin only)")
+	log.Println("  GET  /oidc/profile    - OAuth2 token claims (api:read scope)")
 
 	if err := http.ListenAndServe(ServerPort, r); err != nil {
 		log.Fatal("❌ Server failed to start:", err)
@@ -0,0 +1,109 @@
+package apperrors
+
+import (
+	"sort"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+)
+
+// CatalogEntry documents one error code clients can receive
+type CatalogEntry struct {
+	Code        string           `json:"code"`
+	Type        models.ErrorType `json:"type"`
+	Status      int              `json:"http_status"`
+	Statuses    []int            `json:"http_statuses,omitempty"` // Set when the status is configurable
+	Retryable   bool             `json:"retryable"`
+	Description string           `json:"description"`
+}
+
+type codeDefinition struct {
+	kind        error
+	description string
+}
+
+// codes lists every error code the service emits. Add new codes here when
+// introducing them so GET /errors/catalog stays complete.
+var codes = map[string]codeDefinition{
+	// Client input
+	"INVALID_JSON":               {ErrValidation, "Request body is not valid JSON"},
+	"INVALID_BODY":               {ErrValidation, "Request body could not be read"},
+	"REQUEST_TOO_LARGE":          {ErrTooLarge, "Request body is over the size an Idempotency-Key request may have"},
+	"VALIDATION_FAILED":          {ErrValidation, "One or more fields failed validation; see details.field_errors"},
+	"INVALID_USER_ID":            {ErrValidation, "User ID in the path is not a number"},
+	"INVALID_ORDER":              {ErrValidation, "Order request is missing fields or has invalid values"},
+	"INVALID_CHAOS_CONFIG":       {ErrValidation, "Chaos configuration was rejected"},
+	"INVALID_DEGRADATION_MODE":   {ErrValidation, "Unknown dependency or mode in a degradation update"},
+	"INVALID_IDEMPOTENCY_KEY":    {ErrValidation, "Idempotency-Key header is longer than 255 characters"},
+	"IDEMPOTENCY_KEY_REUSED":     {ErrValidation, "Idempotency-Key was already used with a different request"},
+	"DLQ_INVALID_PAYLOAD":        {ErrValidation, "Dead letter payload cannot be decoded for replay"},
+	"DLQ_UNKNOWN_OPERATION":      {ErrValidation, "Dead letter has no replay handler for its operation"},
+	"SIMULATED_VALIDATION_ERROR": {ErrValidation, "Returned by POST /simulate/validation-error"},
+
+	// Missing resources and conflicts
+	"USER_NOT_FOUND":       {ErrNotFound, "No user exists with the given ID"},
+	"ORDER_NOT_FOUND":      {ErrNotFound, "No order exists with the given ID"},
+	"DLQ_ENTRY_NOT_FOUND":  {ErrNotFound, "No dead letter exists with the given ID"},
+	"EMAIL_ALREADY_EXISTS": {ErrConflict, "Another user already has this email"},
+	"REQUEST_IN_PROGRESS":  {ErrConflict, "A request with the same Idempotency-Key is still running"},
+	"ORDER_FAILED":         {ErrConflict, "An order step failed and completed steps were compensated"},
+
+	// Credentials
+	"ADMIN_AUTH_REQUIRED": {ErrUnauthorized, "Operator endpoint called without the admin token as a bearer token"},
+
+	// Load shedding
+	"RATE_LIMIT_EXCEEDED": {ErrRateLimited, "Too many requests from this IP or user; honor Retry-After"},
+
+	// Dependencies
+	"DATABASE_UNAVAILABLE":      {ErrUnavailable, "MySQL is unavailable; fallback_data holds cached results"},
+	"USER_FETCH_FAILED":         {ErrUnavailable, "Neither MySQL nor any cache could provide the data"},
+	"USER_CREATION_FAILED":      {ErrUnavailable, "User could not be written; details.dlq_id identifies the parked request"},
+	"CIRCUIT_BREAKER_OPEN":      {ErrUnavailable, "A dependency's circuit breaker is open; retry after the hint"},
+	"CAPACITY_EXCEEDED":         {ErrUnavailable, "A dependency's bulkhead is full"},
+	"DLQ_UNAVAILABLE":           {ErrUnavailable, "Dead letter store could not be read"},
+	"DLQ_REPLAY_FAILED":         {ErrUnavailable, "Replaying a dead letter failed; it stays in the queue"},
+	"SAGA_STORE_UNAVAILABLE":    {ErrUnavailable, "Saga state could not be read"},
+	"OUTBOX_UNAVAILABLE":        {ErrUnavailable, "Outbox status could not be read from MySQL"},
+	"HEDGED_REQUEST_FAILED":     {ErrUnavailable, "Both the primary and hedged attempts failed"},
+	"SHUTTING_DOWN":             {ErrUnavailable, "Instance is draining; retry against another instance"},
+	"CHAOS_INJECTED_ERROR":      {ErrUnavailable, "Injected by a chaos rule; the status follows the rule's error_status, 503 by default"},
+	"FEATURE_DISABLED":          {ErrUnavailable, "An operator disabled a dependency this endpoint needs"},
+	"READ_ONLY_MODE":            {ErrUnavailable, "An operator paused writes to a dependency; reads still work"},
+	"DEPENDENCY_DEGRADED":       {ErrUnavailable, "An operator put a dependency in cache-only mode and no cache had the data"},
+	"DEADLINE_BUDGET_EXHAUSTED": {ErrTimeout, "Work was skipped because the route's latency budget was nearly spent"},
+	"TIMEOUT":                   {ErrTimeout, "An operation exceeded its deadline"},
+	"REQUEST_CANCELED":          {ErrUnavailable, "The client went away or the server is stopping"},
+
+	// Server bugs
+	"INTERNAL_ERROR":            {ErrInternal, "Unexpected error"},
+	"PANIC_RECOVERED":           {ErrInternal, "A handler panicked; details.fingerprint identifies the report"},
+	"ORDER_COMPENSATION_FAILED": {ErrInternal, "An order failed and rolling it back failed too; needs manual repair"},
+}
+
+// configurableStatuses lists every status a code can come with when it is
+// not fixed by its kind; the kind's status is then only the default
+var configurableStatuses = map[string][]int{
+	"CHAOS_INJECTED_ERROR": {429, 500, 503, 504},
+}
+
+// Catalog returns every registered error code, sorted by code
+func Catalog() []CatalogEntry {
+	entries := make([]CatalogEntry, 0, len(codes))
+	for code, def := range codes {
+		m := kindMappings[def.kind]
+		entries = append(entries, CatalogEntry{
+			Code:        code,
+			Type:        m.errorType,
+			Status:      m.status,
+			Statuses:    configurableStatuses[code],
+			Retryable:   retryableKind(def.kind),
+			Description: def.description,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Code < entries[j].Code })
+	return entries
+}
+
+func retryableKind(kind error) bool {
+	return kind == ErrUnavailable || kind == ErrRateLimited || kind == ErrTimeout
+}
@@ -0,0 +1,133 @@
+package apperrors
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// codeArg is the position of the code argument in each constructor
+var codeArg = map[string]int{
+	"Validation":   0,
+	"NotFound":     0,
+	"Conflict":     0,
+	"TooLarge":     0,
+	"Unauthorized": 0,
+	"RateLimited":  0,
+	"Unavailable":  0,
+	"Internal":     0,
+	"FromStatus":   1,
+}
+
+// emittedCodes finds every code literal passed to a constructor or set as
+// Error.Code anywhere in the module
+func emittedCodes(t *testing.T, root string) map[string]string {
+	t.Helper()
+	found := map[string]string{}
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		record := func(expr ast.Expr) {
+			lit, ok := expr.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return
+			}
+			code, _ := strconv.Unquote(lit.Value)
+			found[code] = fset.Position(lit.Pos()).String()
+		}
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				name := ""
+				switch fn := n.Fun.(type) {
+				case *ast.SelectorExpr:
+					if pkg, ok := fn.X.(*ast.Ident); ok && pkg.Name == "apperrors" {
+						name = fn.Sel.Name
+					}
+				case *ast.Ident:
+					if file.Name.Name == "apperrors" {
+						name = fn.Name
+					}
+				}
+				if i, ok := codeArg[name]; ok && i < len(n.Args) {
+					record(n.Args[i])
+				}
+			case *ast.KeyValueExpr:
+				if key, ok := n.Key.(*ast.Ident); ok && key.Name == "Code" {
+					record(n.Value)
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	require.NoError(t, err)
+	return found
+}
+
+func TestCodes_CoverEveryEmittedCode(t *testing.T) {
+	found := emittedCodes(t, filepath.Join("..", ".."))
+	require.NotEmpty(t, found, "the scan should find the codes the handlers emit")
+
+	for code, where := range found {
+		_, ok := codes[code]
+		assert.True(t, ok, "%s emits %s, which is missing from codes in catalog.go", where, code)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	entries := Catalog()
+	require.Len(t, entries, len(codes))
+
+	byCode := map[string]CatalogEntry{}
+	for i, e := range entries {
+		if i > 0 {
+			assert.Less(t, entries[i-1].Code, e.Code, "entries are sorted by code")
+		}
+		byCode[e.Code] = e
+	}
+
+	tests := []struct {
+		code      string
+		status    int
+		statuses  []int
+		retryable bool
+	}{
+		{code: "INVALID_JSON", status: http.StatusBadRequest},
+		{code: "EMAIL_ALREADY_EXISTS", status: http.StatusConflict},
+		{code: "REQUEST_TOO_LARGE", status: http.StatusRequestEntityTooLarge},
+		{code: "RATE_LIMIT_EXCEEDED", status: http.StatusTooManyRequests, retryable: true},
+		{code: "CIRCUIT_BREAKER_OPEN", status: http.StatusServiceUnavailable, retryable: true},
+		{code: "TIMEOUT", status: http.StatusGatewayTimeout, retryable: true},
+		{code: "PANIC_RECOVERED", status: http.StatusInternalServerError},
+		{code: "CHAOS_INJECTED_ERROR", status: http.StatusServiceUnavailable, statuses: []int{429, 500, 503, 504}, retryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			e, ok := byCode[tt.code]
+			require.True(t, ok)
+			assert.Equal(t, tt.status, e.Status)
+			assert.Equal(t, tt.statuses, e.Statuses)
+			assert.Equal(t, tt.retryable, e.Retryable)
+		})
+	}
+}
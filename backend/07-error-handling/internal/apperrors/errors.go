@@ -0,0 +1,220 @@
+package apperrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/bulkhead"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/circuit"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/deadline"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/degrade"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+)
+
+// Sentinel errors describe what went wrong independently of transport.
+// Check them with errors.Is; use errors.As with *Error for the details.
+var (
+	ErrValidation   = errors.New("validation failed")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrTooLarge     = errors.New("request too large")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrUnavailable  = errors.New("dependency unavailable")
+	ErrTimeout      = errors.New("timeout")
+	ErrInternal     = errors.New("internal error")
+)
+
+// RetryHinter is implemented by errors that know when a retry could succeed,
+// such as an open circuit breaker
+type RetryHinter interface {
+	RetryAfterHint() time.Duration
+}
+
+// defaultRetryAfter is suggested for retryable errors without a better hint
+const defaultRetryAfter = time.Second
+
+// Error is a domain error carrying everything needed to build an APIError
+type Error struct {
+	Kind       error
+	Code       string
+	Message    string
+	Details    interface{}
+	Retryable  bool
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Unwrap exposes the underlying cause
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is matches the error's kind so errors.Is(err, ErrNotFound) works
+func (e *Error) Is(target error) bool { return e.Kind == target }
+
+// Validation reports invalid client input
+func Validation(code, message string, details interface{}) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Message: message, Details: details}
+}
+
+// NotFound reports a missing resource
+func NotFound(code, message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+// Conflict reports a request clashing with existing state
+func Conflict(code, message string) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message}
+}
+
+// TooLarge reports a request body over the accepted size
+func TooLarge(code, message string) *Error {
+	return &Error{Kind: ErrTooLarge, Code: code, Message: message}
+}
+
+// Unauthorized reports a request without the credentials it needs
+func Unauthorized(code, message string) *Error {
+	return &Error{Kind: ErrUnauthorized, Code: code, Message: message}
+}
+
+// RateLimited reports a client exceeding its request quota; it may retry later
+func RateLimited(code, message string) *Error {
+	return &Error{Kind: ErrRateLimited, Code: code, Message: message, Retryable: true}
+}
+
+// Unavailable reports a failing dependency; callers may retry later
+func Unavailable(code, message string, err error) *Error {
+	return &Error{Kind: ErrUnavailable, Code: code, Message: message, Retryable: true, Err: err}
+}
+
+// Internal reports an unexpected failure
+func Internal(code, message string, err error) *Error {
+	return &Error{Kind: ErrInternal, Code: code, Message: message, Err: err}
+}
+
+// FromStatus builds an error whose kind maps back to the given HTTP status
+func FromStatus(status int, code, message string) *Error {
+	for kind, m := range kindMappings {
+		if m.status == status {
+			return &Error{Kind: kind, Code: code, Message: message, Retryable: status >= 500 || status == http.StatusTooManyRequests}
+		}
+	}
+	return Internal(code, message, nil)
+}
+
+// WithRetryAfter sets an explicit retry hint
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	e.RetryAfter = d
+	return e
+}
+
+// WithDetails attaches extra context to the error
+func (e *Error) WithDetails(details interface{}) *Error {
+	e.Details = details
+	return e
+}
+
+type mapping struct {
+	errorType models.ErrorType
+	status    int
+}
+
+var kindMappings = map[error]mapping{
+	ErrValidation:   {models.ValidationError, http.StatusBadRequest},
+	ErrNotFound:     {models.ValidationError, http.StatusNotFound},
+	ErrConflict:     {models.ValidationError, http.StatusConflict},
+	ErrTooLarge:     {models.ValidationError, http.StatusRequestEntityTooLarge},
+	ErrUnauthorized: {models.AuthenticationError, http.StatusUnauthorized},
+	ErrRateLimited:  {models.RateLimitError, http.StatusTooManyRequests},
+	ErrUnavailable:  {models.ServiceUnavailable, http.StatusServiceUnavailable},
+	ErrTimeout:      {models.NetworkError, http.StatusGatewayTimeout},
+	ErrInternal:     {models.InternalError, http.StatusInternalServerError},
+}
+
+// ToAPIError converts any error into the APIError and status code sent to clients
+func ToAPIError(err error, requestID string) (models.APIError, int) {
+	appErr := classify(err)
+	m, ok := kindMappings[appErr.Kind]
+	if !ok {
+		m = kindMappings[ErrInternal]
+	}
+
+	apiError := models.APIError{
+		Type:      m.errorType,
+		Code:      appErr.Code,
+		Message:   appErr.Message,
+		Details:   appErr.Details,
+		RequestID: requestID,
+		Timestamp: time.Now(),
+		Retryable: appErr.Retryable,
+	}
+	if appErr.Retryable {
+		apiError.RetryAfterMs = retryAfter(appErr, err).Milliseconds()
+	}
+	return apiError, m.status
+}
+
+// retryAfter picks the most specific hint: explicit, then one found in the
+// error chain, then the default
+func retryAfter(appErr *Error, err error) time.Duration {
+	if appErr.RetryAfter > 0 {
+		return appErr.RetryAfter
+	}
+
+	var hinter RetryHinter
+	if errors.As(err, &hinter) {
+		if hint := hinter.RetryAfterHint(); hint > 0 {
+			return hint
+		}
+	}
+	return defaultRetryAfter
+}
+
+// classify turns infrastructure errors into domain errors
+func classify(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var degraded *degrade.Error
+	if errors.As(err, &degraded) {
+		return classifyDegraded(degraded, err)
+	}
+
+	switch {
+	case errors.Is(err, circuit.ErrOpen):
+		return Unavailable("CIRCUIT_BREAKER_OPEN", "Dependency temporarily unavailable", err)
+	case errors.Is(err, bulkhead.ErrBulkheadFull), errors.Is(err, bulkhead.ErrQueueTimeout):
+		return Unavailable("CAPACITY_EXCEEDED", "Too many concurrent requests to a dependency", err)
+	case errors.Is(err, deadline.ErrBudgetExhausted):
+		return &Error{Kind: ErrTimeout, Code: "DEADLINE_BUDGET_EXHAUSTED", Message: "Not enough time left to complete the request", Retryable: true, Err: err}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{Kind: ErrTimeout, Code: "TIMEOUT", Message: "Operation timed out", Retryable: true, Err: err}
+	case errors.Is(err, context.Canceled):
+		return Unavailable("REQUEST_CANCELED", "Request was cancelled before it completed", err)
+	default:
+		return Internal("INTERNAL_ERROR", "Internal server error occurred", err)
+	}
+}
+
+// classifyDegraded explains which operator-set mode refused the request
+func classifyDegraded(degraded *degrade.Error, err error) *Error {
+	switch {
+	case degraded.Mode == degrade.Disabled:
+		return Unavailable("FEATURE_DISABLED", fmt.Sprintf("This feature is switched off while %s is disabled", degraded.Dependency), err)
+	case degraded.Op == degrade.OpWrite:
+		return Unavailable("READ_ONLY_MODE", fmt.Sprintf("Writes are paused while %s is in %s mode", degraded.Dependency, degraded.Mode), err)
+	default:
+		return Unavailable("DEPENDENCY_DEGRADED", fmt.Sprintf("%s is in %s mode", degraded.Dependency, degraded.Mode), err)
+	}
+}
@@ -0,0 +1,170 @@
+package apperrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/bulkhead"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/circuit"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/deadline"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/degrade"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+)
+
+func TestToAPIError(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantCode     string
+		wantType     models.ErrorType
+		wantStatus   int
+		retryable    bool
+		retryAfterMs int64
+	}{
+		{
+			name:       "domain error",
+			err:        NotFound("USER_NOT_FOUND", "User not found"),
+			wantCode:   "USER_NOT_FOUND",
+			wantType:   models.ValidationError,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "wrapped domain error keeps its code",
+			err:        fmt.Errorf("create user: %w", Conflict("EMAIL_ALREADY_EXISTS", "taken")),
+			wantCode:   "EMAIL_ALREADY_EXISTS",
+			wantType:   models.ValidationError,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:         "explicit retry hint wins",
+			err:          RateLimited("RATE_LIMIT_EXCEEDED", "slow down").WithRetryAfter(3 * time.Second),
+			wantCode:     "RATE_LIMIT_EXCEEDED",
+			wantType:     models.RateLimitError,
+			wantStatus:   http.StatusTooManyRequests,
+			retryable:    true,
+			retryAfterMs: 3000,
+		},
+		{
+			name:         "wrapped open breaker uses its hint",
+			err:          fmt.Errorf("db call: %w", &circuit.OpenError{Name: "database", RetryAfter: 1500 * time.Millisecond}),
+			wantCode:     "CIRCUIT_BREAKER_OPEN",
+			wantType:     models.ServiceUnavailable,
+			wantStatus:   http.StatusServiceUnavailable,
+			retryable:    true,
+			retryAfterMs: 1500,
+		},
+		{
+			name:         "full bulkhead",
+			err:          fmt.Errorf("database: %w", bulkhead.ErrBulkheadFull),
+			wantCode:     "CAPACITY_EXCEEDED",
+			wantType:     models.ServiceUnavailable,
+			wantStatus:   http.StatusServiceUnavailable,
+			retryable:    true,
+			retryAfterMs: defaultRetryAfter.Milliseconds(),
+		},
+		{
+			name:         "exhausted budget",
+			err:          fmt.Errorf("database call: %w", deadline.ErrBudgetExhausted),
+			wantCode:     "DEADLINE_BUDGET_EXHAUSTED",
+			wantType:     models.NetworkError,
+			wantStatus:   http.StatusGatewayTimeout,
+			retryable:    true,
+			retryAfterMs: defaultRetryAfter.Milliseconds(),
+		},
+		{
+			name:         "context deadline",
+			err:          fmt.Errorf("query: %w", context.DeadlineExceeded),
+			wantCode:     "TIMEOUT",
+			wantType:     models.NetworkError,
+			wantStatus:   http.StatusGatewayTimeout,
+			retryable:    true,
+			retryAfterMs: defaultRetryAfter.Milliseconds(),
+		},
+		{
+			name:         "cancelled context",
+			err:          fmt.Errorf("query: %w", context.Canceled),
+			wantCode:     "REQUEST_CANCELED",
+			wantType:     models.ServiceUnavailable,
+			wantStatus:   http.StatusServiceUnavailable,
+			retryable:    true,
+			retryAfterMs: defaultRetryAfter.Milliseconds(),
+		},
+		{
+			name:         "disabled dependency",
+			err:          fmt.Errorf("orders: %w", &degrade.Error{Dependency: "database", Mode: degrade.Disabled, Op: degrade.OpFeature}),
+			wantCode:     "FEATURE_DISABLED",
+			wantType:     models.ServiceUnavailable,
+			wantStatus:   http.StatusServiceUnavailable,
+			retryable:    true,
+			retryAfterMs: defaultRetryAfter.Milliseconds(),
+		},
+		{
+			name:         "write refused in read-only mode",
+			err:          &degrade.Error{Dependency: "database", Mode: degrade.ReadOnly, Op: degrade.OpWrite},
+			wantCode:     "READ_ONLY_MODE",
+			wantType:     models.ServiceUnavailable,
+			wantStatus:   http.StatusServiceUnavailable,
+			retryable:    true,
+			retryAfterMs: defaultRetryAfter.Milliseconds(),
+		},
+		{
+			name:         "call refused in cache-only mode",
+			err:          &degrade.Error{Dependency: "redis", Mode: degrade.CacheOnly, Op: degrade.OpCall},
+			wantCode:     "DEPENDENCY_DEGRADED",
+			wantType:     models.ServiceUnavailable,
+			wantStatus:   http.StatusServiceUnavailable,
+			retryable:    true,
+			retryAfterMs: defaultRetryAfter.Milliseconds(),
+		},
+		{
+			name:       "unknown error is internal",
+			err:        errors.New("boom"),
+			wantCode:   "INTERNAL_ERROR",
+			wantType:   models.InternalError,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "status maps back to its kind",
+			err:        FromStatus(http.StatusGatewayTimeout, "CHAOS_INJECTED_ERROR", "injected"),
+			wantCode:   "CHAOS_INJECTED_ERROR",
+			wantType:   models.NetworkError,
+			wantStatus: http.StatusGatewayTimeout,
+			retryable:  true,
+			// No hint in the chain, so the default applies
+			retryAfterMs: defaultRetryAfter.Milliseconds(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiError, status := ToAPIError(tt.err, "req-1")
+
+			assert.Equal(t, tt.wantCode, apiError.Code)
+			assert.Equal(t, tt.wantType, apiError.Type)
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.retryable, apiError.Retryable)
+			assert.Equal(t, tt.retryAfterMs, apiError.RetryAfterMs)
+			assert.Equal(t, "req-1", apiError.RequestID)
+		})
+	}
+}
+
+func TestError_IsAndAs(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := fmt.Errorf("list users: %w", Unavailable("USER_FETCH_FAILED", "Unable to fetch users", cause))
+
+	assert.True(t, errors.Is(err, ErrUnavailable), "matches its kind through the wrap")
+	assert.True(t, errors.Is(err, cause), "unwraps to its cause")
+	assert.False(t, errors.Is(err, ErrNotFound))
+
+	var appErr *Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "USER_FETCH_FAILED", appErr.Code)
+}
@@ -0,0 +1,133 @@
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrBulkheadFull is returned when both the execution slots and the queue are taken
+	ErrBulkheadFull = errors.New("bulkhead is full")
+	// ErrQueueTimeout is returned when a queued call waits longer than the queue timeout
+	ErrQueueTimeout = errors.New("bulkhead queue timeout")
+)
+
+// Bulkhead bounds the number of concurrent calls into one dependency so a slow
+// dependency can only tie up its own share of goroutines
+type Bulkhead struct {
+	name         string
+	slots        chan struct{}
+	maxQueue     int
+	queueTimeout time.Duration
+	queued       int
+	rejected     int64
+	timedOut     int64
+	mutex        sync.Mutex
+}
+
+// Stats is a point-in-time view of a bulkhead
+type Stats struct {
+	Name          string `json:"name"`
+	MaxConcurrent int    `json:"max_concurrent"`
+	Active        int    `json:"active"`
+	MaxQueue      int    `json:"max_queue"`
+	Queued        int    `json:"queued"`
+	Rejected      int64  `json:"rejected_total"`
+	TimedOut      int64  `json:"timed_out_total"`
+}
+
+// New creates a bulkhead allowing maxConcurrent calls with up to maxQueue waiters.
+// It allows at least one call, since with no slots every call would wait forever,
+// and a negative queue is treated as none.
+func New(name string, maxConcurrent, maxQueue int, queueTimeout time.Duration) *Bulkhead {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	if maxQueue < 0 {
+		maxQueue = 0
+	}
+	return &Bulkhead{
+		name:         name,
+		slots:        make(chan struct{}, maxConcurrent),
+		maxQueue:     maxQueue,
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Call executes fn inside the bulkhead; it has the same shape as circuit.Breaker.Call
+// so the two can be composed
+func (b *Bulkhead) Call(fn func() error) error {
+	return b.CallContext(context.Background(), fn)
+}
+
+// CallContext executes fn once a slot is free, giving up when ctx is done
+// or the queue timeout elapses
+func (b *Bulkhead) CallContext(ctx context.Context, fn func() error) error {
+	if err := b.acquire(ctx); err != nil {
+		return err
+	}
+	defer func() { <-b.slots }()
+
+	return fn()
+}
+
+func (b *Bulkhead) acquire(ctx context.Context) error {
+	// Fast path: a slot is free right now
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	b.mutex.Lock()
+	if b.queued >= b.maxQueue {
+		b.rejected++
+		b.mutex.Unlock()
+		logrus.WithContext(ctx).WithField("bulkhead", b.name).Warn("Bulkhead full, rejecting call")
+		return fmt.Errorf("%s: %w", b.name, ErrBulkheadFull)
+	}
+	b.queued++
+	b.mutex.Unlock()
+
+	defer func() {
+		b.mutex.Lock()
+		b.queued--
+		b.mutex.Unlock()
+	}()
+
+	timer := time.NewTimer(b.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		b.mutex.Lock()
+		b.timedOut++
+		b.mutex.Unlock()
+		return fmt.Errorf("%s: %w", b.name, ErrQueueTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the current bulkhead usage
+func (b *Bulkhead) Stats() Stats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return Stats{
+		Name:          b.name,
+		MaxConcurrent: cap(b.slots),
+		Active:        len(b.slots),
+		MaxQueue:      b.maxQueue,
+		Queued:        b.queued,
+		Rejected:      b.rejected,
+		TimedOut:      b.timedOut,
+	}
+}
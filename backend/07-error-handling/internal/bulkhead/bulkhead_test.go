@@ -0,0 +1,121 @@
+package bulkhead
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// occupy holds every slot of b until the test ends
+func occupy(t *testing.T, b *Bulkhead) {
+	release := make(chan struct{})
+	for i := 0; i < cap(b.slots); i++ {
+		go func() {
+			_ = b.Call(func() error {
+				<-release
+				return nil
+			})
+		}()
+	}
+	require.Eventually(t, func() bool { return b.Stats().Active == cap(b.slots) }, time.Second, time.Millisecond)
+	t.Cleanup(func() { close(release) })
+}
+
+// queue starts a call that waits in b's queue and returns its result
+func queue(t *testing.T, ctx context.Context, b *Bulkhead) <-chan error {
+	queued := b.Stats().Queued
+	result := make(chan error, 1)
+	go func() {
+		result <- b.CallContext(ctx, func() error { return nil })
+	}()
+	require.Eventually(t, func() bool { return b.Stats().Queued == queued+1 }, time.Second, time.Millisecond)
+	return result
+}
+
+func TestNew_Limits(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxConcurrent  int
+		maxQueue       int
+		wantConcurrent int
+		wantQueue      int
+	}{
+		{name: "as given", maxConcurrent: 5, maxQueue: 10, wantConcurrent: 5, wantQueue: 10},
+		{name: "no slots allows one call", maxConcurrent: 0, maxQueue: 1, wantConcurrent: 1, wantQueue: 1},
+		{name: "negative values", maxConcurrent: -3, maxQueue: -1, wantConcurrent: 1, wantQueue: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New("test", tt.maxConcurrent, tt.maxQueue, time.Minute)
+
+			stats := b.Stats()
+			assert.Equal(t, tt.wantConcurrent, stats.MaxConcurrent)
+			assert.Equal(t, tt.wantQueue, stats.MaxQueue)
+
+			done := make(chan error, 1)
+			go func() { done <- b.Call(func() error { return nil }) }()
+			select {
+			case err := <-done:
+				assert.NoError(t, err)
+			case <-time.After(time.Second):
+				t.Fatal("a call into an idle bulkhead should run")
+			}
+		})
+	}
+}
+
+func TestBulkhead_FullQueue(t *testing.T) {
+	b := New("test", 1, 1, time.Second)
+	occupy(t, b)
+	waiting := queue(t, context.Background(), b)
+
+	err := b.Call(func() error {
+		t.Error("a rejected call must not run")
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBulkheadFull)
+	assert.Equal(t, int64(1), b.Stats().Rejected)
+
+	select {
+	case err := <-waiting:
+		t.Fatalf("the queued call should still be waiting, got %v", err)
+	default:
+	}
+}
+
+func TestBulkhead_QueueTimeout(t *testing.T) {
+	b := New("test", 1, 1, 20*time.Millisecond)
+	occupy(t, b)
+
+	start := time.Now()
+	err := b.Call(func() error { return nil })
+	assert.ErrorIs(t, err, ErrQueueTimeout)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	stats := b.Stats()
+	assert.Equal(t, int64(1), stats.TimedOut)
+	assert.Zero(t, stats.Queued, "a timed out call leaves the queue")
+}
+
+func TestBulkhead_CanceledWhileQueued(t *testing.T) {
+	b := New("test", 1, 1, time.Minute)
+	occupy(t, b)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	waiting := queue(t, ctx, b)
+	cancel()
+
+	select {
+	case err := <-waiting:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("canceling the context should end the wait")
+	}
+	stats := b.Stats()
+	assert.Zero(t, stats.Queued)
+	assert.Zero(t, stats.TimedOut, "a cancellation is not a queue timeout")
+}
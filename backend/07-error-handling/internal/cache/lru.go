@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Freshness describes how a cached value relates to its TTL
+type Freshness int
+
+const (
+	Miss Freshness = iota
+	Fresh
+	Stale
+)
+
+// Stats counts cache outcomes since startup
+type Stats struct {
+	Size      int   `json:"size"`
+	Capacity  int   `json:"capacity"`
+	Hits      int64 `json:"hits"`
+	StaleHits int64 `json:"stale_hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Refreshes int64 `json:"refreshes"`
+}
+
+type entry[K comparable, V any] struct {
+	key      K
+	value    V
+	storedAt time.Time
+}
+
+// LRU is a bounded cache with per-entry TTL. Entries past their TTL are served
+// as stale for up to staleTTL longer while a background refresh is attempted.
+type LRU[K comparable, V any] struct {
+	capacity   int
+	ttl        time.Duration
+	staleTTL   time.Duration
+	items      map[K]*list.Element
+	order      *list.List
+	revalidate func(K) (V, error)
+	refreshing map[K]bool
+	stats      Stats
+	mutex      sync.Mutex
+}
+
+// NewLRU creates a cache holding at most capacity entries
+func NewLRU[K comparable, V any](capacity int, ttl, staleTTL time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		capacity:   capacity,
+		ttl:        ttl,
+		staleTTL:   staleTTL,
+		items:      make(map[K]*list.Element),
+		order:      list.New(),
+		refreshing: make(map[K]bool),
+	}
+}
+
+// SetRevalidator registers the function used to refresh stale entries in the background
+func (c *LRU[K, V]) SetRevalidator(fn func(K) (V, error)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.revalidate = fn
+}
+
+// Get returns the value for key and whether it is fresh or stale
+func (c *LRU[K, V]) Get(key K) (V, Freshness) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		c.stats.Misses++
+		return zero, Miss
+	}
+
+	e := elem.Value.(*entry[K, V])
+	age := time.Since(e.storedAt)
+	switch {
+	case age <= c.ttl:
+		c.order.MoveToFront(elem)
+		c.stats.Hits++
+		return e.value, Fresh
+	case age <= c.ttl+c.staleTTL:
+		c.order.MoveToFront(elem)
+		c.stats.StaleHits++
+		c.scheduleRefresh(key)
+		return e.value, Stale
+	default:
+		c.removeElement(elem)
+		c.stats.Misses++
+		return zero, Miss
+	}
+}
+
+// Set stores value under key, evicting the least recently used entry if full
+func (c *LRU[K, V]) Set(key K, value V) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.set(key, value)
+}
+
+// Values returns every non-expired value, most recently used first
+func (c *LRU[K, V]) Values() []V {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	values := make([]V, 0, c.order.Len())
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*entry[K, V])
+		if time.Since(e.storedAt) <= c.ttl+c.staleTTL {
+			values = append(values, e.value)
+		}
+	}
+	return values
+}
+
+// Stats returns hit, miss and eviction counters
+func (c *LRU[K, V]) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+	stats.Capacity = c.capacity
+	return stats
+}
+
+func (c *LRU[K, V]) set(key K, value V) {
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry[K, V])
+		e.value = value
+		e.storedAt = time.Now()
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, storedAt: time.Now()})
+	if c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *LRU[K, V]) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry[K, V]).key)
+}
+
+// scheduleRefresh starts at most one background refresh per key; callers hold the mutex
+func (c *LRU[K, V]) scheduleRefresh(key K) {
+	if c.revalidate == nil || c.refreshing[key] {
+		return
+	}
+	c.refreshing[key] = true
+	revalidate := c.revalidate
+
+	go func() {
+		value, err := revalidate(key)
+
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		delete(c.refreshing, key)
+		if err == nil {
+			c.set(key, value)
+			c.stats.Refreshes++
+		}
+	}()
+}
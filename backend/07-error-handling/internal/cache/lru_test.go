@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU_Eviction(t *testing.T) {
+	tests := []struct {
+		name        string
+		ops         func(c *LRU[string, int])
+		wantPresent []string
+		wantEvicted []string
+	}{
+		{
+			name: "least recently set goes first",
+			ops: func(c *LRU[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Set("c", 3)
+				c.Set("d", 4)
+			},
+			wantPresent: []string{"b", "c", "d"},
+			wantEvicted: []string{"a"},
+		},
+		{
+			name: "a read protects an entry",
+			ops: func(c *LRU[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Set("c", 3)
+				c.Get("a")
+				c.Set("d", 4)
+			},
+			wantPresent: []string{"a", "c", "d"},
+			wantEvicted: []string{"b"},
+		},
+		{
+			name: "updating an entry does not evict",
+			ops: func(c *LRU[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Set("c", 3)
+				c.Set("a", 10)
+			},
+			wantPresent: []string{"a", "b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewLRU[string, int](3, time.Minute, time.Minute)
+			tt.ops(c)
+
+			for _, key := range tt.wantPresent {
+				_, freshness := c.Get(key)
+				assert.Equal(t, Fresh, freshness, key)
+			}
+			for _, key := range tt.wantEvicted {
+				_, freshness := c.Get(key)
+				assert.Equal(t, Miss, freshness, key)
+			}
+			stats := c.Stats()
+			assert.Equal(t, len(tt.wantPresent), stats.Size)
+			assert.Equal(t, int64(len(tt.wantEvicted)), stats.Evictions)
+		})
+	}
+}
+
+func TestLRU_Freshness(t *testing.T) {
+	c := NewLRU[string, int](10, 20*time.Millisecond, 20*time.Millisecond)
+	refreshed := make(chan string, 1)
+	c.SetRevalidator(func(key string) (int, error) {
+		refreshed <- key
+		return 2, nil
+	})
+	c.Set("a", 1)
+
+	value, freshness := c.Get("a")
+	assert.Equal(t, Fresh, freshness)
+	assert.Equal(t, 1, value)
+
+	time.Sleep(25 * time.Millisecond)
+	value, freshness = c.Get("a")
+	assert.Equal(t, Stale, freshness, "past the TTL but within the stale TTL")
+	assert.Equal(t, 1, value)
+
+	select {
+	case key := <-refreshed:
+		assert.Equal(t, "a", key)
+	case <-time.After(time.Second):
+		t.Fatal("a stale read should start a refresh")
+	}
+	assert.Eventually(t, func() bool {
+		value, freshness := c.Get("a")
+		return freshness == Fresh && value == 2
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestLRU_Expired(t *testing.T) {
+	c := NewLRU[string, int](10, 10*time.Millisecond, 10*time.Millisecond)
+	c.Set("a", 1)
+
+	time.Sleep(30 * time.Millisecond)
+	_, freshness := c.Get("a")
+	assert.Equal(t, Miss, freshness)
+	assert.Empty(t, c.Values())
+}
@@ -0,0 +1,194 @@
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrInjected marks failures produced by chaos injection rather than a real fault
+var ErrInjected = errors.New("chaos: injected failure")
+
+// MaxLatencyMs bounds a rule's injected delay. Route deadlines cut most delays
+// shorter; this keeps a typo from holding connections past the write timeout.
+const MaxLatencyMs = 30000
+
+var supportedStatus = map[int]bool{
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// Rule describes the faults injected into one route
+type Rule struct {
+	Route       string   `json:"route"`             // mux path template, or "*" for every route
+	Methods     []string `json:"methods,omitempty"` // empty means every method
+	LatencyRate float64  `json:"latency_rate"`
+	LatencyMs   int      `json:"latency_ms"`
+	ErrorRate   float64  `json:"error_rate"`
+	ErrorStatus int      `json:"error_status"` // 429, 500, 503 or 504; defaults to 503
+}
+
+// Config is the complete chaos configuration
+type Config struct {
+	Enabled      bool               `json:"enabled"`
+	Rules        []Rule             `json:"rules"`
+	Dependencies map[string]float64 `json:"dependencies"` // dependency name -> failure rate
+}
+
+// Stats counts injected faults since startup
+type Stats struct {
+	Latencies          int64            `json:"latencies"`
+	Errors             int64            `json:"errors"`
+	DependencyFailures map[string]int64 `json:"dependency_failures"`
+}
+
+// Injector decides, per call, whether to inject a fault
+type Injector struct {
+	config Config
+	stats  Stats
+	mutex  sync.RWMutex
+}
+
+// NewInjector creates an injector with the given configuration
+func NewInjector(config Config) (*Injector, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &Injector{
+		config: config,
+		stats:  Stats{DependencyFailures: make(map[string]int64)},
+	}, nil
+}
+
+// Validate checks rates and status codes
+func (c Config) Validate() error {
+	for i, rule := range c.Rules {
+		if rule.Route == "" {
+			return fmt.Errorf("rule %d: route is required", i)
+		}
+		if !validRate(rule.LatencyRate) || !validRate(rule.ErrorRate) {
+			return fmt.Errorf("rule %d: rates must be between 0 and 1", i)
+		}
+		if rule.LatencyMs < 0 || rule.LatencyMs > MaxLatencyMs {
+			return fmt.Errorf("rule %d: latency_ms must be between 0 and %d", i, MaxLatencyMs)
+		}
+		if rule.ErrorStatus != 0 && !supportedStatus[rule.ErrorStatus] {
+			return fmt.Errorf("rule %d: error_status must be one of 429, 500, 503, 504", i)
+		}
+	}
+	for name, rate := range c.Dependencies {
+		if !validRate(rate) {
+			return fmt.Errorf("dependency %s: rate must be between 0 and 1", name)
+		}
+	}
+	return nil
+}
+
+// Config returns the current configuration
+func (in *Injector) Config() Config {
+	in.mutex.RLock()
+	defer in.mutex.RUnlock()
+	return in.config
+}
+
+// SetConfig replaces the configuration at runtime
+func (in *Injector) SetConfig(config Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	in.mutex.Lock()
+	defer in.mutex.Unlock()
+	in.config = config
+	return nil
+}
+
+// Stats returns counters of injected faults
+func (in *Injector) Stats() Stats {
+	in.mutex.RLock()
+	defer in.mutex.RUnlock()
+
+	stats := in.stats
+	stats.DependencyFailures = make(map[string]int64, len(in.stats.DependencyFailures))
+	for name, count := range in.stats.DependencyFailures {
+		stats.DependencyFailures[name] = count
+	}
+	return stats
+}
+
+// Decision is the set of faults chosen for one request
+type Decision struct {
+	Delay       time.Duration
+	ErrorStatus int
+}
+
+// Decide rolls the dice for a request to route with method
+func (in *Injector) Decide(route, method string) Decision {
+	in.mutex.Lock()
+	defer in.mutex.Unlock()
+
+	var decision Decision
+	if !in.config.Enabled {
+		return decision
+	}
+
+	for _, rule := range in.config.Rules {
+		if !rule.matches(route, method) {
+			continue
+		}
+		if rule.LatencyRate > 0 && rand.Float64() < rule.LatencyRate {
+			decision.Delay += time.Duration(rule.LatencyMs) * time.Millisecond
+			in.stats.Latencies++
+		}
+		if decision.ErrorStatus == 0 && rule.ErrorRate > 0 && rand.Float64() < rule.ErrorRate {
+			decision.ErrorStatus = rule.ErrorStatus
+			if decision.ErrorStatus == 0 {
+				decision.ErrorStatus = http.StatusServiceUnavailable
+			}
+			in.stats.Errors++
+		}
+	}
+	return decision
+}
+
+// DependencyFault returns an injected error for the named dependency, or nil
+func (in *Injector) DependencyFault(name string) error {
+	in.mutex.Lock()
+	defer in.mutex.Unlock()
+
+	if !in.config.Enabled {
+		return nil
+	}
+
+	rate := in.config.Dependencies[name]
+	if rate <= 0 || rand.Float64() >= rate {
+		return nil
+	}
+
+	in.stats.DependencyFailures[name]++
+	return fmt.Errorf("%w: %s", ErrInjected, name)
+}
+
+func (r Rule) matches(route, method string) bool {
+	if r.Route != "*" && r.Route != route {
+		return false
+	}
+	if len(r.Methods) == 0 {
+		return true
+	}
+	for _, m := range r.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func validRate(rate float64) bool {
+	return rate >= 0 && rate <= 1
+}
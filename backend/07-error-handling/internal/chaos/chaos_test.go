@@ -0,0 +1,141 @@
+package chaos
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{name: "empty", config: Config{}},
+		{
+			name:   "full rule",
+			config: Config{Rules: []Rule{{Route: "/users", LatencyRate: 0.5, LatencyMs: MaxLatencyMs, ErrorRate: 1, ErrorStatus: 504}}},
+		},
+		{name: "missing route", config: Config{Rules: []Rule{{LatencyRate: 0.1}}}, wantErr: "route is required"},
+		{name: "rate above 1", config: Config{Rules: []Rule{{Route: "*", ErrorRate: 1.5}}}, wantErr: "rates must be between 0 and 1"},
+		{name: "negative rate", config: Config{Rules: []Rule{{Route: "*", LatencyRate: -0.1}}}, wantErr: "rates must be between 0 and 1"},
+		{name: "negative latency", config: Config{Rules: []Rule{{Route: "*", LatencyMs: -1}}}, wantErr: "latency_ms must be between"},
+		{name: "latency over the cap", config: Config{Rules: []Rule{{Route: "*", LatencyMs: MaxLatencyMs + 1}}}, wantErr: "latency_ms must be between"},
+		{name: "unsupported status", config: Config{Rules: []Rule{{Route: "*", ErrorStatus: 418}}}, wantErr: "error_status must be one of"},
+		{name: "dependency rate", config: Config{Dependencies: map[string]float64{"database": 2}}, wantErr: "dependency database"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestInjector_Decide(t *testing.T) {
+	rules := []Rule{
+		{Route: "/users", Methods: []string{http.MethodPost}, LatencyRate: 1, LatencyMs: 100},
+		{Route: "*", LatencyRate: 1, LatencyMs: 20, ErrorRate: 1},
+		{Route: "/users", ErrorRate: 1, ErrorStatus: http.StatusTooManyRequests},
+	}
+
+	tests := []struct {
+		name       string
+		enabled    bool
+		route      string
+		method     string
+		wantDelay  time.Duration
+		wantStatus int
+	}{
+		{name: "disabled injects nothing", route: "/users", method: http.MethodPost},
+		{
+			name: "matching rules add their delays", enabled: true, route: "/users", method: http.MethodPost,
+			wantDelay: 120 * time.Millisecond, wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "method filter skips a rule", enabled: true, route: "/users", method: http.MethodGet,
+			wantDelay: 20 * time.Millisecond, wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "wildcard matches any route", enabled: true, route: "/orders", method: http.MethodGet,
+			wantDelay: 20 * time.Millisecond, wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			injector, err := NewInjector(Config{Enabled: tt.enabled, Rules: rules})
+			require.NoError(t, err)
+
+			decision := injector.Decide(tt.route, tt.method)
+			assert.Equal(t, tt.wantDelay, decision.Delay)
+			assert.Equal(t, tt.wantStatus, decision.ErrorStatus, "the first rule to fire picks the status")
+		})
+	}
+}
+
+func TestInjector_DecideCountsFaults(t *testing.T) {
+	injector, err := NewInjector(Config{Enabled: true, Rules: []Rule{
+		{Route: "/users", LatencyRate: 1, LatencyMs: 1},
+		{Route: "/orders", ErrorRate: 1, ErrorStatus: http.StatusGatewayTimeout},
+		{Route: "/health"},
+	}})
+	require.NoError(t, err)
+
+	injector.Decide("/users", http.MethodGet)
+	injector.Decide("/users", http.MethodGet)
+	assert.Equal(t, http.StatusGatewayTimeout, injector.Decide("/orders", http.MethodGet).ErrorStatus)
+	assert.Equal(t, Decision{}, injector.Decide("/health", http.MethodGet), "zero rates never fire")
+
+	stats := injector.Stats()
+	assert.Equal(t, int64(2), stats.Latencies)
+	assert.Equal(t, int64(1), stats.Errors)
+}
+
+func TestInjector_DependencyFault(t *testing.T) {
+	injector, err := NewInjector(Config{Enabled: true, Dependencies: map[string]float64{"database": 1, "redis": 0}})
+	require.NoError(t, err)
+
+	err = injector.DependencyFault("database")
+	assert.True(t, errors.Is(err, ErrInjected))
+	assert.Contains(t, err.Error(), "database")
+	assert.NoError(t, injector.DependencyFault("redis"))
+	assert.NoError(t, injector.DependencyFault("unknown"))
+
+	stats := injector.Stats()
+	assert.Equal(t, map[string]int64{"database": 1}, stats.DependencyFailures)
+
+	// Stats hands out a copy, so callers cannot change the counters
+	stats.DependencyFailures["database"] = 100
+	assert.Equal(t, int64(1), injector.Stats().DependencyFailures["database"])
+
+	require.NoError(t, injector.SetConfig(Config{Dependencies: map[string]float64{"database": 1}}))
+	assert.NoError(t, injector.DependencyFault("database"), "disabled config injects nothing")
+}
+
+func TestInjector_SetConfig(t *testing.T) {
+	_, err := NewInjector(Config{Rules: []Rule{{Route: "*", LatencyMs: MaxLatencyMs + 1}}})
+	assert.Error(t, err)
+
+	original := Config{Enabled: true, Rules: []Rule{{Route: "/users", ErrorRate: 1}}}
+	injector, err := NewInjector(original)
+	require.NoError(t, err)
+
+	assert.Error(t, injector.SetConfig(Config{Enabled: true, Rules: []Rule{{Route: ""}}}))
+	assert.Equal(t, original, injector.Config(), "a rejected config leaves the current one in place")
+
+	replacement := Config{Enabled: false}
+	require.NoError(t, injector.SetConfig(replacement))
+	assert.Equal(t, replacement, injector.Config())
+}
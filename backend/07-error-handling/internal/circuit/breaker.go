@@ -1,6 +1,8 @@
 package circuit
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -17,6 +19,29 @@ const (
 	HalfOpen
 )
 
+// ErrOpen matches every OpenError via errors.Is
+var ErrOpen = errors.New("circuit breaker is open")
+
+// OpenError is returned while the circuit is open and says when it will probe again
+type OpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	if e.RetryAfter <= 0 {
+		// Half-open with its probe still in flight
+		return fmt.Sprintf("circuit breaker is open for %s (probe in flight)", e.Name)
+	}
+	return fmt.Sprintf("circuit breaker is open for %s (retry in %s)", e.Name, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Is makes errors.Is(err, ErrOpen) true
+func (e *OpenError) Is(target error) bool { return target == ErrOpen }
+
+// RetryAfterHint reports how long until the breaker lets a probe through
+func (e *OpenError) RetryAfterHint() time.Duration { return e.RetryAfter }
+
 // Breaker implements the circuit breaker pattern
 type Breaker struct {
 	name         string
@@ -26,6 +51,7 @@ type Breaker struct {
 	failures     int
 	lastFailTime time.Time
 	successCount int
+	probing      bool
 	mutex        sync.RWMutex
 }
 
@@ -41,49 +67,108 @@ func New(name string, maxFailures int, resetTimeout time.Duration) *Breaker {
 
 // Call executes the given function with circuit breaker protection
 func (cb *Breaker) Call(fn func() error) error {
-	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
+	return cb.CallContext(context.Background(), fn)
+}
 
-	switch cb.state {
-	case Open:
-		if time.Since(cb.lastFailTime) > cb.resetTimeout {
-			cb.state = HalfOpen
-			cb.successCount = 0
-			logrus.WithField("circuit", cb.name).Info("Circuit breaker moved to half-open state")
-		} else {
-			return fmt.Errorf("circuit breaker is open for %s", cb.name)
-		}
+// CallContext is Call for work bound to ctx. A failure caused by ctx ending
+// is the caller running out of time, not the dependency failing, so it is
+// not counted towards opening the breaker.
+//
+// The mutex is held only to check the state and to record the outcome, never
+// while fn runs, so a slow dependency does not serialize every caller.
+func (cb *Breaker) CallContext(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := cb.allow(); err != nil {
+		return err
 	}
 
 	err := fn()
 
-	if err != nil {
-		cb.failures++
-		cb.lastFailTime = time.Now()
-
-		if cb.state == HalfOpen || cb.failures >= cb.maxFailures {
-			cb.state = Open
-			logrus.WithFields(logrus.Fields{
-				"circuit":  cb.name,
-				"failures": cb.failures,
-			}).Warn("Circuit breaker opened")
-		}
+	if err != nil && ctx.Err() != nil {
+		cb.abandon()
 		return err
 	}
+	cb.done(err == nil)
+	return err
+}
+
+// allow decides whether one call may go through. In half-open only one probe
+// is in flight at a time, so a dependency that is still down gets one request.
+func (cb *Breaker) allow() error {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
 
-	// Success
+	if cb.state == Open {
+		if time.Since(cb.lastFailTime) <= cb.resetTimeout {
+			return &OpenError{Name: cb.name, RetryAfter: cb.resetTimeout - time.Since(cb.lastFailTime)}
+		}
+		cb.state = HalfOpen
+		cb.successCount = 0
+		logrus.WithField("circuit", cb.name).Info("Circuit breaker moved to half-open state")
+	}
 	if cb.state == HalfOpen {
+		if cb.probing {
+			return &OpenError{Name: cb.name}
+		}
+		cb.probing = true
+	}
+	return nil
+}
+
+// done records the outcome of a call that allow let through
+func (cb *Breaker) done(success bool) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	switch cb.state {
+	case HalfOpen:
+		cb.probing = false
+		if !success {
+			cb.trip()
+			return
+		}
 		cb.successCount++
 		if cb.successCount >= 3 { // Require 3 successes to close
 			cb.state = Closed
 			cb.failures = 0
 			logrus.WithField("circuit", cb.name).Info("Circuit breaker closed")
 		}
-	} else {
-		cb.failures = 0
+	case Closed:
+		if success {
+			cb.failures = 0
+			return
+		}
+		cb.failures++
+		cb.lastFailTime = time.Now()
+		if cb.failures >= cb.maxFailures {
+			cb.trip()
+		}
 	}
+	// Open: the call started before the breaker opened and adds nothing
+}
 
-	return nil
+// abandon frees the probe slot of a call that ended without a verdict
+func (cb *Breaker) abandon() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.state == HalfOpen {
+		cb.probing = false
+	}
+}
+
+// trip opens the breaker; the caller holds the mutex
+func (cb *Breaker) trip() {
+	if cb.state == HalfOpen {
+		cb.failures++
+	}
+	cb.state = Open
+	cb.lastFailTime = time.Now()
+	logrus.WithFields(logrus.Fields{
+		"circuit":  cb.name,
+		"failures": cb.failures,
+	}).Warn("Circuit breaker opened")
 }
 
 // GetState returns the current state of the circuit breaker
@@ -132,4 +217,5 @@ func (cb *Breaker) Reset() {
 	cb.state = Closed
 	cb.failures = 0
 	cb.successCount = 0
+	cb.probing = false
 }
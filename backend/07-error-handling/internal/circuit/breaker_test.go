@@ -0,0 +1,177 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDown = errors.New("dependency down")
+
+func fail() error    { return errDown }
+func succeed() error { return nil }
+
+// cooledDown moves the last failure back past the reset timeout, so the
+// next call is let through as a probe
+func cooledDown(cb *Breaker) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.lastFailTime = time.Now().Add(-2 * cb.resetTimeout)
+}
+
+func TestBreaker_TripsAtMaxFailures(t *testing.T) {
+	cb := New("db", 3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		assert.ErrorIs(t, cb.Call(fail), errDown)
+	}
+	assert.Equal(t, "closed", cb.GetState())
+
+	// A success in between starts the count again
+	require.NoError(t, cb.Call(succeed))
+	assert.Zero(t, cb.GetFailures())
+
+	for i := 0; i < 3; i++ {
+		assert.ErrorIs(t, cb.Call(fail), errDown)
+	}
+	assert.Equal(t, "open", cb.GetState())
+
+	called := false
+	err := cb.Call(func() error {
+		called = true
+		return nil
+	})
+	assert.False(t, called, "an open breaker does not call the dependency")
+	assert.ErrorIs(t, err, ErrOpen)
+	var openErr *OpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.Equal(t, "db", openErr.Name)
+	assert.Greater(t, openErr.RetryAfterHint(), time.Duration(0))
+	assert.LessOrEqual(t, openErr.RetryAfterHint(), time.Minute)
+}
+
+func TestBreaker_OneProbeAtATime(t *testing.T) {
+	cb := New("db", 1, time.Minute)
+	assert.ErrorIs(t, cb.Call(fail), errDown)
+	cooledDown(cb)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	probeErr := make(chan error, 1)
+	go func() {
+		probeErr <- cb.Call(func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	assert.Equal(t, "half-open", cb.GetState())
+
+	// Everyone else is turned away while the probe is out
+	called := false
+	err := cb.Call(func() error {
+		called = true
+		return nil
+	})
+	assert.False(t, called)
+	var openErr *OpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.Zero(t, openErr.RetryAfterHint(), "there is no time to wait for, only the probe")
+	assert.Contains(t, err.Error(), "probe in flight")
+
+	close(release)
+	require.NoError(t, <-probeErr)
+	require.NoError(t, cb.Call(succeed), "the next probe is let through once the first returns")
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	t.Run("closes after three successes", func(t *testing.T) {
+		cb := New("db", 1, time.Minute)
+		assert.ErrorIs(t, cb.Call(fail), errDown)
+		cooledDown(cb)
+
+		for i := 1; i <= 2; i++ {
+			require.NoError(t, cb.Call(succeed))
+			assert.Equal(t, "half-open", cb.GetState())
+			assert.Equal(t, i, cb.GetSuccessCount())
+		}
+		require.NoError(t, cb.Call(succeed))
+		assert.Equal(t, "closed", cb.GetState())
+		assert.Zero(t, cb.GetFailures())
+	})
+
+	t.Run("a failed probe opens it again", func(t *testing.T) {
+		cb := New("db", 1, time.Minute)
+		assert.ErrorIs(t, cb.Call(fail), errDown)
+		cooledDown(cb)
+
+		require.NoError(t, cb.Call(succeed))
+		assert.ErrorIs(t, cb.Call(fail), errDown)
+		assert.Equal(t, "open", cb.GetState())
+		assert.ErrorIs(t, cb.Call(succeed), ErrOpen, "and restarts the timeout")
+
+		cooledDown(cb)
+		require.NoError(t, cb.Call(succeed))
+		assert.Equal(t, 1, cb.GetSuccessCount(), "successes before the failure do not count")
+	})
+}
+
+func TestBreaker_ContextEnded(t *testing.T) {
+	t.Run("does not count as a failure", func(t *testing.T) {
+		cb := New("db", 1, time.Minute)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		err := cb.CallContext(ctx, func() error {
+			cancel()
+			return ctx.Err()
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, "closed", cb.GetState())
+		assert.Zero(t, cb.GetFailures())
+	})
+
+	t.Run("frees the probe slot without a verdict", func(t *testing.T) {
+		cb := New("db", 1, time.Minute)
+		assert.ErrorIs(t, cb.Call(fail), errDown)
+		cooledDown(cb)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err := cb.CallContext(ctx, func() error {
+			cancel()
+			return errDown
+		})
+		assert.ErrorIs(t, err, errDown)
+		assert.Equal(t, "half-open", cb.GetState(), "the probe neither opened nor closed the breaker")
+		assert.Zero(t, cb.GetSuccessCount())
+
+		require.NoError(t, cb.Call(succeed), "another probe may go")
+	})
+
+	t.Run("an ended context is refused before the call", func(t *testing.T) {
+		cb := New("db", 1, time.Minute)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		called := false
+		err := cb.CallContext(ctx, func() error {
+			called = true
+			return nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, called)
+	})
+}
+
+func TestBreaker_Reset(t *testing.T) {
+	cb := New("db", 1, time.Minute)
+	assert.ErrorIs(t, cb.Call(fail), errDown)
+	require.Equal(t, "open", cb.GetState())
+
+	cb.Reset()
+	assert.Equal(t, "closed", cb.GetState())
+	require.NoError(t, cb.Call(succeed))
+}
@@ -0,0 +1,58 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Header carries the caller's remaining budget in milliseconds, both on
+// incoming requests and on calls this service makes downstream
+const Header = "X-Request-Timeout-Ms"
+
+// ErrBudgetExhausted is returned instead of starting work that cannot finish
+// before the caller's deadline
+var ErrBudgetExhausted = errors.New("deadline budget exhausted")
+
+// Remaining returns the time left before ctx's deadline; ok is false when
+// ctx has no deadline
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+// Check fails fast when less than need is left of ctx's budget
+func Check(ctx context.Context, operation string, need time.Duration) error {
+	remaining, ok := Remaining(ctx)
+	if !ok || remaining >= need {
+		return nil
+	}
+	return fmt.Errorf("%s needs %v but only %v left: %w", operation, need, remaining.Round(time.Millisecond), ErrBudgetExhausted)
+}
+
+// ParseHeader reads a budget from the Header value; ok is false if it is
+// missing or not a positive number
+func ParseHeader(value string) (time.Duration, bool) {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ms <= 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// FormatHeader renders the budget left in ctx for an outgoing request
+func FormatHeader(ctx context.Context) (string, bool) {
+	remaining, ok := Remaining(ctx)
+	if !ok {
+		return "", false
+	}
+	if remaining < time.Millisecond {
+		remaining = time.Millisecond
+	}
+	return strconv.FormatInt(remaining.Milliseconds(), 10), true
+}
@@ -0,0 +1,81 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check(context.Background(), "query", time.Hour), "no deadline means no limit")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, Check(ctx, "query", 10*time.Millisecond))
+
+	err := Check(ctx, "query", 2*time.Second)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrBudgetExhausted))
+	assert.Contains(t, err.Error(), "query needs 2s")
+}
+
+func TestRemaining(t *testing.T) {
+	_, ok := Remaining(context.Background())
+	assert.False(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	remaining, ok := Remaining(ctx)
+	assert.True(t, ok)
+	assert.InDelta(t, time.Second, remaining, float64(100*time.Millisecond))
+}
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "250", want: 250 * time.Millisecond, wantOK: true},
+		{value: ""},
+		{value: "0"},
+		{value: "-5"},
+		{value: "1.5"},
+		{value: "soon"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := ParseHeader(tt.value)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatHeader(t *testing.T) {
+	_, ok := FormatHeader(context.Background())
+	assert.False(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	value, ok := FormatHeader(ctx)
+	require.True(t, ok)
+	ms, err := strconv.Atoi(value)
+	require.NoError(t, err)
+	assert.InDelta(t, 2000, ms, 100)
+
+	// A spent budget still goes out as a positive value the next hop can parse
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	value, ok = FormatHeader(expired)
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+	_, ok = ParseHeader(value)
+	assert.True(t, ok)
+}
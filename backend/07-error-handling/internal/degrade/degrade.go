@@ -0,0 +1,153 @@
+package degrade
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Mode is how much of a dependency the service is allowed to use
+type Mode string
+
+const (
+	// Normal uses the dependency as usual
+	Normal Mode = "normal"
+	// ReadOnly refuses writes but keeps reading
+	ReadOnly Mode = "read_only"
+	// CacheOnly stops calling the dependency; reads are served from caches
+	CacheOnly Mode = "cache_only"
+	// Disabled switches off every feature that needs the dependency
+	Disabled Mode = "disabled"
+)
+
+var validModes = map[Mode]bool{Normal: true, ReadOnly: true, CacheOnly: true, Disabled: true}
+
+// Operation is what the caller wants to do with the dependency
+type Operation string
+
+const (
+	OpCall    Operation = "call"
+	OpWrite   Operation = "write"
+	OpFeature Operation = "feature"
+)
+
+// ErrDegraded matches every refusal caused by a degradation mode
+var ErrDegraded = errors.New("dependency degraded")
+
+// Error reports that an operator-set mode refused an operation
+type Error struct {
+	Dependency string
+	Mode       Mode
+	Op         Operation
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s is in %s mode: %s refused", e.Dependency, e.Mode, e.Op)
+}
+
+// Is lets errors.Is(err, ErrDegraded) match
+func (e *Error) Is(target error) bool { return target == ErrDegraded }
+
+// Controller holds the current mode of each known dependency
+type Controller struct {
+	modes map[string]Mode
+	mutex sync.RWMutex
+}
+
+// NewController starts every dependency in Normal mode
+func NewController(dependencies ...string) *Controller {
+	modes := make(map[string]Mode, len(dependencies))
+	for _, dep := range dependencies {
+		modes[dep] = Normal
+	}
+	return &Controller{modes: modes}
+}
+
+// SetAll validates every entry before applying any of them
+func (c *Controller) SetAll(modes map[string]Mode) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for dep, mode := range modes {
+		if _, ok := c.modes[dep]; !ok {
+			return fmt.Errorf("unknown dependency %q", dep)
+		}
+		if !validModes[mode] {
+			return fmt.Errorf("unknown mode %q for %s", mode, dep)
+		}
+	}
+	for dep, mode := range modes {
+		c.modes[dep] = mode
+	}
+	return nil
+}
+
+// Mode returns the dependency's current mode
+func (c *Controller) Mode(dependency string) Mode {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if mode, ok := c.modes[dependency]; ok {
+		return mode
+	}
+	return Normal
+}
+
+// Modes returns a copy of every dependency's mode
+func (c *Controller) Modes() map[string]Mode {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	modes := make(map[string]Mode, len(c.modes))
+	for dep, mode := range c.modes {
+		modes[dep] = mode
+	}
+	return modes
+}
+
+// Allow reports whether op may use the dependency, returning an *Error if not
+func (c *Controller) Allow(dependency string, op Operation) error {
+	mode := c.Mode(dependency)
+
+	var refused bool
+	switch op {
+	case OpCall:
+		refused = mode == CacheOnly || mode == Disabled
+	case OpWrite:
+		refused = mode != Normal
+	case OpFeature:
+		refused = mode == Disabled
+	}
+
+	if refused {
+		return &Error{Dependency: dependency, Mode: mode, Op: op}
+	}
+	return nil
+}
+
+// ParseModes reads "database=read_only,redis=cache_only". Mode names are
+// checked here; whether a dependency exists is up to the Controller.
+func ParseModes(raw string) (map[string]Mode, error) {
+	modes := make(map[string]Mode)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		dep, mode, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected dependency=mode, got %q", pair)
+		}
+		dep, mode = strings.TrimSpace(dep), strings.TrimSpace(mode)
+		if dep == "" {
+			return nil, fmt.Errorf("missing dependency in %q", pair)
+		}
+		if !validModes[Mode(mode)] {
+			return nil, fmt.Errorf("unknown mode %q for %s", mode, dep)
+		}
+		modes[dep] = Mode(mode)
+	}
+	return modes, nil
+}
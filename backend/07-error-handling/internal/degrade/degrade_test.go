@@ -0,0 +1,128 @@
+package degrade
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestController_Allow(t *testing.T) {
+	// Which operations each mode refuses
+	refused := map[Mode]map[Operation]bool{
+		Normal:    {},
+		ReadOnly:  {OpWrite: true},
+		CacheOnly: {OpCall: true, OpWrite: true},
+		Disabled:  {OpCall: true, OpWrite: true, OpFeature: true},
+	}
+
+	for mode, refusedOps := range refused {
+		for _, op := range []Operation{OpCall, OpWrite, OpFeature} {
+			t.Run(string(mode)+"/"+string(op), func(t *testing.T) {
+				controller := NewController("database")
+				require.NoError(t, controller.SetAll(map[string]Mode{"database": mode}))
+
+				err := controller.Allow("database", op)
+				if !refusedOps[op] {
+					assert.NoError(t, err)
+					return
+				}
+
+				var degraded *Error
+				require.True(t, errors.As(err, &degraded))
+				assert.Equal(t, &Error{Dependency: "database", Mode: mode, Op: op}, degraded)
+				assert.ErrorIs(t, err, ErrDegraded)
+			})
+		}
+	}
+}
+
+func TestController_UnknownDependencyIsNormal(t *testing.T) {
+	controller := NewController("database")
+
+	assert.Equal(t, Normal, controller.Mode("queue"))
+	assert.NoError(t, controller.Allow("queue", OpWrite))
+}
+
+func TestController_SetAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		modes   map[string]Mode
+		wantErr string
+		want    map[string]Mode
+	}{
+		{
+			name:  "applies every mode",
+			modes: map[string]Mode{"database": ReadOnly, "redis": CacheOnly},
+			want:  map[string]Mode{"database": ReadOnly, "redis": CacheOnly},
+		},
+		{
+			name:    "an unknown dependency changes nothing",
+			modes:   map[string]Mode{"database": Disabled, "queue": Disabled},
+			wantErr: `unknown dependency "queue"`,
+			want:    map[string]Mode{"database": Normal, "redis": Normal},
+		},
+		{
+			name:    "an unknown mode changes nothing",
+			modes:   map[string]Mode{"database": Disabled, "redis": "off"},
+			wantErr: `unknown mode "off" for redis`,
+			want:    map[string]Mode{"database": Normal, "redis": Normal},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := NewController("database", "redis")
+
+			err := controller.SetAll(tt.modes)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, controller.Modes())
+		})
+	}
+}
+
+func TestController_ModesIsACopy(t *testing.T) {
+	controller := NewController("database")
+
+	modes := controller.Modes()
+	modes["database"] = Disabled
+	assert.Equal(t, Normal, controller.Mode("database"))
+}
+
+func TestParseModes(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    map[string]Mode
+		wantErr string
+	}{
+		{name: "empty", raw: "", want: map[string]Mode{}},
+		{
+			name: "pairs with spaces",
+			raw:  " database = read_only , redis=cache_only,",
+			want: map[string]Mode{"database": ReadOnly, "redis": CacheOnly},
+		},
+		{name: "missing separator", raw: "database", wantErr: `expected dependency=mode, got "database"`},
+		{name: "missing dependency", raw: "=disabled", wantErr: `missing dependency in "=disabled"`},
+		{name: "unknown mode", raw: "database=readonly", wantErr: `unknown mode "readonly" for database`},
+		{name: "empty mode", raw: "database=", wantErr: `unknown mode "" for database`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modes, err := ParseModes(tt.raw)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Nil(t, modes)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, modes)
+		})
+	}
+}
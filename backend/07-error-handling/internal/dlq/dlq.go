@@ -0,0 +1,263 @@
+package dlq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNotFound is returned when an entry ID is unknown
+var ErrNotFound = errors.New("dead letter not found")
+
+// Entry is a failed write kept for inspection and replay
+type Entry struct {
+	ID            string          `json:"id"`
+	Operation     string          `json:"operation"`
+	Payload       json.RawMessage `json:"payload"`
+	Error         string          `json:"error"`
+	Attempts      int             `json:"attempts"`
+	RequestID     string          `json:"request_id,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	LastAttemptAt time.Time       `json:"last_attempt_at"`
+}
+
+// Store persists dead letters
+type Store interface {
+	Push(ctx context.Context, entry Entry) error
+	List(ctx context.Context) ([]Entry, error)
+	Get(ctx context.Context, id string) (*Entry, error)
+	Remove(ctx context.Context, id string) error
+}
+
+const redisKey = "dlq:entries"
+
+// RedisStore keeps dead letters in a Redis hash so they survive restarts
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a Redis-backed store; client may be nil when Redis is down
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Push adds or replaces an entry
+func (s *RedisStore) Push(ctx context.Context, entry Entry) error {
+	if s.client == nil {
+		return errors.New("dlq: redis not connected")
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("dlq: encode: %w", err)
+	}
+	if err := s.client.HSet(ctx, redisKey, entry.ID, data).Err(); err != nil {
+		return fmt.Errorf("dlq: %w", err)
+	}
+	return nil
+}
+
+// List returns all entries, oldest first
+func (s *RedisStore) List(ctx context.Context) ([]Entry, error) {
+	if s.client == nil {
+		return nil, errors.New("dlq: redis not connected")
+	}
+
+	values, err := s.client.HVals(ctx, redisKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("dlq: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(values))
+	for _, value := range values {
+		var entry Entry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			return nil, fmt.Errorf("dlq: decode: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	sortByCreatedAt(entries)
+	return entries, nil
+}
+
+// Get returns a single entry
+func (s *RedisStore) Get(ctx context.Context, id string) (*Entry, error) {
+	if s.client == nil {
+		return nil, errors.New("dlq: redis not connected")
+	}
+
+	value, err := s.client.HGet(ctx, redisKey, id).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dlq: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(value, &entry); err != nil {
+		return nil, fmt.Errorf("dlq: decode: %w", err)
+	}
+	return &entry, nil
+}
+
+// Remove deletes an entry
+func (s *RedisStore) Remove(ctx context.Context, id string) error {
+	if s.client == nil {
+		return errors.New("dlq: redis not connected")
+	}
+
+	removed, err := s.client.HDel(ctx, redisKey, id).Result()
+	if err != nil {
+		return fmt.Errorf("dlq: %w", err)
+	}
+	if removed == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MemoryStore keeps dead letters in process; it is lost on restart
+type MemoryStore struct {
+	entries map[string]Entry
+	mutex   sync.RWMutex
+}
+
+// NewMemoryStore creates an in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]Entry)}
+}
+
+// Push adds or replaces an entry
+func (s *MemoryStore) Push(ctx context.Context, entry Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries[entry.ID] = entry
+	return nil
+}
+
+// List returns all entries, oldest first
+func (s *MemoryStore) List(ctx context.Context) ([]Entry, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entries := make([]Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	sortByCreatedAt(entries)
+	return entries, nil
+}
+
+// Get returns a single entry
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Entry, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &entry, nil
+}
+
+// Remove deletes an entry
+func (s *MemoryStore) Remove(ctx context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.entries[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.entries, id)
+	return nil
+}
+
+// FallbackStore writes to Redis and falls back to memory when Redis fails.
+// Reads merge both, so entries parked in memory during an outage stay visible.
+type FallbackStore struct {
+	primary  Store
+	fallback Store
+}
+
+// NewFallbackStore combines a durable primary and an in-process fallback
+func NewFallbackStore(primary, fallback Store) *FallbackStore {
+	return &FallbackStore{primary: primary, fallback: fallback}
+}
+
+// Push stores the entry in the primary, or the fallback if the primary fails.
+// Entries already parked in memory are updated in place.
+func (s *FallbackStore) Push(ctx context.Context, entry Entry) error {
+	if _, err := s.fallback.Get(ctx, entry.ID); err == nil {
+		return s.fallback.Push(ctx, entry)
+	}
+	if err := s.primary.Push(ctx, entry); err != nil {
+		logrus.WithContext(ctx).WithError(err).Warn("Dead letter store unavailable, keeping entry in memory")
+		return s.fallback.Push(ctx, entry)
+	}
+	return nil
+}
+
+// List merges entries from both stores
+func (s *FallbackStore) List(ctx context.Context) ([]Entry, error) {
+	entries, _ := s.fallback.List(ctx)
+	primary, err := s.primary.List(ctx)
+	if err != nil {
+		logrus.WithContext(ctx).WithError(err).Warn("Dead letter store unavailable, listing in-memory entries only")
+		return entries, nil
+	}
+
+	entries = append(entries, primary...)
+	sortByCreatedAt(entries)
+	return entries, nil
+}
+
+// Get looks in the fallback first, then the primary
+func (s *FallbackStore) Get(ctx context.Context, id string) (*Entry, error) {
+	if entry, err := s.fallback.Get(ctx, id); err == nil {
+		return entry, nil
+	}
+	return s.primary.Get(ctx, id)
+}
+
+// Remove deletes the entry from whichever store holds it
+func (s *FallbackStore) Remove(ctx context.Context, id string) error {
+	if err := s.fallback.Remove(ctx, id); err == nil {
+		return nil
+	}
+	return s.primary.Remove(ctx, id)
+}
+
+// Flush moves entries parked in memory into the primary store, so they survive
+// a restart. It returns how many were moved; the rest stay in memory.
+func (s *FallbackStore) Flush(ctx context.Context) (int, error) {
+	entries, err := s.fallback.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	moved := 0
+	for _, entry := range entries {
+		if err := s.primary.Push(ctx, entry); err != nil {
+			return moved, fmt.Errorf("flushed %d of %d dead letters: %w", moved, len(entries), err)
+		}
+		if err := s.fallback.Remove(ctx, entry.ID); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+func sortByCreatedAt(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+}
@@ -0,0 +1,131 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStore is a primary store that is down
+type failingStore struct{ err error }
+
+func (s failingStore) Push(context.Context, Entry) error           { return s.err }
+func (s failingStore) List(context.Context) ([]Entry, error)       { return nil, s.err }
+func (s failingStore) Get(context.Context, string) (*Entry, error) { return nil, s.err }
+func (s failingStore) Remove(context.Context, string) error        { return s.err }
+
+func entry(id string, age time.Duration) Entry {
+	created := time.Now().Add(-age)
+	return Entry{ID: id, Operation: "create_user", Payload: []byte(`{}`), CreatedAt: created, LastAttemptAt: created}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	require.NoError(t, store.Push(ctx, entry("new", time.Minute)))
+	require.NoError(t, store.Push(ctx, entry("old", time.Hour)))
+
+	entries, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "old", entries[0].ID, "oldest first")
+
+	updated := entry("new", time.Minute)
+	updated.Attempts = 3
+	require.NoError(t, store.Push(ctx, updated))
+	got, err := store.Get(ctx, "new")
+	require.NoError(t, err)
+	assert.Equal(t, 3, got.Attempts, "push replaces an entry with the same ID")
+
+	require.NoError(t, store.Remove(ctx, "new"))
+	_, err = store.Get(ctx, "new")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, store.Remove(ctx, "new"), ErrNotFound)
+}
+
+func TestRedisStore_NotConnected(t *testing.T) {
+	ctx := context.Background()
+	store := NewRedisStore(nil)
+
+	assert.Error(t, store.Push(ctx, entry("a", 0)))
+	_, err := store.List(ctx)
+	assert.Error(t, err)
+	_, err = store.Get(ctx, "a")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound, "an outage is not a missing entry")
+	assert.Error(t, store.Remove(ctx, "a"))
+}
+
+func TestFallbackStore_PrimaryHealthy(t *testing.T) {
+	ctx := context.Background()
+	primary, fallback := NewMemoryStore(), NewMemoryStore()
+	store := NewFallbackStore(primary, fallback)
+
+	require.NoError(t, store.Push(ctx, entry("a", 0)))
+	_, err := primary.Get(ctx, "a")
+	assert.NoError(t, err)
+	_, err = fallback.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, store.Remove(ctx, "a"))
+	assert.ErrorIs(t, store.Remove(ctx, "a"), ErrNotFound)
+}
+
+func TestFallbackStore_PrimaryDown(t *testing.T) {
+	ctx := context.Background()
+	fallback := NewMemoryStore()
+	store := NewFallbackStore(failingStore{err: errors.New("redis down")}, fallback)
+
+	require.NoError(t, store.Push(ctx, entry("a", 0)), "the entry is parked in memory instead")
+
+	entries, err := store.List(ctx)
+	require.NoError(t, err, "listing still shows what memory holds")
+	require.Len(t, entries, 1)
+
+	got, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", got.ID)
+
+	moved, err := store.Flush(ctx)
+	assert.Error(t, err)
+	assert.Zero(t, moved)
+	_, err = fallback.Get(ctx, "a")
+	assert.NoError(t, err, "a failed flush keeps the entry in memory")
+}
+
+func TestFallbackStore_MergesAndFlushes(t *testing.T) {
+	ctx := context.Background()
+	primary, fallback := NewMemoryStore(), NewMemoryStore()
+	store := NewFallbackStore(primary, fallback)
+
+	require.NoError(t, primary.Push(ctx, entry("durable", time.Minute)))
+	require.NoError(t, fallback.Push(ctx, entry("parked", time.Hour)))
+
+	entries, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "parked", entries[0].ID, "merged entries stay oldest first")
+
+	// A parked entry is updated where it is, not duplicated into the primary
+	parked := entry("parked", time.Hour)
+	parked.Attempts = 2
+	require.NoError(t, store.Push(ctx, parked))
+	_, err = primary.Get(ctx, "parked")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	moved, err := store.Flush(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved)
+
+	got, err := primary.Get(ctx, "parked")
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.Attempts)
+	remaining, err := fallback.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
@@ -0,0 +1,153 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrMiss tells the chain a step had nothing to offer; it is counted as a miss, not a failure
+	ErrMiss = errors.New("fallback: miss")
+	// ErrExhausted is returned when every step failed or missed
+	ErrExhausted = errors.New("fallback: all sources failed")
+)
+
+// Step is one source in the chain
+type Step[K, V any] struct {
+	Name    string
+	Timeout time.Duration // Zero means the step shares the caller's deadline
+	Fetch   func(ctx context.Context, key K) (V, error)
+}
+
+// Result is the value produced by the first successful step
+type Result[V any] struct {
+	Value      V
+	Source     string
+	Degraded   bool              // true when the primary step did not answer
+	PrimaryErr error             // why the primary step did not answer, set when Degraded
+	Failures   map[string]string // step name -> why it was skipped
+}
+
+// StepStats counts outcomes for one step
+type StepStats struct {
+	Attempts     int64         `json:"attempts"`
+	Successes    int64         `json:"successes"`
+	Misses       int64         `json:"misses"`
+	Failures     int64         `json:"failures"`
+	Timeouts     int64         `json:"timeouts"`
+	TotalLatency time.Duration `json:"-"`
+	AvgLatencyMs float64       `json:"avg_latency_ms"`
+}
+
+// Chain tries each step in order until one succeeds
+type Chain[K, V any] struct {
+	name  string
+	steps []Step[K, V]
+	stats map[string]*StepStats
+	mutex sync.Mutex
+}
+
+// New creates a chain; the first step is the primary source
+func New[K, V any](name string, steps ...Step[K, V]) *Chain[K, V] {
+	stats := make(map[string]*StepStats, len(steps))
+	for _, step := range steps {
+		stats[step.Name] = &StepStats{}
+	}
+	return &Chain[K, V]{name: name, steps: steps, stats: stats}
+}
+
+// Execute returns the first successful step's value for key
+func (c *Chain[K, V]) Execute(ctx context.Context, key K) (Result[V], error) {
+	failures := make(map[string]string)
+	var primaryErr error
+
+	for i, step := range c.steps {
+		if err := ctx.Err(); err != nil {
+			return Result[V]{Failures: failures}, err
+		}
+
+		value, elapsed, err := c.run(ctx, step, key)
+		c.record(step.Name, err, elapsed)
+
+		if err == nil {
+			if i > 0 {
+				logrus.WithContext(ctx).WithFields(logrus.Fields{
+					"chain":  c.name,
+					"source": step.Name,
+				}).Info("Served from fallback source")
+			}
+			return Result[V]{Value: value, Source: step.Name, Degraded: i > 0, PrimaryErr: primaryErr, Failures: failures}, nil
+		}
+
+		failures[step.Name] = err.Error()
+		if i == 0 {
+			primaryErr = err
+		}
+	}
+
+	return Result[V]{Failures: failures}, fmt.Errorf("%s: %w: %w", c.name, ErrExhausted, primaryErr)
+}
+
+// Stats returns per-step counters keyed by step name
+func (c *Chain[K, V]) Stats() map[string]StepStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := make(map[string]StepStats, len(c.stats))
+	for name, s := range c.stats {
+		copied := *s
+		if copied.Attempts > 0 {
+			copied.AvgLatencyMs = float64(copied.TotalLatency.Microseconds()) / 1000 / float64(copied.Attempts)
+		}
+		stats[name] = copied
+	}
+	return stats
+}
+
+// Name returns the chain's name
+func (c *Chain[K, V]) Name() string { return c.name }
+
+// Steps returns the step names in the order they are tried
+func (c *Chain[K, V]) Steps() []string {
+	names := make([]string, len(c.steps))
+	for i, step := range c.steps {
+		names[i] = step.Name
+	}
+	return names
+}
+
+func (c *Chain[K, V]) run(ctx context.Context, step Step[K, V], key K) (V, time.Duration, error) {
+	start := time.Now()
+	if step.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, step.Timeout)
+		defer cancel()
+	}
+
+	value, err := step.Fetch(ctx, key)
+	return value, time.Since(start), err
+}
+
+func (c *Chain[K, V]) record(name string, err error, elapsed time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	s := c.stats[name]
+	s.Attempts++
+	s.TotalLatency += elapsed
+	switch {
+	case err == nil:
+		s.Successes++
+	case errors.Is(err, ErrMiss):
+		s.Misses++
+	case errors.Is(err, context.DeadlineExceeded):
+		s.Timeouts++
+	default:
+		s.Failures++
+	}
+}
@@ -0,0 +1,126 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDown = errors.New("down")
+
+// step answers with value, or with err when it is set, and records that it ran
+func step(name string, value string, err error, tried *[]string) Step[int, string] {
+	return Step[int, string]{
+		Name: name,
+		Fetch: func(context.Context, int) (string, error) {
+			*tried = append(*tried, name)
+			return value, err
+		},
+	}
+}
+
+func TestChain_Execute(t *testing.T) {
+	tests := []struct {
+		name         string
+		errs         []error // One per step: database, redis, local
+		wantSource   string
+		wantDegraded bool
+		wantTried    []string
+		wantErr      bool
+	}{
+		{
+			name:       "primary answers",
+			errs:       []error{nil, nil, nil},
+			wantSource: "database",
+			wantTried:  []string{"database"},
+		},
+		{
+			name:         "first fallback answers",
+			errs:         []error{errDown, nil, nil},
+			wantSource:   "redis",
+			wantDegraded: true,
+			wantTried:    []string{"database", "redis"},
+		},
+		{
+			name:         "a miss moves on like a failure",
+			errs:         []error{errDown, ErrMiss, nil},
+			wantSource:   "local",
+			wantDegraded: true,
+			wantTried:    []string{"database", "redis", "local"},
+		},
+		{
+			name:      "every step fails",
+			errs:      []error{errDown, errDown, ErrMiss},
+			wantTried: []string{"database", "redis", "local"},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tried []string
+			chain := New("test",
+				step("database", "from database", tt.errs[0], &tried),
+				step("redis", "from redis", tt.errs[1], &tried),
+				step("local", "from local", tt.errs[2], &tried),
+			)
+
+			result, err := chain.Execute(context.Background(), 1)
+
+			assert.Equal(t, tt.wantTried, tried, "steps run in order and stop at the first answer")
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrExhausted)
+				assert.ErrorIs(t, err, errDown, "the primary's error is kept")
+				assert.Len(t, result.Failures, 3)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSource, result.Source)
+			assert.Equal(t, "from "+tt.wantSource, result.Value)
+			assert.Equal(t, tt.wantDegraded, result.Degraded)
+			if tt.wantDegraded {
+				assert.ErrorIs(t, result.PrimaryErr, errDown)
+			}
+		})
+	}
+}
+
+func TestChain_StepTimeout(t *testing.T) {
+	var tried []string
+	chain := New("test",
+		Step[int, string]{
+			Name:    "slow",
+			Timeout: 10 * time.Millisecond,
+			Fetch: func(ctx context.Context, _ int) (string, error) {
+				<-ctx.Done()
+				return "", ctx.Err()
+			},
+		},
+		step("fast", "from fast", nil, &tried),
+	)
+
+	result, err := chain.Execute(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "fast", result.Source)
+
+	stats := chain.Stats()
+	assert.Equal(t, int64(1), stats["slow"].Timeouts)
+	assert.Equal(t, int64(1), stats["fast"].Successes)
+	assert.Equal(t, []string{"slow", "fast"}, chain.Steps())
+}
+
+func TestChain_CanceledContext(t *testing.T) {
+	var tried []string
+	chain := New("test", step("database", "from database", nil, &tried))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := chain.Execute(ctx, 1)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, tried, "no step runs once the caller has gone")
+}
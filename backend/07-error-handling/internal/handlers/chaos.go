@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/apperrors"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/chaos"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ChaosHandler lets operators change fault injection without a restart
+type ChaosHandler struct {
+	injector          *chaos.Injector
+	sendJSONResponse  func(http.ResponseWriter, int, models.APIResponse)
+	sendErrorResponse func(http.ResponseWriter, models.APIError, int)
+}
+
+// NewChaosHandler creates a chaos configuration handler
+func NewChaosHandler(
+	injector *chaos.Injector,
+	sendJSONResponse func(http.ResponseWriter, int, models.APIResponse),
+	sendErrorResponse func(http.ResponseWriter, models.APIError, int),
+) *ChaosHandler {
+	return &ChaosHandler{
+		injector:          injector,
+		sendJSONResponse:  sendJSONResponse,
+		sendErrorResponse: sendErrorResponse,
+	}
+}
+
+// Get handles GET /chaos
+func (h *ChaosHandler) Get(w http.ResponseWriter, r *http.Request) {
+	h.sendJSONResponse(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"config": h.injector.Config(),
+			"stats":  h.injector.Stats(),
+		},
+	})
+}
+
+// Update handles PUT /chaos, replacing the whole configuration
+func (h *ChaosHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var config chaos.Config
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		h.sendError(w, r, apperrors.Validation("INVALID_JSON", "Request body contains invalid JSON",
+			map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	if err := h.injector.SetConfig(config); err != nil {
+		h.sendError(w, r, apperrors.Validation("INVALID_CHAOS_CONFIG", err.Error(), nil))
+		return
+	}
+
+	logrus.WithContext(r.Context()).WithFields(logrus.Fields{
+		"enabled": config.Enabled,
+		"rules":   len(config.Rules),
+	}).Warn("Chaos configuration updated")
+
+	h.sendJSONResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: config})
+}
+
+// Disable handles DELETE /chaos, switching injection off but keeping the rules
+func (h *ChaosHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	config := h.injector.Config()
+	config.Enabled = false
+	if err := h.injector.SetConfig(config); err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	logrus.WithContext(r.Context()).Warn("Chaos injection disabled")
+	h.sendJSONResponse(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]interface{}{"message": "Chaos injection disabled"},
+	})
+}
+
+func (h *ChaosHandler) sendError(w http.ResponseWriter, r *http.Request, err error) {
+	apiError, status := apperrors.ToAPIError(err, r.Header.Get("X-Request-ID"))
+	h.sendErrorResponse(w, apiError, status)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/apperrors"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/degrade"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// DegradationHandler lets operators force dependencies into degraded modes
+type DegradationHandler struct {
+	controller        *degrade.Controller
+	sendJSONResponse  func(http.ResponseWriter, int, models.APIResponse)
+	sendErrorResponse func(http.ResponseWriter, models.APIError, int)
+}
+
+// NewDegradationHandler creates a degradation mode handler
+func NewDegradationHandler(
+	controller *degrade.Controller,
+	sendJSONResponse func(http.ResponseWriter, int, models.APIResponse),
+	sendErrorResponse func(http.ResponseWriter, models.APIError, int),
+) *DegradationHandler {
+	return &DegradationHandler{
+		controller:        controller,
+		sendJSONResponse:  sendJSONResponse,
+		sendErrorResponse: sendErrorResponse,
+	}
+}
+
+// Get handles GET /degradation
+func (h *DegradationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	h.sendJSONResponse(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]interface{}{"modes": h.controller.Modes()},
+	})
+}
+
+// Update handles PUT /degradation; dependencies left out of the body keep their mode
+func (h *DegradationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var modes map[string]degrade.Mode
+	if err := json.NewDecoder(r.Body).Decode(&modes); err != nil {
+		h.sendError(w, r, apperrors.Validation("INVALID_JSON", "Request body contains invalid JSON",
+			map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	if err := h.controller.SetAll(modes); err != nil {
+		h.sendError(w, r, apperrors.Validation("INVALID_DEGRADATION_MODE", err.Error(), nil))
+		return
+	}
+
+	logrus.WithContext(r.Context()).WithField("modes", modes).Warn("Degradation modes updated")
+	h.Get(w, r)
+}
+
+// Reset handles DELETE /degradation, returning every dependency to normal
+func (h *DegradationHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	modes := h.controller.Modes()
+	for dep := range modes {
+		modes[dep] = degrade.Normal
+	}
+	if err := h.controller.SetAll(modes); err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	logrus.WithContext(r.Context()).Warn("Degradation modes reset to normal")
+	h.Get(w, r)
+}
+
+func (h *DegradationHandler) sendError(w http.ResponseWriter, r *http.Request, err error) {
+	apiError, status := apperrors.ToAPIError(err, r.Header.Get("X-Request-ID"))
+	h.sendErrorResponse(w, apiError, status)
+}
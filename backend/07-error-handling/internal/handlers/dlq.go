@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/apperrors"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/dlq"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// ReplayFunc re-executes a dead-lettered operation from its stored payload
+type ReplayFunc func(ctx context.Context, payload json.RawMessage) (interface{}, error)
+
+// DLQHandler exposes dead letters for inspection and replay
+type DLQHandler struct {
+	store             dlq.Store
+	replayers         map[string]ReplayFunc
+	sendJSONResponse  func(http.ResponseWriter, int, models.APIResponse)
+	sendErrorResponse func(http.ResponseWriter, models.APIError, int)
+}
+
+// NewDLQHandler creates a dead letter handler
+func NewDLQHandler(
+	store dlq.Store,
+	replayers map[string]ReplayFunc,
+	sendJSONResponse func(http.ResponseWriter, int, models.APIResponse),
+	sendErrorResponse func(http.ResponseWriter, models.APIError, int),
+) *DLQHandler {
+	return &DLQHandler{
+		store:             store,
+		replayers:         replayers,
+		sendJSONResponse:  sendJSONResponse,
+		sendErrorResponse: sendErrorResponse,
+	}
+}
+
+func (h *DLQHandler) sendError(w http.ResponseWriter, r *http.Request, err error) {
+	apiError, status := apperrors.ToAPIError(err, r.Header.Get("X-Request-ID"))
+	h.sendErrorResponse(w, apiError, status)
+}
+
+// List handles GET /dlq
+func (h *DLQHandler) List(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.store.List(r.Context())
+	if err != nil {
+		h.sendError(w, r, apperrors.Unavailable("DLQ_UNAVAILABLE", "Unable to read dead letters", err))
+		return
+	}
+
+	h.sendJSONResponse(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"entries": entries,
+			"count":   len(entries),
+		},
+	})
+}
+
+// Get handles GET /dlq/{id}
+func (h *DLQHandler) Get(w http.ResponseWriter, r *http.Request) {
+	entry, err := h.lookup(r)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	h.sendJSONResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: entry})
+}
+
+// Delete handles DELETE /dlq/{id}, discarding an entry without replaying it
+func (h *DLQHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := h.store.Remove(r.Context(), id); err != nil {
+		h.sendError(w, r, lookupError(err))
+		return
+	}
+
+	h.sendJSONResponse(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]interface{}{"message": "Dead letter discarded", "id": id},
+	})
+}
+
+// Replay handles POST /dlq/{id}/replay
+func (h *DLQHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	entry, err := h.lookup(r)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	replay, ok := h.replayers[entry.Operation]
+	if !ok {
+		h.sendError(w, r, apperrors.Validation("DLQ_UNKNOWN_OPERATION", "No replayer registered for this operation",
+			map[string]interface{}{"operation": entry.Operation}))
+		return
+	}
+
+	result, replayErr := replay(ctx, entry.Payload)
+	entry.Attempts++
+	entry.LastAttemptAt = time.Now()
+
+	if replayErr != nil {
+		entry.Error = replayErr.Error()
+		if err := h.store.Push(ctx, *entry); err != nil {
+			logrus.WithContext(ctx).WithError(err).Error("Failed to update dead letter after replay")
+		}
+
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"dlq_id":    entry.ID,
+			"operation": entry.Operation,
+			"attempts":  entry.Attempts,
+			"error":     replayErr.Error(),
+		}).Warn("Dead letter replay failed")
+
+		var appErr *apperrors.Error
+		if !errors.As(replayErr, &appErr) {
+			replayErr = apperrors.Unavailable("DLQ_REPLAY_FAILED", "Replay failed, entry kept for another attempt", replayErr)
+		}
+		h.sendError(w, r, replayErr)
+		return
+	}
+
+	if err := h.store.Remove(ctx, entry.ID); err != nil {
+		logrus.WithContext(ctx).WithError(err).Error("Replayed dead letter could not be removed")
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"dlq_id":    entry.ID,
+		"operation": entry.Operation,
+		"attempts":  entry.Attempts,
+	}).Info("Dead letter replayed")
+
+	h.sendJSONResponse(w, http.StatusOK, models.APIResponse{
+		Success:  true,
+		Data:     result,
+		Metadata: map[string]interface{}{"dlq_id": entry.ID, "attempts": entry.Attempts},
+	})
+}
+
+func (h *DLQHandler) lookup(r *http.Request) (*dlq.Entry, error) {
+	entry, err := h.store.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	return entry, nil
+}
+
+func lookupError(err error) error {
+	if errors.Is(err, dlq.ErrNotFound) {
+		return apperrors.NotFound("DLQ_ENTRY_NOT_FOUND", "Dead letter not found")
+	}
+	return apperrors.Unavailable("DLQ_UNAVAILABLE", "Unable to read dead letters", err)
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/apperrors"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/dlq"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+)
+
+// responses records what a handler sent through its response callbacks
+type responses struct {
+	status    int
+	body      models.APIResponse
+	errorCode string
+}
+
+func (res *responses) sendJSON(w http.ResponseWriter, status int, body models.APIResponse) {
+	res.status, res.body = status, body
+	w.WriteHeader(status)
+}
+
+func (res *responses) sendError(w http.ResponseWriter, apiError models.APIError, status int) {
+	res.status, res.errorCode = status, apiError.Code
+	w.WriteHeader(status)
+}
+
+func newDLQTest(t *testing.T, replay ReplayFunc) (*DLQHandler, *dlq.MemoryStore, *responses) {
+	t.Helper()
+	store := dlq.NewMemoryStore()
+	created := time.Now().Add(-time.Minute)
+	require.NoError(t, store.Push(context.Background(), dlq.Entry{
+		ID:            "dlq-1",
+		Operation:     "create_user",
+		Payload:       json.RawMessage(`{"name":"Ada"}`),
+		Error:         "database unavailable",
+		Attempts:      3,
+		CreatedAt:     created,
+		LastAttemptAt: created,
+	}))
+	require.NoError(t, store.Push(context.Background(), dlq.Entry{ID: "dlq-2", Operation: "unknown", CreatedAt: created}))
+
+	res := &responses{}
+	handler := NewDLQHandler(store, map[string]ReplayFunc{"create_user": replay}, res.sendJSON, res.sendError)
+	return handler, store, res
+}
+
+func dlqRequest(method, id string) *http.Request {
+	req := httptest.NewRequest(method, "/dlq/"+id, nil)
+	return mux.SetURLVars(req, map[string]string{"id": id})
+}
+
+func TestDLQHandler_ListAndGet(t *testing.T) {
+	handler, _, res := newDLQTest(t, nil)
+
+	handler.List(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/dlq", nil))
+	assert.Equal(t, http.StatusOK, res.status)
+	data := res.body.Data.(map[string]interface{})
+	assert.Equal(t, 2, data["count"])
+
+	handler.Get(httptest.NewRecorder(), dlqRequest(http.MethodGet, "dlq-1"))
+	assert.Equal(t, http.StatusOK, res.status)
+	assert.Equal(t, "dlq-1", res.body.Data.(*dlq.Entry).ID)
+
+	handler.Get(httptest.NewRecorder(), dlqRequest(http.MethodGet, "missing"))
+	assert.Equal(t, http.StatusNotFound, res.status)
+	assert.Equal(t, "DLQ_ENTRY_NOT_FOUND", res.errorCode)
+}
+
+func TestDLQHandler_Delete(t *testing.T) {
+	handler, store, res := newDLQTest(t, nil)
+
+	handler.Delete(httptest.NewRecorder(), dlqRequest(http.MethodDelete, "dlq-1"))
+	assert.Equal(t, http.StatusOK, res.status)
+	_, err := store.Get(context.Background(), "dlq-1")
+	assert.ErrorIs(t, err, dlq.ErrNotFound)
+
+	handler.Delete(httptest.NewRecorder(), dlqRequest(http.MethodDelete, "dlq-1"))
+	assert.Equal(t, http.StatusNotFound, res.status)
+	assert.Equal(t, "DLQ_ENTRY_NOT_FOUND", res.errorCode)
+}
+
+func TestDLQHandler_Replay(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		replayErr    error
+		wantStatus   int
+		wantCode     string
+		wantKept     bool
+		wantAttempts int
+		wantError    string
+	}{
+		{name: "success removes the entry", id: "dlq-1", wantStatus: http.StatusOK},
+		{
+			name: "failure keeps the entry for another attempt", id: "dlq-1", replayErr: errors.New("still down"),
+			wantStatus: http.StatusServiceUnavailable, wantCode: "DLQ_REPLAY_FAILED",
+			wantKept: true, wantAttempts: 4, wantError: "still down",
+		},
+		{
+			name: "domain errors keep their code", id: "dlq-1", replayErr: apperrors.Conflict("EMAIL_ALREADY_EXISTS", "taken"),
+			wantStatus: http.StatusConflict, wantCode: "EMAIL_ALREADY_EXISTS",
+			wantKept: true, wantAttempts: 4, wantError: "taken",
+		},
+		{
+			name: "no replayer for the operation", id: "dlq-2",
+			wantStatus: http.StatusBadRequest, wantCode: "DLQ_UNKNOWN_OPERATION", wantKept: true,
+		},
+		{name: "unknown entry", id: "missing", wantStatus: http.StatusNotFound, wantCode: "DLQ_ENTRY_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var replayed json.RawMessage
+			handler, store, res := newDLQTest(t, func(_ context.Context, payload json.RawMessage) (interface{}, error) {
+				replayed = payload
+				if tt.replayErr != nil {
+					return nil, tt.replayErr
+				}
+				return map[string]string{"name": "Ada"}, nil
+			})
+
+			handler.Replay(httptest.NewRecorder(), dlqRequest(http.MethodPost, tt.id))
+			assert.Equal(t, tt.wantStatus, res.status)
+			assert.Equal(t, tt.wantCode, res.errorCode)
+
+			entry, err := store.Get(context.Background(), tt.id)
+			if !tt.wantKept {
+				assert.ErrorIs(t, err, dlq.ErrNotFound)
+				if tt.wantStatus == http.StatusOK {
+					assert.JSONEq(t, `{"name":"Ada"}`, string(replayed), "the stored payload is replayed")
+					assert.Equal(t, map[string]interface{}{"dlq_id": tt.id, "attempts": 4}, res.body.Metadata)
+				}
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantAttempts > 0 {
+				assert.Equal(t, tt.wantAttempts, entry.Attempts)
+				assert.Contains(t, entry.Error, tt.wantError)
+			}
+		})
+	}
+}
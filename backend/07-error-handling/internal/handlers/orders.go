@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/apperrors"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/orders"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/saga"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/tracing"
+	"github.com/gorilla/mux"
+)
+
+// OrderHandler demonstrates the saga pattern over simulated inventory and payment services
+type OrderHandler struct {
+	coordinator       *saga.Coordinator
+	store             saga.Store
+	inventory         *orders.Inventory
+	payments          *orders.Payments
+	sendJSONResponse  func(http.ResponseWriter, int, models.APIResponse)
+	sendErrorResponse func(http.ResponseWriter, models.APIError, int)
+}
+
+// NewOrderHandler creates an order handler
+func NewOrderHandler(
+	coordinator *saga.Coordinator,
+	store saga.Store,
+	inventory *orders.Inventory,
+	payments *orders.Payments,
+	sendJSONResponse func(http.ResponseWriter, int, models.APIResponse),
+	sendErrorResponse func(http.ResponseWriter, models.APIError, int),
+) *OrderHandler {
+	return &OrderHandler{
+		coordinator:       coordinator,
+		store:             store,
+		inventory:         inventory,
+		payments:          payments,
+		sendJSONResponse:  sendJSONResponse,
+		sendErrorResponse: sendErrorResponse,
+	}
+}
+
+func (h *OrderHandler) sendError(w http.ResponseWriter, r *http.Request, err error) {
+	apiError, status := apperrors.ToAPIError(err, r.Header.Get("X-Request-ID"))
+	h.sendErrorResponse(w, apiError, status)
+}
+
+// CreateOrder handles POST /orders
+func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	var req orders.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, r, apperrors.Validation("INVALID_JSON", "Request body contains invalid JSON",
+			map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	if req.Item == "" || req.Quantity <= 0 || req.Amount <= 0 {
+		h.sendError(w, r, apperrors.Validation("INVALID_ORDER", "item, a positive quantity and a positive amount are required", nil))
+		return
+	}
+
+	s := h.coordinator.New(tracing.NewID(), orders.SagaData(req))
+	if err := h.coordinator.Execute(r.Context(), s); err != nil {
+		details := map[string]interface{}{"saga_id": s.ID, "saga": s}
+		if s.Status == saga.StatusCompensationFailed {
+			h.sendError(w, r, apperrors.Internal("ORDER_COMPENSATION_FAILED",
+				"Order failed and could not be fully rolled back; manual intervention required", err).WithDetails(details))
+			return
+		}
+		h.sendError(w, r, apperrors.Conflict("ORDER_FAILED", "Order failed and all completed steps were rolled back").WithDetails(details))
+		return
+	}
+
+	h.sendJSONResponse(w, http.StatusCreated, models.APIResponse{Success: true, Data: s})
+}
+
+// GetOrder handles GET /orders/{id}, returning the persisted saga state
+func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	s, err := h.store.Get(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, saga.ErrNotFound) {
+		h.sendError(w, r, apperrors.NotFound("ORDER_NOT_FOUND", "Order not found"))
+		return
+	}
+	if err != nil {
+		h.sendError(w, r, apperrors.Unavailable("SAGA_STORE_UNAVAILABLE", "Unable to read order state", err))
+		return
+	}
+
+	h.sendJSONResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: s})
+}
+
+// ListOrders handles GET /orders, including stock and payments so rollbacks are visible
+func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	sagas, err := h.store.List(r.Context())
+	if err != nil {
+		h.sendError(w, r, apperrors.Unavailable("SAGA_STORE_UNAVAILABLE", "Unable to read order state", err))
+		return
+	}
+
+	h.sendJSONResponse(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"orders": sagas,
+			"count":  len(sagas),
+		},
+		Metadata: map[string]interface{}{
+			"inventory":      h.inventory.Stock(),
+			"payments_total": h.payments.Total(),
+		},
+	})
+}
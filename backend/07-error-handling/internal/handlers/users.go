@@ -1,20 +1,54 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/e6a5/learning/backend/07-error-handling/internal/apperrors"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/cache"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/circuit"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/deadline"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/degrade"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/dlq"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/fallback"
 	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/repository"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/retry"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/tracing"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
 
+// createUserRetryConfig keeps retries short: the client is waiting on the response
+var createUserRetryConfig = models.RetryConfig{
+	MaxAttempts:    3,
+	BaseDelay:      100 * time.Millisecond,
+	MaxDelay:       time.Second,
+	BackoffFactor:  2.0,
+	Jitter:         true,
+	AttemptTimeout: 2 * time.Second,
+}
+
+// DependencyCall runs fn against a dependency, bounded by ctx's deadline
+type DependencyCall func(ctx context.Context, fn func() error) error
+
 // UserHandler handles user-related HTTP requests
 type UserHandler struct {
+	users                         *repository.UserRepository
+	cache                         *repository.UserCache
+	deadLetters                   dlq.Store
+	retryBudget                   *retry.Budget
+	dbCall                        DependencyCall
+	redisCall                     DependencyCall
+	userCache                     *cache.LRU[int, models.User]
+	usersChain                    *fallback.Chain[struct{}, []models.User]
+	userChain                     *fallback.Chain[int, *models.User]
 	sendJSONResponse              func(http.ResponseWriter, int, models.APIResponse)
 	sendErrorResponse             func(http.ResponseWriter, models.APIError, int)
 	sendErrorResponseWithFallback func(http.ResponseWriter, models.APIError, interface{}, int)
@@ -22,215 +56,388 @@ type UserHandler struct {
 
 // NewUserHandler creates a new user handler
 func NewUserHandler(
+	users *repository.UserRepository,
+	cache *repository.UserCache,
+	deadLetters dlq.Store,
+	retryBudget *retry.Budget,
+	dbCall, redisCall DependencyCall,
+	userCache *cache.LRU[int, models.User],
 	sendJSONResponse func(http.ResponseWriter, int, models.APIResponse),
 	sendErrorResponse func(http.ResponseWriter, models.APIError, int),
 	sendErrorResponseWithFallback func(http.ResponseWriter, models.APIError, interface{}, int),
 ) *UserHandler {
-	return &UserHandler{
+	h := &UserHandler{
+		users:                         users,
+		cache:                         cache,
+		deadLetters:                   deadLetters,
+		retryBudget:                   retryBudget,
+		dbCall:                        dbCall,
+		redisCall:                     redisCall,
+		userCache:                     userCache,
 		sendJSONResponse:              sendJSONResponse,
 		sendErrorResponse:             sendErrorResponse,
 		sendErrorResponseWithFallback: sendErrorResponseWithFallback,
 	}
+	h.usersChain = h.newUsersChain()
+	h.userChain = h.newUserChain()
+	return h
 }
 
-// GetUsers handles GET /users requests with circuit breaker and fallback
-func (h *UserHandler) GetUsers(dbCall func(func() error) error, userCache map[int]*models.User) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		var users []models.User
-
-		// Try to get users from database with circuit breaker
-		err := dbCall(func() error {
-			// This would be injected database connection in real implementation
-			// For now, simulating the database call structure
-			return fmt.Errorf("database connection not available in handler")
-		})
-
-		if err != nil {
-			logrus.WithFields(logrus.Fields{
-				"error":      err.Error(),
-				"request_id": r.Header.Get("X-Request-ID"),
-			}).Warn("Failed to fetch users from database, using fallback")
+// sendError maps any error to its APIError and status code
+func (h *UserHandler) sendError(w http.ResponseWriter, r *http.Request, err error) {
+	apiError, status := apperrors.ToAPIError(err, r.Header.Get("X-Request-ID"))
+	h.sendErrorResponse(w, apiError, status)
+}
 
-			// Use cached data as fallback
-			var cachedUsers []models.User
-			for _, user := range userCache {
-				cachedUsers = append(cachedUsers, *user)
-			}
+// GetUsers handles GET /users requests with circuit breaker and fallback.
+// Fallback order: MySQL -> Redis -> local in-process cache -> empty list.
+func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-			fallbackData := map[string]interface{}{
-				"users":      cachedUsers,
-				"cache_info": "Data from local cache due to database unavailability",
-				"cache_age":  "unknown",
-			}
+	result, err := h.usersChain.Execute(ctx, struct{}{})
+	if err != nil {
+		h.sendError(w, r, apperrors.Unavailable("USER_FETCH_FAILED", "Unable to fetch users at this time", err))
+		return
+	}
 
-			h.sendErrorResponseWithFallback(w, models.APIError{
-				Type:      models.ServiceUnavailable,
-				Code:      "DATABASE_UNAVAILABLE",
-				Message:   "Unable to fetch latest users, showing cached data",
-				RequestID: r.Header.Get("X-Request-ID"),
-				Timestamp: time.Now(),
-				Retryable: true,
-			}, fallbackData, http.StatusPartialContent)
-			return
+	if !result.Degraded {
+		users := result.Value
+		for i := range users {
+			h.userCache.Set(users[i].ID, users[i])
 		}
+		h.cacheInRedis(ctx, func() error { return h.cache.SetAll(ctx, users) })
 
 		response := models.APIResponse{
 			Success: true,
 			Data: map[string]interface{}{
-				"users": users,
-				"count": len(users),
+				"users":  users,
+				"count":  len(users),
+				"source": result.Source,
 			},
 		}
-
 		h.sendJSONResponse(w, http.StatusOK, response)
+		return
 	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"source":   result.Source,
+		"failures": result.Failures,
+	}).Warn("Failed to fetch users from database, using fallback")
+
+	apiError, _ := apperrors.ToAPIError(
+		apperrors.Unavailable("DATABASE_UNAVAILABLE", "Unable to fetch latest users, showing cached data", result.PrimaryErr),
+		r.Header.Get("X-Request-ID"),
+	)
+
+	h.sendErrorResponseWithFallback(w, apiError, map[string]interface{}{
+		"users":      result.Value,
+		"cache_info": cacheInfo[result.Source],
+		"source":     result.Source,
+	}, http.StatusPartialContent)
+}
+
+// cacheInfo explains each degraded source to the client
+var cacheInfo = map[string]string{
+	"redis":   "Data from Redis cache due to database unavailability",
+	"local":   "Data from local cache due to database and Redis unavailability",
+	"default": "No cached data available, returning an empty list",
 }
 
 // CreateUser handles POST /users requests with validation
-func (h *UserHandler) CreateUser(dbCall func(func() error) error, userCache map[int]*models.User) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		var user models.User
-
-		// Parse and validate input
-		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-			h.sendErrorResponse(w, models.APIError{
-				Type:      models.ValidationError,
-				Code:      "INVALID_JSON",
-				Message:   "Request body contains invalid JSON",
-				Details:   map[string]interface{}{"error": err.Error()},
-				RequestID: r.Header.Get("X-Request-ID"),
-				Timestamp: time.Now(),
-				Retryable: false,
-			}, http.StatusBadRequest)
-			return
-		}
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var user models.User
+
+	// Parse and validate input
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		h.sendError(w, r, apperrors.Validation(
+			"INVALID_JSON",
+			"Request body contains invalid JSON",
+			map[string]interface{}{"error": err.Error()},
+		))
+		return
+	}
 
-		// Validate required fields
-		if err := validateUser(&user); err != nil {
-			err.RequestID = r.Header.Get("X-Request-ID")
-			h.sendErrorResponse(w, *err, http.StatusBadRequest)
-			return
-		}
+	// Validate required fields
+	if err := validateUser(&user); err != nil {
+		h.sendError(w, r, err)
+		return
+	}
 
-		// Try to create user in database
-		err := dbCall(func() error {
-			user.JoinedAt = time.Now()
-			user.ID = 1 // This would be set by database
-			return nil  // Simulated success
+	// Try to create user in database, retrying transient failures
+	err := retry.WithRetryBudget(r.Context(), "create-user", createUserRetryConfig, h.retryBudget, func(ctx context.Context) error {
+		var conflict error
+		err := h.dbCall(ctx, func() error {
+			err := h.users.Create(ctx, &user)
+			if errors.Is(err, apperrors.ErrConflict) {
+				conflict = err
+				return nil // A taken email is an answer, not a database failure
+			}
+			return err
 		})
-
-		if err != nil {
-			logrus.WithFields(logrus.Fields{
-				"error":      err.Error(),
-				"user_name":  user.Name,
-				"user_email": user.Email,
-				"request_id": r.Header.Get("X-Request-ID"),
-			}).Error("Failed to create user in database")
-
-			h.sendErrorResponse(w, models.APIError{
-				Type:      models.DatabaseError,
-				Code:      "USER_CREATION_FAILED",
-				Message:   "Unable to create user at this time",
-				Details:   map[string]interface{}{"retryable": true},
-				RequestID: r.Header.Get("X-Request-ID"),
-				Timestamp: time.Now(),
-				Retryable: true,
-			}, http.StatusServiceUnavailable)
-			return
+		if err == nil && conflict != nil {
+			err = conflict
+		}
+		if errors.Is(err, apperrors.ErrConflict) || errors.Is(err, circuit.ErrOpen) || errors.Is(err, repository.ErrNotConnected) ||
+			errors.Is(err, deadline.ErrBudgetExhausted) {
+			return retry.Permanent(err) // Retrying now can't help
 		}
+		return err
+	})
 
-		// Cache the user locally
-		userCache[user.ID] = &user
+	if errors.Is(err, apperrors.ErrConflict) {
+		h.sendError(w, r, err)
+		return
+	}
 
-		response := models.APIResponse{
-			Success: true,
-			Data:    user,
-			Metadata: map[string]interface{}{
-				"created_at": time.Now(),
-			},
+	if err != nil {
+		logrus.WithContext(r.Context()).WithFields(logrus.Fields{
+			"error":      err.Error(),
+			"user_name":  user.Name,
+			"user_email": user.Email,
+		}).Error("Failed to create user in database")
+
+		appErr := apperrors.Unavailable("USER_CREATION_FAILED", "Unable to create user at this time", err)
+		if dlqID, dlqErr := h.deadLetter(r, "create_user", user, err); dlqErr == nil {
+			appErr.Message = "Unable to create user at this time, request queued for replay"
+			appErr.WithDetails(map[string]interface{}{"dlq_id": dlqID})
 		}
+		h.sendError(w, r, appErr)
+		return
+	}
 
-		h.sendJSONResponse(w, http.StatusCreated, response)
+	// Cache the user locally and in Redis
+	h.userCache.Set(user.ID, user)
+	h.cacheInRedis(r.Context(), func() error { return h.cache.Set(r.Context(), user) })
+
+	response := models.APIResponse{
+		Success: true,
+		Data:    user,
+		Metadata: map[string]interface{}{
+			"created_at": time.Now(),
+		},
 	}
+
+	h.sendJSONResponse(w, http.StatusCreated, response)
 }
 
 // GetUser handles GET /users/{id} requests with cache fallback
-func (h *UserHandler) GetUser(dbCall func(func() error) error, userCache map[int]*models.User) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		idStr := vars["id"]
-
-		id, err := strconv.Atoi(idStr)
-		if err != nil {
-			h.sendErrorResponse(w, models.APIError{
-				Type:      models.ValidationError,
-				Code:      "INVALID_USER_ID",
-				Message:   "User ID must be a valid number",
-				Details:   map[string]interface{}{"provided_id": idStr},
-				RequestID: r.Header.Get("X-Request-ID"),
-				Timestamp: time.Now(),
-				Retryable: false,
-			}, http.StatusBadRequest)
-			return
-		}
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		h.sendError(w, r, apperrors.Validation(
+			"INVALID_USER_ID",
+			"User ID must be a valid number",
+			map[string]interface{}{"provided_id": idStr},
+		))
+		return
+	}
 
-		var user models.User
+	ctx := r.Context()
 
-		// Try to get user from database
-		err = dbCall(func() error {
-			// Simulated database call
-			if id == 1 {
-				user = models.User{ID: 1, Name: "Alice", Email: "alice@example.com", JoinedAt: time.Now()}
-				return nil
-			}
-			return sql.ErrNoRows
-		})
+	result, err := h.userChain.Execute(ctx, id)
+	if errors.Is(err, degrade.ErrDegraded) {
+		h.sendError(w, r, err) // Say it was the operator's doing, not an outage
+		return
+	}
+	if err != nil {
+		h.sendError(w, r, apperrors.Unavailable("USER_FETCH_FAILED", "Unable to fetch user at this time", err))
+		return
+	}
+
+	if result.Degraded {
+		h.sendCachedUser(w, *result.Value, result.Source)
+		return
+	}
+
+	user := result.Value
+	if user == nil {
+		h.sendError(w, r, apperrors.NotFound("USER_NOT_FOUND", fmt.Sprintf("User with ID %d not found", id)))
+		return
+	}
+
+	h.userCache.Set(user.ID, *user)
+	h.cacheInRedis(ctx, func() error { return h.cache.Set(ctx, *user) })
+	h.sendJSONResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: user})
+}
 
-		if err != nil {
-			// Try cache as fallback
-			if cachedUser, exists := userCache[id]; exists {
-				response := models.APIResponse{
-					Success:      true,
-					Data:         *cachedUser,
-					FallbackData: map[string]interface{}{"source": "cache"},
-					Metadata:     map[string]interface{}{"cached": true},
+// newUsersChain builds the fallback order for GET /users:
+// MySQL -> Redis -> local in-process cache -> empty list
+func (h *UserHandler) newUsersChain() *fallback.Chain[struct{}, []models.User] {
+	return fallback.New("users.list",
+		fallback.Step[struct{}, []models.User]{
+			Name:    "database",
+			Timeout: 2 * time.Second,
+			Fetch: func(ctx context.Context, _ struct{}) ([]models.User, error) {
+				var users []models.User
+				err := h.dbCall(ctx, func() error {
+					var err error
+					users, err = h.users.GetAll(ctx)
+					return err
+				})
+				return users, err
+			},
+		},
+		fallback.Step[struct{}, []models.User]{
+			Name:    "redis",
+			Timeout: 500 * time.Millisecond,
+			Fetch: func(ctx context.Context, _ struct{}) ([]models.User, error) {
+				var users []models.User
+				err := h.redisCall(ctx, func() error {
+					var err error
+					users, err = h.cache.GetAll(ctx)
+					if errors.Is(err, repository.ErrCacheMiss) {
+						return nil // A miss says nothing about Redis health
+					}
+					return err
+				})
+				if err == nil && users == nil {
+					return nil, fallback.ErrMiss
 				}
-				h.sendJSONResponse(w, http.StatusOK, response)
-				return
-			}
+				return users, err
+			},
+		},
+		fallback.Step[struct{}, []models.User]{
+			Name: "local",
+			Fetch: func(context.Context, struct{}) ([]models.User, error) {
+				users := h.userCache.Values()
+				if len(users) == 0 {
+					return nil, fallback.ErrMiss
+				}
+				return users, nil
+			},
+		},
+		fallback.Step[struct{}, []models.User]{
+			Name: "default",
+			Fetch: func(context.Context, struct{}) ([]models.User, error) {
+				return []models.User{}, nil
+			},
+		},
+	)
+}
 
-			if err == sql.ErrNoRows {
-				h.sendErrorResponse(w, models.APIError{
-					Type:      models.ValidationError,
-					Code:      "USER_NOT_FOUND",
-					Message:   fmt.Sprintf("User with ID %d not found", id),
-					RequestID: r.Header.Get("X-Request-ID"),
-					Timestamp: time.Now(),
-					Retryable: false,
-				}, http.StatusNotFound)
-			} else {
-				h.sendErrorResponse(w, models.APIError{
-					Type:      models.DatabaseError,
-					Code:      "USER_FETCH_FAILED",
-					Message:   "Unable to fetch user at this time",
-					RequestID: r.Header.Get("X-Request-ID"),
-					Timestamp: time.Now(),
-					Retryable: true,
-				}, http.StatusServiceUnavailable)
-			}
-			return
-		}
+// newUserChain builds the fallback order for GET /users/{id}:
+// MySQL -> Redis -> local cache, fresh or stale
+func (h *UserHandler) newUserChain() *fallback.Chain[int, *models.User] {
+	return fallback.New("users.get",
+		fallback.Step[int, *models.User]{
+			Name:    "database",
+			Timeout: 2 * time.Second,
+			Fetch: func(ctx context.Context, id int) (*models.User, error) {
+				var user *models.User
+				err := h.dbCall(ctx, func() error {
+					var err error
+					user, err = h.users.GetByID(ctx, id)
+					if errors.Is(err, sql.ErrNoRows) {
+						return nil // A missing user is an answer, not a database failure
+					}
+					return err
+				})
+				return user, err
+			},
+		},
+		fallback.Step[int, *models.User]{
+			Name:    "redis",
+			Timeout: 500 * time.Millisecond,
+			Fetch: func(ctx context.Context, id int) (*models.User, error) {
+				var user *models.User
+				err := h.redisCall(ctx, func() error {
+					var err error
+					user, err = h.cache.Get(ctx, id)
+					if errors.Is(err, repository.ErrCacheMiss) {
+						return nil
+					}
+					return err
+				})
+				if err == nil && user == nil {
+					return nil, fallback.ErrMiss
+				}
+				return user, err
+			},
+		},
+		fallback.Step[int, *models.User]{
+			Name: "local",
+			// One read serves fresh and stale entries alike: each Get counts a
+			// hit and a stale one also schedules a refresh
+			Fetch: func(_ context.Context, id int) (*models.User, error) {
+				if user, freshness := h.userCache.Get(id); freshness != cache.Miss {
+					return &user, nil
+				}
+				return nil, fallback.ErrMiss
+			},
+		},
+	)
+}
 
-		response := models.APIResponse{
-			Success: true,
-			Data:    user,
-		}
+// FallbackStats handles GET /fallback/stats, reporting per-step outcomes for each chain
+func (h *UserHandler) FallbackStats(w http.ResponseWriter, r *http.Request) {
+	chains := map[string]interface{}{
+		h.usersChain.Name(): map[string]interface{}{
+			"steps": h.usersChain.Steps(),
+			"stats": h.usersChain.Stats(),
+		},
+		h.userChain.Name(): map[string]interface{}{
+			"steps": h.userChain.Steps(),
+			"stats": h.userChain.Stats(),
+		},
+	}
 
-		h.sendJSONResponse(w, http.StatusOK, response)
+	h.sendJSONResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: chains})
+}
+
+// deadLetter parks a failed write so an operator can inspect and replay it
+func (h *UserHandler) deadLetter(r *http.Request, operation string, payload interface{}, cause error) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	entry := dlq.Entry{
+		ID:            tracing.NewID(),
+		Operation:     operation,
+		Payload:       data,
+		Error:         cause.Error(),
+		Attempts:      1,
+		RequestID:     r.Header.Get("X-Request-ID"),
+		CreatedAt:     now,
+		LastAttemptAt: now,
+	}
+
+	if err := h.deadLetters.Push(r.Context(), entry); err != nil {
+		logrus.WithContext(r.Context()).WithError(err).Error("Failed to store dead letter")
+		return "", err
+	}
+
+	logrus.WithContext(r.Context()).WithFields(logrus.Fields{
+		"dlq_id":    entry.ID,
+		"operation": operation,
+	}).Warn("Write moved to dead letter queue")
+	return entry.ID, nil
+}
+
+func (h *UserHandler) sendCachedUser(w http.ResponseWriter, user models.User, source string) {
+	response := models.APIResponse{
+		Success:      true,
+		Data:         user,
+		FallbackData: map[string]interface{}{"source": source},
+		Metadata:     map[string]interface{}{"cached": true},
 	}
+	h.sendJSONResponse(w, http.StatusOK, response)
 }
 
-func validateUser(user *models.User) *models.APIError {
+// cacheInRedis writes through to Redis; failures are logged, never surfaced to the client
+func (h *UserHandler) cacheInRedis(ctx context.Context, write func() error) {
+	if err := h.redisCall(ctx, write); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"error": err.Error(),
+		}).Warn("Failed to update Redis cache")
+	}
+}
+
+func validateUser(user *models.User) error {
 	var errors []map[string]interface{}
 
 	if user.Name == "" {
@@ -254,14 +461,11 @@ func validateUser(user *models.User) *models.APIError {
 	}
 
 	if len(errors) > 0 {
-		return &models.APIError{
-			Type:      models.ValidationError,
-			Code:      "VALIDATION_FAILED",
-			Message:   "User validation failed",
-			Details:   map[string]interface{}{"field_errors": errors},
-			Timestamp: time.Now(),
-			Retryable: false,
-		}
+		return apperrors.Validation(
+			"VALIDATION_FAILED",
+			"User validation failed",
+			map[string]interface{}{"field_errors": errors},
+		)
 	}
 
 	return nil
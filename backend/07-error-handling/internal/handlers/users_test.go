@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/cache"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+)
+
+func TestUserChain_StaleLocalEntry(t *testing.T) {
+	down := func(context.Context, func() error) error { return errors.New("down") }
+	userCache := cache.NewLRU[int, models.User](10, 10*time.Millisecond, time.Hour)
+	var refreshes atomic.Int32
+	userCache.SetRevalidator(func(id int) (models.User, error) {
+		refreshes.Add(1)
+		return models.User{}, errors.New("still down")
+	})
+	h := NewUserHandler(nil, nil, nil, nil, down, down, userCache, nil, nil, nil)
+
+	userCache.Set(1, models.User{ID: 1, Name: "Ada"})
+	time.Sleep(20 * time.Millisecond)
+
+	result, err := h.userChain.Execute(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "local", result.Source)
+	assert.True(t, result.Degraded)
+	assert.Equal(t, "Ada", result.Value.Name)
+
+	stats := userCache.Stats()
+	assert.Equal(t, int64(1), stats.StaleHits, "one request is one cache read")
+	assert.Zero(t, stats.Misses)
+	assert.Eventually(t, func() bool { return refreshes.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(1), refreshes.Load(), "and at most one refresh")
+
+	assert.Equal(t, []string{"database", "redis", "local"}, h.userChain.Steps())
+}
@@ -0,0 +1,111 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	// ErrNotFound means no response has been stored for the key yet
+	ErrNotFound = errors.New("idempotency key not found")
+	// ErrInProgress means another request with the same key is still running
+	ErrInProgress = errors.New("idempotency key in progress")
+)
+
+// StoredResponse is the replayable copy of a completed response
+type StoredResponse struct {
+	Fingerprint string            `json:"fingerprint"`
+	StatusCode  int               `json:"status_code"`
+	Headers     map[string]string `json:"headers"`
+	Body        []byte            `json:"body"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// Store keeps completed responses keyed by Idempotency-Key, and a lock per key
+// while its request runs
+type Store interface {
+	Get(ctx context.Context, key string) (*StoredResponse, error)
+	Lock(ctx context.Context, key string) error
+	Unlock(ctx context.Context, key string) error
+	Save(ctx context.Context, key string, response StoredResponse) error
+}
+
+// RedisStore keeps responses and locks in Redis, so all instances share them
+type RedisStore struct {
+	client  *redis.Client
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+// NewRedisStore creates a Redis-backed idempotency store; client may be nil when Redis is down
+func NewRedisStore(client *redis.Client, ttl, lockTTL time.Duration) *RedisStore {
+	return &RedisStore{client: client, ttl: ttl, lockTTL: lockTTL}
+}
+
+// Get returns the stored response for key
+func (s *RedisStore) Get(ctx context.Context, key string) (*StoredResponse, error) {
+	if s.client == nil {
+		return nil, errors.New("idempotency store: redis not connected")
+	}
+
+	data, err := s.client.Get(ctx, responseKey(key)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("idempotency store: %w", err)
+	}
+
+	var stored StoredResponse
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("idempotency store: decode: %w", err)
+	}
+	return &stored, nil
+}
+
+// Lock claims key for the current request; ErrInProgress means someone else holds it
+func (s *RedisStore) Lock(ctx context.Context, key string) error {
+	if s.client == nil {
+		return errors.New("idempotency store: redis not connected")
+	}
+
+	ok, err := s.client.SetNX(ctx, lockKey(key), "1", s.lockTTL).Result()
+	if err != nil {
+		return fmt.Errorf("idempotency store: %w", err)
+	}
+	if !ok {
+		return ErrInProgress
+	}
+	return nil
+}
+
+// Unlock releases key without storing a response, so the client may retry
+func (s *RedisStore) Unlock(ctx context.Context, key string) error {
+	return s.client.Del(ctx, lockKey(key)).Err()
+}
+
+// Save stores the response for key and releases the lock
+func (s *RedisStore) Save(ctx context.Context, key string, response StoredResponse) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("idempotency store: encode: %w", err)
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, responseKey(key), data, s.ttl)
+		pipe.Del(ctx, lockKey(key))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("idempotency store: %w", err)
+	}
+	return nil
+}
+
+func responseKey(key string) string { return "idempotency:response:" + key }
+func lockKey(key string) string     { return "idempotency:lock:" + key }
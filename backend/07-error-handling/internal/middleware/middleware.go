@@ -1,12 +1,30 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/e6a5/learning/backend/07-error-handling/internal/apperrors"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/chaos"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/deadline"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/degrade"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/idempotency"
 	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/ratelimit"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/reporting"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/tracing"
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,27 +39,60 @@ func (rw *ResponseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// PanicRecovery recovers from panics and returns structured error responses
-func PanicRecovery(sendErrorFn func(http.ResponseWriter, models.APIError, int)) func(http.Handler) http.Handler {
+// PanicRecovery recovers from panics and returns structured error responses.
+// The stack and a fingerprint are logged, and the event is forwarded to
+// reporter (if any) in the background so the response isn't held up.
+func PanicRecovery(reporter reporting.Reporter, sendErrorFn func(http.ResponseWriter, models.APIError, int)) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if err := recover(); err != nil {
-					logrus.WithFields(logrus.Fields{
-						"panic":      err,
-						"method":     r.Method,
-						"path":       r.URL.Path,
-						"request_id": r.Header.Get("X-Request-ID"),
+					frames := reporting.CaptureStack(1)
+					fingerprint := reporting.Fingerprint(err, frames)
+
+					logrus.WithContext(r.Context()).WithFields(logrus.Fields{
+						"panic":       err,
+						"method":      r.Method,
+						"path":        r.URL.Path,
+						"fingerprint": fingerprint,
+						"stack":       reporting.FormatStack(frames),
 					}).Error("Panic recovered")
 
-					sendErrorFn(w, models.APIError{
-						Type:      models.InternalError,
-						Code:      "PANIC_RECOVERED",
-						Message:   "Internal server error occurred",
-						RequestID: r.Header.Get("X-Request-ID"),
-						Timestamp: time.Now(),
-						Retryable: false,
-					}, http.StatusInternalServerError)
+					if reporter != nil {
+						event := reporting.Event{
+							Fingerprint: fingerprint,
+							Message:     fmt.Sprintf("%v", err),
+							Frames:      frames,
+							RequestID:   tracing.RequestID(r.Context()),
+							Tags: map[string]string{
+								"method": r.Method,
+								"route":  routeTemplate(r),
+							},
+							Extra:     map[string]interface{}{"path": r.URL.Path},
+							Timestamp: time.Now(),
+						}
+						if tc, ok := tracing.Trace(r.Context()); ok {
+							event.TraceID = tc.TraceID
+							event.Tags["trace_id"] = tc.TraceID
+						}
+						event.Tags["request_id"] = event.RequestID
+
+						ctx := context.WithoutCancel(r.Context())
+						go func() {
+							ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+							defer cancel()
+							if err := reporter.Report(ctx, event); err != nil {
+								logrus.WithContext(ctx).WithError(err).Warn("Failed to report panic")
+							}
+						}()
+					}
+
+					apiError, status := apperrors.ToAPIError(
+						apperrors.Internal("PANIC_RECOVERED", "Internal server error occurred", fmt.Errorf("panic: %v", err)).
+							WithDetails(map[string]interface{}{"fingerprint": fingerprint}),
+						r.Header.Get("X-Request-ID"),
+					)
+					sendErrorFn(w, apiError, status)
 				}
 			}()
 			next.ServeHTTP(w, r)
@@ -49,21 +100,35 @@ func PanicRecovery(sendErrorFn func(http.ResponseWriter, models.APIError, int))
 	}
 }
 
-// RequestID adds unique request IDs to requests
-func RequestID(counter *int64, mutex *sync.Mutex) func(http.Handler) http.Handler {
+// RequestID assigns every request an ID and a W3C trace context.
+// A valid incoming X-Request-ID is kept; otherwise the traceparent's trace ID
+// is reused, and failing that a fresh UUID is generated.
+func RequestID() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var trace tracing.TraceContext
+			if parent, ok := tracing.ParseTraceparent(r.Header.Get("traceparent")); ok {
+				trace = parent.Child()
+			} else {
+				trace = tracing.NewTraceContext()
+			}
+
 			requestID := r.Header.Get("X-Request-ID")
-			if requestID == "" {
-				mutex.Lock()
-				*counter++
-				requestID = fmt.Sprintf("req_%d_%d", time.Now().Unix(), *counter)
-				mutex.Unlock()
+			if !tracing.ValidRequestID(requestID) {
+				if trace.ParentSpanID != "" {
+					requestID = trace.TraceID
+				} else {
+					requestID = tracing.NewID()
+				}
 			}
 
+			ctx := tracing.WithRequestID(r.Context(), requestID)
+			ctx = tracing.WithTrace(ctx, trace)
+
 			r.Header.Set("X-Request-ID", requestID)
 			w.Header().Set("X-Request-ID", requestID)
-			next.ServeHTTP(w, r)
+			w.Header().Set("traceparent", trace.Traceparent())
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
@@ -81,13 +146,12 @@ func Logging() func(http.Handler) http.Handler {
 
 			duration := time.Since(start)
 
-			logEntry := logrus.WithFields(logrus.Fields{
-				"method":     r.Method,
-				"path":       r.URL.Path,
-				"status":     wrapped.statusCode,
-				"duration":   duration,
-				"request_id": r.Header.Get("X-Request-ID"),
-				"ip":         r.RemoteAddr,
+			logEntry := logrus.WithContext(r.Context()).WithFields(logrus.Fields{
+				"method":   r.Method,
+				"path":     r.URL.Path,
+				"status":   wrapped.statusCode,
+				"duration": duration,
+				"ip":       r.RemoteAddr,
 			})
 
 			if wrapped.statusCode >= 500 {
@@ -101,12 +165,336 @@ func Logging() func(http.Handler) http.Handler {
 	}
 }
 
-// RateLimit provides basic rate limiting (production would use Redis)
-func RateLimit() func(http.Handler) http.Handler {
+// RateLimitConfig holds the sliding-window limits applied per client
+type RateLimitConfig struct {
+	IPLimit   int
+	UserLimit int
+	Window    time.Duration
+}
+
+type rateLimitCheck struct {
+	key   string
+	limit int
+}
+
+// RateLimit enforces per-IP and per-user sliding windows. Users are identified
+// by the X-User-ID header; anonymous requests are only limited by IP.
+func RateLimit(limiter ratelimit.Limiter, config RateLimitConfig, sendErrorFn func(http.ResponseWriter, models.APIError, int)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			checks := []rateLimitCheck{{key: "ip:" + clientIP(r), limit: config.IPLimit}}
+			if userID := r.Header.Get("X-User-ID"); userID != "" {
+				checks = append(checks, rateLimitCheck{key: "user:" + userID, limit: config.UserLimit})
+			}
+
+			for _, check := range checks {
+				result, err := limiter.Allow(r.Context(), check.key, check.limit, config.Window)
+				if err != nil {
+					// Fail open: rate limiting must never take the API down
+					logrus.WithContext(r.Context()).WithError(err).Warn("Rate limiter unavailable, allowing request")
+					continue
+				}
+
+				w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+				w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+
+				if !result.Allowed {
+					logrus.WithContext(r.Context()).WithFields(logrus.Fields{
+						"key":         check.key,
+						"limit":       check.limit,
+						"retry_after": result.RetryAfter,
+					}).Warn("Rate limit exceeded")
+
+					apiError, status := apperrors.ToAPIError(
+						apperrors.RateLimited("RATE_LIMIT_EXCEEDED", "Too many requests, slow down").
+							WithRetryAfter(result.RetryAfter).
+							WithDetails(map[string]interface{}{"limit": check.limit, "window": config.Window.String()}),
+						r.Header.Get("X-Request-ID"),
+					)
+					sendErrorFn(w, apiError, status)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// recordingWriter captures the response so it can be stored for replay
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// MaxIdempotentBody is the largest body a request with an Idempotency-Key may
+// have; larger ones are refused with 413
+const MaxIdempotentBody = 1 << 20
+
+// Idempotency replays the stored response when a POST is retried with the same
+// Idempotency-Key header. Server errors are not stored, so they can be retried.
+func Idempotency(store idempotency.Store, sendErrorFn func(http.ResponseWriter, models.APIError, int)) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Simple in-memory rate limiting for demonstration
-			// Production implementation would use Redis with sliding windows
+			key := r.Header.Get("Idempotency-Key")
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			sendError := func(err error) {
+				apiError, status := apperrors.ToAPIError(err, r.Header.Get("X-Request-ID"))
+				sendErrorFn(w, apiError, status)
+			}
+
+			if len(key) > 255 {
+				sendError(apperrors.Validation("INVALID_IDEMPOTENCY_KEY", "Idempotency-Key must be at most 255 characters", nil))
+				return
+			}
+
+			// The body is buffered to fingerprint it, so its size is capped
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxIdempotentBody))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				sendError(apperrors.TooLarge("REQUEST_TOO_LARGE", fmt.Sprintf("Request body must be at most %d bytes", tooLarge.Limit)))
+				return
+			}
+			if err != nil {
+				sendError(apperrors.Validation("INVALID_BODY", "Unable to read request body", nil))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			fingerprint := requestFingerprint(r, body)
+
+			replay := func(stored *idempotency.StoredResponse) {
+				if stored.Fingerprint != fingerprint {
+					sendError(apperrors.Validation(
+						"IDEMPOTENCY_KEY_REUSED",
+						"Idempotency-Key was already used with a different request",
+						nil,
+					))
+					return
+				}
+				logrus.WithContext(ctx).WithField("idempotency_key", key).Info("Replaying stored response")
+				for name, value := range stored.Headers {
+					w.Header().Set(name, value)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.StatusCode)
+				w.Write(stored.Body)
+			}
+
+			stored, err := store.Get(ctx, key)
+			switch {
+			case err == nil:
+				replay(stored)
+				return
+			case !errors.Is(err, idempotency.ErrNotFound):
+				// Fail open: without the store we can't deduplicate, but we can still serve
+				logrus.WithContext(ctx).WithError(err).Warn("Idempotency store unavailable, processing request normally")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if err := store.Lock(ctx, key); err != nil {
+				if errors.Is(err, idempotency.ErrInProgress) {
+					sendError(apperrors.Conflict("REQUEST_IN_PROGRESS", "A request with this Idempotency-Key is still being processed"))
+					return
+				}
+				logrus.WithContext(ctx).WithError(err).Warn("Idempotency lock failed, processing request normally")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// The first request may have saved its response and released the key
+			// between the Get above and the Lock; check again before running twice
+			if stored, err := store.Get(ctx, key); err == nil {
+				if err := store.Unlock(ctx, key); err != nil {
+					logrus.WithContext(ctx).WithError(err).Warn("Failed to release idempotency lock")
+				}
+				replay(stored)
+				return
+			}
+
+			// A panic unwinds past the code below; release the key on the way out
+			// so a retry is not refused until the lock expires
+			finished := false
+			defer func() {
+				if finished {
+					return
+				}
+				if err := store.Unlock(ctx, key); err != nil {
+					logrus.WithContext(ctx).WithError(err).Warn("Failed to release idempotency lock after a panic")
+				}
+			}()
+
+			recorder := &recordingWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			finished = true
+
+			if recorder.statusCode >= 500 {
+				if err := store.Unlock(ctx, key); err != nil {
+					logrus.WithContext(ctx).WithError(err).Warn("Failed to release idempotency lock")
+				}
+				return
+			}
+
+			err = store.Save(ctx, key, idempotency.StoredResponse{
+				Fingerprint: fingerprint,
+				StatusCode:  recorder.statusCode,
+				Headers:     map[string]string{"Content-Type": w.Header().Get("Content-Type")},
+				Body:        recorder.body.Bytes(),
+				CreatedAt:   time.Now(),
+			})
+			if err != nil {
+				logrus.WithContext(ctx).WithError(err).Warn("Failed to store idempotent response")
+			}
+		})
+	}
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Degradation refuses requests that operator-set modes don't allow: any request to
+// a route whose dependency is disabled, and writes while it is read-only or cache-only.
+// routes maps mux route templates to the dependencies they need.
+func Degradation(controller *degrade.Controller, routes map[string][]string, sendErrorFn func(http.ResponseWriter, models.APIError, int)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			write := r.Method != http.MethodGet && r.Method != http.MethodHead
+
+			for _, dep := range routes[routeTemplate(r)] {
+				err := controller.Allow(dep, degrade.OpFeature)
+				if err == nil && write {
+					err = controller.Allow(dep, degrade.OpWrite)
+				}
+				if err != nil {
+					logrus.WithContext(r.Context()).WithError(err).Info("Request refused by degradation mode")
+					apiError, status := apperrors.ToAPIError(err, r.Header.Get("X-Request-ID"))
+					sendErrorFn(w, apiError, status)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Deadline gives each request a latency budget: the route's entry in budgets,
+// or defaultBudget, shortened by an upstream caller's deadline.Header. The
+// deadline lives in the request context so every downstream call inherits it.
+func Deadline(budgets map[string]time.Duration, defaultBudget time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			budget, ok := budgets[routeTemplate(r)]
+			if !ok {
+				budget = defaultBudget
+			}
+			if upstream, ok := deadline.ParseHeader(r.Header.Get(deadline.Header)); ok && upstream < budget {
+				budget = upstream
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), budget)
+			defer cancel()
+
+			w.Header().Set(deadline.Header, strconv.FormatInt(budget.Milliseconds(), 10))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// routeTemplate returns the matched mux route template, or the raw path if none matched
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+// Chaos injects latency and errors according to the injector's per-route rules.
+// Requests to /chaos itself are never disrupted so the experiment can be stopped.
+func Chaos(injector *chaos.Injector, sendErrorFn func(http.ResponseWriter, models.APIError, int)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+			if strings.HasPrefix(route, "/chaos") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			decision := injector.Decide(route, r.Method)
+			if decision.Delay > 0 {
+				logrus.WithContext(r.Context()).WithField("delay", decision.Delay).Info("Chaos: injecting latency")
+				select {
+				case <-time.After(decision.Delay):
+				case <-r.Context().Done():
+					// The route's budget ran out mid-delay; answer rather than leave an empty 200
+					w.Header().Set("X-Chaos-Injected", "true")
+					apiError, status := apperrors.ToAPIError(r.Context().Err(), r.Header.Get("X-Request-ID"))
+					sendErrorFn(w, apiError, status)
+					return
+				}
+			}
+
+			if decision.ErrorStatus != 0 {
+				logrus.WithContext(r.Context()).WithField("status", decision.ErrorStatus).Info("Chaos: injecting error")
+				w.Header().Set("X-Chaos-Injected", "true")
+				apiError, status := apperrors.ToAPIError(
+					apperrors.FromStatus(decision.ErrorStatus, "CHAOS_INJECTED_ERROR", "Failure injected by chaos testing"),
+					r.Header.Get("X-Request-ID"),
+				)
+				sendErrorFn(w, apiError, status)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AdminAuth only lets requests through that present token as
+// "Authorization: Bearer <token>"
+func AdminAuth(token string, sendErrorFn func(http.ResponseWriter, models.APIError, int)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				apiError, status := apperrors.ToAPIError(
+					apperrors.Unauthorized("ADMIN_AUTH_REQUIRED", "Admin credentials required"),
+					r.Header.Get("X-Request-ID"),
+				)
+				sendErrorFn(w, apiError, status)
+				return
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
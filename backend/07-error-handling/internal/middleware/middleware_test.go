@@ -0,0 +1,561 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/apperrors"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/chaos"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/deadline"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/idempotency"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/tracing"
+)
+
+func TestIdempotency_BodyLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		size       int
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "at the limit", key: "k1", size: MaxIdempotentBody, wantStatus: http.StatusOK},
+		{name: "over the limit", key: "k2", size: MaxIdempotentBody + 1, wantStatus: http.StatusRequestEntityTooLarge, wantCode: "REQUEST_TOO_LARGE"},
+		{name: "no key, no limit here", size: MaxIdempotentBody + 1, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotCode string
+			sendError := func(w http.ResponseWriter, apiError models.APIError, status int) {
+				gotCode = apiError.Code
+				w.WriteHeader(status)
+			}
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Len(t, body, tt.size, "the handler still sees the whole body")
+			})
+			// Without Redis the store fails open, so only the limit is in play
+			handler := Idempotency(idempotency.NewRedisStore(nil, time.Hour, time.Minute), sendError)(next)
+
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(strings.Repeat("x", tt.size)))
+			if tt.key != "" {
+				req.Header.Set("Idempotency-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantCode, gotCode)
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	const (
+		parentTrace = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpan  = "00f067aa0ba902b7"
+		traceparent = "00-" + parentTrace + "-" + parentSpan + "-01"
+	)
+
+	tests := []struct {
+		name        string
+		requestID   string
+		traceparent string
+		wantID      string // Empty means a freshly generated UUID
+		wantTrace   string // Empty means a new trace
+	}{
+		{name: "a valid ID is kept", requestID: "client-req-1", wantID: "client-req-1"},
+		{name: "an invalid ID is replaced", requestID: "x'*/ DROP TABLE users"},
+		{name: "an overlong ID is replaced", requestID: strings.Repeat("a", 65)},
+		{name: "no ID gets one"},
+		{name: "the trace ID stands in for a missing ID", traceparent: traceparent, wantID: parentTrace, wantTrace: parentTrace},
+		{name: "the trace ID stands in for an invalid ID", requestID: "bad id", traceparent: traceparent, wantID: parentTrace, wantTrace: parentTrace},
+		{name: "a valid ID wins over the trace ID", requestID: "client-req-1", traceparent: traceparent, wantID: "client-req-1", wantTrace: parentTrace},
+		{name: "a malformed traceparent starts a new trace", traceparent: "00-xyz-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctxID, headerID string
+			var trace tracing.TraceContext
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxID = tracing.RequestID(r.Context())
+				headerID = r.Header.Get("X-Request-ID")
+				trace, _ = tracing.Trace(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			if tt.requestID != "" {
+				req.Header.Set("X-Request-ID", tt.requestID)
+			}
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			rec := httptest.NewRecorder()
+			RequestID()(next).ServeHTTP(rec, req)
+
+			if tt.wantID != "" {
+				assert.Equal(t, tt.wantID, ctxID)
+			} else {
+				assert.NotEqual(t, tt.requestID, ctxID)
+				assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, ctxID)
+			}
+			assert.Equal(t, ctxID, headerID, "handlers reading the header see the same ID")
+			assert.Equal(t, ctxID, rec.Header().Get("X-Request-ID"))
+
+			if tt.wantTrace != "" {
+				assert.Equal(t, tt.wantTrace, trace.TraceID)
+				assert.Equal(t, parentSpan, trace.ParentSpanID, "this request is a child of the caller's span")
+			} else {
+				assert.NotEqual(t, parentTrace, trace.TraceID)
+				assert.Empty(t, trace.ParentSpanID)
+			}
+			assert.Equal(t, trace.Traceparent(), rec.Header().Get("traceparent"))
+		})
+	}
+}
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "right token", token: "secret", authorization: "Bearer secret", wantStatus: http.StatusOK},
+		{name: "wrong token", token: "secret", authorization: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{name: "no token", token: "secret", wantStatus: http.StatusUnauthorized},
+		{name: "not a bearer token", token: "secret", authorization: "secret", wantStatus: http.StatusUnauthorized},
+		{name: "none configured", authorization: "Bearer ", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotCode string
+			sendError := func(w http.ResponseWriter, apiError models.APIError, status int) {
+				gotCode = apiError.Code
+				w.WriteHeader(status)
+			}
+			handler := AdminAuth(tt.token, sendError)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodPut, "/degradation", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.Equal(t, "ADMIN_AUTH_REQUIRED", gotCode)
+			}
+		})
+	}
+}
+
+// memoryStore is an in-process idempotency.Store
+type memoryStore struct {
+	mu        sync.Mutex
+	responses map[string]idempotency.StoredResponse
+	locks     map[string]bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{responses: map[string]idempotency.StoredResponse{}, locks: map[string]bool{}}
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) (*idempotency.StoredResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.responses[key]
+	if !ok {
+		return nil, idempotency.ErrNotFound
+	}
+	return &stored, nil
+}
+
+func (s *memoryStore) Lock(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locks[key] {
+		return idempotency.ErrInProgress
+	}
+	s.locks[key] = true
+	return nil
+}
+
+func (s *memoryStore) Unlock(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.locks, key)
+	return nil
+}
+
+func (s *memoryStore) Save(_ context.Context, key string, response idempotency.StoredResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[key] = response
+	delete(s.locks, key)
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	type request struct {
+		body       string
+		wantStatus int
+		wantCode   string
+		wantBody   string
+		replayed   bool
+	}
+	tests := []struct {
+		name      string
+		status    int  // What the handler answers
+		locked    bool // Another request holds the key
+		requests  []request
+		wantCalls int
+	}{
+		{
+			name:   "a retry replays the stored response",
+			status: http.StatusCreated,
+			requests: []request{
+				{body: `{"n":1}`, wantStatus: http.StatusCreated, wantBody: "call 1"},
+				{body: `{"n":1}`, wantStatus: http.StatusCreated, wantBody: "call 1", replayed: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "a key reused with another body is refused",
+			status: http.StatusCreated,
+			requests: []request{
+				{body: `{"n":1}`, wantStatus: http.StatusCreated, wantBody: "call 1"},
+				{body: `{"n":2}`, wantStatus: http.StatusBadRequest, wantCode: "IDEMPOTENCY_KEY_REUSED"},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "a key still in progress conflicts",
+			status: http.StatusCreated,
+			locked: true,
+			requests: []request{
+				{body: `{"n":1}`, wantStatus: http.StatusConflict, wantCode: "REQUEST_IN_PROGRESS"},
+			},
+		},
+		{
+			name:   "a server error releases the key for a retry",
+			status: http.StatusServiceUnavailable,
+			requests: []request{
+				{body: `{"n":1}`, wantStatus: http.StatusServiceUnavailable, wantBody: "call 1"},
+				{body: `{"n":1}`, wantStatus: http.StatusServiceUnavailable, wantBody: "call 2"},
+			},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore()
+			if tt.locked {
+				require.NoError(t, store.Lock(context.Background(), "key"))
+			}
+			calls := 0
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, "call "+strconv.Itoa(calls))
+			})
+			var gotCode string
+			sendError := func(w http.ResponseWriter, apiError models.APIError, status int) {
+				gotCode = apiError.Code
+				w.WriteHeader(status)
+			}
+			handler := Idempotency(store, sendError)(next)
+
+			for _, want := range tt.requests {
+				gotCode = ""
+				req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(want.body))
+				req.Header.Set("Idempotency-Key", "key")
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				assert.Equal(t, want.wantStatus, rec.Code)
+				assert.Equal(t, want.wantCode, gotCode)
+				if want.wantBody != "" {
+					assert.Equal(t, want.wantBody, rec.Body.String())
+					assert.Equal(t, "text/plain", rec.Header().Get("Content-Type"))
+				}
+				if want.replayed {
+					assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+				} else {
+					assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+				}
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+			if !tt.locked {
+				assert.Empty(t, store.locks, "no lock outlives its request")
+			}
+		})
+	}
+}
+
+// racingStore lets another request finish between a Get that misses and
+// the Lock that follows it
+type racingStore struct {
+	*memoryStore
+	finish func()
+}
+
+func (s *racingStore) Lock(ctx context.Context, key string) error {
+	if s.finish != nil {
+		s.finish()
+		s.finish = nil
+	}
+	return s.memoryStore.Lock(ctx, key)
+}
+
+func TestIdempotency_FinishedBetweenGetAndLock(t *testing.T) {
+	store := &racingStore{memoryStore: newMemoryStore()}
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+	sendError := func(w http.ResponseWriter, apiError models.APIError, status int) {
+		w.WriteHeader(status)
+	}
+	handler := Idempotency(store, sendError)(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"n":1}`))
+	req.Header.Set("Idempotency-Key", "key")
+	fingerprint := requestFingerprint(req, []byte(`{"n":1}`))
+
+	// The first request saves its response, releasing the key, just after the
+	// retry's Get found nothing
+	store.finish = func() {
+		require.NoError(t, store.memoryStore.Save(context.Background(), "key", idempotency.StoredResponse{
+			Fingerprint: fingerprint,
+			StatusCode:  http.StatusCreated,
+			Headers:     map[string]string{"Content-Type": "text/plain"},
+			Body:        []byte("call 1"),
+		}))
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Zero(t, calls, "the retry must not create the user again")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "call 1", rec.Body.String())
+	assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+	assert.Empty(t, store.locks, "the retry's lock is released")
+}
+
+func TestChaos(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       chaos.Rule
+		timeout    time.Duration // Budget the request arrives with; 0 means none
+		cancel     bool          // The client is already gone
+		wantStatus int
+		wantCode   string
+		wantCalled bool
+	}{
+		{name: "no fault passes through", rule: chaos.Rule{Route: "/other", ErrorRate: 1}, wantStatus: http.StatusOK, wantCalled: true},
+		{
+			name: "injected error", rule: chaos.Rule{Route: "/users", ErrorRate: 1, ErrorStatus: http.StatusTooManyRequests},
+			wantStatus: http.StatusTooManyRequests, wantCode: "CHAOS_INJECTED_ERROR",
+		},
+		{
+			name: "delay within the budget", rule: chaos.Rule{Route: "/users", LatencyRate: 1, LatencyMs: 10},
+			timeout: time.Second, wantStatus: http.StatusOK, wantCalled: true,
+		},
+		{
+			name: "delay past the deadline times out", rule: chaos.Rule{Route: "/users", LatencyRate: 1, LatencyMs: 5000},
+			timeout: 20 * time.Millisecond, wantStatus: http.StatusGatewayTimeout, wantCode: "TIMEOUT",
+		},
+		{
+			name: "client gone during the delay", rule: chaos.Rule{Route: "/users", LatencyRate: 1, LatencyMs: 5000},
+			cancel: true, wantStatus: http.StatusServiceUnavailable, wantCode: "REQUEST_CANCELED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			injector, err := chaos.NewInjector(chaos.Config{Enabled: true, Rules: []chaos.Rule{tt.rule}})
+			require.NoError(t, err)
+
+			var gotCode string
+			sendError := func(w http.ResponseWriter, apiError models.APIError, status int) {
+				gotCode = apiError.Code
+				w.WriteHeader(status)
+			}
+			called := false
+			handler := Chaos(injector, sendError)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true }))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			if tt.cancel {
+				cancel()
+			}
+			req := httptest.NewRequest(http.MethodGet, "/users", nil).WithContext(ctx)
+			rec := httptest.NewRecorder()
+			start := time.Now()
+			handler.ServeHTTP(rec, req)
+
+			assert.Less(t, time.Since(start), time.Second, "a delay never outlives the request's context")
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantCode, gotCode)
+			assert.Equal(t, tt.wantCalled, called)
+		})
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	budgets := map[string]time.Duration{
+		"/users/{id:[0-9]+}": 3 * time.Second,
+		"/slow":              20 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		wantBudget time.Duration
+	}{
+		{name: "route budget", path: "/users/7", wantBudget: 3 * time.Second},
+		{name: "default budget", path: "/health", wantBudget: 10 * time.Second},
+		{name: "a shorter client deadline wins", path: "/users/7", header: "500", wantBudget: 500 * time.Millisecond},
+		{name: "a longer client deadline is clamped", path: "/users/7", header: "60000", wantBudget: 3 * time.Second},
+		{name: "an unparseable header is ignored", path: "/users/7", header: "soon", wantBudget: 3 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var remaining time.Duration
+			record := func(w http.ResponseWriter, r *http.Request) {
+				remaining, _ = deadline.Remaining(r.Context())
+			}
+			router := mux.NewRouter()
+			router.Use(Deadline(budgets, 10*time.Second))
+			router.HandleFunc("/users/{id:[0-9]+}", record)
+			router.HandleFunc("/health", record)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(deadline.Header, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.InDelta(t, tt.wantBudget, remaining, float64(100*time.Millisecond))
+			assert.Equal(t, strconv.FormatInt(tt.wantBudget.Milliseconds(), 10), rec.Header().Get(deadline.Header))
+		})
+	}
+}
+
+func TestDeadline_ResponseAfterExpiry(t *testing.T) {
+	budgets := map[string]time.Duration{"/slow": 20 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		chaosRule *chaos.Rule
+	}{
+		// A handler that honours its context answers with the deadline error
+		{name: "slow handler"},
+		// The route runs the same budget through chaos latency, which must not
+		// hold the request past it or answer with an empty 200
+		{name: "chaos latency past the budget", chaosRule: &chaos.Rule{Route: "/slow", LatencyRate: 1, LatencyMs: 5000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotCode string
+			sendError := func(w http.ResponseWriter, apiError models.APIError, status int) {
+				gotCode = apiError.Code
+				w.WriteHeader(status)
+			}
+
+			config := chaos.Config{Enabled: tt.chaosRule != nil}
+			if tt.chaosRule != nil {
+				config.Rules = []chaos.Rule{*tt.chaosRule}
+			}
+			injector, err := chaos.NewInjector(config)
+			require.NoError(t, err)
+
+			handlerRan := false
+			router := mux.NewRouter()
+			router.Use(Deadline(budgets, 10*time.Second))
+			router.Use(Chaos(injector, sendError))
+			router.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+				handlerRan = true
+				<-r.Context().Done()
+				apiError, status := apperrors.ToAPIError(r.Context().Err(), "")
+				sendError(w, apiError, status)
+			})
+
+			rec := httptest.NewRecorder()
+			start := time.Now()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+			assert.Less(t, time.Since(start), time.Second)
+			assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+			assert.Equal(t, "TIMEOUT", gotCode)
+			assert.Equal(t, tt.chaosRule == nil, handlerRan, "chaos answers before the handler runs")
+		})
+	}
+}
+
+func TestIdempotency_PanicReleasesKey(t *testing.T) {
+	store := newMemoryStore()
+	var gotCode string
+	sendError := func(w http.ResponseWriter, apiError models.APIError, status int) {
+		gotCode = apiError.Code
+		w.WriteHeader(status)
+	}
+
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	// Recovery sits outside idempotency, as in main.go
+	handler := PanicRecovery(nil, sendError)(Idempotency(store, sendError)(next))
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"n":1}`))
+		req.Header.Set("Idempotency-Key", "key")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, send())
+	assert.Equal(t, "PANIC_RECOVERED", gotCode)
+	assert.Empty(t, store.locks, "the key is released, not held until the lock expires")
+
+	gotCode = ""
+	assert.Equal(t, http.StatusCreated, send(), "a retry runs instead of REQUEST_IN_PROGRESS")
+	assert.Empty(t, gotCode)
+	assert.Equal(t, 2, calls)
+}
@@ -24,6 +24,8 @@ type APIError struct {
 	RequestID string      `json:"request_id"`
 	Timestamp time.Time   `json:"timestamp"`
 	Retryable bool        `json:"retryable"`
+	// Suggested wait before retrying, only set when Retryable
+	RetryAfterMs int64 `json:"retry_after_ms,omitempty"`
 }
 
 // APIResponse represents a standard API response
@@ -45,9 +47,10 @@ type User struct {
 
 // RetryConfig holds configuration for retry logic
 type RetryConfig struct {
-	MaxAttempts   int
-	BaseDelay     time.Duration
-	MaxDelay      time.Duration
-	BackoffFactor float64
-	Jitter        bool
+	MaxAttempts    int
+	BaseDelay      time.Duration
+	MaxDelay       time.Duration
+	BackoffFactor  float64
+	Jitter         bool
+	AttemptTimeout time.Duration // Zero means attempts share the caller's deadline
 }
@@ -0,0 +1,194 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/saga"
+)
+
+// ErrSimulated marks a failure requested by the caller to demonstrate compensation
+var ErrSimulated = errors.New("simulated failure")
+
+// Step names, also accepted as fail_step / fail_compensation values
+const (
+	StepReserveInventory = "reserve_inventory"
+	StepChargePayment    = "charge_payment"
+	StepNotifyCustomer   = "notify_customer"
+)
+
+// Request is the body of POST /orders
+type Request struct {
+	Item             string  `json:"item"`
+	Quantity         int     `json:"quantity"`
+	Amount           float64 `json:"amount"`
+	FailStep         string  `json:"fail_step,omitempty"`
+	FailCompensation string  `json:"fail_compensation,omitempty"`
+}
+
+// Inventory is a simulated stock service
+type Inventory struct {
+	stock        map[string]int
+	reservations map[string]reservation
+	mutex        sync.Mutex
+}
+
+type reservation struct {
+	item     string
+	quantity int
+}
+
+// NewInventory creates an inventory with initial stock levels
+func NewInventory(stock map[string]int) *Inventory {
+	return &Inventory{stock: stock, reservations: make(map[string]reservation)}
+}
+
+// Reserve takes quantity units of item out of stock for an order
+func (inv *Inventory) Reserve(orderID, item string, quantity int) error {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+
+	available, ok := inv.stock[item]
+	if !ok {
+		return fmt.Errorf("unknown item %q", item)
+	}
+	if available < quantity {
+		return fmt.Errorf("only %d %s left in stock", available, item)
+	}
+
+	inv.stock[item] = available - quantity
+	inv.reservations[orderID] = reservation{item: item, quantity: quantity}
+	return nil
+}
+
+// Release returns an order's reserved units to stock
+func (inv *Inventory) Release(orderID string) error {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+
+	res, ok := inv.reservations[orderID]
+	if !ok {
+		return nil // Nothing reserved, nothing to undo
+	}
+	inv.stock[res.item] += res.quantity
+	delete(inv.reservations, orderID)
+	return nil
+}
+
+// Stock returns a copy of current stock levels
+func (inv *Inventory) Stock() map[string]int {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+
+	stock := make(map[string]int, len(inv.stock))
+	for item, quantity := range inv.stock {
+		stock[item] = quantity
+	}
+	return stock
+}
+
+// Payments is a simulated payment provider
+type Payments struct {
+	charges map[string]float64
+	mutex   sync.Mutex
+}
+
+// NewPayments creates an empty payment ledger
+func NewPayments() *Payments {
+	return &Payments{charges: make(map[string]float64)}
+}
+
+// Charge records a payment for an order
+func (p *Payments) Charge(orderID string, amount float64) error {
+	if amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.charges[orderID] = amount
+	return nil
+}
+
+// Refund reverses an order's payment
+func (p *Payments) Refund(orderID string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.charges, orderID)
+	return nil
+}
+
+// Total returns the sum of outstanding charges
+func (p *Payments) Total() float64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var total float64
+	for _, amount := range p.charges {
+		total += amount
+	}
+	return total
+}
+
+// NewCoordinator wires the order steps and their compensations into a saga
+func NewCoordinator(store saga.Store, inventory *Inventory, payments *Payments) *saga.Coordinator {
+	return saga.NewCoordinator("create_order", store,
+		saga.Step{
+			Name: StepReserveInventory,
+			Action: func(ctx context.Context, s *saga.Saga) error {
+				if err := maybeFail(s, "fail_step", StepReserveInventory); err != nil {
+					return err
+				}
+				return inventory.Reserve(s.ID, s.Data["item"].(string), s.Data["quantity"].(int))
+			},
+			Compensate: func(ctx context.Context, s *saga.Saga) error {
+				if err := maybeFail(s, "fail_compensation", StepReserveInventory); err != nil {
+					return err
+				}
+				return inventory.Release(s.ID)
+			},
+		},
+		saga.Step{
+			Name: StepChargePayment,
+			Action: func(ctx context.Context, s *saga.Saga) error {
+				if err := maybeFail(s, "fail_step", StepChargePayment); err != nil {
+					return err
+				}
+				return payments.Charge(s.ID, s.Data["amount"].(float64))
+			},
+			Compensate: func(ctx context.Context, s *saga.Saga) error {
+				if err := maybeFail(s, "fail_compensation", StepChargePayment); err != nil {
+					return err
+				}
+				return payments.Refund(s.ID)
+			},
+		},
+		saga.Step{
+			// Last step: nothing after it can fail, so it needs no compensation
+			Name: StepNotifyCustomer,
+			Action: func(ctx context.Context, s *saga.Saga) error {
+				return maybeFail(s, "fail_step", StepNotifyCustomer)
+			},
+		},
+	)
+}
+
+// SagaData converts a request into the saga's working data
+func SagaData(req Request) map[string]interface{} {
+	return map[string]interface{}{
+		"item":              req.Item,
+		"quantity":          req.Quantity,
+		"amount":            req.Amount,
+		"fail_step":         req.FailStep,
+		"fail_compensation": req.FailCompensation,
+	}
+}
+
+func maybeFail(s *saga.Saga, key, step string) error {
+	if s.Data[key] == step {
+		return fmt.Errorf("%s: %w", step, ErrSimulated)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Event is a domain event waiting in the outbox
+type Event struct {
+	ID            int64           `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"attempts"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// Insert writes an event inside the caller's transaction, so it is committed
+// or rolled back together with the change it describes
+func Insert(ctx context.Context, tx *sql.Tx, aggregateType, aggregateID, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("outbox: encode payload: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload) VALUES (?, ?, ?, ?)",
+		aggregateType, aggregateID, eventType, data)
+	if err != nil {
+		return fmt.Errorf("outbox: insert event: %w", err)
+	}
+	return nil
+}
+
+// Stats describes the relay's progress
+type Stats struct {
+	Pending      int64     `json:"pending"`
+	Published    int64     `json:"published"`
+	Failed       int64     `json:"failed"`
+	RelayedTotal int64     `json:"relayed_total"`
+	LastRunAt    time.Time `json:"last_run_at"`
+	LastError    string    `json:"last_error,omitempty"`
+}
+
+// Relay publishes pending outbox events to Redis Pub/Sub. An event is marked
+// published only after Redis accepted it, so delivery is at-least-once:
+// consumers must tolerate duplicates (the event ID makes that easy).
+type Relay struct {
+	store       Store
+	publisher   Publisher
+	channel     string
+	batchSize   int
+	interval    time.Duration
+	maxAttempts int
+
+	relayed   int64
+	lastRunAt time.Time
+	lastError string
+	mutex     sync.RWMutex
+}
+
+// NewRelay creates a relay that moves events from store to publisher
+func NewRelay(store Store, publisher Publisher, channel string, interval time.Duration) *Relay {
+	return &Relay{
+		store:       store,
+		publisher:   publisher,
+		channel:     channel,
+		batchSize:   100,
+		interval:    interval,
+		maxAttempts: 10,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled
+func (rl *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(rl.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := rl.RelayOnce(ctx)
+			if changed := rl.recordRun(n, err); err != nil && changed {
+				// Only log when the failure changes, not on every tick of an outage
+				logrus.WithError(err).Warn("Outbox relay run failed")
+			} else if n > 0 {
+				logrus.WithField("events", n).Info("Outbox events relayed")
+			}
+		}
+	}
+}
+
+// RelayOnce publishes one batch of pending events and returns how many were published
+func (rl *Relay) RelayOnce(ctx context.Context) (int, error) {
+	batch, err := rl.store.Claim(ctx, rl.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("outbox relay: claim: %w", err)
+	}
+	defer batch.Rollback()
+
+	published := 0
+	for _, e := range batch.Events() {
+		message, _ := json.Marshal(e)
+		if err := rl.publisher.Publish(ctx, rl.channel, message); err != nil {
+			// Without a connection nothing was attempted; release the batch as it was
+			if errors.Is(err, ErrNotConnected) {
+				return 0, fmt.Errorf("outbox relay: %w", err)
+			}
+			if dbErr := batch.MarkFailed(ctx, e.ID, err, e.Attempts+1 >= rl.maxAttempts); dbErr != nil {
+				return published, fmt.Errorf("outbox relay: record failure: %w", dbErr)
+			}
+			continue
+		}
+
+		if err := batch.MarkPublished(ctx, e.ID); err != nil {
+			return published, fmt.Errorf("outbox relay: mark published: %w", err)
+		}
+		published++
+	}
+
+	if err := batch.Commit(); err != nil {
+		return 0, fmt.Errorf("outbox relay: commit: %w", err)
+	}
+	return published, nil
+}
+
+// Stats returns outbox counts from the store plus relay progress
+func (rl *Relay) Stats(ctx context.Context) (Stats, error) {
+	rl.mutex.RLock()
+	stats := Stats{RelayedTotal: rl.relayed, LastRunAt: rl.lastRunAt, LastError: rl.lastError}
+	rl.mutex.RUnlock()
+
+	counts, err := rl.store.Counts(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("outbox: count: %w", err)
+	}
+	stats.Pending = counts[StatusPending]
+	stats.Published = counts[StatusPublished]
+	stats.Failed = counts[StatusFailed]
+	return stats, nil
+}
+
+// recordRun stores the run's outcome and reports whether the error changed
+func (rl *Relay) recordRun(n int, err error) bool {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	previous := rl.lastError
+	rl.relayed += int64(n)
+	rl.lastRunAt = time.Now()
+	rl.lastError = ""
+	if err != nil {
+		rl.lastError = err.Error()
+	}
+	return rl.lastError != previous
+}
@@ -0,0 +1,333 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is an in-process Store with the same claim semantics as
+// SKIP LOCKED: rows held by an open batch are invisible to other claims
+type memoryStore struct {
+	mu        sync.Mutex
+	rows      map[int64]*row
+	commitErr error // Returned by the next Commit, which then keeps nothing
+}
+
+type row struct {
+	event     Event
+	status    string
+	lastError string
+	claimed   bool
+}
+
+func newMemoryStore(n int) *memoryStore {
+	store := &memoryStore{rows: map[int64]*row{}}
+	for id := int64(1); id <= int64(n); id++ {
+		store.rows[id] = &row{
+			event:  Event{ID: id, AggregateType: "user", EventType: "user.created", Payload: json.RawMessage(`{}`), CreatedAt: time.Now()},
+			status: StatusPending,
+		}
+	}
+	return store
+}
+
+func (s *memoryStore) Claim(_ context.Context, limit int) (Batch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int64
+	for id, r := range s.rows {
+		if r.status == StatusPending && !r.claimed {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	batch := &memoryBatch{store: s, updates: map[int64]func(*row){}}
+	for _, id := range ids {
+		s.rows[id].claimed = true
+		batch.events = append(batch.events, s.rows[id].event)
+	}
+	return batch, nil
+}
+
+func (s *memoryStore) Counts(context.Context) (map[string]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := map[string]int64{}
+	for _, r := range s.rows {
+		counts[r.status]++
+	}
+	return counts, nil
+}
+
+func (s *memoryStore) status(id int64) (string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rows[id].status, s.rows[id].event.Attempts
+}
+
+type memoryBatch struct {
+	store   *memoryStore
+	events  []Event
+	updates map[int64]func(*row)
+	done    bool
+}
+
+func (b *memoryBatch) Events() []Event { return b.events }
+
+func (b *memoryBatch) MarkPublished(_ context.Context, id int64) error {
+	b.updates[id] = func(r *row) {
+		r.status = StatusPublished
+		r.event.Attempts++
+	}
+	return nil
+}
+
+func (b *memoryBatch) MarkFailed(_ context.Context, id int64, cause error, final bool) error {
+	b.updates[id] = func(r *row) {
+		r.event.Attempts++
+		r.lastError = cause.Error()
+		if final {
+			r.status = StatusFailed
+		}
+	}
+	return nil
+}
+
+func (b *memoryBatch) Commit() error {
+	b.store.mu.Lock()
+	err := b.store.commitErr
+	b.store.commitErr = nil
+	if err == nil {
+		for id, update := range b.updates {
+			update(b.store.rows[id])
+		}
+	}
+	b.store.mu.Unlock()
+
+	b.release()
+	return err
+}
+
+func (b *memoryBatch) Rollback() error {
+	b.release()
+	return nil
+}
+
+func (b *memoryBatch) release() {
+	b.store.mu.Lock()
+	defer b.store.mu.Unlock()
+	if b.done {
+		return
+	}
+	b.done = true
+	for _, e := range b.events {
+		b.store.rows[e.ID].claimed = false
+	}
+}
+
+// recordingPublisher remembers each delivered event ID and fails where told
+type recordingPublisher struct {
+	mu        sync.Mutex
+	delivered []int64
+	failures  map[int64]int // Publishes of an event that fail before one succeeds
+	err       error         // Returned for every publish when set
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, _ string, message []byte) error {
+	var e Event
+	if err := json.Unmarshal(message, &e); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	if p.failures[e.ID] > 0 {
+		p.failures[e.ID]--
+		return errors.New("redis: connection reset")
+	}
+	p.delivered = append(p.delivered, e.ID)
+	return nil
+}
+
+func TestRelay_RetriesAfterPublishFailure(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore(2)
+	publisher := &recordingPublisher{failures: map[int64]int{1: 1}}
+	relay := NewRelay(store, publisher, "events", time.Second)
+
+	n, err := relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	status, attempts := store.status(1)
+	assert.Equal(t, StatusPending, status, "a failed publish leaves the event for the next run")
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, "redis: connection reset", store.rows[1].lastError)
+
+	n, err = relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	status, attempts = store.status(1)
+	assert.Equal(t, StatusPublished, status)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, []int64{2, 1}, publisher.delivered)
+}
+
+func TestRelay_RedeliversWhenMarkingIsLost(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore(1)
+	store.commitErr = errors.New("connection lost")
+	publisher := &recordingPublisher{}
+	relay := NewRelay(store, publisher, "events", time.Second)
+
+	_, err := relay.RelayOnce(ctx)
+	assert.Error(t, err)
+	status, _ := store.status(1)
+	assert.Equal(t, StatusPending, status)
+
+	n, err := relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// At-least-once: the event went out twice, never zero times
+	assert.Equal(t, []int64{1, 1}, publisher.delivered)
+}
+
+func TestRelay_GivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore(1)
+	publisher := &recordingPublisher{failures: map[int64]int{1: 100}}
+	relay := NewRelay(store, publisher, "events", time.Second)
+	relay.maxAttempts = 3
+
+	for i := 0; i < 5; i++ {
+		_, err := relay.RelayOnce(ctx)
+		require.NoError(t, err)
+	}
+
+	status, attempts := store.status(1)
+	assert.Equal(t, StatusFailed, status)
+	assert.Equal(t, 3, attempts, "a failed event is no longer claimed")
+}
+
+func TestRelay_NotConnectedIsNotAnAttempt(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore(1)
+	relay := NewRelay(store, &recordingPublisher{err: ErrNotConnected}, "events", time.Second)
+
+	_, err := relay.RelayOnce(ctx)
+	assert.ErrorIs(t, err, ErrNotConnected)
+	status, attempts := store.status(1)
+	assert.Equal(t, StatusPending, status)
+	assert.Zero(t, attempts)
+	assert.False(t, store.rows[1].claimed, "the claim is released")
+}
+
+// blockingPublisher holds the first publish until released
+type blockingPublisher struct {
+	recordingPublisher
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (p *blockingPublisher) Publish(ctx context.Context, channel string, message []byte) error {
+	p.once.Do(func() {
+		close(p.started)
+		<-p.release
+	})
+	return p.recordingPublisher.Publish(ctx, channel, message)
+}
+
+func TestRelay_ConcurrentRelaysClaimDisjointEvents(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore(4)
+
+	slow := &blockingPublisher{started: make(chan struct{}), release: make(chan struct{})}
+	first := NewRelay(store, slow, "events", time.Second)
+	first.batchSize = 2
+
+	type result struct {
+		n   int
+		err error
+	}
+	firstDone := make(chan result, 1)
+	go func() {
+		n, err := first.RelayOnce(ctx)
+		firstDone <- result{n, err}
+	}()
+	<-slow.started
+
+	// The first relay holds events 1 and 2; the second takes the rest instead of waiting
+	fast := &recordingPublisher{}
+	n, err := NewRelay(store, fast, "events", time.Second).RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int64{3, 4}, fast.delivered)
+
+	close(slow.release)
+	got := <-firstDone
+	require.NoError(t, got.err)
+	assert.Equal(t, 2, got.n)
+	assert.Equal(t, []int64{1, 2}, slow.delivered)
+
+	counts, err := store.Counts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{StatusPublished: 4}, counts)
+}
+
+func TestRelay_Stats(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore(3)
+	publisher := &recordingPublisher{failures: map[int64]int{2: 100, 3: 1}}
+	relay := NewRelay(store, publisher, "events", time.Second)
+	relay.maxAttempts = 1
+
+	n, err := relay.RelayOnce(ctx)
+	relay.recordRun(n, err)
+
+	stats, err := relay.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), stats.Pending)
+	assert.Equal(t, int64(1), stats.Published)
+	assert.Equal(t, int64(2), stats.Failed)
+	assert.Equal(t, int64(1), stats.RelayedTotal)
+	assert.False(t, stats.LastRunAt.IsZero())
+	assert.Empty(t, stats.LastError)
+
+	assert.True(t, relay.recordRun(0, errors.New("boom")), "a new error is reported as a change")
+	assert.False(t, relay.recordRun(0, errors.New("boom")), "the same error again is not")
+	stats, err = relay.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "boom", stats.LastError)
+}
+
+func TestMySQLStore_NotConnected(t *testing.T) {
+	store := NewMySQLStore(nil)
+
+	_, err := store.Claim(context.Background(), 10)
+	assert.Error(t, err)
+	_, err = store.Counts(context.Background())
+	assert.Error(t, err)
+
+	stats, err := NewRelay(store, NewRedisPublisher(nil), "events", time.Second).Stats(context.Background())
+	assert.Error(t, err)
+	assert.Zero(t, stats.Pending, "relay progress is still returned")
+	assert.ErrorIs(t, NewRedisPublisher(nil).Publish(context.Background(), "events", nil), ErrNotConnected)
+}
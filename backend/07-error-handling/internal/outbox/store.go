@@ -0,0 +1,167 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Event statuses as stored in outbox_events.status
+const (
+	StatusPending   = "pending"
+	StatusPublished = "published"
+	StatusFailed    = "failed"
+)
+
+// ErrNotConnected is returned by a publisher that has no connection at all,
+// as opposed to one whose publish failed
+var ErrNotConnected = errors.New("outbox: not connected")
+
+// Store hands pending events to the relay and records what became of them
+type Store interface {
+	// Claim takes up to limit pending events, oldest first. Events held by
+	// another open batch are skipped rather than waited for.
+	Claim(ctx context.Context, limit int) (Batch, error)
+	// Counts returns how many events are in each status
+	Counts(ctx context.Context) (map[string]int64, error)
+}
+
+// Batch is one relay run's claim. Nothing it records is visible until Commit;
+// Rollback releases the events as they were.
+type Batch interface {
+	Events() []Event
+	MarkPublished(ctx context.Context, id int64) error
+	// MarkFailed counts an attempt; final gives up on the event for good
+	MarkFailed(ctx context.Context, id int64, cause error, final bool) error
+	Commit() error
+	Rollback() error
+}
+
+// Publisher delivers a relayed event
+type Publisher interface {
+	Publish(ctx context.Context, channel string, message []byte) error
+}
+
+// MySQLStore claims rows from outbox_events with SELECT ... FOR UPDATE SKIP LOCKED
+type MySQLStore struct {
+	db *sql.DB
+}
+
+// NewMySQLStore creates a MySQL-backed store; db may be nil when MySQL is down
+func NewMySQLStore(db *sql.DB) *MySQLStore {
+	return &MySQLStore{db: db}
+}
+
+// Claim opens a transaction holding row locks on the claimed events
+func (s *MySQLStore) Claim(ctx context.Context, limit int) (Batch, error) {
+	if s.db == nil {
+		return nil, errors.New("database not connected")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+
+	// SKIP LOCKED lets several instances relay concurrently without double-claiming rows
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, aggregate_type, aggregate_id, event_type, payload, attempts, created_at
+		FROM outbox_events
+		WHERE status = 'pending'
+		ORDER BY id
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("select: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.ID, &e.AggregateType, &e.AggregateID, &e.EventType, &e.Payload, &e.Attempts, &e.CreatedAt); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return &mysqlBatch{tx: tx, events: events}, nil
+}
+
+// Counts groups outbox_events by status
+func (s *MySQLStore) Counts(ctx context.Context) (map[string]int64, error) {
+	if s.db == nil {
+		return nil, errors.New("database not connected")
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT status, COUNT(*) FROM outbox_events GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var status string
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
+
+type mysqlBatch struct {
+	tx     *sql.Tx
+	events []Event
+}
+
+func (b *mysqlBatch) Events() []Event { return b.events }
+
+func (b *mysqlBatch) MarkPublished(ctx context.Context, id int64) error {
+	_, err := b.tx.ExecContext(ctx,
+		"UPDATE outbox_events SET status = 'published', attempts = attempts + 1, published_at = NOW() WHERE id = ?", id)
+	return err
+}
+
+func (b *mysqlBatch) MarkFailed(ctx context.Context, id int64, cause error, final bool) error {
+	status := StatusPending
+	if final {
+		status = StatusFailed
+	}
+	_, err := b.tx.ExecContext(ctx,
+		"UPDATE outbox_events SET attempts = attempts + 1, last_error = ?, status = ? WHERE id = ?",
+		cause.Error(), status, id)
+	return err
+}
+
+func (b *mysqlBatch) Commit() error { return b.tx.Commit() }
+
+func (b *mysqlBatch) Rollback() error { return b.tx.Rollback() }
+
+// RedisPublisher publishes events on a Redis Pub/Sub channel
+type RedisPublisher struct {
+	client *redis.Client
+}
+
+// NewRedisPublisher creates a publisher; client may be nil when Redis is down
+func NewRedisPublisher(client *redis.Client) *RedisPublisher {
+	return &RedisPublisher{client: client}
+}
+
+// Publish sends message to channel
+func (p *RedisPublisher) Publish(ctx context.Context, channel string, message []byte) error {
+	if p.client == nil {
+		return ErrNotConnected
+	}
+	return p.client.Publish(ctx, channel, message).Err()
+}
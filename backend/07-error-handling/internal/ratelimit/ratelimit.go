@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// Result describes the outcome of a rate limit check
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter decides whether one more request fits into key's sliding window
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error)
+}
+
+// RedisLimiter implements a sliding-window log in a Redis sorted set,
+// so all instances share the same counters
+type RedisLimiter struct {
+	client *redis.Client
+}
+
+// NewRedisLimiter creates a Redis-backed limiter
+func NewRedisLimiter(client *redis.Client) *RedisLimiter {
+	return &RedisLimiter{client: client}
+}
+
+// Allow records the request and reports whether it is within the limit
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error) {
+	if l.client == nil {
+		return Result{}, fmt.Errorf("redis limiter: client not connected")
+	}
+
+	now := time.Now()
+	windowStart := now.Add(-window)
+	member := strconv.FormatInt(now.UnixNano(), 10)
+	redisKey := "ratelimit:" + key
+
+	var count *redis.IntCmd
+	var oldest *redis.ZSliceCmd
+	_, err := l.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, redisKey, "0", strconv.FormatInt(windowStart.UnixNano(), 10))
+		pipe.ZAdd(ctx, redisKey, &redis.Z{Score: float64(now.UnixNano()), Member: member})
+		count = pipe.ZCard(ctx, redisKey)
+		oldest = pipe.ZRangeWithScores(ctx, redisKey, 0, 0)
+		pipe.PExpire(ctx, redisKey, window)
+		return nil
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("redis limiter: %w", err)
+	}
+
+	n := int(count.Val())
+	if n <= limit {
+		return Result{Allowed: true, Limit: limit, Remaining: limit - n}, nil
+	}
+
+	// Rejected requests must not consume the window
+	l.client.ZRem(ctx, redisKey, member)
+
+	retryAfter := window
+	if entries := oldest.Val(); len(entries) > 0 {
+		oldestAt := time.Unix(0, int64(entries[0].Score))
+		retryAfter = oldestAt.Add(window).Sub(now)
+	}
+	return Result{Allowed: false, Limit: limit, RetryAfter: retryAfter}, nil
+}
+
+// MemoryLimiter is a per-instance sliding-window log used when Redis is down
+type MemoryLimiter struct {
+	requests  map[string][]time.Time
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+// NewMemoryLimiter creates an in-process limiter
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{requests: make(map[string][]time.Time), lastSweep: time.Now()}
+}
+
+// Allow records the request and reports whether it is within the limit
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	windowStart := now.Add(-window)
+	l.sweep(now, windowStart)
+
+	timestamps := l.requests[key]
+	kept := timestamps[:0]
+	for _, t := range timestamps {
+		if t.After(windowStart) {
+			kept = append(kept, t)
+		}
+	}
+
+	if len(kept) >= limit {
+		l.requests[key] = kept
+		// With a limit of zero nothing was ever kept, so there is no oldest request to wait on
+		retryAfter := window
+		if len(kept) > 0 {
+			retryAfter = kept[0].Add(window).Sub(now)
+		}
+		return Result{Allowed: false, Limit: limit, RetryAfter: retryAfter}, nil
+	}
+
+	kept = append(kept, now)
+	l.requests[key] = kept
+	return Result{Allowed: true, Limit: limit, Remaining: limit - len(kept)}, nil
+}
+
+// sweep drops idle keys once per window so the map doesn't grow with every client seen
+func (l *MemoryLimiter) sweep(now, windowStart time.Time) {
+	if now.Sub(l.lastSweep) < now.Sub(windowStart) {
+		return
+	}
+	l.lastSweep = now
+
+	for key, timestamps := range l.requests {
+		if len(timestamps) == 0 || !timestamps[len(timestamps)-1].After(windowStart) {
+			delete(l.requests, key)
+		}
+	}
+}
+
+// FallbackLimiter prefers the shared Redis limiter and degrades to the
+// in-memory one whenever Redis errors
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+}
+
+// NewFallbackLimiter combines a primary and a fallback limiter
+func NewFallbackLimiter(primary, fallback Limiter) *FallbackLimiter {
+	return &FallbackLimiter{primary: primary, fallback: fallback}
+}
+
+// Allow asks the primary limiter first and the fallback if the primary fails
+func (l *FallbackLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error) {
+	result, err := l.primary.Allow(ctx, key, limit, window)
+	if err == nil {
+		return result, nil
+	}
+
+	logrus.WithContext(ctx).WithError(err).Debug("Rate limiter falling back to in-memory window")
+	return l.fallback.Allow(ctx, key, limit, window)
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       int
+		requests    int
+		wantAllowed int
+	}{
+		{name: "under the limit", limit: 5, requests: 3, wantAllowed: 3},
+		{name: "at the limit", limit: 3, requests: 3, wantAllowed: 3},
+		{name: "over the limit", limit: 3, requests: 5, wantAllowed: 3},
+		{name: "zero limit rejects without panicking", limit: 0, requests: 2, wantAllowed: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewMemoryLimiter()
+
+			allowed := 0
+			var last Result
+			for i := 0; i < tt.requests; i++ {
+				result, err := limiter.Allow(context.Background(), "ip:1.2.3.4", tt.limit, time.Minute)
+				require.NoError(t, err)
+				if result.Allowed {
+					allowed++
+				}
+				last = result
+			}
+
+			assert.Equal(t, tt.wantAllowed, allowed)
+			assert.Equal(t, tt.limit, last.Limit)
+			if !last.Allowed {
+				assert.Positive(t, last.RetryAfter)
+				assert.LessOrEqual(t, last.RetryAfter, time.Minute)
+			}
+		})
+	}
+}
+
+func TestMemoryLimiter_KeysAndWindow(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	ctx := context.Background()
+	window := 20 * time.Millisecond
+
+	result, _ := limiter.Allow(ctx, "a", 1, window)
+	assert.True(t, result.Allowed)
+	result, _ = limiter.Allow(ctx, "a", 1, window)
+	assert.False(t, result.Allowed, "a has used its one request")
+	result, _ = limiter.Allow(ctx, "b", 1, window)
+	assert.True(t, result.Allowed, "b has its own window")
+
+	time.Sleep(30 * time.Millisecond)
+	result, _ = limiter.Allow(ctx, "a", 1, window)
+	assert.True(t, result.Allowed, "the window slid past a's first request")
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, int, time.Duration) (Result, error) {
+	return Result{}, errors.New("redis down")
+}
+
+func TestFallbackLimiter_UsesFallbackOnError(t *testing.T) {
+	limiter := NewFallbackLimiter(failingLimiter{}, NewMemoryLimiter())
+
+	result, err := limiter.Allow(context.Background(), "ip:1.2.3.4", 1, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = limiter.Allow(context.Background(), "ip:1.2.3.4", 1, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed, "the fallback keeps its own count")
+}
+
+func TestRedisLimiter_NotConnected(t *testing.T) {
+	_, err := NewRedisLimiter(nil).Allow(context.Background(), "ip:1.2.3.4", 1, time.Minute)
+	assert.Error(t, err)
+}
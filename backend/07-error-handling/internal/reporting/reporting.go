@@ -0,0 +1,99 @@
+package reporting
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Frame is one call site in a captured stack
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// Event describes a recovered panic or unexpected error
+type Event struct {
+	Fingerprint string                 `json:"fingerprint"`
+	Message     string                 `json:"message"`
+	Frames      []Frame                `json:"frames"`
+	RequestID   string                 `json:"request_id,omitempty"`
+	TraceID     string                 `json:"trace_id,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Timestamp   time.Time              `json:"timestamp"`
+}
+
+// Reporter forwards events to an error tracking service
+type Reporter interface {
+	Report(ctx context.Context, event Event) error
+}
+
+// CaptureStack returns the calling goroutine's stack, skipping the runtime's
+// panic machinery and the first skip frames
+func CaptureStack(skip int) []Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []Frame
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			stack = append(stack, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// FormatStack renders frames one per line, like a trimmed runtime/debug.Stack
+func FormatStack(frames []Frame) string {
+	var b strings.Builder
+	for _, f := range frames {
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+	}
+	return b.String()
+}
+
+// Fingerprint groups events with the same cause: the value's type plus the
+// functions on the stack. Line numbers are left out so small edits elsewhere
+// in a file do not split one issue into many.
+func Fingerprint(value interface{}, frames []Frame) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%T", value)
+	for _, f := range frames {
+		h.Write([]byte(f.Function))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// sampledReporter forwards only a fraction of events
+type sampledReporter struct {
+	next Reporter
+	rate float64
+}
+
+// Sampled wraps next so that only rate (0..1) of events are forwarded
+func Sampled(next Reporter, rate float64) Reporter {
+	if rate >= 1 {
+		return next
+	}
+	return &sampledReporter{next: next, rate: rate}
+}
+
+// Report forwards the event if it is picked by the sample
+func (s *sampledReporter) Report(ctx context.Context, event Event) error {
+	if rand.Float64() >= s.rate {
+		return nil
+	}
+	return s.next.Report(ctx, event)
+}
@@ -0,0 +1,80 @@
+package reporting
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	frames := []Frame{
+		{Function: "main.handler", File: "main.go", Line: 10},
+		{Function: "net/http.HandlerFunc.ServeHTTP", File: "server.go", Line: 2136},
+	}
+	moved := []Frame{
+		{Function: "main.handler", File: "main.go", Line: 42},
+		{Function: "net/http.HandlerFunc.ServeHTTP", File: "server.go", Line: 2200},
+	}
+	otherCaller := []Frame{
+		{Function: "main.otherHandler", File: "main.go", Line: 10},
+		{Function: "net/http.HandlerFunc.ServeHTTP", File: "server.go", Line: 2136},
+	}
+
+	fingerprint := Fingerprint("boom", frames)
+	assert.Len(t, fingerprint, 16)
+	assert.Equal(t, fingerprint, Fingerprint("another message", frames), "the message is not part of it")
+	assert.Equal(t, fingerprint, Fingerprint("boom", moved), "line numbers are not part of it")
+	assert.NotEqual(t, fingerprint, Fingerprint(errors.New("boom"), frames), "the value's type is")
+	assert.NotEqual(t, fingerprint, Fingerprint("boom", otherCaller), "so are the functions")
+}
+
+func TestCaptureStack(t *testing.T) {
+	var frames []Frame
+	func() {
+		defer func() {
+			recover()
+			frames = CaptureStack(1)
+		}()
+		panic("boom")
+	}()
+
+	require.NotEmpty(t, frames)
+	for _, f := range frames {
+		assert.False(t, strings.HasPrefix(f.Function, "runtime."), "runtime frame %s kept", f.Function)
+	}
+	assert.Contains(t, frames[0].Function, "TestCaptureStack", "the panicking function comes first")
+
+	formatted := FormatStack(frames[:1])
+	assert.Contains(t, formatted, frames[0].Function+"\n\t")
+	assert.Contains(t, formatted, "reporting_test.go:")
+}
+
+type countingReporter struct{ count atomic.Int64 }
+
+func (c *countingReporter) Report(context.Context, Event) error {
+	c.count.Add(1)
+	return nil
+}
+
+func TestSampled(t *testing.T) {
+	next := &countingReporter{}
+	assert.Same(t, next, Sampled(next, 1), "a full sample needs no wrapper")
+
+	none := &countingReporter{}
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, Sampled(none, 0).Report(context.Background(), Event{}))
+	}
+	assert.Zero(t, none.count.Load())
+
+	half := &countingReporter{}
+	sampled := Sampled(half, 0.5)
+	for i := 0; i < 10000; i++ {
+		require.NoError(t, sampled.Report(context.Background(), Event{}))
+	}
+	assert.InDelta(t, 5000, half.count.Load(), 500)
+}
@@ -0,0 +1,127 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/tracing"
+)
+
+// SentryReporter posts events to a Sentry-compatible store endpoint.
+// Only the fields this lab needs are sent; any server speaking the Sentry
+// store API (Sentry, GlitchTip, ...) accepts them.
+type SentryReporter struct {
+	endpoint    string
+	publicKey   string
+	environment string
+	client      *http.Client
+}
+
+// NewSentryReporter parses a DSN of the form https://<key>@<host>[/<prefix>]/<project>;
+// a prefix is kept, for servers mounted under a path
+func NewSentryReporter(dsn, environment string) (*SentryReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry DSN: %w", err)
+	}
+
+	path := strings.Trim(u.Path, "/")
+	prefix, project := "", path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		prefix, project = "/"+path[:i], path[i+1:]
+	}
+	if u.User == nil || u.User.Username() == "" || project == "" {
+		return nil, fmt.Errorf("invalid sentry DSN: expected scheme://key@host/project")
+	}
+
+	return &SentryReporter{
+		endpoint:    fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project),
+		publicKey:   u.User.Username(),
+		environment: environment,
+		// A plain transport: request IDs, traceparent and deadlines are for our
+		// own services, not a third party
+		client: &http.Client{Timeout: 5 * time.Second, Transport: http.DefaultTransport},
+	}, nil
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+}
+
+type sentryException struct {
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Stacktrace struct {
+		Frames []sentryFrame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Environment string                 `json:"environment,omitempty"`
+	Fingerprint []string               `json:"fingerprint"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Exception   struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+}
+
+// Report sends the event; Sentry expects frames oldest first
+func (s *SentryReporter) Report(ctx context.Context, event Event) error {
+	payload := sentryEvent{
+		EventID:     strings.ReplaceAll(tracing.NewID(), "-", ""),
+		Timestamp:   event.Timestamp.UTC().Format(time.RFC3339),
+		Level:       "error",
+		Platform:    "go",
+		Environment: s.environment,
+		Fingerprint: []string{event.Fingerprint},
+		Tags:        event.Tags,
+		Extra:       event.Extra,
+	}
+
+	exception := sentryException{Type: "panic", Value: event.Message}
+	for i := len(event.Frames) - 1; i >= 0; i-- {
+		f := event.Frames[i]
+		exception.Stacktrace.Frames = append(exception.Stacktrace.Frames, sentryFrame{
+			Function: f.Function,
+			Filename: f.File,
+			Lineno:   f.Line,
+		})
+	}
+	payload.Exception.Values = []sentryException{exception}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=error-handling-lab/1.0, sentry_key=%s", s.publicKey))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send event to sentry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry rejected event: status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/deadline"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/tracing"
+)
+
+func TestNewSentryReporter(t *testing.T) {
+	tests := []struct {
+		dsn          string
+		wantEndpoint string
+		wantErr      bool
+	}{
+		{dsn: "https://abc123@sentry.example.com/42", wantEndpoint: "https://sentry.example.com/api/42/store/"},
+		{dsn: "http://key@localhost:9000/7/", wantEndpoint: "http://localhost:9000/api/7/store/"},
+		{dsn: "https://abc123@example.com/sentry/42", wantEndpoint: "https://example.com/sentry/api/42/store/"},
+		{dsn: "https://abc123@example.com/errors/sentry/42", wantEndpoint: "https://example.com/errors/sentry/api/42/store/"},
+		{dsn: "https://sentry.example.com/42", wantErr: true},
+		{dsn: "https://abc123@sentry.example.com", wantErr: true},
+		{dsn: "://bad", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			reporter, err := NewSentryReporter(tt.dsn, "test")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEndpoint, reporter.endpoint)
+		})
+	}
+}
+
+func TestSentryReporter_Report(t *testing.T) {
+	var gotPath, gotAuth string
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("X-Sentry-Auth")
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &got))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "://", "://public-key@", 1) + "/42"
+	reporter, err := NewSentryReporter(dsn, "staging")
+	require.NoError(t, err)
+
+	err = reporter.Report(context.Background(), Event{
+		Fingerprint: "abc",
+		Message:     "boom",
+		Frames: []Frame{
+			{Function: "main.handler", File: "main.go", Line: 10},
+			{Function: "net/http.serve", File: "server.go", Line: 99},
+		},
+		Tags:      map[string]string{"route": "/users"},
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "/api/42/store/", gotPath)
+	assert.Contains(t, gotAuth, "sentry_key=public-key")
+	assert.Equal(t, "staging", got["environment"])
+	assert.Equal(t, []interface{}{"abc"}, got["fingerprint"])
+	assert.Equal(t, "2024-01-02T03:04:05Z", got["timestamp"])
+	assert.Len(t, got["event_id"], 32)
+
+	exception := got["exception"].(map[string]interface{})["values"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "boom", exception["value"])
+	frames := exception["stacktrace"].(map[string]interface{})["frames"].([]interface{})
+	require.Len(t, frames, 2)
+	assert.Equal(t, "net/http.serve", frames[0].(map[string]interface{})["function"], "Sentry wants the oldest frame first")
+	assert.Equal(t, "main.handler", frames[1].(map[string]interface{})["function"])
+}
+
+func TestSentryReporter_NoPropagation(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	reporter, err := NewSentryReporter(strings.Replace(server.URL, "://", "://key@", 1)+"/1", "")
+	require.NoError(t, err)
+
+	ctx := tracing.WithRequestID(context.Background(), "req-1")
+	ctx = tracing.WithTrace(ctx, tracing.NewTraceContext())
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	require.NoError(t, reporter.Report(ctx, Event{Timestamp: time.Now()}))
+
+	assert.Empty(t, got.Get("X-Request-ID"), "our identifiers stay in our services")
+	assert.Empty(t, got.Get("traceparent"))
+	assert.Empty(t, got.Get(deadline.Header))
+}
+
+func TestSentryReporter_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	reporter, err := NewSentryReporter(strings.Replace(server.URL, "://", "://key@", 1)+"/1", "")
+	require.NoError(t, err)
+
+	err = reporter.Report(context.Background(), Event{Timestamp: time.Now()})
+	assert.EqualError(t, err, "sentry rejected event: status 429")
+}
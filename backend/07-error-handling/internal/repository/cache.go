@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+)
+
+// ErrCacheMiss is returned when a key is not in Redis
+var ErrCacheMiss = errors.New("cache miss")
+
+const allUsersKey = "users:all"
+
+// UserCache stores users in Redis as a shared, cross-instance cache
+type UserCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewUserCache creates a Redis-backed user cache; client may be nil when Redis is down
+func NewUserCache(client *redis.Client, ttl time.Duration) *UserCache {
+	return &UserCache{client: client, ttl: ttl}
+}
+
+// GetAll returns the cached user list
+func (c *UserCache) GetAll(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	if err := c.get(ctx, allUsersKey, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// SetAll caches the full user list
+func (c *UserCache) SetAll(ctx context.Context, users []models.User) error {
+	return c.set(ctx, allUsersKey, users)
+}
+
+// Get returns a cached user by ID
+func (c *UserCache) Get(ctx context.Context, id int) (*models.User, error) {
+	var user models.User
+	if err := c.get(ctx, userKey(id), &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Set caches a single user and invalidates the list
+func (c *UserCache) Set(ctx context.Context, user models.User) error {
+	if err := c.set(ctx, userKey(user.ID), user); err != nil {
+		return err
+	}
+	return c.client.Del(ctx, allUsersKey).Err()
+}
+
+func (c *UserCache) get(ctx context.Context, key string, dest interface{}) error {
+	if c.client == nil {
+		return ErrNotConnected
+	}
+
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return ErrCacheMiss
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get key %s: %w", key, err)
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("failed to decode key %s: %w", key, err)
+	}
+	return nil
+}
+
+func (c *UserCache) set(ctx context.Context, key string, value interface{}) error {
+	if c.client == nil {
+		return ErrNotConnected
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode key %s: %w", key, err)
+	}
+
+	if err := c.client.Set(ctx, key, data, c.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set key %s: %w", key, err)
+	}
+	return nil
+}
+
+func userKey(id int) string {
+	return fmt.Sprintf("user:%d", id)
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/apperrors"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/outbox"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/tracing"
+)
+
+// mysqlDuplicateEntry is MySQL's ER_DUP_ENTRY error number
+const mysqlDuplicateEntry = 1062
+
+// ErrNotConnected is returned when the dependency was never reached at startup
+var ErrNotConnected = errors.New("dependency not connected")
+
+// UserRepository handles user database operations
+type UserRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository creates a new user repository; db may be nil when MySQL is down
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// GetAll returns all users from the database
+func (r *UserRepository) GetAll(ctx context.Context) ([]models.User, error) {
+	if r.db == nil {
+		return nil, ErrNotConnected
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, email, joined_at FROM users ORDER BY id"+tracing.SQLComment(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.JoinedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetByID returns a single user; the error wraps sql.ErrNoRows when it does not exist
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	if r.db == nil {
+		return nil, ErrNotConnected
+	}
+
+	var u models.User
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, email, joined_at FROM users WHERE id = ?"+tracing.SQLComment(ctx), id).
+		Scan(&u.ID, &u.Name, &u.Email, &u.JoinedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", id, err)
+	}
+
+	return &u, nil
+}
+
+// Create inserts a user and its user.created outbox event in one transaction,
+// then fills in the generated ID and join time
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	if r.db == nil {
+		return ErrNotConnected
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // No-op after a successful commit
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO users (name, email) VALUES (?, ?)"+tracing.SQLComment(ctx), user.Name, user.Email)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return apperrors.Conflict("EMAIL_ALREADY_EXISTS", "A user with this email already exists")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get inserted id: %w", err)
+	}
+
+	user.ID = int(id)
+	err = tx.QueryRowContext(ctx, "SELECT joined_at FROM users WHERE id = ?"+tracing.SQLComment(ctx), user.ID).Scan(&user.JoinedAt)
+	if err != nil {
+		return fmt.Errorf("failed to read created user: %w", err)
+	}
+
+	if err := outbox.Insert(ctx, tx, "user", strconv.Itoa(user.ID), "user.created", user); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user creation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// Budget caps retries to a fraction of requests seen in the current window,
+// so a struggling dependency is not hammered by a retry storm
+type Budget struct {
+	ratio       float64
+	minRetries  int
+	window      time.Duration
+	windowStart time.Time
+	requests    int
+	retries     int
+	rejected    int64
+	mutex       sync.Mutex
+}
+
+// BudgetStats is a point-in-time view of a retry budget
+type BudgetStats struct {
+	Ratio      float64 `json:"ratio"`
+	MinRetries int     `json:"min_retries"`
+	WindowMs   int64   `json:"window_ms"`
+	Requests   int     `json:"requests"`
+	Retries    int     `json:"retries"`
+	Remaining  int     `json:"remaining"`
+	Rejected   int64   `json:"rejected_total"`
+}
+
+// NewBudget creates a retry budget allowing ratio retries per request,
+// plus minRetries per window so low traffic can still retry
+func NewBudget(ratio float64, minRetries int, window time.Duration) *Budget {
+	return &Budget{
+		ratio:       ratio,
+		minRetries:  minRetries,
+		window:      window,
+		windowStart: time.Now(),
+	}
+}
+
+// RecordRequest counts a first attempt against the budget window
+func (b *Budget) RecordRequest() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.rollWindow()
+	b.requests++
+}
+
+// TryRetry reserves a retry if the budget allows it
+func (b *Budget) TryRetry() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.rollWindow()
+	if b.retries >= b.allowance() {
+		b.rejected++
+		return false
+	}
+
+	b.retries++
+	return true
+}
+
+// Stats returns the current budget usage
+func (b *Budget) Stats() BudgetStats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.rollWindow()
+	remaining := b.allowance() - b.retries
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return BudgetStats{
+		Ratio:      b.ratio,
+		MinRetries: b.minRetries,
+		WindowMs:   b.window.Milliseconds(),
+		Requests:   b.requests,
+		Retries:    b.retries,
+		Remaining:  remaining,
+		Rejected:   b.rejected,
+	}
+}
+
+func (b *Budget) allowance() int {
+	return b.minRetries + int(float64(b.requests)*b.ratio)
+}
+
+func (b *Budget) rollWindow() {
+	if time.Since(b.windowStart) >= b.window {
+		b.windowStart = time.Now()
+		b.requests = 0
+		b.retries = 0
+	}
+}
@@ -0,0 +1,55 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudget_TryRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		ratio       float64
+		minRetries  int
+		requests    int
+		wantAllowed int
+	}{
+		{name: "minimum only", ratio: 0.2, minRetries: 2, requests: 0, wantAllowed: 2},
+		{name: "ratio adds to the minimum", ratio: 0.2, minRetries: 2, requests: 10, wantAllowed: 4},
+		{name: "fractions round down", ratio: 0.2, minRetries: 0, requests: 9, wantAllowed: 1},
+		{name: "no budget at all", ratio: 0, minRetries: 0, requests: 100, wantAllowed: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := NewBudget(tt.ratio, tt.minRetries, time.Minute)
+			for i := 0; i < tt.requests; i++ {
+				budget.RecordRequest()
+			}
+
+			allowed := 0
+			for i := 0; i < tt.wantAllowed+5; i++ {
+				if budget.TryRetry() {
+					allowed++
+				}
+			}
+
+			assert.Equal(t, tt.wantAllowed, allowed)
+			stats := budget.Stats()
+			assert.Equal(t, 0, stats.Remaining)
+			assert.Equal(t, int64(5), stats.Rejected)
+			assert.Equal(t, int64(60000), stats.WindowMs)
+		})
+	}
+}
+
+func TestBudget_WindowResets(t *testing.T) {
+	budget := NewBudget(0, 1, 20*time.Millisecond)
+
+	assert.True(t, budget.TryRetry())
+	assert.False(t, budget.TryRetry(), "the window's only retry is spent")
+
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, budget.TryRetry(), "a new window brings a new allowance")
+}
@@ -0,0 +1,86 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HedgeResult reports which attempt produced the returned value
+type HedgeResult[T any] struct {
+	Value   T
+	Attempt int
+	Hedged  bool
+	Elapsed time.Duration
+}
+
+type hedgeOutcome[T any] struct {
+	value   T
+	err     error
+	attempt int
+}
+
+// Hedge runs fn and, if it has not finished after delay, fires a second attempt.
+// The first success wins and the loser's context is cancelled. A nil budget
+// always allows the hedge; otherwise the hedge counts as a retry.
+func Hedge[T any](ctx context.Context, operation string, delay time.Duration, budget *Budget, fn func(ctx context.Context) (T, error)) (HedgeResult[T], error) {
+	start := time.Now()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if budget != nil {
+		budget.RecordRequest()
+	}
+
+	outcomes := make(chan hedgeOutcome[T], 2)
+	launch := func(attempt int) {
+		go func() {
+			value, err := fn(ctx)
+			outcomes <- hedgeOutcome[T]{value: value, err: err, attempt: attempt}
+		}()
+	}
+
+	launch(1)
+	inFlight := 1
+	hedged := false
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if budget != nil && !budget.TryRetry() {
+				logrus.WithContext(ctx).WithField("operation", operation).Warn("Hedge skipped, retry budget exhausted")
+				continue
+			}
+			hedged = true
+			inFlight++
+			launch(2)
+			logrus.WithContext(ctx).WithFields(logrus.Fields{
+				"operation": operation,
+				"delay":     delay,
+			}).Info("Primary attempt slow, hedge request fired")
+
+		case outcome := <-outcomes:
+			inFlight--
+			if outcome.err == nil {
+				return HedgeResult[T]{
+					Value:   outcome.value,
+					Attempt: outcome.attempt,
+					Hedged:  hedged,
+					Elapsed: time.Since(start),
+				}, nil
+			}
+
+			// Hedging targets slow attempts; errors are left to the retry loop
+			if inFlight == 0 {
+				return HedgeResult[T]{Hedged: hedged, Elapsed: time.Since(start)}, outcome.err
+			}
+
+		case <-ctx.Done():
+			return HedgeResult[T]{Hedged: hedged, Elapsed: time.Since(start)}, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedge(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name        string
+		budget      *Budget
+		primary     time.Duration // How long attempt 1 takes
+		primaryErr  error
+		hedge       time.Duration // How long attempt 2 takes
+		wantAttempt int
+		wantHedged  bool
+		wantCalls   int32
+		wantErr     error
+	}{
+		{
+			name:        "fast primary is never hedged",
+			primary:     0,
+			wantAttempt: 1,
+			wantCalls:   1,
+		},
+		{
+			name:        "slow primary loses to the hedge",
+			primary:     time.Second,
+			hedge:       0,
+			wantAttempt: 2,
+			wantHedged:  true,
+			wantCalls:   2,
+		},
+		{
+			name:        "exhausted budget skips the hedge",
+			budget:      NewBudget(0, 0, time.Minute),
+			primary:     50 * time.Millisecond,
+			wantAttempt: 1,
+			wantCalls:   1,
+		},
+		{
+			name:       "an early failure is returned without hedging",
+			primary:    0,
+			primaryErr: errFailed,
+			wantCalls:  1,
+			wantErr:    errFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // The losing attempt can outlive the subtest
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			result, err := Hedge(context.Background(), "test", 10*time.Millisecond, tt.budget, func(ctx context.Context) (int, error) {
+				attempt := int(calls.Add(1))
+				took := tt.primary
+				if attempt == 2 {
+					took = tt.hedge
+				}
+				select {
+				case <-time.After(took):
+				case <-ctx.Done():
+					return 0, ctx.Err()
+				}
+				if attempt == 1 && tt.primaryErr != nil {
+					return 0, tt.primaryErr
+				}
+				return attempt, nil
+			})
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantAttempt, result.Value)
+				assert.Equal(t, tt.wantAttempt, result.Attempt)
+			}
+			assert.Equal(t, tt.wantHedged, result.Hedged)
+			assert.Equal(t, tt.wantCalls, calls.Load())
+		})
+	}
+}
+
+func TestHedge_CountsAgainstBudget(t *testing.T) {
+	budget := NewBudget(0, 1, time.Minute)
+
+	var calls atomic.Int32
+	result, err := Hedge(context.Background(), "test", time.Millisecond, budget, func(ctx context.Context) (int, error) {
+		if calls.Add(1) == 1 {
+			<-ctx.Done() // The primary hangs until the hedge wins
+			return 0, ctx.Err()
+		}
+		return 2, nil
+	})
+
+	require.NoError(t, err)
+	assert.True(t, result.Hedged)
+	stats := budget.Stats()
+	assert.Equal(t, 1, stats.Requests, "the call counts as one request")
+	assert.Equal(t, 1, stats.Retries, "the hedge counts as a retry")
+}
@@ -1,24 +1,87 @@
 package retry
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
 	"time"
 
+	"github.com/e6a5/learning/backend/07-error-handling/internal/deadline"
 	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
 	"github.com/sirupsen/logrus"
 )
 
+// ErrBudgetExhausted is returned when a retry budget refuses another attempt
+var ErrBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryableError lets an error tell the retry loop whether another attempt makes sense
+type RetryableError interface {
+	error
+	Retryable() bool
+}
+
+// permanentError marks a wrapped error as non-retryable
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string   { return e.err.Error() }
+func (e *permanentError) Unwrap() error   { return e.err }
+func (e *permanentError) Retryable() bool { return false }
+
+// Permanent wraps err so that WithRetryContext stops immediately
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsRetryable reports whether err should trigger another attempt
+func IsRetryable(err error) bool {
+	var re RetryableError
+	if errors.As(err, &re) {
+		return re.Retryable()
+	}
+	return true
+}
+
 // WithRetry executes the given function with retry logic
 func WithRetry(operation string, config models.RetryConfig, fn func() error) error {
+	return WithRetryContext(context.Background(), operation, config, func(context.Context) error {
+		return fn()
+	})
+}
+
+// WithRetryContext executes fn with retry logic, aborting as soon as ctx is done.
+// Each attempt gets its own deadline when config.AttemptTimeout is set.
+func WithRetryContext(ctx context.Context, operation string, config models.RetryConfig, fn func(ctx context.Context) error) error {
+	return withRetry(ctx, operation, config, nil, fn)
+}
+
+// WithRetryBudget behaves like WithRetryContext but only retries while budget allows it
+func WithRetryBudget(ctx context.Context, operation string, config models.RetryConfig, budget *Budget, fn func(ctx context.Context) error) error {
+	return withRetry(ctx, operation, config, budget, fn)
+}
+
+func withRetry(ctx context.Context, operation string, config models.RetryConfig, budget *Budget, fn func(ctx context.Context) error) error {
 	var lastErr error
 
+	if budget != nil {
+		budget.RecordRequest()
+	}
+
 	for attempt := 1; attempt <= config.MaxAttempts; attempt++ {
-		lastErr = fn()
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("operation %s cancelled before attempt %d: %w", operation, attempt, err)
+		}
+
+		lastErr = runAttempt(ctx, config, fn)
 		if lastErr == nil {
 			if attempt > 1 {
-				logrus.WithFields(logrus.Fields{
+				logrus.WithContext(ctx).WithFields(logrus.Fields{
 					"operation": operation,
 					"attempt":   attempt,
 				}).Info("Operation succeeded after retry")
@@ -26,24 +89,63 @@ func WithRetry(operation string, config models.RetryConfig, fn func() error) err
 			return nil
 		}
 
+		if !IsRetryable(lastErr) {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{
+				"operation": operation,
+				"attempt":   attempt,
+				"error":     lastErr.Error(),
+			}).Warn("Operation failed with non-retryable error")
+			return fmt.Errorf("operation %s failed permanently: %w", operation, lastErr)
+		}
+
 		if attempt == config.MaxAttempts {
 			break
 		}
 
+		if budget != nil && !budget.TryRetry() {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{
+				"operation": operation,
+				"attempt":   attempt,
+			}).Warn("Retry budget exhausted, giving up")
+			return fmt.Errorf("operation %s failed after %d attempts: %w: %w", operation, attempt, ErrBudgetExhausted, lastErr)
+		}
+
 		delay := calculateBackoffDelay(config, attempt)
-		logrus.WithFields(logrus.Fields{
+
+		// Sleeping only to be cancelled wastes the caller's budget; give up now instead
+		if err := deadline.Check(ctx, operation+" retry", delay); err != nil {
+			return fmt.Errorf("operation %s failed after %d attempts: %w: %w", operation, attempt, err, lastErr)
+		}
+
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
 			"operation": operation,
 			"attempt":   attempt,
 			"error":     lastErr.Error(),
 			"delay":     delay,
 		}).Warn("Operation failed, retrying")
 
-		time.Sleep(delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("operation %s cancelled after %d attempts: %w", operation, attempt, ctx.Err())
+		case <-timer.C:
+		}
 	}
 
 	return fmt.Errorf("operation %s failed after %d attempts: %w", operation, config.MaxAttempts, lastErr)
 }
 
+func runAttempt(ctx context.Context, config models.RetryConfig, fn func(ctx context.Context) error) error {
+	if config.AttemptTimeout <= 0 {
+		return fn(ctx)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, config.AttemptTimeout)
+	defer cancel()
+	return fn(attemptCtx)
+}
+
 func calculateBackoffDelay(config models.RetryConfig, attempt int) time.Duration {
 	delay := float64(config.BaseDelay) * math.Pow(config.BackoffFactor, float64(attempt-1))
 
@@ -0,0 +1,93 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+)
+
+var errTransient = errors.New("transient")
+
+// rejectedError is a failure that says another attempt won't help
+type rejectedError struct{}
+
+func (rejectedError) Error() string   { return "rejected" }
+func (rejectedError) Retryable() bool { return false }
+
+// fastRetries retries up to three times with delays too short to notice
+var fastRetries = models.RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, BackoffFactor: 2}
+
+func TestWithRetryContext_Attempts(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error // One per attempt; attempts past the end succeed
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "succeeds first time", wantCalls: 1},
+		{name: "succeeds after transient failures", errs: []error{errTransient, errTransient}, wantCalls: 3},
+		{name: "gives up after max attempts", errs: []error{errTransient, errTransient, errTransient}, wantCalls: 3, wantErr: errTransient},
+		{name: "permanent stops at once", errs: []error{Permanent(errTransient)}, wantCalls: 1, wantErr: errTransient},
+		{name: "non-retryable stops at once", errs: []error{rejectedError{}}, wantCalls: 1, wantErr: rejectedError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := WithRetryContext(context.Background(), "test", fastRetries, func(context.Context) error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+
+			assert.Equal(t, tt.wantCalls, calls)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestWithRetryContext_CanceledDuringBackoff(t *testing.T) {
+	config := models.RetryConfig{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Minute, BackoffFactor: 1}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	calls := 0
+	start := time.Now()
+	err := WithRetryContext(ctx, "test", config, func(context.Context) error {
+		calls++
+		return errTransient
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls, "no attempt runs after the cancellation")
+	assert.Less(t, time.Since(start), time.Second, "the backoff is cut short")
+}
+
+func TestWithRetryContext_AttemptTimeout(t *testing.T) {
+	config := fastRetries
+	config.AttemptTimeout = 10 * time.Millisecond
+
+	calls := 0
+	err := WithRetryContext(context.Background(), "test", config, func(ctx context.Context) error {
+		calls++
+		_, ok := ctx.Deadline()
+		require.True(t, ok, "each attempt has its own deadline")
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 3, calls, "a timed out attempt is retried")
+}
@@ -0,0 +1,172 @@
+package saga
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Status is the lifecycle state of a saga
+type Status string
+
+const (
+	StatusRunning            Status = "running"
+	StatusCompleted          Status = "completed"
+	StatusCompensating       Status = "compensating"
+	StatusCompensated        Status = "compensated"
+	StatusCompensationFailed Status = "compensation_failed"
+)
+
+// StepStatus is the state of a single step
+type StepStatus string
+
+const (
+	StepPending            StepStatus = "pending"
+	StepDone               StepStatus = "done"
+	StepFailed             StepStatus = "failed"
+	StepCompensated        StepStatus = "compensated"
+	StepCompensationFailed StepStatus = "compensation_failed"
+)
+
+// Step is one unit of work with the action that undoes it
+type Step struct {
+	Name       string
+	Action     func(ctx context.Context, s *Saga) error
+	Compensate func(ctx context.Context, s *Saga) error
+}
+
+// StepRecord is the persisted outcome of a step
+type StepRecord struct {
+	Name       string     `json:"name"`
+	Status     StepStatus `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Saga is the persisted state of one execution
+type Saga struct {
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	Status    Status                 `json:"status"`
+	Data      map[string]interface{} `json:"data"`
+	Steps     []StepRecord           `json:"steps"`
+	Error     string                 `json:"error,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// Coordinator runs steps in order and compensates completed steps in reverse on failure
+type Coordinator struct {
+	name  string
+	steps []Step
+	store Store
+}
+
+// NewCoordinator creates a coordinator for a fixed sequence of steps
+func NewCoordinator(name string, store Store, steps ...Step) *Coordinator {
+	return &Coordinator{name: name, steps: steps, store: store}
+}
+
+// New creates a saga in the running state with one pending record per step
+func (c *Coordinator) New(id string, data map[string]interface{}) *Saga {
+	now := time.Now()
+	s := &Saga{
+		ID:        id,
+		Name:      c.name,
+		Status:    StatusRunning,
+		Data:      data,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, step := range c.steps {
+		s.Steps = append(s.Steps, StepRecord{Name: step.Name, Status: StepPending})
+	}
+	return s
+}
+
+// Execute runs the saga to completion or compensation, persisting after every transition
+func (c *Coordinator) Execute(ctx context.Context, s *Saga) error {
+	c.save(ctx, s)
+
+	for i, step := range c.steps {
+		c.startStep(s, i)
+		c.save(ctx, s)
+
+		if err := step.Action(ctx, s); err != nil {
+			c.finishStep(s, i, StepFailed, err)
+			s.Error = fmt.Sprintf("step %s failed: %v", step.Name, err)
+			s.Status = StatusCompensating
+			c.save(ctx, s)
+
+			logrus.WithContext(ctx).WithFields(logrus.Fields{
+				"saga_id": s.ID,
+				"step":    step.Name,
+				"error":   err.Error(),
+			}).Warn("Saga step failed, compensating")
+
+			c.compensate(ctx, s, i-1)
+			return fmt.Errorf("saga %s: %s", s.ID, s.Error)
+		}
+
+		c.finishStep(s, i, StepDone, nil)
+		c.save(ctx, s)
+	}
+
+	s.Status = StatusCompleted
+	c.save(ctx, s)
+	return nil
+}
+
+// compensate undoes steps from index last down to 0
+func (c *Coordinator) compensate(ctx context.Context, s *Saga, last int) {
+	s.Status = StatusCompensated
+
+	for i := last; i >= 0; i-- {
+		step := c.steps[i]
+		if step.Compensate == nil {
+			continue
+		}
+
+		if err := step.Compensate(ctx, s); err != nil {
+			c.finishStep(s, i, StepCompensationFailed, err)
+			s.Status = StatusCompensationFailed
+
+			logrus.WithContext(ctx).WithFields(logrus.Fields{
+				"saga_id": s.ID,
+				"step":    step.Name,
+				"error":   err.Error(),
+			}).Error("Saga compensation failed, manual intervention required")
+		} else {
+			c.finishStep(s, i, StepCompensated, nil)
+		}
+		c.save(ctx, s)
+	}
+
+	// Also covers a first step failing, where there is nothing to undo
+	c.save(ctx, s)
+}
+
+func (c *Coordinator) startStep(s *Saga, i int) {
+	now := time.Now()
+	s.Steps[i].StartedAt = &now
+}
+
+func (c *Coordinator) finishStep(s *Saga, i int, status StepStatus, err error) {
+	now := time.Now()
+	s.Steps[i].Status = status
+	s.Steps[i].FinishedAt = &now
+	if err != nil {
+		s.Steps[i].Error = err.Error()
+	}
+}
+
+// save persists the saga; a store failure is logged but does not abort the saga
+func (c *Coordinator) save(ctx context.Context, s *Saga) {
+	s.UpdatedAt = time.Now()
+	if err := c.store.Save(ctx, s); err != nil {
+		logrus.WithContext(ctx).WithError(err).WithField("saga_id", s.ID).Error("Failed to persist saga state")
+	}
+}
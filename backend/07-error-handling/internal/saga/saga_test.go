@@ -0,0 +1,197 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingStore keeps every saved status on top of a memory store
+type recordingStore struct {
+	*MemoryStore
+	statuses []Status
+	err      error
+}
+
+func (st *recordingStore) Save(ctx context.Context, s *Saga) error {
+	st.statuses = append(st.statuses, s.Status)
+	if st.err != nil {
+		return st.err
+	}
+	return st.MemoryStore.Save(ctx, s)
+}
+
+// steps builds named steps that log their calls, failing where asked
+func steps(calls *[]string, failAction, failCompensation map[string]bool) []Step {
+	var built []Step
+	for _, name := range []string{"reserve", "charge", "notify"} {
+		name := name
+		built = append(built, Step{
+			Name: name,
+			Action: func(context.Context, *Saga) error {
+				*calls = append(*calls, name)
+				if failAction[name] {
+					return errors.New(name + " refused")
+				}
+				return nil
+			},
+			Compensate: func(context.Context, *Saga) error {
+				*calls = append(*calls, "undo "+name)
+				if failCompensation[name] {
+					return errors.New("undo " + name + " refused")
+				}
+				return nil
+			},
+		})
+	}
+	return built
+}
+
+func stepStatuses(s *Saga) []StepStatus {
+	var statuses []StepStatus
+	for _, step := range s.Steps {
+		statuses = append(statuses, step.Status)
+	}
+	return statuses
+}
+
+func TestCoordinator_Execute(t *testing.T) {
+	tests := []struct {
+		name             string
+		failAction       map[string]bool
+		failCompensation map[string]bool
+		wantErr          string
+		wantCalls        []string
+		wantStatus       Status
+		wantSteps        []StepStatus
+	}{
+		{
+			name:       "every step succeeds",
+			wantCalls:  []string{"reserve", "charge", "notify"},
+			wantStatus: StatusCompleted,
+			wantSteps:  []StepStatus{StepDone, StepDone, StepDone},
+		},
+		{
+			name:       "completed steps are compensated in reverse",
+			failAction: map[string]bool{"notify": true},
+			wantErr:    "step notify failed: notify refused",
+			wantCalls:  []string{"reserve", "charge", "notify", "undo charge", "undo reserve"},
+			wantStatus: StatusCompensated,
+			wantSteps:  []StepStatus{StepCompensated, StepCompensated, StepFailed},
+		},
+		{
+			name:       "a failing first step has nothing to undo",
+			failAction: map[string]bool{"reserve": true},
+			wantErr:    "step reserve failed",
+			wantCalls:  []string{"reserve"},
+			wantStatus: StatusCompensated,
+			wantSteps:  []StepStatus{StepFailed, StepPending, StepPending},
+		},
+		{
+			name:             "a failing compensation still lets earlier ones run",
+			failAction:       map[string]bool{"notify": true},
+			failCompensation: map[string]bool{"charge": true},
+			wantErr:          "step notify failed",
+			wantCalls:        []string{"reserve", "charge", "notify", "undo charge", "undo reserve"},
+			wantStatus:       StatusCompensationFailed,
+			wantSteps:        []StepStatus{StepCompensated, StepCompensationFailed, StepFailed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			store := &recordingStore{MemoryStore: NewMemoryStore()}
+			coordinator := NewCoordinator("order", store, steps(&calls, tt.failAction, tt.failCompensation)...)
+
+			s := coordinator.New("saga-1", map[string]interface{}{"order_id": "o-1"})
+			err := coordinator.Execute(context.Background(), s)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.wantStatus, s.Status)
+			assert.Equal(t, tt.wantSteps, stepStatuses(s))
+
+			// What a restart would see is the final state, not a stale snapshot
+			persisted, err := store.Get(context.Background(), "saga-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, persisted.Status)
+			assert.Equal(t, tt.wantSteps, stepStatuses(persisted))
+			assert.Equal(t, "o-1", persisted.Data["order_id"])
+			assert.Equal(t, s.Error, persisted.Error)
+		})
+	}
+}
+
+func TestCoordinator_PersistsEveryTransition(t *testing.T) {
+	var calls []string
+	store := &recordingStore{MemoryStore: NewMemoryStore()}
+	coordinator := NewCoordinator("order", store, steps(&calls, map[string]bool{"charge": true}, nil)...)
+
+	s := coordinator.New("saga-1", nil)
+	require.Error(t, coordinator.Execute(context.Background(), s))
+
+	// Start, reserve started and done, charge started and failed, one save per
+	// compensation, then the outcome
+	assert.Equal(t, []Status{
+		StatusRunning,
+		StatusRunning, StatusRunning,
+		StatusRunning, StatusCompensating,
+		StatusCompensated,
+		StatusCompensated,
+	}, store.statuses)
+
+	persisted, err := store.Get(context.Background(), "saga-1")
+	require.NoError(t, err)
+	assert.Equal(t, "charge refused", persisted.Steps[1].Error)
+	assert.NotNil(t, persisted.Steps[1].StartedAt)
+	assert.NotNil(t, persisted.Steps[1].FinishedAt)
+	assert.Nil(t, persisted.Steps[2].StartedAt, "steps after the failure never start")
+}
+
+func TestCoordinator_StoreFailureDoesNotAbort(t *testing.T) {
+	var calls []string
+	store := &recordingStore{MemoryStore: NewMemoryStore(), err: errors.New("redis down")}
+	coordinator := NewCoordinator("order", store, steps(&calls, nil, nil)...)
+
+	s := coordinator.New("saga-1", nil)
+	assert.NoError(t, coordinator.Execute(context.Background(), s))
+	assert.Equal(t, StatusCompleted, s.Status)
+	assert.Equal(t, []string{"reserve", "charge", "notify"}, calls)
+}
+
+func TestFallbackStore(t *testing.T) {
+	ctx := context.Background()
+	primary := &recordingStore{MemoryStore: NewMemoryStore(), err: errors.New("redis down")}
+	fallback := NewMemoryStore()
+	store := NewFallbackStore(primary, fallback)
+
+	s := &Saga{ID: "saga-1", Status: StatusRunning, Steps: []StepRecord{{Name: "reserve", Status: StepPending}}}
+	require.NoError(t, store.Save(ctx, s), "state is kept in memory while Redis is down")
+
+	// The stored copy does not change with the caller's saga
+	s.Steps[0].Status = StepDone
+	got, err := store.Get(ctx, "saga-1")
+	require.NoError(t, err)
+	assert.Equal(t, StepPending, got.Steps[0].Status)
+
+	// Once parked in memory, later saves stay there so reads never go backwards
+	primary.err = nil
+	s.Status = StatusCompleted
+	require.NoError(t, store.Save(ctx, s))
+	_, err = primary.Get(ctx, "saga-1")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	sagas, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, sagas, 1)
+	assert.Equal(t, StatusCompleted, sagas[0].Status)
+}
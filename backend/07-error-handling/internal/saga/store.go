@@ -0,0 +1,193 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNotFound is returned when a saga ID is unknown
+var ErrNotFound = errors.New("saga not found")
+
+// Store persists saga state
+type Store interface {
+	Save(ctx context.Context, s *Saga) error
+	Get(ctx context.Context, id string) (*Saga, error)
+	List(ctx context.Context) ([]*Saga, error)
+}
+
+const redisKey = "sagas"
+
+// RedisStore keeps sagas in a Redis hash
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a Redis-backed store; client may be nil when Redis is down
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Save writes the saga
+func (st *RedisStore) Save(ctx context.Context, s *Saga) error {
+	if st.client == nil {
+		return errors.New("saga store: redis not connected")
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("saga store: encode: %w", err)
+	}
+	if err := st.client.HSet(ctx, redisKey, s.ID, data).Err(); err != nil {
+		return fmt.Errorf("saga store: %w", err)
+	}
+	return nil
+}
+
+// Get reads one saga
+func (st *RedisStore) Get(ctx context.Context, id string) (*Saga, error) {
+	if st.client == nil {
+		return nil, errors.New("saga store: redis not connected")
+	}
+
+	data, err := st.client.HGet(ctx, redisKey, id).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("saga store: %w", err)
+	}
+
+	var s Saga
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("saga store: decode: %w", err)
+	}
+	return &s, nil
+}
+
+// List returns all sagas, newest first
+func (st *RedisStore) List(ctx context.Context) ([]*Saga, error) {
+	if st.client == nil {
+		return nil, errors.New("saga store: redis not connected")
+	}
+
+	values, err := st.client.HVals(ctx, redisKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("saga store: %w", err)
+	}
+
+	sagas := make([]*Saga, 0, len(values))
+	for _, value := range values {
+		var s Saga
+		if err := json.Unmarshal([]byte(value), &s); err != nil {
+			return nil, fmt.Errorf("saga store: decode: %w", err)
+		}
+		sagas = append(sagas, &s)
+	}
+	sortNewestFirst(sagas)
+	return sagas, nil
+}
+
+// MemoryStore keeps sagas in process
+type MemoryStore struct {
+	sagas map[string]Saga
+	mutex sync.RWMutex
+}
+
+// NewMemoryStore creates an in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sagas: make(map[string]Saga)}
+}
+
+// Save writes a copy of the saga
+func (st *MemoryStore) Save(ctx context.Context, s *Saga) error {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	copied := *s
+	copied.Steps = append([]StepRecord(nil), s.Steps...)
+	st.sagas[s.ID] = copied
+	return nil
+}
+
+// Get reads one saga
+func (st *MemoryStore) Get(ctx context.Context, id string) (*Saga, error) {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	s, ok := st.sagas[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &s, nil
+}
+
+// List returns all sagas, newest first
+func (st *MemoryStore) List(ctx context.Context) ([]*Saga, error) {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	sagas := make([]*Saga, 0, len(st.sagas))
+	for id := range st.sagas {
+		s := st.sagas[id]
+		sagas = append(sagas, &s)
+	}
+	sortNewestFirst(sagas)
+	return sagas, nil
+}
+
+// FallbackStore prefers Redis and keeps sagas in memory while Redis is down
+type FallbackStore struct {
+	primary  Store
+	fallback Store
+}
+
+// NewFallbackStore combines a durable primary and an in-process fallback
+func NewFallbackStore(primary, fallback Store) *FallbackStore {
+	return &FallbackStore{primary: primary, fallback: fallback}
+}
+
+// Save writes to the primary, or the fallback if the primary fails
+func (st *FallbackStore) Save(ctx context.Context, s *Saga) error {
+	if _, err := st.fallback.Get(ctx, s.ID); err == nil {
+		return st.fallback.Save(ctx, s)
+	}
+	if err := st.primary.Save(ctx, s); err != nil {
+		logrus.WithContext(ctx).WithError(err).Warn("Saga store unavailable, keeping state in memory")
+		return st.fallback.Save(ctx, s)
+	}
+	return nil
+}
+
+// Get looks in the fallback first, then the primary
+func (st *FallbackStore) Get(ctx context.Context, id string) (*Saga, error) {
+	if s, err := st.fallback.Get(ctx, id); err == nil {
+		return s, nil
+	}
+	return st.primary.Get(ctx, id)
+}
+
+// List merges sagas from both stores
+func (st *FallbackStore) List(ctx context.Context) ([]*Saga, error) {
+	sagas, _ := st.fallback.List(ctx)
+	primary, err := st.primary.List(ctx)
+	if err != nil {
+		return sagas, nil
+	}
+
+	sagas = append(sagas, primary...)
+	sortNewestFirst(sagas)
+	return sagas, nil
+}
+
+func sortNewestFirst(sagas []*Saga) {
+	sort.Slice(sagas, func(i, j int) bool {
+		return sagas[i].CreatedAt.After(sagas[j].CreatedAt)
+	})
+}
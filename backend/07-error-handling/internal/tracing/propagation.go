@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/deadline"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// LogHook adds request_id and trace fields to every entry logged with WithContext
+type LogHook struct{}
+
+// Levels applies the hook to all log levels
+func (LogHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+// Fire copies identifiers from the entry's context into its fields
+func (LogHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	if id := RequestID(entry.Context); id != "" {
+		entry.Data["request_id"] = id
+	}
+	if tc, ok := Trace(entry.Context); ok {
+		entry.Data["trace_id"] = tc.TraceID
+		entry.Data["span_id"] = tc.SpanID
+	}
+	return nil
+}
+
+// Transport propagates the request ID, a child traceparent and the remaining
+// deadline budget on outgoing HTTP calls
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// RoundTrip injects propagation headers before delegating to the base transport
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	req = req.Clone(ctx)
+
+	if id := RequestID(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	if tc, ok := Trace(ctx); ok {
+		req.Header.Set("traceparent", tc.Child().Traceparent())
+	}
+	if budget, ok := deadline.FormatHeader(ctx); ok {
+		req.Header.Set(deadline.Header, budget)
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// SQLComment renders the request identifiers as a sqlcommenter-style comment,
+// so slow query logs on the MySQL side can be tied back to a request
+func SQLComment(ctx context.Context) string {
+	var fields []string
+	if id := RequestID(ctx); id != "" {
+		fields = append(fields, fmt.Sprintf("request_id='%s'", url.QueryEscape(id)))
+	}
+	if tc, ok := Trace(ctx); ok {
+		fields = append(fields, fmt.Sprintf("traceparent='%s'", url.QueryEscape(tc.Traceparent())))
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return " /*" + strings.Join(fields, ",") + "*/"
+}
+
+// RedisHook logs failed Redis commands with the request identifiers from ctx
+type RedisHook struct{}
+
+// BeforeProcess is a no-op; identifiers already live in ctx
+func (RedisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcess logs command failures other than cache misses
+func (RedisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if err := cmd.Err(); err != nil && err != redis.Nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"command": cmd.Name(),
+			"error":   err.Error(),
+		}).Warn("Redis command failed")
+	}
+	return nil
+}
+
+// BeforeProcessPipeline is a no-op
+func (RedisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcessPipeline logs each failed command in the pipeline
+func (h RedisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		_ = h.AfterProcess(ctx, cmd)
+	}
+	return nil
+}
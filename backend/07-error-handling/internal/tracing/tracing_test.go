@@ -0,0 +1,160 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/07-error-handling/internal/deadline"
+)
+
+const (
+	traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	spanID  = "00f067aa0ba902b7"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   TraceContext
+		ok     bool
+	}{
+		{name: "sampled", header: "00-" + traceID + "-" + spanID + "-01", want: TraceContext{TraceID: traceID, SpanID: spanID, Flags: "01"}, ok: true},
+		{name: "not sampled", header: "00-" + traceID + "-" + spanID + "-00", want: TraceContext{TraceID: traceID, SpanID: spanID, Flags: "00"}, ok: true},
+		{name: "surrounding spaces", header: " 00-" + traceID + "-" + spanID + "-01 ", want: TraceContext{TraceID: traceID, SpanID: spanID, Flags: "01"}, ok: true},
+		{name: "empty", header: ""},
+		{name: "unknown version", header: "01-" + traceID + "-" + spanID + "-01"},
+		{name: "extra field", header: "00-" + traceID + "-" + spanID + "-01-xx"},
+		{name: "missing field", header: "00-" + traceID + "-" + spanID},
+		{name: "short trace ID", header: "00-" + traceID[:30] + "-" + spanID + "-01"},
+		{name: "short span ID", header: "00-" + traceID + "-" + spanID[:14] + "-01"},
+		{name: "uppercase hex", header: "00-" + strings.ToUpper(traceID) + "-" + spanID + "-01"},
+		{name: "not hex", header: "00-" + strings.Repeat("g", 32) + "-" + spanID + "-01"},
+		{name: "all-zero trace ID", header: "00-" + strings.Repeat("0", 32) + "-" + spanID + "-01"},
+		{name: "all-zero span ID", header: "00-" + traceID + "-" + strings.Repeat("0", 16) + "-01"},
+		{name: "bad flags", header: "00-" + traceID + "-" + spanID + "-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseTraceparent(tt.header)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTraceContext_Child(t *testing.T) {
+	parent, ok := ParseTraceparent("00-" + traceID + "-" + spanID + "-01")
+	require.True(t, ok)
+
+	child := parent.Child()
+	assert.Equal(t, traceID, child.TraceID)
+	assert.Equal(t, spanID, child.ParentSpanID)
+	assert.NotEqual(t, spanID, child.SpanID)
+	assert.Equal(t, "01", child.Flags)
+
+	// What goes out parses back to the same span
+	again, ok := ParseTraceparent(child.Traceparent())
+	require.True(t, ok)
+	assert.Equal(t, child.SpanID, again.SpanID)
+
+	_, ok = ParseTraceparent(NewTraceContext().Traceparent())
+	assert.True(t, ok, "a new trace is a valid traceparent")
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"550e8400-e29b-41d4-a716-446655440000", true},
+		{"req_42.retry:1", true},
+		{strings.Repeat("a", 64), true},
+		{"", false},
+		{strings.Repeat("a", 65), false},
+		{"has space", false},
+		{"quote'", false},
+		{"end*/ comment", false},
+		{"new\nline", false},
+		{"crlf\r\nX-Injected: 1", false},
+		{"ünïcode", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			assert.Equal(t, tt.valid, ValidRequestID(tt.id))
+		})
+	}
+}
+
+func TestNewID(t *testing.T) {
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		id := NewID()
+		assert.Regexp(t, uuidV4, id)
+		assert.True(t, ValidRequestID(id), "our own IDs pass our own check")
+		assert.False(t, seen[id], "IDs do not repeat")
+		seen[id] = true
+	}
+}
+
+func TestSQLComment(t *testing.T) {
+	assert.Empty(t, SQLComment(context.Background()))
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = WithTrace(ctx, TraceContext{TraceID: traceID, SpanID: spanID, Flags: "01"})
+	assert.Equal(t, " /*request_id='req-1',traceparent='00-"+traceID+"-"+spanID+"-01'*/", SQLComment(ctx))
+
+	// IDs are validated before they get here, but the comment is escaped anyway
+	ctx = WithRequestID(context.Background(), "x'*/ DROP TABLE users; --")
+	assert.Equal(t, " /*request_id='x%27%2A%2F+DROP+TABLE+users%3B+--'*/", SQLComment(ctx))
+}
+
+func TestTransport(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &Transport{}}
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = WithTrace(ctx, TraceContext{TraceID: traceID, SpanID: spanID, Flags: "01"})
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "req-1", got.Get("X-Request-ID"))
+	sent, ok := ParseTraceparent(got.Get("traceparent"))
+	require.True(t, ok)
+	assert.Equal(t, traceID, sent.TraceID)
+	assert.NotEqual(t, spanID, sent.SpanID, "the callee gets a child span")
+	assert.NotEmpty(t, got.Get(deadline.Header))
+	assert.Empty(t, req.Header.Get("X-Request-ID"), "the caller's request is not modified")
+
+	// Nothing in ctx, nothing sent
+	req, err = http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Empty(t, got.Get("X-Request-ID"))
+	assert.Empty(t, got.Get("traceparent"))
+	assert.Empty(t, got.Get(deadline.Header))
+}
@@ -4,9 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"math/rand"
 	"net/http"
 	"os"
-	"sync"
+	"os/signal"
+	"strconv"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -15,23 +20,48 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 
+	"github.com/e6a5/learning/backend/07-error-handling/internal/apperrors"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/bulkhead"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/cache"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/chaos"
 	"github.com/e6a5/learning/backend/07-error-handling/internal/circuit"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/deadline"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/degrade"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/dlq"
 	"github.com/e6a5/learning/backend/07-error-handling/internal/handlers"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/idempotency"
 	"github.com/e6a5/learning/backend/07-error-handling/internal/middleware"
 	"github.com/e6a5/learning/backend/07-error-handling/internal/models"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/orders"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/outbox"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/ratelimit"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/reporting"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/repository"
 	"github.com/e6a5/learning/backend/07-error-handling/internal/retry"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/saga"
+	"github.com/e6a5/learning/backend/07-error-handling/internal/tracing"
+	"github.com/e6a5/learning/backend/pkg/env"
+	"github.com/e6a5/learning/backend/pkg/response"
 )
 
 // App holds application dependencies - small, focused
 type App struct {
-	db             *sql.DB
-	redis          *redis.Client
-	dbCircuit      *circuit.Breaker
-	redisCircuit   *circuit.Breaker
-	userCache      map[int]*models.User
-	cacheMutex     sync.RWMutex
-	requestCounter int64
-	counterMutex   sync.Mutex
+	db            *sql.DB
+	redis         *redis.Client
+	dbCircuit     *circuit.Breaker
+	redisCircuit  *circuit.Breaker
+	retryBudget   *retry.Budget
+	dbBulkhead    *bulkhead.Bulkhead
+	redisBulkhead *bulkhead.Bulkhead
+	userCache     *cache.LRU[int, models.User]
+	deadLetters   *dlq.FallbackStore
+	chaos         *chaos.Injector
+	outboxRelay   *outbox.Relay
+	reporter      reporting.Reporter
+	degradation   *degrade.Controller
+	rateLimit     middleware.RateLimitConfig
+	adminToken    string
+	ready         atomic.Bool
 }
 
 func main() {
@@ -40,9 +70,41 @@ func main() {
 
 	// Initialize application with dependencies
 	app := &App{
-		userCache:    make(map[int]*models.User),
-		dbCircuit:    circuit.New("database", 5, 30*time.Second),
-		redisCircuit: circuit.New("redis", 3, 15*time.Second),
+		userCache:     cache.NewLRU[int, models.User](1000, 5*time.Minute, 30*time.Minute),
+		dbCircuit:     circuit.New("database", 5, 30*time.Second),
+		redisCircuit:  circuit.New("redis", 3, 15*time.Second),
+		retryBudget:   retry.NewBudget(0.2, 5, 10*time.Second),
+		dbBulkhead:    bulkhead.New("database", 20, 50, 2*time.Second),
+		redisBulkhead: bulkhead.New("redis", 30, 100, 500*time.Millisecond),
+	}
+
+	injector, err := loadChaosConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid chaos configuration")
+	}
+	app.chaos = injector
+
+	degradation, err := loadDegradationModes()
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid degradation modes")
+	}
+	app.degradation = degradation
+
+	reporter, err := loadErrorReporter()
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid error reporter configuration")
+	}
+	app.reporter = reporter
+
+	rateLimit, err := loadRateLimitConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid rate limit configuration")
+	}
+	app.rateLimit = rateLimit
+
+	app.adminToken = env.Get("ADMIN_TOKEN", "")
+	if app.adminToken == "" {
+		logrus.Info("ADMIN_TOKEN not set, operator endpoints refuse every request")
 	}
 
 	// Initialize databases with retry logic
@@ -50,9 +112,27 @@ func main() {
 		logrus.WithError(err).Warn("Failed to initialize some dependencies, continuing with degraded functionality")
 	}
 
+	// Relay outbox events to Redis Pub/Sub in the background
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	app.outboxRelay = outbox.NewRelay(outbox.NewMySQLStore(app.db), outbox.NewRedisPublisher(app.redis), "events.users", getEnvDuration("OUTBOX_RELAY_INTERVAL", time.Second))
+	go func() {
+		defer close(relayDone)
+		app.outboxRelay.Run(relayCtx)
+	}()
+
 	// Setup HTTP server
 	router := app.setupRoutes()
-	port := getEnv("PORT", "8080")
+	port := env.Get("PORT", "8080")
+
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           router,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
 
 	logrus.WithFields(logrus.Fields{
 		"port":    port,
@@ -60,9 +140,78 @@ func main() {
 	}).Info("🔥 Error Handling Server starting")
 
 	// Start server
-	if err := http.ListenAndServe(":"+port, router); err != nil {
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+	app.ready.Store(true)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serverErr:
 		logrus.WithError(err).Fatal("Server failed to start")
+	case sig := <-quit:
+		logrus.WithField("signal", sig.String()).Info("Shutdown signal received")
 	}
+
+	app.shutdown(server, stopRelay, relayDone)
+}
+
+// shutdown stops taking traffic, drains in-flight requests, then flushes state
+// that would otherwise be lost with the process.
+func (app *App) shutdown(server *http.Server, stopRelay context.CancelFunc, relayDone <-chan struct{}) {
+	// Fail readiness first and give load balancers time to notice before the listener closes
+	app.ready.Store(false)
+	drainDelay := getEnvDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second)
+	logrus.WithField("delay", drainDelay).Info("Readiness set to false, waiting before draining")
+	time.Sleep(drainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second))
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logrus.WithError(err).Error("In-flight requests did not finish in time, forcing shutdown")
+		server.Close()
+	} else {
+		logrus.Info("In-flight requests drained")
+	}
+
+	// Finish the current relay batch so no row is left claimed mid-publish
+	stopRelay()
+	select {
+	case <-relayDone:
+	case <-ctx.Done():
+		logrus.Warn("Outbox relay did not stop in time")
+	}
+
+	// Dead letters parked in memory during a Redis outage would die with the process.
+	// Draining may have used up ctx, so the flush gets a deadline of its own.
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), getEnvDuration("SHUTDOWN_FLUSH_TIMEOUT", 5*time.Second))
+	defer cancelFlush()
+	if moved, err := app.deadLetters.Flush(flushCtx); err != nil {
+		logrus.WithError(err).WithField("flushed", moved).Error("Failed to flush in-memory dead letters, they will be lost")
+	} else if moved > 0 {
+		logrus.WithField("flushed", moved).Info("In-memory dead letters flushed to Redis")
+	}
+
+	// Breaker state is in-process only and is not carried over; the log line is just for operators
+	logrus.WithFields(logrus.Fields{
+		"database": app.dbCircuit.GetState(),
+		"redis":    app.redisCircuit.GetState(),
+	}).Info("Final circuit breaker state")
+
+	if app.db != nil {
+		app.db.Close()
+	}
+	if app.redis != nil {
+		app.redis.Close()
+	}
+
+	logrus.Info("Server exited")
 }
 
 func setupLogging() {
@@ -72,45 +221,256 @@ func setupLogging() {
 
 	logrus.SetFormatter(&logrus.JSONFormatter{})
 	logrus.SetLevel(logrus.InfoLevel)
+	logrus.AddHook(tracing.LogHook{})
 }
 
 func (app *App) setupRoutes() *mux.Router {
 	router := mux.NewRouter()
 
 	// Apply middleware chain
-	router.Use(middleware.PanicRecovery(app.sendErrorResponse))
-	router.Use(middleware.RequestID(&app.requestCounter, &app.counterMutex))
+	router.Use(middleware.RequestID())
+	router.Use(middleware.PanicRecovery(app.reporter, app.sendErrorResponse))
 	router.Use(middleware.Logging())
-	router.Use(middleware.RateLimit())
+	router.Use(middleware.Deadline(map[string]time.Duration{
+		"/users":             5 * time.Second,
+		"/users/{id:[0-9]+}": 3 * time.Second,
+		"/orders":            5 * time.Second,
+		"/simulate/hedged":   2 * time.Second,
+	}, getEnvDuration("ROUTE_TIMEOUT_DEFAULT", 10*time.Second)))
+	router.Use(middleware.Degradation(app.degradation, map[string][]string{
+		"/users":             {"database"},
+		"/users/{id:[0-9]+}": {"database"},
+		"/dlq/{id}/replay":   {"database"},
+		"/outbox/status":     {"database"},
+	}, app.sendErrorResponse))
+	router.Use(middleware.Chaos(app.chaos, app.sendErrorResponse))
+	router.Use(middleware.RateLimit(
+		ratelimit.NewFallbackLimiter(ratelimit.NewRedisLimiter(app.redis), ratelimit.NewMemoryLimiter()),
+		app.rateLimit,
+		app.sendErrorResponse,
+	))
+	router.Use(middleware.Idempotency(
+		idempotency.NewRedisStore(app.redis, 24*time.Hour, 30*time.Second),
+		app.sendErrorResponse,
+	))
+
+	// Operator controls change how the service treats every client, so they
+	// sit behind admin auth; reading their state stays public, except for the
+	// dead letter queue
+	admin := router.NewRoute().Subrouter()
+	admin.Use(middleware.AdminAuth(app.adminToken, app.sendErrorResponse))
+
+	userRepo := repository.NewUserRepository(app.db)
+
+	// Stale local entries are refreshed from MySQL in the background
+	app.userCache.SetRevalidator(func(id int) (models.User, error) {
+		var user *models.User
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		err := app.dbCall(ctx, func() error {
+			var err error
+			user, err = userRepo.GetByID(ctx, id)
+			return err
+		})
+		if err != nil {
+			return models.User{}, err
+		}
+		return *user, nil
+	})
+
+	app.deadLetters = dlq.NewFallbackStore(dlq.NewRedisStore(app.redis), dlq.NewMemoryStore())
+	userCacheRepo := repository.NewUserCache(app.redis, 5*time.Minute)
 
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(
+		userRepo,
+		userCacheRepo,
+		app.deadLetters,
+		app.retryBudget,
+		app.dbCall,
+		app.redisCall,
+		app.userCache,
 		app.sendJSONResponse,
 		app.sendErrorResponse,
 		app.sendErrorResponseWithFallback,
 	)
 
+	dlqHandler := handlers.NewDLQHandler(
+		app.deadLetters,
+		map[string]handlers.ReplayFunc{
+			"create_user": func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+				var user models.User
+				if err := json.Unmarshal(payload, &user); err != nil {
+					return nil, apperrors.Validation("DLQ_INVALID_PAYLOAD", "Stored payload is not a user", nil)
+				}
+				if err := app.dbCall(ctx, func() error { return userRepo.Create(ctx, &user) }); err != nil {
+					return nil, err
+				}
+				app.userCache.Set(user.ID, user)
+				return user, nil
+			},
+		},
+		app.sendJSONResponse,
+		app.sendErrorResponse,
+	)
+
 	// API routes
 	router.HandleFunc("/", app.homeHandler).Methods("GET")
 	router.HandleFunc("/health", app.healthHandler).Methods("GET")
+	router.HandleFunc("/ready", app.readyHandler).Methods("GET")
+	router.HandleFunc("/errors/catalog", app.errorCatalogHandler).Methods("GET")
 
 	// User routes with dependency injection
-	router.HandleFunc("/users", userHandler.GetUsers(app.dbCircuit.Call, app.userCache)).Methods("GET")
-	router.HandleFunc("/users", userHandler.CreateUser(app.dbCircuit.Call, app.userCache)).Methods("POST")
-	router.HandleFunc("/users/{id:[0-9]+}", userHandler.GetUser(app.dbCircuit.Call, app.userCache)).Methods("GET")
+	router.HandleFunc("/users", userHandler.GetUsers).Methods("GET")
+	router.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
+	router.HandleFunc("/users/{id:[0-9]+}", userHandler.GetUser).Methods("GET")
+	router.HandleFunc("/fallback/stats", userHandler.FallbackStats).Methods("GET")
+
+	// Dead letter queue for writes that failed after retries. Entries hold user
+	// payloads and replaying or discarding them is an operator action, so even
+	// reads need admin auth.
+	admin.HandleFunc("/dlq", dlqHandler.List).Methods("GET")
+	admin.HandleFunc("/dlq/{id}", dlqHandler.Get).Methods("GET")
+	admin.HandleFunc("/dlq/{id}", dlqHandler.Delete).Methods("DELETE")
+	admin.HandleFunc("/dlq/{id}/replay", dlqHandler.Replay).Methods("POST")
+
+	// Saga demo: reserve inventory -> charge payment -> notify, with compensation
+	sagaStore := saga.NewFallbackStore(saga.NewRedisStore(app.redis), saga.NewMemoryStore())
+	inventory := orders.NewInventory(map[string]int{"widget": 100, "gadget": 25, "gizmo": 5})
+	payments := orders.NewPayments()
+	orderHandler := handlers.NewOrderHandler(
+		orders.NewCoordinator(sagaStore, inventory, payments),
+		sagaStore,
+		inventory,
+		payments,
+		app.sendJSONResponse,
+		app.sendErrorResponse,
+	)
+	router.HandleFunc("/orders", orderHandler.ListOrders).Methods("GET")
+	router.HandleFunc("/orders", orderHandler.CreateOrder).Methods("POST")
+	router.HandleFunc("/orders/{id}", orderHandler.GetOrder).Methods("GET")
+
+	// Transactional outbox
+	router.HandleFunc("/outbox/status", app.outboxStatusHandler).Methods("GET")
+
+	// Chaos injection management
+	chaosHandler := handlers.NewChaosHandler(app.chaos, app.sendJSONResponse, app.sendErrorResponse)
+	router.HandleFunc("/chaos", chaosHandler.Get).Methods("GET")
+	admin.HandleFunc("/chaos", chaosHandler.Update).Methods("PUT")
+	admin.HandleFunc("/chaos", chaosHandler.Disable).Methods("DELETE")
+
+	// Operator-forced degradation modes
+	degradationHandler := handlers.NewDegradationHandler(app.degradation, app.sendJSONResponse, app.sendErrorResponse)
+	router.HandleFunc("/degradation", degradationHandler.Get).Methods("GET")
+	admin.HandleFunc("/degradation", degradationHandler.Update).Methods("PUT")
+	admin.HandleFunc("/degradation", degradationHandler.Reset).Methods("DELETE")
 
 	// Error simulation routes
 	router.HandleFunc("/simulate/panic", app.simulatePanicHandler).Methods("GET")
 	router.HandleFunc("/simulate/db-error", app.simulateDBErrorHandler).Methods("GET")
 	router.HandleFunc("/simulate/validation-error", app.simulateValidationErrorHandler).Methods("POST")
+	router.HandleFunc("/simulate/hedged", app.simulateHedgedHandler).Methods("GET")
 
 	// Circuit breaker management
 	router.HandleFunc("/circuit-breaker/status", app.circuitBreakerStatusHandler).Methods("GET")
-	router.HandleFunc("/circuit-breaker/reset", app.resetCircuitBreakersHandler).Methods("POST")
+	admin.HandleFunc("/circuit-breaker/reset", app.resetCircuitBreakersHandler).Methods("POST")
+	router.HandleFunc("/retry-budget/status", app.retryBudgetStatusHandler).Methods("GET")
 
 	return router
 }
 
+// minDependencyBudget is the least time worth starting a dependency call with;
+// below it the call would almost certainly be cut off by the caller's deadline
+const minDependencyBudget = 10 * time.Millisecond
+
+// dbCall isolates database work in its bulkhead before it reaches the circuit breaker,
+// so a slow database can never hold more than its share of goroutines.
+// Queueing and the call itself are bounded by ctx's deadline.
+func (app *App) dbCall(ctx context.Context, fn func() error) error {
+	return app.dependencyCall(ctx, "database", app.dbBulkhead, app.dbCircuit, fn)
+}
+
+// redisCall does the same for Redis with its own, separate bulkhead
+func (app *App) redisCall(ctx context.Context, fn func() error) error {
+	return app.dependencyCall(ctx, "redis", app.redisBulkhead, app.redisCircuit, fn)
+}
+
+func (app *App) dependencyCall(ctx context.Context, name string, bh *bulkhead.Bulkhead, cb *circuit.Breaker, fn func() error) error {
+	// Operator-forced modes short-circuit before touching bulkheads or breakers
+	if err := app.degradation.Allow(name, degrade.OpCall); err != nil {
+		return err
+	}
+	if err := deadline.Check(ctx, name+" call", minDependencyBudget); err != nil {
+		return err
+	}
+
+	return bh.CallContext(ctx, func() error {
+		return cb.CallContext(ctx, func() error {
+			if err := app.chaos.DependencyFault(name); err != nil {
+				return err
+			}
+			return fn()
+		})
+	})
+}
+
+// loadChaosConfig reads CHAOS_CONFIG (JSON) and CHAOS_ENABLED; chaos is off by default
+func loadChaosConfig() (*chaos.Injector, error) {
+	var config chaos.Config
+	if raw := os.Getenv("CHAOS_CONFIG"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &config); err != nil {
+			return nil, err
+		}
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv("CHAOS_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	return chaos.NewInjector(config)
+}
+
+// loadDegradationModes reads DEGRADATION_MODES, e.g. "database=read_only,redis=cache_only"
+func loadDegradationModes() (*degrade.Controller, error) {
+	controller := degrade.NewController("database", "redis")
+	modes, err := degrade.ParseModes(os.Getenv("DEGRADATION_MODES"))
+	if err != nil {
+		return nil, err
+	}
+	return controller, controller.SetAll(modes)
+}
+
+// loadRateLimitConfig reads RATE_LIMIT_PER_IP, RATE_LIMIT_PER_USER and RATE_LIMIT_WINDOW.
+// A limit of zero would reject every request, so only positive values are accepted.
+func loadRateLimitConfig() (middleware.RateLimitConfig, error) {
+	config := middleware.RateLimitConfig{
+		IPLimit:   getEnvInt("RATE_LIMIT_PER_IP", 100),
+		UserLimit: getEnvInt("RATE_LIMIT_PER_USER", 60),
+		Window:    getEnvDuration("RATE_LIMIT_WINDOW", time.Minute),
+	}
+	if config.IPLimit <= 0 || config.UserLimit <= 0 {
+		return config, fmt.Errorf("limits must be positive, got %d per IP and %d per user", config.IPLimit, config.UserLimit)
+	}
+	if config.Window <= 0 {
+		return config, fmt.Errorf("window must be positive, got %s", config.Window)
+	}
+	return config, nil
+}
+
+// loadErrorReporter forwards panics to a Sentry-compatible service when
+// ERROR_REPORTER_DSN is set; otherwise they are only logged
+func loadErrorReporter() (reporting.Reporter, error) {
+	dsn := os.Getenv("ERROR_REPORTER_DSN")
+	if dsn == "" {
+		return nil, nil
+	}
+
+	reporter, err := reporting.NewSentryReporter(dsn, env.Get("APP_ENV", "development"))
+	if err != nil {
+		return nil, err
+	}
+	return reporting.Sampled(reporter, getEnvFloat("ERROR_REPORTER_SAMPLE_RATE", 1.0)), nil
+}
+
 func (app *App) initializeDependencies() error {
 	var errors []error
 
@@ -132,21 +492,22 @@ func (app *App) initializeDependencies() error {
 
 func (app *App) initializeMySQL() error {
 	config := models.RetryConfig{
-		MaxAttempts:   5,
-		BaseDelay:     1 * time.Second,
-		MaxDelay:      30 * time.Second,
-		BackoffFactor: 2.0,
-		Jitter:        true,
+		MaxAttempts:    5,
+		BaseDelay:      1 * time.Second,
+		MaxDelay:       30 * time.Second,
+		BackoffFactor:  2.0,
+		Jitter:         true,
+		AttemptTimeout: 5 * time.Second,
 	}
 
-	return retry.WithRetry("mysql-connection", config, func() error {
-		dsn := getEnv("DB_DSN", "user:password@tcp(localhost:3306)/testdb")
+	return retry.WithRetryContext(context.Background(), "mysql-connection", config, func(ctx context.Context) error {
+		dsn := env.Get("DB_DSN", "user:password@tcp(localhost:3306)/testdb?parseTime=true")
 		db, err := sql.Open("mysql", dsn)
 		if err != nil {
-			return err
+			return retry.Permanent(err)
 		}
 
-		if err := db.Ping(); err != nil {
+		if err := db.PingContext(ctx); err != nil {
 			db.Close()
 			return err
 		}
@@ -159,19 +520,18 @@ func (app *App) initializeMySQL() error {
 
 func (app *App) initializeRedis() error {
 	config := models.RetryConfig{
-		MaxAttempts:   3,
-		BaseDelay:     500 * time.Millisecond,
-		MaxDelay:      10 * time.Second,
-		BackoffFactor: 2.0,
-		Jitter:        true,
+		MaxAttempts:    3,
+		BaseDelay:      500 * time.Millisecond,
+		MaxDelay:       10 * time.Second,
+		BackoffFactor:  2.0,
+		Jitter:         true,
+		AttemptTimeout: 5 * time.Second,
 	}
 
-	return retry.WithRetry("redis-connection", config, func() error {
-		addr := getEnv("REDIS_ADDR", "localhost:6379")
+	return retry.WithRetryContext(context.Background(), "redis-connection", config, func(ctx context.Context) error {
+		addr := env.Get("REDIS_ADDR", "localhost:6379")
 		client := redis.NewClient(&redis.Options{Addr: addr})
-
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+		client.AddHook(tracing.RedisHook{})
 
 		if err := client.Ping(ctx).Err(); err != nil {
 			client.Close()
@@ -192,9 +552,13 @@ func (app *App) homeHandler(w http.ResponseWriter, r *http.Request) {
 			"message":     "Welcome to Error Handling Learning Lab!",
 			"server_time": time.Now(),
 			"endpoints": []string{
-				"GET /", "GET /health", "GET /users", "POST /users", "GET /users/{id}",
+				"GET /", "GET /health", "GET /ready", "GET /errors/catalog", "GET /users", "POST /users", "GET /users/{id}",
 				"GET /simulate/panic", "GET /simulate/db-error", "POST /simulate/validation-error",
-				"GET /circuit-breaker/status", "POST /circuit-breaker/reset",
+				"GET /simulate/hedged", "GET /circuit-breaker/status", "POST /circuit-breaker/reset",
+				"GET /retry-budget/status", "GET /dlq", "GET /dlq/{id}", "DELETE /dlq/{id}", "POST /dlq/{id}/replay",
+				"GET /chaos", "PUT /chaos", "DELETE /chaos",
+				"GET /degradation", "PUT /degradation", "DELETE /degradation", "GET /orders", "POST /orders", "GET /orders/{id}",
+				"GET /outbox/status", "GET /fallback/stats",
 			},
 		},
 	}
@@ -207,6 +571,29 @@ func (app *App) healthHandler(w http.ResponseWriter, r *http.Request) {
 	app.sendJSONResponse(w, http.StatusOK, response)
 }
 
+// readyHandler reports whether this instance should receive traffic; it turns
+// 503 as soon as shutdown starts, while /health keeps describing dependencies
+func (app *App) readyHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.ready.Load() {
+		app.sendError(w, r, apperrors.Unavailable("SHUTTING_DOWN", "Server is shutting down", nil))
+		return
+	}
+	app.sendJSONResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: map[string]interface{}{"status": "ready"}})
+}
+
+// errorCatalogHandler lists every error code clients can receive
+func (app *App) errorCatalogHandler(w http.ResponseWriter, r *http.Request) {
+	catalog := apperrors.Catalog()
+	response := models.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"errors": catalog,
+			"count":  len(catalog),
+		},
+	}
+	app.sendJSONResponse(w, http.StatusOK, response)
+}
+
 func (app *App) buildHealthResponse() map[string]interface{} {
 	health := map[string]interface{}{
 		"status":    "healthy",
@@ -244,12 +631,20 @@ func (app *App) buildHealthResponse() map[string]interface{} {
 		},
 	}
 
+	health["degradation"] = app.degradation.Modes()
+	health["local_cache"] = app.userCache.Stats()
+
+	health["bulkheads"] = map[string]interface{}{
+		"database": app.dbBulkhead.Stats(),
+		"redis":    app.redisBulkhead.Stats(),
+	}
+
 	return health
 }
 
 // Error simulation handlers - focused on single responsibility
 func (app *App) simulatePanicHandler(w http.ResponseWriter, r *http.Request) {
-	logrus.WithField("request_id", r.Header.Get("X-Request-ID")).Info("Simulating panic")
+	logrus.WithContext(r.Context()).Info("Simulating panic")
 	panic("This is a simulated panic for testing recovery")
 }
 
@@ -270,15 +665,52 @@ func (app *App) simulateDBErrorHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *App) simulateValidationErrorHandler(w http.ResponseWriter, r *http.Request) {
-	app.sendErrorResponse(w, models.APIError{
-		Type:      models.ValidationError,
-		Code:      "SIMULATED_VALIDATION_ERROR",
-		Message:   "This is a simulated validation error",
-		Details:   map[string]interface{}{"field": "test_field", "value": "invalid_value"},
-		RequestID: r.Header.Get("X-Request-ID"),
-		Timestamp: time.Now(),
-		Retryable: false,
-	}, http.StatusBadRequest)
+	app.sendError(w, r, apperrors.Validation(
+		"SIMULATED_VALIDATION_ERROR",
+		"This is a simulated validation error",
+		map[string]interface{}{"field": "test_field", "value": "invalid_value"},
+	))
+}
+
+func (app *App) simulateHedgedHandler(w http.ResponseWriter, r *http.Request) {
+	threshold := 50 * time.Millisecond
+	if ms, err := strconv.Atoi(r.URL.Query().Get("threshold_ms")); err == nil && ms > 0 {
+		threshold = time.Duration(ms) * time.Millisecond
+	}
+
+	// Simulated backend: usually fast, occasionally stuck in the long tail
+	slowBackend := func(ctx context.Context) (time.Duration, error) {
+		latency := time.Duration(10+rand.Intn(30)) * time.Millisecond
+		if rand.Float64() < 0.3 {
+			latency = time.Duration(200+rand.Intn(300)) * time.Millisecond
+		}
+
+		select {
+		case <-time.After(latency):
+			return latency, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	result, err := retry.Hedge(r.Context(), "simulated-backend", threshold, app.retryBudget, slowBackend)
+	if err != nil {
+		app.sendError(w, r, apperrors.Unavailable("HEDGED_REQUEST_FAILED", "Both primary and hedged attempts failed", err))
+		return
+	}
+
+	response := models.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"winning_attempt":  result.Attempt,
+			"hedged":           result.Hedged,
+			"backend_latency":  result.Value.String(),
+			"observed_latency": result.Elapsed.String(),
+			"hedge_threshold":  threshold.String(),
+			"retry_budget":     app.retryBudget.Stats(),
+		},
+	}
+	app.sendJSONResponse(w, http.StatusOK, response)
 }
 
 func (app *App) circuitBreakerStatusHandler(w http.ResponseWriter, r *http.Request) {
@@ -301,6 +733,30 @@ func (app *App) circuitBreakerStatusHandler(w http.ResponseWriter, r *http.Reque
 	app.sendJSONResponse(w, http.StatusOK, response)
 }
 
+func (app *App) retryBudgetStatusHandler(w http.ResponseWriter, r *http.Request) {
+	response := models.APIResponse{Success: true, Data: app.retryBudget.Stats()}
+	app.sendJSONResponse(w, http.StatusOK, response)
+}
+
+func (app *App) outboxStatusHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := app.outboxRelay.Stats(r.Context())
+	if err != nil {
+		apiError, _ := apperrors.ToAPIError(
+			apperrors.Unavailable("OUTBOX_UNAVAILABLE", "Unable to read outbox counts, showing relay progress only", err),
+			r.Header.Get("X-Request-ID"),
+		)
+		app.sendErrorResponseWithFallback(w, apiError, stats, http.StatusPartialContent)
+		return
+	}
+
+	response := models.APIResponse{
+		Success:  true,
+		Data:     stats,
+		Metadata: map[string]interface{}{"channel": "events.users", "delivery": "at-least-once"},
+	}
+	app.sendJSONResponse(w, http.StatusOK, response)
+}
+
 func (app *App) resetCircuitBreakersHandler(w http.ResponseWriter, r *http.Request) {
 	app.dbCircuit.Reset()
 	app.redisCircuit.Reset()
@@ -314,27 +770,55 @@ func (app *App) resetCircuitBreakersHandler(w http.ResponseWriter, r *http.Reque
 }
 
 // HTTP utility functions - focused on HTTP concerns
+// sendJSONResponse writes this lab's own envelope: its errors are structured
+// objects with codes and retry hints, where pkg/response's are plain strings
 func (app *App) sendJSONResponse(w http.ResponseWriter, statusCode int, data models.APIResponse) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		logrus.WithError(err).Error("Failed to encode JSON response")
-	}
+	response.JSON(w, statusCode, data)
 }
 
 func (app *App) sendErrorResponse(w http.ResponseWriter, apiError models.APIError, statusCode int) {
+	setRetryAfterHeader(w, apiError)
 	response := models.APIResponse{Success: false, Error: &apiError}
 	app.sendJSONResponse(w, statusCode, response)
 }
 
+// sendError maps any error to its APIError and status code
+func (app *App) sendError(w http.ResponseWriter, r *http.Request, err error) {
+	apiError, status := apperrors.ToAPIError(err, r.Header.Get("X-Request-ID"))
+	app.sendErrorResponse(w, apiError, status)
+}
+
 func (app *App) sendErrorResponseWithFallback(w http.ResponseWriter, apiError models.APIError, fallbackData interface{}, statusCode int) {
+	setRetryAfterHeader(w, apiError)
 	response := models.APIResponse{Success: false, Error: &apiError, FallbackData: fallbackData}
 	app.sendJSONResponse(w, statusCode, response)
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+// setRetryAfterHeader mirrors retry_after_ms in the standard header, rounded up to whole seconds
+func setRetryAfterHeader(w http.ResponseWriter, apiError models.APIError) {
+	if !apiError.Retryable || apiError.RetryAfterMs <= 0 {
+		return
+	}
+	seconds := (apiError.RetryAfterMs + 999) / 1000
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
 		return value
 	}
 	return defaultValue
@@ -38,6 +38,22 @@ CREATE TABLE IF NOT EXISTS circuit_breaker_events (
     INDEX idx_created_at (created_at)
 );
 
+-- Transactional outbox: events are written in the same transaction as the
+-- change they describe, then relayed to Redis Pub/Sub by a background worker
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id VARCHAR(100) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSON NOT NULL,
+    status ENUM('pending', 'published', 'failed') NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP NULL,
+    INDEX idx_status_id (status, id)
+);
+
 -- Insert sample data for testing
 INSERT INTO users (name, email) VALUES 
     ('Alice Johnson', 'alice@example.com'),
@@ -0,0 +1,297 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/repository"
+)
+
+// ErrRuleNotFound is returned for operations on an unknown rule ID
+var ErrRuleNotFound = errors.New("alert rule not found")
+
+// Engine evaluates alert rules against the repository's metrics.
+// Request-based metrics (error rate, request rate, latency) are computed
+// from the traffic between two evaluations, so a rule like "error rate > 5%
+// for 5m" needs the interval-by-interval rate to stay above 5% for 5 minutes.
+type Engine struct {
+	repo      *repository.MetricsRepository
+	notifiers []Notifier
+
+	mu     sync.Mutex
+	rules  map[string]models.AlertRule
+	alerts map[string]*models.Alert
+	nextID int
+	prev   *repository.TrafficSnapshot
+}
+
+// NewEngine creates an engine with no rules
+func NewEngine(repo *repository.MetricsRepository, notifiers ...Notifier) *Engine {
+	return &Engine{
+		repo:      repo,
+		notifiers: notifiers,
+		rules:     make(map[string]models.AlertRule),
+		alerts:    make(map[string]*models.Alert),
+	}
+}
+
+// AddRule validates the rule, assigns it an ID and stores it
+func (e *Engine) AddRule(rule models.AlertRule) (models.AlertRule, error) {
+	if err := rule.Validate(); err != nil {
+		return models.AlertRule{}, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.nextID++
+	rule.ID = strconv.Itoa(e.nextID)
+	e.rules[rule.ID] = rule
+	e.alerts[rule.ID] = newAlert(rule)
+	return rule, nil
+}
+
+// UpdateRule replaces a rule and resets its alert state
+func (e *Engine) UpdateRule(id string, rule models.AlertRule) (models.AlertRule, error) {
+	if err := rule.Validate(); err != nil {
+		return models.AlertRule{}, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.rules[id]; !ok {
+		return models.AlertRule{}, ErrRuleNotFound
+	}
+	rule.ID = id
+	e.rules[id] = rule
+	e.alerts[id] = newAlert(rule)
+	return rule, nil
+}
+
+// DeleteRule removes a rule and its alert state
+func (e *Engine) DeleteRule(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.rules[id]; !ok {
+		return ErrRuleNotFound
+	}
+	delete(e.rules, id)
+	delete(e.alerts, id)
+	return nil
+}
+
+// Rule returns one rule by ID
+func (e *Engine) Rule(id string) (models.AlertRule, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rule, ok := e.rules[id]
+	if !ok {
+		return models.AlertRule{}, ErrRuleNotFound
+	}
+	return rule, nil
+}
+
+// Rules returns every rule ordered by ID
+func (e *Engine) Rules() []models.AlertRule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rules := make([]models.AlertRule, 0, len(e.rules))
+	for _, rule := range e.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return idLess(rules[i].ID, rules[j].ID) })
+	return rules
+}
+
+// Alerts returns the current state of every rule ordered by rule ID
+func (e *Engine) Alerts() []models.Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := make([]models.Alert, 0, len(e.alerts))
+	for _, alert := range e.alerts {
+		alerts = append(alerts, *alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return idLess(alerts[i].RuleID, alerts[j].RuleID) })
+	return alerts
+}
+
+// Run evaluates the rules every interval until ctx is done
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.Evaluate(ctx)
+		}
+	}
+}
+
+type notification struct {
+	rule  models.AlertRule
+	alert models.Alert
+}
+
+// Evaluate checks every enabled rule once and sends the resulting notifications
+func (e *Engine) Evaluate(ctx context.Context) {
+	snapshot := e.repo.GetTrafficSnapshot()
+	system := e.repo.GetSystemMetrics()
+	now := snapshot.Taken
+
+	e.mu.Lock()
+	var pending []notification
+	for id, rule := range e.rules {
+		if !rule.Enabled {
+			continue
+		}
+
+		alert := e.alerts[id]
+		value, ok := e.value(rule, snapshot, system)
+		if !ok {
+			// No data is no evidence of recovery, and traffic stopping may be
+			// part of the outage, so the alert stays as it is until data returns
+			continue
+		}
+		if e.transition(rule, alert, rule.Breached(value), value, now) {
+			alert.NotifiedAt = now
+			pending = append(pending, notification{rule: rule, alert: *alert})
+		}
+	}
+	e.prev = &snapshot
+	e.mu.Unlock()
+
+	for _, n := range pending {
+		for _, notifier := range e.notifiers {
+			if err := notifier.Notify(ctx, n.rule, n.alert); err != nil {
+				logrus.WithError(err).WithField("rule_id", n.rule.ID).Error("Alert notification failed")
+			}
+		}
+	}
+}
+
+// transition moves the alert through inactive → pending → firing and back,
+// reporting whether a notification is due
+func (e *Engine) transition(rule models.AlertRule, alert *models.Alert, breached bool, value float64, now time.Time) bool {
+	alert.Value = value
+
+	if !breached {
+		wasFiring := alert.State == models.AlertStateFiring
+		alert.State = models.AlertStateInactive
+		alert.Since = time.Time{}
+		if wasFiring {
+			alert.ResolvedAt = now
+			// Only announce the resolution if the firing was announced
+			return !alert.NotifiedAt.Before(alert.FiredAt)
+		}
+		return false
+	}
+
+	switch alert.State {
+	case models.AlertStateInactive:
+		alert.State = models.AlertStatePending
+		alert.Since = now
+		fallthrough
+	case models.AlertStatePending:
+		if now.Sub(alert.Since) < rule.For() {
+			return false
+		}
+		alert.State = models.AlertStateFiring
+		alert.FiredAt = now
+		alert.ResolvedAt = time.Time{}
+		// A rule flapping faster than its cooldown only notifies once
+		return alert.NotifiedAt.IsZero() || now.Sub(alert.NotifiedAt) >= rule.Cooldown()
+	case models.AlertStateFiring:
+		// Remind every cooldown period while the alert keeps firing
+		return rule.Cooldown() > 0 && now.Sub(alert.NotifiedAt) >= rule.Cooldown()
+	}
+	return false
+}
+
+// value computes the rule's metric; ok is false when there is no data,
+// e.g. an error rate over an interval without requests
+func (e *Engine) value(rule models.AlertRule, snapshot repository.TrafficSnapshot, system models.SystemMetrics) (float64, bool) {
+	switch rule.Metric {
+	case models.AlertMetricCPU:
+		return system.CPUUsage, true
+	case models.AlertMetricMemory:
+		return float64(system.MemoryUsage), true
+	case models.AlertMetricGoroutines:
+		return float64(system.GoroutineCount), true
+	}
+
+	// Request metrics need the previous snapshot to compute a window
+	if e.prev == nil {
+		return 0, false
+	}
+	elapsed := snapshot.Taken.Sub(e.prev.Taken).Seconds()
+
+	var requests, serverErrors int64
+	latency := models.NewLatencyHistogram()
+	for key, count := range snapshot.Requests {
+		if rule.Route != "" && key != rule.Route {
+			continue
+		}
+		requests += count - e.prev.Requests[key]
+		serverErrors += snapshot.ServerErrors[key] - e.prev.ServerErrors[key]
+		if h, ok := snapshot.Latency[key]; ok {
+			latency.Merge(h.Since(e.prev.Latency[key]))
+		}
+	}
+
+	switch rule.Metric {
+	case models.AlertMetricRequestRate:
+		if elapsed <= 0 {
+			return 0, false
+		}
+		return float64(requests) / elapsed, true
+	case models.AlertMetricErrorRate:
+		if requests == 0 {
+			return 0, false
+		}
+		return 100 * float64(serverErrors) / float64(requests), true
+	case models.AlertMetricLatencyP50, models.AlertMetricLatencyP90, models.AlertMetricLatencyP99:
+		if latency.Stats().Count == 0 {
+			return 0, false
+		}
+		return latency.Quantile(quantiles[rule.Metric]), true
+	}
+	return 0, false
+}
+
+var quantiles = map[models.AlertMetric]float64{
+	models.AlertMetricLatencyP50: 0.50,
+	models.AlertMetricLatencyP90: 0.90,
+	models.AlertMetricLatencyP99: 0.99,
+}
+
+func newAlert(rule models.AlertRule) *models.Alert {
+	return &models.Alert{
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		Metric:    rule.Metric,
+		State:     models.AlertStateInactive,
+		Threshold: rule.Threshold,
+	}
+}
+
+// idLess orders numeric IDs numerically
+func idLess(a, b string) bool {
+	x, _ := strconv.Atoi(a)
+	y, _ := strconv.Atoi(b)
+	return x < y
+}
@@ -0,0 +1,69 @@
+package alerting
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/repository"
+)
+
+// recordingNotifier keeps every alert it is sent
+type recordingNotifier struct {
+	alerts []models.Alert
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, _ models.AlertRule, alert models.Alert) error {
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func record(t *testing.T, repo *repository.MetricsRepository, status, count int) {
+	for i := 0; i < count; i++ {
+		require.NoError(t, repo.RecordRequest(models.RequestMetrics{
+			Method: http.MethodGet, Path: "/api/demo", StatusCode: status, Duration: time.Millisecond,
+		}))
+	}
+}
+
+func TestEngine_ErrorRateWithoutTraffic(t *testing.T) {
+	repo := repository.NewMetricsRepository("test", "test")
+	notifier := &recordingNotifier{}
+	engine := NewEngine(repo, notifier)
+	rule, err := engine.AddRule(models.AlertRule{
+		Name: "Errors", Metric: models.AlertMetricErrorRate, Operator: ">", Threshold: 50, Enabled: true,
+	})
+	require.NoError(t, err)
+	state := func() models.AlertState {
+		for _, a := range engine.Alerts() {
+			if a.RuleID == rule.ID {
+				return a.State
+			}
+		}
+		return ""
+	}
+
+	engine.Evaluate(context.Background()) // First snapshot, no window yet
+	assert.Equal(t, models.AlertStateInactive, state())
+
+	record(t, repo, http.StatusInternalServerError, 10)
+	engine.Evaluate(context.Background())
+	assert.Equal(t, models.AlertStateFiring, state())
+
+	// Traffic stopping is not a recovery
+	engine.Evaluate(context.Background())
+	assert.Equal(t, models.AlertStateFiring, state())
+	require.Len(t, notifier.alerts, 1, "only the firing was announced")
+
+	record(t, repo, http.StatusOK, 10)
+	engine.Evaluate(context.Background())
+	assert.Equal(t, models.AlertStateInactive, state())
+	require.Len(t, notifier.alerts, 2)
+	assert.Equal(t, models.AlertStateInactive, notifier.alerts[1].State)
+	assert.False(t, notifier.alerts[1].ResolvedAt.IsZero())
+}
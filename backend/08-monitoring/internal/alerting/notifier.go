@@ -0,0 +1,156 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/tracing"
+)
+
+// Notifier delivers alert state changes
+type Notifier interface {
+	Notify(ctx context.Context, rule models.AlertRule, alert models.Alert) error
+}
+
+// LogNotifier writes alerts to the service log
+type LogNotifier struct{}
+
+// Notify logs the alert
+func (LogNotifier) Notify(ctx context.Context, rule models.AlertRule, alert models.Alert) error {
+	status := alertStatus(alert)
+	entry := logrus.WithFields(logrus.Fields{
+		"alert_status": status,
+		"rule_id":      rule.ID,
+		"rule":         rule.Name,
+		"metric":       rule.Metric,
+		"value":        alert.Value,
+		"operator":     rule.Operator,
+		"threshold":    rule.Threshold,
+	})
+	if status == "resolved" {
+		entry.Info("Alert resolved")
+	} else {
+		entry.Warn("Alert firing")
+	}
+	return nil
+}
+
+// WebhookNotifier POSTs alerts as JSON to the rule's webhook URL, or to
+// DefaultURL when the rule has none
+type WebhookNotifier struct {
+	DefaultURL string
+	client     *http.Client
+}
+
+// NewWebhookNotifier creates a webhook notifier with a 5 second timeout
+func NewWebhookNotifier(defaultURL string) *WebhookNotifier {
+	return &WebhookNotifier{
+		DefaultURL: defaultURL,
+		client:     &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type webhookPayload struct {
+	Status string           `json:"status"` // firing or resolved
+	Rule   models.AlertRule `json:"rule"`
+	Alert  models.Alert     `json:"alert"`
+}
+
+// Notify sends the alert; rules without any webhook are skipped
+func (w *WebhookNotifier) Notify(ctx context.Context, rule models.AlertRule, alert models.Alert) error {
+	url := rule.WebhookURL
+	if url == "" {
+		url = w.DefaultURL
+	}
+	if url == "" {
+		return nil
+	}
+
+	return postJSON(ctx, w.client, url, webhookPayload{Status: alertStatus(alert), Rule: rule, Alert: alert})
+}
+
+// postJSON POSTs payload to url and fails on any non-2xx response
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func alertStatus(alert models.Alert) string {
+	if alert.State == models.AlertStateFiring {
+		return "firing"
+	}
+	return "resolved"
+}
+
+// HealthNotifier reports health check status changes to the log and, when
+// URL is set, POSTs them to a webhook
+type HealthNotifier struct {
+	URL    string
+	client *http.Client
+}
+
+// NewHealthNotifier creates a health notifier with a 5 second webhook timeout
+func NewHealthNotifier(url string) *HealthNotifier {
+	return &HealthNotifier{
+		URL:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type healthPayload struct {
+	Event      string                  `json:"event"` // health_changed or health_recovered
+	Transition models.HealthTransition `json:"transition"`
+}
+
+// Notify logs the transition and sends it to the webhook, if any. It has the
+// signature of repository.TransitionListener.
+func (n *HealthNotifier) Notify(ctx context.Context, transition models.HealthTransition) {
+	entry := logrus.WithFields(logrus.Fields{
+		"check":         transition.Check,
+		"from":          transition.From,
+		"to":            transition.To,
+		"informational": transition.Informational,
+		"check_message": transition.Message,
+	})
+	event := "health_changed"
+	if transition.Recovered() {
+		event = "health_recovered"
+		entry.Info("Health check recovered")
+	} else {
+		entry.Warn("Health check status changed")
+	}
+
+	if n.URL == "" {
+		return
+	}
+	if err := postJSON(ctx, n.client, n.URL, healthPayload{Event: event, Transition: transition}); err != nil {
+		logrus.WithError(err).WithField("check", transition.Check).Error("Health notification failed")
+	}
+}
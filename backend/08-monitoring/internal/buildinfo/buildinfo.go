@@ -0,0 +1,61 @@
+package buildinfo
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+)
+
+// Set at build time, e.g.
+//
+//	go build -ldflags "-X github.com/e6a5/learning/backend/08-monitoring/internal/buildinfo.Commit=$(git rev-parse HEAD)"
+//
+// Commit and BuildTime fall back to the VCS stamp Go embeds when building a
+// package (not a file list) inside a git checkout.
+var (
+	Version   = "1.0.0"
+	Commit    = ""
+	BuildTime = "" // RFC 3339
+)
+
+// Get describes the running binary and process. version is the effective
+// version, which the VERSION env var may override.
+func Get(version string) models.BuildInfo {
+	info := models.BuildInfo{
+		Version:   version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		PID:       os.Getpid(),
+		NumCPU:    runtime.NumCPU(),
+		MaxProcs:  runtime.GOMAXPROCS(0),
+	}
+	info.Hostname, _ = os.Hostname()
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		info.Module = build.Main.Path
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			case "vcs.modified":
+				info.Dirty = setting.Value == "true"
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	return info
+}
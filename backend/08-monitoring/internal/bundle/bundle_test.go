@@ -0,0 +1,73 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactEnvironment(t *testing.T) {
+	config := RedactEnvironment([]string{
+		"PORT=8080",
+		"DEBUG_TOKEN=hunter2",
+		"CONSUL_TOKEN=abc",
+		"AWS_SECRET_ACCESS_KEY=xyz",
+		"ALERT_WEBHOOK_URL=https://hooks.slack.com/services/T0/B0/secret",
+		"DATABASE_URL=mysql://app:pa55@db:3306/metrics",
+		"CONSUL_ADDR=http://consul:8500",
+		"EMPTY_PASSWORD=",
+		"OPTS=a=b",
+		"=C:=C:\\",
+	})
+
+	assert.Equal(t, "8080", config["PORT"])
+	assert.Equal(t, Redacted, config["DEBUG_TOKEN"])
+	assert.Equal(t, Redacted, config["CONSUL_TOKEN"])
+	assert.Equal(t, Redacted, config["AWS_SECRET_ACCESS_KEY"])
+	assert.Equal(t, Redacted, config["ALERT_WEBHOOK_URL"])
+	assert.Equal(t, "mysql://app:[REDACTED]@db:3306/metrics", config["DATABASE_URL"])
+	assert.Equal(t, "http://consul:8500", config["CONSUL_ADDR"])
+	assert.Equal(t, "", config["EMPTY_PASSWORD"], "an unset secret is worth seeing")
+	assert.Equal(t, "a=b", config["OPTS"])
+	assert.Len(t, config, 9)
+}
+
+func TestBuild(t *testing.T) {
+	data, err := Build(Contents{
+		Build:       models.BuildInfo{Version: "1.2.3"},
+		Metrics:     models.MetricsSnapshot{},
+		Health:      models.HealthResponse{Status: models.HealthStatusHealthy},
+		Environment: []string{"DEBUG_TOKEN=hunter2", "PORT=8080"},
+	})
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[f.Name] = content
+	}
+
+	for _, name := range []string{"manifest.json", "build.json", "metrics.json", "health.json", "config.json", "goroutines.txt", "heap.pprof"} {
+		assert.NotEmpty(t, files[name], name)
+	}
+	assert.Contains(t, string(files["goroutines.txt"]), "TestBuild")
+	assert.NotContains(t, string(files["config.json"]), "hunter2")
+
+	var m manifest
+	require.NoError(t, json.Unmarshal(files["manifest.json"], &m))
+	assert.Equal(t, "1.2.3", m.Version)
+	assert.Len(t, m.Files, 6)
+}
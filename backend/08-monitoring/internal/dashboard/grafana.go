@@ -0,0 +1,305 @@
+package dashboard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Grafana's dashboard model, trimmed to the fields the generator sets. The
+// JSON imports as-is through Dashboards > Import.
+type (
+	Dashboard struct {
+		UID           string     `json:"uid"`
+		Title         string     `json:"title"`
+		Tags          []string   `json:"tags"`
+		Timezone      string     `json:"timezone"`
+		SchemaVersion int        `json:"schemaVersion"`
+		Refresh       string     `json:"refresh"`
+		Time          TimeRange  `json:"time"`
+		Templating    Templating `json:"templating"`
+		Panels        []Panel    `json:"panels"`
+	}
+
+	TimeRange struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+
+	Templating struct {
+		List []Variable `json:"list"`
+	}
+
+	// Variable is a dashboard variable; the generator only uses the
+	// datasource picker so the dashboard works with any Prometheus
+	Variable struct {
+		Name  string `json:"name"`
+		Label string `json:"label"`
+		Type  string `json:"type"`
+		Query string `json:"query"`
+	}
+
+	Panel struct {
+		ID          int          `json:"id"`
+		Type        string       `json:"type"` // "row" or "timeseries"
+		Title       string       `json:"title"`
+		GridPos     GridPos      `json:"gridPos"`
+		Datasource  *Datasource  `json:"datasource,omitempty"`
+		Targets     []Target     `json:"targets,omitempty"`
+		FieldConfig *FieldConfig `json:"fieldConfig,omitempty"`
+		Panels      []Panel      `json:"panels"` // rows only; Grafana wants the key present
+	}
+
+	GridPos struct {
+		H int `json:"h"`
+		W int `json:"w"`
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+
+	Datasource struct {
+		Type string `json:"type"`
+		UID  string `json:"uid"`
+	}
+
+	Target struct {
+		RefID        string `json:"refId"`
+		Expr         string `json:"expr"`
+		LegendFormat string `json:"legendFormat"`
+		Exemplar     bool   `json:"exemplar,omitempty"` // show trace exemplars on the graph
+	}
+
+	FieldConfig struct {
+		Defaults FieldDefaults `json:"defaults"`
+	}
+
+	FieldDefaults struct {
+		Unit string `json:"unit"`
+	}
+)
+
+// panelSpec describes one graph; it is added when Requires is registered
+type panelSpec struct {
+	Title    string
+	Requires string
+	Unit     string
+	Targets  []Target
+}
+
+type rowSpec struct {
+	Title  string
+	Panels []panelSpec
+}
+
+// rateWindow adapts to the scrape interval and zoom level
+const rateWindow = "$__rate_interval"
+
+var builtinRows = []rowSpec{
+	{
+		Title: "Requests",
+		Panels: []panelSpec{
+			{Title: "Request rate by route", Requires: "http_requests_total", Unit: "reqps", Targets: []Target{
+				{Expr: `sum by (method, route) (rate(http_requests_total[` + rateWindow + `]))`, LegendFormat: "{{method}} {{route}}"},
+			}},
+			{Title: "Error ratio (5xx)", Requires: "http_requests_total", Unit: "percentunit", Targets: []Target{
+				{Expr: `sum by (route) (rate(http_requests_total{status=~"5.."}[` + rateWindow + `])) / sum by (route) (rate(http_requests_total[` + rateWindow + `]))`, LegendFormat: "{{route}}"},
+			}},
+			{Title: "Latency", Requires: "http_request_duration_seconds", Unit: "s", Targets: withExemplars(quantileTargets("http_request_duration_seconds", "route"))},
+			{Title: "In-flight requests", Requires: "http_requests_in_flight", Unit: "short", Targets: []Target{
+				{Expr: `sum(http_requests_in_flight)`, LegendFormat: "in flight"},
+			}},
+			{Title: "Request size p90", Requires: "http_request_size_bytes", Unit: "bytes", Targets: []Target{
+				{Expr: `histogram_quantile(0.9, sum by (le, route) (rate(http_request_size_bytes_bucket[` + rateWindow + `])))`, LegendFormat: "{{route}}"},
+			}},
+			{Title: "Response size p90", Requires: "http_response_size_bytes", Unit: "bytes", Targets: []Target{
+				{Expr: `histogram_quantile(0.9, sum by (le, route) (rate(http_response_size_bytes_bucket[` + rateWindow + `])))`, LegendFormat: "{{route}}"},
+			}},
+		},
+	},
+	{
+		Title: "System",
+		Panels: []panelSpec{
+			{Title: "CPU", Requires: "process_cpu_usage_percent", Unit: "percent", Targets: []Target{
+				{Expr: `process_cpu_usage_percent`, LegendFormat: "process"},
+				{Expr: `system_cpu_usage_percent`, LegendFormat: "host"},
+			}},
+			{Title: "Memory", Requires: "process_resident_memory_bytes", Unit: "bytes", Targets: []Target{
+				{Expr: `process_resident_memory_bytes`, LegendFormat: "RSS"},
+				{Expr: `go_memstats_heap_inuse_bytes`, LegendFormat: "heap in use"},
+			}},
+			{Title: "Goroutines", Requires: "go_goroutines", Unit: "short", Targets: []Target{
+				{Expr: `go_goroutines`, LegendFormat: "goroutines"},
+			}},
+			{Title: "GC pause", Requires: "go_gc_last_pause_seconds", Unit: "s", Targets: []Target{
+				{Expr: `go_gc_last_pause_seconds`, LegendFormat: "last pause"},
+			}},
+			{Title: "Open file descriptors", Requires: "process_open_fds", Unit: "short", Targets: []Target{
+				{Expr: `process_open_fds`, LegendFormat: "open"},
+				{Expr: `process_max_fds`, LegendFormat: "max"},
+			}},
+		},
+	},
+	{
+		Title: "Service",
+		Panels: []panelSpec{
+			{Title: "Running version", Requires: "build_info", Unit: "short", Targets: []Target{
+				{Expr: `build_info`, LegendFormat: "{{version}} {{commit}}"},
+			}},
+			{Title: "Suppressed log lines", Requires: "log_lines_suppressed_total", Unit: "short", Targets: []Target{
+				{Expr: `sum by (reason) (rate(log_lines_suppressed_total[` + rateWindow + `]))`, LegendFormat: "{{reason}}"},
+			}},
+		},
+	},
+	{
+		Title: "Synthetic checks",
+		Panels: []panelSpec{
+			{Title: "Checks up", Requires: "synthetic_check_up", Unit: "short", Targets: []Target{
+				{Expr: `synthetic_check_up`, LegendFormat: "{{check}}"},
+			}},
+			{Title: "Probe duration", Requires: "synthetic_check_duration_seconds", Unit: "s", Targets: []Target{
+				{Expr: `synthetic_check_duration_seconds`, LegendFormat: "{{check}}"},
+			}},
+		},
+	},
+}
+
+// Generate builds a dashboard for the metrics in registered, which maps
+// metric family names to their type ("counter", "gauge", "histogram",
+// "summary"). Built-in panels whose metric is missing are left out, and every
+// histogram or summary not covered by them gets a quantile panel.
+func Generate(title string, registered map[string]string) Dashboard {
+	d := Dashboard{
+		UID:           "monitoring-service",
+		Title:         title,
+		Tags:          []string{"generated", "monitoring"},
+		Timezone:      "browser",
+		SchemaVersion: 39,
+		Refresh:       "30s",
+		Time:          TimeRange{From: "now-1h", To: "now"},
+		Templating: Templating{List: []Variable{
+			{Name: "datasource", Label: "Data source", Type: "datasource", Query: "prometheus"},
+		}},
+	}
+
+	covered := make(map[string]bool)
+	rows := make([]rowSpec, 0, len(builtinRows)+1)
+	for _, row := range builtinRows {
+		kept := rowSpec{Title: row.Title}
+		for _, panel := range row.Panels {
+			if _, ok := registered[panel.Requires]; ok {
+				kept.Panels = append(kept.Panels, panel)
+			}
+			covered[panel.Requires] = true
+		}
+		rows = append(rows, kept)
+	}
+	rows = append(rows, customRow(registered, covered))
+
+	layout(&d, rows)
+	return d
+}
+
+// customRow adds a panel per histogram or summary the built-in rows skip,
+// which covers distributions registered through /api/metrics/definitions
+func customRow(registered map[string]string, covered map[string]bool) rowSpec {
+	names := make([]string, 0)
+	for name, kind := range registered {
+		// The Go and process collectors' own distributions are not ours to chart
+		runtime := strings.HasPrefix(name, "go_") || strings.HasPrefix(name, "process_")
+		if !covered[name] && !runtime && (kind == "histogram" || kind == "summary") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	row := rowSpec{Title: "Custom metrics"}
+	for _, name := range names {
+		panel := panelSpec{Title: name, Requires: name, Unit: unitFor(name)}
+		if registered[name] == "histogram" {
+			panel.Targets = quantileTargets(name, "")
+		} else {
+			panel.Targets = []Target{{Expr: name, LegendFormat: "p{{quantile}}"}}
+		}
+		row.Panels = append(row.Panels, panel)
+	}
+	return row
+}
+
+// quantileTargets computes p50/p90/p99 from a histogram's buckets, split by
+// the by label when given
+func quantileTargets(name, by string) []Target {
+	group, legend := "le", ""
+	if by != "" {
+		group, legend = "le, "+by, "{{"+by+"}} "
+	}
+
+	quantiles := []struct{ value, label string }{{"0.5", "p50"}, {"0.9", "p90"}, {"0.99", "p99"}}
+	targets := make([]Target, 0, len(quantiles))
+	for _, q := range quantiles {
+		targets = append(targets, Target{
+			Expr:         fmt.Sprintf("histogram_quantile(%s, sum by (%s) (rate(%s_bucket[%s])))", q.value, group, name, rateWindow),
+			LegendFormat: legend + q.label,
+		})
+	}
+	return targets
+}
+
+// withExemplars marks targets to show exemplars; the latency histogram
+// carries trace IDs when tracing is exported
+func withExemplars(targets []Target) []Target {
+	for i := range targets {
+		targets[i].Exemplar = true
+	}
+	return targets
+}
+
+// unitFor guesses a Grafana unit from Prometheus naming conventions
+func unitFor(name string) string {
+	switch {
+	case strings.HasSuffix(name, "_seconds"):
+		return "s"
+	case strings.HasSuffix(name, "_bytes"):
+		return "bytes"
+	case strings.HasSuffix(name, "_ratio"):
+		return "percentunit"
+	}
+	return "short"
+}
+
+// layout places rows full width and their panels two per line. Empty rows are
+// dropped.
+func layout(d *Dashboard, rows []rowSpec) {
+	const panelHeight, panelWidth = 8, 12
+
+	id, y := 1, 0
+	datasource := &Datasource{Type: "prometheus", UID: "${datasource}"}
+	for _, row := range rows {
+		if len(row.Panels) == 0 {
+			continue
+		}
+
+		d.Panels = append(d.Panels, Panel{ID: id, Type: "row", Title: row.Title, GridPos: GridPos{H: 1, W: 24, Y: y}, Panels: []Panel{}})
+		id++
+		y++
+
+		for i, spec := range row.Panels {
+			targets := make([]Target, len(spec.Targets))
+			for j, target := range spec.Targets {
+				target.RefID = string(rune('A' + j))
+				targets[j] = target
+			}
+
+			d.Panels = append(d.Panels, Panel{
+				ID:          id,
+				Type:        "timeseries",
+				Title:       spec.Title,
+				GridPos:     GridPos{H: panelHeight, W: panelWidth, X: (i % 2) * panelWidth, Y: y + (i/2)*panelHeight},
+				Datasource:  datasource,
+				Targets:     targets,
+				FieldConfig: &FieldConfig{Defaults: FieldDefaults{Unit: spec.Unit}},
+			})
+			id++
+		}
+		y += (len(row.Panels) + 1) / 2 * panelHeight
+	}
+}
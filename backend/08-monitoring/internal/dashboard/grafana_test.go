@@ -0,0 +1,80 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func panelTitles(d Dashboard, kind string) []string {
+	var titles []string
+	for _, p := range d.Panels {
+		if p.Type == kind {
+			titles = append(titles, p.Title)
+		}
+	}
+	return titles
+}
+
+func TestGenerate_OnlyRegisteredMetrics(t *testing.T) {
+	d := Generate("Test", map[string]string{
+		"http_requests_total":           "counter",
+		"http_request_duration_seconds": "histogram",
+		"go_goroutines":                 "gauge",
+	})
+
+	assert.Equal(t, []string{"Requests", "System"}, panelTitles(d, "row"))
+	assert.Equal(t, []string{"Request rate by route", "Error ratio (5xx)", "Latency", "Goroutines"}, panelTitles(d, "timeseries"))
+}
+
+func TestGenerate_CustomDistributions(t *testing.T) {
+	d := Generate("Test", map[string]string{
+		"http_request_duration_seconds": "histogram",
+		"job_duration_seconds":          "histogram",
+		"queue_wait":                    "summary",
+		"queue_depth":                   "gauge",
+	})
+
+	assert.Equal(t, []string{"Requests", "Custom metrics"}, panelTitles(d, "row"))
+
+	var job, queue Panel
+	for _, p := range d.Panels {
+		switch p.Title {
+		case "job_duration_seconds":
+			job = p
+		case "queue_wait":
+			queue = p
+		}
+	}
+	require.Len(t, job.Targets, 3)
+	assert.Contains(t, job.Targets[2].Expr, "histogram_quantile(0.99, sum by (le) (rate(job_duration_seconds_bucket")
+	assert.Equal(t, "p99", job.Targets[2].LegendFormat)
+	assert.Equal(t, "s", job.FieldConfig.Defaults.Unit)
+	assert.Equal(t, "queue_wait", queue.Targets[0].Expr)
+}
+
+func TestGenerate_LayoutAndJSON(t *testing.T) {
+	d := Generate("Test", map[string]string{
+		"http_requests_total":      "counter",
+		"http_requests_in_flight":  "gauge",
+		"http_response_size_bytes": "histogram",
+	})
+
+	// Row at y=0, then four panels two per line, ids unique and refIds lettered
+	ids := map[int]bool{}
+	for _, p := range d.Panels {
+		assert.False(t, ids[p.ID], "duplicate panel id %d", p.ID)
+		ids[p.ID] = true
+	}
+	assert.Equal(t, GridPos{H: 8, W: 12, X: 0, Y: 1}, d.Panels[1].GridPos)
+	assert.Equal(t, GridPos{H: 8, W: 12, X: 12, Y: 1}, d.Panels[2].GridPos)
+	assert.Equal(t, GridPos{H: 8, W: 12, X: 0, Y: 9}, d.Panels[3].GridPos)
+	assert.Equal(t, "A", d.Panels[1].Targets[0].RefID)
+
+	data, err := json.Marshal(d)
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(string(data), `"uid":"${datasource}"`))
+}
@@ -0,0 +1,164 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ConsulConfig describes how the service registers itself with a Consul agent
+type ConsulConfig struct {
+	Addr        string            // agent HTTP address, e.g. http://localhost:8500
+	Token       string            // ACL token, if the agent requires one
+	ServiceID   string            // unique per instance
+	ServiceName string            // shared by every instance; what Prometheus selects on
+	Address     string            // host or IP other machines reach this instance at
+	Port        int               // HTTP port, serving both the API and /metrics
+	Tags        []string          // e.g. "prometheus", used to filter scrape targets
+	Meta        map[string]string // free-form; metrics_path is added for relabeling
+	HealthPath  string            // polled by Consul, e.g. /health/ready
+	Interval    time.Duration     // how often Consul polls HealthPath
+	Timeout     time.Duration     // per poll
+	// DeregisterAfter removes an instance that stayed critical this long, so
+	// a crashed process does not linger as a scrape target
+	DeregisterAfter time.Duration
+}
+
+// registration is the body of PUT /v1/agent/service/register
+type registration struct {
+	ID      string            `json:"ID"`
+	Name    string            `json:"Name"`
+	Address string            `json:"Address"`
+	Port    int               `json:"Port"`
+	Tags    []string          `json:"Tags,omitempty"`
+	Meta    map[string]string `json:"Meta,omitempty"`
+	Check   agentCheck        `json:"Check"`
+}
+
+type agentCheck struct {
+	HTTP                           string `json:"HTTP"`
+	Method                         string `json:"Method"`
+	Interval                       string `json:"Interval"`
+	Timeout                        string `json:"Timeout"`
+	DeregisterCriticalServiceAfter string `json:"DeregisterCriticalServiceAfter"`
+}
+
+// ConsulRegistrar registers the service with the local Consul agent through
+// its HTTP API. Prometheus' consul_sd_configs then finds every instance
+// without a static target list.
+type ConsulRegistrar struct {
+	cfg    ConsulConfig
+	client *http.Client
+}
+
+// NewConsulRegistrar creates a registrar talking to cfg.Addr
+func NewConsulRegistrar(cfg ConsulConfig) (*ConsulRegistrar, error) {
+	u, err := url.Parse(cfg.Addr)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("consul address must be an absolute http(s) URL, got %q", cfg.Addr)
+	}
+	if cfg.ServiceName == "" || cfg.ServiceID == "" {
+		return nil, fmt.Errorf("consul service name and ID are required")
+	}
+	if cfg.Port <= 0 {
+		return nil, fmt.Errorf("invalid consul service port %d", cfg.Port)
+	}
+	cfg.Addr = strings.TrimSuffix(cfg.Addr, "/")
+	return &ConsulRegistrar{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// Register adds or updates the service and its health check. Registering
+// the same ID again replaces it, so retries are safe.
+func (c *ConsulRegistrar) Register(ctx context.Context) error {
+	meta := map[string]string{"metrics_path": "/metrics"}
+	for k, v := range c.cfg.Meta {
+		meta[k] = v
+	}
+
+	body := registration{
+		ID:      c.cfg.ServiceID,
+		Name:    c.cfg.ServiceName,
+		Address: c.cfg.Address,
+		Port:    c.cfg.Port,
+		Tags:    c.cfg.Tags,
+		Meta:    meta,
+		Check: agentCheck{
+			HTTP:                           fmt.Sprintf("http://%s:%d%s", c.cfg.Address, c.cfg.Port, c.cfg.HealthPath),
+			Method:                         http.MethodGet,
+			Interval:                       c.cfg.Interval.String(),
+			Timeout:                        c.cfg.Timeout.String(),
+			DeregisterCriticalServiceAfter: c.cfg.DeregisterAfter.String(),
+		},
+	}
+	return c.put(ctx, "/v1/agent/service/register", body)
+}
+
+// Deregister removes the service, so Prometheus stops scraping it at once
+// instead of after the health check turns critical
+func (c *ConsulRegistrar) Deregister(ctx context.Context) error {
+	return c.put(ctx, "/v1/agent/service/deregister/"+url.PathEscape(c.cfg.ServiceID), nil)
+}
+
+// RegisterWithRetry keeps trying until registration succeeds or ctx is
+// done, doubling the wait up to a minute. Consul being down at startup must
+// not keep the service from serving.
+func (c *ConsulRegistrar) RegisterWithRetry(ctx context.Context) {
+	wait := time.Second
+	for {
+		err := c.Register(ctx)
+		if err == nil {
+			logrus.WithFields(logrus.Fields{
+				"consul":     c.cfg.Addr,
+				"service":    c.cfg.ServiceName,
+				"service_id": c.cfg.ServiceID,
+			}).Info("Registered with Consul")
+			return
+		}
+		logrus.WithError(err).WithField("retry_in", wait.String()).Warn("Consul registration failed")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > time.Minute {
+			wait = time.Minute
+		}
+	}
+}
+
+func (c *ConsulRegistrar) put(ctx context.Context, path string, payload interface{}) error {
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.cfg.Addr+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.cfg.Token != "" {
+		req.Header.Set("X-Consul-Token", c.cfg.Token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("consul request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("consul %s returned status %d", path, resp.StatusCode)
+	}
+	return nil
+}
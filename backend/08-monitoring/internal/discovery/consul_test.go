@@ -0,0 +1,67 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsulRegistrar(t *testing.T) {
+	var paths []string
+	var registered registration
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "secret", r.Header.Get("X-Consul-Token"))
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/v1/agent/service/register" {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&registered))
+		}
+	}))
+	defer agent.Close()
+
+	registrar, err := NewConsulRegistrar(ConsulConfig{
+		Addr:            agent.URL + "/",
+		Token:           "secret",
+		ServiceID:       "monitoring-service-web1-8080",
+		ServiceName:     "monitoring-service",
+		Address:         "web1",
+		Port:            8080,
+		Tags:            []string{"prometheus"},
+		Meta:            map[string]string{"version": "1.2.0"},
+		HealthPath:      "/health/ready",
+		Interval:        15 * time.Second,
+		Timeout:         5 * time.Second,
+		DeregisterAfter: time.Minute,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, registrar.Register(context.Background()))
+	assert.Equal(t, "monitoring-service", registered.Name)
+	assert.Equal(t, map[string]string{"metrics_path": "/metrics", "version": "1.2.0"}, registered.Meta)
+	assert.Equal(t, "http://web1:8080/health/ready", registered.Check.HTTP)
+	assert.Equal(t, "15s", registered.Check.Interval)
+	assert.Equal(t, "1m0s", registered.Check.DeregisterCriticalServiceAfter)
+
+	require.NoError(t, registrar.Deregister(context.Background()))
+	assert.Equal(t, []string{"/v1/agent/service/register", "/v1/agent/service/deregister/monitoring-service-web1-8080"}, paths)
+}
+
+func TestConsulRegistrar_Errors(t *testing.T) {
+	_, err := NewConsulRegistrar(ConsulConfig{Addr: "localhost:8500", ServiceID: "a", ServiceName: "a", Port: 8080})
+	assert.Error(t, err, "address needs a scheme")
+
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer agent.Close()
+
+	registrar, err := NewConsulRegistrar(ConsulConfig{Addr: agent.URL, ServiceID: "a", ServiceName: "a", Port: 8080})
+	require.NoError(t, err)
+	assert.EqualError(t, registrar.Register(context.Background()), "consul /v1/agent/service/register returned status 403")
+}
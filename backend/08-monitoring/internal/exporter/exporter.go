@@ -0,0 +1,179 @@
+package exporter
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/repository"
+)
+
+// MetricType is how a pushed value should be aggregated by the receiver
+type MetricType string
+
+const (
+	Counter   MetricType = "counter"   // increment since the previous push
+	Gauge     MetricType = "gauge"     // current value
+	Histogram MetricType = "histogram" // one observation of a distribution
+)
+
+// Metric is one value pushed to an external backend
+type Metric struct {
+	Name  string
+	Type  MetricType
+	Value float64
+	Tags  map[string]string
+}
+
+// Exporter sends metrics to a push-based backend. Prometheus keeps being
+// served at /metrics regardless of the exporters configured.
+type Exporter interface {
+	Export(ctx context.Context, metrics []Metric) error
+	Close() error
+}
+
+// Pusher periodically converts the repository's metrics into Metric values
+// and hands them to an exporter. Counters are sent as the increase since the
+// previous push, the way StatsD expects them.
+type Pusher struct {
+	repo     *repository.MetricsRepository
+	exporter Exporter
+
+	prev       *repository.TrafficSnapshot
+	prevErrors map[string]int64
+	lastPush   time.Time
+}
+
+// NewPusher creates a pusher feeding exporter from repo
+func NewPusher(repo *repository.MetricsRepository, exporter Exporter) *Pusher {
+	return &Pusher{repo: repo, exporter: exporter, lastPush: time.Now()}
+}
+
+// Run pushes every interval until ctx is done, then flushes one last time
+func (p *Pusher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx is already cancelled; give the final push its own deadline
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			p.Push(flushCtx)
+			cancel()
+			if err := p.exporter.Close(); err != nil {
+				logrus.WithError(err).Warn("Closing metrics exporter failed")
+			}
+			return
+		case <-ticker.C:
+			p.Push(ctx)
+		}
+	}
+}
+
+// Push collects and exports one batch
+func (p *Pusher) Push(ctx context.Context) {
+	metrics := p.collect()
+	if len(metrics) == 0 {
+		return
+	}
+	if err := p.exporter.Export(ctx, metrics); err != nil {
+		logrus.WithError(err).Warn("Metrics export failed")
+	}
+}
+
+// collect builds the metrics for the interval since the previous call
+func (p *Pusher) collect() []Metric {
+	snapshot := p.repo.GetTrafficSnapshot()
+	errorCounts := p.repo.GetErrorMetrics()
+	system := p.repo.GetSystemMetrics()
+	since := p.lastPush
+	p.lastPush = snapshot.Taken
+
+	var metrics []Metric
+
+	// Request counts and latency percentiles per route
+	for key, count := range snapshot.Requests {
+		var prevCount int64
+		var prevLatency *models.LatencyHistogram
+		if p.prev != nil {
+			prevCount = p.prev.Requests[key]
+			prevLatency = p.prev.Latency[key]
+		}
+		if count == prevCount {
+			continue
+		}
+
+		tags := routeTags(key)
+		metrics = append(metrics, Metric{Name: "http.requests", Type: Counter, Value: float64(count - prevCount), Tags: tags})
+
+		if h, ok := snapshot.Latency[key]; ok {
+			window := h.Since(prevLatency)
+			for name, q := range map[string]float64{"p50": 0.50, "p90": 0.90, "p99": 0.99} {
+				metrics = append(metrics, Metric{Name: "http.request.duration_ms." + name, Type: Gauge, Value: math.Round(window.Quantile(q)*1000) / 1000, Tags: tags})
+			}
+		}
+	}
+
+	// Error counts per route and status; keys are "METHOD:/route:status"
+	for key, count := range errorCounts {
+		if delta := count - p.prevErrors[key]; delta > 0 {
+			idx := strings.LastIndex(key, ":")
+			tags := routeTags(key[:idx])
+			tags["status"] = key[idx+1:]
+			metrics = append(metrics, Metric{Name: "http.errors", Type: Counter, Value: float64(delta), Tags: tags})
+		}
+	}
+
+	p.prev = &snapshot
+	p.prevErrors = errorCounts
+
+	metrics = append(metrics,
+		Metric{Name: "process.cpu_percent", Type: Gauge, Value: system.CPUUsage},
+		Metric{Name: "system.cpu_percent", Type: Gauge, Value: system.SystemCPUUsage},
+		Metric{Name: "process.rss_bytes", Type: Gauge, Value: float64(system.RSS)},
+		Metric{Name: "process.open_fds", Type: Gauge, Value: float64(system.OpenFDs)},
+		Metric{Name: "go.goroutines", Type: Gauge, Value: float64(system.GoroutineCount)},
+		Metric{Name: "go.heap_alloc_bytes", Type: Gauge, Value: float64(system.HeapAlloc)},
+		Metric{Name: "go.heap_inuse_bytes", Type: Gauge, Value: float64(system.HeapInUse)},
+	)
+
+	return append(metrics, customMetrics(p.repo.GetCustomSeriesSince(since, snapshot.Taken))...)
+}
+
+// customMetrics maps recorded custom points onto push types: counter points
+// are summed, the latest gauge wins, histogram points are sent one by one
+func customMetrics(series []models.SeriesResult) []Metric {
+	var metrics []Metric
+	for _, s := range series {
+		if len(s.Points) == 0 {
+			continue
+		}
+
+		switch s.Type {
+		case "counter":
+			sum := 0.0
+			for _, point := range s.Points {
+				sum += point.Value
+			}
+			metrics = append(metrics, Metric{Name: s.Name, Type: Counter, Value: sum, Tags: s.Labels})
+		case "gauge":
+			metrics = append(metrics, Metric{Name: s.Name, Type: Gauge, Value: s.Points[len(s.Points)-1].Value, Tags: s.Labels})
+		default:
+			for _, point := range s.Points {
+				metrics = append(metrics, Metric{Name: s.Name, Type: Histogram, Value: point.Value, Tags: s.Labels})
+			}
+		}
+	}
+	return metrics
+}
+
+// routeTags splits a "METHOD:/route" key into method and route tags
+func routeTags(key string) map[string]string {
+	method, route, _ := strings.Cut(key, ":")
+	return map[string]string{"method": method, "route": route}
+}
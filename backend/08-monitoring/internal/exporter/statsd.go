@@ -0,0 +1,194 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StatsDConfig configures the StatsD exporter
+type StatsDConfig struct {
+	Addr          string            // host:port of the StatsD agent, UDP
+	Prefix        string            // prepended to every metric name, e.g. "monitoring."
+	DogStatsD     bool              // send tags in the DogStatsD "|#k:v" extension
+	Tags          map[string]string // added to every metric
+	TagMap        map[string]string // renames tag keys; mapping to "" drops the tag
+	MaxPacketSize int               // bytes per UDP datagram
+}
+
+// StatsDExporter pushes metrics over UDP in StatsD line format, packing as
+// many lines as fit into each datagram. Plain StatsD has no tags, so their
+// values are folded into the metric name instead.
+type StatsDExporter struct {
+	cfg  StatsDConfig
+	conn net.Conn
+}
+
+// NewStatsDExporter creates an exporter sending to cfg.Addr. A packet size of
+// 1432 keeps datagrams inside a typical 1500 byte MTU.
+func NewStatsDExporter(cfg StatsDConfig) (*StatsDExporter, error) {
+	if cfg.MaxPacketSize <= 0 {
+		cfg.MaxPacketSize = 1432
+	}
+
+	conn, err := net.Dial("udp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("dialing statsd at %s: %w", cfg.Addr, err)
+	}
+	return &StatsDExporter{cfg: cfg, conn: conn}, nil
+}
+
+// Export sends metrics in as few datagrams as possible
+func (e *StatsDExporter) Export(ctx context.Context, metrics []Metric) error {
+	lines := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		lines = append(lines, e.format(m))
+	}
+
+	var sent, failed int
+	var firstErr error
+	for _, packet := range Batch(lines, e.cfg.MaxPacketSize) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := e.conn.Write(packet); err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		sent++
+	}
+
+	if firstErr != nil {
+		return fmt.Errorf("%d of %d statsd packets failed: %w", failed, sent+failed, firstErr)
+	}
+	return nil
+}
+
+// Close closes the UDP socket
+func (e *StatsDExporter) Close() error {
+	return e.conn.Close()
+}
+
+// format renders one metric as a StatsD line
+func (e *StatsDExporter) format(m Metric) string {
+	tags := e.mapTags(m.Tags)
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	name := e.cfg.Prefix + m.Name
+	if !e.cfg.DogStatsD {
+		for _, k := range keys {
+			name += "." + nameSegment(tags[k])
+		}
+	}
+
+	line := sanitize(name) + ":" + strconv.FormatFloat(m.Value, 'f', -1, 64) + "|" + e.typeSuffix(m.Type)
+
+	if e.cfg.DogStatsD && len(keys) > 0 {
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, sanitize(k)+":"+sanitize(tags[k]))
+		}
+		line += "|#" + strings.Join(pairs, ",")
+	}
+	return line
+}
+
+// mapTags merges the global tags with the metric's own and applies TagMap
+func (e *StatsDExporter) mapTags(tags map[string]string) map[string]string {
+	result := make(map[string]string, len(e.cfg.Tags)+len(tags))
+	add := func(k, v string) {
+		if mapped, ok := e.cfg.TagMap[k]; ok {
+			k = mapped
+		}
+		if k != "" {
+			result[k] = v
+		}
+	}
+
+	for k, v := range e.cfg.Tags {
+		add(k, v)
+	}
+	for k, v := range tags {
+		add(k, v)
+	}
+	return result
+}
+
+func (e *StatsDExporter) typeSuffix(t MetricType) string {
+	switch t {
+	case Counter:
+		return "c"
+	case Histogram:
+		if e.cfg.DogStatsD {
+			return "h"
+		}
+		return "ms" // plain StatsD's timer is its only distribution type
+	}
+	return "g"
+}
+
+// Batch joins lines with newlines into packets of at most maxSize bytes.
+// A line longer than maxSize gets a packet of its own.
+func Batch(lines []string, maxSize int) [][]byte {
+	var packets [][]byte
+	var current []byte
+
+	for _, line := range lines {
+		if len(current) > 0 && len(current)+1+len(line) > maxSize {
+			packets = append(packets, current)
+			current = nil
+		}
+		if len(current) > 0 {
+			current = append(current, '\n')
+		}
+		current = append(current, line...)
+	}
+	if len(current) > 0 {
+		packets = append(packets, current)
+	}
+	return packets
+}
+
+// ParseTags parses "key:value,key:value" as used by STATSD_TAGS and
+// STATSD_TAG_MAP; entries without a colon map to an empty value
+func ParseTags(s string) map[string]string {
+	tags := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(entry, ":")
+		tags[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return tags
+}
+
+var (
+	lineSyntax = strings.NewReplacer(":", "_", "|", "_", "@", "_", "#", "_", ",", "_", "\n", "_", " ", "_")
+	hierarchy  = strings.NewReplacer(".", "_", "/", "_")
+)
+
+// sanitize replaces the characters that are part of the StatsD line syntax
+func sanitize(s string) string {
+	return lineSyntax.Replace(s)
+}
+
+// nameSegment turns a tag value into one level of a dotted metric name, so
+// the route /api/demo becomes api_demo rather than extra levels
+func nameSegment(value string) string {
+	if segment := strings.Trim(hierarchy.Replace(value), "_"); segment != "" {
+		return segment
+	}
+	return "root"
+}
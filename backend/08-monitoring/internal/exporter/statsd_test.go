@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsDExporter_Format(t *testing.T) {
+	requests := Metric{
+		Name:  "http.requests",
+		Type:  Counter,
+		Value: 3,
+		Tags:  map[string]string{"method": "GET", "route": "/api/demo"},
+	}
+
+	tests := []struct {
+		name   string
+		cfg    StatsDConfig
+		metric Metric
+		want   string
+	}{
+		{
+			name:   "dogstatsd tags sorted by key",
+			cfg:    StatsDConfig{Prefix: "monitoring.", DogStatsD: true},
+			metric: requests,
+			want:   "monitoring.http.requests:3|c|#method:GET,route:/api/demo",
+		},
+		{
+			name:   "plain statsd folds tag values into the name",
+			cfg:    StatsDConfig{Prefix: "monitoring."},
+			metric: requests,
+			want:   "monitoring.http.requests.GET.api_demo:3|c",
+		},
+		{
+			name:   "global tags and tag mapping",
+			cfg:    StatsDConfig{DogStatsD: true, Tags: map[string]string{"env": "dev"}, TagMap: map[string]string{"route": "endpoint", "method": ""}},
+			metric: requests,
+			want:   "http.requests:3|c|#endpoint:/api/demo,env:dev",
+		},
+		{
+			name:   "gauge",
+			cfg:    StatsDConfig{DogStatsD: true},
+			metric: Metric{Name: "go.goroutines", Type: Gauge, Value: 42.5},
+			want:   "go.goroutines:42.5|g",
+		},
+		{
+			name:   "histogram is a timer in plain statsd",
+			cfg:    StatsDConfig{},
+			metric: Metric{Name: "upload_size", Type: Histogram, Value: 1024},
+			want:   "upload_size:1024|ms",
+		},
+		{
+			name:   "line syntax characters are replaced",
+			cfg:    StatsDConfig{DogStatsD: true},
+			metric: Metric{Name: "odd:name|x", Type: Gauge, Value: 1, Tags: map[string]string{"plan": "a,b"}},
+			want:   "odd_name_x:1|g|#plan:a_b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &StatsDExporter{cfg: tt.cfg}
+			assert.Equal(t, tt.want, e.format(tt.metric))
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	lines := []string{"a:1|c", "b:2|c", "c:3|c", strings.Repeat("x", 20)}
+
+	packets := Batch(lines, 12)
+
+	if assert.Len(t, packets, 3) {
+		assert.Equal(t, "a:1|c\nb:2|c", string(packets[0]))
+		assert.Equal(t, "c:3|c", string(packets[1]))
+		// Oversized lines are sent alone rather than dropped
+		assert.Equal(t, strings.Repeat("x", 20), string(packets[2]))
+	}
+	assert.Empty(t, Batch(nil, 12))
+}
+
+func TestParseTags(t *testing.T) {
+	assert.Equal(t,
+		map[string]string{"env": "dev", "team": "core", "status": ""},
+		ParseTags(" env:dev, team:core,,status"))
+}
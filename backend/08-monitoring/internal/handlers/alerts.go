@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/alerting"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/pkg/response"
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// AlertHandler exposes alert rules and their current state
+type AlertHandler struct {
+	engine *alerting.Engine
+}
+
+// NewAlertHandler creates a new alert handler
+func NewAlertHandler(engine *alerting.Engine) *AlertHandler {
+	return &AlertHandler{engine: engine}
+}
+
+// GetAlerts handles GET /api/alerts - state of every rule
+func (h *AlertHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts := h.engine.Alerts()
+
+	firing := 0
+	for _, alert := range alerts {
+		if alert.State == models.AlertStateFiring {
+			firing++
+		}
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"alerts":    alerts,
+		"firing":    firing,
+		"timestamp": time.Now(),
+	})
+}
+
+// ListRules handles GET /api/alerts/rules
+func (h *AlertHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"rules": h.engine.Rules(),
+	})
+}
+
+// GetRule handles GET /api/alerts/rules/{id}
+func (h *AlertHandler) GetRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := h.engine.Rule(mux.Vars(r)["id"])
+	if err != nil {
+		respondRuleError(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, rule)
+}
+
+// CreateRule handles POST /api/alerts/rules
+func (h *AlertHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := decodeRule(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.engine.AddRule(rule)
+	if err != nil {
+		respondRuleError(w, err)
+		return
+	}
+	response.JSON(w, http.StatusCreated, created)
+}
+
+// UpdateRule handles PUT /api/alerts/rules/{id}
+func (h *AlertHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := decodeRule(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.engine.UpdateRule(mux.Vars(r)["id"], rule)
+	if err != nil {
+		respondRuleError(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, updated)
+}
+
+// DeleteRule handles DELETE /api/alerts/rules/{id}
+func (h *AlertHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	if err := h.engine.DeleteRule(mux.Vars(r)["id"]); err != nil {
+		respondRuleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeRule reads a rule from the body; rules are enabled unless the
+// body says otherwise
+func decodeRule(w http.ResponseWriter, r *http.Request) (models.AlertRule, bool) {
+	rule := models.AlertRule{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON format")
+		return rule, false
+	}
+	return rule, true
+}
+
+func respondRuleError(w http.ResponseWriter, err error) {
+	var validationErr *validation.Error
+	switch {
+	case errors.Is(err, alerting.ErrRuleNotFound):
+		response.Error(w, http.StatusNotFound, err.Error())
+	case errors.As(err, &validationErr):
+		response.Error(w, http.StatusBadRequest, err.Error())
+	default:
+		response.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/repository"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// AnalyticsHandler summarizes recorded traffic per route
+type AnalyticsHandler struct {
+	repo *repository.MetricsRepository
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(repo *repository.MetricsRepository) *AnalyticsHandler {
+	return &AnalyticsHandler{repo: repo}
+}
+
+// GetAnalytics handles GET /api/analytics - slowest routes, highest error
+// rates, traffic by status class and body sizes. Query parameters:
+//
+//	top           routes per ranking (5)
+//	min_requests  leave routes with fewer requests out of the rankings (1)
+func (h *AnalyticsHandler) GetAnalytics(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	top, ok := positiveInt(query.Get("top"), 5)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "top must be a positive integer")
+		return
+	}
+	minRequests, ok := positiveInt(query.Get("min_requests"), 1)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "min_requests must be a positive integer")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, models.Analyze(h.repo.GetRouteTraffic(), top, int64(minRequests)))
+}
+
+// positiveInt parses value, returning fallback when it is empty
+func positiveInt(value string, fallback int) (int, bool) {
+	if value == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(value)
+	return n, err == nil && n > 0
+}
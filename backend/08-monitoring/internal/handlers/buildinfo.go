@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// BuildInfoHandler serves what is running where
+type BuildInfoHandler struct {
+	info models.BuildInfo
+}
+
+// NewBuildInfoHandler creates a handler serving info, which is fixed for the
+// life of the process
+func NewBuildInfoHandler(info models.BuildInfo) *BuildInfoHandler {
+	return &BuildInfoHandler{info: info}
+}
+
+// GetBuildInfo handles GET /api/buildinfo - version, commit, Go version,
+// host and PID
+func (h *BuildInfoHandler) GetBuildInfo(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"build":     h.info,
+		"timestamp": time.Now(),
+	})
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/bundle"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/repository"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// BundleHandler serves a zip with everything needed to look into an incident
+// after the process is gone
+type BundleHandler struct {
+	repo   *repository.MetricsRepository
+	health *repository.HealthScheduler
+	build  models.BuildInfo
+}
+
+// NewBundleHandler creates a new bundle handler
+func NewBundleHandler(repo *repository.MetricsRepository, health *repository.HealthScheduler, build models.BuildInfo) *BundleHandler {
+	return &BundleHandler{repo: repo, health: health, build: build}
+}
+
+// GetBundle handles GET /api/debug/bundle - metrics, health results,
+// goroutine dump, heap profile and redacted config in one zip
+func (h *BundleHandler) GetBundle(w http.ResponseWriter, r *http.Request) {
+	data, err := bundle.Build(bundle.Contents{
+		Build:       h.build,
+		Metrics:     h.repo.GetSnapshot(),
+		Health:      h.health.Latest(r.Context()),
+		Environment: os.Environ(),
+	})
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, fmt.Sprintf("Could not build bundle: %v", err))
+		return
+	}
+	sendCapture(w, "bundle", "zip", data)
+}
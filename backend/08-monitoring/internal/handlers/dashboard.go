@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/dashboard"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/logging"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/metrics"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// DashboardHandler renders dashboards for the metrics this service exports
+type DashboardHandler struct {
+	prom *metrics.PrometheusMetrics
+}
+
+// NewDashboardHandler creates a new dashboard handler
+func NewDashboardHandler(prom *metrics.PrometheusMetrics) *DashboardHandler {
+	return &DashboardHandler{prom: prom}
+}
+
+// GetGrafanaDashboard handles GET /api/dashboards/grafana - a dashboard JSON
+// ready for Grafana's import. ?title= names it; ?download=true serves it as
+// a file.
+func (h *DashboardHandler) GetGrafanaDashboard(w http.ResponseWriter, r *http.Request) {
+	families, err := h.prom.MetricFamilies()
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Warn("Some metrics could not be gathered for the dashboard")
+	}
+
+	title := r.URL.Query().Get("title")
+	if title == "" {
+		title = "Monitoring Service"
+	}
+	board := dashboard.Generate(title, families)
+
+	if download, _ := strconv.ParseBool(r.URL.Query().Get("download")); !download {
+		response.JSON(w, http.StatusOK, board)
+		return
+	}
+
+	data, err := json.MarshalIndent(board, "", "  ")
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to encode dashboard")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="monitoring-dashboard.json"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"time"
+
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// maxCaptureSeconds bounds on-demand captures so a typo cannot tie up the
+// profiler for hours
+const maxCaptureSeconds = 300
+
+// DebugHandler captures CPU profiles and execution traces on demand
+type DebugHandler struct{}
+
+// NewDebugHandler creates a new debug handler
+func NewDebugHandler() *DebugHandler {
+	return &DebugHandler{}
+}
+
+// CaptureCPUProfile handles GET /debug/capture/cpu?seconds=30 - profiles the
+// CPU for the given duration and returns the result as a download
+func (h *DebugHandler) CaptureCPUProfile(w http.ResponseWriter, r *http.Request) {
+	duration, ok := captureDuration(w, r)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		// Only one CPU profile can run per process
+		response.Error(w, http.StatusConflict, fmt.Sprintf("Could not start CPU profile: %v", err))
+		return
+	}
+	completed := waitForCapture(r, duration)
+	pprof.StopCPUProfile()
+
+	if !completed {
+		return
+	}
+	sendCapture(w, "cpu", "pprof", buf.Bytes())
+}
+
+// CaptureTrace handles GET /debug/capture/trace?seconds=5 - records a
+// runtime execution trace for `go tool trace`
+func (h *DebugHandler) CaptureTrace(w http.ResponseWriter, r *http.Request) {
+	duration, ok := captureDuration(w, r)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		response.Error(w, http.StatusConflict, fmt.Sprintf("Could not start trace: %v", err))
+		return
+	}
+	completed := waitForCapture(r, duration)
+	trace.Stop()
+
+	if !completed {
+		return
+	}
+	sendCapture(w, "trace", "out", buf.Bytes())
+}
+
+// captureDuration reads ?seconds=, defaulting to 30
+func captureDuration(w http.ResponseWriter, r *http.Request) (time.Duration, bool) {
+	seconds := 30
+	if value := r.URL.Query().Get("seconds"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxCaptureSeconds {
+			response.Error(w, http.StatusBadRequest,
+				fmt.Sprintf("seconds must be between 1 and %d", maxCaptureSeconds))
+			return 0, false
+		}
+		seconds = parsed
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// waitForCapture sleeps for the capture duration, returning false if the
+// client went away first
+func waitForCapture(r *http.Request, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func sendCapture(w http.ResponseWriter, kind, ext string, data []byte) {
+	filename := fmt.Sprintf("%s-%s.%s", kind, time.Now().UTC().Format("20060102T150405Z"), ext)
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
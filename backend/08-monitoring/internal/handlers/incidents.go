@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/watchdog"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// IncidentHandler exposes incidents recorded by the leak watchdog
+type IncidentHandler struct {
+	watchdog *watchdog.Watchdog
+}
+
+// NewIncidentHandler creates a new incident handler
+func NewIncidentHandler(w *watchdog.Watchdog) *IncidentHandler {
+	return &IncidentHandler{watchdog: w}
+}
+
+// GetIncidents handles GET /api/incidents - recent incidents, newest first
+func (h *IncidentHandler) GetIncidents(w http.ResponseWriter, r *http.Request) {
+	incidents := h.watchdog.Incidents()
+
+	open := 0
+	for _, incident := range incidents {
+		if incident.Open() {
+			open++
+		}
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"incidents": incidents,
+		"open":      open,
+		"timestamp": time.Now(),
+	})
+}
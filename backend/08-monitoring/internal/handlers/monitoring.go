@@ -1,109 +1,165 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
 
+	"github.com/e6a5/learning/backend/08-monitoring/internal/logging"
 	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
 	"github.com/e6a5/learning/backend/08-monitoring/internal/repository"
-	"github.com/e6a5/learning/backend/08-monitoring/internal/utils"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/synthetic"
+	"github.com/e6a5/learning/backend/pkg/response"
 )
 
 // MonitoringHandler handles monitoring-related HTTP requests
 type MonitoringHandler struct {
-	repo           *repository.MetricsRepository
-	healthCheckers []repository.HealthChecker
-	promRegistry   *prometheus.Registry
+	repo         *repository.MetricsRepository
+	health       *repository.HealthScheduler
+	synthetic    *synthetic.Scheduler
+	promRegistry *prometheus.Registry
 }
 
-// NewMonitoringHandler creates a new monitoring handler
-func NewMonitoringHandler(repo *repository.MetricsRepository, checkers []repository.HealthChecker) *MonitoringHandler {
+// NewMonitoringHandler creates a new monitoring handler serving promRegistry
+// at /metrics and health results from the scheduler's cache
+func NewMonitoringHandler(repo *repository.MetricsRepository, health *repository.HealthScheduler, syntheticChecks *synthetic.Scheduler, promRegistry *prometheus.Registry) *MonitoringHandler {
 	return &MonitoringHandler{
-		repo:           repo,
-		healthCheckers: checkers,
-		promRegistry:   prometheus.NewRegistry(),
+		repo:         repo,
+		health:       health,
+		synthetic:    syntheticChecks,
+		promRegistry: promRegistry,
 	}
 }
 
-// HealthCheck handles GET /health - comprehensive health check
+// HealthCheck handles GET /health - comprehensive health check, served from
+// the cache unless ?force=true
 func (h *MonitoringHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
-	defer cancel()
-
-	response := h.repo.PerformHealthChecks(ctx, h.healthCheckers)
+	var body models.HealthResponse
+	if force, _ := strconv.ParseBool(r.URL.Query().Get("force")); force {
+		body = h.health.Refresh(r.Context())
+	} else {
+		body = h.health.Latest(r.Context())
+	}
 
 	statusCode := http.StatusOK
-	if response.HasCriticalFailures() {
+	if body.HasCriticalFailures() {
 		statusCode = http.StatusServiceUnavailable
-	} else if !response.IsHealthy() {
+	} else if !body.IsHealthy() {
 		statusCode = http.StatusOK // 200 for degraded but still serving
 	}
 
-	utils.RespondJSON(w, statusCode, response)
+	response.JSON(w, statusCode, body)
 }
 
 // LivenessCheck handles GET /health/live - simple liveness probe
 func (h *MonitoringHandler) LivenessCheck(w http.ResponseWriter, r *http.Request) {
-	response := map[string]interface{}{
-		"status":    "alive",
-		"timestamp": time.Now(),
-		"uptime":    time.Since(h.repo.GetSystemMetrics().Timestamp),
+	uptime := h.repo.Uptime()
+	body := map[string]interface{}{
+		"status":             "alive",
+		"timestamp":          time.Now(),
+		"start_time":         h.repo.StartTime(),
+		"uptime_seconds":     uptime.Seconds(),
+		"uptime":             uptime.Round(time.Second).String(),
+		"last_config_reload": h.repo.ConfigLoadedAt(),
 	}
 
-	utils.RespondJSON(w, http.StatusOK, response)
+	response.JSON(w, http.StatusOK, body)
 }
 
-// ReadinessCheck handles GET /health/ready - readiness probe
-func (h *MonitoringHandler) ReadinessCheck(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+// GetHealthHistory handles GET /api/health/history - status changes per
+// check, newest first. Query parameters:
+//
+//	check  only this check's transitions
+//	limit  at most this many transitions (100)
+func (h *MonitoringHandler) GetHealthHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := 100
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			response.Error(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
+	history := h.health.History()
+	checks := history.Checks(time.Now())
+
+	flapping := make([]string, 0)
+	for _, check := range checks {
+		if check.Flapping {
+			flapping = append(flapping, check.Check)
+		}
+	}
 
-	response := h.repo.PerformHealthChecks(ctx, h.healthCheckers)
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"checks":      checks,
+		"flapping":    flapping,
+		"flap_window": history.FlapWindow().String(),
+		"transitions": history.Transitions(query.Get("check"), limit),
+		"timestamp":   time.Now(),
+	})
+}
+
+// ReadinessCheck handles GET /health/ready - readiness probe; only critical
+// checks can make the service unready
+func (h *MonitoringHandler) ReadinessCheck(w http.ResponseWriter, r *http.Request) {
+	body := h.health.Latest(r.Context())
 
 	statusCode := http.StatusOK
-	if response.HasCriticalFailures() {
+	if body.HasCriticalFailures() {
 		statusCode = http.StatusServiceUnavailable
 	}
 
 	readinessResponse := map[string]interface{}{
-		"ready":     response.IsHealthy(),
-		"status":    response.Status,
-		"timestamp": time.Now(),
-		"checks":    len(response.Checks),
+		"ready":      !body.HasCriticalFailures(),
+		"status":     body.Status,
+		"timestamp":  time.Now(),
+		"checks":     len(body.Checks),
+		"checked_at": body.CheckedAt,
 	}
 
-	utils.RespondJSON(w, statusCode, readinessResponse)
+	response.JSON(w, statusCode, readinessResponse)
 }
 
-// GetMetrics handles GET /metrics - Prometheus-style metrics
+// GetMetrics handles GET /metrics - Prometheus-style metrics. Scrapers that
+// ask for OpenMetrics also get the latency exemplars.
 func (h *MonitoringHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
-	promhttp.HandlerFor(h.promRegistry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	promhttp.HandlerFor(h.promRegistry, promhttp.HandlerOpts{EnableOpenMetrics: true}).ServeHTTP(w, r)
 }
 
 // GetCustomMetrics handles GET /api/metrics - custom JSON metrics
 func (h *MonitoringHandler) GetCustomMetrics(w http.ResponseWriter, r *http.Request) {
 	requestMetrics := h.repo.GetRequestMetrics()
+	latencyMetrics := h.repo.GetLatencyMetrics()
 	errorMetrics := h.repo.GetErrorMetrics()
 	customMetrics := h.repo.GetCustomMetrics()
+	distributions := h.repo.GetDistributions()
 	systemMetrics := h.repo.GetSystemMetrics()
 
-	response := map[string]interface{}{
-		"request_metrics": requestMetrics,
-		"error_metrics":   errorMetrics,
-		"custom_metrics":  customMetrics,
-		"system_metrics":  systemMetrics,
-		"timestamp":       time.Now(),
+	body := map[string]interface{}{
+		"request_metrics":  requestMetrics,
+		"latency_metrics":  latencyMetrics,
+		"error_metrics":    errorMetrics,
+		"custom_metrics":   customMetrics,
+		"distributions":    distributions,
+		"synthetic_checks": h.synthetic.Statuses(),
+		"system_metrics":   systemMetrics,
+		"timestamp":        time.Now(),
 	}
 
-	utils.RespondJSON(w, http.StatusOK, response)
+	response.JSON(w, http.StatusOK, body)
 }
 
 // PostCustomMetric handles POST /api/metrics - submit custom metric
@@ -111,7 +167,7 @@ func (h *MonitoringHandler) PostCustomMetric(w http.ResponseWriter, r *http.Requ
 	var metric models.CustomMetric
 
 	if err := json.NewDecoder(r.Body).Decode(&metric); err != nil {
-		utils.RespondJSON(w, http.StatusBadRequest, map[string]string{
+		response.JSON(w, http.StatusBadRequest, map[string]string{
 			"error": "Invalid JSON format",
 		})
 		return
@@ -120,38 +176,209 @@ func (h *MonitoringHandler) PostCustomMetric(w http.ResponseWriter, r *http.Requ
 	metric.Timestamp = time.Now()
 
 	if err := h.repo.RecordCustomMetric(metric); err != nil {
-		log.Printf("Error recording custom metric: %v", err)
-		utils.RespondJSON(w, http.StatusBadRequest, map[string]string{
+		logging.FromContext(r.Context()).WithError(err).WithField("metric", metric.Name).Warn("Error recording custom metric")
+		status := http.StatusBadRequest
+		if errors.Is(err, repository.ErrMetricConflict) {
+			status = http.StatusConflict
+		}
+		response.JSON(w, status, map[string]string{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+	response.JSON(w, http.StatusCreated, map[string]interface{}{
 		"message": "Metric recorded successfully",
 		"metric":  metric,
 	})
 }
 
+// GetCustomMetricSeries handles GET /api/metrics/custom/{name} - history of
+// a custom metric. Query parameters:
+//
+//	since  duration back from now (15m) or RFC 3339 timestamp
+//	step   bucket width for downsampling (1m); raw points when omitted
+//	agg    avg, sum, min, max, last or count; sum for counters, avg otherwise
+//	label  key=value filter, may be repeated
+func (h *MonitoringHandler) GetCustomMetricSeries(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	query := r.URL.Query()
+
+	var since time.Time
+	if value := query.Get("since"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			since = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, value); err == nil {
+			since = t
+		} else {
+			response.Error(w, http.StatusBadRequest, "since must be a positive duration or an RFC 3339 timestamp")
+			return
+		}
+	}
+
+	var step time.Duration
+	if value := query.Get("step"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			response.Error(w, http.StatusBadRequest, "step must be a positive duration")
+			return
+		}
+		step = d
+	}
+
+	agg := query.Get("agg")
+	if agg != "" && !models.ValidAggregation(agg) {
+		response.Error(w, http.StatusBadRequest, "agg must be avg, sum, min, max, last or count")
+		return
+	}
+
+	labels := make(map[string]string)
+	for _, pair := range query["label"] {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || k == "" {
+			response.Error(w, http.StatusBadRequest, "label must be key=value")
+			return
+		}
+		labels[k] = v
+	}
+
+	series := h.repo.QueryCustomMetric(name, labels, since)
+	if len(series) == 0 {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("No series for metric %q", name))
+		return
+	}
+
+	for i := range series {
+		seriesAgg := agg
+		if seriesAgg == "" {
+			seriesAgg = models.DefaultAggregation(series[i].Type)
+		}
+		series[i].Points = models.Downsample(series[i].Points, step, seriesAgg)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"name":      name,
+		"series":    series,
+		"since":     since,
+		"step":      step.String(),
+		"timestamp": time.Now(),
+	})
+}
+
+// GetMetricDefinitions handles GET /api/metrics/definitions - registered
+// custom metric definitions
+func (h *MonitoringHandler) GetMetricDefinitions(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"definitions": h.repo.GetMetricDefinitions(),
+		"timestamp":   time.Now(),
+	})
+}
+
+// RegisterMetric handles POST /api/metrics/definitions - fix a custom
+// metric's type and its histogram buckets or summary quantiles
+func (h *MonitoringHandler) RegisterMetric(w http.ResponseWriter, r *http.Request) {
+	var def models.MetricDefinition
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid JSON format",
+		})
+		return
+	}
+
+	if err := h.repo.RegisterMetric(def); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, repository.ErrMetricConflict) {
+			status = http.StatusConflict
+		}
+		response.JSON(w, status, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, map[string]interface{}{
+		"message":    "Metric registered successfully",
+		"definition": def.WithDefaults(),
+	})
+}
+
+// ResetMetrics handles POST /api/metrics/reset - clear custom metrics matched
+// by ?name= or ?prefix=, or every custom metric with ?all=true
+func (h *MonitoringHandler) ResetMetrics(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name, prefix := query.Get("name"), query.Get("prefix")
+	if name == "" && prefix == "" && query.Get("all") != "true" {
+		response.Error(w, http.StatusBadRequest, "name, prefix or all=true is required")
+		return
+	}
+
+	removed := h.repo.ResetCustomMetrics(name, prefix)
+	logging.FromContext(r.Context()).WithFields(logrus.Fields{
+		"name":           name,
+		"prefix":         prefix,
+		"series_removed": removed,
+	}).Info("Custom metrics reset")
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"message":        "Metrics reset successfully",
+		"series_removed": removed,
+		"timestamp":      time.Now(),
+	})
+}
+
+// GetSnapshot handles GET /api/metrics/snapshot - every metric at one point
+// in time, served as a download for later comparison
+func (h *MonitoringHandler) GetSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot := h.repo.GetSnapshot()
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to encode snapshot")
+		return
+	}
+
+	filename := fmt.Sprintf("metrics-snapshot-%s.json", snapshot.TakenAt.UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// GetCardinality handles GET /api/metrics/cardinality - distinct label
+// sets per custom metric, highest first
+func (h *MonitoringHandler) GetCardinality(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"metrics":   h.repo.GetCardinalityReport(),
+		"timestamp": time.Now(),
+	})
+}
+
 // GetSystemInfo handles GET /api/system - system information
 func (h *MonitoringHandler) GetSystemInfo(w http.ResponseWriter, r *http.Request) {
 	systemMetrics := h.repo.GetSystemMetrics()
 
-	response := map[string]interface{}{
+	body := map[string]interface{}{
 		"system_metrics": systemMetrics,
 		"process_info": map[string]interface{}{
 			"goroutines": systemMetrics.GoroutineCount,
+			"open_fds":   systemMetrics.OpenFDs,
+			"cpu": map[string]interface{}{
+				"process_percent": fmt.Sprintf("%.1f%%", systemMetrics.CPUUsage),
+				"system_percent":  fmt.Sprintf("%.1f%%", systemMetrics.SystemCPUUsage),
+			},
 			"memory": map[string]interface{}{
 				"heap_alloc":   fmt.Sprintf("%.2f MB", float64(systemMetrics.HeapAlloc)/1024/1024),
 				"heap_inuse":   fmt.Sprintf("%.2f MB", float64(systemMetrics.HeapInUse)/1024/1024),
 				"memory_usage": fmt.Sprintf("%.2f MB", float64(systemMetrics.MemoryUsage)/1024/1024),
 				"memory_total": fmt.Sprintf("%.2f MB", float64(systemMetrics.MemoryTotal)/1024/1024),
+				"rss":          fmt.Sprintf("%.2f MB", float64(systemMetrics.RSS)/1024/1024),
 			},
 		},
 		"timestamp": time.Now(),
 	}
 
-	utils.RespondJSON(w, http.StatusOK, response)
+	response.JSON(w, http.StatusOK, body)
 }
 
 // DemoEndpoint handles GET /api/demo - endpoint to generate metrics
@@ -171,18 +398,18 @@ func (h *MonitoringHandler) DemoEndpoint(w http.ResponseWriter, r *http.Request)
 	// Simulate different types of responses
 	switch errorParam {
 	case "400":
-		utils.RespondJSON(w, http.StatusBadRequest, map[string]string{
+		response.JSON(w, http.StatusBadRequest, map[string]string{
 			"error": "Simulated bad request error",
 		})
 		return
 	case "500":
-		utils.RespondJSON(w, http.StatusInternalServerError, map[string]string{
+		response.JSON(w, http.StatusInternalServerError, map[string]string{
 			"error": "Simulated internal server error",
 		})
 		return
 	case "timeout":
 		time.Sleep(6 * time.Second) // Longer than typical timeout
-		utils.RespondJSON(w, http.StatusRequestTimeout, map[string]string{
+		response.JSON(w, http.StatusRequestTimeout, map[string]string{
 			"error": "Simulated timeout",
 		})
 		return
@@ -201,10 +428,10 @@ func (h *MonitoringHandler) DemoEndpoint(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := h.repo.RecordCustomMetric(metric); err != nil {
-		log.Printf("Error recording demo metric: %v", err)
+		logging.FromContext(r.Context()).WithError(err).Error("Error recording demo metric")
 	}
 
-	response := map[string]interface{}{
+	body := map[string]interface{}{
 		"message":   "Demo endpoint successful",
 		"timestamp": time.Now(),
 		"method":    r.Method,
@@ -218,15 +445,12 @@ func (h *MonitoringHandler) DemoEndpoint(w http.ResponseWriter, r *http.Request)
 		},
 	}
 
-	utils.RespondJSON(w, http.StatusOK, response)
+	response.JSON(w, http.StatusOK, body)
 }
 
 // GetStatus handles GET /api/status - application status overview
 func (h *MonitoringHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-
-	healthResponse := h.repo.PerformHealthChecks(ctx, h.healthCheckers)
+	healthResponse := h.health.Latest(r.Context())
 	systemMetrics := h.repo.GetSystemMetrics()
 	requestMetrics := h.repo.GetRequestMetrics()
 
@@ -236,7 +460,15 @@ func (h *MonitoringHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
 		totalRequests += count
 	}
 
-	response := map[string]interface{}{
+	syntheticChecks := h.synthetic.Statuses()
+	syntheticDown := 0
+	for _, check := range syntheticChecks {
+		if check.LastResult != nil && !check.Up {
+			syntheticDown++
+		}
+	}
+
+	body := map[string]interface{}{
 		"application": map[string]interface{}{
 			"status":      healthResponse.Status,
 			"version":     healthResponse.Version,
@@ -249,19 +481,30 @@ func (h *MonitoringHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
 		},
 		"performance": map[string]interface{}{
 			"goroutines": systemMetrics.GoroutineCount,
-			"memory_mb":  float64(systemMetrics.MemoryUsage) / 1024 / 1024,
-			"heap_mb":    float64(systemMetrics.HeapAlloc) / 1024 / 1024,
+			"open_fds":   systemMetrics.OpenFDs,
+			"cpu": map[string]interface{}{
+				"process_percent": fmt.Sprintf("%.1f%%", systemMetrics.CPUUsage),
+				"system_percent":  fmt.Sprintf("%.1f%%", systemMetrics.SystemCPUUsage),
+			},
+			"memory_mb": float64(systemMetrics.MemoryUsage) / 1024 / 1024,
+			"heap_mb":   float64(systemMetrics.HeapAlloc) / 1024 / 1024,
 		},
 		"health_checks": map[string]interface{}{
-			"total":    len(healthResponse.Checks),
-			"healthy":  countHealthyChecks(healthResponse.Checks),
-			"degraded": countDegradedChecks(healthResponse.Checks),
-			"failed":   countFailedChecks(healthResponse.Checks),
+			"total":      len(healthResponse.Checks),
+			"healthy":    countHealthyChecks(healthResponse.Checks),
+			"degraded":   countDegradedChecks(healthResponse.Checks),
+			"failed":     countFailedChecks(healthResponse.Checks),
+			"checked_at": healthResponse.CheckedAt,
+		},
+		"synthetic_checks": map[string]interface{}{
+			"total": len(syntheticChecks),
+			"down":  syntheticDown,
 		},
+		"logging":   logging.Stats(),
 		"timestamp": time.Now(),
 	}
 
-	utils.RespondJSON(w, http.StatusOK, response)
+	response.JSON(w, http.StatusOK, body)
 }
 
 // Helper functions for health check counting
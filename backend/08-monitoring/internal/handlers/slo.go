@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/slo"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// SLOHandler exposes service level objectives and their error budgets
+type SLOHandler struct {
+	tracker *slo.Tracker
+}
+
+// NewSLOHandler creates a new SLO handler
+func NewSLOHandler(tracker *slo.Tracker) *SLOHandler {
+	return &SLOHandler{tracker: tracker}
+}
+
+// GetSLOs handles GET /api/slo - error budget and burn rates per objective
+func (h *SLOHandler) GetSLOs(w http.ResponseWriter, r *http.Request) {
+	statuses := h.tracker.Status()
+
+	alerting := 0
+	for _, status := range statuses {
+		if len(status.Alerts) > 0 {
+			alerting++
+		}
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"objectives": statuses,
+		"alerting":   alerting,
+		"timestamp":  time.Now(),
+	})
+}
+
+// CreateObjective handles POST /api/slo
+func (h *SLOHandler) CreateObjective(w http.ResponseWriter, r *http.Request) {
+	var objective models.Objective
+	if err := json.NewDecoder(r.Body).Decode(&objective); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	created, err := h.tracker.AddObjective(objective)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, created)
+}
+
+// DeleteObjective handles DELETE /api/slo/{id}
+func (h *SLOHandler) DeleteObjective(w http.ResponseWriter, r *http.Request) {
+	if err := h.tracker.DeleteObjective(mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, slo.ErrObjectiveNotFound) {
+			response.Error(w, http.StatusNotFound, err.Error())
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
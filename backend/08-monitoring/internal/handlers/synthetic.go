@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/synthetic"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// SyntheticHandler exposes synthetic checks of external endpoints
+type SyntheticHandler struct {
+	scheduler *synthetic.Scheduler
+}
+
+// NewSyntheticHandler creates a new synthetic check handler
+func NewSyntheticHandler(scheduler *synthetic.Scheduler) *SyntheticHandler {
+	return &SyntheticHandler{scheduler: scheduler}
+}
+
+// ListChecks handles GET /api/synthetic - every check with its latest result
+func (h *SyntheticHandler) ListChecks(w http.ResponseWriter, r *http.Request) {
+	statuses := h.scheduler.Statuses()
+
+	down := 0
+	for _, status := range statuses {
+		if status.LastResult != nil && !status.Up {
+			down++
+		}
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"checks":    statuses,
+		"down":      down,
+		"timestamp": time.Now(),
+	})
+}
+
+// CreateCheck handles POST /api/synthetic
+func (h *SyntheticHandler) CreateCheck(w http.ResponseWriter, r *http.Request) {
+	var check models.SyntheticCheck
+	if err := json.NewDecoder(r.Body).Decode(&check); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	created, err := h.scheduler.AddCheck(check)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, created)
+}
+
+// GetCheck handles GET /api/synthetic/{id} - one check with its probe history
+func (h *SyntheticHandler) GetCheck(w http.ResponseWriter, r *http.Request) {
+	status, err := h.scheduler.Check(mux.Vars(r)["id"])
+	if err != nil {
+		respondSyntheticError(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, status)
+}
+
+// DeleteCheck handles DELETE /api/synthetic/{id}
+func (h *SyntheticHandler) DeleteCheck(w http.ResponseWriter, r *http.Request) {
+	if err := h.scheduler.DeleteCheck(mux.Vars(r)["id"]); err != nil {
+		respondSyntheticError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondSyntheticError(w http.ResponseWriter, err error) {
+	if errors.Is(err, synthetic.ErrCheckNotFound) {
+		response.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	response.Error(w, http.StatusInternalServerError, err.Error())
+}
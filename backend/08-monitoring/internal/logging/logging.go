@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/tracing"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// Config configures logging
+type Config struct {
+	Level  string // any logrus level name: debug, info, warn, error
+	Format string // "json" for log shippers or "console" for humans
+
+	// AccessSampleRate keeps one in this many access logs of successful
+	// requests; failed requests are always logged
+	AccessSampleRate int
+
+	// RateLimit caps identical warnings and errors per RateLimitWindow;
+	// 0 disables the limit
+	RateLimit       int
+	RateLimitWindow time.Duration
+}
+
+// SuppressionStats counts log lines that were not written
+type SuppressionStats struct {
+	AccessSampleRate int   `json:"access_sample_rate"`
+	SampledOut       int64 `json:"access_sampled_out"`
+	RateLimited      int64 `json:"rate_limited"`
+}
+
+var (
+	access        = logrus.New()
+	accessSampler = NewSampler(1)
+	limiter       = NewRateLimiter(0, time.Minute)
+)
+
+// Setup configures the standard logrus logger and the access logger. Access
+// logs bypass the rate limit, which would otherwise fold every failing
+// request into one line, and are sampled instead.
+func Setup(cfg Config) error {
+	parsed, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+
+	var formatter logrus.Formatter
+	switch cfg.Format {
+	case "json":
+		formatter = &logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano}
+	case "console", "text":
+		formatter = &logrus.TextFormatter{FullTimestamp: true, TimestampFormat: "15:04:05.000"}
+	default:
+		return fmt.Errorf("invalid log format %q (want json or console)", cfg.Format)
+	}
+
+	limiter = NewRateLimiter(cfg.RateLimit, cfg.RateLimitWindow)
+	accessSampler = NewSampler(cfg.AccessSampleRate)
+
+	logrus.SetFormatter(&rateLimitedFormatter{Formatter: formatter, limiter: limiter})
+	logrus.SetOutput(os.Stdout)
+	logrus.SetLevel(parsed)
+
+	access.SetFormatter(formatter)
+	access.SetOutput(os.Stdout)
+	access.SetLevel(parsed)
+	return nil
+}
+
+// Stats returns the number of lines sampled out and rate limited so far
+func Stats() SuppressionStats {
+	return SuppressionStats{
+		AccessSampleRate: accessSampler.Rate(),
+		SampledOut:       accessSampler.Suppressed(),
+		RateLimited:      limiter.Suppressed(),
+	}
+}
+
+// KeepAccessLog reports whether the access log line for a response with
+// status should be written
+func KeepAccessLog(status int) bool {
+	return accessSampler.Keep(status)
+}
+
+// AccessFromContext is FromContext for access log lines. Sampled lines carry
+// sample_rate so log queries can scale counts back up.
+func AccessFromContext(ctx context.Context, status int) *logrus.Entry {
+	entry := access.WithFields(contextFields(ctx))
+	if rate := accessSampler.Rate(); rate > 1 && status < 400 {
+		entry = entry.WithField("sample_rate", rate)
+	}
+	return entry
+}
+
+// FromContext returns a logger carrying the request and trace IDs of ctx,
+// so every line logged while serving a request can be joined up
+func FromContext(ctx context.Context) *logrus.Entry {
+	return logrus.WithFields(contextFields(ctx))
+}
+
+func contextFields(ctx context.Context) logrus.Fields {
+	fields := logrus.Fields{}
+	if id := RequestID(ctx); id != "" {
+		fields["request_id"] = id
+	}
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		fields["trace_id"] = traceID
+	}
+	return fields
+}
+
+// WithRequestID stores a request ID in ctx
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored in ctx, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random 16 byte hex ID
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ValidRequestID accepts client supplied IDs that are short and printable, so
+// they can be echoed into headers and logs safely
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
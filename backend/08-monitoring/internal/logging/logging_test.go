@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidRequestID(t *testing.T) {
+	assert.True(t, ValidRequestID("abc-123"))
+	assert.True(t, ValidRequestID(NewRequestID()))
+	assert.False(t, ValidRequestID(""))
+	assert.False(t, ValidRequestID("has space"))
+	assert.False(t, ValidRequestID("line\nbreak"))
+	assert.False(t, ValidRequestID(strings.Repeat("a", 129)))
+}
+
+func TestFromContext(t *testing.T) {
+	ctx := WithRequestID(context.Background(), "abc-123")
+	assert.Equal(t, "abc-123", FromContext(ctx).Data["request_id"])
+	assert.NotContains(t, FromContext(context.Background()).Data, "request_id")
+}
+
+func TestSetup(t *testing.T) {
+	assert.NoError(t, Setup(Config{Level: "debug", Format: "console"}))
+	assert.NoError(t, Setup(Config{Level: "info", Format: "json", AccessSampleRate: 10, RateLimit: 5, RateLimitWindow: time.Minute}))
+	assert.Error(t, Setup(Config{Level: "loud", Format: "json"}))
+	assert.Error(t, Setup(Config{Level: "info", Format: "xml"}))
+}
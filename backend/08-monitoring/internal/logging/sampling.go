@@ -0,0 +1,166 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sampler thins out access logs: every failed request (status >= 400) is
+// kept, successful ones one in every n
+type Sampler struct {
+	n          uint64
+	seen       atomic.Uint64
+	suppressed atomic.Int64
+}
+
+// NewSampler creates a sampler keeping one in n successful requests; n <= 1
+// keeps everything
+func NewSampler(n int) *Sampler {
+	if n < 1 {
+		n = 1
+	}
+	return &Sampler{n: uint64(n)}
+}
+
+// Keep reports whether the access log line for a response with status
+// should be written
+func (s *Sampler) Keep(status int) bool {
+	if status >= 400 || s.n == 1 {
+		return true
+	}
+	if (s.seen.Add(1)-1)%s.n == 0 {
+		return true
+	}
+	s.suppressed.Add(1)
+	return false
+}
+
+// Rate returns n, the inverse of the sampling probability
+func (s *Sampler) Rate() int {
+	return int(s.n)
+}
+
+// Suppressed returns how many lines were sampled out
+func (s *Sampler) Suppressed() int64 {
+	return s.suppressed.Load()
+}
+
+// RateLimiter lets through at most limit occurrences of the same key per
+// window, so an error repeated on every request or tick logs a few times a
+// minute instead of flooding the output
+type RateLimiter struct {
+	mu         sync.Mutex
+	limit      int
+	window     time.Duration
+	keys       map[string]*limitState
+	suppressed int64
+	now        func() time.Time
+}
+
+type limitState struct {
+	start      time.Time
+	count      int
+	suppressed int64
+}
+
+// maxLimiterKeys bounds memory when messages embed changing values
+const maxLimiterKeys = 1000
+
+// NewRateLimiter creates a limiter; a limit of 0 disables it
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:  limit,
+		window: window,
+		keys:   make(map[string]*limitState),
+		now:    time.Now,
+	}
+}
+
+// Allow reports whether an occurrence of key may be logged. When a new window
+// opens it also returns how many occurrences the previous one suppressed.
+func (l *RateLimiter) Allow(key string) (allowed bool, suppressedBefore int64) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	state, ok := l.keys[key]
+	if !ok {
+		if len(l.keys) >= maxLimiterKeys {
+			l.prune(now)
+		}
+		state = &limitState{start: now}
+		l.keys[key] = state
+	} else if now.Sub(state.start) >= l.window {
+		suppressedBefore = state.suppressed
+		*state = limitState{start: now}
+	}
+
+	if state.count >= l.limit {
+		state.suppressed++
+		l.suppressed++
+		return false, 0
+	}
+	state.count++
+	return true, suppressedBefore
+}
+
+// prune drops keys whose window has ended; their suppressed counts are lost,
+// but the total in Suppressed is kept
+func (l *RateLimiter) prune(now time.Time) {
+	for key, state := range l.keys {
+		if now.Sub(state.start) >= l.window {
+			delete(l.keys, key)
+		}
+	}
+}
+
+// Suppressed returns how many lines were dropped in total
+func (l *RateLimiter) Suppressed() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.suppressed
+}
+
+// rateLimitedFormatter applies a RateLimiter to warnings and errors. Entries
+// are keyed by level, message and error, so the same failure repeating is
+// limited while different failures still get through. Fatal and panic
+// entries are never dropped.
+type rateLimitedFormatter struct {
+	logrus.Formatter
+	limiter *RateLimiter
+}
+
+func (f *rateLimitedFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if entry.Level != logrus.ErrorLevel && entry.Level != logrus.WarnLevel {
+		return f.Formatter.Format(entry)
+	}
+
+	key := entry.Level.String() + "|" + entry.Message
+	if err, ok := entry.Data[logrus.ErrorKey]; ok {
+		key += "|" + fmt.Sprint(err)
+	}
+
+	allowed, suppressed := f.limiter.Allow(key)
+	if !allowed {
+		return nil, nil // logrus writes nothing for an empty line
+	}
+	if suppressed > 0 {
+		// Copy the entry: callers may reuse it, and its Data is theirs
+		annotated := *entry
+		annotated.Data = make(logrus.Fields, len(entry.Data)+1)
+		for k, v := range entry.Data {
+			annotated.Data[k] = v
+		}
+		annotated.Data["suppressed"] = suppressed
+		return f.Formatter.Format(&annotated)
+	}
+	return f.Formatter.Format(entry)
+}
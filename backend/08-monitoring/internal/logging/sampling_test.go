@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampler(t *testing.T) {
+	s := NewSampler(3)
+
+	kept := 0
+	for i := 0; i < 9; i++ {
+		if s.Keep(200) {
+			kept++
+		}
+	}
+	assert.Equal(t, 3, kept)
+	assert.Equal(t, int64(6), s.Suppressed())
+
+	// Failures are never sampled out
+	for i := 0; i < 5; i++ {
+		assert.True(t, s.Keep(500))
+		assert.True(t, s.Keep(404))
+	}
+	assert.Equal(t, int64(6), s.Suppressed())
+
+	assert.True(t, NewSampler(0).Keep(200))
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	for _, want := range []bool{true, true, false, false} {
+		allowed, _ := l.Allow("db down")
+		assert.Equal(t, want, allowed)
+	}
+	allowed, _ := l.Allow("other error")
+	assert.True(t, allowed, "keys are limited independently")
+
+	// The next window reports what the previous one dropped
+	now = now.Add(time.Minute)
+	allowed, suppressed := l.Allow("db down")
+	assert.True(t, allowed)
+	assert.Equal(t, int64(2), suppressed)
+	assert.Equal(t, int64(2), l.Suppressed())
+
+	allowed, _ = NewRateLimiter(0, time.Minute).Allow("x")
+	assert.True(t, allowed, "a zero limit disables limiting")
+}
+
+func TestRateLimitedFormatter(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(&rateLimitedFormatter{
+		Formatter: &logrus.TextFormatter{DisableTimestamp: true},
+		limiter:   NewRateLimiter(1, time.Minute),
+	})
+
+	err := errors.New("connection refused")
+	for i := 0; i < 3; i++ {
+		logger.WithError(err).Error("Export failed")
+		logger.Info("tick")
+	}
+	logger.WithError(errors.New("timeout")).Error("Export failed")
+
+	assert.Equal(t, 1, bytes.Count(out.Bytes(), []byte("connection refused")))
+	assert.Equal(t, 1, bytes.Count(out.Bytes(), []byte("timeout")))
+	assert.Equal(t, 3, bytes.Count(out.Bytes(), []byte("tick")), "info lines are not limited")
+}
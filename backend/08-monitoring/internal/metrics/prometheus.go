@@ -0,0 +1,289 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/logging"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+)
+
+// PrometheusMetrics holds the collectors updated by the monitoring middleware
+type PrometheusMetrics struct {
+	Registry        *prometheus.Registry
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	requestSize     *prometheus.HistogramVec
+	responseSize    *prometheus.HistogramVec
+	inFlight        prometheus.Gauge
+	exemplars       bool
+}
+
+// NewPrometheusMetrics creates a registry with HTTP (RED) metrics plus the
+// standard Go runtime and process collectors
+func NewPrometheusMetrics() *PrometheusMetrics {
+	registry := prometheus.NewRegistry()
+	sizeBuckets := prometheus.ExponentialBuckets(100, 10, 6) // 100B .. 10MB
+
+	m := &PrometheusMetrics{
+		Registry: registry,
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method and route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_size_bytes",
+			Help:    "HTTP request body size by method and route.",
+			Buckets: sizeBuckets,
+		}, []string{"method", "route"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "HTTP response body size by method and route.",
+			Buckets: sizeBuckets,
+		}, []string{"method", "route"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests currently being served.",
+		}),
+	}
+
+	registry.MustRegister(
+		m.requestsTotal,
+		m.requestDuration,
+		m.requestSize,
+		m.responseSize,
+		m.inFlight,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// MetricFamilies returns the name and lowercase type ("counter", "histogram",
+// ...) of every registered metric. Vectors only show up in a gather once they
+// have a series, so the HTTP ones are always listed. On a gather error the
+// families that could be collected are still returned.
+func (m *PrometheusMetrics) MetricFamilies() (map[string]string, error) {
+	families := map[string]string{
+		"http_requests_total":           "counter",
+		"http_request_duration_seconds": "histogram",
+		"http_request_size_bytes":       "histogram",
+		"http_response_size_bytes":      "histogram",
+	}
+
+	// Gather returns what it could collect alongside any error
+	gathered, err := m.Registry.Gather()
+	for _, family := range gathered {
+		families[family.GetName()] = strings.ToLower(family.GetType().String())
+	}
+	return families, err
+}
+
+// SetExemplars attaches the trace ID of sampled requests to latency
+// observations as an exemplar, so a slow bucket in Grafana links straight
+// to a trace. Only turn it on when traces are exported somewhere.
+func (m *PrometheusMetrics) SetExemplars(enabled bool) {
+	m.exemplars = enabled
+}
+
+// RequestStarted marks a request as in flight
+func (m *PrometheusMetrics) RequestStarted() {
+	m.inFlight.Inc()
+}
+
+// RequestFinished records a completed request. route must be a template
+// (e.g. /api/users/{id}), never a raw path, to keep label cardinality bounded.
+// traceID, if not empty, becomes the latency exemplar.
+func (m *PrometheusMetrics) RequestFinished(method, route string, status int, duration time.Duration, requestSize, responseSize int64, traceID string) {
+	m.inFlight.Dec()
+	m.requestsTotal.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+
+	observer := m.requestDuration.WithLabelValues(method, route)
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && m.exemplars && traceID != "" {
+		exemplarObserver.ObserveWithExemplar(duration.Seconds(), prometheus.Labels{"trace_id": traceID})
+	} else {
+		observer.Observe(duration.Seconds())
+	}
+	m.requestSize.WithLabelValues(method, route).Observe(float64(requestSize))
+	m.responseSize.WithLabelValues(method, route).Observe(float64(responseSize))
+}
+
+// RegisterSystemMetrics exposes the sampled CPU and GC figures. RSS and open
+// file descriptors are already covered by the process collector.
+func (m *PrometheusMetrics) RegisterSystemMetrics(source func() models.SystemMetrics) {
+	m.Registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "process_cpu_usage_percent",
+			Help: "CPU used by this process over the last sampling interval (100 = one core).",
+		}, func() float64 { return source().CPUUsage }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "system_cpu_usage_percent",
+			Help: "CPU used by the whole host over the last sampling interval.",
+		}, func() float64 { return source().SystemCPUUsage }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "go_gc_last_pause_seconds",
+			Help: "Duration of the most recent garbage collection pause.",
+		}, func() float64 { return source().GC.LastPauseMs / 1000 }),
+	)
+}
+
+// RegisterBuildInfo exposes a constant build_info gauge whose labels identify
+// the binary, so dashboards can annotate deploys and join on version
+func (m *PrometheusMetrics) RegisterBuildInfo(info models.BuildInfo) {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "build_info",
+		Help: "Always 1; labels describe the running build.",
+		ConstLabels: prometheus.Labels{
+			"version":    info.Version,
+			"commit":     info.Commit,
+			"build_time": info.BuildTime,
+			"go_version": info.GoVersion,
+		},
+	})
+	gauge.Set(1)
+	m.Registry.MustRegister(gauge)
+}
+
+// RegisterLogMetrics exposes how many log lines sampling and rate limiting
+// dropped, so a quiet log can be told apart from a quiet service
+func (m *PrometheusMetrics) RegisterLogMetrics(source func() logging.SuppressionStats) {
+	m.Registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "log_lines_suppressed_total",
+			Help:        "Log lines not written, by reason.",
+			ConstLabels: prometheus.Labels{"reason": "sampled"},
+		}, func() float64 { return float64(source().SampledOut) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "log_lines_suppressed_total",
+			Help:        "Log lines not written, by reason.",
+			ConstLabels: prometheus.Labels{"reason": "rate_limited"},
+		}, func() float64 { return float64(source().RateLimited) }),
+	)
+}
+
+// RegisterCustomDistributions exposes custom histogram and summary metrics
+// with the buckets or quantiles they were registered with
+func (m *PrometheusMetrics) RegisterCustomDistributions(source func() []models.Distribution) {
+	m.Registry.MustRegister(&distributionCollector{source: source})
+}
+
+// distributionCollector turns the repository's distributions into const
+// metrics on every scrape. It describes nothing up front, which makes it an
+// unchecked collector: custom metric names are only known at runtime.
+type distributionCollector struct {
+	source func() []models.Distribution
+}
+
+func (c *distributionCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c *distributionCollector) Collect(ch chan<- prometheus.Metric) {
+	distributions := c.source()
+
+	// Every series of a metric must share label names, so use the union and
+	// leave labels a series lacks empty
+	labelNames := make(map[string][]string)
+	for _, d := range distributions {
+		seen := make(map[string]bool)
+		for _, name := range labelNames[d.Name] {
+			seen[name] = true
+		}
+		for k := range d.Labels {
+			if !seen[k] {
+				labelNames[d.Name] = append(labelNames[d.Name], k)
+			}
+		}
+	}
+	for _, names := range labelNames {
+		sort.Strings(names)
+	}
+
+	for _, d := range distributions {
+		names := labelNames[d.Name]
+		values := make([]string, len(names))
+		for i, k := range names {
+			values[i] = d.Labels[k]
+		}
+
+		help := d.Help
+		if help == "" {
+			help = "Custom " + d.Type + " " + d.Name + "."
+		}
+		desc := prometheus.NewDesc(d.Name, help, names, nil)
+
+		var metric prometheus.Metric
+		var err error
+		if d.Type == "histogram" {
+			buckets := make(map[float64]uint64, len(d.Buckets))
+			for _, b := range d.Buckets {
+				buckets[b.UpperBound] = b.Count
+			}
+			metric, err = prometheus.NewConstHistogram(desc, d.Count, d.Sum, buckets, values...)
+		} else {
+			quantiles := make(map[float64]float64, len(d.Quantiles))
+			for _, q := range d.Quantiles {
+				quantiles[q.Quantile] = q.Value
+			}
+			metric, err = prometheus.NewConstSummary(desc, d.Count, d.Sum, quantiles, values...)
+		}
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(desc, err)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+// RegisterSyntheticMetrics exposes the result of every synthetic check, so
+// external endpoints can be alerted on like any other target
+func (m *PrometheusMetrics) RegisterSyntheticMetrics(source func() []models.SyntheticStatus) {
+	labels := []string{"check", "url"}
+	m.Registry.MustRegister(&syntheticCollector{
+		source: source,
+		up:     prometheus.NewDesc("synthetic_check_up", "1 if the last probe of the check succeeded.", labels, nil),
+		latency: prometheus.NewDesc("synthetic_check_duration_seconds",
+			"Duration of the last probe of the check.", labels, nil),
+		probes: prometheus.NewDesc("synthetic_check_probes_total", "Probes sent, by result.",
+			append(labels, "result"), nil),
+	})
+}
+
+// syntheticCollector reads check statuses on every scrape; checks come and
+// go through the API, so their series cannot be registered up front
+type syntheticCollector struct {
+	source              func() []models.SyntheticStatus
+	up, latency, probes *prometheus.Desc
+}
+
+func (c *syntheticCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+	ch <- c.latency
+	ch <- c.probes
+}
+
+func (c *syntheticCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.source() {
+		if s.LastResult == nil {
+			continue // not probed yet
+		}
+
+		up := 0.0
+		if s.Up {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, up, s.Name, s.URL)
+		ch <- prometheus.MustNewConstMetric(c.latency, prometheus.GaugeValue, s.LastResult.LatencyMs/1000, s.Name, s.URL)
+		ch <- prometheus.MustNewConstMetric(c.probes, prometheus.CounterValue, float64(s.Probes-s.Failures), s.Name, s.URL, "success")
+		ch <- prometheus.MustNewConstMetric(c.probes, prometheus.CounterValue, float64(s.Failures), s.Name, s.URL, "failure")
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// latencyExemplars returns the trace IDs attached to the latency buckets
+func latencyExemplars(t *testing.T, m *PrometheusMetrics) []string {
+	t.Helper()
+	families, err := m.Registry.Gather()
+	require.NoError(t, err)
+
+	var traceIDs []string
+	for _, family := range families {
+		if family.GetName() != "http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, bucket := range metric.GetHistogram().GetBucket() {
+				for _, label := range bucket.GetExemplar().GetLabel() {
+					traceIDs = append(traceIDs, label.GetValue())
+				}
+			}
+		}
+	}
+	return traceIDs
+}
+
+func TestRequestFinished_Exemplars(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	m := NewPrometheusMetrics()
+	m.RequestStarted()
+	m.RequestFinished("GET", "/api/demo", 200, 30*time.Millisecond, 0, 10, traceID)
+	assert.Empty(t, latencyExemplars(t, m), "off unless enabled")
+
+	m = NewPrometheusMetrics()
+	m.SetExemplars(true)
+	m.RequestStarted()
+	m.RequestFinished("GET", "/api/demo", 200, 30*time.Millisecond, 0, 10, "")
+	assert.Empty(t, latencyExemplars(t, m), "unsampled requests have no trace to link")
+
+	m.RequestStarted()
+	m.RequestFinished("GET", "/api/demo", 200, 30*time.Millisecond, 0, 10, traceID)
+	assert.Equal(t, []string{traceID}, latencyExemplars(t, m))
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// AdminAuth only lets requests through that present token, either as
+// "Authorization: Bearer <token>" or as the password of HTTP basic auth
+// (any user name), so browsers and `go tool pprof` can both use it
+func AdminAuth(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !validAdminCredentials(r, token) {
+				w.Header().Set("WWW-Authenticate", `Basic realm="debug"`)
+				response.Error(w, http.StatusUnauthorized, "Admin credentials required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func validAdminCredentials(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	presented := ""
+	if _, password, ok := r.BasicAuth(); ok {
+		presented = password
+	} else if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		presented = bearer
+	}
+
+	return subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
@@ -1,24 +1,31 @@
 package middleware
 
 import (
-	"log"
 	"net"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/logging"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/metrics"
 	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
 	"github.com/e6a5/learning/backend/08-monitoring/internal/repository"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/tracing"
+	sharedmw "github.com/e6a5/learning/backend/pkg/middleware"
 )
 
 // MonitoringMiddleware wraps HTTP handlers to collect metrics
 type MonitoringMiddleware struct {
 	repo *repository.MetricsRepository
+	prom *metrics.PrometheusMetrics
 }
 
 // NewMonitoringMiddleware creates a new monitoring middleware
-func NewMonitoringMiddleware(repo *repository.MetricsRepository) *MonitoringMiddleware {
-	return &MonitoringMiddleware{repo: repo}
+func NewMonitoringMiddleware(repo *repository.MetricsRepository, prom *metrics.PrometheusMetrics) *MonitoringMiddleware {
+	return &MonitoringMiddleware{repo: repo, prom: prom}
 }
 
 // responseWriter wraps http.ResponseWriter to capture response data
@@ -43,6 +50,7 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 func (m *MonitoringMiddleware) Wrap(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		m.prom.RequestStarted()
 
 		// Wrap the response writer to capture metrics
 		wrapped := &responseWriter{
@@ -62,10 +70,13 @@ func (m *MonitoringMiddleware) Wrap(next http.Handler) http.Handler {
 		// Calculate duration
 		duration := time.Since(start)
 
+		route := routeLabel(r)
+		m.prom.RequestFinished(r.Method, route, wrapped.statusCode, duration, requestSize, wrapped.responseSize, tracing.SampledTraceID(r.Context()))
+
 		// Create request metrics
-		metrics := models.RequestMetrics{
+		requestMetrics := models.RequestMetrics{
 			Method:       r.Method,
-			Path:         cleanPath(r.URL.Path),
+			Path:         route,
 			StatusCode:   wrapped.statusCode,
 			Duration:     duration,
 			RequestSize:  requestSize,
@@ -76,16 +87,23 @@ func (m *MonitoringMiddleware) Wrap(next http.Handler) http.Handler {
 		}
 
 		// Record metrics
-		if err := m.repo.RecordRequest(metrics); err != nil {
-			log.Printf("Error recording request metrics: %v", err)
+		if err := m.repo.RecordRequest(requestMetrics); err != nil {
+			logging.FromContext(r.Context()).WithError(err).Error("Error recording request metrics")
 		}
-
-		// Log structured request information
-		log.Printf("REQUEST: %s %s | Status: %d | Duration: %v | Size: %d bytes",
-			metrics.Method, metrics.Path, metrics.StatusCode, metrics.Duration, metrics.ResponseSize)
 	})
 }
 
+// routeLabel returns the matched mux route template so that /users/1 and
+// /users/2 share one series; unmatched requests fall back to the cleaned path
+func routeLabel(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return cleanPath(r.URL.Path)
+}
+
 // cleanPath removes parameters from path for consistent metrics
 func cleanPath(path string) string {
 	// Remove query parameters
@@ -130,23 +148,34 @@ func getRemoteIP(r *http.Request) string {
 	return r.RemoteAddr
 }
 
-// CorsMiddleware handles CORS headers
+// CorsMiddleware answers CORS through the shared middleware, letting clients
+// send trace context and request IDs, and read back the IDs this service sets
 func CorsMiddleware(next http.Handler) http.Handler {
+	cors := sharedmw.CORS("traceparent", "tracestate", logging.RequestIDHeader)(next)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Expose-Headers", tracing.TraceIDHeader+", "+logging.RequestIDHeader)
+		cors.ServeHTTP(w, r)
+	})
+}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+// RequestIDMiddleware gives every request an ID, keeping a valid incoming
+// X-Request-ID so IDs can follow a request across services
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(logging.RequestIDHeader)
+		if !logging.ValidRequestID(id) {
+			id = logging.NewRequestID()
 		}
+		w.Header().Set(logging.RequestIDHeader, id)
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
 	})
 }
 
-// LoggingMiddleware logs all requests in a structured format
+// LoggingMiddleware writes one structured access log line per request:
+// info for successes, warn for 4xx and error for 5xx. Successes may be
+// sampled, see logging.Config.AccessSampleRate. It is not pkg/middleware's
+// Logging because it logs through logrus with the request ID and trace.
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -158,12 +187,27 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 		next.ServeHTTP(wrapped, r)
 
-		log.Printf("ACCESS: %s %s %d %v %s",
-			r.Method,
-			r.URL.Path,
-			wrapped.statusCode,
-			time.Since(start),
-			r.RemoteAddr,
-		)
+		if !logging.KeepAccessLog(wrapped.statusCode) {
+			return
+		}
+
+		entry := logging.AccessFromContext(r.Context(), wrapped.statusCode).WithFields(logrus.Fields{
+			"method":         r.Method,
+			"route":          routeLabel(r),
+			"path":           r.URL.Path,
+			"status":         wrapped.statusCode,
+			"latency_ms":     float64(time.Since(start).Microseconds()) / 1000,
+			"response_bytes": wrapped.responseSize,
+			"remote_ip":      getRemoteIP(r),
+		})
+
+		switch {
+		case wrapped.statusCode >= 500:
+			entry.Error("Request failed")
+		case wrapped.statusCode >= 400:
+			entry.Warn("Request rejected")
+		default:
+			entry.Info("Request served")
+		}
 	})
 }
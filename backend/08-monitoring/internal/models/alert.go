@@ -0,0 +1,128 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// AlertMetric names a value the alerting engine can evaluate
+type AlertMetric string
+
+const (
+	AlertMetricErrorRate   AlertMetric = "error_rate_percent" // 5xx share of requests in the last interval
+	AlertMetricRequestRate AlertMetric = "request_rate"       // requests per second in the last interval
+	AlertMetricLatencyP50  AlertMetric = "latency_p50_ms"
+	AlertMetricLatencyP90  AlertMetric = "latency_p90_ms"
+	AlertMetricLatencyP99  AlertMetric = "latency_p99_ms"
+	AlertMetricCPU         AlertMetric = "cpu_usage_percent"
+	AlertMetricMemory      AlertMetric = "memory_usage_bytes"
+	AlertMetricGoroutines  AlertMetric = "goroutine_count"
+)
+
+var alertMetrics = map[AlertMetric]bool{
+	AlertMetricErrorRate:   true,
+	AlertMetricRequestRate: true,
+	AlertMetricLatencyP50:  true,
+	AlertMetricLatencyP90:  true,
+	AlertMetricLatencyP99:  true,
+	AlertMetricCPU:         true,
+	AlertMetricMemory:      true,
+	AlertMetricGoroutines:  true,
+}
+
+// AlertState is where a rule is in its lifecycle
+type AlertState string
+
+const (
+	AlertStateInactive AlertState = "inactive"
+	AlertStatePending  AlertState = "pending" // condition true, waiting out the "for" duration
+	AlertStateFiring   AlertState = "firing"
+)
+
+// AlertRule fires when Metric compares true against Threshold for at least
+// ForSeconds. Route narrows request metrics to one "METHOD:/route" key.
+type AlertRule struct {
+	ID              string      `json:"id"`
+	Name            string      `json:"name"`
+	Metric          AlertMetric `json:"metric"`
+	Route           string      `json:"route,omitempty"`
+	Operator        string      `json:"operator"` // >, >=, <, <=
+	Threshold       float64     `json:"threshold"`
+	ForSeconds      int         `json:"for_seconds"`
+	CooldownSeconds int         `json:"cooldown_seconds"`
+	WebhookURL      string      `json:"webhook_url,omitempty"`
+	Enabled         bool        `json:"enabled"`
+}
+
+// Alert is the current state of one rule, also sent to notifiers
+type Alert struct {
+	RuleID     string      `json:"rule_id"`
+	RuleName   string      `json:"rule_name"`
+	Metric     AlertMetric `json:"metric"`
+	State      AlertState  `json:"state"`
+	Value      float64     `json:"value"`
+	Threshold  float64     `json:"threshold"`
+	Since      time.Time   `json:"since"`
+	FiredAt    time.Time   `json:"fired_at"`
+	ResolvedAt time.Time   `json:"resolved_at"`
+	NotifiedAt time.Time   `json:"notified_at"`
+}
+
+// Validate validates an alert rule
+func (r AlertRule) Validate() error {
+	if r.Name == "" {
+		return &validation.Error{Field: "name", Message: "Rule name is required"}
+	}
+	if len(r.Name) > 100 {
+		return &validation.Error{Field: "name", Message: "Rule name must be less than 100 characters"}
+	}
+	if !alertMetrics[r.Metric] {
+		return &validation.Error{Field: "metric", Message: fmt.Sprintf("Unknown metric %q", r.Metric)}
+	}
+	switch r.Operator {
+	case ">", ">=", "<", "<=":
+	default:
+		return &validation.Error{Field: "operator", Message: "Operator must be >, >=, < or <="}
+	}
+	if r.ForSeconds < 0 {
+		return &validation.Error{Field: "for_seconds", Message: "Duration cannot be negative"}
+	}
+	if r.CooldownSeconds < 0 {
+		return &validation.Error{Field: "cooldown_seconds", Message: "Cooldown cannot be negative"}
+	}
+	if r.WebhookURL != "" {
+		u, err := url.Parse(r.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &validation.Error{Field: "webhook_url", Message: "Webhook URL must be an absolute http(s) URL"}
+		}
+	}
+	return nil
+}
+
+// Breached reports whether value violates the rule's threshold
+func (r AlertRule) Breached(value float64) bool {
+	switch r.Operator {
+	case ">":
+		return value > r.Threshold
+	case ">=":
+		return value >= r.Threshold
+	case "<":
+		return value < r.Threshold
+	case "<=":
+		return value <= r.Threshold
+	}
+	return false
+}
+
+// For returns how long the condition must hold before the rule fires
+func (r AlertRule) For() time.Duration {
+	return time.Duration(r.ForSeconds) * time.Second
+}
+
+// Cooldown returns the minimum gap between two notifications for the rule
+func (r AlertRule) Cooldown() time.Duration {
+	return time.Duration(r.CooldownSeconds) * time.Second
+}
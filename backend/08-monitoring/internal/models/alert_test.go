@@ -0,0 +1,101 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertRule_Validate(t *testing.T) {
+	valid := AlertRule{
+		Name:      "High error rate",
+		Metric:    AlertMetricErrorRate,
+		Operator:  ">",
+		Threshold: 5,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(r *AlertRule)
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:   "valid rule",
+			modify: func(r *AlertRule) {},
+		},
+		{
+			name:   "valid rule with webhook",
+			modify: func(r *AlertRule) { r.WebhookURL = "https://hooks.example.com/alerts" },
+		},
+		{
+			name:    "empty name",
+			modify:  func(r *AlertRule) { r.Name = "" },
+			wantErr: true,
+			errMsg:  "Rule name is required",
+		},
+		{
+			name:    "unknown metric",
+			modify:  func(r *AlertRule) { r.Metric = "disk_usage" },
+			wantErr: true,
+			errMsg:  "Unknown metric",
+		},
+		{
+			name:    "invalid operator",
+			modify:  func(r *AlertRule) { r.Operator = "==" },
+			wantErr: true,
+			errMsg:  "Operator must be",
+		},
+		{
+			name:    "negative duration",
+			modify:  func(r *AlertRule) { r.ForSeconds = -1 },
+			wantErr: true,
+			errMsg:  "Duration cannot be negative",
+		},
+		{
+			name:    "relative webhook URL",
+			modify:  func(r *AlertRule) { r.WebhookURL = "/alerts" },
+			wantErr: true,
+			errMsg:  "Webhook URL must be an absolute http(s) URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := valid
+			tt.modify(&rule)
+
+			err := rule.Validate()
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAlertRule_Breached(t *testing.T) {
+	tests := []struct {
+		operator string
+		value    float64
+		want     bool
+	}{
+		{">", 5.1, true},
+		{">", 5, false},
+		{">=", 5, true},
+		{"<", 4.9, true},
+		{"<", 5, false},
+		{"<=", 5, true},
+		{"??", 100, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.operator, func(t *testing.T) {
+			rule := AlertRule{Operator: tt.operator, Threshold: 5}
+			assert.Equal(t, tt.want, rule.Breached(tt.value))
+		})
+	}
+}
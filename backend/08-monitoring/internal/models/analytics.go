@@ -0,0 +1,197 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// SizeBuckets are the bounds for request and response body sizes, 64B to
+// 16MB in steps of four
+var SizeBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216}
+
+// StatusClasses are the keys of RouteTraffic.StatusClasses
+var StatusClasses = []string{"1xx", "2xx", "3xx", "4xx", "5xx"}
+
+// StatusClass returns "2xx" for 204 and so on; codes outside 100-599 are ""
+func StatusClass(code int) string {
+	if code < 100 || code > 599 {
+		return ""
+	}
+	return StatusClasses[code/100-1]
+}
+
+// RouteTraffic is everything recorded for one "METHOD:/route" key. The size
+// histograms are copies owned by the caller.
+type RouteTraffic struct {
+	Route         string
+	Requests      int64
+	StatusClasses map[string]int64
+	Latency       LatencyStats
+	RequestSize   *BucketHistogram
+	ResponseSize  *BucketHistogram
+}
+
+// SizeStats summarizes body sizes in bytes
+type SizeStats struct {
+	Count   uint64        `json:"count"`
+	Avg     float64       `json:"avg_bytes"`
+	Max     float64       `json:"max_bytes"`
+	P50     float64       `json:"p50_bytes"`
+	P90     float64       `json:"p90_bytes"`
+	P99     float64       `json:"p99_bytes"`
+	Buckets []BucketCount `json:"buckets"` // cumulative, Prometheus style
+}
+
+// RouteLatency ranks a route by latency
+type RouteLatency struct {
+	Route    string       `json:"route"`
+	Requests int64        `json:"requests"`
+	Latency  LatencyStats `json:"latency"`
+}
+
+// RouteErrors ranks a route by error rate; client and server errors both count
+type RouteErrors struct {
+	Route        string  `json:"route"`
+	Requests     int64   `json:"requests"`
+	ClientErrors int64   `json:"client_errors"`
+	ServerErrors int64   `json:"server_errors"`
+	ErrorRate    float64 `json:"error_rate_percent"`
+}
+
+// RouteSizes holds one route's body size distributions
+type RouteSizes struct {
+	Route    string    `json:"route"`
+	Request  SizeStats `json:"request"`
+	Response SizeStats `json:"response"`
+}
+
+// TrafficAnalytics summarizes recorded traffic across routes
+type TrafficAnalytics struct {
+	TotalRequests int64            `json:"total_requests"`
+	Routes        int              `json:"routes"`
+	StatusClasses map[string]int64 `json:"status_classes"`
+	Slowest       []RouteLatency   `json:"slowest_routes"`     // by p99
+	HighestErrors []RouteErrors    `json:"highest_error_rate"` // routes with at least one error
+	RequestSizes  SizeStats        `json:"request_sizes"`
+	ResponseSizes SizeStats        `json:"response_sizes"`
+	LargestRoutes []RouteSizes     `json:"largest_responses"` // by p90 response size
+	MinRequests   int64            `json:"min_requests"`
+	GeneratedAt   time.Time        `json:"generated_at"`
+}
+
+// Analyze ranks routes and keeps the top n of each list. Routes with fewer
+// than minRequests requests are left out of the rankings, so one failed call
+// to a rare route does not top the error list; they still count in the
+// totals.
+func Analyze(routes []RouteTraffic, n int, minRequests int64) TrafficAnalytics {
+	analytics := TrafficAnalytics{
+		Routes:        len(routes),
+		StatusClasses: make(map[string]int64, len(StatusClasses)),
+		Slowest:       []RouteLatency{},
+		HighestErrors: []RouteErrors{},
+		LargestRoutes: []RouteSizes{},
+		MinRequests:   minRequests,
+		GeneratedAt:   time.Now(),
+	}
+	for _, class := range StatusClasses {
+		analytics.StatusClasses[class] = 0
+	}
+
+	requestSizes := NewBucketHistogram(SizeBuckets)
+	responseSizes := NewBucketHistogram(SizeBuckets)
+
+	for _, route := range routes {
+		analytics.TotalRequests += route.Requests
+		for class, count := range route.StatusClasses {
+			analytics.StatusClasses[class] += count
+		}
+		requestSizes.Merge(route.RequestSize)
+		responseSizes.Merge(route.ResponseSize)
+
+		if route.Requests < minRequests {
+			continue
+		}
+
+		analytics.Slowest = append(analytics.Slowest, RouteLatency{
+			Route:    route.Route,
+			Requests: route.Requests,
+			Latency:  route.Latency,
+		})
+
+		clientErrors, serverErrors := route.StatusClasses["4xx"], route.StatusClasses["5xx"]
+		if clientErrors+serverErrors > 0 {
+			analytics.HighestErrors = append(analytics.HighestErrors, RouteErrors{
+				Route:        route.Route,
+				Requests:     route.Requests,
+				ClientErrors: clientErrors,
+				ServerErrors: serverErrors,
+				ErrorRate:    math.Round(float64(clientErrors+serverErrors)/float64(route.Requests)*10000) / 100,
+			})
+		}
+
+		analytics.LargestRoutes = append(analytics.LargestRoutes, RouteSizes{
+			Route:    route.Route,
+			Request:  route.RequestSize.SizeStats(),
+			Response: route.ResponseSize.SizeStats(),
+		})
+	}
+
+	// Ties go to the busier route, then by name, so the order is stable
+	sort.Slice(analytics.Slowest, func(i, j int) bool {
+		a, b := analytics.Slowest[i], analytics.Slowest[j]
+		if a.Latency.P99 != b.Latency.P99 {
+			return a.Latency.P99 > b.Latency.P99
+		}
+		return busier(a.Requests, b.Requests, a.Route, b.Route)
+	})
+	sort.Slice(analytics.HighestErrors, func(i, j int) bool {
+		a, b := analytics.HighestErrors[i], analytics.HighestErrors[j]
+		if a.ErrorRate != b.ErrorRate {
+			return a.ErrorRate > b.ErrorRate
+		}
+		return busier(a.Requests, b.Requests, a.Route, b.Route)
+	})
+	sort.Slice(analytics.LargestRoutes, func(i, j int) bool {
+		a, b := analytics.LargestRoutes[i], analytics.LargestRoutes[j]
+		if a.Response.P90 != b.Response.P90 {
+			return a.Response.P90 > b.Response.P90
+		}
+		return a.Route < b.Route
+	})
+
+	analytics.Slowest = top(analytics.Slowest, n)
+	analytics.HighestErrors = top(analytics.HighestErrors, n)
+	analytics.LargestRoutes = top(analytics.LargestRoutes, n)
+	analytics.RequestSizes = requestSizes.SizeStats()
+	analytics.ResponseSizes = responseSizes.SizeStats()
+	return analytics
+}
+
+// SizeStats summarizes a histogram of body sizes
+func (h *BucketHistogram) SizeStats() SizeStats {
+	stats := SizeStats{Count: h.count, Buckets: h.Cumulative()}
+	if h.count == 0 {
+		return stats
+	}
+	stats.Avg = math.Round(h.sum / float64(h.count))
+	stats.Max = h.max
+	stats.P50 = math.Round(h.Quantile(0.5))
+	stats.P90 = math.Round(h.Quantile(0.9))
+	stats.P99 = math.Round(h.Quantile(0.99))
+	return stats
+}
+
+func busier(a, b int64, routeA, routeB string) bool {
+	if a != b {
+		return a > b
+	}
+	return routeA < routeB
+}
+
+func top[T any](items []T, n int) []T {
+	if n > 0 && len(items) > n {
+		return items[:n]
+	}
+	return items
+}
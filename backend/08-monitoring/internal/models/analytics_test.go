@@ -0,0 +1,79 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func routeTraffic(route string, p99 float64, classes map[string]int64, responseBytes ...float64) RouteTraffic {
+	rt := RouteTraffic{
+		Route:         route,
+		StatusClasses: classes,
+		Latency:       LatencyStats{P99: p99},
+		RequestSize:   NewBucketHistogram(SizeBuckets),
+		ResponseSize:  NewBucketHistogram(SizeBuckets),
+	}
+	for _, n := range classes {
+		rt.Requests += n
+	}
+	for _, b := range responseBytes {
+		rt.ResponseSize.Observe(b)
+	}
+	return rt
+}
+
+func TestAnalyze(t *testing.T) {
+	routes := []RouteTraffic{
+		routeTraffic("GET:/api/users", 40, map[string]int64{"2xx": 95, "4xx": 5}, 2000, 3000),
+		routeTraffic("GET:/api/report", 900, map[string]int64{"2xx": 8, "5xx": 2}, 500000),
+		routeTraffic("POST:/api/orders", 120, map[string]int64{"2xx": 50}, 100),
+		routeTraffic("GET:/api/rare", 5000, map[string]int64{"5xx": 1}),
+	}
+
+	a := Analyze(routes, 2, 2)
+
+	assert.Equal(t, int64(161), a.TotalRequests)
+	assert.Equal(t, 4, a.Routes)
+	assert.Equal(t, map[string]int64{"1xx": 0, "2xx": 153, "3xx": 0, "4xx": 5, "5xx": 3}, a.StatusClasses)
+
+	require.Len(t, a.Slowest, 2, "top n, rare route below min_requests")
+	assert.Equal(t, "GET:/api/report", a.Slowest[0].Route)
+	assert.Equal(t, "POST:/api/orders", a.Slowest[1].Route)
+
+	require.Len(t, a.HighestErrors, 2, "only routes with errors")
+	assert.Equal(t, RouteErrors{Route: "GET:/api/report", Requests: 10, ServerErrors: 2, ErrorRate: 20}, a.HighestErrors[0])
+	assert.Equal(t, 5.0, a.HighestErrors[1].ErrorRate)
+
+	assert.Equal(t, "GET:/api/report", a.LargestRoutes[0].Route)
+	assert.Equal(t, uint64(4), a.ResponseSizes.Count)
+	assert.Equal(t, 500000.0, a.ResponseSizes.Max)
+}
+
+func TestBucketHistogram_Quantile(t *testing.T) {
+	h := NewBucketHistogram([]float64{10, 100})
+	assert.Equal(t, 0.0, h.Quantile(0.5))
+
+	for i := 0; i < 50; i++ {
+		h.Observe(5)
+	}
+	for i := 0; i < 50; i++ {
+		h.Observe(50)
+	}
+	assert.Equal(t, 10.0, h.Quantile(0.5))
+	assert.InDelta(t, 42, h.Quantile(0.9), 0.01, "last bucket ends at the largest value seen, not 100")
+
+	other := NewBucketHistogram([]float64{10, 100})
+	other.Observe(1000)
+	h.Merge(other)
+	assert.Equal(t, uint64(101), h.Count())
+	assert.Equal(t, 1000.0, h.SizeStats().Max)
+}
+
+func TestStatusClass(t *testing.T) {
+	assert.Equal(t, "2xx", StatusClass(204))
+	assert.Equal(t, "5xx", StatusClass(599))
+	assert.Equal(t, "", StatusClass(99))
+	assert.Equal(t, "", StatusClass(600))
+}
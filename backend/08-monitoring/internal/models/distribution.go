@@ -0,0 +1,229 @@
+package models
+
+import (
+	"math"
+	"regexp"
+	"sort"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// DefaultBuckets are used for histograms recorded without a definition; they
+// match the Prometheus client defaults, sized for latencies in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultObjectives are the quantiles reported for summaries recorded without
+// a definition
+var DefaultObjectives = []float64{0.5, 0.9, 0.99}
+
+var metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// MetricDefinition registers a custom metric ahead of its first point, fixing
+// its type and, for distributions, its bucket bounds or quantiles
+type MetricDefinition struct {
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Help       string    `json:"help,omitempty"`
+	Buckets    []float64 `json:"buckets,omitempty"`    // histogram upper bounds, ascending
+	Objectives []float64 `json:"objectives,omitempty"` // summary quantiles, 0..1
+}
+
+// Validate validates a metric definition
+func (d MetricDefinition) Validate() error {
+	if !metricNamePattern.MatchString(d.Name) {
+		return &validation.Error{Field: "name", Message: "Metric name must match [a-zA-Z_:][a-zA-Z0-9_:]*"}
+	}
+	if !validMetricTypes[d.Type] {
+		return &validation.Error{Field: "type", Message: "Metric type must be counter, gauge, histogram, or summary"}
+	}
+	if len(d.Buckets) > 0 && d.Type != "histogram" {
+		return &validation.Error{Field: "buckets", Message: "Buckets only apply to histograms"}
+	}
+	if len(d.Objectives) > 0 && d.Type != "summary" {
+		return &validation.Error{Field: "objectives", Message: "Objectives only apply to summaries"}
+	}
+	for i, b := range d.Buckets {
+		if math.IsNaN(b) || math.IsInf(b, 0) {
+			return &validation.Error{Field: "buckets", Message: "Buckets must be finite; +Inf is implicit"}
+		}
+		if i > 0 && b <= d.Buckets[i-1] {
+			return &validation.Error{Field: "buckets", Message: "Buckets must be strictly increasing"}
+		}
+	}
+	for i, q := range d.Objectives {
+		if q <= 0 || q >= 1 {
+			return &validation.Error{Field: "objectives", Message: "Objectives must be between 0 and 1 exclusive"}
+		}
+		if i > 0 && q <= d.Objectives[i-1] {
+			return &validation.Error{Field: "objectives", Message: "Objectives must be strictly increasing"}
+		}
+	}
+	return nil
+}
+
+// WithDefaults fills in default buckets or objectives when none were given
+func (d MetricDefinition) WithDefaults() MetricDefinition {
+	switch {
+	case d.Type == "histogram" && len(d.Buckets) == 0:
+		d.Buckets = DefaultBuckets
+	case d.Type == "summary" && len(d.Objectives) == 0:
+		d.Objectives = DefaultObjectives
+	}
+	return d
+}
+
+// Equal reports whether two definitions describe the same metric
+func (d MetricDefinition) Equal(other MetricDefinition) bool {
+	return d.Name == other.Name && d.Type == other.Type && d.Help == other.Help &&
+		floatsEqual(d.Buckets, other.Buckets) && floatsEqual(d.Objectives, other.Objectives)
+}
+
+func floatsEqual(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// BucketHistogram counts observations into fixed upper bounds. With no
+// bounds it only tracks count and sum, which is all a summary needs.
+type BucketHistogram struct {
+	bounds []float64
+	counts []uint64 // per bucket, not cumulative; last is +Inf
+	count  uint64
+	sum    float64
+	max    float64
+}
+
+// NewBucketHistogram creates a histogram with the given ascending bounds
+func NewBucketHistogram(bounds []float64) *BucketHistogram {
+	return &BucketHistogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+// Observe records one value
+func (h *BucketHistogram) Observe(v float64) {
+	h.counts[sort.SearchFloat64s(h.bounds, v)]++
+	if h.count == 0 || v > h.max {
+		h.max = v
+	}
+	h.count++
+	h.sum += v
+}
+
+// Merge adds other's observations to h; both must share the same bounds
+func (h *BucketHistogram) Merge(other *BucketHistogram) {
+	if other.count == 0 {
+		return
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	if h.count == 0 || other.max > h.max {
+		h.max = other.max
+	}
+	h.count += other.count
+	h.sum += other.sum
+}
+
+// Quantile estimates the q-th quantile (0..1) by interpolating inside the
+// bucket that contains it; the +Inf bucket reaches up to the largest value
+// observed
+func (h *BucketHistogram) Quantile(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+
+	rank := q * float64(h.count)
+	var cumulative uint64
+	for i, c := range h.counts {
+		if c == 0 || float64(cumulative+c) < rank {
+			cumulative += c
+			continue
+		}
+
+		lower := 0.0
+		if i > 0 {
+			lower = h.bounds[i-1]
+		}
+		upper := h.max
+		if i < len(h.bounds) {
+			upper = math.Min(h.bounds[i], h.max)
+		}
+
+		fraction := (rank - float64(cumulative)) / float64(c)
+		return lower + (upper-lower)*fraction
+	}
+	return h.max
+}
+
+// BucketCount is the number of observations at or below UpperBound
+type BucketCount struct {
+	UpperBound float64 `json:"le"`
+	Count      uint64  `json:"count"`
+}
+
+// QuantileValue is one summary objective and its current value
+type QuantileValue struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+// Distribution is the exported state of one histogram or summary series
+type Distribution struct {
+	Name      string            `json:"name"`
+	Type      string            `json:"type"`
+	Help      string            `json:"help,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Count     uint64            `json:"count"`
+	Sum       float64           `json:"sum"`
+	Buckets   []BucketCount     `json:"buckets,omitempty"`   // cumulative, Prometheus style
+	Quantiles []QuantileValue   `json:"quantiles,omitempty"` // over the retained points
+}
+
+// Cumulative returns the bucket counts the way Prometheus exposes them; the
+// +Inf bucket equals Count and is left out
+func (h *BucketHistogram) Cumulative() []BucketCount {
+	buckets := make([]BucketCount, len(h.bounds))
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		buckets[i] = BucketCount{UpperBound: bound, Count: cumulative}
+	}
+	return buckets
+}
+
+// Count returns the number of observations
+func (h *BucketHistogram) Count() uint64 {
+	return h.count
+}
+
+// Sum returns the sum of all observations
+func (h *BucketHistogram) Sum() float64 {
+	return h.sum
+}
+
+// Quantiles computes each objective over values by linear interpolation
+// between the closest ranks
+func Quantiles(values []float64, objectives []float64) []QuantileValue {
+	result := make([]QuantileValue, 0, len(objectives))
+	if len(values) == 0 {
+		return result
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	for _, q := range objectives {
+		rank := q * float64(len(sorted)-1)
+		lower := int(math.Floor(rank))
+		upper := int(math.Ceil(rank))
+		value := sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+		result = append(result, QuantileValue{Quantile: q, Value: value})
+	}
+	return result
+}
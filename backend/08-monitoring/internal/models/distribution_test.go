@@ -0,0 +1,72 @@
+package models
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+func TestMetricDefinition_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     MetricDefinition
+		wantErr string
+	}{
+		{"histogram with buckets", MetricDefinition{Name: "job_seconds", Type: "histogram", Buckets: []float64{0.1, 1, 10}}, ""},
+		{"summary with objectives", MetricDefinition{Name: "job_seconds", Type: "summary", Objectives: []float64{0.5, 0.99}}, ""},
+		{"defaults", MetricDefinition{Name: "queue_depth", Type: "gauge"}, ""},
+		{"invalid name", MetricDefinition{Name: "job-seconds", Type: "histogram"}, "name"},
+		{"unknown type", MetricDefinition{Name: "job_seconds", Type: "timer"}, "type"},
+		{"buckets on summary", MetricDefinition{Name: "job_seconds", Type: "summary", Buckets: []float64{1}}, "buckets"},
+		{"objectives on histogram", MetricDefinition{Name: "job_seconds", Type: "histogram", Objectives: []float64{0.5}}, "objectives"},
+		{"unsorted buckets", MetricDefinition{Name: "job_seconds", Type: "histogram", Buckets: []float64{1, 0.5}}, "buckets"},
+		{"infinite bucket", MetricDefinition{Name: "job_seconds", Type: "histogram", Buckets: []float64{1, math.Inf(1)}}, "buckets"},
+		{"objective out of range", MetricDefinition{Name: "job_seconds", Type: "summary", Objectives: []float64{0.5, 1}}, "objectives"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.def.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr *validation.Error
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.wantErr, validationErr.Field)
+		})
+	}
+}
+
+func TestMetricDefinition_WithDefaults(t *testing.T) {
+	assert.Equal(t, DefaultBuckets, MetricDefinition{Type: "histogram"}.WithDefaults().Buckets)
+	assert.Equal(t, DefaultObjectives, MetricDefinition{Type: "summary"}.WithDefaults().Objectives)
+	assert.Equal(t, []float64{1, 2}, MetricDefinition{Type: "histogram", Buckets: []float64{1, 2}}.WithDefaults().Buckets)
+}
+
+func TestBucketHistogram_Cumulative(t *testing.T) {
+	h := NewBucketHistogram([]float64{1, 5, 10})
+	for _, v := range []float64{0.5, 1, 3, 7, 20} {
+		h.Observe(v)
+	}
+
+	// A value equal to a bound falls into that bucket, as with Prometheus "le"
+	assert.Equal(t, []BucketCount{{1, 2}, {5, 3}, {10, 4}}, h.Cumulative())
+	assert.Equal(t, uint64(5), h.Count())
+	assert.Equal(t, 31.5, h.Sum())
+}
+
+func TestQuantiles(t *testing.T) {
+	values := []float64{5, 1, 4, 2, 3}
+
+	got := Quantiles(values, []float64{0.5, 0.9})
+	require.Len(t, got, 2)
+	assert.Equal(t, 3.0, got[0].Value)
+	assert.InDelta(t, 4.6, got[1].Value, 1e-9)
+
+	assert.Empty(t, Quantiles(nil, DefaultObjectives))
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// IncidentKind names what the leak watchdog detected
+type IncidentKind string
+
+const (
+	IncidentGoroutineGrowth IncidentKind = "goroutine_growth" // goroutines kept climbing over the window
+	IncidentHeapGrowth      IncidentKind = "heap_growth"      // heap kept climbing over the window
+	IncidentGoroutineLimit  IncidentKind = "goroutine_limit"  // goroutine count above the absolute limit
+	IncidentHeapLimit       IncidentKind = "heap_limit"       // heap above the absolute limit
+)
+
+// Incident is one detected leak symptom. It stays open while the condition
+// holds and gets a ResolvedAt once it clears.
+type Incident struct {
+	ID         int          `json:"id"`
+	Kind       IncidentKind `json:"kind"`
+	Message    string       `json:"message"`
+	Value      float64      `json:"value"`            // latest goroutine count or heap bytes
+	Slope      float64      `json:"slope_per_minute"` // growth rate over the window
+	Threshold  float64      `json:"threshold"`        // slope or limit that was exceeded
+	Window     float64      `json:"window_seconds"`   // span of the samples evaluated
+	DetectedAt time.Time    `json:"detected_at"`
+	ResolvedAt *time.Time   `json:"resolved_at,omitempty"`
+}
+
+// Open reports whether the incident's condition still holds
+func (i Incident) Open() bool {
+	return i.ResolvedAt == nil
+}
+
+// GrowthPerMinute fits a least-squares line through points and returns its
+// slope in units per minute. It returns 0 for fewer than two points or when
+// all points share one timestamp.
+func GrowthPerMinute(points []SeriesPoint) float64 {
+	if len(points) < 2 {
+		return 0
+	}
+
+	origin := points[0].Timestamp
+	var sumX, sumY, sumXY, sumXX float64
+	for _, p := range points {
+		x := p.Timestamp.Sub(origin).Minutes()
+		sumX += x
+		sumY += p.Value
+		sumXY += x * p.Value
+		sumXX += x * x
+	}
+
+	n := float64(len(points))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
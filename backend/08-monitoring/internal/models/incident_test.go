@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrowthPerMinute(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	series := func(values ...float64) []SeriesPoint {
+		points := make([]SeriesPoint, len(values))
+		for i, v := range values {
+			points[i] = SeriesPoint{Timestamp: start.Add(time.Duration(i) * 30 * time.Second), Value: v}
+		}
+		return points
+	}
+
+	tests := []struct {
+		name   string
+		points []SeriesPoint
+		want   float64
+	}{
+		{"steady growth", series(100, 105, 110, 115, 120), 10},
+		{"flat", series(50, 50, 50, 50), 0},
+		{"shrinking", series(40, 30, 20, 10), -20},
+		{"noisy growth", series(100, 110, 104, 116, 112), 6},
+		{"single point", series(100), 0},
+		{"no points", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, GrowthPerMinute(tt.points), 0.001)
+		})
+	}
+}
+
+func TestIncident_Open(t *testing.T) {
+	incident := Incident{Kind: IncidentHeapGrowth}
+	assert.True(t, incident.Open())
+
+	resolved := time.Now()
+	incident.ResolvedAt = &resolved
+	assert.False(t, incident.Open())
+}
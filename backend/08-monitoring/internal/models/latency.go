@@ -0,0 +1,172 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// LatencyStats summarizes a latency distribution in milliseconds
+type LatencyStats struct {
+	Count int64   `json:"count"`
+	Avg   float64 `json:"avg_ms"`
+	Max   float64 `json:"max_ms"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P99   float64 `json:"p99_ms"`
+}
+
+// latencyBounds are bucket upper bounds in milliseconds, growing by 25% from
+// 0.1ms to about a minute, so any percentile is off by at most one bucket width
+var latencyBounds = func() []float64 {
+	var bounds []float64
+	for b := 0.1; b < 60000; b *= 1.25 {
+		bounds = append(bounds, b)
+	}
+	return bounds
+}()
+
+// LatencyHistogram records durations in fixed buckets; memory stays constant
+// no matter how many requests are observed
+type LatencyHistogram struct {
+	counts []int64 // one per bound, plus an overflow bucket
+	count  int64
+	sum    float64
+	max    float64
+}
+
+// NewLatencyHistogram creates an empty histogram
+func NewLatencyHistogram() *LatencyHistogram {
+	return &LatencyHistogram{counts: make([]int64, len(latencyBounds)+1)}
+}
+
+// Observe records one duration
+func (h *LatencyHistogram) Observe(d time.Duration) {
+	ms := float64(d) / float64(time.Millisecond)
+	i := sort.SearchFloat64s(latencyBounds, ms)
+	h.counts[i]++
+	h.count++
+	h.sum += ms
+	if ms > h.max {
+		h.max = ms
+	}
+}
+
+// Quantile estimates the q-th quantile (0..1) in milliseconds by
+// interpolating inside the bucket that contains it
+func (h *LatencyHistogram) Quantile(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+
+	rank := q * float64(h.count)
+	var cumulative int64
+	for i, c := range h.counts {
+		if c == 0 || float64(cumulative+c) < rank {
+			cumulative += c
+			continue
+		}
+
+		lower := 0.0
+		if i > 0 {
+			lower = latencyBounds[i-1]
+		}
+		upper := h.max
+		if i < len(latencyBounds) {
+			upper = math.Min(latencyBounds[i], h.max)
+		}
+
+		fraction := (rank - float64(cumulative)) / float64(c)
+		return lower + (upper-lower)*fraction
+	}
+	return h.max
+}
+
+// CountBelow estimates how many observations took at most ms milliseconds,
+// interpolating inside the bucket that contains ms
+func (h *LatencyHistogram) CountBelow(ms float64) float64 {
+	if ms >= h.max {
+		return float64(h.count)
+	}
+
+	var below float64
+	for i, c := range h.counts {
+		lower := 0.0
+		if i > 0 {
+			lower = latencyBounds[i-1]
+		}
+		upper := h.max
+		if i < len(latencyBounds) {
+			upper = math.Min(latencyBounds[i], h.max)
+		}
+
+		if ms >= upper {
+			below += float64(c)
+			continue
+		}
+		if ms > lower {
+			below += float64(c) * (ms - lower) / (upper - lower)
+		}
+		break
+	}
+	return below
+}
+
+// Stats summarizes the histogram
+func (h *LatencyHistogram) Stats() LatencyStats {
+	if h.count == 0 {
+		return LatencyStats{}
+	}
+	return LatencyStats{
+		Count: h.count,
+		Avg:   round2(h.sum / float64(h.count)),
+		Max:   round2(h.max),
+		P50:   round2(h.Quantile(0.50)),
+		P90:   round2(h.Quantile(0.90)),
+		P99:   round2(h.Quantile(0.99)),
+	}
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Clone returns an independent copy of the histogram
+func (h *LatencyHistogram) Clone() *LatencyHistogram {
+	clone := *h
+	clone.counts = append([]int64(nil), h.counts...)
+	return &clone
+}
+
+// Since returns the observations recorded after prev was cloned from h. The
+// exact maximum of that window is unknown, so it is estimated from the
+// highest non-empty bucket.
+func (h *LatencyHistogram) Since(prev *LatencyHistogram) *LatencyHistogram {
+	if prev == nil {
+		return h.Clone()
+	}
+
+	delta := NewLatencyHistogram()
+	delta.count = h.count - prev.count
+	delta.sum = h.sum - prev.sum
+	for i := range h.counts {
+		delta.counts[i] = h.counts[i] - prev.counts[i]
+		if delta.counts[i] > 0 {
+			delta.max = h.max
+			if i < len(latencyBounds) {
+				delta.max = math.Min(latencyBounds[i], h.max)
+			}
+		}
+	}
+	return delta
+}
+
+// Merge adds other's observations to h
+func (h *LatencyHistogram) Merge(other *LatencyHistogram) {
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.count += other.count
+	h.sum += other.sum
+	h.max = math.Max(h.max, other.max)
+}
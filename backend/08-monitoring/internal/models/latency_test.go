@@ -0,0 +1,117 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyHistogram_Stats(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		want      LatencyStats
+		tolerance float64
+	}{
+		{
+			name: "empty histogram",
+			want: LatencyStats{},
+		},
+		{
+			name:      "single observation",
+			durations: []time.Duration{10 * time.Millisecond},
+			want:      LatencyStats{Count: 1, Avg: 10, Max: 10, P50: 10, P90: 10, P99: 10},
+			tolerance: 2.5, // one bucket is 25% wide
+		},
+		{
+			name:      "uniform 1..100ms",
+			durations: millisRange(1, 100),
+			want:      LatencyStats{Count: 100, Avg: 50.5, Max: 100, P50: 50, P90: 90, P99: 99},
+			tolerance: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewLatencyHistogram()
+			for _, d := range tt.durations {
+				h.Observe(d)
+			}
+
+			got := h.Stats()
+			assert.Equal(t, tt.want.Count, got.Count)
+			assert.InDelta(t, tt.want.Avg, got.Avg, 0.01)
+			assert.InDelta(t, tt.want.Max, got.Max, 0.01)
+			assert.InDelta(t, tt.want.P50, got.P50, tt.tolerance)
+			assert.InDelta(t, tt.want.P90, got.P90, tt.tolerance)
+			assert.InDelta(t, tt.want.P99, got.P99, tt.tolerance)
+		})
+	}
+}
+
+func TestLatencyHistogram_QuantileNeverExceedsMax(t *testing.T) {
+	h := NewLatencyHistogram()
+	h.Observe(3 * time.Millisecond)
+	h.Observe(2 * time.Minute) // beyond the last bucket
+
+	assert.LessOrEqual(t, h.Quantile(0.99), 120000.0)
+	assert.Equal(t, 120000.0, h.Stats().Max)
+}
+
+func millisRange(from, to int) []time.Duration {
+	var durations []time.Duration
+	for i := from; i <= to; i++ {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	return durations
+}
+
+func TestLatencyHistogram_Since(t *testing.T) {
+	h := NewLatencyHistogram()
+	for _, d := range millisRange(1, 10) {
+		h.Observe(d)
+	}
+	prev := h.Clone()
+	for _, d := range millisRange(100, 199) {
+		h.Observe(d)
+	}
+
+	delta := h.Since(prev)
+	assert.Equal(t, int64(100), delta.Stats().Count)
+	assert.InDelta(t, 149.5, delta.Stats().Avg, 0.01)
+	assert.InDelta(t, 150, delta.Quantile(0.5), 5)
+
+	// The clone must not change when the original does
+	assert.Equal(t, int64(10), prev.Stats().Count)
+	assert.Equal(t, int64(110), h.Since(nil).Stats().Count)
+}
+
+func TestLatencyHistogram_Merge(t *testing.T) {
+	a := NewLatencyHistogram()
+	a.Observe(10 * time.Millisecond)
+	b := NewLatencyHistogram()
+	b.Observe(30 * time.Millisecond)
+	b.Observe(50 * time.Millisecond)
+
+	a.Merge(b)
+
+	stats := a.Stats()
+	assert.Equal(t, int64(3), stats.Count)
+	assert.InDelta(t, 30, stats.Avg, 0.01)
+	assert.InDelta(t, 50, stats.Max, 0.01)
+}
+
+func TestLatencyHistogram_CountBelow(t *testing.T) {
+	h := NewLatencyHistogram()
+	for _, d := range millisRange(1, 100) {
+		h.Observe(d)
+	}
+
+	assert.InDelta(t, 50, h.CountBelow(50), 5)
+	assert.InDelta(t, 90, h.CountBelow(90), 5)
+	assert.Equal(t, float64(100), h.CountBelow(100))
+	assert.Equal(t, float64(100), h.CountBelow(5000))
+	assert.Equal(t, float64(0), h.CountBelow(0))
+	assert.Equal(t, float64(0), NewLatencyHistogram().CountBelow(10))
+}
@@ -1,8 +1,10 @@
 package models
 
 import (
-	"fmt"
+	"encoding/json"
 	"time"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
 )
 
 // HealthStatus represents the health state of a service
@@ -22,6 +24,9 @@ type HealthCheck struct {
 	Duration  time.Duration          `json:"duration_ms"`
 	Timestamp time.Time              `json:"timestamp"`
 	Details   map[string]interface{} `json:"details,omitempty"`
+	// Informational checks never fail readiness; an unhealthy one only
+	// degrades the overall status
+	Informational bool `json:"informational"`
 }
 
 // HealthResponse represents the overall health response
@@ -32,17 +37,97 @@ type HealthResponse struct {
 	Timestamp   time.Time     `json:"timestamp"`
 	Checks      []HealthCheck `json:"checks"`
 	Environment string        `json:"environment"`
+	CheckedAt   time.Time     `json:"checked_at"` // when the checks last ran
+	Cached      bool          `json:"cached"`
+	Stale       bool          `json:"stale"` // cache older than the scheduler should allow
+}
+
+// MarshalJSON writes Uptime in seconds, as its name says; a bare Duration
+// would encode as nanoseconds
+func (h HealthResponse) MarshalJSON() ([]byte, error) {
+	type plain HealthResponse
+	return json.Marshal(struct {
+		plain
+		Uptime float64 `json:"uptime_seconds"`
+	}{plain(h), h.Uptime.Seconds()})
+}
+
+// HealthTransition records a health check changing status
+type HealthTransition struct {
+	Check         string       `json:"check"`
+	From          HealthStatus `json:"from"`
+	To            HealthStatus `json:"to"`
+	Message       string       `json:"message,omitempty"` // the check's message after the change
+	Informational bool         `json:"informational"`
+	Timestamp     time.Time    `json:"timestamp"`
+}
+
+// Recovered reports whether the transition returned the check to healthy
+func (t HealthTransition) Recovered() bool {
+	return t.To == HealthStatusHealthy
+}
+
+// HealthCheckHistory summarizes one check's recent transitions
+type HealthCheckHistory struct {
+	Check       string       `json:"check"`
+	Status      HealthStatus `json:"status"`
+	Since       time.Time    `json:"since"`       // when Status was entered; zero if never changed
+	Transitions int          `json:"transitions"` // within the flapping window
+	Flapping    bool         `json:"flapping"`
 }
 
 // CustomMetric represents a custom application metric
 type CustomMetric struct {
 	Name      string            `json:"name"`
-	Type      string            `json:"type"` // counter, gauge, histogram
+	Type      string            `json:"type"` // counter, gauge, histogram, summary
 	Value     float64           `json:"value"`
 	Labels    map[string]string `json:"labels,omitempty"`
 	Timestamp time.Time         `json:"timestamp"`
 }
 
+// CardinalityReport shows how many label sets a custom metric uses
+type CardinalityReport struct {
+	Name       string         `json:"name"`
+	LabelSets  int            `json:"label_sets"`
+	Limit      int            `json:"limit"`
+	Overflowed int64          `json:"overflowed"` // points past the limit
+	Overflow   string         `json:"overflow"`   // aggregate or drop
+	Labels     map[string]int `json:"labels"`     // distinct values per label
+}
+
+// MetricsSnapshot is a point-in-time copy of every metric, meant to be saved
+// and compared, e.g. before and after a load test
+type MetricsSnapshot struct {
+	Version       string                  `json:"version"`
+	Environment   string                  `json:"environment"`
+	Uptime        string                  `json:"uptime"`
+	Requests      map[string]int64        `json:"request_metrics"`
+	Latency       map[string]LatencyStats `json:"latency_metrics"`
+	Errors        map[string]int64        `json:"error_metrics"`
+	CustomMetrics []CustomMetric          `json:"custom_metrics"`
+	Distributions []Distribution          `json:"distributions"`
+	Definitions   []MetricDefinition      `json:"definitions"`
+	Cardinality   []CardinalityReport     `json:"cardinality"`
+	System        SystemMetrics           `json:"system_metrics"`
+	TakenAt       time.Time               `json:"taken_at"`
+}
+
+// BuildInfo identifies the running binary and process
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time,omitempty"`
+	Dirty     bool   `json:"dirty"` // built from a checkout with uncommitted changes
+	Module    string `json:"module,omitempty"`
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	Hostname  string `json:"hostname"`
+	PID       int    `json:"pid"`
+	NumCPU    int    `json:"num_cpu"`
+	MaxProcs  int    `json:"gomaxprocs"`
+}
+
 // RequestMetrics represents HTTP request metrics
 type RequestMetrics struct {
 	Method       string        `json:"method"`
@@ -58,38 +143,47 @@ type RequestMetrics struct {
 
 // SystemMetrics represents system-level metrics
 type SystemMetrics struct {
-	CPUUsage       float64   `json:"cpu_usage_percent"`
+	CPUUsage       float64   `json:"cpu_usage_percent"` // this process, 100 = one core
+	SystemCPUUsage float64   `json:"system_cpu_usage_percent"`
 	MemoryUsage    int64     `json:"memory_usage_bytes"`
 	MemoryTotal    int64     `json:"memory_total_bytes"`
+	RSS            int64     `json:"rss_bytes"`
+	OpenFDs        int32     `json:"open_fds"`
 	GoroutineCount int       `json:"goroutine_count"`
 	HeapInUse      int64     `json:"heap_inuse_bytes"`
 	HeapAlloc      int64     `json:"heap_alloc_bytes"`
+	GC             GCStats   `json:"gc"`
 	Timestamp      time.Time `json:"timestamp"`
 }
 
-// ValidationError represents a validation error
-type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
+// GCStats summarizes garbage collector pauses
+type GCStats struct {
+	NumGC        int64     `json:"num_gc"`
+	LastGC       time.Time `json:"last_gc"`
+	LastPauseMs  float64   `json:"last_pause_ms"`
+	PauseTotalMs float64   `json:"pause_total_ms"`
 }
 
-func (e *ValidationError) Error() string {
-	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+var validMetricTypes = map[string]bool{
+	"counter":   true,
+	"gauge":     true,
+	"histogram": true,
+	"summary":   true,
 }
 
 // Validate validates a custom metric
 func (m CustomMetric) Validate() error {
 	if m.Name == "" {
-		return &ValidationError{Field: "name", Message: "Metric name is required"}
+		return &validation.Error{Field: "name", Message: "Metric name is required"}
 	}
 	if len(m.Name) > 100 {
-		return &ValidationError{Field: "name", Message: "Metric name must be less than 100 characters"}
+		return &validation.Error{Field: "name", Message: "Metric name must be less than 100 characters"}
 	}
 	if m.Type == "" {
-		return &ValidationError{Field: "type", Message: "Metric type is required"}
+		return &validation.Error{Field: "type", Message: "Metric type is required"}
 	}
-	if m.Type != "counter" && m.Type != "gauge" && m.Type != "histogram" {
-		return &ValidationError{Field: "type", Message: "Metric type must be counter, gauge, or histogram"}
+	if !validMetricTypes[m.Type] {
+		return &validation.Error{Field: "type", Message: "Metric type must be counter, gauge, histogram, or summary"}
 	}
 	return nil
 }
@@ -97,10 +191,10 @@ func (m CustomMetric) Validate() error {
 // NewHealthCheck creates a new health check with validation
 func NewHealthCheck(name, message string, status HealthStatus, duration time.Duration) (*HealthCheck, error) {
 	if name == "" {
-		return nil, &ValidationError{Field: "name", Message: "Health check name is required"}
+		return nil, &validation.Error{Field: "name", Message: "Health check name is required"}
 	}
 	if len(name) > 50 {
-		return nil, &ValidationError{Field: "name", Message: "Health check name must be less than 50 characters"}
+		return nil, &validation.Error{Field: "name", Message: "Health check name must be less than 50 characters"}
 	}
 
 	return &HealthCheck{
@@ -117,10 +211,10 @@ func (h HealthResponse) IsHealthy() bool {
 	return h.Status == HealthStatusHealthy
 }
 
-// HasCriticalFailures returns true if any checks are unhealthy
+// HasCriticalFailures returns true if any critical checks are unhealthy
 func (h HealthResponse) HasCriticalFailures() bool {
 	for _, check := range h.Checks {
-		if check.Status == HealthStatusUnhealthy {
+		if check.Status == HealthStatusUnhealthy && !check.Informational {
 			return true
 		}
 	}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -83,7 +84,7 @@ func TestCustomMetric_Validate(t *testing.T) {
 				Value: 1.0,
 			},
 			wantErr: true,
-			errMsg:  "Metric type must be counter, gauge, or histogram",
+			errMsg:  "Metric type must be counter, gauge, histogram, or summary",
 		},
 	}
 
@@ -239,6 +240,16 @@ func TestHealthResponse_HasCriticalFailures(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "informational failure only",
+			response: HealthResponse{
+				Checks: []HealthCheck{
+					{Status: HealthStatusHealthy},
+					{Status: HealthStatusUnhealthy, Informational: true},
+				},
+			},
+			want: false,
+		},
 		{
 			name: "all unhealthy",
 			response: HealthResponse{
@@ -265,3 +276,15 @@ func TestHealthResponse_HasCriticalFailures(t *testing.T) {
 		})
 	}
 }
+
+func TestHealthResponse_MarshalJSON(t *testing.T) {
+	response := HealthResponse{Status: HealthStatusHealthy, Uptime: 90 * time.Second}
+
+	data, err := json.Marshal(response)
+	assert.NoError(t, err)
+
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, 90.0, decoded["uptime_seconds"])
+	assert.Equal(t, "healthy", decoded["status"])
+}
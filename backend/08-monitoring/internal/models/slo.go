@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// SLOKind is what an objective measures
+type SLOKind string
+
+const (
+	SLOAvailability SLOKind = "availability" // share of requests without a 5xx
+	SLOLatency      SLOKind = "latency"      // share of requests faster than ThresholdMs
+)
+
+// Objective is a service level objective: Target percent of the requests to
+// Route (all routes when empty) must be good over the SLO period
+type Objective struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Route       string  `json:"route,omitempty"` // "METHOD:/route"
+	Kind        SLOKind `json:"kind"`
+	Target      float64 `json:"target_percent"`
+	ThresholdMs float64 `json:"threshold_ms,omitempty"` // latency objectives only
+}
+
+// BurnRateWindow pairs a long and a short window: an alert fires only when
+// both burn faster than Factor, so it pages quickly on a real outage but
+// clears as soon as the problem is gone
+type BurnRateWindow struct {
+	Severity string        `json:"severity"`
+	Long     time.Duration `json:"-"`
+	Short    time.Duration `json:"-"`
+	Factor   float64       `json:"factor"`
+}
+
+// DefaultBurnRateWindows are the multiwindow thresholds from the Google SRE
+// workbook: 14.4x spends 2% of a 30 day budget in an hour, 6x spends 5% in
+// six hours
+var DefaultBurnRateWindows = []BurnRateWindow{
+	{Severity: "page", Long: time.Hour, Short: 5 * time.Minute, Factor: 14.4},
+	{Severity: "ticket", Long: 6 * time.Hour, Short: 30 * time.Minute, Factor: 6},
+}
+
+// BurnRateAlert is a burn-rate window whose long and short windows both exceed the factor
+type BurnRateAlert struct {
+	Severity    string  `json:"severity"`
+	LongWindow  string  `json:"long_window"`
+	ShortWindow string  `json:"short_window"`
+	Factor      float64 `json:"factor"`
+	LongBurn    float64 `json:"long_burn_rate"`
+	ShortBurn   float64 `json:"short_burn_rate"`
+}
+
+// SLOStatus is an objective's standing over its period
+type SLOStatus struct {
+	Objective
+	Period          string             `json:"period"`
+	TotalEvents     int64              `json:"total_events"`
+	BadEvents       int64              `json:"bad_events"`
+	Compliance      float64            `json:"compliance_percent"`             // good share over the period
+	BudgetRemaining float64            `json:"error_budget_remaining_percent"` // negative once overspent
+	BurnRates       map[string]float64 `json:"burn_rates"`                     // by window, e.g. "1h"
+	Alerts          []BurnRateAlert    `json:"alerts"`
+}
+
+// Validate validates an objective
+func (o Objective) Validate() error {
+	if o.Name == "" {
+		return &validation.Error{Field: "name", Message: "Objective name is required"}
+	}
+	if o.Kind != SLOAvailability && o.Kind != SLOLatency {
+		return &validation.Error{Field: "kind", Message: "Kind must be availability or latency"}
+	}
+	if o.Target <= 0 || o.Target >= 100 {
+		return &validation.Error{Field: "target_percent", Message: "Target must be between 0 and 100 exclusive"}
+	}
+	if o.Kind == SLOLatency && o.ThresholdMs <= 0 {
+		return &validation.Error{Field: "threshold_ms", Message: "Latency objectives need a positive threshold"}
+	}
+	return nil
+}
+
+// BurnRate is how fast bad events spend the error budget: 1 spends exactly
+// the budget over the SLO period, 2 spends it in half the period
+func BurnRate(total, bad float64, targetPercent float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	allowed := 1 - targetPercent/100
+	return (bad / total) / allowed
+}
+
+// BudgetRemaining returns the percent of the error budget still unspent
+// after total events of which bad were bad
+func BudgetRemaining(total, bad float64, targetPercent float64) float64 {
+	if total <= 0 {
+		return 100
+	}
+	allowed := total * (1 - targetPercent/100)
+	return 100 * (1 - bad/allowed)
+}
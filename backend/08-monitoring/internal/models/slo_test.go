@@ -0,0 +1,78 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjective_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		objective Objective
+		wantErr   bool
+		errMsg    string
+	}{
+		{
+			name:      "valid availability objective",
+			objective: Objective{Name: "API availability", Kind: SLOAvailability, Target: 99.9},
+		},
+		{
+			name:      "valid latency objective",
+			objective: Objective{Name: "Demo latency", Route: "GET:/api/demo", Kind: SLOLatency, Target: 99, ThresholdMs: 300},
+		},
+		{
+			name:      "missing name",
+			objective: Objective{Kind: SLOAvailability, Target: 99.9},
+			wantErr:   true,
+			errMsg:    "Objective name is required",
+		},
+		{
+			name:      "unknown kind",
+			objective: Objective{Name: "x", Kind: "throughput", Target: 99.9},
+			wantErr:   true,
+			errMsg:    "Kind must be availability or latency",
+		},
+		{
+			name:      "target of 100 leaves no budget",
+			objective: Objective{Name: "x", Kind: SLOAvailability, Target: 100},
+			wantErr:   true,
+			errMsg:    "Target must be between 0 and 100",
+		},
+		{
+			name:      "latency without threshold",
+			objective: Objective{Name: "x", Kind: SLOLatency, Target: 99},
+			wantErr:   true,
+			errMsg:    "Latency objectives need a positive threshold",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.objective.Validate()
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBurnRate(t *testing.T) {
+	// 99.9% target allows 1 bad request in 1000
+	assert.InDelta(t, 1, BurnRate(1000, 1, 99.9), 0.001)
+	assert.InDelta(t, 14.4, BurnRate(1000, 14.4, 99.9), 0.001)
+	assert.InDelta(t, 0, BurnRate(1000, 0, 99.9), 0.001)
+	assert.Equal(t, float64(0), BurnRate(0, 0, 99.9))
+}
+
+func TestBudgetRemaining(t *testing.T) {
+	// 99% of 1000 requests allows 10 bad ones
+	assert.InDelta(t, 100, BudgetRemaining(1000, 0, 99), 0.001)
+	assert.InDelta(t, 70, BudgetRemaining(1000, 3, 99), 0.001)
+	assert.InDelta(t, -50, BudgetRemaining(1000, 15, 99), 0.001)
+	assert.Equal(t, float64(100), BudgetRemaining(0, 0, 99))
+}
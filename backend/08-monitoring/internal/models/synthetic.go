@@ -0,0 +1,101 @@
+package models
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// SyntheticCheck probes an external URL on a schedule. A probe succeeds when
+// the response arrives within TimeoutMs with ExpectedStatus.
+type SyntheticCheck struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	URL             string `json:"url"`
+	Method          string `json:"method"`          // GET when empty
+	ExpectedStatus  int    `json:"expected_status"` // 200 when 0
+	TimeoutMs       int    `json:"timeout_ms"`      // 5000 or the interval when 0
+	IntervalSeconds int    `json:"interval_seconds"`
+}
+
+// ProbeResult is the outcome of one synthetic probe
+type ProbeResult struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Success    bool      `json:"success"`
+	StatusCode int       `json:"status_code,omitempty"`
+	LatencyMs  float64   `json:"latency_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// SyntheticStatus is a check with its probe statistics over the kept history
+type SyntheticStatus struct {
+	SyntheticCheck
+	Up           bool          `json:"up"` // last probe succeeded
+	Probes       int64         `json:"probes"`
+	Failures     int64         `json:"failures"`
+	Availability float64       `json:"availability_percent"` // over History
+	Latency      LatencyStats  `json:"latency"`              // over History
+	LastResult   *ProbeResult  `json:"last_result,omitempty"`
+	History      []ProbeResult `json:"history,omitempty"` // oldest first
+}
+
+// WithDefaults fills in the optional fields
+func (c SyntheticCheck) WithDefaults() SyntheticCheck {
+	if c.Method == "" {
+		c.Method = http.MethodGet
+	}
+	if c.ExpectedStatus == 0 {
+		c.ExpectedStatus = http.StatusOK
+	}
+	if c.TimeoutMs == 0 {
+		c.TimeoutMs = 5000
+		if c.IntervalSeconds > 0 && c.IntervalSeconds < 5 {
+			c.TimeoutMs = c.IntervalSeconds * 1000
+		}
+	}
+	return c
+}
+
+// Validate validates a synthetic check after defaults are applied
+func (c SyntheticCheck) Validate() error {
+	if c.Name == "" {
+		return &validation.Error{Field: "name", Message: "Check name is required"}
+	}
+	if len(c.Name) > 100 {
+		return &validation.Error{Field: "name", Message: "Check name must be less than 100 characters"}
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &validation.Error{Field: "url", Message: "URL must be an absolute http(s) URL"}
+	}
+	switch c.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions:
+	default:
+		return &validation.Error{Field: "method", Message: "Method must be GET, HEAD, POST, PUT, DELETE or OPTIONS"}
+	}
+	if c.ExpectedStatus < 100 || c.ExpectedStatus > 599 {
+		return &validation.Error{Field: "expected_status", Message: "Expected status must be between 100 and 599"}
+	}
+	if c.TimeoutMs < 1 {
+		return &validation.Error{Field: "timeout_ms", Message: "Timeout must be positive"}
+	}
+	if c.IntervalSeconds < 1 {
+		return &validation.Error{Field: "interval_seconds", Message: "Interval must be at least one second"}
+	}
+	if c.Timeout() > c.Interval() {
+		return &validation.Error{Field: "timeout_ms", Message: "Timeout cannot be longer than the interval"}
+	}
+	return nil
+}
+
+// Timeout returns how long a probe may take
+func (c SyntheticCheck) Timeout() time.Duration {
+	return time.Duration(c.TimeoutMs) * time.Millisecond
+}
+
+// Interval returns the time between two probes
+func (c SyntheticCheck) Interval() time.Duration {
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
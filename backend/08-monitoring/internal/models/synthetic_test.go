@@ -0,0 +1,93 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyntheticCheck_Validate(t *testing.T) {
+	valid := SyntheticCheck{
+		Name:            "Payments API",
+		URL:             "https://payments.example.com/health",
+		IntervalSeconds: 30,
+	}.WithDefaults()
+
+	tests := []struct {
+		name    string
+		modify  func(c *SyntheticCheck)
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:   "valid check",
+			modify: func(c *SyntheticCheck) {},
+		},
+		{
+			name:    "empty name",
+			modify:  func(c *SyntheticCheck) { c.Name = "" },
+			wantErr: true,
+			errMsg:  "Check name is required",
+		},
+		{
+			name:    "relative URL",
+			modify:  func(c *SyntheticCheck) { c.URL = "/health" },
+			wantErr: true,
+			errMsg:  "URL must be an absolute http(s) URL",
+		},
+		{
+			name:    "unsupported method",
+			modify:  func(c *SyntheticCheck) { c.Method = "PATCH" },
+			wantErr: true,
+			errMsg:  "Method must be GET, HEAD, POST, PUT, DELETE or OPTIONS",
+		},
+		{
+			name:    "expected status out of range",
+			modify:  func(c *SyntheticCheck) { c.ExpectedStatus = 600 },
+			wantErr: true,
+			errMsg:  "Expected status must be between 100 and 599",
+		},
+		{
+			name:    "missing interval",
+			modify:  func(c *SyntheticCheck) { c.IntervalSeconds = 0 },
+			wantErr: true,
+			errMsg:  "Interval must be at least one second",
+		},
+		{
+			name:    "timeout longer than interval",
+			modify:  func(c *SyntheticCheck) { c.TimeoutMs = 31000 },
+			wantErr: true,
+			errMsg:  "Timeout cannot be longer than the interval",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := valid
+			tt.modify(&check)
+
+			err := check.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSyntheticCheck_WithDefaults(t *testing.T) {
+	c := SyntheticCheck{Name: "api", URL: "http://localhost"}.WithDefaults()
+	assert.Equal(t, "GET", c.Method)
+	assert.Equal(t, 200, c.ExpectedStatus)
+	assert.Equal(t, 5000, c.TimeoutMs)
+
+	c = SyntheticCheck{IntervalSeconds: 2}.WithDefaults()
+	assert.Equal(t, 2000, c.TimeoutMs, "default timeout fits the interval")
+
+	c = SyntheticCheck{Method: "HEAD", ExpectedStatus: 204, TimeoutMs: 100}.WithDefaults()
+	assert.Equal(t, "HEAD", c.Method)
+	assert.Equal(t, 204, c.ExpectedStatus)
+	assert.Equal(t, 100, c.TimeoutMs)
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// SeriesPoint is one recorded value of a custom metric
+type SeriesPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// SeriesResult is the queried history of one metric/labels combination
+type SeriesResult struct {
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Points []SeriesPoint     `json:"points"`
+}
+
+// MetricSeries keeps the most recent points of one metric in a ring buffer.
+// Points older than the retention window are dropped as new ones arrive, and
+// once the buffer is full the oldest point is overwritten.
+type MetricSeries struct {
+	points    []SeriesPoint
+	head      int // index of the oldest point
+	size      int
+	retention time.Duration
+}
+
+// NewMetricSeries creates a series holding at most capacity points no older
+// than retention
+func NewMetricSeries(capacity int, retention time.Duration) *MetricSeries {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &MetricSeries{points: make([]SeriesPoint, capacity), retention: retention}
+}
+
+// Add appends a point; points are expected in timestamp order
+func (s *MetricSeries) Add(p SeriesPoint) {
+	s.prune(p.Timestamp)
+
+	if s.size == len(s.points) {
+		s.points[s.head] = p
+		s.head = (s.head + 1) % len(s.points)
+		return
+	}
+	s.points[(s.head+s.size)%len(s.points)] = p
+	s.size++
+}
+
+// Points returns the retained points at or after since, oldest first
+func (s *MetricSeries) Points(since, now time.Time) []SeriesPoint {
+	if cutoff := now.Add(-s.retention); s.retention > 0 && cutoff.After(since) {
+		since = cutoff
+	}
+
+	result := make([]SeriesPoint, 0, s.size)
+	for i := 0; i < s.size; i++ {
+		p := s.points[(s.head+i)%len(s.points)]
+		if !p.Timestamp.Before(since) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Len returns the number of points currently held
+func (s *MetricSeries) Len() int {
+	return s.size
+}
+
+// prune drops points that fell out of the retention window
+func (s *MetricSeries) prune(now time.Time) {
+	if s.retention <= 0 {
+		return
+	}
+	cutoff := now.Add(-s.retention)
+	for s.size > 0 && s.points[s.head].Timestamp.Before(cutoff) {
+		s.head = (s.head + 1) % len(s.points)
+		s.size--
+	}
+}
+
+// Aggregations supported by Downsample
+var seriesAggregations = map[string]bool{
+	"avg": true, "sum": true, "min": true, "max": true, "last": true, "count": true,
+}
+
+// ValidAggregation reports whether Downsample understands agg
+func ValidAggregation(agg string) bool {
+	return seriesAggregations[agg]
+}
+
+// DefaultAggregation is sum for counters, where the points are increments,
+// and avg for everything else
+func DefaultAggregation(metricType string) string {
+	if metricType == "counter" {
+		return "sum"
+	}
+	return "avg"
+}
+
+// Downsample folds points into step-wide buckets aligned to the epoch, one
+// point per non-empty bucket stamped with the bucket start. points must be
+// in timestamp order.
+func Downsample(points []SeriesPoint, step time.Duration, agg string) []SeriesPoint {
+	if step <= 0 || len(points) == 0 {
+		return points
+	}
+
+	var result []SeriesPoint
+	var bucket []float64
+	start := points[0].Timestamp.Truncate(step)
+
+	flush := func() {
+		if len(bucket) > 0 {
+			result = append(result, SeriesPoint{Timestamp: start, Value: aggregate(bucket, agg)})
+		}
+		bucket = bucket[:0]
+	}
+
+	for _, p := range points {
+		if b := p.Timestamp.Truncate(step); !b.Equal(start) {
+			flush()
+			start = b
+		}
+		bucket = append(bucket, p.Value)
+	}
+	flush()
+
+	return result
+}
+
+func aggregate(values []float64, agg string) float64 {
+	switch agg {
+	case "sum", "avg":
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		if agg == "avg" {
+			return sum / float64(len(values))
+		}
+		return sum
+	case "min":
+		min := math.Inf(1)
+		for _, v := range values {
+			min = math.Min(min, v)
+		}
+		return min
+	case "max":
+		max := math.Inf(-1)
+		for _, v := range values {
+			max = math.Max(max, v)
+		}
+		return max
+	case "count":
+		return float64(len(values))
+	}
+	return values[len(values)-1] // last
+}
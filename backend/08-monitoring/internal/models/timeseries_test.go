@@ -0,0 +1,79 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var seriesStart = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func seriesAt(seconds ...int) []SeriesPoint {
+	points := make([]SeriesPoint, 0, len(seconds))
+	for _, s := range seconds {
+		points = append(points, SeriesPoint{
+			Timestamp: seriesStart.Add(time.Duration(s) * time.Second),
+			Value:     float64(s),
+		})
+	}
+	return points
+}
+
+func TestMetricSeries_OverwritesOldestWhenFull(t *testing.T) {
+	series := NewMetricSeries(3, 0)
+	for _, p := range seriesAt(1, 2, 3, 4, 5) {
+		series.Add(p)
+	}
+
+	assert.Equal(t, 3, series.Len())
+	assert.Equal(t, seriesAt(3, 4, 5), series.Points(time.Time{}, seriesStart))
+}
+
+func TestMetricSeries_Retention(t *testing.T) {
+	series := NewMetricSeries(100, time.Minute)
+	for _, p := range seriesAt(0, 30, 60, 90) {
+		series.Add(p)
+	}
+
+	// Adding the point at 90s drops the one at 0s
+	assert.Equal(t, 3, series.Len())
+
+	// Reading later hides points that aged out since the last write
+	now := seriesStart.Add(2 * time.Minute)
+	assert.Equal(t, seriesAt(60, 90), series.Points(time.Time{}, now))
+
+	// since narrows the window further
+	assert.Equal(t, seriesAt(90), series.Points(seriesStart.Add(61*time.Second), now))
+}
+
+func TestDownsample(t *testing.T) {
+	points := seriesAt(0, 10, 20, 60, 70, 150)
+
+	tests := []struct {
+		agg  string
+		want []float64
+	}{
+		{"avg", []float64{10, 65, 150}},
+		{"sum", []float64{30, 130, 150}},
+		{"min", []float64{0, 60, 150}},
+		{"max", []float64{20, 70, 150}},
+		{"last", []float64{20, 70, 150}},
+		{"count", []float64{3, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.agg, func(t *testing.T) {
+			result := Downsample(points, time.Minute, tt.agg)
+
+			if assert.Len(t, result, 3) {
+				for i, p := range result {
+					assert.Equal(t, seriesStart.Add(time.Duration(i)*time.Minute), p.Timestamp)
+					assert.Equal(t, tt.want[i], p.Value)
+				}
+			}
+		})
+	}
+
+	assert.Equal(t, points, Downsample(points, 0, "avg"))
+}
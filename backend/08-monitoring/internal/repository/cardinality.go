@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+)
+
+// ErrCardinalityExceeded is returned in drop mode for a label set that would
+// take a metric past its limit
+var ErrCardinalityExceeded = errors.New("too many distinct label sets for metric")
+
+// Overflow modes for label sets beyond the limit
+const (
+	OverflowAggregate = "aggregate" // fold into a label set whose values are all "other"
+	OverflowDrop      = "drop"      // reject the metric
+)
+
+// OverflowLabelValue replaces every label value of an aggregated overflow point
+const OverflowLabelValue = "other"
+
+// cardinalityGuard caps the distinct label sets each custom metric can
+// create, so a label fed from user IDs or URLs cannot grow memory without bound
+type cardinalityGuard struct {
+	limit    int
+	overflow string
+	metrics  map[string]*metricCardinality
+}
+
+type metricCardinality struct {
+	sets       map[string]bool            // accepted label set keys
+	values     map[string]map[string]bool // label name -> distinct values
+	overflowed int64
+}
+
+func newCardinalityGuard(limit int, overflow string) *cardinalityGuard {
+	return &cardinalityGuard{
+		limit:    limit,
+		overflow: overflow,
+		metrics:  make(map[string]*metricCardinality),
+	}
+}
+
+// admit checks a label set against the metric's limit. Sets already seen and
+// sets under the limit pass unchanged; past the limit it returns the "other"
+// set with aggregated true, or ErrCardinalityExceeded in drop mode.
+func (g *cardinalityGuard) admit(name, setKey string, labels map[string]string) (admitted map[string]string, aggregated bool, err error) {
+	m, ok := g.metrics[name]
+	if !ok {
+		m = &metricCardinality{sets: make(map[string]bool), values: make(map[string]map[string]bool)}
+		g.metrics[name] = m
+	}
+
+	if m.sets[setKey] || g.limit <= 0 || len(m.sets) < g.limit {
+		if !m.sets[setKey] {
+			m.sets[setKey] = true
+			for k, v := range labels {
+				if m.values[k] == nil {
+					m.values[k] = make(map[string]bool)
+				}
+				m.values[k][v] = true
+			}
+		}
+		return labels, false, nil
+	}
+
+	m.overflowed++
+	if g.overflow == OverflowDrop {
+		return nil, false, ErrCardinalityExceeded
+	}
+
+	other := make(map[string]string, len(labels))
+	for k := range labels {
+		other[k] = OverflowLabelValue
+	}
+	return other, true, nil
+}
+
+// forget drops everything tracked for a metric, after it has been reset
+func (g *cardinalityGuard) forget(name string) {
+	delete(g.metrics, name)
+}
+
+// report describes every metric's label usage, highest cardinality first
+func (g *cardinalityGuard) report() []models.CardinalityReport {
+	reports := make([]models.CardinalityReport, 0, len(g.metrics))
+	for name, m := range g.metrics {
+		labels := make(map[string]int, len(m.values))
+		for k, values := range m.values {
+			labels[k] = len(values)
+		}
+		reports = append(reports, models.CardinalityReport{
+			Name:       name,
+			LabelSets:  len(m.sets),
+			Limit:      g.limit,
+			Overflowed: m.overflowed,
+			Overflow:   g.overflow,
+			Labels:     labels,
+		})
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].LabelSets != reports[j].LabelSets {
+			return reports[i].LabelSets > reports[j].LabelSets
+		}
+		return reports[i].Name < reports[j].Name
+	})
+	return reports
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+)
+
+func recordUsers(t *testing.T, repo *MetricsRepository, users ...string) []error {
+	t.Helper()
+	var errs []error
+	for _, user := range users {
+		errs = append(errs, repo.RecordCustomMetric(models.CustomMetric{
+			Name:      "logins_total",
+			Type:      "counter",
+			Value:     1,
+			Labels:    map[string]string{"user": user, "plan": "free"},
+			Timestamp: time.Now(),
+		}))
+	}
+	return errs
+}
+
+func TestCardinalityGuard_Aggregate(t *testing.T) {
+	repo := NewMetricsRepository("test", "test")
+	repo.SetCardinalityLimit(2, OverflowAggregate)
+
+	for _, err := range recordUsers(t, repo, "alice", "bob", "carol", "dave", "alice") {
+		assert.NoError(t, err)
+	}
+
+	users := map[string]bool{}
+	for _, metric := range repo.GetCustomMetrics() {
+		users[metric.Labels["user"]] = true
+		if metric.Labels["user"] == OverflowLabelValue {
+			assert.Equal(t, OverflowLabelValue, metric.Labels["plan"])
+		}
+	}
+	// alice was seen before the limit, so her later point keeps its labels
+	assert.Equal(t, map[string]bool{"alice": true, "bob": true, OverflowLabelValue: true}, users)
+
+	other := repo.QueryCustomMetric("logins_total", map[string]string{"user": OverflowLabelValue}, time.Time{})
+	if assert.Len(t, other, 1) {
+		assert.Len(t, other[0].Points, 2)
+	}
+
+	report := repo.GetCardinalityReport()
+	if assert.Len(t, report, 1) {
+		assert.Equal(t, 2, report[0].LabelSets)
+		assert.Equal(t, int64(2), report[0].Overflowed)
+		assert.Equal(t, map[string]int{"user": 2, "plan": 1}, report[0].Labels)
+	}
+}
+
+func TestCardinalityGuard_Drop(t *testing.T) {
+	repo := NewMetricsRepository("test", "test")
+	repo.SetCardinalityLimit(2, OverflowDrop)
+
+	errs := recordUsers(t, repo, "alice", "bob", "carol")
+
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.ErrorIs(t, errs[2], ErrCardinalityExceeded)
+	assert.Len(t, repo.GetCustomMetrics(), 2)
+}
+
+func TestCardinalityGuard_Disabled(t *testing.T) {
+	repo := NewMetricsRepository("test", "test")
+	repo.SetCardinalityLimit(0, OverflowDrop)
+
+	users := make([]string, 500)
+	for i := range users {
+		users[i] = fmt.Sprintf("user-%d", i)
+	}
+	for _, err := range recordUsers(t, repo, users...) {
+		assert.NoError(t, err)
+	}
+	assert.Len(t, repo.GetCustomMetrics(), 500)
+}
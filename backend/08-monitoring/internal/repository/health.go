@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+)
+
+// HealthScheduler runs the health checks in the background and caches the
+// result, so probes hitting /health do not each fan out to every dependency
+type HealthScheduler struct {
+	repo          *MetricsRepository
+	checkers      []HealthChecker
+	informational map[string]bool
+	interval      time.Duration
+	timeout       time.Duration
+	history       *HealthHistory
+	listeners     []TransitionListener
+
+	refreshMu sync.Mutex    // one refresh at a time, forced or scheduled
+	notified  chan struct{} // closed once the previous round's listeners ran
+
+	mu        sync.RWMutex
+	latest    models.HealthResponse
+	checkedAt time.Time
+}
+
+// TransitionListener is told about every health check status change
+type TransitionListener func(ctx context.Context, transition models.HealthTransition)
+
+// NewHealthScheduler creates a scheduler running checkers every interval,
+// each round bounded by timeout, and recording status changes in history.
+// Checks whose names are listed in informational never fail readiness.
+func NewHealthScheduler(repo *MetricsRepository, checkers []HealthChecker, informational []string, interval, timeout time.Duration, history *HealthHistory) *HealthScheduler {
+	names := make(map[string]bool, len(informational))
+	for _, name := range informational {
+		names[name] = true
+	}
+
+	return &HealthScheduler{
+		repo:          repo,
+		checkers:      checkers,
+		informational: names,
+		interval:      interval,
+		timeout:       timeout,
+		history:       history,
+	}
+}
+
+// OnTransition registers a listener for status changes. Listeners run in
+// the background, so a slow webhook cannot hold up the checks; register
+// them before Start.
+func (s *HealthScheduler) OnTransition(listener TransitionListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// History returns the scheduler's transition history
+func (s *HealthScheduler) History() *HealthHistory {
+	return s.history
+}
+
+// Start runs the checks once right away, then every interval until ctx is done
+func (s *HealthScheduler) Start(ctx context.Context) {
+	go func() {
+		s.Refresh(ctx)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.Refresh(ctx)
+			}
+		}
+	}()
+}
+
+// Refresh runs every check now and caches the result
+func (s *HealthScheduler) Refresh(ctx context.Context) models.HealthResponse {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	response := s.repo.PerformHealthChecks(ctx, s.checkers, s.informational)
+	if transitions := s.history.Record(response.Checks); len(transitions) > 0 && len(s.listeners) > 0 {
+		// ctx ends with this round; the listeners outlive it
+		previous, done := s.notified, make(chan struct{})
+		s.notified = done
+		go s.notify(context.WithoutCancel(ctx), transitions, previous, done)
+	}
+
+	s.mu.Lock()
+	s.latest = response
+	s.checkedAt = response.Timestamp
+	s.mu.Unlock()
+
+	return s.fresh(response, response.Timestamp, false)
+}
+
+// notify hands transitions to every listener once the previous round's
+// listeners are done, so they see changes in order
+func (s *HealthScheduler) notify(ctx context.Context, transitions []models.HealthTransition, previous, done chan struct{}) {
+	defer close(done)
+	if previous != nil {
+		<-previous
+	}
+
+	for _, transition := range transitions {
+		for _, listener := range s.listeners {
+			listener(ctx, transition)
+		}
+	}
+}
+
+// Latest returns the cached result, running the checks inline only if no
+// round has finished yet
+func (s *HealthScheduler) Latest(ctx context.Context) models.HealthResponse {
+	s.mu.RLock()
+	latest, checkedAt := s.latest, s.checkedAt
+	s.mu.RUnlock()
+
+	if checkedAt.IsZero() {
+		return s.Refresh(ctx)
+	}
+	return s.fresh(latest, checkedAt, true)
+}
+
+// fresh stamps a result with its age; uptime and timestamp describe the
+// moment it is served, not the moment it was checked
+func (s *HealthScheduler) fresh(response models.HealthResponse, checkedAt time.Time, cached bool) models.HealthResponse {
+	now := time.Now()
+	response.Timestamp = now
+	response.Uptime = now.Sub(s.repo.startTime)
+	response.CheckedAt = checkedAt
+	response.Cached = cached
+	// A cache older than two intervals means the scheduler is stuck or stopped
+	response.Stale = cached && now.Sub(checkedAt) > 2*s.interval
+	return response
+}
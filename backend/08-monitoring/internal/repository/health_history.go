@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+)
+
+// HealthHistory remembers each check's status and the transitions between
+// them, so a dependency that keeps going up and down shows as flapping
+// instead of looking healthy whenever it happens to be probed
+type HealthHistory struct {
+	maxTransitions  int
+	flapWindow      time.Duration
+	flapTransitions int
+
+	mu          sync.Mutex
+	current     map[string]models.HealthStatus
+	since       map[string]time.Time
+	transitions []models.HealthTransition // oldest first
+}
+
+// NewHealthHistory keeps the last maxTransitions transitions. A check with
+// at least flapTransitions of them within flapWindow is flapping.
+func NewHealthHistory(maxTransitions int, flapWindow time.Duration, flapTransitions int) *HealthHistory {
+	return &HealthHistory{
+		maxTransitions:  maxTransitions,
+		flapWindow:      flapWindow,
+		flapTransitions: flapTransitions,
+		current:         make(map[string]models.HealthStatus),
+		since:           make(map[string]time.Time),
+	}
+}
+
+// Record compares each check with its previous status and returns the
+// transitions. A check seen for the first time is assumed to have been
+// healthy, so one that starts out failing is reported.
+func (h *HealthHistory) Record(checks []models.HealthCheck) []models.HealthTransition {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var changed []models.HealthTransition
+	for _, check := range checks {
+		previous, seen := h.current[check.Name]
+		if !seen {
+			previous = models.HealthStatusHealthy
+		}
+		h.current[check.Name] = check.Status
+		if check.Status == previous {
+			continue
+		}
+
+		transition := models.HealthTransition{
+			Check:         check.Name,
+			From:          previous,
+			To:            check.Status,
+			Message:       check.Message,
+			Informational: check.Informational,
+			Timestamp:     check.Timestamp,
+		}
+		h.since[check.Name] = transition.Timestamp
+		changed = append(changed, transition)
+	}
+
+	h.transitions = append(h.transitions, changed...)
+	if over := len(h.transitions) - h.maxTransitions; over > 0 {
+		h.transitions = append([]models.HealthTransition(nil), h.transitions[over:]...)
+	}
+	return changed
+}
+
+// Transitions returns the newest transitions first, all checks when check is
+// empty, at most limit of them when limit > 0
+func (h *HealthHistory) Transitions(check string, limit int) []models.HealthTransition {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	transitions := make([]models.HealthTransition, 0)
+	for i := len(h.transitions) - 1; i >= 0; i-- {
+		if limit > 0 && len(transitions) == limit {
+			break
+		}
+		if check == "" || h.transitions[i].Check == check {
+			transitions = append(transitions, h.transitions[i])
+		}
+	}
+	return transitions
+}
+
+// Checks summarizes every check seen so far, ordered by name
+func (h *HealthHistory) Checks(now time.Time) []models.HealthCheckHistory {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	recent := make(map[string]int)
+	cutoff := now.Add(-h.flapWindow)
+	for _, t := range h.transitions {
+		if t.Timestamp.After(cutoff) {
+			recent[t.Check]++
+		}
+	}
+
+	checks := make([]models.HealthCheckHistory, 0, len(h.current))
+	for name, status := range h.current {
+		checks = append(checks, models.HealthCheckHistory{
+			Check:       name,
+			Status:      status,
+			Since:       h.since[name],
+			Transitions: recent[name],
+			Flapping:    recent[name] >= h.flapTransitions,
+		})
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Check < checks[j].Check })
+	return checks
+}
+
+// FlapWindow returns the span flapping is judged over
+func (h *HealthHistory) FlapWindow() time.Duration {
+	return h.flapWindow
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+)
+
+func checkAt(name string, status models.HealthStatus, at time.Time) models.HealthCheck {
+	return models.HealthCheck{Name: name, Status: status, Timestamp: at}
+}
+
+func TestHealthHistory_Record(t *testing.T) {
+	history := NewHealthHistory(10, 10*time.Minute, 3)
+	start := time.Now()
+
+	// Healthy on first sight is not a change; failing on first sight is
+	changed := history.Record([]models.HealthCheck{
+		checkAt("database", models.HealthStatusHealthy, start),
+		checkAt("api", models.HealthStatusDegraded, start),
+	})
+	require.Len(t, changed, 1)
+	assert.Equal(t, models.HealthTransition{Check: "api", From: models.HealthStatusHealthy, To: models.HealthStatusDegraded, Timestamp: start}, changed[0])
+
+	changed = history.Record([]models.HealthCheck{
+		checkAt("database", models.HealthStatusUnhealthy, start.Add(time.Minute)),
+		checkAt("api", models.HealthStatusDegraded, start.Add(time.Minute)),
+	})
+	require.Len(t, changed, 1)
+	assert.Equal(t, "database", changed[0].Check)
+
+	changed = history.Record([]models.HealthCheck{
+		checkAt("database", models.HealthStatusHealthy, start.Add(2*time.Minute)),
+		checkAt("api", models.HealthStatusHealthy, start.Add(2*time.Minute)),
+	})
+	assert.Len(t, changed, 2)
+	assert.True(t, changed[0].Recovered())
+
+	transitions := history.Transitions("", 0)
+	require.Len(t, transitions, 4)
+	assert.Equal(t, start.Add(2*time.Minute), transitions[0].Timestamp, "newest first")
+	assert.Len(t, history.Transitions("database", 0), 2)
+	assert.Len(t, history.Transitions("", 1), 1)
+}
+
+func TestHealthHistory_Flapping(t *testing.T) {
+	history := NewHealthHistory(3, 10*time.Minute, 3)
+	start := time.Now().Add(-5 * time.Minute)
+
+	statuses := []models.HealthStatus{models.HealthStatusUnhealthy, models.HealthStatusHealthy, models.HealthStatusUnhealthy, models.HealthStatusHealthy}
+	for i, status := range statuses {
+		history.Record([]models.HealthCheck{checkAt("api", status, start.Add(time.Duration(i)*time.Minute))})
+	}
+
+	assert.Len(t, history.Transitions("", 0), 3, "history is capped")
+
+	checks := history.Checks(time.Now())
+	require.Len(t, checks, 1)
+	assert.Equal(t, models.HealthStatusHealthy, checks[0].Status)
+	assert.Equal(t, start.Add(3*time.Minute), checks[0].Since)
+	assert.Equal(t, 3, checks[0].Transitions)
+	assert.True(t, checks[0].Flapping)
+
+	assert.False(t, history.Checks(time.Now().Add(time.Hour))[0].Flapping, "old transitions do not count")
+}
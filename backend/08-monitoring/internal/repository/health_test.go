@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+)
+
+// stubChecker reports whatever status it was last given and counts its runs
+type stubChecker struct {
+	name string
+
+	mu     sync.Mutex
+	status models.HealthStatus
+	calls  int
+	block  bool // Wait for the round's context to end before reporting
+}
+
+func newStubChecker(name string, status models.HealthStatus) *stubChecker {
+	return &stubChecker{name: name, status: status}
+}
+
+func (c *stubChecker) Check(ctx context.Context) models.HealthCheck {
+	c.mu.Lock()
+	c.calls++
+	status, block := c.status, c.block
+	c.mu.Unlock()
+
+	if block {
+		<-ctx.Done()
+		return models.HealthCheck{Name: c.name, Status: models.HealthStatusUnhealthy, Message: ctx.Err().Error(), Timestamp: time.Now()}
+	}
+	return models.HealthCheck{Name: c.name, Status: status, Timestamp: time.Now()}
+}
+
+func (c *stubChecker) set(status models.HealthStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status = status
+}
+
+func (c *stubChecker) runs() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func TestPerformHealthChecks_Status(t *testing.T) {
+	const (
+		healthy   = models.HealthStatusHealthy
+		degraded  = models.HealthStatusDegraded
+		unhealthy = models.HealthStatusUnhealthy
+	)
+
+	tests := []struct {
+		name          string
+		database      models.HealthStatus
+		cache         models.HealthStatus
+		informational []string
+		want          models.HealthStatus
+	}{
+		{name: "all healthy", database: healthy, cache: healthy, want: healthy},
+		{name: "one degraded", database: healthy, cache: degraded, want: degraded},
+		{name: "one unhealthy", database: unhealthy, cache: healthy, want: unhealthy},
+		{name: "unhealthy outranks a later degraded", database: unhealthy, cache: degraded, want: unhealthy},
+		{name: "unhealthy outranks an earlier degraded", database: degraded, cache: unhealthy, want: unhealthy},
+		{name: "informational unhealthy only degrades", database: healthy, cache: unhealthy, informational: []string{"cache"}, want: degraded},
+		{name: "informational does not hide a critical failure", database: unhealthy, cache: unhealthy, informational: []string{"cache"}, want: unhealthy},
+		{name: "no checks", want: healthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checkers []HealthChecker
+			if tt.database != "" {
+				checkers = append(checkers, newStubChecker("database", tt.database), newStubChecker("cache", tt.cache))
+			}
+			informational := map[string]bool{}
+			for _, name := range tt.informational {
+				informational[name] = true
+			}
+
+			response := NewMetricsRepository("1.0.0", "test").PerformHealthChecks(context.Background(), checkers, informational)
+			assert.Equal(t, tt.want, response.Status)
+			require.Len(t, response.Checks, len(checkers))
+			for _, check := range response.Checks {
+				assert.Equal(t, informational[check.Name], check.Informational, check.Name)
+			}
+		})
+	}
+}
+
+func TestHealthScheduler_Latest(t *testing.T) {
+	checker := newStubChecker("database", models.HealthStatusHealthy)
+	scheduler := NewHealthScheduler(NewMetricsRepository("1.0.0", "test"), []HealthChecker{checker}, nil, time.Minute, time.Second, NewHealthHistory(10, time.Minute, 3))
+	ctx := context.Background()
+
+	response := scheduler.Latest(ctx)
+	assert.Equal(t, 1, checker.runs(), "with nothing cached the checks run inline")
+	assert.False(t, response.Cached)
+	assert.Equal(t, models.HealthStatusHealthy, response.Status)
+
+	// The dependency goes down, but probes keep getting the cached round
+	checker.set(models.HealthStatusUnhealthy)
+	response = scheduler.Latest(ctx)
+	assert.Equal(t, 1, checker.runs())
+	assert.True(t, response.Cached)
+	assert.False(t, response.Stale)
+	assert.Equal(t, models.HealthStatusHealthy, response.Status)
+	assert.False(t, response.Timestamp.Before(response.CheckedAt), "the timestamp is when the result was served")
+
+	response = scheduler.Refresh(ctx)
+	assert.Equal(t, 2, checker.runs())
+	assert.False(t, response.Cached)
+	assert.Equal(t, models.HealthStatusUnhealthy, response.Status)
+	assert.Equal(t, models.HealthStatusUnhealthy, scheduler.Latest(ctx).Status)
+
+	// No round for more than two intervals means the scheduler has stopped
+	scheduler.mu.Lock()
+	scheduler.checkedAt = time.Now().Add(-3 * time.Minute)
+	scheduler.mu.Unlock()
+	assert.True(t, scheduler.Latest(ctx).Stale)
+}
+
+func TestHealthScheduler_Start(t *testing.T) {
+	checker := newStubChecker("database", models.HealthStatusHealthy)
+	scheduler := NewHealthScheduler(NewMetricsRepository("1.0.0", "test"), []HealthChecker{checker}, nil, 5*time.Millisecond, time.Second, NewHealthHistory(10, time.Minute, 3))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler.Start(ctx)
+
+	require.Eventually(t, func() bool { return checker.runs() >= 3 }, time.Second, time.Millisecond,
+		"one round right away, then one per interval")
+	assert.True(t, scheduler.Latest(context.Background()).Cached)
+
+	cancel()
+	// A round may already be under way when ctx ends; after that nothing runs
+	time.Sleep(20 * time.Millisecond)
+	stopped := checker.runs()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stopped, checker.runs())
+}
+
+func TestHealthScheduler_Timeout(t *testing.T) {
+	checker := newStubChecker("database", models.HealthStatusHealthy)
+	checker.block = true
+	scheduler := NewHealthScheduler(NewMetricsRepository("1.0.0", "test"), []HealthChecker{checker}, nil, time.Minute, 10*time.Millisecond, NewHealthHistory(10, time.Minute, 3))
+
+	began := time.Now()
+	response := scheduler.Refresh(context.Background())
+	assert.Less(t, time.Since(began), time.Second, "a hung dependency cannot hold up the round")
+	assert.Equal(t, models.HealthStatusUnhealthy, response.Status)
+	require.Len(t, response.Checks, 1)
+	assert.Equal(t, context.DeadlineExceeded.Error(), response.Checks[0].Message)
+}
+
+func TestHealthScheduler_OnTransition(t *testing.T) {
+	checker := newStubChecker("database", models.HealthStatusHealthy)
+	scheduler := NewHealthScheduler(NewMetricsRepository("1.0.0", "test"), []HealthChecker{checker}, nil, time.Minute, time.Second, NewHealthHistory(10, time.Minute, 3))
+
+	var mu sync.Mutex
+	var seen []models.HealthStatus
+	scheduler.OnTransition(func(_ context.Context, transition models.HealthTransition) {
+		// Slow enough that a later round's listener would overtake an unordered one
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, transition.To)
+	})
+
+	ctx := context.Background()
+	for _, status := range []models.HealthStatus{
+		models.HealthStatusHealthy, // First sight of a healthy check is not a change
+		models.HealthStatusDegraded,
+		models.HealthStatusDegraded,
+		models.HealthStatusUnhealthy,
+		models.HealthStatusHealthy,
+	} {
+		checker.set(status)
+		scheduler.Refresh(ctx)
+	}
+
+	want := []models.HealthStatus{models.HealthStatusDegraded, models.HealthStatusUnhealthy, models.HealthStatusHealthy}
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(seen) == len(want)
+	}, time.Second, time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, want, seen, "listeners see changes in the order they happened")
+	assert.Len(t, scheduler.History().Transitions("database", 0), len(want))
+}
@@ -2,22 +2,47 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/tracing"
 )
 
+// ErrMetricConflict is returned when a metric is registered or recorded with a
+// type or shape that differs from its existing definition
+var ErrMetricConflict = errors.New("metric conflicts with its definition")
+
+// processStart is taken during package initialization, before main runs, so
+// uptime covers startup work such as tracing and exporter setup
+var processStart = time.Now()
+
 // MetricsRepository handles metrics storage and retrieval
 type MetricsRepository struct {
 	mu            sync.RWMutex
 	requestCount  map[string]int64
 	errorCount    map[string]int64
 	customMetrics map[string]models.CustomMetric
+	series        map[string]*models.MetricSeries
+	definitions   map[string]models.MetricDefinition
+	distributions map[string]*models.BucketHistogram
+	cardinality   *cardinalityGuard
+	seriesPoints  int
+	retention     time.Duration
+	latency       map[string]*models.LatencyHistogram
+	routes        map[string]*routeStats
+	sample        processSample
 	startTime     time.Time
+	configLoaded  time.Time
 	version       string
 	environment   string
 }
@@ -39,18 +64,134 @@ type ExternalServiceHealthChecker struct {
 	url  string
 }
 
+// routeStats holds the per-route figures only analytics needs: responses by
+// status class and body size distributions
+type routeStats struct {
+	classes      map[string]int64
+	requestSize  *models.BucketHistogram
+	responseSize *models.BucketHistogram
+}
+
 // NewMetricsRepository creates a new metrics repository
 func NewMetricsRepository(version, environment string) *MetricsRepository {
 	return &MetricsRepository{
 		requestCount:  make(map[string]int64),
 		errorCount:    make(map[string]int64),
 		customMetrics: make(map[string]models.CustomMetric),
-		startTime:     time.Now(),
+		series:        make(map[string]*models.MetricSeries),
+		definitions:   make(map[string]models.MetricDefinition),
+		distributions: make(map[string]*models.BucketHistogram),
+		cardinality:   newCardinalityGuard(100, OverflowAggregate),
+		seriesPoints:  1000,
+		retention:     time.Hour,
+		latency:       make(map[string]*models.LatencyHistogram),
+		routes:        make(map[string]*routeStats),
+		startTime:     processStart,
+		configLoaded:  processStart,
 		version:       version,
 		environment:   environment,
 	}
 }
 
+// SetSeriesRetention bounds the history kept per custom metric: at most
+// maxPoints points no older than retention. It applies to series created
+// afterwards, so call it before recording metrics.
+func (r *MetricsRepository) SetSeriesRetention(retention time.Duration, maxPoints int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retention = retention
+	r.seriesPoints = maxPoints
+}
+
+// SetCardinalityLimit caps the distinct label sets per custom metric; past
+// the limit new sets are aggregated or dropped depending on overflow. A limit
+// of 0 disables the guard. Call it before recording metrics.
+func (r *MetricsRepository) SetCardinalityLimit(limit int, overflow string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cardinality = newCardinalityGuard(limit, overflow)
+}
+
+// StartTime returns when the process started
+func (r *MetricsRepository) StartTime() time.Time {
+	return r.startTime
+}
+
+// Uptime returns how long the process has been running
+func (r *MetricsRepository) Uptime() time.Duration {
+	return time.Since(r.startTime)
+}
+
+// MarkConfigLoaded records that configuration was (re)loaded just now
+func (r *MetricsRepository) MarkConfigLoaded() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.configLoaded = time.Now()
+}
+
+// ConfigLoadedAt returns when configuration was last loaded
+func (r *MetricsRepository) ConfigLoadedAt() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.configLoaded
+}
+
+// RegisterMetric fixes a custom metric's type and its buckets or objectives
+// before it is recorded. Registering the same definition again is a no-op.
+func (r *MetricsRepository) RegisterMetric(def models.MetricDefinition) error {
+	if err := def.Validate(); err != nil {
+		return fmt.Errorf("invalid definition: %w", err)
+	}
+	def = def.WithDefaults()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.definitions[def.Name]; ok {
+		if !existing.Equal(def) {
+			return fmt.Errorf("metric %s: %w", def.Name, ErrMetricConflict)
+		}
+		return nil
+	}
+	// Series recorded before registration used the defaults; their buckets
+	// cannot be redrawn, but summary quantiles are computed on read
+	for _, metric := range r.customMetrics {
+		if metric.Name != def.Name {
+			continue
+		}
+		if metric.Type != def.Type {
+			return fmt.Errorf("metric %s already recorded as %s: %w", def.Name, metric.Type, ErrMetricConflict)
+		}
+		if def.Type == "histogram" && !def.Equal(models.MetricDefinition{Name: def.Name, Type: def.Type, Help: def.Help}.WithDefaults()) {
+			return fmt.Errorf("metric %s already recorded with default buckets: %w", def.Name, ErrMetricConflict)
+		}
+	}
+
+	r.definitions[def.Name] = def
+	return nil
+}
+
+// GetMetricDefinitions returns the registered definitions sorted by name
+func (r *MetricsRepository) GetMetricDefinitions() []models.MetricDefinition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.definitionsLocked()
+}
+
+func (r *MetricsRepository) definitionsLocked() []models.MetricDefinition {
+	result := make([]models.MetricDefinition, 0, len(r.definitions))
+	for _, def := range r.definitions {
+		result = append(result, def)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
 // RecordRequest records HTTP request metrics
 func (r *MetricsRepository) RecordRequest(metrics models.RequestMetrics) error {
 	r.mu.Lock()
@@ -59,6 +200,28 @@ func (r *MetricsRepository) RecordRequest(metrics models.RequestMetrics) error {
 	key := fmt.Sprintf("%s:%s", metrics.Method, metrics.Path)
 	r.requestCount[key]++
 
+	histogram, ok := r.latency[key]
+	if !ok {
+		histogram = models.NewLatencyHistogram()
+		r.latency[key] = histogram
+	}
+	histogram.Observe(metrics.Duration)
+
+	stats, ok := r.routes[key]
+	if !ok {
+		stats = &routeStats{
+			classes:      make(map[string]int64),
+			requestSize:  models.NewBucketHistogram(models.SizeBuckets),
+			responseSize: models.NewBucketHistogram(models.SizeBuckets),
+		}
+		r.routes[key] = stats
+	}
+	if class := models.StatusClass(metrics.StatusCode); class != "" {
+		stats.classes[class]++
+	}
+	stats.requestSize.Observe(float64(metrics.RequestSize))
+	stats.responseSize.Observe(float64(metrics.ResponseSize))
+
 	if metrics.StatusCode >= 400 {
 		errorKey := fmt.Sprintf("%s:%d", key, metrics.StatusCode)
 		r.errorCount[errorKey]++
@@ -76,9 +239,45 @@ func (r *MetricsRepository) RecordCustomMetric(metric models.CustomMetric) error
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	def, defined := r.definitions[metric.Name]
+	if defined && def.Type != metric.Type {
+		return fmt.Errorf("metric %s is registered as %s: %w", metric.Name, def.Type, ErrMetricConflict)
+	}
+
 	key := r.buildMetricKey(metric.Name, metric.Labels)
+	labels, aggregated, err := r.cardinality.admit(metric.Name, key, metric.Labels)
+	if err != nil {
+		return fmt.Errorf("metric %s: %w", metric.Name, err)
+	}
+	if aggregated {
+		metric.Labels = labels
+		key = r.buildMetricKey(metric.Name, labels)
+	}
 	r.customMetrics[key] = metric
 
+	series, ok := r.series[key]
+	if !ok {
+		series = models.NewMetricSeries(r.seriesPoints, r.retention)
+		r.series[key] = series
+	}
+	series.Add(models.SeriesPoint{Timestamp: metric.Timestamp, Value: metric.Value})
+
+	if metric.Type == "histogram" || metric.Type == "summary" {
+		h, ok := r.distributions[key]
+		if !ok {
+			var bounds []float64
+			if metric.Type == "histogram" {
+				bounds = models.DefaultBuckets
+				if defined {
+					bounds = def.Buckets
+				}
+			}
+			h = models.NewBucketHistogram(bounds)
+			r.distributions[key] = h
+		}
+		h.Observe(metric.Value)
+	}
+
 	return nil
 }
 
@@ -94,6 +293,18 @@ func (r *MetricsRepository) GetRequestMetrics() map[string]int64 {
 	return result
 }
 
+// GetLatencyMetrics returns latency percentiles per method and route
+func (r *MetricsRepository) GetLatencyMetrics() map[string]models.LatencyStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[string]models.LatencyStats)
+	for k, h := range r.latency {
+		result[k] = h.Stats()
+	}
+	return result
+}
+
 // GetErrorMetrics returns error count metrics
 func (r *MetricsRepository) GetErrorMetrics() map[string]int64 {
 	r.mu.RLock()
@@ -106,6 +317,74 @@ func (r *MetricsRepository) GetErrorMetrics() map[string]int64 {
 	return result
 }
 
+// GetRouteTraffic returns a copy of everything recorded per route, ordered
+// by route
+func (r *MetricsRepository) GetRouteTraffic() []models.RouteTraffic {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	routes := make([]models.RouteTraffic, 0, len(r.requestCount))
+	for key, count := range r.requestCount {
+		route := models.RouteTraffic{
+			Route:         key,
+			Requests:      count,
+			StatusClasses: make(map[string]int64),
+			RequestSize:   models.NewBucketHistogram(models.SizeBuckets),
+			ResponseSize:  models.NewBucketHistogram(models.SizeBuckets),
+		}
+		if h, ok := r.latency[key]; ok {
+			route.Latency = h.Stats()
+		}
+		if stats, ok := r.routes[key]; ok {
+			for class, n := range stats.classes {
+				route.StatusClasses[class] = n
+			}
+			route.RequestSize.Merge(stats.requestSize)
+			route.ResponseSize.Merge(stats.responseSize)
+		}
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Route < routes[j].Route })
+	return routes
+}
+
+// TrafficSnapshot is a consistent copy of the cumulative request counters,
+// keyed by "METHOD:/route"
+type TrafficSnapshot struct {
+	Requests     map[string]int64
+	ServerErrors map[string]int64 // 5xx responses only
+	Latency      map[string]*models.LatencyHistogram
+	Taken        time.Time
+}
+
+// GetTrafficSnapshot copies request counts, 5xx counts and latency
+// histograms under one lock so rates computed from them line up
+func (r *MetricsRepository) GetTrafficSnapshot() TrafficSnapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot := TrafficSnapshot{
+		Requests:     make(map[string]int64, len(r.requestCount)),
+		ServerErrors: make(map[string]int64),
+		Latency:      make(map[string]*models.LatencyHistogram, len(r.latency)),
+		Taken:        time.Now(),
+	}
+	for k, v := range r.requestCount {
+		snapshot.Requests[k] = v
+	}
+	for k, v := range r.errorCount {
+		// errorCount keys are "METHOD:/route:status"
+		idx := strings.LastIndex(k, ":")
+		if status, err := strconv.Atoi(k[idx+1:]); err == nil && status >= 500 {
+			snapshot.ServerErrors[k[:idx]] += v
+		}
+	}
+	for k, h := range r.latency {
+		snapshot.Latency[k] = h.Clone()
+	}
+	return snapshot
+}
+
 // GetCustomMetrics returns all custom metrics
 func (r *MetricsRepository) GetCustomMetrics() []models.CustomMetric {
 	r.mu.RLock()
@@ -118,36 +397,230 @@ func (r *MetricsRepository) GetCustomMetrics() []models.CustomMetric {
 	return result
 }
 
+// QueryCustomMetric returns the history since the given time of every
+// series named name whose labels include all of labels
+func (r *MetricsRepository) QueryCustomMetric(name string, labels map[string]string, since time.Time) []models.SeriesResult {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var result []models.SeriesResult
+	for key, metric := range r.customMetrics {
+		if metric.Name != name || !hasLabels(metric.Labels, labels) {
+			continue
+		}
+		result = append(result, models.SeriesResult{
+			Name:   metric.Name,
+			Type:   metric.Type,
+			Labels: metric.Labels,
+			Points: r.series[key].Points(since, now),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return r.buildMetricKey(name, result[i].Labels) < r.buildMetricKey(name, result[j].Labels)
+	})
+	return result
+}
+
+// GetDistributions returns every histogram and summary series: cumulative
+// buckets for histograms, and for summaries the configured quantiles over
+// the points still retained
+func (r *MetricsRepository) GetDistributions() []models.Distribution {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.distributionsLocked(time.Now())
+}
+
+// distributionsLocked builds the distributions; r.mu must be held
+func (r *MetricsRepository) distributionsLocked(now time.Time) []models.Distribution {
+	result := make([]models.Distribution, 0, len(r.distributions))
+	for key, h := range r.distributions {
+		metric := r.customMetrics[key]
+		def := r.definitions[metric.Name]
+		d := models.Distribution{
+			Name:   metric.Name,
+			Type:   metric.Type,
+			Help:   def.Help,
+			Labels: metric.Labels,
+			Count:  h.Count(),
+			Sum:    h.Sum(),
+		}
+
+		if metric.Type == "histogram" {
+			d.Buckets = h.Cumulative()
+		} else {
+			objectives := def.Objectives
+			if len(objectives) == 0 {
+				objectives = models.DefaultObjectives
+			}
+			points := r.series[key].Points(time.Time{}, now)
+			values := make([]float64, len(points))
+			for i, p := range points {
+				values[i] = p.Value
+			}
+			d.Quantiles = models.Quantiles(values, objectives)
+		}
+		result = append(result, d)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return r.buildMetricKey(result[i].Name, result[i].Labels) < r.buildMetricKey(result[j].Name, result[j].Labels)
+	})
+	return result
+}
+
+// ResetCustomMetrics forgets the values, history and label sets of every
+// custom metric named name, or starting with prefix when name is empty, and
+// returns the number of series removed. Definitions are kept. An empty name
+// and prefix reset all custom metrics. Request metrics are never reset:
+// alerting and SLOs compute rates from their deltas.
+func (r *MetricsRepository) ResetCustomMetrics(name, prefix string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for key, metric := range r.customMetrics {
+		if name != "" && metric.Name != name || name == "" && !strings.HasPrefix(metric.Name, prefix) {
+			continue
+		}
+		delete(r.customMetrics, key)
+		delete(r.series, key)
+		delete(r.distributions, key)
+		r.cardinality.forget(metric.Name)
+		removed++
+	}
+	return removed
+}
+
+// GetSnapshot copies every metric under one lock so the figures line up
+func (r *MetricsRepository) GetSnapshot() models.MetricsSnapshot {
+	system := r.GetSystemMetrics()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	snapshot := models.MetricsSnapshot{
+		Version:       r.version,
+		Environment:   r.environment,
+		Uptime:        now.Sub(r.startTime).Round(time.Second).String(),
+		Requests:      make(map[string]int64, len(r.requestCount)),
+		Latency:       make(map[string]models.LatencyStats, len(r.latency)),
+		Errors:        make(map[string]int64, len(r.errorCount)),
+		CustomMetrics: make([]models.CustomMetric, 0, len(r.customMetrics)),
+		Distributions: r.distributionsLocked(now),
+		Definitions:   r.definitionsLocked(),
+		Cardinality:   r.cardinality.report(),
+		System:        system,
+		TakenAt:       now,
+	}
+	for k, v := range r.requestCount {
+		snapshot.Requests[k] = v
+	}
+	for k, h := range r.latency {
+		snapshot.Latency[k] = h.Stats()
+	}
+	for k, v := range r.errorCount {
+		snapshot.Errors[k] = v
+	}
+
+	keys := make([]string, 0, len(r.customMetrics))
+	for key := range r.customMetrics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		snapshot.CustomMetrics = append(snapshot.CustomMetrics, r.customMetrics[key])
+	}
+	return snapshot
+}
+
+// GetCardinalityReport returns label usage per custom metric
+func (r *MetricsRepository) GetCardinalityReport() []models.CardinalityReport {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cardinality.report()
+}
+
+// GetCustomSeriesSince returns, for every custom metric, the points recorded
+// after since and no later than until
+func (r *MetricsRepository) GetCustomSeriesSince(since, until time.Time) []models.SeriesResult {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.SeriesResult
+	for key, metric := range r.customMetrics {
+		var points []models.SeriesPoint
+		for _, p := range r.series[key].Points(since, until) {
+			if p.Timestamp.After(since) && !p.Timestamp.After(until) {
+				points = append(points, p)
+			}
+		}
+		if len(points) > 0 {
+			result = append(result, models.SeriesResult{
+				Name:   metric.Name,
+				Type:   metric.Type,
+				Labels: metric.Labels,
+				Points: points,
+			})
+		}
+	}
+	return result
+}
+
+func hasLabels(labels, want map[string]string) bool {
+	for k, v := range want {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
 // GetSystemMetrics returns current system metrics
 func (r *MetricsRepository) GetSystemMetrics() models.SystemMetrics {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 
+	r.mu.RLock()
+	sample := r.sample
+	r.mu.RUnlock()
+
 	return models.SystemMetrics{
-		CPUUsage:       0, // Simplified - would need CPU monitoring
+		CPUUsage:       sample.processCPU,
+		SystemCPUUsage: sample.systemCPU,
 		MemoryUsage:    int64(m.Alloc),
 		MemoryTotal:    int64(m.Sys),
+		RSS:            sample.rss,
+		OpenFDs:        sample.openFDs,
 		GoroutineCount: runtime.NumGoroutine(),
 		HeapInUse:      int64(m.HeapInuse),
 		HeapAlloc:      int64(m.HeapAlloc),
+		GC:             sample.gc,
 		Timestamp:      time.Now(),
 	}
 }
 
-// PerformHealthChecks runs all health checks and returns results
-func (r *MetricsRepository) PerformHealthChecks(ctx context.Context, checkers []HealthChecker) models.HealthResponse {
+// PerformHealthChecks runs all health checks and returns results. Checks
+// named in informational are reported but can only degrade the overall
+// status, never make it unhealthy.
+func (r *MetricsRepository) PerformHealthChecks(ctx context.Context, checkers []HealthChecker, informational map[string]bool) models.HealthResponse {
 	var checks []models.HealthCheck
 	overallStatus := models.HealthStatusHealthy
 
 	// Run all health checks
 	for _, checker := range checkers {
 		check := checker.Check(ctx)
+		check.Informational = informational[check.Name]
 		checks = append(checks, check)
 
 		// Determine overall status
-		if check.Status == models.HealthStatusUnhealthy {
+		if check.Status == models.HealthStatusUnhealthy && !check.Informational {
 			overallStatus = models.HealthStatusUnhealthy
-		} else if check.Status == models.HealthStatusDegraded && overallStatus == models.HealthStatusHealthy {
+		} else if check.Status != models.HealthStatusHealthy && overallStatus == models.HealthStatusHealthy {
 			overallStatus = models.HealthStatusDegraded
 		}
 	}
@@ -162,11 +635,18 @@ func (r *MetricsRepository) PerformHealthChecks(ctx context.Context, checkers []
 	}
 }
 
-// buildMetricKey creates a unique key for metrics with labels
+// buildMetricKey creates a unique key for metrics with labels. Labels are
+// sorted so the same set always maps to the same series.
 func (r *MetricsRepository) buildMetricKey(name string, labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
 	key := name
-	for k, v := range labels {
-		key += fmt.Sprintf(",%s=%s", k, v)
+	for _, k := range names {
+		key += fmt.Sprintf(",%s=%s", k, labels[k])
 	}
 	return key
 }
@@ -219,6 +699,9 @@ func (e *ExternalServiceHealthChecker) Check(ctx context.Context) models.HealthC
 	// Create HTTP client with timeout
 	client := &http.Client{Timeout: 5 * time.Second}
 
+	ctx, span := tracing.StartSpan(ctx, "health_check "+e.name, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
 	req, err := http.NewRequestWithContext(ctx, "GET", e.url, nil)
 	if err != nil {
 		check, _ := models.NewHealthCheck(e.name, fmt.Sprintf("Failed to create request: %v", err),
@@ -226,8 +709,11 @@ func (e *ExternalServiceHealthChecker) Check(ctx context.Context) models.HealthC
 		return *check
 	}
 
+	tracing.Inject(ctx, req.Header)
+
 	resp, err := client.Do(req)
 	if err != nil {
+		span.RecordError(err)
 		status = models.HealthStatusUnhealthy
 		message = fmt.Sprintf("Request failed: %v", err)
 	} else {
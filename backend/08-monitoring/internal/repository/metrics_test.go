@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+)
+
+func record(t *testing.T, repo *MetricsRepository, name, metricType string, value float64) {
+	t.Helper()
+	require.NoError(t, repo.RecordCustomMetric(models.CustomMetric{
+		Name:      name,
+		Type:      metricType,
+		Value:     value,
+		Timestamp: time.Now(),
+	}))
+}
+
+func TestResetCustomMetrics(t *testing.T) {
+	repo := NewMetricsRepository("test", "test")
+	record(t, repo, "loadtest_requests", "counter", 1)
+	record(t, repo, "loadtest_latency", "histogram", 0.2)
+	record(t, repo, "queue_depth", "gauge", 3)
+
+	assert.Equal(t, 2, repo.ResetCustomMetrics("", "loadtest_"))
+	assert.Len(t, repo.GetCustomMetrics(), 1)
+	assert.Empty(t, repo.GetDistributions())
+	assert.Len(t, repo.GetCardinalityReport(), 1)
+
+	assert.Equal(t, 0, repo.ResetCustomMetrics("queue", ""), "name must match exactly")
+	assert.Equal(t, 1, repo.ResetCustomMetrics("", ""))
+	assert.Empty(t, repo.GetCustomMetrics())
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/process"
+	"github.com/sirupsen/logrus"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+)
+
+// processSample is the latest reading from the system sampler
+type processSample struct {
+	processCPU float64
+	systemCPU  float64
+	rss        int64
+	openFDs    int32
+	gc         models.GCStats
+}
+
+// StartSystemSampler samples CPU, RSS, open file descriptors and GC pauses
+// every interval until ctx is done. CPU percentages need two readings, so
+// they cover the time between samples rather than a single instant.
+func (r *MetricsRepository) StartSystemSampler(ctx context.Context, interval time.Duration) {
+	proc, err := process.NewProcessWithContext(ctx, int32(os.Getpid()))
+	if err != nil {
+		logrus.WithError(err).Warn("System sampler disabled")
+		return
+	}
+
+	// Store a first sample now, so RSS, file descriptors and GC are there
+	// before the first tick. It also primes the CPU counters: CPU reads 0
+	// until the next sample has a baseline to compare with.
+	r.storeSample(sampleProcess(ctx, proc))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.storeSample(sampleProcess(ctx, proc))
+			}
+		}
+	}()
+}
+
+func (r *MetricsRepository) storeSample(sample processSample) {
+	r.mu.Lock()
+	r.sample = sample
+	r.mu.Unlock()
+}
+
+func sampleProcess(ctx context.Context, proc *process.Process) processSample {
+	var sample processSample
+
+	if percent, err := proc.PercentWithContext(ctx, 0); err == nil {
+		sample.processCPU = percent
+	}
+	if percents, err := cpu.PercentWithContext(ctx, 0, false); err == nil && len(percents) > 0 {
+		sample.systemCPU = percents[0]
+	}
+	if mem, err := proc.MemoryInfoWithContext(ctx); err == nil {
+		sample.rss = int64(mem.RSS)
+	}
+	if fds, err := proc.NumFDsWithContext(ctx); err == nil {
+		sample.openFDs = fds
+	}
+
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+	sample.gc = models.GCStats{
+		NumGC:        gc.NumGC,
+		LastGC:       gc.LastGC,
+		PauseTotalMs: float64(gc.PauseTotal) / float64(time.Millisecond),
+	}
+	if len(gc.Pause) > 0 {
+		sample.gc.LastPauseMs = float64(gc.Pause[0]) / float64(time.Millisecond)
+	}
+
+	return sample
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleProcess(t *testing.T) {
+	ctx := context.Background()
+	proc, err := process.NewProcessWithContext(ctx, int32(os.Getpid()))
+	require.NoError(t, err)
+
+	runtime.GC()
+	sample := sampleProcess(ctx, proc)
+
+	assert.Positive(t, sample.rss)
+	if runtime.GOOS == "linux" {
+		assert.Positive(t, sample.openFDs, "stdin, stdout and stderr at least")
+	}
+	assert.Positive(t, sample.gc.NumGC)
+	assert.False(t, sample.gc.LastGC.IsZero())
+	assert.GreaterOrEqual(t, sample.gc.PauseTotalMs, sample.gc.LastPauseMs)
+	assert.GreaterOrEqual(t, sample.processCPU, 0.0)
+	assert.GreaterOrEqual(t, sample.systemCPU, 0.0)
+}
+
+func TestStartSystemSampler_FirstSample(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := NewMetricsRepository("1.0.0", "test")
+	repo.StartSystemSampler(ctx, time.Hour)
+
+	// Long before the first tick, the process figures are already there
+	metrics := repo.GetSystemMetrics()
+	assert.Positive(t, metrics.RSS)
+	if runtime.GOOS == "linux" {
+		assert.Positive(t, metrics.OpenFDs)
+	}
+}
@@ -0,0 +1,222 @@
+package slo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/repository"
+)
+
+// ErrObjectiveNotFound is returned for operations on an unknown objective ID
+var ErrObjectiveNotFound = errors.New("objective not found")
+
+// sample holds an objective's cumulative event counts at one moment; the
+// difference between two samples gives the events in between
+type sample struct {
+	at    time.Time
+	total float64
+	bad   float64
+}
+
+// Tracker samples request metrics on a ticker and computes error budgets and
+// burn rates per objective from the differences between samples
+type Tracker struct {
+	repo    *repository.MetricsRepository
+	period  time.Duration
+	windows []models.BurnRateWindow
+
+	mu         sync.Mutex
+	objectives []models.Objective
+	history    map[string][]sample // by objective ID, oldest first
+	nextID     int
+}
+
+// NewTracker creates a tracker whose error budgets cover period
+func NewTracker(repo *repository.MetricsRepository, period time.Duration) *Tracker {
+	return &Tracker{
+		repo:    repo,
+		period:  period,
+		windows: models.DefaultBurnRateWindows,
+		history: make(map[string][]sample),
+	}
+}
+
+// AddObjective validates the objective, assigns it an ID and starts tracking
+// it from the next sample
+func (t *Tracker) AddObjective(o models.Objective) (models.Objective, error) {
+	if err := o.Validate(); err != nil {
+		return models.Objective{}, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.nextID++
+	o.ID = strconv.Itoa(t.nextID)
+	t.objectives = append(t.objectives, o)
+	return o, nil
+}
+
+// DeleteObjective stops tracking an objective
+func (t *Tracker) DeleteObjective(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, o := range t.objectives {
+		if o.ID == id {
+			t.objectives = append(t.objectives[:i], t.objectives[i+1:]...)
+			delete(t.history, id)
+			return nil
+		}
+	}
+	return ErrObjectiveNotFound
+}
+
+// Run samples right away and then every interval until ctx is done
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	t.Sample()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Sample()
+		}
+	}
+}
+
+// Sample records the current cumulative counts of every objective
+func (t *Tracker) Sample() {
+	snapshot := t.repo.GetTrafficSnapshot()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cutoff := snapshot.Taken.Add(-t.period)
+	for _, o := range t.objectives {
+		total, bad := countEvents(o, snapshot)
+		t.history[o.ID] = trimHistory(append(t.history[o.ID], sample{at: snapshot.Taken, total: total, bad: bad}), cutoff)
+	}
+}
+
+// trimHistory drops samples from before cutoff, keeping the last of them as
+// the period's baseline
+func trimHistory(history []sample, cutoff time.Time) []sample {
+	keep := 0
+	for keep < len(history)-1 && !history[keep+1].at.After(cutoff) {
+		keep++
+	}
+	return history[keep:]
+}
+
+// Status reports every objective's budget and burn rates
+func (t *Tracker) Status() []models.SLOStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	statuses := make([]models.SLOStatus, 0, len(t.objectives))
+	for _, o := range t.objectives {
+		history := t.history[o.ID]
+		total, bad := delta(history, t.period)
+
+		status := models.SLOStatus{
+			Objective:       o,
+			Period:          t.period.String(),
+			TotalEvents:     int64(total),
+			BadEvents:       int64(math.Round(bad)),
+			Compliance:      100,
+			BudgetRemaining: round2(models.BudgetRemaining(total, bad, o.Target)),
+			BurnRates:       make(map[string]float64),
+			Alerts:          []models.BurnRateAlert{},
+		}
+		if total > 0 {
+			status.Compliance = round2(100 * (1 - bad/total))
+		}
+
+		burn := func(window time.Duration) float64 {
+			total, bad := delta(history, window)
+			rate := round2(models.BurnRate(total, bad, o.Target))
+			status.BurnRates[shortDuration(window)] = rate
+			return rate
+		}
+		for _, w := range t.windows {
+			long, short := burn(w.Long), burn(w.Short)
+			if long > w.Factor && short > w.Factor {
+				status.Alerts = append(status.Alerts, models.BurnRateAlert{
+					Severity:    w.Severity,
+					LongWindow:  shortDuration(w.Long),
+					ShortWindow: shortDuration(w.Short),
+					Factor:      w.Factor,
+					LongBurn:    long,
+					ShortBurn:   short,
+				})
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// countEvents returns the cumulative total and bad events for an objective
+func countEvents(o models.Objective, snapshot repository.TrafficSnapshot) (total, bad float64) {
+	for key, count := range snapshot.Requests {
+		if o.Route != "" && key != o.Route {
+			continue
+		}
+		total += float64(count)
+
+		switch o.Kind {
+		case models.SLOAvailability:
+			bad += float64(snapshot.ServerErrors[key])
+		case models.SLOLatency:
+			if h, ok := snapshot.Latency[key]; ok {
+				bad += float64(count) - h.CountBelow(o.ThresholdMs)
+			}
+		}
+	}
+	return total, bad
+}
+
+// delta returns the events recorded during the last window. While the
+// history is shorter than the window it covers all of it.
+func delta(history []sample, window time.Duration) (total, bad float64) {
+	if len(history) < 2 {
+		return 0, 0
+	}
+
+	latest := history[len(history)-1]
+	cutoff := latest.at.Add(-window)
+	base := history[0]
+	for _, s := range history {
+		if s.at.After(cutoff) {
+			break
+		}
+		base = s
+	}
+	return latest.total - base.total, latest.bad - base.bad
+}
+
+// shortDuration formats 1h0m0s as 1h and 5m0s as 5m
+func shortDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return strconv.Itoa(int(d/time.Hour)) + "h"
+	case d%time.Minute == 0:
+		return strconv.Itoa(int(d/time.Minute)) + "m"
+	}
+	return d.String()
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,217 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/repository"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// counters builds a cumulative history with one sample per step, each adding
+// the given total and bad events
+func counters(step time.Duration, increments ...[2]float64) []sample {
+	var history []sample
+	var total, bad float64
+	for i, inc := range increments {
+		total += inc[0]
+		bad += inc[1]
+		history = append(history, sample{at: start.Add(time.Duration(i) * step), total: total, bad: bad})
+	}
+	return history
+}
+
+// repeat returns n copies of the increment
+func repeat(n int, inc [2]float64) [][2]float64 {
+	result := make([][2]float64, n)
+	for i := range result {
+		result[i] = inc
+	}
+	return result
+}
+
+func TestDelta(t *testing.T) {
+	history := counters(time.Minute,
+		[2]float64{0, 0},   // 0m
+		[2]float64{100, 1}, // 1m
+		[2]float64{100, 2}, // 2m
+		[2]float64{100, 3}, // 3m
+	)
+
+	tests := []struct {
+		name      string
+		history   []sample
+		window    time.Duration
+		wantTotal float64
+		wantBad   float64
+	}{
+		{name: "no samples", window: time.Minute},
+		{name: "one sample is not a delta", history: history[:1], window: time.Minute},
+		{name: "last minute", history: history, window: time.Minute, wantTotal: 100, wantBad: 3},
+		{name: "last two minutes", history: history, window: 2 * time.Minute, wantTotal: 200, wantBad: 5},
+		{name: "between samples uses the older one", history: history, window: 90 * time.Second, wantTotal: 200, wantBad: 5},
+		{name: "longer than the history covers all of it", history: history, window: time.Hour, wantTotal: 300, wantBad: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, bad := delta(tt.history, tt.window)
+			assert.Equal(t, tt.wantTotal, total)
+			assert.Equal(t, tt.wantBad, bad)
+		})
+	}
+}
+
+func TestTrimHistory(t *testing.T) {
+	history := counters(time.Minute, repeat(5, [2]float64{10, 0})...)
+
+	trimmed := trimHistory(history, start.Add(150*time.Second))
+	require.Len(t, trimmed, 3)
+	assert.Equal(t, start.Add(2*time.Minute), trimmed[0].at, "the last sample before the cutoff is kept as the baseline")
+
+	assert.Len(t, trimHistory(history[:1], start.Add(time.Hour)), 1, "the only sample is never dropped")
+}
+
+func TestTracker_SampleFromRepository(t *testing.T) {
+	repo := repository.NewMetricsRepository("test", "test")
+	tracker := NewTracker(repo, time.Hour)
+
+	availability, err := tracker.AddObjective(models.Objective{Name: "api", Route: "GET:/api", Kind: models.SLOAvailability, Target: 99})
+	require.NoError(t, err)
+	_, err = tracker.AddObjective(models.Objective{Name: "fast", Kind: models.SLOLatency, Target: 90, ThresholdMs: 100})
+	require.NoError(t, err)
+
+	record := func(path string, status int, duration time.Duration, n int) {
+		for i := 0; i < n; i++ {
+			require.NoError(t, repo.RecordRequest(models.RequestMetrics{Method: "GET", Path: path, StatusCode: status, Duration: duration}))
+		}
+	}
+
+	// Traffic before the first sample is the baseline, not part of any window
+	record("/api", 500, 5*time.Millisecond, 50)
+	tracker.Sample()
+
+	statuses := tracker.Status()
+	require.Len(t, statuses, 2)
+	for _, status := range statuses {
+		assert.Zero(t, status.TotalEvents, "one sample is not enough for a delta")
+		assert.Equal(t, 100.0, status.Compliance)
+		assert.Equal(t, 100.0, status.BudgetRemaining)
+		assert.Equal(t, map[string]float64{"1h": 0, "5m": 0, "6h": 0, "30m": 0}, status.BurnRates)
+		assert.Empty(t, status.Alerts)
+	}
+
+	record("/api", 200, 5*time.Millisecond, 98)
+	record("/api", 503, 5*time.Millisecond, 2)
+	record("/other", 500, 2*time.Second, 100)
+	tracker.Sample()
+
+	statuses = tracker.Status()
+	require.Len(t, statuses, 2)
+
+	api := statuses[0]
+	assert.Equal(t, availability.ID, api.ID)
+	assert.Equal(t, int64(100), api.TotalEvents, "only the route's requests since the first sample")
+	assert.Equal(t, int64(2), api.BadEvents)
+	assert.Equal(t, 98.0, api.Compliance)
+	assert.Equal(t, -100.0, api.BudgetRemaining, "twice the 1% budget is spent")
+
+	fast := statuses[1]
+	assert.Equal(t, int64(200), fast.TotalEvents, "no route means every route")
+	assert.Equal(t, int64(100), fast.BadEvents, "the 2s requests are over the threshold")
+	assert.Equal(t, 50.0, fast.Compliance)
+}
+
+func TestTracker_BurnRateAlerts(t *testing.T) {
+	const step = 5 * time.Minute
+	clean := [2]float64{1000, 0}
+
+	tests := []struct {
+		name       string
+		increments [][2]float64 // A baseline, then 6h of 5 minute steps
+		wantAlerts []string
+	}{
+		{
+			name:       "healthy",
+			increments: repeat(73, clean),
+		},
+		{
+			// 20% errors in the last 5 minutes burns 1.7% of an hour's traffic
+			name:       "sudden outage pages",
+			increments: append(repeat(72, clean), [2]float64{1000, 200}),
+			wantAlerts: []string{"page"},
+		},
+		{
+			// The hour still looks bad, but the short window shows it is over
+			name:       "recovered outage stops paging",
+			increments: append(append(repeat(70, clean), [2]float64{1000, 200}), clean, clean),
+		},
+		{
+			// 1% errors all along burns 10x: too slow to page, enough for a ticket
+			name:       "slow burn opens a ticket",
+			increments: repeat(73, [2]float64{1000, 10}),
+			wantAlerts: []string{"ticket"},
+		},
+		{
+			name:       "hard outage does both",
+			increments: repeat(73, [2]float64{1000, 100}),
+			wantAlerts: []string{"page", "ticket"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker(repository.NewMetricsRepository("test", "test"), 24*time.Hour)
+			objective, err := tracker.AddObjective(models.Objective{Name: "api", Kind: models.SLOAvailability, Target: 99.9})
+			require.NoError(t, err)
+
+			tracker.history[objective.ID] = counters(step, tt.increments...)
+
+			statuses := tracker.Status()
+			require.Len(t, statuses, 1)
+
+			var severities []string
+			for _, alert := range statuses[0].Alerts {
+				severities = append(severities, alert.Severity)
+				assert.Greater(t, alert.LongBurn, alert.Factor)
+				assert.Greater(t, alert.ShortBurn, alert.Factor)
+			}
+			assert.Equal(t, tt.wantAlerts, severities)
+		})
+	}
+}
+
+func TestTracker_BurnRatesByWindow(t *testing.T) {
+	tracker := NewTracker(repository.NewMetricsRepository("test", "test"), 24*time.Hour)
+	objective, err := tracker.AddObjective(models.Objective{Name: "api", Kind: models.SLOAvailability, Target: 99})
+	require.NoError(t, err)
+
+	// A baseline, 11 clean steps, then one at 5% errors: an hour of history exactly
+	increments := append([][2]float64{{0, 0}}, repeat(11, [2]float64{100, 0})...)
+	tracker.history[objective.ID] = counters(5*time.Minute, append(increments, [2]float64{100, 5})...)
+
+	rates := tracker.Status()[0].BurnRates
+	assert.Equal(t, 5.0, rates["5m"], "5% errors against a 1% budget")
+	assert.Equal(t, 0.42, rates["1h"], "the same 5 errors spread over 1200 requests")
+	assert.Equal(t, rates["1h"], rates["6h"], "a longer window than the history covers all of it")
+}
+
+func TestTracker_DeleteObjective(t *testing.T) {
+	tracker := NewTracker(repository.NewMetricsRepository("test", "test"), time.Hour)
+	objective, err := tracker.AddObjective(models.Objective{Name: "api", Kind: models.SLOAvailability, Target: 99})
+	require.NoError(t, err)
+	tracker.Sample()
+
+	require.NoError(t, tracker.DeleteObjective(objective.ID))
+	assert.Empty(t, tracker.Status())
+	assert.NotContains(t, tracker.history, objective.ID)
+	assert.ErrorIs(t, tracker.DeleteObjective(objective.ID), ErrObjectiveNotFound)
+
+	_, err = tracker.AddObjective(models.Objective{Name: "bad", Kind: models.SLOAvailability, Target: 100})
+	assert.Error(t, err)
+}
@@ -0,0 +1,303 @@
+package synthetic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/repository"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/tracing"
+)
+
+// ErrCheckNotFound is returned for operations on an unknown check ID
+var ErrCheckNotFound = errors.New("synthetic check not found")
+
+// HealthCheckName is the name synthetic results are reported under in /health
+const HealthCheckName = "synthetic"
+
+// entry is a check with its probe history and the runner probing it
+type entry struct {
+	check    models.SyntheticCheck
+	history  []models.ProbeResult // oldest first, at most maxHistory
+	probes   int64
+	failures int64
+	stop     context.CancelFunc // nil until the scheduler starts
+}
+
+// Scheduler probes every registered check on its own interval. Each check
+// gets a goroutine, so a slow endpoint never delays the others.
+type Scheduler struct {
+	client     *http.Client
+	maxHistory int
+
+	mu     sync.Mutex
+	ctx    context.Context // set by Start; checks added before wait for it
+	checks map[string]*entry
+	nextID int
+}
+
+// NewScheduler creates a scheduler keeping the last maxHistory results of
+// each check
+func NewScheduler(maxHistory int) *Scheduler {
+	if maxHistory < 1 {
+		maxHistory = 1
+	}
+	return &Scheduler{
+		// Redirects are reported as they are; a check expecting 200 from a
+		// URL that moved should fail
+		client: &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}},
+		maxHistory: maxHistory,
+		checks:     make(map[string]*entry),
+	}
+}
+
+// Start probes every check until ctx is done, including checks added later
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ctx = ctx
+	for _, e := range s.checks {
+		s.startLocked(e)
+	}
+}
+
+// AddCheck validates the check, assigns it an ID and starts probing it
+func (s *Scheduler) AddCheck(check models.SyntheticCheck) (models.SyntheticCheck, error) {
+	check = check.WithDefaults()
+	if err := check.Validate(); err != nil {
+		return models.SyntheticCheck{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	check.ID = strconv.Itoa(s.nextID)
+	e := &entry{check: check}
+	s.checks[check.ID] = e
+	if s.ctx != nil {
+		s.startLocked(e)
+	}
+	return check, nil
+}
+
+// DeleteCheck stops probing a check and drops its history
+func (s *Scheduler) DeleteCheck(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.checks[id]
+	if !ok {
+		return ErrCheckNotFound
+	}
+	if e.stop != nil {
+		e.stop()
+	}
+	delete(s.checks, id)
+	return nil
+}
+
+// Check returns one check's status including its probe history
+func (s *Scheduler) Check(id string) (models.SyntheticStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.checks[id]
+	if !ok {
+		return models.SyntheticStatus{}, ErrCheckNotFound
+	}
+	status := e.status()
+	status.History = append([]models.ProbeResult(nil), e.history...)
+	return status, nil
+}
+
+// Statuses returns every check's status without history, ordered by ID
+func (s *Scheduler) Statuses() []models.SyntheticStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]models.SyntheticStatus, 0, len(s.checks))
+	for _, e := range s.checks {
+		statuses = append(statuses, e.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		a, _ := strconv.Atoi(statuses[i].ID)
+		b, _ := strconv.Atoi(statuses[j].ID)
+		return a < b
+	})
+	return statuses
+}
+
+// HealthChecker reports the checks in /health: degraded while any check is
+// down, never unhealthy, since an external endpoint failing says nothing
+// about this service
+func (s *Scheduler) HealthChecker() repository.HealthChecker {
+	return healthChecker{s}
+}
+
+type healthChecker struct {
+	s *Scheduler
+}
+
+func (h healthChecker) Check(ctx context.Context) models.HealthCheck {
+	start := time.Now()
+	statuses := h.s.Statuses()
+
+	var down []string
+	for _, status := range statuses {
+		if status.LastResult != nil && !status.Up {
+			down = append(down, status.Name)
+		}
+	}
+
+	status := models.HealthStatusHealthy
+	message := fmt.Sprintf("%d synthetic checks up", len(statuses)-len(down))
+	if len(down) > 0 {
+		status = models.HealthStatusDegraded
+		message = fmt.Sprintf("%d of %d synthetic checks down", len(down), len(statuses))
+	}
+
+	check, _ := models.NewHealthCheck(HealthCheckName, message, status, time.Since(start))
+	check.Details = map[string]interface{}{
+		"type":   "synthetic",
+		"checks": len(statuses),
+	}
+	if len(down) > 0 {
+		check.Details["down"] = down
+	}
+	return *check
+}
+
+// startLocked launches the goroutine probing e
+func (s *Scheduler) startLocked(e *entry) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	e.stop = cancel
+	go s.run(ctx, e.check)
+}
+
+// run probes check right away and then every interval until ctx is done
+func (s *Scheduler) run(ctx context.Context, check models.SyntheticCheck) {
+	ticker := time.NewTicker(check.Interval())
+	defer ticker.Stop()
+
+	for {
+		s.record(check.ID, Probe(ctx, s.client, check))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// record appends a result to a check's history, unless the check was
+// deleted while the probe was in flight
+func (s *Scheduler) record(id string, result models.ProbeResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.checks[id]
+	if !ok {
+		return
+	}
+
+	wasUp := len(e.history) == 0 || e.history[len(e.history)-1].Success
+	e.history = append(e.history, result)
+	if len(e.history) > s.maxHistory {
+		e.history = e.history[len(e.history)-s.maxHistory:]
+	}
+	e.probes++
+	if !result.Success {
+		e.failures++
+	}
+
+	// Log transitions only; a check that stays down would repeat every interval
+	fields := logrus.Fields{"check": e.check.Name, "url": e.check.URL}
+	if wasUp && !result.Success {
+		logrus.WithFields(fields).WithField("error", result.Error).Warn("Synthetic check down")
+	} else if !wasUp && result.Success {
+		logrus.WithFields(fields).Info("Synthetic check recovered")
+	}
+}
+
+// status summarizes the kept history
+func (e *entry) status() models.SyntheticStatus {
+	status := models.SyntheticStatus{
+		SyntheticCheck: e.check,
+		Probes:         e.probes,
+		Failures:       e.failures,
+		Availability:   100,
+	}
+	if len(e.history) == 0 {
+		return status
+	}
+
+	latency := models.NewLatencyHistogram()
+	succeeded := 0
+	for _, result := range e.history {
+		if result.Success {
+			succeeded++
+		}
+		latency.Observe(time.Duration(result.LatencyMs * float64(time.Millisecond)))
+	}
+
+	last := e.history[len(e.history)-1]
+	status.Up = last.Success
+	status.LastResult = &last
+	status.Availability = float64(succeeded) * 100 / float64(len(e.history))
+	status.Latency = latency.Stats()
+	return status
+}
+
+// Probe sends one request for check and reports whether it answered with the
+// expected status within the timeout. The body is drained so the connection
+// can be reused.
+func Probe(ctx context.Context, client *http.Client, check models.SyntheticCheck) models.ProbeResult {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout())
+	defer cancel()
+
+	ctx, span := tracing.StartSpan(ctx, "synthetic_check "+check.Name, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	result := models.ProbeResult{Timestamp: time.Now()}
+	req, err := http.NewRequestWithContext(ctx, check.Method, check.URL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	tracing.Inject(ctx, req.Header)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		result.StatusCode = resp.StatusCode
+	}
+	result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		result.Error = err.Error()
+	case resp.StatusCode != check.ExpectedStatus:
+		result.Error = fmt.Sprintf("status %d, expected %d", resp.StatusCode, check.ExpectedStatus)
+	default:
+		result.Success = true
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package synthetic
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+)
+
+func TestProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	check := func(path string, timeoutMs int) models.SyntheticCheck {
+		return models.SyntheticCheck{Name: path, URL: server.URL + path, IntervalSeconds: 1, TimeoutMs: timeoutMs}.WithDefaults()
+	}
+
+	result := Probe(context.Background(), server.Client(), check("/ok", 1000))
+	assert.True(t, result.Success)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Empty(t, result.Error)
+
+	result = Probe(context.Background(), server.Client(), check("/down", 1000))
+	assert.False(t, result.Success)
+	assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+	assert.Equal(t, "status 503, expected 200", result.Error)
+
+	result = Probe(context.Background(), server.Client(), check("/slow", 50))
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "deadline exceeded")
+}
+
+func TestScheduler_History(t *testing.T) {
+	s := NewScheduler(3)
+	created, err := s.AddCheck(models.SyntheticCheck{Name: "api", URL: "http://api.internal/health", IntervalSeconds: 60})
+	require.NoError(t, err)
+	assert.Equal(t, "1", created.ID)
+
+	for _, success := range []bool{false, true, true, false} {
+		s.record(created.ID, models.ProbeResult{Timestamp: time.Now(), Success: success, LatencyMs: 10})
+	}
+
+	status, err := s.Check(created.ID)
+	require.NoError(t, err)
+	assert.Len(t, status.History, 3, "history is capped")
+	assert.Equal(t, int64(4), status.Probes)
+	assert.Equal(t, int64(2), status.Failures)
+	assert.False(t, status.Up)
+	assert.InDelta(t, 66.67, status.Availability, 0.01)
+
+	health := s.HealthChecker().Check(context.Background())
+	assert.Equal(t, models.HealthStatusDegraded, health.Status)
+	assert.Equal(t, []string{"api"}, health.Details["down"])
+
+	require.NoError(t, s.DeleteCheck(created.ID))
+	_, err = s.Check(created.ID)
+	assert.ErrorIs(t, err, ErrCheckNotFound)
+}
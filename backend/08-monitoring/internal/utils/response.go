@@ -1,25 +0,0 @@
-package utils
-
-import (
-	"encoding/json"
-	"log"
-	"net/http"
-)
-
-// RespondJSON sends a JSON response with the given status code and data
-func RespondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("Error encoding JSON response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-	}
-}
-
-// RespondError sends a JSON error response
-func RespondError(w http.ResponseWriter, statusCode int, message string) {
-	RespondJSON(w, statusCode, map[string]string{
-		"error": message,
-	})
-}
@@ -0,0 +1,178 @@
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+)
+
+// Config sets what the watchdog treats as a leak. Growth is measured as the
+// least-squares slope over Window, so a burst that is cleaned up again does
+// not count; a zero slope or limit disables that check.
+type Config struct {
+	Window         time.Duration
+	GoroutineSlope float64 // goroutines per minute
+	HeapSlope      float64 // heap bytes per minute
+	GoroutineLimit int
+	HeapLimit      int64 // bytes
+	MaxIncidents   int   // oldest incidents are dropped beyond this
+}
+
+// Watchdog samples goroutine count and heap usage and records an incident
+// when either grows steadily or crosses its absolute limit
+type Watchdog struct {
+	cfg    Config
+	sample func() models.SystemMetrics
+
+	mu         sync.Mutex
+	started    time.Time
+	goroutines []models.SeriesPoint
+	heap       []models.SeriesPoint
+	incidents  []models.Incident // oldest first
+	open       map[models.IncidentKind]int
+	nextID     int
+}
+
+// New creates a watchdog reading metrics from sample
+func New(cfg Config, sample func() models.SystemMetrics) *Watchdog {
+	if cfg.MaxIncidents < 1 {
+		cfg.MaxIncidents = 100
+	}
+	return &Watchdog{
+		cfg:    cfg,
+		sample: sample,
+		open:   make(map[models.IncidentKind]int),
+	}
+}
+
+// Run samples every interval until ctx is done
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Observe(w.sample())
+		}
+	}
+}
+
+// Observe adds one sample and opens or resolves incidents accordingly
+func (w *Watchdog) Observe(m models.SystemMetrics) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := m.Timestamp
+	if w.started.IsZero() {
+		w.started = now
+	}
+	w.goroutines = appendWindow(w.goroutines, models.SeriesPoint{Timestamp: now, Value: float64(m.GoroutineCount)}, now.Add(-w.cfg.Window))
+	w.heap = appendWindow(w.heap, models.SeriesPoint{Timestamp: now, Value: float64(m.HeapAlloc)}, now.Add(-w.cfg.Window))
+
+	goroutines, heap := float64(m.GoroutineCount), float64(m.HeapAlloc)
+
+	w.check(models.IncidentGoroutineLimit, now, w.cfg.GoroutineLimit > 0 && goroutines > float64(w.cfg.GoroutineLimit),
+		models.Incident{
+			Value:     goroutines,
+			Threshold: float64(w.cfg.GoroutineLimit),
+			Message:   fmt.Sprintf("%d goroutines exceed the limit of %d", m.GoroutineCount, w.cfg.GoroutineLimit),
+		})
+	w.check(models.IncidentHeapLimit, now, w.cfg.HeapLimit > 0 && heap > float64(w.cfg.HeapLimit),
+		models.Incident{
+			Value:     heap,
+			Threshold: float64(w.cfg.HeapLimit),
+			Message:   fmt.Sprintf("Heap of %.1f MB exceeds the limit of %.1f MB", heap/1024/1024, float64(w.cfg.HeapLimit)/1024/1024),
+		})
+
+	// Slopes need a full window of history to mean "sustained"
+	if now.Sub(w.started) < w.cfg.Window {
+		return
+	}
+
+	slope := models.GrowthPerMinute(w.goroutines)
+	w.check(models.IncidentGoroutineGrowth, now, w.cfg.GoroutineSlope > 0 && growing(w.goroutines, slope, w.cfg.GoroutineSlope),
+		models.Incident{
+			Value:     goroutines,
+			Slope:     slope,
+			Threshold: w.cfg.GoroutineSlope,
+			Message: fmt.Sprintf("Goroutines grew %.1f/min over %s (threshold %.1f/min), now %d",
+				slope, w.cfg.Window, w.cfg.GoroutineSlope, m.GoroutineCount),
+		})
+
+	slope = models.GrowthPerMinute(w.heap)
+	w.check(models.IncidentHeapGrowth, now, w.cfg.HeapSlope > 0 && growing(w.heap, slope, w.cfg.HeapSlope),
+		models.Incident{
+			Value:     heap,
+			Slope:     slope,
+			Threshold: w.cfg.HeapSlope,
+			Message: fmt.Sprintf("Heap grew %.2f MB/min over %s (threshold %.2f MB/min), now %.1f MB",
+				slope/1024/1024, w.cfg.Window, w.cfg.HeapSlope/1024/1024, heap/1024/1024),
+		})
+}
+
+// Incidents returns the recorded incidents, newest first
+func (w *Watchdog) Incidents() []models.Incident {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	result := make([]models.Incident, 0, len(w.incidents))
+	for i := len(w.incidents) - 1; i >= 0; i-- {
+		result = append(result, w.incidents[i])
+	}
+	return result
+}
+
+// check opens an incident of kind when breached and none is open, and
+// resolves the open one once the condition clears
+func (w *Watchdog) check(kind models.IncidentKind, now time.Time, breached bool, incident models.Incident) {
+	id, isOpen := w.open[kind]
+
+	switch {
+	case breached && !isOpen:
+		w.nextID++
+		incident.ID = w.nextID
+		incident.Kind = kind
+		incident.Window = w.cfg.Window.Seconds()
+		incident.DetectedAt = now
+		w.incidents = append(w.incidents, incident)
+		w.open[kind] = incident.ID
+		logrus.WithFields(logrus.Fields{"incident_id": incident.ID, "kind": kind}).Warn(incident.Message)
+
+		if len(w.incidents) > w.cfg.MaxIncidents {
+			w.incidents = w.incidents[len(w.incidents)-w.cfg.MaxIncidents:]
+		}
+	case !breached && isOpen:
+		delete(w.open, kind)
+		for i := range w.incidents {
+			if w.incidents[i].ID == id {
+				resolved := now
+				w.incidents[i].ResolvedAt = &resolved
+				logrus.WithFields(logrus.Fields{"incident_id": id, "kind": kind}).Info("Incident resolved: " + w.incidents[i].Message)
+			}
+		}
+	}
+}
+
+// growing requires both a steep enough trend and a net increase, so a series
+// that spiked and fell back does not count as a leak
+func growing(points []models.SeriesPoint, slope, threshold float64) bool {
+	return slope > threshold && points[len(points)-1].Value > points[0].Value
+}
+
+// appendWindow adds p and drops points older than cutoff
+func appendWindow(points []models.SeriesPoint, p models.SeriesPoint, cutoff time.Time) []models.SeriesPoint {
+	points = append(points, p)
+	i := 0
+	for i < len(points)-1 && points[i].Timestamp.Before(cutoff) {
+		i++
+	}
+	return points[i:]
+}
@@ -0,0 +1,166 @@
+package watchdog
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
+)
+
+var start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// sample builds metrics taken minute minutes after start
+func sample(minute int, goroutines int, heapMB int64) models.SystemMetrics {
+	return models.SystemMetrics{
+		Timestamp:      start.Add(time.Duration(minute) * time.Minute),
+		GoroutineCount: goroutines,
+		HeapAlloc:      heapMB * 1024 * 1024,
+	}
+}
+
+func kinds(incidents []models.Incident) []models.IncidentKind {
+	var result []models.IncidentKind
+	for _, incident := range incidents {
+		result = append(result, incident.Kind)
+	}
+	return result
+}
+
+func TestWatchdog_Limits(t *testing.T) {
+	w := New(Config{Window: 5 * time.Minute, GoroutineLimit: 1000, HeapLimit: 512 * 1024 * 1024}, nil)
+
+	w.Observe(sample(0, 900, 100))
+	assert.Empty(t, w.Incidents())
+
+	w.Observe(sample(1, 1500, 100))
+	incidents := w.Incidents()
+	require.Len(t, incidents, 1, "limits apply from the first sample, no window needed")
+	assert.Equal(t, models.IncidentGoroutineLimit, incidents[0].Kind)
+	assert.Equal(t, 1500.0, incidents[0].Value)
+	assert.Equal(t, 1000.0, incidents[0].Threshold)
+	assert.Equal(t, start.Add(time.Minute), incidents[0].DetectedAt)
+	assert.Nil(t, incidents[0].ResolvedAt)
+
+	w.Observe(sample(2, 1600, 600))
+	assert.Equal(t, []models.IncidentKind{models.IncidentHeapLimit, models.IncidentGoroutineLimit}, kinds(w.Incidents()),
+		"an open incident is not opened again; newest first")
+
+	w.Observe(sample(3, 800, 600))
+	incidents = w.Incidents()
+	require.Len(t, incidents, 2)
+	assert.Nil(t, incidents[0].ResolvedAt, "the heap is still over")
+	require.NotNil(t, incidents[1].ResolvedAt)
+	assert.Equal(t, start.Add(3*time.Minute), *incidents[1].ResolvedAt)
+
+	w.Observe(sample(4, 1200, 100))
+	assert.Equal(t, []models.IncidentKind{
+		models.IncidentGoroutineLimit, models.IncidentHeapLimit, models.IncidentGoroutineLimit,
+	}, kinds(w.Incidents()), "a new breach after resolution is a new incident")
+}
+
+func TestWatchdog_Growth(t *testing.T) {
+	cfg := Config{Window: 5 * time.Minute, GoroutineSlope: 5, HeapSlope: 10 * 1024 * 1024}
+
+	t.Run("steady goroutine growth", func(t *testing.T) {
+		w := New(cfg, nil)
+		for minute := 0; minute < 5; minute++ {
+			w.Observe(sample(minute, 100+20*minute, 100))
+		}
+		assert.Empty(t, w.Incidents(), "no slope until a full window has passed")
+
+		w.Observe(sample(5, 200, 100))
+		incidents := w.Incidents()
+		require.Len(t, incidents, 1)
+		assert.Equal(t, models.IncidentGoroutineGrowth, incidents[0].Kind)
+		assert.InDelta(t, 20, incidents[0].Slope, 0.01)
+		assert.Equal(t, 5.0, incidents[0].Threshold)
+		assert.Equal(t, 300.0, incidents[0].Window)
+	})
+
+	t.Run("steady heap growth", func(t *testing.T) {
+		w := New(cfg, nil)
+		for minute := 0; minute <= 5; minute++ {
+			w.Observe(sample(minute, 100, 100+int64(50*minute)))
+		}
+		incidents := w.Incidents()
+		require.Len(t, incidents, 1)
+		assert.Equal(t, models.IncidentHeapGrowth, incidents[0].Kind)
+		assert.InDelta(t, 50*1024*1024, incidents[0].Slope, 1)
+	})
+
+	t.Run("a burst that is cleaned up is not a leak", func(t *testing.T) {
+		w := New(cfg, nil)
+		for minute, goroutines := range []int{100, 100, 100, 100, 900, 100} {
+			w.Observe(sample(minute, goroutines, 100))
+		}
+		assert.Empty(t, w.Incidents())
+	})
+
+	t.Run("growth resolves once the series levels off", func(t *testing.T) {
+		w := New(cfg, nil)
+		minute := 0
+		for ; minute <= 5; minute++ {
+			w.Observe(sample(minute, 100+20*minute, 100))
+		}
+		require.Len(t, w.Incidents(), 1)
+
+		// Flat for a whole window, so the old climb has aged out
+		for end := minute + 6; minute < end; minute++ {
+			w.Observe(sample(minute, 200, 100))
+		}
+		incidents := w.Incidents()
+		require.Len(t, incidents, 1)
+		assert.NotNil(t, incidents[0].ResolvedAt)
+	})
+
+	t.Run("a zero threshold disables the check", func(t *testing.T) {
+		w := New(Config{Window: 5 * time.Minute}, nil)
+		for minute := 0; minute <= 10; minute++ {
+			w.Observe(sample(minute, 100+100*minute, 100+100*int64(minute)))
+		}
+		assert.Empty(t, w.Incidents())
+	})
+}
+
+func TestWatchdog_MaxIncidents(t *testing.T) {
+	w := New(Config{Window: time.Minute, GoroutineLimit: 10, MaxIncidents: 2}, nil)
+	for minute := 0; minute < 6; minute++ {
+		// Alternate over and under the limit to open and resolve incidents
+		w.Observe(sample(minute, 5+10*(minute%2), 1))
+	}
+
+	incidents := w.Incidents()
+	require.Len(t, incidents, 2)
+	assert.Equal(t, 3, incidents[0].ID)
+	assert.Equal(t, 2, incidents[1].ID, "the oldest incident is dropped")
+}
+
+func TestWatchdog_Run(t *testing.T) {
+	var mu sync.Mutex
+	goroutines := 0
+	sampler := func() models.SystemMetrics {
+		mu.Lock()
+		defer mu.Unlock()
+		goroutines += 10
+		return models.SystemMetrics{Timestamp: time.Now(), GoroutineCount: goroutines}
+	}
+	w := New(Config{Window: time.Minute, GoroutineLimit: 50}, sampler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Run(ctx, time.Millisecond)
+	}()
+
+	require.Eventually(t, func() bool { return len(w.Incidents()) == 1 }, time.Second, time.Millisecond,
+		"the injected sampler drives the watchdog")
+	cancel()
+	<-done
+	assert.Equal(t, models.IncidentGoroutineLimit, w.Incidents()[0].Kind)
+}
@@ -2,45 +2,199 @@ package main
 
 import (
 	"context"
-	"log"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
 
+	"github.com/e6a5/learning/backend/08-monitoring/internal/alerting"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/buildinfo"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/discovery"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/exporter"
 	"github.com/e6a5/learning/backend/08-monitoring/internal/handlers"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/logging"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/metrics"
 	"github.com/e6a5/learning/backend/08-monitoring/internal/middleware"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/models"
 	"github.com/e6a5/learning/backend/08-monitoring/internal/repository"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/slo"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/synthetic"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/tracing"
+	"github.com/e6a5/learning/backend/08-monitoring/internal/watchdog"
+	"github.com/e6a5/learning/backend/pkg/env"
 )
 
 func main() {
 	// Configuration from environment
-	port := getEnv("PORT", "8080")
-	version := getEnv("VERSION", "1.0.0")
-	environment := getEnv("ENVIRONMENT", "development")
+	port := env.Get("PORT", "8080")
+	version := env.Get("VERSION", buildinfo.Version)
+	environment := env.Get("ENVIRONMENT", "development")
 
-	log.Printf("Starting monitoring service version %s in %s environment", version, environment)
+	err := logging.Setup(logging.Config{
+		Level:            env.Get("LOG_LEVEL", "info"),
+		Format:           env.Get("LOG_FORMAT", "json"),
+		AccessSampleRate: getEnvInt("LOG_ACCESS_SAMPLE_RATE", 1),
+		RateLimit:        getEnvInt("LOG_RATE_LIMIT", 5),
+		RateLimitWindow:  getEnvDuration("LOG_RATE_LIMIT_WINDOW", time.Minute),
+	})
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid logging configuration")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"version":     version,
+		"environment": environment,
+	}).Info("Starting monitoring service")
+
+	// Tracing: spans are always created so trace IDs reach logs and
+	// responses; OTEL_EXPORTER decides whether they leave the process
+	traceExporter := env.Get("OTEL_EXPORTER", "none")
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: env.Get("OTEL_SERVICE_NAME", "monitoring-service"),
+		Version:     version,
+		Environment: environment,
+		Exporter:    traceExporter,
+		Endpoint:    env.Get("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4318"),
+		SampleRatio: getEnvFloat("OTEL_TRACES_SAMPLE_RATIO", 1.0),
+	})
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to set up tracing")
+	}
 
 	// Initialize dependencies
 	metricsRepo := repository.NewMetricsRepository(version, environment)
+	promMetrics := metrics.NewPrometheusMetrics()
+	// Exemplars link latency buckets to traces, which only helps when the
+	// traces are exported
+	promMetrics.SetExemplars(traceExporter != "none" && getEnvBool("METRICS_EXEMPLARS", true))
+	metricsRepo.SetSeriesRetention(getEnvDuration("METRICS_RETENTION", time.Hour), getEnvInt("METRICS_SERIES_POINTS", 1000))
+
+	// Cap distinct label sets per custom metric
+	labelOverflow := env.Get("METRICS_LABEL_OVERFLOW", repository.OverflowAggregate)
+	if labelOverflow != repository.OverflowAggregate && labelOverflow != repository.OverflowDrop {
+		logrus.Fatalf("METRICS_LABEL_OVERFLOW must be %q or %q", repository.OverflowAggregate, repository.OverflowDrop)
+	}
+	metricsRepo.SetCardinalityLimit(getEnvInt("METRICS_MAX_LABEL_SETS", 100), labelOverflow)
+
+	// Background workers stop when main returns
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Sample CPU, RSS, file descriptors and GC pauses in the background
+	metricsRepo.StartSystemSampler(backgroundCtx, getEnvDuration("SYSTEM_SAMPLE_INTERVAL", 5*time.Second))
+	promMetrics.RegisterSystemMetrics(metricsRepo.GetSystemMetrics)
+	promMetrics.RegisterCustomDistributions(metricsRepo.GetDistributions)
+	promMetrics.RegisterLogMetrics(logging.Stats)
+	build := buildinfo.Get(version)
+	promMetrics.RegisterBuildInfo(build)
+
+	// Synthetic checks probe external URLs registered through the API, each
+	// on its own interval
+	syntheticChecks := synthetic.NewScheduler(getEnvInt("SYNTHETIC_HISTORY", 100))
+	syntheticChecks.Start(backgroundCtx)
+	promMetrics.RegisterSyntheticMetrics(syntheticChecks.Statuses)
 
 	// Set up health checkers
 	healthCheckers := []repository.HealthChecker{
 		repository.NewDatabaseHealthChecker("database", "mysql://localhost:3306"),
 		repository.NewExternalServiceHealthChecker("api", "https://httpbin.org/status/200"),
+		syntheticChecks.HealthChecker(),
+	}
+
+	// Health checks run on a schedule and probes read the cached result.
+	// Checks listed in HEALTH_INFORMATIONAL_CHECKS degrade /health but never
+	// fail readiness.
+	healthScheduler := repository.NewHealthScheduler(metricsRepo, healthCheckers,
+		getEnvList("HEALTH_INFORMATIONAL_CHECKS", "api,"+synthetic.HealthCheckName),
+		getEnvDuration("HEALTH_CHECK_INTERVAL", 15*time.Second),
+		getEnvDuration("HEALTH_CHECK_TIMEOUT", 10*time.Second),
+		repository.NewHealthHistory(getEnvInt("HEALTH_HISTORY", 500),
+			getEnvDuration("HEALTH_FLAP_WINDOW", 10*time.Minute),
+			getEnvInt("HEALTH_FLAP_TRANSITIONS", 4)),
+	)
+	// Status changes are logged and sent to HEALTH_WEBHOOK_URL, falling back
+	// to the alert webhook
+	healthNotifier := alerting.NewHealthNotifier(env.Get("HEALTH_WEBHOOK_URL", os.Getenv("ALERT_WEBHOOK_URL")))
+	healthScheduler.OnTransition(healthNotifier.Notify)
+	healthScheduler.Start(backgroundCtx)
+
+	// Alerting: rules are evaluated on a ticker; the webhook is used by
+	// rules that do not set their own
+	alertEngine := alerting.NewEngine(metricsRepo,
+		alerting.LogNotifier{},
+		alerting.NewWebhookNotifier(os.Getenv("ALERT_WEBHOOK_URL")),
+	)
+	addDefaultAlertRules(alertEngine)
+	go alertEngine.Run(backgroundCtx, getEnvDuration("ALERT_EVALUATION_INTERVAL", 15*time.Second))
+
+	// Leak watchdog: flags goroutines or heap that keep growing over the
+	// window, or cross an absolute limit
+	leakWatchdog := watchdog.New(watchdog.Config{
+		Window:         getEnvDuration("LEAK_WINDOW", 10*time.Minute),
+		GoroutineSlope: getEnvFloat("LEAK_GOROUTINE_SLOPE", 10),
+		HeapSlope:      getEnvFloat("LEAK_HEAP_SLOPE_MB", 5) * 1024 * 1024,
+		GoroutineLimit: getEnvInt("LEAK_GOROUTINE_LIMIT", 10000),
+		HeapLimit:      int64(getEnvInt("LEAK_HEAP_LIMIT_MB", 1024)) * 1024 * 1024,
+		MaxIncidents:   100,
+	}, metricsRepo.GetSystemMetrics)
+	go leakWatchdog.Run(backgroundCtx, getEnvDuration("LEAK_SAMPLE_INTERVAL", 30*time.Second))
+
+	// SLOs: error budgets over SLO_PERIOD with multiwindow burn-rate alerts
+	sloTracker := slo.NewTracker(metricsRepo, getEnvDuration("SLO_PERIOD", 24*time.Hour))
+	addDefaultObjectives(sloTracker)
+	go sloTracker.Run(backgroundCtx, getEnvDuration("SLO_SAMPLE_INTERVAL", time.Minute))
+
+	// Optional push export alongside the Prometheus /metrics endpoint
+	if exp := newMetricsExporter(env.Get("METRICS_EXPORTER", "none")); exp != nil {
+		go exporter.NewPusher(metricsRepo, exp).Run(backgroundCtx, getEnvDuration("STATSD_FLUSH_INTERVAL", 10*time.Second))
 	}
 
 	// Initialize handlers
-	monitoringHandler := handlers.NewMonitoringHandler(metricsRepo, healthCheckers)
+	monitoringHandler := handlers.NewMonitoringHandler(metricsRepo, healthScheduler, syntheticChecks, promMetrics.Registry)
+	alertHandler := handlers.NewAlertHandler(alertEngine)
+	debugHandler := handlers.NewDebugHandler()
+	incidentHandler := handlers.NewIncidentHandler(leakWatchdog)
+	sloHandler := handlers.NewSLOHandler(sloTracker)
+	buildInfoHandler := handlers.NewBuildInfoHandler(build)
+	dashboardHandler := handlers.NewDashboardHandler(promMetrics)
+	syntheticHandler := handlers.NewSyntheticHandler(syntheticChecks)
+	analyticsHandler := handlers.NewAnalyticsHandler(metricsRepo)
+	bundleHandler := handlers.NewBundleHandler(metricsRepo, healthScheduler, build)
 
 	// Initialize middleware
-	monitoringMiddleware := middleware.NewMonitoringMiddleware(metricsRepo)
+	monitoringMiddleware := middleware.NewMonitoringMiddleware(metricsRepo, promMetrics)
 
 	// Setup routes
-	router := setupRoutes(monitoringHandler, monitoringMiddleware)
+	router := setupRoutes(monitoringHandler, alertHandler, incidentHandler, sloHandler, buildInfoHandler, dashboardHandler, syntheticHandler, analyticsHandler, monitoringMiddleware)
+
+	// Profiling endpoints and the snapshot bundle expose internals and cost
+	// CPU, resetting metrics or deleting an SLO loses data, and alert rules
+	// and synthetic checks make the service send requests to URLs of the
+	// caller's choosing, so they only exist when an admin token is configured
+	if debugToken := os.Getenv("DEBUG_TOKEN"); debugToken != "" {
+		setupDebugRoutes(router, debugHandler, debugToken)
+		setupAdminRoutes(router, monitoringHandler, bundleHandler, alertHandler, sloHandler, syntheticHandler, debugToken)
+	} else {
+		logrus.Info("DEBUG_TOKEN not set, /debug endpoints, debug bundle, metrics reset and SLO, alert rule and synthetic check changes disabled")
+	}
+
+	// Optional self-registration, so Prometheus discovers this instance
+	// through Consul instead of a static target list
+	var registrar *discovery.ConsulRegistrar
+	if consulAddr := os.Getenv("CONSUL_ADDR"); consulAddr != "" {
+		registrar = newConsulRegistrar(consulAddr, port, version, environment)
+		go registrar.RegisterWithRetry(backgroundCtx)
+	}
+
+	// Everything above read its configuration from the environment
+	metricsRepo.MarkConfigLoaded()
 
 	// Start server
 	server := &http.Server{
@@ -50,9 +204,9 @@ func main() {
 
 	// Graceful shutdown
 	go func() {
-		log.Printf("Server starting on port %s", port)
+		logrus.WithField("port", port).Info("Server starting")
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+			logrus.WithError(err).Fatal("Server failed to start")
 		}
 	}()
 
@@ -61,23 +215,41 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	logrus.Info("Shutting down server")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Leave Consul first so scrapers and clients stop picking this instance.
+	// Stopping the background workers ends a pending registration retry,
+	// which could otherwise re-register it.
+	if registrar != nil {
+		stopBackground()
+		if err := registrar.Deregister(ctx); err != nil {
+			logrus.WithError(err).Warn("Failed to deregister from Consul")
+		} else {
+			logrus.Info("Deregistered from Consul")
+		}
+	}
+
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		logrus.WithError(err).Fatal("Server forced to shutdown")
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		logrus.WithError(err).Warn("Failed to flush traces")
 	}
 
-	log.Println("Server exited")
+	logrus.Info("Server exited")
 }
 
-func setupRoutes(handler *handlers.MonitoringHandler, monitoringMW *middleware.MonitoringMiddleware) *mux.Router {
+func setupRoutes(handler *handlers.MonitoringHandler, alertHandler *handlers.AlertHandler, incidentHandler *handlers.IncidentHandler, sloHandler *handlers.SLOHandler, buildInfoHandler *handlers.BuildInfoHandler, dashboardHandler *handlers.DashboardHandler, syntheticHandler *handlers.SyntheticHandler, analyticsHandler *handlers.AnalyticsHandler, monitoringMW *middleware.MonitoringMiddleware) *mux.Router {
 	router := mux.NewRouter()
 
 	// Apply global middleware
 	router.Use(middleware.CorsMiddleware)
+	router.Use(middleware.RequestIDMiddleware)
+	router.Use(middleware.TracingMiddleware)
 	router.Use(middleware.LoggingMiddleware)
 	router.Use(monitoringMW.Wrap)
 
@@ -94,15 +266,210 @@ func setupRoutes(handler *handlers.MonitoringHandler, monitoringMW *middleware.M
 	apiRouter := router.PathPrefix("/api").Subrouter()
 	apiRouter.HandleFunc("/metrics", handler.GetCustomMetrics).Methods("GET")
 	apiRouter.HandleFunc("/metrics", handler.PostCustomMetric).Methods("POST")
+	apiRouter.HandleFunc("/metrics/custom/{name}", handler.GetCustomMetricSeries).Methods("GET")
+	apiRouter.HandleFunc("/metrics/definitions", handler.GetMetricDefinitions).Methods("GET")
+	apiRouter.HandleFunc("/metrics/definitions", handler.RegisterMetric).Methods("POST")
+	apiRouter.HandleFunc("/metrics/cardinality", handler.GetCardinality).Methods("GET")
+	apiRouter.HandleFunc("/metrics/snapshot", handler.GetSnapshot).Methods("GET")
+	apiRouter.HandleFunc("/health/history", handler.GetHealthHistory).Methods("GET")
+	apiRouter.HandleFunc("/analytics", analyticsHandler.GetAnalytics).Methods("GET")
 	apiRouter.HandleFunc("/system", handler.GetSystemInfo).Methods("GET")
 	apiRouter.HandleFunc("/status", handler.GetStatus).Methods("GET")
+	apiRouter.HandleFunc("/buildinfo", buildInfoHandler.GetBuildInfo).Methods("GET")
+	apiRouter.HandleFunc("/dashboards/grafana", dashboardHandler.GetGrafanaDashboard).Methods("GET")
 	apiRouter.HandleFunc("/demo", handler.DemoEndpoint).Methods("GET")
 
+	// Alerting endpoints
+	apiRouter.HandleFunc("/alerts", alertHandler.GetAlerts).Methods("GET")
+	apiRouter.HandleFunc("/alerts/rules", alertHandler.ListRules).Methods("GET")
+	apiRouter.HandleFunc("/alerts/rules/{id}", alertHandler.GetRule).Methods("GET")
+
+	// Leak watchdog incidents
+	apiRouter.HandleFunc("/incidents", incidentHandler.GetIncidents).Methods("GET")
+
+	// Service level objectives
+	apiRouter.HandleFunc("/slo", sloHandler.GetSLOs).Methods("GET")
+
+	// Synthetic checks of external endpoints
+	apiRouter.HandleFunc("/synthetic", syntheticHandler.ListChecks).Methods("GET")
+	apiRouter.HandleFunc("/synthetic/{id}", syntheticHandler.GetCheck).Methods("GET")
+
 	return router
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+// newMetricsExporter builds the push exporter named by METRICS_EXPORTER, or
+// nil for "none"
+func newMetricsExporter(kind string) exporter.Exporter {
+	switch kind {
+	case "none", "":
+		return nil
+	case "statsd", "dogstatsd":
+		exp, err := exporter.NewStatsDExporter(exporter.StatsDConfig{
+			Addr:          env.Get("STATSD_ADDR", "localhost:8125"),
+			Prefix:        env.Get("STATSD_PREFIX", "monitoring."),
+			DogStatsD:     kind == "dogstatsd",
+			Tags:          exporter.ParseTags(os.Getenv("STATSD_TAGS")),
+			TagMap:        exporter.ParseTags(os.Getenv("STATSD_TAG_MAP")),
+			MaxPacketSize: getEnvInt("STATSD_MAX_PACKET_SIZE", 1432),
+		})
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to set up metrics exporter")
+		}
+		logrus.WithFields(logrus.Fields{
+			"exporter": kind,
+			"addr":     env.Get("STATSD_ADDR", "localhost:8125"),
+		}).Info("Pushing metrics")
+		return exp
+	}
+	logrus.Fatalf("Unknown METRICS_EXPORTER %q (want statsd, dogstatsd or none)", kind)
+	return nil
+}
+
+// newConsulRegistrar builds the Consul registration from CONSUL_* variables.
+// The instance is announced under its hostname, which is what other
+// containers on the same network resolve.
+func newConsulRegistrar(addr, port, version, environment string) *discovery.ConsulRegistrar {
+	hostname, _ := os.Hostname()
+	serviceName := env.Get("CONSUL_SERVICE_NAME", "monitoring-service")
+	address := env.Get("CONSUL_SERVICE_ADDRESS", hostname)
+	portNumber, _ := strconv.Atoi(port)
+
+	registrar, err := discovery.NewConsulRegistrar(discovery.ConsulConfig{
+		Addr:            addr,
+		Token:           os.Getenv("CONSUL_TOKEN"),
+		ServiceID:       env.Get("CONSUL_SERVICE_ID", serviceName+"-"+address+"-"+port),
+		ServiceName:     serviceName,
+		Address:         address,
+		Port:            portNumber,
+		Tags:            getEnvList("CONSUL_TAGS", "prometheus"),
+		Meta:            map[string]string{"version": version, "environment": environment},
+		HealthPath:      "/health/ready",
+		Interval:        getEnvDuration("CONSUL_CHECK_INTERVAL", 15*time.Second),
+		Timeout:         5 * time.Second,
+		DeregisterAfter: getEnvDuration("CONSUL_DEREGISTER_AFTER", time.Minute),
+	})
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid Consul configuration")
+	}
+	return registrar
+}
+
+// addDefaultObjectives tracks availability and latency across all routes
+func addDefaultObjectives(tracker *slo.Tracker) {
+	defaults := []models.Objective{
+		{Name: "Availability", Kind: models.SLOAvailability, Target: 99.9},
+		{Name: "Latency under 300ms", Kind: models.SLOLatency, Target: 99, ThresholdMs: 300},
+	}
+
+	for _, objective := range defaults {
+		if _, err := tracker.AddObjective(objective); err != nil {
+			logrus.WithError(err).WithField("objective", objective.Name).Fatal("Invalid default objective")
+		}
+	}
+}
+
+// setupAdminRoutes mounts API endpoints that discard data, expose internals
+// or make the service call out to a URL, behind admin auth. Reads stay public
+// on the API router.
+func setupAdminRoutes(router *mux.Router, handler *handlers.MonitoringHandler, bundleHandler *handlers.BundleHandler, alertHandler *handlers.AlertHandler, sloHandler *handlers.SLOHandler, syntheticHandler *handlers.SyntheticHandler, token string) {
+	adminRouter := router.PathPrefix("/api").Subrouter()
+	adminRouter.Use(middleware.AdminAuth(token))
+
+	adminRouter.HandleFunc("/metrics/reset", handler.ResetMetrics).Methods("POST")
+	adminRouter.HandleFunc("/slo", sloHandler.CreateObjective).Methods("POST")
+	adminRouter.HandleFunc("/slo/{id}", sloHandler.DeleteObjective).Methods("DELETE")
+	adminRouter.HandleFunc("/debug/bundle", bundleHandler.GetBundle).Methods("GET")
+
+	// Rules carry a webhook URL and checks a URL to probe: unauthenticated,
+	// they would let anyone make this service send requests into its network
+	adminRouter.HandleFunc("/alerts/rules", alertHandler.CreateRule).Methods("POST")
+	adminRouter.HandleFunc("/alerts/rules/{id}", alertHandler.UpdateRule).Methods("PUT")
+	adminRouter.HandleFunc("/alerts/rules/{id}", alertHandler.DeleteRule).Methods("DELETE")
+	adminRouter.HandleFunc("/synthetic", syntheticHandler.CreateCheck).Methods("POST")
+	adminRouter.HandleFunc("/synthetic/{id}", syntheticHandler.DeleteCheck).Methods("DELETE")
+}
+
+// setupDebugRoutes mounts net/http/pprof and on-demand capture downloads
+// under /debug, behind admin auth
+func setupDebugRoutes(router *mux.Router, handler *handlers.DebugHandler, token string) {
+	debugRouter := router.PathPrefix("/debug").Subrouter()
+	debugRouter.Use(middleware.AdminAuth(token))
+
+	debugRouter.HandleFunc("/pprof/cmdline", pprof.Cmdline).Methods("GET")
+	debugRouter.HandleFunc("/pprof/profile", pprof.Profile).Methods("GET")
+	debugRouter.HandleFunc("/pprof/symbol", pprof.Symbol).Methods("GET", "POST")
+	debugRouter.HandleFunc("/pprof/trace", pprof.Trace).Methods("GET")
+	// Index also serves the named profiles: heap, goroutine, allocs, block, mutex...
+	debugRouter.PathPrefix("/pprof/").HandlerFunc(pprof.Index).Methods("GET")
+
+	debugRouter.HandleFunc("/capture/cpu", handler.CaptureCPUProfile).Methods("GET")
+	debugRouter.HandleFunc("/capture/trace", handler.CaptureTrace).Methods("GET")
+}
+
+// addDefaultAlertRules installs the two rules most services start with
+func addDefaultAlertRules(engine *alerting.Engine) {
+	defaults := []models.AlertRule{
+		{
+			Name:            "High error rate",
+			Metric:          models.AlertMetricErrorRate,
+			Operator:        ">",
+			Threshold:       5,
+			ForSeconds:      300,
+			CooldownSeconds: 900,
+			Enabled:         true,
+		},
+		{
+			Name:            "Slow p99 latency",
+			Metric:          models.AlertMetricLatencyP99,
+			Operator:        ">",
+			Threshold:       500,
+			ForSeconds:      300,
+			CooldownSeconds: 900,
+			Enabled:         true,
+		},
+	}
+
+	for _, rule := range defaults {
+		if _, err := engine.AddRule(rule); err != nil {
+			logrus.WithError(err).WithField("rule", rule.Name).Fatal("Invalid default alert rule")
+		}
+	}
+}
+
+// getEnvList splits a comma-separated variable, ignoring blank entries
+func getEnvList(key, defaultValue string) []string {
+	var list []string
+	for _, item := range strings.Split(env.Get(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
 		return value
 	}
 	return defaultValue
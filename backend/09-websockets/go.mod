@@ -0,0 +1,21 @@
+module github.com/e6a5/learning/backend/09-websockets
+
+go 1.23.4
+
+require (
+	github.com/e6a5/learning/backend/pkg v0.0.0
+	github.com/golang-jwt/jwt/v5 v5.0.0
+	github.com/gorilla/mux v1.8.1
+	github.com/gorilla/websocket v1.5.3
+	github.com/stretchr/testify v1.9.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// The shared helpers in backend/pkg come from the directory next door
+// rather than a published version
+replace github.com/e6a5/learning/backend/pkg => ../pkg
@@ -0,0 +1,60 @@
+// Package auth verifies the tokens 06-auth-security issues, so someone who
+// logs in there can chat here under the same username.
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultSecret is 06-auth-security's signing key; sharing it lets that
+// lab's tokens work here without any setup
+const DefaultSecret = "your-secret-key-change-in-production"
+
+// ErrInvalidToken is returned for any token that does not verify
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are the claims 06-auth-security puts in its tokens, field for field
+type Claims struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// ParseToken verifies an HS256 token signed with secret and returns its
+// claims. Expired tokens and tokens without a username are rejected.
+func ParseToken(tokenString, secret string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Username == "" {
+		return nil, fmt.Errorf("%w: no username", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// SignToken issues a token the way 06-auth-security does, valid for ttl;
+// it is for trying this lab without running that one
+func SignToken(userID int, username, role, secret string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID:   userID,
+		Username: username,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Subject:   strconv.Itoa(userID),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
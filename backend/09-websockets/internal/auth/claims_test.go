@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseToken(t *testing.T) {
+	token, err := SignToken(7, "alice", "admin", DefaultSecret, time.Minute)
+	require.NoError(t, err)
+
+	claims, err := ParseToken(token, DefaultSecret)
+	require.NoError(t, err)
+	assert.Equal(t, 7, claims.UserID)
+	assert.Equal(t, "alice", claims.Username)
+	assert.Equal(t, "admin", claims.Role)
+	assert.Equal(t, "7", claims.Subject)
+
+	_, err = ParseToken(token, "another-secret")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	expired, err := SignToken(7, "alice", "user", DefaultSecret, -time.Minute)
+	require.NoError(t, err)
+	_, err = ParseToken(expired, DefaultSecret)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	anonymous, err := SignToken(7, "", "user", DefaultSecret, time.Minute)
+	require.NoError(t, err)
+	_, err = ParseToken(anonymous, DefaultSecret)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestParseToken_RejectsOtherAlgorithms(t *testing.T) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, Claims{Username: "mallory"}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	_, err = ParseToken(token, DefaultSecret)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/e6a5/learning/backend/09-websockets/internal/auth"
+	"github.com/e6a5/learning/backend/09-websockets/internal/hub"
+	"github.com/e6a5/learning/backend/09-websockets/internal/models"
+	"github.com/e6a5/learning/backend/09-websockets/internal/repository"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// AuthConfig decides who may chat. With RequireToken off, a token is still
+// honoured when given, and otherwise the name query parameter is trusted.
+type AuthConfig struct {
+	Secret       string
+	RequireToken bool
+	DevTokens    bool // serve POST /auth/token, for trying the lab alone
+}
+
+// ChatHandler handles chat connections and the HTTP endpoints around them
+type ChatHandler struct {
+	hub      *hub.Hub
+	presence *repository.PresenceRepository
+	history  *repository.HistoryRepository
+	auth     AuthConfig
+	upgrader websocket.Upgrader
+}
+
+// NewChatHandler creates a new chat handler. Connections are accepted from
+// any origin, since the lab is meant to be poked at from anywhere.
+func NewChatHandler(h *hub.Hub, presence *repository.PresenceRepository, history *repository.HistoryRepository, authConfig AuthConfig) *ChatHandler {
+	return &ChatHandler{
+		hub:      h,
+		presence: presence,
+		history:  history,
+		auth:     authConfig,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// Connect handles GET /ws/{room} - upgrades to a WebSocket and joins the room
+func (h *ChatHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	room := mux.Vars(r)["room"]
+	if err := models.ValidateName("room", room); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := h.identify(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	// On failure the upgrader has already answered the request
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Upgrade failed for %s in %s: %v", user, room, err)
+		return
+	}
+
+	log.Printf("%s connected to %s", user, room)
+	h.hub.Serve(conn, room, user)
+	log.Printf("%s disconnected from %s", user, room)
+}
+
+// identify works out who is connecting. Browsers cannot set headers on a
+// WebSocket handshake, so the token may also come as ?token=.
+func (h *ChatHandler) identify(r *http.Request) (string, error) {
+	token := r.URL.Query().Get("token")
+	if header := r.Header.Get("Authorization"); header != "" {
+		bearer, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			return "", errors.New("invalid authorization header format")
+		}
+		token = bearer
+	}
+
+	if token != "" {
+		claims, err := auth.ParseToken(token, h.auth.Secret)
+		if err != nil {
+			return "", errors.New("invalid token")
+		}
+		if err := models.ValidateName("user", claims.Username); err != nil {
+			return "", err
+		}
+		return claims.Username, nil
+	}
+
+	if h.auth.RequireToken {
+		return "", errors.New("token required")
+	}
+	name := r.URL.Query().Get("name")
+	if err := models.ValidateName("name", name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// GetRooms handles GET /rooms - rooms with someone in them
+func (h *ChatHandler) GetRooms(w http.ResponseWriter, r *http.Request) {
+	rooms := h.presence.Rooms()
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"rooms": rooms,
+			"count": len(rooms),
+		},
+	})
+}
+
+// GetUsers handles GET /rooms/{room}/users - who is in a room
+func (h *ChatHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	room := mux.Vars(r)["room"]
+	users := h.presence.Users(room)
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"room":  room,
+			"users": users,
+			"count": len(users),
+		},
+	})
+}
+
+// GetHistory handles GET /rooms/{room}/history - a room's recent messages
+func (h *ChatHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	room := mux.Vars(r)["room"]
+	messages := h.history.Recent(room)
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"room":     room,
+			"messages": messages,
+			"count":    len(messages),
+		},
+	})
+}
+
+// IssueToken handles POST /auth/token - signs a token for a username, the
+// way 06-auth-security's login would
+func (h *ChatHandler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	var req models.TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if err := models.ValidateName("username", req.Username); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	token, err := auth.SignToken(0, req.Username, "user", h.auth.Secret, time.Hour)
+	if err != nil {
+		log.Printf("Error signing token for %s: %v", req.Username, err)
+		response.Error(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{
+		Message: "Token issued, valid for one hour",
+		Data:    map[string]string{"token": token},
+	})
+}
+
+// HealthCheck handles GET /health
+func (h *ChatHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: "WebSocket chat server is healthy",
+		Data: map[string]interface{}{
+			"rooms":          len(h.presence.Rooms()),
+			"token_required": h.auth.RequireToken,
+		},
+	})
+}
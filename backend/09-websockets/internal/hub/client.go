@@ -0,0 +1,119 @@
+package hub
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/e6a5/learning/backend/09-websockets/internal/models"
+)
+
+const (
+	writeWait      = 10 * time.Second  // for one message to go out
+	pongWait       = 60 * time.Second  // for the client to answer a ping
+	pingPeriod     = pongWait * 9 / 10 // sooner than pongWait, so a pong can arrive in time
+	maxMessageSize = 4 * 1024          // bytes in one frame from the client
+	sendBuffer     = 256               // messages queued for a client before it is dropped
+)
+
+// MaxHistory is the most history a room can replay on join: it all goes into
+// the send buffer at once, with room left for the presence message after it
+const MaxHistory = sendBuffer - 1
+
+// Client is one WebSocket connection to one room
+type Client struct {
+	hub  *Hub
+	conn *websocket.Conn
+	send chan models.Message
+	room string
+	user string
+}
+
+// Serve joins conn to room as user and relays messages both ways until the
+// connection closes or the hub stops
+func (h *Hub) Serve(conn *websocket.Conn, room, user string) {
+	c := &Client{hub: h, conn: conn, send: make(chan models.Message, sendBuffer), room: room, user: user}
+	if !submit(h, h.register, c) {
+		conn.Close()
+		return
+	}
+	go c.writePump()
+	c.readPump()
+}
+
+// readPump turns what the client sends into room messages. It is the only
+// reader of the connection.
+func (c *Client) readPump() {
+	defer func() {
+		submit(c.hub, c.hub.unregister, c)
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("Connection of %s in %s closed: %v", c.user, c.room, err)
+			}
+			return
+		}
+
+		var in models.IncomingMessage
+		if err := json.Unmarshal(data, &in); err != nil {
+			c.reply("Invalid JSON")
+			continue
+		}
+		if err := in.Validate(); err != nil {
+			c.reply(err.Error())
+			continue
+		}
+		msg := models.Message{Type: models.TypeMessage, Room: c.room, User: c.user, Text: strings.TrimSpace(in.Text), Time: now()}
+		if !submit(c.hub, c.hub.broadcast, msg) {
+			return
+		}
+	}
+}
+
+// reply tells only this client what went wrong
+func (c *Client) reply(text string) {
+	submit(c.hub, c.hub.direct, delivery{client: c, msg: models.Message{Type: models.TypeError, Room: c.room, Text: text, Time: now()}})
+}
+
+// writePump sends queued messages and keeps the connection alive with
+// pings. It is the only writer of the connection, and sends a close frame
+// once the hub closes the queue.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := c.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
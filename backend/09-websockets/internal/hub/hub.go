@@ -0,0 +1,156 @@
+// Package hub fans chat messages out to everyone in a room. One goroutine,
+// Run, owns the rooms and their connections; everything else talks to it
+// over channels, so no lock guards them.
+package hub
+
+import (
+	"context"
+	"time"
+
+	"github.com/e6a5/learning/backend/09-websockets/internal/models"
+	"github.com/e6a5/learning/backend/09-websockets/internal/repository"
+)
+
+// delivery is a message meant for one client only
+type delivery struct {
+	client *Client
+	msg    models.Message
+}
+
+// Hub routes messages between the clients of each room
+type Hub struct {
+	rooms map[string]map[*Client]bool
+
+	register   chan *Client
+	unregister chan *Client
+	broadcast  chan models.Message
+	direct     chan delivery
+	done       chan struct{} // closed once Run returns
+
+	presence *repository.PresenceRepository
+	history  *repository.HistoryRepository
+}
+
+// NewHub creates a hub that records presence and history in the given
+// repositories; it routes nothing until Run is called
+func NewHub(presence *repository.PresenceRepository, history *repository.HistoryRepository) *Hub {
+	return &Hub{
+		rooms:      make(map[string]map[*Client]bool),
+		register:   make(chan *Client),
+		unregister: make(chan *Client),
+		broadcast:  make(chan models.Message),
+		direct:     make(chan delivery),
+		done:       make(chan struct{}),
+		presence:   presence,
+		history:    history,
+	}
+}
+
+// Run routes messages until ctx is done, then closes every connection
+func (h *Hub) Run(ctx context.Context) {
+	defer close(h.done)
+	for {
+		select {
+		case c := <-h.register:
+			h.join(c)
+		case c := <-h.unregister:
+			h.leave(c)
+		case msg := <-h.broadcast:
+			h.history.Add(msg)
+			h.deliver(msg, nil)
+		case d := <-h.direct:
+			if h.rooms[d.client.room][d.client] {
+				h.send(d.client, d.msg)
+			}
+		case <-ctx.Done():
+			for _, clients := range h.rooms {
+				for c := range clients {
+					h.leave(c)
+				}
+			}
+			return
+		}
+	}
+}
+
+// join adds c to its room and catches it up: the room's recent messages,
+// then who is there. The others hear about it only if the user just arrived.
+func (h *Hub) join(c *Client) {
+	clients, ok := h.rooms[c.room]
+	if !ok {
+		clients = make(map[*Client]bool)
+		h.rooms[c.room] = clients
+	}
+	clients[c] = true
+	arrived := h.presence.Join(c.room, c.user)
+
+	for _, msg := range h.history.Recent(c.room) {
+		if !h.send(c, msg) {
+			return
+		}
+	}
+	users := h.presence.Users(c.room)
+	if !h.send(c, models.Message{Type: models.TypePresence, Room: c.room, Users: users, Time: now()}) {
+		return
+	}
+	if arrived {
+		h.deliver(models.Message{Type: models.TypeJoin, Room: c.room, User: c.user, Users: users, Time: now()}, c)
+	}
+}
+
+// leave removes c and closes its send channel, which ends its write pump; a
+// client already gone is ignored
+func (h *Hub) leave(c *Client) {
+	clients := h.rooms[c.room]
+	if !clients[c] {
+		return
+	}
+	delete(clients, c)
+	if len(clients) == 0 {
+		delete(h.rooms, c.room)
+	}
+	close(c.send)
+
+	if h.presence.Leave(c.room, c.user) {
+		users := h.presence.Users(c.room)
+		h.deliver(models.Message{Type: models.TypeLeave, Room: c.room, User: c.user, Users: users, Time: now()}, nil)
+	}
+}
+
+// deliver sends msg to everyone in its room but except. Clients dropped on
+// the way, including any the leave messages drop, are not sent to again:
+// range skips entries deleted before it reaches them.
+func (h *Hub) deliver(msg models.Message, except *Client) {
+	for c := range h.rooms[msg.Room] {
+		if c != except {
+			h.send(c, msg)
+		}
+	}
+}
+
+// send queues msg for c without waiting. A client whose queue is full is not
+// keeping up, and is dropped rather than allowed to hold up the room; send
+// reports whether c is still there, since its channel is closed once it is not.
+func (h *Hub) send(c *Client, msg models.Message) bool {
+	select {
+	case c.send <- msg:
+		return true
+	default:
+		h.leave(c)
+		return false
+	}
+}
+
+// submit hands work to Run, giving up once it has stopped
+func submit[T any](h *Hub, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-h.done:
+		return false
+	}
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
@@ -0,0 +1,169 @@
+package hub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/09-websockets/internal/models"
+	"github.com/e6a5/learning/backend/09-websockets/internal/repository"
+)
+
+// startHub serves a running hub over a test server; clients pick their room
+// and name in the query string
+func startHub(t *testing.T) string {
+	return serveHub(t, NewHub(repository.NewPresenceRepository(), repository.NewHistoryRepository(10)))
+}
+
+func serveHub(t *testing.T, h *Hub) string {
+	ctx, cancel := context.WithCancel(context.Background())
+	go h.Run(ctx)
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		h.Serve(conn, r.URL.Query().Get("room"), r.URL.Query().Get("user"))
+	}))
+	t.Cleanup(func() {
+		cancel()
+		server.Close()
+	})
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func dial(t *testing.T, url, room, user string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?room="+room+"&user="+user, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func read(t *testing.T, conn *websocket.Conn) models.Message {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	var msg models.Message
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func TestHub_RoomConversation(t *testing.T) {
+	url := startHub(t)
+
+	alice := dial(t, url, "lobby", "alice")
+	msg := read(t, alice)
+	assert.Equal(t, models.TypePresence, msg.Type)
+	assert.Equal(t, []string{"alice"}, msg.Users)
+
+	bob := dial(t, url, "lobby", "bob")
+	msg = read(t, bob)
+	assert.Equal(t, models.TypePresence, msg.Type)
+	assert.Equal(t, []string{"alice", "bob"}, msg.Users)
+
+	msg = read(t, alice)
+	assert.Equal(t, models.TypeJoin, msg.Type)
+	assert.Equal(t, "bob", msg.User)
+
+	require.NoError(t, alice.WriteJSON(models.IncomingMessage{Text: "  hi  "}))
+	for _, conn := range []*websocket.Conn{alice, bob} {
+		msg = read(t, conn)
+		assert.Equal(t, models.TypeMessage, msg.Type)
+		assert.Equal(t, "alice", msg.User)
+		assert.Equal(t, "hi", msg.Text, "text is trimmed")
+		assert.Equal(t, "lobby", msg.Room)
+	}
+
+	require.NoError(t, bob.WriteJSON(models.IncomingMessage{Text: " "}))
+	msg = read(t, bob)
+	assert.Equal(t, models.TypeError, msg.Type)
+	assert.Contains(t, msg.Text, "Text is required")
+
+	require.NoError(t, bob.Close())
+	msg = read(t, alice)
+	assert.Equal(t, models.TypeLeave, msg.Type)
+	assert.Equal(t, "bob", msg.User)
+	assert.Equal(t, []string{"alice"}, msg.Users)
+}
+
+func TestHub_HistoryAndRooms(t *testing.T) {
+	url := startHub(t)
+
+	alice := dial(t, url, "lobby", "alice")
+	read(t, alice) // presence
+	require.NoError(t, alice.WriteJSON(models.IncomingMessage{Text: "first"}))
+	read(t, alice) // own message
+
+	// Someone elsewhere hears nothing of the lobby
+	carol := dial(t, url, "other", "carol")
+	msg := read(t, carol)
+	assert.Equal(t, models.TypePresence, msg.Type)
+	assert.Equal(t, []string{"carol"}, msg.Users)
+
+	// A newcomer catches up on history before presence
+	bob := dial(t, url, "lobby", "bob")
+	msg = read(t, bob)
+	assert.Equal(t, models.TypeMessage, msg.Type)
+	assert.Equal(t, "first", msg.Text)
+	msg = read(t, bob)
+	assert.Equal(t, models.TypePresence, msg.Type)
+}
+
+func TestHub_SecondConnectionIsNotAJoin(t *testing.T) {
+	url := startHub(t)
+
+	alice := dial(t, url, "lobby", "alice")
+	read(t, alice)
+	tab := dial(t, url, "lobby", "alice")
+	msg := read(t, tab)
+	assert.Equal(t, []string{"alice"}, msg.Users)
+
+	// Closing one tab is not leaving; the next thing alice hears is bob
+	require.NoError(t, tab.Close())
+	bob := dial(t, url, "lobby", "bob")
+	read(t, bob)
+	msg = read(t, alice)
+	assert.Equal(t, models.TypeJoin, msg.Type)
+	assert.Equal(t, "bob", msg.User)
+}
+
+func TestHub_HistoryLongerThanSendBuffer(t *testing.T) {
+	total := sendBuffer * 4
+	history := repository.NewHistoryRepository(total)
+	for i := 0; i < total; i++ {
+		history.Add(models.Message{Type: models.TypeMessage, Room: "lobby", User: "alice", Text: "old"})
+	}
+	url := serveHub(t, NewHub(repository.NewPresenceRepository(), history))
+
+	// The catch-up overflows the buffer, so the newcomer is dropped part way
+	// through, without the hub writing to its closed channel
+	bob := dial(t, url, "lobby", "bob")
+	received := 0
+	for {
+		require.NoError(t, bob.SetReadDeadline(time.Now().Add(2*time.Second)))
+		var msg models.Message
+		if err := bob.ReadJSON(&msg); err != nil {
+			assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), "closed by the hub: %v", err)
+			break
+		}
+		assert.Equal(t, models.TypeMessage, msg.Type)
+		received++
+	}
+	// The write pump may start draining before the catch-up ends, so a few
+	// more than the buffer can get through, but never the whole history
+	assert.GreaterOrEqual(t, received, sendBuffer)
+	assert.Less(t, received, total)
+
+	// The hub is still routing
+	carol := dial(t, url, "other", "carol")
+	msg := read(t, carol)
+	assert.Equal(t, models.TypePresence, msg.Type)
+	assert.Equal(t, []string{"carol"}, msg.Users)
+}
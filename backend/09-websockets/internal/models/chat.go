@@ -0,0 +1,73 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// Message types the server sends over a chat connection
+const (
+	TypeMessage  = "message"  // someone said Text
+	TypeJoin     = "join"     // User came into the room
+	TypeLeave    = "leave"    // User's last connection to the room closed
+	TypePresence = "presence" // who is here, sent once on connecting
+	TypeError    = "error"    // what was wrong with the last thing sent
+)
+
+// MaxMessageLength is the most characters one chat message may have
+const MaxMessageLength = 1000
+
+// Message is one event in a room. Users lists who is present on join, leave
+// and presence events.
+type Message struct {
+	Type  string    `json:"type"`
+	Room  string    `json:"room"`
+	User  string    `json:"user,omitempty"`
+	Text  string    `json:"text,omitempty"`
+	Users []string  `json:"users,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// IncomingMessage is what a client sends: only the text, since the server
+// knows the room and who is talking from the connection
+type IncomingMessage struct {
+	Text string `json:"text"`
+}
+
+// Validate validates an incoming chat message
+func (m IncomingMessage) Validate() error {
+	text := strings.TrimSpace(m.Text)
+	if text == "" {
+		return &validation.Error{Field: "text", Message: "Text is required"}
+	}
+	if utf8.RuneCountInString(text) > MaxMessageLength {
+		return &validation.Error{Field: "text", Message: fmt.Sprintf("Text must be at most %d characters", MaxMessageLength)}
+	}
+	return nil
+}
+
+// RoomInfo summarizes a room with someone in it
+type RoomInfo struct {
+	Name  string `json:"name"`
+	Users int    `json:"users"`
+}
+
+// TokenRequest asks for a development token for Username
+type TokenRequest struct {
+	Username string `json:"username"`
+}
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// ValidateName checks a room or user name: 1 to 32 letters, digits, - or _
+func ValidateName(field, name string) error {
+	if !namePattern.MatchString(name) {
+		return &validation.Error{Field: field, Message: "Must be 1-32 letters, digits, - or _"}
+	}
+	return nil
+}
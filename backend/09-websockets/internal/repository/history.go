@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/e6a5/learning/backend/09-websockets/internal/models"
+)
+
+// HistoryRepository keeps the latest messages of each room in memory, so
+// someone joining can catch up on the conversation
+type HistoryRepository struct {
+	mu    sync.RWMutex
+	limit int
+	rooms map[string][]models.Message
+}
+
+// NewHistoryRepository creates a history that keeps limit messages a room
+func NewHistoryRepository(limit int) *HistoryRepository {
+	return &HistoryRepository{limit: limit, rooms: make(map[string][]models.Message)}
+}
+
+// Add records a message, dropping the room's oldest one once it is full
+func (r *HistoryRepository) Add(msg models.Message) {
+	if r.limit <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	messages := append(r.rooms[msg.Room], msg)
+	if len(messages) > r.limit {
+		messages = messages[len(messages)-r.limit:]
+	}
+	r.rooms[msg.Room] = messages
+}
+
+// Recent returns the room's kept messages, oldest first
+func (r *HistoryRepository) Recent(room string) []models.Message {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.Message(nil), r.rooms[room]...)
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/e6a5/learning/backend/09-websockets/internal/models"
+)
+
+func TestHistory_KeepsLatestPerRoom(t *testing.T) {
+	r := NewHistoryRepository(2)
+	for _, text := range []string{"one", "two", "three"} {
+		r.Add(models.Message{Type: models.TypeMessage, Room: "lobby", Text: text})
+	}
+	r.Add(models.Message{Type: models.TypeMessage, Room: "dev", Text: "elsewhere"})
+
+	recent := r.Recent("lobby")
+	assert.Len(t, recent, 2)
+	assert.Equal(t, "two", recent[0].Text)
+	assert.Equal(t, "three", recent[1].Text)
+
+	// Callers get a copy
+	recent[0].Text = "changed"
+	assert.Equal(t, "two", r.Recent("lobby")[0].Text)
+
+	assert.Len(t, r.Recent("dev"), 1)
+	assert.Empty(t, r.Recent("nowhere"))
+}
+
+func TestHistory_Disabled(t *testing.T) {
+	r := NewHistoryRepository(0)
+	r.Add(models.Message{Room: "lobby", Text: "hi"})
+	assert.Empty(t, r.Recent("lobby"))
+}
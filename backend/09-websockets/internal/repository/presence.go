@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/e6a5/learning/backend/09-websockets/internal/models"
+)
+
+// PresenceRepository tracks who is connected to which room. A user may be
+// connected several times, from several tabs, and stays present until the
+// last of those connections closes.
+type PresenceRepository struct {
+	mu    sync.RWMutex
+	rooms map[string]map[string]int // room -> user -> open connections
+}
+
+// NewPresenceRepository creates an empty presence repository
+func NewPresenceRepository() *PresenceRepository {
+	return &PresenceRepository{rooms: make(map[string]map[string]int)}
+}
+
+// Join records a connection and reports whether it is the user's first in
+// the room, that is whether the user just arrived
+func (r *PresenceRepository) Join(room, user string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users, ok := r.rooms[room]
+	if !ok {
+		users = make(map[string]int)
+		r.rooms[room] = users
+	}
+	users[user]++
+	return users[user] == 1
+}
+
+// Leave forgets a connection and reports whether it was the user's last in
+// the room, that is whether the user is gone. Empty rooms are removed.
+func (r *PresenceRepository) Leave(room, user string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := r.rooms[room]
+	if users[user] == 0 {
+		return false
+	}
+	users[user]--
+	if users[user] > 0 {
+		return false
+	}
+	delete(users, user)
+	if len(users) == 0 {
+		delete(r.rooms, room)
+	}
+	return true
+}
+
+// Users returns who is in the room, sorted by name
+func (r *PresenceRepository) Users(room string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]string, 0, len(r.rooms[room]))
+	for user := range r.rooms[room] {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// Rooms returns every room with someone in it, sorted by name
+func (r *PresenceRepository) Rooms() []models.RoomInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rooms := make([]models.RoomInfo, 0, len(r.rooms))
+	for name, users := range r.rooms {
+		rooms = append(rooms, models.RoomInfo{Name: name, Users: len(users)})
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].Name < rooms[j].Name })
+	return rooms
+}
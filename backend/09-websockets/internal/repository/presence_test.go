@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/e6a5/learning/backend/09-websockets/internal/models"
+)
+
+func TestPresence_JoinLeave(t *testing.T) {
+	r := NewPresenceRepository()
+
+	assert.True(t, r.Join("lobby", "alice"), "first connection arrives")
+	assert.False(t, r.Join("lobby", "alice"), "second tab does not")
+	assert.True(t, r.Join("lobby", "bob"))
+	assert.True(t, r.Join("dev", "alice"))
+
+	assert.Equal(t, []string{"alice", "bob"}, r.Users("lobby"))
+	assert.Equal(t, []models.RoomInfo{{Name: "dev", Users: 1}, {Name: "lobby", Users: 2}}, r.Rooms())
+
+	assert.False(t, r.Leave("lobby", "alice"), "one tab still open")
+	assert.True(t, r.Leave("lobby", "alice"))
+	assert.False(t, r.Leave("lobby", "alice"), "already gone")
+	assert.Equal(t, []string{"bob"}, r.Users("lobby"))
+
+	assert.True(t, r.Leave("dev", "alice"))
+	assert.Equal(t, []models.RoomInfo{{Name: "lobby", Users: 1}}, r.Rooms(), "empty rooms are removed")
+	assert.Empty(t, r.Users("dev"))
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/09-websockets/internal/auth"
+	"github.com/e6a5/learning/backend/09-websockets/internal/handlers"
+	"github.com/e6a5/learning/backend/09-websockets/internal/hub"
+	"github.com/e6a5/learning/backend/09-websockets/internal/repository"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+// index is a minimal browser client, so the lab can be tried without tools
+//
+//go:embed static/index.html
+var index []byte
+
+func main() {
+	port := env.Get("PORT", "8080")
+	historyLimit, err := strconv.Atoi(env.Get("HISTORY_LIMIT", "50"))
+	if err != nil {
+		log.Fatal("HISTORY_LIMIT must be a number:", err)
+	}
+	if historyLimit > hub.MaxHistory {
+		log.Fatalf("HISTORY_LIMIT must be at most %d, the history a client can be sent on joining", hub.MaxHistory)
+	}
+	authConfig := handlers.AuthConfig{
+		Secret:       env.Get("JWT_SECRET", auth.DefaultSecret),
+		RequireToken: env.Get("REQUIRE_TOKEN", "false") == "true",
+		DevTokens:    env.Get("DEV_TOKENS", "false") == "true",
+	}
+
+	// Initialize dependencies
+	presenceRepo := repository.NewPresenceRepository()
+	historyRepo := repository.NewHistoryRepository(historyLimit)
+	chatHub := hub.NewHub(presenceRepo, historyRepo)
+	chatHandler := handlers.NewChatHandler(chatHub, presenceRepo, historyRepo, authConfig)
+
+	// The hub routes messages until shutdown, then closes every connection
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	hubDone := make(chan struct{})
+	go func() {
+		chatHub.Run(hubCtx)
+		close(hubDone)
+	}()
+
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           setupRoutes(chatHandler, authConfig.DevTokens),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Println("💬 Chat server running at http://localhost:" + port)
+		if authConfig.RequireToken {
+			log.Println("🔐 Tokens required: log in through 06-auth-security or POST /auth/token")
+		}
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down server...")
+
+	// Shutdown does not wait for hijacked connections, so the hub says
+	// goodbye to every chat client first
+	stopHub()
+	<-hubDone
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Fatal("Server forced to shutdown:", err)
+	}
+	log.Println("Server exited")
+}
+
+func setupRoutes(chatHandler *handlers.ChatHandler, devTokens bool) *mux.Router {
+	router := mux.NewRouter()
+
+	// Browser client
+	router.HandleFunc("/", serveIndex).Methods("GET")
+
+	// WebSocket endpoint, one connection per room
+	router.HandleFunc("/ws/{room}", chatHandler.Connect).Methods("GET")
+
+	// Rooms, presence and history
+	router.HandleFunc("/rooms", chatHandler.GetRooms).Methods("GET")
+	router.HandleFunc("/rooms/{room}/users", chatHandler.GetUsers).Methods("GET")
+	router.HandleFunc("/rooms/{room}/history", chatHandler.GetHistory).Methods("GET")
+
+	// Development tokens, signed like 06-auth-security's
+	if devTokens {
+		router.HandleFunc("/auth/token", chatHandler.IssueToken).Methods("POST")
+	}
+
+	// Health check
+	router.HandleFunc("/health", chatHandler.HealthCheck).Methods("GET")
+
+	return router
+}
+
+func serveIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(index)
+}
@@ -0,0 +1,20 @@
+module github.com/e6a5/learning/backend/10-message-queues
+
+go 1.23.4
+
+require (
+	github.com/e6a5/learning/backend/pkg v0.0.0
+	github.com/gorilla/mux v1.8.1
+	github.com/rabbitmq/amqp091-go v1.10.0
+	github.com/stretchr/testify v1.9.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// The shared helpers in backend/pkg come from the directory next door
+// rather than a published version
+replace github.com/e6a5/learning/backend/pkg => ../pkg
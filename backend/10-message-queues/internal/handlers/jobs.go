@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/10-message-queues/internal/models"
+	"github.com/e6a5/learning/backend/10-message-queues/internal/queue"
+	"github.com/e6a5/learning/backend/10-message-queues/internal/repository"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// JobHandler handles job submission and inspection
+type JobHandler struct {
+	broker *queue.RabbitMQ
+	status *repository.StatusRepository
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(broker *queue.RabbitMQ, status *repository.StatusRepository) *JobHandler {
+	return &JobHandler{broker: broker, status: status}
+}
+
+// CreateJob handles POST /jobs - enqueues a job and returns before it runs
+func (h *JobHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	var req models.JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job := models.NewJob(newJobID(), req, time.Now())
+	if err := h.broker.Enqueue(r.Context(), job); err != nil {
+		log.Printf("Error enqueueing job %s: %v", job.ID, err)
+		response.Error(w, http.StatusServiceUnavailable, "Queue unavailable")
+		return
+	}
+
+	event := models.JobEvent{JobID: job.ID, Type: job.Type, Status: models.StatusQueued, Time: job.CreatedAt}
+	if err := h.broker.PublishEvent(r.Context(), event); err != nil {
+		// The subscription would have recorded it; do it here instead
+		log.Printf("Failed to publish queued event for job %s: %v", job.ID, err)
+		h.status.Apply(event)
+	}
+
+	response.JSON(w, http.StatusAccepted, response.APIResponse{
+		Message: "Job queued",
+		Data:    job,
+	})
+}
+
+// GetJobs handles GET /jobs - recent jobs, optionally ?status=dead
+func (h *JobHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	jobs := h.status.List(r.URL.Query().Get("status"))
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"jobs":   jobs,
+			"count":  len(jobs),
+			"counts": h.status.Counts(),
+		},
+	})
+}
+
+// GetJob handles GET /jobs/{id} - one job and its history of events
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	status, err := h.status.Get(mux.Vars(r)["id"])
+	if errors.Is(err, repository.ErrJobNotFound) {
+		response.Error(w, http.StatusNotFound, "Job not found")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: status})
+}
+
+// GetQueues handles GET /queues - depth of the work and dead-letter queues
+func (h *JobHandler) GetQueues(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.broker.Stats()
+	if err != nil {
+		log.Printf("Error reading queue stats: %v", err)
+		response.Error(w, http.StatusServiceUnavailable, "Queue unavailable")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: stats})
+}
+
+// ReplayDeadLetters handles POST /dlq/replay?limit=n - moves dead jobs back
+// onto the work queue for another full set of attempts
+func (h *JobHandler) ReplayDeadLetters(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			response.Error(w, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+		limit = n
+	}
+
+	moved, err := h.broker.Replay(r.Context(), limit)
+	if err != nil {
+		log.Printf("Replay stopped after %d jobs: %v", moved, err)
+		response.JSON(w, http.StatusInternalServerError, response.APIResponse{
+			Error: "Replay stopped: " + err.Error(),
+			Data:  map[string]int{"replayed": moved},
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: "Dead letters replayed",
+		Data:    map[string]int{"replayed": moved},
+	})
+}
+
+// HealthCheck handles GET /health
+func (h *JobHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.broker.Stats(); err != nil {
+		response.Error(w, http.StatusServiceUnavailable, "RabbitMQ unreachable")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Message queue service is healthy"})
+}
+
+// newJobID returns a random 16-character hex ID
+func newJobID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,109 @@
+// Package jobs holds the work the workers know how to do. The demo jobs
+// only sleep and fail on request, so retries and dead-lettering can be
+// watched without any real email server or image store.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Func does one job with its payload
+type Func func(ctx context.Context, payload json.RawMessage) error
+
+// Registry maps job types to what doing them means
+type Registry map[string]Func
+
+// permanentError marks a failure that another attempt will not fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job goes straight to the dead-letter queue
+// instead of being retried
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// Default returns the demo jobs
+func Default() Registry {
+	return Registry{
+		"email":  sendEmail,
+		"resize": resizeImage,
+		"flaky":  flaky,
+		"poison": poison,
+	}
+}
+
+// sendEmail pretends to deliver {"to": "..."}
+func sendEmail(ctx context.Context, payload json.RawMessage) error {
+	var p struct {
+		To string `json:"to"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil || p.To == "" {
+		return Permanent(errors.New(`payload needs "to"`))
+	}
+	return work(ctx, 200*time.Millisecond)
+}
+
+// resizeImage pretends to resize {"width": n}; big images take longer
+func resizeImage(ctx context.Context, payload json.RawMessage) error {
+	var p struct {
+		Width int `json:"width"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil || p.Width <= 0 {
+		return Permanent(errors.New(`payload needs a positive "width"`))
+	}
+	return work(ctx, time.Duration(p.Width)*time.Millisecond)
+}
+
+// flaky fails with probability {"fail_rate": 0..1}, like a dependency that
+// times out now and then
+func flaky(ctx context.Context, payload json.RawMessage) error {
+	p := struct {
+		FailRate float64 `json:"fail_rate"`
+	}{FailRate: 0.5}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return Permanent(fmt.Errorf("invalid payload: %w", err))
+		}
+	}
+	if err := work(ctx, 100*time.Millisecond); err != nil {
+		return err
+	}
+	if rand.Float64() < p.FailRate {
+		return errors.New("upstream timed out")
+	}
+	return nil
+}
+
+// poison always fails with a retryable error, so it uses up every attempt
+// and lands in the dead-letter queue
+func poison(ctx context.Context, payload json.RawMessage) error {
+	return errors.New("this job never succeeds")
+}
+
+func work(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
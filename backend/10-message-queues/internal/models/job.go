@@ -0,0 +1,93 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// Job statuses, in the order a job normally goes through them
+const (
+	StatusQueued     = "queued"     // accepted by the API, waiting in the queue
+	StatusProcessing = "processing" // a worker picked it up
+	StatusRetrying   = "retrying"   // failed, parked in a delay queue
+	StatusSucceeded  = "succeeded"
+	StatusDead       = "dead" // out of attempts, in the dead-letter queue
+)
+
+// DefaultMaxAttempts is how often a job is tried when the request says nothing
+const DefaultMaxAttempts = 5
+
+// Job is the message that travels through the queues. Attempt counts the
+// tries already made, so it survives a trip through a delay queue.
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Attempt     int             `json:"attempt"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// JobRequest is the body of POST /jobs
+type JobRequest struct {
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	MaxAttempts int             `json:"max_attempts"`
+}
+
+// Validate validates a job request
+func (r JobRequest) Validate() error {
+	if r.Type == "" {
+		return &validation.Error{Field: "type", Message: "Type is required"}
+	}
+	if r.MaxAttempts < 0 || r.MaxAttempts > 20 {
+		return &validation.Error{Field: "max_attempts", Message: "Max attempts must be 1-20, or 0 for the default"}
+	}
+	if len(r.Payload) > 0 && !json.Valid(r.Payload) {
+		return &validation.Error{Field: "payload", Message: "Payload must be valid JSON"}
+	}
+	return nil
+}
+
+// NewJob turns a validated request into a job ready to enqueue
+func NewJob(id string, r JobRequest, now time.Time) Job {
+	maxAttempts := r.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return Job{ID: id, Type: r.Type, Payload: r.Payload, MaxAttempts: maxAttempts, CreatedAt: now}
+}
+
+// JobEvent reports a job changing status. Workers publish them on a fanout
+// exchange so every API instance can follow jobs it did not process.
+type JobEvent struct {
+	JobID   string    `json:"job_id"`
+	Type    string    `json:"type"`
+	Status  string    `json:"status"`
+	Attempt int       `json:"attempt"`
+	Error   string    `json:"error,omitempty"`
+	DelayMs int64     `json:"delay_ms,omitempty"`
+	Worker  string    `json:"worker,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// JobStatus is what the API knows about a job, built from its events
+type JobStatus struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`
+	Status    string     `json:"status"`
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"last_error,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Events    []JobEvent `json:"events"`
+}
+
+// QueueStats is a snapshot of one queue
+type QueueStats struct {
+	Name      string `json:"name"`
+	Messages  int    `json:"messages"`
+	Consumers int    `json:"consumers"`
+}
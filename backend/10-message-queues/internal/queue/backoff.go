@@ -0,0 +1,29 @@
+package queue
+
+import "time"
+
+// Backoff computes how long a failed job waits before its next attempt. The
+// delay doubles with every attempt up to Max. There is no jitter: each
+// distinct delay needs its own TTL queue, so delays come in a few fixed steps.
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay returns the wait after the given failed attempt, counting from 1
+func (b Backoff) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := b.Base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= b.Max {
+			return b.Max
+		}
+	}
+	if delay > b.Max {
+		return b.Max
+	}
+	return delay
+}
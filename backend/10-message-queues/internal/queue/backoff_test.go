@@ -0,0 +1,30 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	b := Backoff{Base: time.Second, Max: 10 * time.Second}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, b.Delay(tt.attempt), "attempt %d", tt.attempt)
+	}
+
+	assert.Equal(t, time.Second, Backoff{Base: 5 * time.Second, Max: time.Second}.Delay(1), "max wins over base")
+}
@@ -0,0 +1,323 @@
+// Package queue moves jobs through RabbitMQ. The topology, all on the
+// default exchange so queues are addressed by name:
+//
+//	jobs              work queue; rejected messages dead-letter to jobs.dead
+//	jobs.retry.<ms>   one per backoff step; expired messages go back to jobs
+//	jobs.dead         jobs out of attempts, or that could not be parsed
+//	jobs.events       fanout exchange for status events
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/e6a5/learning/backend/10-message-queues/internal/models"
+)
+
+// Names of the queues and exchanges this package declares
+const (
+	JobsQueue       = "jobs"
+	DeadLetterQueue = "jobs.dead"
+	EventsExchange  = "jobs.events"
+	retryPrefix     = "jobs.retry."
+)
+
+// ErrNotConfirmed is returned when the broker refuses a published message
+var ErrNotConfirmed = errors.New("publish not confirmed by broker")
+
+// RabbitMQ publishes and consumes jobs. Publishing goes through one channel
+// in confirm mode, so a nil error means the broker has the message.
+type RabbitMQ struct {
+	conn *amqp.Connection
+
+	mu       sync.Mutex // guards pub and declared
+	pub      *amqp.Channel
+	declared map[string]bool // retry queues already declared
+}
+
+// NewRabbitMQ connects to url and declares the topology
+func NewRabbitMQ(url string) (*RabbitMQ, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	pub, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("open channel: %w", err)
+	}
+	if err := pub.Confirm(false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("enable confirms: %w", err)
+	}
+
+	r := &RabbitMQ{conn: conn, pub: pub, declared: make(map[string]bool)}
+	if err := r.declare(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// declare creates the queues and exchange; declaring is idempotent, so
+// every process does it on startup
+func (r *RabbitMQ) declare() error {
+	if _, err := r.pub.QueueDeclare(DeadLetterQueue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare %s: %w", DeadLetterQueue, err)
+	}
+	_, err := r.pub.QueueDeclare(JobsQueue, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": DeadLetterQueue,
+	})
+	if err != nil {
+		return fmt.Errorf("declare %s: %w", JobsQueue, err)
+	}
+	if err := r.pub.ExchangeDeclare(EventsExchange, amqp.ExchangeFanout, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare %s: %w", EventsExchange, err)
+	}
+	return nil
+}
+
+// Close closes the connection and every channel on it
+func (r *RabbitMQ) Close() error {
+	return r.conn.Close()
+}
+
+// Closed is notified when the connection drops
+func (r *RabbitMQ) Closed() <-chan *amqp.Error {
+	return r.conn.NotifyClose(make(chan *amqp.Error, 1))
+}
+
+// Enqueue puts a job on the work queue
+func (r *RabbitMQ) Enqueue(ctx context.Context, job models.Job) error {
+	return r.publishJob(ctx, JobsQueue, job)
+}
+
+// Retry parks a job in the delay queue for delay; when its TTL runs out
+// RabbitMQ dead-letters it back onto the work queue
+func (r *RabbitMQ) Retry(ctx context.Context, job models.Job, delay time.Duration) error {
+	name := fmt.Sprintf("%s%d", retryPrefix, delay.Milliseconds())
+	if err := r.declareRetryQueue(name, delay); err != nil {
+		return err
+	}
+	return r.publishJob(ctx, name, job)
+}
+
+// DeadLetter moves a job to the dead-letter queue for a human to look at
+func (r *RabbitMQ) DeadLetter(ctx context.Context, job models.Job) error {
+	return r.publishJob(ctx, DeadLetterQueue, job)
+}
+
+// PublishEvent announces a status change to every subscriber. Events are
+// informational, so they are neither persistent nor confirmed.
+func (r *RabbitMQ) PublishEvent(ctx context.Context, event models.JobEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.pub.PublishWithDeferredConfirmWithContext(ctx, EventsExchange, "", false, false, amqp.Publishing{
+		ContentType: "application/json",
+		Timestamp:   event.Time,
+		Body:        body,
+	})
+	return err
+}
+
+func (r *RabbitMQ) declareRetryQueue(name string, delay time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.declared[name] {
+		return nil
+	}
+	_, err := r.pub.QueueDeclare(name, true, false, false, false, amqp.Table{
+		"x-message-ttl":             delay.Milliseconds(),
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": JobsQueue,
+	})
+	if err != nil {
+		return fmt.Errorf("declare %s: %w", name, err)
+	}
+	r.declared[name] = true
+	return nil
+}
+
+// publishJob sends a persistent job to queue and waits for the broker to
+// confirm it
+func (r *RabbitMQ) publishJob(ctx context.Context, queue string, job models.Job) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	confirmation, err := r.pub.PublishWithDeferredConfirmWithContext(ctx, "", queue, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    job.ID,
+		Type:         job.Type,
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("publish to %s: %w", queue, err)
+	}
+
+	ok, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("publish to %s: %w", queue, err)
+	}
+	if !ok {
+		return fmt.Errorf("publish to %s: %w", queue, ErrNotConfirmed)
+	}
+	return nil
+}
+
+// Consume hands jobs from the work queue to handle, running up to
+// concurrency at once, until ctx is done. A job is acknowledged once handle
+// returns nil; an error puts it back on the queue. A message that is not a
+// job is rejected, which dead-letters it.
+func (r *RabbitMQ) Consume(ctx context.Context, concurrency int, handle func(context.Context, models.Job) error) error {
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("open channel: %w", err)
+	}
+	defer ch.Close()
+
+	// Prefetch bounds unacknowledged messages, so one worker does not hoard
+	// the queue while others sit idle
+	if err := ch.Qos(concurrency, 0, false); err != nil {
+		return fmt.Errorf("set prefetch: %w", err)
+	}
+	deliveries, err := ch.ConsumeWithContext(ctx, JobsQueue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("consume %s: %w", JobsQueue, err)
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	slots := make(chan struct{}, concurrency)
+	for d := range deliveries {
+		var job models.Job
+		if err := json.Unmarshal(d.Body, &job); err != nil || job.ID == "" {
+			log.Printf("Rejecting malformed message %q: %v", d.MessageId, err)
+			_ = d.Nack(false, false)
+			continue
+		}
+
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(d amqp.Delivery, job models.Job) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			// Jobs in flight finish even when ctx ends, so their outcome is
+			// acknowledged rather than replayed
+			if err := handle(context.WithoutCancel(ctx), job); err != nil {
+				log.Printf("Requeueing job %s: %v", job.ID, err)
+				_ = d.Nack(false, true)
+				return
+			}
+			_ = d.Ack(false)
+		}(d, job)
+	}
+	return ctx.Err()
+}
+
+// Subscribe calls fn with every status event until ctx is done. Each
+// subscriber gets its own temporary queue, so all of them see every event.
+func (r *RabbitMQ) Subscribe(ctx context.Context, fn func(models.JobEvent)) error {
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("open channel: %w", err)
+	}
+	defer ch.Close()
+
+	q, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return fmt.Errorf("declare event queue: %w", err)
+	}
+	if err := ch.QueueBind(q.Name, "", EventsExchange, false, nil); err != nil {
+		return fmt.Errorf("bind event queue: %w", err)
+	}
+	deliveries, err := ch.ConsumeWithContext(ctx, q.Name, "", true, true, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("consume events: %w", err)
+	}
+
+	for d := range deliveries {
+		var event models.JobEvent
+		if err := json.Unmarshal(d.Body, &event); err != nil {
+			log.Printf("Ignoring malformed event: %v", err)
+			continue
+		}
+		fn(event)
+	}
+	return ctx.Err()
+}
+
+// Stats reports the depth of the work and dead-letter queues
+func (r *RabbitMQ) Stats() ([]models.QueueStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make([]models.QueueStats, 0, 2)
+	for _, name := range []string{JobsQueue, DeadLetterQueue} {
+		q, err := r.pub.QueueDeclarePassive(name, true, false, false, false, nil)
+		if err != nil {
+			return nil, fmt.Errorf("inspect %s: %w", name, err)
+		}
+		stats = append(stats, models.QueueStats{Name: q.Name, Messages: q.Messages, Consumers: q.Consumers})
+	}
+	return stats, nil
+}
+
+// Replay moves up to limit jobs from the dead-letter queue back onto the
+// work queue with their attempts reset, and returns how many it moved. A
+// job is only removed from the dead-letter queue once it is safely requeued.
+func (r *RabbitMQ) Replay(ctx context.Context, limit int) (int, error) {
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("open channel: %w", err)
+	}
+	defer ch.Close()
+
+	moved := 0
+	for moved < limit {
+		d, ok, err := ch.Get(DeadLetterQueue, false)
+		if err != nil {
+			return moved, fmt.Errorf("get from %s: %w", DeadLetterQueue, err)
+		}
+		if !ok {
+			break
+		}
+
+		var job models.Job
+		if err := json.Unmarshal(d.Body, &job); err != nil || job.ID == "" {
+			// Not a job, so replaying it would only send it back here
+			_ = d.Nack(false, true)
+			return moved, fmt.Errorf("message %q in %s is not a job", d.MessageId, DeadLetterQueue)
+		}
+		job.Attempt = 0
+		job.LastError = ""
+		if err := r.Enqueue(ctx, job); err != nil {
+			_ = d.Nack(false, true)
+			return moved, err
+		}
+		if err := d.Ack(false); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
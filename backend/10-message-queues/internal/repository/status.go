@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/e6a5/learning/backend/10-message-queues/internal/models"
+)
+
+// ErrJobNotFound is returned for a job this instance has never heard of
+var ErrJobNotFound = errors.New("job not found")
+
+// StatusRepository keeps the status of recent jobs in memory, built from
+// the events workers publish. Once full it forgets the least recently
+// updated job.
+type StatusRepository struct {
+	mu    sync.RWMutex
+	limit int
+	jobs  map[string]*models.JobStatus
+}
+
+// NewStatusRepository creates a repository that remembers up to limit jobs
+func NewStatusRepository(limit int) *StatusRepository {
+	return &StatusRepository{limit: limit, jobs: make(map[string]*models.JobStatus)}
+}
+
+// Apply records an event. Events can arrive out of order, since the API's
+// own queued event races the first worker's; the latest one by time wins
+// the status, and all of them are kept in order.
+func (r *StatusRepository) Apply(event models.JobEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status, ok := r.jobs[event.JobID]
+	if !ok {
+		r.evict()
+		status = &models.JobStatus{ID: event.JobID, Type: event.Type}
+		r.jobs[event.JobID] = status
+	}
+
+	i := sort.Search(len(status.Events), func(i int) bool { return status.Events[i].Time.After(event.Time) })
+	status.Events = append(status.Events, models.JobEvent{})
+	copy(status.Events[i+1:], status.Events[i:])
+	status.Events[i] = event
+
+	latest := status.Events[len(status.Events)-1]
+	status.Status = latest.Status
+	status.UpdatedAt = latest.Time
+	if event.Attempt > status.Attempts {
+		status.Attempts = event.Attempt
+	}
+	for j := len(status.Events) - 1; j >= 0; j-- {
+		if status.Events[j].Error != "" {
+			status.LastError = status.Events[j].Error
+			break
+		}
+	}
+}
+
+// evict drops the least recently updated job when the repository is full
+func (r *StatusRepository) evict() {
+	if r.limit <= 0 || len(r.jobs) < r.limit {
+		return
+	}
+	var oldest *models.JobStatus
+	for _, status := range r.jobs {
+		if oldest == nil || status.UpdatedAt.Before(oldest.UpdatedAt) {
+			oldest = status
+		}
+	}
+	delete(r.jobs, oldest.ID)
+}
+
+// Get returns a copy of one job's status
+func (r *StatusRepository) Get(id string) (models.JobStatus, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	status, ok := r.jobs[id]
+	if !ok {
+		return models.JobStatus{}, ErrJobNotFound
+	}
+	return copyStatus(status), nil
+}
+
+// List returns jobs with the given status, or all of them when status is
+// empty, most recently updated first
+func (r *StatusRepository) List(status string) []models.JobStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]models.JobStatus, 0, len(r.jobs))
+	for _, s := range r.jobs {
+		if status == "" || s.Status == status {
+			list = append(list, copyStatus(s))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].UpdatedAt.After(list[j].UpdatedAt) })
+	return list
+}
+
+// Counts returns how many jobs are in each status
+func (r *StatusRepository) Counts() map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, s := range r.jobs {
+		counts[s.Status]++
+	}
+	return counts
+}
+
+func copyStatus(s *models.JobStatus) models.JobStatus {
+	c := *s
+	c.Events = append([]models.JobEvent(nil), s.Events...)
+	return c
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/10-message-queues/internal/models"
+)
+
+func TestStatus_ApplyOutOfOrder(t *testing.T) {
+	r := NewStatusRepository(10)
+	start := time.Now()
+
+	r.Apply(models.JobEvent{JobID: "1", Type: "email", Status: models.StatusProcessing, Attempt: 1, Time: start.Add(time.Second)})
+	r.Apply(models.JobEvent{JobID: "1", Type: "email", Status: models.StatusQueued, Time: start})
+	r.Apply(models.JobEvent{JobID: "1", Type: "email", Status: models.StatusRetrying, Attempt: 1, Error: "boom", Time: start.Add(2 * time.Second)})
+
+	status, err := r.Get("1")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusRetrying, status.Status)
+	assert.Equal(t, 1, status.Attempts)
+	assert.Equal(t, "boom", status.LastError)
+	require.Len(t, status.Events, 3)
+	assert.Equal(t, models.StatusQueued, status.Events[0].Status, "events are kept in time order")
+
+	_, err = r.Get("2")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestStatus_ListCountsAndEviction(t *testing.T) {
+	r := NewStatusRepository(2)
+	start := time.Now()
+
+	r.Apply(models.JobEvent{JobID: "1", Status: models.StatusSucceeded, Time: start})
+	r.Apply(models.JobEvent{JobID: "2", Status: models.StatusDead, Time: start.Add(time.Second)})
+	assert.Equal(t, map[string]int{models.StatusSucceeded: 1, models.StatusDead: 1}, r.Counts())
+
+	dead := r.List(models.StatusDead)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "2", dead[0].ID)
+
+	// A third job pushes out the least recently updated one
+	r.Apply(models.JobEvent{JobID: "3", Status: models.StatusQueued, Time: start.Add(2 * time.Second)})
+	all := r.List("")
+	require.Len(t, all, 2)
+	assert.Equal(t, "3", all[0].ID, "newest first")
+	assert.Equal(t, "2", all[1].ID)
+}
@@ -0,0 +1,98 @@
+// Package worker decides what happens to a job after each attempt:
+// acknowledged on success, parked in a delay queue on failure, and
+// dead-lettered once it is out of attempts or fails permanently.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/e6a5/learning/backend/10-message-queues/internal/jobs"
+	"github.com/e6a5/learning/backend/10-message-queues/internal/models"
+	"github.com/e6a5/learning/backend/10-message-queues/internal/queue"
+)
+
+// Broker is where a worker sends jobs it could not finish, and its events
+type Broker interface {
+	Retry(ctx context.Context, job models.Job, delay time.Duration) error
+	DeadLetter(ctx context.Context, job models.Job) error
+	PublishEvent(ctx context.Context, event models.JobEvent) error
+}
+
+// Worker runs jobs from the registry
+type Worker struct {
+	name     string
+	broker   Broker
+	registry jobs.Registry
+	backoff  queue.Backoff
+	timeout  time.Duration
+	now      func() time.Time
+}
+
+// NewWorker creates a worker that gives every attempt up to timeout
+func NewWorker(name string, broker Broker, registry jobs.Registry, backoff queue.Backoff, timeout time.Duration) *Worker {
+	return &Worker{name: name, broker: broker, registry: registry, backoff: backoff, timeout: timeout, now: time.Now}
+}
+
+// Handle makes one attempt at job and routes it onwards. It returns an error
+// only when the job could not be routed, in which case the delivery must be
+// requeued so the job is not lost.
+func (w *Worker) Handle(ctx context.Context, job models.Job) error {
+	job.Attempt++
+	w.publish(ctx, job, models.StatusProcessing, "", 0)
+
+	err := w.run(ctx, job)
+	if err == nil {
+		w.publish(ctx, job, models.StatusSucceeded, "", 0)
+		return nil
+	}
+	job.LastError = err.Error()
+
+	if jobs.IsPermanent(err) || job.Attempt >= job.MaxAttempts {
+		if err := w.broker.DeadLetter(ctx, job); err != nil {
+			return fmt.Errorf("dead-letter: %w", err)
+		}
+		log.Printf("Job %s (%s) dead after %d attempts: %s", job.ID, job.Type, job.Attempt, job.LastError)
+		w.publish(ctx, job, models.StatusDead, job.LastError, 0)
+		return nil
+	}
+
+	delay := w.backoff.Delay(job.Attempt)
+	if err := w.broker.Retry(ctx, job, delay); err != nil {
+		return fmt.Errorf("retry: %w", err)
+	}
+	w.publish(ctx, job, models.StatusRetrying, job.LastError, delay)
+	return nil
+}
+
+// run looks up and runs the job under the attempt timeout. A type nobody
+// knows fails permanently.
+func (w *Worker) run(ctx context.Context, job models.Job) error {
+	fn, ok := w.registry[job.Type]
+	if !ok {
+		return jobs.Permanent(fmt.Errorf("unknown job type %q", job.Type))
+	}
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	return fn(ctx, job.Payload)
+}
+
+// publish sends a status event; a lost event only makes the API's view
+// stale, so failures are logged and ignored
+func (w *Worker) publish(ctx context.Context, job models.Job, status, errText string, delay time.Duration) {
+	event := models.JobEvent{
+		JobID:   job.ID,
+		Type:    job.Type,
+		Status:  status,
+		Attempt: job.Attempt,
+		Error:   errText,
+		DelayMs: delay.Milliseconds(),
+		Worker:  w.name,
+		Time:    w.now(),
+	}
+	if err := w.broker.PublishEvent(ctx, event); err != nil {
+		log.Printf("Failed to publish %s event for job %s: %v", status, job.ID, err)
+	}
+}
@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/10-message-queues/internal/jobs"
+	"github.com/e6a5/learning/backend/10-message-queues/internal/models"
+	"github.com/e6a5/learning/backend/10-message-queues/internal/queue"
+)
+
+// fakeBroker records where jobs were routed
+type fakeBroker struct {
+	retried  []models.Job
+	delays   []time.Duration
+	dead     []models.Job
+	events   []models.JobEvent
+	retryErr error
+}
+
+func (b *fakeBroker) Retry(ctx context.Context, job models.Job, delay time.Duration) error {
+	if b.retryErr != nil {
+		return b.retryErr
+	}
+	b.retried = append(b.retried, job)
+	b.delays = append(b.delays, delay)
+	return nil
+}
+
+func (b *fakeBroker) DeadLetter(ctx context.Context, job models.Job) error {
+	b.dead = append(b.dead, job)
+	return nil
+}
+
+func (b *fakeBroker) PublishEvent(ctx context.Context, event models.JobEvent) error {
+	b.events = append(b.events, event)
+	return nil
+}
+
+func (b *fakeBroker) statuses() []string {
+	statuses := make([]string, len(b.events))
+	for i, event := range b.events {
+		statuses[i] = event.Status
+	}
+	return statuses
+}
+
+func newTestWorker(broker Broker) *Worker {
+	registry := jobs.Registry{
+		"ok":   func(ctx context.Context, payload json.RawMessage) error { return nil },
+		"fail": func(ctx context.Context, payload json.RawMessage) error { return errors.New("boom") },
+		"permanent": func(ctx context.Context, payload json.RawMessage) error {
+			return jobs.Permanent(errors.New("bad input"))
+		},
+		"slow": func(ctx context.Context, payload json.RawMessage) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	return NewWorker("test", broker, registry, queue.Backoff{Base: time.Second, Max: time.Minute}, 20*time.Millisecond)
+}
+
+func TestWorker_Success(t *testing.T) {
+	broker := &fakeBroker{}
+	err := newTestWorker(broker).Handle(context.Background(), models.Job{ID: "1", Type: "ok", MaxAttempts: 3})
+
+	require.NoError(t, err)
+	assert.Empty(t, broker.retried)
+	assert.Empty(t, broker.dead)
+	assert.Equal(t, []string{models.StatusProcessing, models.StatusSucceeded}, broker.statuses())
+}
+
+func TestWorker_RetriesWithBackoffThenDeadLetters(t *testing.T) {
+	broker := &fakeBroker{}
+	w := newTestWorker(broker)
+
+	job := models.Job{ID: "1", Type: "fail", MaxAttempts: 3}
+	require.NoError(t, w.Handle(context.Background(), job))
+	require.Len(t, broker.retried, 1)
+	assert.Equal(t, 1, broker.retried[0].Attempt)
+	assert.Equal(t, "boom", broker.retried[0].LastError)
+
+	// The retried copy comes back through the queue
+	require.NoError(t, w.Handle(context.Background(), broker.retried[0]))
+	require.NoError(t, w.Handle(context.Background(), broker.retried[1]))
+
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, broker.delays)
+	require.Len(t, broker.dead, 1)
+	assert.Equal(t, 3, broker.dead[0].Attempt)
+	assert.Equal(t, models.StatusDead, broker.events[len(broker.events)-1].Status)
+}
+
+func TestWorker_PermanentFailuresSkipRetries(t *testing.T) {
+	for _, jobType := range []string{"permanent", "unknown"} {
+		broker := &fakeBroker{}
+		require.NoError(t, newTestWorker(broker).Handle(context.Background(), models.Job{ID: "1", Type: jobType, MaxAttempts: 5}))
+
+		assert.Empty(t, broker.retried, jobType)
+		require.Len(t, broker.dead, 1, jobType)
+		assert.Equal(t, 1, broker.dead[0].Attempt)
+	}
+}
+
+func TestWorker_AttemptTimeout(t *testing.T) {
+	broker := &fakeBroker{}
+	require.NoError(t, newTestWorker(broker).Handle(context.Background(), models.Job{ID: "1", Type: "slow", MaxAttempts: 2}))
+
+	require.Len(t, broker.retried, 1)
+	assert.Contains(t, broker.retried[0].LastError, "deadline exceeded")
+}
+
+func TestWorker_RoutingFailureRequeues(t *testing.T) {
+	broker := &fakeBroker{retryErr: errors.New("connection lost")}
+	err := newTestWorker(broker).Handle(context.Background(), models.Job{ID: "1", Type: "fail", MaxAttempts: 3})
+
+	assert.ErrorContains(t, err, "connection lost")
+}
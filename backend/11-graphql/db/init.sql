@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS users (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL
+);
+
+-- Who follows whom; this is what makes the users a graph worth querying
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id INT NOT NULL,
+    followee_id INT NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    INDEX idx_followee (followee_id),
+    FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO users (name, email) VALUES
+    ('Alice', 'alice@example.com'),
+    ('Bob', 'bob@example.com'),
+    ('Carol', 'carol@example.com'),
+    ('Dave', 'dave@example.com'),
+    ('Erin', 'erin@example.com');
+
+INSERT INTO follows (follower_id, followee_id) VALUES
+    (1, 2), (1, 3), (2, 1), (2, 3), (3, 1), (4, 1), (4, 2), (5, 4);
@@ -0,0 +1,62 @@
+// Package events fans user-created events out to subscriptions
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/e6a5/learning/backend/11-graphql/internal/models"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before
+// it starts missing them
+const subscriberBuffer = 16
+
+// Broker delivers published users to every current subscriber
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[chan *models.User]struct{}
+}
+
+// NewBroker creates a broker with no subscribers
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[chan *models.User]struct{})}
+}
+
+// Subscribe returns a channel of users created from now on. It is closed
+// once ctx is done, which for a subscription is when the client stops it.
+func (b *Broker) Subscribe(ctx context.Context) <-chan *models.User {
+	ch := make(chan *models.User, subscriberBuffer)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		close(ch)
+		b.mu.Unlock()
+	}()
+	return ch
+}
+
+// Publish sends user to every subscriber without waiting; a subscriber
+// with a full buffer misses the event rather than stall the mutation
+func (b *Broker) Publish(user *models.User) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- user:
+		default:
+		}
+	}
+}
+
+// Subscribers returns how many subscriptions are open
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
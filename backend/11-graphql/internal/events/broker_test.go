@@ -0,0 +1,39 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/e6a5/learning/backend/11-graphql/internal/models"
+)
+
+func TestBroker_PublishSubscribe(t *testing.T) {
+	b := NewBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	first := b.Subscribe(ctx)
+	second := b.Subscribe(context.Background())
+	assert.Equal(t, 2, b.Subscribers())
+
+	b.Publish(&models.User{ID: 1, Name: "Alice"})
+	assert.Equal(t, "Alice", (<-first).Name)
+	assert.Equal(t, "Alice", (<-second).Name)
+
+	cancel()
+	_, open := <-first
+	assert.False(t, open, "closed once its context is done")
+	assert.Eventually(t, func() bool { return b.Subscribers() == 1 }, time.Second, time.Millisecond)
+}
+
+func TestBroker_SlowSubscriberMissesEvents(t *testing.T) {
+	b := NewBroker()
+	ch := b.Subscribe(context.Background())
+
+	for i := 0; i < subscriberBuffer+5; i++ {
+		b.Publish(&models.User{ID: i})
+	}
+	assert.Len(t, ch, subscriberBuffer, "publishing never blocks")
+}
@@ -0,0 +1,58 @@
+// Package graph is the GraphQL layer: the schema, the resolvers below and
+// the executor gqlgen generates from them.
+package graph
+
+//go:generate go run github.com/99designs/gqlgen generate
+
+import (
+	"errors"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/e6a5/learning/backend/11-graphql/internal/events"
+	"github.com/e6a5/learning/backend/11-graphql/internal/models"
+	"github.com/e6a5/learning/backend/11-graphql/internal/repository"
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// MaxPageSize caps the users query's limit argument
+const MaxPageSize = 100
+
+// Resolver holds what the resolvers need; gqlgen builds the per-type
+// resolvers on top of it. The repository is not called Users: the users
+// query's resolver method would hide it.
+type Resolver struct {
+	UserRepo *repository.UserRepository
+	Events   *events.Broker
+}
+
+// userError turns repository and validation errors into GraphQL errors
+// with a machine-readable code in extensions
+func userError(err error) error {
+	var validationErr *validation.Error
+	switch {
+	case errors.As(err, &validationErr):
+		return &gqlerror.Error{
+			Message:    validationErr.Message,
+			Extensions: map[string]interface{}{"code": "BAD_USER_INPUT", "field": validationErr.Field},
+		}
+	case errors.Is(err, repository.ErrUserNotFound):
+		return &gqlerror.Error{
+			Message:    "User not found",
+			Extensions: map[string]interface{}{"code": "NOT_FOUND"},
+		}
+	}
+	return err
+}
+
+// nonNil turns a user with nobody related into an empty list, since the
+// schema promises a list
+func nonNil(users []*models.User, err error) ([]*models.User, error) {
+	if err != nil {
+		return nil, err
+	}
+	if users == nil {
+		users = []*models.User{}
+	}
+	return users, nil
+}
@@ -0,0 +1,123 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.49
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/e6a5/learning/backend/11-graphql/internal/loader"
+	"github.com/e6a5/learning/backend/11-graphql/internal/models"
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// CreateUser is the resolver for the createUser field.
+func (r *mutationResolver) CreateUser(ctx context.Context, input models.CreateUserInput) (*models.User, error) {
+	if err := input.Validate(); err != nil {
+		return nil, userError(err)
+	}
+	user, err := r.UserRepo.Create(ctx, input)
+	if err != nil {
+		log.Printf("Error creating user: %v", err)
+		return nil, err
+	}
+	r.Events.Publish(user)
+	return user, nil
+}
+
+// UpdateUser is the resolver for the updateUser field.
+func (r *mutationResolver) UpdateUser(ctx context.Context, id int, input models.UpdateUserInput) (*models.User, error) {
+	if err := input.Validate(); err != nil {
+		return nil, userError(err)
+	}
+	user, err := r.UserRepo.Update(ctx, id, input)
+	if err != nil {
+		return nil, userError(err)
+	}
+	return user, nil
+}
+
+// DeleteUser is the resolver for the deleteUser field.
+func (r *mutationResolver) DeleteUser(ctx context.Context, id int) (bool, error) {
+	if err := r.UserRepo.Delete(ctx, id); err != nil {
+		return false, userError(err)
+	}
+	return true, nil
+}
+
+// Follow is the resolver for the follow field.
+func (r *mutationResolver) Follow(ctx context.Context, followerID int, followeeID int) (bool, error) {
+	if followerID == followeeID {
+		return false, userError(&validation.Error{Field: "followeeId", Message: "Users cannot follow themselves"})
+	}
+	for _, id := range []int{followerID, followeeID} {
+		if _, err := r.UserRepo.Get(ctx, id); err != nil {
+			return false, userError(err)
+		}
+	}
+	if err := r.UserRepo.Follow(ctx, followerID, followeeID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Unfollow is the resolver for the unfollow field.
+func (r *mutationResolver) Unfollow(ctx context.Context, followerID int, followeeID int) (bool, error) {
+	if err := r.UserRepo.Unfollow(ctx, followerID, followeeID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, limit int, offset int) ([]*models.User, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, userError(&validation.Error{Field: "limit", Message: fmt.Sprintf("Limit must be between 1 and %d", MaxPageSize)})
+	}
+	if offset < 0 {
+		return nil, userError(&validation.Error{Field: "offset", Message: "Offset cannot be negative"})
+	}
+	return r.UserRepo.List(ctx, limit, offset)
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id int) (*models.User, error) {
+	// Through the loader, so a query asking for the same user twice runs
+	// one statement
+	return loader.For(ctx).UserByID.Load(ctx, id)
+}
+
+// UserCreated is the resolver for the userCreated field.
+func (r *subscriptionResolver) UserCreated(ctx context.Context) (<-chan *models.User, error) {
+	return r.Events.Subscribe(ctx), nil
+}
+
+// Followers is the resolver for the followers field.
+func (r *userResolver) Followers(ctx context.Context, obj *models.User) ([]*models.User, error) {
+	return nonNil(loader.For(ctx).FollowersOf.Load(ctx, obj.ID))
+}
+
+// Following is the resolver for the following field.
+func (r *userResolver) Following(ctx context.Context, obj *models.User) ([]*models.User, error) {
+	return nonNil(loader.For(ctx).FollowingOf.Load(ctx, obj.ID))
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/e6a5/learning/backend/11-graphql/internal/events"
+	"github.com/e6a5/learning/backend/11-graphql/internal/repository"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// StatsHandler serves the plain HTTP endpoints next to /query
+type StatsHandler struct {
+	repo     *repository.UserRepository
+	broker   *events.Broker
+	batching bool
+}
+
+// NewStatsHandler creates a new stats handler
+func NewStatsHandler(repo *repository.UserRepository, broker *events.Broker, batching bool) *StatsHandler {
+	return &StatsHandler{repo: repo, broker: broker, batching: batching}
+}
+
+// GetStats handles GET /stats - SQL statements run so far; compare the
+// count before and after a query to see what batching saves
+func (h *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"sql_queries":   h.repo.Queries(),
+			"subscriptions": h.broker.Subscribers(),
+			"batching":      h.batching,
+		},
+	})
+}
+
+// HealthCheck handles GET /health
+func (h *StatsHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	if err := h.repo.Ping(r.Context()); err != nil {
+		response.Error(w, http.StatusServiceUnavailable, "Database unreachable")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "GraphQL server is healthy"})
+}
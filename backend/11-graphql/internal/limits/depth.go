@@ -0,0 +1,82 @@
+// Package limits protects the server from queries that are cheap to write
+// and expensive to run. followers { followers { followers ... } } grows
+// exponentially with depth, so depth is capped before anything executes.
+package limits
+
+import (
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// DepthLimit rejects operations nested deeper than Max fields. Introspection
+// fields are not counted, so tools like the playground keep working.
+type DepthLimit struct {
+	Max int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = DepthLimit{}
+
+// ExtensionName names the extension in gqlgen's extension list
+func (d DepthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+// Validate is called once when the extension is registered
+func (d DepthLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationContext measures the operation after it is parsed and
+// validated, and stops it if it is too deep
+func (d DepthLimit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	depth := Depth(rc.Doc, rc.Operation.SelectionSet)
+	if depth > d.Max {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.Max)
+		err.Extensions = map[string]interface{}{"code": "DEPTH_LIMIT_EXCEEDED"}
+		return err
+	}
+	return nil
+}
+
+// Depth returns how many fields deep a selection set goes. Fragments count
+// as the fields they contain; fragments are looked up in doc.
+func Depth(doc *ast.QueryDocument, set ast.SelectionSet) int {
+	return depth(doc, set, map[string]bool{})
+}
+
+func depth(doc *ast.QueryDocument, set ast.SelectionSet, visiting map[string]bool) int {
+	deepest := 0
+	for _, selection := range set {
+		d := 0
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			d = 1 + depth(doc, s.SelectionSet, visiting)
+		case *ast.InlineFragment:
+			d = depth(doc, s.SelectionSet, visiting)
+		case *ast.FragmentSpread:
+			// Validation rejects fragment cycles, but a lookup that loops
+			// forever is not worth the risk
+			fragment := doc.Fragments.ForName(s.Name)
+			if fragment == nil || visiting[s.Name] {
+				continue
+			}
+			visiting[s.Name] = true
+			d = depth(doc, fragment.SelectionSet, visiting)
+			delete(visiting, s.Name)
+		}
+		if d > deepest {
+			deepest = d
+		}
+	}
+	return deepest
+}
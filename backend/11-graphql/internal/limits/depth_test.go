@@ -0,0 +1,32 @@
+package limits
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+func TestDepth(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"flat", `{ users { id name } }`, 2},
+		{"nested", `{ users { followers { followers { name } } } }`, 4},
+		{"deepest branch wins", `{ user(id: 1) { name following { id } followers { followers { id } } } }`, 4},
+		{"inline fragment", `{ users { ... on User { followers { id } } } }`, 3},
+		{"named fragment", `{ users { ...F } } fragment F on User { followers { following { id } } }`, 4},
+		{"introspection is free", `{ __schema { types { fields { type { name } } } } users { id } }`, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.ParseQuery(&ast.Source{Input: tt.query})
+			require.Nil(t, err)
+			assert.Equal(t, tt.want, Depth(doc, doc.Operations[0].SelectionSet))
+		})
+	}
+}
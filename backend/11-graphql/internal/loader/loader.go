@@ -0,0 +1,121 @@
+// Package loader batches and caches lookups made while resolving one
+// GraphQL request. Resolvers for a list of users each ask for their own
+// followers; instead of one query per user, the keys asked for within a
+// short window are fetched together in a single query.
+package loader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchFunc fetches many keys at once. Keys missing from the result get the
+// zero value.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// result is one key's value, ready once done is closed
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// batch collects keys until it is dispatched
+type batch[K comparable, V any] struct {
+	keys    []K
+	results []*result[V]
+}
+
+// Loader batches Load calls made within Wait of each other, up to MaxBatch
+// keys, and caches every result for its lifetime. It is meant to live for
+// a single request.
+type Loader[K comparable, V any] struct {
+	fetch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	cache   map[K]*result[V]
+	pending *batch[K, V]
+}
+
+// New creates a loader around fetch
+func New[K comparable, V any](fetch BatchFunc[K, V], wait time.Duration, maxBatch int) *Loader[K, V] {
+	return &Loader[K, V]{fetch: fetch, wait: wait, maxBatch: maxBatch, cache: make(map[K]*result[V])}
+}
+
+// Load returns the value for key, waiting for the batch it joins
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	r, ok := l.cache[key]
+	if !ok {
+		r = &result[V]{done: make(chan struct{})}
+		l.cache[key] = r
+		l.enqueue(ctx, key, r)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// enqueue adds key to the pending batch, starting one if needed. A full
+// batch goes out at once; otherwise a timer sends it after wait. Called with
+// mu held.
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K, r *result[V]) {
+	if l.pending == nil {
+		b := &batch[K, V]{}
+		l.pending = b
+		time.AfterFunc(l.wait, func() {
+			l.mu.Lock()
+			if l.pending != b {
+				// Already sent for being full
+				l.mu.Unlock()
+				return
+			}
+			l.pending = nil
+			l.mu.Unlock()
+			l.dispatch(ctx, b)
+		})
+	}
+
+	b := l.pending
+	b.keys = append(b.keys, key)
+	b.results = append(b.results, r)
+	if l.maxBatch > 0 && len(b.keys) >= l.maxBatch {
+		l.pending = nil
+		go l.dispatch(ctx, b)
+	}
+}
+
+// dispatch fetches a batch and hands every waiting Load its value. The
+// request's context may be cancelled by then, so the fetch runs without
+// its cancellation.
+func (l *Loader[K, V]) dispatch(ctx context.Context, b *batch[K, V]) {
+	values, err := l.fetch(context.WithoutCancel(ctx), b.keys)
+	for i, key := range b.keys {
+		r := b.results[i]
+		if err != nil {
+			r.err = err
+		} else {
+			r.value = values[key]
+		}
+		close(r.done)
+	}
+	if err != nil {
+		// Do not cache failures; the next request tries again
+		l.mu.Lock()
+		for i, key := range b.keys {
+			if l.cache[key] == b.results[i] {
+				delete(l.cache, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,106 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a batch function that remembers the batches it was given
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+	err     error
+}
+
+func (r *recorder) fetch(ctx context.Context, keys []int) (map[int]string, error) {
+	r.mu.Lock()
+	r.batches = append(r.batches, append([]int(nil), keys...))
+	r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	values := make(map[int]string, len(keys))
+	for _, k := range keys {
+		if k >= 0 {
+			values[k] = string(rune('a' + k))
+		}
+	}
+	return values, nil
+}
+
+// loadAll loads keys concurrently, like sibling resolvers do
+func loadAll(t *testing.T, l *Loader[int, string], keys ...int) []string {
+	values := make([]string, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i, key int) {
+			defer wg.Done()
+			v, err := l.Load(context.Background(), key)
+			assert.NoError(t, err)
+			values[i] = v
+		}(i, key)
+	}
+	wg.Wait()
+	return values
+}
+
+func TestLoader_BatchesAndCaches(t *testing.T) {
+	r := &recorder{}
+	l := New(r.fetch, 10*time.Millisecond, 100)
+
+	assert.Equal(t, []string{"a", "b", "c", "a", ""}, loadAll(t, l, 0, 1, 2, 0, -1))
+	require.Len(t, r.batches, 1, "one query for all keys")
+	assert.ElementsMatch(t, []int{0, 1, 2, -1}, r.batches[0], "duplicates are fetched once")
+
+	// Cached keys do not go back to the database
+	assert.Equal(t, []string{"b", "d"}, loadAll(t, l, 1, 3))
+	require.Len(t, r.batches, 2)
+	assert.Equal(t, []int{3}, r.batches[1])
+}
+
+func TestLoader_MaxBatch(t *testing.T) {
+	r := &recorder{}
+	l := New(r.fetch, 10*time.Millisecond, 2)
+
+	loadAll(t, l, 0, 1, 2, 3, 4)
+	total := 0
+	for _, b := range r.batches {
+		assert.LessOrEqual(t, len(b), 2)
+		total += len(b)
+	}
+	assert.Equal(t, 5, total)
+}
+
+func TestLoader_ErrorsAreNotCached(t *testing.T) {
+	r := &recorder{err: errors.New("db down")}
+	l := New(r.fetch, time.Millisecond, 100)
+
+	_, err := l.Load(context.Background(), 1)
+	assert.EqualError(t, err, "db down")
+
+	r.err = nil
+	v, err := l.Load(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "b", v)
+}
+
+func TestUnbatched(t *testing.T) {
+	var calls atomic.Int32
+	fetch := unbatched(func(ctx context.Context, keys []int) (map[int]int, error) {
+		calls.Add(1)
+		return map[int]int{keys[0]: keys[0] * 10}, nil
+	})
+
+	values, err := fetch(context.Background(), []int{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int{1: 10, 2: 20, 3: 30}, values)
+	assert.Equal(t, int32(3), calls.Load(), "one query per key")
+}
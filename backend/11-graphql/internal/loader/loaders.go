@@ -0,0 +1,72 @@
+package loader
+
+import (
+	"context"
+	"time"
+
+	"github.com/e6a5/learning/backend/11-graphql/internal/models"
+	"github.com/e6a5/learning/backend/11-graphql/internal/repository"
+)
+
+// Batching window and size: long enough for sibling resolvers to join, short
+// enough not to be noticed
+const (
+	wait     = 2 * time.Millisecond
+	maxBatch = 100
+)
+
+// Loaders are the per-request loaders resolvers use
+type Loaders struct {
+	UserByID    *Loader[int, *models.User]
+	FollowersOf *Loader[int, []*models.User]
+	FollowingOf *Loader[int, []*models.User]
+}
+
+// NewLoaders creates a fresh set of loaders over repo
+func NewLoaders(repo *repository.UserRepository) *Loaders {
+	return &Loaders{
+		UserByID:    New(repo.GetByIDs, wait, maxBatch),
+		FollowersOf: New(repo.FollowersOf, wait, maxBatch),
+		FollowingOf: New(repo.FollowingOf, wait, maxBatch),
+	}
+}
+
+// unbatched turns a batch function into one that fetches each key on its
+// own, which is what resolvers would do without a loader
+func unbatched[V any](fetch BatchFunc[int, V]) BatchFunc[int, V] {
+	return func(ctx context.Context, keys []int) (map[int]V, error) {
+		values := make(map[int]V, len(keys))
+		for _, key := range keys {
+			one, err := fetch(ctx, []int{key})
+			if err != nil {
+				return nil, err
+			}
+			values[key] = one[key]
+		}
+		return values, nil
+	}
+}
+
+// NewUnbatchedLoaders creates loaders that run one query per key, to show
+// the N+1 problem batching solves
+func NewUnbatchedLoaders(repo *repository.UserRepository) *Loaders {
+	return &Loaders{
+		UserByID:    New(unbatched(repo.GetByIDs), 0, 1),
+		FollowersOf: New(unbatched(repo.FollowersOf), 0, 1),
+		FollowingOf: New(unbatched(repo.FollowingOf), 0, 1),
+	}
+}
+
+type contextKey struct{}
+
+// WithLoaders attaches loaders to ctx. They should live for one response:
+// a subscription resolves every event with fresh ones, or it would keep
+// serving the followers it saw first.
+func WithLoaders(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, contextKey{}, loaders)
+}
+
+// For returns the loaders attached to ctx
+func For(ctx context.Context) *Loaders {
+	return ctx.Value(contextKey{}).(*Loaders)
+}
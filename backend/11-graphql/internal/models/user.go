@@ -0,0 +1,50 @@
+package models
+
+import (
+	"net/mail"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// User represents a user in the database. The GraphQL User type binds to
+// it directly; followers and following are resolved separately.
+type User struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// CreateUserInput is the input of the createUser mutation
+type CreateUserInput struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// UpdateUserInput is the input of the updateUser mutation
+type UpdateUserInput struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Validate validates the create user input
+func (i CreateUserInput) Validate() error {
+	return validateUser(i.Name, i.Email)
+}
+
+// Validate validates the update user input
+func (i UpdateUserInput) Validate() error {
+	return validateUser(i.Name, i.Email)
+}
+
+func validateUser(name, email string) error {
+	if name == "" {
+		return &validation.Error{Field: "name", Message: "Name is required"}
+	}
+	if email == "" {
+		return &validation.Error{Field: "email", Message: "Email is required"}
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return &validation.Error{Field: "email", Message: "Email is invalid"}
+	}
+	return nil
+}
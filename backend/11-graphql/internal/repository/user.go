@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/e6a5/learning/backend/11-graphql/internal/models"
+)
+
+// ErrUserNotFound is returned when no user has the given ID
+var ErrUserNotFound = errors.New("user not found")
+
+// UserRepository handles user database operations. It counts the queries
+// it runs, so the effect of batching shows up in GET /stats.
+type UserRepository struct {
+	db      *sql.DB
+	queries atomic.Int64
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Queries returns how many SQL statements have run since startup
+func (r *UserRepository) Queries() int64 {
+	return r.queries.Load()
+}
+
+// Ping checks the database connection; it is not counted as a query
+func (r *UserRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// List returns a page of users ordered by ID
+func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]*models.User, error) {
+	r.queries.Add(1)
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, email FROM users ORDER BY id LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return users, nil
+}
+
+// Get returns one user
+func (r *UserRepository) Get(ctx context.Context, id int) (*models.User, error) {
+	r.queries.Add(1)
+	var u models.User
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, email FROM users WHERE id = ?", id).Scan(&u.ID, &u.Name, &u.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &u, nil
+}
+
+// Create creates a new user and returns it with its ID
+func (r *UserRepository) Create(ctx context.Context, input models.CreateUserInput) (*models.User, error) {
+	r.queries.Add(1)
+	result, err := r.db.ExecContext(ctx, "INSERT INTO users (name, email) VALUES (?, ?)", input.Name, input.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user id: %w", err)
+	}
+	return &models.User{ID: int(id), Name: input.Name, Email: input.Email}, nil
+}
+
+// Update updates an existing user
+func (r *UserRepository) Update(ctx context.Context, id int, input models.UpdateUserInput) (*models.User, error) {
+	r.queries.Add(1)
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET name = ?, email = ? WHERE id = ?", input.Name, input.Email, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	// MySQL reports 0 rows affected when nothing changed, so check existence
+	// rather than trusting the count
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		if _, err := r.Get(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return &models.User{ID: id, Name: input.Name, Email: input.Email}, nil
+}
+
+// Delete deletes a user; their follows go with them
+func (r *UserRepository) Delete(ctx context.Context, id int) error {
+	r.queries.Add(1)
+	result, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// Follow makes follower follow followee; following twice is not an error
+func (r *UserRepository) Follow(ctx context.Context, follower, followee int) error {
+	r.queries.Add(1)
+	_, err := r.db.ExecContext(ctx, "INSERT IGNORE INTO follows (follower_id, followee_id) VALUES (?, ?)", follower, followee)
+	if err != nil {
+		return fmt.Errorf("failed to follow: %w", err)
+	}
+	return nil
+}
+
+// Unfollow undoes Follow
+func (r *UserRepository) Unfollow(ctx context.Context, follower, followee int) error {
+	r.queries.Add(1)
+	_, err := r.db.ExecContext(ctx, "DELETE FROM follows WHERE follower_id = ? AND followee_id = ?", follower, followee)
+	if err != nil {
+		return fmt.Errorf("failed to unfollow: %w", err)
+	}
+	return nil
+}
+
+// GetByIDs loads many users in one query, keyed by ID. IDs that do not
+// exist are missing from the map.
+func (r *UserRepository) GetByIDs(ctx context.Context, ids []int) (map[int]*models.User, error) {
+	r.queries.Add(1)
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, email FROM users WHERE id IN ("+placeholders(len(ids))+")", args(ids)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make(map[int]*models.User, len(ids))
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users[u.ID] = &u
+	}
+	return users, rows.Err()
+}
+
+// FollowersOf loads the followers of many users in one query
+func (r *UserRepository) FollowersOf(ctx context.Context, ids []int) (map[int][]*models.User, error) {
+	return r.related(ctx, "f.followee_id", "f.follower_id", ids)
+}
+
+// FollowingOf loads whom many users follow in one query
+func (r *UserRepository) FollowingOf(ctx context.Context, ids []int) (map[int][]*models.User, error) {
+	return r.related(ctx, "f.follower_id", "f.followee_id", ids)
+}
+
+// related joins follows to users: for every ID in keyColumn, the users in
+// otherColumn
+func (r *UserRepository) related(ctx context.Context, keyColumn, otherColumn string, ids []int) (map[int][]*models.User, error) {
+	r.queries.Add(1)
+	query := fmt.Sprintf(`SELECT %s, u.id, u.name, u.email
+		FROM follows f JOIN users u ON u.id = %s
+		WHERE %s IN (%s) ORDER BY u.id`, keyColumn, otherColumn, keyColumn, placeholders(len(ids)))
+	rows, err := r.db.QueryContext(ctx, query, args(ids)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query follows: %w", err)
+	}
+	defer rows.Close()
+
+	related := make(map[int][]*models.User, len(ids))
+	for rows.Next() {
+		var key int
+		var u models.User
+		if err := rows.Scan(&key, &u.ID, &u.Name, &u.Email); err != nil {
+			return nil, fmt.Errorf("failed to scan follow: %w", err)
+		}
+		related[key] = append(related[key], &u)
+	}
+	return related, rows.Err()
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+func args(ids []int) []interface{} {
+	a := make([]interface{}, len(ids))
+	for i, id := range ids {
+		a[i] = id
+	}
+	return a
+}
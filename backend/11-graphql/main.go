@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/99designs/gqlgen/graphql/playground"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/e6a5/learning/backend/11-graphql/internal/events"
+	"github.com/e6a5/learning/backend/11-graphql/internal/graph"
+	"github.com/e6a5/learning/backend/11-graphql/internal/handlers"
+	"github.com/e6a5/learning/backend/11-graphql/internal/limits"
+	"github.com/e6a5/learning/backend/11-graphql/internal/loader"
+	"github.com/e6a5/learning/backend/11-graphql/internal/repository"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+// followerFanout is the number of followers complexity assumes a user has
+const followerFanout = 10
+
+func main() {
+	// Initialize database connection
+	db, err := initializeDatabase()
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer db.Close()
+
+	batching := env.Get("DATALOADER", "true") == "true"
+	maxDepth := getEnvInt("MAX_DEPTH", 5)
+	maxComplexity := getEnvInt("MAX_COMPLEXITY", 1000)
+
+	// Initialize dependencies
+	userRepo := repository.NewUserRepository(db)
+	broker := events.NewBroker()
+	server := newGraphQLServer(userRepo, broker, batching, maxDepth, maxComplexity)
+	statsHandler := handlers.NewStatsHandler(userRepo, broker, batching)
+
+	// Setup HTTP server
+	router := setupRoutes(server, statsHandler)
+	port := env.Get("PORT", "8080")
+
+	log.Printf("🕸️  GraphQL server running at http://localhost:%s (playground at /)", port)
+	log.Printf("Dataloader batching: %v, max depth: %d, max complexity: %d", batching, maxDepth, maxComplexity)
+	log.Fatal(http.ListenAndServe(":"+port, router))
+}
+
+func initializeDatabase() (*sql.DB, error) {
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		dsn = "user:pass@tcp(localhost:3306)/graphlab"
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// newGraphQLServer builds the executor with its transports and limits
+func newGraphQLServer(repo *repository.UserRepository, broker *events.Broker, batching bool, maxDepth, maxComplexity int) *handler.Server {
+	cfg := graph.Config{Resolvers: &graph.Resolver{UserRepo: repo, Events: broker}}
+
+	// Complexity estimates the cost of a query before running it: a list
+	// costs its children times how many items it may hold. The limit is
+	// clamped to what the resolver accepts, or a negative one on an alias
+	// would cancel out the cost of its siblings.
+	cfg.Complexity.Query.Users = func(childComplexity int, limit int, offset int) int {
+		return min(max(limit, 1), graph.MaxPageSize) * childComplexity
+	}
+	cfg.Complexity.User.Followers = func(childComplexity int) int {
+		return followerFanout * childComplexity
+	}
+	cfg.Complexity.User.Following = func(childComplexity int) int {
+		return followerFanout * childComplexity
+	}
+
+	srv := handler.New(graph.NewExecutableSchema(cfg))
+
+	// Subscriptions run over WebSockets; queries and mutations over HTTP
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+
+	srv.Use(extension.Introspection{})
+	srv.Use(limits.DepthLimit{Max: maxDepth})
+	srv.Use(extension.FixedComplexityLimit(maxComplexity))
+
+	// Fresh loaders for every response, including each subscription event
+	srv.AroundResponses(func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+		loaders := loader.NewUnbatchedLoaders(repo)
+		if batching {
+			loaders = loader.NewLoaders(repo)
+		}
+		return next(loader.WithLoaders(ctx, loaders))
+	})
+
+	return srv
+}
+
+func setupRoutes(server *handler.Server, statsHandler *handlers.StatsHandler) *mux.Router {
+	router := mux.NewRouter()
+
+	// GraphQL endpoint and playground
+	router.Handle("/query", server)
+	router.Handle("/", playground.Handler("Users GraphQL", "/query")).Methods("GET")
+
+	// Query counter and health check
+	router.HandleFunc("/stats", statsHandler.GetStats).Methods("GET")
+	router.HandleFunc("/health", statsHandler.HealthCheck).Methods("GET")
+
+	return router
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(env.Get(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		log.Fatalf("%s must be a number: %v", key, err)
+	}
+	return value
+}
@@ -0,0 +1,8 @@
+//go:build tools
+
+// Pins the gqlgen version `go generate` runs, so go mod tidy keeps it
+package main
+
+import (
+	_ "github.com/99designs/gqlgen"
+)
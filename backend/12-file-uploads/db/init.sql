@@ -0,0 +1,11 @@
+-- File metadata; the contents live in object storage under object_key
+CREATE TABLE IF NOT EXISTS files (
+    id CHAR(16) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    object_key VARCHAR(255) NOT NULL,
+    created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    INDEX idx_created_at (created_at)
+);
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/12-file-uploads/internal/models"
+	"github.com/e6a5/learning/backend/12-file-uploads/internal/repository"
+	"github.com/e6a5/learning/backend/12-file-uploads/internal/storage"
+	"github.com/e6a5/learning/backend/12-file-uploads/internal/upload"
+	"github.com/e6a5/learning/backend/pkg/response"
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// formOverhead allows for multipart boundaries, headers and small fields
+// on top of the file itself
+const formOverhead = 64 << 10
+
+// FileHandler handles uploads, downloads and file metadata
+type FileHandler struct {
+	files     *repository.FileRepository
+	store     *storage.MinIO
+	policy    upload.Policy
+	urlExpiry time.Duration
+}
+
+// NewFileHandler creates a new file handler
+func NewFileHandler(files *repository.FileRepository, store *storage.MinIO, policy upload.Policy, urlExpiry time.Duration) *FileHandler {
+	return &FileHandler{files: files, store: store, policy: policy, urlExpiry: urlExpiry}
+}
+
+// UploadFile handles POST /files - streams the multipart "file" field to
+// object storage and records its metadata
+func (h *FileHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
+	// ParseMultipartForm would spool the file to memory or disk first; the
+	// multipart reader hands it over as it arrives. The body is capped too,
+	// so no part can make the request unbounded.
+	r.Body = http.MaxBytesReader(w, r.Body, h.policy.MaxSize+formOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Expected a multipart/form-data body")
+		return
+	}
+
+	part, err := filePart(reader)
+	if err != nil {
+		h.respondUploadError(w, err)
+		return
+	}
+	defer part.Close()
+
+	name, err := models.CleanFileName(part.FileName())
+	if err != nil {
+		h.respondUploadError(w, err)
+		return
+	}
+	up, err := h.policy.Open(part)
+	if err != nil {
+		h.respondUploadError(w, err)
+		return
+	}
+
+	id := newFileID()
+	file := models.File{
+		ID:          id,
+		Name:        name,
+		ContentType: up.ContentType,
+		ObjectKey:   "files/" + id,
+		CreatedAt:   time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if _, err := h.store.Put(r.Context(), file.ObjectKey, up, file.ContentType); err != nil {
+		// The storage client wraps read errors its own way; the upload
+		// knows whether it was the file that failed
+		if up.Err() != nil {
+			h.respondUploadError(w, up.Err())
+			return
+		}
+		log.Printf("Error storing file %s: %v", id, err)
+		response.Error(w, http.StatusBadGateway, "Storage unavailable")
+		return
+	}
+	file.Size = up.Size()
+	file.SHA256 = up.Checksum()
+
+	if err := h.files.Create(r.Context(), file); err != nil {
+		log.Printf("Error saving file %s: %v", id, err)
+		// Without metadata nobody can find the object, so do not keep it
+		if err := h.store.Delete(context.WithoutCancel(r.Context()), file.ObjectKey); err != nil {
+			log.Printf("Error removing orphaned object %s: %v", file.ObjectKey, err)
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to save file")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, response.APIResponse{
+		Message: "File uploaded",
+		Data:    file,
+	})
+}
+
+// filePart skips to the form field named "file"
+func filePart(reader *multipart.Reader) (*multipart.Part, error) {
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, &validation.Error{Field: "file", Message: "A file field is required"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// respondUploadError maps what went wrong reading an upload to a status
+func (h *FileHandler) respondUploadError(w http.ResponseWriter, err error) {
+	var validationErr *validation.Error
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &validationErr):
+		status := http.StatusBadRequest
+		if validationErr.Field == "content_type" {
+			status = http.StatusUnsupportedMediaType
+		}
+		response.Error(w, status, validationErr.Message)
+	case errors.Is(err, upload.ErrTooLarge), errors.As(err, &maxBytesErr):
+		response.JSON(w, http.StatusRequestEntityTooLarge, response.APIResponse{
+			Error: fmt.Sprintf("File must be at most %d bytes", h.policy.MaxSize),
+		})
+	default:
+		// A client that disconnects or sends a broken form ends up here
+		log.Printf("Error reading upload: %v", err)
+		response.Error(w, http.StatusBadRequest, "Could not read upload")
+	}
+}
+
+// GetFiles handles GET /files - recent files, ?limit=n (default 50, max 200)
+func (h *FileHandler) GetFiles(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 200 {
+			response.Error(w, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+		limit = n
+	}
+
+	files, err := h.files.List(r.Context(), limit)
+	if err != nil {
+		log.Printf("Error listing files: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to list files")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"files": files,
+			"count": len(files),
+		},
+	})
+}
+
+// GetFile handles GET /files/{id} - one file's metadata
+func (h *FileHandler) GetFile(w http.ResponseWriter, r *http.Request) {
+	file, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: file})
+}
+
+// DownloadFile handles GET /files/{id}/download - redirects to a presigned
+// URL, or returns it as JSON with ?redirect=false
+func (h *FileHandler) DownloadFile(w http.ResponseWriter, r *http.Request) {
+	file, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	u, err := h.store.PresignGet(r.Context(), file.ObjectKey, file.Name, h.urlExpiry)
+	if err != nil {
+		log.Printf("Error presigning %s: %v", file.ID, err)
+		response.Error(w, http.StatusBadGateway, "Storage unavailable")
+		return
+	}
+
+	if r.URL.Query().Get("redirect") == "false" {
+		response.JSON(w, http.StatusOK, response.APIResponse{
+			Data: models.DownloadURL{URL: u.String(), ExpiresAt: time.Now().Add(h.urlExpiry).UTC()},
+		})
+		return
+	}
+	// The bytes go straight from storage to the client, not through us
+	http.Redirect(w, r, u.String(), http.StatusTemporaryRedirect)
+}
+
+// DeleteFile handles DELETE /files/{id}
+func (h *FileHandler) DeleteFile(w http.ResponseWriter, r *http.Request) {
+	file, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	// Metadata goes first: if the object delete then fails, the leftover
+	// object only costs space, whereas metadata without an object is a
+	// broken download
+	if err := h.files.Delete(r.Context(), file.ID); err != nil {
+		if errors.Is(err, repository.ErrFileNotFound) {
+			response.Error(w, http.StatusNotFound, "File not found")
+			return
+		}
+		log.Printf("Error deleting file %s: %v", file.ID, err)
+		response.Error(w, http.StatusInternalServerError, "Failed to delete file")
+		return
+	}
+	if err := h.store.Delete(r.Context(), file.ObjectKey); err != nil {
+		log.Printf("Error deleting object %s: %v", file.ObjectKey, err)
+	}
+
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "File deleted"})
+}
+
+// HealthCheck handles GET /health
+func (h *FileHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	if err := h.files.Ping(r.Context()); err != nil {
+		response.Error(w, http.StatusServiceUnavailable, "Database unreachable")
+		return
+	}
+	if err := h.store.Ping(r.Context()); err != nil {
+		response.Error(w, http.StatusServiceUnavailable, "Object storage unreachable")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "File service is healthy"})
+}
+
+// lookup loads the file named in the URL, responding if it cannot
+func (h *FileHandler) lookup(w http.ResponseWriter, r *http.Request) (*models.File, bool) {
+	file, err := h.files.Get(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, repository.ErrFileNotFound) {
+		response.Error(w, http.StatusNotFound, "File not found")
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error getting file: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to get file")
+		return nil, false
+	}
+	return file, true
+}
+
+// newFileID returns a random 16-character hex ID
+func newFileID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
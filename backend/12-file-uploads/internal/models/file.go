@@ -0,0 +1,54 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// MaxFileNameLength matches the name column
+const MaxFileNameLength = 255
+
+// File is the metadata of an uploaded file. The contents are in object
+// storage under ObjectKey, which clients never see.
+type File struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	ObjectKey   string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DownloadURL is a presigned link to a file's contents
+type DownloadURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CleanFileName turns the name a client sent into one safe to store and
+// echo back: no directories, no control characters, not too long
+func CleanFileName(name string) (string, error) {
+	// Browsers on Windows may send the full path
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "", &validation.Error{Field: "file", Message: "File name is required"}
+	}
+	if len(name) > MaxFileNameLength {
+		return "", &validation.Error{Field: "file", Message: fmt.Sprintf("File name must be at most %d bytes", MaxFileNameLength)}
+	}
+	return name, nil
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+func TestCleanFileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", input: "cat.png", want: "cat.png"},
+		{name: "unix path", input: "../../etc/passwd", want: "passwd"},
+		{name: "windows path", input: `C:\Users\alice\cat.png`, want: "cat.png"},
+		{name: "control characters", input: "cat\r\n.png", want: "cat.png"},
+		{name: "surrounding spaces", input: "  cat.png ", want: "cat.png"},
+		{name: "empty", input: "", wantErr: true},
+		{name: "root", input: "/", wantErr: true},
+		{name: "parent", input: "..", wantErr: true},
+		{name: "too long", input: strings.Repeat("a", MaxFileNameLength+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CleanFileName(tt.input)
+			if tt.wantErr {
+				var validationErr *validation.Error
+				assert.ErrorAs(t, err, &validationErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/e6a5/learning/backend/12-file-uploads/internal/models"
+)
+
+// ErrFileNotFound is returned when no file has the given ID
+var ErrFileNotFound = errors.New("file not found")
+
+// FileRepository handles file metadata in MySQL
+type FileRepository struct {
+	db *sql.DB
+}
+
+// NewFileRepository creates a new file repository
+func NewFileRepository(db *sql.DB) *FileRepository {
+	return &FileRepository{db: db}
+}
+
+// Create records an uploaded file
+func (r *FileRepository) Create(ctx context.Context, f models.File) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO files (id, name, content_type, size, sha256, object_key, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		f.ID, f.Name, f.ContentType, f.Size, f.SHA256, f.ObjectKey, f.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	return nil
+}
+
+// Get returns one file
+func (r *FileRepository) Get(ctx context.Context, id string) (*models.File, error) {
+	var f models.File
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, name, content_type, size, sha256, object_key, created_at FROM files WHERE id = ?", id).
+		Scan(&f.ID, &f.Name, &f.ContentType, &f.Size, &f.SHA256, &f.ObjectKey, &f.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+	return &f, nil
+}
+
+// List returns the most recent files, newest first
+func (r *FileRepository) List(ctx context.Context, limit int) ([]models.File, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, name, content_type, size, sha256, object_key, created_at FROM files ORDER BY created_at DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query files: %w", err)
+	}
+	defer rows.Close()
+
+	files := []models.File{}
+	for rows.Next() {
+		var f models.File
+		if err := rows.Scan(&f.ID, &f.Name, &f.ContentType, &f.Size, &f.SHA256, &f.ObjectKey, &f.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan file: %w", err)
+		}
+		files = append(files, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return files, nil
+}
+
+// Delete removes a file's metadata
+func (r *FileRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM files WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrFileNotFound
+	}
+	return nil
+}
+
+// Ping checks the database connection
+func (r *FileRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
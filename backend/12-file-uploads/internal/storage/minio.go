@@ -0,0 +1,126 @@
+// Package storage keeps file contents in an S3-compatible object store.
+// MinIO runs locally; pointing the endpoint at AWS S3 works the same way.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// partSize is the smallest part S3 accepts. Uploads of unknown length are
+// buffered one part at a time, so this bounds memory per upload.
+const partSize = 5 << 20
+
+// Config says where the bucket is
+type Config struct {
+	Endpoint string
+	// PublicEndpoint is the host clients use for download links, which may
+	// differ from the one the server reaches: minio:9000 inside Compose,
+	// localhost:9000 outside it
+	PublicEndpoint string
+	AccessKey      string
+	SecretKey      string
+	Bucket         string
+	Region         string
+	UseSSL         bool
+}
+
+// MinIO stores objects in one bucket
+type MinIO struct {
+	client *minio.Client
+	signer *minio.Client
+	bucket string
+	region string
+}
+
+// NewMinIO creates clients for the bucket; it does not connect yet
+func NewMinIO(cfg Config) (*MinIO, error) {
+	client, err := newClient(cfg.Endpoint, cfg)
+	if err != nil {
+		return nil, err
+	}
+	signer := client
+	if cfg.PublicEndpoint != "" && cfg.PublicEndpoint != cfg.Endpoint {
+		// Presigning is local arithmetic, so this client never has to reach
+		// the public endpoint; the signature covers its host
+		if signer, err = newClient(cfg.PublicEndpoint, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return &MinIO{client: client, signer: signer, bucket: cfg.Bucket, region: cfg.Region}, nil
+}
+
+func newClient(endpoint string, cfg Config) (*minio.Client, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		// A known region saves a lookup request before presigning
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create client for %s: %w", endpoint, err)
+	}
+	return client, nil
+}
+
+// EnsureBucket creates the bucket if it does not exist yet
+func (m *MinIO) EnsureBucket(ctx context.Context) error {
+	exists, err := m.client.BucketExists(ctx, m.bucket)
+	if err != nil {
+		return fmt.Errorf("check bucket %s: %w", m.bucket, err)
+	}
+	if exists {
+		return nil
+	}
+	if err := m.client.MakeBucket(ctx, m.bucket, minio.MakeBucketOptions{Region: m.region}); err != nil {
+		return fmt.Errorf("create bucket %s: %w", m.bucket, err)
+	}
+	return nil
+}
+
+// Put streams r into the object key and returns its size. The length is
+// not known up front, so it goes up as a multipart upload; if r fails the
+// upload is abandoned and no object appears.
+func (m *MinIO) Put(ctx context.Context, key string, r io.Reader, contentType string) (int64, error) {
+	info, err := m.client.PutObject(ctx, m.bucket, key, r, -1, minio.PutObjectOptions{
+		ContentType: contentType,
+		PartSize:    partSize,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("put %s: %w", key, err)
+	}
+	return info.Size, nil
+}
+
+// PresignGet returns a link that downloads key as filename until expiry.
+// Whoever holds the link can use it; the server is not involved again.
+func (m *MinIO) PresignGet(ctx context.Context, key, filename string, expiry time.Duration) (*url.URL, error) {
+	params := url.Values{}
+	params.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	u, err := m.signer.PresignedGetObject(ctx, m.bucket, key, expiry, params)
+	if err != nil {
+		return nil, fmt.Errorf("presign %s: %w", key, err)
+	}
+	return u, nil
+}
+
+// Delete removes key; removing a missing key is not an error
+func (m *MinIO) Delete(ctx context.Context, key string) error {
+	if err := m.client.RemoveObject(ctx, m.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// Ping checks the store can be reached
+func (m *MinIO) Ping(ctx context.Context) error {
+	_, err := m.client.BucketExists(ctx, m.bucket)
+	return err
+}
@@ -0,0 +1,125 @@
+// Package upload checks a file while it streams through the server. Nothing
+// is buffered beyond the first 512 bytes: the content type is sniffed from
+// those, and the size and checksum are tallied as the rest passes by.
+package upload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// sniffLen is how much http.DetectContentType looks at
+const sniffLen = 512
+
+// ErrTooLarge is returned by Read once a file exceeds the policy's MaxSize
+var ErrTooLarge = errors.New("file too large")
+
+// Policy says which files are accepted
+type Policy struct {
+	MaxSize      int64
+	AllowedTypes []string
+}
+
+// Allows reports whether files of contentType are accepted
+func (p Policy) Allows(contentType string) bool {
+	for _, allowed := range p.AllowedTypes {
+		if allowed == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// Open starts reading a file. Its type is decided from its first bytes,
+// never from the name or the Content-Type the client sent, which are both
+// whatever the client wants them to be.
+func (p Policy) Open(r io.Reader) (*Upload, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, &validation.Error{Field: "file", Message: "File is empty"}
+	}
+	head = head[:n]
+
+	contentType := detect(head)
+	if !p.Allows(contentType) {
+		return nil, &validation.Error{
+			Field:   "content_type",
+			Message: fmt.Sprintf("Files of type %s are not allowed; allowed: %s", contentType, strings.Join(p.AllowedTypes, ", ")),
+		}
+	}
+
+	return &Upload{
+		ContentType: contentType,
+		r:           io.MultiReader(bytes.NewReader(head), r),
+		hash:        sha256.New(),
+		max:         p.MaxSize,
+	}, nil
+}
+
+// detect sniffs the media type, without parameters like charset
+func detect(head []byte) string {
+	contentType := http.DetectContentType(head)
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return contentType
+}
+
+// Upload reads an accepted file, counting and hashing it on the way
+type Upload struct {
+	ContentType string
+
+	r    io.Reader
+	hash hash.Hash
+	size int64
+	max  int64
+	err  error
+}
+
+// Read reads the file, failing with ErrTooLarge past the size limit
+func (u *Upload) Read(b []byte) (int, error) {
+	if u.err != nil {
+		return 0, u.err
+	}
+	n, err := u.r.Read(b)
+	u.size += int64(n)
+	if u.max > 0 && u.size > u.max {
+		u.err = ErrTooLarge
+		return 0, u.err
+	}
+	u.hash.Write(b[:n])
+	if err != nil && err != io.EOF {
+		u.err = err
+	}
+	return n, err
+}
+
+// Err returns what stopped the file being read, other than reaching its end.
+// Storage clients may wrap it, so check here rather than in their error.
+func (u *Upload) Err() error {
+	return u.err
+}
+
+// Size is the number of bytes read so far
+func (u *Upload) Size() int64 {
+	return u.size
+}
+
+// Checksum is the hex SHA-256 of the bytes read so far
+func (u *Upload) Checksum() string {
+	return hex.EncodeToString(u.hash.Sum(nil))
+}
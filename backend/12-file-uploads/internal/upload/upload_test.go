@@ -0,0 +1,78 @@
+package upload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// pngHeader is enough of a PNG for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func policy(max int64) Policy {
+	return Policy{MaxSize: max, AllowedTypes: []string{"image/png", "text/plain"}}
+}
+
+func TestOpenReadsWholeFile(t *testing.T) {
+	content := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{7}, 2000)...)
+
+	u, err := policy(10_000).Open(bytes.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", u.ContentType)
+
+	got, err := io.ReadAll(u)
+	require.NoError(t, err)
+	assert.Equal(t, content, got, "sniffed bytes must not be lost")
+
+	sum := sha256.Sum256(content)
+	assert.Equal(t, int64(len(content)), u.Size())
+	assert.Equal(t, hex.EncodeToString(sum[:]), u.Checksum())
+	assert.NoError(t, u.Err())
+}
+
+func TestOpenStripsParameters(t *testing.T) {
+	u, err := policy(100).Open(strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", u.ContentType)
+}
+
+func TestOpenRejectsType(t *testing.T) {
+	_, err := policy(10_000).Open(strings.NewReader("%PDF-1.7\n"))
+
+	var validationErr *validation.Error
+	require.ErrorAs(t, err, &validationErr)
+	assert.Contains(t, validationErr.Message, "application/pdf")
+}
+
+func TestOpenRejectsEmpty(t *testing.T) {
+	_, err := policy(100).Open(strings.NewReader(""))
+
+	var validationErr *validation.Error
+	assert.ErrorAs(t, err, &validationErr)
+}
+
+func TestReadStopsPastMaxSize(t *testing.T) {
+	u, err := policy(1000).Open(strings.NewReader(strings.Repeat("a", 1001)))
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(u)
+	assert.ErrorIs(t, err, ErrTooLarge)
+	assert.ErrorIs(t, u.Err(), ErrTooLarge)
+}
+
+func TestReadAllowsExactlyMaxSize(t *testing.T) {
+	u, err := policy(1000).Open(strings.NewReader(strings.Repeat("a", 1000)))
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(u)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), u.Size())
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/12-file-uploads/internal/handlers"
+	"github.com/e6a5/learning/backend/12-file-uploads/internal/repository"
+	"github.com/e6a5/learning/backend/12-file-uploads/internal/storage"
+	"github.com/e6a5/learning/backend/12-file-uploads/internal/upload"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+func main() {
+	// Initialize database connection
+	db, err := initializeDatabase()
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer db.Close()
+
+	store, err := initializeStorage()
+	if err != nil {
+		log.Fatal("Failed to initialize object storage:", err)
+	}
+
+	policy := upload.Policy{
+		MaxSize:      getEnvInt64("MAX_UPLOAD_SIZE", 10<<20),
+		AllowedTypes: strings.Split(env.Get("ALLOWED_TYPES", "image/png,image/jpeg,image/gif,application/pdf,text/plain"), ","),
+	}
+
+	// Initialize dependencies
+	fileRepo := repository.NewFileRepository(db)
+	fileHandler := handlers.NewFileHandler(fileRepo, store, policy, getEnvDuration("DOWNLOAD_URL_EXPIRY", 15*time.Minute))
+
+	// Setup HTTP server
+	port := env.Get("PORT", "8080")
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: setupRoutes(fileHandler),
+		// No overall read timeout: a large upload on a slow link takes as
+		// long as it takes
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Printf("📁 File service running at http://localhost:%s (max %d bytes, types %v)", port, policy.MaxSize, policy.AllowedTypes)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	log.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
+	log.Println("Exited")
+}
+
+func initializeDatabase() (*sql.DB, error) {
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		dsn = "user:pass@tcp(localhost:3306)/filelab?parseTime=true"
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func initializeStorage() (*storage.MinIO, error) {
+	endpoint := env.Get("S3_ENDPOINT", "localhost:9000")
+	store, err := storage.NewMinIO(storage.Config{
+		Endpoint:       endpoint,
+		PublicEndpoint: env.Get("S3_PUBLIC_ENDPOINT", endpoint),
+		AccessKey:      env.Get("S3_ACCESS_KEY", "minioadmin"),
+		SecretKey:      env.Get("S3_SECRET_KEY", "minioadmin"),
+		Bucket:         env.Get("S3_BUCKET", "uploads"),
+		Region:         env.Get("S3_REGION", "us-east-1"),
+		UseSSL:         env.Get("S3_USE_SSL", "false") == "true",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := store.EnsureBucket(ctx); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func setupRoutes(fileHandler *handlers.FileHandler) *mux.Router {
+	router := mux.NewRouter()
+
+	// Files
+	router.HandleFunc("/files", fileHandler.UploadFile).Methods("POST")
+	router.HandleFunc("/files", fileHandler.GetFiles).Methods("GET")
+	router.HandleFunc("/files/{id}", fileHandler.GetFile).Methods("GET")
+	router.HandleFunc("/files/{id}/download", fileHandler.DownloadFile).Methods("GET")
+	router.HandleFunc("/files/{id}", fileHandler.DeleteFile).Methods("DELETE")
+
+	// Health check
+	router.HandleFunc("/health", fileHandler.HealthCheck).Methods("GET")
+
+	return router
+}
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	value, err := strconv.ParseInt(env.Get(key, strconv.FormatInt(defaultValue, 10)), 10, 64)
+	if err != nil {
+		log.Fatalf("%s must be a number: %v", key, err)
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(env.Get(key, defaultValue.String()))
+	if err != nil {
+		log.Fatalf("%s must be a duration like 2s: %v", key, err)
+	}
+	return value
+}
@@ -0,0 +1,27 @@
+-- Every job ever submitted and how far it got
+CREATE TABLE IF NOT EXISTS jobs (
+    id CHAR(16) PRIMARY KEY,
+    type VARCHAR(64) NOT NULL,
+    payload JSON NULL,
+    status VARCHAR(16) NOT NULL,
+    run_at DATETIME(3) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL,
+    last_error TEXT NOT NULL,
+    schedule_id VARCHAR(16) NOT NULL DEFAULT '',
+    created_at DATETIME(3) NOT NULL,
+    started_at DATETIME(3) NULL,
+    finished_at DATETIME(3) NULL,
+    INDEX idx_status (status, run_at),
+    INDEX idx_created_at (created_at)
+);
+
+-- Recurring jobs; next_run moves forward every time one fires
+CREATE TABLE IF NOT EXISTS schedules (
+    id CHAR(16) PRIMARY KEY,
+    type VARCHAR(64) NOT NULL,
+    payload JSON NULL,
+    every_ms BIGINT NOT NULL,
+    next_run DATETIME(3) NOT NULL,
+    created_at DATETIME(3) NOT NULL
+);
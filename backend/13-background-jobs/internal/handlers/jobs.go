@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/models"
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/queue"
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/repository"
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/tasks"
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/worker"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// JobHandler handles job submission, schedules and inspection
+type JobHandler struct {
+	queue     *queue.Queue
+	scheduler *queue.Scheduler
+	pool      *worker.Pool
+	jobs      *repository.JobRepository
+	registry  tasks.Registry
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(q *queue.Queue, scheduler *queue.Scheduler, pool *worker.Pool, jobs *repository.JobRepository, registry tasks.Registry) *JobHandler {
+	return &JobHandler{queue: q, scheduler: scheduler, pool: pool, jobs: jobs, registry: registry}
+}
+
+// CreateJob handles POST /jobs - queues a job to run now, at run_at or
+// after delay
+func (h *JobHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	var req models.JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, ok := h.registry[req.Type]; !ok {
+		response.Error(w, http.StatusBadRequest, "Unknown job type "+strconv.Quote(req.Type))
+		return
+	}
+
+	job := models.NewJob(queue.NewID(), req, time.Now())
+	if err := h.queue.Submit(r.Context(), job); err != nil {
+		if errors.Is(err, queue.ErrClosed) {
+			response.Error(w, http.StatusServiceUnavailable, "Shutting down, try again shortly")
+			return
+		}
+		log.Printf("Error submitting job: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to queue job")
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, response.APIResponse{
+		Message: "Job queued",
+		Data:    job,
+	})
+}
+
+// GetJobs handles GET /jobs - recent jobs, ?status=failed and ?limit=n
+func (h *JobHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			response.Error(w, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		limit = n
+	}
+
+	jobs, err := h.jobs.List(r.Context(), r.URL.Query().Get("status"), limit)
+	if err != nil {
+		log.Printf("Error listing jobs: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to list jobs")
+		return
+	}
+	counts, err := h.jobs.Counts(r.Context())
+	if err != nil {
+		log.Printf("Error counting jobs: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to list jobs")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"jobs":   jobs,
+			"count":  len(jobs),
+			"counts": counts,
+		},
+	})
+}
+
+// GetJob handles GET /jobs/{id} - one job and how far it got
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Get(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, repository.ErrJobNotFound) {
+		response.Error(w, http.StatusNotFound, "Job not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting job: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to get job")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: job})
+}
+
+// CancelJob handles DELETE /jobs/{id} - cancels a job that has not started
+func (h *JobHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.queue.Cancel(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, queue.ErrNotPending) {
+		response.Error(w, http.StatusConflict, "Only pending jobs can be cancelled")
+		return
+	}
+	if err != nil {
+		log.Printf("Error cancelling job: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to cancel job")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: "Job cancelled",
+		Data:    job,
+	})
+}
+
+// CreateSchedule handles POST /schedules - runs a job every interval
+func (h *JobHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var req models.ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, ok := h.registry[req.Type]; !ok {
+		response.Error(w, http.StatusBadRequest, "Unknown job type "+strconv.Quote(req.Type))
+		return
+	}
+
+	sched := models.NewSchedule(queue.NewID(), req, time.Now())
+	if err := h.scheduler.Add(r.Context(), sched); err != nil {
+		log.Printf("Error adding schedule: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to create schedule")
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{
+		Message: "Schedule created",
+		Data:    sched,
+	})
+}
+
+// GetSchedules handles GET /schedules
+func (h *JobHandler) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules := h.scheduler.List()
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"schedules": schedules,
+			"count":     len(schedules),
+		},
+	})
+}
+
+// DeleteSchedule handles DELETE /schedules/{id}
+func (h *JobHandler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	err := h.scheduler.Remove(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, queue.ErrScheduleNotFound) {
+		response.Error(w, http.StatusNotFound, "Schedule not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting schedule: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to delete schedule")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Schedule deleted"})
+}
+
+// GetStats handles GET /stats - the queue and the workers right now
+func (h *JobHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	pending, due := h.queue.Stats()
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: models.QueueStats{
+			Pending:   pending,
+			Due:       due,
+			Running:   h.pool.Running(),
+			Workers:   h.pool.Size(),
+			Schedules: len(h.scheduler.List()),
+		},
+	})
+}
+
+// HealthCheck handles GET /health
+func (h *JobHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	if err := h.jobs.Ping(r.Context()); err != nil {
+		response.Error(w, http.StatusServiceUnavailable, "Database unreachable")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Background job service is healthy"})
+}
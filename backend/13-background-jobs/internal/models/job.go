@@ -0,0 +1,177 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// Job statuses. A job waits as pending until its run_at, then runs; a failed
+// attempt with attempts left goes back to pending with a later run_at.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed" // out of attempts, or failed permanently
+	StatusCancelled = "cancelled"
+)
+
+// DefaultMaxAttempts is how often a job is tried when the request says nothing
+const DefaultMaxAttempts = 3
+
+// MinScheduleInterval keeps recurring jobs from flooding the queue
+const MinScheduleInterval = time.Second
+
+// Job is one unit of work and everything known about its progress
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Status      string          `json:"status"`
+	RunAt       time.Time       `json:"run_at"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	ScheduleID  string          `json:"schedule_id,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Finished reports whether the job will never run again
+func (j Job) Finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed || j.Status == StatusCancelled
+}
+
+// JobRequest is the body of POST /jobs. A job runs now, at run_at, or
+// after delay, such as "30s".
+type JobRequest struct {
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	RunAt       *time.Time      `json:"run_at"`
+	Delay       string          `json:"delay"`
+	MaxAttempts int             `json:"max_attempts"`
+}
+
+// Validate validates a job request
+func (r JobRequest) Validate() error {
+	if r.Type == "" {
+		return &validation.Error{Field: "type", Message: "Type is required"}
+	}
+	if len(r.Payload) > 0 && !json.Valid(r.Payload) {
+		return &validation.Error{Field: "payload", Message: "Payload must be valid JSON"}
+	}
+	if r.RunAt != nil && r.Delay != "" {
+		return &validation.Error{Field: "delay", Message: "Give run_at or delay, not both"}
+	}
+	if r.Delay != "" {
+		if d, err := time.ParseDuration(r.Delay); err != nil || d < 0 {
+			return &validation.Error{Field: "delay", Message: "Delay must be a positive duration like 30s"}
+		}
+	}
+	if r.MaxAttempts < 0 || r.MaxAttempts > 10 {
+		return &validation.Error{Field: "max_attempts", Message: "Max attempts must be 1-10, or 0 for the default"}
+	}
+	return nil
+}
+
+// NewJob turns a validated request into a pending job
+func NewJob(id string, r JobRequest, now time.Time) Job {
+	runAt := now
+	if r.RunAt != nil {
+		runAt = *r.RunAt
+	} else if r.Delay != "" {
+		d, _ := time.ParseDuration(r.Delay)
+		runAt = now.Add(d)
+	}
+	maxAttempts := r.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return Job{
+		ID:          id,
+		Type:        r.Type,
+		Payload:     r.Payload,
+		Status:      StatusPending,
+		RunAt:       runAt.UTC(),
+		MaxAttempts: maxAttempts,
+		CreatedAt:   now.UTC(),
+	}
+}
+
+// Schedule creates a job of its type every interval
+type Schedule struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Every     Duration        `json:"every"`
+	NextRun   time.Time       `json:"next_run"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ScheduleRequest is the body of POST /schedules
+type ScheduleRequest struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+	Every   Duration        `json:"every"`
+}
+
+// Validate validates a schedule request
+func (r ScheduleRequest) Validate() error {
+	if r.Type == "" {
+		return &validation.Error{Field: "type", Message: "Type is required"}
+	}
+	if len(r.Payload) > 0 && !json.Valid(r.Payload) {
+		return &validation.Error{Field: "payload", Message: "Payload must be valid JSON"}
+	}
+	if time.Duration(r.Every) < MinScheduleInterval {
+		return &validation.Error{Field: "every", Message: fmt.Sprintf("Every must be at least %s", MinScheduleInterval)}
+	}
+	return nil
+}
+
+// NewSchedule turns a validated request into a schedule whose first run is
+// one interval from now
+func NewSchedule(id string, r ScheduleRequest, now time.Time) Schedule {
+	return Schedule{
+		ID:        id,
+		Type:      r.Type,
+		Payload:   r.Payload,
+		Every:     r.Every,
+		NextRun:   now.Add(time.Duration(r.Every)).UTC(),
+		CreatedAt: now.UTC(),
+	}
+}
+
+// Duration is a time.Duration written as "30s" in JSON
+type Duration time.Duration
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON reads a duration string such as "1m30s"
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\"")
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// QueueStats is a snapshot of the queue and the workers
+type QueueStats struct {
+	Pending   int `json:"pending"`
+	Due       int `json:"due"`
+	Running   int `json:"running"`
+	Workers   int `json:"workers"`
+	Schedules int `json:"schedules"`
+}
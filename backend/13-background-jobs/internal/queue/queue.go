@@ -0,0 +1,243 @@
+// Package queue is the in-process job queue. Pending jobs wait in a heap
+// ordered by when they are due, and workers take them from the front. Every
+// change goes to a Store first, so after a restart Recover picks up where
+// the last process left off.
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/models"
+)
+
+// idleWait is how long Take sleeps with nothing pending before it looks
+// again; any Submit wakes it sooner
+const idleWait = time.Minute
+
+// ErrClosed is returned by Submit once the queue is shutting down
+var ErrClosed = errors.New("queue is closed")
+
+// ErrNotPending is returned when cancelling a job that is not waiting
+var ErrNotPending = errors.New("job is not pending")
+
+// Store persists jobs and schedules
+type Store interface {
+	SaveJob(ctx context.Context, job models.Job) error
+	UnfinishedJobs(ctx context.Context) ([]models.Job, error)
+	SaveSchedule(ctx context.Context, s models.Schedule) error
+	DeleteSchedule(ctx context.Context, id string) error
+	Schedules(ctx context.Context) ([]models.Schedule, error)
+}
+
+// Queue holds pending jobs until they are due and a worker takes them
+type Queue struct {
+	store Store
+	now   func() time.Time
+
+	mu      sync.Mutex
+	pending jobHeap
+	byID    map[string]*entry
+	closed  bool
+	// changed is closed and replaced whenever the front of the queue may
+	// have moved, waking every waiting Take
+	changed chan struct{}
+}
+
+// New creates an empty queue over store
+func New(store Store) *Queue {
+	return &Queue{
+		store:   store,
+		now:     time.Now,
+		byID:    make(map[string]*entry),
+		changed: make(chan struct{}),
+	}
+}
+
+// Recover loads the jobs a previous process left unfinished. Jobs it was
+// running when it died go back to pending: the attempt was interrupted,
+// so it does not count.
+func (q *Queue) Recover(ctx context.Context) (int, error) {
+	jobs, err := q.store.UnfinishedJobs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("load unfinished jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if job.Status == models.StatusRunning {
+			job.Status = models.StatusPending
+			job.Attempts--
+			job.StartedAt = nil
+			if err := q.store.SaveJob(ctx, job); err != nil {
+				return 0, fmt.Errorf("requeue job %s: %w", job.ID, err)
+			}
+		}
+		q.push(job)
+	}
+	return len(jobs), nil
+}
+
+// Submit persists a new pending job and queues it
+func (q *Queue) Submit(ctx context.Context, job models.Job) error {
+	q.mu.Lock()
+	closed := q.closed
+	q.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+
+	if err := q.store.SaveJob(ctx, job); err != nil {
+		return fmt.Errorf("save job %s: %w", job.ID, err)
+	}
+	q.push(job)
+	return nil
+}
+
+// Retry persists a failed job's next attempt and queues it. Unlike Submit
+// it works while closing: the job is already the queue's responsibility.
+func (q *Queue) Retry(ctx context.Context, job models.Job) error {
+	if err := q.store.SaveJob(ctx, job); err != nil {
+		return fmt.Errorf("save job %s: %w", job.ID, err)
+	}
+	q.push(job)
+	return nil
+}
+
+// Cancel removes a pending job so it never runs
+func (q *Queue) Cancel(ctx context.Context, id string) (models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.byID[id]
+	if !ok {
+		return models.Job{}, ErrNotPending
+	}
+	job := e.job
+	job.Status = models.StatusCancelled
+	now := q.now().UTC()
+	job.FinishedAt = &now
+	// Saved under the lock, so no worker can take the job in between
+	if err := q.store.SaveJob(ctx, job); err != nil {
+		return models.Job{}, fmt.Errorf("save job %s: %w", id, err)
+	}
+	heap.Remove(&q.pending, e.index)
+	delete(q.byID, id)
+	q.notify()
+	return job, nil
+}
+
+// Take waits for the front job to be due and returns it. It returns ctx's
+// error once ctx is done, which is how workers are told to stop.
+func (q *Queue) Take(ctx context.Context) (models.Job, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return models.Job{}, err
+		}
+
+		q.mu.Lock()
+		wait := idleWait
+		if len(q.pending) > 0 {
+			wait = q.pending[0].job.RunAt.Sub(q.now())
+			if wait <= 0 {
+				e := heap.Pop(&q.pending).(*entry)
+				delete(q.byID, e.job.ID)
+				q.mu.Unlock()
+				return e.job, nil
+			}
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-changed:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return models.Job{}, ctx.Err()
+		}
+	}
+}
+
+// Close stops Submit accepting jobs. Pending jobs stay in the store for the
+// next process.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+}
+
+// Stats counts pending jobs, and those among them already due
+func (q *Queue) Stats() (pending, due int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	for _, e := range q.pending {
+		if !e.job.RunAt.After(now) {
+			due++
+		}
+	}
+	return len(q.pending), due
+}
+
+func (q *Queue) push(job models.Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if old, ok := q.byID[job.ID]; ok {
+		heap.Remove(&q.pending, old.index)
+	}
+	e := &entry{job: job}
+	heap.Push(&q.pending, e)
+	q.byID[job.ID] = e
+	q.notify()
+}
+
+// notify wakes every waiting Take; called with mu held
+func (q *Queue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// entry is a job in the heap, with its position for heap.Remove
+type entry struct {
+	job   models.Job
+	index int
+}
+
+// jobHeap orders jobs by RunAt, then by creation for jobs due together
+type jobHeap []*entry
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].job.RunAt.Equal(h[j].job.RunAt) {
+		return h[i].job.CreatedAt.Before(h[j].job.CreatedAt)
+	}
+	return h[i].job.RunAt.Before(h[j].job.RunAt)
+}
+
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *jobHeap) Push(x interface{}) {
+	e := x.(*entry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
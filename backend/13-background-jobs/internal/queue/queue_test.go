@@ -0,0 +1,195 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/models"
+)
+
+// memoryStore keeps jobs and schedules in maps
+type memoryStore struct {
+	mu        sync.Mutex
+	jobs      map[string]models.Job
+	schedules map[string]models.Schedule
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{jobs: make(map[string]models.Job), schedules: make(map[string]models.Schedule)}
+}
+
+func (s *memoryStore) SaveJob(ctx context.Context, job models.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *memoryStore) UnfinishedJobs(ctx context.Context) ([]models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []models.Job
+	for _, job := range s.jobs {
+		if !job.Finished() {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+func (s *memoryStore) SaveSchedule(ctx context.Context, sched models.Schedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedules[sched.ID] = sched
+	return nil
+}
+
+func (s *memoryStore) DeleteSchedule(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.schedules, id)
+	return nil
+}
+
+func (s *memoryStore) Schedules(ctx context.Context) ([]models.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var schedules []models.Schedule
+	for _, sched := range s.schedules {
+		schedules = append(schedules, sched)
+	}
+	return schedules, nil
+}
+
+func (s *memoryStore) job(id string) models.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[id]
+}
+
+func pendingJob(id string, runAt time.Time) models.Job {
+	return models.Job{ID: id, Type: "test", Status: models.StatusPending, RunAt: runAt, MaxAttempts: 3, CreatedAt: runAt}
+}
+
+func takeWithin(t *testing.T, q *Queue, d time.Duration) (models.Job, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return q.Take(ctx)
+}
+
+func TestTakeReturnsJobsInRunAtOrder(t *testing.T) {
+	q := New(newMemoryStore())
+	now := time.Now()
+	ctx := context.Background()
+
+	require.NoError(t, q.Submit(ctx, pendingJob("later", now.Add(-time.Second))))
+	require.NoError(t, q.Submit(ctx, pendingJob("first", now.Add(-time.Minute))))
+
+	job, err := takeWithin(t, q, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "first", job.ID)
+
+	job, err = takeWithin(t, q, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "later", job.ID)
+}
+
+func TestTakeWaitsUntilDue(t *testing.T) {
+	q := New(newMemoryStore())
+	start := time.Now()
+	require.NoError(t, q.Submit(context.Background(), pendingJob("soon", start.Add(100*time.Millisecond))))
+
+	job, err := takeWithin(t, q, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "soon", job.ID)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestTakeWakesOnSubmit(t *testing.T) {
+	q := New(newMemoryStore())
+
+	taken := make(chan models.Job)
+	go func() {
+		job, err := takeWithin(t, q, time.Second)
+		if err == nil {
+			taken <- job
+		}
+		close(taken)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, q.Submit(context.Background(), pendingJob("new", time.Now())))
+	assert.Equal(t, "new", (<-taken).ID)
+}
+
+func TestTakeStopsWithContext(t *testing.T) {
+	q := New(newMemoryStore())
+
+	_, err := takeWithin(t, q, 20*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSubmitAfterClose(t *testing.T) {
+	q := New(newMemoryStore())
+	q.Close()
+
+	err := q.Submit(context.Background(), pendingJob("late", time.Now()))
+	assert.ErrorIs(t, err, ErrClosed)
+
+	// Retries of jobs already accepted still go in
+	assert.NoError(t, q.Retry(context.Background(), pendingJob("retry", time.Now())))
+}
+
+func TestCancel(t *testing.T) {
+	store := newMemoryStore()
+	q := New(store)
+	ctx := context.Background()
+	require.NoError(t, q.Submit(ctx, pendingJob("doomed", time.Now().Add(time.Hour))))
+
+	job, err := q.Cancel(ctx, "doomed")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCancelled, job.Status)
+	assert.Equal(t, models.StatusCancelled, store.job("doomed").Status)
+
+	pending, _ := q.Stats()
+	assert.Zero(t, pending)
+
+	_, err = q.Cancel(ctx, "doomed")
+	assert.ErrorIs(t, err, ErrNotPending)
+}
+
+func TestRecoverRequeuesInterruptedJobs(t *testing.T) {
+	store := newMemoryStore()
+	ctx := context.Background()
+	started := time.Now()
+
+	interrupted := pendingJob("interrupted", started.Add(-time.Minute))
+	interrupted.Status = models.StatusRunning
+	interrupted.Attempts = 1
+	interrupted.StartedAt = &started
+	require.NoError(t, store.SaveJob(ctx, interrupted))
+	require.NoError(t, store.SaveJob(ctx, pendingJob("waiting", started.Add(time.Hour))))
+	done := pendingJob("done", started)
+	done.Status = models.StatusSucceeded
+	require.NoError(t, store.SaveJob(ctx, done))
+
+	q := New(store)
+	n, err := q.Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	recovered := store.job("interrupted")
+	assert.Equal(t, models.StatusPending, recovered.Status)
+	assert.Equal(t, 0, recovered.Attempts, "an interrupted attempt does not count")
+	assert.Nil(t, recovered.StartedAt)
+
+	pending, due := q.Stats()
+	assert.Equal(t, 2, pending)
+	assert.Equal(t, 1, due)
+}
@@ -0,0 +1,178 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/models"
+)
+
+// ErrScheduleNotFound is returned when no schedule has the given ID
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// Scheduler turns recurring schedules into jobs on the queue
+type Scheduler struct {
+	queue *Queue
+	store Store
+	now   func() time.Time
+
+	mu        sync.Mutex
+	schedules map[string]models.Schedule
+	changed   chan struct{}
+}
+
+// NewScheduler creates a scheduler that submits to queue
+func NewScheduler(queue *Queue, store Store) *Scheduler {
+	return &Scheduler{
+		queue:     queue,
+		store:     store,
+		now:       time.Now,
+		schedules: make(map[string]models.Schedule),
+		changed:   make(chan struct{}),
+	}
+}
+
+// Load reads the saved schedules
+func (s *Scheduler) Load(ctx context.Context) (int, error) {
+	schedules, err := s.store.Schedules(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("load schedules: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sched := range schedules {
+		s.schedules[sched.ID] = sched
+	}
+	s.notify()
+	return len(schedules), nil
+}
+
+// Add saves a schedule and starts running it
+func (s *Scheduler) Add(ctx context.Context, sched models.Schedule) error {
+	if err := s.store.SaveSchedule(ctx, sched); err != nil {
+		return fmt.Errorf("save schedule %s: %w", sched.ID, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedules[sched.ID] = sched
+	s.notify()
+	return nil
+}
+
+// Remove stops a schedule; jobs it already created still run
+func (s *Scheduler) Remove(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.schedules[id]; !ok {
+		return ErrScheduleNotFound
+	}
+	if err := s.store.DeleteSchedule(ctx, id); err != nil {
+		return fmt.Errorf("delete schedule %s: %w", id, err)
+	}
+	delete(s.schedules, id)
+	return nil
+}
+
+// List returns the schedules, the next to run first
+func (s *Scheduler) List() []models.Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]models.Schedule, 0, len(s.schedules))
+	for _, sched := range s.schedules {
+		list = append(list, sched)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].NextRun.Before(list[j].NextRun) })
+	return list
+}
+
+// Run submits a job whenever a schedule is due, until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		s.fireDue(ctx)
+
+		s.mu.Lock()
+		wait := idleWait
+		for _, sched := range s.schedules {
+			if d := sched.NextRun.Sub(s.now()); d < wait {
+				wait = d
+			}
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-changed:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// fireDue submits a job for every due schedule and moves it to its next run
+func (s *Scheduler) fireDue(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, sched := range s.schedules {
+		if sched.NextRun.After(now) {
+			continue
+		}
+
+		job := models.Job{
+			ID:          NewID(),
+			Type:        sched.Type,
+			Payload:     sched.Payload,
+			Status:      models.StatusPending,
+			RunAt:       now.UTC(),
+			MaxAttempts: models.DefaultMaxAttempts,
+			ScheduleID:  sched.ID,
+			CreatedAt:   now.UTC(),
+		}
+		// The job is submitted before the schedule moves on: a crash in
+		// between runs it twice rather than not at all
+		if err := s.queue.Submit(ctx, job); err != nil {
+			log.Printf("Schedule %s could not submit a job: %v", id, err)
+			continue
+		}
+
+		sched.NextRun = nextRun(sched, now)
+		if err := s.store.SaveSchedule(ctx, sched); err != nil {
+			log.Printf("Error saving schedule %s: %v", id, err)
+		}
+		s.schedules[id] = sched
+	}
+}
+
+// nextRun is the first run after now. Runs missed while the process was
+// down are skipped, not made up in a burst.
+func nextRun(sched models.Schedule, now time.Time) time.Time {
+	every := time.Duration(sched.Every)
+	missed := now.Sub(sched.NextRun)/every + 1
+	return sched.NextRun.Add(missed * every)
+}
+
+// notify wakes Run to look at the schedules again; called with mu held
+func (s *Scheduler) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// NewID returns a random 16-character hex ID
+func NewID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
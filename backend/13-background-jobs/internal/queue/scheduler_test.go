@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/models"
+)
+
+func TestNextRunSkipsMissedRuns(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sched := models.Schedule{Every: models.Duration(time.Minute), NextRun: base}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{name: "on time", now: base, want: base.Add(time.Minute)},
+		{name: "a little late", now: base.Add(10 * time.Second), want: base.Add(time.Minute)},
+		{name: "down for an hour", now: base.Add(time.Hour + 30*time.Second), want: base.Add(61 * time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, nextRun(sched, tt.now))
+		})
+	}
+}
+
+func TestSchedulerSubmitsDueJobs(t *testing.T) {
+	store := newMemoryStore()
+	q := New(store)
+	s := NewScheduler(q, store)
+	ctx := context.Background()
+
+	now := time.Now()
+	due := models.Schedule{ID: "due", Type: "cleanup", Every: models.Duration(time.Minute), NextRun: now.Add(-time.Second)}
+	later := models.Schedule{ID: "later", Type: "report", Every: models.Duration(time.Minute), NextRun: now.Add(time.Hour)}
+	require.NoError(t, s.Add(ctx, due))
+	require.NoError(t, s.Add(ctx, later))
+
+	s.fireDue(ctx)
+
+	job, err := takeWithin(t, q, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "cleanup", job.Type)
+	assert.Equal(t, "due", job.ScheduleID)
+
+	pending, _ := q.Stats()
+	assert.Zero(t, pending, "only the due schedule fires")
+
+	saved, err := store.Schedules(ctx)
+	require.NoError(t, err)
+	for _, sched := range saved {
+		if sched.ID == "due" {
+			assert.True(t, sched.NextRun.After(now), "the next run is persisted")
+		}
+	}
+}
+
+func TestSchedulerRemove(t *testing.T) {
+	store := newMemoryStore()
+	s := NewScheduler(New(store), store)
+	ctx := context.Background()
+	require.NoError(t, s.Add(ctx, models.Schedule{ID: "s", Every: models.Duration(time.Minute), NextRun: time.Now()}))
+
+	require.NoError(t, s.Remove(ctx, "s"))
+	assert.Empty(t, s.List())
+	assert.ErrorIs(t, s.Remove(ctx, "s"), ErrScheduleNotFound)
+}
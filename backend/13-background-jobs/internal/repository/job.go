@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/models"
+)
+
+// ErrJobNotFound is returned when no job has the given ID
+var ErrJobNotFound = errors.New("job not found")
+
+const jobColumns = "id, type, payload, status, run_at, attempts, max_attempts, last_error, schedule_id, created_at, started_at, finished_at"
+
+// JobRepository persists jobs and schedules in MySQL. The queue keeps its
+// own copy of pending jobs in memory; this is what survives a restart.
+type JobRepository struct {
+	db *sql.DB
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *sql.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// SaveJob inserts a job or updates its progress
+func (r *JobRepository) SaveJob(ctx context.Context, job models.Job) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO jobs (`+jobColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			status = VALUES(status), run_at = VALUES(run_at), attempts = VALUES(attempts),
+			last_error = VALUES(last_error), started_at = VALUES(started_at), finished_at = VALUES(finished_at)`,
+		job.ID, job.Type, nullJSON(job.Payload), job.Status, job.RunAt, job.Attempts, job.MaxAttempts,
+		job.LastError, job.ScheduleID, job.CreatedAt, job.StartedAt, job.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+	return nil
+}
+
+// Get returns one job
+func (r *JobRepository) Get(ctx context.Context, id string) (*models.Job, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = ?", id)
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+// List returns the most recent jobs, newest first, optionally only those
+// with status
+func (r *JobRepository) List(ctx context.Context, status string, limit int) ([]models.Job, error) {
+	query := "SELECT " + jobColumns + " FROM jobs"
+	args := []interface{}{}
+	if status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY created_at DESC LIMIT ?"
+	args = append(args, limit)
+	return r.queryJobs(ctx, query, args...)
+}
+
+// UnfinishedJobs returns every pending or running job, for the queue to
+// load on startup
+func (r *JobRepository) UnfinishedJobs(ctx context.Context) ([]models.Job, error) {
+	return r.queryJobs(ctx, "SELECT "+jobColumns+" FROM jobs WHERE status IN (?, ?) ORDER BY run_at",
+		models.StatusPending, models.StatusRunning)
+}
+
+// Counts returns how many jobs are in each status
+func (r *JobRepository) Counts(ctx context.Context) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT status, COUNT(*) FROM jobs GROUP BY status")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan count: %w", err)
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// SaveSchedule inserts a schedule or moves its next run
+func (r *JobRepository) SaveSchedule(ctx context.Context, s models.Schedule) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO schedules (id, type, payload, every_ms, next_run, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE next_run = VALUES(next_run)`,
+		s.ID, s.Type, nullJSON(s.Payload), time.Duration(s.Every).Milliseconds(), s.NextRun, s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save schedule: %w", err)
+	}
+	return nil
+}
+
+// DeleteSchedule removes a schedule
+func (r *JobRepository) DeleteSchedule(ctx context.Context, id string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM schedules WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+	return nil
+}
+
+// Schedules returns every schedule
+func (r *JobRepository) Schedules(ctx context.Context) ([]models.Schedule, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, type, payload, every_ms, next_run, created_at FROM schedules")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []models.Schedule{}
+	for rows.Next() {
+		var s models.Schedule
+		var payload []byte
+		var everyMs int64
+		if err := rows.Scan(&s.ID, &s.Type, &payload, &everyMs, &s.NextRun, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		s.Payload = payload
+		s.Every = models.Duration(time.Duration(everyMs) * time.Millisecond)
+		schedules = append(schedules, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return schedules, nil
+}
+
+// Ping checks the database connection
+func (r *JobRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r *JobRepository) queryJobs(ctx context.Context, query string, args ...interface{}) ([]models.Job, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return jobs, nil
+}
+
+// scanner is what *sql.Row and *sql.Rows have in common
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(s scanner) (*models.Job, error) {
+	var job models.Job
+	var payload []byte
+	var startedAt, finishedAt sql.NullTime
+	err := s.Scan(&job.ID, &job.Type, &payload, &job.Status, &job.RunAt, &job.Attempts, &job.MaxAttempts,
+		&job.LastError, &job.ScheduleID, &job.CreatedAt, &startedAt, &finishedAt)
+	if err != nil {
+		return nil, err
+	}
+	job.Payload = payload
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return &job, nil
+}
+
+// nullJSON stores an empty payload as NULL rather than invalid JSON
+func nullJSON(payload json.RawMessage) interface{} {
+	if len(payload) == 0 {
+		return nil
+	}
+	return []byte(payload)
+}
@@ -0,0 +1,115 @@
+// Package tasks holds the work the pool knows how to do. The demo tasks
+// only sleep and fail on request, so scheduling, retries and draining can
+// be watched without any real dependencies.
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// Func does one job with its payload. It must return when ctx is done.
+type Func func(ctx context.Context, payload json.RawMessage) error
+
+// Registry maps job types to what doing them means
+type Registry map[string]Func
+
+// permanentError marks a failure that another attempt will not fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job fails at once instead of being retried
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// Default returns the demo tasks
+func Default() Registry {
+	return Registry{
+		"email":   sendEmail,
+		"report":  buildReport,
+		"cleanup": cleanup,
+		"flaky":   flaky,
+	}
+}
+
+// sendEmail pretends to deliver {"to": "..."}
+func sendEmail(ctx context.Context, payload json.RawMessage) error {
+	var p struct {
+		To string `json:"to"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil || p.To == "" {
+		return Permanent(errors.New(`payload needs "to"`))
+	}
+	return work(ctx, 200*time.Millisecond)
+}
+
+// buildReport pretends to build a report for {"seconds": n}, long enough
+// to watch a shutdown wait for it
+func buildReport(ctx context.Context, payload json.RawMessage) error {
+	p := struct {
+		Seconds int `json:"seconds"`
+	}{Seconds: 5}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &p); err != nil || p.Seconds <= 0 {
+			return Permanent(errors.New(`payload needs a positive "seconds"`))
+		}
+	}
+	return work(ctx, time.Duration(p.Seconds)*time.Second)
+}
+
+// cleanup is the kind of housekeeping that runs on a schedule
+func cleanup(ctx context.Context, payload json.RawMessage) error {
+	if err := work(ctx, 50*time.Millisecond); err != nil {
+		return err
+	}
+	log.Printf("🧹 Removed %d expired sessions", rand.Intn(20))
+	return nil
+}
+
+// flaky fails with probability {"fail_rate": 0..1}, like a dependency that
+// times out now and then
+func flaky(ctx context.Context, payload json.RawMessage) error {
+	p := struct {
+		FailRate float64 `json:"fail_rate"`
+	}{FailRate: 0.5}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return Permanent(fmt.Errorf("invalid payload: %w", err))
+		}
+	}
+	if err := work(ctx, 100*time.Millisecond); err != nil {
+		return err
+	}
+	if rand.Float64() < p.FailRate {
+		return errors.New("upstream timed out")
+	}
+	return nil
+}
+
+func work(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,140 @@
+// Package worker runs jobs from the queue on a fixed number of goroutines.
+// Stopping is in two steps: stop taking new jobs and let the ones in hand
+// finish (draining), then, if that takes too long, cancel them.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/models"
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/queue"
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/tasks"
+)
+
+// saveTimeout bounds status writes, which run even after the job's own
+// context is cancelled
+const saveTimeout = 5 * time.Second
+
+// Pool is a fixed set of workers sharing one queue
+type Pool struct {
+	queue    *queue.Queue
+	store    queue.Store
+	registry tasks.Registry
+	size     int
+	timeout  time.Duration
+	backoff  Backoff
+	now      func() time.Time
+
+	running atomic.Int32
+}
+
+// NewPool creates a pool of size workers giving every attempt up to timeout
+func NewPool(q *queue.Queue, store queue.Store, registry tasks.Registry, size int, timeout time.Duration, backoff Backoff) *Pool {
+	return &Pool{queue: q, store: store, registry: registry, size: size, timeout: timeout, backoff: backoff, now: time.Now}
+}
+
+// Size is the number of workers
+func (p *Pool) Size() int {
+	return p.size
+}
+
+// Running is the number of jobs in progress
+func (p *Pool) Running() int {
+	return int(p.running.Load())
+}
+
+// Run starts the workers and blocks until they have all stopped. They stop
+// taking jobs once stop is done and finish the ones in hand; kill cancels
+// those too.
+func (p *Pool) Run(stop, kill context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.size; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, err := p.queue.Take(stop)
+				if err != nil {
+					return
+				}
+				p.process(kill, job)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// process makes one attempt at job and records the outcome
+func (p *Pool) process(kill context.Context, job models.Job) {
+	p.running.Add(1)
+	defer p.running.Add(-1)
+
+	started := p.now().UTC()
+	job.Status = models.StatusRunning
+	job.Attempts++
+	job.StartedAt = &started
+	job.FinishedAt = nil
+	p.save(kill, job)
+
+	err := p.run(kill, job)
+	finished := p.now().UTC()
+
+	switch {
+	case err == nil:
+		job.Status = models.StatusSucceeded
+		job.LastError = ""
+		job.FinishedAt = &finished
+
+	case kill.Err() != nil:
+		// Cancelled by shutdown, which is not the job's fault. It stays
+		// pending in the store and runs again after the restart.
+		log.Printf("Job %s (%s) interrupted by shutdown", job.ID, job.Type)
+		job.Status = models.StatusPending
+		job.Attempts--
+		job.StartedAt = nil
+
+	case tasks.IsPermanent(err) || job.Attempts >= job.MaxAttempts:
+		log.Printf("Job %s (%s) failed after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+		job.Status = models.StatusFailed
+		job.LastError = err.Error()
+		job.FinishedAt = &finished
+
+	default:
+		job.Status = models.StatusPending
+		job.LastError = err.Error()
+		job.RunAt = finished.Add(p.backoff.Delay(job.Attempts))
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(kill), saveTimeout)
+		defer cancel()
+		if err := p.queue.Retry(ctx, job); err != nil {
+			log.Printf("Error requeueing job %s: %v", job.ID, err)
+		}
+		return
+	}
+	p.save(kill, job)
+}
+
+// run looks up and runs the job under the attempt timeout. A type nobody
+// knows fails permanently.
+func (p *Pool) run(ctx context.Context, job models.Job) error {
+	fn, ok := p.registry[job.Type]
+	if !ok {
+		return tasks.Permanent(fmt.Errorf("unknown job type %q", job.Type))
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	return fn(ctx, job.Payload)
+}
+
+// save records job's status, even while shutting down
+func (p *Pool) save(ctx context.Context, job models.Job) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), saveTimeout)
+	defer cancel()
+	if err := p.store.SaveJob(ctx, job); err != nil {
+		log.Printf("Error saving job %s: %v", job.ID, err)
+	}
+}
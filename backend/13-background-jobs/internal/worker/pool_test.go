@@ -0,0 +1,198 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/models"
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/queue"
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/tasks"
+)
+
+// fakeStore records every saved state of every job
+type fakeStore struct {
+	mu    sync.Mutex
+	saves map[string][]models.Job
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{saves: make(map[string][]models.Job)}
+}
+
+func (s *fakeStore) SaveJob(ctx context.Context, job models.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saves[job.ID] = append(s.saves[job.ID], job)
+	return nil
+}
+
+func (s *fakeStore) UnfinishedJobs(ctx context.Context) ([]models.Job, error)      { return nil, nil }
+func (s *fakeStore) SaveSchedule(ctx context.Context, sched models.Schedule) error { return nil }
+func (s *fakeStore) DeleteSchedule(ctx context.Context, id string) error           { return nil }
+func (s *fakeStore) Schedules(ctx context.Context) ([]models.Schedule, error)      { return nil, nil }
+
+// last returns the latest saved state of a job
+func (s *fakeStore) last(id string) models.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saves := s.saves[id]
+	if len(saves) == 0 {
+		return models.Job{}
+	}
+	return saves[len(saves)-1]
+}
+
+func newTestPool(store *fakeStore, registry tasks.Registry) (*Pool, *queue.Queue) {
+	q := queue.New(store)
+	return NewPool(q, store, registry, 2, time.Second, Backoff{Base: time.Minute, Max: time.Hour}), q
+}
+
+func job(id, typ string, maxAttempts int) models.Job {
+	now := time.Now()
+	return models.Job{ID: id, Type: typ, Status: models.StatusPending, RunAt: now, MaxAttempts: maxAttempts, CreatedAt: now}
+}
+
+func TestProcessSucceeds(t *testing.T) {
+	store := newFakeStore()
+	pool, _ := newTestPool(store, tasks.Registry{
+		"ok": func(ctx context.Context, payload json.RawMessage) error { return nil },
+	})
+
+	pool.process(context.Background(), job("j", "ok", 3))
+
+	saves := store.saves["j"]
+	require.Len(t, saves, 2)
+	assert.Equal(t, models.StatusRunning, saves[0].Status)
+	assert.Equal(t, models.StatusSucceeded, saves[1].Status)
+	assert.Equal(t, 1, saves[1].Attempts)
+	assert.NotNil(t, saves[1].FinishedAt)
+}
+
+func TestProcessRetriesWithBackoff(t *testing.T) {
+	store := newFakeStore()
+	pool, q := newTestPool(store, tasks.Registry{
+		"fail": func(ctx context.Context, payload json.RawMessage) error { return errors.New("boom") },
+	})
+
+	before := time.Now()
+	pool.process(context.Background(), job("j", "fail", 3))
+
+	retried := store.last("j")
+	assert.Equal(t, models.StatusPending, retried.Status)
+	assert.Equal(t, "boom", retried.LastError)
+	assert.WithinDuration(t, before.Add(time.Minute), retried.RunAt, time.Second)
+
+	pending, due := q.Stats()
+	assert.Equal(t, 1, pending, "the retry is back in the queue")
+	assert.Equal(t, 0, due)
+}
+
+func TestProcessFailsWhenOutOfAttempts(t *testing.T) {
+	store := newFakeStore()
+	pool, q := newTestPool(store, tasks.Registry{
+		"fail": func(ctx context.Context, payload json.RawMessage) error { return errors.New("boom") },
+	})
+
+	last := job("j", "fail", 3)
+	last.Attempts = 2
+	pool.process(context.Background(), last)
+
+	assert.Equal(t, models.StatusFailed, store.last("j").Status)
+	pending, _ := q.Stats()
+	assert.Zero(t, pending)
+}
+
+func TestProcessFailsPermanentErrorsAtOnce(t *testing.T) {
+	store := newFakeStore()
+	pool, _ := newTestPool(store, tasks.Registry{})
+
+	pool.process(context.Background(), job("j", "unknown", 3))
+
+	failed := store.last("j")
+	assert.Equal(t, models.StatusFailed, failed.Status)
+	assert.Contains(t, failed.LastError, "unknown job type")
+}
+
+func TestRunDrainsJobsInHand(t *testing.T) {
+	store := newFakeStore()
+	started := make(chan struct{})
+	pool, q := newTestPool(store, tasks.Registry{
+		"slow": func(ctx context.Context, payload json.RawMessage) error {
+			close(started)
+			return sleep(ctx, 100*time.Millisecond)
+		},
+	})
+	require.NoError(t, q.Submit(context.Background(), job("j", "slow", 1)))
+
+	stop, stopTaking := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		pool.Run(stop, context.Background())
+		close(done)
+	}()
+
+	<-started
+	stopTaking()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("pool did not stop")
+	}
+	assert.Equal(t, models.StatusSucceeded, store.last("j").Status, "the running job finished")
+}
+
+func TestRunKillLeavesJobPending(t *testing.T) {
+	store := newFakeStore()
+	started := make(chan struct{})
+	pool, q := newTestPool(store, tasks.Registry{
+		"stuck": func(ctx context.Context, payload json.RawMessage) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	require.NoError(t, q.Submit(context.Background(), job("j", "stuck", 3)))
+
+	stop, stopTaking := context.WithCancel(context.Background())
+	kill, killRunning := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		pool.Run(stop, kill)
+		close(done)
+	}()
+
+	<-started
+	stopTaking()
+	killRunning()
+	<-done
+
+	interrupted := store.last("j")
+	assert.Equal(t, models.StatusPending, interrupted.Status)
+	assert.Equal(t, 0, interrupted.Attempts, "a killed attempt does not count")
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Base: time.Second, Max: 10 * time.Second}
+
+	assert.Equal(t, time.Second, b.Delay(1))
+	assert.Equal(t, 2*time.Second, b.Delay(2))
+	assert.Equal(t, 8*time.Second, b.Delay(4))
+	assert.Equal(t, 10*time.Second, b.Delay(5))
+	assert.Equal(t, 10*time.Second, b.Delay(50))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/handlers"
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/queue"
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/repository"
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/tasks"
+	"github.com/e6a5/learning/backend/13-background-jobs/internal/worker"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+func main() {
+	// Initialize database connection
+	db, err := initializeDatabase()
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer db.Close()
+
+	// Initialize dependencies, picking up what the last process left behind
+	jobRepo := repository.NewJobRepository(db)
+	registry := tasks.Default()
+	jobQueue := queue.New(jobRepo)
+	scheduler := queue.NewScheduler(jobQueue, jobRepo)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	recovered, err := jobQueue.Recover(ctx)
+	if err != nil {
+		log.Fatal("Failed to recover jobs:", err)
+	}
+	schedules, err := scheduler.Load(ctx)
+	if err != nil {
+		log.Fatal("Failed to load schedules:", err)
+	}
+	cancel()
+	log.Printf("Recovered %d unfinished jobs and %d schedules", recovered, schedules)
+
+	pool := worker.NewPool(jobQueue, jobRepo, registry,
+		getEnvInt("WORKERS", 4),
+		getEnvDuration("JOB_TIMEOUT", 30*time.Second),
+		worker.Backoff{
+			Base: getEnvDuration("RETRY_BASE_DELAY", time.Second),
+			Max:  getEnvDuration("RETRY_MAX_DELAY", time.Minute),
+		})
+
+	// Two contexts for two stages of stopping: stop taking jobs, then kill
+	// the ones still running
+	takeCtx, stopTaking := context.WithCancel(context.Background())
+	killCtx, kill := context.WithCancel(context.Background())
+	poolDone := make(chan struct{})
+	go func() {
+		pool.Run(takeCtx, killCtx)
+		close(poolDone)
+	}()
+
+	schedulerCtx, stopScheduling := context.WithCancel(context.Background())
+	go scheduler.Run(schedulerCtx)
+
+	// Setup HTTP server
+	jobHandler := handlers.NewJobHandler(jobQueue, scheduler, pool, jobRepo, registry)
+	port := env.Get("PORT", "8080")
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           setupRoutes(jobHandler),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Printf("⚙️  Background job service running at http://localhost:%s with %d workers", port, pool.Size())
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	sig, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-sig.Done()
+
+	// 1. Stop new work arriving: no new jobs from schedules or the API
+	log.Println("Shutting down: no longer accepting jobs")
+	stopScheduling()
+	jobQueue.Close()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
+
+	// 2. Drain: workers finish what they hold but take nothing new
+	drainTimeout := getEnvDuration("DRAIN_TIMEOUT", 20*time.Second)
+	log.Printf("Draining %d running jobs (up to %s)", pool.Running(), drainTimeout)
+	stopTaking()
+	select {
+	case <-poolDone:
+		log.Println("All running jobs finished")
+	case <-time.After(drainTimeout):
+		// 3. Out of patience: cancel the rest; they stay pending and run
+		// again after the restart
+		log.Printf("Drain timed out, cancelling %d jobs", pool.Running())
+		kill()
+		<-poolDone
+	}
+	kill()
+	log.Println("Exited")
+}
+
+func initializeDatabase() (*sql.DB, error) {
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		dsn = "user:pass@tcp(localhost:3306)/jobslab?parseTime=true"
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func setupRoutes(jobHandler *handlers.JobHandler) *mux.Router {
+	router := mux.NewRouter()
+
+	// Jobs
+	router.HandleFunc("/jobs", jobHandler.CreateJob).Methods("POST")
+	router.HandleFunc("/jobs", jobHandler.GetJobs).Methods("GET")
+	router.HandleFunc("/jobs/{id}", jobHandler.GetJob).Methods("GET")
+	router.HandleFunc("/jobs/{id}", jobHandler.CancelJob).Methods("DELETE")
+
+	// Recurring schedules
+	router.HandleFunc("/schedules", jobHandler.CreateSchedule).Methods("POST")
+	router.HandleFunc("/schedules", jobHandler.GetSchedules).Methods("GET")
+	router.HandleFunc("/schedules/{id}", jobHandler.DeleteSchedule).Methods("DELETE")
+
+	// Queue stats and health check
+	router.HandleFunc("/stats", jobHandler.GetStats).Methods("GET")
+	router.HandleFunc("/health", jobHandler.HealthCheck).Methods("GET")
+
+	return router
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(env.Get(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		log.Fatalf("%s must be a number: %v", key, err)
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(env.Get(key, defaultValue.String()))
+	if err != nil {
+		log.Fatalf("%s must be a duration like 2s: %v", key, err)
+	}
+	return value
+}
@@ -0,0 +1,146 @@
+// Package config loads the service's settings in layers, each overriding
+// the one before: defaults in code, then a YAML file, then environment
+// variables. The result is validated as a whole before anything uses it.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is every setting of the service
+type Config struct {
+	Env       string          `yaml:"env" json:"env"`
+	Server    ServerConfig    `yaml:"server" json:"server"`
+	Log       LogConfig       `yaml:"log" json:"log"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" json:"rate_limit"`
+	Features  FeaturesConfig  `yaml:"features" json:"features"`
+	Admin     AdminConfig     `yaml:"admin" json:"admin"`
+}
+
+// ServerConfig is read once, when the listener starts
+type ServerConfig struct {
+	Port            int      `yaml:"port" json:"port"`
+	ReadTimeout     Duration `yaml:"read_timeout" json:"read_timeout"`
+	WriteTimeout    Duration `yaml:"write_timeout" json:"write_timeout"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" json:"shutdown_timeout"`
+}
+
+// LogConfig controls logging; the level can change while running
+type LogConfig struct {
+	Level  string `yaml:"level" json:"level"`
+	Format string `yaml:"format" json:"format"`
+}
+
+// RateLimitConfig caps requests across all clients
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second" json:"requests_per_second"`
+	Burst             int     `yaml:"burst" json:"burst"`
+}
+
+// FeaturesConfig holds behaviour that can be switched while running
+type FeaturesConfig struct {
+	Greeting    string `yaml:"greeting" json:"greeting"`
+	Maintenance bool   `yaml:"maintenance" json:"maintenance"`
+}
+
+// AdminConfig protects the configuration endpoints
+type AdminConfig struct {
+	Token Secret `yaml:"token" json:"token"`
+}
+
+// Defaults is the bottom layer: a configuration that works on a laptop
+func Defaults() *Config {
+	return &Config{
+		Env: "development",
+		Server: ServerConfig{
+			Port:            8080,
+			ReadTimeout:     Duration(5 * time.Second),
+			WriteTimeout:    Duration(10 * time.Second),
+			ShutdownTimeout: Duration(10 * time.Second),
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		RateLimit: RateLimitConfig{
+			RequestsPerSecond: 50,
+			Burst:             100,
+		},
+		Features: FeaturesConfig{
+			Greeting: "Hello from 14-config-management",
+		},
+	}
+}
+
+// Validate checks the whole configuration and reports every problem at
+// once, so a broken deploy is fixed in one round rather than one per error
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(oneOf(c.Env, "development", "staging", "production"),
+		"env must be development, staging or production, not %q", c.Env)
+
+	check(c.Server.Port >= 1 && c.Server.Port <= 65535, "server.port must be 1-65535, not %d", c.Server.Port)
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	check(oneOf(c.Log.Level, "debug", "info", "warn", "error"),
+		"log.level must be debug, info, warn or error, not %q", c.Log.Level)
+	check(oneOf(c.Log.Format, "text", "json"), "log.format must be text or json, not %q", c.Log.Format)
+
+	check(c.RateLimit.RequestsPerSecond > 0, "rate_limit.requests_per_second must be positive")
+	check(c.RateLimit.Burst >= 1, "rate_limit.burst must be at least 1")
+
+	check(c.Features.Greeting != "", "features.greeting is required")
+
+	if c.Env == "production" {
+		check(c.Admin.Token != "", "admin.token is required in production")
+	}
+	check(c.Admin.Token == "" || len(c.Admin.Token) >= 16, "admin.token must be at least 16 characters")
+
+	return errors.Join(errs...)
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration is a time.Duration written as "30s" in YAML, JSON and the
+// environment
+type Duration time.Duration
+
+// UnmarshalYAML reads a duration string such as "1m30s"
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalYAML writes the duration as a string
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts every environment variable the loader reads. The rest of
+// the name is the setting's key in upper case: server.port is APP_SERVER_PORT.
+const EnvPrefix = "APP_"
+
+// Layers a setting can come from, lowest first
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// Sources maps every setting, such as "server.port", to the layer that set it
+type Sources map[string]string
+
+// Load builds the configuration from the defaults, the YAML file at path
+// (none if empty) and environ, a list of KEY=value pairs as from
+// os.Environ. Unknown keys in either are errors: a typo should fail loudly,
+// not leave the default quietly in place.
+func Load(path string, environ []string) (*Config, Sources, error) {
+	cfg := Defaults()
+	sources := Sources{}
+	for _, f := range fields(cfg) {
+		sources[f.key] = SourceDefault
+	}
+
+	if path != "" {
+		keys, err := loadFile(cfg, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, key := range keys {
+			sources[key] = SourceFile
+		}
+	}
+
+	keys, err := loadEnv(cfg, environ)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, key := range keys {
+		sources[key] = SourceEnv
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, sources, nil
+}
+
+// loadFile decodes the file over cfg and returns the keys it set
+func loadFile(cfg *Config, path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	var keys []string
+	if len(root.Content) > 0 {
+		keys = leafKeys(root.Content[0], "")
+	}
+	return keys, nil
+}
+
+// leafKeys lists the dotted keys of the scalar values under a mapping node
+func leafKeys(node *yaml.Node, prefix string) []string {
+	if node.Kind != yaml.MappingNode {
+		return []string{prefix}
+	}
+	var keys []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		keys = append(keys, leafKeys(node.Content[i+1], key)...)
+	}
+	return keys
+}
+
+// loadEnv applies the APP_ variables in environ to cfg and returns the keys
+// they set
+func loadEnv(cfg *Config, environ []string) ([]string, error) {
+	vars := make(map[string]string)
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if ok && strings.HasPrefix(name, EnvPrefix) {
+			vars[name] = value
+		}
+	}
+
+	var keys []string
+	var errs []error
+	for _, f := range fields(cfg) {
+		name := EnvName(f.key)
+		value, ok := vars[name]
+		if !ok {
+			continue
+		}
+		delete(vars, name)
+		if err := set(f.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		keys = append(keys, f.key)
+	}
+
+	unknown := make([]string, 0, len(vars))
+	for name := range vars {
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, fmt.Errorf("%s: no such setting", name))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid environment:\n%w", err)
+	}
+	return keys, nil
+}
+
+// EnvName is the environment variable that sets key
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// field is one setting: its dotted key and where it lives in a Config
+type field struct {
+	key   string
+	value reflect.Value
+}
+
+// fields lists every setting in cfg, keyed by the YAML names
+func fields(cfg *Config) []field {
+	return walk(reflect.ValueOf(cfg).Elem(), "")
+}
+
+func walk(v reflect.Value, prefix string) []field {
+	var out []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			out = append(out, walk(fv, key)...)
+			continue
+		}
+		out = append(out, field{key: key, value: fv})
+	}
+	return out
+}
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// set parses s into v according to v's type
+func set(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("not a duration like 30s: %q", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("not a whole number: %q", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("not a number: %q", s)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("not true or false: %q", s)
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// Changed lists the keys whose values differ between a and b
+func Changed(a, b *Config) []string {
+	af, bf := fields(a), fields(b)
+	var keys []string
+	for i := range af {
+		if !reflect.DeepEqual(af[i].value.Interface(), bf[i].value.Interface()) {
+			keys = append(keys, af[i].key)
+		}
+	}
+	return keys
+}
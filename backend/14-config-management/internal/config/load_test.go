@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, sources, err := Load("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, Defaults(), cfg)
+	assert.Equal(t, SourceDefault, sources["server.port"])
+	assert.Equal(t, SourceDefault, sources["admin.token"])
+}
+
+func TestLoadLayers(t *testing.T) {
+	path := writeFile(t, `
+server:
+  port: 9000
+  read_timeout: 2s
+log:
+  level: debug
+features:
+  greeting: from the file
+`)
+	cfg, sources, err := Load(path, []string{
+		"APP_SERVER_PORT=9100",
+		"APP_FEATURES_MAINTENANCE=true",
+		"PATH=/usr/bin",
+	})
+	require.NoError(t, err)
+
+	// The environment beats the file, the file beats the defaults
+	assert.Equal(t, 9100, cfg.Server.Port)
+	assert.Equal(t, Duration(2*time.Second), cfg.Server.ReadTimeout)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, "from the file", cfg.Features.Greeting)
+	assert.True(t, cfg.Features.Maintenance)
+	assert.Equal(t, Duration(10*time.Second), cfg.Server.WriteTimeout)
+
+	assert.Equal(t, SourceEnv, sources["server.port"])
+	assert.Equal(t, SourceFile, sources["server.read_timeout"])
+	assert.Equal(t, SourceEnv, sources["features.maintenance"])
+	assert.Equal(t, SourceDefault, sources["server.write_timeout"])
+}
+
+func TestLoadRejectsUnknownFileKey(t *testing.T) {
+	path := writeFile(t, "server:\n  prot: 9000\n")
+	_, _, err := Load(path, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "prot")
+}
+
+func TestLoadRejectsBadEnvironment(t *testing.T) {
+	_, _, err := Load("", []string{
+		"APP_SERVER_PROT=9000",
+		"APP_SERVER_PORT=lots",
+		"APP_SERVER_READ_TIMEOUT=5",
+	})
+	require.Error(t, err)
+	// Every problem is reported, not just the first
+	assert.Contains(t, err.Error(), "APP_SERVER_PROT: no such setting")
+	assert.Contains(t, err.Error(), "APP_SERVER_PORT: not a whole number")
+	assert.Contains(t, err.Error(), "APP_SERVER_READ_TIMEOUT: not a duration")
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, _, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), nil)
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Defaults()
+	require.NoError(t, cfg.Validate())
+
+	cfg.Env = "production"
+	cfg.Server.Port = 0
+	cfg.Log.Level = "loud"
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.port")
+	assert.Contains(t, err.Error(), "log.level")
+	assert.Contains(t, err.Error(), "admin.token is required in production")
+
+	cfg = Defaults()
+	cfg.Admin.Token = "short"
+	assert.ErrorContains(t, cfg.Validate(), "at least 16 characters")
+}
+
+func TestChanged(t *testing.T) {
+	a, b := Defaults(), Defaults()
+	assert.Empty(t, Changed(a, b))
+
+	b.Server.Port = 9000
+	b.Features.Maintenance = true
+	assert.Equal(t, []string{"server.port", "features.maintenance"}, Changed(a, b))
+}
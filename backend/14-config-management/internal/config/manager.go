@@ -0,0 +1,181 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounce lets a burst of file events, as editors and Kubernetes produce
+// when replacing a file, settle into one reload
+const debounce = 200 * time.Millisecond
+
+// restartOnly are the settings read once at startup. A reload that changes
+// them keeps the running values and reports them as pending a restart.
+var restartOnly = []string{
+	"env",
+	"server.port",
+	"server.read_timeout",
+	"server.write_timeout",
+	"server.shutdown_timeout",
+	"log.format",
+}
+
+// Snapshot is one loaded configuration. It is never modified, so readers
+// can hold on to it without locks.
+type Snapshot struct {
+	Config         *Config   `json:"config"`
+	Sources        Sources   `json:"sources"`
+	Version        int       `json:"version"`
+	LoadedAt       time.Time `json:"loaded_at"`
+	PendingRestart []string  `json:"pending_restart,omitempty"`
+}
+
+// Manager holds the current configuration and replaces it on reload
+type Manager struct {
+	path    string
+	environ func() []string
+
+	current atomic.Pointer[Snapshot]
+
+	mu        sync.Mutex // serialises reloads and guards listeners
+	listeners []func(*Config)
+}
+
+// NewManager loads the configuration for the first time. Unlike a reload,
+// a failure here is fatal: there is nothing to fall back to.
+func NewManager(path string, environ func() []string) (*Manager, error) {
+	cfg, sources, err := Load(path, environ())
+	if err != nil {
+		return nil, err
+	}
+	m := &Manager{path: path, environ: environ}
+	m.current.Store(&Snapshot{Config: cfg, Sources: sources, Version: 1, LoadedAt: time.Now().UTC()})
+	return m, nil
+}
+
+// Current returns the configuration in effect. Call it per use rather
+// than keeping the result, or reloads will pass you by.
+func (m *Manager) Current() *Snapshot {
+	return m.current.Load()
+}
+
+// OnChange registers fn to be called with every newly applied configuration
+func (m *Manager) OnChange(fn func(*Config)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// Reload loads the configuration again and applies it. If it fails to load
+// or validate, the running configuration stays and the error says why.
+func (m *Manager) Reload() (*Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old := m.current.Load()
+	cfg, sources, err := Load(m.path, m.environ())
+	if err != nil {
+		return old, err
+	}
+
+	// Settings that need a restart keep their running values
+	var pending []string
+	for _, key := range Changed(old.Config, cfg) {
+		for _, restart := range restartOnly {
+			if key == restart {
+				pending = append(pending, key)
+			}
+		}
+	}
+	cfg.Env = old.Config.Env
+	cfg.Server = old.Config.Server
+	cfg.Log.Format = old.Config.Log.Format
+
+	changed := Changed(old.Config, cfg)
+	if len(changed) == 0 && slices.Equal(pending, old.PendingRestart) {
+		return old, nil
+	}
+
+	next := &Snapshot{
+		Config:         cfg,
+		Sources:        sources,
+		Version:        old.Version + 1,
+		LoadedAt:       time.Now().UTC(),
+		PendingRestart: pending,
+	}
+	m.current.Store(next)
+
+	slog.Info("Configuration reloaded", "version", next.Version, "changed", changed)
+	if len(pending) > 0 {
+		slog.Warn("Some changes need a restart to apply", "settings", pending)
+	}
+	for _, fn := range m.listeners {
+		fn(cfg)
+	}
+	return next, nil
+}
+
+// Watch reloads on SIGHUP and whenever the config file changes, until ctx
+// is done. It watches the file's directory rather than the file: editors
+// and Kubernetes ConfigMaps replace files instead of writing to them, which
+// would leave a watch on the old file seeing nothing.
+func (m *Manager) Watch(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if m.path != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("create watcher: %w", err)
+		}
+		defer watcher.Close()
+		if err := watcher.Add(filepath.Dir(m.path)); err != nil {
+			return fmt.Errorf("watch %s: %w", filepath.Dir(m.path), err)
+		}
+		events, errs = watcher.Events, watcher.Errors
+	}
+
+	target := filepath.Clean(m.path)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			m.reload("SIGHUP")
+		case event := <-events:
+			// ..data is the symlink Kubernetes swaps to update a ConfigMap
+			if filepath.Clean(event.Name) == target || filepath.Base(event.Name) == "..data" {
+				timer.Reset(debounce)
+			}
+		case <-timer.C:
+			m.reload("file change")
+		case err := <-errs:
+			slog.Error("Config watcher error", "error", err)
+		}
+	}
+}
+
+func (m *Manager) reload(trigger string) {
+	slog.Info("Reloading configuration", "trigger", trigger)
+	if _, err := m.Reload(); err != nil {
+		slog.Error("Reload rejected, keeping the running configuration", "error", err)
+	}
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	path := writeFile(t, "features:\n  greeting: one\n")
+	m, err := NewManager(path, func() []string { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, 1, m.Current().Version)
+
+	var seen []string
+	m.OnChange(func(c *Config) { seen = append(seen, c.Features.Greeting) })
+
+	// Nothing changed: same snapshot, no listeners
+	snapshot, err := m.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, 1, snapshot.Version)
+
+	require.NoError(t, os.WriteFile(path, []byte("features:\n  greeting: two\n"), 0o644))
+	snapshot, err = m.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, 2, snapshot.Version)
+	assert.Equal(t, "two", m.Current().Config.Features.Greeting)
+	assert.Equal(t, []string{"two"}, seen)
+}
+
+func TestReloadKeepsConfigWhenInvalid(t *testing.T) {
+	path := writeFile(t, "features:\n  greeting: one\n")
+	m, err := NewManager(path, func() []string { return nil })
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("rate_limit:\n  burst: 0\n"), 0o644))
+	snapshot, err := m.Reload()
+	require.ErrorContains(t, err, "rate_limit.burst")
+	assert.Equal(t, 1, snapshot.Version)
+	assert.Equal(t, "one", m.Current().Config.Features.Greeting)
+}
+
+func TestReloadDefersRestartOnlySettings(t *testing.T) {
+	path := writeFile(t, "server:\n  port: 8080\n")
+	m, err := NewManager(path, func() []string { return nil })
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 9000\nlog:\n  level: debug\n"), 0o644))
+	snapshot, err := m.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, 8080, snapshot.Config.Server.Port)
+	assert.Equal(t, "debug", snapshot.Config.Log.Level)
+	assert.Equal(t, []string{"server.port"}, snapshot.PendingRestart)
+}
+
+func TestWatchReloadsOnFileChange(t *testing.T) {
+	path := writeFile(t, "features:\n  greeting: one\n")
+	m, err := NewManager(path, func() []string { return nil })
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Watch(ctx)
+	time.Sleep(50 * time.Millisecond) // let the watcher start
+
+	// Replace the file the way editors do: write elsewhere, then rename
+	tmp := filepath.Join(filepath.Dir(path), "config.yaml.tmp")
+	require.NoError(t, os.WriteFile(tmp, []byte("features:\n  greeting: two\n"), 0o644))
+	require.NoError(t, os.Rename(tmp, path))
+
+	assert.Eventually(t, func() bool {
+		return m.Current().Config.Features.Greeting == "two"
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestWatchReloadsOnSIGHUP(t *testing.T) {
+	var greeting atomic.Value
+	greeting.Store("one")
+	m, err := NewManager("", func() []string {
+		return []string{"APP_FEATURES_GREETING=" + greeting.Load().(string)}
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Watch(ctx)
+	time.Sleep(50 * time.Millisecond) // let the watcher start
+
+	greeting.Store("two")
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	assert.Eventually(t, func() bool {
+		return m.Current().Config.Features.Greeting == "two"
+	}, 2*time.Second, 20*time.Millisecond)
+}
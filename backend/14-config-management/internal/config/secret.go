@@ -0,0 +1,47 @@
+package config
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// redacted is what a secret looks like anywhere it might be printed
+const redacted = "[REDACTED]"
+
+// Secret is a string that does not print itself. fmt, JSON, YAML and slog
+// all see "[REDACTED]"; only Reveal returns the value, so every use of a
+// secret is a deliberate, greppable call.
+type Secret string
+
+// Reveal returns the secret's value
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String redacts the secret for fmt's %s and %v
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString redacts the secret for fmt's %#v
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// MarshalJSON redacts the secret in JSON
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// MarshalYAML redacts the secret in YAML
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// LogValue redacts the secret in structured logs
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const token = "s3cr3t-admin-token"
+
+func TestSecretRedaction(t *testing.T) {
+	cfg := Defaults()
+	cfg.Admin.Token = token
+
+	printed := []string{
+		fmt.Sprint(cfg.Admin.Token),
+		fmt.Sprintf("%v", cfg),
+		fmt.Sprintf("%+v", cfg),
+		fmt.Sprintf("%#v", cfg.Admin),
+	}
+	for _, s := range printed {
+		assert.NotContains(t, s, token)
+		assert.Contains(t, s, redacted)
+	}
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), token)
+
+	data, err = yaml.Marshal(cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), token)
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("loaded", "token", cfg.Admin.Token)
+	assert.NotContains(t, buf.String(), token)
+	assert.Contains(t, buf.String(), redacted)
+
+	assert.Equal(t, token, cfg.Admin.Token.Reveal())
+}
+
+func TestEmptySecretPrintsEmpty(t *testing.T) {
+	assert.Equal(t, "", Secret("").String())
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/e6a5/learning/backend/14-config-management/internal/config"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// AppHandler serves the demo endpoints and the configuration itself
+type AppHandler struct {
+	manager *config.Manager
+}
+
+// NewAppHandler creates a new app handler
+func NewAppHandler(manager *config.Manager) *AppHandler {
+	return &AppHandler{manager: manager}
+}
+
+// Greet handles GET / - answers with features.greeting, read per request
+func (h *AppHandler) Greet(w http.ResponseWriter, r *http.Request) {
+	snapshot := h.manager.Current()
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: snapshot.Config.Features.Greeting,
+		Data:    map[string]int{"config_version": snapshot.Version},
+	})
+}
+
+// GetConfig handles GET /config - the configuration in effect, secrets
+// redacted, with the layer each setting came from
+func (h *AppHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		response.Error(w, http.StatusUnauthorized, "Admin token required")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: h.manager.Current()})
+}
+
+// ReloadConfig handles POST /config/reload - the same as sending SIGHUP,
+// but the caller sees the outcome
+func (h *AppHandler) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		response.Error(w, http.StatusUnauthorized, "Admin token required")
+		return
+	}
+	snapshot, err := h.manager.Reload()
+	if err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.APIResponse{
+			Error: err.Error(),
+			Data:  map[string]int{"running_version": snapshot.Version},
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: "Configuration reloaded",
+		Data:    snapshot,
+	})
+}
+
+// HealthCheck handles GET /health
+func (h *AppHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Config service is healthy"})
+}
+
+// authorized checks the bearer token against admin.token. With no token
+// configured, as in development, everyone is an admin.
+func (h *AppHandler) authorized(r *http.Request) bool {
+	token := h.manager.Current().Config.Admin.Token.Reveal()
+	if token == "" {
+		return true
+	}
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
@@ -0,0 +1,57 @@
+// Package middleware reads the current configuration on every request, so
+// a reload takes effect on the next one without restarting the server.
+package middleware
+
+import (
+	"net/http"
+
+	"golang.org/x/time/rate"
+
+	"github.com/e6a5/learning/backend/14-config-management/internal/config"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// Maintenance answers 503 while features.maintenance is on, except for the
+// paths operators need to turn it off again
+func Maintenance(manager *config.Manager, exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if manager.Current().Config.Features.Maintenance && !contains(exempt, r.URL.Path) {
+				w.Header().Set("Retry-After", "60")
+				response.Error(w, http.StatusServiceUnavailable, "Down for maintenance")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimit shares one token bucket between all clients. The limiter is
+// adjusted in place when the configuration changes, keeping its tokens.
+func RateLimit(manager *config.Manager) func(http.Handler) http.Handler {
+	cfg := manager.Current().Config.RateLimit
+	limiter := rate.NewLimiter(rate.Limit(cfg.RequestsPerSecond), cfg.Burst)
+	manager.OnChange(func(c *config.Config) {
+		limiter.SetLimit(rate.Limit(c.RateLimit.RequestsPerSecond))
+		limiter.SetBurst(c.RateLimit.Burst)
+	})
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !limiter.Allow() {
+				response.Error(w, http.StatusTooManyRequests, "Rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/14-config-management/internal/config"
+	"github.com/e6a5/learning/backend/14-config-management/internal/handlers"
+	"github.com/e6a5/learning/backend/14-config-management/internal/middleware"
+	"github.com/e6a5/learning/backend/pkg/env"
+	sharedmw "github.com/e6a5/learning/backend/pkg/middleware"
+)
+
+func main() {
+	// Load and validate everything before starting anything. A bad
+	// configuration stops the process here, listing every problem.
+	manager, err := config.NewManager(env.Get("CONFIG_FILE", "config/config.yaml"), os.Environ)
+	if err != nil {
+		log.Fatal("Failed to load configuration: ", err)
+	}
+	cfg := manager.Current().Config
+
+	// The level lives in a LevelVar so a reload can change it; the format
+	// picks the handler, which is why it needs a restart
+	var level slog.LevelVar
+	setLevel(&level, cfg.Log.Level)
+	options := &slog.HandlerOptions{Level: &level}
+	if cfg.Log.Format == "json" {
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, options)))
+	} else {
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, options)))
+	}
+	manager.OnChange(func(c *config.Config) { setLevel(&level, c.Log.Level) })
+
+	// The admin token prints as [REDACTED], however it is logged
+	slog.Info("Configuration loaded", "env", cfg.Env, "admin_token", cfg.Admin.Token, "sources", manager.Current().Sources)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		if err := manager.Watch(ctx); err != nil {
+			slog.Error("Config watch stopped, reload with POST /config/reload", "error", err)
+		}
+	}()
+
+	// Setup HTTP server from the settings that need a restart
+	appHandler := handlers.NewAppHandler(manager)
+	server := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:           setupRoutes(appHandler, manager),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout),
+	}
+	go func() {
+		log.Printf("🔧 Config management service running at http://localhost:%d", cfg.Server.Port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout))
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
+	log.Println("Server exited")
+}
+
+func setupRoutes(appHandler *handlers.AppHandler, manager *config.Manager) *mux.Router {
+	router := mux.NewRouter()
+	// Request logs are at debug level, so raising log.level to debug with a
+	// reload turns them on
+	router.Use(sharedmw.Logging(slog.Default(), slog.LevelDebug))
+	router.Use(middleware.Maintenance(manager, "/health", "/config", "/config/reload"))
+	router.Use(middleware.RateLimit(manager))
+
+	// The app
+	router.HandleFunc("/", appHandler.Greet).Methods("GET")
+
+	// Configuration
+	router.HandleFunc("/config", appHandler.GetConfig).Methods("GET")
+	router.HandleFunc("/config/reload", appHandler.ReloadConfig).Methods("POST")
+
+	// Health check
+	router.HandleFunc("/health", appHandler.HealthCheck).Methods("GET")
+
+	return router
+}
+
+// setLevel applies a validated log.level
+func setLevel(level *slog.LevelVar, name string) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		slog.Error("Ignoring log level", "level", name, "error", err)
+		return
+	}
+	level.Set(l)
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/models"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/repository"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/sender"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/templates"
+	"github.com/e6a5/learning/backend/pkg/response"
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// EmailHandler handles sending emails and following their delivery
+type EmailHandler struct {
+	sender    *sender.Sender
+	emails    *repository.EmailRepository
+	templates *templates.Templates
+}
+
+// NewEmailHandler creates a new email handler
+func NewEmailHandler(s *sender.Sender, emails *repository.EmailRepository, t *templates.Templates) *EmailHandler {
+	return &EmailHandler{sender: s, emails: emails, templates: t}
+}
+
+// SendEmail handles POST /emails - renders a template and queues it
+func (h *EmailHandler) SendEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.SendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	email, created, err := h.sender.Submit(req)
+	if err != nil {
+		respondSendError(w, req.Template, err)
+		return
+	}
+	if !created {
+		response.JSON(w, http.StatusOK, response.APIResponse{
+			Message: "Email already queued with this idempotency key",
+			Data:    email,
+		})
+		return
+	}
+	response.JSON(w, http.StatusAccepted, response.APIResponse{
+		Message: "Email queued",
+		Data:    email,
+	})
+}
+
+// GetEmails handles GET /emails - recent emails, ?status=failed and ?limit=n
+func (h *EmailHandler) GetEmails(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			response.Error(w, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		limit = n
+	}
+
+	emails := h.emails.List(r.URL.Query().Get("status"), limit)
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"emails": emails,
+			"count":  len(emails),
+			"counts": h.emails.Counts(),
+		},
+	})
+}
+
+// GetEmail handles GET /emails/{id} - one email's delivery status
+func (h *EmailHandler) GetEmail(w http.ResponseWriter, r *http.Request) {
+	email, err := h.emails.Get(mux.Vars(r)["id"])
+	if errors.Is(err, repository.ErrEmailNotFound) {
+		response.Error(w, http.StatusNotFound, "Email not found")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: email})
+}
+
+// GetTemplates handles GET /templates - every template and the data it needs
+func (h *EmailHandler) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: h.templates.List()})
+}
+
+// PreviewTemplate handles POST /templates/{name}/preview - renders a
+// template with the data in the body, without sending anything
+func (h *EmailHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	var data map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		response.Error(w, http.StatusBadRequest, "Body must be a JSON object of strings")
+		return
+	}
+
+	rendered, err := h.templates.Render(name, data)
+	if err != nil {
+		respondSendError(w, name, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: rendered})
+}
+
+// GetStats handles GET /stats - emails per status and the queue
+func (h *EmailHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"provider": h.sender.Provider(),
+			"queued":   h.sender.Queued(),
+			"counts":   h.emails.Counts(),
+		},
+	})
+}
+
+// HealthCheck handles GET /health
+func (h *EmailHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: "Email service is healthy",
+		Data:    map[string]string{"provider": h.sender.Provider()},
+	})
+}
+
+// respondSendError maps the errors of rendering and queueing to statuses
+func respondSendError(w http.ResponseWriter, template string, err error) {
+	var validationErr *validation.Error
+	switch {
+	case errors.Is(err, templates.ErrUnknownTemplate):
+		response.Error(w, http.StatusBadRequest, "Unknown template "+strconv.Quote(template))
+	case errors.As(err, &validationErr):
+		response.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, sender.ErrQueueFull):
+		w.Header().Set("Retry-After", "5")
+		response.Error(w, http.StatusServiceUnavailable, "Too many emails queued, try again shortly")
+	case errors.Is(err, sender.ErrClosed):
+		response.Error(w, http.StatusServiceUnavailable, "Shutting down, try again shortly")
+	default:
+		log.Printf("Error rendering %s: %v", template, err)
+		response.Error(w, http.StatusInternalServerError, "Failed to render email")
+	}
+}
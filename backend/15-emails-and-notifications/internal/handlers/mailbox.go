@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/mailbox"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// MailboxHandler lets tests read what was sent
+type MailboxHandler struct {
+	mailbox *mailbox.Mailbox
+}
+
+// NewMailboxHandler creates a new mailbox handler
+func NewMailboxHandler(mb *mailbox.Mailbox) *MailboxHandler {
+	return &MailboxHandler{mailbox: mb}
+}
+
+// GetMessages handles GET /mailbox - sent messages newest first, ?to= for
+// one recipient
+func (h *MailboxHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
+	messages := h.mailbox.List(r.URL.Query().Get("to"))
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"messages": messages,
+			"count":    len(messages),
+		},
+	})
+}
+
+// GetMessage handles GET /mailbox/{id} - the message sent for an email ID
+func (h *MailboxHandler) GetMessage(w http.ResponseWriter, r *http.Request) {
+	msg, err := h.mailbox.Get(mux.Vars(r)["id"])
+	if errors.Is(err, mailbox.ErrNotFound) {
+		response.Error(w, http.StatusNotFound, "Message not found, it may not be sent yet")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: msg})
+}
+
+// ClearMailbox handles DELETE /mailbox - empties it between tests
+func (h *MailboxHandler) ClearMailbox(w http.ResponseWriter, r *http.Request) {
+	n := h.mailbox.Clear()
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Deleted " + strconv.Itoa(n) + " messages"})
+}
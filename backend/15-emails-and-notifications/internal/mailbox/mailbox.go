@@ -0,0 +1,110 @@
+// Package mailbox keeps a copy of every email the service has sent, so
+// tests can ask "did Alice get a reset link, and what was it?" without
+// reading a real inbox. It holds the links themselves, so it is for
+// development and tests only.
+package mailbox
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/provider"
+)
+
+// ErrNotFound is returned for a message the mailbox does not hold
+var ErrNotFound = errors.New("message not found")
+
+// linkPattern finds links in the plain text body
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// Message is one sent email as the recipient would see it
+type Message struct {
+	ID         string    `json:"id"`
+	To         string    `json:"to"`
+	From       string    `json:"from"`
+	Subject    string    `json:"subject"`
+	Text       string    `json:"text"`
+	HTML       string    `json:"html"`
+	Links      []string  `json:"links"`
+	Provider   string    `json:"provider"`
+	ProviderID string    `json:"provider_id"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// Mailbox holds the most recent sent messages, oldest dropped first
+type Mailbox struct {
+	mu       sync.RWMutex
+	limit    int
+	messages []Message // oldest first
+}
+
+// New creates a mailbox that keeps up to limit messages
+func New(limit int) *Mailbox {
+	return &Mailbox{limit: limit}
+}
+
+// Record stores a message that was sent through provider
+func (m *Mailbox) Record(msg provider.Message, providerName, providerID string, sentAt time.Time) {
+	links := linkPattern.FindAllString(msg.Text, -1)
+	if links == nil {
+		links = []string{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.limit > 0 && len(m.messages) >= m.limit {
+		m.messages = m.messages[1:]
+	}
+	m.messages = append(m.messages, Message{
+		ID:         msg.ID,
+		To:         msg.To,
+		From:       msg.From,
+		Subject:    msg.Subject,
+		Text:       msg.Text,
+		HTML:       msg.HTML,
+		Links:      links,
+		Provider:   providerName,
+		ProviderID: providerID,
+		SentAt:     sentAt.UTC(),
+	})
+}
+
+// List returns the messages sent to to, or all of them when to is empty,
+// newest first
+func (m *Mailbox) List(to string) []Message {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := []Message{}
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if to == "" || strings.EqualFold(m.messages[i].To, to) {
+			list = append(list, m.messages[i])
+		}
+	}
+	return list
+}
+
+// Get returns one message by the ID of the email it came from
+func (m *Mailbox) Get(id string) (Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, msg := range m.messages {
+		if msg.ID == id {
+			return msg, nil
+		}
+	}
+	return Message{}, ErrNotFound
+}
+
+// Clear empties the mailbox, so a test starts from nothing
+func (m *Mailbox) Clear() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := len(m.messages)
+	m.messages = nil
+	return n
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"net/mail"
+	"time"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// Email statuses. A failed attempt with attempts left goes to retrying
+// until its next attempt is due.
+const (
+	StatusQueued   = "queued"
+	StatusSending  = "sending"
+	StatusRetrying = "retrying"
+	StatusSent     = "sent"
+	StatusFailed   = "failed" // out of attempts, or rejected for good
+)
+
+// DefaultMaxAttempts is how often an email is tried when the request says
+// nothing
+const DefaultMaxAttempts = 5
+
+// Email is one message to one recipient and everything known about its
+// delivery. The template data is not kept: it often holds a reset or
+// verification link, which must not be readable from GET /emails.
+type Email struct {
+	ID             string     `json:"id"`
+	To             string     `json:"to"`
+	Template       string     `json:"template"`
+	Subject        string     `json:"subject"`
+	IdempotencyKey string     `json:"idempotency_key,omitempty"`
+	Status         string     `json:"status"`
+	Provider       string     `json:"provider,omitempty"`
+	ProviderID     string     `json:"provider_id,omitempty"`
+	Attempts       int        `json:"attempts"`
+	MaxAttempts    int        `json:"max_attempts"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+}
+
+// Finished reports whether the email will never be tried again
+func (e Email) Finished() bool {
+	return e.Status == StatusSent || e.Status == StatusFailed
+}
+
+// SendRequest is the body of POST /emails. Data fills in the template;
+// a template that uses a key missing from data is rejected.
+type SendRequest struct {
+	To             string            `json:"to"`
+	Template       string            `json:"template"`
+	Data           map[string]string `json:"data"`
+	IdempotencyKey string            `json:"idempotency_key"`
+	MaxAttempts    int               `json:"max_attempts"`
+}
+
+// Validate validates a send request
+func (r SendRequest) Validate() error {
+	if r.To == "" {
+		return &validation.Error{Field: "to", Message: "To is required"}
+	}
+	if addr, err := mail.ParseAddress(r.To); err != nil || addr.Address != r.To {
+		return &validation.Error{Field: "to", Message: "To must be a bare address like alice@example.com"}
+	}
+	if r.Template == "" {
+		return &validation.Error{Field: "template", Message: "Template is required"}
+	}
+	if len(r.IdempotencyKey) > 100 {
+		return &validation.Error{Field: "idempotency_key", Message: "Idempotency key must be at most 100 characters"}
+	}
+	if r.MaxAttempts < 0 || r.MaxAttempts > 10 {
+		return &validation.Error{Field: "max_attempts", Message: "Max attempts must be 1-10, or 0 for the default"}
+	}
+	return nil
+}
+
+// NewEmail turns a validated request into a queued email
+func NewEmail(id string, r SendRequest, subject string, now time.Time) Email {
+	maxAttempts := r.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return Email{
+		ID:             id,
+		To:             r.To,
+		Template:       r.Template,
+		Subject:        subject,
+		IdempotencyKey: r.IdempotencyKey,
+		Status:         StatusQueued,
+		MaxAttempts:    maxAttempts,
+		CreatedAt:      now.UTC(),
+	}
+}
@@ -0,0 +1,78 @@
+// Package provider delivers rendered emails. Each way of sending, SMTP or
+// an HTTP API like SES, is a Provider, so the sender neither knows nor
+// cares which one it is talking to.
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Message is a rendered email, ready to send
+type Message struct {
+	ID      string // Our ID, sent as the Message-ID so bounces can be traced
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Provider sends messages. Send returns the provider's own ID for the
+// message, and an error wrapped with Permanent when retrying cannot help.
+type Provider interface {
+	Name() string
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// permanentError marks a failure that another attempt will not fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the email fails at once instead of being retried
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// Factory creates a provider from its settings
+type Factory func(getenv func(string, string) string) (Provider, error)
+
+// factories are the providers PROVIDER can name
+var factories = map[string]Factory{
+	"smtp":     newSMTPFromEnv,
+	"ses-mock": newSESMockFromEnv,
+}
+
+// New creates the provider called name, configured from getenv
+func New(name string, getenv func(string, string) string) (Provider, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q, want one of %v", name, Names())
+	}
+	return factory(getenv)
+}
+
+// Names lists the providers New knows
+func Names() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
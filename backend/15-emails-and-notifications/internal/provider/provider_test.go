@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMessage(t *testing.T) {
+	msg := Message{
+		ID:      "abc123",
+		From:    "Lab <no-reply@example.com>",
+		To:      "alice@example.com",
+		Subject: "Grüße\r\nBcc: mallory@example.com",
+		Text:    "Hello Alice",
+		HTML:    "<p>Hello Alice</p>",
+	}
+	body, err := buildMessage(msg, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	require.NoError(t, err)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(body)))
+	require.NoError(t, err)
+	assert.Equal(t, "<abc123@example.com>", parsed.Header.Get("Message-ID"))
+	assert.Empty(t, parsed.Header.Get("Bcc"), "the subject must not add headers")
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, msg.Subject, subject)
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(part) // NextPart decodes quoted-printable
+		require.NoError(t, err)
+		parts = append(parts, part.Header.Get("Content-Type")+": "+string(content))
+	}
+	assert.Equal(t, []string{
+		"text/plain; charset=utf-8: Hello Alice",
+		"text/html; charset=utf-8: <p>Hello Alice</p>",
+	}, parts)
+}
+
+func TestClassify(t *testing.T) {
+	assert.True(t, IsPermanent(classify(&textproto.Error{Code: 550, Msg: "no such user"})))
+	assert.False(t, IsPermanent(classify(&textproto.Error{Code: 451, Msg: "try again later"})))
+	assert.False(t, IsPermanent(classify(errors.New("connection reset"))))
+}
+
+func TestSESMock(t *testing.T) {
+	ses := NewSESMock(SESMockConfig{MaxPerSec: 2, Suppressed: []string{"gone@example.com"}})
+	ctx := context.Background()
+
+	_, err := ses.Send(ctx, Message{To: "Gone@example.com"})
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, ErrMessageRejected)
+
+	_, err = ses.Send(ctx, Message{To: "bounce@simulator.amazonses.com"})
+	assert.True(t, IsPermanent(err))
+
+	// Two a second, then throttled, which is worth retrying
+	for i := 0; i < 2; i++ {
+		id, err := ses.Send(ctx, Message{To: "alice@example.com"})
+		require.NoError(t, err)
+		assert.Len(t, id, 32)
+	}
+	_, err = ses.Send(ctx, Message{To: "alice@example.com"})
+	assert.ErrorIs(t, err, ErrThrottled)
+	assert.False(t, IsPermanent(err))
+}
+
+func TestNew(t *testing.T) {
+	getenv := func(key, defaultValue string) string { return defaultValue }
+
+	p, err := New("smtp", getenv)
+	require.NoError(t, err)
+	assert.Equal(t, "smtp", p.Name())
+
+	_, err = New("carrier-pigeon", getenv)
+	assert.ErrorContains(t, err, "ses-mock")
+}
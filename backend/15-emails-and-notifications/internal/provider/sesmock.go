@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors the SES mock answers with, modelled on the real API's
+var (
+	ErrThrottled       = errors.New("Throttling: Maximum sending rate exceeded")
+	ErrUnavailable     = errors.New("ServiceUnavailable: try again later")
+	ErrMessageRejected = errors.New("MessageRejected: address is on the suppression list")
+)
+
+// SESMockConfig shapes how the mock misbehaves
+type SESMockConfig struct {
+	Latency    time.Duration // per call, as an HTTP API would take
+	FailRate   float64       // share of calls that fail with ErrUnavailable
+	MaxPerSec  int           // sending quota; 0 is unlimited
+	Suppressed []string      // addresses that are always rejected
+}
+
+// SESMock behaves like an HTTP email API such as Amazon SES, without
+// sending anything: it takes time, enforces a sending rate, fails now and
+// then, and rejects suppressed addresses for good. Like SES's mailbox
+// simulator, it also rejects bounce@simulator.amazonses.com.
+type SESMock struct {
+	config     SESMockConfig
+	suppressed map[string]bool
+
+	mu          sync.Mutex
+	windowStart time.Time
+	inWindow    int
+}
+
+// NewSESMock creates an SES-style mock provider
+func NewSESMock(config SESMockConfig) *SESMock {
+	suppressed := map[string]bool{"bounce@simulator.amazonses.com": true}
+	for _, addr := range config.Suppressed {
+		suppressed[strings.ToLower(addr)] = true
+	}
+	return &SESMock{config: config, suppressed: suppressed}
+}
+
+func newSESMockFromEnv(getenv func(string, string) string) (Provider, error) {
+	latency, err := time.ParseDuration(getenv("SES_MOCK_LATENCY", "100ms"))
+	if err != nil {
+		return nil, fmt.Errorf("SES_MOCK_LATENCY must be a duration like 100ms: %w", err)
+	}
+	failRate, err := strconv.ParseFloat(getenv("SES_MOCK_FAIL_RATE", "0.1"), 64)
+	if err != nil || failRate < 0 || failRate > 1 {
+		return nil, fmt.Errorf("SES_MOCK_FAIL_RATE must be between 0 and 1")
+	}
+	maxPerSec, err := strconv.Atoi(getenv("SES_MOCK_MAX_PER_SECOND", "5"))
+	if err != nil || maxPerSec < 0 {
+		return nil, fmt.Errorf("SES_MOCK_MAX_PER_SECOND must be a whole number")
+	}
+	var suppressed []string
+	if list := getenv("SES_MOCK_SUPPRESSED", ""); list != "" {
+		suppressed = strings.Split(list, ",")
+	}
+	return NewSESMock(SESMockConfig{
+		Latency:    latency,
+		FailRate:   failRate,
+		MaxPerSec:  maxPerSec,
+		Suppressed: suppressed,
+	}), nil
+}
+
+// Name implements Provider
+func (s *SESMock) Name() string {
+	return "ses-mock"
+}
+
+// Send implements Provider
+func (s *SESMock) Send(ctx context.Context, msg Message) (string, error) {
+	select {
+	case <-time.After(s.config.Latency):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	if s.suppressed[strings.ToLower(msg.To)] {
+		return "", Permanent(ErrMessageRejected)
+	}
+	if !s.allow(time.Now()) {
+		return "", ErrThrottled
+	}
+	if mathrand.Float64() < s.config.FailRate {
+		return "", ErrUnavailable
+	}
+
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id), nil
+}
+
+// allow counts a call against the quota of the current second
+func (s *SESMock) allow(now time.Time) bool {
+	if s.config.MaxPerSec == 0 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.windowStart) >= time.Second {
+		s.windowStart = now
+		s.inWindow = 0
+	}
+	if s.inWindow >= s.config.MaxPerSec {
+		return false
+	}
+	s.inWindow++
+	return true
+}
@@ -0,0 +1,193 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// SMTPConfig is where and how to connect
+type SMTPConfig struct {
+	Addr     string // host:port
+	Username string // no authentication when empty
+	Password string
+}
+
+// SMTP sends through an SMTP server, such as Mailpit locally or a relay
+// like Postmark or SES's SMTP interface in production
+type SMTP struct {
+	config SMTPConfig
+}
+
+// NewSMTP creates an SMTP provider
+func NewSMTP(config SMTPConfig) *SMTP {
+	return &SMTP{config: config}
+}
+
+func newSMTPFromEnv(getenv func(string, string) string) (Provider, error) {
+	config := SMTPConfig{
+		Addr:     getenv("SMTP_ADDR", "localhost:1025"),
+		Username: getenv("SMTP_USERNAME", ""),
+		Password: getenv("SMTP_PASSWORD", ""),
+	}
+	if _, _, err := net.SplitHostPort(config.Addr); err != nil {
+		return nil, fmt.Errorf("SMTP_ADDR must be host:port: %w", err)
+	}
+	return NewSMTP(config), nil
+}
+
+// Name implements Provider
+func (s *SMTP) Name() string {
+	return "smtp"
+}
+
+// Send implements Provider. It opens a connection per message, which is
+// fine at this volume; a busy sender would keep a pool of them.
+func (s *SMTP) Send(ctx context.Context, msg Message) (string, error) {
+	host, _, _ := net.SplitHostPort(s.config.Addr)
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.config.Addr)
+	if err != nil {
+		return "", fmt.Errorf("connect to %s: %w", s.config.Addr, err)
+	}
+	// net/smtp knows nothing of contexts, so the deadline goes on the
+	// connection
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return "", classify(err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return "", fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if s.config.Username != "" {
+		// PlainAuth refuses to send the password without TLS, except to
+		// localhost
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, host)); err != nil {
+			return "", classify(err)
+		}
+	}
+
+	body, err := buildMessage(msg, time.Now())
+	if err != nil {
+		return "", Permanent(err)
+	}
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return "", Permanent(err)
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return "", classify(err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return "", classify(err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return "", classify(err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return "", err
+	}
+	// The server accepts or refuses the message when the data ends
+	if err := w.Close(); err != nil {
+		return "", classify(err)
+	}
+	_ = client.Quit()
+	return messageID(msg), nil
+}
+
+// classify marks 5xx replies as permanent. A 4xx, like a full mailbox or
+// greylisting, means try again later.
+func classify(err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return Permanent(err)
+	}
+	return err
+}
+
+// buildMessage writes msg as a MIME message with a plain text and an HTML
+// alternative. Mail clients show the last alternative they understand, so
+// HTML goes last.
+func buildMessage(msg Message, date time.Time) ([]byte, error) {
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	// Header values are encoded, so a subject cannot smuggle in a line
+	// break and add headers of its own
+	headers := []string{
+		"From: " + from.String(),
+		"To: " + msg.To,
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + date.Format(time.RFC1123Z),
+		"Message-ID: " + messageID(msg),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + mw.Boundary(),
+	}
+	for _, h := range headers {
+		if strings.ContainsAny(h, "\r\n") {
+			return nil, fmt.Errorf("header %q contains a line break", h)
+		}
+		buf.WriteString(h + "\r\n")
+	}
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messageID is the Message-ID header for msg, in the sender's domain
+func messageID(msg Message) string {
+	domain := "localhost"
+	if from, err := mail.ParseAddress(msg.From); err == nil {
+		domain = from.Address[strings.LastIndex(from.Address, "@")+1:]
+	}
+	return "<" + msg.ID + "@" + domain + ">"
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/models"
+)
+
+// ErrEmailNotFound is returned for an email this instance has never heard of
+var ErrEmailNotFound = errors.New("email not found")
+
+// EmailRepository keeps recent emails in memory. Once full it forgets the
+// oldest finished email; unfinished ones are never dropped.
+type EmailRepository struct {
+	mu     sync.RWMutex
+	limit  int
+	emails map[string]*models.Email
+	byKey  map[string]string // idempotency key to email ID
+}
+
+// NewEmailRepository creates a repository that remembers about limit emails
+func NewEmailRepository(limit int) *EmailRepository {
+	return &EmailRepository{
+		limit:  limit,
+		emails: make(map[string]*models.Email),
+		byKey:  make(map[string]string),
+	}
+}
+
+// Create stores a new email. If another email already has its idempotency
+// key, that one is returned instead with created false, so a client that
+// retries a request does not send the email twice.
+func (r *EmailRepository) Create(email models.Email) (models.Email, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if email.IdempotencyKey != "" {
+		if id, ok := r.byKey[email.IdempotencyKey]; ok {
+			return *r.emails[id], false
+		}
+		r.byKey[email.IdempotencyKey] = email.ID
+	}
+	r.evict()
+	r.emails[email.ID] = &email
+	return email, true
+}
+
+// Update changes one email under the lock and returns the result
+func (r *EmailRepository) Update(id string, fn func(*models.Email)) (models.Email, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	email, ok := r.emails[id]
+	if !ok {
+		return models.Email{}, ErrEmailNotFound
+	}
+	fn(email)
+	return *email, nil
+}
+
+// Delete forgets an email, and its idempotency key with it
+func (r *EmailRepository) Delete(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if email, ok := r.emails[id]; ok {
+		delete(r.emails, id)
+		if email.IdempotencyKey != "" {
+			delete(r.byKey, email.IdempotencyKey)
+		}
+	}
+}
+
+// evict drops the oldest finished email when the repository is full
+func (r *EmailRepository) evict() {
+	if r.limit <= 0 || len(r.emails) < r.limit {
+		return
+	}
+	var oldest *models.Email
+	for _, email := range r.emails {
+		if email.Finished() && (oldest == nil || email.CreatedAt.Before(oldest.CreatedAt)) {
+			oldest = email
+		}
+	}
+	if oldest == nil {
+		return
+	}
+	delete(r.emails, oldest.ID)
+	if oldest.IdempotencyKey != "" {
+		delete(r.byKey, oldest.IdempotencyKey)
+	}
+}
+
+// Get returns one email
+func (r *EmailRepository) Get(id string) (models.Email, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	email, ok := r.emails[id]
+	if !ok {
+		return models.Email{}, ErrEmailNotFound
+	}
+	return *email, nil
+}
+
+// List returns up to limit emails with the given status, or all of them
+// when status is empty, newest first
+func (r *EmailRepository) List(status string, limit int) []models.Email {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]models.Email, 0, len(r.emails))
+	for _, email := range r.emails {
+		if status == "" || email.Status == status {
+			list = append(list, *email)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+// Counts returns how many emails are in each status
+func (r *EmailRepository) Counts() map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, email := range r.emails {
+		counts[email.Status]++
+	}
+	return counts
+}
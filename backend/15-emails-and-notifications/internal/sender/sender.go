@@ -0,0 +1,234 @@
+// Package sender queues emails and delivers them through a provider. An
+// email is rendered once when it is submitted; workers then try to send
+// it, retrying with backoff, until it is sent, rejected for good, or out
+// of attempts.
+package sender
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/mailbox"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/models"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/provider"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/repository"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/templates"
+)
+
+// Errors Submit returns besides template and validation errors
+var (
+	ErrQueueFull = errors.New("queue is full")
+	ErrClosed    = errors.New("sender is closed")
+)
+
+// Config tunes a Sender
+type Config struct {
+	From      string        // The From header, like "Lab <no-reply@example.com>"
+	Workers   int           // Emails sent at once
+	QueueSize int           // Emails waiting before Submit refuses more
+	Timeout   time.Duration // Time allowed for one attempt
+	Backoff   Backoff
+}
+
+// Sender owns the queue and the workers that drain it
+type Sender struct {
+	config    Config
+	provider  provider.Provider
+	templates *templates.Templates
+	emails    *repository.EmailRepository
+	mailbox   *mailbox.Mailbox // nil when disabled
+
+	queue     chan string // IDs of emails due for an attempt
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	mu       sync.Mutex
+	messages map[string]provider.Message // rendered, until finished
+}
+
+// New creates a sender; nothing is sent until Run. mb may be nil.
+func New(config Config, p provider.Provider, t *templates.Templates, emails *repository.EmailRepository, mb *mailbox.Mailbox) *Sender {
+	return &Sender{
+		config:    config,
+		provider:  p,
+		templates: t,
+		emails:    emails,
+		mailbox:   mb,
+		queue:     make(chan string, config.QueueSize),
+		closed:    make(chan struct{}),
+		messages:  make(map[string]provider.Message),
+	}
+}
+
+// Provider returns the name of the provider emails go through
+func (s *Sender) Provider() string {
+	return s.provider.Name()
+}
+
+// Queued returns how many emails are waiting for a worker
+func (s *Sender) Queued() int {
+	return len(s.queue)
+}
+
+// Submit renders and queues an email. Rendering here, not in the worker,
+// means bad template data is the caller's 400 rather than a failed email
+// nobody notices. created is false when the idempotency key matched an
+// earlier email, which is returned instead.
+func (s *Sender) Submit(req models.SendRequest) (email models.Email, created bool, err error) {
+	select {
+	case <-s.closed:
+		return models.Email{}, false, ErrClosed
+	default:
+	}
+
+	rendered, err := s.templates.Render(req.Template, req.Data)
+	if err != nil {
+		return models.Email{}, false, err
+	}
+
+	email, created = s.emails.Create(models.NewEmail(NewID(), req, rendered.Subject, time.Now()))
+	if !created {
+		return email, false, nil
+	}
+
+	s.mu.Lock()
+	s.messages[email.ID] = provider.Message{
+		ID:      email.ID,
+		From:    s.config.From,
+		To:      email.To,
+		Subject: rendered.Subject,
+		Text:    rendered.Text,
+		HTML:    rendered.HTML,
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.queue <- email.ID:
+		return email, true, nil
+	default:
+		// Refusing now lets the caller retry later with the same key; the
+		// alternative is an unbounded queue that only fails when memory does
+		s.forget(email.ID)
+		s.emails.Delete(email.ID)
+		return models.Email{}, false, ErrQueueFull
+	}
+}
+
+// Run starts the workers and blocks until ctx is done and every attempt in
+// progress has finished
+func (s *Sender) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < s.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-s.queue:
+					s.attempt(id)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Close refuses new emails and abandons pending retries. Emails still
+// queued are lost: this queue lives in memory, unlike the one in
+// 13-background-jobs.
+func (s *Sender) Close() {
+	s.closeOnce.Do(func() { close(s.closed) })
+}
+
+// attempt sends an email once and decides what happens next
+func (s *Sender) attempt(id string) {
+	s.mu.Lock()
+	msg, ok := s.messages[id]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	email, err := s.emails.Update(id, func(e *models.Email) {
+		e.Status = models.StatusSending
+		e.Attempts++
+		e.NextAttemptAt = nil
+	})
+	if err != nil {
+		s.forget(id)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	providerID, err := s.provider.Send(ctx, msg)
+	cancel()
+	now := time.Now().UTC()
+
+	switch {
+	case err == nil:
+		s.finish(id, func(e *models.Email) {
+			e.Status = models.StatusSent
+			e.Provider = s.provider.Name()
+			e.ProviderID = providerID
+			e.LastError = ""
+			e.SentAt = &now
+		})
+		if s.mailbox != nil {
+			s.mailbox.Record(msg, s.provider.Name(), providerID, now)
+		}
+		log.Printf("Sent email %s (%s) to %s", id, email.Template, email.To)
+
+	case provider.IsPermanent(err) || email.Attempts >= email.MaxAttempts:
+		s.finish(id, func(e *models.Email) {
+			e.Status = models.StatusFailed
+			e.LastError = err.Error()
+		})
+		log.Printf("Email %s failed after %d attempts: %v", id, email.Attempts, err)
+
+	default:
+		delay := s.config.Backoff.Delay(email.Attempts)
+		next := now.Add(delay)
+		_, _ = s.emails.Update(id, func(e *models.Email) {
+			e.Status = models.StatusRetrying
+			e.LastError = err.Error()
+			e.NextAttemptAt = &next
+		})
+		log.Printf("Email %s attempt %d failed, retrying in %s: %v", id, email.Attempts, delay, err)
+		time.AfterFunc(delay, func() { s.requeue(id) })
+	}
+}
+
+// requeue puts a retry back in line. Retries wait for room rather than
+// being refused, since they were accepted once already.
+func (s *Sender) requeue(id string) {
+	select {
+	case s.queue <- id:
+	case <-s.closed:
+	}
+}
+
+// finish records the final state and drops the rendered message
+func (s *Sender) finish(id string, fn func(*models.Email)) {
+	_, _ = s.emails.Update(id, fn)
+	s.forget(id)
+}
+
+func (s *Sender) forget(id string) {
+	s.mu.Lock()
+	delete(s.messages, id)
+	s.mu.Unlock()
+}
+
+// NewID returns a random email ID
+func NewID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
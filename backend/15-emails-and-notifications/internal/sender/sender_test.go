@@ -0,0 +1,202 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/mailbox"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/models"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/provider"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/repository"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/templates"
+)
+
+// fakeProvider fails the first failures calls with err, then succeeds
+type fakeProvider struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	calls    int
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) Send(ctx context.Context, msg provider.Message) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.calls <= p.failures {
+		return "", p.err
+	}
+	return "fake-" + msg.ID, nil
+}
+
+func (p *fakeProvider) Calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func newTestSender(t *testing.T, p provider.Provider, queueSize int) (*Sender, *repository.EmailRepository, *mailbox.Mailbox) {
+	t.Helper()
+	tmpl, err := templates.Load()
+	require.NoError(t, err)
+	emails := repository.NewEmailRepository(100)
+	mb := mailbox.New(100)
+	s := New(Config{
+		From:      "Lab <no-reply@example.com>",
+		Workers:   2,
+		QueueSize: queueSize,
+		Timeout:   time.Second,
+		Backoff:   Backoff{Base: 10 * time.Millisecond, Max: 50 * time.Millisecond},
+	}, p, tmpl, emails, mb)
+	return s, emails, mb
+}
+
+func run(t *testing.T, s *Sender) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		s.Close()
+		cancel()
+		<-done
+	})
+}
+
+func resetRequest(to string) models.SendRequest {
+	return models.SendRequest{
+		To:       to,
+		Template: "password_reset",
+		Data: map[string]string{
+			"name":       "Alice",
+			"link":       "http://localhost:8081/reset?token=abc",
+			"expires_in": "30 minutes",
+		},
+	}
+}
+
+func waitForStatus(t *testing.T, emails *repository.EmailRepository, id, status string) models.Email {
+	t.Helper()
+	var email models.Email
+	require.Eventually(t, func() bool {
+		email, _ = emails.Get(id)
+		return email.Status == status
+	}, 2*time.Second, 5*time.Millisecond)
+	return email
+}
+
+func TestSendRecordsInMailbox(t *testing.T) {
+	s, emails, mb := newTestSender(t, &fakeProvider{}, 10)
+	run(t, s)
+
+	email, created, err := s.Submit(resetRequest("alice@example.com"))
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "Reset your password", email.Subject)
+
+	sent := waitForStatus(t, emails, email.ID, models.StatusSent)
+	assert.Equal(t, 1, sent.Attempts)
+	assert.Equal(t, "fake-"+email.ID, sent.ProviderID)
+
+	messages := mb.List("alice@example.com")
+	require.Len(t, messages, 1)
+	assert.Equal(t, []string{"http://localhost:8081/reset?token=abc"}, messages[0].Links)
+}
+
+func TestRetriesTemporaryFailures(t *testing.T) {
+	p := &fakeProvider{failures: 2, err: errors.New("connection refused")}
+	s, emails, _ := newTestSender(t, p, 10)
+	run(t, s)
+
+	email, _, err := s.Submit(resetRequest("alice@example.com"))
+	require.NoError(t, err)
+
+	sent := waitForStatus(t, emails, email.ID, models.StatusSent)
+	assert.Equal(t, 3, sent.Attempts)
+	assert.Empty(t, sent.LastError)
+}
+
+func TestGivesUpAfterMaxAttempts(t *testing.T) {
+	p := &fakeProvider{failures: 100, err: errors.New("connection refused")}
+	s, emails, mb := newTestSender(t, p, 10)
+	run(t, s)
+
+	req := resetRequest("alice@example.com")
+	req.MaxAttempts = 3
+	email, _, err := s.Submit(req)
+	require.NoError(t, err)
+
+	failed := waitForStatus(t, emails, email.ID, models.StatusFailed)
+	assert.Equal(t, 3, failed.Attempts)
+	assert.Equal(t, "connection refused", failed.LastError)
+	assert.Equal(t, 3, p.Calls())
+	assert.Empty(t, mb.List(""))
+}
+
+func TestPermanentFailureIsNotRetried(t *testing.T) {
+	p := &fakeProvider{failures: 100, err: provider.Permanent(errors.New("550 no such user"))}
+	s, emails, _ := newTestSender(t, p, 10)
+	run(t, s)
+
+	email, _, err := s.Submit(resetRequest("nobody@example.com"))
+	require.NoError(t, err)
+
+	failed := waitForStatus(t, emails, email.ID, models.StatusFailed)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, 1, p.Calls())
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	s, _, _ := newTestSender(t, &fakeProvider{}, 10)
+
+	req := resetRequest("alice@example.com")
+	req.IdempotencyKey = "reset-42"
+	first, created, err := s.Submit(req)
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	second, created, err := s.Submit(req)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, first.ID, second.ID)
+	assert.Equal(t, 1, s.Queued())
+}
+
+func TestSubmitErrors(t *testing.T) {
+	// No workers run, so the queue fills up
+	s, emails, _ := newTestSender(t, &fakeProvider{}, 1)
+
+	_, _, err := s.Submit(models.SendRequest{To: "alice@example.com", Template: "nope"})
+	assert.ErrorIs(t, err, templates.ErrUnknownTemplate)
+
+	_, _, err = s.Submit(resetRequest("alice@example.com"))
+	require.NoError(t, err)
+	req := resetRequest("bob@example.com")
+	req.IdempotencyKey = "bob"
+	_, _, err = s.Submit(req)
+	assert.ErrorIs(t, err, ErrQueueFull)
+	// The refused email is forgotten, so the client's retry is not a duplicate
+	assert.Len(t, emails.List("", 10), 1)
+
+	s.Close()
+	_, _, err = s.Submit(req)
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Base: time.Second, Max: 5 * time.Second}
+	assert.Equal(t, time.Second, b.Delay(1))
+	assert.Equal(t, 2*time.Second, b.Delay(2))
+	assert.Equal(t, 4*time.Second, b.Delay(3))
+	assert.Equal(t, 5*time.Second, b.Delay(4))
+}
@@ -0,0 +1,185 @@
+// Package templates renders emails from the files in files/. Every email
+// has two files: name.txt defines the subject and the plain text body,
+// name.html the "content" block of the shared HTML layout. Both get the
+// same data, a map of strings from the send request.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"io/fs"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+//go:embed files
+var files embed.FS
+
+// ErrUnknownTemplate is returned for a template name with no files
+var ErrUnknownTemplate = errors.New("unknown template")
+
+// Rendered is an email ready to send
+type Rendered struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// Info describes a template for GET /templates
+type Info struct {
+	Name   string   `json:"name"`
+	Fields []string `json:"fields"`
+}
+
+// template is one parsed email
+type template struct {
+	text   *texttemplate.Template
+	html   *htmltemplate.Template
+	fields []string
+}
+
+// Templates holds every email the service can send
+type Templates struct {
+	byName map[string]*template
+}
+
+// Load parses every template in files/. It fails on a broken template, so
+// a typo stops the service at startup rather than the first send.
+func Load() (*Templates, error) {
+	names, err := fs.Glob(files, "files/*.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Templates{byName: make(map[string]*template)}
+	for _, path := range names {
+		name := strings.TrimSuffix(strings.TrimPrefix(path, "files/"), ".txt")
+
+		text, err := texttemplate.New(name+".txt").Option("missingkey=error").ParseFS(files, path)
+		if err != nil {
+			return nil, err
+		}
+		if text.Lookup("subject") == nil {
+			return nil, errors.New(path + ` does not define "subject"`)
+		}
+		html, err := htmltemplate.New("layout.html").Option("missingkey=error").ParseFS(files, "files/layout.html", "files/"+name+".html")
+		if err != nil {
+			return nil, err
+		}
+
+		// The fields a template needs are whatever keys either file uses
+		seen := make(map[string]bool)
+		for _, tmpl := range text.Templates() {
+			collectFields(tmpl.Tree.Root, seen)
+		}
+		collectFields(html.Lookup("content").Tree.Root, seen)
+		fields := make([]string, 0, len(seen))
+		for field := range seen {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		t.byName[name] = &template{text: text, html: html, fields: fields}
+	}
+	return t, nil
+}
+
+// List describes every template, by name
+func (t *Templates) List() []Info {
+	list := make([]Info, 0, len(t.byName))
+	for name, tmpl := range t.byName {
+		list = append(list, Info{Name: name, Fields: tmpl.fields})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Render fills in the named template. Every field the template uses must
+// be in data; a missing one is a validation error naming all of them.
+func (t *Templates) Render(name string, data map[string]string) (Rendered, error) {
+	tmpl, ok := t.byName[name]
+	if !ok {
+		return Rendered{}, ErrUnknownTemplate
+	}
+
+	var missing []string
+	for _, field := range tmpl.fields {
+		if _, ok := data[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return Rendered{}, &validation.Error{Field: "data", Message: "Missing " + strings.Join(missing, ", ")}
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Rendered{}, err
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return Rendered{}, err
+	}
+
+	// The layout shows the subject as the page title
+	htmlData := make(map[string]string, len(data)+1)
+	for k, v := range data {
+		htmlData[k] = v
+	}
+	htmlData["subject"] = subject.String()
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", htmlData); err != nil {
+		return Rendered{}, err
+	}
+
+	return Rendered{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// collectFields adds the top-level keys, such as "link" in {{.link}}, used
+// anywhere under node
+func collectFields(node parse.Node, seen map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectFields(child, seen)
+		}
+	case *parse.ActionNode:
+		collectFields(n.Pipe, seen)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			for _, arg := range cmd.Args {
+				collectFields(arg, seen)
+			}
+		}
+	case *parse.FieldNode:
+		seen[n.Ident[0]] = true
+	case *parse.IfNode:
+		collectFields(n.Pipe, seen)
+		collectFields(n.List, seen)
+		collectFields(n.ElseList, seen)
+	case *parse.RangeNode:
+		collectFields(n.Pipe, seen)
+		collectFields(n.List, seen)
+		collectFields(n.ElseList, seen)
+	case *parse.WithNode:
+		collectFields(n.Pipe, seen)
+		collectFields(n.List, seen)
+		collectFields(n.ElseList, seen)
+	case *parse.TemplateNode:
+		collectFields(n.Pipe, seen)
+	}
+}
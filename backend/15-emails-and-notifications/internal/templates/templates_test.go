@@ -0,0 +1,72 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+func TestLoad(t *testing.T) {
+	tmpl, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, []Info{
+		{Name: "password_reset", Fields: []string{"expires_in", "link", "name"}},
+		{Name: "verify_email", Fields: []string{"link", "name"}},
+		{Name: "welcome", Fields: []string{"link", "name"}},
+	}, tmpl.List())
+}
+
+func TestRender(t *testing.T) {
+	tmpl, err := Load()
+	require.NoError(t, err)
+
+	rendered, err := tmpl.Render("password_reset", map[string]string{
+		"name":       "Alice",
+		"link":       "https://example.com/reset?token=abc",
+		"expires_in": "30 minutes",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Reset your password", rendered.Subject)
+	assert.Contains(t, rendered.Text, "Hi Alice,")
+	assert.Contains(t, rendered.Text, "https://example.com/reset?token=abc")
+	assert.Contains(t, rendered.HTML, `href="https://example.com/reset?token=abc"`)
+	assert.Contains(t, rendered.HTML, "<title>Reset your password</title>")
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	tmpl, err := Load()
+	require.NoError(t, err)
+
+	rendered, err := tmpl.Render("welcome", map[string]string{
+		"name": "<script>alert(1)</script>",
+		"link": "javascript:alert(1)",
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, rendered.HTML, "<script>")
+	assert.NotContains(t, rendered.HTML, `href="javascript:`)
+	// Plain text is not HTML, so it is left alone
+	assert.Contains(t, rendered.Text, "<script>")
+}
+
+func TestRenderMissingData(t *testing.T) {
+	tmpl, err := Load()
+	require.NoError(t, err)
+
+	_, err = tmpl.Render("password_reset", map[string]string{"name": "Alice"})
+	var validationErr *validation.Error
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "data", validationErr.Field)
+	assert.Equal(t, "Missing expires_in, link", validationErr.Message)
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	tmpl, err := Load()
+	require.NoError(t, err)
+
+	_, err = tmpl.Render("nope", nil)
+	assert.ErrorIs(t, err, ErrUnknownTemplate)
+}
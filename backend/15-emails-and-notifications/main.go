@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/mail"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/handlers"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/mailbox"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/models"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/provider"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/repository"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/sender"
+	"github.com/e6a5/learning/backend/15-emails-and-notifications/internal/templates"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+func main() {
+	// Broken templates or settings stop the service here, not at the first send
+	tmpl, err := templates.Load()
+	if err != nil {
+		log.Fatal("Failed to load templates: ", err)
+	}
+	p, err := provider.New(env.Get("PROVIDER", "ses-mock"), env.Get)
+	if err != nil {
+		log.Fatal("Failed to create provider: ", err)
+	}
+	from := env.Get("MAIL_FROM", "Learning Lab <no-reply@example.com>")
+	if _, err := mail.ParseAddress(from); err != nil {
+		log.Fatalf("MAIL_FROM must be an address like \"Lab <no-reply@example.com>\": %v", err)
+	}
+
+	// The mailbox holds every link sent, reset links included, so it can be
+	// turned off outside development
+	var mb *mailbox.Mailbox
+	if getEnvBool("MAILBOX_ENABLED", true) {
+		mb = mailbox.New(getEnvInt("MAILBOX_LIMIT", 500))
+	}
+
+	emailRepo := repository.NewEmailRepository(getEnvInt("EMAIL_LIMIT", 1000))
+	s := sender.New(sender.Config{
+		From:      from,
+		Workers:   getEnvInt("WORKERS", 4),
+		QueueSize: getEnvInt("QUEUE_SIZE", 100),
+		Timeout:   getEnvDuration("SEND_TIMEOUT", 10*time.Second),
+		Backoff: sender.Backoff{
+			Base: getEnvDuration("RETRY_BASE_DELAY", time.Second),
+			Max:  getEnvDuration("RETRY_MAX_DELAY", time.Minute),
+		},
+	}, p, tmpl, emailRepo, mb)
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	workersDone := make(chan struct{})
+	go func() {
+		s.Run(workersCtx)
+		close(workersDone)
+	}()
+
+	// Setup HTTP server
+	emailHandler := handlers.NewEmailHandler(s, emailRepo, tmpl)
+	var mailboxHandler *handlers.MailboxHandler
+	if mb != nil {
+		mailboxHandler = handlers.NewMailboxHandler(mb)
+	}
+	port := env.Get("PORT", "8080")
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           setupRoutes(emailHandler, mailboxHandler),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Printf("📧 Email service running at http://localhost:%s, sending through %s", port, p.Name())
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	sig, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-sig.Done()
+
+	log.Println("Shutting down server...")
+	s.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
+
+	// Sends in progress finish; queued and retrying emails are lost
+	stopWorkers()
+	<-workersDone
+	counts := emailRepo.Counts()
+	if unsent := counts[models.StatusQueued] + counts[models.StatusRetrying]; unsent > 0 {
+		log.Printf("Exiting with %d emails unsent", unsent)
+	}
+	log.Println("Server exited")
+}
+
+func setupRoutes(emailHandler *handlers.EmailHandler, mailboxHandler *handlers.MailboxHandler) *mux.Router {
+	router := mux.NewRouter()
+
+	// Emails
+	router.HandleFunc("/emails", emailHandler.SendEmail).Methods("POST")
+	router.HandleFunc("/emails", emailHandler.GetEmails).Methods("GET")
+	router.HandleFunc("/emails/{id}", emailHandler.GetEmail).Methods("GET")
+
+	// Templates
+	router.HandleFunc("/templates", emailHandler.GetTemplates).Methods("GET")
+	router.HandleFunc("/templates/{name}/preview", emailHandler.PreviewTemplate).Methods("POST")
+
+	// Local mailbox, when enabled
+	if mailboxHandler != nil {
+		router.HandleFunc("/mailbox", mailboxHandler.GetMessages).Methods("GET")
+		router.HandleFunc("/mailbox", mailboxHandler.ClearMailbox).Methods("DELETE")
+		router.HandleFunc("/mailbox/{id}", mailboxHandler.GetMessage).Methods("GET")
+	}
+
+	// Stats and health check
+	router.HandleFunc("/stats", emailHandler.GetStats).Methods("GET")
+	router.HandleFunc("/health", emailHandler.HealthCheck).Methods("GET")
+
+	return router
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(env.Get(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		log.Fatalf("%s must be a number: %v", key, err)
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(env.Get(key, defaultValue.String()))
+	if err != nil {
+		log.Fatalf("%s must be a duration like 2s: %v", key, err)
+	}
+	return value
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(env.Get(key, strconv.FormatBool(defaultValue)))
+	if err != nil {
+		log.Fatalf("%s must be true or false: %v", key, err)
+	}
+	return value
+}
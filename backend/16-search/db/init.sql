@@ -0,0 +1,102 @@
+-- Authors; their name is copied into every document in the search index
+CREATE TABLE IF NOT EXISTS users (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    email VARCHAR(100) NOT NULL UNIQUE
+);
+
+-- The source of truth. Only published documents are searchable.
+CREATE TABLE IF NOT EXISTS documents (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    author_id INT NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    body TEXT NOT NULL,
+    tags JSON NOT NULL,
+    status ENUM('draft', 'published') NOT NULL DEFAULT 'published',
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (author_id) REFERENCES users(id)
+);
+
+-- Documents whose index entry may be stale. Triggers fill it, so every
+-- change is caught, including ones made by hand in a SQL client; the
+-- indexer empties it.
+CREATE TABLE IF NOT EXISTS search_outbox (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    document_id INT NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE TRIGGER documents_insert AFTER INSERT ON documents
+FOR EACH ROW INSERT INTO search_outbox (document_id) VALUES (NEW.id);
+
+CREATE TRIGGER documents_update AFTER UPDATE ON documents
+FOR EACH ROW INSERT INTO search_outbox (document_id) VALUES (NEW.id);
+
+CREATE TRIGGER documents_delete AFTER DELETE ON documents
+FOR EACH ROW INSERT INTO search_outbox (document_id) VALUES (OLD.id);
+
+-- Renaming an author changes every document they wrote, as far as the
+-- index is concerned: the price of denormalising
+CREATE TRIGGER users_update AFTER UPDATE ON users
+FOR EACH ROW INSERT INTO search_outbox (document_id)
+    SELECT id FROM documents WHERE author_id = NEW.id AND NEW.name <> OLD.name;
+
+INSERT INTO users (name, email) VALUES
+    ('Ada Lovelace', 'ada@example.com'),
+    ('Grace Hopper', 'grace@example.com'),
+    ('Ken Thompson', 'ken@example.com'),
+    ('Barbara Liskov', 'barbara@example.com');
+
+INSERT INTO documents (author_id, title, body, tags, status, created_at) VALUES
+    (1, 'Getting started with Go HTTP servers',
+     'The net/http package is enough to build a production web server. Handlers, middleware and graceful shutdown are covered step by step.',
+     '["go", "http"]', 'published', '2024-01-10 09:00:00'),
+    (2, 'Connection pooling in MySQL',
+     'Opening a connection per request is slow. database/sql keeps a pool; tuning max open and idle connections matters under load.',
+     '["mysql", "performance"]', 'published', '2024-01-22 14:30:00'),
+    (3, 'Caching strategies with Redis',
+     'Cache-aside, write-through and write-behind each trade consistency for speed. Expiry and stampede protection keep the cache honest.',
+     '["redis", "caching", "performance"]', 'published', '2024-02-05 11:15:00'),
+    (4, 'Designing gRPC services',
+     'Protocol buffers define the contract. Streaming RPCs suit large or long-running transfers better than unary calls.',
+     '["grpc", "api"]', 'published', '2024-02-18 16:45:00'),
+    (1, 'Table-driven tests in Go',
+     'A slice of cases and one loop: table-driven tests make it cheap to add the edge case you just found. Subtests give each a name.',
+     '["go", "testing"]', 'published', '2024-03-02 10:00:00'),
+    (2, 'Storing passwords safely',
+     'Never store passwords in plain text. Use a slow, salted hash such as bcrypt or argon2, and rehash when the cost goes up.',
+     '["security", "auth"]', 'published', '2024-03-15 08:20:00'),
+    (3, 'Retries, backoff and circuit breakers',
+     'Retrying a failing dependency can make an outage worse. Exponential backoff with jitter and a circuit breaker give it room to recover.',
+     '["resilience", "errors"]', 'published', '2024-04-01 13:00:00'),
+    (4, 'Metrics that matter',
+     'Rate, errors and duration describe most services. Histograms beat averages for latency, and every metric needs an owner.',
+     '["monitoring", "performance"]', 'published', '2024-04-20 17:30:00'),
+    (1, 'Running WebSockets at scale',
+     'Each connection is a goroutine and a socket. Heartbeats find dead peers, and a broker spreads messages across instances.',
+     '["websockets", "go", "scaling"]', 'published', '2024-05-06 12:00:00'),
+    (2, 'Dead letter queues explained',
+     'A message that keeps failing should not block the queue. Dead-lettering parks it for a human and keeps the rest moving.',
+     '["queues", "rabbitmq", "errors"]', 'published', '2024-05-25 09:40:00'),
+    (3, 'Solving N+1 queries in GraphQL',
+     'Resolvers that fetch one row each turn a list into hundreds of queries. Dataloaders batch them into one.',
+     '["graphql", "performance", "mysql"]', 'published', '2024-06-11 15:10:00'),
+    (4, 'Streaming file uploads',
+     'Buffering uploads in memory does not scale. Streaming multipart bodies straight to object storage keeps memory flat.',
+     '["uploads", "storage", "http"]', 'published', '2024-06-30 10:25:00'),
+    (1, 'Graceful shutdown for background workers',
+     'Stop taking work, let running jobs finish, then cancel what is left. Persisted jobs are recovered on the next start.',
+     '["go", "jobs", "resilience"]', 'published', '2024-07-14 11:50:00'),
+    (2, 'Hot reloading configuration',
+     'Layered config from defaults, files and the environment, validated as a whole and swapped atomically on SIGHUP.',
+     '["config", "go"]', 'published', '2024-08-02 14:05:00'),
+    (3, 'Sending email reliably',
+     'Templates rendered up front, a queue with retries, and a clear line between temporary and permanent failures.',
+     '["email", "queues"]', 'published', '2024-08-21 16:35:00'),
+    (4, 'Full-text search with inverted indexes',
+     'Search engines split text into terms, stem them and map each term to the documents that contain it. Relevance comes from BM25.',
+     '["search", "elasticsearch"]', 'published', '2024-09-09 09:15:00'),
+    (1, 'Draft: notes on event sourcing',
+     'Storing events instead of state. Not ready to publish yet, so it must not show up in search.',
+     '["events", "architecture"]', 'draft', '2024-09-30 18:00:00');
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/16-search/internal/models"
+	"github.com/e6a5/learning/backend/16-search/internal/repository"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// DocumentHandler handles writes to MySQL. It never touches the index:
+// the indexer picks every change up from the outbox.
+type DocumentHandler struct {
+	docs *repository.DocumentRepository
+}
+
+// NewDocumentHandler creates a new document handler
+func NewDocumentHandler(docs *repository.DocumentRepository) *DocumentHandler {
+	return &DocumentHandler{docs: docs}
+}
+
+// CreateDocument handles POST /documents
+func (h *DocumentHandler) CreateDocument(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeDocument(w, r)
+	if !ok {
+		return
+	}
+	doc, err := h.docs.Create(r.Context(), req)
+	if err != nil {
+		respondDocumentError(w, err, "create")
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{
+		Message: "Document created, searchable within seconds",
+		Data:    doc,
+	})
+}
+
+// GetDocument handles GET /documents/{id} - straight from MySQL
+func (h *DocumentHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+	doc, err := h.docs.Get(r.Context(), id)
+	if err != nil {
+		respondDocumentError(w, err, "get")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: doc})
+}
+
+// UpdateDocument handles PUT /documents/{id}
+func (h *DocumentHandler) UpdateDocument(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeDocument(w, r)
+	if !ok {
+		return
+	}
+	doc, err := h.docs.Update(r.Context(), id, req)
+	if err != nil {
+		respondDocumentError(w, err, "update")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: "Document updated",
+		Data:    doc,
+	})
+}
+
+// DeleteDocument handles DELETE /documents/{id}
+func (h *DocumentHandler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.docs.Delete(r.Context(), id); err != nil {
+		respondDocumentError(w, err, "delete")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Document deleted"})
+}
+
+// RenameAuthor handles PUT /authors/{id} - {"name": "..."}; every document
+// by the author is reindexed
+func (h *DocumentHandler) RenameAuthor(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if req.Name = strings.TrimSpace(req.Name); req.Name == "" || len(req.Name) > 100 {
+		response.Error(w, http.StatusBadRequest, "name: Name is required and must be at most 100 characters")
+		return
+	}
+	if err := h.docs.RenameAuthor(r.Context(), id, req.Name); err != nil {
+		respondDocumentError(w, err, "rename author")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Author renamed, their documents are being reindexed"})
+}
+
+func decodeDocument(w http.ResponseWriter, r *http.Request) (models.DocumentRequest, bool) {
+	var req models.DocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON")
+		return req, false
+	}
+	if err := req.Validate(); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return req, false
+	}
+	return req, true
+}
+
+func parseID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func respondDocumentError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, repository.ErrDocumentNotFound):
+		response.Error(w, http.StatusNotFound, "Document not found")
+	case errors.Is(err, repository.ErrAuthorNotFound):
+		response.Error(w, http.StatusBadRequest, "Author not found")
+	default:
+		log.Printf("Error trying to %s: %v", action, err)
+		response.Error(w, http.StatusInternalServerError, "Failed to "+action)
+	}
+}
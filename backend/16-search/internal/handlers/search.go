@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/e6a5/learning/backend/16-search/internal/indexer"
+	"github.com/e6a5/learning/backend/16-search/internal/models"
+	"github.com/e6a5/learning/backend/16-search/internal/repository"
+	"github.com/e6a5/learning/backend/16-search/internal/search"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// SearchHandler handles searching and managing the index
+type SearchHandler struct {
+	index   *search.Index
+	client  *search.Client
+	indexer *indexer.Indexer
+	docs    *repository.DocumentRepository
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(index *search.Index, client *search.Client, x *indexer.Indexer, docs *repository.DocumentRepository) *SearchHandler {
+	return &SearchHandler{index: index, client: client, indexer: x, docs: docs}
+}
+
+// Search handles GET /search - ?q= with tag, author, from, to, sort, page
+// and size
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	req, err := models.ParseSearchRequest(r.URL.Query())
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	result, err := h.index.Search(r.Context(), req)
+	if err != nil {
+		log.Printf("Error searching: %v", err)
+		response.Error(w, http.StatusBadGateway, "Search is unavailable")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: result})
+}
+
+// Reindex handles POST /reindex - rebuilds the index from MySQL behind the
+// alias, without a moment where searches come back empty
+func (h *SearchHandler) Reindex(w http.ResponseWriter, r *http.Request) {
+	// A rebuild outlives an impatient client; finish it regardless
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 10*time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	name, count, err := h.indexer.Rebuild(ctx)
+	if err != nil {
+		log.Printf("Error reindexing: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Reindex failed, the old index is still in use")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: "Reindexed",
+		Data: map[string]interface{}{
+			"index":       name,
+			"documents":   count,
+			"duration_ms": time.Since(start).Milliseconds(),
+		},
+	})
+}
+
+// GetStats handles GET /stats - the live index, its size, and the changes
+// not yet applied to it
+func (h *SearchHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	name, count, err := h.index.Stats(r.Context())
+	if err != nil {
+		log.Printf("Error getting index stats: %v", err)
+		response.Error(w, http.StatusBadGateway, "Search is unavailable")
+		return
+	}
+	pending, err := h.docs.CountChanges(r.Context())
+	if err != nil {
+		log.Printf("Error counting changes: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to get stats")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: models.IndexStats{
+		Index:          name,
+		Documents:      count,
+		PendingChanges: pending,
+	}})
+}
+
+// HealthCheck handles GET /health - MySQL and the search cluster
+func (h *SearchHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	if err := h.docs.Ping(ctx); err != nil {
+		response.Error(w, http.StatusServiceUnavailable, "Database unavailable")
+		return
+	}
+	if err := h.client.Ping(ctx); err != nil {
+		response.Error(w, http.StatusServiceUnavailable, "Search cluster unavailable")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Search service is healthy"})
+}
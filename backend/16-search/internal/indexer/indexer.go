@@ -0,0 +1,164 @@
+// Package indexer keeps the search index in step with MySQL. Triggers
+// record every changed document ID in the search_outbox table; the indexer
+// reads a batch, loads the current rows, writes them to the index in one
+// bulk request, and only then clears the batch from the outbox. A crash in
+// between repeats the batch, which is harmless: indexing the current row
+// twice leaves the same result.
+package indexer
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/e6a5/learning/backend/16-search/internal/models"
+	"github.com/e6a5/learning/backend/16-search/internal/repository"
+	"github.com/e6a5/learning/backend/16-search/internal/search"
+)
+
+// Store is the MySQL side the indexer reads from
+type Store interface {
+	PendingChanges(ctx context.Context, limit int) ([]repository.Change, error)
+	DeleteChanges(ctx context.Context, ids []int64) error
+	GetMany(ctx context.Context, ids []int) ([]models.Document, error)
+	PublishedAfter(ctx context.Context, afterID, limit int) ([]models.Document, error)
+}
+
+// Index is the search side the indexer writes to
+type Index interface {
+	Apply(ctx context.Context, ops []search.BulkOp) (map[string]error, error)
+	Rebuild(ctx context.Context, load func(add func([]models.Document) error) error) (string, int, error)
+}
+
+// Indexer applies outbox changes to the index
+type Indexer struct {
+	store     Store
+	index     Index
+	batchSize int
+	interval  time.Duration
+
+	// mu keeps a rebuild and a sync from running at once. Changes made
+	// during a rebuild stay in the outbox and are applied to the new index
+	// after the swap.
+	mu sync.Mutex
+}
+
+// New creates an indexer that polls every interval
+func New(store Store, index Index, batchSize int, interval time.Duration) *Indexer {
+	return &Indexer{store: store, index: index, batchSize: batchSize, interval: interval}
+}
+
+// Run syncs until ctx is done. A full batch is followed straight away by
+// the next, so a backlog drains without waiting for the interval.
+func (x *Indexer) Run(ctx context.Context) {
+	ticker := time.NewTicker(x.interval)
+	defer ticker.Stop()
+	for {
+		n, err := x.SyncOnce(ctx)
+		if err != nil {
+			log.Printf("Index sync failed, retrying: %v", err)
+		}
+		if err == nil && n == x.batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncOnce applies one batch of changes and returns how many outbox rows
+// it cleared
+func (x *Indexer) SyncOnce(ctx context.Context) (int, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	changes, err := x.store.PendingChanges(ctx, x.batchSize)
+	if err != nil || len(changes) == 0 {
+		return 0, err
+	}
+
+	// One document may have changed many times; its current row is all
+	// that matters
+	seen := make(map[int]bool)
+	var ids []int
+	for _, c := range changes {
+		if !seen[c.DocumentID] {
+			seen[c.DocumentID] = true
+			ids = append(ids, c.DocumentID)
+		}
+	}
+	docs, err := x.store.GetMany(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	// Published documents are indexed; deleted and draft ones removed
+	ops := make([]search.BulkOp, 0, len(ids))
+	found := make(map[int]bool)
+	for _, doc := range docs {
+		found[doc.ID] = true
+		if doc.Status == models.StatusPublished {
+			ops = append(ops, search.IndexOp(doc))
+		} else {
+			ops = append(ops, search.DeleteOp(doc.ID))
+		}
+	}
+	for _, id := range ids {
+		if !found[id] {
+			ops = append(ops, search.DeleteOp(id))
+		}
+	}
+
+	failed, err := x.index.Apply(ctx, ops)
+	if err != nil {
+		return 0, err
+	}
+
+	// Clear what was applied. Failed documents stay in the outbox to be
+	// tried again; so does anything newer, which this batch did not see.
+	done := make([]int64, 0, len(changes))
+	for _, c := range changes {
+		if err, ok := failed[strconv.Itoa(c.DocumentID)]; ok {
+			log.Printf("Failed to index document %d: %v", c.DocumentID, err)
+			continue
+		}
+		done = append(done, c.ID)
+	}
+	if err := x.store.DeleteChanges(ctx, done); err != nil {
+		return 0, err
+	}
+	if len(done) > 0 {
+		log.Printf("Synced %d documents to the index", len(ids)-len(failed))
+	}
+	return len(done), nil
+}
+
+// Rebuild reindexes every published document into a fresh index and swaps
+// it in. Syncing pauses meanwhile; changes wait in the outbox and are
+// applied to the new index afterwards, so none are lost.
+func (x *Indexer) Rebuild(ctx context.Context) (string, int, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	return x.index.Rebuild(ctx, func(add func([]models.Document) error) error {
+		afterID := 0
+		for {
+			docs, err := x.store.PublishedAfter(ctx, afterID, x.batchSize)
+			if err != nil {
+				return err
+			}
+			if len(docs) == 0 {
+				return nil
+			}
+			if err := add(docs); err != nil {
+				return err
+			}
+			afterID = docs[len(docs)-1].ID
+		}
+	})
+}
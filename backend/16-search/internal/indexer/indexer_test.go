@@ -0,0 +1,242 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/16-search/internal/models"
+	"github.com/e6a5/learning/backend/16-search/internal/repository"
+	"github.com/e6a5/learning/backend/16-search/internal/search"
+)
+
+// fakeStore is MySQL in memory: rows by ID and the outbox in order
+type fakeStore struct {
+	docs   map[int]models.Document
+	outbox []repository.Change
+	nextID int64
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{docs: make(map[int]models.Document)}
+}
+
+// put saves a document and records the change, as the triggers do
+func (s *fakeStore) put(doc models.Document) {
+	s.docs[doc.ID] = doc
+	s.touch(doc.ID)
+}
+
+func (s *fakeStore) remove(id int) {
+	delete(s.docs, id)
+	s.touch(id)
+}
+
+func (s *fakeStore) touch(id int) {
+	s.nextID++
+	s.outbox = append(s.outbox, repository.Change{ID: s.nextID, DocumentID: id})
+}
+
+func (s *fakeStore) PendingChanges(ctx context.Context, limit int) ([]repository.Change, error) {
+	if len(s.outbox) < limit {
+		limit = len(s.outbox)
+	}
+	return append([]repository.Change(nil), s.outbox[:limit]...), nil
+}
+
+func (s *fakeStore) DeleteChanges(ctx context.Context, ids []int64) error {
+	done := make(map[int64]bool)
+	for _, id := range ids {
+		done[id] = true
+	}
+	kept := s.outbox[:0]
+	for _, c := range s.outbox {
+		if !done[c.ID] {
+			kept = append(kept, c)
+		}
+	}
+	s.outbox = kept
+	return nil
+}
+
+func (s *fakeStore) GetMany(ctx context.Context, ids []int) ([]models.Document, error) {
+	var docs []models.Document
+	for _, id := range ids {
+		if doc, ok := s.docs[id]; ok {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+func (s *fakeStore) PublishedAfter(ctx context.Context, afterID, limit int) ([]models.Document, error) {
+	var docs []models.Document
+	for _, doc := range s.docs {
+		if doc.ID > afterID && doc.Status == models.StatusPublished {
+			docs = append(docs, doc)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	if len(docs) > limit {
+		docs = docs[:limit]
+	}
+	return docs, nil
+}
+
+// fakeIndex records what is written and can fail chosen documents
+type fakeIndex struct {
+	applied [][]search.BulkOp
+	fail    map[string]error
+	err     error
+	batches [][]models.Document
+}
+
+func (i *fakeIndex) Apply(ctx context.Context, ops []search.BulkOp) (map[string]error, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	i.applied = append(i.applied, ops)
+	failed := make(map[string]error)
+	for _, op := range ops {
+		if err, ok := i.fail[op.ID]; ok {
+			failed[op.ID] = err
+		}
+	}
+	return failed, nil
+}
+
+func (i *fakeIndex) Rebuild(ctx context.Context, load func(add func([]models.Document) error) error) (string, int, error) {
+	count := 0
+	err := load(func(docs []models.Document) error {
+		i.batches = append(i.batches, docs)
+		count += len(docs)
+		return nil
+	})
+	return "documents_new", count, err
+}
+
+func doc(id int, status string) models.Document {
+	now := time.Now()
+	return models.Document{ID: id, Title: "Document", Status: status, CreatedAt: now, UpdatedAt: now}
+}
+
+// summary lists ops as "index 1" or "delete 2"
+func summary(ops []search.BulkOp) []string {
+	var s []string
+	for _, op := range ops {
+		if op.Delete {
+			s = append(s, "delete "+op.ID)
+		} else {
+			s = append(s, "index "+op.ID)
+		}
+	}
+	return s
+}
+
+func TestSyncOnce(t *testing.T) {
+	store := newFakeStore()
+	index := &fakeIndex{}
+	x := New(store, index, 100, time.Second)
+
+	store.put(doc(1, models.StatusPublished))
+	store.put(doc(1, models.StatusPublished)) // Edited twice: indexed once
+	store.put(doc(2, models.StatusDraft))     // Drafts are not searchable
+	store.put(doc(3, models.StatusPublished))
+	store.remove(3) // Deleted before the sync
+
+	n, err := x.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	require.Len(t, index.applied, 1)
+	assert.Equal(t, []string{"index 1", "delete 2", "delete 3"}, summary(index.applied[0]))
+	assert.Empty(t, store.outbox)
+
+	// Nothing left to do
+	n, err = x.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Len(t, index.applied, 1)
+}
+
+func TestSyncOnceKeepsFailures(t *testing.T) {
+	store := newFakeStore()
+	index := &fakeIndex{fail: map[string]error{"2": errors.New("mapper_parsing_exception")}}
+	x := New(store, index, 100, time.Second)
+
+	store.put(doc(1, models.StatusPublished))
+	store.put(doc(2, models.StatusPublished))
+
+	n, err := x.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.Len(t, store.outbox, 1)
+	assert.Equal(t, 2, store.outbox[0].DocumentID)
+
+	// Once the index accepts it, the change is cleared
+	index.fail = nil
+	n, err = x.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, store.outbox)
+}
+
+func TestSyncOnceIndexDown(t *testing.T) {
+	store := newFakeStore()
+	index := &fakeIndex{err: errors.New("connection refused")}
+	x := New(store, index, 100, time.Second)
+
+	store.put(doc(1, models.StatusPublished))
+
+	_, err := x.SyncOnce(context.Background())
+	require.Error(t, err)
+	// The change waits for the index to come back
+	assert.Len(t, store.outbox, 1)
+}
+
+func TestSyncOnceBatchSize(t *testing.T) {
+	store := newFakeStore()
+	index := &fakeIndex{}
+	x := New(store, index, 2, time.Second)
+
+	for id := 1; id <= 5; id++ {
+		store.put(doc(id, models.StatusPublished))
+	}
+
+	n, err := x.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"index 1", "index 2"}, summary(index.applied[0]))
+	assert.Len(t, store.outbox, 3)
+}
+
+func TestRebuild(t *testing.T) {
+	store := newFakeStore()
+	index := &fakeIndex{}
+	x := New(store, index, 2, time.Second)
+
+	for id := 1; id <= 5; id++ {
+		store.put(doc(id, models.StatusPublished))
+	}
+	store.put(doc(6, models.StatusDraft))
+
+	name, count, err := x.Rebuild(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "documents_new", name)
+	assert.Equal(t, 5, count)
+
+	// Paged by ID, drafts left out
+	require.Len(t, index.batches, 3)
+	var ids []int
+	for _, batch := range index.batches {
+		assert.LessOrEqual(t, len(batch), 2)
+		for _, d := range batch {
+			ids = append(ids, d.ID)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// Document statuses; only published documents are searchable
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+)
+
+// Document is a row of the documents table, with its author's name
+type Document struct {
+	ID         int       `json:"id"`
+	AuthorID   int       `json:"author_id"`
+	AuthorName string    `json:"author_name"`
+	Title      string    `json:"title"`
+	Body       string    `json:"body"`
+	Tags       []string  `json:"tags"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// DocumentRequest is the body of POST /documents and PUT /documents/{id}
+type DocumentRequest struct {
+	AuthorID int      `json:"author_id"`
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Tags     []string `json:"tags"`
+	Status   string   `json:"status"`
+}
+
+// Validate validates a document request and normalises its tags
+func (r *DocumentRequest) Validate() error {
+	if r.AuthorID <= 0 {
+		return &validation.Error{Field: "author_id", Message: "Author ID is required"}
+	}
+	r.Title = strings.TrimSpace(r.Title)
+	if r.Title == "" || len(r.Title) > 200 {
+		return &validation.Error{Field: "title", Message: "Title is required and must be at most 200 characters"}
+	}
+	if strings.TrimSpace(r.Body) == "" {
+		return &validation.Error{Field: "body", Message: "Body is required"}
+	}
+	if r.Status == "" {
+		r.Status = StatusPublished
+	}
+	if r.Status != StatusDraft && r.Status != StatusPublished {
+		return &validation.Error{Field: "status", Message: "Status must be draft or published"}
+	}
+	// Tags are matched exactly, so they are stored in one case
+	tags := []string{}
+	for _, tag := range r.Tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > 10 {
+		return &validation.Error{Field: "tags", Message: "At most 10 tags"}
+	}
+	r.Tags = tags
+	return nil
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// MaxResultWindow is how deep from/size paging may go, Elasticsearch's
+// index.max_result_window. Deeper pages need search_after.
+const MaxResultWindow = 10000
+
+// Sort orders
+const (
+	SortRelevance = "relevance"
+	SortNewest    = "newest"
+)
+
+// SearchRequest is a parsed GET /search query string
+type SearchRequest struct {
+	Query  string     // Full-text query; empty matches everything
+	Tags   []string   // Every tag must match
+	Author string     // Author name, matched exactly
+	From   *time.Time // Created on or after
+	To     *time.Time // Created before
+	Sort   string
+	Page   int // From 1
+	Size   int
+}
+
+// ParseSearchRequest reads q, tag (repeatable), author, from, to, sort,
+// page and size
+func ParseSearchRequest(v url.Values) (SearchRequest, error) {
+	req := SearchRequest{
+		Query:  strings.TrimSpace(v.Get("q")),
+		Author: v.Get("author"),
+		Sort:   SortRelevance,
+		Page:   1,
+		Size:   10,
+	}
+	for _, tag := range v["tag"] {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			req.Tags = append(req.Tags, tag)
+		}
+	}
+
+	for _, d := range []struct {
+		field string
+		dst   **time.Time
+	}{{"from", &req.From}, {"to", &req.To}} {
+		if s := v.Get(d.field); s != "" {
+			t, err := time.Parse("2006-01-02", s)
+			if err != nil {
+				return req, &validation.Error{Field: d.field, Message: "Must be a date like 2024-01-31"}
+			}
+			*d.dst = &t
+		}
+	}
+
+	if s := v.Get("sort"); s != "" {
+		if s != SortRelevance && s != SortNewest {
+			return req, &validation.Error{Field: "sort", Message: "Sort must be relevance or newest"}
+		}
+		req.Sort = s
+	}
+
+	var err error
+	if req.Page, err = intParam(v, "page", req.Page); err != nil || req.Page < 1 {
+		return req, &validation.Error{Field: "page", Message: "Page must be 1 or more"}
+	}
+	if req.Size, err = intParam(v, "size", req.Size); err != nil || req.Size < 1 || req.Size > 100 {
+		return req, &validation.Error{Field: "size", Message: "Size must be between 1 and 100"}
+	}
+	if req.Page*req.Size > MaxResultWindow {
+		return req, &validation.Error{Field: "page", Message: "Results beyond the first 10000 are not available; narrow the search"}
+	}
+	return req, nil
+}
+
+func intParam(v url.Values, key string, defaultValue int) (int, error) {
+	s := v.Get(key)
+	if s == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// SearchResult is one page of hits
+type SearchResult struct {
+	Total     int64         `json:"total"`
+	Page      int           `json:"page"`
+	Size      int           `json:"size"`
+	TookMs    int           `json:"took_ms"`
+	Hits      []Hit         `json:"hits"`
+	TagCounts []FacetBucket `json:"tag_counts"`
+}
+
+// Hit is one matching document. Highlights holds the matching fragments of
+// title and body, with terms wrapped in <mark>.
+type Hit struct {
+	ID         int                 `json:"id"`
+	Score      float64             `json:"score"`
+	Title      string              `json:"title"`
+	AuthorName string              `json:"author_name"`
+	Tags       []string            `json:"tags"`
+	CreatedAt  time.Time           `json:"created_at"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+// FacetBucket counts the matching documents with one value, such as a tag
+type FacetBucket struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// IndexStats describes the search index and how far behind MySQL it is
+type IndexStats struct {
+	Index          string `json:"index"`
+	Documents      int64  `json:"documents"`
+	PendingChanges int64  `json:"pending_changes"`
+}
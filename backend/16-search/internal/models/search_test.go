@@ -0,0 +1,68 @@
+package models
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+func TestParseSearchRequestDefaults(t *testing.T) {
+	req, err := ParseSearchRequest(url.Values{})
+	require.NoError(t, err)
+	assert.Equal(t, SearchRequest{Sort: SortRelevance, Page: 1, Size: 10}, req)
+}
+
+func TestParseSearchRequest(t *testing.T) {
+	v, err := url.ParseQuery("q=+go+channels+&tag=Go&tag=concurrency&tag=+&author=Alice+Smith&from=2024-01-01&to=2024-02-01&sort=newest&page=3&size=20")
+	require.NoError(t, err)
+
+	req, err := ParseSearchRequest(v)
+	require.NoError(t, err)
+	assert.Equal(t, "go channels", req.Query)
+	assert.Equal(t, []string{"go", "concurrency"}, req.Tags)
+	assert.Equal(t, "Alice Smith", req.Author)
+	assert.Equal(t, "2024-01-01", req.From.Format("2006-01-02"))
+	assert.Equal(t, "2024-02-01", req.To.Format("2006-01-02"))
+	assert.Equal(t, SortNewest, req.Sort)
+	assert.Equal(t, 3, req.Page)
+	assert.Equal(t, 20, req.Size)
+}
+
+func TestParseSearchRequestErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		field string
+	}{
+		{"from=yesterday", "from"},
+		{"to=2024-13-01", "to"},
+		{"sort=popular", "sort"},
+		{"page=0", "page"},
+		{"page=two", "page"},
+		{"size=0", "size"},
+		{"size=101", "size"},
+		{"page=101&size=100", "page"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			v, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			_, err = ParseSearchRequest(v)
+			var verr *validation.Error
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, tt.field, verr.Field)
+		})
+	}
+}
+
+func TestParseSearchRequestLastPage(t *testing.T) {
+	// The last page inside the result window is allowed
+	req, err := ParseSearchRequest(url.Values{"page": {"100"}, "size": {"100"}})
+	require.NoError(t, err)
+	assert.Equal(t, 100, req.Page)
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/e6a5/learning/backend/16-search/internal/models"
+)
+
+// Errors for missing rows
+var (
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrAuthorNotFound   = errors.New("author not found")
+)
+
+const documentQuery = `SELECT d.id, d.author_id, u.name, d.title, d.body, d.tags, d.status, d.created_at, d.updated_at
+	FROM documents d JOIN users u ON u.id = d.author_id`
+
+// DocumentRepository handles documents in MySQL, the source of truth the
+// search index is built from
+type DocumentRepository struct {
+	db *sql.DB
+}
+
+// NewDocumentRepository creates a new document repository
+func NewDocumentRepository(db *sql.DB) *DocumentRepository {
+	return &DocumentRepository{db: db}
+}
+
+// Ping checks the database connection
+func (r *DocumentRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// Create inserts a document; a trigger queues it for indexing
+func (r *DocumentRepository) Create(ctx context.Context, req models.DocumentRequest) (*models.Document, error) {
+	tags, err := json.Marshal(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.db.ExecContext(ctx, "INSERT INTO documents (author_id, title, body, tags, status) VALUES (?, ?, ?, ?, ?)",
+		req.AuthorID, req.Title, req.Body, tags, req.Status)
+	if err != nil {
+		return nil, translateError(err, "failed to create document")
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document id: %w", err)
+	}
+	return r.Get(ctx, int(id))
+}
+
+// Update replaces a document's fields
+func (r *DocumentRepository) Update(ctx context.Context, id int, req models.DocumentRequest) (*models.Document, error) {
+	tags, err := json.Marshal(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.db.ExecContext(ctx, "UPDATE documents SET author_id = ?, title = ?, body = ?, tags = ?, status = ? WHERE id = ?",
+		req.AuthorID, req.Title, req.Body, tags, req.Status, id)
+	if err != nil {
+		return nil, translateError(err, "failed to update document")
+	}
+	// MySQL reports 0 rows affected when nothing changed, so Get decides
+	// whether the document exists
+	return r.Get(ctx, id)
+}
+
+// Delete deletes a document
+func (r *DocumentRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM documents WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrDocumentNotFound
+	}
+	return nil
+}
+
+// Get returns one document with its author's name
+func (r *DocumentRepository) Get(ctx context.Context, id int) (*models.Document, error) {
+	docs, err := r.query(ctx, documentQuery+" WHERE d.id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrDocumentNotFound
+	}
+	return &docs[0], nil
+}
+
+// GetMany returns the documents with the given IDs that still exist
+func (r *DocumentRepository) GetMany(ctx context.Context, ids []int) ([]models.Document, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	return r.query(ctx, documentQuery+" WHERE d.id IN ("+placeholders+")", args...)
+}
+
+// PublishedAfter returns up to limit published documents with IDs above
+// afterID, in ID order, for a full reindex to page through
+func (r *DocumentRepository) PublishedAfter(ctx context.Context, afterID, limit int) ([]models.Document, error) {
+	return r.query(ctx, documentQuery+" WHERE d.status = ? AND d.id > ? ORDER BY d.id LIMIT ?",
+		models.StatusPublished, afterID, limit)
+}
+
+// RenameAuthor changes an author's name; a trigger queues all their
+// documents for reindexing
+func (r *DocumentRepository) RenameAuthor(ctx context.Context, id int, name string) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET name = ? WHERE id = ?", name, id)
+	if err != nil {
+		return fmt.Errorf("failed to rename author: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", id).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check author: %w", err)
+		}
+		if !exists {
+			return ErrAuthorNotFound
+		}
+	}
+	return nil
+}
+
+// Change is one row of the outbox: a document whose index entry may be stale
+type Change struct {
+	ID         int64
+	DocumentID int
+}
+
+// PendingChanges returns up to limit changes, oldest first
+func (r *DocumentRepository) PendingChanges(ctx context.Context, limit int) ([]Change, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, document_id FROM search_outbox ORDER BY id LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []Change{}
+	for rows.Next() {
+		var c Change
+		if err := rows.Scan(&c.ID, &c.DocumentID); err != nil {
+			return nil, fmt.Errorf("failed to scan change: %w", err)
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// DeleteChanges removes changes that have been applied to the index
+func (r *DocumentRepository) DeleteChanges(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM search_outbox WHERE id IN ("+placeholders+")", args...); err != nil {
+		return fmt.Errorf("failed to clear outbox: %w", err)
+	}
+	return nil
+}
+
+// CountChanges returns how many changes wait in the outbox
+func (r *DocumentRepository) CountChanges(ctx context.Context) (int64, error) {
+	var n int64
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM search_outbox").Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count outbox: %w", err)
+	}
+	return n, nil
+}
+
+func (r *DocumentRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.Document, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	docs := []models.Document{}
+	for rows.Next() {
+		var d models.Document
+		var tags []byte
+		if err := rows.Scan(&d.ID, &d.AuthorID, &d.AuthorName, &d.Title, &d.Body, &tags, &d.Status, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		if err := json.Unmarshal(tags, &d.Tags); err != nil {
+			return nil, fmt.Errorf("failed to decode tags of document %d: %w", d.ID, err)
+		}
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+// translateError turns a foreign key failure on author_id into
+// ErrAuthorNotFound
+func translateError(err error, message string) error {
+	// MySQL error 1452: cannot add or update a child row
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1452 {
+		return ErrAuthorNotFound
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
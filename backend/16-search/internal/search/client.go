@@ -0,0 +1,215 @@
+// Package search talks to Elasticsearch or OpenSearch over their REST API.
+// The two share the parts used here (indices, aliases, _bulk and the query
+// DSL), so a plain HTTP client serves both and the JSON stays visible.
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when the index or alias does not exist
+var ErrNotFound = errors.New("not found")
+
+// Client is a minimal REST client for one cluster
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// NewClient creates a client for the cluster at baseURL, such as
+// http://localhost:9200
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a request and decodes a JSON response into out, if not nil. A
+// body that is not a []byte is encoded as JSON.
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(b)
+		contentType = "application/x-ndjson"
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", method, path, ErrNotFound)
+	}
+	if resp.StatusCode >= 300 {
+		// Errors come as {"error": {"type": ..., "reason": ...}}
+		var e struct {
+			Error struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(data, &e) == nil && e.Error.Type != "" {
+			return fmt.Errorf("%s %s: %d %s: %s", method, path, resp.StatusCode, e.Error.Type, e.Error.Reason)
+		}
+		return fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, bytes.TrimSpace(data))
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Ping checks the cluster answers
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/", nil, nil)
+}
+
+// CreateIndex creates an index with settings and mappings
+func (c *Client) CreateIndex(ctx context.Context, name string, definition json.RawMessage) error {
+	return c.do(ctx, http.MethodPut, "/"+name, definition, nil)
+}
+
+// DeleteIndex deletes an index
+func (c *Client) DeleteIndex(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/"+name, nil, nil)
+}
+
+// Refresh makes recent writes to index searchable now rather than within
+// the refresh interval
+func (c *Client) Refresh(ctx context.Context, index string) error {
+	return c.do(ctx, http.MethodPost, "/"+index+"/_refresh", nil, nil)
+}
+
+// AliasTargets returns the indices alias points to
+func (c *Client) AliasTargets(ctx context.Context, alias string) ([]string, error) {
+	var out map[string]json.RawMessage
+	if err := c.do(ctx, http.MethodGet, "/_alias/"+alias, nil, &out); err != nil {
+		return nil, err
+	}
+	indices := make([]string, 0, len(out))
+	for index := range out {
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+// SwapAlias points alias at index instead of from, in one atomic step, so
+// searches never see neither or both
+func (c *Client) SwapAlias(ctx context.Context, alias, index string, from []string) error {
+	actions := []map[string]interface{}{}
+	for _, old := range from {
+		actions = append(actions, map[string]interface{}{"remove": map[string]string{"index": old, "alias": alias}})
+	}
+	actions = append(actions, map[string]interface{}{"add": map[string]string{"index": index, "alias": alias}})
+	return c.do(ctx, http.MethodPost, "/_aliases", map[string]interface{}{"actions": actions}, nil)
+}
+
+// Count returns how many documents index holds
+func (c *Client) Count(ctx context.Context, index string) (int64, error) {
+	var out struct {
+		Count int64 `json:"count"`
+	}
+	err := c.do(ctx, http.MethodGet, "/"+index+"/_count", nil, &out)
+	return out.Count, err
+}
+
+// Search runs a query against index and decodes the response into out
+func (c *Client) Search(ctx context.Context, index string, query interface{}, out interface{}) error {
+	return c.do(ctx, http.MethodPost, "/"+index+"/_search", query, out)
+}
+
+// BulkOp is one line of a _bulk request: an index with a document, or a
+// delete without one
+type BulkOp struct {
+	Delete   bool
+	ID       string
+	Version  int64 // Written with version_type external_gte; 0 for none
+	Document interface{}
+}
+
+// Bulk applies ops to index in one request. The request can succeed while
+// single operations fail, so it returns the errors per document ID. A
+// delete of a missing document and a write older than the stored version
+// are not errors: either way the index already holds what it should.
+func (c *Client) Bulk(ctx context.Context, index string, ops []BulkOp) (map[string]error, error) {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body) // Encode ends each line with \n, as NDJSON wants
+	for _, op := range ops {
+		meta := map[string]interface{}{"_index": index, "_id": op.ID}
+		if op.Delete {
+			if err := enc.Encode(map[string]interface{}{"delete": meta}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if op.Version > 0 {
+			meta["version"] = op.Version
+			meta["version_type"] = "external_gte"
+		}
+		if err := enc.Encode(map[string]interface{}{"index": meta}); err != nil {
+			return nil, err
+		}
+		if err := enc.Encode(op.Document); err != nil {
+			return nil, err
+		}
+	}
+
+	var out struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string `json:"_id"`
+			Status int    `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/_bulk", body.Bytes(), &out); err != nil {
+		return nil, err
+	}
+
+	failed := make(map[string]error)
+	if !out.Errors {
+		return failed, nil
+	}
+	for _, item := range out.Items {
+		for action, result := range item {
+			if result.Error == nil || result.Status == http.StatusNotFound && action == "delete" ||
+				result.Error.Type == "version_conflict_engine_exception" {
+				continue
+			}
+			failed[result.ID] = fmt.Errorf("%s: %s: %s", action, result.Error.Type, result.Error.Reason)
+		}
+	}
+	return failed, nil
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulk(t *testing.T) {
+	var lines []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_bulk", r.URL.Path)
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			lines = append(lines, line)
+		}
+
+		_, _ = io.WriteString(w, `{"errors": true, "items": [
+			{"index": {"_id": "1", "status": 201}},
+			{"index": {"_id": "2", "status": 409, "error": {"type": "version_conflict_engine_exception", "reason": "newer version stored"}}},
+			{"index": {"_id": "3", "status": 400, "error": {"type": "document_parsing_exception", "reason": "bad field"}}},
+			{"delete": {"_id": "4", "status": 404, "result": "not_found"}}
+		]}`)
+	}))
+	defer server.Close()
+
+	failed, err := NewClient(server.URL).Bulk(context.Background(), "documents", []BulkOp{
+		{ID: "1", Version: 100, Document: map[string]string{"title": "one"}},
+		{ID: "2", Version: 200, Document: map[string]string{"title": "two"}},
+		{ID: "3", Document: map[string]string{"title": "three"}},
+		{ID: "4", Delete: true},
+	})
+	require.NoError(t, err)
+
+	// A stale write and a delete of a missing document are not failures
+	require.Len(t, failed, 1)
+	assert.ErrorContains(t, failed["3"], "document_parsing_exception")
+
+	// Every write is an action line and a source line; deletes have no source
+	require.Len(t, lines, 7)
+	assert.Equal(t, map[string]interface{}{"index": map[string]interface{}{
+		"_index": "documents", "_id": "1", "version": float64(100), "version_type": "external_gte",
+	}}, lines[0])
+	assert.Equal(t, map[string]interface{}{"title": "one"}, lines[1])
+	assert.Equal(t, map[string]interface{}{"index": map[string]interface{}{"_index": "documents", "_id": "3"}}, lines[4])
+	assert.Equal(t, map[string]interface{}{"delete": map[string]interface{}{"_index": "documents", "_id": "4"}}, lines[6])
+}
+
+func TestClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing/_count":
+			w.WriteHeader(http.StatusNotFound)
+		case "/broken/_search":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error": {"type": "parsing_exception", "reason": "unknown query [nope]"}, "status": 400}`)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = io.WriteString(w, "upstream down\n")
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL + "/")
+	ctx := context.Background()
+
+	_, err := client.Count(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	err = client.Search(ctx, "broken", map[string]string{}, nil)
+	assert.EqualError(t, err, "POST /broken/_search: 400 parsing_exception: unknown query [nope]")
+
+	err = client.Ping(ctx)
+	assert.EqualError(t, err, "GET /: 502 upstream down")
+}
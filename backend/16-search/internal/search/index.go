@@ -0,0 +1,156 @@
+package search
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/e6a5/learning/backend/16-search/internal/models"
+)
+
+// mapping is how documents are analysed: title and body with the English
+// analyzer, so "running" finds "run"; tags as exact keywords for filters
+//
+//go:embed mapping.json
+var mapping []byte
+
+// indexedDocument is what the index stores for a document: what search
+// needs, not the whole row
+type indexedDocument struct {
+	Title      string    `json:"title"`
+	Body       string    `json:"body"`
+	Tags       []string  `json:"tags"`
+	AuthorID   int       `json:"author_id"`
+	AuthorName string    `json:"author_name"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// IndexOp turns a document into a bulk operation. Its version is the row's
+// updated_at, so a write from an older read never overwrites a newer one.
+func IndexOp(doc models.Document) BulkOp {
+	return BulkOp{
+		ID:      strconv.Itoa(doc.ID),
+		Version: doc.UpdatedAt.UnixMicro(),
+		Document: indexedDocument{
+			Title:      doc.Title,
+			Body:       doc.Body,
+			Tags:       doc.Tags,
+			AuthorID:   doc.AuthorID,
+			AuthorName: doc.AuthorName,
+			CreatedAt:  doc.CreatedAt,
+			UpdatedAt:  doc.UpdatedAt,
+		},
+	}
+}
+
+// DeleteOp removes a document from the index
+func DeleteOp(id int) BulkOp {
+	return BulkOp{Delete: true, ID: strconv.Itoa(id)}
+}
+
+// Index is the documents index, reached through an alias. Searches and
+// writes use the alias; a reindex builds a new index behind it and swaps.
+type Index struct {
+	client *Client
+	alias  string
+}
+
+// NewIndex creates an index handle for alias
+func NewIndex(client *Client, alias string) *Index {
+	return &Index{client: client, alias: alias}
+}
+
+// Alias returns the name searches and writes use
+func (i *Index) Alias() string {
+	return i.alias
+}
+
+// Exists reports whether the alias points at an index yet
+func (i *Index) Exists(ctx context.Context) (bool, error) {
+	_, err := i.client.AliasTargets(ctx, i.alias)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Apply writes ops through the alias
+func (i *Index) Apply(ctx context.Context, ops []BulkOp) (map[string]error, error) {
+	return i.client.Bulk(ctx, i.alias, ops)
+}
+
+// Rebuild fills a new index from load, which calls add with batches of
+// documents, then points the alias at it and drops the old one. Searches
+// keep using the old index until the swap, so they never see it half full.
+func (i *Index) Rebuild(ctx context.Context, load func(add func([]models.Document) error) error) (string, int, error) {
+	old, err := i.client.AliasTargets(ctx, i.alias)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return "", 0, err
+	}
+
+	name := i.newName()
+	if err := i.client.CreateIndex(ctx, name, mapping); err != nil {
+		return "", 0, fmt.Errorf("create index: %w", err)
+	}
+
+	count := 0
+	err = load(func(docs []models.Document) error {
+		ops := make([]BulkOp, len(docs))
+		for j, doc := range docs {
+			ops[j] = IndexOp(doc)
+		}
+		failed, err := i.client.Bulk(ctx, name, ops)
+		if err != nil {
+			return err
+		}
+		for id, err := range failed {
+			return fmt.Errorf("index document %s: %w", id, err)
+		}
+		count += len(docs)
+		return nil
+	})
+	if err == nil {
+		err = i.client.Refresh(ctx, name)
+	}
+	if err == nil {
+		err = i.client.SwapAlias(ctx, i.alias, name, old)
+	}
+	if err != nil {
+		_ = i.client.DeleteIndex(context.WithoutCancel(ctx), name)
+		return "", 0, err
+	}
+
+	for _, index := range old {
+		if err := i.client.DeleteIndex(ctx, index); err != nil {
+			log.Printf("Failed to delete old index %s: %v", index, err)
+		}
+	}
+	return name, count, nil
+}
+
+// Stats returns the index behind the alias and its document count
+func (i *Index) Stats(ctx context.Context) (string, int64, error) {
+	targets, err := i.client.AliasTargets(ctx, i.alias)
+	if err != nil {
+		return "", 0, err
+	}
+	count, err := i.client.Count(ctx, i.alias)
+	if err != nil {
+		return "", 0, err
+	}
+	name := ""
+	if len(targets) > 0 {
+		name = targets[0]
+	}
+	return name, count, nil
+}
+
+// newName is a fresh index name, such as documents_20240131-150405.123
+func (i *Index) newName() string {
+	return i.alias + "_" + time.Now().UTC().Format("20060102-150405.000")
+}
@@ -0,0 +1,137 @@
+package search
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/e6a5/learning/backend/16-search/internal/models"
+)
+
+// BuildQuery turns a search request into the query DSL. The full-text part
+// goes in "must", where it scores; filters go in "filter", where they only
+// include or exclude, and can be cached.
+func BuildQuery(req models.SearchRequest) map[string]interface{} {
+	var must interface{} = map[string]interface{}{"match_all": map[string]interface{}{}}
+	if req.Query != "" {
+		must = map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query": req.Query,
+				// A match in the title or tags counts for more than one in the body
+				"fields":    []string{"title^3", "tags^2", "body", "author_name"},
+				"fuzziness": "AUTO", // Forgive a typo or two
+			},
+		}
+	}
+
+	filters := []interface{}{}
+	for _, tag := range req.Tags {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"tags": tag}})
+	}
+	if req.Author != "" {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"author_name.raw": req.Author}})
+	}
+	if req.From != nil || req.To != nil {
+		dates := map[string]interface{}{}
+		if req.From != nil {
+			dates["gte"] = req.From.Format(time.RFC3339)
+		}
+		if req.To != nil {
+			dates["lt"] = req.To.Format(time.RFC3339)
+		}
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"created_at": dates}})
+	}
+
+	sort := []interface{}{"_score", map[string]string{"created_at": "desc"}}
+	if req.Sort == models.SortNewest || req.Query == "" {
+		sort = []interface{}{map[string]string{"created_at": "desc"}}
+	}
+
+	return map[string]interface{}{
+		"from":             (req.Page - 1) * req.Size,
+		"size":             req.Size,
+		"track_total_hits": true,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{"must": must, "filter": filters},
+		},
+		"sort": sort,
+		"highlight": map[string]interface{}{
+			"pre_tags":  []string{"<mark>"},
+			"post_tags": []string{"</mark>"},
+			"fields": map[string]interface{}{
+				"title": map[string]interface{}{"number_of_fragments": 0}, // The whole title
+				"body":  map[string]interface{}{"fragment_size": 150, "number_of_fragments": 3},
+			},
+		},
+		// Tag counts over everything that matched, not just this page, to
+		// offer as further filters
+		"aggs": map[string]interface{}{
+			"tags": map[string]interface{}{"terms": map[string]interface{}{"field": "tags", "size": 20}},
+		},
+	}
+}
+
+// searchResponse is the part of a _search response that is used
+type searchResponse struct {
+	Took int `json:"took"`
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			ID        string              `json:"_id"`
+			Score     *float64            `json:"_score"`
+			Source    indexedDocument     `json:"_source"`
+			Highlight map[string][]string `json:"highlight"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations struct {
+		Tags struct {
+			Buckets []struct {
+				Key      string `json:"key"`
+				DocCount int64  `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"tags"`
+	} `json:"aggregations"`
+}
+
+// toResult converts a raw response into a page of results
+func (r searchResponse) toResult(req models.SearchRequest) models.SearchResult {
+	result := models.SearchResult{
+		Total:     r.Hits.Total.Value,
+		Page:      req.Page,
+		Size:      req.Size,
+		TookMs:    r.Took,
+		Hits:      make([]models.Hit, 0, len(r.Hits.Hits)),
+		TagCounts: make([]models.FacetBucket, 0, len(r.Aggregations.Tags.Buckets)),
+	}
+	for _, h := range r.Hits.Hits {
+		id, _ := strconv.Atoi(h.ID)
+		hit := models.Hit{
+			ID:         id,
+			Title:      h.Source.Title,
+			AuthorName: h.Source.AuthorName,
+			Tags:       h.Source.Tags,
+			CreatedAt:  h.Source.CreatedAt,
+			Highlights: h.Highlight,
+		}
+		// Sorting by anything but the score alone leaves it null
+		if h.Score != nil {
+			hit.Score = *h.Score
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+	for _, b := range r.Aggregations.Tags.Buckets {
+		result.TagCounts = append(result.TagCounts, models.FacetBucket{Value: b.Key, Count: b.DocCount})
+	}
+	return result
+}
+
+// Search runs req against the index
+func (i *Index) Search(ctx context.Context, req models.SearchRequest) (models.SearchResult, error) {
+	var resp searchResponse
+	if err := i.client.Search(ctx, i.alias, BuildQuery(req), &resp); err != nil {
+		return models.SearchResult{}, err
+	}
+	return resp.toResult(req), nil
+}
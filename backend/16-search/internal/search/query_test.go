@@ -0,0 +1,112 @@
+package search
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/16-search/internal/models"
+)
+
+// roundTrip encodes a query the way it is sent, so tests compare JSON and
+// not Go types
+func roundTrip(t *testing.T, v interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &out))
+	return out
+}
+
+func TestBuildQueryMatchAll(t *testing.T) {
+	q := roundTrip(t, BuildQuery(models.SearchRequest{Sort: models.SortRelevance, Page: 1, Size: 10}))
+
+	boolQuery := q["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"match_all": map[string]interface{}{}}, boolQuery["must"])
+	assert.Empty(t, boolQuery["filter"])
+	// Without a query every score is equal, so newest first
+	assert.Equal(t, []interface{}{map[string]interface{}{"created_at": "desc"}}, q["sort"])
+	assert.EqualValues(t, 0, q["from"])
+	assert.EqualValues(t, 10, q["size"])
+}
+
+func TestBuildQuery(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	q := roundTrip(t, BuildQuery(models.SearchRequest{
+		Query:  "go channels",
+		Tags:   []string{"go", "concurrency"},
+		Author: "Alice Smith",
+		From:   &from,
+		To:     &to,
+		Sort:   models.SortRelevance,
+		Page:   3,
+		Size:   20,
+	}))
+
+	assert.EqualValues(t, 40, q["from"])
+	assert.EqualValues(t, 20, q["size"])
+	assert.Equal(t, []interface{}{"_score", map[string]interface{}{"created_at": "desc"}}, q["sort"])
+
+	boolQuery := q["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	match := boolQuery["must"].(map[string]interface{})["multi_match"].(map[string]interface{})
+	assert.Equal(t, "go channels", match["query"])
+	assert.Contains(t, match["fields"], "title^3")
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"term": map[string]interface{}{"tags": "go"}},
+		map[string]interface{}{"term": map[string]interface{}{"tags": "concurrency"}},
+		map[string]interface{}{"term": map[string]interface{}{"author_name.raw": "Alice Smith"}},
+		map[string]interface{}{"range": map[string]interface{}{"created_at": map[string]interface{}{
+			"gte": "2024-01-01T00:00:00Z",
+			"lt":  "2024-02-01T00:00:00Z",
+		}}},
+	}, boolQuery["filter"])
+}
+
+func TestBuildQuerySortNewest(t *testing.T) {
+	q := roundTrip(t, BuildQuery(models.SearchRequest{Query: "go", Sort: models.SortNewest, Page: 1, Size: 10}))
+	assert.Equal(t, []interface{}{map[string]interface{}{"created_at": "desc"}}, q["sort"])
+}
+
+func TestToResult(t *testing.T) {
+	// Trimmed from a real response
+	raw := `{
+		"took": 7,
+		"hits": {
+			"total": {"value": 2, "relation": "eq"},
+			"hits": [
+				{"_id": "3", "_score": 4.2,
+				 "_source": {"title": "Go channels", "body": "...", "tags": ["go"], "author_id": 1,
+				             "author_name": "Alice Smith", "created_at": "2024-01-05T10:00:00Z", "updated_at": "2024-01-05T10:00:00Z"},
+				 "highlight": {"title": ["<mark>Go</mark> channels"]}},
+				{"_id": "9", "_score": null,
+				 "_source": {"title": "Worker pools", "body": "...", "tags": ["go", "concurrency"], "author_id": 2,
+				             "author_name": "Bob Jones", "created_at": "2024-01-04T10:00:00Z", "updated_at": "2024-01-04T10:00:00Z"}}
+			]
+		},
+		"aggregations": {"tags": {"buckets": [{"key": "go", "doc_count": 2}, {"key": "concurrency", "doc_count": 1}]}}
+	}`
+	var resp searchResponse
+	require.NoError(t, json.Unmarshal([]byte(raw), &resp))
+
+	result := resp.toResult(models.SearchRequest{Page: 1, Size: 10})
+	assert.EqualValues(t, 2, result.Total)
+	assert.Equal(t, 7, result.TookMs)
+	require.Len(t, result.Hits, 2)
+
+	assert.Equal(t, 3, result.Hits[0].ID)
+	assert.Equal(t, 4.2, result.Hits[0].Score)
+	assert.Equal(t, "Alice Smith", result.Hits[0].AuthorName)
+	assert.Equal(t, []string{"<mark>Go</mark> channels"}, result.Hits[0].Highlights["title"])
+
+	assert.Equal(t, 9, result.Hits[1].ID)
+	assert.Zero(t, result.Hits[1].Score)
+	assert.Equal(t, []string{"go", "concurrency"}, result.Hits[1].Tags)
+
+	assert.Equal(t, []models.FacetBucket{{Value: "go", Count: 2}, {Value: "concurrency", Count: 1}}, result.TagCounts)
+}
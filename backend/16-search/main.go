@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/16-search/internal/handlers"
+	"github.com/e6a5/learning/backend/16-search/internal/indexer"
+	"github.com/e6a5/learning/backend/16-search/internal/repository"
+	"github.com/e6a5/learning/backend/16-search/internal/search"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+func main() {
+	// Initialize database connection
+	db, err := initializeDatabase()
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer db.Close()
+
+	// Connect to Elasticsearch or OpenSearch; the API is the same
+	client := search.NewClient(env.Get("SEARCH_URL", "http://localhost:9200"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx); err != nil {
+		log.Fatal("Failed to reach the search cluster: ", err)
+	}
+
+	docRepo := repository.NewDocumentRepository(db)
+	index := search.NewIndex(client, env.Get("SEARCH_INDEX", "documents"))
+	x := indexer.New(docRepo, index,
+		getEnvInt("SYNC_BATCH_SIZE", 500),
+		getEnvDuration("SYNC_INTERVAL", time.Second))
+
+	// First start: build the index from MySQL. Afterwards the outbox keeps
+	// it current, including changes made while the service was down.
+	exists, err := index.Exists(ctx)
+	if err != nil {
+		log.Fatal("Failed to check the index: ", err)
+	}
+	if !exists {
+		name, count, err := x.Rebuild(context.Background())
+		if err != nil {
+			log.Fatal("Failed to build the index: ", err)
+		}
+		log.Printf("Built index %s with %d documents", name, count)
+	}
+	cancel()
+
+	syncCtx, stopSync := context.WithCancel(context.Background())
+	syncDone := make(chan struct{})
+	go func() {
+		x.Run(syncCtx)
+		close(syncDone)
+	}()
+
+	// Setup HTTP server
+	documentHandler := handlers.NewDocumentHandler(docRepo)
+	searchHandler := handlers.NewSearchHandler(index, client, x, docRepo)
+	port := env.Get("PORT", "8080")
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           setupRoutes(documentHandler, searchHandler),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Printf("🔎 Search service running at http://localhost:%s", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	sig, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-sig.Done()
+
+	log.Println("Shutting down server...")
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
+	// Unsynced changes stay in the outbox for the next start
+	stopSync()
+	<-syncDone
+	log.Println("Server exited")
+}
+
+func initializeDatabase() (*sql.DB, error) {
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		dsn = "user:pass@tcp(localhost:3306)/searchlab?parseTime=true"
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func setupRoutes(documentHandler *handlers.DocumentHandler, searchHandler *handlers.SearchHandler) *mux.Router {
+	router := mux.NewRouter()
+
+	// Documents, stored in MySQL
+	router.HandleFunc("/documents", documentHandler.CreateDocument).Methods("POST")
+	router.HandleFunc("/documents/{id}", documentHandler.GetDocument).Methods("GET")
+	router.HandleFunc("/documents/{id}", documentHandler.UpdateDocument).Methods("PUT")
+	router.HandleFunc("/documents/{id}", documentHandler.DeleteDocument).Methods("DELETE")
+	router.HandleFunc("/authors/{id}", documentHandler.RenameAuthor).Methods("PUT")
+
+	// Search, served by the index
+	router.HandleFunc("/search", searchHandler.Search).Methods("GET")
+	router.HandleFunc("/reindex", searchHandler.Reindex).Methods("POST")
+	router.HandleFunc("/stats", searchHandler.GetStats).Methods("GET")
+
+	// Health check
+	router.HandleFunc("/health", searchHandler.HealthCheck).Methods("GET")
+
+	return router
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(env.Get(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		log.Fatalf("%s must be a number: %v", key, err)
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(env.Get(key, defaultValue.String()))
+	if err != nil {
+		log.Fatalf("%s must be a duration like 2s: %v", key, err)
+	}
+	return value
+}
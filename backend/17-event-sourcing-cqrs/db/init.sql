@@ -0,0 +1,61 @@
+-- The event store: the only source of truth. Rows are inserted, never
+-- updated or deleted.
+CREATE TABLE IF NOT EXISTS events (
+    position BIGINT PRIMARY KEY,
+    aggregate_id VARCHAR(32) NOT NULL,
+    version INT NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    data JSON NOT NULL,
+    occurred_at DATETIME(6) NOT NULL,
+    -- Two writers deciding on the same version: one insert fails
+    UNIQUE KEY stream_version (aggregate_id, version)
+);
+
+-- The last position handed out. Appends lock this row, so positions are
+-- committed in order and projections never skip one.
+CREATE TABLE IF NOT EXISTS event_sequence (
+    id TINYINT PRIMARY KEY,
+    last_position BIGINT NOT NULL
+);
+
+-- Aggregate state at a version, so loading skips the events before it.
+-- Safe to delete at any time.
+CREATE TABLE IF NOT EXISTS snapshots (
+    aggregate_id VARCHAR(32) PRIMARY KEY,
+    version INT NOT NULL,
+    state JSON NOT NULL,
+    created_at DATETIME(6) NOT NULL
+);
+
+-- Read side. Everything below is derived from events and can be rebuilt.
+CREATE TABLE IF NOT EXISTS user_views (
+    id VARCHAR(32) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    version INT NOT NULL,
+    registered_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    -- Not UNIQUE: the read model records what the events say, and a
+    -- projection that refused an event would be stuck on it forever
+    INDEX idx_email (email),
+    INDEX idx_status_registered (status, registered_at)
+);
+
+-- How far each stored projection has read
+CREATE TABLE IF NOT EXISTS projection_checkpoints (
+    name VARCHAR(64) PRIMARY KEY,
+    position BIGINT NOT NULL
+);
+
+-- Some history to start with. The read model is empty: the projection
+-- builds it on the first start.
+INSERT INTO events (position, aggregate_id, version, type, data, occurred_at) VALUES
+    (1, 'a1b2c3d4e5f60718', 1, 'UserRegistered', '{"name": "Alice Smith", "email": "alice@example.com"}', '2024-01-10 09:00:00'),
+    (2, 'b2c3d4e5f6071829', 1, 'UserRegistered', '{"name": "Bob Jones", "email": "bob@example.com"}', '2024-01-11 10:30:00'),
+    (3, 'a1b2c3d4e5f60718', 2, 'EmailChanged', '{"from": "alice@example.com", "to": "alice.smith@example.com"}', '2024-02-01 14:00:00'),
+    (4, 'a1b2c3d4e5f60718', 3, 'NameChanged', '{"name": "Alice Johnson"}', '2024-03-15 16:20:00'),
+    (5, 'b2c3d4e5f6071829', 2, 'UserDeactivated', '{"reason": "Requested account closure"}', '2024-04-02 08:45:00'),
+    (6, 'c3d4e5f60718293a', 1, 'UserRegistered', '{"name": "Carol White", "email": "carol@example.com"}', '2024-04-20 12:00:00');
+
+INSERT INTO event_sequence (id, last_position) VALUES (1, 6);
@@ -0,0 +1,223 @@
+// Package command is the write side. A command loads an aggregate from
+// its events, checks the rules, and appends the new events; it never
+// reads or writes a read model, apart from the one uniqueness check.
+package command
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/domain"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/eventstore"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/models"
+)
+
+// Command errors; rule violations come from the domain package
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrEmailTaken   = errors.New("email already in use")
+)
+
+// maxAttempts bounds how often a command is retried after losing a race
+// to another write
+const maxAttempts = 3
+
+// Store is the event store the write side needs
+type Store interface {
+	Load(ctx context.Context, aggregateID string, afterVersion int) ([]models.Event, error)
+	Append(ctx context.Context, events []models.Event) ([]models.Event, error)
+	LoadSnapshot(ctx context.Context, aggregateID string) (*models.Snapshot, error)
+	SaveSnapshot(ctx context.Context, snap models.Snapshot) error
+}
+
+// Emails tells whether an email is in use. It reads the read model, which
+// may be a moment behind, so two registrations racing with one email can
+// both pass; see the README.
+type Emails interface {
+	EmailTaken(ctx context.Context, email string) (bool, error)
+}
+
+// Result is what a command did
+type Result struct {
+	ID       string `json:"id"`
+	Version  int    `json:"version"`
+	Position int64  `json:"position,omitempty"` // Of the last new event; 0 if nothing changed
+	Events   int    `json:"events"`
+}
+
+// Service runs commands
+type Service struct {
+	store         Store
+	emails        Emails
+	snapshotEvery int
+}
+
+// NewService creates a command service that snapshots an aggregate every
+// snapshotEvery events; 0 turns snapshots off
+func NewService(store Store, emails Emails, snapshotEvery int) *Service {
+	return &Service{store: store, emails: emails, snapshotEvery: snapshotEvery}
+}
+
+// Register creates a user
+func (s *Service) Register(ctx context.Context, req models.RegisterRequest) (Result, error) {
+	if err := s.checkEmail(ctx, req.Email); err != nil {
+		return Result{}, err
+	}
+	id, err := generateID()
+	if err != nil {
+		return Result{}, err
+	}
+	u := domain.NewUser(id)
+	if err := u.Register(req.Name, req.Email); err != nil {
+		return Result{}, err
+	}
+	return s.save(ctx, u)
+}
+
+// Rename changes a user's name. expectedVersion, if not 0, is the version
+// the caller last saw; see execute.
+func (s *Service) Rename(ctx context.Context, id string, expectedVersion int, req models.RenameRequest) (Result, error) {
+	return s.execute(ctx, id, expectedVersion, func(u *domain.User) error {
+		return u.Rename(req.Name)
+	})
+}
+
+// ChangeEmail changes a user's email
+func (s *Service) ChangeEmail(ctx context.Context, id string, expectedVersion int, req models.ChangeEmailRequest) (Result, error) {
+	return s.execute(ctx, id, expectedVersion, func(u *domain.User) error {
+		if req.Email != u.Email {
+			if err := s.checkEmail(ctx, req.Email); err != nil {
+				return err
+			}
+		}
+		return u.ChangeEmail(req.Email)
+	})
+}
+
+// Deactivate deactivates a user
+func (s *Service) Deactivate(ctx context.Context, id string, expectedVersion int, req models.DeactivateRequest) (Result, error) {
+	return s.execute(ctx, id, expectedVersion, func(u *domain.User) error {
+		return u.Deactivate(req.Reason)
+	})
+}
+
+// Reactivate reactivates a user
+func (s *Service) Reactivate(ctx context.Context, id string, expectedVersion int) (Result, error) {
+	return s.execute(ctx, id, expectedVersion, func(u *domain.User) error {
+		return u.Reactivate()
+	})
+}
+
+// Load rebuilds a user from the latest snapshot and the events after it.
+// A user with no events comes back at version 0.
+func (s *Service) Load(ctx context.Context, id string) (*domain.User, error) {
+	u := domain.NewUser(id)
+	snap, err := s.store.LoadSnapshot(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if snap != nil {
+		if restored, err := domain.FromSnapshot(*snap); err != nil {
+			// A snapshot is only a shortcut; replay everything instead
+			log.Printf("Ignoring snapshot: %v", err)
+		} else {
+			u = restored
+		}
+	}
+
+	events, err := s.store.Load(ctx, id, u.Version)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		if err := u.Apply(e); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// execute loads a user, runs cmd against it and appends what it recorded.
+// With an expected version, the caller decided on that version and gets
+// ErrVersionConflict if the user has moved on. Without one, losing a race
+// to another write means loading again and rerunning cmd on the newer
+// state, where its rules may now fail.
+func (s *Service) execute(ctx context.Context, id string, expectedVersion int, cmd func(*domain.User) error) (Result, error) {
+	for attempt := 1; ; attempt++ {
+		u, err := s.Load(ctx, id)
+		if err != nil {
+			return Result{}, err
+		}
+		if u.Version == 0 {
+			return Result{}, ErrUserNotFound
+		}
+		if expectedVersion != 0 && u.Version != expectedVersion {
+			return Result{}, eventstore.ErrVersionConflict
+		}
+		if err := cmd(u); err != nil {
+			return Result{}, err
+		}
+
+		result, err := s.save(ctx, u)
+		if errors.Is(err, eventstore.ErrVersionConflict) && expectedVersion == 0 && attempt < maxAttempts {
+			continue
+		}
+		return result, err
+	}
+}
+
+// save appends the user's new events, and takes a snapshot when they
+// cross a multiple of snapshotEvery
+func (s *Service) save(ctx context.Context, u *domain.User) (Result, error) {
+	changes := u.Changes()
+	result := Result{ID: u.ID, Version: u.Version, Events: len(changes)}
+	if len(changes) == 0 {
+		return result, nil
+	}
+
+	stored, err := s.store.Append(ctx, changes)
+	if err != nil {
+		return Result{}, err
+	}
+	result.Position = stored[len(stored)-1].Position
+
+	before := u.Version - len(changes)
+	if s.snapshotEvery > 0 && u.Version/s.snapshotEvery > before/s.snapshotEvery {
+		// The events are safe; a failed snapshot only makes loading slower
+		if err := s.snapshot(ctx, u); err != nil {
+			log.Printf("Failed to snapshot user %s: %v", u.ID, err)
+		}
+	}
+	return result, nil
+}
+
+func (s *Service) snapshot(ctx context.Context, u *domain.User) error {
+	snap, err := u.Snapshot()
+	if err != nil {
+		return err
+	}
+	return s.store.SaveSnapshot(ctx, snap)
+}
+
+func (s *Service) checkEmail(ctx context.Context, email string) error {
+	taken, err := s.emails.EmailTaken(ctx, email)
+	if err != nil {
+		return fmt.Errorf("failed to check email: %w", err)
+	}
+	if taken {
+		return ErrEmailTaken
+	}
+	return nil
+}
+
+func generateID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
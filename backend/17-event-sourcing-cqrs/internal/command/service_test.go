@@ -0,0 +1,218 @@
+package command
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/domain"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/eventstore"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/models"
+)
+
+// memoryStore is the event store in memory, with the same version check
+type memoryStore struct {
+	mu        sync.Mutex
+	events    []models.Event
+	snapshots map[string]models.Snapshot
+	loads     int // Events read by Load, to see snapshots work
+
+	// beforeAppend, if set, runs once before the next append, to let a
+	// test slip a concurrent write in
+	beforeAppend func()
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{snapshots: make(map[string]models.Snapshot)}
+}
+
+func (s *memoryStore) Load(ctx context.Context, id string, afterVersion int) ([]models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []models.Event
+	for _, e := range s.events {
+		if e.AggregateID == id && e.Version > afterVersion {
+			events = append(events, e)
+		}
+	}
+	s.loads += len(events)
+	return events, nil
+}
+
+func (s *memoryStore) Append(ctx context.Context, events []models.Event) ([]models.Event, error) {
+	if hook := s.beforeAppend; hook != nil {
+		s.beforeAppend = nil
+		hook()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.events {
+		if e.AggregateID == events[0].AggregateID && e.Version >= events[0].Version {
+			return nil, eventstore.ErrVersionConflict
+		}
+	}
+	stored := make([]models.Event, len(events))
+	for i, e := range events {
+		e.Position = int64(len(s.events) + 1)
+		s.events = append(s.events, e)
+		stored[i] = e
+	}
+	return stored, nil
+}
+
+func (s *memoryStore) LoadSnapshot(ctx context.Context, id string) (*models.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if snap, ok := s.snapshots[id]; ok {
+		return &snap, nil
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) SaveSnapshot(ctx context.Context, snap models.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots[snap.AggregateID] = snap
+	return nil
+}
+
+// takenEmails stands in for the read model
+type takenEmails map[string]bool
+
+func (t takenEmails) EmailTaken(ctx context.Context, email string) (bool, error) {
+	return t[email], nil
+}
+
+func register(t *testing.T, s *Service) string {
+	t.Helper()
+	result, err := s.Register(context.Background(), models.RegisterRequest{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	return result.ID
+}
+
+func TestRegisterAndLoad(t *testing.T) {
+	store := newMemoryStore()
+	s := NewService(store, takenEmails{}, 0)
+	ctx := context.Background()
+
+	id := register(t, s)
+	assert.Len(t, id, 16)
+
+	result, err := s.Rename(ctx, id, 0, models.RenameRequest{Name: "Alice Smith"})
+	require.NoError(t, err)
+	assert.Equal(t, Result{ID: id, Version: 2, Position: 2, Events: 1}, result)
+
+	u, err := s.Load(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Smith", u.Name)
+	assert.Equal(t, 2, u.Version)
+}
+
+func TestCommandErrors(t *testing.T) {
+	s := NewService(newMemoryStore(), takenEmails{"bob@example.com": true}, 0)
+	ctx := context.Background()
+
+	_, err := s.Rename(ctx, "missing", 0, models.RenameRequest{Name: "Bob"})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	_, err = s.Register(ctx, models.RegisterRequest{Name: "Bob", Email: "bob@example.com"})
+	assert.ErrorIs(t, err, ErrEmailTaken)
+
+	id := register(t, s)
+	_, err = s.ChangeEmail(ctx, id, 0, models.ChangeEmailRequest{Email: "bob@example.com"})
+	assert.ErrorIs(t, err, ErrEmailTaken)
+
+	// Keeping one's own email is not a clash, just nothing to do
+	result, err := s.ChangeEmail(ctx, id, 0, models.ChangeEmailRequest{Email: "alice@example.com"})
+	require.NoError(t, err)
+	assert.Zero(t, result.Events)
+
+	_, err = s.Reactivate(ctx, id, 0)
+	assert.ErrorIs(t, err, domain.ErrAlreadyActive)
+}
+
+func TestExpectedVersion(t *testing.T) {
+	s := NewService(newMemoryStore(), takenEmails{}, 0)
+	ctx := context.Background()
+	id := register(t, s)
+
+	_, err := s.Rename(ctx, id, 1, models.RenameRequest{Name: "Alice Smith"})
+	require.NoError(t, err)
+
+	// Based on version 1, but the user is at 2 now
+	_, err = s.Rename(ctx, id, 1, models.RenameRequest{Name: "Alice Jones"})
+	assert.ErrorIs(t, err, eventstore.ErrVersionConflict)
+}
+
+func TestConcurrentWriteRetries(t *testing.T) {
+	store := newMemoryStore()
+	s := NewService(store, takenEmails{}, 0)
+	ctx := context.Background()
+	id := register(t, s)
+
+	// Between loading the user and appending, someone else deactivates it
+	store.beforeAppend = func() {
+		_, err := s.Deactivate(ctx, id, 0, models.DeactivateRequest{})
+		require.NoError(t, err)
+	}
+	_, err := s.Rename(ctx, id, 0, models.RenameRequest{Name: "Alice Smith"})
+
+	// The retry checks the rules again, on the newer state
+	assert.ErrorIs(t, err, domain.ErrDeactivated)
+	u, err := s.Load(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", u.Name)
+	assert.Equal(t, 2, u.Version)
+}
+
+func TestConcurrentWriteWithExpectedVersion(t *testing.T) {
+	store := newMemoryStore()
+	s := NewService(store, takenEmails{}, 0)
+	ctx := context.Background()
+	id := register(t, s)
+
+	store.beforeAppend = func() {
+		_, err := s.Rename(ctx, id, 0, models.RenameRequest{Name: "Alice Jones"})
+		require.NoError(t, err)
+	}
+	_, err := s.Rename(ctx, id, 1, models.RenameRequest{Name: "Alice Smith"})
+	assert.ErrorIs(t, err, eventstore.ErrVersionConflict)
+}
+
+func TestSnapshots(t *testing.T) {
+	store := newMemoryStore()
+	s := NewService(store, takenEmails{}, 5)
+	ctx := context.Background()
+	id := register(t, s)
+
+	for i := 0; i < 11; i++ {
+		_, err := s.Rename(ctx, id, 0, models.RenameRequest{Name: "Alice " + string(rune('A'+i))})
+		require.NoError(t, err)
+	}
+	// Version 12: snapshots were taken at 5 and 10
+	require.Contains(t, store.snapshots, id)
+	assert.Equal(t, 10, store.snapshots[id].Version)
+
+	store.loads = 0
+	u, err := s.Load(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 12, u.Version)
+	assert.Equal(t, "Alice K", u.Name)
+	// Only the events after the snapshot were read
+	assert.Equal(t, 2, store.loads)
+}
+
+func TestCorruptSnapshotIsIgnored(t *testing.T) {
+	store := newMemoryStore()
+	s := NewService(store, takenEmails{}, 0)
+	ctx := context.Background()
+	id := register(t, s)
+
+	store.snapshots[id] = models.Snapshot{AggregateID: id, Version: 1, State: []byte(`{"id": "someone else", "version": 1}`)}
+	u, err := s.Load(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", u.Name)
+}
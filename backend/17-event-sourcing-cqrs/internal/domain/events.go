@@ -0,0 +1,37 @@
+package domain
+
+// Event types. They are stored as strings and replayed for as long as the
+// events exist, so a name, once used, never changes meaning.
+const (
+	EventUserRegistered  = "UserRegistered"
+	EventNameChanged     = "NameChanged"
+	EventEmailChanged    = "EmailChanged"
+	EventUserDeactivated = "UserDeactivated"
+	EventUserReactivated = "UserReactivated"
+)
+
+// UserRegistered starts a user's stream
+type UserRegistered struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// NameChanged records a new display name
+type NameChanged struct {
+	Name string `json:"name"`
+}
+
+// EmailChanged records a new email. The old one is kept too: a projection
+// may need it, and the event should say what happened without a lookup.
+type EmailChanged struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// UserDeactivated records that a user can no longer sign in
+type UserDeactivated struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// UserReactivated undoes UserDeactivated
+type UserReactivated struct{}
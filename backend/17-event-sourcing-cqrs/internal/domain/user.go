@@ -0,0 +1,192 @@
+// Package domain holds the user aggregate. Commands check the rules
+// against the current state and, if they pass, record events; applying
+// an event is the only way state changes, whether the event is new or
+// replayed from the store.
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/models"
+)
+
+// Rule violations
+var (
+	ErrAlreadyRegistered = errors.New("user already registered")
+	ErrNotRegistered     = errors.New("user not registered")
+	ErrDeactivated       = errors.New("user is deactivated")
+	ErrAlreadyActive     = errors.New("user is already active")
+)
+
+// User is the user aggregate
+type User struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	Status       string    `json:"status"`
+	Version      int       `json:"version"` // Of the last event applied
+	RegisteredAt time.Time `json:"registered_at"`
+
+	changes []models.Event
+}
+
+// NewUser returns an empty aggregate for id, to register or to load events
+// into
+func NewUser(id string) *User {
+	return &User{ID: id}
+}
+
+// FromSnapshot restores an aggregate from a snapshot; events after the
+// snapshot's version are applied on top
+func FromSnapshot(s models.Snapshot) (*User, error) {
+	var u User
+	if err := json.Unmarshal(s.State, &u); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot of %s: %w", s.AggregateID, err)
+	}
+	if u.ID != s.AggregateID || u.Version != s.Version {
+		return nil, fmt.Errorf("snapshot of %s does not match its state", s.AggregateID)
+	}
+	return &u, nil
+}
+
+// Snapshot captures the current state
+func (u *User) Snapshot() (models.Snapshot, error) {
+	state, err := json.Marshal(u)
+	if err != nil {
+		return models.Snapshot{}, err
+	}
+	return models.Snapshot{AggregateID: u.ID, Version: u.Version, State: state}, nil
+}
+
+// Changes returns the events recorded by commands since the aggregate was
+// loaded, to be appended to the store
+func (u *User) Changes() []models.Event {
+	return u.changes
+}
+
+// Register starts a new user
+func (u *User) Register(name, email string) error {
+	if u.Version > 0 {
+		return ErrAlreadyRegistered
+	}
+	return u.record(EventUserRegistered, UserRegistered{Name: name, Email: email})
+}
+
+// Rename changes the display name. The same name records nothing.
+func (u *User) Rename(name string) error {
+	if err := u.checkActive(); err != nil {
+		return err
+	}
+	if name == u.Name {
+		return nil
+	}
+	return u.record(EventNameChanged, NameChanged{Name: name})
+}
+
+// ChangeEmail changes the email. The same email records nothing.
+func (u *User) ChangeEmail(email string) error {
+	if err := u.checkActive(); err != nil {
+		return err
+	}
+	if email == u.Email {
+		return nil
+	}
+	return u.record(EventEmailChanged, EmailChanged{From: u.Email, To: email})
+}
+
+// Deactivate stops the user signing in
+func (u *User) Deactivate(reason string) error {
+	if err := u.checkActive(); err != nil {
+		return err
+	}
+	return u.record(EventUserDeactivated, UserDeactivated{Reason: reason})
+}
+
+// Reactivate undoes Deactivate
+func (u *User) Reactivate() error {
+	if u.Version == 0 {
+		return ErrNotRegistered
+	}
+	if u.Status == models.StatusActive {
+		return ErrAlreadyActive
+	}
+	return u.record(EventUserReactivated, UserReactivated{})
+}
+
+func (u *User) checkActive() error {
+	if u.Version == 0 {
+		return ErrNotRegistered
+	}
+	if u.Status == models.StatusDeactivated {
+		return ErrDeactivated
+	}
+	return nil
+}
+
+// record turns data into the next event, applies it and keeps it as a
+// change
+func (u *User) record(eventType string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	e := models.Event{
+		AggregateID: u.ID,
+		Version:     u.Version + 1,
+		Type:        eventType,
+		Data:        raw,
+		// MySQL keeps microseconds; so does the event, or a replay would
+		// differ from the original
+		OccurredAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if err := u.Apply(e); err != nil {
+		return err
+	}
+	u.changes = append(u.changes, e)
+	return nil
+}
+
+// Apply changes state by one event. It never checks rules: the event
+// already happened.
+func (u *User) Apply(e models.Event) error {
+	if e.Version != u.Version+1 {
+		return fmt.Errorf("event %d of %s applied at version %d", e.Version, u.ID, u.Version)
+	}
+	switch e.Type {
+	case EventUserRegistered:
+		var data UserRegistered
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return decodeError(e, err)
+		}
+		u.Name, u.Email, u.Status, u.RegisteredAt = data.Name, data.Email, models.StatusActive, e.OccurredAt
+	case EventNameChanged:
+		var data NameChanged
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return decodeError(e, err)
+		}
+		u.Name = data.Name
+	case EventEmailChanged:
+		var data EmailChanged
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return decodeError(e, err)
+		}
+		u.Email = data.To
+	case EventUserDeactivated:
+		u.Status = models.StatusDeactivated
+	case EventUserReactivated:
+		u.Status = models.StatusActive
+	default:
+		// An event this version of the code does not know: replaying past
+		// it would build the wrong state
+		return fmt.Errorf("unknown event type %q", e.Type)
+	}
+	u.Version = e.Version
+	return nil
+}
+
+func decodeError(e models.Event, err error) error {
+	return fmt.Errorf("failed to decode %s event %d of %s: %w", e.Type, e.Version, e.AggregateID, err)
+}
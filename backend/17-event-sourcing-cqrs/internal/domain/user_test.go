@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/models"
+)
+
+func registered(t *testing.T) *User {
+	t.Helper()
+	u := NewUser("u1")
+	require.NoError(t, u.Register("Alice", "alice@example.com"))
+	return u
+}
+
+func types(events []models.Event) []string {
+	var s []string
+	for _, e := range events {
+		s = append(s, e.Type)
+	}
+	return s
+}
+
+func TestCommandsRecordEvents(t *testing.T) {
+	u := registered(t)
+	require.NoError(t, u.Rename("Alice Smith"))
+	require.NoError(t, u.ChangeEmail("alice.smith@example.com"))
+	require.NoError(t, u.Deactivate("left"))
+	require.NoError(t, u.Reactivate())
+
+	assert.Equal(t, []string{EventUserRegistered, EventNameChanged, EventEmailChanged, EventUserDeactivated, EventUserReactivated}, types(u.Changes()))
+	assert.Equal(t, 5, u.Version)
+	assert.Equal(t, "Alice Smith", u.Name)
+	assert.Equal(t, "alice.smith@example.com", u.Email)
+	assert.Equal(t, models.StatusActive, u.Status)
+
+	for i, e := range u.Changes() {
+		assert.Equal(t, "u1", e.AggregateID)
+		assert.Equal(t, i+1, e.Version)
+	}
+
+	var changed EmailChanged
+	require.NoError(t, json.Unmarshal(u.Changes()[2].Data, &changed))
+	assert.Equal(t, EmailChanged{From: "alice@example.com", To: "alice.smith@example.com"}, changed)
+}
+
+func TestRules(t *testing.T) {
+	u := NewUser("u1")
+	assert.ErrorIs(t, u.Rename("Alice"), ErrNotRegistered)
+	assert.ErrorIs(t, u.Reactivate(), ErrNotRegistered)
+
+	u = registered(t)
+	assert.ErrorIs(t, u.Register("Alice", "alice@example.com"), ErrAlreadyRegistered)
+	assert.ErrorIs(t, u.Reactivate(), ErrAlreadyActive)
+
+	require.NoError(t, u.Deactivate(""))
+	assert.ErrorIs(t, u.Rename("Bob"), ErrDeactivated)
+	assert.ErrorIs(t, u.ChangeEmail("bob@example.com"), ErrDeactivated)
+	assert.ErrorIs(t, u.Deactivate(""), ErrDeactivated)
+
+	// A refused command records nothing
+	assert.Len(t, u.Changes(), 2)
+}
+
+func TestNoOpCommands(t *testing.T) {
+	u := registered(t)
+	require.NoError(t, u.Rename("Alice"))
+	require.NoError(t, u.ChangeEmail("alice@example.com"))
+	assert.Len(t, u.Changes(), 1)
+}
+
+func TestReplay(t *testing.T) {
+	original := registered(t)
+	require.NoError(t, original.Rename("Alice Smith"))
+	require.NoError(t, original.Deactivate("left"))
+
+	replayed := NewUser("u1")
+	for _, e := range original.Changes() {
+		require.NoError(t, replayed.Apply(e))
+	}
+	assert.Empty(t, replayed.Changes())
+	original.changes = nil
+	assert.Equal(t, original, replayed)
+}
+
+func TestApplyOutOfOrder(t *testing.T) {
+	events := registered(t).Changes()
+	u := registered(t)
+	// Version 1 again
+	assert.Error(t, u.Apply(events[0]))
+}
+
+func TestApplyUnknownEvent(t *testing.T) {
+	u := registered(t)
+	err := u.Apply(models.Event{AggregateID: "u1", Version: 2, Type: "PasswordChanged", Data: json.RawMessage(`{}`)})
+	assert.ErrorContains(t, err, "unknown event type")
+	assert.Equal(t, 1, u.Version)
+}
+
+func TestSnapshot(t *testing.T) {
+	u := registered(t)
+	require.NoError(t, u.Rename("Alice Smith"))
+
+	snap, err := u.Snapshot()
+	require.NoError(t, err)
+	assert.Equal(t, 2, snap.Version)
+
+	restored, err := FromSnapshot(snap)
+	require.NoError(t, err)
+	u.changes = nil
+	assert.Equal(t, u, restored)
+
+	// State that does not match the snapshot's header is refused
+	snap.Version = 3
+	_, err = FromSnapshot(snap)
+	assert.Error(t, err)
+}
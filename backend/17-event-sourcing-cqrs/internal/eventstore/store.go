@@ -0,0 +1,171 @@
+// Package eventstore keeps events in MySQL: one append-only table for
+// every stream, plus snapshots.
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/models"
+)
+
+// ErrVersionConflict is returned when another write reached the stream
+// first: the events were decided on a state that is no longer current
+var ErrVersionConflict = errors.New("version conflict")
+
+// Store is the MySQL event store
+type Store struct {
+	db *sql.DB
+}
+
+// New creates an event store
+func New(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Ping checks the database connection
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Append stores the events of one aggregate, all or none, and returns them
+// with their positions. The first event's version must be the stream's
+// next one; if another write took it, nothing is stored and the error is
+// ErrVersionConflict.
+func (s *Store) Append(ctx context.Context, events []models.Event) ([]models.Event, error) {
+	if len(events) == 0 {
+		return events, nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Positions come from one locked row rather than AUTO_INCREMENT. With
+	// AUTO_INCREMENT, a transaction could take position 5, commit after
+	// another took 6, and a projection that had already read 6 would
+	// never see 5. The lock makes appends commit in position order, at
+	// the cost of one append at a time.
+	var last int64
+	if err := tx.QueryRowContext(ctx, "SELECT last_position FROM event_sequence WHERE id = 1 FOR UPDATE").Scan(&last); err != nil {
+		return nil, fmt.Errorf("failed to lock event sequence: %w", err)
+	}
+
+	stored := make([]models.Event, len(events))
+	for i, e := range events {
+		last++
+		e.Position = last
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO events (position, aggregate_id, version, type, data, occurred_at) VALUES (?, ?, ?, ?, ?, ?)",
+			e.Position, e.AggregateID, e.Version, e.Type, string(e.Data), e.OccurredAt)
+		// MySQL error 1062: duplicate (aggregate_id, version)
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return nil, ErrVersionConflict
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to append event: %w", err)
+		}
+		stored[i] = e
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE event_sequence SET last_position = ? WHERE id = 1", last); err != nil {
+		return nil, fmt.Errorf("failed to update event sequence: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit events: %w", err)
+	}
+	return stored, nil
+}
+
+// Load returns the events of one aggregate after a version, in order
+func (s *Store) Load(ctx context.Context, aggregateID string, afterVersion int) ([]models.Event, error) {
+	return s.query(ctx, `SELECT position, aggregate_id, version, type, data, occurred_at
+		FROM events WHERE aggregate_id = ? AND version > ? ORDER BY version`, aggregateID, afterVersion)
+}
+
+// ReadAll returns up to limit events of every aggregate after a position,
+// in order. This is what projections follow.
+func (s *Store) ReadAll(ctx context.Context, afterPosition int64, limit int) ([]models.Event, error) {
+	return s.query(ctx, `SELECT position, aggregate_id, version, type, data, occurred_at
+		FROM events WHERE position > ? ORDER BY position LIMIT ?`, afterPosition, limit)
+}
+
+// LastPosition returns the position of the newest event
+func (s *Store) LastPosition(ctx context.Context) (int64, error) {
+	var last int64
+	if err := s.db.QueryRowContext(ctx, "SELECT last_position FROM event_sequence WHERE id = 1").Scan(&last); err != nil {
+		return 0, fmt.Errorf("failed to get last position: %w", err)
+	}
+	return last, nil
+}
+
+// LoadSnapshot returns the latest snapshot of an aggregate, or nil if it
+// has none
+func (s *Store) LoadSnapshot(ctx context.Context, aggregateID string) (*models.Snapshot, error) {
+	var snap models.Snapshot
+	var state []byte
+	err := s.db.QueryRowContext(ctx,
+		"SELECT aggregate_id, version, state, created_at FROM snapshots WHERE aggregate_id = ?", aggregateID).
+		Scan(&snap.AggregateID, &snap.Version, &state, &snap.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+	snap.State = state
+	return &snap, nil
+}
+
+// SaveSnapshot saves a snapshot unless a newer one is already saved
+func (s *Store) SaveSnapshot(ctx context.Context, snap models.Snapshot) error {
+	// Assignments run left to right: state is compared with the old version
+	// before version is updated
+	_, err := s.db.ExecContext(ctx, `INSERT INTO snapshots (aggregate_id, version, state, created_at)
+		VALUES (?, ?, ?, NOW(6)) AS new
+		ON DUPLICATE KEY UPDATE
+			state = IF(new.version > snapshots.version, new.state, snapshots.state),
+			created_at = IF(new.version > snapshots.version, new.created_at, snapshots.created_at),
+			version = GREATEST(snapshots.version, new.version)`,
+		snap.AggregateID, snap.Version, string(snap.State))
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return nil
+}
+
+// DeleteSnapshots removes every snapshot. Nothing is lost: they are only
+// a shortcut through the events.
+func (s *Store) DeleteSnapshots(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM snapshots")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete snapshots: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (s *Store) query(ctx context.Context, query string, args ...interface{}) ([]models.Event, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.Event{}
+	for rows.Next() {
+		var e models.Event
+		var data []byte
+		if err := rows.Scan(&e.Position, &e.AggregateID, &e.Version, &e.Type, &data, &e.OccurredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		e.Data = data
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
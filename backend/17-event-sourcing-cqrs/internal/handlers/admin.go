@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/eventstore"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/projection"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// AdminHandler handles projections, snapshots and health
+type AdminHandler struct {
+	projector *projection.Projector
+	store     *eventstore.Store
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(projector *projection.Projector, store *eventstore.Store) *AdminHandler {
+	return &AdminHandler{projector: projector, store: store}
+}
+
+// ListProjections handles GET /projections - positions and lag
+func (h *AdminHandler) ListProjections(w http.ResponseWriter, r *http.Request) {
+	status, err := h.projector.Status(r.Context())
+	if err != nil {
+		log.Printf("Error getting projection status: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to get projections")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: status})
+}
+
+// ReplayProjection handles POST /projections/{name}/replay - empties the
+// read model and rebuilds it from the first event
+func (h *AdminHandler) ReplayProjection(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	// A replay outlives an impatient client; finish it regardless
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 10*time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	count, err := h.projector.Replay(ctx, name)
+	if errors.Is(err, projection.ErrUnknownProjection) {
+		response.Error(w, http.StatusNotFound, "Projection not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error replaying %s: %v", name, err)
+		response.Error(w, http.StatusInternalServerError, "Replay failed; the projection resumes from where it stopped")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: "Replayed " + name,
+		Data: map[string]interface{}{
+			"events":      count,
+			"duration_ms": time.Since(start).Milliseconds(),
+		},
+	})
+}
+
+// DeleteSnapshots handles DELETE /snapshots - users load from their full
+// streams until new snapshots are taken
+func (h *AdminHandler) DeleteSnapshots(w http.ResponseWriter, r *http.Request) {
+	count, err := h.store.DeleteSnapshots(r.Context())
+	if err != nil {
+		log.Printf("Error deleting snapshots: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to delete snapshots")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Snapshots deleted", Data: map[string]int64{"deleted": count}})
+}
+
+// HealthCheck handles GET /health
+func (h *AdminHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	if err := h.store.Ping(ctx); err != nil {
+		response.Error(w, http.StatusServiceUnavailable, "Database unavailable")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Event sourcing service is healthy"})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/command"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/domain"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/eventstore"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/models"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// CommandHandler is the write path: it changes users and answers with the
+// new version, never with the user itself
+type CommandHandler struct {
+	commands *command.Service
+}
+
+// NewCommandHandler creates a new command handler
+func NewCommandHandler(commands *command.Service) *CommandHandler {
+	return &CommandHandler{commands: commands}
+}
+
+// Register handles POST /users
+func (h *CommandHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterRequest
+	if !decodeCommand(w, r, &req, req.Validate) {
+		return
+	}
+	result, err := h.commands.Register(r.Context(), req)
+	respondCommand(w, result, err, http.StatusCreated, "register")
+}
+
+// Rename handles PUT /users/{id}/name
+func (h *CommandHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	expected, ok := expectedVersion(w, r)
+	if !ok {
+		return
+	}
+	var req models.RenameRequest
+	if !decodeCommand(w, r, &req, req.Validate) {
+		return
+	}
+	result, err := h.commands.Rename(r.Context(), mux.Vars(r)["id"], expected, req)
+	respondCommand(w, result, err, http.StatusOK, "rename")
+}
+
+// ChangeEmail handles PUT /users/{id}/email
+func (h *CommandHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	expected, ok := expectedVersion(w, r)
+	if !ok {
+		return
+	}
+	var req models.ChangeEmailRequest
+	if !decodeCommand(w, r, &req, req.Validate) {
+		return
+	}
+	result, err := h.commands.ChangeEmail(r.Context(), mux.Vars(r)["id"], expected, req)
+	respondCommand(w, result, err, http.StatusOK, "change email")
+}
+
+// Deactivate handles POST /users/{id}/deactivate - the body is optional
+func (h *CommandHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	expected, ok := expectedVersion(w, r)
+	if !ok {
+		return
+	}
+	var req models.DeactivateRequest
+	if r.ContentLength != 0 && !decodeCommand(w, r, &req, req.Validate) {
+		return
+	}
+	result, err := h.commands.Deactivate(r.Context(), mux.Vars(r)["id"], expected, req)
+	respondCommand(w, result, err, http.StatusOK, "deactivate")
+}
+
+// Reactivate handles POST /users/{id}/reactivate
+func (h *CommandHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
+	expected, ok := expectedVersion(w, r)
+	if !ok {
+		return
+	}
+	result, err := h.commands.Reactivate(r.Context(), mux.Vars(r)["id"], expected)
+	respondCommand(w, result, err, http.StatusOK, "reactivate")
+}
+
+// decodeCommand decodes the body into req and validates it
+func decodeCommand(w http.ResponseWriter, r *http.Request, req interface{}, validate func() error) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON")
+		return false
+	}
+	if err := validate(); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// expectedVersion reads If-Match, the version the client based the command
+// on, as 3 or "3". Without it, the command runs on the latest version.
+func expectedVersion(w http.ResponseWriter, r *http.Request) (int, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version < 1 {
+		response.Error(w, http.StatusBadRequest, "If-Match must be a version such as \"3\"")
+		return 0, false
+	}
+	return version, true
+}
+
+func respondCommand(w http.ResponseWriter, result command.Result, err error, status int, action string) {
+	switch {
+	case err == nil:
+		w.Header().Set("ETag", strconv.Quote(strconv.Itoa(result.Version)))
+		message := "Accepted; queries show it once projected"
+		if result.Events == 0 {
+			message = "Nothing changed"
+		}
+		response.JSON(w, status, response.APIResponse{Message: message, Data: result})
+	case errors.Is(err, command.ErrUserNotFound):
+		response.Error(w, http.StatusNotFound, "User not found")
+	case errors.Is(err, eventstore.ErrVersionConflict):
+		response.Error(w, http.StatusConflict, "User has changed since that version; reload and try again")
+	case errors.Is(err, command.ErrEmailTaken),
+		errors.Is(err, domain.ErrDeactivated),
+		errors.Is(err, domain.ErrAlreadyActive):
+		response.Error(w, http.StatusConflict, capitalize(err.Error()))
+	default:
+		log.Printf("Error trying to %s: %v", action, err)
+		response.Error(w, http.StatusInternalServerError, "Failed to "+action)
+	}
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
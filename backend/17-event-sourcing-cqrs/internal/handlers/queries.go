@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/models"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/projection"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/query"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// QueryHandler is the read path: it answers from read models only
+type QueryHandler struct {
+	users   *query.Users
+	history *query.History
+	stats   *projection.Stats
+}
+
+// NewQueryHandler creates a new query handler
+func NewQueryHandler(users *query.Users, history *query.History, stats *projection.Stats) *QueryHandler {
+	return &QueryHandler{users: users, history: history, stats: stats}
+}
+
+// ListUsers handles GET /users - newest first, ?status=, ?limit=, ?offset=
+func (h *QueryHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status != "" && status != models.StatusActive && status != models.StatusDeactivated {
+		response.Error(w, http.StatusBadRequest, "Status must be active or deactivated")
+		return
+	}
+	limit, err := intQuery(r, "limit", 50)
+	if err != nil || limit < 1 || limit > 100 {
+		response.Error(w, http.StatusBadRequest, "Limit must be between 1 and 100")
+		return
+	}
+	offset, err := intQuery(r, "offset", 0)
+	if err != nil || offset < 0 {
+		response.Error(w, http.StatusBadRequest, "Offset must be 0 or more")
+		return
+	}
+
+	users, err := h.users.List(r.Context(), status, limit, offset)
+	if err != nil {
+		log.Printf("Error listing users: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to list users")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: users})
+}
+
+// GetUser handles GET /users/{id} - the version is also the ETag, to send
+// back in If-Match
+func (h *QueryHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.users.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondQueryError(w, err, "get user")
+		return
+	}
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(user.Version)))
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: user})
+}
+
+// GetEvents handles GET /users/{id}/events - the user's full stream
+func (h *QueryHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := h.history.Events(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondQueryError(w, err, "get events")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: events})
+}
+
+// GetStateAt handles GET /users/{id}/state?version=n - the user as they
+// were after event n, replayed from the stream
+func (h *QueryHandler) GetStateAt(w http.ResponseWriter, r *http.Request) {
+	version, err := intQuery(r, "version", 0)
+	if err != nil || version < 0 {
+		response.Error(w, http.StatusBadRequest, "Version must be 1 or more")
+		return
+	}
+	user, err := h.history.StateAt(r.Context(), mux.Vars(r)["id"], version)
+	if err != nil {
+		respondQueryError(w, err, "replay user")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: user})
+}
+
+// GetStats handles GET /stats - counts from the in-memory projection
+func (h *QueryHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: h.stats.Current()})
+}
+
+func intQuery(r *http.Request, key string, defaultValue int) (int, error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(s)
+}
+
+func respondQueryError(w http.ResponseWriter, err error, action string) {
+	if errors.Is(err, query.ErrUserNotFound) {
+		response.Error(w, http.StatusNotFound, "User not found")
+		return
+	}
+	log.Printf("Error trying to %s: %v", action, err)
+	response.Error(w, http.StatusInternalServerError, "Failed to "+action)
+}
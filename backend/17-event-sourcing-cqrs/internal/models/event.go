@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event is one stored fact about an aggregate. Events are never updated or
+// deleted; the current state is whatever replaying them produces.
+type Event struct {
+	Position    int64           `json:"position"` // Order across all streams, set on append
+	AggregateID string          `json:"aggregate_id"`
+	Version     int             `json:"version"` // Order within the stream, from 1
+	Type        string          `json:"type"`
+	Data        json.RawMessage `json:"data"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+}
+
+// Snapshot is an aggregate's state at a version, saved so loading it does
+// not have to replay the whole stream
+type Snapshot struct {
+	AggregateID string          `json:"aggregate_id"`
+	Version     int             `json:"version"`
+	State       json.RawMessage `json:"state"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// ProjectionStatus is how far one projection has read
+type ProjectionStatus struct {
+	Name     string `json:"name"`
+	Position int64  `json:"position"`
+	Lag      int64  `json:"lag"` // Events not yet applied
+}
+
+// UserStats is the stats read model
+type UserStats struct {
+	Users        int64            `json:"users"`
+	Active       int64            `json:"active"`
+	Deactivated  int64            `json:"deactivated"`
+	EventsByType map[string]int64 `json:"events_by_type"`
+}
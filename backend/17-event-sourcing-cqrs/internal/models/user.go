@@ -0,0 +1,91 @@
+package models
+
+import (
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// User statuses
+const (
+	StatusActive      = "active"
+	StatusDeactivated = "deactivated"
+)
+
+// UserView is the read model of a user: a row of user_views, built from
+// the events by the users projection
+type UserView struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	Status       string    `json:"status"`
+	Version      int       `json:"version"`
+	RegisteredAt time.Time `json:"registered_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// RegisterRequest is the body of POST /users
+type RegisterRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Validate validates a registration and normalises the email
+func (r *RegisterRequest) Validate() error {
+	if err := validateName(&r.Name); err != nil {
+		return err
+	}
+	return validateEmail(&r.Email)
+}
+
+// ChangeEmailRequest is the body of PUT /users/{id}/email
+type ChangeEmailRequest struct {
+	Email string `json:"email"`
+}
+
+// Validate validates the new email and normalises it
+func (r *ChangeEmailRequest) Validate() error {
+	return validateEmail(&r.Email)
+}
+
+// RenameRequest is the body of PUT /users/{id}/name
+type RenameRequest struct {
+	Name string `json:"name"`
+}
+
+// Validate validates the new name
+func (r *RenameRequest) Validate() error {
+	return validateName(&r.Name)
+}
+
+// DeactivateRequest is the body of POST /users/{id}/deactivate
+type DeactivateRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Validate validates the reason
+func (r *DeactivateRequest) Validate() error {
+	r.Reason = strings.TrimSpace(r.Reason)
+	if len(r.Reason) > 200 {
+		return &validation.Error{Field: "reason", Message: "Reason must be at most 200 characters"}
+	}
+	return nil
+}
+
+func validateName(name *string) error {
+	*name = strings.TrimSpace(*name)
+	if *name == "" || len(*name) > 100 {
+		return &validation.Error{Field: "name", Message: "Name is required and must be at most 100 characters"}
+	}
+	return nil
+}
+
+func validateEmail(email *string) error {
+	*email = strings.ToLower(strings.TrimSpace(*email))
+	if addr, err := mail.ParseAddress(*email); err != nil || addr.Address != *email || len(*email) > 255 {
+		return &validation.Error{Field: "email", Message: "A valid email is required"}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+// Package projection builds read models from the event stream. Each
+// projection remembers the position of the last event it applied and
+// follows the stream from there; replaying one means emptying it and
+// following the stream again from the start.
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/models"
+)
+
+// ErrUnknownProjection is returned for a name no projection has
+var ErrUnknownProjection = errors.New("unknown projection")
+
+// Source is the event stream projections read
+type Source interface {
+	ReadAll(ctx context.Context, afterPosition int64, limit int) ([]models.Event, error)
+	LastPosition(ctx context.Context) (int64, error)
+}
+
+// Projection builds one read model
+type Projection interface {
+	Name() string
+	// Position returns the position of the last event applied
+	Position(ctx context.Context) (int64, error)
+	// Apply applies events in order and records the last one's position,
+	// both or neither, so an event is never applied twice or skipped
+	Apply(ctx context.Context, events []models.Event) error
+	// Reset empties the read model and sets the position back to 0
+	Reset(ctx context.Context) error
+}
+
+// Projector keeps projections up to date
+type Projector struct {
+	source      Source
+	projections []Projection
+	batchSize   int
+	interval    time.Duration
+
+	// mu keeps a replay and the regular catch-up apart
+	mu sync.Mutex
+}
+
+// NewProjector creates a projector that polls every interval
+func NewProjector(source Source, projections []Projection, batchSize int, interval time.Duration) *Projector {
+	return &Projector{source: source, projections: projections, batchSize: batchSize, interval: interval}
+}
+
+// Run catches up until ctx is done. While a projection is far behind, it
+// reads batch after batch without waiting for the interval.
+func (p *Projector) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		behind, err := p.CatchUp(ctx)
+		if err != nil {
+			log.Printf("Projection failed, retrying: %v", err)
+		}
+		if err == nil && behind {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CatchUp applies one batch to every projection and reports whether any
+// got a full batch, and so may be further behind. A projection that fails
+// stops where it is; the others carry on.
+func (p *Projector) CatchUp(ctx context.Context) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	behind := false
+	var errs []error
+	for _, proj := range p.projections {
+		n, err := p.step(ctx, proj)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		behind = behind || n == p.batchSize
+	}
+	return behind, errors.Join(errs...)
+}
+
+// Replay empties one projection and rebuilds it from the first event. It
+// returns how many events it applied.
+func (p *Projector) Replay(ctx context.Context, name string) (int, error) {
+	proj, err := p.find(name)
+	if err != nil {
+		return 0, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := proj.Reset(ctx); err != nil {
+		return 0, fmt.Errorf("failed to reset %s: %w", name, err)
+	}
+	total := 0
+	for {
+		n, err := p.step(ctx, proj)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < p.batchSize {
+			return total, nil
+		}
+	}
+}
+
+// Status returns every projection's position and how far it is behind
+func (p *Projector) Status(ctx context.Context) ([]models.ProjectionStatus, error) {
+	last, err := p.source.LastPosition(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := make([]models.ProjectionStatus, 0, len(p.projections))
+	for _, proj := range p.projections {
+		pos, err := proj.Position(ctx)
+		if err != nil {
+			return nil, err
+		}
+		status = append(status, models.ProjectionStatus{Name: proj.Name(), Position: pos, Lag: last - pos})
+	}
+	return status, nil
+}
+
+// step applies the next batch to one projection
+func (p *Projector) step(ctx context.Context, proj Projection) (int, error) {
+	pos, err := proj.Position(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get position of %s: %w", proj.Name(), err)
+	}
+	events, err := p.source.ReadAll(ctx, pos, p.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+	if err := proj.Apply(ctx, events); err != nil {
+		return 0, fmt.Errorf("%s failed at position %d: %w", proj.Name(), pos+1, err)
+	}
+	return len(events), nil
+}
+
+func (p *Projector) find(name string) (Projection, error) {
+	for _, proj := range p.projections {
+		if proj.Name() == name {
+			return proj, nil
+		}
+	}
+	return nil, ErrUnknownProjection
+}
@@ -0,0 +1,152 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/domain"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/models"
+)
+
+// fakeSource is an event stream in memory
+type fakeSource struct {
+	events []models.Event
+}
+
+func (s *fakeSource) add(id, eventType string) {
+	s.events = append(s.events, models.Event{
+		Position:    int64(len(s.events) + 1),
+		AggregateID: id,
+		Type:        eventType,
+	})
+}
+
+func (s *fakeSource) ReadAll(ctx context.Context, after int64, limit int) ([]models.Event, error) {
+	var events []models.Event
+	for _, e := range s.events {
+		if e.Position > after && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (s *fakeSource) LastPosition(ctx context.Context) (int64, error) {
+	return int64(len(s.events)), nil
+}
+
+// brokenProjection fails on every batch
+type brokenProjection struct{}
+
+func (brokenProjection) Name() string                                { return "broken" }
+func (brokenProjection) Position(ctx context.Context) (int64, error) { return 0, nil }
+func (brokenProjection) Apply(ctx context.Context, _ []models.Event) error {
+	return errors.New("disk full")
+}
+func (brokenProjection) Reset(ctx context.Context) error { return nil }
+
+func seed() *fakeSource {
+	s := &fakeSource{}
+	s.add("a", domain.EventUserRegistered)
+	s.add("b", domain.EventUserRegistered)
+	s.add("a", domain.EventNameChanged)
+	s.add("b", domain.EventUserDeactivated)
+	s.add("c", domain.EventUserRegistered)
+	return s
+}
+
+func TestCatchUp(t *testing.T) {
+	source := seed()
+	stats := NewStats()
+	p := NewProjector(source, []Projection{stats}, 2, time.Second)
+	ctx := context.Background()
+
+	// Batches of two: full, full, then the last one
+	for _, wantBehind := range []bool{true, true, false} {
+		behind, err := p.CatchUp(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, wantBehind, behind)
+	}
+
+	assert.Equal(t, models.UserStats{
+		Users:       3,
+		Active:      2,
+		Deactivated: 1,
+		EventsByType: map[string]int64{
+			domain.EventUserRegistered:  3,
+			domain.EventNameChanged:     1,
+			domain.EventUserDeactivated: 1,
+		},
+	}, stats.Current())
+
+	status, err := p.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ProjectionStatus{{Name: "stats", Position: 5, Lag: 0}}, status)
+
+	// New events are picked up from the checkpoint
+	source.add("b", domain.EventUserReactivated)
+	_, err = p.CatchUp(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, stats.Current().Active)
+	assert.EqualValues(t, 0, stats.Current().Deactivated)
+}
+
+func TestReplay(t *testing.T) {
+	source := seed()
+	stats := NewStats()
+	p := NewProjector(source, []Projection{stats}, 2, time.Second)
+	ctx := context.Background()
+
+	for {
+		behind, err := p.CatchUp(ctx)
+		require.NoError(t, err)
+		if !behind {
+			break
+		}
+	}
+	before := stats.Current()
+
+	n, err := p.Replay(ctx, "stats")
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	// The same events give the same read model
+	assert.Equal(t, before, stats.Current())
+
+	_, err = p.Replay(ctx, "nope")
+	assert.ErrorIs(t, err, ErrUnknownProjection)
+}
+
+func TestBrokenProjectionDoesNotStopOthers(t *testing.T) {
+	source := seed()
+	stats := NewStats()
+	p := NewProjector(source, []Projection{brokenProjection{}, stats}, 10, time.Second)
+	ctx := context.Background()
+
+	_, err := p.CatchUp(ctx)
+	assert.ErrorContains(t, err, "broken failed at position 1: disk full")
+	assert.EqualValues(t, 3, stats.Current().Users)
+
+	status, err := p.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ProjectionStatus{
+		{Name: "broken", Position: 0, Lag: 5},
+		{Name: "stats", Position: 5, Lag: 0},
+	}, status)
+}
+
+func TestStatsCurrentIsACopy(t *testing.T) {
+	source := seed()
+	stats := NewStats()
+	p := NewProjector(source, []Projection{stats}, 10, time.Second)
+	_, err := p.CatchUp(context.Background())
+	require.NoError(t, err)
+
+	current := stats.Current()
+	current.EventsByType[domain.EventUserRegistered] = 100
+	assert.EqualValues(t, 3, stats.Current().EventsByType[domain.EventUserRegistered])
+}
@@ -0,0 +1,82 @@
+package projection
+
+import (
+	"context"
+	"maps"
+	"sync"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/domain"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/models"
+)
+
+// Stats counts users and events in memory. It keeps no checkpoint on
+// disk, so every start replays the whole stream into it: a read model
+// that is cheap to rebuild need not be stored at all.
+type Stats struct {
+	mu       sync.RWMutex
+	position int64
+	stats    models.UserStats
+}
+
+// NewStats creates the stats projection
+func NewStats() *Stats {
+	s := &Stats{}
+	s.reset()
+	return s
+}
+
+// Name implements Projection
+func (s *Stats) Name() string {
+	return "stats"
+}
+
+// Position implements Projection
+func (s *Stats) Position(ctx context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.position, nil
+}
+
+// Apply implements Projection
+func (s *Stats) Apply(ctx context.Context, events []models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range events {
+		s.stats.EventsByType[e.Type]++
+		switch e.Type {
+		case domain.EventUserRegistered:
+			s.stats.Users++
+			s.stats.Active++
+		case domain.EventUserDeactivated:
+			s.stats.Active--
+			s.stats.Deactivated++
+		case domain.EventUserReactivated:
+			s.stats.Active++
+			s.stats.Deactivated--
+		}
+		s.position = e.Position
+	}
+	return nil
+}
+
+// Reset implements Projection
+func (s *Stats) Reset(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reset()
+	return nil
+}
+
+func (s *Stats) reset() {
+	s.position = 0
+	s.stats = models.UserStats{EventsByType: make(map[string]int64)}
+}
+
+// Current returns a copy of the counts
+func (s *Stats) Current() models.UserStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stats := s.stats
+	stats.EventsByType = maps.Clone(s.stats.EventsByType)
+	return stats
+}
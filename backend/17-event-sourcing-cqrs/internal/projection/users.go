@@ -0,0 +1,134 @@
+package projection
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/domain"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/models"
+)
+
+// Users maintains the user_views table that queries read. The rows and the
+// checkpoint are written in one transaction.
+type Users struct {
+	db *sql.DB
+}
+
+// NewUsers creates the users projection
+func NewUsers(db *sql.DB) *Users {
+	return &Users{db: db}
+}
+
+// Name implements Projection
+func (p *Users) Name() string {
+	return "users"
+}
+
+// Position implements Projection
+func (p *Users) Position(ctx context.Context) (int64, error) {
+	return readCheckpoint(ctx, p.db, p.Name())
+}
+
+// Apply implements Projection
+func (p *Users) Apply(ctx context.Context, events []models.Event) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, e := range events {
+		if err := p.apply(ctx, tx, e); err != nil {
+			return err
+		}
+	}
+	if err := writeCheckpoint(ctx, tx, p.Name(), events[len(events)-1].Position); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p *Users) apply(ctx context.Context, tx *sql.Tx, e models.Event) error {
+	var err error
+	switch e.Type {
+	case domain.EventUserRegistered:
+		var data domain.UserRegistered
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO user_views (id, name, email, status, version, registered_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			e.AggregateID, data.Name, data.Email, models.StatusActive, e.Version, e.OccurredAt, e.OccurredAt)
+	case domain.EventNameChanged:
+		var data domain.NameChanged
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return err
+		}
+		err = p.update(ctx, tx, e, "name", data.Name)
+	case domain.EventEmailChanged:
+		var data domain.EmailChanged
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return err
+		}
+		err = p.update(ctx, tx, e, "email", data.To)
+	case domain.EventUserDeactivated:
+		err = p.update(ctx, tx, e, "status", models.StatusDeactivated)
+	case domain.EventUserReactivated:
+		err = p.update(ctx, tx, e, "status", models.StatusActive)
+	default:
+		// Projections take the events they need and skip the rest
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply %s to user %s: %w", e.Type, e.AggregateID, err)
+	}
+	return nil
+}
+
+// update sets one column, from a fixed list, along with the version
+func (p *Users) update(ctx context.Context, tx *sql.Tx, e models.Event, column, value string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE user_views SET "+column+" = ?, version = ?, updated_at = ? WHERE id = ?",
+		value, e.Version, e.OccurredAt, e.AggregateID)
+	return err
+}
+
+// Reset implements Projection
+func (p *Users) Reset(ctx context.Context) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_views"); err != nil {
+		return fmt.Errorf("failed to empty user_views: %w", err)
+	}
+	if err := writeCheckpoint(ctx, tx, p.Name(), 0); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func readCheckpoint(ctx context.Context, db *sql.DB, name string) (int64, error) {
+	var pos int64
+	err := db.QueryRowContext(ctx, "SELECT position FROM projection_checkpoints WHERE name = ?", name).Scan(&pos)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	return pos, nil
+}
+
+func writeCheckpoint(ctx context.Context, tx *sql.Tx, name string, pos int64) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO projection_checkpoints (name, position) VALUES (?, ?) AS new
+		ON DUPLICATE KEY UPDATE position = new.position`, name, pos)
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
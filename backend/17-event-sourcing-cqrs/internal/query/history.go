@@ -0,0 +1,54 @@
+package query
+
+import (
+	"context"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/domain"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/models"
+)
+
+// EventSource is the event store history reads
+type EventSource interface {
+	Load(ctx context.Context, aggregateID string, afterVersion int) ([]models.Event, error)
+}
+
+// History answers questions about the past, which only the events can
+type History struct {
+	events EventSource
+}
+
+// NewHistory creates the history queries
+func NewHistory(events EventSource) *History {
+	return &History{events: events}
+}
+
+// Events returns every event of a user
+func (h *History) Events(ctx context.Context, id string) ([]models.Event, error) {
+	events, err := h.events.Load(ctx, id, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, ErrUserNotFound
+	}
+	return events, nil
+}
+
+// StateAt replays a user's events up to and including version; 0 means
+// all of them
+func (h *History) StateAt(ctx context.Context, id string, version int) (*domain.User, error) {
+	events, err := h.Events(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	u := domain.NewUser(id)
+	for _, e := range events {
+		if version > 0 && e.Version > version {
+			break
+		}
+		if err := u.Apply(e); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
@@ -0,0 +1,75 @@
+// Package query is the read side. It answers from read models the
+// projections build, and never from the events, apart from history.
+package query
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/models"
+)
+
+// ErrUserNotFound is returned when the read model has no such user, which
+// includes a user registered a moment ago and not yet projected
+var ErrUserNotFound = errors.New("user not found")
+
+// Users reads the user_views table
+type Users struct {
+	db *sql.DB
+}
+
+// NewUsers creates the user queries
+func NewUsers(db *sql.DB) *Users {
+	return &Users{db: db}
+}
+
+// Get returns one user
+func (q *Users) Get(ctx context.Context, id string) (*models.UserView, error) {
+	users, err := q.query(ctx, "WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, ErrUserNotFound
+	}
+	return &users[0], nil
+}
+
+// List returns users newest first, optionally only those with a status
+func (q *Users) List(ctx context.Context, status string, limit, offset int) ([]models.UserView, error) {
+	if status != "" {
+		return q.query(ctx, "WHERE status = ? ORDER BY registered_at DESC, id LIMIT ? OFFSET ?", status, limit, offset)
+	}
+	return q.query(ctx, "ORDER BY registered_at DESC, id LIMIT ? OFFSET ?", limit, offset)
+}
+
+// EmailTaken reports whether any user has email
+func (q *Users) EmailTaken(ctx context.Context, email string) (bool, error) {
+	var taken bool
+	err := q.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM user_views WHERE email = ?)", email).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("failed to check email: %w", err)
+	}
+	return taken, nil
+}
+
+func (q *Users) query(ctx context.Context, where string, args ...interface{}) ([]models.UserView, error) {
+	rows, err := q.db.QueryContext(ctx,
+		"SELECT id, name, email, status, version, registered_at, updated_at FROM user_views "+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.UserView{}
+	for rows.Next() {
+		var u models.UserView
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Status, &u.Version, &u.RegisteredAt, &u.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
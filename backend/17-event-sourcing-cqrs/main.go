@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/command"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/eventstore"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/handlers"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/projection"
+	"github.com/e6a5/learning/backend/17-event-sourcing-cqrs/internal/query"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+func main() {
+	// Initialize database connection
+	db, err := initializeDatabase()
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer db.Close()
+
+	// Write side: events in, events out
+	store := eventstore.New(db)
+	users := query.NewUsers(db)
+	commands := command.NewService(store, users, getEnvInt("SNAPSHOT_EVERY", 10))
+
+	// Read side: projections follow the events into read models
+	stats := projection.NewStats()
+	projector := projection.NewProjector(store,
+		[]projection.Projection{projection.NewUsers(db), stats},
+		getEnvInt("PROJECTION_BATCH_SIZE", 500),
+		getEnvDuration("PROJECTION_INTERVAL", 200*time.Millisecond))
+
+	projectorCtx, stopProjector := context.WithCancel(context.Background())
+	projectorDone := make(chan struct{})
+	go func() {
+		projector.Run(projectorCtx)
+		close(projectorDone)
+	}()
+
+	// Setup HTTP server
+	commandHandler := handlers.NewCommandHandler(commands)
+	queryHandler := handlers.NewQueryHandler(users, query.NewHistory(store), stats)
+	adminHandler := handlers.NewAdminHandler(projector, store)
+	port := env.Get("PORT", "8080")
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           setupRoutes(commandHandler, queryHandler, adminHandler),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Printf("📜 Event sourcing service running at http://localhost:%s", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	sig, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-sig.Done()
+
+	log.Println("Shutting down server...")
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
+	// Projections resume from their checkpoints on the next start
+	stopProjector()
+	<-projectorDone
+	log.Println("Server exited")
+}
+
+func initializeDatabase() (*sql.DB, error) {
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		dsn = "user:pass@tcp(localhost:3306)/eventlab?parseTime=true"
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func setupRoutes(commandHandler *handlers.CommandHandler, queryHandler *handlers.QueryHandler, adminHandler *handlers.AdminHandler) *mux.Router {
+	router := mux.NewRouter()
+
+	// Commands: change state by appending events
+	router.HandleFunc("/users", commandHandler.Register).Methods("POST")
+	router.HandleFunc("/users/{id}/name", commandHandler.Rename).Methods("PUT")
+	router.HandleFunc("/users/{id}/email", commandHandler.ChangeEmail).Methods("PUT")
+	router.HandleFunc("/users/{id}/deactivate", commandHandler.Deactivate).Methods("POST")
+	router.HandleFunc("/users/{id}/reactivate", commandHandler.Reactivate).Methods("POST")
+
+	// Queries: read models and history
+	router.HandleFunc("/users", queryHandler.ListUsers).Methods("GET")
+	router.HandleFunc("/users/{id}", queryHandler.GetUser).Methods("GET")
+	router.HandleFunc("/users/{id}/events", queryHandler.GetEvents).Methods("GET")
+	router.HandleFunc("/users/{id}/state", queryHandler.GetStateAt).Methods("GET")
+	router.HandleFunc("/stats", queryHandler.GetStats).Methods("GET")
+
+	// Projections and snapshots
+	router.HandleFunc("/projections", adminHandler.ListProjections).Methods("GET")
+	router.HandleFunc("/projections/{name}/replay", adminHandler.ReplayProjection).Methods("POST")
+	router.HandleFunc("/snapshots", adminHandler.DeleteSnapshots).Methods("DELETE")
+
+	// Health check
+	router.HandleFunc("/health", adminHandler.HealthCheck).Methods("GET")
+
+	return router
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(env.Get(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		log.Fatalf("%s must be a number: %v", key, err)
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(env.Get(key, defaultValue.String()))
+	if err != nil {
+		log.Fatalf("%s must be a duration like 2s: %v", key, err)
+	}
+	return value
+}
@@ -0,0 +1,135 @@
+// Command demo drives the gateway through every path worth tracing and
+// prints a Jaeger link for each request. It is itself traced: each
+// scenario is a root span, so a trace starts in this process and crosses
+// the gateway, the user service, Redis and MySQL.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/e6a5/learning/backend/18-distributed-tracing/internal/telemetry"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+var tracer = otel.Tracer("github.com/e6a5/learning/backend/18-distributed-tracing/demo")
+
+type client struct {
+	http     *http.Client
+	gateway  string
+	jaegerUI string
+}
+
+func main() {
+	shutdownTracing, err := telemetry.Setup(context.Background(), "demo")
+	if err != nil {
+		log.Fatal("Failed to set up tracing:", err)
+	}
+
+	c := &client{
+		// The transport starts a client span per request and injects
+		// traceparent and baggage headers
+		http:     &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport), Timeout: 10 * time.Second},
+		gateway:  env.Get("GATEWAY_URL", "http://localhost:8080"),
+		jaegerUI: env.Get("JAEGER_UI", "http://localhost:16686"),
+	}
+
+	email := fmt.Sprintf("demo-%d@example.com", time.Now().UnixNano())
+	var id int
+	c.run("create", http.MethodPost, "/users", map[string]string{"name": "Demo User", "email": email}, &id)
+	if id == 0 {
+		log.Println("Create failed; the lookups below use seeded user 1")
+		id = 1
+	}
+	c.run("get-cold", http.MethodGet, fmt.Sprintf("/users/%d", id), nil, nil)
+	c.run("get-warm", http.MethodGet, fmt.Sprintf("/users/%d", id), nil, nil)
+	c.run("list", http.MethodGet, "/users?limit=5", nil, nil)
+	c.run("get-missing", http.MethodGet, "/users/999999", nil, nil)
+	c.run("duplicate-create", http.MethodPost, "/users", map[string]string{"name": "Demo User", "email": email}, nil)
+	c.run("invalid-create", http.MethodPost, "/users", map[string]string{"name": "", "email": "not-an-email"}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Println("Failed to flush spans:", err)
+	}
+	fmt.Printf("\nAll traces: %s/search?service=demo\n", c.jaegerUI)
+}
+
+// run sends one request inside a root span named after the scenario. The
+// scenario also goes into baggage, which every service copies onto its
+// spans, so it can be searched for as baggage.demo.scenario in Jaeger.
+// If createdID is not nil it receives the ID of a created user.
+func (c *client) run(scenario, method, path string, body interface{}, createdID *int) {
+	member, err := baggage.NewMember("demo.scenario", scenario)
+	if err != nil {
+		log.Fatalf("Invalid baggage: %v", err)
+	}
+	bag, err := baggage.New(member)
+	if err != nil {
+		log.Fatalf("Invalid baggage: %v", err)
+	}
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	ctx, span := tracer.Start(ctx, "demo "+scenario, trace.WithAttributes(attribute.String("demo.scenario", scenario)))
+	defer span.End()
+
+	status, data, err := c.do(ctx, method, path, body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		fmt.Printf("%-17s %s %s: %v\n", scenario, method, path, err)
+		return
+	}
+	fmt.Printf("%-17s %s %s -> %d\n%17s %s/trace/%s\n", scenario, method, path, status, "", c.jaegerUI, telemetry.TraceID(ctx))
+
+	if createdID != nil && status == http.StatusCreated {
+		var resp struct {
+			Data struct {
+				ID int `json:"id"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(data, &resp); err == nil {
+			*createdID = resp.Data.ID
+		}
+	}
+}
+
+func (c *client) do(ctx context.Context, method, path string, body interface{}) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.gateway+path, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, data, err
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/e6a5/learning/backend/18-distributed-tracing/gateway/internal/models"
+)
+
+// UserCache keeps users and their view counts in Redis. The client is
+// instrumented with redisotel, so every command is a span of its own.
+type UserCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewUserCache creates a cache whose entries expire after ttl
+func NewUserCache(client *redis.Client, ttl time.Duration) *UserCache {
+	return &UserCache{client: client, ttl: ttl}
+}
+
+// Get returns a cached user; found is false on a miss
+func (c *UserCache) Get(ctx context.Context, id int32) (user *models.User, found bool, err error) {
+	data, err := c.client.Get(ctx, userKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get user %d: %w", id, err)
+	}
+	var u models.User
+	if err := json.Unmarshal(data, &u); err != nil {
+		return nil, false, fmt.Errorf("failed to decode user %d: %w", id, err)
+	}
+	return &u, true, nil
+}
+
+// Set caches a user
+func (c *UserCache) Set(ctx context.Context, user models.User) error {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return fmt.Errorf("failed to encode user %d: %w", user.ID, err)
+	}
+	if err := c.client.Set(ctx, userKey(user.ID), data, c.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set user %d: %w", user.ID, err)
+	}
+	return nil
+}
+
+// IncrViews counts one more view of a user and returns the new count
+func (c *UserCache) IncrViews(ctx context.Context, id int32) (int64, error) {
+	views, err := c.client.Incr(ctx, "views:user:"+strconv.Itoa(int(id))).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count view of user %d: %w", id, err)
+	}
+	return views, nil
+}
+
+// Ping checks if Redis is accessible
+func (c *UserCache) Ping(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis ping failed: %w", err)
+	}
+	return nil
+}
+
+func userKey(id int32) string {
+	return "user:" + strconv.Itoa(int(id))
+}
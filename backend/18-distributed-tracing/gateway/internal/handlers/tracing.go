@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/e6a5/learning/backend/18-distributed-tracing/internal/telemetry"
+)
+
+// Tracing is router middleware that finishes the server span otelhttp
+// started. otelhttp wraps the whole router, so it cannot know the route;
+// naming the span after the template ("GET /users/{id}") rather than the
+// path keeps every user from getting an operation of their own in Jaeger.
+// It also returns the trace ID to the client and records baggage.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				span.SetName(r.Method + " " + template)
+				span.SetAttributes(semconv.HTTPRoute(template))
+			}
+		}
+		if traceID := telemetry.TraceID(r.Context()); traceID != "" {
+			w.Header().Set(telemetry.TraceIDHeader, traceID)
+		}
+		telemetry.AddBaggage(r.Context())
+		next.ServeHTTP(w, r)
+	})
+}
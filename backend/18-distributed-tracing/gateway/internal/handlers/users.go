@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/e6a5/learning/backend/18-distributed-tracing/gateway/internal/models"
+	"github.com/e6a5/learning/backend/18-distributed-tracing/internal/telemetry"
+	pb "github.com/e6a5/learning/backend/18-distributed-tracing/proto"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// Cache is the user cache the handlers need
+type Cache interface {
+	Get(ctx context.Context, id int32) (*models.User, bool, error)
+	Set(ctx context.Context, user models.User) error
+	IncrViews(ctx context.Context, id int32) (int64, error)
+	Ping(ctx context.Context) error
+}
+
+// UserHandler turns HTTP requests into calls to the user service, with a
+// Redis cache in front of reads. Every call it makes takes the request
+// context, which is how the trace reaches gRPC and Redis.
+type UserHandler struct {
+	users pb.UserServiceClient
+	cache Cache
+}
+
+// NewUserHandler creates a new user handler
+func NewUserHandler(users pb.UserServiceClient, cache Cache) *UserHandler {
+	return &UserHandler{users: users, cache: cache}
+}
+
+// CreateUser handles POST /users - create a user through the user service
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	user, err := h.users.CreateUser(r.Context(), &pb.CreateUserRequest{Name: req.Name, Email: req.Email})
+	if err != nil {
+		respondRPCError(w, r, err, "create user")
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{
+		Message: "User created",
+		Data:    models.UserFromProto(user),
+	})
+}
+
+// GetUser handles GET /users/{id} - from the cache, or from the user
+// service on a miss. The view count is updated at the same time, so the
+// trace shows two Redis spans side by side.
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil || id < 1 {
+		response.Error(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	var views int64
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var err error
+		if views, err = h.cache.IncrViews(ctx, int32(id)); err != nil {
+			logf(ctx, "Failed to count view: %v", err)
+		}
+	}()
+	defer wg.Wait()
+
+	// A broken cache is recorded on the span and treated as a miss: the
+	// user service can still answer
+	user, hit, err := h.cache.Get(ctx, int32(id))
+	if err != nil {
+		span.RecordError(err)
+		logf(ctx, "Cache lookup failed: %v", err)
+	}
+	span.SetAttributes(attribute.Bool("cache.hit", hit))
+
+	if !hit {
+		pbUser, err := h.users.GetUser(ctx, &pb.GetUserRequest{Id: int32(id)})
+		if err != nil {
+			respondRPCError(w, r, err, "get user")
+			return
+		}
+		u := models.UserFromProto(pbUser)
+		user = &u
+		if err := h.cache.Set(ctx, u); err != nil {
+			span.RecordError(err)
+			logf(ctx, "Failed to cache user: %v", err)
+		}
+	}
+
+	wg.Wait()
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: models.UserDetails{User: *user, Views: views, Cached: hit},
+	})
+}
+
+// ListUsers handles GET /users - a page of users, ?page=, ?limit=
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	page, err := intQuery(r, "page", 1)
+	if err != nil || page < 1 {
+		response.Error(w, http.StatusBadRequest, "Page must be 1 or more")
+		return
+	}
+	limit, err := intQuery(r, "limit", 10)
+	if err != nil || limit < 1 || limit > 100 {
+		response.Error(w, http.StatusBadRequest, "Limit must be between 1 and 100")
+		return
+	}
+
+	resp, err := h.users.ListUsers(r.Context(), &pb.ListUsersRequest{Page: page, Limit: limit})
+	if err != nil {
+		respondRPCError(w, r, err, "list users")
+		return
+	}
+	users := make([]models.User, 0, len(resp.Users))
+	for _, u := range resp.Users {
+		users = append(users, models.UserFromProto(u))
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: models.UserList{Users: users, Total: resp.Total, Page: page, Limit: limit},
+	})
+}
+
+// Health handles GET /health - checks Redis; the user service is not
+// checked, so a slow backend does not make the gateway look down
+func (h *UserHandler) Health(w http.ResponseWriter, r *http.Request) {
+	if err := h.cache.Ping(r.Context()); err != nil {
+		response.Error(w, http.StatusServiceUnavailable, "Redis unavailable")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "OK"})
+}
+
+// respondRPCError maps a gRPC status to the HTTP status closest to it.
+// The gRPC client span already carries the code; the HTTP server span
+// gets the status through otelhttp.
+func respondRPCError(w http.ResponseWriter, r *http.Request, err error, action string) {
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.InvalidArgument:
+		response.Error(w, http.StatusBadRequest, st.Message())
+	case codes.NotFound:
+		response.Error(w, http.StatusNotFound, st.Message())
+	case codes.AlreadyExists:
+		response.Error(w, http.StatusConflict, st.Message())
+	case codes.Unavailable:
+		logf(r.Context(), "User service unavailable during %s: %v", action, err)
+		response.Error(w, http.StatusServiceUnavailable, "User service unavailable")
+	case codes.DeadlineExceeded:
+		logf(r.Context(), "User service timed out during %s: %v", action, err)
+		response.Error(w, http.StatusGatewayTimeout, "User service timed out")
+	default:
+		logf(r.Context(), "Failed to %s: %v", action, err)
+		response.Error(w, http.StatusBadGateway, "Failed to "+action)
+	}
+}
+
+// logf prefixes a log line with the trace ID, so a line in the logs leads
+// to the trace in Jaeger
+func logf(ctx context.Context, format string, args ...interface{}) {
+	log.Printf("trace_id=%s "+format, append([]interface{}{telemetry.TraceID(ctx)}, args...)...)
+}
+
+func intQuery(r *http.Request, key string, defaultValue int32) (int32, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 32)
+	return int32(n), err
+}
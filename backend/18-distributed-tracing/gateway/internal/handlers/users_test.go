@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/e6a5/learning/backend/18-distributed-tracing/gateway/internal/models"
+	"github.com/e6a5/learning/backend/18-distributed-tracing/internal/telemetry"
+	pb "github.com/e6a5/learning/backend/18-distributed-tracing/proto"
+)
+
+type fakeUsers struct {
+	pb.UserServiceClient
+	users map[int32]*pb.User
+	err   error
+	calls int
+}
+
+func (f *fakeUsers) GetUser(ctx context.Context, req *pb.GetUserRequest, opts ...grpc.CallOption) (*pb.User, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	u, ok := f.users[req.Id]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return u, nil
+}
+
+func (f *fakeUsers) CreateUser(ctx context.Context, req *pb.CreateUserRequest, opts ...grpc.CallOption) (*pb.User, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &pb.User{Id: 7, Name: req.Name, Email: req.Email}, nil
+}
+
+type fakeCache struct {
+	mu    sync.Mutex
+	users map[int32]models.User
+	views map[int32]int64
+	err   error
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{users: map[int32]models.User{}, views: map[int32]int64{}}
+}
+
+func (c *fakeCache) Get(ctx context.Context, id int32) (*models.User, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, false, c.err
+	}
+	u, ok := c.users[id]
+	return &u, ok, nil
+}
+
+func (c *fakeCache) Set(ctx context.Context, user models.User) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.users[user.ID] = user
+	return nil
+}
+
+func (c *fakeCache) IncrViews(ctx context.Context, id int32) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.views[id]++
+	return c.views[id], nil
+}
+
+func (c *fakeCache) Ping(ctx context.Context) error { return nil }
+
+// newServer routes like main.go does, under otelhttp with an in-memory
+// span recorder
+func newServer(users *fakeUsers, cache *fakeCache) (http.Handler, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	h := NewUserHandler(users, cache)
+	router := mux.NewRouter()
+	router.Use(Tracing)
+	router.HandleFunc("/users", h.CreateUser).Methods("POST")
+	router.HandleFunc("/users/{id}", h.GetUser).Methods("GET")
+	return otelhttp.NewHandler(router, "gateway", otelhttp.WithTracerProvider(provider)), recorder
+}
+
+func serverSpan(t *testing.T, recorder *tracetest.SpanRecorder) sdktrace.ReadOnlySpan {
+	spans := recorder.Ended()
+	require.NotEmpty(t, spans)
+	return spans[len(spans)-1]
+}
+
+func hasAttribute(span sdktrace.ReadOnlySpan, kv attribute.KeyValue) bool {
+	for _, a := range span.Attributes() {
+		if a == kv {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGetUser_MissThenHit(t *testing.T) {
+	users := &fakeUsers{users: map[int32]*pb.User{1: {Id: 1, Name: "Alice", Email: "alice@example.com"}}}
+	cache := newFakeCache()
+	server, recorder := newServer(users, cache)
+
+	for i, wantHit := range []bool{false, true} {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data models.UserDetails `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, "Alice", resp.Data.Name)
+		assert.Equal(t, wantHit, resp.Data.Cached)
+		assert.Equal(t, int64(i+1), resp.Data.Views)
+		assert.NotEmpty(t, w.Header().Get(telemetry.TraceIDHeader))
+
+		span := serverSpan(t, recorder)
+		assert.Equal(t, "GET /users/{id}", span.Name())
+		assert.True(t, hasAttribute(span, attribute.Bool("cache.hit", wantHit)))
+	}
+	assert.Equal(t, 1, users.calls, "the second read is served from the cache")
+}
+
+func TestGetUser_CacheDownFallsBack(t *testing.T) {
+	users := &fakeUsers{users: map[int32]*pb.User{1: {Id: 1, Name: "Alice"}}}
+	cache := newFakeCache()
+	cache.err = errors.New("connection refused")
+	server, recorder := newServer(users, cache)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, users.calls)
+
+	span := serverSpan(t, recorder)
+	require.NotEmpty(t, span.Events())
+	assert.Equal(t, "exception", span.Events()[0].Name)
+}
+
+func TestRPCErrorMapping(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want int
+	}{
+		{codes.InvalidArgument, http.StatusBadRequest},
+		{codes.NotFound, http.StatusNotFound},
+		{codes.AlreadyExists, http.StatusConflict},
+		{codes.Unavailable, http.StatusServiceUnavailable},
+		{codes.DeadlineExceeded, http.StatusGatewayTimeout},
+		{codes.Internal, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			server, _ := newServer(&fakeUsers{err: status.Error(tt.code, "boom")}, newFakeCache())
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"A","email":"a@example.com"}`)))
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
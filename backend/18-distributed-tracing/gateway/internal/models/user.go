@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	pb "github.com/e6a5/learning/backend/18-distributed-tracing/proto"
+)
+
+// User is the JSON shape of a user
+type User struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserFromProto converts a user from the user service
+func UserFromProto(u *pb.User) User {
+	return User{
+		ID:        u.Id,
+		Name:      u.Name,
+		Email:     u.Email,
+		CreatedAt: time.Unix(u.CreatedAt, 0).UTC(),
+	}
+}
+
+// UserList is one page of users
+type UserList struct {
+	Users []User `json:"users"`
+	Total int32  `json:"total"`
+	Page  int32  `json:"page"`
+	Limit int32  `json:"limit"`
+}
+
+// UserDetails is a user with how often it has been viewed through the
+// gateway
+type UserDetails struct {
+	User
+	Views  int64 `json:"views"`
+	Cached bool  `json:"cached"`
+}
+
+// CreateUserRequest is the body of POST /users. The gateway passes it on
+// unchecked: the user service validates, so a bad request still makes a
+// trace that crosses both processes.
+type CreateUserRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/e6a5/learning/backend/18-distributed-tracing/gateway/internal/cache"
+	"github.com/e6a5/learning/backend/18-distributed-tracing/gateway/internal/handlers"
+	"github.com/e6a5/learning/backend/18-distributed-tracing/internal/telemetry"
+	pb "github.com/e6a5/learning/backend/18-distributed-tracing/proto"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+func main() {
+	shutdownTracing, err := telemetry.Setup(context.Background(), "gateway")
+	if err != nil {
+		log.Fatal("Failed to set up tracing:", err)
+	}
+
+	// The stats handler starts a client span for every call and injects
+	// its context into the gRPC metadata as traceparent
+	conn, err := grpc.NewClient(env.Get("USER_SERVICE_ADDR", "localhost:50051"),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
+	if err != nil {
+		log.Fatal("Failed to create user service client:", err)
+	}
+	defer conn.Close()
+
+	rdb, err := initializeRedis()
+	if err != nil {
+		log.Fatal("Failed to initialize Redis:", err)
+	}
+	defer rdb.Close()
+
+	userHandler := handlers.NewUserHandler(
+		pb.NewUserServiceClient(conn),
+		cache.NewUserCache(rdb, getEnvDuration("CACHE_TTL", time.Minute)),
+	)
+	router := setupRoutes(userHandler)
+
+	// otelhttp wraps the router so the server span starts before routing
+	// and continues any trace the client sent in traceparent. Health
+	// checks every few seconds would bury the interesting traces.
+	handler := otelhttp.NewHandler(router, "gateway", otelhttp.WithFilter(func(r *http.Request) bool {
+		return r.URL.Path != "/health"
+	}))
+
+	port := env.Get("PORT", "8080")
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Printf("🔭 Gateway running at http://localhost:%s", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	log.Println("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Println("Failed to flush spans:", err)
+	}
+	log.Println("Server exited")
+}
+
+// initializeRedis connects to Redis with tracing hooks installed, so each
+// command becomes a span under the request that issued it
+func initializeRedis() (*redis.Client, error) {
+	rdb := redis.NewClient(&redis.Options{Addr: env.Get("REDIS_ADDR", "localhost:6379")})
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		rdb.Close()
+		return nil, err
+	}
+	return rdb, nil
+}
+
+func setupRoutes(userHandler *handlers.UserHandler) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(handlers.Tracing)
+
+	router.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
+	router.HandleFunc("/users", userHandler.ListUsers).Methods("GET")
+	router.HandleFunc("/users/{id}", userHandler.GetUser).Methods("GET")
+	router.HandleFunc("/health", userHandler.Health).Methods("GET")
+
+	return router
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := env.Get(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid %s %q: %v", key, value, err)
+	}
+	return d
+}
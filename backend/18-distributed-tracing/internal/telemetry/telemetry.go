@@ -0,0 +1,100 @@
+// Package telemetry sets up OpenTelemetry tracing the same way for every
+// service in this lab. Configuration comes from the standard OTEL_*
+// environment variables, so the code does not change between a laptop,
+// compose and production.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDHeader carries the trace ID back to HTTP clients, so a response
+// can be looked up in Jaeger
+const TraceIDHeader = "X-Trace-ID"
+
+// Setup installs the global tracer provider and propagators for a service
+// and returns a function that flushes buffered spans; call it on shutdown
+// or the last spans are lost.
+//
+// OTEL_TRACES_EXPORTER picks the exporter: otlp (default), console or
+// none. The OTLP exporter reads OTEL_EXPORTER_OTLP_ENDPOINT, and the
+// sampler OTEL_TRACES_SAMPLER and OTEL_TRACES_SAMPLER_ARG.
+func Setup(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	// W3C traceparent carries the trace between processes; baggage carries
+	// key-value pairs along with it
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build resource: %w", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	switch exporter := os.Getenv("OTEL_TRACES_EXPORTER"); exporter {
+	case "", "otlp":
+		exp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		// Batching keeps exporting off the request path
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case "console":
+		exp, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create console exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case "none":
+		// Spans are still created and propagated, just not exported
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q", exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// TraceID returns the trace ID of the span in ctx, or "" if there is none
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
+
+// AddBaggage copies the baggage in ctx onto the current span as
+// baggage.<key> attributes. Baggage travels with the trace but is not
+// recorded anywhere by itself; this makes it searchable in Jaeger.
+func AddBaggage(ctx context.Context) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	for _, member := range baggage.FromContext(ctx).Members() {
+		span.SetAttributes(attribute.String("baggage."+member.Key(), member.Value()))
+	}
+}
@@ -0,0 +1,152 @@
+package telemetry
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/e6a5/learning/backend/18-distributed-tracing/proto"
+)
+
+// setupRecorder installs a tracer provider that keeps spans in memory
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Setenv("OTEL_TRACES_EXPORTER", "none")
+	shutdown, err := Setup(context.Background(), "test")
+	require.NoError(t, err)
+	t.Cleanup(func() { shutdown(context.Background()) })
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	return recorder
+}
+
+func withScenario(t *testing.T, ctx context.Context, scenario string) context.Context {
+	member, err := baggage.NewMember("demo.scenario", scenario)
+	require.NoError(t, err)
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key attribute.Key) (string, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value.AsString(), true
+		}
+	}
+	return "", false
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	t.Setenv("OTEL_TRACES_EXPORTER", "zipkin")
+	_, err := Setup(context.Background(), "test")
+	assert.Error(t, err)
+}
+
+func TestTraceID_NoSpan(t *testing.T) {
+	assert.Empty(t, TraceID(context.Background()))
+}
+
+func TestHTTP_TracePropagates(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	server := httptest.NewServer(otelhttp.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		AddBaggage(r.Context())
+		w.Header().Set(TraceIDHeader, TraceID(r.Context()))
+	}), "server"))
+	defer server.Close()
+
+	ctx, root := otel.Tracer("test").Start(withScenario(t, context.Background(), "http"), "root")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	client := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	root.End()
+
+	// The server saw the client's trace, not one of its own
+	assert.Equal(t, TraceID(ctx), resp.Header.Get(TraceIDHeader))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	var serverSpan sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		assert.Equal(t, root.SpanContext().TraceID(), span.SpanContext().TraceID())
+		if span.SpanKind() == trace.SpanKindServer {
+			serverSpan = span
+		}
+	}
+	require.NotNil(t, serverSpan)
+	scenario, ok := attributeValue(serverSpan, "baggage.demo.scenario")
+	assert.True(t, ok)
+	assert.Equal(t, "http", scenario)
+}
+
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	traceID string
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	s.traceID = TraceID(ctx)
+	AddBaggage(ctx)
+	return &pb.User{Id: req.Id}, nil
+}
+
+func TestGRPC_TracePropagates(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	listener := bufconn.Listen(1 << 20)
+	impl := &userServer{}
+	server := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	pb.RegisterUserServiceServer(server, impl)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, root := otel.Tracer("test").Start(withScenario(t, context.Background(), "grpc"), "root")
+	_, err = pb.NewUserServiceClient(conn).GetUser(ctx, &pb.GetUserRequest{Id: 1})
+	require.NoError(t, err)
+	root.End()
+	server.GracefulStop()
+
+	assert.Equal(t, TraceID(ctx), impl.traceID)
+
+	var kinds []trace.SpanKind
+	for _, span := range recorder.Ended() {
+		assert.Equal(t, root.SpanContext().TraceID(), span.SpanContext().TraceID())
+		kinds = append(kinds, span.SpanKind())
+		if span.SpanKind() == trace.SpanKindServer {
+			assert.Equal(t, "user.UserService/GetUser", span.Name())
+			scenario, _ := attributeValue(span, "baggage.demo.scenario")
+			assert.Equal(t, "grpc", scenario)
+		}
+	}
+	assert.ElementsMatch(t, []trace.SpanKind{trace.SpanKindInternal, trace.SpanKindClient, trace.SpanKindServer}, kinds)
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	pb "github.com/e6a5/learning/backend/18-distributed-tracing/proto"
+)
+
+// Repository errors
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrEmailTaken   = errors.New("email already in use")
+)
+
+// UserRepository handles user database operations. The *sql.DB it is given
+// is wrapped by otelsql, so every query shows up as a span without any
+// tracing code here.
+type UserRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Ping checks the database connection
+func (r *UserRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// Create inserts a user and returns it with its ID
+func (r *UserRepository) Create(ctx context.Context, name, email string) (*pb.User, error) {
+	now := time.Now().UTC()
+	result, err := r.db.ExecContext(ctx, "INSERT INTO users (name, email, created_at) VALUES (?, ?, ?)", name, email, now)
+	// MySQL error 1062: duplicate email
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return nil, ErrEmailTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user id: %w", err)
+	}
+	return &pb.User{Id: int32(id), Name: name, Email: email, CreatedAt: now.Unix()}, nil
+}
+
+// Get returns one user
+func (r *UserRepository) Get(ctx context.Context, id int32) (*pb.User, error) {
+	var u pb.User
+	var createdAt time.Time
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, email, created_at FROM users WHERE id = ?", id).
+		Scan(&u.Id, &u.Name, &u.Email, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	u.CreatedAt = createdAt.Unix()
+	return &u, nil
+}
+
+// List returns a page of users, newest first, and the total count
+func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]*pb.User, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, email, created_at FROM users ORDER BY id DESC LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*pb.User{}
+	for rows.Next() {
+		var u pb.User
+		var createdAt time.Time
+		if err := rows.Scan(&u.Id, &u.Name, &u.Email, &createdAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		u.CreatedAt = createdAt.Unix()
+		users = append(users, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return users, total, nil
+}
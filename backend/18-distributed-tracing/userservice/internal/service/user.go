@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/mail"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/e6a5/learning/backend/18-distributed-tracing/internal/telemetry"
+	pb "github.com/e6a5/learning/backend/18-distributed-tracing/proto"
+	"github.com/e6a5/learning/backend/18-distributed-tracing/userservice/internal/repository"
+)
+
+var tracer = otel.Tracer("github.com/e6a5/learning/backend/18-distributed-tracing/userservice")
+
+// Store is the user storage the service needs
+type Store interface {
+	Create(ctx context.Context, name, email string) (*pb.User, error)
+	Get(ctx context.Context, id int32) (*pb.User, error)
+	List(ctx context.Context, limit, offset int) ([]*pb.User, int, error)
+}
+
+// UserService implements the gRPC UserService interface. Unlike in
+// 04-grpc-basics, failures are gRPC status codes rather than a success
+// flag: the tracing instrumentation records the code on the span, so a
+// NOT_FOUND and an INTERNAL look different in Jaeger.
+type UserService struct {
+	pb.UnimplementedUserServiceServer
+	store Store
+}
+
+// NewUserService creates a new user service
+func NewUserService(store Store) *UserService {
+	return &UserService{store: store}
+}
+
+// CreateUser handles unary RPC for creating a user
+func (s *UserService) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	name, email, err := validate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.store.Create(ctx, name, email)
+	if errors.Is(err, repository.ErrEmailTaken) {
+		return nil, status.Error(codes.AlreadyExists, "email already in use")
+	}
+	if err != nil {
+		return nil, internal(ctx, "create user", err)
+	}
+	return user, nil
+}
+
+// GetUser handles unary RPC for retrieving a user by ID
+func (s *UserService) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	user, err := s.store.Get(ctx, req.Id)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, status.Errorf(codes.NotFound, "user %d not found", req.Id)
+	}
+	if err != nil {
+		return nil, internal(ctx, "get user", err)
+	}
+	return user, nil
+}
+
+// ListUsers handles unary RPC for listing users with pagination
+func (s *UserService) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	page, limit := int(req.Page), int(req.Limit)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	users, total, err := s.store.List(ctx, limit, (page-1)*limit)
+	if err != nil {
+		return nil, internal(ctx, "list users", err)
+	}
+	return &pb.ListUsersResponse{Users: users, Total: int32(total)}, nil
+}
+
+// validate checks a new user in a span of its own. Most spans come from
+// instrumented libraries; a manual one like this marks a step of the
+// service's own logic that is worth seeing in the timeline.
+func validate(ctx context.Context, req *pb.CreateUserRequest) (string, string, error) {
+	_, span := tracer.Start(ctx, "validate user")
+	defer span.End()
+
+	name := strings.TrimSpace(req.Name)
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	span.SetAttributes(attribute.Int("user.name_length", len(name)))
+
+	if name == "" || len(name) > 100 {
+		span.SetStatus(otelcodes.Error, "invalid name")
+		return "", "", status.Error(codes.InvalidArgument, "name is required and must be at most 100 characters")
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		span.SetStatus(otelcodes.Error, "invalid email")
+		return "", "", status.Error(codes.InvalidArgument, "a valid email is required")
+	}
+	return name, email, nil
+}
+
+// internal logs err with the trace ID, so the log line and the trace can
+// be found from each other, and hides it from the caller
+func internal(ctx context.Context, action string, err error) error {
+	log.Printf("trace_id=%s Failed to %s: %v", telemetry.TraceID(ctx), action, err)
+	return status.Error(codes.Internal, "failed to "+action)
+}
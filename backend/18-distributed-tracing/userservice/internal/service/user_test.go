@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/e6a5/learning/backend/18-distributed-tracing/proto"
+	"github.com/e6a5/learning/backend/18-distributed-tracing/userservice/internal/repository"
+)
+
+type fakeStore struct {
+	users       map[int32]*pb.User
+	err         error
+	limit, skip int
+}
+
+func (s *fakeStore) Create(ctx context.Context, name, email string) (*pb.User, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	for _, u := range s.users {
+		if u.Email == email {
+			return nil, repository.ErrEmailTaken
+		}
+	}
+	u := &pb.User{Id: int32(len(s.users) + 1), Name: name, Email: email}
+	s.users[u.Id] = u
+	return u, nil
+}
+
+func (s *fakeStore) Get(ctx context.Context, id int32) (*pb.User, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	u, ok := s.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (s *fakeStore) List(ctx context.Context, limit, offset int) ([]*pb.User, int, error) {
+	s.limit, s.skip = limit, offset
+	return nil, len(s.users), s.err
+}
+
+func newService() (*UserService, *fakeStore) {
+	store := &fakeStore{users: map[int32]*pb.User{}}
+	return NewUserService(store), store
+}
+
+func TestCreateUser(t *testing.T) {
+	svc, _ := newService()
+
+	user, err := svc.CreateUser(context.Background(), &pb.CreateUserRequest{Name: " Alice ", Email: "Alice@Example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", user.Name)
+	assert.Equal(t, "alice@example.com", user.Email)
+
+	_, err = svc.CreateUser(context.Background(), &pb.CreateUserRequest{Name: "Alice", Email: "alice@example.com"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestCreateUser_Invalid(t *testing.T) {
+	svc, _ := newService()
+	for _, req := range []*pb.CreateUserRequest{
+		{Name: "", Email: "alice@example.com"},
+		{Name: "Alice", Email: "not-an-email"},
+		{Name: "Alice", Email: "Alice <alice@example.com>"},
+	} {
+		_, err := svc.CreateUser(context.Background(), req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "%v", req)
+	}
+}
+
+func TestGetUser_Errors(t *testing.T) {
+	svc, store := newService()
+
+	_, err := svc.GetUser(context.Background(), &pb.GetUserRequest{Id: 42})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	store.err = errors.New("connection refused")
+	_, err = svc.GetUser(context.Background(), &pb.GetUserRequest{Id: 42})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.NotContains(t, err.Error(), "connection refused", "internal errors are not leaked")
+}
+
+func TestListUsers_Pagination(t *testing.T) {
+	svc, store := newService()
+
+	_, err := svc.ListUsers(context.Background(), &pb.ListUsersRequest{Page: 3, Limit: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 20, store.limit)
+	assert.Equal(t, 40, store.skip)
+
+	_, err = svc.ListUsers(context.Background(), &pb.ListUsersRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 10, store.limit)
+	assert.Equal(t, 0, store.skip)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/XSAM/otelsql"
+	_ "github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"google.golang.org/grpc"
+
+	"github.com/e6a5/learning/backend/18-distributed-tracing/internal/telemetry"
+	pb "github.com/e6a5/learning/backend/18-distributed-tracing/proto"
+	"github.com/e6a5/learning/backend/18-distributed-tracing/userservice/internal/repository"
+	"github.com/e6a5/learning/backend/18-distributed-tracing/userservice/internal/service"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+func main() {
+	shutdownTracing, err := telemetry.Setup(context.Background(), "user-service")
+	if err != nil {
+		log.Fatal("Failed to set up tracing:", err)
+	}
+
+	// Initialize database connection
+	db, err := initializeDatabase()
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer db.Close()
+
+	// The stats handler starts a server span for every call, continuing
+	// the trace from the traceparent the client sent in gRPC metadata
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.UnaryInterceptor(baggageInterceptor),
+	)
+	pb.RegisterUserServiceServer(grpcServer, service.NewUserService(repository.NewUserRepository(db)))
+
+	port := env.Get("GRPC_PORT", "50051")
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		log.Fatalf("Failed to listen on port %s: %v", port, err)
+	}
+	go func() {
+		log.Printf("👤 User service running on port %s", port)
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Fatalf("Failed to serve: %v", err)
+		}
+	}()
+
+	sig, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-sig.Done()
+
+	log.Println("Shutting down server...")
+	grpcServer.GracefulStop()
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Println("Failed to flush spans:", err)
+	}
+	log.Println("Server exited")
+}
+
+// initializeDatabase opens MySQL through otelsql, which wraps the driver
+// so that every query, exec and transaction creates a span
+func initializeDatabase() (*sql.DB, error) {
+	dsn := env.Get("DB_DSN", "user:pass@tcp(localhost:3306)/tracelab?parseTime=true")
+
+	db, err := otelsql.Open("mysql", dsn,
+		otelsql.WithAttributes(semconv.DBSystemMySQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			// Skip the spans that add noise rather than insight
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
+}
+
+// baggageInterceptor records the baggage that came with a call on the
+// server span, so the demo's scenario name is searchable here too
+func baggageInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	telemetry.AddBaggage(ctx)
+	return handler(ctx, req)
+}
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS flags (
+    flag_key VARCHAR(64) PRIMARY KEY,
+    description VARCHAR(500) NOT NULL DEFAULT '',
+    -- The kill switch: off means off for everyone
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    rollout TINYINT UNSIGNED NOT NULL DEFAULT 0,
+    -- User IDs that always get the feature, as a JSON array
+    targets JSON NOT NULL,
+    -- Bumped on every change; clients keep the highest they have seen
+    version INT NOT NULL DEFAULT 1,
+    updated_at DATETIME NOT NULL
+);
+
+INSERT INTO flags (flag_key, description, enabled, rollout, targets, updated_at) VALUES
+    ('users-newest-first', 'List users newest first in 01-http-server', TRUE, 50, '["alice"]', NOW()),
+    ('new-checkout', 'The redesigned checkout flow', TRUE, 10, '["alice", "bob"]', NOW()),
+    ('dark-mode', 'Dark theme for the web app', FALSE, 100, '[]', NOW());
@@ -0,0 +1,68 @@
+package flag
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	f := Flag{Key: "new-checkout", Enabled: true, Rollout: 0, Targets: []string{"alice"}}
+
+	e := f.Evaluate("alice")
+	assert.True(t, e.Enabled)
+	assert.Equal(t, ReasonTarget, e.Reason)
+
+	e = f.Evaluate("bob")
+	assert.False(t, e.Enabled)
+	assert.Equal(t, ReasonRollout, e.Reason)
+
+	f.Rollout = 100
+	assert.True(t, f.Evaluate("bob").Enabled)
+
+	// The kill switch wins over targeting
+	f.Enabled = false
+	e = f.Evaluate("alice")
+	assert.False(t, e.Enabled)
+	assert.Equal(t, ReasonDisabled, e.Reason)
+}
+
+func TestBucket_Stable(t *testing.T) {
+	assert.Equal(t, Bucket("new-checkout", "alice"), Bucket("new-checkout", "alice"))
+}
+
+func TestRollout_Distribution(t *testing.T) {
+	f := Flag{Key: "new-checkout", Enabled: true, Rollout: 25}
+	on := 0
+	for i := 0; i < 10000; i++ {
+		if f.Evaluate(fmt.Sprintf("user-%d", i)).Enabled {
+			on++
+		}
+	}
+	assert.InDelta(t, 2500, on, 250)
+}
+
+func TestRollout_Monotonic(t *testing.T) {
+	// Raising the rollout only adds users
+	low := Flag{Key: "new-checkout", Enabled: true, Rollout: 10}
+	high := Flag{Key: "new-checkout", Enabled: true, Rollout: 30}
+	for i := 0; i < 1000; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		if low.Evaluate(user).Enabled {
+			assert.True(t, high.Evaluate(user).Enabled, user)
+		}
+	}
+}
+
+func TestBucket_IndependentPerFlag(t *testing.T) {
+	same := 0
+	for i := 0; i < 1000; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		if (Bucket("flag-a", user) < 50) == (Bucket("flag-b", user) < 50) {
+			same++
+		}
+	}
+	// Independent flags agree about half the time, not always
+	assert.InDelta(t, 500, same, 100)
+}
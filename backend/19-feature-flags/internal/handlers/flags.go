@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/19-feature-flags/flag"
+	"github.com/e6a5/learning/backend/19-feature-flags/internal/models"
+	"github.com/e6a5/learning/backend/19-feature-flags/internal/repository"
+	"github.com/e6a5/learning/backend/19-feature-flags/internal/stream"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// Store is the flag storage the handlers need
+type Store interface {
+	Ping(ctx context.Context) error
+	List(ctx context.Context) ([]flag.Flag, error)
+	Get(ctx context.Context, key string) (flag.Flag, error)
+	Create(ctx context.Context, req models.CreateFlagRequest) (flag.Flag, error)
+	Update(ctx context.Context, key string, req models.UpdateFlagRequest) (flag.Flag, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// FlagHandler manages flags. Every change is published to the broker
+// after it is stored, so connected SDKs hear about it within moments.
+type FlagHandler struct {
+	store  Store
+	broker *stream.Broker
+}
+
+// NewFlagHandler creates a new flag handler
+func NewFlagHandler(store Store, broker *stream.Broker) *FlagHandler {
+	return &FlagHandler{store: store, broker: broker}
+}
+
+// ListFlags handles GET /flags - every flag
+func (h *FlagHandler) ListFlags(w http.ResponseWriter, r *http.Request) {
+	flags, err := h.store.List(r.Context())
+	if err != nil {
+		respondError(w, err, "list flags")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: flags})
+}
+
+// GetFlag handles GET /flags/{key}
+func (h *FlagHandler) GetFlag(w http.ResponseWriter, r *http.Request) {
+	f, err := h.store.Get(r.Context(), mux.Vars(r)["key"])
+	if err != nil {
+		respondError(w, err, "get flag")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: f})
+}
+
+// CreateFlag handles POST /flags
+func (h *FlagHandler) CreateFlag(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateFlagRequest
+	if !decode(w, r, &req, req.Validate) {
+		return
+	}
+	f, err := h.store.Create(r.Context(), req)
+	if err != nil {
+		respondError(w, err, "create flag")
+		return
+	}
+	h.broker.Publish(flag.Event{Type: flag.EventPut, Flag: &f})
+	response.JSON(w, http.StatusCreated, response.APIResponse{Message: "Flag created", Data: f})
+}
+
+// UpdateFlag handles PUT /flags/{key} - replace the flag; send the
+// version you read to be told if someone changed it in the meantime
+func (h *FlagHandler) UpdateFlag(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateFlagRequest
+	if !decode(w, r, &req, req.Validate) {
+		return
+	}
+	f, err := h.store.Update(r.Context(), mux.Vars(r)["key"], req)
+	if err != nil {
+		respondError(w, err, "update flag")
+		return
+	}
+	h.broker.Publish(flag.Event{Type: flag.EventPut, Flag: &f})
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Flag updated", Data: f})
+}
+
+// DeleteFlag handles DELETE /flags/{key}
+func (h *FlagHandler) DeleteFlag(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if err := h.store.Delete(r.Context(), key); err != nil {
+		respondError(w, err, "delete flag")
+		return
+	}
+	h.broker.Publish(flag.Event{Type: flag.EventDelete, Key: key})
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Flag deleted"})
+}
+
+// EvaluateFlag handles GET /flags/{key}/evaluate?user_id= - the answer
+// for one user and why, for clients that do not use the SDK
+func (h *FlagHandler) EvaluateFlag(w http.ResponseWriter, r *http.Request) {
+	f, err := h.store.Get(r.Context(), mux.Vars(r)["key"])
+	if err != nil {
+		respondError(w, err, "evaluate flag")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: f.Evaluate(r.URL.Query().Get("user_id"))})
+}
+
+// EvaluateAll handles GET /evaluate?user_id= - every flag for one user
+func (h *FlagHandler) EvaluateAll(w http.ResponseWriter, r *http.Request) {
+	flags, err := h.store.List(r.Context())
+	if err != nil {
+		respondError(w, err, "evaluate flags")
+		return
+	}
+	userID := r.URL.Query().Get("user_id")
+	evaluations := make([]flag.Evaluation, 0, len(flags))
+	for _, f := range flags {
+		evaluations = append(evaluations, f.Evaluate(userID))
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: evaluations})
+}
+
+// HealthCheck handles GET /health
+func (h *FlagHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.Ping(r.Context()); err != nil {
+		response.Error(w, http.StatusServiceUnavailable, "Database unavailable")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: "OK",
+		Data:    map[string]int{"subscribers": h.broker.Subscribers()},
+	})
+}
+
+// decode reads a JSON body and validates it, answering 400 on failure
+func decode(w http.ResponseWriter, r *http.Request, req interface{}, validate func() error) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON")
+		return false
+	}
+	if err := validate(); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+func respondError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, repository.ErrFlagNotFound):
+		response.Error(w, http.StatusNotFound, "Flag not found")
+	case errors.Is(err, repository.ErrFlagExists):
+		response.Error(w, http.StatusConflict, "Flag already exists")
+	case errors.Is(err, repository.ErrVersionConflict):
+		response.Error(w, http.StatusConflict, "Flag was changed by someone else; reload it and try again")
+	default:
+		log.Printf("Failed to %s: %v", action, err)
+		response.Error(w, http.StatusInternalServerError, "Failed to "+action)
+	}
+}
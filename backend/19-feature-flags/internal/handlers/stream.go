@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/e6a5/learning/backend/19-feature-flags/flag"
+	"github.com/e6a5/learning/backend/19-feature-flags/internal/stream"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// StreamHandler serves flag changes as server-sent events
+type StreamHandler struct {
+	store     Store
+	broker    *stream.Broker
+	heartbeat time.Duration
+}
+
+// NewStreamHandler creates a stream handler that writes a comment line
+// every heartbeat, so idle connections are not cut by proxies and dead
+// ones are noticed by clients
+func NewStreamHandler(store Store, broker *stream.Broker, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{store: store, broker: broker, heartbeat: heartbeat}
+}
+
+// Stream handles GET /stream - a snapshot of every flag, then each change
+// as it happens
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		response.Error(w, http.StatusInternalServerError, "Streaming unsupported")
+		return
+	}
+
+	// Subscribe before reading the snapshot. A change made in between is
+	// then both in the snapshot and on the channel; the client ignores
+	// versions it already has. The other order could lose it.
+	sub := h.broker.Subscribe()
+	if sub == nil {
+		response.Error(w, http.StatusServiceUnavailable, "Shutting down")
+		return
+	}
+	defer h.broker.Unsubscribe(sub)
+
+	flags, err := h.store.List(r.Context())
+	if err != nil {
+		respondError(w, err, "load flags")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // keep nginx from buffering events
+	w.WriteHeader(http.StatusOK)
+	if err := writeEvent(w, flag.Event{Type: flag.EventSnapshot, Flags: flags}); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-sub.Events():
+			if !ok {
+				// Too slow, or shutting down: the client reconnects
+				return
+			}
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes one server-sent event: a name and one line of JSON
+func writeEvent(w http.ResponseWriter, e flag.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", e.Type, err)
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+	return err
+}
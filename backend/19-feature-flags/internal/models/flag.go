@@ -0,0 +1,69 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// MaxTargets keeps targeting lists small enough to ship to every client
+const MaxTargets = 1000
+
+var keyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// CreateFlagRequest is the body of POST /flags
+type CreateFlagRequest struct {
+	Key string `json:"key"`
+	UpdateFlagRequest
+}
+
+// Validate validates a create flag request
+func (r *CreateFlagRequest) Validate() error {
+	r.Key = strings.TrimSpace(r.Key)
+	if !keyPattern.MatchString(r.Key) {
+		return &validation.Error{Field: "key", Message: "Key must be 1-64 lowercase letters, digits, '.', '_' or '-'"}
+	}
+	return r.UpdateFlagRequest.Validate()
+}
+
+// UpdateFlagRequest is the body of PUT /flags/{key}: the whole flag, not
+// a patch. Version, if set, must match the stored one.
+type UpdateFlagRequest struct {
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+	Rollout     int      `json:"rollout"`
+	Targets     []string `json:"targets"`
+	Version     int      `json:"version"`
+}
+
+// Validate validates an update flag request
+func (r *UpdateFlagRequest) Validate() error {
+	r.Description = strings.TrimSpace(r.Description)
+	if len(r.Description) > 500 {
+		return &validation.Error{Field: "description", Message: "Description must be at most 500 characters"}
+	}
+	if r.Rollout < 0 || r.Rollout > 100 {
+		return &validation.Error{Field: "rollout", Message: "Rollout must be between 0 and 100"}
+	}
+	if len(r.Targets) > MaxTargets {
+		return &validation.Error{Field: "targets", Message: fmt.Sprintf("At most %d targets", MaxTargets)}
+	}
+
+	// Trim and drop duplicates, so the stored list is clean
+	seen := make(map[string]bool, len(r.Targets))
+	targets := make([]string, 0, len(r.Targets))
+	for _, target := range r.Targets {
+		target = strings.TrimSpace(target)
+		if target == "" || len(target) > 128 {
+			return &validation.Error{Field: "targets", Message: "Targets must be 1-128 characters"}
+		}
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+	r.Targets = targets
+	return nil
+}
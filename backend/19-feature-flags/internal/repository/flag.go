@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/e6a5/learning/backend/19-feature-flags/flag"
+	"github.com/e6a5/learning/backend/19-feature-flags/internal/models"
+)
+
+// Repository errors
+var (
+	ErrFlagNotFound    = errors.New("flag not found")
+	ErrFlagExists      = errors.New("flag already exists")
+	ErrVersionConflict = errors.New("flag was changed by someone else")
+)
+
+const flagColumns = "flag_key, description, enabled, rollout, targets, version, updated_at"
+
+// FlagRepository handles flag database operations
+type FlagRepository struct {
+	db *sql.DB
+}
+
+// NewFlagRepository creates a new flag repository
+func NewFlagRepository(db *sql.DB) *FlagRepository {
+	return &FlagRepository{db: db}
+}
+
+// Ping checks the database connection
+func (r *FlagRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// List returns every flag ordered by key
+func (r *FlagRepository) List(ctx context.Context) ([]flag.Flag, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+flagColumns+" FROM flags ORDER BY flag_key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []flag.Flag{}
+	for rows.Next() {
+		f, err := scanFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return flags, nil
+}
+
+// Get returns one flag
+func (r *FlagRepository) Get(ctx context.Context, key string) (flag.Flag, error) {
+	return getFlag(r.db.QueryRowContext(ctx, "SELECT "+flagColumns+" FROM flags WHERE flag_key = ?", key))
+}
+
+// Create stores a new flag at version 1
+func (r *FlagRepository) Create(ctx context.Context, req models.CreateFlagRequest) (flag.Flag, error) {
+	f := flag.Flag{
+		Key:         req.Key,
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		Rollout:     req.Rollout,
+		Targets:     req.Targets,
+		Version:     1,
+		UpdatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+	targets, err := json.Marshal(f.Targets)
+	if err != nil {
+		return flag.Flag{}, fmt.Errorf("failed to encode targets: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		"INSERT INTO flags ("+flagColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		f.Key, f.Description, f.Enabled, f.Rollout, targets, f.Version, f.UpdatedAt)
+	// MySQL error 1062: duplicate key
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return flag.Flag{}, ErrFlagExists
+	}
+	if err != nil {
+		return flag.Flag{}, fmt.Errorf("failed to create flag: %w", err)
+	}
+	return f, nil
+}
+
+// Update replaces a flag and bumps its version. A non-zero req.Version
+// must match the stored version, so two people editing the same flag
+// cannot silently overwrite each other.
+func (r *FlagRepository) Update(ctx context.Context, key string, req models.UpdateFlagRequest) (flag.Flag, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return flag.Flag{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	f, err := getFlag(tx.QueryRowContext(ctx, "SELECT "+flagColumns+" FROM flags WHERE flag_key = ? FOR UPDATE", key))
+	if err != nil {
+		return flag.Flag{}, err
+	}
+	if req.Version != 0 && req.Version != f.Version {
+		return flag.Flag{}, ErrVersionConflict
+	}
+
+	f.Description = req.Description
+	f.Enabled = req.Enabled
+	f.Rollout = req.Rollout
+	f.Targets = req.Targets
+	f.Version++
+	f.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	targets, err := json.Marshal(f.Targets)
+	if err != nil {
+		return flag.Flag{}, fmt.Errorf("failed to encode targets: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE flags SET description = ?, enabled = ?, rollout = ?, targets = ?, version = ?, updated_at = ? WHERE flag_key = ?",
+		f.Description, f.Enabled, f.Rollout, targets, f.Version, f.UpdatedAt, key)
+	if err != nil {
+		return flag.Flag{}, fmt.Errorf("failed to update flag: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return flag.Flag{}, fmt.Errorf("failed to commit flag: %w", err)
+	}
+	return f, nil
+}
+
+// Delete deletes a flag
+func (r *FlagRepository) Delete(ctx context.Context, key string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM flags WHERE flag_key = ?", key)
+	if err != nil {
+		return fmt.Errorf("failed to delete flag: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrFlagNotFound
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func getFlag(row *sql.Row) (flag.Flag, error) {
+	f, err := scanFlag(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return flag.Flag{}, ErrFlagNotFound
+	}
+	return f, err
+}
+
+func scanFlag(s scanner) (flag.Flag, error) {
+	var f flag.Flag
+	var targets []byte
+	if err := s.Scan(&f.Key, &f.Description, &f.Enabled, &f.Rollout, &targets, &f.Version, &f.UpdatedAt); err != nil {
+		return flag.Flag{}, fmt.Errorf("failed to scan flag: %w", err)
+	}
+	if err := json.Unmarshal(targets, &f.Targets); err != nil {
+		return flag.Flag{}, fmt.Errorf("failed to decode targets of %s: %w", f.Key, err)
+	}
+	return f, nil
+}
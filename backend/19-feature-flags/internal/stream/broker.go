@@ -0,0 +1,92 @@
+// Package stream fans flag changes out to connected clients
+package stream
+
+import (
+	"sync"
+
+	"github.com/e6a5/learning/backend/19-feature-flags/flag"
+)
+
+// Subscription is one client's queue of events. The channel is closed
+// when the client falls too far behind or the broker shuts down.
+type Subscription struct {
+	events chan flag.Event
+}
+
+// Events returns the channel events arrive on
+func (s *Subscription) Events() <-chan flag.Event {
+	return s.events
+}
+
+// Broker delivers every published event to every subscription. It never
+// waits for a slow client: a subscription whose buffer is full is closed,
+// and the client reconnects and starts over from a fresh snapshot. One
+// stuck connection cannot hold up flag changes for everyone else.
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	buffer int
+	closed bool
+}
+
+// NewBroker creates a broker whose subscriptions buffer up to buffer events
+func NewBroker(buffer int) *Broker {
+	return &Broker{subs: make(map[*Subscription]struct{}), buffer: buffer}
+}
+
+// Subscribe starts receiving events. It returns nil after Close.
+func (b *Broker) Subscribe() *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	s := &Subscription{events: make(chan flag.Event, b.buffer)}
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// Unsubscribe stops receiving events; it is safe to call more than once
+func (b *Broker) Unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(s)
+}
+
+// Publish sends an event to every subscription without blocking
+func (b *Broker) Publish(e flag.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		select {
+		case s.events <- e:
+		default:
+			b.remove(s)
+		}
+	}
+}
+
+// Subscribers returns how many clients are connected
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Close ends every subscription and refuses new ones. Streams never end
+// by themselves, so without this a graceful shutdown would wait forever.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		b.remove(s)
+	}
+}
+
+func (b *Broker) remove(s *Subscription) {
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.events)
+	}
+}
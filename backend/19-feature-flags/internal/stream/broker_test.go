@@ -0,0 +1,54 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/19-feature-flags/flag"
+)
+
+func TestBroker_DeliversToEverySubscriber(t *testing.T) {
+	b := NewBroker(4)
+	s1, s2 := b.Subscribe(), b.Subscribe()
+
+	b.Publish(flag.Event{Type: flag.EventDelete, Key: "a"})
+	assert.Equal(t, "a", (<-s1.Events()).Key)
+	assert.Equal(t, "a", (<-s2.Events()).Key)
+
+	b.Unsubscribe(s1)
+	b.Unsubscribe(s1)
+	_, open := <-s1.Events()
+	assert.False(t, open)
+	assert.Equal(t, 1, b.Subscribers())
+}
+
+func TestBroker_DropsSlowSubscriber(t *testing.T) {
+	b := NewBroker(2)
+	slow, fast := b.Subscribe(), b.Subscribe()
+
+	for i := 0; i < 3; i++ {
+		b.Publish(flag.Event{Type: flag.EventDelete, Key: "a"})
+		<-fast.Events()
+	}
+
+	// The slow one gets what fit in its buffer, then a closed channel
+	assert.Len(t, slow.Events(), 2)
+	<-slow.Events()
+	<-slow.Events()
+	_, open := <-slow.Events()
+	assert.False(t, open)
+	assert.Equal(t, 1, b.Subscribers())
+}
+
+func TestBroker_Close(t *testing.T) {
+	b := NewBroker(2)
+	s := b.Subscribe()
+	b.Close()
+
+	_, open := <-s.Events()
+	assert.False(t, open)
+	require.Nil(t, b.Subscribe())
+	b.Publish(flag.Event{Type: flag.EventDelete, Key: "a"})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/19-feature-flags/internal/handlers"
+	"github.com/e6a5/learning/backend/19-feature-flags/internal/repository"
+	"github.com/e6a5/learning/backend/19-feature-flags/internal/stream"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+func main() {
+	// Initialize database connection
+	db, err := initializeDatabase()
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewFlagRepository(db)
+	broker := stream.NewBroker(getEnvInt("STREAM_BUFFER", 64))
+	flagHandler := handlers.NewFlagHandler(repo, broker)
+	streamHandler := handlers.NewStreamHandler(repo, broker, getEnvDuration("HEARTBEAT_INTERVAL", 15*time.Second))
+
+	// No WriteTimeout: a stream is one response that never ends
+	port := env.Get("PORT", "8080")
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           setupRoutes(flagHandler, streamHandler),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Printf("🚩 Feature flag service running at http://localhost:%s", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	sig, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-sig.Done()
+
+	log.Println("Shutting down server...")
+	// Shutdown waits for handlers to return, and streams only return when
+	// their subscription ends. Clients reconnect to another instance.
+	broker.Close()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
+	log.Println("Server exited")
+}
+
+func initializeDatabase() (*sql.DB, error) {
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		dsn = "user:pass@tcp(localhost:3306)/flagslab?parseTime=true"
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func setupRoutes(flagHandler *handlers.FlagHandler, streamHandler *handlers.StreamHandler) *mux.Router {
+	router := mux.NewRouter()
+
+	// Flag management
+	router.HandleFunc("/flags", flagHandler.CreateFlag).Methods("POST")
+	router.HandleFunc("/flags", flagHandler.ListFlags).Methods("GET")
+	router.HandleFunc("/flags/{key}", flagHandler.GetFlag).Methods("GET")
+	router.HandleFunc("/flags/{key}", flagHandler.UpdateFlag).Methods("PUT")
+	router.HandleFunc("/flags/{key}", flagHandler.DeleteFlag).Methods("DELETE")
+
+	// Evaluation, for clients without the SDK
+	router.HandleFunc("/flags/{key}/evaluate", flagHandler.EvaluateFlag).Methods("GET")
+	router.HandleFunc("/evaluate", flagHandler.EvaluateAll).Methods("GET")
+
+	// Changes as server-sent events, for the SDK
+	router.HandleFunc("/stream", streamHandler.Stream).Methods("GET")
+
+	// Health check
+	router.HandleFunc("/health", flagHandler.HealthCheck).Methods("GET")
+
+	return router
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(env.Get(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		log.Fatalf("%s must be a number: %v", key, err)
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(env.Get(key, defaultValue.String()))
+	if err != nil {
+		log.Fatalf("%s must be a duration like 2s: %v", key, err)
+	}
+	return value
+}
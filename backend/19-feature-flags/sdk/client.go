@@ -0,0 +1,276 @@
+// Package sdk is the client side of the feature flag service. A Client
+// keeps a copy of every flag in memory and evaluates them locally, so
+// checking a flag costs a map lookup rather than a network call. The
+// copy is kept current over a stream from the service: a change made
+// through the API reaches every client within moments.
+//
+// When the service is unreachable the client keeps the flags it last
+// saw and reconnects in the background. Before it has seen any, every
+// check returns the fallback the caller passed in.
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/e6a5/learning/backend/19-feature-flags/flag"
+)
+
+// Client evaluates flags from a local copy kept in sync with the service
+type Client struct {
+	baseURL     string
+	http        *http.Client
+	minRetry    time.Duration
+	maxRetry    time.Duration
+	idleTimeout time.Duration
+	logf        func(format string, args ...interface{})
+	onChange    func(key string)
+
+	mu        sync.RWMutex
+	flags     map[string]flag.Flag
+	connected bool
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for the stream. It must not
+// have a Timeout: the stream is one request that lasts for hours.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithRetry sets the reconnect delay, which doubles from min to max while
+// the service stays unreachable
+func WithRetry(min, max time.Duration) Option {
+	return func(c *Client) { c.minRetry, c.maxRetry = min, max }
+}
+
+// WithIdleTimeout sets how long the stream may stay silent before the
+// client gives up on it and reconnects. It should be a few times the
+// service's heartbeat.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(c *Client) { c.idleTimeout = d }
+}
+
+// WithLogger sets where connection changes are logged
+func WithLogger(logf func(format string, args ...interface{})) Option {
+	return func(c *Client) { c.logf = logf }
+}
+
+// WithOnChange registers a function called with the key of every flag
+// that is created, changed or deleted. It runs on the stream goroutine,
+// so it should return quickly.
+func WithOnChange(fn func(key string)) Option {
+	return func(c *Client) { c.onChange = fn }
+}
+
+// New creates a client for the service at baseURL. Call Run to connect.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		http:        &http.Client{},
+		minRetry:    500 * time.Millisecond,
+		maxRetry:    30 * time.Second,
+		idleTimeout: time.Minute,
+		logf:        log.Printf,
+		flags:       make(map[string]flag.Flag),
+		ready:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Run keeps the local copy in sync until ctx is cancelled, reconnecting
+// whenever the stream breaks
+func (c *Client) Run(ctx context.Context) {
+	delay := c.minRetry
+	for {
+		connected, err := c.stream(ctx)
+		c.setConnected(false)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			// The stream worked for a while: start the backoff over
+			delay = c.minRetry
+		}
+		wait := delay/2 + rand.N(delay/2+1) // jitter, so clients do not reconnect in lockstep
+		c.logf("Feature flag stream lost (%v), reconnecting in %s", err, wait.Round(time.Millisecond))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		delay = min(delay*2, c.maxRetry)
+	}
+}
+
+// Ready is closed once the first snapshot has arrived
+func (c *Client) Ready() <-chan struct{} {
+	return c.ready
+}
+
+// WaitReady waits for the first snapshot or for ctx to end. Services can
+// call it at startup with a short timeout and carry on either way.
+func (c *Client) WaitReady(ctx context.Context) error {
+	select {
+	case <-c.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Connected reports whether the stream is up. When it is down, flags are
+// answered from the last copy, which may be stale.
+func (c *Client) Connected() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.connected
+}
+
+// Enabled reports whether userID gets the feature behind key. fallback is
+// returned for a flag the client does not know: one that does not exist,
+// or any flag before the first snapshot.
+func (c *Client) Enabled(key, userID string, fallback bool) bool {
+	e := c.Evaluate(key, userID)
+	if e.Reason == flag.ReasonMissing {
+		return fallback
+	}
+	return e.Enabled
+}
+
+// Evaluate returns the full evaluation, including the reason
+func (c *Client) Evaluate(key, userID string) flag.Evaluation {
+	c.mu.RLock()
+	f, ok := c.flags[key]
+	c.mu.RUnlock()
+	if !ok {
+		return flag.Evaluation{Key: key, UserID: userID, Reason: flag.ReasonMissing}
+	}
+	return f.Evaluate(userID)
+}
+
+// Flags returns the local copy, ordered by key
+func (c *Client) Flags() []flag.Flag {
+	c.mu.RLock()
+	flags := make([]flag.Flag, 0, len(c.flags))
+	for _, f := range c.flags {
+		flags = append(flags, f)
+	}
+	c.mu.RUnlock()
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	return flags
+}
+
+// stream reads one connection until it fails. connected is true if the
+// snapshot arrived, so the connection was good for a while at least.
+func (c *Client) stream(ctx context.Context) (connected bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/stream", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	// A connection that dies without a FIN looks idle forever. The
+	// heartbeat means silence is never normal, so silence cancels the
+	// request and the reader fails.
+	var timedOut atomic.Bool
+	idle := time.AfterFunc(c.idleTimeout, func() {
+		timedOut.Store(true)
+		cancel()
+	})
+	defer idle.Stop()
+
+	events := newEventReader(resp.Body)
+	for {
+		e, err := events.next(func() { idle.Reset(c.idleTimeout) })
+		if err != nil {
+			if timedOut.Load() {
+				err = fmt.Errorf("no data for %s", c.idleTimeout)
+			}
+			return connected, err
+		}
+		if e.Type == flag.EventSnapshot && !connected {
+			connected = true
+			c.setConnected(true)
+			c.logf("Feature flag stream connected, %d flags", len(e.Flags))
+		}
+		c.apply(e)
+	}
+}
+
+// apply updates the local copy. Puts are applied only if they are newer
+// than what the client has: the snapshot may already include a change
+// that arrives again as an event.
+func (c *Client) apply(e flag.Event) {
+	var changed []string
+	c.mu.Lock()
+	switch e.Type {
+	case flag.EventSnapshot:
+		flags := make(map[string]flag.Flag, len(e.Flags))
+		for _, f := range e.Flags {
+			flags[f.Key] = f
+			if old, ok := c.flags[f.Key]; !ok || old.Version != f.Version {
+				changed = append(changed, f.Key)
+			}
+		}
+		for key := range c.flags {
+			if _, ok := flags[key]; !ok {
+				changed = append(changed, key)
+			}
+		}
+		c.flags = flags
+	case flag.EventPut:
+		if e.Flag != nil && e.Flag.Version > c.flags[e.Flag.Key].Version {
+			c.flags[e.Flag.Key] = *e.Flag
+			changed = append(changed, e.Flag.Key)
+		}
+	case flag.EventDelete:
+		if _, ok := c.flags[e.Key]; ok {
+			delete(c.flags, e.Key)
+			changed = append(changed, e.Key)
+		}
+	}
+	c.mu.Unlock()
+
+	if e.Type == flag.EventSnapshot {
+		c.readyOnce.Do(func() { close(c.ready) })
+	}
+	if c.onChange != nil {
+		for _, key := range changed {
+			c.onChange(key)
+		}
+	}
+}
+
+func (c *Client) setConnected(connected bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connected = connected
+}
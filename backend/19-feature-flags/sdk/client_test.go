@@ -0,0 +1,237 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/19-feature-flags/flag"
+	"github.com/e6a5/learning/backend/19-feature-flags/internal/handlers"
+	"github.com/e6a5/learning/backend/19-feature-flags/internal/models"
+	"github.com/e6a5/learning/backend/19-feature-flags/internal/repository"
+	"github.com/e6a5/learning/backend/19-feature-flags/internal/stream"
+)
+
+// memoryStore stands in for MySQL behind the real handlers
+type memoryStore struct {
+	mu    sync.Mutex
+	flags map[string]flag.Flag
+}
+
+func (s *memoryStore) Ping(ctx context.Context) error { return nil }
+
+func (s *memoryStore) List(ctx context.Context) ([]flag.Flag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flags := []flag.Flag{}
+	for _, f := range s.flags {
+		flags = append(flags, f)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	return flags, nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) (flag.Flag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.flags[key]
+	if !ok {
+		return flag.Flag{}, repository.ErrFlagNotFound
+	}
+	return f, nil
+}
+
+func (s *memoryStore) Create(ctx context.Context, req models.CreateFlagRequest) (flag.Flag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.flags[req.Key]; ok {
+		return flag.Flag{}, repository.ErrFlagExists
+	}
+	f := flag.Flag{Key: req.Key, Enabled: req.Enabled, Rollout: req.Rollout, Targets: req.Targets, Version: 1}
+	s.flags[f.Key] = f
+	return f, nil
+}
+
+func (s *memoryStore) Update(ctx context.Context, key string, req models.UpdateFlagRequest) (flag.Flag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.flags[key]
+	if !ok {
+		return flag.Flag{}, repository.ErrFlagNotFound
+	}
+	f.Enabled, f.Rollout, f.Targets = req.Enabled, req.Rollout, req.Targets
+	f.Version++
+	s.flags[key] = f
+	return f, nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.flags[key]; !ok {
+		return repository.ErrFlagNotFound
+	}
+	delete(s.flags, key)
+	return nil
+}
+
+type testService struct {
+	*httptest.Server
+	store *memoryStore
+}
+
+func newTestService(t *testing.T, flags ...flag.Flag) *testService {
+	store := &memoryStore{flags: map[string]flag.Flag{}}
+	for _, f := range flags {
+		store.flags[f.Key] = f
+	}
+	broker := stream.NewBroker(16)
+	flagHandler := handlers.NewFlagHandler(store, broker)
+	streamHandler := handlers.NewStreamHandler(store, broker, time.Hour)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/flags", flagHandler.CreateFlag).Methods("POST")
+	router.HandleFunc("/flags/{key}", flagHandler.UpdateFlag).Methods("PUT")
+	router.HandleFunc("/flags/{key}", flagHandler.DeleteFlag).Methods("DELETE")
+	router.HandleFunc("/stream", streamHandler.Stream).Methods("GET")
+
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		broker.Close()
+		server.Close()
+	})
+	return &testService{Server: server, store: store}
+}
+
+func (s *testService) call(t *testing.T, method, path, body string) {
+	req, err := http.NewRequest(method, s.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Less(t, resp.StatusCode, 300, "%s %s", method, path)
+}
+
+// startClient runs a client until the test ends and reports changed keys
+func startClient(t *testing.T, url string, opts ...Option) (*Client, <-chan string) {
+	changes := make(chan string, 16)
+	opts = append([]Option{
+		WithRetry(10*time.Millisecond, 50*time.Millisecond),
+		WithLogger(t.Logf),
+		WithOnChange(func(key string) { changes <- key }),
+	}, opts...)
+	c := New(url, opts...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return c, changes
+}
+
+func waitChange(t *testing.T, changes <-chan string, want string) {
+	t.Helper()
+	select {
+	case key := <-changes:
+		assert.Equal(t, want, key)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no change to %s", want)
+	}
+}
+
+func TestClient_SnapshotAndUpdates(t *testing.T) {
+	svc := newTestService(t, flag.Flag{Key: "dark-mode", Enabled: true, Rollout: 100, Version: 1})
+	c, changes := startClient(t, svc.URL)
+
+	// Unknown flags fall back until the snapshot arrives
+	assert.True(t, c.Enabled("dark-mode", "alice", true))
+	waitChange(t, changes, "dark-mode")
+	require.NoError(t, c.WaitReady(context.Background()))
+	assert.True(t, c.Connected())
+	assert.True(t, c.Enabled("dark-mode", "alice", false))
+
+	svc.call(t, http.MethodPost, "/flags", `{"key":"new-checkout","enabled":true,"targets":["alice"]}`)
+	waitChange(t, changes, "new-checkout")
+	assert.True(t, c.Enabled("new-checkout", "alice", false))
+	assert.False(t, c.Enabled("new-checkout", "bob", true))
+
+	svc.call(t, http.MethodPut, "/flags/dark-mode", `{"enabled":false}`)
+	waitChange(t, changes, "dark-mode")
+	assert.Equal(t, flag.ReasonDisabled, c.Evaluate("dark-mode", "alice").Reason)
+
+	svc.call(t, http.MethodDelete, "/flags/new-checkout", "")
+	waitChange(t, changes, "new-checkout")
+	assert.Equal(t, flag.ReasonMissing, c.Evaluate("new-checkout", "alice").Reason)
+	assert.Len(t, c.Flags(), 1)
+}
+
+func TestClient_ResyncsAfterReconnect(t *testing.T) {
+	svc := newTestService(t, flag.Flag{Key: "dark-mode", Enabled: true, Rollout: 100, Version: 1})
+	c, changes := startClient(t, svc.URL)
+	waitChange(t, changes, "dark-mode")
+
+	// Changed while the client is cut off: no event reaches it, but the
+	// snapshot on reconnect does
+	svc.store.mu.Lock()
+	svc.store.flags["dark-mode"] = flag.Flag{Key: "dark-mode", Enabled: false, Version: 2}
+	svc.store.mu.Unlock()
+	svc.CloseClientConnections()
+
+	waitChange(t, changes, "dark-mode")
+	assert.False(t, c.Enabled("dark-mode", "alice", true))
+}
+
+func TestClient_IgnoresStaleEvents(t *testing.T) {
+	c := New("http://unused")
+	c.apply(flag.Event{Type: flag.EventSnapshot, Flags: []flag.Flag{{Key: "a", Enabled: true, Rollout: 100, Version: 3}}})
+	c.apply(flag.Event{Type: flag.EventPut, Flag: &flag.Flag{Key: "a", Enabled: false, Version: 2}})
+	assert.True(t, c.Enabled("a", "alice", false))
+}
+
+func TestClient_ReconnectsWhenIdle(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connections.Add(1)
+		fmt.Fprint(w, "event: snapshot\ndata: {\"type\":\"snapshot\"}\n\n")
+		w.(http.Flusher).Flush()
+		// Then silence, like a connection that died without closing
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	startClient(t, server.URL, WithIdleTimeout(50*time.Millisecond))
+	assert.Eventually(t, func() bool { return connections.Load() >= 2 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestClient_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	c, _ := startClient(t, server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Error(t, c.WaitReady(ctx))
+	assert.False(t, c.Connected())
+	assert.True(t, c.Enabled("dark-mode", "alice", true))
+	assert.False(t, c.Enabled("dark-mode", "alice", false))
+}
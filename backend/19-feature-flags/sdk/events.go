@@ -0,0 +1,60 @@
+package sdk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/e6a5/learning/backend/19-feature-flags/flag"
+)
+
+// eventReader reads server-sent events: "field: value" lines, ended by a
+// blank line. Lines starting with a colon are comments, which the
+// service sends as heartbeats.
+type eventReader struct {
+	r *bufio.Reader
+}
+
+func newEventReader(r io.Reader) *eventReader {
+	return &eventReader{r: bufio.NewReader(r)}
+}
+
+// next returns the next event. seen is called for every line read,
+// heartbeats included.
+func (er *eventReader) next(seen func()) (flag.Event, error) {
+	var data bytes.Buffer
+	for {
+		line, err := er.r.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return flag.Event{}, err
+		}
+		seen()
+		line = bytes.TrimRight(line, "\r\n")
+
+		switch {
+		case len(line) == 0:
+			if data.Len() == 0 {
+				continue
+			}
+			var e flag.Event
+			if err := json.Unmarshal(data.Bytes(), &e); err != nil {
+				return flag.Event{}, fmt.Errorf("bad event: %w", err)
+			}
+			return e, nil
+		case line[0] == ':':
+			// comment
+		case bytes.HasPrefix(line, []byte("data:")):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.Write(bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" ")))
+		default:
+			// event: and id: lines; the type is inside the data
+		}
+	}
+}
@@ -0,0 +1,40 @@
+-- Cron schedules; next_run_at moves forward every time one fires, and
+-- moving it is how an instance claims the firing
+CREATE TABLE IF NOT EXISTS schedules (
+    id CHAR(16) PRIMARY KEY,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    cron VARCHAR(128) NOT NULL,
+    timezone VARCHAR(64) NOT NULL,
+    task VARCHAR(64) NOT NULL,
+    payload JSON NULL,
+    overlap VARCHAR(16) NOT NULL,
+    misfire VARCHAR(16) NOT NULL,
+    misfire_grace_ms BIGINT NOT NULL,
+    jitter_ms BIGINT NOT NULL,
+    timeout_ms BIGINT NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    next_run_at DATETIME(3) NOT NULL,
+    due_at DATETIME(3) NOT NULL,
+    created_at DATETIME(3) NOT NULL,
+    updated_at DATETIME(3) NOT NULL,
+    INDEX idx_due (enabled, due_at)
+);
+
+-- Every firing: the ones that ran, and the ones skipped or missed
+CREATE TABLE IF NOT EXISTS runs (
+    id CHAR(16) PRIMARY KEY,
+    schedule_id CHAR(16) NOT NULL,
+    scheduled_at DATETIME(3) NOT NULL,
+    trigger_type VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    instance VARCHAR(64) NOT NULL,
+    started_at DATETIME(3) NOT NULL,
+    heartbeat_at DATETIME(3) NOT NULL,
+    finished_at DATETIME(3) NULL,
+    error TEXT NOT NULL,
+    INDEX idx_schedule (schedule_id, started_at),
+    INDEX idx_status (status, heartbeat_at),
+    INDEX idx_started_at (started_at),
+    INDEX idx_finished_at (finished_at),
+    FOREIGN KEY (schedule_id) REFERENCES schedules (id) ON DELETE CASCADE
+);
@@ -0,0 +1,123 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"* * * FOO *",
+		"@reboot",
+		"0 0 30 2 *", // February 30th
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2025, 1, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 1, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 1, 15, 10, 15, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2025, 1, 15, 10, 25, 0, 0, time.UTC)},
+		{"0 9-17 * * *", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2025, 1, 16, 2, 30, 0, 0, time.UTC)},
+		{"0 9 * * MON-FRI", time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,20 jul,aug *", time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, s.Next(from))
+		})
+	}
+}
+
+func TestNext_StrictlyAfter(t *testing.T) {
+	s, err := Parse("0 * * * *")
+	require.NoError(t, err)
+	at := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, at.Add(time.Hour), s.Next(at))
+}
+
+func TestNext_DayOfMonthOrDayOfWeek(t *testing.T) {
+	// Both restricted: the 1st, or any Monday
+	s, err := Parse("0 0 1 * MON")
+	require.NoError(t, err)
+	next := s.Next(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC), next, "Monday the 20th")
+	next = s.Next(time.Date(2025, 1, 27, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), next, "Saturday the 1st")
+
+	// Only day of week restricted: every Monday, whatever the date
+	s, err = Parse("0 0 * * MON")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC), s.Next(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestNext_TimeZone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	s, err := Parse("0 9 * * *")
+	require.NoError(t, err)
+
+	next := s.Next(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC).In(tokyo))
+	assert.Equal(t, time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC), next.UTC(), "09:00 in Tokyo is midnight UTC")
+}
+
+func TestNext_DaylightSaving(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// 2025-03-09: 02:00 jumps to 03:00, so 02:30 does not exist that day
+	s, err := Parse("30 2 * * *")
+	require.NoError(t, err)
+	next := s.Next(time.Date(2025, 3, 8, 12, 0, 0, 0, ny))
+	assert.Equal(t, time.Date(2025, 3, 10, 2, 30, 0, 0, ny), next)
+
+	// 2025-11-02: 02:00 goes back to 01:00, and 01:30 happens twice
+	s, err = Parse("30 1 * * *")
+	require.NoError(t, err)
+	first := s.Next(time.Date(2025, 11, 2, 0, 0, 0, 0, ny))
+	assert.Equal(t, 1, first.Hour())
+	assert.Equal(t, 2, first.Day())
+	second := s.Next(first)
+	assert.Equal(t, 3, second.Day(), "runs once on the repeated hour")
+
+	// Every 30 minutes keeps going through the repeated hour
+	s, err = Parse("*/30 * * * *")
+	require.NoError(t, err)
+	at := time.Date(2025, 11, 2, 0, 45, 0, 0, ny)
+	for i := 0; i < 6; i++ {
+		next := s.Next(at)
+		require.True(t, next.After(at))
+		at = next
+	}
+}
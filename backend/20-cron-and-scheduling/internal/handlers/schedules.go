@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/cron"
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/models"
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/repository"
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/scheduler"
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/tasks"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// ScheduleHandler handles schedules, their runs and cron previews
+type ScheduleHandler struct {
+	scheduler *scheduler.Scheduler
+	schedules *repository.ScheduleRepository
+	registry  tasks.Registry
+	instance  string
+}
+
+// NewScheduleHandler creates a new schedule handler
+func NewScheduleHandler(s *scheduler.Scheduler, schedules *repository.ScheduleRepository, registry tasks.Registry, instance string) *ScheduleHandler {
+	return &ScheduleHandler{scheduler: s, schedules: schedules, registry: registry, instance: instance}
+}
+
+// CreateSchedule handles POST /schedules - runs a task on a cron expression
+func (h *ScheduleHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	sched := models.Schedule{ID: scheduler.NewID(), CreatedAt: now, UpdatedAt: now}
+	req.Apply(&sched)
+	if err := h.scheduler.Add(r.Context(), &sched); err != nil {
+		respondScheduleError(w, err, "create schedule")
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{
+		Message: "Schedule created",
+		Data:    sched,
+	})
+}
+
+// GetSchedules handles GET /schedules - every schedule, the next due first
+func (h *ScheduleHandler) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.schedules.ListSchedules(r.Context())
+	if err != nil {
+		log.Printf("Error listing schedules: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to list schedules")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"schedules": schedules,
+			"count":     len(schedules),
+		},
+	})
+}
+
+// GetSchedule handles GET /schedules/{id} - one schedule and its next runs
+func (h *ScheduleHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	sched, err := h.schedules.GetSchedule(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondScheduleError(w, err, "get schedule")
+		return
+	}
+	upcoming, err := upcomingRuns(*sched, 5)
+	if err != nil {
+		log.Printf("Error planning schedule %s: %v", sched.ID, err)
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"schedule": sched,
+			"upcoming": upcoming,
+		},
+	})
+}
+
+// UpdateSchedule handles PUT /schedules/{id} - replaces a schedule; its
+// next run is worked out again from now
+func (h *ScheduleHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+	sched, err := h.schedules.GetSchedule(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondScheduleError(w, err, "update schedule")
+		return
+	}
+
+	req.Apply(sched)
+	sched.UpdatedAt = time.Now().UTC()
+	if err := h.scheduler.Update(r.Context(), sched); err != nil {
+		respondScheduleError(w, err, "update schedule")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: "Schedule updated",
+		Data:    sched,
+	})
+}
+
+// DeleteSchedule handles DELETE /schedules/{id} - removes a schedule and
+// its run history. A run in progress finishes first.
+func (h *ScheduleHandler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	if err := h.schedules.DeleteSchedule(r.Context(), mux.Vars(r)["id"]); err != nil {
+		respondScheduleError(w, err, "delete schedule")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Schedule deleted"})
+}
+
+// PauseSchedule handles POST /schedules/{id}/pause
+func (h *ScheduleHandler) PauseSchedule(w http.ResponseWriter, r *http.Request) {
+	h.setEnabled(w, r, false)
+}
+
+// ResumeSchedule handles POST /schedules/{id}/resume - firings missed
+// while paused are not made up
+func (h *ScheduleHandler) ResumeSchedule(w http.ResponseWriter, r *http.Request) {
+	h.setEnabled(w, r, true)
+}
+
+func (h *ScheduleHandler) setEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	sched, err := h.schedules.GetSchedule(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondScheduleError(w, err, "update schedule")
+		return
+	}
+	sched.Enabled = enabled
+	sched.UpdatedAt = time.Now().UTC()
+	if err := h.scheduler.Update(r.Context(), sched); err != nil {
+		respondScheduleError(w, err, "update schedule")
+		return
+	}
+
+	message := "Schedule paused"
+	if enabled {
+		message = "Schedule resumed"
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: message, Data: sched})
+}
+
+// TriggerSchedule handles POST /schedules/{id}/run - runs it now, once
+func (h *ScheduleHandler) TriggerSchedule(w http.ResponseWriter, r *http.Request) {
+	run, err := h.scheduler.Trigger(r.Context(), mux.Vars(r)["id"])
+	switch {
+	case errors.Is(err, scheduler.ErrAlreadyRunning):
+		response.Error(w, http.StatusConflict, "A run is still going and the schedule forbids overlap")
+		return
+	case errors.Is(err, scheduler.ErrClosed):
+		response.Error(w, http.StatusServiceUnavailable, "Shutting down, try again shortly")
+		return
+	case err != nil:
+		respondScheduleError(w, err, "run schedule")
+		return
+	}
+	response.JSON(w, http.StatusAccepted, response.APIResponse{
+		Message: "Run started",
+		Data:    run,
+	})
+}
+
+// GetScheduleRuns handles GET /schedules/{id}/runs - the schedule's run
+// history, ?status= and ?limit=
+func (h *ScheduleHandler) GetScheduleRuns(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := h.schedules.GetSchedule(r.Context(), id); err != nil {
+		respondScheduleError(w, err, "list runs")
+		return
+	}
+	h.listRuns(w, r, id)
+}
+
+// GetRuns handles GET /runs - recent runs of every schedule, ?status= and
+// ?limit=
+func (h *ScheduleHandler) GetRuns(w http.ResponseWriter, r *http.Request) {
+	h.listRuns(w, r, "")
+}
+
+func (h *ScheduleHandler) listRuns(w http.ResponseWriter, r *http.Request, scheduleID string) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			response.Error(w, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.schedules.ListRuns(r.Context(), scheduleID, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		log.Printf("Error listing runs: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to list runs")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"runs":  runs,
+			"count": len(runs),
+		},
+	})
+}
+
+// GetRun handles GET /runs/{id}
+func (h *ScheduleHandler) GetRun(w http.ResponseWriter, r *http.Request) {
+	run, err := h.schedules.GetRun(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondScheduleError(w, err, "get run")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: run})
+}
+
+// CancelRun handles POST /runs/{id}/cancel - stops a run on whichever
+// instance has it
+func (h *ScheduleHandler) CancelRun(w http.ResponseWriter, r *http.Request) {
+	err := h.scheduler.Cancel(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, scheduler.ErrNotRunning) {
+		response.Error(w, http.StatusConflict, "Only running runs can be cancelled")
+		return
+	}
+	if err != nil {
+		log.Printf("Error cancelling run: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Failed to cancel run")
+		return
+	}
+	response.JSON(w, http.StatusAccepted, response.APIResponse{Message: "Cancel requested"})
+}
+
+// PreviewCron handles GET /cron/preview?expr=...&tz=...&count=n - when an
+// expression would fire, without saving anything
+func (h *ScheduleHandler) PreviewCron(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	count := 5
+	if v := q.Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 50 {
+			response.Error(w, http.StatusBadRequest, "count must be between 1 and 50")
+			return
+		}
+		count = n
+	}
+	tz := q.Get("tz")
+	if tz == "" {
+		tz = "UTC"
+	}
+	if _, err := cron.Parse(q.Get("expr")); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid cron expression: "+err.Error())
+		return
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		response.Error(w, http.StatusBadRequest, "Unknown timezone")
+		return
+	}
+
+	upcoming, err := upcomingRuns(models.Schedule{Cron: q.Get("expr"), Timezone: tz}, count)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"expr":     q.Get("expr"),
+			"timezone": tz,
+			"next":     upcoming,
+		},
+	})
+}
+
+// HealthCheck handles GET /health
+func (h *ScheduleHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	if err := h.schedules.Ping(r.Context()); err != nil {
+		response.Error(w, http.StatusServiceUnavailable, "Database unreachable")
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: "Scheduler is healthy",
+		Data: map[string]interface{}{
+			"instance": h.instance,
+			"running":  h.scheduler.Running(),
+		},
+	})
+}
+
+// decodeRequest reads and validates a schedule request, answering 400 if
+// it is no good
+func (h *ScheduleHandler) decodeRequest(w http.ResponseWriter, r *http.Request) (models.ScheduleRequest, bool) {
+	var req models.ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid JSON")
+		return req, false
+	}
+	if err := req.Validate(); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return req, false
+	}
+	if _, ok := h.registry[req.Task]; !ok {
+		response.Error(w, http.StatusBadRequest, "Unknown task "+strconv.Quote(req.Task))
+		return req, false
+	}
+	return req, true
+}
+
+// upcomingRuns lists the next count firings of sched in its own timezone
+func upcomingRuns(sched models.Schedule, count int) ([]time.Time, error) {
+	loc, err := time.LoadLocation(sched.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	upcoming := make([]time.Time, 0, count)
+	at := time.Now()
+	for i := 0; i < count; i++ {
+		if at, err = scheduler.NextRun(sched, at); err != nil {
+			return nil, err
+		}
+		upcoming = append(upcoming, at.In(loc))
+	}
+	return upcoming, nil
+}
+
+func respondScheduleError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, repository.ErrScheduleNotFound):
+		response.Error(w, http.StatusNotFound, "Schedule not found")
+	case errors.Is(err, repository.ErrRunNotFound):
+		response.Error(w, http.StatusNotFound, "Run not found")
+	case errors.Is(err, repository.ErrNameTaken):
+		response.Error(w, http.StatusConflict, "A schedule with this name already exists")
+	default:
+		log.Printf("Error trying to %s: %v", action, err)
+		response.Error(w, http.StatusInternalServerError, "Failed to "+action)
+	}
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/cron"
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+// Overlap policies: what to do when a schedule fires while its previous
+// run is still going
+const (
+	OverlapAllow   = "allow"   // start another run alongside
+	OverlapForbid  = "forbid"  // record the new run as skipped
+	OverlapReplace = "replace" // cancel the old run and start the new one
+)
+
+// Misfire policies: what to do with runs that were due while no scheduler
+// was up, and are later than the schedule's misfire grace
+const (
+	MisfireSkip    = "skip"     // record them as missed and wait for the next one
+	MisfireRunOnce = "run_once" // run once for all of them
+	MisfireRunAll  = "run_all"  // run each of them, oldest first, up to MaxCatchUp
+)
+
+// MaxCatchUp caps how many missed runs run_all makes up, and how many are
+// recorded as missed
+const MaxCatchUp = 10
+
+// Run statuses
+const (
+	RunRunning    = "running"
+	RunCancelling = "cancelling" // cancel requested, waiting for the runner to notice
+	RunSucceeded  = "succeeded"
+	RunFailed     = "failed"
+	RunSkipped    = "skipped"   // not started: the previous run was still going
+	RunMissed     = "missed"    // not started: no scheduler was up in time
+	RunCancelled  = "cancelled" // stopped by a replace, a request or shutdown
+	RunAbandoned  = "abandoned" // its instance stopped heartbeating mid-run
+)
+
+// Run triggers
+const (
+	TriggerSchedule = "schedule"
+	TriggerCatchUp  = "catch_up"
+	TriggerManual   = "manual"
+)
+
+// Defaults for fields a schedule request leaves out
+const (
+	DefaultOverlap      = OverlapForbid
+	DefaultMisfire      = MisfireRunOnce
+	DefaultMisfireGrace = time.Minute
+	DefaultTimeout      = 5 * time.Minute
+)
+
+// Schedule is a task and the cron expression that says when it runs
+type Schedule struct {
+	ID           string          `json:"id"`
+	Name         string          `json:"name"`
+	Cron         string          `json:"cron"`
+	Timezone     string          `json:"timezone"`
+	Task         string          `json:"task"`
+	Payload      json.RawMessage `json:"payload,omitempty"`
+	Overlap      string          `json:"overlap"`
+	Misfire      string          `json:"misfire"`
+	MisfireGrace Duration        `json:"misfire_grace"`
+	Jitter       Duration        `json:"jitter"`
+	Timeout      Duration        `json:"timeout"`
+	Enabled      bool            `json:"enabled"`
+	// NextRunAt is when the cron expression fires next; DueAt is that plus
+	// the schedule's jitter, when it actually starts
+	NextRunAt time.Time `json:"next_run_at"`
+	DueAt     time.Time `json:"due_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Run is one time a schedule fired, or was due to
+type Run struct {
+	ID          string     `json:"id"`
+	ScheduleID  string     `json:"schedule_id"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	Trigger     string     `json:"trigger"`
+	Status      string     `json:"status"`
+	Instance    string     `json:"instance,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	HeartbeatAt time.Time  `json:"heartbeat_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// Active reports whether the run may still be doing its work
+func (r Run) Active() bool {
+	return r.Status == RunRunning || r.Status == RunCancelling
+}
+
+// ScheduleRequest is the body of POST /schedules and PUT /schedules/{id}
+type ScheduleRequest struct {
+	Name         string          `json:"name"`
+	Cron         string          `json:"cron"`
+	Timezone     string          `json:"timezone"`
+	Task         string          `json:"task"`
+	Payload      json.RawMessage `json:"payload"`
+	Overlap      string          `json:"overlap"`
+	Misfire      string          `json:"misfire"`
+	MisfireGrace *Duration       `json:"misfire_grace"`
+	Jitter       Duration        `json:"jitter"`
+	Timeout      *Duration       `json:"timeout"`
+	Enabled      *bool           `json:"enabled"`
+}
+
+// Validate validates a schedule request
+func (r ScheduleRequest) Validate() error {
+	if r.Name == "" || len(r.Name) > 64 {
+		return &validation.Error{Field: "name", Message: "Name is required, up to 64 characters"}
+	}
+	if _, err := cron.Parse(r.Cron); err != nil {
+		return &validation.Error{Field: "cron", Message: "Invalid cron expression: " + err.Error()}
+	}
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		return &validation.Error{Field: "timezone", Message: "Unknown timezone, use a name like Europe/Berlin"}
+	}
+	if r.Task == "" {
+		return &validation.Error{Field: "task", Message: "Task is required"}
+	}
+	if len(r.Payload) > 0 && !json.Valid(r.Payload) {
+		return &validation.Error{Field: "payload", Message: "Payload must be valid JSON"}
+	}
+	switch r.Overlap {
+	case "", OverlapAllow, OverlapForbid, OverlapReplace:
+	default:
+		return &validation.Error{Field: "overlap", Message: "Overlap must be allow, forbid or replace"}
+	}
+	switch r.Misfire {
+	case "", MisfireSkip, MisfireRunOnce, MisfireRunAll:
+	default:
+		return &validation.Error{Field: "misfire", Message: "Misfire must be skip, run_once or run_all"}
+	}
+	if r.MisfireGrace != nil && *r.MisfireGrace < 0 {
+		return &validation.Error{Field: "misfire_grace", Message: "Misfire grace cannot be negative"}
+	}
+	if r.Jitter < 0 || time.Duration(r.Jitter) > time.Hour {
+		return &validation.Error{Field: "jitter", Message: "Jitter must be between 0 and 1h"}
+	}
+	if r.Timeout != nil && (*r.Timeout < Duration(time.Second) || *r.Timeout > Duration(24*time.Hour)) {
+		return &validation.Error{Field: "timeout", Message: "Timeout must be between 1s and 24h"}
+	}
+	return nil
+}
+
+// Apply copies a validated request onto s, filling in defaults. It leaves
+// the ID, the next run and the timestamps alone.
+func (r ScheduleRequest) Apply(s *Schedule) {
+	s.Name = r.Name
+	s.Cron = r.Cron
+	s.Timezone = r.Timezone
+	if s.Timezone == "" {
+		s.Timezone = "UTC"
+	}
+	s.Task = r.Task
+	s.Payload = r.Payload
+	s.Overlap = r.Overlap
+	if s.Overlap == "" {
+		s.Overlap = DefaultOverlap
+	}
+	s.Misfire = r.Misfire
+	if s.Misfire == "" {
+		s.Misfire = DefaultMisfire
+	}
+	s.MisfireGrace = Duration(DefaultMisfireGrace)
+	if r.MisfireGrace != nil {
+		s.MisfireGrace = *r.MisfireGrace
+	}
+	s.Jitter = r.Jitter
+	s.Timeout = Duration(DefaultTimeout)
+	if r.Timeout != nil {
+		s.Timeout = *r.Timeout
+	}
+	s.Enabled = r.Enabled == nil || *r.Enabled
+}
+
+// Duration is a time.Duration written as "30s" in JSON
+type Duration time.Duration
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON reads a duration string such as "1m30s"
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\"")
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,379 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/models"
+)
+
+var (
+	// ErrScheduleNotFound is returned when no schedule has the given ID
+	ErrScheduleNotFound = errors.New("schedule not found")
+	// ErrRunNotFound is returned when no run has the given ID
+	ErrRunNotFound = errors.New("run not found")
+	// ErrNameTaken is returned when another schedule has the same name
+	ErrNameTaken = errors.New("schedule name already taken")
+)
+
+const scheduleColumns = "id, name, cron, timezone, task, payload, overlap, misfire, misfire_grace_ms, jitter_ms, timeout_ms, enabled, next_run_at, due_at, created_at, updated_at"
+
+// trigger is a reserved word in MySQL, hence trigger_type
+const runColumns = "id, schedule_id, scheduled_at, trigger_type, status, instance, started_at, heartbeat_at, finished_at, error"
+
+// ScheduleRepository persists schedules and their runs in MySQL. It is
+// the only state the scheduler has: every instance reads and claims
+// through it.
+type ScheduleRepository struct {
+	db *sql.DB
+}
+
+// NewScheduleRepository creates a new schedule repository
+func NewScheduleRepository(db *sql.DB) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+// CreateSchedule inserts a schedule
+func (r *ScheduleRepository) CreateSchedule(ctx context.Context, s models.Schedule) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO schedules (`+scheduleColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.ID, s.Name, s.Cron, s.Timezone, s.Task, nullJSON(s.Payload), s.Overlap, s.Misfire,
+		ms(s.MisfireGrace), ms(s.Jitter), ms(s.Timeout), s.Enabled, s.NextRunAt, s.DueAt, s.CreatedAt, s.UpdatedAt)
+	if isDuplicate(err) {
+		return ErrNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create schedule: %w", err)
+	}
+	return nil
+}
+
+// UpdateSchedule replaces everything about a schedule but its ID and
+// creation time
+func (r *ScheduleRepository) UpdateSchedule(ctx context.Context, s models.Schedule) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE schedules SET
+			name = ?, cron = ?, timezone = ?, task = ?, payload = ?, overlap = ?, misfire = ?,
+			misfire_grace_ms = ?, jitter_ms = ?, timeout_ms = ?, enabled = ?, next_run_at = ?, due_at = ?, updated_at = ?
+		WHERE id = ?`,
+		s.Name, s.Cron, s.Timezone, s.Task, nullJSON(s.Payload), s.Overlap, s.Misfire,
+		ms(s.MisfireGrace), ms(s.Jitter), ms(s.Timeout), s.Enabled, s.NextRunAt, s.DueAt, s.UpdatedAt, s.ID)
+	if isDuplicate(err) {
+		return ErrNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update schedule: %w", err)
+	}
+	return requireRow(result, ErrScheduleNotFound)
+}
+
+// GetSchedule returns one schedule
+func (r *ScheduleRepository) GetSchedule(ctx context.Context, id string) (*models.Schedule, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+scheduleColumns+" FROM schedules WHERE id = ?", id)
+	s, err := scanSchedule(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	return s, nil
+}
+
+// ListSchedules returns every schedule, the next due first
+func (r *ScheduleRepository) ListSchedules(ctx context.Context) ([]models.Schedule, error) {
+	return r.querySchedules(ctx, "SELECT "+scheduleColumns+" FROM schedules ORDER BY enabled DESC, due_at")
+}
+
+// DeleteSchedule removes a schedule and, through the foreign key, its runs
+func (r *ScheduleRepository) DeleteSchedule(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM schedules WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+	return requireRow(result, ErrScheduleNotFound)
+}
+
+// DueSchedules returns enabled schedules due by now, the most overdue first
+func (r *ScheduleRepository) DueSchedules(ctx context.Context, now time.Time, limit int) ([]models.Schedule, error) {
+	return r.querySchedules(ctx, "SELECT "+scheduleColumns+" FROM schedules WHERE enabled = TRUE AND due_at <= ? ORDER BY due_at LIMIT ?",
+		now, limit)
+}
+
+// Advance claims a firing. The WHERE on next_run_at makes it a
+// compare-and-swap: of several instances that read the same due schedule,
+// only the first update matches a row.
+func (r *ScheduleRepository) Advance(ctx context.Context, id string, from, next, due time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "UPDATE schedules SET next_run_at = ?, due_at = ? WHERE id = ? AND next_run_at = ?",
+		next, due, id, from)
+	if err != nil {
+		return false, fmt.Errorf("failed to advance schedule: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to advance schedule: %w", err)
+	}
+	return n == 1, nil
+}
+
+// SaveRun inserts a run or records its progress
+func (r *ScheduleRepository) SaveRun(ctx context.Context, run models.Run) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO runs (`+runColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			status = VALUES(status), heartbeat_at = VALUES(heartbeat_at),
+			finished_at = VALUES(finished_at), error = VALUES(error)`,
+		run.ID, run.ScheduleID, run.ScheduledAt, run.Trigger, run.Status, run.Instance,
+		run.StartedAt, run.HeartbeatAt, run.FinishedAt, run.Error)
+	if err != nil {
+		return fmt.Errorf("failed to save run: %w", err)
+	}
+	return nil
+}
+
+// StartRun saves a new run unless its schedule has an active run that
+// heartbeated since since. Locking the schedule's row serialises starts of
+// the same schedule across instances.
+func (r *ScheduleRepository) StartRun(ctx context.Context, run models.Run, since time.Time) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to start run: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id string
+	err = tx.QueryRowContext(ctx, "SELECT id FROM schedules WHERE id = ? FOR UPDATE", run.ScheduleID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrScheduleNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to start run: %w", err)
+	}
+
+	var active int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM runs WHERE schedule_id = ? AND status IN (?, ?) AND heartbeat_at >= ?",
+		run.ScheduleID, models.RunRunning, models.RunCancelling, since).Scan(&active)
+	if err != nil {
+		return false, fmt.Errorf("failed to start run: %w", err)
+	}
+	if active > 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO runs (`+runColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.ID, run.ScheduleID, run.ScheduledAt, run.Trigger, run.Status, run.Instance,
+		run.StartedAt, run.HeartbeatAt, run.FinishedAt, run.Error)
+	if err != nil {
+		return false, fmt.Errorf("failed to start run: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to start run: %w", err)
+	}
+	return true, nil
+}
+
+// GetRun returns one run
+func (r *ScheduleRepository) GetRun(ctx context.Context, id string) (*models.Run, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+runColumns+" FROM runs WHERE id = ?", id)
+	run, err := scanRun(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run: %w", err)
+	}
+	return run, nil
+}
+
+// ListRuns returns the most recent runs, newest first, optionally only of
+// one schedule or with one status
+func (r *ScheduleRepository) ListRuns(ctx context.Context, scheduleID, status string, limit int) ([]models.Run, error) {
+	query := "SELECT " + runColumns + " FROM runs WHERE TRUE"
+	args := []interface{}{}
+	if scheduleID != "" {
+		query += " AND schedule_id = ?"
+		args = append(args, scheduleID)
+	}
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY started_at DESC LIMIT ?"
+	args = append(args, limit)
+	return r.queryRuns(ctx, query, args...)
+}
+
+// ActiveRuns returns a schedule's running runs that heartbeated since since
+func (r *ScheduleRepository) ActiveRuns(ctx context.Context, scheduleID string, since time.Time) ([]models.Run, error) {
+	return r.queryRuns(ctx, "SELECT "+runColumns+" FROM runs WHERE schedule_id = ? AND status IN (?, ?) AND heartbeat_at >= ?",
+		scheduleID, models.RunRunning, models.RunCancelling, since)
+}
+
+// RequestCancel marks a running run as cancelling
+func (r *ScheduleRepository) RequestCancel(ctx context.Context, runID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "UPDATE runs SET status = ? WHERE id = ? AND status = ?",
+		models.RunCancelling, runID, models.RunRunning)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel run: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel run: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Heartbeat moves a run's heartbeat and returns its status
+func (r *ScheduleRepository) Heartbeat(ctx context.Context, runID string, at time.Time) (string, error) {
+	if _, err := r.db.ExecContext(ctx, "UPDATE runs SET heartbeat_at = ? WHERE id = ?", at, runID); err != nil {
+		return "", fmt.Errorf("failed to heartbeat: %w", err)
+	}
+	var status string
+	err := r.db.QueryRowContext(ctx, "SELECT status FROM runs WHERE id = ?", runID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrRunNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read run status: %w", err)
+	}
+	return status, nil
+}
+
+// AbandonStale marks runs whose instance went quiet as abandoned
+func (r *ScheduleRepository) AbandonStale(ctx context.Context, before, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE runs SET status = ?, finished_at = ?, error = ?
+		WHERE status IN (?, ?) AND heartbeat_at < ?`,
+		models.RunAbandoned, now, "instance stopped heartbeating", models.RunRunning, models.RunCancelling, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to abandon stale runs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// PruneRuns deletes runs that finished before before
+func (r *ScheduleRepository) PruneRuns(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM runs WHERE finished_at < ?", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune runs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Ping checks the database connection
+func (r *ScheduleRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r *ScheduleRepository) querySchedules(ctx context.Context, query string, args ...interface{}) ([]models.Schedule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []models.Schedule{}
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		schedules = append(schedules, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return schedules, nil
+}
+
+func (r *ScheduleRepository) queryRuns(ctx context.Context, query string, args ...interface{}) ([]models.Run, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []models.Run{}
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan run: %w", err)
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return runs, nil
+}
+
+// scanner is what *sql.Row and *sql.Rows have in common
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSchedule(sc scanner) (*models.Schedule, error) {
+	var s models.Schedule
+	var payload []byte
+	var graceMs, jitterMs, timeoutMs int64
+	err := sc.Scan(&s.ID, &s.Name, &s.Cron, &s.Timezone, &s.Task, &payload, &s.Overlap, &s.Misfire,
+		&graceMs, &jitterMs, &timeoutMs, &s.Enabled, &s.NextRunAt, &s.DueAt, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	s.Payload = payload
+	s.MisfireGrace = fromMs(graceMs)
+	s.Jitter = fromMs(jitterMs)
+	s.Timeout = fromMs(timeoutMs)
+	return &s, nil
+}
+
+func scanRun(sc scanner) (*models.Run, error) {
+	var run models.Run
+	var finishedAt sql.NullTime
+	err := sc.Scan(&run.ID, &run.ScheduleID, &run.ScheduledAt, &run.Trigger, &run.Status, &run.Instance,
+		&run.StartedAt, &run.HeartbeatAt, &finishedAt, &run.Error)
+	if err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	return &run, nil
+}
+
+func requireRow(result sql.Result, notFound error) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read rows affected: %w", err)
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func isDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+func ms(d models.Duration) int64 {
+	return time.Duration(d).Milliseconds()
+}
+
+func fromMs(n int64) models.Duration {
+	return models.Duration(time.Duration(n) * time.Millisecond)
+}
+
+// nullJSON stores an empty payload as NULL rather than invalid JSON
+func nullJSON(payload json.RawMessage) interface{} {
+	if len(payload) == 0 {
+		return nil
+	}
+	return []byte(payload)
+}
@@ -0,0 +1,675 @@
+// Package scheduler fires cron schedules kept in a store. Any number of
+// instances can run against the same store: each firing is claimed by
+// moving the schedule's next run forward with a conditional update, so
+// exactly one instance wins it, and the others see a schedule that is no
+// longer due.
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/cron"
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/models"
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/tasks"
+)
+
+const (
+	// dueBatch is how many due schedules one poll handles
+	dueBatch = 100
+	// maxOverdue bounds the search for missed runs after a long outage
+	maxOverdue = 1000
+	// pruneEvery is how often old runs are deleted
+	pruneEvery = time.Hour
+	// saveTimeout bounds run writes, which happen even while shutting down
+	saveTimeout = 5 * time.Second
+)
+
+var (
+	// ErrAlreadyRunning is returned by Trigger when the schedule forbids
+	// overlap and a run is still going
+	ErrAlreadyRunning = errors.New("schedule is already running")
+	// ErrNotRunning is returned by Cancel for a run that has finished
+	ErrNotRunning = errors.New("run is not running")
+	// ErrClosed is returned once the scheduler is shutting down
+	ErrClosed = errors.New("scheduler is closed")
+)
+
+// Reasons a run's context is cancelled, recorded as its error
+var (
+	errReplaced        = errors.New("replaced by a newer run")
+	errCancelRequested = errors.New("cancelled on request")
+	errShutdown        = errors.New("scheduler shut down")
+)
+
+// Store persists schedules and their runs
+type Store interface {
+	CreateSchedule(ctx context.Context, s models.Schedule) error
+	UpdateSchedule(ctx context.Context, s models.Schedule) error
+	GetSchedule(ctx context.Context, id string) (*models.Schedule, error)
+	// DueSchedules returns enabled schedules whose due_at has passed
+	DueSchedules(ctx context.Context, now time.Time, limit int) ([]models.Schedule, error)
+	// Advance moves a schedule from next run from to next, due at due. It
+	// reports false if the schedule was no longer at from: another
+	// instance claimed the firing, or the schedule was edited.
+	Advance(ctx context.Context, id string, from, next, due time.Time) (bool, error)
+	SaveRun(ctx context.Context, run models.Run) error
+	// StartRun saves a new run unless its schedule has an active run that
+	// heartbeated since since, and reports whether it did. The check and
+	// the save are one step, so two callers cannot both start a run.
+	StartRun(ctx context.Context, run models.Run, since time.Time) (bool, error)
+	// ActiveRuns returns a schedule's runs that are still going and have
+	// heartbeated since since
+	ActiveRuns(ctx context.Context, scheduleID string, since time.Time) ([]models.Run, error)
+	// RequestCancel marks a running run as cancelling, for the instance
+	// running it to notice. It reports false if the run was not running.
+	RequestCancel(ctx context.Context, runID string) (bool, error)
+	// Heartbeat records that a run is alive and returns its status
+	Heartbeat(ctx context.Context, runID string, at time.Time) (string, error)
+	// AbandonStale marks active runs that stopped heartbeating before
+	// before as abandoned
+	AbandonStale(ctx context.Context, before, now time.Time) (int64, error)
+	// PruneRuns deletes runs that finished before before
+	PruneRuns(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Config tunes a scheduler
+type Config struct {
+	Instance          string        // name recorded on the runs this instance starts
+	PollInterval      time.Duration // how often to look for due schedules
+	HeartbeatInterval time.Duration // how often a run reports that it is alive
+	StaleAfter        time.Duration // silence after which a run counts as abandoned
+	Retention         time.Duration // how long finished runs are kept
+}
+
+// Scheduler polls the store for due schedules and runs their tasks
+type Scheduler struct {
+	store    Store
+	registry tasks.Registry
+	cfg      Config
+	now      func() time.Time
+	wake     chan struct{}
+
+	mu      sync.Mutex
+	running map[string]context.CancelCauseFunc // by run ID
+	closed  bool
+	wg      sync.WaitGroup
+
+	lastPrune time.Time
+}
+
+// firing is one time a schedule should run
+type firing struct {
+	at      time.Time
+	trigger string
+}
+
+// New creates a scheduler over store
+func New(store Store, registry tasks.Registry, cfg Config) *Scheduler {
+	return &Scheduler{
+		store:    store,
+		registry: registry,
+		cfg:      cfg,
+		now:      time.Now,
+		wake:     make(chan struct{}, 1),
+		running:  make(map[string]context.CancelCauseFunc),
+	}
+}
+
+// Add saves a new schedule, due at its first firing from now
+func (s *Scheduler) Add(ctx context.Context, sched *models.Schedule) error {
+	if err := s.reschedule(sched); err != nil {
+		return err
+	}
+	if err := s.store.CreateSchedule(ctx, *sched); err != nil {
+		return err
+	}
+	s.Wake()
+	return nil
+}
+
+// Update saves an edited schedule and moves its next run to the first
+// firing from now. Runs missed while it was paused are not made up.
+func (s *Scheduler) Update(ctx context.Context, sched *models.Schedule) error {
+	if err := s.reschedule(sched); err != nil {
+		return err
+	}
+	if err := s.store.UpdateSchedule(ctx, *sched); err != nil {
+		return err
+	}
+	s.Wake()
+	return nil
+}
+
+func (s *Scheduler) reschedule(sched *models.Schedule) error {
+	next, err := NextRun(*sched, s.now())
+	if err != nil {
+		return err
+	}
+	sched.NextRunAt = next
+	sched.DueAt = next.Add(Jitter(*sched, next))
+	return nil
+}
+
+// Wake makes Run poll now rather than at the next interval
+func (s *Scheduler) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run polls for due schedules until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		s.tick(ctx)
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// tick does one poll: housekeeping, then every due schedule
+func (s *Scheduler) tick(ctx context.Context) {
+	now := s.now()
+	if n, err := s.store.AbandonStale(ctx, now.Add(-s.cfg.StaleAfter), now.UTC()); err != nil {
+		log.Printf("Error abandoning stale runs: %v", err)
+	} else if n > 0 {
+		log.Printf("Marked %d runs abandoned: their instance stopped heartbeating", n)
+	}
+	if s.cfg.Retention > 0 && now.Sub(s.lastPrune) >= pruneEvery {
+		if n, err := s.store.PruneRuns(ctx, now.Add(-s.cfg.Retention)); err != nil {
+			log.Printf("Error pruning runs: %v", err)
+		} else if n > 0 {
+			log.Printf("Pruned %d runs older than %s", n, s.cfg.Retention)
+		}
+		s.lastPrune = now
+	}
+
+	due, err := s.store.DueSchedules(ctx, now.UTC(), dueBatch)
+	if err != nil {
+		log.Printf("Error loading due schedules: %v", err)
+		return
+	}
+	for _, sched := range due {
+		s.fire(ctx, sched, now)
+	}
+}
+
+// fire claims a due schedule's firing and starts its run. Runs that were
+// due while nobody was polling go through the misfire policy first.
+func (s *Scheduler) fire(ctx context.Context, sched models.Schedule, now time.Time) {
+	if s.isClosed() {
+		return
+	}
+	times, next, err := overdue(sched, now)
+	if err != nil {
+		log.Printf("Schedule %s cannot fire: %v", sched.Name, err)
+		return
+	}
+	run, missed := planMisfire(sched, times, now)
+
+	claimed, err := s.store.Advance(ctx, sched.ID, sched.NextRunAt, next, next.Add(Jitter(sched, next)))
+	if err != nil {
+		log.Printf("Error claiming schedule %s: %v", sched.Name, err)
+		return
+	}
+	if !claimed {
+		// Another instance got there first
+		return
+	}
+
+	if len(missed) > 0 {
+		log.Printf("Schedule %s missed %d runs (misfire %s)", sched.Name, len(missed), sched.Misfire)
+		if len(missed) > models.MaxCatchUp {
+			missed = missed[len(missed)-models.MaxCatchUp:]
+		}
+		for _, at := range missed {
+			s.record(sched, firing{at: at, trigger: models.TriggerSchedule}, models.RunMissed, "no scheduler was up in time")
+		}
+	}
+	if len(run) > 0 {
+		s.start(ctx, sched, run)
+	}
+}
+
+// start applies the overlap policy and runs the firings one after the
+// other in the background. The first is claimed before returning, so a
+// firing that may not start is recorded as skipped straight away.
+func (s *Scheduler) start(ctx context.Context, sched models.Schedule, firings []firing) {
+	if err := s.makeRoom(ctx, sched); err != nil {
+		log.Printf("Error checking runs of %s: %v", sched.Name, err)
+		return
+	}
+	if !s.enter() {
+		for _, f := range firings {
+			s.record(sched, f, models.RunCancelled, errShutdown.Error())
+		}
+		return
+	}
+
+	run, runCtx, err := s.begin(sched, firings[0])
+	if err != nil {
+		s.wg.Done()
+		s.skip(sched, firings, err)
+		return
+	}
+
+	go func() {
+		defer s.wg.Done()
+		s.execute(runCtx, sched, run)
+		for _, f := range firings[1:] {
+			if s.isClosed() {
+				s.record(sched, f, models.RunCancelled, errShutdown.Error())
+				continue
+			}
+			// A manual run may have started in between
+			run, runCtx, err := s.begin(sched, f)
+			if err != nil {
+				s.skip(sched, []firing{f}, err)
+				continue
+			}
+			s.execute(runCtx, sched, run)
+		}
+	}()
+}
+
+// skip records firings that begin refused
+func (s *Scheduler) skip(sched models.Schedule, firings []firing, err error) {
+	if !errors.Is(err, ErrAlreadyRunning) {
+		log.Printf("Error starting a run of %s: %v", sched.Name, err)
+		return
+	}
+	log.Printf("Schedule %s is still running, skipping %d runs", sched.Name, len(firings))
+	for _, f := range firings {
+		s.record(sched, f, models.RunSkipped, "previous run still running")
+	}
+}
+
+// Trigger runs a schedule now, outside its cron expression. Overlap rules
+// still apply.
+func (s *Scheduler) Trigger(ctx context.Context, id string) (models.Run, error) {
+	sched, err := s.store.GetSchedule(ctx, id)
+	if err != nil {
+		return models.Run{}, err
+	}
+	if err := s.makeRoom(ctx, *sched); err != nil {
+		return models.Run{}, err
+	}
+	if !s.enter() {
+		return models.Run{}, ErrClosed
+	}
+
+	run, runCtx, err := s.begin(*sched, firing{at: s.now(), trigger: models.TriggerManual})
+	if err != nil {
+		s.wg.Done()
+		return models.Run{}, err
+	}
+	go func() {
+		defer s.wg.Done()
+		s.execute(runCtx, *sched, run)
+	}()
+	return run, nil
+}
+
+// Cancel stops a run, here or, through the store, on whichever instance
+// is running it
+func (s *Scheduler) Cancel(ctx context.Context, runID string) error {
+	return s.cancel(ctx, runID, errCancelRequested)
+}
+
+func (s *Scheduler) cancel(ctx context.Context, runID string, cause error) error {
+	if s.cancelLocal(runID, cause) {
+		return nil
+	}
+	// Running elsewhere: its instance notices at its next heartbeat
+	ok, err := s.store.RequestCancel(ctx, runID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotRunning
+	}
+	return nil
+}
+
+func (s *Scheduler) cancelLocal(runID string, cause error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cancel, ok := s.running[runID]
+	if ok {
+		cancel(cause)
+	}
+	return ok
+}
+
+// makeRoom cancels the active runs of a schedule that replaces them.
+// Forbidding overlap is left to begin, which checks and starts in one step.
+func (s *Scheduler) makeRoom(ctx context.Context, sched models.Schedule) error {
+	if sched.Overlap != models.OverlapReplace {
+		return nil
+	}
+	active, err := s.store.ActiveRuns(ctx, sched.ID, s.now().Add(-s.cfg.StaleAfter))
+	if err != nil {
+		return err
+	}
+	for _, run := range active {
+		log.Printf("Schedule %s: replacing run %s", sched.Name, run.ID)
+		if err := s.cancel(ctx, run.ID, errReplaced); err != nil && !errors.Is(err, ErrNotRunning) {
+			log.Printf("Error cancelling run %s: %v", run.ID, err)
+		}
+	}
+	return nil
+}
+
+// begin saves a run as running and registers its cancel func. When the
+// schedule forbids overlap, the store only saves it if no other run is
+// active, and begin returns ErrAlreadyRunning if one is.
+func (s *Scheduler) begin(sched models.Schedule, f firing) (models.Run, context.Context, error) {
+	now := s.now().UTC()
+	run := models.Run{
+		ID:          NewID(),
+		ScheduleID:  sched.ID,
+		ScheduledAt: f.at.UTC(),
+		Trigger:     f.trigger,
+		Status:      models.RunRunning,
+		Instance:    s.cfg.Instance,
+		StartedAt:   now,
+		HeartbeatAt: now,
+	}
+	if sched.Overlap == models.OverlapForbid {
+		saveCtx, cancelSave := context.WithTimeout(context.Background(), saveTimeout)
+		started, err := s.store.StartRun(saveCtx, run, s.now().Add(-s.cfg.StaleAfter))
+		cancelSave()
+		if err != nil {
+			return models.Run{}, nil, err
+		}
+		if !started {
+			return models.Run{}, nil, ErrAlreadyRunning
+		}
+	} else {
+		s.save(run)
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	s.mu.Lock()
+	s.running[run.ID] = cancel
+	s.mu.Unlock()
+	return run, ctx, nil
+}
+
+// execute runs the task under the schedule's timeout, heartbeating while
+// it goes, and records how it ended
+func (s *Scheduler) execute(ctx context.Context, sched models.Schedule, run models.Run) {
+	defer func() {
+		s.mu.Lock()
+		cancel := s.running[run.ID]
+		delete(s.running, run.ID)
+		s.mu.Unlock()
+		cancel(nil)
+	}()
+
+	beatCtx, stopBeat := context.WithCancel(ctx)
+	go s.heartbeat(beatCtx, run.ID)
+	err := s.runTask(ctx, sched)
+	stopBeat()
+
+	finished := s.now().UTC()
+	run.FinishedAt = &finished
+	run.HeartbeatAt = finished
+	cause := context.Cause(ctx)
+	switch {
+	case err == nil:
+		run.Status = models.RunSucceeded
+	case cause != nil:
+		run.Status = models.RunCancelled
+		run.Error = cause.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		run.Status = models.RunFailed
+		run.Error = fmt.Sprintf("timed out after %s", time.Duration(sched.Timeout))
+	default:
+		run.Status = models.RunFailed
+		run.Error = err.Error()
+	}
+	s.save(run)
+	log.Printf("Schedule %s: run %s %s in %s", sched.Name, run.ID, run.Status, finished.Sub(run.StartedAt).Round(time.Millisecond))
+}
+
+func (s *Scheduler) runTask(ctx context.Context, sched models.Schedule) error {
+	fn, ok := s.registry[sched.Task]
+	if !ok {
+		return fmt.Errorf("unknown task %q", sched.Task)
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(sched.Timeout))
+	defer cancel()
+	return fn(ctx, sched.Payload)
+}
+
+// heartbeat tells the store the run is alive until ctx is done, and
+// cancels the run when another instance asked for it
+func (s *Scheduler) heartbeat(ctx context.Context, runID string) {
+	ticker := time.NewTicker(s.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		status, err := s.store.Heartbeat(ctx, runID, s.now().UTC())
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Error heartbeating run %s: %v", runID, err)
+			}
+			continue
+		}
+		if status == models.RunCancelling {
+			s.cancelLocal(runID, errCancelRequested)
+		}
+	}
+}
+
+// record saves a firing that did not run
+func (s *Scheduler) record(sched models.Schedule, f firing, status, reason string) {
+	now := s.now().UTC()
+	s.save(models.Run{
+		ID:          NewID(),
+		ScheduleID:  sched.ID,
+		ScheduledAt: f.at.UTC(),
+		Trigger:     f.trigger,
+		Status:      status,
+		Instance:    s.cfg.Instance,
+		StartedAt:   now,
+		HeartbeatAt: now,
+		FinishedAt:  &now,
+		Error:       reason,
+	})
+}
+
+// save records a run, even while shutting down
+func (s *Scheduler) save(run models.Run) {
+	ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+	defer cancel()
+	if err := s.store.SaveRun(ctx, run); err != nil {
+		log.Printf("Error saving run %s: %v", run.ID, err)
+	}
+}
+
+// enter counts a new background run, unless the scheduler is closed
+func (s *Scheduler) enter() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Scheduler) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// Running is the number of runs in progress on this instance
+func (s *Scheduler) Running() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.running)
+}
+
+// Drain stops starting runs and waits up to timeout for the ones in
+// progress. Whatever is still going after that is cancelled. It reports
+// whether everything finished by itself.
+func (s *Scheduler) Drain(timeout time.Duration) bool {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+	}
+
+	s.mu.Lock()
+	for _, cancel := range s.running {
+		cancel(errShutdown)
+	}
+	s.mu.Unlock()
+	<-done
+	return false
+}
+
+// NextRun is the first time after after that sched fires
+func NextRun(sched models.Schedule, after time.Time) (time.Time, error) {
+	spec, loc, err := parse(sched)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return next(spec, loc, after, sched.Cron)
+}
+
+// Jitter is how long after at a run of sched starts: a spread of up to
+// the schedule's jitter, derived from its ID so that every instance agrees
+// on it, and schedules sharing an expression do not all start at once
+func Jitter(sched models.Schedule, at time.Time) time.Duration {
+	spread := time.Duration(sched.Jitter).Milliseconds()
+	if spread <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s/%d", sched.ID, at.Unix())
+	return time.Duration(h.Sum64()%uint64(spread)) * time.Millisecond
+}
+
+// overdue lists the firings of sched from its next run up to now, oldest
+// first, and the first firing after now. After a very long outage it
+// gives up listing and jumps straight to now.
+func overdue(sched models.Schedule, now time.Time) ([]time.Time, time.Time, error) {
+	spec, loc, err := parse(sched)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var times []time.Time
+	t := sched.NextRunAt
+	for !t.After(now) && len(times) < maxOverdue {
+		times = append(times, t)
+		if t, err = next(spec, loc, t, sched.Cron); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	if !t.After(now) {
+		if t, err = next(spec, loc, now, sched.Cron); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	return times, t, nil
+}
+
+// planMisfire splits the overdue firings of sched into those to run and
+// those to record as missed. The latest firing is on time if it is within
+// the misfire grace; everything else is late and handled by the policy.
+func planMisfire(sched models.Schedule, times []time.Time, now time.Time) (run []firing, missed []time.Time) {
+	if len(times) == 0 {
+		return nil, nil
+	}
+	late := times
+	var onTime *firing
+	last := times[len(times)-1]
+	if now.Sub(last.Add(Jitter(sched, last))) <= time.Duration(sched.MisfireGrace) {
+		late = times[:len(times)-1]
+		onTime = &firing{at: last, trigger: models.TriggerSchedule}
+	}
+
+	switch sched.Misfire {
+	case models.MisfireRunAll:
+		for _, at := range late {
+			run = append(run, firing{at: at, trigger: models.TriggerCatchUp})
+		}
+	case models.MisfireRunOnce:
+		// The on-time run stands in for the late ones, if there is one
+		if onTime == nil && len(late) > 0 {
+			run = []firing{{at: late[len(late)-1], trigger: models.TriggerCatchUp}}
+			late = late[:len(late)-1]
+		}
+		missed = late
+	default:
+		missed = late
+	}
+	if onTime != nil {
+		run = append(run, *onTime)
+	}
+	if len(run) > models.MaxCatchUp {
+		for _, f := range run[:len(run)-models.MaxCatchUp] {
+			missed = append(missed, f.at)
+		}
+		run = run[len(run)-models.MaxCatchUp:]
+	}
+	return run, missed
+}
+
+func parse(sched models.Schedule) (*cron.Schedule, *time.Location, error) {
+	spec, err := cron.Parse(sched.Cron)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := time.LoadLocation(sched.Timezone)
+	if err != nil {
+		return nil, nil, err
+	}
+	return spec, loc, nil
+}
+
+func next(spec *cron.Schedule, loc *time.Location, after time.Time, expr string) (time.Time, error) {
+	t := spec.Next(after.In(loc))
+	if t.IsZero() {
+		return time.Time{}, fmt.Errorf("%q does not fire again", expr)
+	}
+	return t.UTC(), nil
+}
+
+// NewID returns a random 16-character hex ID
+func NewID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,510 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/models"
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/tasks"
+)
+
+// memoryStore keeps schedules and runs in maps. Advance and StartRun hold
+// the lock, so they claim as atomically as their MySQL counterparts.
+type memoryStore struct {
+	mu        sync.Mutex
+	schedules map[string]models.Schedule
+	runs      map[string]models.Run
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{schedules: make(map[string]models.Schedule), runs: make(map[string]models.Run)}
+}
+
+func (m *memoryStore) CreateSchedule(ctx context.Context, s models.Schedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.schedules[s.ID] = s
+	return nil
+}
+
+func (m *memoryStore) UpdateSchedule(ctx context.Context, s models.Schedule) error {
+	return m.CreateSchedule(ctx, s)
+}
+
+func (m *memoryStore) GetSchedule(ctx context.Context, id string) (*models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.schedules[id]
+	if !ok {
+		return nil, errors.New("schedule not found")
+	}
+	return &s, nil
+}
+
+func (m *memoryStore) DueSchedules(ctx context.Context, now time.Time, limit int) ([]models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []models.Schedule
+	for _, s := range m.schedules {
+		if s.Enabled && !s.DueAt.After(now) {
+			due = append(due, s)
+		}
+	}
+	return due, nil
+}
+
+func (m *memoryStore) Advance(ctx context.Context, id string, from, next, due time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.schedules[id]
+	if !ok || !s.NextRunAt.Equal(from) {
+		return false, nil
+	}
+	s.NextRunAt, s.DueAt = next, due
+	m.schedules[id] = s
+	return true, nil
+}
+
+func (m *memoryStore) SaveRun(ctx context.Context, run models.Run) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[run.ID] = run
+	return nil
+}
+
+func (m *memoryStore) StartRun(ctx context.Context, run models.Run, since time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, other := range m.runs {
+		if other.ScheduleID == run.ScheduleID && other.Active() && !other.HeartbeatAt.Before(since) {
+			return false, nil
+		}
+	}
+	m.runs[run.ID] = run
+	return true, nil
+}
+
+func (m *memoryStore) ActiveRuns(ctx context.Context, scheduleID string, since time.Time) ([]models.Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var active []models.Run
+	for _, run := range m.runs {
+		if run.ScheduleID == scheduleID && run.Active() && !run.HeartbeatAt.Before(since) {
+			active = append(active, run)
+		}
+	}
+	return active, nil
+}
+
+func (m *memoryStore) RequestCancel(ctx context.Context, runID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run, ok := m.runs[runID]
+	if !ok || run.Status != models.RunRunning {
+		return false, nil
+	}
+	run.Status = models.RunCancelling
+	m.runs[runID] = run
+	return true, nil
+}
+
+func (m *memoryStore) Heartbeat(ctx context.Context, runID string, at time.Time) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run, ok := m.runs[runID]
+	if !ok {
+		return "", errors.New("run not found")
+	}
+	run.HeartbeatAt = at
+	m.runs[runID] = run
+	return run.Status, nil
+}
+
+func (m *memoryStore) AbandonStale(ctx context.Context, before, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for id, run := range m.runs {
+		if run.Active() && run.HeartbeatAt.Before(before) {
+			run.Status = models.RunAbandoned
+			run.FinishedAt = &now
+			m.runs[id] = run
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memoryStore) PruneRuns(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for id, run := range m.runs {
+		if run.FinishedAt != nil && run.FinishedAt.Before(before) {
+			delete(m.runs, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// allRuns returns the runs of every schedule, oldest firing first
+func (m *memoryStore) allRuns() []models.Run {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	runs := make([]models.Run, 0, len(m.runs))
+	for _, run := range m.runs {
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].ScheduledAt.Before(runs[j].ScheduledAt) })
+	return runs
+}
+
+func (m *memoryStore) run(id string) models.Run {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.runs[id]
+}
+
+// testRegistry has a task that succeeds at once and one that runs until
+// it is cancelled, announcing each start on started
+func testRegistry(started chan<- string) tasks.Registry {
+	return tasks.Registry{
+		"ok": func(ctx context.Context, payload json.RawMessage) error { return nil },
+		"block": func(ctx context.Context, payload json.RawMessage) error {
+			started <- "block"
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+}
+
+func newTestScheduler(store Store, registry tasks.Registry, now time.Time) *Scheduler {
+	s := New(store, registry, Config{
+		Instance:          "test",
+		PollInterval:      time.Hour,
+		HeartbeatInterval: 10 * time.Millisecond,
+		StaleAfter:        time.Minute,
+	})
+	s.now = func() time.Time { return now }
+	return s
+}
+
+// everyMinute is a schedule of task next due at next
+func everyMinute(id, task string, next time.Time) models.Schedule {
+	return models.Schedule{
+		ID:           id,
+		Name:         id,
+		Cron:         "* * * * *",
+		Timezone:     "UTC",
+		Task:         task,
+		Overlap:      models.OverlapForbid,
+		Misfire:      models.MisfireRunOnce,
+		MisfireGrace: models.Duration(time.Minute),
+		Timeout:      models.Duration(time.Minute),
+		Enabled:      true,
+		NextRunAt:    next,
+		DueAt:        next,
+	}
+}
+
+func waitForStatus(t *testing.T, store *memoryStore, runID, status string) {
+	t.Helper()
+	require.Eventually(t, func() bool { return store.run(runID).Status == status },
+		2*time.Second, 5*time.Millisecond, "run %s never became %s", runID, status)
+}
+
+func minutes(from time.Time, n int) []time.Time {
+	times := make([]time.Time, n)
+	for i := range times {
+		times[i] = from.Add(time.Duration(i) * time.Minute)
+	}
+	return times
+}
+
+func TestPlanMisfire(t *testing.T) {
+	base := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		misfire     string
+		times       []time.Time
+		now         time.Time
+		wantRun     int
+		wantMissed  int
+		wantTrigger string // of the last run
+	}{
+		{name: "on time", misfire: models.MisfireSkip, times: minutes(base, 1), now: base.Add(5 * time.Second),
+			wantRun: 1, wantTrigger: models.TriggerSchedule},
+		{name: "skip, latest on time", misfire: models.MisfireSkip, times: minutes(base, 5), now: base.Add(4*time.Minute + 30*time.Second),
+			wantRun: 1, wantMissed: 4, wantTrigger: models.TriggerSchedule},
+		{name: "skip, all late", misfire: models.MisfireSkip, times: minutes(base, 5), now: base.Add(30 * time.Minute),
+			wantMissed: 5},
+		{name: "run once, latest on time", misfire: models.MisfireRunOnce, times: minutes(base, 5), now: base.Add(4*time.Minute + 30*time.Second),
+			wantRun: 1, wantMissed: 4, wantTrigger: models.TriggerSchedule},
+		{name: "run once, all late", misfire: models.MisfireRunOnce, times: minutes(base, 5), now: base.Add(30 * time.Minute),
+			wantRun: 1, wantMissed: 4, wantTrigger: models.TriggerCatchUp},
+		{name: "run all, latest on time", misfire: models.MisfireRunAll, times: minutes(base, 5), now: base.Add(4*time.Minute + 30*time.Second),
+			wantRun: 5, wantTrigger: models.TriggerSchedule},
+		{name: "run all, all late", misfire: models.MisfireRunAll, times: minutes(base, 5), now: base.Add(30 * time.Minute),
+			wantRun: 5, wantTrigger: models.TriggerCatchUp},
+		{name: "run all, capped", misfire: models.MisfireRunAll, times: minutes(base, 15), now: base.Add(time.Hour),
+			wantRun: models.MaxCatchUp, wantMissed: 5, wantTrigger: models.TriggerCatchUp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched := everyMinute("s", "ok", base)
+			sched.Misfire = tt.misfire
+
+			run, missed := planMisfire(sched, tt.times, tt.now)
+			assert.Len(t, run, tt.wantRun)
+			assert.Len(t, missed, tt.wantMissed)
+			if tt.wantRun > 0 {
+				assert.Equal(t, tt.wantTrigger, run[len(run)-1].trigger)
+				assert.Equal(t, tt.times[len(tt.times)-1], run[len(run)-1].at, "the latest firing always runs last")
+			}
+		})
+	}
+}
+
+func TestOverdue(t *testing.T) {
+	base := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	sched := everyMinute("s", "ok", base)
+
+	times, next, err := overdue(sched, base.Add(3*time.Minute+30*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, minutes(base, 4), times)
+	assert.Equal(t, base.Add(4*time.Minute), next)
+
+	// Down for a year: the list stops, and the next run is still after now
+	now := base.AddDate(1, 0, 0)
+	times, next, err = overdue(sched, now)
+	require.NoError(t, err)
+	assert.Len(t, times, maxOverdue)
+	assert.Equal(t, now.Add(time.Minute), next)
+}
+
+func TestJitter(t *testing.T) {
+	at := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	sched := everyMinute("s", "ok", at)
+	assert.Zero(t, Jitter(sched, at))
+
+	sched.Jitter = models.Duration(30 * time.Second)
+	first := Jitter(sched, at)
+	assert.Equal(t, first, Jitter(sched, at), "every instance works out the same jitter")
+	assert.Less(t, first, 30*time.Second)
+
+	seen := map[time.Duration]bool{}
+	for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		sched.ID = id
+		seen[Jitter(sched, at)] = true
+	}
+	assert.Greater(t, len(seen), 1, "schedules with the same expression are spread out")
+}
+
+func TestFire_OneInstanceClaims(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 5, 0, time.UTC)
+	store := newMemoryStore()
+	require.NoError(t, store.CreateSchedule(context.Background(), everyMinute("s", "ok", now.Truncate(time.Minute))))
+	a := newTestScheduler(store, testRegistry(nil), now)
+	b := newTestScheduler(store, testRegistry(nil), now)
+
+	// Both instances read the schedule as due before either fires it
+	due, err := store.DueSchedules(context.Background(), now, dueBatch)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	a.fire(context.Background(), due[0], now)
+	b.fire(context.Background(), due[0], now)
+	a.Drain(time.Second)
+	b.Drain(time.Second)
+
+	runs := store.allRuns()
+	require.Len(t, runs, 1, "only one instance runs the firing")
+	assert.Equal(t, models.RunSucceeded, runs[0].Status)
+	sched, _ := store.GetSchedule(context.Background(), "s")
+	assert.Equal(t, now.Truncate(time.Minute).Add(time.Minute), sched.NextRunAt)
+}
+
+func TestFire_RecordsMissedRuns(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 30, 30, 0, time.UTC)
+	store := newMemoryStore()
+	sched := everyMinute("s", "ok", now.Add(-5*time.Minute).Truncate(time.Minute))
+	sched.Misfire = models.MisfireSkip
+	require.NoError(t, store.CreateSchedule(context.Background(), sched))
+	s := newTestScheduler(store, testRegistry(nil), now)
+
+	s.fire(context.Background(), sched, now)
+	s.Drain(time.Second)
+
+	statuses := map[string]int{}
+	for _, run := range store.allRuns() {
+		statuses[run.Status]++
+	}
+	assert.Equal(t, map[string]int{models.RunMissed: 5, models.RunSucceeded: 1}, statuses)
+}
+
+func TestOverlapForbid(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 5, 0, time.UTC)
+	store := newMemoryStore()
+	started := make(chan string, 1)
+	sched := everyMinute("s", "block", now.Truncate(time.Minute))
+	require.NoError(t, store.CreateSchedule(context.Background(), sched))
+	s := newTestScheduler(store, testRegistry(started), now)
+	ctx := context.Background()
+
+	first, err := s.Trigger(ctx, "s")
+	require.NoError(t, err)
+	<-started
+
+	_, err = s.Trigger(ctx, "s")
+	assert.ErrorIs(t, err, ErrAlreadyRunning)
+
+	// The scheduled firing is recorded as skipped rather than started
+	s.fire(ctx, sched, now)
+	var skipped int
+	for _, run := range store.allRuns() {
+		if run.Status == models.RunSkipped {
+			skipped++
+		}
+	}
+	assert.Equal(t, 1, skipped)
+
+	require.NoError(t, s.Cancel(ctx, first.ID))
+	waitForStatus(t, store, first.ID, models.RunCancelled)
+	assert.Equal(t, errCancelRequested.Error(), store.run(first.ID).Error)
+	assert.ErrorIs(t, s.Cancel(ctx, first.ID), ErrNotRunning)
+}
+
+func TestOverlapForbid_Concurrent(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 5, 0, time.UTC)
+	store := newMemoryStore()
+	started := make(chan string, 1)
+	sched := everyMinute("s", "block", now.Truncate(time.Minute))
+	require.NoError(t, store.CreateSchedule(context.Background(), sched))
+	a := newTestScheduler(store, testRegistry(started), now)
+	b := newTestScheduler(store, testRegistry(started), now)
+	ctx := context.Background()
+
+	// Manual runs on both instances race each other and a scheduled firing
+	const triggers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, triggers)
+	for i := 0; i < triggers; i++ {
+		wg.Add(1)
+		go func(s *Scheduler) {
+			defer wg.Done()
+			_, err := s.Trigger(ctx, "s")
+			errs <- err
+		}([]*Scheduler{a, b}[i%2])
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.fire(ctx, sched, now)
+	}()
+	wg.Wait()
+	close(errs)
+	<-started
+
+	var ok int
+	for err := range errs {
+		if err == nil {
+			ok++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrAlreadyRunning)
+	}
+	statuses := map[string]int{}
+	for _, run := range store.allRuns() {
+		statuses[run.Status]++
+	}
+	assert.Equal(t, 1, statuses[models.RunRunning], "only one run starts")
+	assert.LessOrEqual(t, ok, 1)
+	assert.Equal(t, ok, statuses[models.RunSkipped], "the scheduled firing is skipped unless it is the run that started")
+
+	a.Drain(20 * time.Millisecond)
+	b.Drain(20 * time.Millisecond)
+}
+
+func TestOverlapReplace(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 5, 0, time.UTC)
+	store := newMemoryStore()
+	started := make(chan string, 1)
+	sched := everyMinute("s", "block", now)
+	sched.Overlap = models.OverlapReplace
+	require.NoError(t, store.CreateSchedule(context.Background(), sched))
+	s := newTestScheduler(store, testRegistry(started), now)
+	ctx := context.Background()
+
+	first, err := s.Trigger(ctx, "s")
+	require.NoError(t, err)
+	<-started
+	second, err := s.Trigger(ctx, "s")
+	require.NoError(t, err)
+	<-started
+
+	waitForStatus(t, store, first.ID, models.RunCancelled)
+	assert.Equal(t, errReplaced.Error(), store.run(first.ID).Error)
+	assert.Equal(t, models.RunRunning, store.run(second.ID).Status)
+
+	// Shutdown cancels what is left once the drain times out
+	assert.False(t, s.Drain(20*time.Millisecond))
+	assert.Equal(t, models.RunCancelled, store.run(second.ID).Status)
+	assert.Equal(t, errShutdown.Error(), store.run(second.ID).Error)
+
+	_, err = s.Trigger(ctx, "s")
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestTimeout(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 5, 0, time.UTC)
+	store := newMemoryStore()
+	sched := everyMinute("s", "block", now)
+	sched.Timeout = models.Duration(20 * time.Millisecond)
+	require.NoError(t, store.CreateSchedule(context.Background(), sched))
+	s := newTestScheduler(store, testRegistry(make(chan string, 1)), now)
+
+	run, err := s.Trigger(context.Background(), "s")
+	require.NoError(t, err)
+	waitForStatus(t, store, run.ID, models.RunFailed)
+	assert.Equal(t, "timed out after 20ms", store.run(run.ID).Error)
+}
+
+func TestCancelFromAnotherInstance(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 5, 0, time.UTC)
+	store := newMemoryStore()
+	started := make(chan string, 1)
+	require.NoError(t, store.CreateSchedule(context.Background(), everyMinute("s", "block", now)))
+	a := newTestScheduler(store, testRegistry(started), now)
+	b := newTestScheduler(store, testRegistry(started), now)
+
+	run, err := a.Trigger(context.Background(), "s")
+	require.NoError(t, err)
+	<-started
+
+	// b is not running it, so it asks through the store; a notices at its
+	// next heartbeat
+	require.NoError(t, b.Cancel(context.Background(), run.ID))
+	waitForStatus(t, store, run.ID, models.RunCancelled)
+	assert.True(t, a.Drain(time.Second))
+}
+
+func TestTick_AbandonsStaleRuns(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 5, 0, time.UTC)
+	store := newMemoryStore()
+	require.NoError(t, store.SaveRun(context.Background(), models.Run{
+		ID: "r", ScheduleID: "s", Status: models.RunRunning, HeartbeatAt: now.Add(-time.Hour),
+	}))
+	s := newTestScheduler(store, testRegistry(nil), now)
+
+	s.tick(context.Background())
+	assert.Equal(t, models.RunAbandoned, store.run("r").Status)
+}
@@ -0,0 +1,104 @@
+// Package tasks holds the work schedules can run. The demo tasks log,
+// sleep, call a URL or fail on purpose, which is enough to watch overlap,
+// timeouts and misfires without any real dependencies.
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Func does one run with the schedule's payload. It must return when ctx
+// is done.
+type Func func(ctx context.Context, payload json.RawMessage) error
+
+// Registry maps task names to what running them means
+type Registry map[string]Func
+
+// Default returns the demo tasks
+func Default() Registry {
+	return Registry{
+		"log":   logMessage,
+		"sleep": sleep,
+		"http":  callURL,
+		"fail":  fail,
+	}
+}
+
+// logMessage logs {"message": "..."}
+func logMessage(ctx context.Context, payload json.RawMessage) error {
+	p := struct {
+		Message string `json:"message"`
+	}{Message: "tick"}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return fmt.Errorf("invalid payload: %w", err)
+		}
+	}
+	log.Printf("⏰ %s", p.Message)
+	return nil
+}
+
+// sleep works for {"seconds": n}, long enough for the next run to fire
+// while it is still going
+func sleep(ctx context.Context, payload json.RawMessage) error {
+	p := struct {
+		Seconds int `json:"seconds"`
+	}{Seconds: 5}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &p); err != nil || p.Seconds <= 0 {
+			return errors.New(`payload needs a positive "seconds"`)
+		}
+	}
+	return work(ctx, time.Duration(p.Seconds)*time.Second)
+}
+
+// callURL sends {"method": "GET", "url": "..."} and fails on an error
+// status, like a job that pings a webhook or warms a cache
+func callURL(ctx context.Context, payload json.RawMessage) error {
+	p := struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	}{Method: http.MethodGet}
+	if err := json.Unmarshal(payload, &p); err != nil || p.URL == "" {
+		return errors.New(`payload needs "url"`)
+	}
+	req, err := http.NewRequestWithContext(ctx, p.Method, p.URL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s %s: %s", p.Method, p.URL, resp.Status)
+	}
+	return nil
+}
+
+// fail always fails with {"message": "..."}
+func fail(ctx context.Context, payload json.RawMessage) error {
+	p := struct {
+		Message string `json:"message"`
+	}{Message: "failed on purpose"}
+	if len(payload) > 0 {
+		_ = json.Unmarshal(payload, &p)
+	}
+	return errors.New(p.Message)
+}
+
+func work(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/handlers"
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/repository"
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/scheduler"
+	"github.com/e6a5/learning/backend/20-cron-and-scheduling/internal/tasks"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+func main() {
+	// Initialize database connection
+	db, err := initializeDatabase()
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer db.Close()
+
+	// Initialize dependencies. The instance name only labels runs: the
+	// database decides who runs what.
+	hostname, _ := os.Hostname()
+	instance := env.Get("INSTANCE_ID", hostname)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	registry := tasks.Default()
+	sched := scheduler.New(scheduleRepo, registry, scheduler.Config{
+		Instance:          instance,
+		PollInterval:      getEnvDuration("POLL_INTERVAL", time.Second),
+		HeartbeatInterval: getEnvDuration("HEARTBEAT_INTERVAL", 5*time.Second),
+		StaleAfter:        getEnvDuration("STALE_AFTER", 30*time.Second),
+		Retention:         getEnvDuration("RUN_RETENTION", 7*24*time.Hour),
+	})
+
+	pollCtx, stopPolling := context.WithCancel(context.Background())
+	pollDone := make(chan struct{})
+	go func() {
+		sched.Run(pollCtx)
+		close(pollDone)
+	}()
+
+	// Setup HTTP server
+	scheduleHandler := handlers.NewScheduleHandler(sched, scheduleRepo, registry, instance)
+	port := env.Get("PORT", "8080")
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           setupRoutes(scheduleHandler),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Printf("⏰ Scheduler %s running at http://localhost:%s", instance, port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	sig, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-sig.Done()
+
+	// Stop claiming firings first: another instance picks them up, and
+	// nothing new starts here while draining
+	log.Println("Shutting down: no longer claiming schedules")
+	stopPolling()
+	<-pollDone
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
+
+	drainTimeout := getEnvDuration("DRAIN_TIMEOUT", 20*time.Second)
+	log.Printf("Draining %d running runs (up to %s)", sched.Running(), drainTimeout)
+	if sched.Drain(drainTimeout) {
+		log.Println("All runs finished")
+	} else {
+		log.Println("Drain timed out, remaining runs cancelled")
+	}
+	log.Println("Exited")
+}
+
+func initializeDatabase() (*sql.DB, error) {
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		dsn = "user:pass@tcp(localhost:3306)/cronlab?parseTime=true"
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func setupRoutes(scheduleHandler *handlers.ScheduleHandler) *mux.Router {
+	router := mux.NewRouter()
+
+	// Schedules
+	router.HandleFunc("/schedules", scheduleHandler.CreateSchedule).Methods("POST")
+	router.HandleFunc("/schedules", scheduleHandler.GetSchedules).Methods("GET")
+	router.HandleFunc("/schedules/{id}", scheduleHandler.GetSchedule).Methods("GET")
+	router.HandleFunc("/schedules/{id}", scheduleHandler.UpdateSchedule).Methods("PUT")
+	router.HandleFunc("/schedules/{id}", scheduleHandler.DeleteSchedule).Methods("DELETE")
+	router.HandleFunc("/schedules/{id}/pause", scheduleHandler.PauseSchedule).Methods("POST")
+	router.HandleFunc("/schedules/{id}/resume", scheduleHandler.ResumeSchedule).Methods("POST")
+	router.HandleFunc("/schedules/{id}/run", scheduleHandler.TriggerSchedule).Methods("POST")
+	router.HandleFunc("/schedules/{id}/runs", scheduleHandler.GetScheduleRuns).Methods("GET")
+
+	// Run history
+	router.HandleFunc("/runs", scheduleHandler.GetRuns).Methods("GET")
+	router.HandleFunc("/runs/{id}", scheduleHandler.GetRun).Methods("GET")
+	router.HandleFunc("/runs/{id}/cancel", scheduleHandler.CancelRun).Methods("POST")
+
+	// Cron preview and health check
+	router.HandleFunc("/cron/preview", scheduleHandler.PreviewCron).Methods("GET")
+	router.HandleFunc("/health", scheduleHandler.HealthCheck).Methods("GET")
+
+	return router
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(env.Get(key, defaultValue.String()))
+	if err != nil {
+		log.Fatalf("%s must be a duration like 2s: %v", key, err)
+	}
+	return value
+}
@@ -0,0 +1,101 @@
+// Command client chats with the frame server from a terminal. nc cannot
+// do it: every message needs its length in front, in binary.
+//
+//	go run ./client -name alice
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/frame"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9001", "chat server address")
+	name := flag.String("name", os.Getenv("USER"), "name to chat as")
+	ping := flag.Duration("ping", 30*time.Second, "how often to ping, to stay under the server's idle timeout")
+	flag.Parse()
+
+	conn, err := net.DialTimeout("tcp", *addr, 5*time.Second)
+	if err != nil {
+		log.Fatal("Failed to connect:", err)
+	}
+	defer conn.Close()
+
+	if err := frame.Write(conn, frame.New(frame.TypeHello, *name)); err != nil {
+		log.Fatal("Failed to say hello:", err)
+	}
+
+	// Print what the server sends until it hangs up
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		br := bufio.NewReader(conn)
+		for {
+			f, err := frame.Read(br)
+			if err != nil {
+				if err != io.EOF {
+					fmt.Println("*** connection lost:", err)
+				}
+				return
+			}
+			switch f.Type {
+			case frame.TypeMessage:
+				fmt.Println(string(f.Payload))
+			case frame.TypeInfo:
+				fmt.Println("***", string(f.Payload))
+			case frame.TypeError:
+				fmt.Println("!!!", string(f.Payload))
+			case frame.TypeBye:
+				fmt.Println("*** server said bye:", string(f.Payload))
+				return
+			case frame.TypePing:
+				_ = frame.Write(conn, frame.Frame{Type: frame.TypePong, Payload: f.Payload})
+			}
+		}
+	}()
+
+	// Ping now and then, so a quiet reader is not taken for a dead one
+	go func() {
+		for range time.Tick(*ping) {
+			if err := frame.Write(conn, frame.New(frame.TypePing, "")); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Send every line typed; end of input says bye
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				_ = frame.Write(conn, frame.New(frame.TypeBye, ""))
+				return
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if err := frame.Write(conn, frame.New(frame.TypeSay, line)); err != nil {
+				log.Fatal("Failed to send:", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Package chat is one chat room spoken over the frame protocol. A client
+// says Hello with a name, then Say frames go to everyone as Message
+// frames. Each member has a reader, the goroutine serving the connection,
+// and a writer fed by a buffered channel, so one slow client never holds
+// up the room: when its buffer is full, it is disconnected.
+package chat
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/frame"
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/tcpserver"
+	"github.com/e6a5/learning/backend/pkg/validation"
+)
+
+const (
+	// MaxTextLength is the most characters one Say may have
+	MaxTextLength = 1000
+	// sendBuffer is how many frames may wait for a member before it counts
+	// as too slow
+	sendBuffer = 64
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,20}$`)
+
+// Room is the set of members, by name
+type Room struct {
+	mu      sync.Mutex
+	members map[string]*member
+}
+
+type member struct {
+	name string
+	conn *tcpserver.Conn
+	send chan frame.Frame // closed by leave
+}
+
+// NewRoom creates an empty room
+func NewRoom() *Room {
+	return &Room{members: make(map[string]*member)}
+}
+
+// Serve runs one member's connection: hello, then frames until the client
+// says bye, goes quiet or disconnects, or the server shuts down
+func (r *Room) Serve(ctx context.Context, conn *tcpserver.Conn) {
+	br := bufio.NewReader(conn)
+	m, err := r.hello(br, conn)
+	if err != nil {
+		_ = frame.Write(conn, frame.New(frame.TypeError, err.Error()))
+		return
+	}
+
+	writerDone := make(chan struct{})
+	go m.write(writerDone)
+
+	reason := r.read(ctx, br, m)
+	if reason != "" {
+		r.send(m, frame.New(frame.TypeBye, reason))
+	}
+	r.leave(m)
+	// The writer sends whatever is queued, the Bye included, and stops
+	<-writerDone
+}
+
+// hello reads the first frame, which must name the member, and joins them
+func (r *Room) hello(br *bufio.Reader, conn *tcpserver.Conn) (*member, error) {
+	f, err := frame.Read(br)
+	if err != nil {
+		return nil, fmt.Errorf("expected hello: %w", err)
+	}
+	if f.Type != frame.TypeHello {
+		return nil, fmt.Errorf("expected hello, got %s", f.Type)
+	}
+	name := string(f.Payload)
+	if !validName.MatchString(name) {
+		return nil, &validation.Error{Field: "name", Message: "Name must be 1-20 letters, digits, - or _"}
+	}
+
+	m := &member{name: name, conn: conn, send: make(chan frame.Frame, sendBuffer)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, taken := r.members[name]; taken {
+		return nil, &validation.Error{Field: "name", Message: name + " is already here"}
+	}
+	r.members[name] = m
+	r.sendLocked(m, frame.New(frame.TypeInfo, fmt.Sprintf("Welcome %s! Here: %s", name, strings.Join(r.namesLocked(), ", "))))
+	r.broadcastLocked(frame.New(frame.TypeInfo, name+" joined"), m)
+	return m, nil
+}
+
+// read handles frames from m until the connection ends. It returns the
+// reason to give the client in a Bye, or "" if there is nobody to tell.
+func (r *Room) read(ctx context.Context, br *bufio.Reader, m *member) string {
+	for {
+		f, err := frame.Read(br)
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			return "server shutting down"
+		case tcpserver.IsTimeout(err):
+			return "idle for too long"
+		case errors.Is(err, frame.ErrTooLarge), errors.Is(err, frame.ErrEmpty):
+			// The stream cannot be trusted past a bad length
+			return err.Error()
+		default:
+			// EOF, reset, or closed for being too slow
+			return ""
+		}
+
+		switch f.Type {
+		case frame.TypeSay:
+			text := strings.TrimSpace(string(f.Payload))
+			if text == "" || utf8.RuneCountInString(text) > MaxTextLength || !utf8.ValidString(text) {
+				r.send(m, frame.New(frame.TypeError, fmt.Sprintf("Text must be 1-%d characters of UTF-8", MaxTextLength)))
+				continue
+			}
+			r.broadcast(frame.New(frame.TypeMessage, m.name+": "+text), nil)
+		case frame.TypePing:
+			r.send(m, frame.Frame{Type: frame.TypePong, Payload: f.Payload})
+		case frame.TypePong:
+		case frame.TypeBye:
+			return ""
+		default:
+			r.send(m, frame.New(frame.TypeError, "Unexpected "+f.Type.String()+" frame"))
+		}
+	}
+}
+
+// leave removes m and closes its queue, which stops its writer
+func (r *Room) leave(m *member) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.members[m.name] != m {
+		return
+	}
+	delete(r.members, m.name)
+	close(m.send)
+	r.broadcastLocked(frame.New(frame.TypeInfo, m.name+" left"), nil)
+}
+
+// Members lists who is in the room
+func (r *Room) Members() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.namesLocked()
+}
+
+func (r *Room) send(m *member, f frame.Frame) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sendLocked(m, f)
+}
+
+func (r *Room) broadcast(f frame.Frame, except *member) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.broadcastLocked(f, except)
+}
+
+func (r *Room) broadcastLocked(f frame.Frame, except *member) {
+	for _, m := range r.members {
+		if m != except {
+			r.sendLocked(m, f)
+		}
+	}
+}
+
+// sendLocked queues f for m without waiting. A member whose queue is full
+// is not keeping up: closing its connection ends its reader, which leaves.
+func (r *Room) sendLocked(m *member, f frame.Frame) {
+	if r.members[m.name] != m {
+		return
+	}
+	select {
+	case m.send <- f:
+	default:
+		m.conn.Close()
+	}
+}
+
+func (r *Room) namesLocked() []string {
+	names := make([]string, 0, len(r.members))
+	for name := range r.members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// write sends queued frames until the queue is closed. After a failed
+// write it only drains the queue: the connection is closed, and the
+// reader will notice.
+func (m *member) write(done chan<- struct{}) {
+	defer close(done)
+	failed := false
+	for f := range m.send {
+		if failed {
+			continue
+		}
+		if err := frame.Write(m.conn, f); err != nil {
+			failed = true
+			m.conn.Close()
+		}
+	}
+}
+
+// Reject tells a client turned away at the connection limit why
+func Reject(conn net.Conn) {
+	_ = frame.Write(conn, frame.New(frame.TypeError, "Server full, try again later"))
+}
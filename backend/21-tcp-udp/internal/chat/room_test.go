@@ -0,0 +1,131 @@
+package chat
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/frame"
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/tcpserver"
+)
+
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// startRoom serves a room on a free port
+func startRoom(t *testing.T) (*Room, *tcpserver.Server, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	room := NewRoom()
+	s := tcpserver.New(tcpserver.Config{Name: "chat"}, room.Serve)
+	go func() { _ = s.Serve(ln) }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = s.Shutdown(ctx)
+	})
+	return room, s, ln.Addr().String()
+}
+
+func join(t *testing.T, addr, name string) *client {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	c := &client{t: t, conn: conn, br: bufio.NewReader(conn)}
+	c.send(frame.TypeHello, name)
+	return c
+}
+
+func (c *client) send(t frame.Type, text string) {
+	require.NoError(c.t, frame.Write(c.conn, frame.New(t, text)))
+}
+
+// expect reads the next frame and checks it
+func (c *client) expect(t frame.Type, text string) {
+	f, err := frame.Read(c.br)
+	require.NoError(c.t, err)
+	assert.Equal(c.t, t, f.Type)
+	assert.Equal(c.t, text, string(f.Payload))
+}
+
+func TestRoom_Conversation(t *testing.T) {
+	room, _, addr := startRoom(t)
+
+	alice := join(t, addr, "alice")
+	alice.expect(frame.TypeInfo, "Welcome alice! Here: alice")
+	bob := join(t, addr, "bob")
+	bob.expect(frame.TypeInfo, "Welcome bob! Here: alice, bob")
+	alice.expect(frame.TypeInfo, "bob joined")
+	assert.Equal(t, []string{"alice", "bob"}, room.Members())
+
+	// Everyone hears a message, the sender included
+	bob.send(frame.TypeSay, "  hi all ")
+	alice.expect(frame.TypeMessage, "bob: hi all")
+	bob.expect(frame.TypeMessage, "bob: hi all")
+
+	alice.send(frame.TypePing, "42")
+	alice.expect(frame.TypePong, "42")
+
+	alice.send(frame.TypeSay, "")
+	alice.expect(frame.TypeError, "Text must be 1-1000 characters of UTF-8")
+	alice.send(frame.TypeMessage, "not mine to send")
+	alice.expect(frame.TypeError, "Unexpected message frame")
+
+	bob.send(frame.TypeBye, "")
+	alice.expect(frame.TypeInfo, "bob left")
+	assert.Equal(t, []string{"alice"}, room.Members())
+}
+
+func TestRoom_HelloErrors(t *testing.T) {
+	_, _, addr := startRoom(t)
+	join(t, addr, "alice").expect(frame.TypeInfo, "Welcome alice! Here: alice")
+
+	tests := []struct {
+		name  string
+		hello func(c *client)
+		want  string
+	}{
+		{"name taken", func(c *client) { c.send(frame.TypeHello, "alice") }, "name: alice is already here"},
+		{"invalid name", func(c *client) { c.send(frame.TypeHello, "bob smith") }, "name: Name must be 1-20 letters, digits, - or _"},
+		{"not a hello", func(c *client) { c.send(frame.TypeSay, "hi") }, "expected hello, got say"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", addr)
+			require.NoError(t, err)
+			defer conn.Close()
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+			c := &client{t: t, conn: conn, br: bufio.NewReader(conn)}
+
+			tt.hello(c)
+			c.expect(frame.TypeError, tt.want)
+			_, err = frame.Read(c.br)
+			assert.ErrorIs(t, err, io.EOF, "closed after the error")
+		})
+	}
+}
+
+func TestRoom_ShutdownSaysBye(t *testing.T) {
+	room, s, addr := startRoom(t)
+	alice := join(t, addr, "alice")
+	alice.expect(frame.TypeInfo, "Welcome alice! Here: alice")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Shutdown(ctx))
+
+	alice.expect(frame.TypeBye, "server shutting down")
+	_, err := frame.Read(alice.br)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Empty(t, room.Members())
+}
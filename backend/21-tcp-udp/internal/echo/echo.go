@@ -0,0 +1,52 @@
+// Package echo is the simplest TCP protocol there is: lines of text,
+// sent back as they came. Any line-based tool can talk to it, nc or
+// telnet included.
+package echo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/tcpserver"
+)
+
+// MaxLine is the longest line accepted. bufio.Scanner needs a bound, and
+// so does a server: a client that never sends a newline would otherwise
+// grow the buffer until memory runs out.
+const MaxLine = 4 * 1024
+
+// Serve echoes every line back until the client sends "quit", goes quiet
+// or disconnects
+func Serve(ctx context.Context, conn *tcpserver.Conn) {
+	fmt.Fprintf(conn, "Echo server, connection #%d. Type a line, or quit.\n", conn.Info().ID)
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 1024), MaxLine)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "quit" {
+			fmt.Fprintln(conn, "Bye!")
+			return
+		}
+		fmt.Fprintln(conn, line)
+	}
+
+	err := scanner.Err()
+	switch {
+	case ctx.Err() != nil:
+		fmt.Fprintln(conn, "Server shutting down, bye!")
+	case tcpserver.IsTimeout(err):
+		fmt.Fprintln(conn, "Idle for too long, bye!")
+	case errors.Is(err, bufio.ErrTooLong):
+		fmt.Fprintf(conn, "Line longer than %d bytes, bye!\n", MaxLine)
+	}
+}
+
+// Reject tells a client turned away at the connection limit why
+func Reject(conn net.Conn) {
+	fmt.Fprintln(conn, "Server full, try again later.")
+}
@@ -0,0 +1,123 @@
+// Package frame is the chat protocol's framing. TCP delivers a stream of
+// bytes, not messages: one Write may arrive as two Reads, and two Writes
+// as one. A length prefix tells the reader where each message ends:
+//
+//	┌──────────────────┬────────┬─────────────────────────┐
+//	│ length (uint32,  │ type   │ payload                 │
+//	│ big endian)      │ 1 byte │ length - 1 bytes        │
+//	└──────────────────┴────────┴─────────────────────────┘
+//
+// The length counts the type byte and the payload, so it is never 0.
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// HeaderSize is the length prefix in bytes
+const HeaderSize = 4
+
+// MaxPayload is the largest payload either side accepts. A reader must
+// bound what it allocates: without a limit, four bytes from anyone could
+// ask for 4 GiB.
+const MaxPayload = 64 * 1024
+
+// Type says what a frame means
+type Type byte
+
+// Frame types. Clients send Hello once, then Say, Ping and Bye; the
+// server sends Message, Info, Error, Pong and Bye.
+const (
+	TypeHello   Type = 1 // client: the name to chat as
+	TypeSay     Type = 2 // client: text for the room
+	TypeMessage Type = 3 // server: "name: text" from the room
+	TypeInfo    Type = 4 // server: joins, leaves, the welcome
+	TypeError   Type = 5 // server: what was wrong with the last frame
+	TypePing    Type = 6 // either side: are you there?
+	TypePong    Type = 7 // either side: yes
+	TypeBye     Type = 8 // either side: closing, with an optional reason
+)
+
+var (
+	// ErrTooLarge is returned for a frame longer than MaxPayload
+	ErrTooLarge = errors.New("frame too large")
+	// ErrEmpty is returned for a frame with a length of 0
+	ErrEmpty = errors.New("empty frame")
+)
+
+// Frame is one message
+type Frame struct {
+	Type    Type
+	Payload []byte
+}
+
+// New is a frame of type t carrying text
+func New(t Type, text string) Frame {
+	return Frame{Type: t, Payload: []byte(text)}
+}
+
+// String names the frame type
+func (t Type) String() string {
+	switch t {
+	case TypeHello:
+		return "hello"
+	case TypeSay:
+		return "say"
+	case TypeMessage:
+		return "message"
+	case TypeInfo:
+		return "info"
+	case TypeError:
+		return "error"
+	case TypePing:
+		return "ping"
+	case TypePong:
+		return "pong"
+	case TypeBye:
+		return "bye"
+	default:
+		return fmt.Sprintf("type(%d)", byte(t))
+	}
+}
+
+// Write writes f in a single Write call, so frames written by different
+// goroutines never interleave
+func Write(w io.Writer, f Frame) error {
+	if len(f.Payload) > MaxPayload {
+		return ErrTooLarge
+	}
+	buf := make([]byte, HeaderSize+1+len(f.Payload))
+	binary.BigEndian.PutUint32(buf, uint32(1+len(f.Payload)))
+	buf[HeaderSize] = byte(f.Type)
+	copy(buf[HeaderSize+1:], f.Payload)
+	_, err := w.Write(buf)
+	return err
+}
+
+// Read reads one frame. It returns io.EOF only if the stream ended cleanly
+// between frames, and io.ErrUnexpectedEOF if it ended inside one.
+func Read(r io.Reader) (Frame, error) {
+	var header [HeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return Frame{}, err
+	}
+	n := binary.BigEndian.Uint32(header[:])
+	if n == 0 {
+		return Frame{}, ErrEmpty
+	}
+	if n-1 > MaxPayload {
+		return Frame{}, ErrTooLarge
+	}
+
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return Frame{}, err
+	}
+	return Frame{Type: Type(body[0]), Payload: body[1:]}, nil
+}
@@ -0,0 +1,69 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, New(TypeSay, "hello")))
+	require.NoError(t, Write(&buf, New(TypePing, "")))
+	assert.Equal(t, []byte{0, 0, 0, 6, byte(TypeSay), 'h', 'e', 'l', 'l', 'o'}, buf.Bytes()[:10])
+
+	f, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, TypeSay, f.Type)
+	assert.Equal(t, "hello", string(f.Payload))
+
+	f, err = Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, TypePing, f.Type)
+	assert.Empty(t, f.Payload)
+
+	_, err = Read(&buf)
+	assert.Equal(t, io.EOF, err, "a clean end between frames")
+}
+
+// oneByteReader hands out one byte per Read, like a slow network
+type oneByteReader struct{ r io.Reader }
+
+func (o oneByteReader) Read(p []byte) (int, error) {
+	return o.r.Read(p[:1])
+}
+
+func TestRead_SplitAcrossReads(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, New(TypeMessage, "alice: hi")))
+
+	f, err := Read(oneByteReader{&buf})
+	require.NoError(t, err)
+	assert.Equal(t, "alice: hi", string(f.Payload))
+}
+
+func TestRead_Truncated(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, New(TypeSay, "hello")))
+
+	_, err := Read(bytes.NewReader(buf.Bytes()[:7]))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	_, err = Read(bytes.NewReader(buf.Bytes()[:2]))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestRead_BadLength(t *testing.T) {
+	header := make([]byte, HeaderSize)
+	binary.BigEndian.PutUint32(header, MaxPayload+2)
+	_, err := Read(bytes.NewReader(header))
+	assert.ErrorIs(t, err, ErrTooLarge, "rejected before reading or allocating the body")
+
+	_, err = Read(bytes.NewReader([]byte{0, 0, 0, 0}))
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	assert.ErrorIs(t, Write(io.Discard, Frame{Type: TypeSay, Payload: make([]byte, MaxPayload+1)}), ErrTooLarge)
+}
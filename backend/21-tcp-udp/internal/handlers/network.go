@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/chat"
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/tcpserver"
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/udp"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// NetworkHandler shows what the TCP and UDP servers are doing, over HTTP
+type NetworkHandler struct {
+	echo    *tcpserver.Server
+	chat    *tcpserver.Server
+	room    *chat.Room
+	counter *udp.Counter
+}
+
+// NewNetworkHandler creates a new network handler
+func NewNetworkHandler(echo, chatServer *tcpserver.Server, room *chat.Room, counter *udp.Counter) *NetworkHandler {
+	return &NetworkHandler{echo: echo, chat: chatServer, room: room, counter: counter}
+}
+
+// GetStats handles GET /stats - connection counts per TCP server and the
+// UDP counters
+func (h *NetworkHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"echo": h.echo.Stats(),
+			"chat": h.chat.Stats(),
+			"udp":  h.counter.Stats(),
+		},
+	})
+}
+
+// GetConnections handles GET /connections - every open TCP connection
+func (h *NetworkHandler) GetConnections(w http.ResponseWriter, r *http.Request) {
+	conns := append(h.echo.Connections(), h.chat.Connections()...)
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"connections": conns,
+			"count":       len(conns),
+		},
+	})
+}
+
+// GetMembers handles GET /chat/members - who is in the chat room
+func (h *NetworkHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
+	members := h.room.Members()
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Data: map[string]interface{}{
+			"members": members,
+			"count":   len(members),
+		},
+	})
+}
+
+// ResetCounters handles DELETE /udp/counters
+func (h *NetworkHandler) ResetCounters(w http.ResponseWriter, r *http.Request) {
+	h.counter.Reset()
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Counters reset"})
+}
+
+// HealthCheck handles GET /health
+func (h *NetworkHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, response.APIResponse{Message: "Network servers are healthy"})
+}
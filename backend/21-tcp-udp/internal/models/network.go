@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ServerStats counts the connections of one TCP server
+type ServerStats struct {
+	Name     string `json:"name"`
+	Addr     string `json:"addr"`
+	Active   int    `json:"active"`
+	MaxConns int    `json:"max_conns"`
+	Accepted int64  `json:"accepted"`
+	Rejected int64  `json:"rejected"` // turned away at the connection limit
+	TimedOut int64  `json:"timed_out"`
+}
+
+// ConnInfo is one open TCP connection
+type ConnInfo struct {
+	ID          int64     `json:"id"`
+	Server      string    `json:"server"`
+	Remote      string    `json:"remote"`
+	ConnectedAt time.Time `json:"connected_at"`
+	BytesIn     int64     `json:"bytes_in"`
+	BytesOut    int64     `json:"bytes_out"`
+}
+
+// UDPStats counts the datagrams of the UDP counter server
+type UDPStats struct {
+	Addr      string           `json:"addr"`
+	Datagrams int64            `json:"datagrams"`
+	Malformed int64            `json:"malformed"` // lines that were not name:value
+	Oversized int64            `json:"oversized"` // datagrams too big for the buffer
+	Counters  map[string]int64 `json:"counters"`
+}
@@ -0,0 +1,76 @@
+package tcpserver
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/models"
+)
+
+// longAgo is a deadline that has already passed, to fail reads at once
+var longAgo = time.Unix(1, 0)
+
+// Conn is an accepted connection. Every Read and Write moves its
+// deadline, so a client that goes quiet for IdleTimeout, or stops reading
+// for WriteTimeout, gets an error instead of holding a goroutine forever.
+type Conn struct {
+	net.Conn
+	server      *Server
+	id          int64
+	connectedAt time.Time
+
+	bytesIn, bytesOut atomic.Int64
+}
+
+// Read reads with the idle deadline. Once the server is shutting down,
+// reads fail at once.
+func (c *Conn) Read(p []byte) (int, error) {
+	if d := c.server.cfg.IdleTimeout; d > 0 {
+		_ = c.Conn.SetReadDeadline(time.Now().Add(d))
+	}
+	// Checked after setting the deadline, so it cannot undo interrupt
+	if c.server.closing.Load() {
+		_ = c.Conn.SetReadDeadline(longAgo)
+	}
+	n, err := c.Conn.Read(p)
+	c.bytesIn.Add(int64(n))
+	if IsTimeout(err) && !c.server.closing.Load() {
+		c.server.timedOut.Add(1)
+	}
+	return n, err
+}
+
+// Write writes with the write deadline
+func (c *Conn) Write(p []byte) (int, error) {
+	if d := c.server.cfg.WriteTimeout; d > 0 {
+		_ = c.Conn.SetWriteDeadline(time.Now().Add(d))
+	}
+	n, err := c.Conn.Write(p)
+	c.bytesOut.Add(int64(n))
+	return n, err
+}
+
+// Info describes the connection
+func (c *Conn) Info() models.ConnInfo {
+	return models.ConnInfo{
+		ID:          c.id,
+		Server:      c.server.cfg.Name,
+		Remote:      c.RemoteAddr().String(),
+		ConnectedAt: c.connectedAt,
+		BytesIn:     c.bytesIn.Load(),
+		BytesOut:    c.bytesOut.Load(),
+	}
+}
+
+// interrupt fails a Read in progress
+func (c *Conn) interrupt() {
+	_ = c.Conn.SetReadDeadline(longAgo)
+}
+
+// IsTimeout reports whether err is a deadline passing
+func IsTimeout(err error) bool {
+	return errors.Is(err, os.ErrDeadlineExceeded)
+}
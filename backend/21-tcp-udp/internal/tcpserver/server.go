@@ -0,0 +1,208 @@
+// Package tcpserver manages the connections of a TCP server, whatever
+// they speak: it accepts, enforces a connection limit and deadlines, keeps
+// track of who is connected, and shuts down in two steps, asking handlers
+// to finish before closing what is left.
+package tcpserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/models"
+)
+
+// ErrServerClosed is returned by Serve after Shutdown
+var ErrServerClosed = errors.New("tcp server closed")
+
+// Handler serves one connection and returns when it is done with it. ctx
+// is cancelled when the server shuts down; reads fail at the same moment,
+// so a handler blocked in Read finds out at once.
+type Handler func(ctx context.Context, conn *Conn)
+
+// Config limits a server's connections
+type Config struct {
+	Name         string
+	MaxConns     int           // connections served at once; 0 is no limit
+	IdleTimeout  time.Duration // longest wait for the client to send anything
+	WriteTimeout time.Duration // longest wait for one write to go out
+	// Reject, if set, writes a last word to a connection turned away at
+	// the limit, in whatever protocol the server speaks
+	Reject func(conn net.Conn)
+}
+
+// Server accepts connections and hands each one to the handler on its own
+// goroutine
+type Server struct {
+	cfg     Config
+	handler Handler
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	closing atomic.Bool
+	wg      sync.WaitGroup
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[*Conn]struct{}
+	nextID   int64
+
+	accepted, rejected, timedOut atomic.Int64
+}
+
+// New creates a server that serves every connection with handler
+func New(cfg Config, handler Handler) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{cfg: cfg, handler: handler, ctx: ctx, cancel: cancel, conns: make(map[*Conn]struct{})}
+}
+
+// ListenAndServe listens on addr and serves until Shutdown
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve accepts connections on ln until Shutdown
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	if s.closing.Load() {
+		s.mu.Unlock()
+		ln.Close()
+		return ErrServerClosed
+	}
+	s.listener = ln
+	s.mu.Unlock()
+
+	var delay time.Duration
+	for {
+		nc, err := ln.Accept()
+		if err != nil {
+			if s.closing.Load() {
+				return ErrServerClosed
+			}
+			// Usually out of file descriptors: wait instead of spinning,
+			// as net/http does
+			if delay == 0 {
+				delay = 5 * time.Millisecond
+			} else if delay *= 2; delay > time.Second {
+				delay = time.Second
+			}
+			log.Printf("%s: accept failed, retrying in %s: %v", s.cfg.Name, delay, err)
+			time.Sleep(delay)
+			continue
+		}
+		delay = 0
+
+		conn, ok := s.track(nc)
+		if !ok {
+			s.rejected.Add(1)
+			if s.cfg.Reject != nil {
+				_ = nc.SetWriteDeadline(time.Now().Add(time.Second))
+				s.cfg.Reject(nc)
+			}
+			nc.Close()
+			continue
+		}
+		s.accepted.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+			defer s.untrack(conn)
+			s.handler(s.ctx, conn)
+		}()
+	}
+}
+
+// track registers a new connection, unless the server is full or closing
+func (s *Server) track(nc net.Conn) (*Conn, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing.Load() || (s.cfg.MaxConns > 0 && len(s.conns) >= s.cfg.MaxConns) {
+		return nil, false
+	}
+	s.nextID++
+	conn := &Conn{Conn: nc, server: s, id: s.nextID, connectedAt: time.Now().UTC()}
+	s.conns[conn] = struct{}{}
+	s.wg.Add(1)
+	return conn, true
+}
+
+func (s *Server) untrack(conn *Conn) {
+	conn.Close()
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+}
+
+// Shutdown stops accepting, cancels the handlers' context and interrupts
+// their reads, then waits for them to return. Connections still open when
+// ctx is done are closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing.Store(true)
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.interrupt()
+	}
+	s.mu.Unlock()
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	<-done
+	return ctx.Err()
+}
+
+// Stats counts the server's connections
+func (s *Server) Stats() models.ServerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := models.ServerStats{
+		Name:     s.cfg.Name,
+		Active:   len(s.conns),
+		MaxConns: s.cfg.MaxConns,
+		Accepted: s.accepted.Load(),
+		Rejected: s.rejected.Load(),
+		TimedOut: s.timedOut.Load(),
+	}
+	if s.listener != nil {
+		stats.Addr = s.listener.Addr().String()
+	}
+	return stats
+}
+
+// Connections lists the open connections, oldest first
+func (s *Server) Connections() []models.ConnInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos := make([]models.ConnInfo, 0, len(s.conns))
+	for conn := range s.conns {
+		infos = append(infos, conn.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
@@ -0,0 +1,161 @@
+package tcpserver
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// start serves handler on a free port and returns its address
+func start(t *testing.T, cfg Config, handler Handler) (*Server, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := New(cfg, handler)
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(ln) }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = s.Shutdown(ctx)
+		assert.ErrorIs(t, <-served, ErrServerClosed)
+	})
+	return s, ln.Addr().String()
+}
+
+func dial(t *testing.T, addr string) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	return conn, bufio.NewReader(conn)
+}
+
+// readUntilError echoes lines and says why it stopped
+func readUntilError(ctx context.Context, conn *Conn) {
+	fmt.Fprintln(conn, "hello")
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		fmt.Fprintln(conn, scanner.Text())
+	}
+	switch {
+	case ctx.Err() != nil:
+		fmt.Fprintln(conn, "shutting down")
+	case IsTimeout(scanner.Err()):
+		fmt.Fprintln(conn, "idle")
+	}
+}
+
+func TestServer_ConnectionLimit(t *testing.T) {
+	s, addr := start(t, Config{
+		Name:     "test",
+		MaxConns: 1,
+		Reject:   func(conn net.Conn) { fmt.Fprintln(conn, "full") },
+	}, readUntilError)
+
+	first, firstReader := dial(t, addr)
+	line, err := firstReader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", line)
+
+	_, secondReader := dial(t, addr)
+	line, err = secondReader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "full\n", line)
+	_, err = secondReader.ReadString('\n')
+	assert.ErrorIs(t, err, io.EOF, "closed after the rejection")
+
+	stats := s.Stats()
+	assert.Equal(t, 1, stats.Active)
+	assert.Equal(t, int64(1), stats.Accepted)
+	assert.Equal(t, int64(1), stats.Rejected)
+	require.Len(t, s.Connections(), 1)
+	assert.Equal(t, first.LocalAddr().String(), s.Connections()[0].Remote)
+
+	// Once the first leaves there is room again
+	first.Close()
+	require.Eventually(t, func() bool { return s.Stats().Active == 0 }, time.Second, 5*time.Millisecond)
+	_, thirdReader := dial(t, addr)
+	line, err = thirdReader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", line)
+}
+
+func TestServer_IdleTimeout(t *testing.T) {
+	s, addr := start(t, Config{Name: "test", IdleTimeout: 50 * time.Millisecond}, readUntilError)
+
+	conn, reader := dial(t, addr)
+	_, err := reader.ReadString('\n')
+	require.NoError(t, err)
+
+	// Talking keeps the connection open past the timeout
+	for i := 0; i < 3; i++ {
+		time.Sleep(30 * time.Millisecond)
+		fmt.Fprintln(conn, "still here")
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "still here\n", line)
+	}
+
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "idle\n", line)
+	_, err = reader.ReadString('\n')
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, int64(1), s.Stats().TimedOut)
+}
+
+func TestServer_ShutdownInterruptsReads(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := New(Config{Name: "test"}, readUntilError)
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(ln) }()
+
+	_, reader := dial(t, ln.Addr().String())
+	_, err = reader.ReadString('\n')
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Shutdown(ctx), "the handler returns without waiting for the client")
+	assert.ErrorIs(t, <-served, ErrServerClosed)
+
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "shutting down\n", line)
+}
+
+func TestServer_ShutdownClosesStragglers(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := New(Config{Name: "test"}, func(ctx context.Context, conn *Conn) {
+		fmt.Fprintln(conn, "hello")
+		<-release // ignores shutdown
+	})
+	go func() { _ = s.Serve(ln) }()
+
+	_, reader := dial(t, ln.Addr().String())
+	_, err = reader.ReadString('\n')
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- s.Shutdown(ctx) }()
+
+	// The connection is closed at the deadline, though the handler has
+	// not returned
+	_, err = reader.ReadString('\n')
+	assert.ErrorIs(t, err, io.EOF)
+	release <- struct{}{}
+	assert.ErrorIs(t, <-done, context.DeadlineExceeded)
+}
@@ -0,0 +1,145 @@
+// Package udp is a metrics sink in the style of StatsD. Clients fire
+// datagrams of "name:value" lines and never wait for an answer, so a
+// metric costs the sender one syscall and nothing if the sink is down.
+// The price is that datagrams can be lost, duplicated or reordered, and
+// nobody is told.
+package udp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/models"
+)
+
+// MaxDatagram is the largest datagram accepted. 1432 bytes of payload fit
+// in one Ethernet frame with IPv4 and UDP headers; bigger datagrams are
+// split into IP fragments, and losing any fragment loses the lot.
+const MaxDatagram = 1432
+
+// Counter adds up the values sent to it, by name
+type Counter struct {
+	mu       sync.Mutex
+	counters map[string]int64
+	addr     string
+
+	datagrams, malformed, oversized atomic.Int64
+}
+
+// NewCounter creates an empty counter
+func NewCounter() *Counter {
+	return &Counter{counters: make(map[string]int64)}
+}
+
+// ListenAndServe listens on addr and serves until ctx is done
+func (c *Counter) ListenAndServe(ctx context.Context, addr string) error {
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return c.Serve(ctx, pc)
+}
+
+// Serve reads datagrams from pc until ctx is done. There are no
+// connections to manage: one socket receives from every client.
+func (c *Counter) Serve(ctx context.Context, pc net.PacketConn) error {
+	c.mu.Lock()
+	c.addr = pc.LocalAddr().String()
+	c.mu.Unlock()
+
+	stop := context.AfterFunc(ctx, func() { pc.Close() })
+	defer stop()
+
+	// One byte more than allowed: a read that fills it was too big, since
+	// the kernel silently cuts a datagram to fit the buffer
+	buf := make([]byte, MaxDatagram+1)
+	for {
+		n, from, err := pc.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			log.Printf("UDP read failed: %v", err)
+			continue
+		}
+		c.datagrams.Add(1)
+		if n > MaxDatagram {
+			c.oversized.Add(1)
+			continue
+		}
+
+		data := bytes.TrimSpace(buf[:n])
+		// "ping" is the one request with an answer: UDP can reply to the
+		// sender's address just as well, it only has no connection to do
+		// it on
+		if string(data) == "ping" {
+			if _, err := pc.WriteTo([]byte("pong\n"), from); err != nil {
+				log.Printf("UDP reply to %s failed: %v", from, err)
+			}
+			continue
+		}
+		c.record(data)
+	}
+}
+
+// record adds every "name:value" line of a datagram
+func (c *Counter) record(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		name, value, ok := parseLine(line)
+		if !ok {
+			c.malformed.Add(1)
+			continue
+		}
+		c.counters[name] += value
+	}
+}
+
+// parseLine reads "name:value", where value is an integer; "name" alone
+// counts 1
+func parseLine(line []byte) (string, int64, bool) {
+	line = bytes.TrimSpace(line)
+	name, value, found := bytes.Cut(line, []byte(":"))
+	if len(name) == 0 || len(name) > 128 || bytes.ContainsAny(name, " \t") {
+		return "", 0, false
+	}
+	if !found {
+		return string(name), 1, true
+	}
+	n, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return string(name), n, true
+}
+
+// Stats returns the counters and how many datagrams arrived
+func (c *Counter) Stats() models.UDPStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counters := make(map[string]int64, len(c.counters))
+	for name, value := range c.counters {
+		counters[name] = value
+	}
+	return models.UDPStats{
+		Addr:      c.addr,
+		Datagrams: c.datagrams.Load(),
+		Malformed: c.malformed.Load(),
+		Oversized: c.oversized.Load(),
+		Counters:  counters,
+	}
+}
+
+// Reset clears the counters
+func (c *Counter) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counters = make(map[string]int64)
+}
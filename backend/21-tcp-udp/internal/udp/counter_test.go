@@ -0,0 +1,70 @@
+package udp
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startCounter(t *testing.T) (*Counter, net.Conn) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	c := NewCounter()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, c.Serve(ctx, pc))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return c, conn
+}
+
+func TestCounter(t *testing.T) {
+	c, conn := startCounter(t)
+
+	for _, datagram := range []string{
+		"page.views:1",
+		"page.views:2\nsignups",
+		"bytes:-5",
+		"not a metric",
+		strings.Repeat("x", MaxDatagram+10),
+	} {
+		_, err := conn.Write([]byte(datagram))
+		require.NoError(t, err)
+	}
+
+	// Nothing confirms a datagram arrived; poll until they all have
+	require.Eventually(t, func() bool { return c.Stats().Datagrams == 5 }, 2*time.Second, 5*time.Millisecond)
+	stats := c.Stats()
+	assert.Equal(t, map[string]int64{"page.views": 3, "signups": 1, "bytes": -5}, stats.Counters)
+	assert.Equal(t, int64(1), stats.Malformed)
+	assert.Equal(t, int64(1), stats.Oversized)
+
+	c.Reset()
+	assert.Empty(t, c.Stats().Counters)
+}
+
+func TestCounter_Ping(t *testing.T) {
+	_, conn := startCounter(t)
+
+	_, err := conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	buf := make([]byte, 16)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "pong\n", string(buf[:n]))
+}
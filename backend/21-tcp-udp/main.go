@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/chat"
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/echo"
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/handlers"
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/tcpserver"
+	"github.com/e6a5/learning/backend/21-tcp-udp/internal/udp"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+func main() {
+	maxConns := getEnvInt("MAX_CONNS", 100)
+	idleTimeout := getEnvDuration("IDLE_TIMEOUT", 2*time.Minute)
+	writeTimeout := getEnvDuration("WRITE_TIMEOUT", 10*time.Second)
+
+	// Two TCP servers with the same connection management, speaking
+	// different protocols: lines of text, and length-prefixed frames
+	echoServer := tcpserver.New(tcpserver.Config{
+		Name:         "echo",
+		MaxConns:     maxConns,
+		IdleTimeout:  idleTimeout,
+		WriteTimeout: writeTimeout,
+		Reject:       echo.Reject,
+	}, echo.Serve)
+	room := chat.NewRoom()
+	chatServer := tcpserver.New(tcpserver.Config{
+		Name:         "chat",
+		MaxConns:     maxConns,
+		IdleTimeout:  idleTimeout,
+		WriteTimeout: writeTimeout,
+		Reject:       chat.Reject,
+	}, room.Serve)
+	counter := udp.NewCounter()
+
+	echoAddr := env.Get("ECHO_ADDR", ":9000")
+	chatAddr := env.Get("CHAT_ADDR", ":9001")
+	udpAddr := env.Get("UDP_ADDR", ":9002")
+	go serveTCP(echoServer, echoAddr)
+	go serveTCP(chatServer, chatAddr)
+
+	udpCtx, stopUDP := context.WithCancel(context.Background())
+	udpDone := make(chan struct{})
+	go func() {
+		defer close(udpDone)
+		if err := counter.ListenAndServe(udpCtx, udpAddr); err != nil {
+			log.Fatal("UDP server failed to start:", err)
+		}
+	}()
+
+	// Setup HTTP server, to look inside the others
+	networkHandler := handlers.NewNetworkHandler(echoServer, chatServer, room, counter)
+	port := env.Get("PORT", "8080")
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           setupRoutes(networkHandler),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Printf("🔌 Echo on tcp %s, chat on tcp %s, counters on udp %s, stats at http://localhost:%s",
+			echoAddr, chatAddr, udpAddr, port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	sig, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-sig.Done()
+
+	// Tell every TCP client goodbye, wait for them, then close the rest
+	log.Println("Shutting down...")
+	ctx, cancel := context.WithTimeout(context.Background(), getEnvDuration("SHUTDOWN_TIMEOUT", 10*time.Second))
+	defer cancel()
+	for _, s := range []*tcpserver.Server{echoServer, chatServer} {
+		if err := s.Shutdown(ctx); err != nil {
+			log.Printf("%s connections forced closed: %v", s.Stats().Name, err)
+		}
+	}
+	stopUDP()
+	<-udpDone
+	if err := server.Shutdown(ctx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
+	log.Println("Server exited")
+}
+
+func serveTCP(s *tcpserver.Server, addr string) {
+	if err := s.ListenAndServe(addr); err != nil && !errors.Is(err, tcpserver.ErrServerClosed) {
+		log.Fatalf("TCP server on %s failed: %v", addr, err)
+	}
+}
+
+func setupRoutes(networkHandler *handlers.NetworkHandler) *mux.Router {
+	router := mux.NewRouter()
+
+	// What the servers are doing
+	router.HandleFunc("/stats", networkHandler.GetStats).Methods("GET")
+	router.HandleFunc("/connections", networkHandler.GetConnections).Methods("GET")
+	router.HandleFunc("/chat/members", networkHandler.GetMembers).Methods("GET")
+	router.HandleFunc("/udp/counters", networkHandler.ResetCounters).Methods("DELETE")
+
+	// Health check
+	router.HandleFunc("/health", networkHandler.HealthCheck).Methods("GET")
+
+	return router
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(env.Get(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		log.Fatalf("%s must be a number: %v", key, err)
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(env.Get(key, defaultValue.String()))
+	if err != nil {
+		log.Fatalf("%s must be a duration like 2s: %v", key, err)
+	}
+	return value
+}
@@ -0,0 +1,241 @@
+// Command client signs in with the provider the way a native app does:
+// authorization code with PKCE, a redirect to a port on 127.0.0.1, and
+// the ID token checked before anything in it is believed.
+//
+//	go run ./client
+//	go run ./client -api http://localhost:8081/oidc/profile
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/pkce"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/resource"
+)
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokens struct {
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	Scope        string `json:"scope"`
+}
+
+func main() {
+	issuer := flag.String("issuer", "http://localhost:8092", "provider's issuer URL")
+	clientID := flag.String("client", "demo-cli", "client ID")
+	scope := flag.String("scope", "openid profile email offline_access api:read api:write", "scopes to ask for")
+	listen := flag.String("listen", "127.0.0.1:9999", "where to receive the redirect; must match a registered redirect URI")
+	api := flag.String("api", "", "an API URL to call with the access token")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	// Everything else is found from the issuer
+	var meta discovery
+	if err := getJSON(ctx, client, *issuer+"/.well-known/openid-configuration", "", &meta); err != nil {
+		log.Fatal("Failed to discover the provider:", err)
+	}
+	if meta.Issuer != *issuer {
+		log.Fatalf("Provider says its issuer is %s, not %s", meta.Issuer, *issuer)
+	}
+
+	// Fresh for every sign-in: state ties the redirect to this request,
+	// nonce ties the ID token to it, and the verifier ties the code to it
+	verifier, err := pkce.NewVerifier()
+	if err != nil {
+		log.Fatal(err)
+	}
+	state, nonce := random(), random()
+	redirectURI := "http://" + *listen + "/callback"
+
+	codes := make(chan string, 1)
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatal("Failed to listen for the redirect:", err)
+	}
+	go func() {
+		_ = http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			switch {
+			case q.Get("state") != state:
+				http.Error(w, "Unexpected state: this is not the sign-in we started", http.StatusBadRequest)
+				return
+			case q.Get("iss") != meta.Issuer:
+				http.Error(w, "Unexpected issuer", http.StatusBadRequest)
+				return
+			case q.Get("error") != "":
+				fmt.Fprintf(w, "Sign-in failed: %s\n", q.Get("error_description"))
+				log.Fatalf("Sign-in failed: %s: %s", q.Get("error"), q.Get("error_description"))
+			}
+			fmt.Fprintln(w, "Signed in. You can close this tab.")
+			codes <- q.Get("code")
+		}))
+	}()
+
+	authURL := meta.AuthorizationEndpoint + "?" + url.Values{
+		"response_type":         {"code"},
+		"client_id":             {*clientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {*scope},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {pkce.Challenge(verifier)},
+		"code_challenge_method": {pkce.Method},
+	}.Encode()
+	fmt.Printf("Open this in a browser and sign in (alice / alice-password):\n\n  %s\n\n", authURL)
+
+	var code string
+	select {
+	case code = <-codes:
+	case <-ctx.Done():
+		log.Fatal("Gave up waiting for the sign-in")
+	}
+
+	// The back channel: the code and the verifier, straight to the provider
+	var tok tokens
+	err = postForm(ctx, client, meta.TokenEndpoint, url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {*clientID},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}, &tok)
+	if err != nil {
+		log.Fatal("Failed to exchange the code:", err)
+	}
+
+	if tok.IDToken != "" {
+		subject, err := checkIDToken(ctx, tok.IDToken, resource.NewKeySet(meta.JWKSURI, client), meta.Issuer, *clientID, nonce)
+		if err != nil {
+			log.Fatal("ID token rejected:", err)
+		}
+		fmt.Printf("ID token checked: signed in as %s\n", subject)
+	}
+	fmt.Printf("Scopes granted: %s\nAccess token, valid %ds:\n\n%s\n\n", tok.Scope, tok.ExpiresIn, tok.AccessToken)
+	if tok.RefreshToken != "" {
+		fmt.Printf("Refresh token:\n\n%s\n\n", tok.RefreshToken)
+	}
+
+	if strings.Contains(" "+tok.Scope+" ", " openid ") {
+		var info map[string]interface{}
+		if err := getJSON(ctx, client, meta.UserinfoEndpoint, tok.AccessToken, &info); err != nil {
+			log.Fatal("Failed to get user info:", err)
+		}
+		printJSON("User info", info)
+	}
+
+	if *api != "" {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, *api, nil)
+		req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+		resp, err := client.Do(req)
+		if err != nil {
+			log.Fatal("Failed to call the API:", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("%s said %s:\n%s\n", *api, resp.Status, body)
+	}
+}
+
+// checkIDToken verifies the ID token's signature, issuer, audience and
+// nonce. Only then may it be believed: it came through the client's own
+// back channel, but so could a token issued to another client.
+func checkIDToken(ctx context.Context, raw string, keys *resource.KeySet, issuer, clientID, nonce string) (string, error) {
+	var claims struct {
+		jwt.RegisteredClaims
+		Nonce string `json:"nonce"`
+	}
+	_, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return keys.Key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(clientID),
+	)
+	if err != nil {
+		return "", err
+	}
+	if claims.Nonce != nonce {
+		return "", errors.New("nonce does not match: the token was not issued for this sign-in")
+	}
+	return claims.Subject, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, endpoint, bearer string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	return do(client, req, v)
+}
+
+func postForm(ctx context.Context, client *http.Client, endpoint string, form url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return do(client, req, v)
+}
+
+func do(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	return json.Unmarshal(body, v)
+}
+
+func printJSON(title string, v interface{}) {
+	fmt.Println(title + ":")
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func random() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
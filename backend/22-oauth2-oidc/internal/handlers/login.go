@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/models"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/provider"
+)
+
+// authorizeParams are carried from GET /authorize to the login form
+var authorizeParams = []string{
+	"client_id", "redirect_uri", "response_type", "scope", "state", "nonce",
+	"code_challenge", "code_challenge_method",
+}
+
+type loginPage struct {
+	Client   models.Client
+	Request  *models.AuthorizeRequest
+	Params   url.Values
+	Username string
+	Error    string
+}
+
+// Fields are the hidden form fields that repeat the request
+func (p loginPage) Fields() map[string]string {
+	fields := make(map[string]string, len(authorizeParams))
+	for _, name := range authorizeParams {
+		if v := p.Params.Get(name); v != "" {
+			fields[name] = v
+		}
+	}
+	return fields
+}
+
+// Scopes are what the client asks for, in words
+func (p loginPage) Scopes() []string {
+	descriptions := make([]string, 0, len(p.Request.Scopes))
+	for _, s := range p.Request.Scopes {
+		descriptions = append(descriptions, provider.Scopes[s])
+	}
+	return descriptions
+}
+
+var loginTemplate = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Sign in to {{.Client.Name}}</title>
+<style>
+body { font-family: sans-serif; max-width: 24em; margin: 4em auto; }
+label, input, button { display: block; width: 100%; margin: .5em 0; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<h1>Sign in</h1>
+<p><strong>{{.Client.Name}}</strong> would like to:</p>
+<ul>{{range .Scopes}}<li>{{.}}</li>{{end}}</ul>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post" action="/authorize">
+{{range $name, $value := .Fields}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+{{end}}<label>Username <input name="username" value="{{.Username}}" autocomplete="username" autofocus></label>
+<label>Password <input name="password" type="password" autocomplete="current-password"></label>
+<button name="action" value="allow">Sign in and allow</button>
+<button name="action" value="deny">Deny</button>
+</form>
+<p><small>You will be sent back to {{.Request.RedirectURI}}</small></p>
+</body>
+</html>
+`))
+
+// renderLogin shows the login page. It must not be framed: a page that
+// overlays it could trick the user into clicking allow.
+func renderLogin(w http.ResponseWriter, status int, page loginPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	w.WriteHeader(status)
+	if err := loginTemplate.Execute(w, page); err != nil {
+		log.Printf("Failed to render login page: %v", err)
+	}
+}
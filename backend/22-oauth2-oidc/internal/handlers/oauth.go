@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/models"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/provider"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// OAuthHandler serves the provider's endpoints. Their responses follow the
+// OAuth and OpenID specs rather than this repo's APIResponse, since
+// off-the-shelf clients read them.
+type OAuthHandler struct {
+	provider *provider.Provider
+}
+
+// NewOAuthHandler creates a new OAuth handler
+func NewOAuthHandler(p *provider.Provider) *OAuthHandler {
+	return &OAuthHandler{provider: p}
+}
+
+// Discovery handles GET /.well-known/openid-configuration
+func (h *OAuthHandler) Discovery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSON(w, http.StatusOK, h.provider.Discovery())
+}
+
+// JWKS handles GET /jwks.json - the public keys tokens are signed with
+func (h *OAuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	// Short enough that a new key is picked up soon after it is published
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, h.provider.Keys().JWKS())
+}
+
+// Authorize handles GET /authorize - the login page, if the request is
+// good
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	req, client, err := h.provider.Authorize(r.URL.Query())
+	if err != nil {
+		h.authorizeError(w, r, req, err)
+		return
+	}
+	renderLogin(w, http.StatusOK, loginPage{Client: client, Request: req, Params: r.URL.Query()})
+}
+
+// Login handles POST /authorize - the login form. The request is checked
+// again: hidden fields come from the browser and prove nothing.
+func (h *OAuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	params := url.Values{}
+	for _, name := range authorizeParams {
+		if v := r.PostForm.Get(name); v != "" {
+			params.Set(name, v)
+		}
+	}
+	req, client, err := h.provider.Authorize(params)
+	if err != nil {
+		h.authorizeError(w, r, req, err)
+		return
+	}
+
+	if r.PostForm.Get("action") != "allow" {
+		err := models.NewOAuthError(models.ErrAccessDenied, "The user said no")
+		http.Redirect(w, r, h.provider.ErrorRedirect(req, err), http.StatusSeeOther)
+		return
+	}
+	user, err := h.provider.Login(r.PostForm.Get("username"), r.PostForm.Get("password"))
+	if err != nil {
+		renderLogin(w, http.StatusUnauthorized, loginPage{
+			Client:   client,
+			Request:  req,
+			Params:   params,
+			Username: r.PostForm.Get("username"),
+			Error:    "Wrong username or password",
+		})
+		return
+	}
+
+	location, err := h.provider.IssueCode(req, user)
+	if err != nil {
+		log.Printf("Failed to issue code: %v", err)
+		http.Redirect(w, r, h.provider.ErrorRedirect(req, err), http.StatusSeeOther)
+		return
+	}
+	log.Printf("%s signed in to %s with scopes %s", user.Username, client.ID, strings.Join(req.Scopes, " "))
+	http.Redirect(w, r, location, http.StatusSeeOther)
+}
+
+// authorizeError sends an error back to the client if it is safe to, and
+// shows it to the user otherwise
+func (h *OAuthHandler) authorizeError(w http.ResponseWriter, r *http.Request, req *models.AuthorizeRequest, err error) {
+	if req != nil {
+		http.Redirect(w, r, h.provider.ErrorRedirect(req, err), http.StatusFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	_, _ = w.Write([]byte("This sign-in link is broken: " + err.Error() + "\n"))
+}
+
+// Token handles POST /token - codes and refresh tokens for tokens
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	client, err := h.authenticateClient(r)
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+	resp, err := h.provider.Token(client, r.PostForm)
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Introspect handles POST /introspect - whether a token is active, for
+// APIs that would rather ask than check a signature
+func (h *OAuthHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	client, err := h.authenticateClient(r)
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+	if r.PostForm.Get("token") == "" {
+		writeOAuthError(w, r, models.NewOAuthError(models.ErrInvalidRequest, "token is required"))
+		return
+	}
+	resp, err := h.provider.Introspect(client, r.PostForm.Get("token"))
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Revoke handles POST /revoke - sign out, from the client's side
+func (h *OAuthHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	client, err := h.authenticateClient(r)
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+	h.provider.Revoke(client, r.PostForm.Get("token"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// UserInfo handles GET /userinfo - claims about the user, as the access
+// token's scopes allow
+func (h *OAuthHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="userinfo"`)
+		writeJSON(w, http.StatusUnauthorized, models.NewOAuthError(models.ErrInvalidRequest, "Bearer token required"))
+		return
+	}
+	info, err := h.provider.UserInfo(token)
+	if err != nil {
+		var oauthErr *models.OAuthError
+		if errors.As(err, &oauthErr) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="userinfo", error="`+oauthErr.Code+`"`)
+		}
+		writeOAuthError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+// HealthCheck handles GET /health
+func (h *OAuthHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, response.APIResponse{
+		Message: "OAuth2/OIDC provider is healthy",
+		Data:    map[string]string{"issuer": h.provider.Discovery().Issuer, "kid": h.provider.Keys().KeyID()},
+	})
+}
+
+// authenticateClient reads client credentials from HTTP Basic auth, or
+// else from the form (client_secret_post), and parses the form
+func (h *OAuthHandler) authenticateClient(r *http.Request) (models.Client, error) {
+	if err := r.ParseForm(); err != nil {
+		return models.Client{}, models.NewOAuthError(models.ErrInvalidRequest, "Invalid form")
+	}
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		// Basic auth values are form-encoded first (RFC 6749, 2.3.1)
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if id == "" {
+		return models.Client{}, models.NewOAuthError(models.ErrInvalidClient, "Client authentication required")
+	}
+	return h.provider.AuthenticateClient(id, secret)
+}
+
+func writeOAuthError(w http.ResponseWriter, r *http.Request, err error) {
+	var oauthErr *models.OAuthError
+	if !errors.As(err, &oauthErr) {
+		log.Printf("Failed to handle %s: %v", r.URL.Path, err)
+		oauthErr = &models.OAuthError{Code: "server_error", Status: http.StatusInternalServerError}
+	}
+	if oauthErr.Code == models.ErrInvalidClient {
+		if _, _, basic := r.BasicAuth(); basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+		}
+	}
+	writeJSON(w, oauthErr.Status, oauthErr)
+}
+
+// writeJSON sends v as JSON. Tokens must never be cached, and nothing
+// here should be (RFC 6749, 5.1), so everything says no-store unless it
+// said otherwise.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to encode JSON response: %v", err)
+	}
+}
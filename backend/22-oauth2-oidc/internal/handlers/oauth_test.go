@@ -0,0 +1,435 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/keys"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/models"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/pkce"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/provider"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/store"
+)
+
+const redirectURI = "http://127.0.0.1:9999/callback"
+
+type testServer struct {
+	t      *testing.T
+	url    string
+	keys   *keys.Set
+	client *http.Client
+}
+
+// newTestServer runs the provider with alice, a public client and a
+// confidential API client
+func newTestServer(t *testing.T) *testServer {
+	var router http.Handler
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		router.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	st := store.NewMemory()
+	hash, err := bcrypt.GenerateFromPassword([]byte("alice-password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	st.AddUser(models.User{ID: "u-1", Username: "alice", PasswordHash: hash, Name: "Alice", Email: "alice@example.com", EmailVerified: true})
+	st.AddClient(models.Client{
+		ID:           "demo-cli",
+		Name:         "Demo CLI",
+		RedirectURIs: []string{redirectURI},
+		Scopes:       []string{"openid", "profile", "email", "offline_access", "api:read", "api:write"},
+	})
+	st.AddClient(models.Client{ID: "learning-api", Name: "API", SecretHash: store.Hash("api-secret")})
+
+	keySet, err := keys.Generate()
+	require.NoError(t, err)
+	p := provider.New(provider.Config{
+		Issuer:          srv.URL,
+		Audience:        "learning-api",
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+		CodeTTL:         time.Minute,
+	}, keySet, st)
+
+	h := NewOAuthHandler(p)
+	r := mux.NewRouter()
+	r.HandleFunc("/.well-known/openid-configuration", h.Discovery).Methods("GET")
+	r.HandleFunc("/jwks.json", h.JWKS).Methods("GET")
+	r.HandleFunc("/authorize", h.Authorize).Methods("GET")
+	r.HandleFunc("/authorize", h.Login).Methods("POST")
+	r.HandleFunc("/token", h.Token).Methods("POST")
+	r.HandleFunc("/revoke", h.Revoke).Methods("POST")
+	r.HandleFunc("/introspect", h.Introspect).Methods("POST")
+	r.HandleFunc("/userinfo", h.UserInfo).Methods("GET")
+	router = r
+
+	return &testServer{
+		t:    t,
+		url:  srv.URL,
+		keys: keySet,
+		// Redirects are the answers here, not something to follow
+		client: &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}},
+	}
+}
+
+// goodRequest is a good authorization request for verifier
+func goodRequest(verifier, scope string) url.Values {
+	return url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"demo-cli"},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {scope},
+		"state":                 {"state-123"},
+		"nonce":                 {"nonce-456"},
+		"code_challenge":        {pkce.Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+}
+
+func (s *testServer) post(path string, form url.Values, basicUser, basicPass string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, s.url+path, strings.NewReader(form.Encode()))
+	require.NoError(s.t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if basicUser != "" {
+		req.SetBasicAuth(basicUser, basicPass)
+	}
+	resp, err := s.client.Do(req)
+	require.NoError(s.t, err)
+	s.t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// login signs alice in and returns the redirect's query
+func (s *testServer) login(params url.Values, password string) (*http.Response, url.Values) {
+	form := url.Values{"username": {"alice"}, "password": {password}, "action": {"allow"}}
+	for k, v := range params {
+		form[k] = v
+	}
+	resp := s.post("/authorize", form, "", "")
+	if resp.StatusCode != http.StatusSeeOther {
+		return resp, nil
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(s.t, err)
+	return resp, location.Query()
+}
+
+// signIn runs the whole flow and returns the tokens
+func (s *testServer) signIn(scope string) models.TokenResponse {
+	verifier, err := pkce.NewVerifier()
+	require.NoError(s.t, err)
+	_, q := s.login(goodRequest(verifier, scope), "alice-password")
+	require.NotEmpty(s.t, q.Get("code"))
+
+	var tok models.TokenResponse
+	resp := s.exchange(q.Get("code"), verifier)
+	require.Equal(s.t, http.StatusOK, resp.StatusCode)
+	decode(s.t, resp, &tok)
+	return tok
+}
+
+func (s *testServer) exchange(code, verifier string) *http.Response {
+	return s.post("/token", url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {"demo-cli"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}, "", "")
+}
+
+func (s *testServer) refresh(refreshToken, scope string) *http.Response {
+	form := url.Values{"grant_type": {"refresh_token"}, "client_id": {"demo-cli"}, "refresh_token": {refreshToken}}
+	if scope != "" {
+		form.Set("scope", scope)
+	}
+	return s.post("/token", form, "", "")
+}
+
+func (s *testServer) introspect(token string) models.Introspection {
+	var result models.Introspection
+	resp := s.post("/introspect", url.Values{"token": {token}}, "learning-api", "api-secret")
+	require.Equal(s.t, http.StatusOK, resp.StatusCode)
+	decode(s.t, resp, &result)
+	return result
+}
+
+func decode(t *testing.T, resp *http.Response, v interface{}) {
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}
+
+func oauthError(t *testing.T, resp *http.Response) string {
+	var e models.OAuthError
+	decode(t, resp, &e)
+	return e.Code
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	s := newTestServer(t)
+	verifier, err := pkce.NewVerifier()
+	require.NoError(t, err)
+	params := goodRequest(verifier, "openid profile email offline_access api:read")
+
+	// The login page names the client and what it wants
+	resp, err := s.client.Get(s.url + "/authorize?" + params.Encode())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	page, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+	assert.Contains(t, string(page), "Demo CLI")
+	assert.Contains(t, string(page), "Stay signed in")
+
+	resp, _ = s.login(params, "wrong")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	_, q := s.login(params, "alice-password")
+	assert.Equal(t, "state-123", q.Get("state"))
+	assert.Equal(t, s.url, q.Get("iss"))
+	require.NotEmpty(t, q.Get("code"))
+
+	resp = s.exchange(q.Get("code"), verifier)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+	var tok models.TokenResponse
+	decode(t, resp, &tok)
+	assert.Equal(t, "Bearer", tok.TokenType)
+	assert.Equal(t, 60, tok.ExpiresIn)
+	assert.Equal(t, "openid profile email offline_access api:read", tok.Scope)
+	assert.NotEmpty(t, tok.RefreshToken)
+
+	// The ID token is for the client, the access token for the API
+	var id provider.IDClaims
+	require.NoError(t, s.keys.Parse(tok.IDToken, provider.TypeIDToken, &id))
+	assert.Equal(t, "u-1", id.Subject)
+	assert.Equal(t, "nonce-456", id.Nonce)
+	assert.Equal(t, []string{"demo-cli"}, []string(id.Audience))
+	var access provider.AccessClaims
+	require.NoError(t, s.keys.Parse(tok.AccessToken, provider.TypeAccessToken, &access))
+	assert.Equal(t, []string{"learning-api"}, []string(access.Audience))
+	assert.Error(t, s.keys.Parse(tok.IDToken, provider.TypeAccessToken, &access), "an ID token is not an access token")
+
+	req, _ := http.NewRequest(http.MethodGet, s.url+"/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+	resp, err = s.client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var info map[string]interface{}
+	decode(t, resp, &info)
+	assert.Equal(t, map[string]interface{}{
+		"sub": "u-1", "name": "Alice", "preferred_username": "alice",
+		"email": "alice@example.com", "email_verified": true,
+	}, info)
+
+	result := s.introspect(tok.AccessToken)
+	assert.True(t, result.Active)
+	assert.Equal(t, "u-1", result.Subject)
+	assert.Equal(t, "alice", result.Username)
+	assert.Equal(t, "learning-api", result.Audience)
+	assert.Equal(t, "demo-cli", result.ClientID)
+
+	// Only confidential clients may introspect
+	resp = s.post("/introspect", url.Values{"token": {tok.AccessToken}, "client_id": {"demo-cli"}}, "", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, models.ErrUnauthorizedClient, oauthError(t, resp))
+}
+
+func TestAuthorize_Errors(t *testing.T) {
+	s := newTestServer(t)
+	verifier, err := pkce.NewVerifier()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		change func(q url.Values)
+		error  string // "" for an error page rather than a redirect
+	}{
+		{"unknown client", func(q url.Values) { q.Set("client_id", "nobody") }, ""},
+		{"unregistered redirect", func(q url.Values) { q.Set("redirect_uri", "https://evil.example/callback") }, ""},
+		{"implicit flow", func(q url.Values) { q.Set("response_type", "token") }, models.ErrUnsupportedResponseType},
+		{"no PKCE", func(q url.Values) { q.Del("code_challenge") }, models.ErrInvalidRequest},
+		{"plain PKCE", func(q url.Values) { q.Set("code_challenge_method", "plain") }, models.ErrInvalidRequest},
+		{"unknown scope", func(q url.Values) { q.Set("scope", "openid admin") }, models.ErrInvalidScope},
+		{"no scope", func(q url.Values) { q.Del("scope") }, models.ErrInvalidScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := goodRequest(verifier, "openid")
+			tt.change(params)
+			resp, err := s.client.Get(s.url + "/authorize?" + params.Encode())
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			if tt.error == "" {
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+				assert.Empty(t, resp.Header.Get("Location"), "never redirect to an unchecked URI")
+				return
+			}
+			require.Equal(t, http.StatusFound, resp.StatusCode)
+			location, err := url.Parse(resp.Header.Get("Location"))
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(location.String(), redirectURI))
+			assert.Equal(t, tt.error, location.Query().Get("error"))
+			assert.Equal(t, "state-123", location.Query().Get("state"))
+		})
+	}
+
+	// Saying no goes back to the client too
+	form := goodRequest(verifier, "openid")
+	form.Set("action", "deny")
+	resp := s.post("/authorize", form, "", "")
+	require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, models.ErrAccessDenied, location.Query().Get("error"))
+}
+
+func TestToken_CodeChecks(t *testing.T) {
+	s := newTestServer(t)
+	verifier, err := pkce.NewVerifier()
+	require.NoError(t, err)
+	other, err := pkce.NewVerifier()
+	require.NoError(t, err)
+
+	// A stolen code is useless without the verifier
+	_, q := s.login(goodRequest(verifier, "openid"), "alice-password")
+	resp := s.exchange(q.Get("code"), other)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, models.ErrInvalidGrant, oauthError(t, resp))
+
+	// ...and a code is only good once, whatever happened the first time
+	resp = s.exchange(q.Get("code"), verifier)
+	assert.Equal(t, models.ErrInvalidGrant, oauthError(t, resp))
+
+	_, q = s.login(goodRequest(verifier, "openid"), "alice-password")
+	resp = s.post("/token", url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {"demo-cli"},
+		"code":          {q.Get("code")},
+		"redirect_uri":  {"http://127.0.0.1:9999/other"},
+		"code_verifier": {verifier},
+	}, "", "")
+	assert.Equal(t, models.ErrInvalidGrant, oauthError(t, resp))
+
+	resp = s.post("/token", url.Values{"grant_type": {"password"}, "client_id": {"demo-cli"}}, "", "")
+	assert.Equal(t, models.ErrUnsupportedGrantType, oauthError(t, resp))
+
+	resp = s.post("/token", url.Values{"grant_type": {"authorization_code"}}, "learning-api", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, `Basic realm="token"`, resp.Header.Get("WWW-Authenticate"))
+	assert.Equal(t, models.ErrInvalidClient, oauthError(t, resp))
+}
+
+func TestToken_CodeReuseRevokes(t *testing.T) {
+	s := newTestServer(t)
+	verifier, err := pkce.NewVerifier()
+	require.NoError(t, err)
+	_, q := s.login(goodRequest(verifier, "openid offline_access"), "alice-password")
+
+	var tok models.TokenResponse
+	resp := s.exchange(q.Get("code"), verifier)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	decode(t, resp, &tok)
+	require.True(t, s.introspect(tok.AccessToken).Active)
+
+	resp = s.exchange(q.Get("code"), verifier)
+	assert.Equal(t, models.ErrInvalidGrant, oauthError(t, resp))
+	assert.False(t, s.introspect(tok.AccessToken).Active, "what the code got the first time is revoked")
+	assert.False(t, s.introspect(tok.RefreshToken).Active)
+}
+
+func TestRefresh_Rotation(t *testing.T) {
+	s := newTestServer(t)
+	first := s.signIn("openid offline_access api:read api:write")
+
+	var second models.TokenResponse
+	resp := s.refresh(first.RefreshToken, "api:read")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	decode(t, resp, &second)
+	assert.Equal(t, "api:read", second.Scope, "narrowed for this access token")
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	assert.False(t, s.introspect(first.RefreshToken).Active)
+
+	// The new refresh token keeps every scope granted
+	var third models.TokenResponse
+	resp = s.refresh(second.RefreshToken, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	decode(t, resp, &third)
+	assert.Equal(t, "openid offline_access api:read api:write", third.Scope)
+
+	resp = s.refresh(third.RefreshToken, "api:read admin")
+	assert.Equal(t, models.ErrInvalidScope, oauthError(t, resp))
+
+	// Using a rotated token again means it was copied: sign the family out
+	resp = s.refresh(first.RefreshToken, "")
+	assert.Equal(t, models.ErrInvalidGrant, oauthError(t, resp))
+	assert.False(t, s.introspect(third.RefreshToken).Active)
+	assert.False(t, s.introspect(third.AccessToken).Active)
+	resp = s.refresh(third.RefreshToken, "")
+	assert.Equal(t, models.ErrInvalidGrant, oauthError(t, resp))
+}
+
+func TestRevoke(t *testing.T) {
+	s := newTestServer(t)
+	tok := s.signIn("openid offline_access")
+	other := s.signIn("openid offline_access")
+
+	// Revoking an access token ends that token only
+	resp := s.post("/revoke", url.Values{"token": {tok.AccessToken}, "client_id": {"demo-cli"}}, "", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, s.introspect(tok.AccessToken).Active)
+	assert.True(t, s.introspect(tok.RefreshToken).Active)
+
+	req, _ := http.NewRequest(http.MethodGet, s.url+"/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+	resp, err := s.client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("WWW-Authenticate"), `error="invalid_token"`)
+
+	// Revoking a refresh token signs out that login, not the other one
+	resp = s.post("/revoke", url.Values{"token": {tok.RefreshToken}, "client_id": {"demo-cli"}}, "", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, s.introspect(tok.RefreshToken).Active)
+	assert.True(t, s.introspect(other.AccessToken).Active)
+
+	// Unknown tokens are fine
+	resp = s.post("/revoke", url.Values{"token": {"nonsense"}, "client_id": {"demo-cli"}}, "", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestDiscovery(t *testing.T) {
+	s := newTestServer(t)
+
+	var meta models.Discovery
+	resp, err := s.client.Get(s.url + "/.well-known/openid-configuration")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	decode(t, resp, &meta)
+	assert.Equal(t, s.url, meta.Issuer)
+	assert.Equal(t, s.url+"/jwks.json", meta.JWKSURI)
+	assert.Equal(t, []string{"S256"}, meta.CodeChallengeMethodsSupported)
+
+	var set keys.JWKS
+	resp, err = s.client.Get(meta.JWKSURI)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	decode(t, resp, &set)
+	require.Len(t, set.Keys, 1)
+	assert.Equal(t, s.keys.KeyID(), set.Keys[0].Kid)
+	assert.Equal(t, "AQAB", set.Keys[0].E)
+}
@@ -0,0 +1,153 @@
+// Package keys holds the provider's signing key. Tokens are signed with
+// the private half; the public half is published as a JSON Web Key Set,
+// so anyone can check a token without asking the provider.
+package keys
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Bits is the size of generated keys
+const Bits = 2048
+
+// JWK is one public key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS is the document served at jwks_uri
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Set is the signing key. There is one: rotating keys means publishing
+// the next key before signing with it, and the old one until its tokens
+// expire.
+type Set struct {
+	key *rsa.PrivateKey
+	kid string
+}
+
+// Generate creates a set with a new key. Tokens signed with it stop
+// verifying when the process restarts.
+func Generate() (*Set, error) {
+	key, err := rsa.GenerateKey(rand.Reader, Bits)
+	if err != nil {
+		return nil, err
+	}
+	return newSet(key), nil
+}
+
+// LoadOrGenerate reads a PEM key from path, or generates one and saves
+// it there, so tokens outlive restarts
+func LoadOrGenerate(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		set, err := Generate()
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(set.key)
+		if err != nil {
+			return nil, err
+		}
+		pemData := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		if err := os.WriteFile(path, pemData, 0o600); err != nil {
+			return nil, err
+		}
+		return set, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an RSA key", path)
+	}
+	return newSet(key), nil
+}
+
+func newSet(key *rsa.PrivateKey) *Set {
+	return &Set{key: key, kid: thumbprint(&key.PublicKey)}
+}
+
+// thumbprint is the RFC 7638 key ID: a hash of the public key, so the
+// same key always gets the same ID
+func thumbprint(pub *rsa.PublicKey) string {
+	// Members in lexical order, no whitespace, as the RFC requires
+	canonical := fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, encodeInt(big.NewInt(int64(pub.E))), encodeInt(pub.N))
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func encodeInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+// KeyID is the ID sent in the kid header of every token
+func (s *Set) KeyID() string {
+	return s.kid
+}
+
+// Sign signs claims with RS256. typ goes in the header: "at+jwt" for
+// access tokens (RFC 9068) and "JWT" for ID tokens, so one cannot be
+// passed off as the other.
+func (s *Set) Sign(typ string, claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.kid
+	token.Header["typ"] = typ
+	return token.SignedString(s.key)
+}
+
+// Parse verifies a token signed by this set and fills claims
+func (s *Set) Parse(raw, typ string, claims jwt.Claims, opts ...jwt.ParserOption) error {
+	opts = append(opts, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}))
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Header["kid"] != s.kid {
+			return nil, errors.New("unknown key")
+		}
+		if token.Header["typ"] != typ {
+			return nil, fmt.Errorf("expected a token of type %s", typ)
+		}
+		return &s.key.PublicKey, nil
+	}, opts...)
+	return err
+}
+
+// JWKS is the public key, ready to publish
+func (s *Set) JWKS() JWKS {
+	pub := &s.key.PublicKey
+	return JWKS{Keys: []JWK{{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: jwt.SigningMethodRS256.Alg(),
+		Kid: s.kid,
+		N:   encodeInt(pub.N),
+		E:   encodeInt(big.NewInt(int64(pub.E))),
+	}}}
+}
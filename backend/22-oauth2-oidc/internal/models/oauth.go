@@ -0,0 +1,153 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Client is an application allowed to ask for tokens
+type Client struct {
+	ID           string
+	Name         string
+	SecretHash   []byte   // SHA-256 of the secret; nil for a public client
+	RedirectURIs []string // matched exactly, never by prefix
+	Scopes       []string // the most it may ask for
+}
+
+// Public reports whether the client cannot keep a secret, like a mobile,
+// desktop or browser app. It proves itself with PKCE alone.
+func (c *Client) Public() bool {
+	return c.SecretHash == nil
+}
+
+// User is someone who can sign in
+type User struct {
+	ID            string // the subject: stable, unique, never reassigned
+	Username      string
+	PasswordHash  []byte
+	Name          string
+	Email         string
+	EmailVerified bool
+}
+
+// AuthorizeRequest is a checked request to /authorize
+type AuthorizeRequest struct {
+	ClientID      string
+	RedirectURI   string
+	Scopes        []string
+	State         string
+	Nonce         string
+	CodeChallenge string
+}
+
+// AuthCode is an authorization code and what it was issued for
+type AuthCode struct {
+	AuthorizeRequest
+	UserID    string
+	AuthTime  time.Time
+	ExpiresAt time.Time
+	Used      bool
+	Family    string // the tokens issued for it, to revoke if it is reused
+}
+
+// RefreshToken is a refresh token and what it may be exchanged for. Each
+// use replaces it with a new one in the same family.
+type RefreshToken struct {
+	Family    string
+	ClientID  string
+	UserID    string
+	Scopes    []string
+	AuthTime  time.Time
+	ExpiresAt time.Time
+	Used      bool
+	Revoked   bool
+}
+
+// TokenResponse is the body of a successful POST /token (RFC 6749, 5.1)
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope"`
+}
+
+// Introspection is the body of POST /introspect (RFC 7662). An inactive
+// token says nothing more, not even why.
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+	ID        string `json:"jti,omitempty"`
+}
+
+// Discovery is the OpenID Provider metadata served at
+// /.well-known/openid-configuration
+type Discovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	AuthorizationResponseIssParameter bool     `json:"authorization_response_iss_parameter_supported"`
+}
+
+// OAuthError is an error as OAuth clients expect it (RFC 6749, 5.2): a
+// code from a fixed list, and a description for humans
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	Status      int    `json:"-"`
+}
+
+func (e *OAuthError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+// The error codes this provider uses
+const (
+	ErrInvalidRequest          = "invalid_request"
+	ErrInvalidClient           = "invalid_client"
+	ErrInvalidGrant            = "invalid_grant"
+	ErrUnauthorizedClient      = "unauthorized_client"
+	ErrUnsupportedGrantType    = "unsupported_grant_type"
+	ErrUnsupportedResponseType = "unsupported_response_type"
+	ErrInvalidScope            = "invalid_scope"
+	ErrAccessDenied            = "access_denied"
+	ErrInvalidToken            = "invalid_token"
+	ErrInsufficientScope       = "insufficient_scope"
+)
+
+var errorStatus = map[string]int{
+	ErrInvalidClient:     http.StatusUnauthorized,
+	ErrInvalidToken:      http.StatusUnauthorized,
+	ErrInsufficientScope: http.StatusForbidden,
+}
+
+// NewOAuthError creates an error with the status its code is sent with
+func NewOAuthError(code, format string, args ...interface{}) *OAuthError {
+	status, ok := errorStatus[code]
+	if !ok {
+		status = http.StatusBadRequest
+	}
+	return &OAuthError{Code: code, Description: fmt.Sprintf(format, args...), Status: status}
+}
@@ -0,0 +1,65 @@
+// Package pkce is Proof Key for Code Exchange (RFC 7636). The client
+// makes up a secret, the verifier, and sends only its hash, the
+// challenge, when it asks for a code. To exchange the code it must show
+// the verifier. Someone who steals the code on its way back, from a log or
+// another app registered for the same redirect, cannot use it.
+package pkce
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"regexp"
+)
+
+// Method is the only challenge method accepted. "plain" sends the
+// verifier itself as the challenge, which protects nothing once the
+// authorization request can be seen.
+const Method = "S256"
+
+var (
+	// A verifier is 43 to 128 unreserved characters
+	verifierPattern = regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+	// A challenge is a base64url SHA-256 without padding: always 43
+	challengePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`)
+
+	// ErrMismatch is returned when the verifier does not hash to the
+	// challenge
+	ErrMismatch = errors.New("code_verifier does not match code_challenge")
+	// ErrInvalidVerifier is returned for a verifier of the wrong length or
+	// characters
+	ErrInvalidVerifier = errors.New("code_verifier must be 43-128 characters of A-Z a-z 0-9 - . _ ~")
+)
+
+// NewVerifier makes a verifier from 32 random bytes
+func NewVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Challenge is the S256 challenge for a verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ValidChallenge reports whether s looks like an S256 challenge
+func ValidChallenge(s string) bool {
+	return challengePattern.MatchString(s)
+}
+
+// Verify checks that verifier hashes to challenge
+func Verify(verifier, challenge string) error {
+	if !verifierPattern.MatchString(verifier) {
+		return ErrInvalidVerifier
+	}
+	if subtle.ConstantTimeCompare([]byte(Challenge(verifier)), []byte(challenge)) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package pkce
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChallenge_RFCExample(t *testing.T) {
+	// Appendix B of RFC 7636
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", Challenge(verifier))
+	assert.NoError(t, Verify(verifier, Challenge(verifier)))
+}
+
+func TestVerify(t *testing.T) {
+	verifier, err := NewVerifier()
+	require.NoError(t, err)
+	assert.Len(t, verifier, 43)
+	challenge := Challenge(verifier)
+	assert.True(t, ValidChallenge(challenge))
+
+	other, err := NewVerifier()
+	require.NoError(t, err)
+	assert.ErrorIs(t, Verify(other, challenge), ErrMismatch)
+
+	assert.ErrorIs(t, Verify("too-short", challenge), ErrInvalidVerifier)
+	assert.ErrorIs(t, Verify(strings.Repeat("a", 129), challenge), ErrInvalidVerifier)
+	assert.ErrorIs(t, Verify(strings.Repeat("a", 42)+"!", challenge), ErrInvalidVerifier)
+
+	// "plain": the verifier sent as its own challenge
+	assert.ErrorIs(t, Verify(verifier, verifier), ErrMismatch)
+	assert.False(t, ValidChallenge("not a challenge"))
+}
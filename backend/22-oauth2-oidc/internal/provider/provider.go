@@ -0,0 +1,520 @@
+// Package provider is the authorization server: it checks authorization
+// requests, signs users in, and trades codes and refresh tokens for
+// access and ID tokens. It knows nothing about HTTP beyond url.Values.
+package provider
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/keys"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/models"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/pkce"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/store"
+)
+
+// Token types, sent as the typ header
+const (
+	TypeAccessToken = "at+jwt"
+	TypeIDToken     = "JWT"
+)
+
+// Scopes and what the login page says they allow
+var Scopes = map[string]string{
+	"openid":         "Sign you in",
+	"profile":        "See your name and username",
+	"email":          "See your email address",
+	"offline_access": "Stay signed in, until you sign out",
+	"api:read":       "Read your data in the lab APIs",
+	"api:write":      "Change your data in the lab APIs",
+}
+
+// ErrBadCredentials is returned for a wrong username or password, without
+// saying which
+var ErrBadCredentials = errors.New("wrong username or password")
+
+// Config is the provider's configuration
+type Config struct {
+	Issuer          string        // URL the provider is reached at; goes in iss
+	Audience        string        // the APIs access tokens are for; goes in aud
+	AccessTokenTTL  time.Duration // short: a JWT cannot be recalled once out
+	RefreshTokenTTL time.Duration
+	CodeTTL         time.Duration
+}
+
+// AccessClaims are the claims of an access token (RFC 9068)
+type AccessClaims struct {
+	jwt.RegisteredClaims
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope"`
+}
+
+// IDClaims are the claims of an ID token: who signed in, when, and for
+// which client
+type IDClaims struct {
+	jwt.RegisteredClaims
+	Nonce    string `json:"nonce,omitempty"`
+	AuthTime int64  `json:"auth_time"`
+}
+
+// Provider is the authorization server
+type Provider struct {
+	cfg   Config
+	keys  *keys.Set
+	store *store.Memory
+	now   func() time.Time
+
+	// Compared against when the username is unknown, so both failures
+	// take as long
+	dummyHash []byte
+}
+
+// New creates a provider
+func New(cfg Config, keySet *keys.Set, st *store.Memory) *Provider {
+	dummy, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	return &Provider{cfg: cfg, keys: keySet, store: st, now: time.Now, dummyHash: dummy}
+}
+
+// Keys is the provider's key set
+func (p *Provider) Keys() *keys.Set {
+	return p.keys
+}
+
+// Authorize checks an authorization request. The request comes back with
+// the error if the client and redirect URI are good, and the error must
+// then go to the redirect URI. Without them the error is for the user's
+// eyes only: redirecting to an unchecked URI would make the provider an
+// open redirect.
+func (p *Provider) Authorize(q url.Values) (*models.AuthorizeRequest, models.Client, error) {
+	client, err := p.store.Client(q.Get("client_id"))
+	if err != nil {
+		return nil, client, models.NewOAuthError(models.ErrInvalidClient, "Unknown client_id")
+	}
+	redirectURI := q.Get("redirect_uri")
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !slices.Contains(client.RedirectURIs, redirectURI) {
+		return nil, client, models.NewOAuthError(models.ErrInvalidRequest, "redirect_uri is not registered for this client")
+	}
+
+	req := &models.AuthorizeRequest{
+		ClientID:      client.ID,
+		RedirectURI:   redirectURI,
+		State:         q.Get("state"),
+		Nonce:         q.Get("nonce"),
+		CodeChallenge: q.Get("code_challenge"),
+	}
+	if q.Get("response_type") != "code" {
+		return req, client, models.NewOAuthError(models.ErrUnsupportedResponseType, "Only response_type=code is supported")
+	}
+	if req.Scopes, err = checkScopes(q.Get("scope"), client.Scopes); err != nil {
+		return req, client, err
+	}
+	// PKCE for every client, confidential ones too, as OAuth 2.1 has it
+	if q.Get("code_challenge_method") != pkce.Method || !pkce.ValidChallenge(req.CodeChallenge) {
+		return req, client, models.NewOAuthError(models.ErrInvalidRequest, "code_challenge with code_challenge_method=S256 is required")
+	}
+	return req, client, nil
+}
+
+// checkScopes splits a scope parameter and checks every scope is allowed
+func checkScopes(scope string, allowed []string) ([]string, error) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		return nil, models.NewOAuthError(models.ErrInvalidScope, "scope is required")
+	}
+	var unique []string
+	for _, s := range scopes {
+		if _, known := Scopes[s]; !known || !slices.Contains(allowed, s) {
+			return nil, models.NewOAuthError(models.ErrInvalidScope, "Scope %q is not allowed for this client", s)
+		}
+		if !slices.Contains(unique, s) {
+			unique = append(unique, s)
+		}
+	}
+	return unique, nil
+}
+
+// Login checks a username and password
+func (p *Provider) Login(username, password string) (models.User, error) {
+	user, err := p.store.UserByUsername(username)
+	if err != nil {
+		_ = bcrypt.CompareHashAndPassword(p.dummyHash, []byte(password))
+		return models.User{}, ErrBadCredentials
+	}
+	if bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)) != nil {
+		return models.User{}, ErrBadCredentials
+	}
+	return user, nil
+}
+
+// IssueCode creates a code for a signed-in user and returns where to send
+// the browser with it
+func (p *Provider) IssueCode(req *models.AuthorizeRequest, user models.User) (string, error) {
+	code, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	family, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	now := p.now()
+	p.store.SaveCode(code, models.AuthCode{
+		AuthorizeRequest: *req,
+		UserID:           user.ID,
+		AuthTime:         now,
+		ExpiresAt:        now.Add(p.cfg.CodeTTL),
+		Family:           family,
+	})
+	return p.redirect(req, url.Values{"code": {code}}), nil
+}
+
+// ErrorRedirect returns where to send the browser to report err to the
+// client
+func (p *Provider) ErrorRedirect(req *models.AuthorizeRequest, err error) string {
+	var oauthErr *models.OAuthError
+	if !errors.As(err, &oauthErr) {
+		oauthErr = models.NewOAuthError("server_error", "Something went wrong")
+	}
+	values := url.Values{"error": {oauthErr.Code}}
+	if oauthErr.Description != "" {
+		values.Set("error_description", oauthErr.Description)
+	}
+	return p.redirect(req, values)
+}
+
+// redirect adds state and iss to the redirect URI. iss (RFC 9207) lets a
+// client that uses several providers check the answer came from the one
+// it asked.
+func (p *Provider) redirect(req *models.AuthorizeRequest, values url.Values) string {
+	if req.State != "" {
+		values.Set("state", req.State)
+	}
+	values.Set("iss", p.cfg.Issuer)
+	u, _ := url.Parse(req.RedirectURI)
+	q := u.Query()
+	for k, v := range values {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// AuthenticateClient checks a client's credentials at the token,
+// introspection and revocation endpoints. A public client sends its ID
+// alone; a confidential one must send its secret.
+func (p *Provider) AuthenticateClient(id, secret string) (models.Client, error) {
+	client, err := p.store.Client(id)
+	if err != nil {
+		return client, models.NewOAuthError(models.ErrInvalidClient, "Client authentication failed")
+	}
+	if client.Public() {
+		if secret != "" {
+			return client, models.NewOAuthError(models.ErrInvalidClient, "Client authentication failed")
+		}
+		return client, nil
+	}
+	// Secrets are long and random, so a fast hash is enough, unlike for
+	// passwords
+	if subtle.ConstantTimeCompare(store.Hash(secret), client.SecretHash) != 1 {
+		return client, models.NewOAuthError(models.ErrInvalidClient, "Client authentication failed")
+	}
+	return client, nil
+}
+
+// Token handles a token request from an authenticated client
+func (p *Provider) Token(client models.Client, form url.Values) (models.TokenResponse, error) {
+	switch form.Get("grant_type") {
+	case "authorization_code":
+		return p.exchangeCode(client, form)
+	case "refresh_token":
+		return p.refresh(client, form)
+	case "":
+		return models.TokenResponse{}, models.NewOAuthError(models.ErrInvalidRequest, "grant_type is required")
+	default:
+		return models.TokenResponse{}, models.NewOAuthError(models.ErrUnsupportedGrantType, "Supported: authorization_code, refresh_token")
+	}
+}
+
+func (p *Provider) exchangeCode(client models.Client, form url.Values) (models.TokenResponse, error) {
+	code, err := p.store.UseCode(form.Get("code"), p.now())
+	if errors.Is(err, store.ErrAlreadyUsed) {
+		// Either the client retried, or someone else has the code. Assume
+		// the worst: whatever the first exchange got is revoked too.
+		p.store.RevokeFamily(code.Family)
+		return models.TokenResponse{}, models.NewOAuthError(models.ErrInvalidGrant, "Code already used")
+	}
+	if err != nil {
+		return models.TokenResponse{}, models.NewOAuthError(models.ErrInvalidGrant, "Code is invalid or expired")
+	}
+	if code.ClientID != client.ID {
+		return models.TokenResponse{}, models.NewOAuthError(models.ErrInvalidGrant, "Code was issued to another client")
+	}
+	if form.Get("redirect_uri") != code.RedirectURI {
+		return models.TokenResponse{}, models.NewOAuthError(models.ErrInvalidGrant, "redirect_uri does not match the authorization request")
+	}
+	if err := pkce.Verify(form.Get("code_verifier"), code.CodeChallenge); err != nil {
+		return models.TokenResponse{}, models.NewOAuthError(models.ErrInvalidGrant, "%s", err.Error())
+	}
+
+	user, err := p.store.User(code.UserID)
+	if err != nil {
+		return models.TokenResponse{}, models.NewOAuthError(models.ErrInvalidGrant, "User no longer exists")
+	}
+	return p.issue(client, user, code.Scopes, code.Scopes, code.Family, code.Nonce, code.AuthTime)
+}
+
+// refresh trades a refresh token for new tokens and a new refresh token.
+// Rotation means a stolen refresh token works once: when the thief or
+// the client uses it second, the whole family is revoked.
+func (p *Provider) refresh(client models.Client, form url.Values) (models.TokenResponse, error) {
+	rt, err := p.store.UseRefresh(form.Get("refresh_token"), p.now())
+	if errors.Is(err, store.ErrAlreadyUsed) {
+		p.store.RevokeFamily(rt.Family)
+		return models.TokenResponse{}, models.NewOAuthError(models.ErrInvalidGrant, "Refresh token already used; signed out everywhere it was shared")
+	}
+	if err != nil {
+		return models.TokenResponse{}, models.NewOAuthError(models.ErrInvalidGrant, "Refresh token is invalid, expired or revoked")
+	}
+	if rt.ClientID != client.ID {
+		return models.TokenResponse{}, models.NewOAuthError(models.ErrInvalidGrant, "Refresh token was issued to another client")
+	}
+
+	// The access token may have fewer scopes than were granted, never
+	// more. The new refresh token keeps all of them.
+	scopes := rt.Scopes
+	if form.Get("scope") != "" {
+		if scopes, err = checkScopes(form.Get("scope"), rt.Scopes); err != nil {
+			return models.TokenResponse{}, err
+		}
+	}
+
+	user, err := p.store.User(rt.UserID)
+	if err != nil {
+		return models.TokenResponse{}, models.NewOAuthError(models.ErrInvalidGrant, "User no longer exists")
+	}
+	return p.issue(client, user, rt.Scopes, scopes, rt.Family, "", rt.AuthTime)
+}
+
+// issue signs an access token for scopes, an ID token if openid was
+// granted, and a refresh token for everything granted if offline_access
+// was
+func (p *Provider) issue(client models.Client, user models.User, granted, scopes []string, family, nonce string, authTime time.Time) (models.TokenResponse, error) {
+	now := p.now()
+	jti, err := randomToken()
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	scope := strings.Join(scopes, " ")
+	expiresAt := now.Add(p.cfg.AccessTokenTTL)
+	access, err := p.keys.Sign(TypeAccessToken, AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    p.cfg.Issuer,
+			Subject:   user.ID,
+			Audience:  jwt.ClaimStrings{p.cfg.Audience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        jti,
+		},
+		ClientID: client.ID,
+		Scope:    scope,
+	})
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	p.store.TrackAccess(family, jti, expiresAt)
+
+	resp := models.TokenResponse{
+		AccessToken: access,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(p.cfg.AccessTokenTTL.Seconds()),
+		Scope:       scope,
+	}
+	if slices.Contains(granted, "openid") {
+		resp.IDToken, err = p.keys.Sign(TypeIDToken, IDClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    p.cfg.Issuer,
+				Subject:   user.ID,
+				Audience:  jwt.ClaimStrings{client.ID},
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+				IssuedAt:  jwt.NewNumericDate(now),
+			},
+			Nonce:    nonce,
+			AuthTime: authTime.Unix(),
+		})
+		if err != nil {
+			return models.TokenResponse{}, err
+		}
+	}
+	if slices.Contains(granted, "offline_access") {
+		if resp.RefreshToken, err = randomToken(); err != nil {
+			return models.TokenResponse{}, err
+		}
+		p.store.SaveRefresh(resp.RefreshToken, models.RefreshToken{
+			Family:    family,
+			ClientID:  client.ID,
+			UserID:    user.ID,
+			Scopes:    granted,
+			AuthTime:  authTime,
+			ExpiresAt: now.Add(p.cfg.RefreshTokenTTL),
+		})
+	}
+	return resp, nil
+}
+
+// parseAccess verifies an access token's signature, issuer and expiry,
+// and that it was not revoked
+func (p *Provider) parseAccess(raw string) (*AccessClaims, error) {
+	var claims AccessClaims
+	err := p.keys.Parse(raw, TypeAccessToken, &claims, jwt.WithIssuer(p.cfg.Issuer), jwt.WithTimeFunc(p.now))
+	if err != nil {
+		return nil, err
+	}
+	// Every token this provider signs expires; one that does not was not
+	// signed here
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("token has no exp")
+	}
+	if p.store.AccessRevoked(claims.ID) {
+		return nil, errors.New("token revoked")
+	}
+	return &claims, nil
+}
+
+// Introspect says whether a token is active, and what it is for. Only
+// confidential clients, like APIs checking tokens, may ask.
+func (p *Provider) Introspect(client models.Client, token string) (models.Introspection, error) {
+	if client.Public() {
+		return models.Introspection{}, models.NewOAuthError(models.ErrUnauthorizedClient, "Only confidential clients may introspect")
+	}
+
+	if claims, err := p.parseAccess(token); err == nil {
+		username := ""
+		if user, err := p.store.User(claims.Subject); err == nil {
+			username = user.Username
+		}
+		return models.Introspection{
+			Active:    true,
+			Scope:     claims.Scope,
+			ClientID:  claims.ClientID,
+			Username:  username,
+			TokenType: "Bearer",
+			ExpiresAt: claims.ExpiresAt.Unix(),
+			IssuedAt:  claims.IssuedAt.Unix(),
+			Subject:   claims.Subject,
+			Audience:  strings.Join(claims.Audience, " "),
+			Issuer:    claims.Issuer,
+			ID:        claims.ID,
+		}, nil
+	}
+
+	rt, err := p.store.Refresh(token)
+	if err != nil || rt.Used || rt.Revoked || !p.now().Before(rt.ExpiresAt) {
+		return models.Introspection{Active: false}, nil
+	}
+	return models.Introspection{
+		Active:    true,
+		Scope:     strings.Join(rt.Scopes, " "),
+		ClientID:  rt.ClientID,
+		TokenType: "refresh_token",
+		ExpiresAt: rt.ExpiresAt.Unix(),
+		Subject:   rt.UserID,
+		Issuer:    p.cfg.Issuer,
+	}, nil
+}
+
+// Revoke revokes a token the client holds (RFC 7009). Revoking a refresh
+// token signs that login out: its family goes with it. Unknown tokens are
+// not an error, so a client can always clean up.
+func (p *Provider) Revoke(client models.Client, token string) {
+	if claims, err := p.parseAccess(token); err == nil {
+		if claims.ClientID == client.ID {
+			p.store.RevokeAccess(claims.ID, claims.ExpiresAt.Time)
+		}
+		return
+	}
+	if rt, err := p.store.Refresh(token); err == nil && rt.ClientID == client.ID {
+		p.store.RevokeFamily(rt.Family)
+	}
+}
+
+// UserInfo returns the claims about the user an access token allows
+func (p *Provider) UserInfo(token string) (map[string]interface{}, error) {
+	claims, err := p.parseAccess(token)
+	if err != nil {
+		return nil, models.NewOAuthError(models.ErrInvalidToken, "Access token is invalid, expired or revoked")
+	}
+	scopes := strings.Fields(claims.Scope)
+	if !slices.Contains(scopes, "openid") {
+		return nil, models.NewOAuthError(models.ErrInsufficientScope, "The openid scope is required")
+	}
+	user, err := p.store.User(claims.Subject)
+	if err != nil {
+		return nil, models.NewOAuthError(models.ErrInvalidToken, "User no longer exists")
+	}
+
+	info := map[string]interface{}{"sub": user.ID}
+	if slices.Contains(scopes, "profile") {
+		info["name"] = user.Name
+		info["preferred_username"] = user.Username
+	}
+	if slices.Contains(scopes, "email") {
+		info["email"] = user.Email
+		info["email_verified"] = user.EmailVerified
+	}
+	return info, nil
+}
+
+// Discovery is the provider's metadata, from which clients find
+// everything else
+func (p *Provider) Discovery() models.Discovery {
+	scopes := make([]string, 0, len(Scopes))
+	for s := range Scopes {
+		scopes = append(scopes, s)
+	}
+	slices.Sort(scopes)
+	return models.Discovery{
+		Issuer:                            p.cfg.Issuer,
+		AuthorizationEndpoint:             p.cfg.Issuer + "/authorize",
+		TokenEndpoint:                     p.cfg.Issuer + "/token",
+		UserinfoEndpoint:                  p.cfg.Issuer + "/userinfo",
+		JWKSURI:                           p.cfg.Issuer + "/jwks.json",
+		IntrospectionEndpoint:             p.cfg.Issuer + "/introspect",
+		RevocationEndpoint:                p.cfg.Issuer + "/revoke",
+		ScopesSupported:                   scopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{jwt.SigningMethodRS256.Alg()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{pkce.Method},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "name", "preferred_username", "email", "email_verified"},
+		AuthorizationResponseIssParameter: true,
+	}
+}
+
+// Cleanup forgets expired codes, tokens and revocations
+func (p *Provider) Cleanup() {
+	p.store.Cleanup(p.now())
+}
+
+// randomToken is 32 random bytes, base64url: codes, refresh tokens and
+// token IDs
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
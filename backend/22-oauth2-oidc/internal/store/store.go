@@ -0,0 +1,242 @@
+// Package store keeps clients, users and issued grants in memory. Codes
+// and refresh tokens are stored by their SHA-256, so a dump of the store
+// holds nothing that can be exchanged for a token.
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/models"
+)
+
+var (
+	// ErrNotFound is returned for an unknown client, user, code or token
+	ErrNotFound = errors.New("not found")
+	// ErrAlreadyUsed is returned the second time a code or refresh token
+	// is presented
+	ErrAlreadyUsed = errors.New("already used")
+)
+
+// Memory is an in-memory store
+type Memory struct {
+	mu        sync.Mutex
+	clients   map[string]*models.Client
+	users     map[string]*models.User // by ID
+	usernames map[string]string       // username to ID
+	codes     map[string]*models.AuthCode
+	refresh   map[string]*models.RefreshToken
+	// Access tokens are JWTs and not stored; a revoked one is remembered
+	// by its ID until it would have expired anyway
+	revoked  map[string]time.Time
+	families map[string][]accessToken // access tokens issued per family
+}
+
+type accessToken struct {
+	id        string
+	expiresAt time.Time
+}
+
+// NewMemory creates an empty store
+func NewMemory() *Memory {
+	return &Memory{
+		clients:   make(map[string]*models.Client),
+		users:     make(map[string]*models.User),
+		usernames: make(map[string]string),
+		codes:     make(map[string]*models.AuthCode),
+		refresh:   make(map[string]*models.RefreshToken),
+		revoked:   make(map[string]time.Time),
+		families:  make(map[string][]accessToken),
+	}
+}
+
+// Hash is how codes, tokens and client secrets are stored
+func Hash(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+func key(secret string) string {
+	return hex.EncodeToString(Hash(secret))
+}
+
+// AddClient registers a client
+func (s *Memory) AddClient(c models.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[c.ID] = &c
+}
+
+// Client finds a client by ID
+func (s *Memory) Client(id string) (models.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.clients[id]
+	if !ok {
+		return models.Client{}, ErrNotFound
+	}
+	return *c, nil
+}
+
+// AddUser registers a user
+func (s *Memory) AddUser(u models.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[u.ID] = &u
+	s.usernames[u.Username] = u.ID
+}
+
+// User finds a user by ID
+func (s *Memory) User(id string) (models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return *u, nil
+}
+
+// UserByUsername finds a user by username
+func (s *Memory) UserByUsername(username string) (models.User, error) {
+	s.mu.Lock()
+	id, ok := s.usernames[username]
+	s.mu.Unlock()
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return s.User(id)
+}
+
+// SaveCode stores a new authorization code
+func (s *Memory) SaveCode(code string, c models.AuthCode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes[key(code)] = &c
+}
+
+// UseCode marks a code used and returns it. A code used before comes
+// back with ErrAlreadyUsed, so the caller can revoke what it was
+// exchanged for.
+func (s *Memory) UseCode(code string, now time.Time) (models.AuthCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.codes[key(code)]
+	if !ok || !now.Before(c.ExpiresAt) {
+		return models.AuthCode{}, ErrNotFound
+	}
+	if c.Used {
+		return *c, ErrAlreadyUsed
+	}
+	c.Used = true
+	return *c, nil
+}
+
+// SaveRefresh stores a new refresh token
+func (s *Memory) SaveRefresh(token string, rt models.RefreshToken) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh[key(token)] = &rt
+}
+
+// UseRefresh marks a refresh token used and returns it, like UseCode
+func (s *Memory) UseRefresh(token string, now time.Time) (models.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt, ok := s.refresh[key(token)]
+	if !ok || rt.Revoked || !now.Before(rt.ExpiresAt) {
+		return models.RefreshToken{}, ErrNotFound
+	}
+	if rt.Used {
+		return *rt, ErrAlreadyUsed
+	}
+	rt.Used = true
+	return *rt, nil
+}
+
+// Refresh looks a refresh token up without using it. Used and revoked
+// tokens are returned too; the caller decides what they mean.
+func (s *Memory) Refresh(token string) (models.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt, ok := s.refresh[key(token)]
+	if !ok {
+		return models.RefreshToken{}, ErrNotFound
+	}
+	return *rt, nil
+}
+
+// TrackAccess records that an access token was issued in a family, so
+// revoking the family revokes it too
+func (s *Memory) TrackAccess(family, id string, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.families[family] = append(s.families[family], accessToken{id: id, expiresAt: expiresAt})
+}
+
+// RevokeFamily revokes every refresh and access token issued from one
+// login
+func (s *Memory) RevokeFamily(family string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rt := range s.refresh {
+		if rt.Family == family {
+			rt.Revoked = true
+		}
+	}
+	for _, at := range s.families[family] {
+		s.revoked[at.id] = at.expiresAt
+	}
+}
+
+// RevokeAccess revokes one access token
+func (s *Memory) RevokeAccess(id string, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[id] = expiresAt
+}
+
+// AccessRevoked reports whether an access token was revoked
+func (s *Memory) AccessRevoked(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, revoked := s.revoked[id]
+	return revoked
+}
+
+// Cleanup forgets codes, tokens and revocations past their expiry
+func (s *Memory) Cleanup(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, c := range s.codes {
+		if now.After(c.ExpiresAt) {
+			delete(s.codes, k)
+		}
+	}
+	for k, rt := range s.refresh {
+		if now.After(rt.ExpiresAt) {
+			delete(s.refresh, k)
+		}
+	}
+	for id, expiresAt := range s.revoked {
+		if now.After(expiresAt) {
+			delete(s.revoked, id)
+		}
+	}
+	for family, tokens := range s.families {
+		live := tokens[:0]
+		for _, at := range tokens {
+			if !now.After(at.expiresAt) {
+				live = append(live, at)
+			}
+		}
+		if len(live) == 0 {
+			delete(s.families, family)
+		} else {
+			s.families[family] = live
+		}
+	}
+}
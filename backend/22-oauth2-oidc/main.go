@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/handlers"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/keys"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/models"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/provider"
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/store"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+func main() {
+	keySet, err := loadKeys()
+	if err != nil {
+		log.Fatal("Failed to load signing key:", err)
+	}
+
+	port := env.Get("PORT", "8080")
+	st := store.NewMemory()
+	seed(st)
+	p := provider.New(provider.Config{
+		Issuer:          strings.TrimSuffix(env.Get("ISSUER", "http://localhost:"+port), "/"),
+		Audience:        env.Get("AUDIENCE", "learning-api"),
+		AccessTokenTTL:  getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		CodeTTL:         getEnvDuration("CODE_TTL", time.Minute),
+	}, keySet, st)
+	oauthHandler := handlers.NewOAuthHandler(p)
+
+	// Forget expired codes, tokens and revocations now and then
+	go func() {
+		for range time.Tick(time.Minute) {
+			p.Cleanup()
+		}
+	}()
+
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           setupRoutes(oauthHandler),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Printf("🪪 OAuth2/OIDC provider running at %s (key %s)", p.Discovery().Issuer, keySet.KeyID())
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	sig, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-sig.Done()
+
+	log.Println("Shutting down server...")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
+	log.Println("Server exited")
+}
+
+// loadKeys uses the key in KEY_FILE, creating it if needed, or a new key
+// for every run
+func loadKeys() (*keys.Set, error) {
+	if path := env.Get("KEY_FILE", ""); path != "" {
+		return keys.LoadOrGenerate(path)
+	}
+	log.Println("KEY_FILE not set, tokens will not survive a restart")
+	return keys.Generate()
+}
+
+// seed registers the demo users and clients. Everything is in memory, so
+// they are all there is.
+func seed(st *store.Memory) {
+	for _, u := range []struct {
+		id, username, password, name, email string
+	}{
+		{"u-1001", "alice", "alice-password", "Alice Liddell", "alice@example.com"},
+		{"u-1002", "bob", "bob-password", "Bob Builder", "bob@example.com"},
+	} {
+		hash, err := bcrypt.GenerateFromPassword([]byte(u.password), bcrypt.DefaultCost)
+		if err != nil {
+			log.Fatal("Failed to hash password:", err)
+		}
+		st.AddUser(models.User{ID: u.id, Username: u.username, PasswordHash: hash, Name: u.name, Email: u.email, EmailVerified: true})
+	}
+
+	// A command-line app: public, since anyone can read its binary
+	st.AddClient(models.Client{
+		ID:           "demo-cli",
+		Name:         "Demo CLI",
+		RedirectURIs: []string{"http://127.0.0.1:9999/callback"},
+		Scopes:       []string{"openid", "profile", "email", "offline_access", "api:read", "api:write"},
+	})
+	// The lab APIs: confidential, and only here to introspect tokens
+	st.AddClient(models.Client{
+		ID:         "learning-api",
+		Name:       "Learning APIs",
+		SecretHash: store.Hash(env.Get("API_CLIENT_SECRET", "learning-api-secret")),
+	})
+}
+
+func setupRoutes(oauthHandler *handlers.OAuthHandler) *mux.Router {
+	router := mux.NewRouter()
+
+	// Discovery: where everything else is
+	router.HandleFunc("/.well-known/openid-configuration", oauthHandler.Discovery).Methods("GET")
+	router.HandleFunc("/jwks.json", oauthHandler.JWKS).Methods("GET")
+
+	// The browser's part: sign in and consent
+	router.HandleFunc("/authorize", oauthHandler.Authorize).Methods("GET")
+	router.HandleFunc("/authorize", oauthHandler.Login).Methods("POST")
+
+	// The client's part: back channel, authenticated
+	router.HandleFunc("/token", oauthHandler.Token).Methods("POST")
+	router.HandleFunc("/revoke", oauthHandler.Revoke).Methods("POST")
+
+	// The API's part
+	router.HandleFunc("/introspect", oauthHandler.Introspect).Methods("POST")
+	router.HandleFunc("/userinfo", oauthHandler.UserInfo).Methods("GET", "POST")
+
+	// Health check
+	router.HandleFunc("/health", oauthHandler.HealthCheck).Methods("GET")
+
+	return router
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(env.Get(key, defaultValue.String()))
+	if err != nil {
+		log.Fatalf("%s must be a duration like 2s: %v", key, err)
+	}
+	return value
+}
@@ -0,0 +1,62 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// FromContext returns the claims Require put in the request's context
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
+
+// Require lets through requests with a valid bearer token granted every
+// one of scopes. Failures are answered as RFC 6750 describes: 401 for a
+// missing or bad token, 403 for a good token without the scope.
+func (v *Verifier) Require(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				// No error code: the client may just not know it needs a token
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				respondError(w, http.StatusUnauthorized, "invalid_request", "Bearer token required")
+				return
+			}
+
+			claims, err := v.Verify(r.Context(), token)
+			if errors.Is(err, ErrInvalidToken) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+				respondError(w, http.StatusUnauthorized, "invalid_token", "Token is invalid, expired or revoked")
+				return
+			}
+			if err != nil {
+				log.Printf("Failed to check access token: %v", err)
+				respondError(w, http.StatusServiceUnavailable, "temporarily_unavailable", "Cannot check tokens right now")
+				return
+			}
+
+			for _, scope := range scopes {
+				if !claims.HasScope(scope) {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="insufficient_scope", scope="`+strings.Join(scopes, " ")+`"`)
+					respondError(w, http.StatusForbidden, "insufficient_scope", "Token needs scope "+strings.Join(scopes, " "))
+					return
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, claims)))
+		})
+	}
+}
+
+func respondError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": code, "error_description": description})
+}
@@ -0,0 +1,188 @@
+// Package resource checks access tokens from the provider in this module,
+// for the APIs that accept them: resource servers, in OAuth terms.
+//
+//	auth := resource.NewVerifier("http://localhost:8092", "learning-api")
+//	router.Handle("/notes", auth.Require("api:write")(createNote)).Methods("POST")
+//
+// There are two ways to check a token. By default the verifier checks its
+// signature with the provider's published keys: no call to the provider
+// per request, but a revoked token stays valid until it expires. With
+// WithIntrospection it asks the provider about every token instead: a
+// revoked token fails at once, and every request waits on the provider.
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned for a token that is malformed, expired,
+// revoked, or not meant for this API. Any other error means the token
+// could not be checked.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are what an API learns from a valid access token
+type Claims struct {
+	Subject   string    `json:"sub"`
+	ClientID  string    `json:"client_id"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// HasScope reports whether the token was granted scope
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// Verifier checks access tokens for one API
+type Verifier struct {
+	issuer   string
+	audience string
+	baseURL  string
+	client   *http.Client
+	keys     *KeySet
+
+	// Set by WithIntrospection
+	clientID, clientSecret string
+}
+
+// Option configures a Verifier
+type Option func(*Verifier)
+
+// WithBaseURL sets where to reach the provider, when that is not its
+// issuer URL: inside Docker, the issuer is what the browser sees, and the
+// API reaches the provider by its service name
+func WithBaseURL(baseURL string) Option {
+	return func(v *Verifier) { v.baseURL = strings.TrimSuffix(baseURL, "/") }
+}
+
+// WithHTTPClient sets the client used to reach the provider
+func WithHTTPClient(client *http.Client) Option {
+	return func(v *Verifier) { v.client = client }
+}
+
+// WithIntrospection checks every token with the provider, authenticating
+// as a confidential client
+func WithIntrospection(clientID, clientSecret string) Option {
+	return func(v *Verifier) { v.clientID, v.clientSecret = clientID, clientSecret }
+}
+
+// NewVerifier creates a verifier for tokens from issuer meant for
+// audience
+func NewVerifier(issuer, audience string, opts ...Option) *Verifier {
+	v := &Verifier{
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		audience: audience,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+	v.baseURL = v.issuer
+	for _, opt := range opts {
+		opt(v)
+	}
+	v.keys = NewKeySet(v.baseURL+"/jwks.json", v.client)
+	return v
+}
+
+// Verify checks a token and returns its claims
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	if v.clientID != "" {
+		return v.introspect(ctx, token)
+	}
+
+	var claims struct {
+		jwt.RegisteredClaims
+		ClientID string `json:"client_id"`
+		Scope    string `json:"scope"`
+	}
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		// An ID token is signed with the same key; only typ tells them
+		// apart, and an ID token says nothing about what its bearer may do
+		if t.Header["typ"] != "at+jwt" {
+			return nil, fmt.Errorf("%w: not an access token", ErrInvalidToken)
+		}
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithAudience(v.audience),
+	)
+	if err != nil {
+		// A key that could not be fetched is the provider's problem, not
+		// the token's
+		if errors.Is(err, jwt.ErrTokenUnverifiable) && !errors.Is(err, ErrInvalidToken) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: no exp", ErrInvalidToken)
+	}
+	return &Claims{
+		Subject:   claims.Subject,
+		ClientID:  claims.ClientID,
+		Scopes:    strings.Fields(claims.Scope),
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// introspect asks the provider about a token (RFC 7662)
+func (v *Verifier) introspect(ctx context.Context, token string) (*Claims, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.baseURL+"/introspect", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(v.clientID), url.QueryEscape(v.clientSecret))
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("introspect: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspect: %s", resp.Status)
+	}
+	var result struct {
+		Active    bool   `json:"active"`
+		Scope     string `json:"scope"`
+		ClientID  string `json:"client_id"`
+		TokenType string `json:"token_type"`
+		ExpiresAt int64  `json:"exp"`
+		Subject   string `json:"sub"`
+		Audience  string `json:"aud"`
+		Issuer    string `json:"iss"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("introspect: %w", err)
+	}
+
+	// Active only means the provider issued it and it still counts: a
+	// refresh token, or a token for another API, is active too
+	switch {
+	case !result.Active:
+		return nil, fmt.Errorf("%w: not active", ErrInvalidToken)
+	case result.TokenType != "Bearer":
+		return nil, fmt.Errorf("%w: not an access token", ErrInvalidToken)
+	case result.Issuer != v.issuer:
+		return nil, fmt.Errorf("%w: issued by %s", ErrInvalidToken, result.Issuer)
+	case !slices.Contains(strings.Fields(result.Audience), v.audience):
+		return nil, fmt.Errorf("%w: not meant for %s", ErrInvalidToken, v.audience)
+	}
+	return &Claims{
+		Subject:   result.Subject,
+		ClientID:  result.ClientID,
+		Scopes:    strings.Fields(result.Scope),
+		ExpiresAt: time.Unix(result.ExpiresAt, 0),
+	}, nil
+}
@@ -0,0 +1,226 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/22-oauth2-oidc/internal/keys"
+)
+
+const audience = "learning-api"
+
+// fakeProvider serves a key set that can be swapped, and counts fetches
+type fakeProvider struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    *keys.Set
+	fetches atomic.Int32
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	set, err := keys.Generate()
+	require.NoError(t, err)
+	p := &fakeProvider{keys: set}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.fetches.Add(1)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(p.keys.JWKS())
+	}))
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *fakeProvider) rotate(t *testing.T) {
+	set, err := keys.Generate()
+	require.NoError(t, err)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = set
+}
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope"`
+}
+
+// sign makes an access token, changed by change
+func (p *fakeProvider) sign(t *testing.T, typ string, change func(c *tokenClaims)) string {
+	c := tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    p.URL,
+			Subject:   "u-1",
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		ClientID: "demo-cli",
+		Scope:    "openid api:read",
+	}
+	if change != nil {
+		change(&c)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	token, err := p.keys.Sign(typ, c)
+	require.NoError(t, err)
+	return token
+}
+
+func TestVerify(t *testing.T) {
+	p := newFakeProvider(t)
+	v := NewVerifier(p.URL, audience)
+	ctx := context.Background()
+
+	claims, err := v.Verify(ctx, p.sign(t, "at+jwt", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "u-1", claims.Subject)
+	assert.Equal(t, "demo-cli", claims.ClientID)
+	assert.True(t, claims.HasScope("api:read"))
+	assert.False(t, claims.HasScope("api:write"))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"ID token", p.sign(t, "JWT", nil)},
+		{"another API's", p.sign(t, "at+jwt", func(c *tokenClaims) { c.Audience = jwt.ClaimStrings{"billing-api"} })},
+		{"another issuer's", p.sign(t, "at+jwt", func(c *tokenClaims) { c.Issuer = "https://evil.example" })},
+		{"expired", p.sign(t, "at+jwt", func(c *tokenClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Second)) })},
+		{"never expires", p.sign(t, "at+jwt", func(c *tokenClaims) { c.ExpiresAt = nil })},
+		{"garbage", "not.a.token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(ctx, tt.token)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+	assert.Equal(t, int32(1), p.fetches.Load(), "keys are fetched once and kept")
+}
+
+func TestVerify_KeyRotation(t *testing.T) {
+	p := newFakeProvider(t)
+	v := NewVerifier(p.URL, audience)
+	ctx := context.Background()
+	_, err := v.Verify(ctx, p.sign(t, "at+jwt", nil))
+	require.NoError(t, err)
+
+	// A new kid soon after a fetch is refused without asking again, or
+	// made-up kids would cost a fetch each
+	p.rotate(t)
+	token := p.sign(t, "at+jwt", nil)
+	_, err = v.Verify(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Equal(t, int32(1), p.fetches.Load())
+
+	// Later, it is fetched again and found
+	v.keys.mu.Lock()
+	v.keys.fetched = time.Now().Add(-MinRefresh)
+	v.keys.mu.Unlock()
+	_, err = v.Verify(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), p.fetches.Load())
+}
+
+func TestRequire(t *testing.T) {
+	p := newFakeProvider(t)
+	v := NewVerifier(p.URL, audience)
+	handler := v.Require("api:read")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := FromContext(r.Context())
+		require.True(t, ok)
+		_, _ = w.Write([]byte(claims.Subject))
+	}))
+
+	call := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/notes", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call("Bearer " + p.sign(t, "at+jwt", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "u-1", rec.Body.String())
+
+	rec = call("")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer realm="api"`, rec.Header().Get("WWW-Authenticate"))
+
+	rec = call("Bearer " + p.sign(t, "JWT", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
+
+	rec = call("Bearer " + p.sign(t, "at+jwt", func(c *tokenClaims) { c.Scope = "openid" }))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), `error="insufficient_scope", scope="api:read"`)
+
+	// When the keys cannot be fetched, the token is not to blame
+	down := NewVerifier("http://127.0.0.1:1", audience)
+	req := httptest.NewRequest(http.MethodGet, "/notes", nil)
+	req.Header.Set("Authorization", "Bearer "+p.sign(t, "at+jwt", nil))
+	rec = httptest.NewRecorder()
+	down.Require()(handler).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestVerify_Introspection(t *testing.T) {
+	var answer map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "learning-api" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "/introspect", r.URL.Path)
+		assert.Equal(t, "the-token", r.FormValue("token"))
+		_ = json.NewEncoder(w).Encode(answer)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	v := NewVerifier(srv.URL, audience, WithIntrospection("learning-api", "secret"))
+
+	active := func() map[string]interface{} {
+		return map[string]interface{}{
+			"active": true, "token_type": "Bearer", "iss": srv.URL, "aud": audience,
+			"sub": "u-1", "scope": "api:read api:write", "client_id": "demo-cli", "exp": time.Now().Add(time.Minute).Unix(),
+		}
+	}
+	answer = active()
+	claims, err := v.Verify(ctx, "the-token")
+	require.NoError(t, err)
+	assert.Equal(t, "u-1", claims.Subject)
+	assert.True(t, claims.HasScope("api:write"))
+
+	answer = map[string]interface{}{"active": false}
+	_, err = v.Verify(ctx, "the-token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Active is not enough: it must be an access token for this API
+	answer = active()
+	answer["token_type"] = "refresh_token"
+	_, err = v.Verify(ctx, "the-token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	answer = active()
+	answer["aud"] = "billing-api"
+	_, err = v.Verify(ctx, "the-token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	wrong := NewVerifier(srv.URL, audience, WithIntrospection("learning-api", "wrong"))
+	_, err = wrong.Verify(ctx, "the-token")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidToken, "a misconfigured API is not a bad token")
+}
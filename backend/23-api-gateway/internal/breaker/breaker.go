@@ -0,0 +1,151 @@
+// Package breaker stops the gateway from sending requests to an upstream
+// that keeps failing. Each upstream has its own breaker, so one service
+// going down does not slow down the routes to the others.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// State is where a breaker is in its cycle
+type State int
+
+const (
+	// Closed lets every request through and counts failures in a row
+	Closed State = iota
+	// Open refuses every request until the cooldown is over
+	Open
+	// HalfOpen lets one request through to see if the upstream is back
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// MarshalText makes states readable in JSON
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ErrOpen is returned by Allow while requests are refused
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Breaker opens after threshold failures in a row, and after cooldown lets
+// a single probe through: its success closes the breaker, its failure
+// opens it for another cooldown.
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// New creates a closed breaker
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: max(threshold, 1), cooldown: cooldown, now: time.Now}
+}
+
+// Allow asks to send one request. When it returns nil, the caller must
+// report how the request went with Done, or Abandon if it cannot tell.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open {
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrOpen
+		}
+		b.state = HalfOpen
+	}
+	if b.state == HalfOpen {
+		// One probe at a time: if the upstream is still down, only one
+		// request waits on it
+		if b.probing {
+			return ErrOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Done records how a request that Allow let through went
+func (b *Breaker) Done(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case HalfOpen:
+		b.probing = false
+		if success {
+			b.state, b.failures = Closed, 0
+		} else {
+			b.trip()
+		}
+	case Closed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.threshold {
+			b.trip()
+		}
+	}
+	// Open: the request was let through before the breaker opened, and
+	// says nothing the breaker does not already know
+}
+
+// Abandon gives up a request without a verdict, such as one the client
+// canceled. It frees the probe slot without closing or opening anything.
+func (b *Breaker) Abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == HalfOpen {
+		b.probing = false
+	}
+}
+
+func (b *Breaker) trip() {
+	b.state = Open
+	b.openedAt = b.now()
+	b.failures = 0
+}
+
+// RetryAfter is how long until an open breaker lets a probe through
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != Open {
+		return 0
+	}
+	return max(b.cooldown-b.now().Sub(b.openedAt), 0)
+}
+
+// Snapshot is a breaker's state at one moment
+type Snapshot struct {
+	State    State `json:"state"`
+	Failures int   `json:"failures"`
+}
+
+// Snapshot returns the breaker's state and its failures in a row. An open
+// breaker whose cooldown is over reports open until a request arrives.
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return Snapshot{State: b.state, Failures: b.failures}
+}
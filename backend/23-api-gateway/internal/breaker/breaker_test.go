@@ -0,0 +1,98 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a time source the test moves by hand
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newBreaker(threshold int, cooldown time.Duration) (*Breaker, *clock) {
+	c := &clock{t: time.Unix(0, 0)}
+	b := New(threshold, cooldown)
+	b.now = c.now
+	return b, c
+}
+
+func fail(t *testing.T, b *Breaker, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		require.NoError(t, b.Allow())
+		b.Done(false)
+	}
+}
+
+func TestBreaker_Opens(t *testing.T) {
+	b, _ := newBreaker(3, time.Minute)
+
+	// A success in between starts the count again
+	fail(t, b, 2)
+	require.NoError(t, b.Allow())
+	b.Done(true)
+	fail(t, b, 2)
+	assert.Equal(t, Snapshot{State: Closed, Failures: 2}, b.Snapshot())
+
+	fail(t, b, 1)
+	assert.Equal(t, Open, b.Snapshot().State)
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+	assert.Equal(t, time.Minute, b.RetryAfter())
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	b, c := newBreaker(1, time.Minute)
+	fail(t, b, 1)
+
+	c.advance(30 * time.Second)
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+	assert.Equal(t, 30*time.Second, b.RetryAfter())
+
+	// After the cooldown one probe goes through, and only one
+	c.advance(30 * time.Second)
+	require.NoError(t, b.Allow())
+	assert.Equal(t, HalfOpen, b.Snapshot().State)
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+
+	// A failed probe opens it for another cooldown
+	b.Done(false)
+	assert.Equal(t, Open, b.Snapshot().State)
+	assert.Equal(t, time.Minute, b.RetryAfter())
+
+	// A successful one closes it
+	c.advance(time.Minute)
+	require.NoError(t, b.Allow())
+	b.Done(true)
+	assert.Equal(t, Snapshot{State: Closed}, b.Snapshot())
+	assert.NoError(t, b.Allow())
+}
+
+func TestBreaker_Abandon(t *testing.T) {
+	b, c := newBreaker(1, time.Minute)
+	fail(t, b, 1)
+	c.advance(time.Minute)
+
+	// A probe the client gave up on says nothing about the upstream,
+	// but must not keep the next probe out
+	require.NoError(t, b.Allow())
+	b.Abandon()
+	assert.Equal(t, HalfOpen, b.Snapshot().State)
+	assert.NoError(t, b.Allow())
+}
+
+func TestBreaker_LateResults(t *testing.T) {
+	b, _ := newBreaker(2, time.Minute)
+
+	// Two requests in flight when the breaker opens: the second result
+	// arrives after, and changes nothing
+	require.NoError(t, b.Allow())
+	require.NoError(t, b.Allow())
+	fail(t, b, 2)
+	b.Done(true)
+	assert.Equal(t, Open, b.Snapshot().State)
+}
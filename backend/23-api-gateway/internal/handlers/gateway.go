@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/e6a5/learning/backend/23-api-gateway/internal/health"
+	"github.com/e6a5/learning/backend/23-api-gateway/internal/proxy"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// GatewayHandler serves the gateway's own endpoints, the ones that are
+// not proxied anywhere
+type GatewayHandler struct {
+	gateway *proxy.Gateway
+	checker *health.Checker
+}
+
+// NewGatewayHandler creates a new gateway handler
+func NewGatewayHandler(gateway *proxy.Gateway, checker *health.Checker) *GatewayHandler {
+	return &GatewayHandler{gateway: gateway, checker: checker}
+}
+
+// HealthCheck handles GET /health - every upstream's health in one answer.
+// A degraded gateway still answers 200: it can serve the routes whose
+// upstreams are up, so a load balancer should keep sending it traffic.
+func (h *GatewayHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Check(r.Context())
+	status := http.StatusOK
+	if report.Status == health.StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	response.JSON(w, status, response.APIResponse{Data: report})
+}
+
+// Routes handles GET /gateway/routes - the routing table, in the order
+// routes are tried, with each upstream's breaker
+func (h *GatewayHandler) Routes(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, response.APIResponse{Data: h.gateway.Routes()})
+}
@@ -0,0 +1,112 @@
+// Package health asks every upstream how it is, all at once, and sums the
+// answers up into one status for the gateway.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/e6a5/learning/backend/23-api-gateway/internal/breaker"
+)
+
+// Overall statuses
+const (
+	StatusOK       = "ok"       // every upstream is up
+	StatusDegraded = "degraded" // some are down; routes to the others work
+	StatusDown     = "down"     // every upstream is down
+)
+
+// Target is an upstream to check
+type Target struct {
+	Name    string
+	URL     string // the health endpoint
+	Breaker *breaker.Breaker
+}
+
+// UpstreamStatus is one upstream's answer, and its breaker's state
+type UpstreamStatus struct {
+	Up         bool             `json:"up"`
+	HTTPStatus int              `json:"http_status,omitempty"`
+	Latency    string           `json:"latency"`
+	Error      string           `json:"error,omitempty"`
+	Breaker    breaker.Snapshot `json:"breaker"`
+}
+
+// Report is the gateway's health
+type Report struct {
+	Status    string                    `json:"status"`
+	Upstreams map[string]UpstreamStatus `json:"upstreams"`
+}
+
+// Checker checks targets. Checks go straight to the upstreams, not through
+// their breakers: they should see a service come back before a breaker
+// lets traffic through, and must not trip one themselves.
+type Checker struct {
+	targets []Target
+	client  *http.Client
+}
+
+// NewChecker creates a checker that gives each upstream timeout to answer
+func NewChecker(targets []Target, timeout time.Duration) *Checker {
+	return &Checker{targets: targets, client: &http.Client{Timeout: timeout}}
+}
+
+// Check asks every target at the same time, so the report takes as long
+// as the slowest upstream rather than all of them added up
+func (c *Checker) Check(ctx context.Context) Report {
+	statuses := make([]UpstreamStatus, len(c.targets))
+	var wg sync.WaitGroup
+	for i, t := range c.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = c.check(ctx, t)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Upstreams: make(map[string]UpstreamStatus, len(c.targets))}
+	up := 0
+	for i, t := range c.targets {
+		report.Upstreams[t.Name] = statuses[i]
+		if statuses[i].Up {
+			up++
+		}
+	}
+	switch up {
+	case len(c.targets):
+		report.Status = StatusOK
+	case 0:
+		report.Status = StatusDown
+	default:
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+func (c *Checker) check(ctx context.Context, t Target) UpstreamStatus {
+	status := UpstreamStatus{Breaker: t.Breaker.Snapshot()}
+	start := time.Now()
+	defer func() { status.Latency = time.Since(start).Round(time.Millisecond).String() }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.URL, nil)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	resp.Body.Close()
+	status.HTTPStatus = resp.StatusCode
+	status.Up = resp.StatusCode < http.StatusInternalServerError
+	if !status.Up {
+		status.Error = fmt.Sprintf("answered %s", resp.Status)
+	}
+	return status
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/23-api-gateway/internal/breaker"
+)
+
+func server(t *testing.T, status int) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestChecker_Check(t *testing.T) {
+	up := server(t, http.StatusOK)
+	failing := server(t, http.StatusServiceUnavailable)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	gone := closed.URL
+	closed.Close()
+
+	target := func(name, url string) Target {
+		return Target{Name: name, URL: url, Breaker: breaker.New(1, time.Minute)}
+	}
+
+	tests := []struct {
+		name       string
+		targets    []Target
+		wantStatus string
+	}{
+		{"all up", []Target{target("a", up), target("b", up)}, StatusOK},
+		{"one answers 503", []Target{target("a", up), target("b", failing)}, StatusDegraded},
+		{"one unreachable", []Target{target("a", up), target("b", gone)}, StatusDegraded},
+		{"all down", []Target{target("a", failing), target("b", gone)}, StatusDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewChecker(tt.targets, time.Second).Check(context.Background())
+			assert.Equal(t, tt.wantStatus, report.Status)
+			require.Len(t, report.Upstreams, len(tt.targets))
+		})
+	}
+
+	report := NewChecker([]Target{target("a", failing), target("b", gone)}, time.Second).Check(context.Background())
+	assert.Equal(t, http.StatusServiceUnavailable, report.Upstreams["a"].HTTPStatus)
+	assert.NotEmpty(t, report.Upstreams["a"].Error)
+	assert.Zero(t, report.Upstreams["b"].HTTPStatus)
+	assert.NotEmpty(t, report.Upstreams["b"].Error)
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	checker := NewChecker([]Target{{Name: "slow", URL: srv.URL, Breaker: breaker.New(1, time.Minute)}}, 50*time.Millisecond)
+	start := time.Now()
+	report := checker.Check(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
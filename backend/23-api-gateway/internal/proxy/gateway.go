@@ -0,0 +1,353 @@
+// Package proxy is the gateway itself: it finds the route for a request,
+// checks who is calling, and hands the request to the route's upstream
+// through that upstream's circuit breaker.
+package proxy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/e6a5/learning/backend/23-api-gateway/internal/auth"
+	"github.com/e6a5/learning/backend/23-api-gateway/internal/breaker"
+	"github.com/e6a5/learning/backend/pkg/response"
+)
+
+// Headers the gateway sets on the way in. Upstreams trust them because
+// only the gateway can reach them, so the client's own are removed first.
+const (
+	HeaderRequestID = "X-Request-ID"
+	HeaderUserID    = "X-User-ID"
+	HeaderUserName  = "X-User-Name"
+	HeaderUserRole  = "X-User-Role"
+)
+
+// Access is who may use a route
+type Access int
+
+const (
+	// Public routes need no token
+	Public Access = iota
+	// User routes need a valid token
+	User
+	// Admin routes need a valid token with the admin role
+	Admin
+)
+
+func (a Access) String() string {
+	switch a {
+	case User:
+		return "user"
+	case Admin:
+		return "admin"
+	default:
+		return "public"
+	}
+}
+
+// MarshalText makes access levels readable in JSON
+func (a Access) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// Route sends requests under Prefix to an upstream. Rewrite, when set,
+// replaces Prefix in the path the upstream sees.
+type Route struct {
+	Prefix   string `json:"prefix"`
+	Upstream string `json:"upstream"`
+	Rewrite  string `json:"rewrite,omitempty"`
+	Access   Access `json:"access"`
+}
+
+// matches reports whether path is Prefix or below it: /users matches
+// /users/1 but not /usersettings
+func (rt Route) matches(path string) bool {
+	return path == rt.Prefix || strings.HasPrefix(path, rt.Prefix+"/")
+}
+
+// upstreamPath is the path the upstream sees for path
+func (rt Route) upstreamPath(path string) string {
+	if rt.Rewrite == "" {
+		return path
+	}
+	return rt.Rewrite + strings.TrimPrefix(path, rt.Prefix)
+}
+
+// Upstream is a service behind the gateway
+type Upstream struct {
+	Name       string
+	URL        *url.URL
+	HealthPath string
+	Breaker    *breaker.Breaker
+	proxy      *httputil.ReverseProxy
+}
+
+// NewUpstream prepares a service at rawURL. Connecting, and waiting for
+// the response headers, each give up after timeout; a body may take longer.
+func NewUpstream(name, rawURL, healthPath string, timeout time.Duration, b *breaker.Breaker) (*Upstream, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("upstream %s: %q is not an absolute URL", name, rawURL)
+	}
+	up := &Upstream{Name: name, URL: u, HealthPath: healthPath, Breaker: b}
+	up.proxy = &httputil.ReverseProxy{
+		Rewrite:        up.rewrite,
+		ModifyResponse: up.modifyResponse,
+		ErrorHandler:   up.errorHandler,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: timeout}).DialContext,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConnsPerHost:   32,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+	return up, nil
+}
+
+type requestKey struct{}
+
+// request is what the gateway learned about a request before proxying it
+type request struct {
+	route  Route
+	claims *auth.Claims
+}
+
+func (up *Upstream) rewrite(pr *httputil.ProxyRequest) {
+	req := pr.In.Context().Value(requestKey{}).(request)
+	pr.Out.URL.Path = req.route.upstreamPath(pr.In.URL.Path)
+	pr.Out.URL.RawPath = ""
+	pr.SetURL(up.URL)
+	pr.SetXForwarded()
+
+	pr.Out.Header.Del(HeaderUserID)
+	pr.Out.Header.Del(HeaderUserName)
+	pr.Out.Header.Del(HeaderUserRole)
+	if c := req.claims; c != nil {
+		pr.Out.Header.Set(HeaderUserID, strconv.Itoa(c.UserID))
+		pr.Out.Header.Set(HeaderUserName, c.Username)
+		pr.Out.Header.Set(HeaderUserRole, c.Role)
+	}
+}
+
+// modifyResponse counts a 5xx as the upstream failing, anything else as
+// it working: a 404 is the upstream answering correctly. The gateway
+// answers CORS for every upstream, so theirs are dropped rather than sent
+// twice, which browsers reject.
+func (up *Upstream) modifyResponse(resp *http.Response) error {
+	up.Breaker.Done(resp.StatusCode < http.StatusInternalServerError)
+	for name := range resp.Header {
+		if strings.HasPrefix(name, "Access-Control-") {
+			resp.Header.Del(name)
+		}
+	}
+	return nil
+}
+
+func (up *Upstream) errorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	var netErr net.Error
+	switch {
+	case r.Context().Err() != nil:
+		// The client left: nothing learned about the upstream
+		up.Breaker.Abandon()
+		response.Error(w, http.StatusBadGateway, "Request canceled")
+	case errors.As(err, &netErr) && netErr.Timeout():
+		up.Breaker.Done(false)
+		response.Error(w, http.StatusGatewayTimeout, up.Name+" did not answer in time")
+	default:
+		up.Breaker.Done(false)
+		response.Error(w, http.StatusBadGateway, up.Name+" is unreachable")
+	}
+	logFrom(r).Warn("Upstream failed", "upstream", up.Name, "error", err)
+}
+
+// Gateway routes requests to upstreams
+type Gateway struct {
+	routes    []Route
+	upstreams map[string]*Upstream
+	secret    string
+	logger    *slog.Logger
+}
+
+// New creates a gateway. Routes are tried longest prefix first, so
+// /admin/users wins over /admin whatever order they are given in.
+func New(upstreams []*Upstream, routes []Route, secret string, logger *slog.Logger) (*Gateway, error) {
+	g := &Gateway{upstreams: map[string]*Upstream{}, secret: secret, logger: logger}
+	for _, up := range upstreams {
+		g.upstreams[up.Name] = up
+	}
+	for _, rt := range routes {
+		if !strings.HasPrefix(rt.Prefix, "/") || strings.HasSuffix(rt.Prefix, "/") {
+			return nil, fmt.Errorf("route %q: a prefix starts with / and does not end with one", rt.Prefix)
+		}
+		if g.upstreams[rt.Upstream] == nil {
+			return nil, fmt.Errorf("route %s: no upstream named %q", rt.Prefix, rt.Upstream)
+		}
+	}
+	g.routes = append([]Route(nil), routes...)
+	sort.SliceStable(g.routes, func(i, j int) bool { return len(g.routes[i].Prefix) > len(g.routes[j].Prefix) })
+	return g, nil
+}
+
+// Upstreams returns the upstreams, in name order
+func (g *Gateway) Upstreams() []*Upstream {
+	list := make([]*Upstream, 0, len(g.upstreams))
+	for _, up := range g.upstreams {
+		list = append(list, up)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// RouteInfo is a route with where it goes and how that upstream is doing
+type RouteInfo struct {
+	Route
+	Target  string           `json:"target"`
+	Breaker breaker.Snapshot `json:"breaker"`
+}
+
+// Routes describes the routing table, in the order routes are tried
+func (g *Gateway) Routes() []RouteInfo {
+	infos := make([]RouteInfo, 0, len(g.routes))
+	for _, rt := range g.routes {
+		up := g.upstreams[rt.Upstream]
+		infos = append(infos, RouteInfo{Route: rt, Target: up.URL.String(), Breaker: up.Breaker.Snapshot()})
+	}
+	return infos
+}
+
+func (g *Gateway) match(path string) (Route, bool) {
+	for _, rt := range g.routes {
+		if rt.matches(path) {
+			return rt, true
+		}
+	}
+	return Route{}, false
+}
+
+// ServeHTTP proxies one request, and logs it once it has been answered
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	// Keep the caller's request ID, so one ID follows the request from
+	// the client through every service; make one if there is none
+	id := r.Header.Get(HeaderRequestID)
+	if id == "" || len(id) > 64 {
+		id = newRequestID()
+		r.Header.Set(HeaderRequestID, id)
+	}
+	w.Header().Set(HeaderRequestID, id)
+	logger := g.logger.With("request_id", id)
+	r = r.WithContext(context.WithValue(r.Context(), loggerKey{}, logger))
+	rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+
+	attrs := []any{"method", r.Method, "path", r.URL.Path}
+	defer func() {
+		attrs = append(attrs, "status", rec.status, "bytes", rec.bytes, "duration", time.Since(start))
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelWarn
+		}
+		logger.Log(r.Context(), level, "Request proxied", attrs...)
+	}()
+
+	rt, ok := g.match(r.URL.Path)
+	if !ok {
+		response.Error(rec, http.StatusNotFound, "No route for "+r.URL.Path)
+		return
+	}
+	up := g.upstreams[rt.Upstream]
+	attrs = append(attrs, "upstream", up.Name, "upstream_path", rt.upstreamPath(r.URL.Path))
+
+	claims, ok := g.authorize(rec, r, rt)
+	if !ok {
+		return
+	}
+	if claims != nil {
+		attrs = append(attrs, "user", claims.Username)
+	}
+
+	if err := up.Breaker.Allow(); err != nil {
+		// Fail fast: the client hears at once instead of after a timeout,
+		// and the upstream gets time to recover
+		retry := math.Ceil(up.Breaker.RetryAfter().Seconds())
+		rec.Header().Set("Retry-After", strconv.Itoa(int(max(retry, 1))))
+		response.Error(rec, http.StatusServiceUnavailable, up.Name+" is unavailable, try again later")
+		return
+	}
+	ctx := context.WithValue(r.Context(), requestKey{}, request{route: rt, claims: claims})
+	up.proxy.ServeHTTP(rec, r.WithContext(ctx))
+}
+
+// authorize enforces the route's access level, answering the request
+// itself when the caller may not pass
+func (g *Gateway) authorize(w http.ResponseWriter, r *http.Request, rt Route) (*auth.Claims, bool) {
+	if rt.Access == Public {
+		return nil, true
+	}
+	claims, err := auth.FromRequest(r, g.secret)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="gateway"`)
+		message := "Invalid or expired token"
+		if errors.Is(err, auth.ErrNoToken) {
+			message = "Authorization header required"
+		}
+		response.Error(w, http.StatusUnauthorized, message)
+		return nil, false
+	}
+	if rt.Access == Admin && claims.Role != "admin" {
+		response.Error(w, http.StatusForbidden, "Admin access required")
+		return nil, false
+	}
+	return claims, true
+}
+
+type loggerKey struct{}
+
+// logFrom returns the logger for r, which carries its request ID
+func logFrom(r *http.Request) *slog.Logger {
+	if logger, ok := r.Context().Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// recorder remembers the status and size of the response for the log
+type recorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *recorder) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Unwrap lets the reverse proxy flush streamed responses through
+func (w *recorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,306 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/e6a5/learning/backend/23-api-gateway/internal/auth"
+	"github.com/e6a5/learning/backend/23-api-gateway/internal/breaker"
+)
+
+const secret = "test-secret"
+
+// seen is what an upstream received
+type seen struct {
+	Path      string `json:"path"`
+	RawQuery  string `json:"query"`
+	UserID    string `json:"user_id"`
+	UserName  string `json:"user_name"`
+	UserRole  string `json:"user_role"`
+	RequestID string `json:"request_id"`
+	Forwarded string `json:"forwarded"`
+}
+
+// echo is an upstream that answers with what it received
+func echo(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "https://upstream.example")
+		_ = json.NewEncoder(w).Encode(seen{
+			Path:      r.URL.Path,
+			RawQuery:  r.URL.RawQuery,
+			UserID:    r.Header.Get(HeaderUserID),
+			UserName:  r.Header.Get(HeaderUserName),
+			UserRole:  r.Header.Get(HeaderUserRole),
+			RequestID: r.Header.Get(HeaderRequestID),
+			Forwarded: r.Header.Get("X-Forwarded-For"),
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newUpstream(t *testing.T, name, url string) *Upstream {
+	t.Helper()
+	up, err := NewUpstream(name, url, "/health", time.Second, breaker.New(2, time.Minute))
+	require.NoError(t, err)
+	return up
+}
+
+func newGateway(t *testing.T, ups []*Upstream, routes []Route) *Gateway {
+	t.Helper()
+	g, err := New(ups, routes, secret, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return g
+}
+
+func token(t *testing.T, role string) string {
+	t.Helper()
+	tok, err := auth.SignToken(7, "alice", role, secret, time.Hour)
+	require.NoError(t, err)
+	return "Bearer " + tok
+}
+
+func serve(g *Gateway, method, target, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder) seen {
+	t.Helper()
+	var s seen
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&s))
+	return s
+}
+
+func TestGateway_Routing(t *testing.T) {
+	api, admin := echo(t), echo(t)
+	g := newGateway(t,
+		[]*Upstream{newUpstream(t, "api", api.URL), newUpstream(t, "admin", admin.URL)},
+		[]Route{
+			{Prefix: "/api", Upstream: "api"},
+			{Prefix: "/api/admin", Upstream: "admin", Rewrite: "/internal"},
+		})
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantPath   string
+	}{
+		{"prefix itself", "/api", http.StatusOK, "/api"},
+		{"below prefix", "/api/items/1?x=1", http.StatusOK, "/api/items/1"},
+		{"longest prefix wins and is rewritten", "/api/admin/users", http.StatusOK, "/internal/users"},
+		{"prefix only matches whole segments", "/apiary", http.StatusNotFound, ""},
+		{"no route", "/nothing", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(g, http.MethodGet, tt.target, "")
+			require.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantPath, decode(t, rec).Path)
+			}
+		})
+	}
+
+	// The query string goes through untouched
+	assert.Equal(t, "x=1", decode(t, serve(g, http.MethodGet, "/api/items/1?x=1", "")).RawQuery)
+}
+
+func TestGateway_Access(t *testing.T) {
+	srv := echo(t)
+	g := newGateway(t, []*Upstream{newUpstream(t, "api", srv.URL)}, []Route{
+		{Prefix: "/open", Upstream: "api", Access: Public},
+		{Prefix: "/users", Upstream: "api", Access: User},
+		{Prefix: "/admin", Upstream: "api", Access: Admin},
+	})
+	other, err := auth.SignToken(7, "alice", "admin", "another-secret", time.Hour)
+	require.NoError(t, err)
+	expired, err := auth.SignToken(7, "alice", "admin", secret, -time.Minute)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		target        string
+		authorization string
+		wantStatus    int
+	}{
+		{"public without token", "/open", "", http.StatusOK},
+		{"user route without token", "/users", "", http.StatusUnauthorized},
+		{"not a bearer token", "/users", "Basic YWxpY2U6c2VjcmV0", http.StatusUnauthorized},
+		{"signed with another secret", "/users", "Bearer " + other, http.StatusUnauthorized},
+		{"expired token", "/users", "Bearer " + expired, http.StatusUnauthorized},
+		{"user route with user token", "/users", token(t, "user"), http.StatusOK},
+		{"admin route with user token", "/admin", token(t, "user"), http.StatusForbidden},
+		{"admin route with admin token", "/admin", token(t, "admin"), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(g, http.MethodGet, tt.target, tt.authorization)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer realm="gateway"`, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestGateway_Headers(t *testing.T) {
+	srv := echo(t)
+	g := newGateway(t, []*Upstream{newUpstream(t, "api", srv.URL)}, []Route{
+		{Prefix: "/open", Upstream: "api", Access: Public},
+		{Prefix: "/users", Upstream: "api", Access: User},
+	})
+
+	t.Run("identity comes from the token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("Authorization", token(t, "user"))
+		req.Header.Set(HeaderUserRole, "admin")
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, req)
+
+		s := decode(t, rec)
+		assert.Equal(t, "7", s.UserID)
+		assert.Equal(t, "alice", s.UserName)
+		assert.Equal(t, "user", s.UserRole)
+		assert.NotEmpty(t, s.Forwarded)
+	})
+
+	t.Run("spoofed identity is removed on public routes", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/open", nil)
+		req.Header.Set(HeaderUserName, "root")
+		req.Header.Set(HeaderUserRole, "admin")
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, req)
+
+		s := decode(t, rec)
+		assert.Empty(t, s.UserName)
+		assert.Empty(t, s.UserRole)
+	})
+
+	t.Run("request ID is made or kept", func(t *testing.T) {
+		rec := serve(g, http.MethodGet, "/open", "")
+		id := rec.Header().Get(HeaderRequestID)
+		assert.Len(t, id, 16)
+		assert.Equal(t, id, decode(t, rec).RequestID)
+
+		req := httptest.NewRequest(http.MethodGet, "/open", nil)
+		req.Header.Set(HeaderRequestID, "from-client")
+		rec = httptest.NewRecorder()
+		g.ServeHTTP(rec, req)
+		assert.Equal(t, "from-client", rec.Header().Get(HeaderRequestID))
+		assert.Equal(t, "from-client", decode(t, rec).RequestID)
+	})
+
+	t.Run("upstream CORS headers are dropped", func(t *testing.T) {
+		rec := serve(g, http.MethodGet, "/open", "")
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func TestGateway_Breaker(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/failing/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+	healthy := echo(t)
+
+	failing := newUpstream(t, "failing", srv.URL)
+	g := newGateway(t,
+		[]*Upstream{failing, newUpstream(t, "healthy", healthy.URL)},
+		[]Route{{Prefix: "/failing", Upstream: "failing"}, {Prefix: "/healthy", Upstream: "healthy"}})
+
+	// A 404 is the upstream working
+	assert.Equal(t, http.StatusNotFound, serve(g, http.MethodGet, "/failing/missing", "").Code)
+	assert.Equal(t, breaker.Closed, failing.Breaker.Snapshot().State)
+
+	// Two 500s in a row open it
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusInternalServerError, serve(g, http.MethodGet, "/failing", "").Code)
+	}
+	assert.Equal(t, breaker.Open, failing.Breaker.Snapshot().State)
+	require.Equal(t, int32(3), calls.Load())
+
+	// Now the gateway answers without calling the upstream
+	rec := serve(g, http.MethodGet, "/failing", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	assert.Equal(t, int32(3), calls.Load())
+
+	// The other upstream has its own breaker
+	assert.Equal(t, http.StatusOK, serve(g, http.MethodGet, "/healthy", "").Code)
+}
+
+func TestGateway_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	up := newUpstream(t, "gone", url)
+	g := newGateway(t, []*Upstream{up}, []Route{{Prefix: "/gone", Upstream: "gone"}})
+
+	rec := serve(g, http.MethodGet, "/gone", "")
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Contains(t, rec.Body.String(), "gone is unreachable")
+	assert.Equal(t, 1, up.Breaker.Snapshot().Failures)
+}
+
+func TestGateway_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	up, err := NewUpstream("slow", srv.URL, "/health", 50*time.Millisecond, breaker.New(2, time.Minute))
+	require.NoError(t, err)
+	g := newGateway(t, []*Upstream{up}, []Route{{Prefix: "/slow", Upstream: "slow"}})
+
+	rec := serve(g, http.MethodGet, "/slow", "")
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	assert.Equal(t, 1, up.Breaker.Snapshot().Failures)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	up := newUpstream(t, "api", "http://localhost:1")
+	tests := []struct {
+		name  string
+		route Route
+	}{
+		{"relative prefix", Route{Prefix: "api", Upstream: "api"}},
+		{"trailing slash", Route{Prefix: "/api/", Upstream: "api"}},
+		{"unknown upstream", Route{Prefix: "/api", Upstream: "nope"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New([]*Upstream{up}, []Route{tt.route}, secret, slog.Default())
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := NewUpstream("api", "localhost:8080", "/health", time.Second, breaker.New(1, time.Minute))
+	assert.Error(t, err)
+}
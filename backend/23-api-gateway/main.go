@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/e6a5/learning/backend/23-api-gateway/internal/breaker"
+	"github.com/e6a5/learning/backend/23-api-gateway/internal/handlers"
+	"github.com/e6a5/learning/backend/23-api-gateway/internal/health"
+	"github.com/e6a5/learning/backend/23-api-gateway/internal/proxy"
+	"github.com/e6a5/learning/backend/pkg/env"
+	"github.com/e6a5/learning/backend/pkg/middleware"
+)
+
+// upstreamConfig is a service behind the gateway and where to find it
+type upstreamConfig struct {
+	name       string
+	urlEnv     string
+	defaultURL string
+	healthPath string
+}
+
+var upstreams = []upstreamConfig{
+	// 01-http-server, run on 8083 so it does not take the others' ports
+	{"http", "HTTP_SERVICE_URL", "http://localhost:8083", "/health"},
+	// 06-auth-security, whose status page is its health check
+	{"auth", "AUTH_SERVICE_URL", "http://localhost:8081", "/"},
+	// The gateway in 18-distributed-tracing, which turns HTTP into gRPC calls
+	{"grpc-gateway", "GRPC_GATEWAY_URL", "http://localhost:8080", "/health"},
+}
+
+// routes is the routing table. Order does not matter: the longest prefix
+// that matches wins.
+var routes = []proxy.Route{
+	// Sign-up and login are open; 06-auth-security checks /auth/profile itself
+	{Prefix: "/auth", Upstream: "auth", Access: proxy.Public},
+	// Its user list, under a path that says who it is for
+	{Prefix: "/admin/users", Upstream: "auth", Rewrite: "/users", Access: proxy.Admin},
+	{Prefix: "/learn", Upstream: "http", Access: proxy.Public},
+	{Prefix: "/users", Upstream: "http", Access: proxy.User},
+	{Prefix: "/grpc/users", Upstream: "grpc-gateway", Rewrite: "/users", Access: proxy.User},
+}
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	timeout := getEnvDuration("UPSTREAM_TIMEOUT", 5*time.Second)
+	failures := getEnvInt("BREAKER_FAILURES", 5)
+	cooldown := getEnvDuration("BREAKER_COOLDOWN", 30*time.Second)
+
+	// One breaker per upstream, so one failing service cannot close the
+	// routes to the others
+	var ups []*proxy.Upstream
+	var targets []health.Target
+	for _, c := range upstreams {
+		up, err := proxy.NewUpstream(c.name, env.Get(c.urlEnv, c.defaultURL), c.healthPath, timeout, breaker.New(failures, cooldown))
+		if err != nil {
+			log.Fatal(err)
+		}
+		ups = append(ups, up)
+		targets = append(targets, health.Target{Name: up.Name, URL: up.URL.JoinPath(up.HealthPath).String(), Breaker: up.Breaker})
+		log.Printf("↪️  %s at %s", up.Name, up.URL)
+	}
+
+	// No default: the lab's development secret is public, so a gateway that
+	// fell back to it would accept tokens anyone can sign
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		log.Fatal("JWT_SECRET must be set to the secret 06-auth-security signs with")
+	}
+
+	gateway, err := proxy.New(ups, routes, secret, logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+	checker := health.NewChecker(targets, getEnvDuration("HEALTH_TIMEOUT", 2*time.Second))
+	gatewayHandler := handlers.NewGatewayHandler(gateway, checker)
+
+	// No WriteTimeout: it would cut off upstreams that stream
+	port := env.Get("PORT", "8080")
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           setupRoutes(gatewayHandler, gateway),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Printf("🚪 API gateway running at http://localhost:%s", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	sig, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-sig.Done()
+
+	log.Println("Shutting down server...")
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
+	log.Println("Server exited")
+}
+
+func setupRoutes(gatewayHandler *handlers.GatewayHandler, gateway *proxy.Gateway) *mux.Router {
+	router := mux.NewRouter()
+
+	// The gateway answers CORS for every upstream, preflights included:
+	// they never reach an upstream or need a token
+	router.Use(middleware.CORS(proxy.HeaderRequestID))
+
+	// The gateway's own endpoints
+	router.HandleFunc("/health", gatewayHandler.HealthCheck).Methods("GET")
+	router.HandleFunc("/gateway/routes", gatewayHandler.Routes).Methods("GET")
+
+	// Everything else goes to an upstream, and logs itself
+	router.PathPrefix("/").Handler(gateway)
+
+	return router
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(env.Get(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		log.Fatalf("%s must be a number: %v", key, err)
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(env.Get(key, defaultValue.String()))
+	if err != nil {
+		log.Fatalf("%s must be a duration like 2s: %v", key, err)
+	}
+	return value
+}
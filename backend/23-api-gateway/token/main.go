@@ -0,0 +1,35 @@
+// Command token prints a token signed the way 06-auth-security signs
+// them, for trying the gateway's protected routes without running that lab.
+//
+//	make token
+//	JWT_SECRET=... go run ./token -user alice -role admin
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/e6a5/learning/backend/23-api-gateway/internal/auth"
+)
+
+func main() {
+	userID := flag.Int("id", 1, "user ID")
+	username := flag.String("user", "demo", "username")
+	role := flag.String("role", "user", "role: user or admin")
+	ttl := flag.Duration("ttl", time.Hour, "how long the token is valid")
+	secret := flag.String("secret", os.Getenv("JWT_SECRET"), "signing secret, the gateway's JWT_SECRET")
+	flag.Parse()
+
+	if *secret == "" {
+		log.Fatal("set -secret or JWT_SECRET to the gateway's secret")
+	}
+
+	token, err := auth.SignToken(*userID, *username, *role, *secret, *ttl)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(token)
+}
@@ -0,0 +1,297 @@
+// Command client uploads and downloads files through the file service,
+// in chunks, with a progress bar. Both directions resume where they
+// stopped: run the same command again after an interruption.
+//
+//	go run ./client upload ./photo.jpg
+//	go run ./client -stop-after 1048576 upload ./photo.jpg   # stop after 1 MiB
+//	go run ./client download photo.jpg
+//	go run ./client status photo.jpg
+//	go run ./client list
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/e6a5/learning/backend/24-grpc-file-streaming/internal/storage"
+	pb "github.com/e6a5/learning/backend/24-grpc-file-streaming/proto"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+// options shape a transfer
+type options struct {
+	chunkSize int
+	stopAfter int64 // stop after this many bytes, to try resuming; 0 never
+	rate      int64 // bytes a second; 0 as fast as possible
+}
+
+func main() {
+	addr := flag.String("addr", env.Get("FILE_SERVICE_ADDR", "localhost:50052"), "file service address")
+	name := flag.String("name", "", "upload: name on the server (default: the file's own)")
+	out := flag.String("o", "", "download: where to save (default: the file's name)")
+	var opts options
+	flag.IntVar(&opts.chunkSize, "chunk", 64*1024, "bytes per chunk")
+	flag.Int64Var(&opts.stopAfter, "stop-after", 0, "stop after this many bytes, to try resuming")
+	flag.Int64Var(&opts.rate, "rate", 0, "limit to this many bytes a second, to watch the progress")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: client [flags] upload <path> | download <name> | status <name> | list")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if opts.chunkSize <= 0 {
+		log.Fatal("-chunk must be positive")
+	}
+
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewFileServiceClient(conn)
+	ctx := context.Background()
+
+	args := flag.Args()
+	switch {
+	case len(args) == 2 && args[0] == "upload":
+		err = upload(ctx, client, args[1], *name, opts)
+	case len(args) == 2 && args[0] == "download":
+		err = download(ctx, client, args[1], *out, opts)
+	case len(args) == 2 && args[0] == "status":
+		err = printStatus(ctx, client, args[1])
+	case len(args) == 1 && args[0] == "list":
+		err = list(ctx, client)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+}
+
+// upload sends the file at path, resuming an earlier upload of the same
+// bytes when the server has part of it
+func upload(ctx context.Context, client pb.FileServiceClient, path, name string, opts options) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = filepath.Base(path)
+	}
+
+	// The checksum covers the whole file, so it is known before the first
+	// chunk goes out; the server checks it after the last one arrives
+	log.Printf("🔢 Hashing %s (%s)...", path, formatSize(info.Size()))
+	sum, err := storage.HashFile(path)
+	if err != nil {
+		return err
+	}
+
+	st, err := client.GetUploadStatus(ctx, &pb.GetUploadStatusRequest{Name: name})
+	var offset int64
+	switch {
+	case status.Code(err) == codes.NotFound:
+	case err != nil:
+		return err
+	case st.Complete && st.File.Sha256 == sum:
+		log.Printf("✅ %s is already on the server", name)
+		return nil
+	case !st.Complete && st.Size == info.Size():
+		// The server checks the checksum too, in case this is a different
+		// file of the same size
+		offset = st.Received
+		log.Printf("↪️  Resuming at %s of %s", formatSize(offset), formatSize(info.Size()))
+	}
+
+	err = sendFrom(ctx, client, f, name, info.Size(), sum, offset, opts)
+	if status.Code(err) == codes.FailedPrecondition && offset > 0 {
+		log.Printf("↩️  Cannot resume (%s), starting again", status.Convert(err).Message())
+		err = sendFrom(ctx, client, f, name, info.Size(), sum, 0, opts)
+	}
+	return err
+}
+
+func sendFrom(ctx context.Context, client pb.FileServiceClient, f *os.File, name string, size int64, sum string, offset int64, opts options) error {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	// Returning early, on a read error, cancels the call
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.Upload(ctx)
+	if err != nil {
+		return err
+	}
+	err = stream.Send(&pb.UploadRequest{Data: &pb.UploadRequest_Info{Info: &pb.UploadInfo{
+		Name: name, Size: size, Sha256: sum, Offset: offset,
+	}}})
+
+	p := newProgress("⬆️  "+name, size, offset)
+	buf := make([]byte, opts.chunkSize)
+	var sent int64
+	for err == nil && offset+sent < size {
+		if opts.stopAfter > 0 && sent >= opts.stopAfter {
+			break
+		}
+		var n int
+		n, err = io.ReadFull(f, buf[:min(int64(len(buf)), size-offset-sent)])
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", f.Name(), err)
+		}
+		// An error here means the server has ended the call;
+		// CloseAndRecv below says why
+		err = stream.Send(&pb.UploadRequest{Data: &pb.UploadRequest_Chunk{Chunk: buf[:n]}})
+		sent += int64(n)
+		p.add(n)
+		throttle(p.start, sent, opts.rate)
+	}
+
+	st, err := stream.CloseAndRecv()
+	p.finish()
+	if err != nil {
+		return err
+	}
+	if !st.Complete {
+		log.Printf("✂️  Stopped with %s of %s on the server; run the same command to resume", formatSize(st.Received), formatSize(st.Size))
+		return nil
+	}
+	log.Printf("✅ Uploaded %s, sha256 %s, checked by the server", name, st.File.Sha256)
+	return nil
+}
+
+// download fetches name into out. Bytes go to out.part first, so an
+// interrupted download can resume from its size, and out only appears
+// once the checksum matches.
+func download(ctx context.Context, client pb.FileServiceClient, name, out string, opts options) error {
+	if out == "" {
+		out = name
+	}
+	part := out + ".part"
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+		log.Printf("↪️  Resuming at %s", formatSize(offset))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.Download(ctx, &pb.DownloadRequest{Name: name, Offset: offset, ChunkSize: int32(opts.chunkSize)})
+	if err != nil {
+		return err
+	}
+	// The first message is the file's info, or the call's error
+	first, err := stream.Recv()
+	if status.Code(err) == codes.OutOfRange {
+		// The file on the server is shorter than what we have: it changed
+		log.Printf("↩️  %s changed on the server, starting again", name)
+		if err := os.Remove(part); err != nil {
+			return err
+		}
+		return download(ctx, client, name, out, opts)
+	}
+	if err != nil {
+		return err
+	}
+	info := first.GetInfo()
+	if info == nil {
+		return errors.New("the server did not start with the file's info")
+	}
+
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	p := newProgress("⬇️  "+name, info.Size, offset)
+	var received int64
+	for {
+		if opts.stopAfter > 0 && received >= opts.stopAfter {
+			// Hanging up cancels the stream on the server too
+			cancel()
+			p.finish()
+			log.Printf("✂️  Stopped with %s of %s in %s; run the same command to resume", formatSize(offset+received), formatSize(info.Size), part)
+			return nil
+		}
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			p.finish()
+			return err
+		}
+		chunk := resp.GetChunk()
+		if _, err := f.Write(chunk); err != nil {
+			return err
+		}
+		received += int64(len(chunk))
+		p.add(len(chunk))
+		throttle(p.start, received, opts.rate)
+	}
+	p.finish()
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// Check every byte, resumed ones included: if the file changed on the
+	// server between attempts, the two halves do not add up
+	sum, err := storage.HashFile(part)
+	if err != nil {
+		return err
+	}
+	if sum != info.Sha256 {
+		os.Remove(part)
+		return fmt.Errorf("checksum mismatch: got %s, want %s; removed %s, run again", sum, info.Sha256, part)
+	}
+	if err := os.Rename(part, out); err != nil {
+		return err
+	}
+	log.Printf("✅ Saved %s, sha256 %s matches", out, sum)
+	return nil
+}
+
+func printStatus(ctx context.Context, client pb.FileServiceClient, name string) error {
+	st, err := client.GetUploadStatus(ctx, &pb.GetUploadStatusRequest{Name: name})
+	if err != nil {
+		return err
+	}
+	if st.Complete {
+		fmt.Printf("%s: complete, %s, sha256 %s\n", st.Name, formatSize(st.Size), st.File.Sha256)
+		return nil
+	}
+	fmt.Printf("%s: %s of %s uploaded\n", st.Name, formatSize(st.Received), formatSize(st.Size))
+	return nil
+}
+
+func list(ctx context.Context, client pb.FileServiceClient) error {
+	resp, err := client.ListFiles(ctx, &pb.ListFilesRequest{})
+	if err != nil {
+		return err
+	}
+	if len(resp.Files) == 0 {
+		fmt.Println("No files yet")
+	}
+	for _, f := range resp.Files {
+		fmt.Printf("%-30s %10s  %s  %s\n", f.Name, formatSize(f.Size), time.Unix(f.CreatedAt, 0).Format(time.DateTime), f.Sha256[:12])
+	}
+	return nil
+}
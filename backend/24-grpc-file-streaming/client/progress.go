@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// progress draws a transfer's progress on one terminal line, at most ten
+// times a second so that drawing does not slow the transfer down
+type progress struct {
+	label   string
+	total   int64
+	done    int64 // bytes the other side has, earlier attempts included
+	resumed int64 // bytes that were there before this attempt
+	start   time.Time
+	drawn   time.Time
+}
+
+func newProgress(label string, total, offset int64) *progress {
+	return &progress{label: label, total: total, done: offset, resumed: offset, start: time.Now()}
+}
+
+func (p *progress) add(n int) {
+	p.done += int64(n)
+	if time.Since(p.drawn) >= 100*time.Millisecond {
+		p.draw()
+	}
+}
+
+// finish draws the last state and ends the line
+func (p *progress) finish() {
+	p.draw()
+	fmt.Fprintln(os.Stderr)
+}
+
+func (p *progress) draw() {
+	p.drawn = time.Now()
+	percent := 100.0
+	if p.total > 0 {
+		percent = float64(p.done) * 100 / float64(p.total)
+	}
+	const width = 30
+	filled := int(percent / 100 * width)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+
+	// Speed counts this attempt only: resumed bytes did not move now
+	elapsed := time.Since(p.start).Seconds()
+	speed := float64(p.done-p.resumed) / max(elapsed, 0.001)
+	eta := "--"
+	if speed > 0 {
+		eta = time.Duration(float64(p.total-p.done) / speed * float64(time.Second)).Round(time.Second).String()
+	}
+	fmt.Fprintf(os.Stderr, "\r%s %s %5.1f%%  %s / %s  %s/s  ETA %s   ",
+		p.label, bar, percent, formatSize(p.done), formatSize(p.total), formatSize(int64(speed)), eta)
+}
+
+// throttle sleeps until sent bytes since start are within rate bytes a
+// second; it does nothing when rate is 0
+func throttle(start time.Time, sent, rate int64) {
+	if rate <= 0 {
+		return
+	}
+	due := time.Duration(float64(sent) / float64(rate) * float64(time.Second))
+	if wait := due - time.Since(start); wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// formatSize prints n bytes the way people read them: 1.5 MiB
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/e6a5/learning/backend/24-grpc-file-streaming/internal/storage"
+	pb "github.com/e6a5/learning/backend/24-grpc-file-streaming/proto"
+)
+
+// Chunk sizes for downloads. Every chunk is one gRPC message, and gRPC
+// refuses messages over 4 MiB by default, so the cap stays well below it.
+const (
+	DefaultChunkSize = 64 * 1024
+	MaxChunkSize     = 1024 * 1024
+)
+
+// FileService implements the gRPC FileService interface
+type FileService struct {
+	pb.UnimplementedFileServiceServer
+	store *storage.Store
+}
+
+// NewFileService creates a new file service
+func NewFileService(store *storage.Store) *FileService {
+	return &FileService{store: store}
+}
+
+// Upload handles client streaming RPC for uploading a file in chunks. It
+// answers once the client closes its side: with the file when every byte
+// arrived and the checksum matched, or with how far it got otherwise.
+func (s *FileService) Upload(stream pb.FileService_UploadServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "empty upload")
+	}
+	if err != nil {
+		return err
+	}
+	info := first.GetInfo()
+	if info == nil {
+		return status.Error(codes.InvalidArgument, "the first message must be the file's info")
+	}
+
+	upload, err := s.store.Begin(info.Name, info.Size, info.Sha256, info.Offset)
+	if err != nil {
+		return toStatus(err)
+	}
+	// Whatever happens, what arrived stays on disk for a resume
+	defer upload.Close()
+	start := time.Now()
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// The client is gone: a dropped connection, or a canceled call
+			log.Printf("⬆️  %s interrupted at %d of %d bytes: %v", info.Name, upload.Received(), info.Size, err)
+			return err
+		}
+		if req.GetInfo() != nil {
+			return status.Error(codes.InvalidArgument, "only the first message may be the file's info")
+		}
+		if err := upload.Write(req.GetChunk()); err != nil {
+			return toStatus(err)
+		}
+	}
+
+	st, err := upload.Finish()
+	if err != nil {
+		return toStatus(err)
+	}
+	if st.File != nil {
+		log.Printf("⬆️  %s complete: %d bytes, this stream %d in %s", info.Name, st.Size, st.Received-info.Offset, time.Since(start).Round(time.Millisecond))
+	} else {
+		log.Printf("⬆️  %s paused at %d of %d bytes", info.Name, st.Received, st.Size)
+	}
+	return stream.SendAndClose(toUploadStatus(st))
+}
+
+// Download handles server streaming RPC for downloading a file in chunks,
+// starting at the requested offset
+func (s *FileService) Download(req *pb.DownloadRequest, stream pb.FileService_DownloadServer) error {
+	f, info, err := s.store.Open(req.Name)
+	if err != nil {
+		return toStatus(err)
+	}
+	defer f.Close()
+
+	if req.Offset < 0 || req.Offset > info.Size {
+		return status.Errorf(codes.OutOfRange, "offset %d is outside %s's %d bytes", req.Offset, info.Name, info.Size)
+	}
+	chunkSize := int(req.ChunkSize)
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	chunkSize = min(chunkSize, MaxChunkSize)
+
+	// The info goes first, so the client knows the total for its progress
+	// bar and the checksum to check against at the end
+	if err := stream.Send(&pb.DownloadResponse{Data: &pb.DownloadResponse_Info{Info: toFileInfo(info)}}); err != nil {
+		return err
+	}
+	if _, err := f.Seek(req.Offset, io.SeekStart); err != nil {
+		return internal("seek", err)
+	}
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			// Send blocks while the client is behind: gRPC flow control
+			// keeps a slow reader from filling the server's memory
+			if err := stream.Send(&pb.DownloadResponse{Data: &pb.DownloadResponse_Chunk{Chunk: buf[:n]}}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return internal("read", err)
+		}
+	}
+}
+
+// GetUploadStatus handles unary RPC for how far an upload has got
+func (s *FileService) GetUploadStatus(ctx context.Context, req *pb.GetUploadStatusRequest) (*pb.UploadStatus, error) {
+	st, err := s.store.Status(req.Name)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toUploadStatus(st), nil
+}
+
+// ListFiles handles unary RPC for listing the finished files
+func (s *FileService) ListFiles(ctx context.Context, req *pb.ListFilesRequest) (*pb.ListFilesResponse, error) {
+	files, err := s.store.List()
+	if err != nil {
+		return nil, internal("list", err)
+	}
+	resp := &pb.ListFilesResponse{Files: make([]*pb.FileInfo, 0, len(files))}
+	for _, f := range files {
+		resp.Files = append(resp.Files, toFileInfo(f))
+	}
+	return resp, nil
+}
+
+// toStatus turns storage errors into gRPC status codes the client can act
+// on: FAILED_PRECONDITION means ask for the status and resume from there,
+// DATA_LOSS means start again from 0
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, storage.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, storage.ErrBusy):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, storage.ErrOffset):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, storage.ErrTooLarge):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, storage.ErrChecksum):
+		return status.Error(codes.DataLoss, err.Error())
+	default:
+		return internal("store", err)
+	}
+}
+
+// internal logs the cause and hides it from the client
+func internal(op string, err error) error {
+	log.Printf("Failed to %s: %v", op, err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+func toFileInfo(f storage.FileInfo) *pb.FileInfo {
+	return &pb.FileInfo{Name: f.Name, Size: f.Size, Sha256: f.SHA256, CreatedAt: f.CreatedAt.Unix()}
+}
+
+func toUploadStatus(st storage.Status) *pb.UploadStatus {
+	resp := &pb.UploadStatus{Name: st.Name, Size: st.Size, Received: st.Received, Complete: st.File != nil}
+	if st.File != nil {
+		resp.File = toFileInfo(*st.File)
+	}
+	return resp
+}
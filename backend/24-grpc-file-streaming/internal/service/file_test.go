@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/e6a5/learning/backend/24-grpc-file-streaming/internal/storage"
+	pb "github.com/e6a5/learning/backend/24-grpc-file-streaming/proto"
+)
+
+// newClient runs the service on an in-memory connection: real gRPC
+// streams, no ports
+func newClient(t *testing.T) pb.FileServiceClient {
+	t.Helper()
+	store, err := storage.New(t.TempDir(), 10<<20)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pb.RegisterFileServiceServer(server, NewFileService(store))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewFileServiceClient(conn)
+}
+
+func randomFile(t *testing.T, size int) ([]byte, string) {
+	t.Helper()
+	data := make([]byte, size)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	sum := sha256.Sum256(data)
+	return data, hex.EncodeToString(sum[:])
+}
+
+// upload sends file[offset:end] in chunks and closes the stream
+func upload(t *testing.T, client pb.FileServiceClient, name string, file []byte, sum string, offset, end int) (*pb.UploadStatus, error) {
+	t.Helper()
+	stream, err := client.Upload(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.UploadRequest{Data: &pb.UploadRequest_Info{Info: &pb.UploadInfo{
+		Name: name, Size: int64(len(file)), Sha256: sum, Offset: int64(offset),
+	}}}))
+	for i := offset; i < end; i += 32 * 1024 {
+		chunk := file[i:min(i+32*1024, end)]
+		if err := stream.Send(&pb.UploadRequest{Data: &pb.UploadRequest_Chunk{Chunk: chunk}}); err != nil {
+			break
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+// download collects the info and the bytes of a download from offset
+func download(t *testing.T, client pb.FileServiceClient, name string, offset int64) (*pb.FileInfo, []byte, int, error) {
+	t.Helper()
+	stream, err := client.Download(context.Background(), &pb.DownloadRequest{Name: name, Offset: offset, ChunkSize: 16 * 1024})
+	require.NoError(t, err)
+	var info *pb.FileInfo
+	var data bytes.Buffer
+	chunks := 0
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return info, data.Bytes(), chunks, nil
+		}
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if resp.GetInfo() != nil {
+			info = resp.GetInfo()
+			continue
+		}
+		data.Write(resp.GetChunk())
+		chunks++
+	}
+}
+
+func TestUploadDownload(t *testing.T) {
+	client := newClient(t)
+	file, sum := randomFile(t, 200*1024+123)
+
+	st, err := upload(t, client, "photo.jpg", file, sum, 0, len(file))
+	require.NoError(t, err)
+	assert.True(t, st.Complete)
+	assert.Equal(t, sum, st.File.Sha256)
+
+	info, data, chunks, err := download(t, client, "photo.jpg", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(file)), info.Size)
+	assert.Equal(t, sum, info.Sha256)
+	assert.Equal(t, file, data)
+	assert.Equal(t, 13, chunks) // 16 KiB each, the last one short
+
+	// Resuming a download is asking for the rest
+	_, data, _, err = download(t, client, "photo.jpg", 150*1024)
+	require.NoError(t, err)
+	assert.Equal(t, file[150*1024:], data)
+
+	list, err := client.ListFiles(context.Background(), &pb.ListFilesRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Files, 1)
+	assert.Equal(t, "photo.jpg", list.Files[0].Name)
+}
+
+func TestUpload_Resume(t *testing.T) {
+	client := newClient(t)
+	file, sum := randomFile(t, 100*1024)
+
+	// The first attempt stops partway
+	st, err := upload(t, client, "video.mp4", file, sum, 0, 40*1024)
+	require.NoError(t, err)
+	assert.False(t, st.Complete)
+	assert.Equal(t, int64(40*1024), st.Received)
+
+	// The client asks where to carry on, and sends the rest
+	st, err = client.GetUploadStatus(context.Background(), &pb.GetUploadStatusRequest{Name: "video.mp4"})
+	require.NoError(t, err)
+	require.Equal(t, int64(40*1024), st.Received)
+
+	_, err = upload(t, client, "video.mp4", file, sum, 30*1024, len(file))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	st, err = upload(t, client, "video.mp4", file, sum, int(st.Received), len(file))
+	require.NoError(t, err)
+	assert.True(t, st.Complete)
+
+	_, data, _, err := download(t, client, "video.mp4", 0)
+	require.NoError(t, err)
+	assert.Equal(t, file, data)
+}
+
+func TestUpload_Errors(t *testing.T) {
+	client := newClient(t)
+	file, sum := randomFile(t, 1024)
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		corrupted := bytes.Clone(file)
+		corrupted[500] ^= 0xff
+		_, err := upload(t, client, "file.bin", corrupted, sum, 0, len(corrupted))
+		assert.Equal(t, codes.DataLoss, status.Code(err))
+
+		_, err = client.GetUploadStatus(context.Background(), &pb.GetUploadStatusRequest{Name: "file.bin"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("bad name", func(t *testing.T) {
+		_, err := upload(t, client, "../escape", file, sum, 0, len(file))
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("chunk before info", func(t *testing.T) {
+		stream, err := client.Upload(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&pb.UploadRequest{Data: &pb.UploadRequest_Chunk{Chunk: file}}))
+		_, err = stream.CloseAndRecv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		stream, err := client.Upload(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&pb.UploadRequest{Data: &pb.UploadRequest_Info{Info: &pb.UploadInfo{
+			Name: "huge.bin", Size: 100 << 20, Sha256: sum,
+		}}}))
+		_, err = stream.CloseAndRecv()
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+}
+
+func TestDownload_Errors(t *testing.T) {
+	client := newClient(t)
+	file, sum := randomFile(t, 1024)
+	_, err := upload(t, client, "file.bin", file, sum, 0, len(file))
+	require.NoError(t, err)
+
+	_, _, _, err = download(t, client, "missing.bin", 0)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, _, _, err = download(t, client, "file.bin", 2048)
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+}
@@ -0,0 +1,363 @@
+// Package storage keeps uploaded files on disk, along with the uploads
+// still in progress, so that an upload cut short can carry on later from
+// the last byte that arrived.
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalid is returned for a bad name, size or checksum
+	ErrInvalid = errors.New("invalid upload")
+	// ErrNotFound is returned when there is no such file or upload
+	ErrNotFound = errors.New("not found")
+	// ErrBusy is returned when the file is already being uploaded
+	ErrBusy = errors.New("already being uploaded")
+	// ErrOffset is returned when an upload does not resume where the last
+	// one stopped
+	ErrOffset = errors.New("wrong offset")
+	// ErrTooLarge is returned for files over the store's limit, and for
+	// uploads that send more bytes than they announced
+	ErrTooLarge = errors.New("too large")
+	// ErrChecksum is returned when the bytes received do not hash to the
+	// checksum the upload announced; the upload is discarded
+	ErrChecksum = errors.New("checksum mismatch")
+)
+
+var checksumPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// FileInfo describes a file that finished uploading
+type FileInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Status is how far an upload has got
+type Status struct {
+	Name     string
+	Size     int64
+	Received int64
+	// File is set once the upload is complete
+	File *FileInfo
+}
+
+// Store keeps files in a directory:
+//
+//	dir/<name>                     finished files
+//	dir/.meta/<name>.json          their size, checksum and time
+//	dir/.partial/<name>            uploads in progress, as far as they got
+//	dir/.partial-meta/<name>.json  the size and checksum they were started with
+//
+// Names cannot start with a dot, so they never clash with the folders. The
+// partial metadata has a folder of its own, or the upload of x.json would
+// overwrite that of x.
+type Store struct {
+	dir     string
+	maxSize int64
+
+	mu     sync.Mutex
+	active map[string]bool
+}
+
+// New opens the store in dir, creating it if needed. Files may be up to
+// maxSize bytes.
+func New(dir string, maxSize int64) (*Store, error) {
+	for _, d := range []string{dir, filepath.Join(dir, ".meta"), filepath.Join(dir, ".partial"), filepath.Join(dir, ".partial-meta")} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", d, err)
+		}
+	}
+	return &Store{dir: dir, maxSize: maxSize, active: map[string]bool{}}, nil
+}
+
+// ValidateName checks that name is a plain file name that cannot reach
+// outside the store
+func ValidateName(name string) error {
+	if name == "" || len(name) > 255 || strings.HasPrefix(name, ".") ||
+		strings.ContainsAny(name, `/\`) || strings.ContainsRune(name, 0) {
+		return fmt.Errorf("%w: %q is not a plain file name", ErrInvalid, name)
+	}
+	return nil
+}
+
+func (s *Store) path(name string) string     { return filepath.Join(s.dir, name) }
+func (s *Store) metaPath(name string) string { return filepath.Join(s.dir, ".meta", name+".json") }
+func (s *Store) partPath(name string) string { return filepath.Join(s.dir, ".partial", name) }
+func (s *Store) partMetaPath(name string) string {
+	return filepath.Join(s.dir, ".partial-meta", name+".json")
+}
+
+// partMeta is what an upload was started with. A resumed upload must
+// announce the same, or it would finish someone else's bytes.
+type partMeta struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Upload is one stream of an upload. Only one can be open per name.
+type Upload struct {
+	store    *Store
+	name     string
+	meta     partMeta
+	file     *os.File
+	received int64
+	closed   bool
+}
+
+// Begin starts an upload of size bytes that should hash to sum, or resumes
+// one from offset. Offset must be 0, which starts again from scratch, or
+// exactly the number of bytes that arrived so far.
+func (s *Store) Begin(name string, size int64, sum string, offset int64) (*Upload, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	if size < 0 || !checksumPattern.MatchString(sum) {
+		return nil, fmt.Errorf("%w: needs a size and a hex SHA-256", ErrInvalid)
+	}
+	if size > s.maxSize {
+		return nil, fmt.Errorf("%w: %d bytes is over the limit of %d", ErrTooLarge, size, s.maxSize)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active[name] {
+		return nil, fmt.Errorf("%s is %w", name, ErrBusy)
+	}
+
+	meta := partMeta{Size: size, SHA256: sum}
+	var file *os.File
+	var err error
+	if offset == 0 {
+		file, err = s.start(name, meta)
+	} else {
+		file, err = s.resume(name, meta, offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.active[name] = true
+	return &Upload{store: s, name: name, meta: meta, file: file, received: offset}, nil
+}
+
+func (s *Store) start(name string, meta partMeta) (*os.File, error) {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(s.partMetaPath(name), data, 0o644); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(s.partPath(name), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+}
+
+func (s *Store) resume(name string, meta partMeta, offset int64) (*os.File, error) {
+	have, err := s.readPartMeta(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: no upload of %s to resume, start at 0", ErrOffset, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if have != meta {
+		return nil, fmt.Errorf("%w: %s was started with a different size or checksum, start at 0", ErrOffset, name)
+	}
+	info, err := os.Stat(s.partPath(name))
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() != offset {
+		return nil, fmt.Errorf("%w: have %d bytes of %s, not %d", ErrOffset, info.Size(), name, offset)
+	}
+	return os.OpenFile(s.partPath(name), os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+func (s *Store) readPartMeta(name string) (partMeta, error) {
+	var meta partMeta
+	data, err := os.ReadFile(s.partMetaPath(name))
+	if err != nil {
+		return meta, err
+	}
+	return meta, json.Unmarshal(data, &meta)
+}
+
+// Write appends a chunk
+func (u *Upload) Write(chunk []byte) error {
+	if u.received+int64(len(chunk)) > u.meta.Size {
+		return fmt.Errorf("%w: more than the %d bytes announced", ErrTooLarge, u.meta.Size)
+	}
+	n, err := u.file.Write(chunk)
+	u.received += int64(n)
+	return err
+}
+
+// Received is the number of bytes the store has of this upload
+func (u *Upload) Received() int64 {
+	return u.received
+}
+
+// Finish ends this stream. If every byte has arrived, the file is checked
+// against its checksum and, if it matches, becomes a finished file.
+// Otherwise what arrived is kept, ready to be resumed.
+func (u *Upload) Finish() (Status, error) {
+	defer u.Close()
+	status := Status{Name: u.name, Size: u.meta.Size, Received: u.received}
+	if err := u.file.Sync(); err != nil {
+		return status, err
+	}
+	if u.received < u.meta.Size {
+		return status, nil
+	}
+
+	// Hash what is on disk rather than what went past in this stream: a
+	// resumed upload's first bytes came in an earlier one
+	sum, err := HashFile(u.store.partPath(u.name))
+	if err != nil {
+		return status, err
+	}
+	if sum != u.meta.SHA256 {
+		u.store.discard(u.name)
+		return status, fmt.Errorf("%w: %s hashed to %s", ErrChecksum, u.name, sum)
+	}
+
+	info := FileInfo{Name: u.name, Size: u.meta.Size, SHA256: sum, CreatedAt: time.Now().UTC()}
+	if err := u.store.commit(info); err != nil {
+		return status, err
+	}
+	status.File = &info
+	return status, nil
+}
+
+// Close releases the name, keeping whatever arrived for a later resume.
+// It is safe to call more than once.
+func (u *Upload) Close() error {
+	if u.closed {
+		return nil
+	}
+	u.closed = true
+	u.store.mu.Lock()
+	delete(u.store.active, u.name)
+	u.store.mu.Unlock()
+	return u.file.Close()
+}
+
+// commit moves a finished upload into place, replacing any file of the
+// same name. The rename is atomic, so a download sees the old file or the
+// new one, never half of each.
+func (s *Store) commit(info FileInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.metaPath(info.Name), data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(s.partPath(info.Name), s.path(info.Name)); err != nil {
+		return err
+	}
+	return os.Remove(s.partMetaPath(info.Name))
+}
+
+func (s *Store) discard(name string) {
+	os.Remove(s.partPath(name))
+	os.Remove(s.partMetaPath(name))
+}
+
+// Status reports how far the upload of name has got. An upload in
+// progress wins over a finished file of the same name.
+func (s *Store) Status(name string) (Status, error) {
+	if err := ValidateName(name); err != nil {
+		return Status{}, err
+	}
+	if meta, err := s.readPartMeta(name); err == nil {
+		info, err := os.Stat(s.partPath(name))
+		if err != nil {
+			return Status{}, err
+		}
+		return Status{Name: name, Size: meta.Size, Received: info.Size()}, nil
+	}
+	info, err := s.Info(name)
+	if err != nil {
+		return Status{}, err
+	}
+	return Status{Name: name, Size: info.Size, Received: info.Size, File: &info}, nil
+}
+
+// Info describes a finished file
+func (s *Store) Info(name string) (FileInfo, error) {
+	var info FileInfo
+	if err := ValidateName(name); err != nil {
+		return info, err
+	}
+	data, err := os.ReadFile(s.metaPath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return info, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return info, err
+	}
+	return info, json.Unmarshal(data, &info)
+}
+
+// Open opens a finished file for reading
+func (s *Store) Open(name string) (*os.File, FileInfo, error) {
+	info, err := s.Info(name)
+	if err != nil {
+		return nil, info, err
+	}
+	f, err := os.Open(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, info, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	return f, info, err
+}
+
+// List returns the finished files, by name
+func (s *Store) List() ([]FileInfo, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, ".meta"))
+	if err != nil {
+		return nil, err
+	}
+	files := []FileInfo{}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		info, err := s.Info(name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, info)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// HashFile returns the hex SHA-256 of the file at path
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
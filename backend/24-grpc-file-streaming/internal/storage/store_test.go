@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func newStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := New(t.TempDir(), 1024)
+	require.NoError(t, err)
+	return s
+}
+
+// send writes data as one stream and finishes it
+func send(t *testing.T, s *Store, name string, file []byte, offset int64, data []byte) (Status, error) {
+	t.Helper()
+	up, err := s.Begin(name, int64(len(file)), checksum(file), offset)
+	require.NoError(t, err)
+	require.NoError(t, up.Write(data))
+	return up.Finish()
+}
+
+func TestUpload_Complete(t *testing.T) {
+	s := newStore(t)
+	file := []byte("hello, chunked world")
+
+	st, err := send(t, s, "hello.txt", file, 0, file)
+	require.NoError(t, err)
+	require.NotNil(t, st.File)
+	assert.Equal(t, int64(len(file)), st.Received)
+	assert.Equal(t, checksum(file), st.File.SHA256)
+
+	data, err := os.ReadFile(filepath.Join(s.dir, "hello.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, file, data)
+
+	files, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "hello.txt", files[0].Name)
+}
+
+func TestUpload_Resume(t *testing.T) {
+	s := newStore(t)
+	file := []byte("0123456789abcdefghij")
+
+	// The first stream stops halfway: the bytes stay, the file is not listed
+	st, err := send(t, s, "data.bin", file, 0, file[:10])
+	require.NoError(t, err)
+	assert.Nil(t, st.File)
+	st, err = s.Status("data.bin")
+	require.NoError(t, err)
+	assert.Equal(t, Status{Name: "data.bin", Size: 20, Received: 10}, st)
+	files, err := s.List()
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	// The second carries on from there
+	st, err = send(t, s, "data.bin", file, 10, file[10:])
+	require.NoError(t, err)
+	require.NotNil(t, st.File)
+	info, err := s.Info("data.bin")
+	require.NoError(t, err)
+	assert.Equal(t, checksum(file), info.SHA256)
+}
+
+func TestUpload_NamesThatLookLikeMetadata(t *testing.T) {
+	s := newStore(t)
+	plain := []byte("0123456789")
+	suffixed := []byte(`{"not":"metadata"}`)
+
+	// Both in flight at once: neither may touch the other's bytes or metadata
+	up, err := s.Begin("x", int64(len(plain)), checksum(plain), 0)
+	require.NoError(t, err)
+	upJSON, err := s.Begin("x.json", int64(len(suffixed)), checksum(suffixed), 0)
+	require.NoError(t, err)
+	require.NoError(t, up.Write(plain[:4]))
+	require.NoError(t, upJSON.Write(suffixed[:5]))
+
+	st, err := s.Status("x")
+	require.NoError(t, err)
+	assert.Equal(t, Status{Name: "x", Size: 10, Received: 4}, st)
+
+	require.NoError(t, up.Write(plain[4:]))
+	st, err = up.Finish()
+	require.NoError(t, err)
+	require.NotNil(t, st.File)
+
+	// x committing leaves x.json's partial upload where it was
+	st, err = upJSON.Finish()
+	require.NoError(t, err)
+	assert.Nil(t, st.File)
+	st, err = s.Status("x.json")
+	require.NoError(t, err)
+	assert.Equal(t, Status{Name: "x.json", Size: int64(len(suffixed)), Received: 5}, st)
+
+	st, err = send(t, s, "x.json", suffixed, 5, suffixed[5:])
+	require.NoError(t, err)
+	require.NotNil(t, st.File)
+
+	files, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, checksum(plain), files[0].SHA256)
+	assert.Equal(t, checksum(suffixed), files[1].SHA256)
+}
+
+func TestUpload_ResumeRefused(t *testing.T) {
+	s := newStore(t)
+	file := []byte("0123456789abcdefghij")
+	_, err := send(t, s, "data.bin", file, 0, file[:10])
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		size   int64
+		sum    string
+		offset int64
+	}{
+		{"offset behind", 20, checksum(file), 5},
+		{"offset ahead", 20, checksum(file), 15},
+		{"different checksum", 20, checksum([]byte("something else")), 10},
+		{"different size", 30, checksum(file), 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Begin("data.bin", tt.size, tt.sum, tt.offset)
+			assert.ErrorIs(t, err, ErrOffset)
+		})
+	}
+
+	_, err = s.Begin("nothing.bin", 20, checksum(file), 10)
+	assert.ErrorIs(t, err, ErrOffset)
+}
+
+func TestUpload_Checksum(t *testing.T) {
+	s := newStore(t)
+	file := []byte("what the client meant to send")
+	corrupted := []byte("what the client meant to sent")
+
+	up, err := s.Begin("file.txt", int64(len(file)), checksum(file), 0)
+	require.NoError(t, err)
+	require.NoError(t, up.Write(corrupted))
+	_, err = up.Finish()
+	assert.ErrorIs(t, err, ErrChecksum)
+
+	// The bad bytes are gone: there is nothing to resume or download
+	_, err = s.Status("file.txt")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestUpload_Limits(t *testing.T) {
+	s := newStore(t)
+	file := []byte("four")
+
+	up, err := s.Begin("file.txt", int64(len(file)), checksum(file), 0)
+	require.NoError(t, err)
+	assert.ErrorIs(t, up.Write([]byte("five!")), ErrTooLarge)
+
+	// One stream per name at a time
+	_, err = s.Begin("file.txt", int64(len(file)), checksum(file), 0)
+	assert.ErrorIs(t, err, ErrBusy)
+	require.NoError(t, up.Close())
+	up, err = s.Begin("file.txt", int64(len(file)), checksum(file), 0)
+	require.NoError(t, err)
+	require.NoError(t, up.Close())
+
+	_, err = s.Begin("big.bin", 2048, checksum(file), 0)
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, err = s.Begin("file.txt", 4, "not-a-checksum", 0)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"photo.jpg", true},
+		{"my report (final).pdf", true},
+		{"", false},
+		{"../etc/passwd", false},
+		{"dir/file.txt", false},
+		{`dir\file.txt`, false},
+		{".meta", false},
+		{".partial", false},
+		{".partial-meta", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateName(tt.name)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalid)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/e6a5/learning/backend/24-grpc-file-streaming/internal/service"
+	"github.com/e6a5/learning/backend/24-grpc-file-streaming/internal/storage"
+	pb "github.com/e6a5/learning/backend/24-grpc-file-streaming/proto"
+	"github.com/e6a5/learning/backend/pkg/env"
+)
+
+func main() {
+	dir := env.Get("STORAGE_DIR", "./data")
+	maxSize, err := strconv.ParseInt(env.Get("MAX_FILE_SIZE", strconv.Itoa(1<<30)), 10, 64)
+	if err != nil {
+		log.Fatalf("MAX_FILE_SIZE must be a number of bytes: %v", err)
+	}
+	store, err := storage.New(dir, maxSize)
+	if err != nil {
+		log.Fatal("Failed to open storage:", err)
+	}
+
+	grpcServer := grpc.NewServer()
+	pb.RegisterFileServiceServer(grpcServer, service.NewFileService(store))
+	// Reflection lets grpcurl list and call the service without the .proto
+	reflection.Register(grpcServer)
+
+	port := env.Get("GRPC_PORT", "50051")
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		log.Fatalf("Failed to listen on port %s: %v", port, err)
+	}
+	go func() {
+		log.Printf("📁 File service running on port %s, storing files in %s", port, dir)
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Fatalf("Failed to serve: %v", err)
+		}
+	}()
+
+	sig, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-sig.Done()
+
+	// GracefulStop waits for every stream to finish, which for a big file
+	// can take a while. Past the timeout the rest are cut off: what they
+	// uploaded so far is kept, and the clients can resume.
+	log.Println("Shutting down server...")
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		log.Println("Server forced to shutdown: streams still open")
+		grpcServer.Stop()
+	}
+	log.Println("Server exited")
+}
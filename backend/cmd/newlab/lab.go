@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+//go:embed all:templates
+var templates embed.FS
+
+// Lab is everything the templates need to know about a new lab
+type Lab struct {
+	Number   int
+	Name     string // rate-limiting
+	Dir      string // 23-rate-limiting
+	Module   string // github.com/e6a5/learning/backend/23-rate-limiting
+	Title    string // Rate Limiting
+	Question string
+	Emoji    string
+	Port     int // on the host; the service listens on 8080 in its container
+
+	Resource string // bucket: variables, file names, messages
+	Plural   string // buckets: routes
+	Type     string // Bucket: types and methods
+	Types    string // Buckets
+}
+
+const modulePrefix = "github.com/e6a5/learning/backend/"
+
+var (
+	namePattern     = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+	resourcePattern = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+	labPattern      = regexp.MustCompile(`^(\d{2})-`)
+)
+
+// reserved are names a resource cannot have: it becomes a variable in
+// code that also uses these keywords, predeclared names, packages and
+// local variables
+var reserved = map[string]bool{}
+
+func init() {
+	for _, name := range strings.Fields(`
+		break case chan const continue default defer else fallthrough for func
+		go goto if import interface map package range return select struct
+		switch type var
+		append bool byte cap close copy delete error false int len make max
+		min new nil panic print rune string true
+		assert context env errors fmt handlers http httptest json log
+		middleware models mux repository require response signal slog sort
+		strconv strings sync syscall testing time validation
+		action code created err first got h i id j list ok path port r rec
+		repo req resp router second server sig stop store t tests third tt w
+	`) {
+		reserved[name] = true
+	}
+}
+
+// NewLab checks name and resource and fills in everything derived from
+// them. Title, Question, Emoji and Port get defaults the caller may change.
+func NewLab(number int, name, resource string) (Lab, error) {
+	if !namePattern.MatchString(name) {
+		return Lab{}, fmt.Errorf("name %q must be lowercase words joined by dashes, like rate-limiting", name)
+	}
+	if !resourcePattern.MatchString(resource) {
+		return Lab{}, fmt.Errorf("resource %q must be one lowercase word, like item", resource)
+	}
+	if reserved[resource] {
+		return Lab{}, fmt.Errorf("resource %q clashes with a Go keyword or a package the lab uses", resource)
+	}
+	if number < 1 || number > 99 {
+		return Lab{}, fmt.Errorf("number %d must be between 1 and 99", number)
+	}
+
+	dir := fmt.Sprintf("%02d-%s", number, name)
+	words := strings.Split(name, "-")
+	for i, w := range words {
+		words[i] = capitalize(w)
+	}
+	title := strings.Join(words, " ")
+	return Lab{
+		Number:   number,
+		Name:     name,
+		Dir:      dir,
+		Module:   modulePrefix + dir,
+		Title:    title,
+		Question: fmt.Sprintf("How does %s work, and how do I build it?", strings.ToLower(title)),
+		Emoji:    "🧪",
+		Port:     8080,
+		Resource: resource,
+		Plural:   plural(resource),
+		Type:     capitalize(resource),
+		Types:    capitalize(plural(resource)),
+	}, nil
+}
+
+// plural is English plural for the simple nouns resources tend to be
+func plural(s string) string {
+	switch {
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "z"),
+		strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	}
+	return s + "s"
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// NextNumber is one more than the highest numbered lab in backend
+func NextNumber(backend string) (int, error) {
+	entries, err := os.ReadDir(backend)
+	if err != nil {
+		return 0, err
+	}
+	highest := 0
+	for _, e := range entries {
+		m := labPattern.FindStringSubmatch(e.Name())
+		if !e.IsDir() || m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		highest = max(highest, n)
+	}
+	return highest + 1, nil
+}
+
+// FindBackend walks up from dir to the backend directory, the one with
+// the shared pkg module in it
+func FindBackend(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "pkg", "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("backend directory not found: run newlab from inside backend/, or pass -backend")
+		}
+		dir = parent
+	}
+}
+
+// Generate writes the lab into backend and returns the files it wrote,
+// relative to the lab. Every template is rendered before anything is
+// written, so a broken template leaves no half-made lab behind.
+func Generate(backend string, lab Lab) ([]string, error) {
+	dir := filepath.Join(backend, lab.Dir)
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("%s already exists", dir)
+	}
+	if taken, _ := filepath.Glob(filepath.Join(backend, fmt.Sprintf("%02d-*", lab.Number))); len(taken) > 0 {
+		return nil, fmt.Errorf("lab number %02d is taken by %s", lab.Number, filepath.Base(taken[0]))
+	}
+
+	files := map[string][]byte{}
+	var names []string
+	err := fs.WalkDir(templates, "templates", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := render(path, lab)
+		if err != nil {
+			return err
+		}
+		// Template names say RESOURCE and PLURAL where the lab's names go
+		name := strings.TrimSuffix(strings.TrimPrefix(path, "templates/"), ".tmpl")
+		name = strings.NewReplacer("RESOURCE", lab.Resource, "PLURAL", lab.Plural).Replace(name)
+		if strings.HasSuffix(name, ".go") {
+			if content, err = format.Source(content); err != nil {
+				return fmt.Errorf("%s is not valid Go: %w", name, err)
+			}
+		}
+		files[name] = content
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(target, files[name], 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// funcs are for the templates: pad lines up the comments in README trees
+var funcs = template.FuncMap{
+	"pad": func(width int, s string) string {
+		if n := utf8.RuneCountInString(s); n < width {
+			return s + strings.Repeat(" ", width-n)
+		}
+		return s + " "
+	},
+}
+
+func render(path string, lab Lab) ([]byte, error) {
+	text, err := templates.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(path).Option("missingkey=error").Funcs(funcs).Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, lab); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend is a backend directory with a few labs and the shared pkg
+func fakeBackend(t *testing.T, labs ...string) string {
+	dir := t.TempDir()
+	for _, lab := range append(labs, "pkg") {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, lab), 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "go.mod"), []byte("module pkg\n"), 0o644))
+	return dir
+}
+
+func TestNewLab(t *testing.T) {
+	lab, err := NewLab(23, "rate-limiting", "bucket")
+	require.NoError(t, err)
+	assert.Equal(t, "23-rate-limiting", lab.Dir)
+	assert.Equal(t, "github.com/e6a5/learning/backend/23-rate-limiting", lab.Module)
+	assert.Equal(t, "Rate Limiting", lab.Title)
+	assert.Equal(t, "buckets", lab.Plural)
+	assert.Equal(t, "Bucket", lab.Type)
+	assert.Equal(t, "Buckets", lab.Types)
+
+	tests := []struct {
+		name     string
+		number   int
+		lab      string
+		resource string
+	}{
+		{"spaces in name", 23, "rate limiting", "item"},
+		{"capitals in name", 23, "RateLimiting", "item"},
+		{"number in name", 23, "23-rate-limiting", "item"},
+		{"two-word resource", 23, "rate-limiting", "rate-bucket"},
+		{"keyword resource", 23, "rate-limiting", "type"},
+		{"package resource", 23, "rate-limiting", "response"},
+		{"local variable resource", 23, "rate-limiting", "id"},
+		{"no number", 0, "rate-limiting", "item"},
+		{"three digits", 100, "rate-limiting", "item"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLab(tt.number, tt.lab, tt.resource)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPlural(t *testing.T) {
+	for singular, want := range map[string]string{
+		"item":   "items",
+		"entry":  "entries",
+		"key":    "keys",
+		"status": "statuses",
+		"box":    "boxes",
+		"match":  "matches",
+	} {
+		assert.Equal(t, want, plural(singular))
+	}
+}
+
+func TestNextNumber(t *testing.T) {
+	backend := fakeBackend(t, "00-set-up", "01-http-server", "22-oauth2-oidc", "cmd")
+	n, err := NextNumber(backend)
+	require.NoError(t, err)
+	assert.Equal(t, 23, n)
+}
+
+func TestFindBackend(t *testing.T) {
+	backend := fakeBackend(t, "cmd/newlab")
+	found, err := FindBackend(filepath.Join(backend, "cmd", "newlab"))
+	require.NoError(t, err)
+	assert.Equal(t, backend, found)
+
+	_, err = FindBackend(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestGenerate(t *testing.T) {
+	backend := fakeBackend(t, "22-oauth2-oidc")
+	lab, err := NewLab(23, "rate-limiting", "bucket")
+	require.NoError(t, err)
+	lab.Port = 8093
+
+	files, err := Generate(backend, lab)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"go.mod", "main.go", "Dockerfile", "compose.yml", "Makefile", "README.md",
+		"internal/models/bucket.go",
+		"internal/repository/bucket.go",
+		"internal/repository/bucket_test.go",
+		"internal/handlers/buckets.go",
+		"internal/handlers/buckets_test.go",
+	}, files)
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(backend, lab.Dir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+	assert.Contains(t, read("go.mod"), "module github.com/e6a5/learning/backend/23-rate-limiting\n")
+	assert.Contains(t, read("go.mod"), "replace github.com/e6a5/learning/backend/pkg => ../pkg")
+	assert.Contains(t, read("main.go"), `router.HandleFunc("/buckets/{id:[0-9]+}", bucketHandler.GetBucket)`)
+	assert.Contains(t, read("internal/handlers/buckets.go"), "func (h *BucketHandler) ListBuckets(")
+	assert.Contains(t, read("Dockerfile"), "COPY 23-rate-limiting/go.mod 23-rate-limiting/go.sum ./")
+	assert.Contains(t, read("compose.yml"), `"8093:8080"`)
+	assert.Contains(t, read("Makefile"), "PORT := 8093")
+	assert.Contains(t, read("README.md"), "# 🧪 23-rate-limiting: Rate Limiting\n")
+
+	// Comments in the README tree line up whatever the resource is called
+	var columns []int
+	for _, line := range strings.Split(read("README.md"), "\n") {
+		if strings.Contains(line, "─ ") && strings.Contains(line, "# ") {
+			columns = append(columns, len([]rune(line[:strings.Index(line, "# ")])))
+		}
+	}
+	require.NotEmpty(t, columns)
+	for _, c := range columns {
+		assert.Equal(t, columns[0], c)
+	}
+
+	// Never over an existing lab, or a lab with the same number
+	_, err = Generate(backend, lab)
+	assert.ErrorContains(t, err, "already exists")
+	other, err := NewLab(22, "api-gateway", "route")
+	require.NoError(t, err)
+	_, err = Generate(backend, other)
+	assert.ErrorContains(t, err, "taken by 22-oauth2-oidc")
+}
@@ -0,0 +1,99 @@
+// Command newlab starts a new lab with the layout every lab shares:
+// main.go wired to the shared pkg, models, an in-memory repository and
+// handlers with their tests, a Dockerfile built from backend/, compose.yml,
+// a Makefile and a README to fill in.
+//
+//	cd backend/cmd/newlab
+//	go run . rate-limiting
+//	go run . -resource bucket -port 8093 -question "How do I stop one client from using up the API?" rate-limiting
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+func main() {
+	log.SetFlags(0)
+	number := flag.Int("number", 0, "lab number; the next free one if 0")
+	resource := flag.String("resource", "item", "what the starter API stores, one lowercase word")
+	title := flag.String("title", "", "title for the README; made from the name if empty")
+	question := flag.String("question", "", "the lab's learning question")
+	emoji := flag.String("emoji", "", "emoji for the README heading")
+	port := flag.Int("port", 8080, "port on the host in compose.yml and the Makefile")
+	backend := flag.String("backend", "", "the backend directory; found from the working directory if empty")
+	tidy := flag.Bool("tidy", true, "run go mod tidy in the new lab, which writes its go.sum")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: newlab [flags] <name>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *backend == "" {
+		dir, err := FindBackend(".")
+		if err != nil {
+			log.Fatal(err)
+		}
+		*backend = dir
+	}
+	if *number == 0 {
+		n, err := NextNumber(*backend)
+		if err != nil {
+			log.Fatal("Failed to find the next lab number: ", err)
+		}
+		*number = n
+	}
+
+	lab, err := NewLab(*number, flag.Arg(0), *resource)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *title != "" {
+		lab.Title = *title
+	}
+	if *question != "" {
+		lab.Question = *question
+	}
+	if *emoji != "" {
+		lab.Emoji = *emoji
+	}
+	lab.Port = *port
+
+	files, err := Generate(*backend, lab)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dir := filepath.Join(*backend, lab.Dir)
+	fmt.Printf("Created %s:\n", lab.Dir)
+	for _, f := range files {
+		fmt.Printf("  %s\n", f)
+	}
+
+	// go.sum cannot come from a template: it depends on what is in the
+	// module cache and what the proxy says
+	if *tidy {
+		cmd := exec.Command("go", "mod", "tidy")
+		cmd.Dir = dir
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Println("go mod tidy failed; run make deps in the lab once it can reach the module proxy")
+		}
+	}
+
+	rel := dir
+	if wd, err := os.Getwd(); err == nil {
+		if r, err := filepath.Rel(wd, dir); err == nil && len(r) < len(dir) {
+			rel = r
+		}
+	}
+	fmt.Printf("\nNext:\n  cd %s && make test && make run\n", rel)
+	fmt.Println("  Fill in README.md, and add the lab to the table in backend/README.md")
+}
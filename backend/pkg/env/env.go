@@ -0,0 +1,12 @@
+// Package env reads configuration from environment variables
+package env
+
+import "os"
+
+// Get gets an environment variable with a default value
+func Get(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
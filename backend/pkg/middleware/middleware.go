@@ -0,0 +1,71 @@
+// Package middleware has the HTTP middleware every lab API wants and none
+// of them teaches: CORS and request logs.
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CORS lets pages on any origin call the API and answers preflight
+// requests. Content-Type and Authorization may always be sent; headers
+// adds more.
+func CORS(headers ...string) func(http.Handler) http.Handler {
+	allowed := strings.Join(append([]string{"Content-Type", "Authorization"}, headers...), ", ")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", allowed)
+
+			// Handle preflight requests
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// statusWriter remembers the status code a handler answered with
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the real writer, for
+// flushing streams and hijacking WebSocket connections
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Logging logs every request at level once it has been answered, with its
+// method, path, status and duration. Use a level below the logger's to
+// turn request logs off without removing the middleware.
+func Logging(logger *slog.Logger, level slog.Level) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(sw, r)
+
+			logger.Log(r.Context(), level, "Request processed",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", sw.status,
+				"duration", time.Since(start),
+				"ip", r.RemoteAddr,
+			)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Package response writes the JSON envelope the lab APIs answer with:
+//
+//	{"message": "...", "data": {...}}   on success
+//	{"error": "..."}                    on failure
+package response
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// APIResponse represents a standard API response
+type APIResponse struct {
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// JSON sends a JSON response with the given status code and data. data is
+// usually an APIResponse, but any value that encodes to JSON will do.
+func JSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("Failed to encode JSON response: %v", err)
+	}
+}
+
+// Error sends an APIResponse with only the error message set
+func Error(w http.ResponseWriter, statusCode int, message string) {
+	JSON(w, statusCode, APIResponse{Error: message})
+}
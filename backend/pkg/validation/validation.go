@@ -0,0 +1,15 @@
+// Package validation holds the error that request validation returns, so
+// handlers can tell bad input (400) from everything else (500).
+package validation
+
+import "fmt"
+
+// Error represents a validation error
+type Error struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
@@ -0,0 +1,112 @@
+// Package animate runs a full-screen animation at a fixed frame rate.
+package animate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/terminal"
+)
+
+// Update draws frame onto c and reports whether to keep going. Frames are
+// numbered from 0 and come fps times a second of wall time, so motion worked
+// out from the frame number keeps its speed even when drawing falls behind.
+// After the window is resized the current frame is drawn again, on a canvas
+// of the new size.
+type Update func(frame int, c *drawing.Canvas) bool
+
+// Run animates on a canvas the size of the terminal until update returns
+// false or the user presses ctrl-C, then puts the screen back as it was. The
+// canvas follows the terminal when its window is resized.
+func Run(fps int, update Update) error {
+	if fps <= 0 {
+		return fmt.Errorf("fps must be positive, got %d", fps)
+	}
+	size, err := terminal.Size()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	interval := time.Second / time.Duration(fps)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	due := ticks(ctx, ticker.C, time.Now(), interval)
+	resized := terminal.NotifyResize(ctx)
+	return play(ctx, os.Stdout, drawing.NewCanvas(size.Width, size.Height), due, resized, update)
+}
+
+// ticks turns clock ticks into the number of the frame due at each one,
+// until ctx is done
+func ticks(ctx context.Context, clock <-chan time.Time, start time.Time, interval time.Duration) <-chan int {
+	due := make(chan int)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-clock:
+				select {
+				case due <- int(now.Sub(start) / interval):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return due
+}
+
+// play draws frame 0 at once; then, each time a frame number comes due, it
+// runs update for every frame up to it and flushes once, so a slow terminal
+// drops frames from the screen but never from the animation's own clock
+func play(ctx context.Context, w io.Writer, c *drawing.Canvas, due <-chan int, resized <-chan terminal.Bounds, update Update) (err error) {
+	if _, err := io.WriteString(w, ansi.EnterAltScreen()+ansi.HideCursor()); err != nil {
+		return err
+	}
+	defer func() {
+		_, teardownErr := io.WriteString(w, ansi.ShowCursor()+ansi.LeaveAltScreen())
+		if err == nil {
+			err = teardownErr
+		}
+	}()
+
+	if !update(0, c) {
+		return c.Flush(w)
+	}
+	next := 1
+	for {
+		if err := c.Flush(w); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case frame, ok := <-due:
+			if !ok {
+				return nil
+			}
+			for ; next <= frame; next++ {
+				if !update(next, c) {
+					return c.Flush(w)
+				}
+			}
+		case b := <-resized:
+			c.Resize(b.Width, b.Height)
+			if !update(next-1, c) {
+				return c.Flush(w)
+			}
+		}
+	}
+}
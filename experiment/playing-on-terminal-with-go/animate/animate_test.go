@@ -0,0 +1,174 @@
+package animate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/terminal"
+)
+
+// dueFrames is a due channel that yields frames and then closes
+func dueFrames(frames ...int) <-chan int {
+	due := make(chan int, len(frames))
+	for _, f := range frames {
+		due <- f
+	}
+	close(due)
+	return due
+}
+
+func TestPlayCatchesUpOnMissedFrames(t *testing.T) {
+	var seen []int
+	update := func(frame int, c *drawing.Canvas) bool {
+		seen = append(seen, frame)
+		return true
+	}
+
+	var out strings.Builder
+	err := play(context.Background(), &out, drawing.NewCanvas(2, 1), dueFrames(1, 1, 4, 5), nil, update)
+	if err != nil {
+		t.Fatalf("play() error = %v", err)
+	}
+	if expected := []int{0, 1, 2, 3, 4, 5}; !reflect.DeepEqual(seen, expected) {
+		t.Errorf("frames = %v, want %v", seen, expected)
+	}
+}
+
+func TestPlayFlushesOncePerTick(t *testing.T) {
+	update := func(frame int, c *drawing.Canvas) bool {
+		c.Clear()
+		c.Set(frame%3+1, 1, 'o', ansi.Style{})
+		return true
+	}
+
+	var out strings.Builder
+	if err := play(context.Background(), &out, drawing.NewCanvas(3, 1), dueFrames(2), nil, update); err != nil {
+		t.Fatalf("play() error = %v", err)
+	}
+
+	// frame 1 is worked out but never shown
+	expected := ansi.EnterAltScreen() + ansi.HideCursor() +
+		ansi.MoveCursor(1, 1) + "o" + ansi.MoveCursor(2, 1) + " " + ansi.MoveCursor(3, 1) + " " +
+		ansi.MoveCursor(1, 1) + " " + ansi.MoveCursor(3, 1) + "o" +
+		ansi.ShowCursor() + ansi.LeaveAltScreen()
+	if out.String() != expected {
+		t.Errorf("output = %q, want %q", out.String(), expected)
+	}
+}
+
+func TestPlayStopsWhenUpdateSaysSo(t *testing.T) {
+	var last int
+	update := func(frame int, c *drawing.Canvas) bool {
+		last = frame
+		c.Set(1, 1, rune('0'+frame), ansi.Style{})
+		return frame < 2
+	}
+
+	var out strings.Builder
+	if err := play(context.Background(), &out, drawing.NewCanvas(1, 1), dueFrames(5, 9), nil, update); err != nil {
+		t.Fatalf("play() error = %v", err)
+	}
+	if last != 2 {
+		t.Errorf("last frame = %d, want 2", last)
+	}
+	if !strings.Contains(out.String(), ansi.MoveCursor(1, 1)+"2") {
+		t.Errorf("output %q does not show the final frame", out.String())
+	}
+}
+
+func TestPlayRestoresScreenWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var out strings.Builder
+	due := make(chan int) // never ticks
+	err := play(ctx, &out, drawing.NewCanvas(1, 1), due, nil, func(int, *drawing.Canvas) bool { return true })
+	if err != nil {
+		t.Fatalf("play() error = %v", err)
+	}
+	if !strings.HasSuffix(out.String(), ansi.ShowCursor()+ansi.LeaveAltScreen()) {
+		t.Errorf("output %q does not end by restoring the screen", out.String())
+	}
+}
+
+func TestPlayRedrawsAfterResize(t *testing.T) {
+	var frames []int
+	var widths []int
+	update := func(frame int, c *drawing.Canvas) bool {
+		frames = append(frames, frame)
+		widths = append(widths, c.Width())
+		c.Set(c.Width(), 1, 'o', ansi.Style{})
+		return true
+	}
+
+	due := make(chan int)
+	resized := make(chan terminal.Bounds)
+	done := make(chan error)
+	var out strings.Builder
+	go func() { done <- play(context.Background(), &out, drawing.NewCanvas(2, 1), due, resized, update) }()
+
+	due <- 1
+	resized <- terminal.Bounds{Width: 3, Height: 1}
+	close(due)
+	if err := <-done; err != nil {
+		t.Fatalf("play() error = %v", err)
+	}
+
+	if expected := []int{0, 1, 1}; !reflect.DeepEqual(frames, expected) {
+		t.Errorf("frames = %v, want %v", frames, expected)
+	}
+	if expected := []int{2, 2, 3}; !reflect.DeepEqual(widths, expected) {
+		t.Errorf("canvas widths = %v, want %v", widths, expected)
+	}
+	if !strings.Contains(out.String(), ansi.MoveCursor(3, 1)+"o") {
+		t.Errorf("output %q does not draw on the new column", out.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("closed") }
+
+func TestPlayWriteError(t *testing.T) {
+	err := play(context.Background(), failingWriter{}, drawing.NewCanvas(1, 1), dueFrames(), nil, func(int, *drawing.Canvas) bool { return true })
+	if err == nil {
+		t.Error("play() error = nil, want the writer's error")
+	}
+}
+
+func TestTicks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Unix(0, 0)
+	clock := make(chan time.Time)
+	due := ticks(ctx, clock, start, 100*time.Millisecond)
+
+	for _, test := range []struct {
+		elapsed  time.Duration
+		expected int
+	}{
+		{100 * time.Millisecond, 1},
+		{250 * time.Millisecond, 2},
+		{900 * time.Millisecond, 9},
+	} {
+		clock <- start.Add(test.elapsed)
+		if frame := <-due; frame != test.expected {
+			t.Errorf("after %v frame = %d, want %d", test.elapsed, frame, test.expected)
+		}
+	}
+}
+
+func TestRunRejectsBadFPS(t *testing.T) {
+	for _, fps := range []int{0, -1} {
+		if err := Run(fps, func(int, *drawing.Canvas) bool { return false }); err == nil {
+			t.Errorf("Run(%d) error = nil, want an error", fps)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package ansi
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 const (
 	ESC = "\033"
@@ -37,3 +41,147 @@ func PrintAtCoordinatesWithColor(x, y int, char rune, colorCode int) string {
 	}
 	return positioned + colored
 }
+
+// ColorNames lists the colors ColorCode knows in code order: the eight
+// standard ones, 30 to 37, then their bright variants, 90 to 97
+var ColorNames = []string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"bright-black", "bright-red", "bright-green", "bright-yellow",
+	"bright-blue", "bright-magenta", "bright-cyan", "bright-white",
+}
+
+// returns 0, which means no color, for an unknown name
+func ColorCode(name string) int {
+	for i, known := range ColorNames {
+		if known == name {
+			if i < 8 {
+				return 30 + i
+			}
+			return 90 + i - 8
+		}
+	}
+	return 0
+}
+
+// background codes sit 10 above their foreground ones; 0 for an unknown name
+func BackgroundCode(name string) int {
+	code := ColorCode(name)
+	if code == 0 {
+		return 0
+	}
+	return code + 10
+}
+
+// zero value draws plain text
+type Style struct {
+	FG        int
+	BG        int
+	Bold      bool
+	Dim       bool
+	Italic    bool
+	Underline bool
+	Reverse   bool
+}
+
+// attributes go first, then FG and BG, all in one sequence
+func (s Style) Apply(text string) string {
+	codes := ""
+	attrs := []struct {
+		on   bool
+		code int
+	}{
+		{s.Bold, 1},
+		{s.Dim, 2},
+		{s.Italic, 3},
+		{s.Underline, 4},
+		{s.Reverse, 7},
+		{s.FG != 0, s.FG},
+		{s.BG != 0, s.BG},
+	}
+	for _, attr := range attrs {
+		if !attr.on {
+			continue
+		}
+		if codes != "" {
+			codes += ";"
+		}
+		codes += fmt.Sprint(attr.code)
+	}
+	if codes == "" {
+		return text
+	}
+	return fmt.Sprintf("%s[%sm%s%s[0m", ESC, codes, text, ESC)
+}
+
+// Update returns s changed by the codes of one SGR sequence, the part
+// between ESC[ and m, the way a terminal reads them: codes add to the
+// current style and 0, or no codes at all, resets it
+func (s Style) Update(codes string) (Style, error) {
+	if codes == "" {
+		return Style{}, nil
+	}
+	for _, field := range strings.Split(codes, ";") {
+		code, err := strconv.Atoi(field)
+		if err != nil {
+			return s, fmt.Errorf("bad style code %q", field)
+		}
+		switch {
+		case code == 0:
+			s = Style{}
+		case code == 1:
+			s.Bold = true
+		case code == 2:
+			s.Dim = true
+		case code == 3:
+			s.Italic = true
+		case code == 4:
+			s.Underline = true
+		case code == 7:
+			s.Reverse = true
+		case code >= 30 && code <= 37, code >= 90 && code <= 97:
+			s.FG = code
+		case code >= 40 && code <= 47, code >= 100 && code <= 107:
+			s.BG = code
+		default:
+			return s, fmt.Errorf("unsupported style code %d", code)
+		}
+	}
+	return s, nil
+}
+
+func PrintAtCoordinatesWithStyle(x, y int, char rune, style Style) string {
+	return MoveCursor(x, y) + style.Apply(string(char))
+}
+
+// the alternate screen keeps the shell's scrollback intact while a
+// full-screen program runs
+func EnterAltScreen() string {
+	return fmt.Sprintf("%s[?1049h", ESC)
+}
+
+func LeaveAltScreen() string {
+	return fmt.Sprintf("%s[?1049l", ESC)
+}
+
+func HideCursor() string {
+	return fmt.Sprintf("%s[?25l", ESC)
+}
+
+func ShowCursor() string {
+	return fmt.Sprintf("%s[?25h", ESC)
+}
+
+// reports presses, releases, drags and the wheel as input, in the SGR
+// encoding terminal.KeyReader decodes; a shell left with it on gets the reports
+func EnableMouse() string {
+	return fmt.Sprintf("%s[?1002h%s[?1006h", ESC, ESC)
+}
+
+func DisableMouse() string {
+	return fmt.Sprintf("%s[?1006l%s[?1002l", ESC, ESC)
+}
+
+// moves up n lines, staying in the same column
+func CursorUp(n int) string {
+	return fmt.Sprintf("%s[%dA", ESC, n)
+}
@@ -66,3 +66,170 @@ func TestPrintAtCoordinatesWithColor(t *testing.T) {
 		t.Errorf("Expected %s, got %s", expected, result)
 	}
 }
+
+func TestColorCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int
+	}{
+		{"black", 30},
+		{"red", 31},
+		{"cyan", 36},
+		{"white", 37},
+		{"bright-black", 90},
+		{"bright-red", 91},
+		{"bright-white", 97},
+		{"", 0},
+		{"purple", 0},
+		{"bright", 0},
+	}
+
+	for _, test := range tests {
+		result := ColorCode(test.name)
+		if result != test.expected {
+			t.Errorf("ColorCode(%q): expected %d, got %d", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestBackgroundCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int
+	}{
+		{"black", 40},
+		{"red", 41},
+		{"white", 47},
+		{"bright-cyan", 106},
+		{"", 0},
+		{"purple", 0},
+	}
+
+	for _, test := range tests {
+		result := BackgroundCode(test.name)
+		if result != test.expected {
+			t.Errorf("BackgroundCode(%q): expected %d, got %d", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestStyleApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		style    Style
+		expected string
+	}{
+		{"plain", Style{}, "Hi"},
+		{"foreground", Style{FG: 31}, ESC + "[31mHi" + ESC + "[0m"},
+		{"background", Style{BG: 44}, ESC + "[44mHi" + ESC + "[0m"},
+		{"bold", Style{Bold: true}, ESC + "[1mHi" + ESC + "[0m"},
+		{"dim", Style{Dim: true}, ESC + "[2mHi" + ESC + "[0m"},
+		{"italic", Style{Italic: true}, ESC + "[3mHi" + ESC + "[0m"},
+		{"underline", Style{Underline: true}, ESC + "[4mHi" + ESC + "[0m"},
+		{"reverse", Style{Reverse: true}, ESC + "[7mHi" + ESC + "[0m"},
+		{"combined", Style{FG: 33, BG: 44, Bold: true, Underline: true}, ESC + "[1;4;33;44mHi" + ESC + "[0m"},
+	}
+
+	for _, test := range tests {
+		result := test.style.Apply("Hi")
+		if result != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestStyleApplyMatchesColorize(t *testing.T) {
+	expected := Colorize("Hello, World!", 32)
+	result := Style{FG: 32}.Apply("Hello, World!")
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestStyleUpdate(t *testing.T) {
+	tests := []struct {
+		start    Style
+		codes    string
+		expected Style
+	}{
+		{Style{}, "31", Style{FG: 31}},
+		{Style{}, "1;4;33;44", Style{FG: 33, BG: 44, Bold: true, Underline: true}},
+		{Style{}, "2;3;7", Style{Dim: true, Italic: true, Reverse: true}},
+		{Style{Bold: true}, "31", Style{FG: 31, Bold: true}},
+		{Style{FG: 31}, "32", Style{FG: 32}},
+		{Style{FG: 31, Bold: true}, "0", Style{}},
+		{Style{FG: 31}, "", Style{}},
+		{Style{}, "1;0;32", Style{FG: 32}},
+		{Style{}, "91;104", Style{FG: 91, BG: 104}},
+	}
+
+	for _, test := range tests {
+		result, err := test.start.Update(test.codes)
+		if err != nil {
+			t.Errorf("Update(%q): %v", test.codes, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%+v.Update(%q): expected %+v, got %+v", test.start, test.codes, test.expected, result)
+		}
+	}
+
+	for _, codes := range []string{"38;5;200", "x", "1;;2"} {
+		if _, err := (Style{}).Update(codes); err == nil {
+			t.Errorf("Update(%q): expected an error", codes)
+		}
+	}
+}
+
+func TestStyleUpdateReadsApply(t *testing.T) {
+	style := Style{FG: 36, BG: 41, Bold: true, Dim: true, Italic: true, Underline: true, Reverse: true}
+	applied := style.Apply("x")
+	codes := applied[len(ESC+"[") : len(applied)-len("mx"+ESC+"[0m")]
+	result, err := Style{}.Update(codes)
+	if err != nil || result != style {
+		t.Errorf("Expected %+v, got %+v (%v)", style, result, err)
+	}
+}
+
+func TestPrintAtCoordinatesWithStyle(t *testing.T) {
+	expected := ESC + "[10;5H" + ESC + "[1;31;47mX" + ESC + "[0m"
+	result := PrintAtCoordinatesWithStyle(5, 10, 'X', Style{FG: 31, BG: 47, Bold: true})
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestAltScreen(t *testing.T) {
+	if result, expected := EnterAltScreen(), ESC+"[?1049h"; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+	if result, expected := LeaveAltScreen(), ESC+"[?1049l"; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestCursorVisibility(t *testing.T) {
+	if result, expected := HideCursor(), ESC+"[?25l"; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+	if result, expected := ShowCursor(), ESC+"[?25h"; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestMouseReporting(t *testing.T) {
+	if result, expected := EnableMouse(), ESC+"[?1002h"+ESC+"[?1006h"; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+	if result, expected := DisableMouse(), ESC+"[?1006l"+ESC+"[?1002l"; result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestCursorUp(t *testing.T) {
+	expected := ESC + "[3A"
+	result := CursorUp(3)
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
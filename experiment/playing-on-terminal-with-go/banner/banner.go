@@ -0,0 +1,148 @@
+// Package banner draws text in large letters made of many cells, for titles.
+package banner
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+)
+
+//go:embed font.txt
+var standard string
+
+// Standard is the built-in font: capitals, digits and common punctuation,
+// five cells tall
+var Standard = mustParse(standard)
+
+// Font is a bitmap font; every glyph is Height rows tall, and as wide as it
+// needs to be
+type Font struct {
+	Height int
+	glyphs map[rune][]string
+}
+
+// ParseFont reads glyphs in turn, each a line "= X" naming the character X,
+// or just "=" for space, followed by its rows: # for a lit cell, . for an
+// unlit one.
+//
+//	= T
+//	###
+//	.#.
+//	.#.
+func ParseFont(r io.Reader) (*Font, error) {
+	f := &Font{glyphs: make(map[rune][]string)}
+	var char rune
+	var rows []string
+	add := func() error {
+		if rows == nil {
+			return nil
+		}
+		if f.Height == 0 {
+			f.Height = len(rows)
+		}
+		if len(rows) != f.Height {
+			return fmt.Errorf("glyph %q has %d rows, want %d", char, len(rows), f.Height)
+		}
+		f.glyphs[char] = rows
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if name, ok := strings.CutPrefix(line, "="); ok {
+			if err := add(); err != nil {
+				return nil, err
+			}
+			runes := []rune(strings.TrimPrefix(name, " "))
+			switch len(runes) {
+			case 0:
+				char = ' '
+			case 1:
+				char = runes[0]
+			default:
+				return nil, fmt.Errorf("line %d: glyph name %q is not one character", n, name)
+			}
+			rows = []string{}
+			continue
+		}
+		if rows == nil {
+			return nil, fmt.Errorf("line %d: rows before any glyph name", n)
+		}
+		if strings.Trim(line, "#.") != "" {
+			return nil, fmt.Errorf("line %d: glyph rows are # and . only", n)
+		}
+		if len(rows) > 0 && len(line) != len(rows[0]) {
+			return nil, fmt.Errorf("line %d: glyph %q rows differ in width", n, char)
+		}
+		rows = append(rows, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := add(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func mustParse(text string) *Font {
+	f, err := ParseFont(strings.NewReader(text))
+	if err != nil {
+		panic("banner: built-in font: " + err.Error())
+	}
+	return f
+}
+
+// glyph falls back to the capital letter, then to ?, and is nil when the
+// font has neither
+func (f *Font) glyph(char rune) []string {
+	for _, c := range []rune{char, unicode.ToUpper(char), '?'} {
+		if rows, ok := f.glyphs[c]; ok {
+			return rows
+		}
+	}
+	return nil
+}
+
+// Render lays text out in Height lines, letters one cell apart, with fill
+// for lit cells and spaces elsewhere
+func (f *Font) Render(text string, fill rune) []string {
+	lines := make([]string, f.Height)
+	pixels := strings.NewReplacer("#", string(fill), ".", " ")
+	first := true
+	for _, char := range text {
+		rows := f.glyph(char)
+		if rows == nil {
+			continue
+		}
+		for y, row := range rows {
+			if !first {
+				lines[y] += " "
+			}
+			lines[y] += pixels.Replace(row)
+		}
+		first = false
+	}
+	return lines
+}
+
+// Width is how many cells text takes rendered
+func (f *Font) Width(text string) int {
+	if f.Height == 0 {
+		return 0
+	}
+	return len([]rune(f.Render(text, '#')[0]))
+}
+
+// Draw puts text on the canvas with its top-left corner at (x, y). Unlit
+// cells are left alone, so the banner can go over a background.
+func (f *Font) Draw(c *drawing.Canvas, x, y int, text string, fill rune, style ansi.Style) {
+	c.DrawSprite(x, y, drawing.TextSprite(style, f.Render(text, fill)...))
+}
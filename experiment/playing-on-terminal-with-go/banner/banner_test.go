@@ -0,0 +1,134 @@
+package banner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+)
+
+func TestStandardCoversItsCharacters(t *testing.T) {
+	chars := "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 !?.,:-'/()+=_"
+	for _, char := range chars {
+		if _, ok := Standard.glyphs[char]; !ok {
+			t.Errorf("Standard has no glyph for %q", char)
+		}
+	}
+	if Standard.Height != 5 {
+		t.Errorf("Standard.Height = %d, want 5", Standard.Height)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{
+			name: "letters one cell apart",
+			text: "HI",
+			expected: []string{
+				"#   # ###",
+				"#   #  # ",
+				"#####  # ",
+				"#   #  # ",
+				"#   # ###",
+			},
+		},
+		{
+			name: "lowercase as capitals",
+			text: "hi",
+			expected: []string{
+				"#   # ###",
+				"#   #  # ",
+				"#####  # ",
+				"#   #  # ",
+				"#   # ###",
+			},
+		},
+		{
+			name: "unknown as question mark",
+			text: "~",
+			expected: []string{
+				"### ",
+				"   #",
+				" ## ",
+				"    ",
+				" #  ",
+			},
+		},
+		{
+			name:     "nothing",
+			text:     "",
+			expected: []string{"", "", "", "", ""},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := Standard.Render(test.text, '#')
+			if strings.Join(result, "\n") != strings.Join(test.expected, "\n") {
+				t.Errorf("Render(%q) =\n%s\nwant\n%s", test.text, strings.Join(result, "\n"), strings.Join(test.expected, "\n"))
+			}
+		})
+	}
+}
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"", 0},
+		{"I", 3},
+		{"HI", 9},
+		{"A B", 15},
+	}
+
+	for _, test := range tests {
+		if result := Standard.Width(test.text); result != test.expected {
+			t.Errorf("Width(%q) = %d, want %d", test.text, result, test.expected)
+		}
+	}
+}
+
+func TestDraw(t *testing.T) {
+	c := drawing.NewCanvas(6, 5)
+	c.Print(1, 3, "......", ansi.Style{})
+	Standard.Draw(c, 2, 1, "1", '█', ansi.Style{FG: 31})
+
+	rows := make([]string, c.Height())
+	for y := 1; y <= c.Height(); y++ {
+		for x := 1; x <= c.Width(); x++ {
+			rows[y-1] += string(c.Get(x, y).Char)
+		}
+	}
+	expected := "  █   \n ██   \n..█...\n  █   \n ███  "
+	if result := strings.Join(rows, "\n"); result != expected {
+		t.Errorf("Draw() =\n%s\nwant\n%s", result, expected)
+	}
+	if cell := c.Get(3, 1); cell.Style != (ansi.Style{FG: 31}) {
+		t.Errorf("Get(3, 1) style = %+v, want red", cell.Style)
+	}
+}
+
+func TestParseFontErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		font string
+	}{
+		{"rows before a name", "#.#\n"},
+		{"long name", "= AB\n#\n"},
+		{"other characters", "= A\n#x#\n"},
+		{"ragged rows", "= A\n##\n#\n"},
+		{"different heights", "= A\n#\n#\n= B\n#\n"},
+	}
+
+	for _, test := range tests {
+		if _, err := ParseFont(strings.NewReader(test.font)); err == nil {
+			t.Errorf("%s: ParseFont() succeeded, want an error", test.name)
+		}
+	}
+}
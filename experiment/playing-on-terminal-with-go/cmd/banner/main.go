@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/banner"
+)
+
+func run(args []string) (string, error) {
+	x, y, char, color, text, err := parseArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	if err := validateArgs(x, y, text); err != nil {
+		return "", err
+	}
+	runes := []rune(char)
+	if len(runes) != 1 {
+		return "", fmt.Errorf("char must be exactly one character, got %d", len(runes))
+	}
+
+	style := ansi.Style{FG: ansi.ColorCode(color)}
+	lines := banner.Standard.Render(text, runes[0])
+	result := ""
+	for i, line := range lines {
+		// without a position the banner prints where the cursor is
+		if x == 0 && y == 0 {
+			if i > 0 {
+				result += "\n"
+			}
+		} else {
+			result += ansi.MoveCursor(max(x, 1), max(y, 1)+i)
+		}
+		result += style.Apply(strings.TrimRight(line, " "))
+	}
+	return result, nil
+}
+
+func parseArgs(args []string) (int, int, string, string, string, error) {
+	fs := flag.NewFlagSet("banner", flag.ContinueOnError)
+	x := fs.Int("x", 0, "x coordinate of the top-left corner; 0 with y 0 prints in place")
+	y := fs.Int("y", 0, "y coordinate of the top-left corner")
+	char := fs.String("char", "█", "character the letters are made of")
+	color := fs.String("color", "", "color of the letters")
+
+	if err := fs.Parse(args); err != nil {
+		return 0, 0, "", "", "", err
+	}
+
+	return *x, *y, *char, *color, strings.Join(fs.Args(), " "), nil
+}
+
+func validateArgs(x, y int, text string) error {
+	if x < 0 || y < 0 {
+		return fmt.Errorf("x and y must be positive")
+	}
+	if text == "" {
+		return fmt.Errorf("usage: banner [flags] text")
+	}
+	return nil
+}
+
+func main() {
+	result, err := run(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(result)
+}
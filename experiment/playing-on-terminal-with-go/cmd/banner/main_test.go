@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "in place",
+			args:     []string{"--char=#", "I"},
+			expected: "###\n #\n #\n #\n###",
+			wantErr:  false,
+		},
+		{
+			name:     "words joined",
+			args:     []string{"--char=#", "-", "-"},
+			expected: "\n\n###     ###\n\n",
+			wantErr:  false,
+		},
+		{
+			name: "at a position in color",
+			args: []string{"--x=3", "--y=2", "--char=o", "--color=red", "I"},
+			expected: ansi.MoveCursor(3, 2) + ansi.Colorize("ooo", 31) +
+				ansi.MoveCursor(3, 3) + ansi.Colorize(" o", 31) +
+				ansi.MoveCursor(3, 4) + ansi.Colorize(" o", 31) +
+				ansi.MoveCursor(3, 5) + ansi.Colorize(" o", 31) +
+				ansi.MoveCursor(3, 6) + ansi.Colorize("ooo", 31),
+			wantErr: false,
+		},
+		{
+			name:     "no text",
+			args:     []string{"--char=#"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "negative position",
+			args:     []string{"--x=-1", "I"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "char too long",
+			args:     []string{"--char=##", "I"},
+			expected: "",
+			wantErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := run(test.args)
+			if (err != nil) != test.wantErr {
+				t.Errorf("run() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if result != test.expected {
+				t.Errorf("run() result = %q, expected %q", result, test.expected)
+			}
+		})
+	}
+}
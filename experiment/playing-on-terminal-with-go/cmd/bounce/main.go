@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/animate"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+)
+
+func run(args []string) error {
+	fps, frames, char, color, spritePath, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if err := validateArgs(fps, frames); err != nil {
+		return err
+	}
+	runes := []rune(char)
+	if len(runes) != 1 {
+		return fmt.Errorf("char must be exactly one character, got %d", len(runes))
+	}
+
+	ball, err := loadBall(spritePath, runes[0], ansi.Style{FG: ansi.ColorCode(color)})
+	if err != nil {
+		return err
+	}
+	return animate.Run(fps, func(frame int, c *drawing.Canvas) bool {
+		c.Clear()
+		// bouncing the top-left corner within this range keeps the whole
+		// sprite on screen
+		x, y := ballAt(frame, c.Width()-ball.Width+1, c.Height()-ball.Height+1)
+		c.DrawSprite(x, y, ball)
+		return frames == 0 || frame < frames-1
+	})
+}
+
+// the ball is the sprite at path, or a single char when there is none
+func loadBall(path string, char rune, style ansi.Style) (*drawing.Sprite, error) {
+	if path == "" {
+		return drawing.TextSprite(style, string(char)), nil
+	}
+	return drawing.LoadSprite(path)
+}
+
+// the ball moves one cell a frame on each axis, starting halfway down the
+// left edge so it does not just run along the diagonal
+func ballAt(frame, width, height int) (int, int) {
+	return bounce(frame, width), bounce(frame+height/2, height)
+}
+
+// walks n steps back and forth across 1..size
+func bounce(n, size int) int {
+	if size <= 1 {
+		return 1
+	}
+	period := 2 * (size - 1)
+	pos := n % period
+	if pos >= size {
+		pos = period - pos
+	}
+	return pos + 1
+}
+
+func parseArgs(args []string) (int, int, string, string, string, error) {
+	fs := flag.NewFlagSet("bounce", flag.ContinueOnError)
+	fps := fs.Int("fps", 30, "frames per second")
+	frames := fs.Int("frames", 0, "stop after this many frames; 0 runs until ctrl-C")
+	char := fs.String("char", "O", "character for the ball")
+	color := fs.String("color", "", "color of the ball")
+	sprite := fs.String("sprite", "", "sprite file to bounce instead of char")
+
+	if err := fs.Parse(args); err != nil {
+		return 0, 0, "", "", "", err
+	}
+
+	return *fps, *frames, *char, *color, *sprite, nil
+}
+
+func validateArgs(fps, frames int) error {
+	if fps <= 0 {
+		return fmt.Errorf("fps must be positive")
+	}
+	if frames < 0 {
+		return fmt.Errorf("frames must not be negative")
+	}
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+)
+
+func TestBounce(t *testing.T) {
+	size := 4
+	expected := []int{1, 2, 3, 4, 3, 2, 1, 2, 3, 4, 3}
+	for n, want := range expected {
+		if result := bounce(n, size); result != want {
+			t.Errorf("bounce(%d, %d) = %d, want %d", n, size, result, want)
+		}
+	}
+}
+
+func TestBounceTinyScreen(t *testing.T) {
+	for n := 0; n < 3; n++ {
+		if result := bounce(n, 1); result != 1 {
+			t.Errorf("bounce(%d, 1) = %d, want 1", n, result)
+		}
+	}
+}
+
+func TestBallAtStaysOnScreen(t *testing.T) {
+	width, height := 7, 5
+	for frame := 0; frame < 100; frame++ {
+		x, y := ballAt(frame, width, height)
+		if x < 1 || x > width || y < 1 || y > height {
+			t.Fatalf("ballAt(%d) = (%d, %d), off a %dx%d screen", frame, x, y, width, height)
+		}
+	}
+	if x, y := ballAt(0, width, height); x != 1 || y != 3 {
+		t.Errorf("ballAt(0) = (%d, %d), want (1, 3)", x, y)
+	}
+}
+
+func TestRunRejectsBadArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"zero fps", []string{"--fps=0"}},
+		{"negative frames", []string{"--frames=-1"}},
+		{"char too long", []string{"--char=OO"}},
+		{"unknown flag", []string{"--speed=2"}},
+		{"missing sprite", []string{"--sprite=testdata/missing.txt"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := run(test.args); err == nil {
+				t.Errorf("run(%v) error = nil, want an error", test.args)
+			}
+		})
+	}
+}
+
+func TestLoadBall(t *testing.T) {
+	ball, err := loadBall("", 'O', ansi.Style{FG: 31})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := drawing.NewCanvas(1, 1)
+	c.DrawSprite(1, 1, ball)
+	if cell := c.Get(1, 1); cell != (drawing.Cell{Char: 'O', Style: ansi.Style{FG: 31}}) {
+		t.Errorf("ball = %+v, want a red O", cell)
+	}
+
+	ball, err = loadBall("../../drawing/testdata/cat.txt", 'O', ansi.Style{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ball.Width != 7 || ball.Height != 3 {
+		t.Errorf("sprite ball is %dx%d, want the 7x3 cat", ball.Width, ball.Height)
+	}
+}
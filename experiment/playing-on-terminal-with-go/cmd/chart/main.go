@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/widgets"
+)
+
+// the scale bars and sparks take from green through red as values grow
+var heat = []widgets.Threshold{
+	{At: 0, Color: ansi.ColorCode("green")},
+	{At: 0.5, Color: ansi.ColorCode("yellow")},
+	{At: 0.8, Color: ansi.ColorCode("red")},
+}
+
+// readSeries reads "label value" lines, the value being the last field;
+// blank lines and # comments are skipped, so the Prometheus text format of
+// a /metrics page reads as is. Only labels containing match are kept.
+func readSeries(r io.Reader, match string) ([]string, []float64, error) {
+	var labels []string
+	var values []float64
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cut := strings.LastIndexAny(line, " \t")
+		label, field := strings.TrimSpace(line[:max(cut, 0)]), line[cut+1:]
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %q is not a number", n, field)
+		}
+		if strings.Contains(label, match) {
+			labels = append(labels, label)
+			values = append(values, value)
+		}
+	}
+	return labels, values, scanner.Err()
+}
+
+func run(args []string, r io.Reader, w io.Writer) error {
+	kind, size, format, match, plain, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if err := validateArgs(kind, size); err != nil {
+		return err
+	}
+
+	labels, values, err := readSeries(r, match)
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("no values to chart")
+	}
+	scale := heat
+	if plain {
+		scale = nil
+	}
+
+	if kind == "spark" {
+		_, err := fmt.Fprintln(w, widgets.Sparkline(values, scale))
+		return err
+	}
+	chart := widgets.NewBarChart(size)
+	chart.Labels, chart.Values, chart.Scale, chart.Format = labels, values, scale, format
+	out := chart.Horizontal()
+	if kind == "vbar" {
+		out = chart.Vertical()
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+func parseArgs(args []string) (string, int, string, string, bool, error) {
+	fs := flag.NewFlagSet("chart", flag.ContinueOnError)
+	kind := fs.String("kind", "bar", "bar, vbar or spark")
+	size := fs.Int("size", 40, "cells the largest bar takes")
+	format := fs.String("format", "%g", "how values are printed")
+	match := fs.String("match", "", "only chart labels containing this")
+	plain := fs.Bool("plain", false, "no colors")
+
+	if err := fs.Parse(args); err != nil {
+		return "", 0, "", "", false, err
+	}
+
+	return *kind, *size, *format, *match, *plain, nil
+}
+
+func validateArgs(kind string, size int) error {
+	if kind != "bar" && kind != "vbar" && kind != "spark" {
+		return fmt.Errorf("kind must be bar, vbar or spark, got %q", kind)
+	}
+	if size <= 0 {
+		return fmt.Errorf("size must be positive")
+	}
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+const metrics = `# HELP http_requests_total Requests served
+# TYPE http_requests_total counter
+http_requests_total{path="/users"} 10
+http_requests_total{path="/health"} 40
+
+go_goroutines 7
+`
+
+func TestReadSeries(t *testing.T) {
+	labels, values, err := readSeries(strings.NewReader(metrics), "http_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 2 || labels[1] != `http_requests_total{path="/health"}` || values[1] != 40 {
+		t.Errorf("readSeries() = %q %v, want the two request counters", labels, values)
+	}
+
+	if _, _, err := readSeries(strings.NewReader("cpu lots\n"), ""); err == nil {
+		t.Error("readSeries() of a non-number succeeded")
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "horizontal bars",
+			args:     []string{"--size=4", "--plain"},
+			input:    "a 2\nbb 4\n",
+			expected: "a  │██ 2\nbb │████ 4\n",
+		},
+		{
+			name:     "vertical bars",
+			args:     []string{"--kind=vbar", "--size=2", "--plain"},
+			input:    "a 2\nb 4\n",
+			expected: "4 ┤   █\n  │ █ █\n0 └────\n    a b\n",
+		},
+		{
+			name:     "sparkline in color",
+			args:     []string{"--kind=spark"},
+			input:    "1\n8\n",
+			expected: ansi.Colorize("▁", 32) + ansi.Colorize("█", 31) + "\n",
+		},
+		{
+			name:     "metrics matched by label",
+			args:     []string{"--size=4", "--plain", "--match=go_"},
+			input:    metrics,
+			expected: "go_goroutines │████ 7\n",
+		},
+		{
+			name:    "nothing matched",
+			args:    []string{"--match=nope"},
+			input:   metrics,
+			wantErr: true,
+		},
+		{
+			name:    "unknown kind",
+			args:    []string{"--kind=pie"},
+			input:   "a 1\n",
+			wantErr: true,
+		},
+		{
+			name:    "zero size",
+			args:    []string{"--size=0"},
+			input:   "a 1\n",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			err := run(test.args, strings.NewReader(test.input), &out)
+			if (err != nil) != test.wantErr {
+				t.Errorf("run() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if out.String() != test.expected {
+				t.Errorf("run() output = %q, expected %q", out.String(), test.expected)
+			}
+		})
+	}
+}
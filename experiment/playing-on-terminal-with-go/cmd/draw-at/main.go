@@ -4,15 +4,21 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
 )
 
 func run(args []string) (string, error) {
-	x, y, char, color, err := parseArgs(args)
+	x, y, char, style, overflow, listColors, err := parseArgs(args)
 	if err != nil {
 		return "", err
 	}
+	if listColors {
+		return colorList(), nil
+	}
 
 	if err := validateArgs(x, y); err != nil {
 		return "", err
@@ -22,26 +28,55 @@ func run(args []string) (string, error) {
 		return "", fmt.Errorf("char must be exactly one character, got %d", len(runes))
 	}
 
-	colorCode, err := colorNameToCode(color)
+	points, err := drawing.FitTerminal([][2]int{{x, y}}, overflow)
 	if err != nil {
 		return "", err
 	}
-	result := ansi.PrintAtCoordinatesWithColor(x, y, runes[0], colorCode)
+	result := ansi.PrintAtCoordinatesWithStyle(points[0][0], points[0][1], runes[0], style)
 	return result, nil
 }
 
-func parseArgs(args []string) (int, int, string, string, error) {
+func parseArgs(args []string) (int, int, string, ansi.Style, drawing.Overflow, bool, error) {
 	fs := flag.NewFlagSet("draw-at", flag.ContinueOnError)
 	x := fs.Int("x", 0, "x coordinate")
 	y := fs.Int("y", 0, "y coordinate")
 	char := fs.String("char", "", "character to print")
-	color := fs.String("color", "", "color to print")
+	color := fs.String("color", "", "color to print, by name or code")
+	bg := fs.String("bg", "", "background color, by name or code")
+	bold := fs.Bool("bold", false, "bold text")
+	dim := fs.Bool("dim", false, "dim text")
+	italic := fs.Bool("italic", false, "italic text")
+	underline := fs.Bool("underline", false, "underlined text")
+	reverse := fs.Bool("reverse", false, "swap foreground and background")
+	overflowName := fs.String("overflow", "ignore", "off-screen coordinates: ignore, clamp or error")
+	listColors := fs.Bool("list-colors", false, "print the color names and codes, then exit")
 
 	if err := fs.Parse(args); err != nil {
-		return 0, 0, "", "", err
+		return 0, 0, "", ansi.Style{}, drawing.Ignore, false, err
 	}
 
-	return *x, *y, *char, *color, nil
+	fg, err := colorNameToCode(*color)
+	if err != nil {
+		return 0, 0, "", ansi.Style{}, drawing.Ignore, false, err
+	}
+	bgCode, err := parseColor(*bg, 10)
+	if err != nil {
+		return 0, 0, "", ansi.Style{}, drawing.Ignore, false, err
+	}
+	overflow, ok := drawing.ParseOverflow(*overflowName)
+	if !ok {
+		return 0, 0, "", ansi.Style{}, drawing.Ignore, false, fmt.Errorf("overflow must be ignore, clamp or error, got %q", *overflowName)
+	}
+	style := ansi.Style{
+		FG:        fg,
+		BG:        bgCode,
+		Bold:      *bold,
+		Dim:       *dim,
+		Italic:    *italic,
+		Underline: *underline,
+		Reverse:   *reverse,
+	}
+	return *x, *y, *char, style, overflow, *listColors, nil
 }
 
 func validateArgs(x, y int) error {
@@ -52,21 +87,35 @@ func validateArgs(x, y int) error {
 }
 
 func colorNameToCode(colorName string) (int, error) {
-	// using map to store the color name and code
-	colorMap := map[string]int{
-		"red":     31,
-		"green":   32,
-		"yellow":  33,
-		"blue":    34,
-		"magenta": 35,
-		"cyan":    36,
-		"white":   37,
-	}
+	return parseColor(colorName, 0)
+}
 
-	if code, ok := colorMap[colorName]; ok {
+// takes a name from ansi.ColorNames or a code as is; offset is 0 for
+// foreground codes and 10 for background ones, which sit above them. An
+// empty name means no color.
+func parseColor(name string, offset int) (int, error) {
+	if name == "" {
+		return 0, nil
+	}
+	if code := ansi.ColorCode(name); code != 0 {
+		return code + offset, nil
+	}
+	standard, bright := 30+offset, 90+offset
+	if code, err := strconv.Atoi(name); err == nil && (code >= standard && code <= standard+7 || code >= bright && code <= bright+7) {
 		return code, nil
 	}
-	return 0, nil
+	return 0, fmt.Errorf("unknown color %q; use a code %d-%d or %d-%d, or one of: %s",
+		name, standard, standard+7, bright, bright+7, strings.Join(ansi.ColorNames, ", "))
+}
+
+// one line per color, each name shown in its own color
+func colorList() string {
+	list := ""
+	for _, name := range ansi.ColorNames {
+		code := ansi.ColorCode(name)
+		list += fmt.Sprintf("%3d %s\n", code, ansi.Colorize(name, code))
+	}
+	return strings.TrimSuffix(list, "\n")
 }
 
 func main() {
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
@@ -27,6 +29,72 @@ func TestRun(t *testing.T) {
 			expected: ansi.ESC + "[10;5H" + ansi.ESC + "[31mX" + ansi.ESC + "[0m",
 			wantErr:  false,
 		},
+		{
+			name:     "print at coordinates with background",
+			args:     []string{"--x=5", "--y=10", "--char=X", "--bg=blue"},
+			expected: ansi.ESC + "[10;5H" + ansi.ESC + "[44mX" + ansi.ESC + "[0m",
+			wantErr:  false,
+		},
+		{
+			name:     "print at coordinates with style",
+			args:     []string{"--x=5", "--y=10", "--char=X", "--color=yellow", "--bg=black", "--bold", "--underline"},
+			expected: ansi.ESC + "[10;5H" + ansi.ESC + "[1;4;33;40mX" + ansi.ESC + "[0m",
+			wantErr:  false,
+		},
+		{
+			name:     "print at coordinates reversed and dim",
+			args:     []string{"--x=5", "--y=10", "--char=X", "--dim", "--italic", "--reverse"},
+			expected: ansi.ESC + "[10;5H" + ansi.ESC + "[2;3;7mX" + ansi.ESC + "[0m",
+			wantErr:  false,
+		},
+		{
+			name:     "print at coordinates in bright colors",
+			args:     []string{"--x=5", "--y=10", "--char=X", "--color=bright-red", "--bg=bright-blue"},
+			expected: ansi.ESC + "[10;5H" + ansi.ESC + "[91;104mX" + ansi.ESC + "[0m",
+			wantErr:  false,
+		},
+		{
+			name:     "print at coordinates with numeric colors",
+			args:     []string{"--x=5", "--y=10", "--char=X", "--color=36", "--bg=100"},
+			expected: ansi.ESC + "[10;5H" + ansi.ESC + "[36;100mX" + ansi.ESC + "[0m",
+			wantErr:  false,
+		},
+		{
+			name:     "unknown color",
+			args:     []string{"--x=5", "--y=10", "--char=X", "--color=purple"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown background",
+			args:     []string{"--x=5", "--y=10", "--char=X", "--bg=31"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "clamped to the screen",
+			args:     []string{"--x=100", "--y=10", "--char=X", "--overflow=clamp"},
+			expected: ansi.ESC + "[10;80HX",
+			wantErr:  false,
+		},
+		{
+			name:     "off screen is an error",
+			args:     []string{"--x=100", "--y=10", "--char=X", "--overflow=error"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "off screen is ignored by default",
+			args:     []string{"--x=100", "--y=10", "--char=X"},
+			expected: ansi.ESC + "[10;100HX",
+			wantErr:  false,
+		},
+		{
+			name:     "unknown overflow",
+			args:     []string{"--x=5", "--y=10", "--char=X", "--overflow=wrap"},
+			expected: "",
+			wantErr:  true,
+		},
 		{
 			name:     "error case",
 			args:     []string{"--x=-1", "--y=10", "--char=X"},
@@ -35,6 +103,7 @@ func TestRun(t *testing.T) {
 		},
 	}
 
+	screen80x24(t)
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			result, err := run(test.args)
@@ -47,3 +116,69 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestColorNameToCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int
+		wantErr  bool
+	}{
+		{"", 0, false},
+		{"red", 31, false},
+		{"bright-white", 97, false},
+		{"33", 33, false},
+		{"94", 94, false},
+		{"purple", 0, true},
+		{"29", 0, true},
+		{"44", 0, true},
+		{"Red", 0, true},
+	}
+
+	for _, test := range tests {
+		result, err := colorNameToCode(test.name)
+		if (err != nil) != test.wantErr {
+			t.Errorf("colorNameToCode(%q) error = %v, wantErr %v", test.name, err, test.wantErr)
+		}
+		if result != test.expected {
+			t.Errorf("colorNameToCode(%q) = %d, want %d", test.name, result, test.expected)
+		}
+	}
+
+	_, err := colorNameToCode("purple")
+	if err == nil || !strings.Contains(err.Error(), "bright-magenta") {
+		t.Errorf("error %v does not list the valid names", err)
+	}
+}
+
+func TestListColors(t *testing.T) {
+	result, err := run([]string{"--list-colors"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(result, "\n")
+	if len(lines) != len(ansi.ColorNames) {
+		t.Fatalf("listed %d colors, want %d", len(lines), len(ansi.ColorNames))
+	}
+	if expected := " 91 " + ansi.Colorize("bright-red", 91); lines[9] != expected {
+		t.Errorf("line 10 = %q, want %q", lines[9], expected)
+	}
+}
+
+// screen80x24 makes the terminal size 80x24 whether or not the test runs in
+// a terminal: stdout becomes a pipe, so the size comes from COLUMNS and LINES
+func screen80x24(t *testing.T) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		w.Close()
+		r.Close()
+	})
+	t.Setenv("COLUMNS", "80")
+	t.Setenv("LINES", "24")
+}
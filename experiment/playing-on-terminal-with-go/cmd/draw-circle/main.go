@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+)
+
+func run(args []string) (string, error) {
+	x, y, r, char, color, overflow, err := parseArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	if err := validateArgs(x, y, r); err != nil {
+		return "", err
+	}
+	runes := []rune(char)
+	if len(runes) != 1 {
+		return "", fmt.Errorf("char must be exactly one character, got %d", len(runes))
+	}
+
+	points, err := drawing.FitTerminal(drawing.CirclePoints(x, y, r), overflow)
+	if err != nil {
+		return "", err
+	}
+	result := drawing.DrawPoints(points, runes[0])
+	if colorCode := ansi.ColorCode(color); colorCode != 0 {
+		result = ansi.Colorize(result, colorCode)
+	}
+	return result, nil
+}
+
+func parseArgs(args []string) (int, int, int, string, string, drawing.Overflow, error) {
+	fs := flag.NewFlagSet("draw-circle", flag.ContinueOnError)
+	x := fs.Int("x", 0, "x coordinate of the center")
+	y := fs.Int("y", 0, "y coordinate of the center")
+	r := fs.Int("r", 0, "radius")
+	char := fs.String("char", "", "character to draw with")
+	color := fs.String("color", "", "color to draw with")
+	overflowName := fs.String("overflow", "ignore", "off-screen cells: ignore, clamp or error")
+
+	if err := fs.Parse(args); err != nil {
+		return 0, 0, 0, "", "", drawing.Ignore, err
+	}
+
+	overflow, ok := drawing.ParseOverflow(*overflowName)
+	if !ok {
+		return 0, 0, 0, "", "", drawing.Ignore, fmt.Errorf("overflow must be ignore, clamp or error, got %q", *overflowName)
+	}
+	return *x, *y, *r, *char, *color, overflow, nil
+}
+
+func validateArgs(x, y, r int) error {
+	if x < 0 || y < 0 {
+		return fmt.Errorf("x and y must be positive")
+	}
+	if r < 0 {
+		return fmt.Errorf("r must be positive")
+	}
+	return nil
+}
+
+func main() {
+	result, err := run(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(result)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+func TestRun(t *testing.T) {
+	circle := ansi.ESC + "[3;4Ho" + ansi.ESC + "[4;3Ho" + ansi.ESC + "[3;2Ho" + ansi.ESC + "[2;3Ho"
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "draw circle",
+			args:     []string{"--x=3", "--y=3", "--r=1", "--char=o"},
+			expected: circle,
+			wantErr:  false,
+		},
+		{
+			name:     "draw circle with color",
+			args:     []string{"--x=3", "--y=3", "--r=1", "--char=o", "--color=red"},
+			expected: ansi.ESC + "[31m" + circle + ansi.ESC + "[0m",
+			wantErr:  false,
+		},
+		{
+			name:     "clamped to the screen",
+			args:     []string{"--x=80", "--y=3", "--r=1", "--char=o", "--overflow=clamp"},
+			expected: ansi.ESC + "[3;80Ho" + ansi.ESC + "[4;80Ho" + ansi.ESC + "[3;79Ho" + ansi.ESC + "[2;80Ho",
+			wantErr:  false,
+		},
+		{
+			name:     "off screen is an error",
+			args:     []string{"--x=80", "--y=3", "--r=1", "--char=o", "--overflow=error"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "on screen with error overflow",
+			args:     []string{"--x=3", "--y=3", "--r=1", "--char=o", "--overflow=error"},
+			expected: circle,
+			wantErr:  false,
+		},
+		{
+			name:     "negative center",
+			args:     []string{"--x=-1", "--y=3", "--r=1", "--char=o"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "negative radius",
+			args:     []string{"--x=3", "--y=3", "--r=-1", "--char=o"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "char too long",
+			args:     []string{"--x=3", "--y=3", "--r=1", "--char=oo"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown flag",
+			args:     []string{"--z=3"},
+			expected: "",
+			wantErr:  true,
+		},
+	}
+
+	screen80x24(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := run(test.args)
+			if (err != nil) != test.wantErr {
+				t.Errorf("run() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if result != test.expected {
+				t.Errorf("run() result = %v, expected %v", result, test.expected)
+			}
+		})
+	}
+}
+
+// same as in draw-at: with stdout piped, COLUMNS and LINES decide the size
+func screen80x24(t *testing.T) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		w.Close()
+		r.Close()
+	})
+	t.Setenv("COLUMNS", "80")
+	t.Setenv("LINES", "24")
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+)
+
+func run(args []string) (string, error) {
+	from, to, char, color, overflow, err := parseArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	if err := validateArgs(from, to); err != nil {
+		return "", err
+	}
+	runes := []rune(char)
+	if len(runes) != 1 {
+		return "", fmt.Errorf("char must be exactly one character, got %d", len(runes))
+	}
+
+	// the same points drawing.DrawLine draws, fitted to the screen first
+	points, err := drawing.FitTerminal(drawing.LinePoints(from[0], from[1], to[0], to[1]), overflow)
+	if err != nil {
+		return "", err
+	}
+	result := drawing.DrawPoints(points, runes[0])
+	if colorCode := ansi.ColorCode(color); colorCode != 0 {
+		result = ansi.Colorize(result, colorCode)
+	}
+	return result, nil
+}
+
+func parseArgs(args []string) ([2]int, [2]int, string, string, drawing.Overflow, error) {
+	fs := flag.NewFlagSet("draw-line", flag.ContinueOnError)
+	from := fs.String("from", "", "start of the line as x,y")
+	to := fs.String("to", "", "end of the line as x,y")
+	char := fs.String("char", "", "character to draw with")
+	color := fs.String("color", "", "color to draw with")
+	overflowName := fs.String("overflow", "ignore", "off-screen cells: ignore, clamp or error")
+
+	if err := fs.Parse(args); err != nil {
+		return [2]int{}, [2]int{}, "", "", drawing.Ignore, err
+	}
+
+	start, err := parsePoint("from", *from)
+	if err != nil {
+		return [2]int{}, [2]int{}, "", "", drawing.Ignore, err
+	}
+	end, err := parsePoint("to", *to)
+	if err != nil {
+		return [2]int{}, [2]int{}, "", "", drawing.Ignore, err
+	}
+	overflow, ok := drawing.ParseOverflow(*overflowName)
+	if !ok {
+		return [2]int{}, [2]int{}, "", "", drawing.Ignore, fmt.Errorf("overflow must be ignore, clamp or error, got %q", *overflowName)
+	}
+	return start, end, *char, *color, overflow, nil
+}
+
+// reads "x,y"; name is the flag it came from, for the error
+func parsePoint(name, value string) ([2]int, error) {
+	xs, ys, ok := strings.Cut(value, ",")
+	x, errX := strconv.Atoi(strings.TrimSpace(xs))
+	y, errY := strconv.Atoi(strings.TrimSpace(ys))
+	if !ok || errX != nil || errY != nil {
+		return [2]int{}, fmt.Errorf("%s must be x,y, got %q", name, value)
+	}
+	return [2]int{x, y}, nil
+}
+
+func validateArgs(from, to [2]int) error {
+	if from[0] < 0 || from[1] < 0 || to[0] < 0 || to[1] < 0 {
+		return fmt.Errorf("x and y must be positive")
+	}
+	return nil
+}
+
+func main() {
+	result, err := run(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(result)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+)
+
+func TestRun(t *testing.T) {
+	line := ansi.ESC + "[2;1H-" + ansi.ESC + "[2;2H-" + ansi.ESC + "[2;3H-"
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "draw line",
+			args:     []string{"--from=1,2", "--to=3,2", "--char=-"},
+			expected: line,
+			wantErr:  false,
+		},
+		{
+			name:     "draw line with color",
+			args:     []string{"--from=1,2", "--to=3,2", "--char=-", "--color=green"},
+			expected: ansi.ESC + "[32m" + line + ansi.ESC + "[0m",
+			wantErr:  false,
+		},
+		{
+			name:     "shallow slope",
+			args:     []string{"--from=1,1", "--to=5,3", "--char=*"},
+			expected: ansi.ESC + "[1;1H*" + ansi.ESC + "[2;2H*" + ansi.ESC + "[2;3H*" + ansi.ESC + "[3;4H*" + ansi.ESC + "[3;5H*",
+			wantErr:  false,
+		},
+		{
+			name:     "steep slope backwards",
+			args:     []string{"--from=7, 9", "--to=2,1", "--char=#"},
+			expected: drawing.DrawLine(7, 9, 2, 1, '#'),
+			wantErr:  false,
+		},
+		{
+			name:     "single point",
+			args:     []string{"--from=4,4", "--to=4,4", "--char=o"},
+			expected: ansi.ESC + "[4;4Ho",
+			wantErr:  false,
+		},
+		{
+			name:     "clamped to the screen",
+			args:     []string{"--from=79,1", "--to=82,1", "--char=-", "--overflow=clamp"},
+			expected: ansi.ESC + "[1;79H-" + ansi.ESC + "[1;80H-",
+			wantErr:  false,
+		},
+		{
+			name:     "off screen is an error",
+			args:     []string{"--from=79,1", "--to=82,1", "--char=-", "--overflow=error"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "missing to",
+			args:     []string{"--from=1,1", "--char=-"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "malformed point",
+			args:     []string{"--from=1;1", "--to=3,1", "--char=-"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "negative point",
+			args:     []string{"--from=-1,1", "--to=3,1", "--char=-"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "char too long",
+			args:     []string{"--from=1,1", "--to=3,1", "--char=--"},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown overflow",
+			args:     []string{"--from=1,1", "--to=3,1", "--char=-", "--overflow=wrap"},
+			expected: "",
+			wantErr:  true,
+		},
+	}
+
+	screen80x24(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := run(test.args)
+			if (err != nil) != test.wantErr {
+				t.Errorf("run() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if result != test.expected {
+				t.Errorf("run() result = %q, expected %q", result, test.expected)
+			}
+		})
+	}
+}
+
+// as in draw-at and draw-circle, piping stdout makes COLUMNS and LINES count
+func screen80x24(t *testing.T) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		w.Close()
+		r.Close()
+	})
+	t.Setenv("COLUMNS", "80")
+	t.Setenv("LINES", "24")
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/animate"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/terminal"
+)
+
+const fps = 60
+
+const maxSpeed = fps // one generation a frame is as fast as the screen shows
+
+const help = "space pause, n step, +/- speed, r reseed, q quit"
+
+// session runs a world from keys and frames. Speed is generations a second:
+// each frame adds speed to a budget and every fps of it buys a generation,
+// so any speed up to one per frame keeps steady time.
+type session struct {
+	w          *world
+	generation int
+	speed      int
+	budget     int
+	paused     bool
+	keys       <-chan terminal.Key
+	reseed     func() *world
+}
+
+func (s *session) update(frame int, c *drawing.Canvas) bool {
+	for drained := false; !drained; {
+		select {
+		case key, ok := <-s.keys:
+			if !ok || !s.handle(key) {
+				return false
+			}
+		default:
+			drained = true
+		}
+	}
+
+	if !s.paused {
+		for s.budget += s.speed; s.budget >= fps; s.budget -= fps {
+			s.advance()
+		}
+	}
+	s.draw(c)
+	return true
+}
+
+func (s *session) advance() {
+	s.w = s.w.next()
+	s.generation++
+}
+
+// applies one key and reports whether to keep going
+func (s *session) handle(key terminal.Key) bool {
+	switch key.Kind {
+	case terminal.KeyEscape:
+		return false
+	case terminal.KeyCtrl:
+		return key.Rune != 'c'
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'q':
+			return false
+		case ' ':
+			s.paused = !s.paused
+			s.budget = 0
+		case 'n':
+			if s.paused {
+				s.advance()
+			}
+		case '+', '=':
+			s.speed = min(s.speed*2, maxSpeed)
+		case '-':
+			s.speed = max(s.speed/2, 1)
+		case 'r':
+			s.w = s.reseed()
+			s.generation = 0
+		}
+	}
+	return true
+}
+
+// the world fills the canvas above a status line. Every cell is set each
+// frame; the canvas works out which of them actually changed.
+func (s *session) draw(c *drawing.Canvas) {
+	c.Clear()
+	cell := ansi.Style{FG: ansi.ColorCode("green")}
+	for y := 0; y < s.w.height; y++ {
+		for x := 0; x < s.w.width; x++ {
+			if s.w.alive(x, y) {
+				c.Set(x+1, y+1, '█', cell)
+			}
+		}
+	}
+
+	status := fmt.Sprintf(" gen %d  pop %d  %d/s", s.generation, s.w.population(), s.speed)
+	if s.paused {
+		status += "  paused"
+	}
+	c.Print(1, c.Height(), status+"  "+help, ansi.Style{Reverse: true})
+}
+
+func readKeys(keys *terminal.KeyReader) <-chan terminal.Key {
+	out := make(chan terminal.Key, 16)
+	go func() {
+		defer close(out)
+		for {
+			key, err := keys.ReadKey()
+			if err != nil {
+				return
+			}
+			out <- key
+		}
+	}()
+	return out
+}
+
+func run(args []string) error {
+	pattern, speed, density, seedValue, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+	if err := validateArgs(speed, density); err != nil {
+		return err
+	}
+
+	size, err := terminal.Size()
+	if err != nil {
+		return err
+	}
+	if size.Height < 2 {
+		return fmt.Errorf("terminal too small: need at least 2 rows, got %d", size.Height)
+	}
+	rng := rand.New(rand.NewSource(seedValue))
+	first, err := seed(size.Width, size.Height-1, pattern, density, rng)
+	if err != nil {
+		return err
+	}
+	// the pattern fitted once, so seeding it again at this size cannot fail
+	reseed := func() *world {
+		w, _ := seed(size.Width, size.Height-1, pattern, density, rng)
+		return w
+	}
+
+	restore, err := terminal.EnableRaw(os.Stdin.Fd())
+	if err != nil {
+		return fmt.Errorf("life needs a terminal: %w", err)
+	}
+	defer restore()
+
+	s := &session{w: first, speed: speed, keys: readKeys(terminal.NewKeyReader(os.Stdin)), reseed: reseed}
+	return animate.Run(fps, s.update)
+}
+
+func parseArgs(args []string) (string, int, float64, int64, error) {
+	fs := flag.NewFlagSet("life", flag.ContinueOnError)
+	pattern := fs.String("pattern", "random", "starting pattern: "+patternNames())
+	speed := fs.Int("speed", 10, "generations per second")
+	density := fs.Float64("density", 0.25, "share of cells alive in a random start")
+	seedValue := fs.Int64("seed", 0, "seed for a random start; 0 picks one from the clock")
+
+	if err := fs.Parse(args); err != nil {
+		return "", 0, 0, 0, err
+	}
+	if *seedValue == 0 {
+		*seedValue = time.Now().UnixNano()
+	}
+	return *pattern, *speed, *density, *seedValue, nil
+}
+
+func validateArgs(speed int, density float64) error {
+	if speed < 1 || speed > maxSpeed {
+		return fmt.Errorf("speed must be between 1 and %d", maxSpeed)
+	}
+	if density < 0 || density > 1 {
+		return fmt.Errorf("density must be between 0 and 1")
+	}
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/terminal"
+)
+
+func testSession(speed int, keys ...terminal.Key) *session {
+	in := make(chan terminal.Key, len(keys))
+	for _, key := range keys {
+		in <- key
+	}
+	reseed := func() *world { w, _ := seed(5, 5, "blinker", 0, nil); return w }
+	return &session{w: reseed(), speed: speed, keys: in, reseed: reseed}
+}
+
+func char(c rune) terminal.Key {
+	return terminal.Key{Kind: terminal.KeyRune, Rune: c}
+}
+
+func TestSessionSpeed(t *testing.T) {
+	tests := []struct {
+		speed    int
+		frames   int
+		expected int
+	}{
+		{1, 59, 0},
+		{1, 60, 1},
+		{10, 60, 10},
+		{60, 30, 30},
+		{7, 120, 14},
+	}
+
+	for _, test := range tests {
+		s := testSession(test.speed)
+		c := drawing.NewCanvas(5, 6)
+		for frame := 1; frame <= test.frames; frame++ {
+			s.update(frame, c)
+		}
+		if s.generation != test.expected {
+			t.Errorf("%d/s for %d frames: generation = %d, want %d", test.speed, test.frames, s.generation, test.expected)
+		}
+	}
+}
+
+func TestSessionPauseAndStep(t *testing.T) {
+	s := testSession(60, char(' '))
+	c := drawing.NewCanvas(5, 6)
+	s.update(1, c)
+	if !s.paused || s.generation != 0 {
+		t.Fatalf("paused = %v, generation = %d; want paused at 0", s.paused, s.generation)
+	}
+
+	s = testSession(60, char(' '), char('n'), char('n'))
+	s.update(1, c)
+	if s.generation != 2 {
+		t.Errorf("generation after two steps = %d, want 2", s.generation)
+	}
+
+	s = testSession(60, char('n'))
+	s.update(1, c)
+	if s.generation != 1 {
+		t.Errorf("n while running stepped an extra generation: %d", s.generation)
+	}
+}
+
+func TestSessionChangeSpeed(t *testing.T) {
+	s := testSession(10, char('+'), char('+'), char('+'))
+	s.update(1, drawing.NewCanvas(5, 6))
+	if s.speed != 60 {
+		t.Errorf("speed = %d, want capped at 60", s.speed)
+	}
+
+	s = testSession(10, char('-'), char('-'), char('-'), char('-'), char('-'))
+	s.update(1, drawing.NewCanvas(5, 6))
+	if s.speed != 1 {
+		t.Errorf("speed = %d, want floored at 1", s.speed)
+	}
+}
+
+func TestSessionReseed(t *testing.T) {
+	s := testSession(60)
+	c := drawing.NewCanvas(5, 6)
+	s.update(1, c)
+	s.keys = testSession(60, char('r')).keys
+	s.update(2, c)
+	// the reseed lands first, then this frame's generation
+	if s.generation != 1 {
+		t.Errorf("generation after reseed = %d, want 1", s.generation)
+	}
+}
+
+func TestSessionQuits(t *testing.T) {
+	for _, key := range []terminal.Key{char('q'), {Kind: terminal.KeyEscape}, {Kind: terminal.KeyCtrl, Rune: 'c'}} {
+		if testSession(1, key).update(1, drawing.NewCanvas(5, 6)) {
+			t.Errorf("update() after %+v = true, want false", key)
+		}
+	}
+}
+
+func TestSessionDraw(t *testing.T) {
+	s := testSession(1, char(' '))
+	c := drawing.NewCanvas(5, 6)
+	s.update(1, c)
+
+	for x := 1; x <= 5; x++ {
+		expected := ' '
+		if x >= 2 && x <= 4 {
+			expected = '█'
+		}
+		if cell := c.Get(x, 3); cell.Char != expected {
+			t.Errorf("Get(%d, 3) = %q, want %q", x, cell.Char, expected)
+		}
+	}
+	status := ""
+	for x := 1; x <= 5; x++ {
+		status += string(c.Get(x, 6).Char)
+	}
+	if status != " gen " {
+		t.Errorf("last row = %q, want the status", status)
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	tests := []struct {
+		speed   int
+		density float64
+		wantErr bool
+	}{
+		{10, 0.25, false},
+		{60, 1, false},
+		{0, 0.25, true},
+		{61, 0.25, true},
+		{10, -0.1, true},
+		{10, 1.5, true},
+	}
+
+	for _, test := range tests {
+		err := validateArgs(test.speed, test.density)
+		if (err != nil) != test.wantErr {
+			t.Errorf("validateArgs(%d, %v) error = %v, wantErr %v", test.speed, test.density, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// world is a grid of cells whose edges wrap around, so gliders leaving one
+// side come back on the other instead of dying against a wall
+type world struct {
+	width, height int
+	cells         []bool
+}
+
+func newWorld(width, height int) *world {
+	return &world{width: width, height: height, cells: make([]bool, width*height)}
+}
+
+// x and y count from 0 and wrap in both directions
+func (w *world) alive(x, y int) bool {
+	x = (x%w.width + w.width) % w.width
+	y = (y%w.height + w.height) % w.height
+	return w.cells[y*w.width+x]
+}
+
+func (w *world) set(x, y int, alive bool) {
+	x = (x%w.width + w.width) % w.width
+	y = (y%w.height + w.height) % w.height
+	w.cells[y*w.width+x] = alive
+}
+
+func (w *world) neighbours(x, y int) int {
+	n := 0
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if (dx != 0 || dy != 0) && w.alive(x+dx, y+dy) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// a live cell survives with two or three neighbours; a dead one comes alive
+// with exactly three
+func (w *world) next() *world {
+	next := newWorld(w.width, w.height)
+	for y := 0; y < w.height; y++ {
+		for x := 0; x < w.width; x++ {
+			n := w.neighbours(x, y)
+			next.cells[y*w.width+x] = n == 3 || n == 2 && w.alive(x, y)
+		}
+	}
+	return next
+}
+
+func (w *world) population() int {
+	n := 0
+	for _, alive := range w.cells {
+		if alive {
+			n++
+		}
+	}
+	return n
+}
+
+// patterns are drawn with O for a live cell and anything else for a dead one
+var patterns = map[string][]string{
+	"blinker": {"OOO"},
+	"glider": {
+		".O.",
+		"..O",
+		"OOO",
+	},
+	"lwss": {
+		".O..O",
+		"O....",
+		"O...O",
+		"OOOO.",
+	},
+	"pulsar": {
+		"..OOO...OOO..",
+		".............",
+		"O....O.O....O",
+		"O....O.O....O",
+		"O....O.O....O",
+		"..OOO...OOO..",
+		".............",
+		"..OOO...OOO..",
+		"O....O.O....O",
+		"O....O.O....O",
+		"O....O.O....O",
+		".............",
+		"..OOO...OOO..",
+	},
+	"gosper": {
+		"........................O...........",
+		"......................O.O...........",
+		"............OO......OO............OO",
+		"...........O...O....OO............OO",
+		"OO........O.....O...OO..............",
+		"OO........O...O.OO....O.O...........",
+		"..........O.....O.......O...........",
+		"...........O...O....................",
+		"............OO......................",
+	},
+	"rpentomino": {
+		".OO",
+		"OO.",
+		".O.",
+	},
+}
+
+func patternNames() string {
+	names := []string{"random"}
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return strings.Join(names, ", ")
+}
+
+// seed fills a fresh world: "random" makes each cell alive with the given
+// density, and a named pattern is placed in the middle
+func seed(width, height int, pattern string, density float64, rng *rand.Rand) (*world, error) {
+	w := newWorld(width, height)
+	if pattern == "random" {
+		for i := range w.cells {
+			w.cells[i] = rng.Float64() < density
+		}
+		return w, nil
+	}
+
+	rows, ok := patterns[pattern]
+	if !ok {
+		return nil, fmt.Errorf("unknown pattern %q; want one of %s", pattern, patternNames())
+	}
+	if len(rows) > height || len(rows[0]) > width {
+		return nil, fmt.Errorf("pattern %s needs %dx%d cells, got %dx%d", pattern, len(rows[0]), len(rows), width, height)
+	}
+	left, top := (width-len(rows[0]))/2, (height-len(rows))/2
+	for y, row := range rows {
+		for x, c := range row {
+			w.set(left+x, top+y, c == 'O')
+		}
+	}
+	return w, nil
+}
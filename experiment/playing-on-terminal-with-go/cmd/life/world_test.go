@@ -0,0 +1,146 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// parse builds a world from rows drawn like the patterns
+func parse(rows ...string) *world {
+	w := newWorld(len(rows[0]), len(rows))
+	for y, row := range rows {
+		for x, c := range row {
+			w.set(x, y, c == 'O')
+		}
+	}
+	return w
+}
+
+func (w *world) String() string {
+	var b strings.Builder
+	for y := 0; y < w.height; y++ {
+		for x := 0; x < w.width; x++ {
+			if w.alive(x, y) {
+				b.WriteByte('O')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func TestNextBlinkerOscillates(t *testing.T) {
+	w := parse(
+		".....",
+		"..O..",
+		"..O..",
+		"..O..",
+		".....",
+	)
+	expected := parse(
+		".....",
+		".....",
+		".OOO.",
+		".....",
+		".....",
+	)
+
+	if result := w.next(); result.String() != expected.String() {
+		t.Errorf("next() =\n%s\nwant\n%s", result, expected)
+	}
+	if result := w.next().next(); result.String() != w.String() {
+		t.Errorf("two steps =\n%s\nwant the start again\n%s", result, w)
+	}
+}
+
+func TestNextBlockIsStill(t *testing.T) {
+	w := parse(
+		"....",
+		".OO.",
+		".OO.",
+		"....",
+	)
+	if result := w.next(); result.String() != w.String() {
+		t.Errorf("next() =\n%s\nwant unchanged\n%s", result, w)
+	}
+}
+
+func TestNextLonelyCellsDie(t *testing.T) {
+	w := parse(
+		"O...",
+		"....",
+		"..O.",
+		"....",
+	)
+	if n := w.next().population(); n != 0 {
+		t.Errorf("population = %d, want 0", n)
+	}
+}
+
+func TestGliderWrapsAround(t *testing.T) {
+	w, err := seed(6, 6, "glider", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := w.String()
+
+	// a glider moves one cell diagonally every four generations, so after
+	// 4*6 it has crossed the whole wrapped 6x6 world back to where it began
+	for i := 0; i < 24; i++ {
+		w = w.next()
+		if n := w.population(); n != 5 {
+			t.Fatalf("generation %d: population = %d, want 5", i+1, n)
+		}
+	}
+	if w.String() != start {
+		t.Errorf("after 24 generations =\n%s\nwant the start\n%s", w, start)
+	}
+}
+
+func TestSeedPattern(t *testing.T) {
+	w, err := seed(5, 3, "blinker", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := ".....\n.OOO.\n.....\n"; w.String() != expected {
+		t.Errorf("seed() =\n%s\nwant\n%s", w, expected)
+	}
+}
+
+func TestSeedRandom(t *testing.T) {
+	a, _ := seed(40, 20, "random", 0.3, rand.New(rand.NewSource(7)))
+	b, _ := seed(40, 20, "random", 0.3, rand.New(rand.NewSource(7)))
+	if a.String() != b.String() {
+		t.Error("the same seed gave different worlds")
+	}
+	if n := a.population(); n < 160 || n > 320 {
+		t.Errorf("population = %d of 800 at density 0.3", n)
+	}
+
+	empty, _ := seed(10, 10, "random", 0, rand.New(rand.NewSource(7)))
+	if empty.population() != 0 {
+		t.Error("density 0 left cells alive")
+	}
+}
+
+func TestSeedErrors(t *testing.T) {
+	if _, err := seed(10, 10, "spaceship", 0, nil); err == nil || !strings.Contains(err.Error(), "glider") {
+		t.Errorf("unknown pattern error = %v, want one listing the patterns", err)
+	}
+	if _, err := seed(10, 10, "gosper", 0, nil); err == nil {
+		t.Error("gosper fitted a 10x10 world")
+	}
+}
+
+func TestPatternsAreRectangular(t *testing.T) {
+	for name, rows := range patterns {
+		for i, row := range rows {
+			if len(row) != len(rows[0]) {
+				t.Errorf("%s row %d is %d wide, want %d", name, i, len(row), len(rows[0]))
+			}
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/terminal"
+)
+
+// tab cycles through these; "default" is the terminal's own color
+var palette = []string{"default", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+const help = "arrows or mouse move, space or click stamps, type to pick a brush, tab or wheel color, backspace or right-click erase, ^F or middle-click fill, ^S save, esc quit"
+
+type painter struct {
+	art    *drawing.Canvas
+	x, y   int
+	brush  rune
+	color  int // index into palette
+	status string
+
+	// where the last press or drag landed, so a fast drag that skips cells
+	// still draws a solid line
+	dragX, dragY int
+}
+
+func newPainter(width, height int) *painter {
+	return &painter{art: drawing.NewCanvas(width, height), x: 1, y: 1, brush: '#', status: help}
+}
+
+func (p *painter) style() ansi.Style {
+	return ansi.Style{FG: ansi.ColorCode(palette[p.color])}
+}
+
+// applies one key and reports whether to keep going
+func (p *painter) handle(key terminal.Key, save func(text string) error) bool {
+	switch key.Kind {
+	case terminal.KeyUp:
+		p.move(0, -1)
+	case terminal.KeyDown:
+		p.move(0, 1)
+	case terminal.KeyLeft:
+		p.move(-1, 0)
+	case terminal.KeyRight:
+		p.move(1, 0)
+	case terminal.KeyRune:
+		if key.Rune != ' ' {
+			p.brush = key.Rune
+		}
+		p.art.Set(p.x, p.y, p.brush, p.style())
+	case terminal.KeyBackspace:
+		p.art.Set(p.x, p.y, ' ', ansi.Style{})
+	case terminal.KeyTab:
+		p.cycleColor(1)
+	case terminal.KeyMouse:
+		p.mouse(key.Mouse)
+	case terminal.KeyEscape:
+		return false
+	case terminal.KeyCtrl:
+		switch key.Rune {
+		case 'c':
+			return false
+		case 'f':
+			p.art.Fill(p.x, p.y, p.brush, p.style())
+		case 's':
+			if err := save(p.art.Text()); err != nil {
+				p.status = "save failed: " + err.Error()
+			} else {
+				p.status = "saved"
+			}
+		}
+	}
+	return true
+}
+
+func (p *painter) mouse(m terminal.Mouse) {
+	switch m.Action {
+	case terminal.MouseScrollUp:
+		p.cycleColor(-1)
+		return
+	case terminal.MouseScrollDown:
+		p.cycleColor(1)
+		return
+	case terminal.MouseRelease:
+		return
+	}
+
+	// the status line is not part of the art
+	b := terminal.Bounds{Width: p.art.Width(), Height: p.art.Height()}
+	if !b.Contains(m.X, m.Y) {
+		return
+	}
+	if m.Action == terminal.MousePress {
+		p.dragX, p.dragY = m.X, m.Y
+	}
+
+	switch m.Button {
+	case terminal.MouseLeft:
+		p.art.DrawLine(p.dragX, p.dragY, m.X, m.Y, p.brush, p.style())
+	case terminal.MouseRight:
+		p.art.DrawLine(p.dragX, p.dragY, m.X, m.Y, ' ', ansi.Style{})
+	case terminal.MouseMiddle:
+		if m.Action == terminal.MousePress {
+			p.art.Fill(m.X, m.Y, p.brush, p.style())
+		}
+	default:
+		return
+	}
+	p.x, p.y = m.X, m.Y
+	p.dragX, p.dragY = m.X, m.Y
+}
+
+func (p *painter) cycleColor(step int) {
+	p.color = (p.color + step + len(palette)) % len(palette)
+}
+
+func (p *painter) move(dx, dy int) {
+	b := terminal.Bounds{Width: p.art.Width(), Height: p.art.Height()}
+	p.x, p.y = b.Clamp(p.x+dx, p.y+dy)
+}
+
+// the art keeps what still fits; a window too short for the status line is
+// left alone until it grows again
+func (p *painter) resize(b terminal.Bounds, screen *drawing.Canvas) {
+	if b.Width < 1 || b.Height < 2 {
+		return
+	}
+	screen.Resize(b.Width, b.Height)
+	p.art.Resize(b.Width, b.Height-1)
+	p.move(0, 0)
+}
+
+// the art fills the screen above a status line
+func render(screen *drawing.Canvas, p *painter, w io.Writer) error {
+	for y := 1; y <= p.art.Height(); y++ {
+		for x := 1; x <= p.art.Width(); x++ {
+			cell := p.art.Get(x, y)
+			screen.Set(x, y, cell.Char, cell.Style)
+		}
+	}
+
+	status := []rune(fmt.Sprintf(" %c %-7s  %s", p.brush, palette[p.color], p.status))
+	bar := ansi.Style{Reverse: true}
+	for x := 1; x <= screen.Width(); x++ {
+		char := ' '
+		if x <= len(status) {
+			char = status[x-1]
+		}
+		screen.Set(x, screen.Height(), char, bar)
+	}
+
+	if err := screen.Flush(w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, ansi.MoveCursor(p.x, p.y))
+	return err
+}
+
+// one key off the terminal, or why there are no more
+type input struct {
+	key terminal.Key
+	err error
+}
+
+// reads keys in the background so a resize can redraw while paint waits
+// for one; the last input carries the error that stopped the reading
+func readKeys(keys *terminal.KeyReader) <-chan input {
+	in := make(chan input)
+	go func() {
+		for {
+			key, err := keys.ReadKey()
+			in <- input{key, err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return in
+}
+
+func loop(p *painter, in <-chan input, resized <-chan terminal.Bounds, screen *drawing.Canvas, w io.Writer, save func(string) error) error {
+	for {
+		if err := render(screen, p, w); err != nil {
+			return err
+		}
+
+		select {
+		case b := <-resized:
+			p.resize(b, screen)
+		case next := <-in:
+			if next.err == io.EOF {
+				return nil
+			}
+			if next.err != nil {
+				return next.err
+			}
+			if !p.handle(next.key, save) {
+				return nil
+			}
+		}
+	}
+}
+
+func run(args []string) error {
+	out, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	size, err := terminal.Size()
+	if err != nil {
+		return err
+	}
+	if size.Height < 2 {
+		return fmt.Errorf("terminal too small: need at least 2 rows, got %d", size.Height)
+	}
+
+	restore, err := terminal.EnableRaw(os.Stdin.Fd())
+	if err != nil {
+		return fmt.Errorf("paint needs a terminal: %w", err)
+	}
+	defer restore()
+	fmt.Print(ansi.EnterAltScreen() + ansi.ClearScreen() + ansi.EnableMouse())
+	defer fmt.Print(ansi.DisableMouse() + ansi.LeaveAltScreen())
+
+	p := newPainter(size.Width, size.Height-1)
+	p.status = "saving to " + out + "; " + help
+	save := func(text string) error { return os.WriteFile(out, []byte(text), 0o644) }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := readKeys(terminal.NewKeyReader(os.Stdin))
+	return loop(p, in, terminal.NotifyResize(ctx), drawing.NewCanvas(size.Width, size.Height), os.Stdout, save)
+}
+
+func parseArgs(args []string) (string, error) {
+	fs := flag.NewFlagSet("paint", flag.ContinueOnError)
+	out := fs.String("out", "paint.ans", "file ^S saves the drawing to; cmd/play shows it")
+
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if *out == "" {
+		return "", fmt.Errorf("out must not be empty")
+	}
+	return *out, nil
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/terminal"
+)
+
+func noSave(string) error { return nil }
+
+func TestPainterMoveStaysOnCanvas(t *testing.T) {
+	p := newPainter(3, 2)
+	tests := []struct {
+		key          terminal.KeyKind
+		wantX, wantY int
+	}{
+		{terminal.KeyLeft, 1, 1},
+		{terminal.KeyUp, 1, 1},
+		{terminal.KeyRight, 2, 1},
+		{terminal.KeyRight, 3, 1},
+		{terminal.KeyRight, 3, 1},
+		{terminal.KeyDown, 3, 2},
+		{terminal.KeyDown, 3, 2},
+	}
+
+	for _, test := range tests {
+		p.handle(terminal.Key{Kind: test.key}, noSave)
+		if p.x != test.wantX || p.y != test.wantY {
+			t.Errorf("after key %d at (%d, %d), want (%d, %d)", test.key, p.x, p.y, test.wantX, test.wantY)
+		}
+	}
+}
+
+func TestPainterStamp(t *testing.T) {
+	p := newPainter(3, 1)
+
+	p.handle(terminal.Key{Kind: terminal.KeyRune, Rune: ' '}, noSave)
+	if cell := p.art.Get(1, 1); cell.Char != '#' {
+		t.Errorf("space stamped %q, want the default brush #", cell.Char)
+	}
+
+	p.handle(terminal.Key{Kind: terminal.KeyRight}, noSave)
+	p.handle(terminal.Key{Kind: terminal.KeyRune, Rune: '*'}, noSave)
+	p.handle(terminal.Key{Kind: terminal.KeyRight}, noSave)
+	p.handle(terminal.Key{Kind: terminal.KeyRune, Rune: ' '}, noSave)
+	if result, expected := p.art.Text(), "#**\n"; result != expected {
+		t.Errorf("Text() = %q, want %q", result, expected)
+	}
+
+	p.handle(terminal.Key{Kind: terminal.KeyBackspace}, noSave)
+	if result, expected := p.art.Text(), "#*\n"; result != expected {
+		t.Errorf("Text() after erase = %q, want %q", result, expected)
+	}
+}
+
+func TestPainterColor(t *testing.T) {
+	p := newPainter(2, 1)
+	p.handle(terminal.Key{Kind: terminal.KeyTab}, noSave)
+	p.handle(terminal.Key{Kind: terminal.KeyRune, Rune: 'x'}, noSave)
+
+	expected := drawing.Cell{Char: 'x', Style: ansi.Style{FG: 31}}
+	if cell := p.art.Get(1, 1); cell != expected {
+		t.Errorf("Get(1, 1) = %+v, want red x", cell)
+	}
+
+	for range palette {
+		p.handle(terminal.Key{Kind: terminal.KeyTab}, noSave)
+	}
+	if palette[p.color] != "red" {
+		t.Errorf("after a full cycle color = %s, want red again", palette[p.color])
+	}
+}
+
+func TestPainterQuit(t *testing.T) {
+	for _, key := range []terminal.Key{{Kind: terminal.KeyEscape}, {Kind: terminal.KeyCtrl, Rune: 'c'}} {
+		if newPainter(1, 1).handle(key, noSave) {
+			t.Errorf("handle(%+v) = true, want false", key)
+		}
+	}
+}
+
+func TestPainterSave(t *testing.T) {
+	p := newPainter(2, 1)
+	p.handle(terminal.Key{Kind: terminal.KeyRune, Rune: 'o'}, noSave)
+
+	saved := ""
+	p.handle(terminal.Key{Kind: terminal.KeyCtrl, Rune: 's'}, func(text string) error {
+		saved = text
+		return nil
+	})
+	if saved != "o\n" || p.status != "saved" {
+		t.Errorf("saved %q with status %q, want %q and saved", saved, p.status, "o\n")
+	}
+
+	p.handle(terminal.Key{Kind: terminal.KeyCtrl, Rune: 's'}, func(string) error {
+		return errors.New("disk full")
+	})
+	if p.status != "save failed: disk full" {
+		t.Errorf("status = %q, want the save error", p.status)
+	}
+}
+
+func TestLoop(t *testing.T) {
+	input := "a\x1b[C\x1b[Bb\t \x13\x03ignored"
+	p := newPainter(4, 2)
+	screen := drawing.NewCanvas(4, 3)
+	var out strings.Builder
+	saved := ""
+
+	in := readKeys(terminal.NewKeyReader(strings.NewReader(input)))
+	err := loop(p, in, nil, screen, &out, func(text string) error {
+		saved = text
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("loop() error = %v", err)
+	}
+
+	// b is stamped, then stamped over in red
+	expected := "a\n " + ansi.ESC + "[31mb" + ansi.ESC + "[0m\n"
+	if saved != expected {
+		t.Errorf("saved %q, want %q", saved, expected)
+	}
+	if p.art.Get(1, 1).Char != 'a' || strings.Contains(p.art.Text(), "ignored") {
+		t.Errorf("art = %q, want input after ctrl-C ignored", p.art.Text())
+	}
+	if !strings.HasSuffix(out.String(), ansi.MoveCursor(2, 2)) {
+		t.Errorf("output %q does not end with the cursor at (2, 2)", out.String())
+	}
+}
+
+func TestLoopResize(t *testing.T) {
+	p := newPainter(4, 2)
+	p.x, p.y = 4, 2
+	p.art.Set(1, 1, 'a', ansi.Style{})
+	p.art.Set(4, 2, 'z', ansi.Style{})
+	screen := drawing.NewCanvas(4, 3)
+
+	in := make(chan input)
+	resized := make(chan terminal.Bounds)
+	done := make(chan error)
+	var out strings.Builder
+	go func() { done <- loop(p, in, resized, screen, &out, noSave) }()
+
+	resized <- terminal.Bounds{Width: 2, Height: 1} // too short; ignored
+	resized <- terminal.Bounds{Width: 3, Height: 2}
+	in <- input{key: terminal.Key{Kind: terminal.KeyEscape}}
+	if err := <-done; err != nil {
+		t.Fatalf("loop() error = %v", err)
+	}
+
+	if screen.Width() != 3 || screen.Height() != 2 {
+		t.Errorf("screen = %dx%d, want 3x2", screen.Width(), screen.Height())
+	}
+	if result, expected := p.art.Text(), "a\n"; result != expected {
+		t.Errorf("art = %q, want %q", result, expected)
+	}
+	if p.x != 3 || p.y != 1 {
+		t.Errorf("cursor = (%d, %d), want it pulled back to (3, 1)", p.x, p.y)
+	}
+}
+
+func TestLoopReadError(t *testing.T) {
+	in := make(chan input, 1)
+	in <- input{err: errors.New("read failed")}
+	var out strings.Builder
+	if err := loop(newPainter(1, 1), in, nil, drawing.NewCanvas(1, 2), &out, noSave); err == nil {
+		t.Error("loop() error = nil, want the read error")
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{"default", nil, "paint.ans", false},
+		{"custom", []string{"--out=art.txt"}, "art.txt", false},
+		{"empty", []string{"--out="}, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := parseArgs(test.args)
+			if (err != nil) != test.wantErr {
+				t.Errorf("parseArgs() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if result != test.expected {
+				t.Errorf("parseArgs() = %q, want %q", result, test.expected)
+			}
+		})
+	}
+}
+
+func mouse(action terminal.MouseAction, button terminal.MouseButton, x, y int) terminal.Key {
+	return terminal.Key{Kind: terminal.KeyMouse, Mouse: terminal.Mouse{Action: action, Button: button, X: x, Y: y}}
+}
+
+func TestPainterMouseDraws(t *testing.T) {
+	p := newPainter(5, 3)
+	p.handle(terminal.Key{Kind: terminal.KeyRune, Rune: '*'}, noSave)
+	p.handle(terminal.Key{Kind: terminal.KeyBackspace}, noSave)
+
+	p.handle(mouse(terminal.MousePress, terminal.MouseLeft, 1, 2), noSave)
+	// a fast drag skips straight to column 4; the cells between fill in
+	p.handle(mouse(terminal.MouseDrag, terminal.MouseLeft, 4, 2), noSave)
+	p.handle(mouse(terminal.MouseRelease, terminal.MouseLeft, 4, 2), noSave)
+
+	if result, expected := p.art.Text(), "\n****\n\n"; result != expected {
+		t.Errorf("Text() = %q, want %q", result, expected)
+	}
+	if p.x != 4 || p.y != 2 {
+		t.Errorf("cursor = (%d, %d), want it to follow the mouse to (4, 2)", p.x, p.y)
+	}
+
+	p.handle(mouse(terminal.MousePress, terminal.MouseRight, 2, 2), noSave)
+	p.handle(mouse(terminal.MouseDrag, terminal.MouseRight, 3, 2), noSave)
+	if result, expected := p.art.Text(), "\n*  *\n\n"; result != expected {
+		t.Errorf("Text() after right drag = %q, want %q", result, expected)
+	}
+}
+
+func TestPainterMouseIgnoresStatusLine(t *testing.T) {
+	p := newPainter(3, 1)
+	p.handle(mouse(terminal.MousePress, terminal.MouseLeft, 2, 2), noSave)
+	if p.art.Text() != "\n" || p.x != 1 {
+		t.Errorf("click on the status line drew %q and moved to %d", p.art.Text(), p.x)
+	}
+}
+
+func TestPainterWheelChangesColor(t *testing.T) {
+	p := newPainter(1, 1)
+	p.handle(mouse(terminal.MouseScrollDown, terminal.MouseNoButton, 1, 1), noSave)
+	if palette[p.color] != "red" {
+		t.Errorf("color after scrolling down = %s, want red", palette[p.color])
+	}
+	p.handle(mouse(terminal.MouseScrollUp, terminal.MouseNoButton, 1, 1), noSave)
+	p.handle(mouse(terminal.MouseScrollUp, terminal.MouseNoButton, 1, 1), noSave)
+	if palette[p.color] != palette[len(palette)-1] {
+		t.Errorf("color after scrolling past the start = %s, want %s", palette[p.color], palette[len(palette)-1])
+	}
+	if p.art.Text() != "\n" {
+		t.Errorf("scrolling drew %q", p.art.Text())
+	}
+}
+
+func TestPainterFill(t *testing.T) {
+	p := newPainter(5, 3)
+	p.art.DrawBox(1, 1, 5, 3, drawing.ASCIIBorder, ansi.Style{})
+
+	p.x, p.y = 3, 2
+	p.handle(terminal.Key{Kind: terminal.KeyCtrl, Rune: 'f'}, noSave)
+	if result, expected := p.art.Text(), "+---+\n|###|\n+---+\n"; result != expected {
+		t.Errorf("Text() after ^F = %q, want %q", result, expected)
+	}
+
+	p.handle(terminal.Key{Kind: terminal.KeyTab}, noSave)
+	p.handle(mouse(terminal.MousePress, terminal.MouseMiddle, 2, 2), noSave)
+	red := ansi.Style{FG: 31}
+	for x := 2; x <= 4; x++ {
+		if cell := p.art.Get(x, 2); cell != (drawing.Cell{Char: '#', Style: red}) {
+			t.Errorf("Get(%d, 2) = %+v, want a red #", x, cell)
+		}
+	}
+	if p.art.Get(1, 2).Char != '|' {
+		t.Error("middle-click fill went through the border")
+	}
+}
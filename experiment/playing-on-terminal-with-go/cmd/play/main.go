@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+)
+
+// play shows the frames in turn, holding each for its own delay or for
+// fallback when it has none, and leaves the last one up. A picture of one
+// frame is just printed. sleep returns false once playing should stop.
+func play(w io.Writer, frames []drawing.Frame, repeat int, fallback time.Duration, sleep func(time.Duration) bool) (err error) {
+	if len(frames) == 1 && frames[0].Delay == 0 {
+		_, err := io.WriteString(w, frames[0].Canvas.Text())
+		return err
+	}
+
+	width, height := 0, 0
+	for _, f := range frames {
+		width, height = max(width, f.Canvas.Width()), max(height, f.Canvas.Height())
+	}
+	screen := drawing.NewCanvas(width, height)
+
+	if _, err := io.WriteString(w, ansi.HideCursor()+ansi.ClearScreen()); err != nil {
+		return err
+	}
+	defer func() {
+		_, restoreErr := io.WriteString(w, ansi.MoveCursor(1, height+1)+ansi.ShowCursor())
+		if err == nil {
+			err = restoreErr
+		}
+	}()
+
+	for round := 0; repeat == 0 || round < repeat; round++ {
+		for _, f := range frames {
+			show(screen, f.Canvas)
+			if err := screen.Flush(w); err != nil {
+				return err
+			}
+			delay := f.Delay
+			if delay == 0 {
+				delay = fallback
+			}
+			if !sleep(delay) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// frames smaller than the screen leave the rest of it blank
+func show(screen, frame *drawing.Canvas) {
+	screen.Clear()
+	for y := 1; y <= frame.Height(); y++ {
+		for x := 1; x <= frame.Width(); x++ {
+			cell := frame.Get(x, y)
+			screen.Set(x, y, cell.Char, cell.Style)
+		}
+	}
+}
+
+func run(args []string, w io.Writer, sleep func(time.Duration) bool) error {
+	path, delay, repeat, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if err := validateArgs(path, delay, repeat); err != nil {
+		return err
+	}
+
+	frames, err := drawing.LoadANS(path)
+	if err != nil {
+		return err
+	}
+	return play(w, frames, repeat, delay, sleep)
+}
+
+func parseArgs(args []string) (string, time.Duration, int, error) {
+	fs := flag.NewFlagSet("play", flag.ContinueOnError)
+	delay := fs.Duration("delay", 100*time.Millisecond, "how long frames without their own delay stay up")
+	repeat := fs.Int("repeat", 1, "times to play the frames; 0 loops until ctrl-C")
+
+	if err := fs.Parse(args); err != nil {
+		return "", 0, 0, err
+	}
+	if fs.NArg() != 1 {
+		return "", 0, 0, fmt.Errorf("usage: play [flags] file.ans")
+	}
+
+	return fs.Arg(0), *delay, *repeat, nil
+}
+
+func validateArgs(path string, delay time.Duration, repeat int) error {
+	if path == "" {
+		return fmt.Errorf("file must not be empty")
+	}
+	if delay < 0 {
+		return fmt.Errorf("delay must not be negative")
+	}
+	if repeat < 0 {
+		return fmt.Errorf("repeat must not be negative")
+	}
+	return nil
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sleep := func(d time.Duration) bool {
+		select {
+		case <-time.After(d):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	if err := run(os.Args[1:], os.Stdout, sleep); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
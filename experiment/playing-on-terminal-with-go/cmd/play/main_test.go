@@ -0,0 +1,146 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+)
+
+func frame(text string, delay time.Duration) drawing.Frame {
+	lines := strings.Split(text, "\n")
+	c := drawing.NewCanvas(len(lines[0]), len(lines))
+	for y, line := range lines {
+		c.Print(1, y+1, line, ansi.Style{})
+	}
+	return drawing.Frame{Canvas: c, Delay: delay}
+}
+
+func TestPlay(t *testing.T) {
+	var slept []time.Duration
+	sleep := func(d time.Duration) bool {
+		slept = append(slept, d)
+		return true
+	}
+
+	var out strings.Builder
+	frames := []drawing.Frame{frame("ab", time.Second), frame("a", 0)}
+	if err := play(&out, frames, 2, 50*time.Millisecond, sleep); err != nil {
+		t.Fatal(err)
+	}
+
+	// the second frame only changes the cell it no longer covers
+	first := ansi.MoveCursor(1, 1) + "a" + ansi.MoveCursor(2, 1) + "b"
+	second := ansi.MoveCursor(2, 1) + " "
+	expected := ansi.HideCursor() + ansi.ClearScreen() +
+		first + second + ansi.MoveCursor(2, 1) + "b" + second +
+		ansi.MoveCursor(1, 2) + ansi.ShowCursor()
+	if out.String() != expected {
+		t.Errorf("output = %q, want %q", out.String(), expected)
+	}
+
+	want := []time.Duration{time.Second, 50 * time.Millisecond, time.Second, 50 * time.Millisecond}
+	if len(slept) != len(want) {
+		t.Fatalf("slept %v, want %v", slept, want)
+	}
+	for i := range want {
+		if slept[i] != want[i] {
+			t.Errorf("slept %v, want %v", slept, want)
+			break
+		}
+	}
+}
+
+func TestPlayStops(t *testing.T) {
+	calls := 0
+	sleep := func(time.Duration) bool {
+		calls++
+		return calls < 3
+	}
+
+	var out strings.Builder
+	if err := play(&out, []drawing.Frame{frame("x", 0), frame("y", 0)}, 0, time.Second, sleep); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("played %d frames, want to stop after the 3rd", calls)
+	}
+	if !strings.HasSuffix(out.String(), ansi.ShowCursor()) {
+		t.Errorf("output %q does not end by showing the cursor", out.String())
+	}
+}
+
+func TestPlaySinglePicture(t *testing.T) {
+	sleep := func(time.Duration) bool {
+		t.Error("slept on a still picture")
+		return true
+	}
+
+	var out strings.Builder
+	if err := play(&out, []drawing.Frame{frame("hi", 0)}, 1, time.Second, sleep); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "hi\n" {
+		t.Errorf("output = %q, want %q", out.String(), "hi\n")
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		contains string
+		wantErr  bool
+	}{
+		{
+			name:     "replays the recording",
+			args:     []string{"../../drawing/testdata/blink.ans"},
+			contains: ansi.MoveCursor(2, 1) + ansi.Colorize("*", 33),
+		},
+		{
+			name:     "plays a saved drawing",
+			args:     []string{"../../drawing/testdata/plain.txt"},
+			contains: "+-+\n| |\n",
+		},
+		{
+			name:    "no file",
+			args:    []string{},
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			args:    []string{"testdata/missing.ans"},
+			wantErr: true,
+		},
+		{
+			name:    "negative delay",
+			args:    []string{"--delay=-1s", "../../drawing/testdata/blink.ans"},
+			wantErr: true,
+		},
+		{
+			name:    "negative repeat",
+			args:    []string{"--repeat=-1", "../../drawing/testdata/blink.ans"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		var out strings.Builder
+		err := run(test.args, &out, func(time.Duration) bool { return true })
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !strings.Contains(out.String(), test.contains) {
+			t.Errorf("%s: output %q does not contain %q", test.name, out.String(), test.contains)
+		}
+	}
+}
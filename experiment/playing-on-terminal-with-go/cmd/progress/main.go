@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/widgets"
+)
+
+// simulate pretends to work through steps, redrawing the bar in place on
+// one line after each; sleep stands in for the work
+func simulate(w io.Writer, bar widgets.ProgressBar, steps int, stepTime time.Duration, sleep func(time.Duration)) error {
+	for done := 0; done <= steps; done++ {
+		if done > 0 {
+			sleep(stepTime)
+		}
+		elapsed := time.Duration(done) * stepTime
+		if _, err := fmt.Fprint(w, "\r"+ansi.ClearLine()+bar.Render(done, steps, elapsed)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func run(args []string, w io.Writer, sleep func(time.Duration)) error {
+	steps, duration, width, fill, plain, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if err := validateArgs(steps, duration, width); err != nil {
+		return err
+	}
+	runes := []rune(fill)
+	if len(runes) != 1 {
+		return fmt.Errorf("fill must be exactly one character, got %d", len(runes))
+	}
+
+	bar := widgets.NewProgressBar(width)
+	bar.Fill = runes[0]
+	bar.ETA = true
+	if !plain {
+		bar.Thresholds = []widgets.Threshold{
+			{At: 0, Color: ansi.ColorCode("red")},
+			{At: 1.0 / 3, Color: ansi.ColorCode("yellow")},
+			{At: 2.0 / 3, Color: ansi.ColorCode("green")},
+		}
+	}
+	return simulate(w, bar, steps, duration/time.Duration(steps), sleep)
+}
+
+func parseArgs(args []string) (int, time.Duration, int, string, bool, error) {
+	fs := flag.NewFlagSet("progress", flag.ContinueOnError)
+	steps := fs.Int("steps", 50, "units of work in the task")
+	duration := fs.Duration("duration", 5*time.Second, "how long the whole task takes")
+	width := fs.Int("width", 40, "width of the bar")
+	fill := fs.String("fill", "█", "character for the finished part")
+	plain := fs.Bool("plain", false, "no colors")
+
+	if err := fs.Parse(args); err != nil {
+		return 0, 0, 0, "", false, err
+	}
+
+	return *steps, *duration, *width, *fill, *plain, nil
+}
+
+func validateArgs(steps int, duration time.Duration, width int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive")
+	}
+	if duration < 0 {
+		return fmt.Errorf("duration must not be negative")
+	}
+	if width <= 0 {
+		return fmt.Errorf("width must be positive")
+	}
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, time.Sleep); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/widgets"
+)
+
+func TestSimulate(t *testing.T) {
+	var slept []time.Duration
+	sleep := func(d time.Duration) { slept = append(slept, d) }
+
+	var out strings.Builder
+	bar := widgets.ProgressBar{Width: 2, Fill: '#', Empty: '.', Percent: true}
+	if err := simulate(&out, bar, 2, time.Second, sleep); err != nil {
+		t.Fatal(err)
+	}
+
+	redraw := "\r" + ansi.ClearLine()
+	expected := redraw + "[..]   0%" + redraw + "[#.]  50%" + redraw + "[##] 100%" + "\n"
+	if out.String() != expected {
+		t.Errorf("output = %q, want %q", out.String(), expected)
+	}
+	if len(slept) != 2 || slept[0] != time.Second {
+		t.Errorf("slept %v, want a second for each of 2 steps", slept)
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		contains string
+		wantErr  bool
+	}{
+		{
+			name:     "finishes with eta zero",
+			args:     []string{"--steps=4", "--width=4", "--plain"},
+			contains: "[████] 100% ETA 0s\n",
+		},
+		{
+			name:     "colored by progress",
+			args:     []string{"--steps=3", "--width=3", "--fill=#"},
+			contains: ansi.Colorize("###", 32),
+		},
+		{
+			name:    "zero steps",
+			args:    []string{"--steps=0"},
+			wantErr: true,
+		},
+		{
+			name:    "negative duration",
+			args:    []string{"--duration=-1s"},
+			wantErr: true,
+		},
+		{
+			name:    "zero width",
+			args:    []string{"--width=0"},
+			wantErr: true,
+		},
+		{
+			name:    "fill too long",
+			args:    []string{"--fill=##"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			err := run(test.args, &out, func(time.Duration) {})
+			if (err != nil) != test.wantErr {
+				t.Errorf("run() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !strings.Contains(out.String(), test.contains) {
+				t.Errorf("run() output = %q, want it to contain %q", out.String(), test.contains)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+)
+
+type point struct{ x, y int }
+
+var (
+	up    = point{0, -1}
+	down  = point{0, 1}
+	left  = point{-1, 0}
+	right = point{1, 0}
+)
+
+// the snake moves every startStep frames at first, one frame sooner for
+// every stepScore points, but never more often than every minStep
+const (
+	startStep = 8
+	minStep   = 3
+	stepScore = 3
+)
+
+// game is the rules alone: a field counted from (1,1), a snake head first,
+// and one piece of food. It knows nothing of time or keys.
+type game struct {
+	width, height int
+	snake         []point
+	dir, nextDir  point
+	food          point
+	score         int
+	over          bool
+	rng           *rand.Rand
+}
+
+func newGame(width, height int, rng *rand.Rand) *game {
+	mid := point{width / 2, height / 2}
+	g := &game{
+		width:   width,
+		height:  height,
+		snake:   []point{mid, {mid.x - 1, mid.y}, {mid.x - 2, mid.y}},
+		dir:     right,
+		nextDir: right,
+		rng:     rng,
+	}
+	g.placeFood()
+	return g
+}
+
+// takes effect on the next step; turning back onto the snake's own neck
+// is ignored
+func (g *game) turn(d point) {
+	if d.x == -g.dir.x && d.y == -g.dir.y {
+		return
+	}
+	g.nextDir = d
+}
+
+func (g *game) step() {
+	if g.over {
+		return
+	}
+	g.dir = g.nextDir
+	head := point{g.snake[0].x + g.dir.x, g.snake[0].y + g.dir.y}
+	if head.x < 1 || head.y < 1 || head.x > g.width || head.y > g.height {
+		g.over = true
+		return
+	}
+
+	// the tail moves out of the way unless the snake is growing
+	eating := head == g.food
+	body := g.snake
+	if !eating {
+		body = body[:len(body)-1]
+	}
+	for _, p := range body {
+		if p == head {
+			g.over = true
+			return
+		}
+	}
+
+	g.snake = append([]point{head}, body...)
+	if eating {
+		g.score++
+		g.placeFood()
+	}
+}
+
+// frames between steps at the current score
+func (g *game) stepEvery() int {
+	return max(startStep-g.score/stepScore, minStep)
+}
+
+// a snake that fills the field has won, which also ends the game
+func (g *game) placeFood() {
+	var free []point
+	for y := 1; y <= g.height; y++ {
+		for x := 1; x <= g.width; x++ {
+			if !g.occupied(point{x, y}) {
+				free = append(free, point{x, y})
+			}
+		}
+	}
+	if len(free) == 0 {
+		g.over = true
+		return
+	}
+	g.food = free[g.rng.Intn(len(free))]
+}
+
+func (g *game) occupied(p point) bool {
+	for _, s := range g.snake {
+		if s == p {
+			return true
+		}
+	}
+	return false
+}
+
+// the score on the top row, then the field inside a border below it
+func (g *game) draw(c *drawing.Canvas) {
+	status := fmt.Sprintf("score %d", g.score)
+	if g.over {
+		status += "  game over: r to restart, q to quit"
+	}
+	c.Print(1, 1, status, ansi.Style{Bold: true})
+
+	c.DrawBox(1, 2, g.width+2, g.height+2, drawing.RoundedBorder, ansi.Style{Dim: true})
+
+	// field cell (x, y) sits at (x+1, y+2) on the canvas; the snake goes on
+	// last, over a stale food cell once it has filled the field
+	c.Set(g.food.x+1, g.food.y+2, '*', ansi.Style{FG: ansi.ColorCode("red")})
+	body := ansi.Style{FG: ansi.ColorCode("green")}
+	for i := len(g.snake) - 1; i >= 0; i-- {
+		char := 'o'
+		if i == 0 {
+			char = '@'
+		}
+		c.Set(g.snake[i].x+1, g.snake[i].y+2, char, body)
+	}
+}
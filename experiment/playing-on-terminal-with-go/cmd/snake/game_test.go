@@ -0,0 +1,164 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+)
+
+func testGame(width, height int) *game {
+	return newGame(width, height, rand.New(rand.NewSource(1)))
+}
+
+func TestNewGame(t *testing.T) {
+	g := testGame(10, 6)
+	if expected := []point{{5, 3}, {4, 3}, {3, 3}}; !reflect.DeepEqual(g.snake, expected) {
+		t.Errorf("snake = %v, want %v", g.snake, expected)
+	}
+	if g.occupied(g.food) {
+		t.Errorf("food %v is under the snake", g.food)
+	}
+}
+
+func TestStepMoves(t *testing.T) {
+	g := testGame(10, 6)
+	g.food = point{1, 1}
+	g.step()
+	if expected := []point{{6, 3}, {5, 3}, {4, 3}}; !reflect.DeepEqual(g.snake, expected) {
+		t.Errorf("snake = %v, want %v", g.snake, expected)
+	}
+
+	g.turn(up)
+	g.step()
+	if expected := []point{{6, 2}, {6, 3}, {5, 3}}; !reflect.DeepEqual(g.snake, expected) {
+		t.Errorf("snake after turning = %v, want %v", g.snake, expected)
+	}
+}
+
+func TestTurnBackIsIgnored(t *testing.T) {
+	g := testGame(10, 6)
+	g.food = point{1, 1}
+	g.turn(left)
+	g.step()
+	if g.over || g.snake[0] != (point{6, 3}) {
+		t.Errorf("turning back: head = %v, over = %v; want to carry on right", g.snake[0], g.over)
+	}
+}
+
+func TestStepEats(t *testing.T) {
+	g := testGame(10, 6)
+	g.food = point{6, 3}
+	g.step()
+
+	if g.score != 1 || len(g.snake) != 4 {
+		t.Errorf("score = %d, length = %d, want 1 and 4", g.score, len(g.snake))
+	}
+	if g.occupied(g.food) {
+		t.Errorf("new food %v is under the snake", g.food)
+	}
+}
+
+func TestStepHitsWall(t *testing.T) {
+	g := testGame(10, 6)
+	g.food = point{1, 1}
+	for i := 0; i < 5; i++ {
+		g.step()
+	}
+	if g.over || g.snake[0].x != 10 {
+		t.Fatalf("head = %v, over = %v; want alive at the right edge", g.snake[0], g.over)
+	}
+	g.step()
+	if !g.over {
+		t.Error("over = false after running into the wall")
+	}
+
+	head := g.snake[0]
+	g.step()
+	if g.snake[0] != head {
+		t.Error("snake moved after the game ended")
+	}
+}
+
+func TestStepHitsItself(t *testing.T) {
+	g := testGame(10, 6)
+	g.snake = []point{{5, 3}, {4, 3}, {4, 4}, {5, 4}, {6, 4}}
+	g.food = point{1, 1}
+	g.turn(down)
+	g.step()
+	if !g.over {
+		t.Error("over = false after biting the body")
+	}
+}
+
+func TestStepIntoVacatedTail(t *testing.T) {
+	g := testGame(10, 6)
+	// a tight loop: the head moves onto the cell the tail leaves
+	g.snake = []point{{5, 3}, {5, 4}, {6, 4}, {6, 3}}
+	g.dir, g.nextDir = up, up
+	g.food = point{1, 1}
+	g.turn(right)
+	g.step()
+	if g.over {
+		t.Error("over = true after chasing the tail")
+	}
+}
+
+func TestStepEvery(t *testing.T) {
+	tests := []struct {
+		score    int
+		expected int
+	}{
+		{0, 8},
+		{2, 8},
+		{3, 7},
+		{15, 3},
+		{100, 3},
+	}
+
+	g := testGame(10, 6)
+	for _, test := range tests {
+		g.score = test.score
+		if result := g.stepEvery(); result != test.expected {
+			t.Errorf("stepEvery() at score %d = %d, want %d", test.score, result, test.expected)
+		}
+	}
+}
+
+func TestFullFieldEndsGame(t *testing.T) {
+	g := testGame(2, 2)
+	g.snake = []point{{1, 1}, {2, 1}, {2, 2}, {1, 2}}
+	g.placeFood()
+	if !g.over {
+		t.Error("over = false with nowhere left for food")
+	}
+}
+
+func TestDraw(t *testing.T) {
+	g := testGame(10, 6)
+	g.food = point{1, 1}
+	c := drawing.NewCanvas(12, 9)
+	g.draw(c)
+
+	checks := []struct {
+		x, y int
+		char rune
+	}{
+		{1, 1, 's'},
+		{7, 1, '0'},
+		{1, 2, '╭'},
+		{12, 2, '╮'},
+		{5, 2, '─'},
+		{1, 5, '│'},
+		{12, 9, '╯'},
+		{2, 3, '*'},
+		{6, 5, '@'},
+		{5, 5, 'o'},
+	}
+	for _, check := range checks {
+		if cell := c.Get(check.x, check.y); cell.Char != check.char {
+			t.Errorf("Get(%d, %d) = %q, want %q", check.x, check.y, cell.Char, check.char)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/animate"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/terminal"
+)
+
+// steps are counted in frames, so this sets how finely speed can change
+const fps = 60
+
+// the smallest field worth playing on
+const minWidth, minHeight = 10, 5
+
+// session drives a game from keys and frames. Keys are drained at the start
+// of each frame, so a turn pressed between steps is not lost.
+type session struct {
+	g        *game
+	keys     <-chan terminal.Key
+	lastStep int
+	restart  func() *game
+}
+
+func (s *session) update(frame int, c *drawing.Canvas) bool {
+	for drained := false; !drained; {
+		select {
+		case key, ok := <-s.keys:
+			if !ok || !s.handle(key) {
+				return false
+			}
+		default:
+			drained = true
+		}
+	}
+
+	if frame-s.lastStep >= s.g.stepEvery() {
+		s.g.step()
+		s.lastStep = frame
+	}
+	c.Clear()
+	s.g.draw(c)
+	return true
+}
+
+// applies one key and reports whether to keep playing
+func (s *session) handle(key terminal.Key) bool {
+	switch key.Kind {
+	case terminal.KeyUp:
+		s.g.turn(up)
+	case terminal.KeyDown:
+		s.g.turn(down)
+	case terminal.KeyLeft:
+		s.g.turn(left)
+	case terminal.KeyRight:
+		s.g.turn(right)
+	case terminal.KeyEscape:
+		return false
+	case terminal.KeyCtrl:
+		return key.Rune != 'c'
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'w':
+			s.g.turn(up)
+		case 's':
+			s.g.turn(down)
+		case 'a':
+			s.g.turn(left)
+		case 'd':
+			s.g.turn(right)
+		case 'q':
+			return false
+		case 'r':
+			if s.g.over {
+				s.g = s.restart()
+			}
+		}
+	}
+	return true
+}
+
+// raw mode turns ctrl-C into a key, so quitting goes through the keys too
+func readKeys(keys *terminal.KeyReader) <-chan terminal.Key {
+	out := make(chan terminal.Key, 16)
+	go func() {
+		defer close(out)
+		for {
+			key, err := keys.ReadKey()
+			if err != nil {
+				return
+			}
+			out <- key
+		}
+	}()
+	return out
+}
+
+func run(args []string) error {
+	seed, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	size, err := terminal.Size()
+	if err != nil {
+		return err
+	}
+	// the border takes two columns; it and the score take three rows
+	width, height := size.Width-2, size.Height-3
+	if width < minWidth || height < minHeight {
+		return fmt.Errorf("terminal too small: need at least %dx%d", minWidth+2, minHeight+3)
+	}
+
+	restore, err := terminal.EnableRaw(os.Stdin.Fd())
+	if err != nil {
+		return fmt.Errorf("snake needs a terminal: %w", err)
+	}
+	defer restore()
+
+	rng := rand.New(rand.NewSource(seed))
+	restart := func() *game { return newGame(width, height, rng) }
+	s := &session{g: restart(), keys: readKeys(terminal.NewKeyReader(os.Stdin)), restart: restart}
+	return animate.Run(fps, s.update)
+}
+
+func parseArgs(args []string) (int64, error) {
+	fs := flag.NewFlagSet("snake", flag.ContinueOnError)
+	seed := fs.Int64("seed", 0, "seed for where food appears; 0 picks one from the clock")
+
+	if err := fs.Parse(args); err != nil {
+		return 0, err
+	}
+	if *seed == 0 {
+		return time.Now().UnixNano(), nil
+	}
+	return *seed, nil
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+	"github.com/e6a5/learning/experiment/ternimal-with-go/terminal"
+)
+
+func testSession(keys ...terminal.Key) *session {
+	in := make(chan terminal.Key, len(keys))
+	for _, key := range keys {
+		in <- key
+	}
+	restart := func() *game { return testGame(10, 6) }
+	return &session{g: restart(), keys: in, restart: restart}
+}
+
+func TestSessionStepsOnSchedule(t *testing.T) {
+	s := testSession()
+	s.g.food = point{1, 1}
+	c := drawing.NewCanvas(12, 9)
+
+	for frame := 1; frame <= 16; frame++ {
+		s.update(frame, c)
+	}
+	if s.g.snake[0] != (point{7, 3}) {
+		t.Errorf("head after 16 frames = %v, want two steps on at (7, 3)", s.g.snake[0])
+	}
+}
+
+func TestSessionTurnsBeforeStepping(t *testing.T) {
+	s := testSession(terminal.Key{Kind: terminal.KeyDown})
+	s.g.food = point{1, 1}
+	s.update(8, drawing.NewCanvas(12, 9))
+	if s.g.snake[0] != (point{5, 4}) {
+		t.Errorf("head = %v, want (5, 4) after turning down", s.g.snake[0])
+	}
+}
+
+func TestSessionWASD(t *testing.T) {
+	s := testSession(terminal.Key{Kind: terminal.KeyRune, Rune: 'w'})
+	s.g.food = point{1, 1}
+	s.update(8, drawing.NewCanvas(12, 9))
+	if s.g.snake[0] != (point{5, 2}) {
+		t.Errorf("head = %v, want (5, 2) after w", s.g.snake[0])
+	}
+}
+
+func TestSessionQuits(t *testing.T) {
+	for _, key := range []terminal.Key{
+		{Kind: terminal.KeyRune, Rune: 'q'},
+		{Kind: terminal.KeyEscape},
+		{Kind: terminal.KeyCtrl, Rune: 'c'},
+	} {
+		if testSession(key).update(1, drawing.NewCanvas(12, 9)) {
+			t.Errorf("update() after %+v = true, want false", key)
+		}
+	}
+
+	in := make(chan terminal.Key)
+	close(in)
+	s := &session{g: testGame(10, 6), keys: in}
+	if s.update(1, drawing.NewCanvas(12, 9)) {
+		t.Error("update() once input ends = true, want false")
+	}
+}
+
+func TestSessionRestartsOnlyWhenOver(t *testing.T) {
+	s := testSession(terminal.Key{Kind: terminal.KeyRune, Rune: 'r'})
+	first := s.g
+	s.update(1, drawing.NewCanvas(12, 9))
+	if s.g != first {
+		t.Error("r restarted a game still in play")
+	}
+
+	s = testSession(terminal.Key{Kind: terminal.KeyRune, Rune: 'r'})
+	s.g.over = true
+	first = s.g
+	s.update(1, drawing.NewCanvas(12, 9))
+	if s.g == first || s.g.over {
+		t.Error("r did not start a fresh game after game over")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/widgets"
+)
+
+var taskNames = []string{"download", "unpack", "compile", "test", "package"}
+
+// fake tasks sleep for a random time up to longest; the one named fail
+// returns an error instead of succeeding
+func runTasks(w io.Writer, longest time.Duration, fail string, rng *rand.Rand, sleep func(time.Duration)) error {
+	s := widgets.NewSpinners(w, 80*time.Millisecond)
+	for _, name := range taskNames {
+		d := time.Duration(rng.Int63n(int64(longest) + 1))
+		s.Go(name, func() error {
+			sleep(d)
+			if name == fail {
+				return errors.New("simulated failure")
+			}
+			return nil
+		})
+	}
+	return s.Wait()
+}
+
+func run(args []string, w io.Writer, sleep func(time.Duration)) error {
+	longest, fail, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if err := validateArgs(longest, fail); err != nil {
+		return err
+	}
+	return runTasks(w, longest, fail, rand.New(rand.NewSource(time.Now().UnixNano())), sleep)
+}
+
+func parseArgs(args []string) (time.Duration, string, error) {
+	fs := flag.NewFlagSet("spinner", flag.ContinueOnError)
+	longest := fs.Duration("longest", 3*time.Second, "longest a task may take")
+	fail := fs.String("fail", "", "name of a task that should fail")
+
+	if err := fs.Parse(args); err != nil {
+		return 0, "", err
+	}
+
+	return *longest, *fail, nil
+}
+
+func validateArgs(longest time.Duration, fail string) error {
+	if longest < 0 {
+		return fmt.Errorf("longest must not be negative")
+	}
+	if fail == "" {
+		return nil
+	}
+	for _, name := range taskNames {
+		if name == fail {
+			return nil
+		}
+	}
+	return fmt.Errorf("no task named %q; tasks are %v", fail, taskNames)
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, time.Sleep); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
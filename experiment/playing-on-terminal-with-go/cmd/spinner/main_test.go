@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		contains string
+		wantErr  bool
+	}{
+		{
+			name:     "all succeed",
+			args:     []string{"--longest=0"},
+			contains: " package\n",
+		},
+		{
+			name:     "one fails",
+			args:     []string{"--longest=0", "--fail=compile"},
+			contains: " compile: simulated failure\n",
+			wantErr:  true,
+		},
+		{
+			name:    "unknown task",
+			args:    []string{"--fail=deploy"},
+			wantErr: true,
+		},
+		{
+			name:    "negative duration",
+			args:    []string{"--longest=-1s"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			err := run(test.args, &out, func(time.Duration) {})
+			if (err != nil) != test.wantErr {
+				t.Errorf("run() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !strings.Contains(out.String(), test.contains) {
+				t.Errorf("run() output = %q, want it to contain %q", out.String(), test.contains)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package drawing
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+// Frame is one picture of an .ans file and how long it stays up before the
+// next; a zero Delay leaves the pace to whoever plays it
+type Frame struct {
+	Canvas *Canvas
+	Delay  time.Duration
+}
+
+// frameBreak starts the line that ends a frame; the rest of that line is the
+// frame's delay, if it has one
+const frameBreak = "\f"
+
+// WriteANS saves frames as ANSI art. Each canvas is written as its Text, so
+// a file of one frame is the picture itself and prints with cat; frames
+// after it follow a form feed line holding the delay.
+//
+//	<frame 1>
+//	\f250ms
+//	<frame 2>
+func WriteANS(w io.Writer, frames ...Frame) error {
+	out := ""
+	for i, f := range frames {
+		out += f.Canvas.Text()
+		if i < len(frames)-1 || f.Delay != 0 {
+			out += frameBreak
+			if f.Delay != 0 {
+				out += f.Delay.String()
+			}
+			out += "\n"
+		}
+	}
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+func SaveANS(path string, frames ...Frame) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteANS(f, frames...); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadANS reads frames back from what WriteANS writes, or from any text
+// styled with SGR sequences. Each canvas is as wide as its longest line,
+// since Text drops the blanks at the end of a row.
+func ReadANS(r io.Reader) ([]Frame, error) {
+	var frames []Frame
+	var rows [][]Cell
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if rest, ok := strings.CutPrefix(line, frameBreak); ok {
+			delay, err := parseDelay(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			frames = append(frames, Frame{Canvas: canvasOf(rows), Delay: delay})
+			rows = nil
+			continue
+		}
+		row, err := decodeRow(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// a break on the last line only gives the last frame its delay
+	if rows != nil || len(frames) == 0 {
+		frames = append(frames, Frame{Canvas: canvasOf(rows)})
+	}
+	return frames, nil
+}
+
+func LoadANS(path string) ([]Frame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	frames, err := ReadANS(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return frames, nil
+}
+
+func parseDelay(text string) (time.Duration, error) {
+	if text == "" {
+		return 0, nil
+	}
+	delay, err := time.ParseDuration(text)
+	if err != nil || delay < 0 {
+		return 0, fmt.Errorf("bad frame delay %q", text)
+	}
+	return delay, nil
+}
+
+// decodeRow turns a line of styled text into cells; each SGR sequence
+// changes the style of the characters after it
+func decodeRow(line string) ([]Cell, error) {
+	var row []Cell
+	var style ansi.Style
+	for line != "" {
+		if rest, ok := strings.CutPrefix(line, ansi.ESC+"["); ok {
+			end := strings.IndexFunc(rest, func(r rune) bool { return r != ';' && (r < '0' || r > '9') })
+			if end < 0 || rest[end] != 'm' {
+				return nil, fmt.Errorf("only color and style escape sequences are supported")
+			}
+			var err error
+			if style, err = style.Update(rest[:end]); err != nil {
+				return nil, err
+			}
+			line = rest[end+1:]
+			continue
+		}
+		char, size := utf8.DecodeRuneInString(line)
+		row = append(row, Cell{Char: char, Style: style})
+		line = line[size:]
+	}
+	return row, nil
+}
+
+func canvasOf(rows [][]Cell) *Canvas {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	c := NewCanvas(width, len(rows))
+	for y, row := range rows {
+		for x, cell := range row {
+			c.Set(x+1, y+1, cell.Char, cell.Style)
+		}
+	}
+	return c
+}
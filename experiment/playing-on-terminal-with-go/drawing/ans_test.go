@@ -0,0 +1,149 @@
+package drawing
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+func TestANSRoundTrip(t *testing.T) {
+	first := NewCanvas(5, 2)
+	first.Print(1, 1, "hi", ansi.Style{FG: 31, Bold: true})
+	first.Set(5, 2, '#', ansi.Style{BG: 44, Underline: true})
+	second := NewCanvas(5, 2)
+	second.Set(3, 1, 'é', ansi.Style{})
+	second.Set(1, 2, 'x', ansi.Style{Dim: true, Italic: true, Reverse: true})
+
+	var buf bytes.Buffer
+	if err := WriteANS(&buf, Frame{Canvas: first, Delay: 150 * time.Millisecond}, Frame{Canvas: second}); err != nil {
+		t.Fatal(err)
+	}
+	frames, err := ReadANS(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(frames) != 2 {
+		t.Fatalf("read %d frames, want 2", len(frames))
+	}
+	for i, original := range []*Canvas{first, second} {
+		if result := frames[i].Canvas.Text(); result != original.Text() {
+			t.Errorf("frame %d Text() = %q, want %q", i, result, original.Text())
+		}
+	}
+	if frames[0].Delay != 150*time.Millisecond || frames[1].Delay != 0 {
+		t.Errorf("delays = %v, %v, want 150ms, 0", frames[0].Delay, frames[1].Delay)
+	}
+}
+
+func TestWriteANSSingleFrame(t *testing.T) {
+	c := NewCanvas(3, 2)
+	c.Set(2, 1, 'o', ansi.Style{FG: 32})
+
+	var buf bytes.Buffer
+	if err := WriteANS(&buf, Frame{Canvas: c}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != c.Text() {
+		t.Errorf("WriteANS() = %q, want the plain Text %q", buf.String(), c.Text())
+	}
+
+	buf.Reset()
+	if err := WriteANS(&buf, Frame{Canvas: c, Delay: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	frames, err := ReadANS(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 1 || frames[0].Delay != time.Second {
+		t.Errorf("read %d frames, first delay %v, want 1 frame of 1s", len(frames), frames[0].Delay)
+	}
+}
+
+func TestReadANSStyles(t *testing.T) {
+	// other tools leave styles on across characters and stack them
+	text := ansi.ESC + "[1mA" + ansi.ESC + "[31mB" + ansi.ESC + "[0mC" + ansi.ESC + "[44;32mD" + ansi.ESC + "[mE\n"
+	frames, err := ReadANS(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := frames[0].Canvas
+	expected := []Cell{
+		{'A', ansi.Style{Bold: true}},
+		{'B', ansi.Style{FG: 31, Bold: true}},
+		{'C', ansi.Style{}},
+		{'D', ansi.Style{FG: 32, BG: 44}},
+		{'E', ansi.Style{}},
+	}
+	if c.Width() != len(expected) || c.Height() != 1 {
+		t.Fatalf("canvas is %dx%d, want %dx1", c.Width(), c.Height(), len(expected))
+	}
+	for x, cell := range expected {
+		if result := c.Get(x+1, 1); result != cell {
+			t.Errorf("Get(%d, 1) = %+v, want %+v", x+1, result, cell)
+		}
+	}
+}
+
+func TestReadANSErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"cursor move", "a" + ansi.MoveCursor(3, 3) + "b\n"},
+		{"unterminated sequence", "a" + ansi.ESC + "[31"},
+		{"unknown style", ansi.ESC + "[38;5;200mx\n"},
+		{"bad delay", "a\n\fsoon\n"},
+		{"negative delay", "a\n\f-1s\n"},
+	}
+
+	for _, test := range tests {
+		if _, err := ReadANS(strings.NewReader(test.text)); err == nil {
+			t.Errorf("%s: ReadANS() succeeded, want an error", test.name)
+		}
+	}
+}
+
+func TestLoadANS(t *testing.T) {
+	frames, err := LoadANS("testdata/blink.ans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("read %d frames, want 2", len(frames))
+	}
+	if expected := "* \n ~"; plain(frames[0].Canvas) != expected {
+		t.Errorf("first frame =\n%s\nwant\n%s", plain(frames[0].Canvas), expected)
+	}
+	if frames[1].Delay != 300*time.Millisecond {
+		t.Errorf("last delay = %v, want 300ms", frames[1].Delay)
+	}
+	if cell := frames[1].Canvas.Get(1, 2); cell != (Cell{'~', ansi.Style{FG: 34, Bold: true}}) {
+		t.Errorf("Get(1, 2) = %+v, want a bold blue ~", cell)
+	}
+
+	if _, err := LoadANS("testdata/missing.ans"); err == nil {
+		t.Error("LoadANS() of a missing file succeeded")
+	}
+}
+
+func TestSaveANS(t *testing.T) {
+	c := NewCanvas(2, 1)
+	c.Print(1, 1, "ok", ansi.Style{FG: 36})
+	path := t.TempDir() + "/art.ans"
+	if err := SaveANS(path, Frame{Canvas: c}); err != nil {
+		t.Fatal(err)
+	}
+	frames, err := LoadANS(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 1 || frames[0].Canvas.Text() != c.Text() {
+		t.Errorf("loaded %d frames, want the saved canvas back", len(frames))
+	}
+}
@@ -0,0 +1,55 @@
+package drawing
+
+import "github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+
+// Border is the set of characters a box is drawn with. The tees and cross
+// are for boxes split into cells, such as tables.
+type Border struct {
+	Horizontal, Vertical                       rune
+	TopLeft, TopRight, BottomLeft, BottomRight rune
+	TeeDown, TeeUp, TeeRight, TeeLeft, Cross   rune
+}
+
+var (
+	SingleBorder  = Border{'─', '│', '┌', '┐', '└', '┘', '┬', '┴', '├', '┤', '┼'}
+	DoubleBorder  = Border{'═', '║', '╔', '╗', '╚', '╝', '╦', '╩', '╠', '╣', '╬'}
+	RoundedBorder = Border{'─', '│', '╭', '╮', '╰', '╯', '┬', '┴', '├', '┤', '┼'}
+	ASCIIBorder   = Border{'-', '|', '+', '+', '+', '+', '+', '+', '+', '+', '+'}
+)
+
+// DrawBox outlines the w by h rectangle whose top-left cell is (x, y).
+// Anything narrower or shorter than 2 has no inside to outline and is skipped.
+func (c *Canvas) DrawBox(x, y, w, h int, b Border, style ansi.Style) {
+	if w < 2 || h < 2 {
+		return
+	}
+	right, bottom := x+w-1, y+h-1
+	c.DrawLine(x+1, y, right-1, y, b.Horizontal, style)
+	c.DrawLine(x+1, bottom, right-1, bottom, b.Horizontal, style)
+	c.DrawLine(x, y+1, x, bottom-1, b.Vertical, style)
+	c.DrawLine(right, y+1, right, bottom-1, b.Vertical, style)
+	c.Set(x, y, b.TopLeft, style)
+	c.Set(right, y, b.TopRight, style)
+	c.Set(x, bottom, b.BottomLeft, style)
+	c.Set(right, bottom, b.BottomRight, style)
+}
+
+// DrawFrame is DrawBox with title set into the top edge, as "┌─ title ─┐".
+// A title too long for the box is cut short; one with no room is left out.
+func (c *Canvas) DrawFrame(x, y, w, h int, title string, b Border, style ansi.Style) {
+	c.DrawBox(x, y, w, h, b, style)
+	if w < 2 || h < 2 {
+		return
+	}
+
+	// a corner and a line on each side, and a space either side of the title
+	room := w - 6
+	runes := []rune(title)
+	if room <= 0 || len(runes) == 0 {
+		return
+	}
+	if len(runes) > room {
+		runes = runes[:room]
+	}
+	c.Print(x+2, y, " "+string(runes)+" ", style)
+}
@@ -0,0 +1,94 @@
+package drawing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+// plain reads the canvas back without styles, one string per row
+func plain(c *Canvas) string {
+	rows := make([]string, c.Height())
+	for y := 1; y <= c.Height(); y++ {
+		for x := 1; x <= c.Width(); x++ {
+			rows[y-1] += string(c.Get(x, y).Char)
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
+func TestDrawBox(t *testing.T) {
+	tests := []struct {
+		name     string
+		border   Border
+		expected string
+	}{
+		{"single", SingleBorder, " ┌──┐\n │  │\n └──┘"},
+		{"double", DoubleBorder, " ╔══╗\n ║  ║\n ╚══╝"},
+		{"rounded", RoundedBorder, " ╭──╮\n │  │\n ╰──╯"},
+		{"ascii", ASCIIBorder, " +--+\n |  |\n +--+"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewCanvas(5, 3)
+			c.DrawBox(2, 1, 4, 3, test.border, ansi.Style{})
+			if result := plain(c); result != test.expected {
+				t.Errorf("DrawBox() =\n%s\nwant\n%s", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestDrawBoxSmallest(t *testing.T) {
+	c := NewCanvas(2, 2)
+	c.DrawBox(1, 1, 2, 2, SingleBorder, ansi.Style{})
+	if expected := "┌┐\n└┘"; plain(c) != expected {
+		t.Errorf("DrawBox() =\n%s\nwant\n%s", plain(c), expected)
+	}
+
+	for _, size := range [][2]int{{1, 3}, {3, 1}, {0, 0}} {
+		c := NewCanvas(3, 3)
+		c.DrawBox(1, 1, size[0], size[1], SingleBorder, ansi.Style{})
+		if strings.TrimSpace(plain(c)) != "" {
+			t.Errorf("DrawBox(%dx%d) drew\n%s", size[0], size[1], plain(c))
+		}
+	}
+}
+
+func TestDrawBoxKeepsStyle(t *testing.T) {
+	c := NewCanvas(3, 3)
+	style := ansi.Style{FG: 34}
+	c.DrawBox(1, 1, 3, 3, SingleBorder, style)
+	if cell := c.Get(1, 1); cell.Style != style {
+		t.Errorf("corner style = %+v, want %+v", cell.Style, style)
+	}
+	if cell := c.Get(2, 2); cell != blank {
+		t.Errorf("inside = %+v, want blank", cell)
+	}
+}
+
+func TestDrawFrame(t *testing.T) {
+	tests := []struct {
+		name     string
+		w        int
+		title    string
+		expected string
+	}{
+		{"fits", 12, "Score", "┌─ Score ──┐\n│          │\n└──────────┘"},
+		{"cut short", 9, "Score", "┌─ Sco ─┐\n│       │\n└───────┘"},
+		{"no room", 6, "Score", "┌────┐\n│    │\n└────┘"},
+		{"no title", 6, "", "┌────┐\n│    │\n└────┘"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewCanvas(test.w, 3)
+			c.DrawFrame(1, 1, test.w, 3, test.title, SingleBorder, ansi.Style{})
+			if result := plain(c); result != test.expected {
+				t.Errorf("DrawFrame() =\n%s\nwant\n%s", result, test.expected)
+			}
+		})
+	}
+}
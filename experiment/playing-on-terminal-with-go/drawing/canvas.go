@@ -0,0 +1,133 @@
+package drawing
+
+import (
+	"io"
+	"strings"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+type Cell struct {
+	Char  rune
+	Style ansi.Style
+}
+
+var blank = Cell{Char: ' '}
+
+// Canvas buffers a frame and remembers the last one it flushed, so Flush
+// only writes the cells that changed in between. Coordinates start at (1,1)
+// like the terminal's.
+type Canvas struct {
+	width, height int
+	cells         []Cell
+	flushed       []Cell // nil until the first Flush
+}
+
+func NewCanvas(width, height int) *Canvas {
+	c := &Canvas{width: width, height: height, cells: make([]Cell, width*height)}
+	c.Clear()
+	return c
+}
+
+func (c *Canvas) Width() int  { return c.width }
+func (c *Canvas) Height() int { return c.height }
+
+// cells outside the canvas are ignored, so shapes may run off the edge
+func (c *Canvas) Set(x, y int, char rune, style ansi.Style) {
+	if i, ok := c.index(x, y); ok {
+		c.cells[i] = Cell{Char: char, Style: style}
+	}
+}
+
+// writes text left to right from (x, y), cutting it off at the edge
+func (c *Canvas) Print(x, y int, text string, style ansi.Style) {
+	for _, char := range text {
+		c.Set(x, y, char, style)
+		x++
+	}
+}
+
+// returns a blank cell outside the canvas
+func (c *Canvas) Get(x, y int) Cell {
+	if i, ok := c.index(x, y); ok {
+		return c.cells[i]
+	}
+	return blank
+}
+
+func (c *Canvas) DrawLine(x1, y1, x2, y2 int, char rune, style ansi.Style) {
+	c.Plot(LinePoints(x1, y1, x2, y2), char, style)
+}
+
+// keeps the cells that still fit and blanks the new ones; the next Flush
+// redraws everything, since the terminal will have rewrapped the old screen
+func (c *Canvas) Resize(width, height int) {
+	cells := make([]Cell, width*height)
+	for i := range cells {
+		cells[i] = blank
+	}
+	for y := 1; y <= height && y <= c.height; y++ {
+		for x := 1; x <= width && x <= c.width; x++ {
+			cells[(y-1)*width+x-1] = c.cells[(y-1)*c.width+x-1]
+		}
+	}
+	c.width, c.height, c.cells = width, height, cells
+	c.flushed = nil
+}
+
+// blanks the next frame; cells that stay blank are erased by the next Flush
+func (c *Canvas) Clear() {
+	for i := range c.cells {
+		c.cells[i] = blank
+	}
+}
+
+// the first Flush draws every cell, since the screen may hold anything;
+// later ones draw only cells that differ from the last flushed frame
+func (c *Canvas) Flush(w io.Writer) error {
+	// one write per frame; a full screen is thousands of cells
+	var out strings.Builder
+	for i, cell := range c.cells {
+		if c.flushed != nil && c.flushed[i] == cell {
+			continue
+		}
+		out.WriteString(ansi.MoveCursor(i%c.width+1, i/c.width+1))
+		out.WriteString(cell.Style.Apply(string(cell.Char)))
+	}
+	if out.Len() > 0 {
+		if _, err := io.WriteString(w, out.String()); err != nil {
+			return err
+		}
+	}
+
+	if c.flushed == nil {
+		c.flushed = make([]Cell, len(c.cells))
+	}
+	copy(c.flushed, c.cells)
+	return nil
+}
+
+// the canvas as styled lines, without positioning, so it prints anywhere;
+// blank cells at the end of a row are dropped
+func (c *Canvas) Text() string {
+	var text strings.Builder
+	for y := 1; y <= c.height; y++ {
+		row := c.cells[(y-1)*c.width : y*c.width]
+		end := len(row)
+		for end > 0 && row[end-1] == blank {
+			end--
+		}
+		for _, cell := range row[:end] {
+			text.WriteString(cell.Style.Apply(string(cell.Char)))
+		}
+		text.WriteByte('\n')
+	}
+	return text.String()
+}
+
+func (c *Canvas) index(x, y int) (int, bool) {
+	if x < 1 || y < 1 || x > c.width || y > c.height {
+		return 0, false
+	}
+	return (y-1)*c.width + x - 1, true
+}
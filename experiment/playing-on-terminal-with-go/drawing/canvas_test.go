@@ -0,0 +1,174 @@
+package drawing
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+func flush(t *testing.T, c *Canvas) string {
+	t.Helper()
+	var out strings.Builder
+	if err := c.Flush(&out); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	return out.String()
+}
+
+func TestCanvasFirstFlushDrawsEveryCell(t *testing.T) {
+	c := NewCanvas(2, 2)
+	c.Set(2, 1, 'X', ansi.Style{})
+
+	expected := ansi.ESC + "[1;1H " + ansi.ESC + "[1;2HX" + ansi.ESC + "[2;1H " + ansi.ESC + "[2;2H "
+	if result := flush(t, c); result != expected {
+		t.Errorf("Flush() = %q, want %q", result, expected)
+	}
+}
+
+func TestCanvasFlushWritesOnlyChanges(t *testing.T) {
+	c := NewCanvas(3, 3)
+	c.Set(1, 1, 'A', ansi.Style{})
+	flush(t, c)
+
+	if result := flush(t, c); result != "" {
+		t.Errorf("Flush() without changes = %q, want nothing", result)
+	}
+
+	c.Set(1, 1, 'A', ansi.Style{})
+	c.Set(3, 2, 'B', ansi.Style{})
+	expected := ansi.ESC + "[2;3HB"
+	if result := flush(t, c); result != expected {
+		t.Errorf("Flush() = %q, want %q", result, expected)
+	}
+}
+
+func TestCanvasFlushRestylesCell(t *testing.T) {
+	c := NewCanvas(1, 1)
+	c.Set(1, 1, 'A', ansi.Style{})
+	flush(t, c)
+
+	c.Set(1, 1, 'A', ansi.Style{FG: 31})
+	expected := ansi.ESC + "[1;1H" + ansi.ESC + "[31mA" + ansi.ESC + "[0m"
+	if result := flush(t, c); result != expected {
+		t.Errorf("Flush() = %q, want %q", result, expected)
+	}
+}
+
+func TestCanvasClearErasesOnFlush(t *testing.T) {
+	c := NewCanvas(2, 1)
+	c.Set(1, 1, 'A', ansi.Style{})
+	flush(t, c)
+
+	c.Clear()
+	expected := ansi.ESC + "[1;1H "
+	if result := flush(t, c); result != expected {
+		t.Errorf("Flush() = %q, want %q", result, expected)
+	}
+}
+
+func TestCanvasSetOutsideIsIgnored(t *testing.T) {
+	c := NewCanvas(2, 2)
+	for _, p := range [][2]int{{0, 1}, {1, 0}, {3, 1}, {1, 3}, {-5, -5}} {
+		c.Set(p[0], p[1], 'X', ansi.Style{})
+	}
+	if result := flush(t, c); strings.ContainsRune(result, 'X') {
+		t.Errorf("Flush() = %q, want no X", result)
+	}
+	if cell := c.Get(3, 1); cell != blank {
+		t.Errorf("Get() outside = %+v, want blank", cell)
+	}
+}
+
+func TestCanvasDrawLine(t *testing.T) {
+	c := NewCanvas(5, 5)
+	style := ansi.Style{Bold: true}
+	c.DrawLine(1, 1, 5, 3, '*', style)
+
+	for _, p := range LinePoints(1, 1, 5, 3) {
+		if cell := c.Get(p[0], p[1]); cell != (Cell{'*', style}) {
+			t.Errorf("Get(%d, %d) = %+v, want bold *", p[0], p[1], cell)
+		}
+	}
+	if cell := c.Get(5, 1); cell != blank {
+		t.Errorf("Get(5, 1) = %+v, want blank", cell)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestCanvasFlushErrorKeepsChanges(t *testing.T) {
+	c := NewCanvas(1, 1)
+	flush(t, c)
+
+	c.Set(1, 1, 'A', ansi.Style{})
+	if err := c.Flush(failingWriter{}); err == nil {
+		t.Fatal("Flush() error = nil, want the writer's error")
+	}
+	expected := ansi.ESC + "[1;1HA"
+	if result := flush(t, c); result != expected {
+		t.Errorf("Flush() after failure = %q, want %q", result, expected)
+	}
+}
+
+func TestCanvasText(t *testing.T) {
+	c := NewCanvas(4, 3)
+	c.Set(1, 1, 'A', ansi.Style{})
+	c.Set(3, 1, 'B', ansi.Style{FG: 32})
+	c.Set(2, 3, 'C', ansi.Style{})
+
+	expected := "A " + ansi.ESC + "[32mB" + ansi.ESC + "[0m\n\n C\n"
+	if result := c.Text(); result != expected {
+		t.Errorf("Text() = %q, want %q", result, expected)
+	}
+}
+
+func TestCanvasResize(t *testing.T) {
+	c := NewCanvas(3, 2)
+	c.Set(1, 1, 'A', ansi.Style{})
+	c.Set(3, 1, 'B', ansi.Style{})
+	c.Set(2, 2, 'C', ansi.Style{})
+	flush(t, c)
+
+	c.Resize(2, 3)
+	if c.Width() != 2 || c.Height() != 3 {
+		t.Fatalf("size = %dx%d, want 2x3", c.Width(), c.Height())
+	}
+	if expected := "A\n C\n\n"; c.Text() != expected {
+		t.Errorf("Text() = %q, want %q", c.Text(), expected)
+	}
+
+	expected := ansi.ESC + "[1;1HA" + ansi.ESC + "[1;2H " + ansi.ESC + "[2;1H " + ansi.ESC + "[2;2HC" +
+		ansi.ESC + "[3;1H " + ansi.ESC + "[3;2H "
+	if result := flush(t, c); result != expected {
+		t.Errorf("Flush() after Resize = %q, want a full redraw %q", result, expected)
+	}
+}
+
+func TestCanvasPrint(t *testing.T) {
+	c := NewCanvas(5, 2)
+	c.Print(2, 1, "héllo", ansi.Style{})
+	c.Print(-1, 2, "abc", ansi.Style{})
+
+	if expected := " héll\nc\n"; c.Text() != expected {
+		t.Errorf("Text() = %q, want %q", c.Text(), expected)
+	}
+}
+
+// a full redraw of a large terminal has to fit well inside a 60 fps frame
+func BenchmarkCanvasFlushFullScreen(b *testing.B) {
+	c := NewCanvas(200, 60)
+	for y := 1; y <= c.Height(); y++ {
+		c.Print(1, y, strings.Repeat("#", c.Width()), ansi.Style{FG: 32})
+	}
+	for i := 0; i < b.N; i++ {
+		c.flushed = nil
+		if err := c.Flush(io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
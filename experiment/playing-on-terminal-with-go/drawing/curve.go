@@ -0,0 +1,112 @@
+package drawing
+
+import (
+	"math"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+func DrawQuadratic(x0, y0, x1, y1, x2, y2 int, char rune) string {
+	return DrawPoints(QuadraticPoints(x0, y0, x1, y1, x2, y2), char)
+}
+
+// a quadratic Bezier from (x0, y0) to (x2, y2), pulled toward (x1, y1)
+func QuadraticPoints(x0, y0, x1, y1, x2, y2 int) [][2]int {
+	px := [3]float64{float64(x0), float64(x1), float64(x2)}
+	py := [3]float64{float64(y0), float64(y1), float64(y2)}
+	return curvePoints(polygonLength(px[:], py[:]), func(t float64) (float64, float64) {
+		a, b, c := (1-t)*(1-t), 2*(1-t)*t, t*t
+		return a*px[0] + b*px[1] + c*px[2], a*py[0] + b*py[1] + c*py[2]
+	})
+}
+
+func DrawCubic(x0, y0, x1, y1, x2, y2, x3, y3 int, char rune) string {
+	return DrawPoints(CubicPoints(x0, y0, x1, y1, x2, y2, x3, y3), char)
+}
+
+// a cubic Bezier from (x0, y0) to (x3, y3), leaving toward (x1, y1) and
+// arriving from (x2, y2)
+func CubicPoints(x0, y0, x1, y1, x2, y2, x3, y3 int) [][2]int {
+	px := [4]float64{float64(x0), float64(x1), float64(x2), float64(x3)}
+	py := [4]float64{float64(y0), float64(y1), float64(y2), float64(y3)}
+	return curvePoints(polygonLength(px[:], py[:]), func(t float64) (float64, float64) {
+		u := 1 - t
+		a, b, c, d := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+		return a*px[0] + b*px[1] + c*px[2] + d*px[3], a*py[0] + b*py[1] + c*py[2] + d*py[3]
+	})
+}
+
+func DrawArc(cx, cy, r int, from, to float64, char rune) string {
+	return DrawPoints(ArcPoints(cx, cy, r, from, to), char)
+}
+
+// the part of the circle around (cx, cy) from angle from to angle to, in
+// degrees counterclockwise from the right as seen on screen, where y grows
+// downward; to below from sweeps clockwise instead
+func ArcPoints(cx, cy, r int, from, to float64) [][2]int {
+	if r < 0 {
+		return nil
+	}
+	start, sweep := from*math.Pi/180, (to-from)*math.Pi/180
+	steps := int(math.Ceil(math.Abs(sweep) * float64(r)))
+	return curvePoints(steps, func(t float64) (float64, float64) {
+		angle := start + sweep*t
+		return float64(cx) + float64(r)*math.Cos(angle), float64(cy) - float64(r)*math.Sin(angle)
+	})
+}
+
+// curvePoints samples f from t = 0 to 1 in steps, about one cell apart
+// when steps is the curve's length, and joins the cells the samples round
+// to with lines, so the curve has no gaps however it bends
+func curvePoints(steps int, f func(t float64) (float64, float64)) [][2]int {
+	at := func(t float64) [2]int {
+		x, y := f(t)
+		return [2]int{int(math.Round(x)), int(math.Round(y))}
+	}
+
+	prev := at(0)
+	points := [][2]int{prev}
+	for i := 1; i <= steps; i++ {
+		next := at(float64(i) / float64(steps))
+		if next == prev {
+			continue
+		}
+		points = append(points, LinePoints(prev[0], prev[1], next[0], next[1])[1:]...)
+		prev = next
+	}
+
+	// where rounding turned a corner the curve cuts diagonally, drop the
+	// corner cell, as a line would not have drawn it
+	thin := points[:1]
+	for i := 1; i < len(points); i++ {
+		if i+1 < len(points) && touching(thin[len(thin)-1], points[i+1]) {
+			continue
+		}
+		thin = append(thin, points[i])
+	}
+	return thin
+}
+
+// whether two different cells share a side or a corner
+func touching(a, b [2]int) bool {
+	dx, dy := abs(a[0]-b[0]), abs(a[1]-b[1])
+	return max(dx, dy) == 1
+}
+
+// the length of the polygon through the control points, which no Bezier
+// curve on them is longer than
+func polygonLength(xs, ys []float64) int {
+	length := 0.0
+	for i := 1; i < len(xs); i++ {
+		length += math.Hypot(xs[i]-xs[i-1], ys[i]-ys[i-1])
+	}
+	return int(math.Ceil(length))
+}
+
+// Plot sets every point to char, like the string Draw functions do, so
+// the *Points helpers draw on a canvas
+func (c *Canvas) Plot(points [][2]int, char rune, style ansi.Style) {
+	for _, p := range points {
+		c.Set(p[0], p[1], char, style)
+	}
+}
@@ -0,0 +1,160 @@
+package drawing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+// cellsOf is what the Draw functions print for char at each cell in turn
+func cellsOf(char rune, cells ...[2]int) string {
+	result := ""
+	for _, c := range cells {
+		result += ansi.PrintAtCoordinates(c[0], c[1], char)
+	}
+	return result
+}
+
+func TestDrawQuadratic(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   string
+		expected string
+	}{
+		{
+			name:     "hump",
+			result:   DrawQuadratic(1, 5, 5, 1, 9, 5, '#'),
+			expected: cellsOf('#', [2]int{1, 5}, [2]int{2, 4}, [2]int{3, 4}, [2]int{4, 3}, [2]int{5, 3}, [2]int{6, 3}, [2]int{7, 4}, [2]int{8, 4}, [2]int{9, 5}),
+		},
+		{
+			name:     "control point on the line",
+			result:   DrawQuadratic(1, 1, 3, 1, 5, 1, '#'),
+			expected: DrawLine(1, 1, 5, 1, '#'),
+		},
+		{
+			name:     "single point",
+			result:   DrawQuadratic(2, 2, 2, 2, 2, 2, '#'),
+			expected: cellsOf('#', [2]int{2, 2}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.result != test.expected {
+				t.Errorf("got %q, want %q", test.result, test.expected)
+			}
+		})
+	}
+}
+
+func TestDrawCubic(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   string
+		expected string
+	}{
+		{
+			name:     "s curve",
+			result:   DrawCubic(1, 5, 3, 1, 7, 9, 9, 5, '#'),
+			expected: cellsOf('#', [2]int{1, 5}, [2]int{2, 4}, [2]int{3, 4}, [2]int{4, 4}, [2]int{5, 5}, [2]int{6, 6}, [2]int{7, 6}, [2]int{8, 6}, [2]int{9, 5}),
+		},
+		{
+			name:     "straight",
+			result:   DrawCubic(1, 1, 1, 2, 1, 3, 1, 4, '#'),
+			expected: DrawLine(1, 1, 1, 4, '#'),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.result != test.expected {
+				t.Errorf("got %q, want %q", test.result, test.expected)
+			}
+		})
+	}
+}
+
+func TestDrawArc(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   string
+		expected string
+	}{
+		{
+			name:   "upper half",
+			result: DrawArc(6, 6, 4, 0, 180, 'o'),
+			expected: cellsOf('o', [2]int{10, 6}, [2]int{10, 5}, [2]int{10, 4}, [2]int{9, 3}, [2]int{8, 3}, [2]int{7, 2},
+				[2]int{6, 2}, [2]int{5, 2}, [2]int{4, 3}, [2]int{3, 3}, [2]int{2, 4}, [2]int{2, 5}, [2]int{2, 6}),
+		},
+		{
+			name:     "quarter clockwise",
+			result:   DrawArc(3, 3, 2, 0, -90, 'o'),
+			expected: cellsOf('o', [2]int{5, 3}, [2]int{4, 4}, [2]int{3, 5}),
+		},
+		{
+			name:     "radius zero",
+			result:   DrawArc(3, 3, 0, 0, 360, 'o'),
+			expected: cellsOf('o', [2]int{3, 3}),
+		},
+		{
+			name:     "negative radius",
+			result:   DrawArc(3, 3, -1, 0, 360, 'o'),
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.result != test.expected {
+				t.Errorf("got %q, want %q", test.result, test.expected)
+			}
+		})
+	}
+}
+
+func TestCurvesAreConnected(t *testing.T) {
+	curves := map[string][][2]int{
+		"quadratic": QuadraticPoints(2, 30, 40, -20, 70, 25),
+		"cubic":     CubicPoints(5, 5, 60, 0, -20, 40, 50, 30),
+		"loop":      CubicPoints(10, 10, 40, 30, -10, 30, 20, 10),
+		"arc":       ArcPoints(30, 30, 17, 45, 300),
+	}
+
+	for name, points := range curves {
+		for i := 1; i < len(points); i++ {
+			if !touching(points[i-1], points[i]) {
+				t.Errorf("%s: gap between %v and %v", name, points[i-1], points[i])
+			}
+			if i >= 2 && touching(points[i-2], points[i]) {
+				t.Errorf("%s: corner cell %v between %v and %v", name, points[i-1], points[i-2], points[i])
+			}
+		}
+	}
+}
+
+func TestArcStaysOnCircle(t *testing.T) {
+	for _, r := range []int{1, 3, 8, 20} {
+		points := ArcPoints(30, 30, r, 0, 360)
+		if points[0] != [2]int{30 + r, 30} {
+			t.Errorf("r=%d: arc starts at %v, want %v", r, points[0], [2]int{30 + r, 30})
+		}
+		for _, p := range points {
+			dx, dy := float64(p[0]-30), float64(p[1]-30)
+			if off := math.Abs(math.Hypot(dx, dy) - float64(r)); off > 0.75 {
+				t.Errorf("r=%d: cell %v is %.2f cells off the circle", r, p, off)
+			}
+		}
+	}
+}
+
+func TestCanvasPlot(t *testing.T) {
+	c := NewCanvas(5, 3)
+	c.Plot(ArcPoints(3, 3, 2, 0, 180), '*', ansi.Style{FG: 33})
+	if expected := " *** \n*   *\n*   *"; plain(c) != expected {
+		t.Errorf("Plot() =\n%s\nwant\n%s", plain(c), expected)
+	}
+	if cell := c.Get(3, 1); cell.Style != (ansi.Style{FG: 33}) {
+		t.Errorf("Get(3, 1) style = %+v, want yellow", cell.Style)
+	}
+}
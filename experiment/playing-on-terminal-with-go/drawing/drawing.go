@@ -3,16 +3,45 @@ package drawing
 import "github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
 
 func DrawLine(x1, y1, x2, y2 int, char rune) string {
-	if y1 == y2 {
-		return DrawHorizontalLine(x1, x2, y1, char)
+	return DrawPoints(LinePoints(x1, y1, x2, y2), char)
+}
+
+// Bresenham: step along the longer axis, and along the other one whenever the
+// accumulated error says the true line has moved half a cell
+func LinePoints(x1, y1, x2, y2 int) [][2]int {
+	dx, dy := abs(x2-x1), -abs(y2-y1)
+	sx, sy := 1, 1
+	if x1 > x2 {
+		sx = -1
+	}
+	if y1 > y2 {
+		sy = -1
 	}
-	if x1 == x2 {
-		return DrawVerticalLine(x1, y1, y2, char)
+
+	var points [][2]int
+	err := dx + dy
+	for x, y := x1, y1; ; {
+		points = append(points, [2]int{x, y})
+		if x == x2 && y == y2 {
+			return points
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y += sy
+		}
 	}
-	if x1 < x2 && y1 < y2 {
-		return DrawDiagonalLine(x1, y1, x2, y2, char)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
 	}
-	return ""
+	return n
 }
 
 func DrawHorizontalLine(x1, x2, y int, char rune) string {
@@ -38,3 +67,132 @@ func DrawDiagonalLine(x1, y1, x2, y2 int, char rune) string {
 	}
 	return result
 }
+
+// top and bottom edges first, then the sides between them, each cell once
+func DrawRectangle(x, y, w, h int, char rune) string {
+	if w <= 0 || h <= 0 {
+		return ""
+	}
+	right, bottom := x+w-1, y+h-1
+
+	result := DrawHorizontalLine(x, right, y, char)
+	if h == 1 {
+		return result
+	}
+	result += DrawHorizontalLine(x, right, bottom, char)
+	if h == 2 {
+		return result
+	}
+	result += DrawVerticalLine(x, y+1, bottom-1, char)
+	if w > 1 {
+		result += DrawVerticalLine(right, y+1, bottom-1, char)
+	}
+	return result
+}
+
+func DrawFilledRectangle(x, y, w, h int, char rune) string {
+	if w <= 0 || h <= 0 {
+		return ""
+	}
+	result := ""
+	for row := y; row < y+h; row++ {
+		result += DrawHorizontalLine(x, x+w-1, row, char)
+	}
+	return result
+}
+
+func DrawCircle(cx, cy, r int, char rune) string {
+	return DrawPoints(CirclePoints(cx, cy, r), char)
+}
+
+// midpoint circle: walk one octant from (r, 0) and mirror each cell into the
+// other seven; cells where octants meet repeat, which DrawPoints skips
+func CirclePoints(cx, cy, r int) [][2]int {
+	if r < 0 {
+		return nil
+	}
+	var points [][2]int
+	x, y, d := r, 0, 1-r
+	for x >= y {
+		points = append(points,
+			[2]int{cx + x, cy + y}, [2]int{cx + y, cy + x},
+			[2]int{cx - y, cy + x}, [2]int{cx - x, cy + y},
+			[2]int{cx - x, cy - y}, [2]int{cx - y, cy - x},
+			[2]int{cx + y, cy - x}, [2]int{cx + x, cy - y},
+		)
+		y++
+		if d < 0 {
+			d += 2*y + 1
+		} else {
+			x--
+			d += 2*(y-x) + 1
+		}
+	}
+	return points
+}
+
+// midpoint ellipse: region 1 steps x while the curve is flatter than 45°,
+// region 2 steps y for the rest; each cell is mirrored into all four quadrants
+func DrawEllipse(cx, cy, rx, ry int, char rune) string {
+	if rx < 0 || ry < 0 {
+		return ""
+	}
+	if rx == 0 || ry == 0 {
+		return DrawLine(cx-rx, cy-ry, cx+rx, cy+ry, char)
+	}
+
+	var points [][2]int
+	mirror := func(x, y int) {
+		points = append(points,
+			[2]int{cx + x, cy + y}, [2]int{cx - x, cy + y},
+			[2]int{cx - x, cy - y}, [2]int{cx + x, cy - y},
+		)
+	}
+
+	rx2, ry2 := float64(rx*rx), float64(ry*ry)
+	x, y := 0, ry
+	dx, dy := 0.0, 2*rx2*float64(y)
+	d1 := ry2 - rx2*float64(ry) + rx2/4
+	for dx < dy {
+		mirror(x, y)
+		x++
+		dx += 2 * ry2
+		if d1 < 0 {
+			d1 += dx + ry2
+		} else {
+			y--
+			dy -= 2 * rx2
+			d1 += dx - dy + ry2
+		}
+	}
+
+	fx, fy := float64(x)+0.5, float64(y-1)
+	d2 := ry2*fx*fx + rx2*fy*fy - rx2*ry2
+	for y >= 0 {
+		mirror(x, y)
+		y--
+		dy -= 2 * rx2
+		if d2 > 0 {
+			d2 += rx2 - dy
+		} else {
+			x++
+			dx += 2 * ry2
+			d2 += dx - dy + rx2
+		}
+	}
+	return DrawPoints(points, char)
+}
+
+// draws each distinct point once, in order
+func DrawPoints(points [][2]int, char rune) string {
+	seen := make(map[[2]int]bool, len(points))
+	result := ""
+	for _, p := range points {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		result += ansi.PrintAtCoordinates(p[0], p[1], char)
+	}
+	return result
+}
@@ -1,6 +1,9 @@
 package drawing
 
 import (
+	"fmt"
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
@@ -40,6 +43,69 @@ func TestDrawLine(t *testing.T) {
 			char:     'X',
 			expected: ansi.ESC + "[1;1HX" + ansi.ESC + "[2;2HX" + ansi.ESC + "[3;3HX" + ansi.ESC + "[4;4HX" + ansi.ESC + "[5;5HX",
 		},
+		{
+			name:     "shallow slope",
+			x1:       1,
+			y1:       1,
+			x2:       5,
+			y2:       3,
+			char:     'X',
+			expected: ansi.ESC + "[1;1HX" + ansi.ESC + "[2;2HX" + ansi.ESC + "[2;3HX" + ansi.ESC + "[3;4HX" + ansi.ESC + "[3;5HX",
+		},
+		{
+			name:     "steep slope",
+			x1:       1,
+			y1:       1,
+			x2:       3,
+			y2:       5,
+			char:     'X',
+			expected: ansi.ESC + "[1;1HX" + ansi.ESC + "[2;2HX" + ansi.ESC + "[3;2HX" + ansi.ESC + "[4;3HX" + ansi.ESC + "[5;3HX",
+		},
+		{
+			name:     "negative slope",
+			x1:       1,
+			y1:       5,
+			x2:       5,
+			y2:       1,
+			char:     'X',
+			expected: ansi.ESC + "[5;1HX" + ansi.ESC + "[4;2HX" + ansi.ESC + "[3;3HX" + ansi.ESC + "[2;4HX" + ansi.ESC + "[1;5HX",
+		},
+		{
+			name:     "right to left",
+			x1:       5,
+			y1:       1,
+			x2:       1,
+			y2:       1,
+			char:     'X',
+			expected: ansi.ESC + "[1;5HX" + ansi.ESC + "[1;4HX" + ansi.ESC + "[1;3HX" + ansi.ESC + "[1;2HX" + ansi.ESC + "[1;1HX",
+		},
+		{
+			name:     "bottom to top",
+			x1:       2,
+			y1:       4,
+			x2:       2,
+			y2:       1,
+			char:     'X',
+			expected: ansi.ESC + "[4;2HX" + ansi.ESC + "[3;2HX" + ansi.ESC + "[2;2HX" + ansi.ESC + "[1;2HX",
+		},
+		{
+			name:     "up-left diagonal",
+			x1:       3,
+			y1:       3,
+			x2:       1,
+			y2:       1,
+			char:     'X',
+			expected: ansi.ESC + "[3;3HX" + ansi.ESC + "[2;2HX" + ansi.ESC + "[1;1HX",
+		},
+		{
+			name:     "single point",
+			x1:       2,
+			y1:       2,
+			x2:       2,
+			y2:       2,
+			char:     'X',
+			expected: ansi.ESC + "[2;2HX",
+		},
 	}
 
 	for _, test := range tests {
@@ -51,3 +117,299 @@ func TestDrawLine(t *testing.T) {
 		})
 	}
 }
+
+func TestDrawRectangle(t *testing.T) {
+	tests := []struct {
+		name       string
+		x, y, w, h int
+		char       rune
+		expected   string
+	}{
+		{
+			name:     "3x3 outline",
+			x:        1,
+			y:        1,
+			w:        3,
+			h:        3,
+			char:     '#',
+			expected: ansi.ESC + "[1;1H#" + ansi.ESC + "[1;2H#" + ansi.ESC + "[1;3H#" + ansi.ESC + "[3;1H#" + ansi.ESC + "[3;2H#" + ansi.ESC + "[3;3H#" + ansi.ESC + "[2;1H#" + ansi.ESC + "[2;3H#",
+		},
+		{
+			name:     "offset 2x4 outline",
+			x:        5,
+			y:        2,
+			w:        2,
+			h:        4,
+			char:     '*',
+			expected: ansi.ESC + "[2;5H*" + ansi.ESC + "[2;6H*" + ansi.ESC + "[5;5H*" + ansi.ESC + "[5;6H*" + ansi.ESC + "[3;5H*" + ansi.ESC + "[4;5H*" + ansi.ESC + "[3;6H*" + ansi.ESC + "[4;6H*",
+		},
+		{
+			name:     "single row is one line",
+			x:        1,
+			y:        1,
+			w:        3,
+			h:        1,
+			char:     'X',
+			expected: ansi.ESC + "[1;1HX" + ansi.ESC + "[1;2HX" + ansi.ESC + "[1;3HX",
+		},
+		{
+			name:     "single column draws each cell once",
+			x:        1,
+			y:        1,
+			w:        1,
+			h:        3,
+			char:     'X',
+			expected: ansi.ESC + "[1;1HX" + ansi.ESC + "[3;1HX" + ansi.ESC + "[2;1HX",
+		},
+		{
+			name:     "single cell",
+			x:        4,
+			y:        4,
+			w:        1,
+			h:        1,
+			char:     'X',
+			expected: ansi.ESC + "[4;4HX",
+		},
+		{
+			name:     "zero width",
+			x:        1,
+			y:        1,
+			w:        0,
+			h:        3,
+			char:     'X',
+			expected: "",
+		},
+		{
+			name:     "negative height",
+			x:        1,
+			y:        1,
+			w:        3,
+			h:        -1,
+			char:     'X',
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := DrawRectangle(test.x, test.y, test.w, test.h, test.char)
+			if result != test.expected {
+				t.Errorf("DrawRectangle() = %q, want %q", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestDrawFilledRectangle(t *testing.T) {
+	tests := []struct {
+		name       string
+		x, y, w, h int
+		char       rune
+		expected   string
+	}{
+		{
+			name:     "3x2 filled",
+			x:        1,
+			y:        1,
+			w:        3,
+			h:        2,
+			char:     '#',
+			expected: ansi.ESC + "[1;1H#" + ansi.ESC + "[1;2H#" + ansi.ESC + "[1;3H#" + ansi.ESC + "[2;1H#" + ansi.ESC + "[2;2H#" + ansi.ESC + "[2;3H#",
+		},
+		{
+			name:     "offset single column",
+			x:        3,
+			y:        2,
+			w:        1,
+			h:        2,
+			char:     '|',
+			expected: ansi.ESC + "[2;3H|" + ansi.ESC + "[3;3H|",
+		},
+		{
+			name:     "empty",
+			x:        1,
+			y:        1,
+			w:        0,
+			h:        0,
+			char:     'X',
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := DrawFilledRectangle(test.x, test.y, test.w, test.h, test.char)
+			if result != test.expected {
+				t.Errorf("DrawFilledRectangle() = %q, want %q", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestDrawCircle(t *testing.T) {
+	tests := []struct {
+		name      string
+		cx, cy, r int
+		char      rune
+		expected  string
+	}{
+		{
+			name:     "radius 0 is the center",
+			cx:       3,
+			cy:       3,
+			r:        0,
+			char:     'o',
+			expected: ansi.ESC + "[3;3Ho",
+		},
+		{
+			name:     "radius 1",
+			cx:       3,
+			cy:       3,
+			r:        1,
+			char:     'o',
+			expected: ansi.ESC + "[3;4Ho" + ansi.ESC + "[4;3Ho" + ansi.ESC + "[3;2Ho" + ansi.ESC + "[2;3Ho",
+		},
+		{
+			name: "radius 2",
+			cx:   3,
+			cy:   3,
+			r:    2,
+			char: 'o',
+			expected: ansi.ESC + "[3;5Ho" + ansi.ESC + "[5;3Ho" + ansi.ESC + "[3;1Ho" + ansi.ESC + "[1;3Ho" +
+				ansi.ESC + "[4;5Ho" + ansi.ESC + "[5;4Ho" + ansi.ESC + "[5;2Ho" + ansi.ESC + "[4;1Ho" +
+				ansi.ESC + "[2;1Ho" + ansi.ESC + "[1;2Ho" + ansi.ESC + "[1;4Ho" + ansi.ESC + "[2;5Ho",
+		},
+		{
+			name:     "negative radius",
+			cx:       3,
+			cy:       3,
+			r:        -1,
+			char:     'o',
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := DrawCircle(test.cx, test.cy, test.r, test.char)
+			if result != test.expected {
+				t.Errorf("DrawCircle() = %q, want %q", result, test.expected)
+			}
+		})
+	}
+}
+
+// too many cells to spell out for big circles, so check what must hold for
+// any radius: every cell is drawn once and lies within half a cell of the
+// true circle
+func TestDrawCircleStaysOnCircle(t *testing.T) {
+	for r := 1; r <= 20; r++ {
+		cells := parseCells(t, DrawCircle(30, 30, r, 'o'))
+		seen := make(map[[2]int]bool)
+		for _, c := range cells {
+			if seen[c] {
+				t.Errorf("r=%d: cell %v drawn twice", r, c)
+			}
+			seen[c] = true
+
+			dx, dy := float64(c[0]-30), float64(c[1]-30)
+			if off := math.Abs(math.Hypot(dx, dy) - float64(r)); off > 0.5 {
+				t.Errorf("r=%d: cell %v is %.2f cells off the circle", r, c, off)
+			}
+		}
+	}
+}
+
+func TestDrawEllipse(t *testing.T) {
+	tests := []struct {
+		name           string
+		cx, cy, rx, ry int
+		char           rune
+		expected       string
+	}{
+		{
+			name: "wide",
+			cx:   4,
+			cy:   3,
+			rx:   3,
+			ry:   1,
+			char: 'o',
+			expected: ansi.ESC + "[4;4Ho" + ansi.ESC + "[2;4Ho" + ansi.ESC + "[4;5Ho" + ansi.ESC + "[4;3Ho" +
+				ansi.ESC + "[2;3Ho" + ansi.ESC + "[2;5Ho" + ansi.ESC + "[4;6Ho" + ansi.ESC + "[4;2Ho" +
+				ansi.ESC + "[2;2Ho" + ansi.ESC + "[2;6Ho" + ansi.ESC + "[3;7Ho" + ansi.ESC + "[3;1Ho",
+		},
+		{
+			name:     "flat is a horizontal line",
+			cx:       3,
+			cy:       2,
+			rx:       2,
+			ry:       0,
+			char:     '-',
+			expected: ansi.ESC + "[2;1H-" + ansi.ESC + "[2;2H-" + ansi.ESC + "[2;3H-" + ansi.ESC + "[2;4H-" + ansi.ESC + "[2;5H-",
+		},
+		{
+			name:     "thin is a vertical line",
+			cx:       2,
+			cy:       3,
+			rx:       0,
+			ry:       1,
+			char:     '|',
+			expected: ansi.ESC + "[2;2H|" + ansi.ESC + "[3;2H|" + ansi.ESC + "[4;2H|",
+		},
+		{
+			name:     "negative radius",
+			cx:       3,
+			cy:       3,
+			rx:       2,
+			ry:       -2,
+			char:     'o',
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := DrawEllipse(test.cx, test.cy, test.rx, test.ry, test.char)
+			if result != test.expected {
+				t.Errorf("DrawEllipse() = %q, want %q", result, test.expected)
+			}
+		})
+	}
+}
+
+// every cell of an ellipse must be symmetric about both axes through the center
+func TestDrawEllipseIsSymmetric(t *testing.T) {
+	for _, radii := range [][2]int{{1, 1}, {5, 2}, {2, 5}, {10, 4}, {12, 12}} {
+		rx, ry := radii[0], radii[1]
+		cells := parseCells(t, DrawEllipse(30, 30, rx, ry, 'o'))
+		seen := make(map[[2]int]bool)
+		for _, c := range cells {
+			seen[c] = true
+		}
+		for c := range seen {
+			for _, m := range [][2]int{{60 - c[0], c[1]}, {c[0], 60 - c[1]}} {
+				if !seen[m] {
+					t.Errorf("rx=%d ry=%d: %v drawn but not its mirror %v", rx, ry, c, m)
+				}
+			}
+		}
+		if !seen[[2]int{30 + rx, 30}] || !seen[[2]int{30, 30 + ry}] {
+			t.Errorf("rx=%d ry=%d: ellipse misses its axis ends", rx, ry)
+		}
+	}
+}
+
+// parseCells turns drawing output back into the (x, y) cells it prints at
+func parseCells(t *testing.T, s string) [][2]int {
+	t.Helper()
+	var cells [][2]int
+	for _, part := range strings.Split(s, ansi.ESC+"[")[1:] {
+		var x, y int
+		var char rune
+		if _, err := fmt.Sscanf(part, "%d;%dH%c", &y, &x, &char); err != nil {
+			t.Fatalf("cannot parse %q: %v", part, err)
+		}
+		cells = append(cells, [2]int{x, y})
+	}
+	return cells
+}
@@ -0,0 +1,35 @@
+package drawing
+
+import "github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+
+// Fill floods the region around (x, y): every cell reachable through up,
+// down, left and right steps that looks exactly like (x, y) does, character
+// and style both, becomes char in style. Any other cell is a wall, so a
+// shape's outline keeps the fill inside it.
+func (c *Canvas) Fill(x, y int, char rune, style ansi.Style) {
+	start, ok := c.index(x, y)
+	if !ok {
+		return
+	}
+	target, fill := c.cells[start], Cell{Char: char, Style: style}
+	if target == fill {
+		return
+	}
+
+	// a stack of cells still to visit rather than recursion, which a screen
+	// sized region would run deep enough to hurt
+	stack := [][2]int{{x, y}}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		i, ok := c.index(p[0], p[1])
+		if !ok || c.cells[i] != target {
+			continue
+		}
+		c.cells[i] = fill
+		stack = append(stack,
+			[2]int{p[0] + 1, p[1]}, [2]int{p[0] - 1, p[1]},
+			[2]int{p[0], p[1] + 1}, [2]int{p[0], p[1] - 1},
+		)
+	}
+}
@@ -0,0 +1,121 @@
+package drawing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+// fixture builds a canvas from rows of plain characters, with . for blank
+func fixture(rows ...string) *Canvas {
+	c := NewCanvas(len([]rune(rows[0])), len(rows))
+	for y, row := range rows {
+		for x, char := range []rune(row) {
+			if char != '.' {
+				c.Set(x+1, y+1, char, ansi.Style{})
+			}
+		}
+	}
+	return c
+}
+
+// dots is plain with blanks shown as . again
+func dots(c *Canvas) string {
+	return strings.ReplaceAll(plain(c), " ", ".")
+}
+
+func TestFill(t *testing.T) {
+	tests := []struct {
+		name     string
+		canvas   []string
+		x, y     int
+		expected []string
+	}{
+		{
+			name:     "inside a box",
+			canvas:   []string{"#####", "#...#", "#...#", "#####"},
+			x:        3,
+			y:        2,
+			expected: []string{"#####", "#ooo#", "#ooo#", "#####"},
+		},
+		{
+			name:     "outside a box",
+			canvas:   []string{".......", ".###...", ".#.#...", ".###..."},
+			x:        1,
+			y:        1,
+			expected: []string{"ooooooo", "o###ooo", "o#.#ooo", "o###ooo"},
+		},
+		{
+			name:     "diagonal gaps hold",
+			canvas:   []string{"..#", ".#.", "#.."},
+			x:        1,
+			y:        1,
+			expected: []string{"oo#", "o#.", "#.."},
+		},
+		{
+			name:     "winding corridor",
+			canvas:   []string{"#.####", "#.#..#", "#...##", "######"},
+			x:        2,
+			y:        1,
+			expected: []string{"#o####", "#o#oo#", "#ooo##", "######"},
+		},
+		{
+			name:     "replaces a character too",
+			canvas:   []string{"xxx", "x#x", "xx."},
+			x:        1,
+			y:        1,
+			expected: []string{"ooo", "o#o", "oo."},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := fixture(test.canvas...)
+			c.Fill(test.x, test.y, 'o', ansi.Style{})
+			expected := strings.Join(test.expected, "\n")
+			if result := dots(c); result != expected {
+				t.Errorf("Fill() =\n%s\nwant\n%s", result, expected)
+			}
+		})
+	}
+}
+
+func TestFillStyleIsPartOfTheCell(t *testing.T) {
+	c := fixture("xxx")
+	c.Set(2, 1, 'x', ansi.Style{FG: 31})
+	c.Fill(1, 1, 'o', ansi.Style{})
+	if expected := "oxx"; dots(c) != expected {
+		t.Errorf("Fill() = %s, want %s: the red x is a wall", dots(c), expected)
+	}
+	if c.Get(2, 1).Style.FG != 31 {
+		t.Error("Fill() changed the red x")
+	}
+}
+
+func TestFillSameCellIsNoOp(t *testing.T) {
+	c := fixture("oo", "o#")
+	c.Fill(1, 1, 'o', ansi.Style{})
+	if expected := "oo\no#"; dots(c) != expected {
+		t.Errorf("Fill() =\n%s\nwant\n%s", dots(c), expected)
+	}
+}
+
+func TestFillOutsideIsIgnored(t *testing.T) {
+	c := fixture("..", "..")
+	c.Fill(3, 1, 'o', ansi.Style{})
+	c.Fill(0, 0, 'o', ansi.Style{})
+	if expected := "..\n.."; dots(c) != expected {
+		t.Errorf("Fill() outside =\n%s\nwant nothing filled", dots(c))
+	}
+}
+
+func TestFillLargeRegion(t *testing.T) {
+	c := NewCanvas(300, 200)
+	c.Fill(150, 100, 'o', ansi.Style{})
+	for _, p := range [][2]int{{1, 1}, {300, 200}, {1, 200}, {300, 1}} {
+		if c.Get(p[0], p[1]).Char != 'o' {
+			t.Fatalf("Get(%d, %d) not filled", p[0], p[1])
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package drawing
+
+import "github.com/e6a5/learning/experiment/ternimal-with-go/terminal"
+
+// what to do with points outside the visible area
+type Overflow int
+
+const (
+	// leave them; the terminal decides, usually by pinning them to the edge
+	Ignore Overflow = iota
+	// pull each one onto the nearest visible cell
+	Clamp
+	// refuse to draw anything
+	Fail
+)
+
+func ParseOverflow(name string) (Overflow, bool) {
+	switch name {
+	case "ignore":
+		return Ignore, true
+	case "clamp":
+		return Clamp, true
+	case "error":
+		return Fail, true
+	}
+	return Ignore, false
+}
+
+// Fit applies o to the points outside b. With Fail the error names the
+// first such point and wraps terminal.ErrOutOfBounds.
+func Fit(points [][2]int, b terminal.Bounds, o Overflow) ([][2]int, error) {
+	if o == Ignore {
+		return points, nil
+	}
+	fitted := make([][2]int, 0, len(points))
+	for _, p := range points {
+		if o == Fail {
+			if err := b.Check(p[0], p[1]); err != nil {
+				return nil, err
+			}
+			fitted = append(fitted, p)
+			continue
+		}
+		x, y := b.Clamp(p[0], p[1])
+		fitted = append(fitted, [2]int{x, y})
+	}
+	return fitted, nil
+}
+
+// FitTerminal is Fit against the current terminal size, which it only asks
+// for when o needs it
+func FitTerminal(points [][2]int, o Overflow) ([][2]int, error) {
+	if o == Ignore {
+		return points, nil
+	}
+	b, err := terminal.Size()
+	if err != nil {
+		return nil, err
+	}
+	return Fit(points, b, o)
+}
@@ -0,0 +1,71 @@
+package drawing
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/terminal"
+)
+
+func TestFit(t *testing.T) {
+	b := terminal.Bounds{Width: 10, Height: 5}
+	points := [][2]int{{3, 3}, {12, 4}, {0, 9}}
+
+	tests := []struct {
+		name     string
+		overflow Overflow
+		expected [][2]int
+		wantErr  bool
+	}{
+		{"ignore", Ignore, points, false},
+		{"clamp", Clamp, [][2]int{{3, 3}, {10, 4}, {1, 5}}, false},
+		{"error", Fail, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Fit(points, b, test.overflow)
+			if (err != nil) != test.wantErr {
+				t.Errorf("Fit() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if err != nil && !errors.Is(err, terminal.ErrOutOfBounds) {
+				t.Errorf("Fit() error = %v, want ErrOutOfBounds", err)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("Fit() = %v, want %v", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestFitInsideIsUnchanged(t *testing.T) {
+	b := terminal.Bounds{Width: 10, Height: 10}
+	points := CirclePoints(5, 5, 3)
+	for _, o := range []Overflow{Ignore, Clamp, Fail} {
+		result, err := Fit(points, b, o)
+		if err != nil || !reflect.DeepEqual(result, points) {
+			t.Errorf("Fit(%v) = %v, %v, want the points unchanged", o, result, err)
+		}
+	}
+}
+
+func TestParseOverflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected Overflow
+		ok       bool
+	}{
+		{"ignore", Ignore, true},
+		{"clamp", Clamp, true},
+		{"error", Fail, true},
+		{"wrap", Ignore, false},
+	}
+
+	for _, test := range tests {
+		result, ok := ParseOverflow(test.name)
+		if result != test.expected || ok != test.ok {
+			t.Errorf("ParseOverflow(%q) = %v, %v, want %v, %v", test.name, result, ok, test.expected, test.ok)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package drawing
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+// Sprite is multi-cell art. Spaces in it are see-through: drawing a sprite
+// leaves whatever the canvas had under them.
+type Sprite struct {
+	Width, Height int
+	rows          [][]Cell
+}
+
+// the letters a color mask may use; an uppercase one is also bold, and a
+// space or . keeps the terminal's own color
+var maskColors = map[rune]string{
+	'k': "black",
+	'r': "red",
+	'g': "green",
+	'y': "yellow",
+	'b': "blue",
+	'm': "magenta",
+	'c': "cyan",
+	'w': "white",
+}
+
+// ParseSprite reads the sprite format: the art line by line, then optionally
+// a line of just "---" and a color mask laid over the art cell for cell.
+//
+//	 /\_/\
+//	( o.o )
+//	---
+//	 yyyyy
+//	y GwG y
+//
+// A mask may be shorter than the art; cells it does not reach keep the
+// terminal's color.
+func ParseSprite(r io.Reader) (*Sprite, error) {
+	var art, mask []string
+	inMask := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case line == "---" && !inMask:
+			inMask = true
+		case inMask:
+			mask = append(mask, line)
+		default:
+			art = append(art, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(mask) > len(art) {
+		return nil, fmt.Errorf("color mask has %d lines, art only %d", len(mask), len(art))
+	}
+
+	s := TextSprite(ansi.Style{}, art...)
+	for y, line := range mask {
+		for x, letter := range []rune(line) {
+			if x >= len(s.rows[y]) {
+				return nil, fmt.Errorf("color mask line %d is longer than its art", y+1)
+			}
+			style, err := maskStyle(letter)
+			if err != nil {
+				return nil, fmt.Errorf("color mask line %d: %w", y+1, err)
+			}
+			s.rows[y][x].Style = style
+		}
+	}
+	return s, nil
+}
+
+// TextSprite makes a sprite of lines drawn all in one style
+func TextSprite(style ansi.Style, lines ...string) *Sprite {
+	s := &Sprite{Height: len(lines), rows: make([][]Cell, len(lines))}
+	for y, line := range lines {
+		for _, char := range line {
+			s.rows[y] = append(s.rows[y], Cell{Char: char, Style: style})
+		}
+		s.Width = max(s.Width, len(s.rows[y]))
+	}
+	return s
+}
+
+func maskStyle(letter rune) (ansi.Style, error) {
+	if letter == ' ' || letter == '.' {
+		return ansi.Style{}, nil
+	}
+	name, ok := maskColors[unicode.ToLower(letter)]
+	if !ok {
+		return ansi.Style{}, fmt.Errorf("unknown color %q", letter)
+	}
+	return ansi.Style{FG: ansi.ColorCode(name), Bold: unicode.IsUpper(letter)}, nil
+}
+
+func LoadSprite(path string) (*Sprite, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s, err := ParseSprite(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// DrawSprite puts s on the canvas with its top-left corner at (x, y); any
+// part off the canvas is cut off
+func (c *Canvas) DrawSprite(x, y int, s *Sprite) {
+	for dy, row := range s.rows {
+		for dx, cell := range row {
+			if cell.Char != ' ' {
+				c.Set(x+dx, y+dy, cell.Char, cell.Style)
+			}
+		}
+	}
+}
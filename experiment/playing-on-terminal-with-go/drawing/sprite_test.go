@@ -0,0 +1,107 @@
+package drawing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+func TestLoadSprite(t *testing.T) {
+	s, err := LoadSprite("testdata/cat.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Width != 7 || s.Height != 3 {
+		t.Errorf("size = %dx%d, want 7x3", s.Width, s.Height)
+	}
+
+	c := NewCanvas(7, 3)
+	c.DrawSprite(1, 1, s)
+	if expected := " /\\_/\\ \n( o.o )\n > ^ < "; plain(c) != expected {
+		t.Errorf("DrawSprite() =\n%s\nwant\n%s", plain(c), expected)
+	}
+
+	checks := []struct {
+		x, y  int
+		style ansi.Style
+	}{
+		{2, 1, ansi.Style{FG: 33}},
+		{3, 2, ansi.Style{FG: 32, Bold: true}},
+		{4, 2, ansi.Style{FG: 37}},
+		{1, 2, ansi.Style{FG: 33}},
+		{4, 3, ansi.Style{FG: 31}},
+	}
+	for _, check := range checks {
+		if cell := c.Get(check.x, check.y); cell.Style != check.style {
+			t.Errorf("Get(%d, %d) style = %+v, want %+v", check.x, check.y, cell.Style, check.style)
+		}
+	}
+}
+
+func TestLoadSpriteWithoutMask(t *testing.T) {
+	s, err := LoadSprite("testdata/plain.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCanvas(3, 3)
+	c.DrawSprite(1, 1, s)
+	if c.Get(1, 1) != (Cell{Char: '+'}) {
+		t.Errorf("Get(1, 1) = %+v, want an unstyled +", c.Get(1, 1))
+	}
+}
+
+func TestSpriteSpacesAreSeeThrough(t *testing.T) {
+	s, err := ParseSprite(strings.NewReader("a b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := fixture("xxxx")
+	c.DrawSprite(2, 1, s)
+	if expected := "xaxb"; dots(c) != expected {
+		t.Errorf("DrawSprite() = %s, want %s", dots(c), expected)
+	}
+}
+
+func TestDrawSpriteClips(t *testing.T) {
+	s, err := LoadSprite("testdata/rocket.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCanvas(3, 2)
+	c.DrawSprite(0, -1, s)
+	if expected := "|=|\n|_|"; plain(c) != expected {
+		t.Errorf("DrawSprite() =\n%s\nwant\n%s", plain(c), expected)
+	}
+}
+
+func TestParseSpriteErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"unknown color", "ab\n---\nzz", "unknown color 'z'"},
+		{"mask too wide", "ab\n---\nrrr", "longer than its art"},
+		{"mask too tall", "ab\n---\nrr\nrr", "art only 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseSprite(strings.NewReader(test.input))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("ParseSprite() error = %v, want one mentioning %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadSpriteNamesTheFile(t *testing.T) {
+	_, err := LoadSprite("testdata/bad-color.txt")
+	if err == nil || !strings.HasPrefix(err.Error(), "testdata/bad-color.txt: ") {
+		t.Errorf("LoadSprite() error = %v, want it to start with the path", err)
+	}
+	if _, err := LoadSprite("testdata/missing.txt"); err == nil {
+		t.Error("LoadSprite() of a missing file succeeded")
+	}
+}
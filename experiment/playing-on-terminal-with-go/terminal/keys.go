@@ -0,0 +1,103 @@
+package terminal
+
+import (
+	"bufio"
+	"io"
+)
+
+type KeyKind int
+
+const (
+	KeyRune KeyKind = iota // a printable character, in Key.Rune
+	KeyUp
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyEscape
+	KeyCtrl  // a control character; Key.Rune holds its letter, 's' for ctrl-S
+	KeyMouse // a mouse event, in Key.Mouse; see ansi.EnableMouse
+)
+
+type Key struct {
+	Kind  KeyKind
+	Rune  rune
+	Mouse Mouse
+}
+
+// KeyReader decodes what a raw terminal sends into keys
+type KeyReader struct {
+	r *bufio.Reader
+}
+
+func NewKeyReader(r io.Reader) *KeyReader {
+	return &KeyReader{r: bufio.NewReader(r)}
+}
+
+// arrows come as ESC [ A..D and alt+letter as ESC letter, which reads as the
+// letter. An ESC with nothing buffered after it is the escape key itself;
+// other sequences are dropped and the key after them is returned.
+func (k *KeyReader) ReadKey() (Key, error) {
+	ch, _, err := k.r.ReadRune()
+	if err != nil {
+		return Key{}, err
+	}
+
+	switch ch {
+	case '\r', '\n':
+		return Key{Kind: KeyEnter}, nil
+	case '\t':
+		return Key{Kind: KeyTab}, nil
+	case 0x7f, 0x08:
+		return Key{Kind: KeyBackspace}, nil
+	case 0x1b:
+		return k.readEscape()
+	}
+	if ch < 0x20 {
+		return Key{Kind: KeyCtrl, Rune: 'a' + ch - 1}, nil
+	}
+	return Key{Kind: KeyRune, Rune: ch}, nil
+}
+
+func (k *KeyReader) readEscape() (Key, error) {
+	if k.r.Buffered() == 0 {
+		return Key{Kind: KeyEscape}, nil
+	}
+	next, _, err := k.r.ReadRune()
+	if err != nil {
+		return Key{}, err
+	}
+	if next != '[' && next != 'O' {
+		return Key{Kind: KeyRune, Rune: next}, nil
+	}
+	if next == '[' {
+		if peek, err := k.r.Peek(1); err == nil && peek[0] == '<' {
+			k.r.ReadByte()
+			return k.readMouse()
+		}
+	}
+
+	// parameters, then a final letter
+	for {
+		final, _, err := k.r.ReadRune()
+		if err != nil {
+			return Key{}, err
+		}
+		if final >= '0' && final <= '9' || final == ';' {
+			continue
+		}
+		switch final {
+		case 'A':
+			return Key{Kind: KeyUp}, nil
+		case 'B':
+			return Key{Kind: KeyDown}, nil
+		case 'C':
+			return Key{Kind: KeyRight}, nil
+		case 'D':
+			return Key{Kind: KeyLeft}, nil
+		}
+		return k.ReadKey()
+	}
+}
@@ -0,0 +1,72 @@
+package terminal
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Key
+	}{
+		{"letter", "a", Key{Kind: KeyRune, Rune: 'a'}},
+		{"multibyte", "é", Key{Kind: KeyRune, Rune: 'é'}},
+		{"up", "\x1b[A", Key{Kind: KeyUp}},
+		{"down", "\x1b[B", Key{Kind: KeyDown}},
+		{"right", "\x1b[C", Key{Kind: KeyRight}},
+		{"left", "\x1b[D", Key{Kind: KeyLeft}},
+		{"application mode arrow", "\x1bOA", Key{Kind: KeyUp}},
+		{"shift arrow", "\x1b[1;2C", Key{Kind: KeyRight}},
+		{"unknown sequence is skipped", "\x1b[3~x", Key{Kind: KeyRune, Rune: 'x'}},
+		{"alt letter", "\x1bx", Key{Kind: KeyRune, Rune: 'x'}},
+		{"escape", "\x1b", Key{Kind: KeyEscape}},
+		{"enter", "\r", Key{Kind: KeyEnter}},
+		{"tab", "\t", Key{Kind: KeyTab}},
+		{"backspace", "\x7f", Key{Kind: KeyBackspace}},
+		{"ctrl-s", "\x13", Key{Kind: KeyCtrl, Rune: 's'}},
+		{"ctrl-c", "\x03", Key{Kind: KeyCtrl, Rune: 'c'}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := NewKeyReader(strings.NewReader(test.input)).ReadKey()
+			if err != nil {
+				t.Fatalf("ReadKey() error = %v", err)
+			}
+			if result != test.expected {
+				t.Errorf("ReadKey() = %+v, want %+v", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestReadKeySequence(t *testing.T) {
+	keys := NewKeyReader(strings.NewReader("a\x1b[Bb"))
+	expected := []Key{{Kind: KeyRune, Rune: 'a'}, {Kind: KeyDown}, {Kind: KeyRune, Rune: 'b'}}
+	for _, want := range expected {
+		result, err := keys.ReadKey()
+		if err != nil || result != want {
+			t.Fatalf("ReadKey() = %+v, %v, want %+v", result, err, want)
+		}
+	}
+	if _, err := keys.ReadKey(); err != io.EOF {
+		t.Errorf("ReadKey() at end = %v, want io.EOF", err)
+	}
+}
+
+func TestEnableRawOnNonTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	if _, err := EnableRaw(r.Fd()); err == nil {
+		t.Error("EnableRaw(pipe) error = nil, want an error")
+	}
+}
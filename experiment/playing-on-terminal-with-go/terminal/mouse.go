@@ -0,0 +1,89 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type MouseAction int
+
+const (
+	MousePress MouseAction = iota
+	MouseRelease
+	MouseDrag // moved with a button held
+	MouseScrollUp
+	MouseScrollDown
+)
+
+type MouseButton int
+
+const (
+	MouseLeft MouseButton = iota
+	MouseMiddle
+	MouseRight
+	MouseNoButton // scrolling, or a release the terminal did not attribute
+)
+
+// Mouse is where and how the mouse was used; X and Y count from (1,1) like
+// the rest of the screen
+type Mouse struct {
+	Action MouseAction
+	Button MouseButton
+	X, Y   int
+}
+
+// readMouse reads the rest of an SGR report, ESC [ < code ; x ; y then M for
+// a press or drag and m for a release
+func (k *KeyReader) readMouse() (Key, error) {
+	report := ""
+	for {
+		ch, _, err := k.r.ReadRune()
+		if err != nil {
+			return Key{}, err
+		}
+		if ch == 'M' || ch == 'm' {
+			m, err := parseMouse(report, ch == 'm')
+			if err != nil {
+				return Key{}, err
+			}
+			return Key{Kind: KeyMouse, Mouse: m}, nil
+		}
+		report += string(ch)
+	}
+}
+
+func parseMouse(report string, released bool) (Mouse, error) {
+	fields := strings.Split(report, ";")
+	if len(fields) != 3 {
+		return Mouse{}, fmt.Errorf("mouse report %q: want code;x;y", report)
+	}
+	var nums [3]int
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return Mouse{}, fmt.Errorf("mouse report %q: %w", report, err)
+		}
+		nums[i] = n
+	}
+	code, x, y := nums[0], nums[1], nums[2]
+
+	// the low two bits pick the button; 32 flags motion and 64 the wheel,
+	// while 4, 8 and 16 are shift, meta and ctrl, which paint has no use for
+	m := Mouse{Button: MouseButton(code & 3), X: x, Y: y}
+	switch {
+	case code&64 != 0:
+		m.Button = MouseNoButton
+		m.Action = MouseScrollUp
+		if code&1 != 0 {
+			m.Action = MouseScrollDown
+		}
+	case released:
+		m.Action = MouseRelease
+	case code&32 != 0:
+		m.Action = MouseDrag
+	default:
+		m.Action = MousePress
+	}
+	return m, nil
+}
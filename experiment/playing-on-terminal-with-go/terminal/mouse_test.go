@@ -0,0 +1,56 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadKeyMouse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Mouse
+	}{
+		{"left press", "\x1b[<0;5;10M", Mouse{MousePress, MouseLeft, 5, 10}},
+		{"left release", "\x1b[<0;5;10m", Mouse{MouseRelease, MouseLeft, 5, 10}},
+		{"middle press", "\x1b[<1;1;1M", Mouse{MousePress, MouseMiddle, 1, 1}},
+		{"right press", "\x1b[<2;80;24M", Mouse{MousePress, MouseRight, 80, 24}},
+		{"left drag", "\x1b[<32;6;10M", Mouse{MouseDrag, MouseLeft, 6, 10}},
+		{"right drag", "\x1b[<34;6;10M", Mouse{MouseDrag, MouseRight, 6, 10}},
+		{"ctrl left press", "\x1b[<16;3;4M", Mouse{MousePress, MouseLeft, 3, 4}},
+		{"scroll up", "\x1b[<64;3;4M", Mouse{MouseScrollUp, MouseNoButton, 3, 4}},
+		{"scroll down", "\x1b[<65;3;4M", Mouse{MouseScrollDown, MouseNoButton, 3, 4}},
+		{"wide screen", "\x1b[<0;300;120M", Mouse{MousePress, MouseLeft, 300, 120}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := NewKeyReader(strings.NewReader(test.input)).ReadKey()
+			if err != nil {
+				t.Fatalf("ReadKey() error = %v", err)
+			}
+			expected := Key{Kind: KeyMouse, Mouse: test.expected}
+			if result != expected {
+				t.Errorf("ReadKey() = %+v, want %+v", result, expected)
+			}
+		})
+	}
+}
+
+func TestReadKeyMouseThenKey(t *testing.T) {
+	keys := NewKeyReader(strings.NewReader("\x1b[<0;2;3M\x1b[A"))
+	if key, err := keys.ReadKey(); err != nil || key.Kind != KeyMouse {
+		t.Fatalf("ReadKey() = %+v, %v, want a mouse event", key, err)
+	}
+	if key, err := keys.ReadKey(); err != nil || key.Kind != KeyUp {
+		t.Errorf("ReadKey() = %+v, %v, want up", key, err)
+	}
+}
+
+func TestReadKeyBadMouseReport(t *testing.T) {
+	for _, input := range []string{"\x1b[<0;5M", "\x1b[<a;5;5M"} {
+		if key, err := NewKeyReader(strings.NewReader(input)).ReadKey(); err == nil {
+			t.Errorf("ReadKey(%q) = %+v, want an error", input, key)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package terminal
+
+import "errors"
+
+func EnableRaw(fd uintptr) (restore func() error, err error) {
+	return nil, errors.New("raw mode is not supported on this platform")
+}
@@ -0,0 +1,46 @@
+package terminal
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+// NotifyResize sends the new size each time the window changes, until ctx
+// is done. A program busy drawing misses nothing that matters: only the
+// latest size is kept, so a slow reader never sees a stale one.
+func NotifyResize(ctx context.Context) <-chan Bounds {
+	signals := make(chan os.Signal, 1)
+	if resizeSignal != nil {
+		signal.Notify(signals, resizeSignal)
+		go func() {
+			<-ctx.Done()
+			signal.Stop(signals)
+		}()
+	}
+	return watchResize(ctx, signals, Size)
+}
+
+func watchResize(ctx context.Context, signals <-chan os.Signal, size func() (Bounds, error)) <-chan Bounds {
+	resized := make(chan Bounds, 1)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+			}
+			b, err := size()
+			if err != nil {
+				continue
+			}
+			// replace a size nobody has read yet
+			select {
+			case <-resized:
+			default:
+			}
+			resized <- b
+		}
+	}()
+	return resized
+}
@@ -0,0 +1,84 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWatchResize(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sizes := []Bounds{{80, 24}, {100, 30}}
+	size := func() (Bounds, error) {
+		b := sizes[0]
+		sizes = sizes[1:]
+		return b, nil
+	}
+	signals := make(chan os.Signal)
+	resized := watchResize(ctx, signals, size)
+
+	signals <- os.Interrupt
+	if b := <-resized; b != (Bounds{80, 24}) {
+		t.Errorf("first resize = %v, want 80x24", b)
+	}
+	signals <- os.Interrupt
+	if b := <-resized; b != (Bounds{100, 30}) {
+		t.Errorf("second resize = %v, want 100x30", b)
+	}
+}
+
+func TestWatchResizeKeepsOnlyLatest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	width := 0
+	size := func() (Bounds, error) {
+		width++
+		return Bounds{width, 10}, nil
+	}
+	signals := make(chan os.Signal)
+	resized := watchResize(ctx, signals, size)
+
+	for i := 0; i < 3; i++ {
+		signals <- os.Interrupt
+	}
+	// the unbuffered sends above return once the watcher has the signal, not
+	// once it has stored the size, so wait for the last one to land
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case b := <-resized:
+			if b.Width == 3 {
+				return
+			}
+		case <-deadline:
+			t.Fatal("never saw the latest size")
+		}
+	}
+}
+
+func TestWatchResizeSkipsFailedSize(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fail := true
+	size := func() (Bounds, error) {
+		if fail {
+			fail = false
+			return Bounds{}, errors.New("not a terminal")
+		}
+		return Bounds{40, 12}, nil
+	}
+	signals := make(chan os.Signal)
+	resized := watchResize(ctx, signals, size)
+
+	signals <- os.Interrupt
+	signals <- os.Interrupt
+	if b := <-resized; b != (Bounds{40, 12}) {
+		t.Errorf("resize = %v, want 40x12", b)
+	}
+}
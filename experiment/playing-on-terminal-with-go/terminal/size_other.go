@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package terminal
+
+import (
+	"errors"
+	"os"
+)
+
+// no resize signal here, so NotifyResize never sends
+var resizeSignal os.Signal
+
+func windowSize(fd uintptr) (Bounds, error) {
+	return Bounds{}, errors.New("not supported on this platform")
+}
@@ -0,0 +1,30 @@
+//go:build linux || darwin
+
+package terminal
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// the kernel sends this whenever the window changes size
+var resizeSignal os.Signal = syscall.SIGWINCH
+
+// the kernel's struct winsize
+type winsize struct {
+	Row, Col       uint16
+	Xpixel, Ypixel uint16
+}
+
+func windowSize(fd uintptr) (Bounds, error) {
+	var ws winsize
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return Bounds{}, errno
+	}
+	if ws.Col == 0 || ws.Row == 0 {
+		return Bounds{}, syscall.ENOTTY
+	}
+	return Bounds{Width: int(ws.Col), Height: int(ws.Row)}, nil
+}
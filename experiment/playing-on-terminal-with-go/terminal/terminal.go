@@ -0,0 +1,63 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+var ErrOutOfBounds = errors.New("outside the visible area")
+
+// Bounds is the visible area; like the terminal it counts from (1,1)
+type Bounds struct {
+	Width, Height int
+}
+
+func (b Bounds) Contains(x, y int) bool {
+	return x >= 1 && y >= 1 && x <= b.Width && y <= b.Height
+}
+
+// moves (x, y) onto the nearest cell inside b
+func (b Bounds) Clamp(x, y int) (int, int) {
+	return clamp(x, 1, b.Width), clamp(y, 1, b.Height)
+}
+
+func (b Bounds) Check(x, y int) error {
+	if !b.Contains(x, y) {
+		return fmt.Errorf("(%d,%d) is %w of %dx%d", x, y, ErrOutOfBounds, b.Width, b.Height)
+	}
+	return nil
+}
+
+func clamp(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
+
+// asks the terminal on stdout first; when stdout is not a terminal, as in a
+// pipe, COLUMNS and LINES still work if the shell exported them
+func Size() (Bounds, error) {
+	b, err := windowSize(os.Stdout.Fd())
+	if err == nil {
+		return b, nil
+	}
+	if b, ok := sizeFromEnv(os.Getenv); ok {
+		return b, nil
+	}
+	return Bounds{}, fmt.Errorf("terminal size: %w", err)
+}
+
+func sizeFromEnv(getenv func(string) string) (Bounds, bool) {
+	width, err1 := strconv.Atoi(getenv("COLUMNS"))
+	height, err2 := strconv.Atoi(getenv("LINES"))
+	if err1 != nil || err2 != nil || width <= 0 || height <= 0 {
+		return Bounds{}, false
+	}
+	return Bounds{Width: width, Height: height}, true
+}
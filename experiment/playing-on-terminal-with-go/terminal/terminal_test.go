@@ -0,0 +1,99 @@
+package terminal
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestBoundsContains(t *testing.T) {
+	b := Bounds{Width: 80, Height: 24}
+	tests := []struct {
+		x, y     int
+		expected bool
+	}{
+		{1, 1, true},
+		{80, 24, true},
+		{40, 12, true},
+		{0, 1, false},
+		{1, 0, false},
+		{81, 24, false},
+		{80, 25, false},
+	}
+
+	for _, test := range tests {
+		if result := b.Contains(test.x, test.y); result != test.expected {
+			t.Errorf("Contains(%d, %d) = %v, want %v", test.x, test.y, result, test.expected)
+		}
+	}
+}
+
+func TestBoundsClamp(t *testing.T) {
+	b := Bounds{Width: 80, Height: 24}
+	tests := []struct {
+		x, y         int
+		wantX, wantY int
+	}{
+		{5, 10, 5, 10},
+		{0, 0, 1, 1},
+		{-3, 30, 1, 24},
+		{100, 5, 80, 5},
+	}
+
+	for _, test := range tests {
+		x, y := b.Clamp(test.x, test.y)
+		if x != test.wantX || y != test.wantY {
+			t.Errorf("Clamp(%d, %d) = (%d, %d), want (%d, %d)", test.x, test.y, x, y, test.wantX, test.wantY)
+		}
+	}
+}
+
+func TestBoundsCheck(t *testing.T) {
+	b := Bounds{Width: 80, Height: 24}
+	if err := b.Check(80, 24); err != nil {
+		t.Errorf("Check(80, 24) = %v, want nil", err)
+	}
+
+	err := b.Check(81, 1)
+	if !errors.Is(err, ErrOutOfBounds) {
+		t.Fatalf("Check(81, 1) = %v, want ErrOutOfBounds", err)
+	}
+	if expected := "(81,1) is outside the visible area of 80x24"; err.Error() != expected {
+		t.Errorf("Check(81, 1) = %q, want %q", err, expected)
+	}
+}
+
+func TestSizeFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected Bounds
+		ok       bool
+	}{
+		{"both set", map[string]string{"COLUMNS": "120", "LINES": "40"}, Bounds{120, 40}, true},
+		{"missing lines", map[string]string{"COLUMNS": "120"}, Bounds{}, false},
+		{"not a number", map[string]string{"COLUMNS": "wide", "LINES": "40"}, Bounds{}, false},
+		{"zero", map[string]string{"COLUMNS": "0", "LINES": "40"}, Bounds{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, ok := sizeFromEnv(func(key string) string { return test.env[key] })
+			if result != test.expected || ok != test.ok {
+				t.Errorf("sizeFromEnv() = %v, %v, want %v, %v", result, ok, test.expected, test.ok)
+			}
+		})
+	}
+}
+
+func TestWindowSizeOfNonTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "not-a-tty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if b, err := windowSize(f.Fd()); err == nil {
+		t.Errorf("windowSize(file) = %v, want an error", b)
+	}
+}
@@ -0,0 +1,174 @@
+package widgets
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+// the eighths a sparkline draws with, lowest first
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws values on one line, each as a block from ▁ to █ by where
+// it falls between the smallest and the largest; a flat series stays at ▁
+// and NaN leaves a gap. scale colors each block by that same fraction.
+func Sparkline(values []float64, scale []Threshold) string {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			lo, hi = min(lo, v), max(hi, v)
+		}
+	}
+
+	// runs of one color share a single escape sequence
+	out, run, runColor := "", "", 0
+	for _, v := range values {
+		char, color := ' ', 0
+		if !math.IsNaN(v) {
+			fraction := 0.0
+			if hi > lo {
+				fraction = (v - lo) / (hi - lo)
+			}
+			char = sparks[int(math.Round(fraction*float64(len(sparks)-1)))]
+			color = scaleColor(scale, fraction)
+		}
+		if color != runColor {
+			out += paint(run, runColor)
+			run, runColor = "", color
+		}
+		run += string(char)
+	}
+	return out + paint(run, runColor)
+}
+
+// BarChart compares values, one labelled bar each, scaled so the largest
+// fills Size cells. Values of zero or less get no bar.
+type BarChart struct {
+	Labels []string // a value without a label gets none
+	Values []float64
+	Size   int
+	Fill   rune
+	// colors each bar by its value as a fraction of the largest
+	Scale []Threshold
+	// how values print beside the bars or on the axis; "%g" when empty
+	Format string
+}
+
+func NewBarChart(size int) BarChart {
+	return BarChart{Size: size, Fill: '█', Format: "%g"}
+}
+
+// Horizontal draws a bar per line, after its label and followed by the
+// value:
+//
+//	cpu  │████████ 80
+//	disk │██ 20
+func (b BarChart) Horizontal() string {
+	labelWidth := 0
+	for i := range b.Values {
+		labelWidth = max(labelWidth, StringWidth(b.label(i)))
+	}
+
+	top := b.top()
+	out := ""
+	for i, v := range b.Values {
+		bar := b.bar(v, top, b.length(v, top))
+		out += pad(b.label(i), labelWidth, AlignLeft) + " │" + bar + " " + b.format(v) + "\n"
+	}
+	return out
+}
+
+// Vertical draws the bars standing side by side, Size lines tall, above a
+// line of labels, if there are any. Each bar is as wide as its label; the axis on the left
+// marks the largest value and zero.
+//
+//	80 ┤ ███
+//	   │ ███ ██
+//	 0 └───────
+//	     cpu io
+func (b BarChart) Vertical() string {
+	top := b.top()
+	widths := make([]int, len(b.Values))
+	axisLength := 0
+	for i := range b.Values {
+		widths[i] = max(1, StringWidth(b.label(i)))
+		axisLength += widths[i] + 1
+	}
+	topLabel, zeroLabel := b.format(top), b.format(0)
+	labelWidth := max(StringWidth(topLabel), StringWidth(zeroLabel))
+
+	out := ""
+	for row := b.Size; row >= 1; row-- {
+		line := strings.Repeat(" ", labelWidth) + " │"
+		if row == b.Size {
+			line = pad(topLabel, labelWidth, AlignRight) + " ┤"
+		}
+		for i, v := range b.Values {
+			cell := strings.Repeat(" ", widths[i])
+			if b.length(v, top) >= row {
+				cell = b.bar(v, top, widths[i])
+			}
+			line += " " + cell
+		}
+		out += strings.TrimRight(line, " ") + "\n"
+	}
+	out += pad(zeroLabel, labelWidth, AlignRight) + " └" + strings.Repeat("─", axisLength) + "\n"
+	if len(b.Labels) == 0 {
+		return out
+	}
+
+	line := strings.Repeat(" ", labelWidth+2)
+	for i := range b.Values {
+		line += " " + pad(b.label(i), widths[i], AlignCenter)
+	}
+	return out + strings.TrimRight(line, " ") + "\n"
+}
+
+// top is the value a full bar stands for, 0 when nothing is positive
+func (b BarChart) top() float64 {
+	top := 0.0
+	for _, v := range b.Values {
+		top = max(top, v)
+	}
+	return top
+}
+
+// length is how many cells of Size the bar for v takes
+func (b BarChart) length(v, top float64) int {
+	if top <= 0 || v <= 0 {
+		return 0
+	}
+	return min(int(math.Round(v/top*float64(b.Size))), b.Size)
+}
+
+func (b BarChart) bar(v, top float64, cells int) string {
+	fraction := 0.0
+	if top > 0 {
+		fraction = v / top
+	}
+	return paint(strings.Repeat(string(b.Fill), cells), scaleColor(b.Scale, fraction))
+}
+
+func (b BarChart) label(i int) string {
+	if i < len(b.Labels) {
+		return b.Labels[i]
+	}
+	return ""
+}
+
+func (b BarChart) format(v float64) string {
+	if b.Format == "" {
+		return fmt.Sprintf("%g", v)
+	}
+	return fmt.Sprintf(b.Format, v)
+}
+
+// paint colors s, leaving it alone without a color or anything to color
+func paint(s string, color int) string {
+	if color == 0 || s == "" {
+		return s
+	}
+	return ansi.Colorize(s, color)
+}
@@ -0,0 +1,89 @@
+package widgets
+
+import (
+	"math"
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		scale    []Threshold
+		expected string
+	}{
+		{"empty", nil, nil, ""},
+		{"rising", []float64{1, 2, 3, 4, 5, 6, 7, 8}, nil, "▁▂▃▄▅▆▇█"},
+		{"scaled to its range", []float64{100, 150, 200}, nil, "▁▅█"},
+		{"negative", []float64{-4, 0, 4}, nil, "▁▅█"},
+		{"flat", []float64{3, 3, 3}, nil, "▁▁▁"},
+		{"gap", []float64{0, math.NaN(), 1}, nil, "▁ █"},
+		{
+			name:     "colored in runs",
+			values:   []float64{0, 1, 7, 8, 0},
+			scale:    []Threshold{{At: 0, Color: 32}, {At: 0.5, Color: 31}},
+			expected: ansi.Colorize("▁▂", 32) + ansi.Colorize("▇█", 31) + ansi.Colorize("▁", 32),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := Sparkline(test.values, test.scale)
+			if result != test.expected {
+				t.Errorf("Sparkline() = %q, want %q", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestBarChartHorizontal(t *testing.T) {
+	chart := BarChart{Labels: []string{"cpu", "disk", "io"}, Values: []float64{80, 20, -5}, Size: 8, Fill: '#', Format: "%.0f%%"}
+	expected := "" +
+		"cpu  │######## 80%\n" +
+		"disk │## 20%\n" +
+		"io   │ -5%\n"
+	if result := chart.Horizontal(); result != expected {
+		t.Errorf("Horizontal() =\n%s\nwant\n%s", result, expected)
+	}
+}
+
+func TestBarChartVertical(t *testing.T) {
+	chart := NewBarChart(3)
+	chart.Labels = []string{"a", "bb"}
+	chart.Values = []float64{30, 10}
+	chart.Fill = '#'
+	expected := "" +
+		"30 ┤ #\n" +
+		"   │ #\n" +
+		"   │ # ##\n" +
+		" 0 └─────\n" +
+		"     a bb\n"
+	if result := chart.Vertical(); result != expected {
+		t.Errorf("Vertical() =\n%s\nwant\n%s", result, expected)
+	}
+}
+
+func TestBarChartScale(t *testing.T) {
+	chart := BarChart{
+		Labels: []string{"hi", "lo"},
+		Values: []float64{4, 1},
+		Size:   4,
+		Fill:   '=',
+		Scale:  []Threshold{{At: 0, Color: 32}, {At: 0.75, Color: 31}},
+	}
+	expected := "hi │" + ansi.Colorize("====", 31) + " 4\n" +
+		"lo │" + ansi.Colorize("=", 32) + " 1\n"
+	if result := chart.Horizontal(); result != expected {
+		t.Errorf("Horizontal() = %q, want %q", result, expected)
+	}
+}
+
+func TestBarChartNothingPositive(t *testing.T) {
+	chart := BarChart{Values: []float64{0, -1}, Size: 2, Fill: '#'}
+	expected := "0 ┤\n  │\n0 └────\n"
+	if result := chart.Vertical(); result != expected {
+		t.Errorf("Vertical() = %q, want %q", result, expected)
+	}
+}
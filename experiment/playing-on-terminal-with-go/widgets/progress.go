@@ -0,0 +1,79 @@
+// Package widgets holds small text-mode components that render to strings
+// of ANSI escapes, ready to print or to place on a canvas.
+package widgets
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+// Threshold colors the filled part of a bar from progress At, a fraction
+// from 0 to 1, upward; charts use the same scale for their values
+type Threshold struct {
+	At    float64
+	Color int
+}
+
+type ProgressBar struct {
+	Width   int // cells between the brackets
+	Fill    rune
+	Empty   rune
+	Percent bool
+	ETA     bool
+	// kept in ascending order of At; the bar takes the color of the last
+	// one reached, and none before the first
+	Thresholds []Threshold
+}
+
+func NewProgressBar(width int) ProgressBar {
+	return ProgressBar{Width: width, Fill: '█', Empty: '░', Percent: true}
+}
+
+// Render draws the bar for done out of total, followed by the labels it has
+// turned on. The ETA assumes the rest goes at the pace of what took elapsed.
+func (b ProgressBar) Render(done, total int, elapsed time.Duration) string {
+	// whole numbers as far as possible: 29 of 100 as a float times 100 is 28.99…
+	done, total = min(max(done, 0), total), max(total, 0)
+	if total == 0 {
+		done, total = 1, 1
+	}
+	fraction := float64(done) / float64(total)
+	filled := done * b.Width / total
+
+	bar := strings.Repeat(string(b.Fill), filled)
+	if color := scaleColor(b.Thresholds, fraction); color != 0 && bar != "" {
+		bar = ansi.Colorize(bar, color)
+	}
+	result := "[" + bar + strings.Repeat(string(b.Empty), b.Width-filled) + "]"
+
+	if b.Percent {
+		result += fmt.Sprintf(" %3d%%", done*100/total)
+	}
+	if b.ETA {
+		result += " ETA " + eta(fraction, elapsed)
+	}
+	return result
+}
+
+// scaleColor picks the color of the last threshold fraction reaches
+func scaleColor(scale []Threshold, fraction float64) int {
+	color := 0
+	for _, t := range scale {
+		if fraction < t.At {
+			break
+		}
+		color = t.Color
+	}
+	return color
+}
+
+func eta(fraction float64, elapsed time.Duration) string {
+	if fraction == 0 {
+		return "?"
+	}
+	remaining := time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+	return remaining.Round(time.Second).String()
+}
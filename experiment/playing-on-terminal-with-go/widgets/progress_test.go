@@ -0,0 +1,134 @@
+package widgets
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+func TestProgressBarRender(t *testing.T) {
+	tests := []struct {
+		name        string
+		bar         ProgressBar
+		done, total int
+		elapsed     time.Duration
+		expected    string
+	}{
+		{
+			name:     "empty",
+			bar:      NewProgressBar(10),
+			done:     0,
+			total:    100,
+			expected: "[░░░░░░░░░░]   0%",
+		},
+		{
+			name:     "half",
+			bar:      NewProgressBar(10),
+			done:     50,
+			total:    100,
+			expected: "[█████░░░░░]  50%",
+		},
+		{
+			name:     "rounds down until a cell is full",
+			bar:      NewProgressBar(10),
+			done:     19,
+			total:    100,
+			expected: "[█░░░░░░░░░]  19%",
+		},
+		{
+			name:     "no float rounding",
+			bar:      NewProgressBar(100),
+			done:     29,
+			total:    100,
+			expected: "[" + strings.Repeat("█", 29) + strings.Repeat("░", 71) + "]  29%",
+		},
+		{
+			name:     "complete",
+			bar:      NewProgressBar(10),
+			done:     100,
+			total:    100,
+			expected: "[██████████] 100%",
+		},
+		{
+			name:     "past the end",
+			bar:      NewProgressBar(4),
+			done:     7,
+			total:    5,
+			expected: "[████] 100%",
+		},
+		{
+			name:     "nothing to do is done",
+			bar:      NewProgressBar(4),
+			done:     0,
+			total:    0,
+			expected: "[████] 100%",
+		},
+		{
+			name:     "custom characters without percent",
+			bar:      ProgressBar{Width: 6, Fill: '=', Empty: '-'},
+			done:     1,
+			total:    2,
+			expected: "[===---]",
+		},
+		{
+			name:     "eta",
+			bar:      ProgressBar{Width: 4, Fill: '#', Empty: '.', Percent: true, ETA: true},
+			done:     1,
+			total:    4,
+			elapsed:  10 * time.Second,
+			expected: "[#...]  25% ETA 30s",
+		},
+		{
+			name:     "eta before any progress",
+			bar:      ProgressBar{Width: 4, Fill: '#', Empty: '.', ETA: true},
+			done:     0,
+			total:    4,
+			elapsed:  time.Second,
+			expected: "[....] ETA ?",
+		},
+		{
+			name:     "eta in minutes",
+			bar:      ProgressBar{Width: 2, Fill: '#', Empty: '.', ETA: true},
+			done:     1,
+			total:    10,
+			elapsed:  10 * time.Second,
+			expected: "[..] ETA 1m30s",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := test.bar.Render(test.done, test.total, test.elapsed)
+			if result != test.expected {
+				t.Errorf("Render() = %q, want %q", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestProgressBarThresholds(t *testing.T) {
+	bar := ProgressBar{Width: 4, Fill: '#', Empty: '.', Thresholds: []Threshold{
+		{At: 0.25, Color: 31},
+		{At: 0.5, Color: 33},
+		{At: 1, Color: 32},
+	}}
+
+	tests := []struct {
+		done     int
+		expected string
+	}{
+		{0, "[....]"},
+		{1, "[" + ansi.Colorize("#", 31) + "...]"},
+		{2, "[" + ansi.Colorize("##", 33) + "..]"},
+		{3, "[" + ansi.Colorize("###", 33) + ".]"},
+		{4, "[" + ansi.Colorize("####", 32) + "]"},
+	}
+
+	for _, test := range tests {
+		if result := bar.Render(test.done, 4, 0); result != test.expected {
+			t.Errorf("Render(%d, 4) = %q, want %q", test.done, result, test.expected)
+		}
+	}
+}
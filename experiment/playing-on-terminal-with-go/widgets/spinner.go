@@ -0,0 +1,133 @@
+package widgets
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+var SpinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Spinners shows one line per running task, stacked in the order they were
+// started, and redraws the whole block in place every interval. A finished
+// task's line stops spinning and shows ✓, or ✗ and its error.
+type Spinners struct {
+	w        io.Writer
+	interval time.Duration
+
+	mu    sync.Mutex
+	tasks []*task
+	frame int
+	drawn int // lines the last draw left on screen
+	start sync.Once
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+	done chan struct{}
+}
+
+type task struct {
+	name     string
+	finished bool
+	err      error
+}
+
+func NewSpinners(w io.Writer, interval time.Duration) *Spinners {
+	return &Spinners{w: w, interval: interval, stop: make(chan struct{}), done: make(chan struct{})}
+}
+
+// Go runs fn in its own goroutine under a new line named name
+func (s *Spinners) Go(name string, fn func() error) {
+	t := &task{name: name}
+	s.mu.Lock()
+	s.tasks = append(s.tasks, t)
+	s.mu.Unlock()
+	s.start.Do(func() { go s.animate() })
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := fn()
+		s.mu.Lock()
+		t.finished, t.err = true, err
+		s.mu.Unlock()
+	}()
+}
+
+// Wait blocks until every task has finished, draws their final lines, and
+// returns their errors joined, or nil if all succeeded. Tasks may not be
+// started once Wait has been called.
+func (s *Spinners) Wait() error {
+	s.wg.Wait()
+	s.start.Do(func() { close(s.done) })
+	close(s.stop)
+	<-s.done
+
+	s.draw()
+	var errs []error
+	for _, t := range s.tasks {
+		if t.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.name, t.err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Spinners) animate() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.draw()
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			s.frame++
+			s.mu.Unlock()
+		}
+	}
+}
+
+// a failed write only costs the picture, never a task, so it is dropped
+func (s *Spinners) draw() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = io.WriteString(s.w, s.render())
+	s.drawn = len(s.tasks)
+}
+
+// render goes back up over the block last drawn and writes every line anew
+func (s *Spinners) render() string {
+	out := ""
+	if s.drawn > 0 {
+		out += "\r" + ansi.CursorUp(s.drawn)
+	}
+	for _, t := range s.tasks {
+		out += "\r" + ansi.ClearLine() + s.line(t) + "\n"
+	}
+	return out
+}
+
+func (s *Spinners) line(t *task) string {
+	switch {
+	case !t.finished:
+		return SpinnerFrames[s.frame%len(SpinnerFrames)] + " " + t.name
+	case t.err != nil:
+		return ansi.Colorize("✗", ansi.ColorCode("red")) + " " + t.name + ": " + t.err.Error()
+	}
+	return ansi.Colorize("✓", ansi.ColorCode("green")) + " " + t.name
+}
+
+// Spin runs fn under a single spinner line and returns its error
+func Spin(w io.Writer, name string, fn func() error) error {
+	s := NewSpinners(w, 100*time.Millisecond)
+	s.Go(name, fn)
+	return s.Wait()
+}
@@ -0,0 +1,128 @@
+package widgets
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/ansi"
+)
+
+var (
+	tick  = ansi.Colorize("✓", 32)
+	cross = ansi.Colorize("✗", 31)
+	line  = "\r" + ansi.ClearLine()
+)
+
+func TestSpinnersRender(t *testing.T) {
+	s := NewSpinners(nil, time.Second)
+	s.tasks = []*task{
+		{name: "build"},
+		{name: "test", finished: true},
+		{name: "lint", finished: true, err: errors.New("2 issues")},
+	}
+
+	expected := line + "⠋ build\n" + line + tick + " test\n" + line + cross + " lint: 2 issues\n"
+	if result := s.render(); result != expected {
+		t.Errorf("render() = %q, want %q", result, expected)
+	}
+
+	s.frame = 11
+	s.drawn = 3
+	expected = "\r" + ansi.CursorUp(3) + line + "⠙ build\n" + line + tick + " test\n" + line + cross + " lint: 2 issues\n"
+	if result := s.render(); result != expected {
+		t.Errorf("render() on frame 11 = %q, want %q", result, expected)
+	}
+}
+
+// syncBuffer lets the animation goroutine write while the test reads
+type syncBuffer struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func TestSpinnersRunTasksTogether(t *testing.T) {
+	var out syncBuffer
+	s := NewSpinners(&out, time.Millisecond)
+
+	// each task waits for the other, so they only finish if both run at once
+	aStarted, bStarted := make(chan struct{}), make(chan struct{})
+	s.Go("a", func() error {
+		close(aStarted)
+		<-bStarted
+		return nil
+	})
+	s.Go("b", func() error {
+		close(bStarted)
+		<-aStarted
+		return errors.New("boom")
+	})
+
+	err := s.Wait()
+	if err == nil || err.Error() != "b: boom" {
+		t.Errorf("Wait() = %v, want b: boom", err)
+	}
+
+	final := "\r" + ansi.CursorUp(2) + line + tick + " a\n" + line + cross + " b: boom\n"
+	if !strings.HasSuffix(out.String(), final) {
+		t.Errorf("output ends %q, want the final block %q", out.String(), final)
+	}
+}
+
+func TestSpinnersAnimateWhileRunning(t *testing.T) {
+	var out syncBuffer
+	s := NewSpinners(&out, time.Millisecond)
+
+	release := make(chan struct{})
+	s.Go("slow", func() error {
+		<-release
+		return nil
+	})
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(out.String(), "⠙ slow") {
+		if time.Now().After(deadline) {
+			t.Fatalf("spinner never moved past its first frame: %q", out.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	if err := s.Wait(); err != nil {
+		t.Errorf("Wait() = %v, want nil", err)
+	}
+}
+
+func TestSpinnersWaitWithoutTasks(t *testing.T) {
+	var out strings.Builder
+	if err := NewSpinners(&out, time.Millisecond).Wait(); err != nil {
+		t.Errorf("Wait() = %v, want nil", err)
+	}
+	if out.String() != "" {
+		t.Errorf("output = %q, want nothing", out.String())
+	}
+}
+
+func TestSpinJoinsErrors(t *testing.T) {
+	var out syncBuffer
+	err := Spin(&out, "fetch", func() error { return errors.New("timeout") })
+	if err == nil || err.Error() != "fetch: timeout" {
+		t.Errorf("Spin() = %v, want fetch: timeout", err)
+	}
+	if !strings.HasSuffix(out.String(), cross+" fetch: timeout\n") {
+		t.Errorf("output = %q, want it to end with the failure", out.String())
+	}
+}
@@ -0,0 +1,119 @@
+package widgets
+
+import (
+	"strings"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+)
+
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignRight
+	AlignCenter
+)
+
+type Column struct {
+	Title string
+	Align Align
+	// cells wider than this are truncated; 0 lets the column grow to fit
+	MaxWidth int
+}
+
+// Table lays rows out in columns as wide as their widest cell. A row with
+// fewer cells than there are columns leaves the rest empty; extra cells are
+// dropped.
+type Table struct {
+	Columns []Column
+	Rows    [][]string
+	Border  bool
+	// the characters Border draws with; the zero value means SingleBorder
+	Style drawing.Border
+}
+
+// Render returns the table as lines ending in "\n": with Border each cell is
+// boxed in, otherwise columns are simply two spaces apart
+func (t Table) Render() string {
+	widths := t.widths()
+	b := t.Style
+	if b == (drawing.Border{}) {
+		b = drawing.SingleBorder
+	}
+
+	out := ""
+	if t.Border {
+		out += rule(widths, b.Horizontal, b.TopLeft, b.TeeDown, b.TopRight)
+	}
+	titles := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		titles[i] = c.Title
+	}
+	out += t.row(titles, widths, b.Vertical)
+	if t.Border {
+		out += rule(widths, b.Horizontal, b.TeeRight, b.Cross, b.TeeLeft)
+	}
+	for _, cells := range t.Rows {
+		out += t.row(cells, widths, b.Vertical)
+	}
+	if t.Border {
+		out += rule(widths, b.Horizontal, b.BottomLeft, b.TeeUp, b.BottomRight)
+	}
+	return out
+}
+
+func (t Table) widths() []int {
+	widths := make([]int, len(t.Columns))
+	for i, c := range t.Columns {
+		widths[i] = StringWidth(c.Title)
+		for _, cells := range t.Rows {
+			if i < len(cells) {
+				widths[i] = max(widths[i], StringWidth(cells[i]))
+			}
+		}
+		if c.MaxWidth > 0 {
+			widths[i] = min(widths[i], c.MaxWidth)
+		}
+	}
+	return widths
+}
+
+func (t Table) row(cells []string, widths []int, vertical rune) string {
+	parts := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		parts[i] = pad(Truncate(cell, widths[i]), widths[i], c.Align)
+	}
+
+	if t.Border {
+		v := string(vertical)
+		return v + " " + strings.Join(parts, " "+v+" ") + " " + v + "\n"
+	}
+	return strings.TrimRight(strings.Join(parts, "  "), " ") + "\n"
+}
+
+// rule is a horizontal border line, with cross between columns
+func rule(widths []int, line, left, cross, right rune) string {
+	segments := make([]string, len(widths))
+	for i, w := range widths {
+		segments[i] = strings.Repeat(string(line), w+2)
+	}
+	return string(left) + strings.Join(segments, string(cross)) + string(right) + "\n"
+}
+
+func pad(s string, width int, align Align) string {
+	gap := width - StringWidth(s)
+	if gap <= 0 {
+		return s
+	}
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", gap) + s
+	case AlignCenter:
+		return strings.Repeat(" ", gap/2) + s + strings.Repeat(" ", gap-gap/2)
+	}
+	return s + strings.Repeat(" ", gap)
+}
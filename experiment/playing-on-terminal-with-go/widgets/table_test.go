@@ -0,0 +1,109 @@
+package widgets
+
+import (
+	"testing"
+
+	"github.com/e6a5/learning/experiment/ternimal-with-go/drawing"
+)
+
+func TestTableRender(t *testing.T) {
+	columns := []Column{
+		{Title: "Name"},
+		{Title: "Qty", Align: AlignRight},
+		{Title: "Note", Align: AlignCenter},
+	}
+	rows := [][]string{
+		{"apple", "3", "ok"},
+		{"kiwi", "120", "ripe"},
+	}
+
+	tests := []struct {
+		name     string
+		table    Table
+		expected string
+	}{
+		{
+			name:  "plain",
+			table: Table{Columns: columns, Rows: rows},
+			expected: "" +
+				"Name   Qty  Note\n" +
+				"apple    3   ok\n" +
+				"kiwi   120  ripe\n",
+		},
+		{
+			name:  "bordered",
+			table: Table{Columns: columns, Rows: rows, Border: true},
+			expected: "" +
+				"┌───────┬─────┬──────┐\n" +
+				"│ Name  │ Qty │ Note │\n" +
+				"├───────┼─────┼──────┤\n" +
+				"│ apple │   3 │  ok  │\n" +
+				"│ kiwi  │ 120 │ ripe │\n" +
+				"└───────┴─────┴──────┘\n",
+		},
+		{
+			name:  "double",
+			table: Table{Columns: columns[:2], Rows: rows[:1], Border: true, Style: drawing.DoubleBorder},
+			expected: "" +
+				"╔═══════╦═════╗\n" +
+				"║ Name  ║ Qty ║\n" +
+				"╠═══════╬═════╣\n" +
+				"║ apple ║   3 ║\n" +
+				"╚═══════╩═════╝\n",
+		},
+		{
+			name: "short and long rows",
+			table: Table{
+				Columns: []Column{{Title: "A"}, {Title: "B"}},
+				Rows:    [][]string{{"1"}, {"2", "3", "ignored"}},
+				Border:  true,
+			},
+			expected: "" +
+				"┌───┬───┐\n" +
+				"│ A │ B │\n" +
+				"├───┼───┤\n" +
+				"│ 1 │   │\n" +
+				"│ 2 │ 3 │\n" +
+				"└───┴───┘\n",
+		},
+		{
+			name: "truncated",
+			table: Table{
+				Columns: []Column{{Title: "Description", MaxWidth: 6}},
+				Rows:    [][]string{{"a very long line"}, {"short"}},
+				Border:  true,
+			},
+			expected: "" +
+				"┌────────┐\n" +
+				"│ Descr… │\n" +
+				"├────────┤\n" +
+				"│ a ver… │\n" +
+				"│ short  │\n" +
+				"└────────┘\n",
+		},
+		{
+			name: "wide characters",
+			table: Table{
+				Columns: []Column{{Title: "City"}, {Title: "Pop", Align: AlignRight}},
+				Rows:    [][]string{{"東京", "14M"}, {"Paris", "2M"}},
+				Border:  true,
+			},
+			expected: "" +
+				"┌───────┬─────┐\n" +
+				"│ City  │ Pop │\n" +
+				"├───────┼─────┤\n" +
+				"│ 東京  │ 14M │\n" +
+				"│ Paris │  2M │\n" +
+				"└───────┴─────┘\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := test.table.Render()
+			if result != test.expected {
+				t.Errorf("Render() =\n%s\nwant\n%s", result, test.expected)
+			}
+		})
+	}
+}
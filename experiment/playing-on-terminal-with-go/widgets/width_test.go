@@ -0,0 +1,54 @@
+package widgets
+
+import "testing"
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"héllo", 5},
+		{"héllo", 5}, // e and a combining acute
+		{"日本語", 6},
+		{"한국", 4},
+		{"ｗｉｄｅ", 8},
+		{"ok 🙂", 5},
+		{"a\tb", 2},
+	}
+
+	for _, test := range tests {
+		if result := StringWidth(test.s); result != test.expected {
+			t.Errorf("StringWidth(%q) = %d, want %d", test.s, result, test.expected)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s        string
+		width    int
+		expected string
+	}{
+		{"hello", 5, "hello"},
+		{"hello", 10, "hello"},
+		{"hello", 4, "hel…"},
+		{"hello", 1, "…"},
+		{"hello", 0, ""},
+		{"日本語", 5, "日本…"},
+		{"日本語", 4, "日…"},
+		{"日本語", 2, "…"},
+		{"héllo", 3, "hé…"},
+	}
+
+	for _, test := range tests {
+		result := Truncate(test.s, test.width)
+		if result != test.expected {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", test.s, test.width, result, test.expected)
+		}
+		if StringWidth(result) > test.width {
+			t.Errorf("Truncate(%q, %d) is %d cells wide", test.s, test.width, StringWidth(result))
+		}
+	}
+}